@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/lmmendes/attic/internal/config"
+	"github.com/lmmendes/attic/internal/database"
+)
+
+// openDatabase connects to the database of cfg. Without ATTIC_DATABASE_URL
+// it first starts the embedded PostgreSQL under ATTIC_DATA_DIR, pointing
+// cfg.DatabaseURL at it. The returned function closes the connection and
+// stops the embedded server.
+func openDatabase(ctx context.Context, cfg *config.Config) (*database.DB, func(), error) {
+	var embedded *database.Embedded
+	if cfg.EmbeddedDatabase() {
+		slog.Info("starting embedded PostgreSQL", "data_dir", cfg.DataDir, "port", cfg.EmbeddedDatabasePort)
+		var err error
+		embedded, err = database.StartEmbedded(cfg.DataDir, uint32(cfg.EmbeddedDatabasePort))
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.DatabaseURL = embedded.URL()
+	}
+	stopEmbedded := func() {
+		if embedded == nil {
+			return
+		}
+		if err := embedded.Stop(); err != nil {
+			slog.Error("failed to stop embedded PostgreSQL", "error", err)
+		}
+	}
+
+	db, err := database.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		stopEmbedded()
+		return nil, nil, err
+	}
+	return db, func() {
+		db.Close()
+		stopEmbedded()
+	}, nil
+}
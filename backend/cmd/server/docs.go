@@ -0,0 +1,106 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Swagger UI and ReDoc assets are vendored (see scripts/fetch-swagger-ui.sh)
+// so the documentation pages work offline and under a strict CSP.
+//
+//go:embed all:swagger-ui
+var swaggerUIFS embed.FS
+
+const docsPath = "/api/docs"
+
+// docsHandler serves the Swagger UI page
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := fs.Stat(swaggerUIFS, "swagger-ui/swagger-ui-bundle.js"); err != nil {
+		w.Write([]byte(swaggerUIMissingHTML))
+		return
+	}
+	w.Write([]byte(swaggerUIHTML))
+}
+
+// redocHandler serves the ReDoc rendering of the API documentation
+func redocHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if _, err := fs.Stat(swaggerUIFS, "swagger-ui/redoc.standalone.js"); err != nil {
+		w.Write([]byte(swaggerUIMissingHTML))
+		return
+	}
+	w.Write([]byte(redocHTML))
+}
+
+// docsAssetsHandler serves the vendored Swagger UI assets and initializer script
+func docsAssetsHandler() http.HandlerFunc {
+	assets, err := fs.Sub(swaggerUIFS, "swagger-ui")
+	if err != nil {
+		panic("failed to create sub filesystem: " + err.Error())
+	}
+	fileServer := http.StripPrefix(docsPath+"/", http.FileServer(http.FS(assets)))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == docsPath+"/swagger-initializer.js" {
+			w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+			w.Write([]byte(swaggerInitializerJS))
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		fileServer.ServeHTTP(w, r)
+	}
+}
+
+const swaggerUIHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>Attic API Documentation</title>
+  <link rel="stylesheet" href="/api/docs/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/api/docs/swagger-ui-bundle.js"></script>
+  <script src="/api/docs/swagger-initializer.js"></script>
+</body>
+</html>`
+
+// ReDoc reads the spec URL from its element, so no initializer is needed
+const redocHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1.0">
+  <title>Attic API Documentation</title>
+</head>
+<body>
+  <redoc spec-url="/api/openapi.yaml"></redoc>
+  <script src="/api/docs/redoc.standalone.js"></script>
+</body>
+</html>`
+
+// Inline scripts are blocked by the CSP, so the initializer is served as a file
+const swaggerInitializerJS = `window.onload = function() {
+  SwaggerUIBundle({
+    url: "/api/openapi.yaml",
+    dom_id: '#swagger-ui',
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIBundle.SwaggerUIStandalonePreset],
+    layout: "BaseLayout"
+  });
+};
+`
+
+const swaggerUIMissingHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>Attic API Documentation</title>
+</head>
+<body>
+  <p>The API documentation assets were not bundled in this build (run <code>make swagger-ui</code>).
+  The OpenAPI specification is available at <a href="/api/openapi.yaml">/api/openapi.yaml</a>.</p>
+</body>
+</html>`
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/config"
+	"github.com/lmmendes/attic/internal/database"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// The end-to-end tests boot the server as main does, against Postgres and
+// MinIO containers, and talk to it over HTTP
+
+const (
+	e2eAdminEmail    = "admin@example.com"
+	e2eAdminPassword = "correct-horse-battery"
+	e2eBucket        = "attic-e2e"
+)
+
+var e2eServer *httptest.Server
+
+func TestMain(m *testing.M) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pg, err := postgres.Run(ctx,
+		"postgres:16-alpine",
+		postgres.WithDatabase("attic_e2e"),
+		postgres.WithUsername("test"),
+		postgres.WithPassword("test"),
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(30*time.Second),
+		),
+	)
+	if err != nil {
+		panic("failed to start postgres: " + err.Error())
+	}
+	databaseURL, err := pg.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		panic("failed to get connection string: " + err.Error())
+	}
+
+	minio, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "minio/minio:RELEASE.2025-04-22T22-12-26Z",
+			ExposedPorts: []string{"9000/tcp"},
+			Cmd:          []string{"server", "/data"},
+			Env: map[string]string{
+				"MINIO_ROOT_USER":     "attic",
+				"MINIO_ROOT_PASSWORD": "attic-secret",
+			},
+			WaitingFor: wait.ForHTTP("/minio/health/live").WithPort("9000/tcp").WithStartupTimeout(60 * time.Second),
+		},
+		Started: true,
+	})
+	if err != nil {
+		panic("failed to start minio: " + err.Error())
+	}
+	endpoint, err := minio.PortEndpoint(ctx, "9000/tcp", "http")
+	if err != nil {
+		panic("failed to get minio endpoint: " + err.Error())
+	}
+	if err := createBucket(ctx, endpoint); err != nil {
+		panic("failed to create bucket: " + err.Error())
+	}
+
+	for key, value := range map[string]string{
+		"ATTIC_DATABASE_URL":          databaseURL,
+		"ATTIC_S3_ENDPOINT":           endpoint,
+		"ATTIC_S3_BUCKET":             e2eBucket,
+		"ATTIC_S3_ACCESS_KEY":         "attic",
+		"ATTIC_S3_SECRET_KEY":         "attic-secret",
+		"ATTIC_ADMIN_EMAIL":           e2eAdminEmail,
+		"ATTIC_ADMIN_PASSWORD":        e2eAdminPassword,
+		"ATTIC_SESSION_SECRET":        "e2e-session-secret-32-bytes-long",
+		"ATTIC_RATE_LIMIT_PER_MINUTE": "0",
+	} {
+		os.Setenv(key, value)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		panic("failed to load config: " + err.Error())
+	}
+	db, err := database.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		panic("failed to connect to database: " + err.Error())
+	}
+	router, err := newServer(ctx, cfg, db)
+	if err != nil {
+		panic("failed to start server: " + err.Error())
+	}
+	e2eServer = httptest.NewServer(router)
+
+	code := m.Run()
+
+	e2eServer.Close()
+	cancel()
+	db.Close()
+	minio.Terminate(context.Background())
+	pg.Terminate(context.Background())
+	os.Exit(code)
+}
+
+func createBucket(ctx context.Context, endpoint string) error {
+	client := s3.New(s3.Options{
+		BaseEndpoint: aws.String(endpoint),
+		Region:       "us-east-1",
+		Credentials:  credentials.NewStaticCredentialsProvider("attic", "attic-secret", ""),
+		UsePathStyle: true,
+	})
+	_, err := client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(e2eBucket)})
+	return err
+}
+
+// e2eClient calls the server like a browser (session cookie and CSRF token)
+// or an API client (bearer token)
+type e2eClient struct {
+	t     *testing.T
+	http  *http.Client
+	token string
+	csrf  string
+}
+
+func newClient(t *testing.T) *e2eClient {
+	jar, _ := cookiejar.New(nil)
+	return &e2eClient{t: t, http: &http.Client{Jar: jar}}
+}
+
+// newAdminClient returns a client with a bearer token of the bootstrap admin
+func newAdminClient(t *testing.T) *e2eClient {
+	return newUserClient(t, e2eAdminEmail, e2eAdminPassword)
+}
+
+// newUserClient returns a client with a bearer token of a user
+func newUserClient(t *testing.T, email, password string) *e2eClient {
+	c := newClient(t)
+	var tokens auth.TokenPair
+	c.expect(c.do(http.MethodPost, "/auth/token", map[string]string{"email": email, "password": password}), http.StatusOK, &tokens)
+	if tokens.AccessToken == "" {
+		t.Fatal("expected an access token")
+	}
+	c.token = tokens.AccessToken
+	return c
+}
+
+func (c *e2eClient) do(method, path string, body any) *http.Response {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatal(err)
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, e2eServer.URL+path, r)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req)
+}
+
+func (c *e2eClient) send(req *http.Request) *http.Response {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.csrf != "" {
+		req.Header.Set(auth.CSRFHeader, c.csrf)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return resp
+}
+
+// expect checks the status of a response and decodes its JSON body into v
+// (if not nil)
+func (c *e2eClient) expect(resp *http.Response, status int, v any) {
+	c.t.Helper()
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != status {
+		c.t.Fatalf("%s %s: expected %d, got %d: %s", resp.Request.Method, resp.Request.URL.Path, status, resp.StatusCode, body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(body, v); err != nil {
+			c.t.Fatalf("%s %s: invalid response %s: %v", resp.Request.Method, resp.Request.URL.Path, body, err)
+		}
+	}
+}
+
+type e2eRecord struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func Test_E2E_Auth(t *testing.T) {
+	c := newClient(t)
+	c.expect(c.do(http.MethodGet, "/api/me", nil), http.StatusUnauthorized, nil)
+	c.expect(c.do(http.MethodPost, "/auth/login", map[string]string{"email": e2eAdminEmail, "password": "wrong"}), http.StatusUnauthorized, nil)
+	c.expect(c.do(http.MethodPost, "/auth/login", map[string]string{"email": e2eAdminEmail, "password": e2eAdminPassword}), http.StatusOK, nil)
+
+	var me struct {
+		Email string `json:"email"`
+	}
+	c.expect(c.do(http.MethodGet, "/api/me", nil), http.StatusOK, &me)
+	if me.Email != e2eAdminEmail {
+		t.Errorf("expected the admin, got %q", me.Email)
+	}
+
+	// Changes with the session cookie need the CSRF token
+	category := map[string]string{"name": "Auth test"}
+	c.expect(c.do(http.MethodPost, "/api/categories", category), http.StatusForbidden, nil)
+	var csrf struct {
+		Token string `json:"csrf_token"`
+	}
+	c.expect(c.do(http.MethodGet, "/api/auth/csrf", nil), http.StatusOK, &csrf)
+	c.csrf = csrf.Token
+	c.expect(c.do(http.MethodPost, "/api/categories", category), http.StatusCreated, nil)
+
+	c.expect(c.do(http.MethodPost, "/auth/logout", nil), http.StatusOK, nil)
+	c.expect(c.do(http.MethodGet, "/api/me", nil), http.StatusUnauthorized, nil)
+}
+
+func Test_E2E_Import(t *testing.T) {
+	// A JSON API standing in for an external catalog
+	catalog := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/search":
+			fmt.Fprint(w, `{"results":[{"id":"13","title":"Catan"}]}`)
+		case "/games/13":
+			fmt.Fprint(w, `{"title":"Catan","summary":"Trade and build"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer catalog.Close()
+
+	c := newAdminClient(t)
+	c.expect(c.do(http.MethodPut, "/api/admin/http-plugins/games", map[string]string{
+		"name":             "Games",
+		"category_name":    "Board Games",
+		"search_url":       catalog.URL + "/search?q={query}",
+		"fetch_url":        catalog.URL + "/games/{id}",
+		"results_path":     "$.results",
+		"result_id":        "$.id",
+		"result_title":     "$.title",
+		"name_path":        "$.title",
+		"description_path": "$.summary",
+	}), http.StatusCreated, nil)
+
+	var search struct {
+		Results []struct {
+			ExternalID string `json:"external_id"`
+		} `json:"results"`
+	}
+	c.expect(c.do(http.MethodGet, "/api/plugins/http_games/search?q=catan", nil), http.StatusOK, &search)
+	if len(search.Results) != 1 {
+		t.Fatalf("expected one result, got %+v", search.Results)
+	}
+
+	var imported struct {
+		Asset struct {
+			e2eRecord
+			Description *string `json:"description"`
+		} `json:"asset"`
+	}
+	c.expect(c.do(http.MethodPost, "/api/plugins/http_games/import", map[string]string{"external_id": search.Results[0].ExternalID}), http.StatusCreated, &imported)
+	if imported.Asset.Name != "Catan" || imported.Asset.Description == nil || *imported.Asset.Description != "Trade and build" {
+		t.Errorf("unexpected imported asset %+v", imported.Asset)
+	}
+
+	var asset e2eRecord
+	c.expect(c.do(http.MethodGet, "/api/assets/"+imported.Asset.ID, nil), http.StatusOK, &asset)
+	if asset.Name != "Catan" {
+		t.Errorf("expected the imported asset to be stored, got %+v", asset)
+	}
+}
+
+func Test_E2E_UploadAndExport(t *testing.T) {
+	c := newAdminClient(t)
+
+	var me e2eRecord
+	c.expect(c.do(http.MethodGet, "/api/me", nil), http.StatusOK, &me)
+	var category, asset e2eRecord
+	c.expect(c.do(http.MethodPost, "/api/categories", map[string]string{"name": "Appliances"}), http.StatusCreated, &category)
+	c.expect(c.do(http.MethodPost, "/api/assets", map[string]any{
+		"category_id": category.ID,
+		"name":        "Washing machine",
+		"owner_id":    me.ID,
+	}), http.StatusCreated, &asset)
+
+	// Upload a manual to MinIO
+	manual := "Use the eco program."
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "manual.txt")
+	part.Write([]byte(manual))
+	form.Close()
+	req, _ := http.NewRequest(http.MethodPost, e2eServer.URL+"/api/assets/"+asset.ID+"/attachments", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	var attachment e2eRecord
+	c.expect(c.send(req), http.StatusCreated, &attachment)
+
+	// Download it by its presigned URL
+	var download struct {
+		URL string `json:"url"`
+	}
+	c.expect(c.do(http.MethodGet, "/api/attachments/"+attachment.ID, nil), http.StatusOK, &download)
+	resp, err := http.Get(download.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != manual {
+		t.Errorf("expected the uploaded file, got %d %q", resp.StatusCode, data)
+	}
+
+	// Export the owner's assets with their files
+	resp = c.do(http.MethodGet, "/api/owners/"+me.ID+"/handover", nil)
+	archive, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the handover archive, got %d: %s", resp.StatusCode, archive)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	if !strings.Contains(files["items.csv"], "Washing machine") {
+		t.Errorf("expected the asset in the item list, got %q", files["items.csv"])
+	}
+	found := false
+	for name, content := range files {
+		if strings.HasSuffix(name, "manual.txt") && content == manual {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the manual in the archive, got %v", zr.File)
+	}
+
+	c.expect(c.do(http.MethodDelete, "/api/attachments/"+attachment.ID, nil), http.StatusNoContent, nil)
+}
+
+func Test_E2E_Seed(t *testing.T) {
+	c := newAdminClient(t)
+	type assetList struct {
+		Assets []struct {
+			MainAttachmentURL string `json:"main_attachment_url"`
+		} `json:"assets"`
+		Total int `json:"total"`
+	}
+	var before assetList
+	c.expect(c.do(http.MethodGet, "/api/assets?limit=1", nil), http.StatusOK, &before)
+
+	if code := runSeed(context.Background(), []string{"--assets", "25", "--photos", "--seed", "1"}); code != 0 {
+		t.Fatalf("seed exited with %d", code)
+	}
+
+	var after assetList
+	c.expect(c.do(http.MethodGet, "/api/assets?limit=100", nil), http.StatusOK, &after)
+	if after.Total != before.Total+25 {
+		t.Errorf("expected 25 more assets, got %d before and %d after", before.Total, after.Total)
+	}
+	photos := 0
+	for _, a := range after.Assets {
+		if a.MainAttachmentURL != "" {
+			photos++
+		}
+	}
+	if photos < 25 {
+		t.Errorf("expected the seeded assets to have photos, got %d", photos)
+	}
+
+	var categories []e2eRecord
+	c.expect(c.do(http.MethodGet, "/api/categories", nil), http.StatusOK, &categories)
+	found := false
+	for _, cat := range categories {
+		found = found || cat.Name == "Electronics"
+	}
+	if !found {
+		t.Errorf("expected the seeded categories, got %+v", categories)
+	}
+}
+
+func Test_E2E_HighValueHidden(t *testing.T) {
+	admin := newAdminClient(t)
+
+	var category, asset e2eRecord
+	admin.expect(admin.do(http.MethodPost, "/api/categories", map[string]string{"name": "Jewelry"}), http.StatusCreated, &category)
+	admin.expect(admin.do(http.MethodPost, "/api/assets", map[string]any{
+		"category_id": category.ID,
+		"name":        "Diamond ring",
+		"high_value":  true,
+	}), http.StatusCreated, &asset)
+	expires := time.Now().AddDate(0, 0, 10).Format("2006-01-02")
+	admin.expect(admin.do(http.MethodPost, "/api/assets/"+asset.ID+"/warranty", map[string]string{"end_date": expires}), http.StatusCreated, nil)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "appraisal.txt")
+	part.Write([]byte("Appraised at 12,000"))
+	form.Close()
+	req, _ := http.NewRequest(http.MethodPost, e2eServer.URL+"/api/assets/"+asset.ID+"/attachments", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	var attachment e2eRecord
+	admin.expect(admin.send(req), http.StatusCreated, &attachment)
+
+	// Only insurers see high-value assets; a plain user doesn't
+	admin.expect(admin.do(http.MethodPost, "/api/admin/roles", map[string]any{
+		"name":        "insurer",
+		"permissions": []string{"assets:read"},
+	}), http.StatusCreated, nil)
+	admin.expect(admin.do(http.MethodPut, "/api/admin/high-value", map[string]any{"visible_roles": []string{"insurer"}}), http.StatusOK, nil)
+	admin.expect(admin.do(http.MethodPost, "/api/users", map[string]string{
+		"email":    "member@example.com",
+		"password": e2eAdminPassword,
+		"role":     "user",
+	}), http.StatusCreated, nil)
+	user := newUserClient(t, "member@example.com", e2eAdminPassword)
+
+	for _, path := range []string{
+		"/api/assets/" + asset.ID,
+		"/api/assets/" + asset.ID + "/attachments",
+		"/api/attachments/" + attachment.ID,
+		"/api/attachments/" + attachment.ID + "/thumbnail",
+		"/api/assets/" + asset.ID + "/warranty",
+	} {
+		user.expect(user.do(http.MethodGet, path, nil), http.StatusNotFound, nil)
+	}
+	user.expect(user.do(http.MethodDelete, "/api/attachments/"+attachment.ID, nil), http.StatusNotFound, nil)
+	admin.expect(admin.do(http.MethodGet, "/api/attachments/"+attachment.ID, nil), http.StatusOK, nil)
+
+	for _, path := range []string{"/api/warranties", "/api/warranties/expiring"} {
+		var warranties []struct {
+			AssetID string `json:"asset_id"`
+		}
+		user.expect(user.do(http.MethodGet, path, nil), http.StatusOK, &warranties)
+		for _, w := range warranties {
+			if w.AssetID == asset.ID {
+				t.Errorf("%s: expected the high-value asset's warranty to be hidden", path)
+			}
+		}
+	}
+
+	var task e2eRecord
+	admin.expect(admin.do(http.MethodPost, "/api/assets/"+asset.ID+"/maintenance", map[string]any{
+		"name":           "Clean and inspect",
+		"interval_count": 1,
+		"interval_unit":  "week",
+	}), http.StatusCreated, &task)
+	for _, path := range []string{"/api/assets/" + asset.ID + "/maintenance", "/api/assets/" + asset.ID + "/maintenance/log"} {
+		user.expect(user.do(http.MethodGet, path, nil), http.StatusNotFound, nil)
+	}
+	user.expect(user.do(http.MethodPost, "/api/maintenance/"+task.ID+"/complete", map[string]string{}), http.StatusNotFound, nil)
+	user.expect(user.do(http.MethodDelete, "/api/maintenance/"+task.ID, nil), http.StatusNotFound, nil)
+	for _, path := range []string{"/api/maintenance", "/api/maintenance/upcoming"} {
+		var tasks []e2eRecord
+		user.expect(user.do(http.MethodGet, path, nil), http.StatusOK, &tasks)
+		for _, listed := range tasks {
+			if listed.ID == task.ID {
+				t.Errorf("%s: expected the high-value asset's task to be hidden", path)
+			}
+		}
+	}
+
+	var reservation e2eRecord
+	starts := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Hour)
+	admin.expect(admin.do(http.MethodPost, "/api/assets/"+asset.ID+"/reservations", map[string]string{
+		"starts_at": starts.Format(time.RFC3339),
+		"ends_at":   starts.Add(4 * time.Hour).Format(time.RFC3339),
+	}), http.StatusCreated, &reservation)
+	for _, path := range []string{"/api/assets/" + asset.ID + "/reservations", "/api/assets/" + asset.ID + "/availability"} {
+		user.expect(user.do(http.MethodGet, path, nil), http.StatusNotFound, nil)
+	}
+	user.expect(user.do(http.MethodDelete, "/api/reservations/"+reservation.ID, nil), http.StatusNotFound, nil)
+	var reservations []e2eRecord
+	user.expect(user.do(http.MethodGet, "/api/reservations", nil), http.StatusOK, &reservations)
+	for _, listed := range reservations {
+		if listed.ID == reservation.ID {
+			t.Error("expected the high-value asset's reservation to be hidden")
+		}
+	}
+
+	var cost e2eRecord
+	admin.expect(admin.do(http.MethodPost, "/api/assets/"+asset.ID+"/costs", map[string]any{
+		"name":     "Insurance rider",
+		"amount":   25,
+		"interval": "monthly",
+	}), http.StatusCreated, &cost)
+	user.expect(user.do(http.MethodGet, "/api/assets/"+asset.ID+"/costs", nil), http.StatusNotFound, nil)
+	user.expect(user.do(http.MethodDelete, "/api/costs/"+cost.ID, nil), http.StatusNotFound, nil)
+	var costs struct {
+		Costs []e2eRecord `json:"costs"`
+	}
+	user.expect(user.do(http.MethodGet, "/api/costs", nil), http.StatusOK, &costs)
+	for _, listed := range costs.Costs {
+		if listed.ID == cost.ID {
+			t.Error("expected the high-value asset's recurring cost to be hidden")
+		}
+	}
+
+	var sync struct {
+		Changes map[string]struct {
+			Created []string `json:"created"`
+		} `json:"changes"`
+	}
+	user.expect(user.do(http.MethodGet, "/api/sync", nil), http.StatusOK, &sync)
+	for resource, id := range map[string]string{"assets": asset.ID, "attachments": attachment.ID, "reservations": reservation.ID} {
+		if slices.Contains(sync.Changes[resource].Created, id) {
+			t.Errorf("expected the high-value asset's %s to be left out of the sync", resource)
+		}
+	}
+}
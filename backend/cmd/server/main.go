@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
@@ -12,6 +13,7 @@ import (
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // Time zone settings must work in minimal container images
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -20,13 +22,29 @@ import (
 	"github.com/lmmendes/attic/internal/auth"
 	"github.com/lmmendes/attic/internal/config"
 	"github.com/lmmendes/attic/internal/database"
+	"github.com/lmmendes/attic/internal/diagnostics"
 	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
 	"github.com/lmmendes/attic/internal/handler"
+	"github.com/lmmendes/attic/internal/history"
+	"github.com/lmmendes/attic/internal/imaging"
+	"github.com/lmmendes/attic/internal/jobs"
+	"github.com/lmmendes/attic/internal/links"
+	"github.com/lmmendes/attic/internal/mail"
+	"github.com/lmmendes/attic/internal/manuals"
+	"github.com/lmmendes/attic/internal/metrics"
 	"github.com/lmmendes/attic/internal/plugin"
 	"github.com/lmmendes/attic/internal/plugin/bgg"
 	"github.com/lmmendes/attic/internal/plugin/googlebooks"
+	"github.com/lmmendes/attic/internal/plugin/icecat"
 	"github.com/lmmendes/attic/internal/plugin/tmdb"
+	"github.com/lmmendes/attic/internal/plugin/upcitemdb"
+	"github.com/lmmendes/attic/internal/portable"
+	"github.com/lmmendes/attic/internal/ratelimit"
 	"github.com/lmmendes/attic/internal/repository"
+	"github.com/lmmendes/attic/internal/search"
+	"github.com/lmmendes/attic/internal/secrets"
+	"github.com/lmmendes/attic/internal/security"
 	"github.com/lmmendes/attic/internal/storage"
 	"github.com/lmmendes/attic/migrations"
 )
@@ -37,6 +55,58 @@ var openapiSpec []byte
 // Version is set by ldflags during build
 var Version = "dev"
 
+// exposedHeaders are response headers readable by the SPA and other browser clients
+var exposedHeaders = []string{
+	"Link", "Retry-After", "ETag", "Deprecation", "Sunset",
+	"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+	"X-Plugin-Quota-Limit", "X-Plugin-Quota-Remaining", "X-Plugin-Quota-Reset",
+	"X-Storage-Quota-Limit", "X-Storage-Quota-Used", "X-Storage-Quota-Remaining",
+}
+
+// oidcRevocationRetention is how long back-channel logout revocations are kept
+const oidcRevocationRetention = 30 * 24 * time.Hour
+
+// renewalCheckInterval is how often recurring cost renewals are checked
+const renewalCheckInterval = time.Hour
+
+// loanCheckInterval is how often overdue loans are checked for reminders
+const loanCheckInterval = time.Hour
+
+// lowStockCheckInterval is how often assets are checked for low-stock alerts
+const lowStockCheckInterval = 15 * time.Minute
+
+// consumptionCheckInterval is how often consumption schedules are checked for
+// due decrements
+const consumptionCheckInterval = time.Hour
+
+// labelBatchInterval is how often queued label batches are checked for rendering
+const labelBatchInterval = 15 * time.Second
+
+// reportCheckInterval is how often scheduled reports are checked for due runs
+const reportCheckInterval = time.Minute
+
+// textExtractionInterval is how often new attachments are checked for text to index
+const textExtractionInterval = time.Minute
+
+// archiveCheckInterval is how often attachments of deleted assets are checked for archiving
+const archiveCheckInterval = 24 * time.Hour
+
+// registrationsPerHour limits self-registrations per client IP
+const registrationsPerHour = 10
+
+// loginAttemptsPerMinute limits password logins per account, and
+// clientLoginsPerMinute per client IP, shared by the accounts of a household
+const (
+	loginAttemptsPerMinute = 10
+	clientLoginsPerMinute  = 30
+)
+
+// twoFactorAttemptsPerMinute limits two-factor code attempts per user
+const twoFactorAttemptsPerMinute = 10
+
+// galleryRequestsPerHour limits requests to the public gallery per client IP
+const galleryRequestsPerHour = 600
+
 func main() {
 	// CLI flags for password reset
 	resetPassword := flag.Bool("reset-password", false, "Reset a user's password")
@@ -49,6 +119,25 @@ func main() {
 	}))
 	slog.SetDefault(logger)
 
+	// Subcommands
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "seed":
+			os.Exit(runSeed(context.Background(), args[1:]))
+		case "transfer":
+			os.Exit(runTransfer(context.Background(), args[1:]))
+		}
+		switch strings.Join(args, " ") {
+		case "config check":
+			os.Exit(runConfigCheck(context.Background()))
+		case "storage relayout":
+			os.Exit(runStorageRelayout(context.Background()))
+		default:
+			fmt.Fprintf(os.Stderr, "unknown command %q, available: config check, storage relayout, seed, transfer\n", strings.Join(args, " "))
+			os.Exit(2)
+		}
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		slog.Error("failed to load config", "error", err)
@@ -58,12 +147,12 @@ func main() {
 	ctx := context.Background()
 
 	// Database connection
-	db, err := database.New(ctx, cfg.DatabaseURL)
+	db, closeDB, err := openDatabase(ctx, cfg)
 	if err != nil {
 		slog.Error("failed to connect to database", "error", err)
 		os.Exit(1)
 	}
-	defer db.Close()
+	defer closeDB()
 
 	// Handle CLI password reset
 	if *resetPassword {
@@ -73,47 +162,68 @@ func main() {
 
 	slog.Info("connected to database")
 
-	// Run migrations
-	if err := db.Migrate(ctx, migrations.FS); err != nil {
-		slog.Error("failed to run migrations", "error", err)
+	router, err := newServer(ctx, cfg, db)
+	if err != nil {
+		slog.Error("failed to start server", "error", err)
+		closeDB()
 		os.Exit(1)
 	}
 
-	// Initialize file storage (S3 or local)
-	var fileStorage storage.FileStorage
-	if cfg.UseS3Storage() {
-		s3Client, err := storage.NewS3Client(ctx, storage.S3Config{
-			Endpoint:  cfg.S3Endpoint,
-			Region:    cfg.S3Region,
-			Bucket:    cfg.S3Bucket,
-			AccessKey: cfg.S3AccessKey,
-			SecretKey: cfg.S3SecretKey,
-		})
-		if err != nil {
-			slog.Warn("failed to connect to S3, attachments will be disabled", "error", err)
-		} else {
-			slog.Info("using S3 storage", "bucket", cfg.S3Bucket)
-			fileStorage = s3Client
-		}
-	} else {
-		localStorage, err := storage.NewLocalStorage(storage.LocalConfig{
-			BasePath: cfg.LocalStoragePath,
-			BaseURL:  cfg.BaseURL + "/files",
-			PUID:     cfg.PUID,
-			PGID:     cfg.PGID,
-		})
-		if err != nil {
-			slog.Warn("failed to initialize local storage, attachments will be disabled", "error", err)
-		} else {
-			if cfg.HasFileOwnership() {
-				slog.Info("using local file storage", "path", cfg.LocalStoragePath, "puid", *cfg.PUID, "pgid", *cfg.PGID)
-			} else {
-				slog.Info("using local file storage", "path", cfg.LocalStoragePath)
-			}
-			fileStorage = localStorage
+	srv := &http.Server{
+		Addr:         ":" + cfg.Port,
+		Handler:      router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 60 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Graceful shutdown
+	done := make(chan os.Signal, 1)
+	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		slog.Info("starting server", "port", cfg.Port, "version", Version)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("server error", "error", err)
+			closeDB()
+			os.Exit(1)
 		}
+	}()
+
+	<-done
+	slog.Info("shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("server shutdown error", "error", err)
+		closeDB()
+		os.Exit(1)
+	}
+
+	slog.Info("server stopped")
+}
+
+// newServer wires the server from the configuration: it migrates the
+// database, sets up storage, authentication, handlers and background jobs
+// running until ctx is done, and returns the router. The end-to-end tests
+// boot the server with it.
+func newServer(ctx context.Context, cfg *config.Config, db *database.DB) (http.Handler, error) {
+	// Absolute URLs (share links, file URLs, OIDC redirects) are built from the public base URL
+	linkBuilder, err := links.New(cfg.BaseURL, cfg.AlternateHosts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid link configuration: %w", err)
 	}
 
+	// Run migrations
+	if err := db.Migrate(ctx, migrations.FS); err != nil {
+		return nil, fmt.Errorf("running migrations: %w", err)
+	}
+
+	// Initialize file storage (S3 or local)
+	fileStorage := newFileStorage(ctx, cfg, linkBuilder)
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db.Pool)
 	repos := &handler.Repositories{
@@ -126,113 +236,387 @@ func main() {
 		Warranties:    repository.NewWarrantyRepository(db.Pool),
 		Attachments:   repository.NewAttachmentRepository(db.Pool),
 		Attributes:    repository.NewAttributeRepository(db.Pool),
+		Lists:         repository.NewAssetListRepository(db.Pool),
+		Reservations:  repository.NewReservationRepository(db.Pool),
+		Costs:         repository.NewRecurringCostRepository(db.Pool),
+		Maintenance:   repository.NewMaintenanceRepository(db.Pool),
+		Reminders:     repository.NewReminderRepository(db.Pool),
+		Depreciation:  repository.NewDepreciationRepository(db.Pool),
+		Consumption:   repository.NewConsumptionRepository(db.Pool),
+		Values:        repository.NewAssetValueRepository(db.Pool),
+		Purchases:     repository.NewPurchaseRepository(db.Pool),
+		Bundles:       repository.NewPurchaseGroupRepository(db.Pool),
+		Contacts:      repository.NewContactRepository(db.Pool),
+		Loans:         repository.NewLoanRepository(db.Pool),
+		LabelBatches:  repository.NewLabelBatchRepository(db.Pool),
+		Power:         repository.NewPowerUsageRepository(db.Pool),
+		Reports:       repository.NewReportScheduleRepository(db.Pool),
+		Sync:          repository.NewSyncRepository(db.Pool),
+		Settings:      repository.NewSettingsRepository(db.Pool),
+		Logins:        repository.NewLoginEventRepository(db.Pool),
+		AuthEvents:    repository.NewAuthEventRepository(db.Pool),
+		Roles:         repository.NewRoleRepository(db.Pool),
+		Imports:       repository.NewImportRepository(db.Pool),
+		Sources:       repository.NewAssetSourceRepository(db.Pool),
+		ShortLinks:    repository.NewShortLinkRepository(db.Pool),
+		Invites:       repository.NewInviteRepository(db.Pool),
+		AssetEvents:   repository.NewAssetEventRepository(db.Pool),
+		Comments:      repository.NewAssetCommentRepository(db.Pool),
 	}
 
 	// Resolve default organization from database
 	defaultOrg, err := repos.Organizations.GetDefault(ctx)
 	if err != nil {
-		slog.Error("failed to get default organization", "error", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("getting default organization: %w", err)
 	}
 	if defaultOrg == nil {
-		slog.Error("no default organization found - ensure migrations have been run")
-		os.Exit(1)
+		return nil, errors.New("no default organization found - ensure migrations have been run")
 	}
 	defaultOrgID := defaultOrg.ID
 
 	// Bootstrap admin user if needed
 	if err := bootstrapAdmin(ctx, userRepo, cfg, defaultOrgID); err != nil {
-		slog.Error("failed to bootstrap admin", "error", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("bootstrapping admin: %w", err)
+	}
+
+	// Encryption of secrets stored in the database (e.g. OIDC client secrets)
+	secretBox, err := secrets.New(cfg.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("initializing encryption: %w", err)
+	}
+
+	// An organization OIDC provider (managed via /api/admin/oidc) takes precedence over the environment
+	if err := applyOrganizationOIDC(ctx, cfg, repos.Settings, secretBox, defaultOrgID); err != nil {
+		return nil, fmt.Errorf("loading organization OIDC provider: %w", err)
 	}
 
 	// Session manager for local auth
 	sessionManager := auth.NewSessionManager(cfg.SessionSecret, cfg.SessionDurationHours)
+	sameSite, _ := auth.ParseSameSite(cfg.SessionCookieSameSite) // Validated by config.Load
+	sessionManager.SetCookieOptions(auth.CookieOptions{
+		Name:     cfg.SessionCookieName,
+		Domain:   cfg.SessionCookieDomain,
+		SameSite: sameSite,
+		Secure:   auth.CookieSecureMode(cfg.SessionCookieSecure),
+	})
+	sessionManager.SetRollingRenewal(cfg.SessionRolling)
+	if err := userRepo.DeleteExpiredSessions(ctx, time.Now()); err != nil {
+		slog.Warn("failed to prune expired sessions", "error", err)
+	}
+	sessionManager.SetStore(userRepo)
 
 	// Auth middleware
+	oidcProviders := oidcProviderConfigs(cfg)
 	authMiddleware, err := auth.NewMiddleware(ctx, auth.Config{
-		IssuerURL:   cfg.OIDCIssuer,
-		ClientID:    cfg.OIDCClientID,
+		Providers:   oidcProviders,
 		Disabled:    cfg.AuthDisabled,
 		OIDCEnabled: cfg.OIDCEnabled,
 	})
 	if err != nil {
-		slog.Error("failed to initialize auth", "error", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("initializing auth: %w", err)
 	}
 
-	// Set session manager for local auth
+	// Set session manager for local auth; service accounts use their tokens in every mode
 	authMiddleware.SetSessionManager(sessionManager)
+	authMiddleware.SetServiceTokens(userRepo)
+
+	// Reverse-proxy header authentication (replaces OIDC and local logins)
+	var proxyAuth *auth.ProxyAuth
+	if cfg.ProxyAuthEnabled {
+		proxyAuth, err = auth.NewProxyAuth(auth.ProxyConfig{
+			TrustedProxies: cfg.TrustedProxies,
+			UserHeader:     cfg.ProxyAuthUserHeader,
+			EmailHeader:    cfg.ProxyAuthEmailHeader,
+			NameHeader:     cfg.ProxyAuthNameHeader,
+			GroupsHeader:   cfg.ProxyAuthGroupsHeader,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy authentication configuration: %w", err)
+		}
+		authMiddleware.SetProxyAuth(proxyAuth)
+	}
 
 	// OAuth handler for OIDC login flow (only if OIDC enabled)
 	var oauthHandler *auth.OAuthHandler
 	if cfg.OIDCEnabled {
 		oauthHandler, err = auth.NewOAuthHandler(ctx, auth.OAuthConfig{
-			IssuerURL:     cfg.OIDCIssuer,
-			ClientID:      cfg.OIDCClientID,
-			ClientSecret:  cfg.OIDCClientSecret,
-			BaseURL:       cfg.BaseURL,
+			Providers:     oidcProviders,
+			BaseURL:       linkBuilder.BaseURL(),
 			SessionSecret: cfg.SessionSecret,
 			Disabled:      cfg.AuthDisabled,
 		})
 		if err != nil {
-			slog.Error("failed to initialize OAuth handler", "error", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("initializing OAuth handler: %w", err)
+		}
+		oauthHandler.SetSecrets(secretBox)
+
+		revocations := repository.NewOIDCRevocationRepository(db.Pool)
+		if err := revocations.DeleteBefore(ctx, time.Now().Add(-oidcRevocationRetention)); err != nil {
+			slog.Warn("failed to prune OIDC logout revocations", "error", err)
 		}
+		oauthHandler.SetRevocationStore(revocations)
 		authMiddleware.SetOAuthHandler(oauthHandler)
 	}
 
 	// User provisioner (for OIDC mode)
 	userProvisioner := auth.NewUserProvisioner(userRepo, defaultOrgID)
+	roleMapping, err := auth.ParseRoleMapping(cfg.OIDCRoleClaim, cfg.OIDCRoleMapping)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ATTIC_OIDC_ROLE_MAPPING: %w", err)
+	}
+	if roleMapping != nil {
+		for _, role := range roleMapping.Roles() {
+			if _, builtin := domain.BuiltinRole(role); builtin {
+				continue
+			}
+			if custom, err := repos.Roles.GetByName(ctx, defaultOrgID, role); err == nil && custom == nil {
+				slog.Warn("OIDC role mapping assigns an unknown role", "role", role)
+			}
+		}
+		userProvisioner.SetRoleMapping(roleMapping)
+		slog.Info("OIDC role mapping enabled", "claim", roleMapping.Claim, "rules", len(roleMapping.Rules))
+	}
+	authorizer := auth.NewAuthorizer(repos.Roles, defaultOrgID)
+
+	// Requests are scoped to an organization the user is a member of
+	orgResolver := auth.NewOrganizationResolver(repos.Organizations, defaultOrgID)
 
 	if cfg.AuthDisabled {
 		slog.Warn("authentication is disabled")
+	} else if cfg.ProxyAuthEnabled {
+		slog.Info("reverse-proxy header authentication enabled", "trusted_proxies", cfg.TrustedProxies)
 	} else if cfg.OIDCEnabled {
-		slog.Info("OIDC authentication enabled", "issuer", cfg.OIDCIssuer)
+		slog.Info("OIDC authentication enabled", "issuer", cfg.OIDCIssuer, "providers", len(oidcProviders))
 	} else {
 		slog.Info("local (email/password) authentication enabled")
 	}
 
 	// Initialize plugin registry
-	pluginRegistry := plugin.NewRegistry()
-	if err := pluginRegistry.Register(googlebooks.New()); err != nil {
-		slog.Error("failed to register Google Books plugin", "error", err)
-	}
-	if err := pluginRegistry.Register(tmdb.NewMoviesPlugin()); err != nil {
-		slog.Error("failed to register TMDB Movies plugin", "error", err)
-	}
-	if err := pluginRegistry.Register(tmdb.NewSeriesPlugin()); err != nil {
-		slog.Error("failed to register TMDB Series plugin", "error", err)
+	pluginRegistry := newPluginRegistry()
+	slog.Info("registered plugins", "count", len(pluginRegistry.List()), "enrichers", len(pluginRegistry.ListEnrichers()))
+
+	// Report unreachable services without failing startup (see "attic config check")
+	go diagnostics.Log(diagnostics.Run(ctx, serviceChecks(cfg, pluginRegistry), diagnostics.DefaultTimeout))
+
+	// Event bus for integrations
+	eventBus := events.NewBus()
+
+	// Change history of assets, from the changes of asset events
+	eventBus.Subscribe(history.NewRecorder(repos.AssetEvents).HandleEvent)
+
+	// Optional external search engine, kept in sync via asset events
+	var searchEngine search.Engine
+	var searchIndexer *search.Indexer
+	if cfg.SearchEngine != "" {
+		searchEngine, err = search.New(search.Config{
+			Engine: cfg.SearchEngine,
+			URL:    cfg.SearchURL,
+			APIKey: cfg.SearchAPIKey,
+			Index:  cfg.SearchIndex,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("initializing search engine: %w", err)
+		}
+		if err := searchEngine.Setup(ctx); err != nil {
+			slog.Warn("failed to set up search index", "error", err)
+		}
+		searchIndexer = search.NewIndexer(searchEngine, repos.Assets)
+		eventBus.Subscribe(searchIndexer.HandleEvent)
+		go searchIndexer.Run(ctx)
+		slog.Info("external search enabled", "engine", cfg.SearchEngine, "index", cfg.SearchIndex)
 	}
-	if err := pluginRegistry.Register(bgg.New()); err != nil {
-		slog.Error("failed to register BGG plugin", "error", err)
+
+	// Optional product manual fetching for new assets
+	var manualFetcher *handler.ManualFetcher
+	if cfg.ManualSources != "" && fileStorage != nil {
+		sources, err := manuals.ParseSources(cfg.ManualSources)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manual sources: %w", err)
+		}
+		manualFetcher = handler.NewManualFetcher(repos, fileStorage, manuals.NewFetcher(sources...), cfg.StorageQuotaBytes)
+		eventBus.Subscribe(manualFetcher.HandleEvent)
+		go manualFetcher.Run(ctx)
+		slog.Info("product manual fetching enabled", "sources", len(sources))
 	}
-	slog.Info("registered plugins", "count", len(pluginRegistry.List()))
 
 	// Initialize handlers
 	h := handler.New(db, repos, fileStorage, defaultOrgID)
+	h.SetStorageQuota(cfg.StorageQuotaBytes)
+	h.SetEvents(eventBus)
+	h.SetLinks(linkBuilder)
+	h.SetSecrets(secretBox)
+	images := imaging.New(cfg.ImageProcessor == "native")
+	h.SetImageProcessor(images)
+	slog.Info("image processing", "processor", images.Name())
+	if cfg.ImageConvertFormat != "" {
+		if _, ok := imaging.AsConverter(images); ok {
+			format, _ := imaging.ParseFormat(cfg.ImageConvertFormat)
+			h.SetImageConversion(handler.ImageConversion{
+				ConvertOptions: imaging.ConvertOptions{
+					Format:  format,
+					Quality: cfg.ImageConvertQuality,
+					MaxSize: cfg.ImageConvertMaxSize,
+				},
+				MinSize:       int64(cfg.ImageConvertMinKB) * 1024,
+				KeepOriginals: cfg.ImageKeepOriginals,
+			})
+			slog.Info("image conversion enabled", "format", format, "keep_originals", cfg.ImageKeepOriginals)
+		} else {
+			slog.Warn("image conversion requires ATTIC_IMAGE_PROCESSOR=native with vipsthumbnail installed; uploads are stored as is")
+		}
+	}
+	if searchEngine != nil {
+		h.SetSearch(searchEngine, searchIndexer)
+	}
 	pluginHandler := handler.NewPluginHandler(pluginRegistry, repos, fileStorage, defaultOrgID)
+	pluginHandler.SetEvents(eventBus)
+	pluginErrors := metrics.NewCounterVec("attic_plugin_errors_total", "Failed calls to external plugin sources.", "plugin", "operation")
+	pluginHandler.SetErrorCounter(pluginErrors)
+	pluginHandler.SetSecrets(secretBox)
+	if err := pluginHandler.LoadHTTPPlugins(ctx); err != nil {
+		slog.Error("failed to load HTTP plugins", "error", err)
+	}
+	if err := pluginHandler.LoadPluginSettings(ctx); err != nil {
+		slog.Error("failed to load plugin settings", "error", err)
+	}
+	// Follow category names changed by plugin updates instead of leaving stale names
+	categoryChanges, err := pluginRegistry.ReconcileCategories(ctx, repos.Categories, defaultOrgID)
+	if err != nil {
+		slog.Error("failed to reconcile plugin categories", "error", err)
+	}
+	for _, c := range categoryChanges {
+		slog.Info("reconciled plugin category", "plugin_id", c.PluginID, "from", c.From, "to", c.To, "action", c.Action)
+	}
+	// Outgoing email (optional)
+	var mailer mail.Mailer
+	if cfg.MailEnabled() {
+		mailer = mail.NewSMTP(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		})
+		slog.Info("email enabled", "smtp_host", cfg.SMTPHost)
+	}
+
+	// Login audit log (and optional new device alerts)
+	loginAudit := handler.NewLoginAudit(repos, defaultOrgID)
+	if cfg.LoginAlerts {
+		if mailer != nil {
+			loginAudit.SetAlerts(mailer, linkBuilder)
+		} else {
+			slog.Warn("login alerts require ATTIC_SMTP_HOST, alerts disabled")
+		}
+	}
+
+	// Background jobs: recurring cost renewals (reminders need email), overdue loan
+	// reminders, low-stock alerts, consumption schedules, scheduled reports,
+	// queued label batches, attachment text extraction for search and archiving
+	// of deleted assets' attachments
+	h.SetMailer(mailer)
+	scheduler := jobs.New()
+	scheduler.Every("renewal-reminders", renewalCheckInterval, handler.NewRenewalReminders(repos, mailer, linkBuilder).RunOnce)
+	if mailer != nil {
+		scheduler.Every("loan-reminders", loanCheckInterval, handler.NewLoanReminders(repos, mailer).RunOnce)
+		scheduler.Every("low-stock-alerts", lowStockCheckInterval, handler.NewLowStockAlerts(repos, mailer, linkBuilder).RunOnce)
+	}
+	scheduler.Every("consumption", consumptionCheckInterval, h.RunConsumption)
+	scheduler.Every("scheduled-reports", reportCheckInterval, h.RunDueReports)
+	scheduler.Every("label-batches", labelBatchInterval, h.RenderLabelBatches)
+	scheduler.Every("attachment-text", textExtractionInterval, h.ExtractAttachmentText)
+	if cfg.StorageArchiveDays > 0 {
+		h.SetArchiveAfter(time.Duration(cfg.StorageArchiveDays) * 24 * time.Hour)
+		scheduler.Every("attachment-archive", archiveCheckInterval, h.ArchiveAttachments)
+	}
+	scheduler.Start(ctx)
+
 	authHandler := handler.NewAuthHandler(userRepo, sessionManager, cfg.PasswordMinLength, cfg.OIDCEnabled)
+	authHandler.SetLoginAudit(loginAudit)
+	authHandler.SetPasswordPolicy(passwordPolicy(cfg))
+	authHandler.SetTwoFactor(repos.Settings, secretBox)
+	authHandler.SetLoginLimits(ratelimit.New(loginAttemptsPerMinute, time.Minute), ratelimit.New(twoFactorAttemptsPerMinute, time.Minute))
+	authHandler.SetInvites(repos.Invites)
+	if webAuthn, err := auth.NewWebAuthn(cfg.BaseURL, "Attic"); err != nil {
+		slog.Warn("passkeys disabled", "error", err)
+	} else {
+		authHandler.SetPasskeys(webAuthn)
+	}
+	if proxyAuth != nil {
+		authHandler.SetProxyAuth(proxyAuth)
+	}
 	if oauthHandler != nil {
 		authHandler.SetOAuthHandler(oauthHandler)
+		oauthHandler.SetLoginHook(func(r *http.Request, subject, email string, success bool, failureReason string) {
+			var user *domain.User
+			if subject != "" {
+				// Nil on first login, the user is provisioned on the first API call
+				user, _ = userRepo.GetByOIDCSubject(r.Context(), subject)
+			}
+			loginAudit.Record(r, user, email, domain.LoginMethodOIDC, failureReason)
+		})
+		oauthHandler.SetLogoutHook(func(r *http.Request, subject, email string) {
+			if user, err := userRepo.GetByOIDCSubject(r.Context(), subject); err == nil && user != nil {
+				loginAudit.RecordEvent(r, user, domain.AuthEventLogout)
+			}
+		})
 	}
 	userMgmtHandler := handler.NewUserManagementHandler(userRepo, sessionManager, cfg.PasswordMinLength, defaultOrgID)
+	userMgmtHandler.SetRoles(repos.Roles)
+	userMgmtHandler.SetOrganizations(repos.Organizations)
+	userMgmtHandler.SetLoginAudit(loginAudit)
+	userMgmtHandler.SetPasswordPolicy(passwordPolicy(cfg))
+	if cfg.SelfRegistration {
+		authHandler.SetSelfRegistration(defaultOrgID)
+	}
+
+	// Email verification of accounts created by admins (links need email)
+	if mailer != nil {
+		verifier := handler.NewEmailVerifier(userRepo, sessionManager, repos.Settings, mailer, linkBuilder)
+		verifier.SetRequired(cfg.RequireEmailVerification)
+		authHandler.SetEmailVerifier(verifier)
+		userMgmtHandler.SetEmailVerifier(verifier)
+	}
+
+	// API description for integrations, with the optional features of this server
+	apiMeta := handler.NewAPIMeta(Version, map[string]bool{
+		"oidc":              cfg.OIDCEnabled,
+		"proxy_auth":        cfg.ProxyAuthEnabled,
+		"self_registration": cfg.SelfRegistration,
+		"email":             mailer != nil,
+		"search":            searchEngine != nil,
+		"manuals":           manualFetcher != nil,
+		"scim":              cfg.SCIMToken != "",
+		"grafana":           cfg.GrafanaToken != "",
+		"metrics":           cfg.MetricsEnabled,
+	})
+
+	// Forwarded client addresses are only taken from trusted proxies
+	realIP, err := auth.RealIP(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
 
 	r := chi.NewRouter()
 
 	// Global middleware
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(auth.RememberPeer) // Before RealIP, for trusted proxy checks
+	r.Use(realIP)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(security.Headers(security.Config{
+		SPAPolicy: cfg.ContentSecurityPolicy,
+		DocsPath:  docsPath,
+	}))
 
 	// CORS middleware
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   strings.Split(cfg.CORSOrigins, ","),
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", auth.OrganizationHeader},
+		ExposedHeaders:   exposedHeaders,
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -241,86 +625,341 @@ func main() {
 	r.Get("/health", h.Health)
 	r.Get("/ready", h.Ready)
 
+	// Prometheus metrics (optional bearer token)
+	if cfg.MetricsEnabled {
+		registry := metrics.NewRegistry()
+		registry.MustRegister(pluginErrors)
+		registry.RegisterCollector(h.CollectMetrics)
+		r.Handle("/metrics", registry.Handler(cfg.MetricsToken))
+		if cfg.MetricsToken == "" {
+			slog.Warn("metrics endpoint enabled without ATTIC_METRICS_TOKEN, anyone can scrape it")
+		}
+	}
+
 	// OpenAPI documentation
 	r.Get("/api/openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/yaml")
 		w.Write(openapiSpec)
 	})
-	r.Get("/api/docs", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		w.Write([]byte(swaggerUIHTML))
+	r.Get("/api/schema/export.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.Write(portable.Schema)
 	})
-
-	// Serve local files (only when using local storage)
-	if localStorage, ok := fileStorage.(*storage.LocalStorage); ok {
-		fileServer := http.StripPrefix("/files/", http.FileServer(http.Dir(localStorage.BasePath())))
-		r.Get("/files/*", func(w http.ResponseWriter, r *http.Request) {
-			fileServer.ServeHTTP(w, r)
-		})
+	r.Get("/api/docs", docsHandler)
+	r.Get("/api/docs/redoc", redocHandler)
+	r.Get("/api/docs/*", docsAssetsHandler())
+
+	// Organization branding (no auth required, used by the login page)
+	r.Get("/api/branding", h.GetBranding)
+	r.Get("/api/branding/logo", h.GetBrandingLogo)
+
+	// Serve local files (only when using local storage) by their signed URL,
+	// or else to users who may read the attachment
+	if _, ok := fileStorage.(*storage.LocalStorage); ok {
+		fileAuth := []func(http.Handler) http.Handler{authMiddleware.Authenticate}
+		if cfg.OIDCEnabled || cfg.ProxyAuthEnabled {
+			fileAuth = append(fileAuth, userProvisioner.Provision)
+		}
+		fileAuth = append(fileAuth, orgResolver.Resolve, authorizer.Require(domain.PermissionAssetsRead))
+		r.Get("/files/*", h.LocalFiles(chi.Chain(fileAuth...).Handler).ServeHTTP)
 	}
 
 	// Auth routes (no auth required)
 	r.Route("/auth", func(r chi.Router) {
 		// Local auth endpoints
-		r.Post("/login", authHandler.Login)
+		// Guessing passwords is also throttled per account, and codes per user,
+		// by the handler
+		loginLimit := ratelimit.New(clientLoginsPerMinute, time.Minute).Middleware("X-RateLimit", rateLimitKey)
+		r.With(loginLimit).Post("/login", authHandler.Login)
+		r.With(loginLimit).Post("/login/2fa", authHandler.LoginTwoFactor)
 		r.Post("/logout", authHandler.Logout)
 		r.Get("/session", authHandler.GetSession)
 		r.Get("/mode", authHandler.GetAuthMode)
+		r.Get("/verify-email", authHandler.VerifyEmail)
+
+		// Bearer tokens for API clients (mobile apps, CLIs) instead of the cookie
+		r.With(loginLimit).Post("/token", authHandler.Token)
+		r.With(loginLimit).Post("/token/2fa", authHandler.TokenTwoFactor)
+		r.Post("/refresh", authHandler.RefreshToken)
+		if cfg.SelfRegistration {
+			r.With(ratelimit.New(registrationsPerHour, time.Hour).Middleware("X-RateLimit", rateLimitKey)).Post("/register", authHandler.Register)
+		}
+
+		// Invite links: what they invite to, and signing up with one
+		r.Get("/invites/{token}", h.GetInvite)
+		r.With(ratelimit.New(registrationsPerHour, time.Hour).Middleware("X-RateLimit", rateLimitKey)).Post("/invites/{token}/register", authHandler.RegisterWithInvite)
+
+		// TOTP enrollment, for signed-in users or with a setup challenge from login
+		r.Post("/2fa/setup", authHandler.SetupTwoFactor)
+		r.With(loginLimit).Post("/2fa/enable", authHandler.EnableTwoFactor)
+
+		// Passwordless login with passkeys (WebAuthn)
+		r.Post("/passkeys/login/options", authHandler.PasskeyLoginOptions)
+		r.Post("/passkeys/login", authHandler.LoginPasskey)
 
 		// OIDC endpoints (only when OIDC enabled)
 		if cfg.OIDCEnabled && oauthHandler != nil {
 			r.Get("/oidc/login", oauthHandler.Login)
 			r.Get("/oidc/callback", oauthHandler.Callback)
 			r.Get("/oidc/logout", oauthHandler.Logout)
+			r.Post("/oidc/backchannel-logout", oauthHandler.BackchannelLogout)
 		}
 	})
 
+	// Shared lists (public, token protected)
+	r.Get("/share/lists/{token}", h.GetSharedAssetList)
+
+	// Public read-only gallery of selected categories
+	r.Route("/public/{slug}", func(r chi.Router) {
+		r.Use(ratelimit.New(galleryRequestsPerHour, time.Hour).Middleware("X-RateLimit", rateLimitKey))
+		r.Get("/", h.GetPublicGallery)
+		r.Get("/images/{assetId}", h.GetPublicGalleryImage)
+	})
+
+	// Widgets of the public gallery and shared lists for iframes on other sites
+	r.Group(func(r chi.Router) {
+		r.Use(ratelimit.New(galleryRequestsPerHour, time.Hour).Middleware("X-RateLimit", rateLimitKey))
+		r.Get("/embed/public/{slug}", h.EmbedPublicGallery)
+		r.Get("/embed/lists/{token}", h.EmbedSharedList)
+		r.Get("/oembed", h.GetOEmbed)
+	})
+
+	// Short links to views of the app (the app asks the user to sign in)
+	r.Get("/l/{code}", h.FollowShortLink)
+
+	// SCIM provisioning (identity providers, bearer token protected)
+	if cfg.SCIMToken != "" {
+		scimHandler := handler.NewSCIMHandler(userRepo, defaultOrgID, cfg.SCIMToken)
+		scimHandler.SetLinks(linkBuilder)
+		r.Route("/scim/v2", func(r chi.Router) {
+			r.Use(scimHandler.RequireToken)
+			r.Get("/ServiceProviderConfig", scimHandler.ServiceProviderConfig)
+			r.Get("/ResourceTypes", scimHandler.ResourceTypes)
+			r.Get("/Schemas", scimHandler.Schemas)
+			r.Get("/Users", scimHandler.ListUsers)
+			r.Post("/Users", scimHandler.CreateUser)
+			r.Get("/Users/{id}", scimHandler.GetUser)
+			r.Put("/Users/{id}", scimHandler.ReplaceUser)
+			r.Patch("/Users/{id}", scimHandler.PatchUser)
+			r.Delete("/Users/{id}", scimHandler.DeleteUser)
+		})
+	}
+
+	// Grafana JSON datasource (bearer token, no user session)
+	if cfg.GrafanaToken != "" {
+		r.Route("/grafana", func(r chi.Router) {
+			r.Use(handler.RequireGrafanaToken(cfg.GrafanaToken))
+			r.Get("/", h.GrafanaTestConnection)
+			r.Post("/search", h.GrafanaSearch)
+			r.Post("/metrics", h.GrafanaMetrics)
+			r.Post("/query", h.GrafanaQuery)
+			r.Post("/annotations", h.GrafanaAnnotations)
+		})
+	}
+
 	// API routes (auth required)
 	r.Route("/api", func(r chi.Router) {
 		// Apply auth middleware to all /api routes
 		r.Use(authMiddleware.Authenticate)
 
-		// Only use user provisioner for OIDC mode
-		if cfg.OIDCEnabled {
+		// Only use user provisioner for OIDC and proxy modes
+		if cfg.OIDCEnabled || cfg.ProxyAuthEnabled {
 			r.Use(userProvisioner.Provision)
 		}
+		r.Use(orgResolver.Resolve)
 
-		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		// Cookie-authenticated changes need the CSRF token
+		r.Use(authMiddleware.RequireCSRF)
+
+		// Per-user (or per-client) API rate limit
+		if cfg.RateLimitPerMinute > 0 {
+			r.Use(ratelimit.New(cfg.RateLimitPerMinute, time.Minute).Middleware("X-RateLimit", rateLimitKey))
+		}
+
+		// API version, features and deprecation notices
+		r.Get("/meta", apiMeta.Get)
+		apiStatusSunset := time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)
+		r.With(apiMeta.Deprecate(handler.Deprecation{
+			Method:      http.MethodGet,
+			Path:        "/api/",
+			Since:       time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			Sunset:      &apiStatusSunset,
+			Replacement: "/api/meta",
+			Notice:      "Use /api/meta for the version, or /health for the status",
+		})).Get("/", func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
 			w.Write([]byte(`{"status":"ok","version":"` + Version + `"}`))
 		})
 
+		// Role permissions: inventory routes need assets:read to read and
+		// assets:write to change data
+		assetAccess := authorizer.RequireByMethod(domain.PermissionAssetsRead, domain.PermissionAssetsWrite)
+		requireSettings := authorizer.Require(domain.PermissionSettingsManage)
+
+		// Plugin calls hit third-party APIs, so they get their own quota
+		pluginQuota := func(next http.Handler) http.Handler { return next }
+		if cfg.PluginRateLimitPerHour > 0 {
+			pluginQuota = ratelimit.New(cfg.PluginRateLimitPerHour, time.Hour).Middleware("X-Plugin-Quota", rateLimitKey)
+		}
+
 		// Auth endpoints (requires authentication)
 		r.Route("/auth", func(r chi.Router) {
+			r.Get("/csrf", authHandler.CSRFToken)
 			r.Put("/password", authHandler.ChangePassword)
+			r.Get("/2fa", authHandler.GetTwoFactor)
+			r.Post("/2fa/backup-codes", authHandler.RegenerateBackupCodes)
+			r.Delete("/2fa", authHandler.DisableTwoFactor)
+			r.Get("/passkeys", authHandler.ListPasskeys)
+			r.Post("/passkeys/register/options", authHandler.PasskeyRegistrationOptions)
+			r.Post("/passkeys/register", authHandler.RegisterPasskey)
+			r.Delete("/passkeys/{id}", authHandler.DeletePasskey)
 		})
 
 		// Current user info
 		r.Get("/me", h.GetCurrentUser)
+		r.Get("/me/security", h.GetMySecurity)
+		r.Put("/me/time-zone", h.UpdateMyTimeZone)
+		r.Get("/me/preferences", h.GetMyPreferences)
+		r.Put("/me/preferences", h.UpdateMyPreferences)
+		r.Get("/me/preferences/views/{view}", h.GetMyViewPreferences)
+		r.Put("/me/preferences/views/{view}", h.UpdateMyViewPreferences)
+		r.Delete("/me/preferences/views/{view}", h.DeleteMyViewPreferences)
+		r.Get("/me/sessions", authHandler.ListSessions)
+		r.Delete("/me/sessions", authHandler.RevokeSessions)
+		r.Delete("/me/sessions/{id}", authHandler.RevokeSession)
+
+		// Organizations of the current user; X-Org-ID selects one per request
+		r.Get("/me/orgs", h.ListMyOrganizations)
+		r.Put("/me/orgs/current", h.SelectMyOrganization)
+		r.Post("/me/invites/{token}", h.AcceptInvite)
+
+		// The caller's organization; admins of the default organization
+		// create, export and delete further organizations
+		r.Get("/organization", h.GetOrganization)
+		r.With(requireSettings).Put("/organization", h.UpdateOrganization)
+		// Whole organization in the open export format, see /api/schema/export.json
+		r.With(requireSettings).Get("/organization/export", h.ExportPortable)
+		r.With(requireSettings, authorizer.Require(domain.PermissionAssetsWrite)).Post("/organization/import", h.ImportPortable)
+		r.With(authorizer.Require(domain.PermissionUsersManage)).Post("/organizations", userMgmtHandler.CreateOrganization)
+		r.Route("/organizations/{id}", func(r chi.Router) {
+			r.Use(authorizer.Require(domain.PermissionUsersManage))
+			r.Get("/export", h.ExportOrganizationData)
+			r.Post("/deletion", h.RequestOrganizationDeletion)
+			r.Delete("/", h.DeleteOrganization)
+		})
+
+		// Members of the organization, including users of other organizations
+		r.Route("/organization/members", func(r chi.Router) {
+			r.Use(authorizer.Require(domain.PermissionUsersManage))
+			r.Get("/", h.ListMembers)
+			r.Post("/", h.AddMember)
+			r.Put("/{userId}", h.UpdateMember)
+			r.Delete("/{userId}", h.RemoveMember)
+		})
+
+		// Invite links to the organization, with a preset role
+		r.Route("/organization/invites", func(r chi.Router) {
+			r.Use(authorizer.Require(domain.PermissionUsersManage))
+			r.Get("/", h.ListInvites)
+			r.Post("/", h.CreateInvite)
+			r.Delete("/{id}", h.RevokeInvite)
+		})
+
+		// Administration
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(requireSettings)
+			r.Get("/logins", h.ListLogins)
+			r.Get("/auth-events", h.ListAuthEvents)
+			r.Get("/oidc", h.GetOIDCProvider)
+			r.Put("/oidc", h.UpdateOIDCProvider)
+			r.Delete("/oidc", h.DeleteOIDCProvider)
+			r.Get("/high-value", h.GetHighValuePolicy)
+			r.Put("/high-value", h.UpdateHighValuePolicy)
+			r.Get("/time-zone", h.GetTimeZone)
+			r.Put("/time-zone", h.UpdateTimeZone)
+			r.Get("/energy", h.GetEnergySettings)
+			r.Put("/energy", h.UpdateEnergySettings)
+			r.Get("/public-gallery", h.GetPublicGallerySettings)
+			r.Put("/public-gallery", h.UpdatePublicGallerySettings)
+			r.Get("/two-factor", h.GetTwoFactorPolicy)
+			r.Put("/two-factor", h.UpdateTwoFactorPolicy)
+			r.Get("/http-plugins", pluginHandler.ListHTTPPlugins)
+			r.Put("/http-plugins/{id}", pluginHandler.UpdateHTTPPlugin)
+			r.Delete("/http-plugins/{id}", pluginHandler.DeleteHTTPPlugin)
+			r.Get("/import-transforms", pluginHandler.ListImportTransforms)
+			r.Put("/import-transforms/{pluginId}", pluginHandler.UpdateImportTransforms)
+			r.Get("/permissions", h.ListPermissions)
+			r.Get("/roles", h.ListRoles)
+			r.Post("/roles", h.CreateRole)
+			r.Put("/roles/{id}", h.UpdateRole)
+			r.Delete("/roles/{id}", h.DeleteRole)
+		})
+
+		// Offline bootstrap and delta sync
+		r.With(assetAccess).Get("/bootstrap/taxonomy", h.GetTaxonomyBundle)
+		r.With(assetAccess).Get("/sync", h.GetSyncChanges)
+
+		// External search engine (typo-tolerant, faceted)
+		r.With(assetAccess).Get("/search", h.SearchAssets)
+		r.With(requireSettings).Post("/search/reindex", h.ReindexSearch)
 
-		// User management (admin only)
+		// User management
 		r.Route("/users", func(r chi.Router) {
-			r.Use(auth.RequireAdmin(sessionManager))
+			r.Use(authorizer.Require(domain.PermissionUsersManage))
+			r.Use(h.ScopeTo(repository.ResourceUser))
 			r.Get("/", userMgmtHandler.ListUsers)
 			r.Post("/", userMgmtHandler.CreateUser)
 			r.Get("/{id}", userMgmtHandler.GetUser)
 			r.Put("/{id}", userMgmtHandler.UpdateUser)
 			r.Delete("/{id}", userMgmtHandler.DeleteUser)
 			r.Post("/{id}/reset-password", userMgmtHandler.ResetPassword)
+			r.Delete("/{id}/sessions", userMgmtHandler.ExpireSessions)
+			r.Post("/{id}/reset-two-factor", userMgmtHandler.ResetTwoFactor)
+			r.Post("/{id}/verification", userMgmtHandler.ResendVerification)
+			r.Get("/pending", userMgmtHandler.ListPendingUsers)
+			r.Post("/{id}/approve", userMgmtHandler.ApproveUser)
+			r.Post("/{id}/reject", userMgmtHandler.RejectUser)
+		})
+
+		// Service accounts for integrations, with scoped tokens
+		r.Route("/service-accounts", func(r chi.Router) {
+			r.Use(authorizer.Require(domain.PermissionUsersManage))
+			r.Use(h.ScopeTo(repository.ResourceUser))
+			r.Get("/", userMgmtHandler.ListServiceAccounts)
+			r.Post("/", userMgmtHandler.CreateServiceAccount)
+			r.Delete("/{id}", userMgmtHandler.DeleteServiceAccount)
+			r.Get("/{id}/tokens", userMgmtHandler.ListServiceTokens)
+			r.Post("/{id}/tokens", userMgmtHandler.CreateServiceToken)
+			r.Delete("/{id}/tokens/{tokenId}", userMgmtHandler.DeleteServiceToken)
+		})
+
+		// Organization settings
+		r.Route("/settings", func(r chi.Router) {
+			r.Use(requireSettings)
+			r.Put("/branding", h.UpdateBranding)
+			r.Post("/branding/logo", h.UploadBrandingLogo)
+			r.Delete("/branding/logo", h.DeleteBrandingLogo)
 		})
 
 		// Categories
 		r.Route("/categories", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceCategory))
 			r.Get("/", h.ListCategories)
 			r.Post("/", h.CreateCategory)
 			r.Get("/asset-counts", h.GetCategoryAssetCounts)
 			r.Get("/{id}", h.GetCategory)
 			r.Put("/{id}", h.UpdateCategory)
 			r.Delete("/{id}", h.DeleteCategory)
+			r.Get("/{id}/depreciation", h.GetCategoryDepreciation)
+			r.Put("/{id}/depreciation", h.UpdateCategoryDepreciation)
+			r.Delete("/{id}/depreciation", h.DeleteCategoryDepreciation)
 		})
 
 		// Attributes
 		r.Route("/attributes", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceAttribute))
 			r.Get("/", h.ListAttributes)
 			r.Post("/", h.CreateAttribute)
 			r.Get("/{id}", h.GetAttribute)
@@ -330,6 +969,8 @@ func main() {
 
 		// Locations
 		r.Route("/locations", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceLocation))
 			r.Get("/", h.ListLocations)
 			r.Post("/", h.CreateLocation)
 			r.Get("/{id}", h.GetLocation)
@@ -339,6 +980,8 @@ func main() {
 
 		// Conditions
 		r.Route("/conditions", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceCondition))
 			r.Get("/", h.ListConditions)
 			r.Post("/", h.CreateCondition)
 			r.Get("/{id}", h.GetCondition)
@@ -348,12 +991,22 @@ func main() {
 
 		// Assets
 		r.Route("/assets", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceAsset))
 			r.Get("/", h.ListAssets)
 			r.Get("/stats", h.GetAssetStats)
+			r.Get("/stats/history", h.GetValueHistory)
 			r.Post("/", h.CreateAsset)
+			r.Post("/bulk", h.BulkAssets)
 			r.Get("/{id}", h.GetAsset)
 			r.Put("/{id}", h.UpdateAsset)
+			r.Patch("/{id}", h.PatchAsset)
 			r.Delete("/{id}", h.DeleteAsset)
+			r.Get("/{id}/history", h.GetAssetHistory)
+			r.Get("/{id}/attribute-history", h.GetAttributeHistory)
+			r.Post("/{id}/revert/{eventId}", h.RevertAsset)
+			r.Post("/{id}/short-id", h.AssignShortID)
+			r.Post("/{id}/adjust", h.AdjustAssetQuantity)
 
 			// Warranty (nested under asset)
 			r.Get("/{id}/warranty", h.GetWarranty)
@@ -364,66 +1017,287 @@ func main() {
 			// Attachments (nested under asset)
 			r.Get("/{id}/attachments", h.ListAttachments)
 			r.Post("/{id}/attachments", h.UploadAttachment)
+			if manualFetcher != nil {
+				r.Post("/{id}/manual", manualFetcher.FetchAssetManual)
+			}
+
+			// Data the import plugin returned for the asset
+			r.Get("/{id}/source", h.GetAssetSource)
+
+			// Product data enrichment from enrichment plugins
+			r.With(pluginQuota).Post("/{id}/enrich", pluginHandler.EnrichAsset)
 
 			// Main image
 			r.Put("/{id}/main-image/{attachmentId}", h.SetMainAttachment)
 			r.Delete("/{id}/main-image", h.ClearMainAttachment)
+
+			// Power consumption (nested under asset)
+			r.Get("/{id}/power", h.GetPowerUsage)
+			r.Put("/{id}/power", h.UpdatePowerUsage)
+			r.Delete("/{id}/power", h.DeletePowerUsage)
+
+			// Valuations (nested under asset)
+			r.Get("/{id}/values", h.ListAssetValues)
+			r.Post("/{id}/values", h.CreateAssetValue)
+			r.Delete("/{id}/values/{valueId}", h.DeleteAssetValue)
+
+			// Depreciation (nested under asset)
+			r.Get("/{id}/depreciation", h.GetAssetDepreciation)
+			r.Put("/{id}/depreciation", h.UpdateAssetDepreciation)
+			r.Delete("/{id}/depreciation", h.DeleteAssetDepreciation)
+
+			// Consumption schedule (nested under asset)
+			r.Get("/{id}/consumption", h.GetConsumption)
+			r.Put("/{id}/consumption", h.UpdateConsumption)
+			r.Delete("/{id}/consumption", h.DeleteConsumption)
+
+			// Recurring costs (nested under asset)
+			r.Get("/{id}/costs", h.ListAssetRecurringCosts)
+			r.Post("/{id}/costs", h.CreateRecurringCost)
+
+			// Maintenance tasks and log (nested under asset)
+			r.Get("/{id}/maintenance", h.ListAssetMaintenance)
+			r.Post("/{id}/maintenance", h.CreateMaintenanceTask)
+			r.Get("/{id}/maintenance/log", h.ListMaintenanceLog)
+			r.Post("/{id}/maintenance/log", h.LogMaintenance)
+
+			// Reservations (nested under asset)
+			r.Get("/{id}/reservations", h.ListAssetReservations)
+			r.Post("/{id}/reservations", h.CreateReservation)
+			r.Get("/{id}/availability", h.GetAssetAvailability)
+
+			// Loans (nested under asset)
+			r.Get("/{id}/loans", h.ListAssetLoans)
+			r.Post("/{id}/loans", h.CheckOutAsset)
+
+			// Comments (nested under asset)
+			r.Get("/{id}/comments", h.ListAssetComments)
+			r.Post("/{id}/comments", h.CreateAssetComment)
+			r.Delete("/{id}/comments/{commentId}", h.DeleteAssetComment)
+		})
+
+		// Owners (household members assets can belong to)
+		r.Route("/owners", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Get("/", h.ListOwners)
+			r.Get("/{id}/handover", h.ExportOwnerHandover)
+		})
+
+		// Reports
+		r.With(assetAccess).Get("/reports/insurance", h.GetInsuranceReport)
+		r.With(assetAccess).Get("/reports/energy", h.GetEnergyReport)
+
+		// Scheduled report delivery
+		r.Route("/reports/schedules", func(r chi.Router) {
+			r.Use(requireSettings)
+			r.Use(h.ScopeTo(repository.ResourceReport))
+			r.Get("/", h.ListReportSchedules)
+			r.Post("/", h.CreateReportSchedule)
+			r.Get("/{id}", h.GetReportSchedule)
+			r.Put("/{id}", h.UpdateReportSchedule)
+			r.Delete("/{id}", h.DeleteReportSchedule)
+			r.Get("/{id}/runs", h.ListReportRuns)
+			r.Post("/{id}/run", h.RunReportSchedule)
+		})
+
+		// Recurring costs (by cost ID)
+		r.Route("/costs", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceCost))
+			r.Get("/", h.ListRecurringCosts)
+			r.Put("/{id}", h.UpdateRecurringCost)
+			r.Delete("/{id}", h.DeleteRecurringCost)
+		})
+
+		// Reservation calendar and operations (by reservation ID)
+		r.Route("/reservations", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceReservation))
+			r.Get("/", h.ListReservations)
+			r.Put("/{id}", h.UpdateReservation)
+			r.Delete("/{id}", h.DeleteReservation)
+		})
+
+		// Maintenance tasks (by task ID)
+		r.Route("/maintenance", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceMaintenance))
+			r.Get("/", h.ListMaintenanceTasks)
+			r.Get("/upcoming", h.ListUpcomingMaintenance)
+			r.Put("/{id}", h.UpdateMaintenanceTask)
+			r.Delete("/{id}", h.DeleteMaintenanceTask)
+			r.Post("/{id}/complete", h.CompleteMaintenanceTask)
+		})
+
+		// Reminders, about assets or standalone
+		r.Route("/reminders", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceReminder))
+			r.Get("/", h.ListReminders)
+			r.Post("/", h.CreateReminder)
+			r.Get("/{id}", h.GetReminder)
+			r.Put("/{id}", h.UpdateReminder)
+			r.Delete("/{id}", h.DeleteReminder)
+		})
+
+		// Purchases, from the wishlist to the order to the asset they become
+		r.Route("/purchases", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourcePurchase))
+			r.Get("/", h.ListPurchases)
+			r.Post("/", h.CreatePurchase)
+			r.Get("/{id}", h.GetPurchase)
+			r.Put("/{id}", h.UpdatePurchase)
+			r.Delete("/{id}", h.DeletePurchase)
+			r.Post("/{id}/order", h.OrderPurchase)
+			r.Post("/{id}/receive", h.ReceivePurchase)
+		})
+
+		// Purchase groups: assets bought together for one price
+		r.Route("/purchase-groups", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceBundle))
+			r.Get("/", h.ListPurchaseGroups)
+			r.Post("/", h.CreatePurchaseGroup)
+			r.Get("/{id}", h.GetPurchaseGroup)
+			r.Delete("/{id}", h.DeletePurchaseGroup)
+		})
+
+		// Currently loaned assets and operations (by loan ID)
+		r.Route("/loans", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceLoan))
+			r.Get("/", h.ListLoans)
+			r.Put("/{id}", h.UpdateLoan)
+			r.Post("/{id}/return", h.ReturnLoan)
+		})
+
+		// Assets by scanned QR code or label
+		r.With(assetAccess).Get("/resolve", h.ResolveCode)
+
+		// Consumables below their low-stock threshold
+		r.With(assetAccess).Get("/shopping-list", h.GetShoppingList)
+
+		// Printable asset labels with QR codes
+		r.Route("/labels", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Get("/presets", h.ListLabelPresets)
+			r.Route("/batch", func(r chi.Router) {
+				r.Use(h.ScopeTo(repository.ResourceLabelBatch))
+				r.Post("/", h.CreateLabelBatch)
+				r.Get("/{id}", h.GetLabelBatch)
+				r.Get("/{id}/pdf", h.DownloadLabelBatch)
+			})
+		})
+
+		// Contacts outside the organization that assets are lent to
+		r.Route("/contacts", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceContact))
+			r.Get("/", h.ListContacts)
+			r.Post("/", h.CreateContact)
+			r.Get("/{id}", h.GetContact)
+			r.Put("/{id}", h.UpdateContact)
+			r.Delete("/{id}", h.DeleteContact)
+			r.Get("/{id}/loans", h.ListContactLoans)
 		})
 
 		// Attachment operations (by attachment ID)
 		r.Route("/attachments", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceAttachment))
 			r.Get("/{attachmentId}", h.GetAttachment)
+			r.Get("/{attachmentId}/thumbnail", h.GetAttachmentThumbnail)
 			r.Delete("/{attachmentId}", h.DeleteAttachment)
+			r.With(requireSettings).Get("/{attachmentId}/downloads", h.ListAttachmentDownloads)
+		})
+
+		// Short links to views of the app with their filter state
+		r.Route("/links", func(r chi.Router) {
+			r.Post("/", h.CreateShortLink)
+			r.Get("/{code}", h.GetShortLink)
+		})
+
+		// Asset lists (static collections)
+		r.Route("/lists", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Use(h.ScopeTo(repository.ResourceList))
+			r.Get("/", h.ListAssetLists)
+			r.Post("/", h.CreateAssetList)
+			r.Get("/{id}", h.GetAssetList)
+			r.Put("/{id}", h.UpdateAssetList)
+			r.Delete("/{id}", h.DeleteAssetList)
+			r.Get("/{id}/print", h.PrintAssetList)
+			r.Post("/{id}/assets", h.AddAssetListAssets)
+			r.Delete("/{id}/assets/{assetId}", h.RemoveAssetListAsset)
+			r.Post("/{id}/share", h.ShareAssetList)
+			r.Delete("/{id}/share", h.UnshareAssetList)
 		})
 
 		// Warranties overview
-		r.Get("/warranties", h.ListWarranties)
-		r.Get("/warranties/expiring", h.ListExpiringWarranties)
+		r.With(assetAccess).Get("/warranties", h.ListWarranties)
+		r.With(assetAccess).Get("/warranties/expiring", h.ListExpiringWarranties)
+
+		// Import log
+		r.Route("/imports", func(r chi.Router) {
+			r.Use(assetAccess)
+			r.Get("/", pluginHandler.ListImports)
+			r.Get("/{id}", pluginHandler.GetImport)
+			r.With(pluginQuota).Post("/{id}/rerun", pluginHandler.RerunImport)
+		})
 
 		// Import Plugins
 		r.Route("/plugins", func(r chi.Router) {
+			r.Use(assetAccess)
 			r.Get("/", pluginHandler.ListPlugins)
+			r.Get("/enrichers", pluginHandler.ListEnrichers)
 			r.Get("/{pluginId}", pluginHandler.GetPlugin)
-			r.Get("/{pluginId}/search", pluginHandler.Search)
-			r.Post("/{pluginId}/import", pluginHandler.Import)
+			r.With(requireSettings).Put("/{pluginId}/enabled", pluginHandler.SetPluginEnabled)
+
+			r.Group(func(r chi.Router) {
+				r.Use(pluginQuota)
+				r.Get("/{pluginId}/search", pluginHandler.Search)
+				r.Post("/{pluginId}/import", pluginHandler.Import)
+			})
 		})
 	})
 
 	// Serve embedded frontend for all non-API routes
 	r.Handle("/*", spaHandler())
 
-	srv := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      r,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 60 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
-
-	// Graceful shutdown
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-
-	go func() {
-		slog.Info("starting server", "port", cfg.Port, "version", Version)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			slog.Error("server error", "error", err)
-			os.Exit(1)
-		}
-	}()
-
-	<-done
-	slog.Info("shutting down server")
-
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	return r, nil
+}
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
-		slog.Error("server shutdown error", "error", err)
-		os.Exit(1)
+// newPluginRegistry registers the built-in import and enrichment plugins
+func newPluginRegistry() *plugin.Registry {
+	registry := plugin.NewRegistry()
+	if err := registry.Register(googlebooks.New()); err != nil {
+		slog.Error("failed to register Google Books plugin", "error", err)
 	}
+	if err := registry.Register(tmdb.NewMoviesPlugin()); err != nil {
+		slog.Error("failed to register TMDB Movies plugin", "error", err)
+	}
+	if err := registry.Register(tmdb.NewSeriesPlugin()); err != nil {
+		slog.Error("failed to register TMDB Series plugin", "error", err)
+	}
+	if err := registry.Register(bgg.New()); err != nil {
+		slog.Error("failed to register BGG plugin", "error", err)
+	}
+	if err := registry.RegisterEnricher(icecat.New()); err != nil {
+		slog.Error("failed to register Icecat plugin", "error", err)
+	}
+	if err := registry.RegisterEnricher(upcitemdb.New()); err != nil {
+		slog.Error("failed to register UPCitemdb plugin", "error", err)
+	}
+	return registry
+}
 
-	slog.Info("server stopped")
+// rateLimitKey keys rate limits by authenticated user, falling back to client IP
+func rateLimitKey(r *http.Request) string {
+	if claims := auth.GetClaims(r.Context()); claims != nil && claims.Subject != "" {
+		return "user:" + claims.Subject
+	}
+	return "ip:" + ratelimit.ClientIP(r)
 }
 
 // bootstrapAdmin creates the initial admin user if no users exist
@@ -443,12 +1317,14 @@ func bootstrapAdmin(ctx context.Context, userRepo *repository.UserRepository, cf
 		return fmt.Errorf("hashing admin password: %w", err)
 	}
 
-	// Create admin user
+	// Create admin user (configured by the operator, so considered verified)
+	now := time.Now()
 	admin := &domain.User{
 		OrganizationID: defaultOrgID,
 		Email:          cfg.AdminEmail,
 		PasswordHash:   &hash,
 		Role:           domain.UserRoleAdmin,
+		VerifiedAt:     &now,
 	}
 	displayName := "Administrator"
 	admin.DisplayName = &displayName
@@ -467,6 +1343,112 @@ func bootstrapAdmin(ctx context.Context, userRepo *repository.UserRepository, cf
 	return nil
 }
 
+// applyOrganizationOIDC overrides the OIDC environment configuration with the
+// organization's enabled provider, if any
+func applyOrganizationOIDC(ctx context.Context, cfg *config.Config, settings *repository.SettingsRepository, box *secrets.Box, orgID uuid.UUID) error {
+	var provider domain.OIDCProvider
+	found, err := settings.Get(ctx, orgID, domain.SettingOIDC, &provider)
+	if err != nil || !found || !provider.Enabled {
+		return err
+	}
+
+	clientSecret := ""
+	if provider.ClientSecretEncrypted != "" {
+		clientSecret, err = box.Decrypt(provider.ClientSecretEncrypted)
+		if err != nil {
+			return fmt.Errorf("decrypting client secret (was ATTIC_ENCRYPTION_KEY changed?): %w", err)
+		}
+	}
+
+	cfg.OIDCEnabled = true
+	cfg.OIDCIssuer = provider.IssuerURL
+	cfg.OIDCClientID = provider.ClientID
+	cfg.OIDCClientSecret = clientSecret
+	slog.Info("using organization OIDC provider", "issuer", provider.IssuerURL)
+	return nil
+}
+
+// newFileStorage returns the S3 storage when S3 is configured, else local
+// storage; nil (with a warning) when it cannot be initialized
+func newFileStorage(ctx context.Context, cfg *config.Config, linkBuilder *links.Builder) storage.FileStorage {
+	layout, _ := storage.ParseLayout(cfg.StorageLayout) // Validated by config.Load
+	if cfg.UseS3Storage() {
+		s3Client, err := storage.NewS3Client(ctx, storage.S3Config{
+			Endpoint:     cfg.S3Endpoint,
+			Region:       cfg.S3Region,
+			Bucket:       cfg.S3Bucket,
+			AccessKey:    cfg.S3AccessKey,
+			SecretKey:    cfg.S3SecretKey,
+			Layout:       layout,
+			ArchiveClass: cfg.StorageArchiveClass,
+		})
+		if err != nil {
+			slog.Warn("failed to connect to S3, attachments will be disabled", "error", err)
+			return nil
+		}
+		slog.Info("using S3 storage", "bucket", cfg.S3Bucket, "layout", layout)
+		return s3Client
+	}
+
+	localStorage, err := storage.NewLocalStorage(storage.LocalConfig{
+		BasePath:   cfg.LocalStoragePath,
+		BaseURL:    linkBuilder.URL("/files"),
+		PUID:       cfg.PUID,
+		PGID:       cfg.PGID,
+		Layout:     layout,
+		SigningKey: []byte(cfg.SessionSecret),
+	})
+	if err != nil {
+		slog.Warn("failed to initialize local storage, attachments will be disabled", "error", err)
+		return nil
+	}
+	if cfg.HasFileOwnership() {
+		slog.Info("using local file storage", "path", cfg.LocalStoragePath, "layout", layout, "puid", *cfg.PUID, "pgid", *cfg.PGID)
+	} else {
+		slog.Info("using local file storage", "path", cfg.LocalStoragePath, "layout", layout)
+	}
+	return localStorage
+}
+
+// oidcProviderConfigs lists the default OIDC provider (when configured)
+// followed by the additional ones
+func oidcProviderConfigs(cfg *config.Config) []auth.ProviderConfig {
+	var providers []auth.ProviderConfig
+	if cfg.OIDCIssuer != "" {
+		providers = append(providers, auth.ProviderConfig{
+			ID:           auth.DefaultProviderID,
+			Name:         cfg.OIDCName,
+			IssuerURL:    cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+		})
+	}
+	for _, p := range cfg.OIDCProviders {
+		providers = append(providers, auth.ProviderConfig{
+			ID:           p.ID,
+			Name:         p.Name,
+			IssuerURL:    p.IssuerURL,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+		})
+	}
+	return providers
+}
+
+// passwordPolicy builds the password requirements from the configuration
+func passwordPolicy(cfg *config.Config) auth.PasswordPolicy {
+	return auth.PasswordPolicy{
+		MinLength:     cfg.PasswordMinLength,
+		MaxLength:     cfg.PasswordMaxLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+		BanCommon:     cfg.PasswordBanCommon,
+		HistorySize:   cfg.PasswordHistorySize,
+	}
+}
+
 // handlePasswordReset handles the CLI password reset command
 func handlePasswordReset(ctx context.Context, db *database.DB, cfg *config.Config, email, newPassword string) {
 	if email == "" {
@@ -478,7 +1460,7 @@ func handlePasswordReset(ctx context.Context, db *database.DB, cfg *config.Confi
 		os.Exit(1)
 	}
 
-	if err := auth.ValidatePassword(newPassword, cfg.PasswordMinLength); err != nil {
+	if err := passwordPolicy(cfg).Validate(newPassword); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %s\n", err)
 		os.Exit(1)
 	}
@@ -508,27 +1490,3 @@ func handlePasswordReset(ctx context.Context, db *database.DB, cfg *config.Confi
 
 	fmt.Printf("Password updated successfully for user '%s'\n", email)
 }
-
-const swaggerUIHTML = `<!DOCTYPE html>
-<html lang="en">
-<head>
-  <meta charset="UTF-8">
-  <meta name="viewport" content="width=device-width, initial-scale=1.0">
-  <title>Attic API Documentation</title>
-  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.11.0/swagger-ui.css">
-</head>
-<body>
-  <div id="swagger-ui"></div>
-  <script src="https://unpkg.com/swagger-ui-dist@5.11.0/swagger-ui-bundle.js"></script>
-  <script>
-    window.onload = function() {
-      SwaggerUIBundle({
-        url: "/api/openapi.yaml",
-        dom_id: '#swagger-ui',
-        presets: [SwaggerUIBundle.presets.apis, SwaggerUIBundle.SwaggerUIStandalonePreset],
-        layout: "BaseLayout"
-      });
-    };
-  </script>
-</body>
-</html>`
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/lmmendes/attic/internal/config"
+	"github.com/lmmendes/attic/internal/handler"
+	"github.com/lmmendes/attic/internal/links"
+	"github.com/lmmendes/attic/internal/repository"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+// runStorageRelayout implements "attic storage relayout": it moves the files
+// of existing attachments to the keys of ATTIC_STORAGE_LAYOUT, returning the
+// exit code
+func runStorageRelayout(ctx context.Context) int {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		return 1
+	}
+	linkBuilder, err := links.New(cfg.BaseURL, cfg.AlternateHosts)
+	if err != nil {
+		slog.Error("invalid link configuration", "error", err)
+		return 1
+	}
+
+	db, closeDB, err := openDatabase(ctx, cfg)
+	if err != nil {
+		slog.Error("failed to connect to database", "error", err)
+		return 1
+	}
+	defer closeDB()
+
+	fileStorage := newFileStorage(ctx, cfg, linkBuilder)
+	if fileStorage == nil {
+		return 1
+	}
+
+	org, err := repository.NewOrganizationRepository(db.Pool).GetDefault(ctx)
+	if err != nil || org == nil {
+		slog.Error("failed to get default organization - ensure migrations have been run", "error", err)
+		return 1
+	}
+
+	repos := &handler.Repositories{Attachments: repository.NewAttachmentRepository(db.Pool)}
+	layout, _ := storage.ParseLayout(cfg.StorageLayout) // Validated by config.Load
+	moved, failed, err := handler.New(db, repos, fileStorage, org.ID).RelayoutStorage(ctx, layout)
+	if err != nil {
+		slog.Error("failed to relayout storage", "moved", moved, "error", err)
+		return 1
+	}
+	slog.Info("storage relayout finished", "layout", layout, "moved", moved, "failed", failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
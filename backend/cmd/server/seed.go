@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/config"
+	"github.com/lmmendes/attic/internal/links"
+	"github.com/lmmendes/attic/internal/repository"
+	"github.com/lmmendes/attic/internal/seed"
+	"github.com/lmmendes/attic/migrations"
+)
+
+// runSeed implements "attic seed": it adds generated assets to an
+// organization for load testing, returning the exit code
+func runSeed(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	assets := flags.Int("assets", 1000, "Number of assets to generate")
+	photos := flags.Bool("photos", false, "Upload a generated main photo per asset")
+	orgFlag := flags.String("org", "", "ID of the organization to seed (default: the default organization)")
+	randomSeed := flags.Int64("seed", time.Now().UnixNano(), "Random seed; the same seed generates the same data")
+	workers := flags.Int("workers", 8, "Parallel photo uploads")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *assets < 0 || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: attic seed [--assets N] [--photos] [--org ID] [--seed N] [--workers N]")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		return 1
+	}
+	db, closeDB, err := openDatabase(ctx, cfg)
+	if err != nil {
+		slog.Error("failed to connect to database", "error", err)
+		return 1
+	}
+	defer closeDB()
+	if err := db.Migrate(ctx, migrations.FS); err != nil {
+		slog.Error("failed to run migrations", "error", err)
+		return 1
+	}
+
+	orgID, err := targetOrganization(ctx, repository.NewOrganizationRepository(db.Pool), *orgFlag)
+	if err != nil {
+		slog.Error("failed to get organization", "org", *orgFlag, "error", err)
+		return 1
+	}
+
+	var fileStorage seed.Storage
+	if *photos {
+		linkBuilder, err := links.New(cfg.BaseURL, cfg.AlternateHosts)
+		if err != nil {
+			slog.Error("invalid link configuration", "error", err)
+			return 1
+		}
+		s := newFileStorage(ctx, cfg, linkBuilder)
+		if s == nil {
+			return 1
+		}
+		fileStorage = s
+	}
+
+	started := time.Now()
+	result, err := seed.New(db.Pool, fileStorage).Run(ctx, orgID, seed.Options{
+		Assets:  *assets,
+		Photos:  *photos,
+		Seed:    *randomSeed,
+		Workers: *workers,
+	})
+	if err != nil {
+		slog.Error("failed to seed", "error", err)
+		return 1
+	}
+	slog.Info("seed finished", "organization_id", orgID, "seed", *randomSeed,
+		"categories", result.Categories, "locations", result.Locations, "assets", result.Assets, "photos", result.Photos,
+		"duration", time.Since(started).Round(time.Millisecond))
+	return 0
+}
+
+// targetOrganization returns the organization of an --org flag: the one with
+// the ID, or the default organization if empty
+func targetOrganization(ctx context.Context, orgs *repository.OrganizationRepository, id string) (uuid.UUID, error) {
+	if id == "" {
+		org, err := orgs.GetDefault(ctx)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if org == nil {
+			return uuid.Nil, errors.New("no default organization")
+		}
+		return org.ID, nil
+	}
+	orgID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid organization ID")
+	}
+	org, err := orgs.GetByID(ctx, orgID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if org == nil {
+		return uuid.Nil, errors.New("organization not found")
+	}
+	return org.ID, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/config"
+	"github.com/lmmendes/attic/internal/links"
+	"github.com/lmmendes/attic/internal/portable"
+	"github.com/lmmendes/attic/internal/repository"
+	"github.com/lmmendes/attic/migrations"
+)
+
+// runTransfer implements "attic transfer": it pulls the open export of an
+// organization from another Attic instance through its API and merges it into
+// an organization here, returning the exit code
+func runTransfer(ctx context.Context, args []string) int {
+	flags := flag.NewFlagSet("transfer", flag.ContinueOnError)
+	from := flags.String("from", "", "URL of the Attic instance to transfer from, e.g. https://old.example.com")
+	token := flags.String("token", os.Getenv("ATTIC_TRANSFER_TOKEN"), "API token of an admin there (default: $ATTIC_TRANSFER_TOKEN)")
+	fromOrg := flags.String("from-org", "", "ID of the organization to transfer there (default: the token user's current one)")
+	orgFlag := flags.String("org", "", "ID of the organization to merge into (default: the default organization)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *from == "" || *token == "" || flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: attic transfer --from URL --token TOKEN [--from-org ID] [--org ID]")
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("failed to load config", "error", err)
+		return 1
+	}
+	db, closeDB, err := openDatabase(ctx, cfg)
+	if err != nil {
+		slog.Error("failed to connect to database", "error", err)
+		return 1
+	}
+	defer closeDB()
+	if err := db.Migrate(ctx, migrations.FS); err != nil {
+		slog.Error("failed to run migrations", "error", err)
+		return 1
+	}
+
+	orgs := repository.NewOrganizationRepository(db.Pool)
+	orgID, err := targetOrganization(ctx, orgs, *orgFlag)
+	if err != nil {
+		slog.Error("failed to get organization", "org", *orgFlag, "error", err)
+		return 1
+	}
+
+	started := time.Now()
+	slog.Info("downloading export", "from", *from)
+	archiveFile, size, err := downloadExport(ctx, *from, *token, *fromOrg)
+	if err != nil {
+		slog.Error("failed to download export", "from", *from, "error", err)
+		return 1
+	}
+	defer os.Remove(archiveFile.Name())
+	defer archiveFile.Close()
+
+	archive, err := portable.Read(archiveFile, size)
+	if err != nil {
+		slog.Error("invalid export", "from", *from, "error", err)
+		return 1
+	}
+	export := archive.Export
+
+	// Assets get IDs derived from their IDs there, so those of an earlier
+	// transfer are recognized and skipped; deleted ones stay deleted
+	export.DeriveAssetIDs(orgID)
+	ids := make([]uuid.UUID, len(export.Assets))
+	for i, a := range export.Assets {
+		ids[i] = a.ID
+	}
+	existing, err := orgs.ExistingAssetIDs(ctx, ids)
+	if err != nil {
+		slog.Error("failed to check transferred assets", "error", err)
+		return 1
+	}
+	export.DropAssets(existing)
+
+	var keys map[uuid.UUID]string
+	var fileStorage portable.FileStore
+	if len(export.Files) > 0 {
+		linkBuilder, err := links.New(cfg.BaseURL, cfg.AlternateHosts)
+		if err != nil {
+			slog.Error("invalid link configuration", "error", err)
+			return 1
+		}
+		s := newFileStorage(ctx, cfg, linkBuilder)
+		if s == nil {
+			return 1
+		}
+		fileStorage = s
+		if keys, err = archive.UploadFiles(ctx, fileStorage, orgID); err != nil {
+			slog.Error("failed to store files", "error", err)
+			return 1
+		}
+	}
+
+	result, err := orgs.ImportPortable(ctx, orgID, export, keys)
+	if err != nil {
+		if fileStorage != nil {
+			portable.DeleteFiles(ctx, fileStorage, keys)
+		}
+		slog.Error("failed to merge export", "organization_id", orgID, "error", err)
+		return 1
+	}
+
+	for _, email := range result.UnmatchedUsers {
+		slog.Warn("user is not a member here, their assets and files were transferred without owner", "email", email)
+	}
+	slog.Info("transfer finished", "from", *from, "organization_id", orgID,
+		"assets", result.Assets, "skipped_assets", len(existing), "files", result.Files,
+		"categories_created", result.Categories.Created, "categories_matched", result.Categories.Matched,
+		"locations_created", result.Locations.Created, "locations_matched", result.Locations.Matched,
+		"conditions_created", result.Conditions.Created, "conditions_matched", result.Conditions.Matched,
+		"attributes_created", result.Attributes.Created, "attributes_matched", result.Attributes.Matched,
+		"tags_created", result.Tags.Created, "tags_matched", result.Tags.Matched,
+		"duration", time.Since(started).Round(time.Millisecond))
+	return 0
+}
+
+// downloadExport saves the open export of the instance at baseURL to a
+// temporary file, which the caller removes
+func downloadExport(ctx context.Context, baseURL, token, orgID string) (*os.File, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/api/organization/export", nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if orgID != "" {
+		req.Header.Set(auth.OrganizationHeader, orgID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// The archive is spooled to disk, zip needs random access
+	f, err := os.CreateTemp("", "attic-transfer-*.zip")
+	if err != nil {
+		return nil, 0, err
+	}
+	size, err := io.Copy(f, resp.Body)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, 0, errors.Join(errors.New("download interrupted"), err)
+	}
+	return f, size, nil
+}
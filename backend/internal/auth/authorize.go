@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// RoleStore looks up the custom roles of an organization
+type RoleStore interface {
+	GetByName(ctx context.Context, orgID uuid.UUID, name domain.UserRole) (*domain.Role, error)
+}
+
+// Authorizer enforces the permissions of the authenticated user's role
+type Authorizer struct {
+	roles RoleStore
+	orgID uuid.UUID
+}
+
+// NewAuthorizer creates an authorizer resolving custom roles of the request's
+// organization, or else of orgID
+func NewAuthorizer(roles RoleStore, orgID uuid.UUID) *Authorizer {
+	return &Authorizer{roles: roles, orgID: orgID}
+}
+
+// CurrentRole returns the role of the authenticated user in the request's
+// organization ("" if unknown)
+func CurrentRole(ctx context.Context) domain.UserRole {
+	if role := organizationRole(ctx); role != "" {
+		return role
+	}
+	// Domain user from context first (used by OIDC via UserProvisioner)
+	if user := GetUser(ctx); user != nil {
+		return user.Role
+	}
+	if claims := GetClaims(ctx); claims != nil {
+		return claims.Role
+	}
+	return ""
+}
+
+// Role resolves a built-in or custom role; nil if it does not exist
+func (a *Authorizer) Role(ctx context.Context, name domain.UserRole) (*domain.Role, error) {
+	if role, ok := domain.BuiltinRole(name); ok {
+		return role, nil
+	}
+	if name == "" || a.roles == nil {
+		return nil, nil
+	}
+	orgID := OrganizationID(ctx)
+	if orgID == uuid.Nil {
+		orgID = a.orgID
+	}
+	return a.roles.GetByName(ctx, orgID, name)
+}
+
+// Permissions returns the permissions of a role (none for unknown roles)
+func (a *Authorizer) Permissions(ctx context.Context, name domain.UserRole) ([]domain.Permission, error) {
+	role, err := a.Role(ctx, name)
+	if err != nil || role == nil {
+		return []domain.Permission{}, err
+	}
+	return role.Permissions, nil
+}
+
+// Require returns middleware that only lets through users whose role grants
+// all the given permissions (and, for service tokens, whose scopes do)
+func (a *Authorizer) Require(perms ...domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if a.authorize(w, r, perms) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RequireByMethod returns middleware requiring read for safe methods (GET,
+// HEAD, OPTIONS) and write for all others
+func (a *Authorizer) RequireByMethod(read, write domain.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			perm := write
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				perm = read
+			}
+			if a.authorize(w, r, []domain.Permission{perm}) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// authorize checks the permissions, writing an error response if denied
+func (a *Authorizer) authorize(w http.ResponseWriter, r *http.Request, perms []domain.Permission) bool {
+	name := CurrentRole(r.Context())
+	if name == "" {
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return false
+	}
+
+	role, err := a.Role(r.Context(), name)
+	if err != nil {
+		slog.Error("failed to resolve role", "role", name, "error", err)
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return false
+	}
+	for _, perm := range perms {
+		if role == nil || !role.Has(perm) {
+			http.Error(w, `{"error":"permission required: `+string(perm)+`"}`, http.StatusForbidden)
+			return false
+		}
+		if !hasScope(r.Context(), perm) {
+			http.Error(w, `{"error":"token scope required: `+string(perm)+`"}`, http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
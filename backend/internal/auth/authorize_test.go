@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type testRoleStore map[domain.UserRole]*domain.Role
+
+func (s testRoleStore) GetByName(_ context.Context, _ uuid.UUID, name domain.UserRole) (*domain.Role, error) {
+	return s[name], nil
+}
+
+func newTestAuthorizer() *Authorizer {
+	return NewAuthorizer(testRoleStore{
+		"viewer": {Name: "viewer", Permissions: []domain.Permission{domain.PermissionAssetsRead}},
+	}, uuid.New())
+}
+
+func serveWithRole(role domain.UserRole, method string, mw func(http.Handler) http.Handler) (int, bool) {
+	nextCalled := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nextCalled = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(method, "/", nil)
+	if role != "" {
+		req = req.WithContext(context.WithValue(req.Context(), UserContextKey, &Claims{Subject: "u1", Role: role}))
+	}
+	rec := httptest.NewRecorder()
+	mw(next).ServeHTTP(rec, req)
+	return rec.Code, nextCalled
+}
+
+func Test_Authorizer_Require_Unauthenticated_ReturnsUnauthorized(t *testing.T) {
+	code, called := serveWithRole("", http.MethodGet, newTestAuthorizer().Require(domain.PermissionSettingsManage))
+	if called || code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without calling next, got %d (called %v)", code, called)
+	}
+}
+
+func Test_Authorizer_Require(t *testing.T) {
+	a := newTestAuthorizer()
+	tests := []struct {
+		role domain.UserRole
+		perm domain.Permission
+		want int
+	}{
+		{domain.UserRoleAdmin, domain.PermissionSettingsManage, http.StatusOK},
+		{domain.UserRoleAdmin, domain.PermissionUsersManage, http.StatusOK},
+		{domain.UserRoleUser, domain.PermissionSettingsManage, http.StatusForbidden},
+		{domain.UserRoleUser, domain.PermissionAssetsWrite, http.StatusOK},
+		{"viewer", domain.PermissionAssetsRead, http.StatusOK},
+		{"viewer", domain.PermissionAssetsWrite, http.StatusForbidden},
+		{"deleted", domain.PermissionAssetsRead, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.role)+"/"+string(tt.perm), func(t *testing.T) {
+			code, called := serveWithRole(tt.role, http.MethodGet, a.Require(tt.perm))
+			if code != tt.want || called != (tt.want == http.StatusOK) {
+				t.Errorf("expected %d, got %d (called %v)", tt.want, code, called)
+			}
+		})
+	}
+}
+
+func Test_Authorizer_RequireByMethod(t *testing.T) {
+	mw := newTestAuthorizer().RequireByMethod(domain.PermissionAssetsRead, domain.PermissionAssetsWrite)
+
+	if code, _ := serveWithRole("viewer", http.MethodGet, mw); code != http.StatusOK {
+		t.Errorf("expected viewer to read, got %d", code)
+	}
+	if code, _ := serveWithRole("viewer", http.MethodPost, mw); code != http.StatusForbidden {
+		t.Errorf("expected viewer not to write, got %d", code)
+	}
+	if code, _ := serveWithRole(domain.UserRoleUser, http.MethodDelete, mw); code != http.StatusOK {
+		t.Errorf("expected user to write, got %d", code)
+	}
+}
+
+func Test_CurrentRole_PrefersDomainUser(t *testing.T) {
+	ctx := context.WithValue(context.Background(), UserContextKey, &Claims{Role: domain.UserRoleUser})
+	ctx = context.WithValue(ctx, DomainUserContextKey, &domain.User{Role: domain.UserRoleAdmin})
+	if role := CurrentRole(ctx); role != domain.UserRoleAdmin {
+		t.Errorf("expected admin, got %q", role)
+	}
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// challengeTTL is how long a login challenge can be completed
+const challengeTTL = 5 * time.Minute
+
+// emailVerificationTTL is how long an email verification link is valid
+const emailVerificationTTL = 72 * time.Hour
+
+// maxChallengeFailures is how many wrong answers a login challenge takes
+// before it is spent and the login must start over with the password
+const maxChallengeFailures = 5
+
+// ChallengePurpose is the step a login challenge is waiting for
+type ChallengePurpose string
+
+const (
+	ChallengeTwoFactor       ChallengePurpose = "two_factor"       // Enter a TOTP or backup code
+	ChallengeTwoFactorSetup  ChallengePurpose = "two_factor_setup" // Enroll in 2FA before the first login
+	ChallengePasskeyRegister ChallengePurpose = "passkey_register" // WebAuthn challenge of a passkey registration
+	ChallengePasskeyLogin    ChallengePurpose = "passkey_login"    // WebAuthn challenge of a passkey login (no user yet)
+	ChallengeVerifyEmail     ChallengePurpose = "verify_email"     // Link in an email verification message
+)
+
+// ErrInvalidChallenge is returned for forged, expired or mismatched challenges
+var ErrInvalidChallenge = errors.New("invalid or expired login challenge")
+
+type challenge struct {
+	UserID    uuid.UUID        `json:"user_id"`
+	Purpose   ChallengePurpose `json:"purpose"`
+	ExpiresAt time.Time        `json:"expires_at"`
+	Nonce     string           `json:"nonce"`           // Random, so every challenge is unique
+	Email     string           `json:"email,omitempty"` // Address being verified
+}
+
+// CreateChallenge returns a signed token proving that a user passed the
+// password step of a login, to be completed by the given step. Passkey
+// challenges are also used as WebAuthn challenges.
+func (m *SessionManager) CreateChallenge(userID uuid.UUID, purpose ChallengePurpose) (string, error) {
+	return m.createChallenge(challenge{
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(challengeTTL),
+	})
+}
+
+// VerifyChallenge checks a token created by CreateChallenge for purpose and
+// returns the user it was issued to
+func (m *SessionManager) VerifyChallenge(token string, purpose ChallengePurpose) (uuid.UUID, error) {
+	c, err := m.verifyChallenge(token, purpose)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return c.UserID, nil
+}
+
+// CreateEmailVerification returns a signed token for the link that confirms
+// the user owns email
+func (m *SessionManager) CreateEmailVerification(userID uuid.UUID, email string) (string, error) {
+	return m.createChallenge(challenge{
+		UserID:    userID,
+		Purpose:   ChallengeVerifyEmail,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+		Email:     email,
+	})
+}
+
+// VerifyEmailVerification checks a token created by CreateEmailVerification
+// and returns the user and the address it confirms
+func (m *SessionManager) VerifyEmailVerification(token string) (uuid.UUID, string, error) {
+	c, err := m.verifyChallenge(token, ChallengeVerifyEmail)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return c.UserID, c.Email, nil
+}
+
+// FailChallenge records a wrong answer to a challenge; after
+// maxChallengeFailures it no longer verifies
+func (m *SessionManager) FailChallenge(token string) {
+	c, err := m.parseChallenge(token)
+	if err != nil {
+		return
+	}
+	m.challenges.record(c, func(use *challengeUse) bool {
+		use.failures++
+		return true
+	})
+}
+
+// SpendChallenge marks a challenge as used, so that it completes a single
+// login. It reports false if the challenge was already spent.
+func (m *SessionManager) SpendChallenge(token string) bool {
+	c, err := m.parseChallenge(token)
+	if err != nil {
+		return false
+	}
+	return m.challenges.record(c, func(use *challengeUse) bool {
+		if use.spent() {
+			return false
+		}
+		use.failures = maxChallengeFailures
+		return true
+	})
+}
+
+// challengeUse counts the wrong answers to a challenge; spending it counts
+// as maxChallengeFailures
+type challengeUse struct {
+	failures  int
+	expiresAt time.Time
+}
+
+func (u *challengeUse) spent() bool {
+	return u.failures >= maxChallengeFailures
+}
+
+// challengeLog tracks answered challenges until they expire, by nonce
+type challengeLog struct {
+	mu   sync.Mutex
+	uses map[string]*challengeUse
+}
+
+// record applies update to the use of c and returns its result
+func (l *challengeLog) record(c *challenge, update func(*challengeUse) bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for nonce, use := range l.uses {
+		if now.After(use.expiresAt) {
+			delete(l.uses, nonce)
+		}
+	}
+	if l.uses == nil {
+		l.uses = make(map[string]*challengeUse)
+	}
+	use, ok := l.uses[c.Nonce]
+	if !ok {
+		use = &challengeUse{expiresAt: c.ExpiresAt}
+		l.uses[c.Nonce] = use
+	}
+	return update(use)
+}
+
+// spent reports whether the challenge c was spent
+func (l *challengeLog) spent(c *challenge) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	use, ok := l.uses[c.Nonce]
+	return ok && use.spent()
+}
+
+func (m *SessionManager) createChallenge(c challenge) (string, error) {
+	c.Nonce = generateSecureToken(22)
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + m.sign(payload), nil
+}
+
+func (m *SessionManager) verifyChallenge(token string, purpose ChallengePurpose) (*challenge, error) {
+	c, err := m.parseChallenge(token)
+	if err != nil {
+		return nil, err
+	}
+	if c.Purpose != purpose || time.Now().After(c.ExpiresAt) || m.challenges.spent(c) {
+		return nil, ErrInvalidChallenge
+	}
+	return c, nil
+}
+
+// parseChallenge checks the signature of a challenge token and decodes it
+func (m *SessionManager) parseChallenge(token string) (*challenge, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(m.sign(payload))) {
+		return nil, ErrInvalidChallenge
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	var c challenge
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidChallenge
+	}
+	return &c, nil
+}
+
+func (m *SessionManager) sign(payload string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte("challenge:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func Test_SessionManager_VerifyChallenge_Valid(t *testing.T) {
+	m := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	userID := uuid.New()
+
+	token, err := m.CreateChallenge(userID, ChallengeTwoFactor)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := m.VerifyChallenge(token, ChallengeTwoFactor)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != userID {
+		t.Errorf("expected user %s, got %s", userID, got)
+	}
+}
+
+func Test_SessionManager_VerifyChallenge_Rejected(t *testing.T) {
+	m := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	other := NewSessionManager("another-secret-key-32-bytes-long", 24)
+	token, _ := m.CreateChallenge(uuid.New(), ChallengeTwoFactorSetup)
+	foreign, _ := other.CreateChallenge(uuid.New(), ChallengeTwoFactorSetup)
+
+	tests := map[string]struct {
+		token   string
+		purpose ChallengePurpose
+	}{
+		"wrong purpose": {token, ChallengeTwoFactor},
+		"other secret":  {foreign, ChallengeTwoFactorSetup},
+		"tampered":      {"x" + token, ChallengeTwoFactorSetup},
+		"empty":         {"", ChallengeTwoFactorSetup},
+	}
+	for name, tt := range tests {
+		if _, err := m.VerifyChallenge(tt.token, tt.purpose); err != ErrInvalidChallenge {
+			t.Errorf("%s: expected ErrInvalidChallenge, got %v", name, err)
+		}
+	}
+}
+
+func Test_SessionManager_EmailVerification(t *testing.T) {
+	m := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	userID := uuid.New()
+
+	token, err := m.CreateEmailVerification(userID, "alice@example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	gotID, gotEmail, err := m.VerifyEmailVerification(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotID != userID || gotEmail != "alice@example.com" {
+		t.Errorf("expected %s/alice@example.com, got %s/%s", userID, gotID, gotEmail)
+	}
+
+	// Login challenges can't be used as verification links and vice versa
+	login, _ := m.CreateChallenge(userID, ChallengeTwoFactor)
+	if _, _, err := m.VerifyEmailVerification(login); err != ErrInvalidChallenge {
+		t.Errorf("expected ErrInvalidChallenge, got %v", err)
+	}
+	if _, err := m.VerifyChallenge(token, ChallengeTwoFactor); err != ErrInvalidChallenge {
+		t.Errorf("expected ErrInvalidChallenge, got %v", err)
+	}
+}
+
+func Test_SessionManager_FailChallenge(t *testing.T) {
+	m := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	token, _ := m.CreateChallenge(uuid.New(), ChallengeTwoFactor)
+	other, _ := m.CreateChallenge(uuid.New(), ChallengeTwoFactor)
+
+	for range maxChallengeFailures - 1 {
+		m.FailChallenge(token)
+	}
+	if _, err := m.VerifyChallenge(token, ChallengeTwoFactor); err != nil {
+		t.Fatalf("expected the challenge to survive %d failures, got %v", maxChallengeFailures-1, err)
+	}
+	m.FailChallenge(token)
+	if _, err := m.VerifyChallenge(token, ChallengeTwoFactor); err != ErrInvalidChallenge {
+		t.Errorf("expected ErrInvalidChallenge after %d failures, got %v", maxChallengeFailures, err)
+	}
+	if _, err := m.VerifyChallenge(other, ChallengeTwoFactor); err != nil {
+		t.Errorf("expected other challenges to be unaffected, got %v", err)
+	}
+}
+
+func Test_SessionManager_SpendChallenge(t *testing.T) {
+	m := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	token, _ := m.CreateChallenge(uuid.New(), ChallengeTwoFactor)
+
+	if !m.SpendChallenge(token) {
+		t.Fatal("expected the first use to spend the challenge")
+	}
+	if m.SpendChallenge(token) {
+		t.Error("expected a challenge to be spent only once")
+	}
+	if _, err := m.VerifyChallenge(token, ChallengeTwoFactor); err != ErrInvalidChallenge {
+		t.Errorf("expected ErrInvalidChallenge for a spent challenge, got %v", err)
+	}
+	if m.SpendChallenge("x" + token) {
+		t.Error("expected forged challenges not to be spendable")
+	}
+}
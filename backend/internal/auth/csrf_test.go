@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func csrfRequest(method string, claims *Claims, cookie, header string) *http.Request {
+	req := httptest.NewRequest(method, "/api/assets", nil)
+	if claims != nil {
+		req = req.WithContext(context.WithValue(req.Context(), UserContextKey, claims))
+	}
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: cookie})
+	}
+	if header != "" {
+		req.Header.Set(CSRFHeader, header)
+	}
+	return req
+}
+
+func Test_RequireCSRF(t *testing.T) {
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	m := &Middleware{sessionManager: manager}
+	user := &Claims{Subject: "user-1"}
+	token := manager.CSRFToken(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), user.Subject)
+	other := manager.CSRFToken(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), "user-2")
+
+	tests := map[string]struct {
+		req    *http.Request
+		status int
+	}{
+		"safe method":           {csrfRequest(http.MethodGet, user, "", ""), http.StatusOK},
+		"valid token":           {csrfRequest(http.MethodPost, user, token, token), http.StatusOK},
+		"missing token":         {csrfRequest(http.MethodDelete, user, token, ""), http.StatusForbidden},
+		"header without cookie": {csrfRequest(http.MethodPut, user, "", token), http.StatusForbidden},
+		"mismatch":              {csrfRequest(http.MethodPost, user, token, other), http.StatusForbidden},
+		"token of another user": {csrfRequest(http.MethodPost, user, other, other), http.StatusForbidden},
+		"unauthenticated":       {csrfRequest(http.MethodPost, nil, "", ""), http.StatusOK},
+	}
+	bearer := csrfRequest(http.MethodPost, user, "", "")
+	bearer.Header.Set("Authorization", "Bearer token")
+	tests["authorization header"] = struct {
+		req    *http.Request
+		status int
+	}{bearer, http.StatusOK}
+
+	handler := m.RequireCSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for name, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, tt.req)
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d", name, tt.status, rec.Code)
+		}
+	}
+}
+
+func Test_RequireCSRF_SetsCookie(t *testing.T) {
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	m := &Middleware{sessionManager: manager}
+	user := &Claims{Subject: "user-1"}
+	handler := m.RequireCSRF(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, csrfRequest(http.MethodGet, user, "", ""))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CSRFCookieName || cookies[0].HttpOnly {
+		t.Fatalf("expected a readable CSRF cookie, got %+v", cookies)
+	}
+	if err := manager.VerifyCSRF(csrfRequest(http.MethodPost, user, cookies[0].Value, cookies[0].Value), user.Subject); err != nil {
+		t.Errorf("expected the issued token to verify, got %v", err)
+	}
+
+	// A valid cookie is kept
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, csrfRequest(http.MethodGet, user, cookies[0].Value, ""))
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("expected no new cookie for a valid token")
+	}
+}
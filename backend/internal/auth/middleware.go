@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -16,27 +17,51 @@ const (
 	UserContextKey contextKey = "user"
 )
 
+// passwordChangeRoutes are the API routes a session that must change its
+// password can still use
+var passwordChangeRoutes = map[string]string{
+	"/api/auth/password": http.MethodPut,
+	"/api/auth/csrf":     http.MethodGet,
+	"/api/me":            http.MethodGet,
+}
+
 // Claims represents the JWT claims we care about
 type Claims struct {
 	Subject     string `json:"sub"`
 	Email       string `json:"email"`
 	Name        string `json:"name"`
 	DisplayName string `json:"preferred_username"`
+
+	// Role of the local session user (OIDC roles come from the provisioned user)
+	Role domain.UserRole `json:"-"`
+
+	// All claims of a verified OIDC token, for role mapping
+	Raw map[string]any `json:"-"`
+
+	// Permissions a service token is limited to (nil = all of the role)
+	Scopes []domain.Permission `json:"-"`
+}
+
+// tokenVerifier verifies access tokens issued by one OIDC provider
+type tokenVerifier struct {
+	providerID string
+	verifier   *oidc.IDTokenVerifier
 }
 
 // Middleware handles authentication (both OIDC and local)
 type Middleware struct {
-	verifier       *oidc.IDTokenVerifier
+	verifiers      []tokenVerifier // In provider order; the first verifies legacy sessions
 	disabled       bool
 	oidcEnabled    bool
 	oauth          *OAuthHandler
 	sessionManager *SessionManager
+	proxy          *ProxyAuth        // nil = reverse-proxy header authentication disabled
+	serviceTokens  ServiceTokenStore // nil = service accounts disabled
 }
 
 // Config for auth middleware
 type Config struct {
-	IssuerURL   string
-	ClientID    string
+	Providers   []ProviderConfig
 	Disabled    bool // For development without auth
 	OIDCEnabled bool // Whether OIDC is the auth method
 }
@@ -54,19 +79,21 @@ func NewMiddleware(ctx context.Context, cfg Config) (*Middleware, error) {
 
 	// Only initialize OIDC if enabled
 	if cfg.OIDCEnabled {
-		provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
-		if err != nil {
-			return nil, err
-		}
+		for _, pc := range cfg.Providers {
+			provider, err := oidc.NewProvider(ctx, pc.IssuerURL)
+			if err != nil {
+				return nil, err
+			}
 
-		verifier := provider.Verifier(&oidc.Config{
-			ClientID:                   cfg.ClientID,
-			SkipClientIDCheck:          true, // Keycloak access tokens use 'azp' not 'aud'
-			SkipExpiryCheck:            false,
-			SkipIssuerCheck:            false,
-			InsecureSkipSignatureCheck: false,
-		})
-		m.verifier = verifier
+			verifier := provider.Verifier(&oidc.Config{
+				ClientID:                   pc.ClientID,
+				SkipClientIDCheck:          true, // Keycloak access tokens use 'azp' not 'aud'
+				SkipExpiryCheck:            false,
+				SkipIssuerCheck:            false,
+				InsecureSkipSignatureCheck: false,
+			})
+			m.verifiers = append(m.verifiers, tokenVerifier{providerID: pc.ID, verifier: verifier})
+		}
 	}
 
 	return m, nil
@@ -77,6 +104,12 @@ func (m *Middleware) SetOAuthHandler(oauth *OAuthHandler) {
 	m.oauth = oauth
 }
 
+// SetProxyAuth makes the middleware authenticate users by trusted proxy headers
+// instead of OIDC or local sessions
+func (m *Middleware) SetProxyAuth(p *ProxyAuth) {
+	m.proxy = p
+}
+
 // SetSessionManager sets the session manager for local auth
 func (m *Middleware) SetSessionManager(sm *SessionManager) {
 	m.sessionManager = sm
@@ -92,13 +125,18 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 				Email:       "dev@example.com",
 				Name:        "Development User",
 				DisplayName: "devuser",
+				Role:        domain.UserRoleAdmin,
 			}
 			ctx := context.WithValue(r.Context(), UserContextKey, claims)
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
-		if m.oidcEnabled {
+		if token, ok := m.serviceToken(r); ok {
+			m.authenticateServiceToken(w, r, next, token)
+		} else if m.proxy != nil {
+			m.authenticateProxy(w, r, next)
+		} else if m.oidcEnabled {
 			// OIDC authentication
 			m.authenticateOIDC(w, r, next)
 		} else {
@@ -111,6 +149,7 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
 // authenticateOIDC handles OIDC-based authentication
 func (m *Middleware) authenticateOIDC(w http.ResponseWriter, r *http.Request, next http.Handler) {
 	var tokenString string
+	var session *Session
 
 	// First, try Authorization header
 	authHeader := r.Header.Get("Authorization")
@@ -121,9 +160,11 @@ func (m *Middleware) authenticateOIDC(w http.ResponseWriter, r *http.Request, ne
 		}
 	}
 
-	// If no header, try session cookie
+	// If no header, try session cookie (refreshed if about to expire)
 	if tokenString == "" && m.oauth != nil {
-		tokenString = m.oauth.GetAccessToken(r)
+		if session = m.oauth.ActiveSession(w, r); session != nil {
+			tokenString = session.AccessToken
+		}
 	}
 
 	if tokenString == "" {
@@ -132,7 +173,7 @@ func (m *Middleware) authenticateOIDC(w http.ResponseWriter, r *http.Request, ne
 	}
 
 	// Verify the token
-	idToken, err := m.verifier.Verify(r.Context(), tokenString)
+	providerID, idToken, err := m.verifyToken(r.Context(), tokenString, session)
 	if err != nil {
 		slog.Error("token verification failed", "error", err)
 		http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
@@ -146,12 +187,19 @@ func (m *Middleware) authenticateOIDC(w http.ResponseWriter, r *http.Request, ne
 		http.Error(w, `{"error":"invalid token claims"}`, http.StatusUnauthorized)
 		return
 	}
+	if err := idToken.Claims(&claims.Raw); err != nil {
+		slog.Error("failed to parse claims", "error", err)
+		http.Error(w, `{"error":"invalid token claims"}`, http.StatusUnauthorized)
+		return
+	}
 
-	claims.Subject = idToken.Subject
+	claims.Subject = providerSubject(providerID, idToken.Subject)
 
 	// If claims are missing from access token, supplement from session cookie
 	if (claims.Email == "" || claims.DisplayName == "") && m.oauth != nil {
-		session, _ := m.oauth.getSessionFromCookie(r)
+		if session == nil {
+			session, _ = m.oauth.getSessionFromCookie(r)
+		}
 		if session != nil {
 			if claims.Email == "" {
 				claims.Email = session.Email
@@ -167,7 +215,39 @@ func (m *Middleware) authenticateOIDC(w http.ResponseWriter, r *http.Request, ne
 	next.ServeHTTP(w, r.WithContext(ctx))
 }
 
-// authenticateLocal handles local (email/password) authentication
+// verifyToken verifies an access token with the provider of the session, or
+// for bearer tokens (session is nil) with the first provider accepting it
+func (m *Middleware) verifyToken(ctx context.Context, token string, session *Session) (string, *oidc.IDToken, error) {
+	err := errors.New("no OIDC provider configured")
+	for i, v := range m.verifiers {
+		if session != nil && v.providerID != session.Provider && (session.Provider != "" || i > 0) {
+			continue
+		}
+		var idToken *oidc.IDToken
+		if idToken, err = v.verifier.Verify(ctx, token); err == nil {
+			return v.providerID, idToken, nil
+		}
+	}
+	return "", nil, err
+}
+
+// authenticateProxy handles trusted reverse-proxy header authentication
+func (m *Middleware) authenticateProxy(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	claims, err := m.proxy.Claims(r)
+	if err != nil {
+		if errors.Is(err, errUntrustedProxy) {
+			slog.Warn("rejected proxy authentication", "error", err, "remote_addr", peerAddr(r))
+		}
+		http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), UserContextKey, claims)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// authenticateLocal handles local (email/password) authentication with the
+// session cookie or, for API clients, a bearer access token
 func (m *Middleware) authenticateLocal(w http.ResponseWriter, r *http.Request, next http.Handler) {
 	if m.sessionManager == nil {
 		http.Error(w, `{"error":"session manager not configured"}`, http.StatusInternalServerError)
@@ -180,12 +260,23 @@ func (m *Middleware) authenticateLocal(w http.ResponseWriter, r *http.Request, n
 		return
 	}
 
+	if session.MustChangePassword && passwordChangeRoutes[r.URL.Path] != r.Method {
+		http.Error(w, `{"error":"password change required"}`, http.StatusForbidden)
+		return
+	}
+
+	// Rolling renewal keeps active users signed in
+	if _, err := m.sessionManager.RenewSession(w, r, session); err != nil {
+		slog.Warn("failed to renew session", "error", err)
+	}
+
 	// Convert local session to claims for compatibility
 	claims := &Claims{
 		Subject:     session.UserID.String(),
 		Email:       session.Email,
 		Name:        session.Name,
 		DisplayName: session.Name,
+		Role:        session.Role,
 	}
 
 	// Add claims to context
@@ -215,34 +306,3 @@ func (m *Middleware) Optional(next http.Handler) http.Handler {
 		m.Authenticate(next).ServeHTTP(w, r)
 	})
 }
-
-// RequireAdmin middleware checks if the user has admin role
-func RequireAdmin(sessionManager *SessionManager) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Check domain user from context first (used by OIDC via UserProvisioner)
-			if user := GetUser(r.Context()); user != nil {
-				if user.Role != domain.UserRoleAdmin {
-					http.Error(w, `{"error":"admin access required"}`, http.StatusForbidden)
-					return
-				}
-				next.ServeHTTP(w, r)
-				return
-			}
-
-			// Fall back to local session
-			session, err := sessionManager.GetSession(r)
-			if err != nil {
-				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
-				return
-			}
-
-			if session.Role != domain.UserRoleAdmin {
-				http.Error(w, `{"error":"admin access required"}`, http.StatusForbidden)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	}
-}
@@ -281,110 +281,6 @@ func Test_Optional_WithAuthHeader_ValidatesToken(t *testing.T) {
 	}
 }
 
-// Tests for RequireAdmin middleware
-
-func Test_RequireAdmin_NoSession_ReturnsUnauthorized(t *testing.T) {
-	sm := NewSessionManager("test-secret", 24)
-
-	nextCalled := false
-	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		nextCalled = true
-	})
-
-	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
-	rec := httptest.NewRecorder()
-
-	RequireAdmin(sm)(next).ServeHTTP(rec, req)
-
-	if nextCalled {
-		t.Error("expected next handler NOT to be called")
-	}
-	if rec.Code != http.StatusUnauthorized {
-		t.Errorf("expected status 401, got %d", rec.Code)
-	}
-}
-
-func Test_RequireAdmin_NonAdminUser_ReturnsForbidden(t *testing.T) {
-	sm := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
-
-	// Create a non-admin session
-	user := &domain.User{
-		ID:    uuid.New(),
-		Email: "user@example.com",
-		Role:  domain.UserRoleUser,
-	}
-	createReq := httptest.NewRequest(http.MethodPost, "/", nil)
-	createRec := httptest.NewRecorder()
-	sm.CreateSession(createRec, createReq, user)
-
-	var sessionCookie *http.Cookie
-	for _, c := range createRec.Result().Cookies() {
-		if c.Name == "attic_session" {
-			sessionCookie = c
-			break
-		}
-	}
-
-	nextCalled := false
-	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		nextCalled = true
-	})
-
-	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
-	req.AddCookie(sessionCookie)
-	rec := httptest.NewRecorder()
-
-	RequireAdmin(sm)(next).ServeHTTP(rec, req)
-
-	if nextCalled {
-		t.Error("expected next handler NOT to be called for non-admin")
-	}
-	if rec.Code != http.StatusForbidden {
-		t.Errorf("expected status 403, got %d", rec.Code)
-	}
-}
-
-func Test_RequireAdmin_AdminUser_AllowsRequest(t *testing.T) {
-	sm := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
-
-	// Create an admin session
-	user := &domain.User{
-		ID:    uuid.New(),
-		Email: "admin@example.com",
-		Role:  domain.UserRoleAdmin,
-	}
-	createReq := httptest.NewRequest(http.MethodPost, "/", nil)
-	createRec := httptest.NewRecorder()
-	sm.CreateSession(createRec, createReq, user)
-
-	var sessionCookie *http.Cookie
-	for _, c := range createRec.Result().Cookies() {
-		if c.Name == "attic_session" {
-			sessionCookie = c
-			break
-		}
-	}
-
-	nextCalled := false
-	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		nextCalled = true
-		w.WriteHeader(http.StatusOK)
-	})
-
-	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
-	req.AddCookie(sessionCookie)
-	rec := httptest.NewRecorder()
-
-	RequireAdmin(sm)(next).ServeHTTP(rec, req)
-
-	if !nextCalled {
-		t.Error("expected next handler to be called for admin")
-	}
-	if rec.Code != http.StatusOK {
-		t.Errorf("expected status 200, got %d", rec.Code)
-	}
-}
-
 // Tests for SetOAuthHandler and SetSessionManager
 
 func Test_SetOAuthHandler_SetsHandler(t *testing.T) {
@@ -502,3 +398,36 @@ func Test_Middleware_Local_ExpiredSession_ReturnsUnauthorized(t *testing.T) {
 		t.Errorf("expected status 401, got %d", rec.Code)
 	}
 }
+
+func Test_Middleware_Local_MustChangePassword_OnlyAllowsPasswordChange(t *testing.T) {
+	store := newMemorySessionStore()
+	sm := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	sm.SetStore(store)
+	m := &Middleware{sessionManager: sm}
+
+	signedIn := signIn(t, sm, &domain.User{ID: uuid.New(), Email: "test@example.com"})
+	for _, s := range store.sessions {
+		s.MustChangePassword = true
+	}
+	cookie, _ := signedIn.Cookie("attic_session")
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/assets", http.StatusForbidden},
+		{http.MethodPut, "/api/me/time-zone", http.StatusForbidden},
+		{http.MethodGet, "/api/me", http.StatusOK},
+		{http.MethodPut, "/api/auth/password", http.StatusOK},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.AddCookie(cookie)
+		rec := httptest.NewRecorder()
+		m.Authenticate(next).ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.want, rec.Code)
+		}
+	}
+}
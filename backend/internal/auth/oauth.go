@@ -5,12 +5,16 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/lmmendes/attic/internal/secrets"
 	"golang.org/x/oauth2"
 )
 
@@ -27,26 +31,65 @@ type Session struct {
 	IDToken      string    `json:"id_token,omitempty"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	Subject      string    `json:"sub"`
+	SessionID    string    `json:"sid,omitempty"` // Provider session ID (for back-channel logout)
+	IssuedAt     time.Time `json:"iat"`           // Login time
 	Email        string    `json:"email"`
 	Name         string    `json:"name"`
+	Provider     string    `json:"provider,omitempty"` // Empty for sessions created before multiple providers
 }
 
-// OAuthHandler handles OAuth login flow
-type OAuthHandler struct {
+// DefaultProviderID identifies the provider configured with ATTIC_OIDC_ISSUER_URL
+// (or the organization OIDC setting). Its subjects are stored unprefixed.
+const DefaultProviderID = "default"
+
+// ProviderConfig configures one OIDC provider
+type ProviderConfig struct {
+	ID           string // Selects the provider, e.g. /auth/oidc/login?provider=google
+	Name         string // Shown on the login screen
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+}
+
+// ProviderInfo describes an OIDC provider for the login screen
+type ProviderInfo struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	LoginURL string `json:"login_url"`
+}
+
+// oidcProvider is a discovered OIDC provider with its client configuration
+type oidcProvider struct {
+	id                 string
+	name               string
 	provider           *oidc.Provider
 	oauth2Config       oauth2.Config
 	verifier           *oidc.IDTokenVerifier
-	baseURL            string
-	secret             []byte
-	disabled           bool
 	endSessionEndpoint string
 }
 
+// OAuthHandler handles OAuth login flow
+type OAuthHandler struct {
+	providers   []*oidcProvider // The first one is used when no provider is selected
+	baseURL     string
+	secret      []byte
+	disabled    bool
+	loginHook   LoginHook
+	logoutHook  LogoutHook
+	secrets     *secrets.Box    // nil = refresh tokens stored unencrypted
+	revocations RevocationStore // nil = back-channel logout disabled
+}
+
+// LoginHook is called after every OIDC callback. subject and email are empty
+// when the failure happened before the ID token was verified.
+type LoginHook func(r *http.Request, subject, email string, success bool, failureReason string)
+
+// LogoutHook is called when a user signs out of an OIDC session
+type LogoutHook func(r *http.Request, subject, email string)
+
 // OAuthConfig for OAuth handler
 type OAuthConfig struct {
-	IssuerURL     string
-	ClientID      string
-	ClientSecret  string
+	Providers     []ProviderConfig
 	BaseURL       string
 	SessionSecret string
 	Disabled      bool
@@ -55,17 +98,37 @@ type OAuthConfig struct {
 // NewOAuthHandler creates a new OAuth handler
 func NewOAuthHandler(ctx context.Context, cfg OAuthConfig) (*OAuthHandler, error) {
 	if cfg.Disabled {
-		return &OAuthHandler{
-			nil,
-			oauth2.Config{},
-			nil,
-			cfg.BaseURL,
-			nil,
-			true,
-			"",
-		}, nil
+		return &OAuthHandler{baseURL: cfg.BaseURL, disabled: true}, nil
+	}
+	if len(cfg.Providers) == 0 {
+		return nil, errors.New("no OIDC provider configured")
+	}
+
+	h := &OAuthHandler{baseURL: cfg.BaseURL}
+	for _, pc := range cfg.Providers {
+		p, err := newOIDCProvider(ctx, pc, cfg.BaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("OIDC provider %s: %w", pc.ID, err)
+		}
+		h.providers = append(h.providers, p)
+	}
+
+	secret := []byte(cfg.SessionSecret)
+	if len(secret) < 32 {
+		// Pad secret if too short
+		padded := make([]byte, 32)
+		copy(padded, secret)
+		secret = padded
 	}
+	h.secret = secret[:32]
+
+	return h, nil
+}
 
+// newOIDCProvider discovers a provider and configures its client. All
+// providers share one redirect URL; the state cookie records which one a
+// login was started with.
+func newOIDCProvider(ctx context.Context, cfg ProviderConfig, baseURL string) (*oidcProvider, error) {
 	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
 	if err != nil {
 		return nil, err
@@ -75,7 +138,7 @@ func NewOAuthHandler(ctx context.Context, cfg OAuthConfig) (*OAuthHandler, error
 		ClientID:     cfg.ClientID,
 		ClientSecret: cfg.ClientSecret,
 		Endpoint:     provider.Endpoint(),
-		RedirectURL:  cfg.BaseURL + "/auth/oidc/callback",
+		RedirectURL:  baseURL + "/auth/oidc/callback",
 		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
 	}
 
@@ -83,34 +146,81 @@ func NewOAuthHandler(ctx context.Context, cfg OAuthConfig) (*OAuthHandler, error
 		ClientID: cfg.ClientID,
 	})
 
-	secret := []byte(cfg.SessionSecret)
-	if len(secret) < 32 {
-		// Pad secret if too short
-		padded := make([]byte, 32)
-		copy(padded, secret)
-		secret = padded
-	}
-
 	// Extract end_session_endpoint from OIDC discovery document
 	var providerClaims struct {
 		EndSessionEndpoint string `json:"end_session_endpoint"`
 	}
 	if err := provider.Claims(&providerClaims); err != nil {
-		slog.Warn("failed to extract end_session_endpoint from OIDC discovery", "error", err)
+		slog.Warn("failed to extract end_session_endpoint from OIDC discovery", "provider", cfg.ID, "error", err)
 	}
 
-	return &OAuthHandler{
-		provider,
-		oauth2Config,
-		verifier,
-		cfg.BaseURL,
-		secret[:32],
-		false,
-		providerClaims.EndSessionEndpoint,
+	return &oidcProvider{
+		id:                 cfg.ID,
+		name:               cfg.Name,
+		provider:           provider,
+		oauth2Config:       oauth2Config,
+		verifier:           verifier,
+		endSessionEndpoint: providerClaims.EndSessionEndpoint,
 	}, nil
 }
 
-// Login redirects to the OAuth provider
+// lookupProvider returns the provider with the given ID; an empty ID selects
+// the first provider
+func (h *OAuthHandler) lookupProvider(id string) *oidcProvider {
+	if len(h.providers) == 0 {
+		return nil
+	}
+	if id == "" {
+		return h.providers[0]
+	}
+	for _, p := range h.providers {
+		if p.id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// Providers lists the configured providers for the login screen
+func (h *OAuthHandler) Providers() []ProviderInfo {
+	list := make([]ProviderInfo, 0, len(h.providers))
+	for _, p := range h.providers {
+		list = append(list, ProviderInfo{
+			ID:       p.id,
+			Name:     p.name,
+			LoginURL: "/auth/oidc/login?provider=" + url.QueryEscape(p.id),
+		})
+	}
+	return list
+}
+
+// providerSubject namespaces the subjects of additional providers, so users
+// of different providers can't collide
+func providerSubject(providerID, subject string) string {
+	if providerID == "" || providerID == DefaultProviderID {
+		return subject
+	}
+	return providerID + ":" + subject
+}
+
+// SetLoginHook registers a callback for OIDC login attempts (e.g. for auditing)
+func (h *OAuthHandler) SetLoginHook(hook LoginHook) {
+	h.loginHook = hook
+}
+
+// SetLogoutHook registers a callback for OIDC logouts (e.g. for auditing)
+func (h *OAuthHandler) SetLogoutHook(hook LogoutHook) {
+	h.logoutHook = hook
+}
+
+func (h *OAuthHandler) notifyLogin(r *http.Request, subject, email string, success bool, failureReason string) {
+	if h.loginHook != nil {
+		h.loginHook(r, subject, email, success, failureReason)
+	}
+}
+
+// Login redirects to the OAuth provider; return_to is the local path to go
+// to after signing in, e.g. an invite to accept
 func (h *OAuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	if h.disabled {
 		// In disabled mode, just redirect to home
@@ -118,13 +228,24 @@ func (h *OAuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	p := h.lookupProvider(r.URL.Query().Get("provider"))
+	if p == nil {
+		http.Error(w, "Unknown OIDC provider", http.StatusBadRequest)
+		return
+	}
+
 	// Generate state for CSRF protection
 	state := generateRandomString(32)
 
-	// Store state in cookie
+	// Store state in cookie, along with the provider to complete the login
+	// with and where to go afterwards
+	value := p.id + "." + state
+	if returnTo := localPath(r.URL.Query().Get("return_to")); returnTo != "/" {
+		value += "." + url.QueryEscape(returnTo)
+	}
 	http.SetCookie(w, &http.Cookie{
 		Name:     stateCookieName,
-		Value:    state,
+		Value:    value,
 		Path:     "/",
 		MaxAge:   300, // 5 minutes
 		HttpOnly: true,
@@ -133,7 +254,7 @@ func (h *OAuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	})
 
 	// Redirect to OAuth provider
-	authURL := h.oauth2Config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+	authURL := p.oauth2Config.AuthCodeURL(state, oauth2.AccessTypeOffline)
 	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
 }
 
@@ -152,11 +273,20 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if r.URL.Query().Get("state") != stateCookie.Value {
+	providerID, state, _ := strings.Cut(stateCookie.Value, ".")
+	state, returnTo, _ := strings.Cut(state, ".")
+	returnTo, _ = url.QueryUnescape(returnTo)
+	if state == "" || r.URL.Query().Get("state") != state {
 		slog.Error("state mismatch")
 		http.Error(w, "State mismatch", http.StatusBadRequest)
 		return
 	}
+	p := h.lookupProvider(providerID)
+	if p == nil {
+		slog.Error("unknown OIDC provider in state", "provider", providerID)
+		http.Error(w, "Unknown OIDC provider", http.StatusBadRequest)
+		return
+	}
 
 	// Clear state cookie
 	http.SetCookie(w, &http.Cookie{
@@ -171,15 +301,17 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	if errMsg := r.URL.Query().Get("error"); errMsg != "" {
 		errDesc := r.URL.Query().Get("error_description")
 		slog.Error("OAuth error", "error", errMsg, "description", errDesc)
+		h.notifyLogin(r, "", "", false, "provider_error")
 		http.Error(w, "OAuth error: "+errMsg, http.StatusBadRequest)
 		return
 	}
 
 	// Exchange code for tokens
 	code := r.URL.Query().Get("code")
-	token, err := h.oauth2Config.Exchange(r.Context(), code)
+	token, err := p.oauth2Config.Exchange(r.Context(), code)
 	if err != nil {
 		slog.Error("failed to exchange code", "error", err)
+		h.notifyLogin(r, "", "", false, "code_exchange_failed")
 		http.Error(w, "Failed to exchange code", http.StatusInternalServerError)
 		return
 	}
@@ -192,17 +324,19 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	idToken, err := h.verifier.Verify(r.Context(), rawIDToken)
+	idToken, err := p.verifier.Verify(r.Context(), rawIDToken)
 	if err != nil {
 		slog.Error("failed to verify id_token", "error", err)
+		h.notifyLogin(r, "", "", false, "invalid_id_token")
 		http.Error(w, "Invalid ID token", http.StatusInternalServerError)
 		return
 	}
 
 	// Extract claims
 	var claims struct {
-		Email string `json:"email"`
-		Name  string `json:"name"`
+		Email     string `json:"email"`
+		Name      string `json:"name"`
+		SessionID string `json:"sid"`
 	}
 	if err := idToken.Claims(&claims); err != nil {
 		slog.Error("failed to parse claims", "error", err)
@@ -210,15 +344,27 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	subject := providerSubject(p.id, idToken.Subject)
+
+	refreshToken, err := h.sealRefreshToken(token.RefreshToken)
+	if err != nil {
+		slog.Error("failed to encrypt refresh token", "error", err)
+		http.Error(w, "Failed to create session", http.StatusInternalServerError)
+		return
+	}
+
 	// Create session
 	session := Session{
 		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
+		RefreshToken: refreshToken,
 		IDToken:      rawIDToken,
 		ExpiresAt:    token.Expiry,
-		Subject:      idToken.Subject,
+		Subject:      subject,
+		SessionID:    claims.SessionID,
+		IssuedAt:     time.Now(),
 		Email:        claims.Email,
 		Name:         claims.Name,
+		Provider:     p.id,
 	}
 
 	// Store session in cookie
@@ -228,23 +374,31 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Redirect to home
-	http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+	h.notifyLogin(r, subject, claims.Email, true, "")
+
+	// Redirect to where the login started, or home
+	http.Redirect(w, r, localPath(returnTo), http.StatusTemporaryRedirect)
+}
+
+// localPath returns path if it is a path on this host, so redirects to it
+// can't leave the app, or else "/"
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.ContainsAny(path, "\\\r\n") {
+		return "/"
+	}
+	return path
 }
 
 // Logout clears the session
 func (h *OAuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	// Read session before clearing so we can pass id_token_hint to the provider
 	session, _ := h.getSessionFromCookie(r)
+	if session != nil && h.logoutHook != nil {
+		h.logoutHook(r, session.Subject, session.Email)
+	}
 
 	// Clear session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     sessionCookieName,
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-	})
+	h.clearSessionCookie(w)
 
 	if h.disabled {
 		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
@@ -253,19 +407,28 @@ func (h *OAuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 
 	postLogoutRedirect := h.baseURL + "/login?logout=true"
 
+	var p *oidcProvider
+	if session != nil {
+		p = h.lookupProvider(session.Provider)
+	}
+	if p == nil {
+		http.Redirect(w, r, postLogoutRedirect, http.StatusTemporaryRedirect)
+		return
+	}
+
 	params := url.Values{}
 	params.Set("post_logout_redirect_uri", postLogoutRedirect)
-	params.Set("client_id", h.oauth2Config.ClientID)
-	if session != nil && session.IDToken != "" {
+	params.Set("client_id", p.oauth2Config.ClientID)
+	if session.IDToken != "" {
 		params.Set("id_token_hint", session.IDToken)
 	}
 
 	var logoutURL string
-	if h.endSessionEndpoint != "" {
-		logoutURL = h.endSessionEndpoint + "?" + params.Encode()
+	if p.endSessionEndpoint != "" {
+		logoutURL = p.endSessionEndpoint + "?" + params.Encode()
 	} else {
 		// Fallback: derive logout URL from auth endpoint (e.g. Keycloak)
-		authURL := h.provider.Endpoint().AuthURL
+		authURL := p.provider.Endpoint().AuthURL
 		if len(authURL) > 4 {
 			logoutURL = authURL[:len(authURL)-4] + "logout?" + params.Encode()
 		} else {
@@ -279,7 +442,7 @@ func (h *OAuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 // GetSession writes the current session info as JSON to the response
 func (h *OAuthHandler) GetSession(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(h.GetSessionInfo(r))
+	json.NewEncoder(w).Encode(h.SessionInfo(w, r))
 }
 
 // GetSessionInfo returns session data as a map without writing to the response
@@ -297,14 +460,15 @@ func (h *OAuthHandler) GetSessionInfo(r *http.Request) map[string]any {
 	}
 
 	session, err := h.getSessionFromCookie(r)
-	if err != nil || session == nil {
-		return map[string]any{
-			"authenticated": false,
-			"oidc_enabled":  true,
-		}
+	if err != nil {
+		session = nil
 	}
+	return sessionInfo(session)
+}
 
-	if time.Now().After(session.ExpiresAt) {
+// sessionInfo describes a session for the session endpoint
+func sessionInfo(session *Session) map[string]any {
+	if session == nil || time.Now().After(session.ExpiresAt) {
 		return map[string]any{
 			"authenticated": false,
 			"oidc_enabled":  true,
@@ -387,3 +551,9 @@ func generateRandomString(length int) string {
 	rand.Read(b)
 	return base64.URLEncoding.EncodeToString(b)[:length]
 }
+
+// DiscoverIssuer checks that issuerURL serves a valid OIDC discovery document
+func DiscoverIssuer(ctx context.Context, issuerURL string) error {
+	_, err := oidc.NewProvider(ctx, issuerURL)
+	return err
+}
@@ -6,8 +6,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
+
+	"golang.org/x/oauth2"
 )
 
 func Test_NewOAuthHandler_Disabled_ReturnsHandler(t *testing.T) {
@@ -426,3 +430,121 @@ func Test_Session_Struct_Fields(t *testing.T) {
 		t.Error("Name not set correctly")
 	}
 }
+
+func Test_OAuthHandler_Providers_ListsLoginURLs(t *testing.T) {
+	handler := &OAuthHandler{providers: []*oidcProvider{
+		{id: DefaultProviderID, name: "Keycloak"},
+		{id: "google", name: "Google"},
+	}}
+
+	providers := handler.Providers()
+
+	if len(providers) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(providers))
+	}
+	if providers[1].ID != "google" || providers[1].Name != "Google" || providers[1].LoginURL != "/auth/oidc/login?provider=google" {
+		t.Errorf("unexpected provider %+v", providers[1])
+	}
+	if handler.lookupProvider("").id != DefaultProviderID {
+		t.Error("expected the first provider to be the default")
+	}
+	if handler.lookupProvider("github") != nil {
+		t.Error("expected unknown provider to be nil")
+	}
+}
+
+func Test_OAuthHandler_Login_UnknownProvider_ReturnsBadRequest(t *testing.T) {
+	handler := &OAuthHandler{providers: []*oidcProvider{{id: DefaultProviderID}}}
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/login?provider=github", nil)
+	rec := httptest.NewRecorder()
+
+	handler.Login(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func Test_OAuthHandler_Login_ReturnTo_StoredInStateCookie(t *testing.T) {
+	handler := &OAuthHandler{providers: []*oidcProvider{{
+		id:           DefaultProviderID,
+		oauth2Config: oauth2.Config{Endpoint: oauth2.Endpoint{AuthURL: "https://idp.example.com/auth"}},
+	}}}
+
+	tests := []struct {
+		returnTo string
+		want     string
+	}{
+		{"/invite/abc?x=1", "/invite/abc?x=1"},
+		{"", ""},
+		{"https://evil.example.com", ""},
+		{"//evil.example.com", ""},
+		{"/\\evil.example.com", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/auth/oidc/login?return_to="+url.QueryEscape(tt.returnTo), nil)
+		rec := httptest.NewRecorder()
+
+		handler.Login(rec, req)
+
+		if rec.Code != http.StatusTemporaryRedirect {
+			t.Fatalf("%q: expected status 307, got %d", tt.returnTo, rec.Code)
+		}
+		cookies := rec.Result().Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("%q: expected a state cookie, got %v", tt.returnTo, cookies)
+		}
+		parts := strings.Split(cookies[0].Value, ".")
+		got := ""
+		if len(parts) == 3 {
+			got, _ = url.QueryUnescape(parts[2])
+		}
+		if parts[0] != DefaultProviderID || got != tt.want {
+			t.Errorf("%q: unexpected state cookie %q", tt.returnTo, cookies[0].Value)
+		}
+	}
+}
+
+func Test_localPath(t *testing.T) {
+	tests := map[string]string{
+		"/invite/abc":              "/invite/abc",
+		"/":                        "/",
+		"":                         "/",
+		"invite":                   "/",
+		"//evil.example.com":       "/",
+		"/\\evil.example.com":      "/",
+		"https://evil.example.com": "/",
+	}
+	for path, want := range tests {
+		if got := localPath(path); got != want {
+			t.Errorf("localPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func Test_OAuthHandler_Callback_StateWithoutProvider_ReturnsBadRequest(t *testing.T) {
+	handler := &OAuthHandler{providers: []*oidcProvider{{id: DefaultProviderID}}}
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?state=abc", nil)
+	req.AddCookie(&http.Cookie{Name: stateCookieName, Value: "abc"})
+	rec := httptest.NewRecorder()
+
+	handler.Callback(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rec.Code)
+	}
+}
+
+func Test_providerSubject(t *testing.T) {
+	if got := providerSubject(DefaultProviderID, "123"); got != "123" {
+		t.Errorf("expected default provider subject to be unprefixed, got %q", got)
+	}
+	if got := providerSubject("", "123"); got != "123" {
+		t.Errorf("expected legacy session subject to be unprefixed, got %q", got)
+	}
+	if got := providerSubject("google", "123"); got != "google:123" {
+		t.Errorf("expected prefixed subject, got %q", got)
+	}
+}
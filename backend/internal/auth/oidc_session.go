@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/lmmendes/attic/internal/secrets"
+	"golang.org/x/oauth2"
+)
+
+// refreshWindow is how long before access token expiry the session is refreshed
+const refreshWindow = 2 * time.Minute
+
+// backchannelLogoutEvent is the event type of OIDC back-channel logout tokens
+const backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// maxLogoutTokenAge bounds the iat of accepted logout tokens
+const maxLogoutTokenAge = 10 * time.Minute
+
+var errNoRefreshToken = errors.New("session has no refresh token")
+
+// RevocationStore records OIDC sessions ended by the provider (back-channel logout)
+type RevocationStore interface {
+	Revoke(ctx context.Context, subject, sid string) error
+	IsRevoked(ctx context.Context, subject, sid string, issuedAt time.Time) (bool, error)
+}
+
+// SetSecrets enables encryption of refresh tokens stored in the session cookie
+func (h *OAuthHandler) SetSecrets(box *secrets.Box) {
+	h.secrets = box
+}
+
+// SetRevocationStore enables back-channel logout
+func (h *OAuthHandler) SetRevocationStore(store RevocationStore) {
+	h.revocations = store
+}
+
+// ActiveSession returns the session from the cookie, silently refreshing its
+// tokens when they are about to expire. It returns nil when there is no
+// session or the provider ended it through back-channel logout.
+func (h *OAuthHandler) ActiveSession(w http.ResponseWriter, r *http.Request) *Session {
+	session, err := h.getSessionFromCookie(r)
+	if err != nil || session == nil {
+		return nil
+	}
+
+	if h.revocations != nil {
+		revoked, err := h.revocations.IsRevoked(r.Context(), session.Subject, session.SessionID, session.IssuedAt)
+		if err != nil {
+			slog.Error("failed to check session revocation", "error", err)
+			return nil
+		}
+		if revoked {
+			h.clearSessionCookie(w)
+			return nil
+		}
+	}
+
+	if time.Until(session.ExpiresAt) > refreshWindow {
+		return session
+	}
+
+	refreshed, err := h.refreshSession(r.Context(), session)
+	if err != nil {
+		if !errors.Is(err, errNoRefreshToken) {
+			slog.Warn("failed to refresh OIDC session", "error", err)
+		}
+		return session
+	}
+
+	if err := h.setSessionCookie(w, r, refreshed); err != nil {
+		slog.Error("failed to set session cookie", "error", err)
+	}
+	return refreshed
+}
+
+// SessionInfo is like GetSessionInfo, but refreshes the session when needed
+func (h *OAuthHandler) SessionInfo(w http.ResponseWriter, r *http.Request) map[string]any {
+	if h.disabled {
+		return h.GetSessionInfo(r)
+	}
+	return sessionInfo(h.ActiveSession(w, r))
+}
+
+// refreshSession exchanges the refresh token for new tokens
+func (h *OAuthHandler) refreshSession(ctx context.Context, session *Session) (*Session, error) {
+	refreshToken, err := h.openRefreshToken(session.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+	p := h.lookupProvider(session.Provider)
+	if refreshToken == "" || p == nil {
+		return nil, errNoRefreshToken
+	}
+
+	// An expired token forces the token source to use the refresh token
+	src := p.oauth2Config.TokenSource(ctx, &oauth2.Token{
+		RefreshToken: refreshToken,
+		Expiry:       time.Now().Add(-time.Minute),
+	})
+	token, err := src.Token()
+	if err != nil {
+		return nil, fmt.Errorf("refreshing token: %w", err)
+	}
+
+	refreshed := *session
+	refreshed.AccessToken = token.AccessToken
+	refreshed.ExpiresAt = token.Expiry
+	if token.RefreshToken != "" && token.RefreshToken != refreshToken {
+		if refreshed.RefreshToken, err = h.sealRefreshToken(token.RefreshToken); err != nil {
+			return nil, err
+		}
+	}
+	if rawIDToken, ok := token.Extra("id_token").(string); ok && rawIDToken != "" {
+		if _, err := p.verifier.Verify(ctx, rawIDToken); err != nil {
+			return nil, fmt.Errorf("verifying refreshed id_token: %w", err)
+		}
+		refreshed.IDToken = rawIDToken
+	}
+
+	return &refreshed, nil
+}
+
+// sealRefreshToken encrypts a refresh token for storage in the session cookie
+func (h *OAuthHandler) sealRefreshToken(token string) (string, error) {
+	if h.secrets == nil || token == "" {
+		return token, nil
+	}
+	return h.secrets.Encrypt(token)
+}
+
+// openRefreshToken reverses sealRefreshToken (unencrypted tokens are returned as-is)
+func (h *OAuthHandler) openRefreshToken(stored string) (string, error) {
+	if h.secrets == nil || !strings.HasPrefix(stored, "v1:") {
+		return stored, nil
+	}
+	return h.secrets.Decrypt(stored)
+}
+
+func (h *OAuthHandler) clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
+
+// logoutClaims are the claims of an OIDC back-channel logout token
+type logoutClaims struct {
+	SessionID string                     `json:"sid"`
+	Nonce     string                     `json:"nonce"`
+	Events    map[string]json.RawMessage `json:"events"`
+}
+
+// BackchannelLogout handles OIDC back-channel logout requests from the
+// provider. Providers other than the first are selected with ?provider=<id>
+// in the registered back-channel logout URL.
+func (h *OAuthHandler) BackchannelLogout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	p := h.lookupProvider(r.URL.Query().Get("provider"))
+	if h.disabled || p == nil || h.revocations == nil {
+		http.Error(w, `{"error":"back-channel logout not supported"}`, http.StatusNotImplemented)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	rawToken := r.PostForm.Get("logout_token")
+	if rawToken == "" {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	// Logout tokens may omit exp; expiry and age are checked in validateLogoutToken
+	verifier := p.provider.Verifier(&oidc.Config{ClientID: p.oauth2Config.ClientID, SkipExpiryCheck: true})
+	token, err := verifier.Verify(r.Context(), rawToken)
+	if err != nil {
+		slog.Warn("invalid logout token", "error", err)
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	var claims logoutClaims
+	if err := token.Claims(&claims); err != nil {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	if err := validateLogoutToken(token.Subject, token.IssuedAt, token.Expiry, claims, time.Now()); err != nil {
+		slog.Warn("invalid logout token", "error", err)
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+
+	subject := token.Subject
+	if subject != "" {
+		subject = providerSubject(p.id, subject)
+	}
+	if err := h.revocations.Revoke(r.Context(), subject, claims.SessionID); err != nil {
+		slog.Error("failed to revoke session", "error", err)
+		http.Error(w, `{"error":"server_error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	slog.Info("OIDC session ended by provider", "provider", p.id, "sub", subject, "sid", claims.SessionID)
+	w.WriteHeader(http.StatusOK)
+}
+
+// validateLogoutToken applies the back-channel logout token rules (OIDC
+// Back-Channel Logout 1.0, section 2.6) not covered by signature verification
+func validateLogoutToken(subject string, issuedAt, expiry time.Time, claims logoutClaims, now time.Time) error {
+	if _, ok := claims.Events[backchannelLogoutEvent]; !ok {
+		return errors.New("missing back-channel logout event")
+	}
+	if claims.Nonce != "" {
+		return errors.New("logout token must not contain a nonce")
+	}
+	if subject == "" && claims.SessionID == "" {
+		return errors.New("logout token must contain sub or sid")
+	}
+	if issuedAt.IsZero() || now.Sub(issuedAt) > maxLogoutTokenAge || issuedAt.Sub(now) > time.Minute {
+		return errors.New("logout token iat out of range")
+	}
+	if !expiry.IsZero() && now.After(expiry) {
+		return errors.New("logout token expired")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/secrets"
+)
+
+type fakeRevocationStore struct {
+	revoked bool
+}
+
+func (f *fakeRevocationStore) Revoke(ctx context.Context, subject, sid string) error {
+	f.revoked = true
+	return nil
+}
+
+func (f *fakeRevocationStore) IsRevoked(ctx context.Context, subject, sid string, issuedAt time.Time) (bool, error) {
+	return f.revoked, nil
+}
+
+func sessionRequest(t *testing.T, session Session) *http.Request {
+	t.Helper()
+	data, _ := json.Marshal(session)
+	req := httptest.NewRequest(http.MethodGet, "/api/assets", nil)
+	req.AddCookie(&http.Cookie{
+		Name:  sessionCookieName,
+		Value: base64.StdEncoding.EncodeToString(data),
+	})
+	return req
+}
+
+func Test_OAuthHandler_sealRefreshToken_RoundTrip(t *testing.T) {
+	box, _ := secrets.New("test-passphrase")
+	handler := &OAuthHandler{secrets: box}
+
+	sealed, err := handler.sealRefreshToken("refresh-123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sealed == "refresh-123" || strings.Contains(sealed, "refresh-123") {
+		t.Errorf("expected refresh token to be encrypted, got %q", sealed)
+	}
+
+	opened, err := handler.openRefreshToken(sealed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened != "refresh-123" {
+		t.Errorf("expected 'refresh-123', got %q", opened)
+	}
+}
+
+func Test_OAuthHandler_openRefreshToken_PlaintextPassesThrough(t *testing.T) {
+	box, _ := secrets.New("test-passphrase")
+	handler := &OAuthHandler{secrets: box}
+
+	opened, err := handler.openRefreshToken("legacy-token")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opened != "legacy-token" {
+		t.Errorf("expected 'legacy-token', got %q", opened)
+	}
+}
+
+func Test_OAuthHandler_ActiveSession_ValidSession_ReturnsSession(t *testing.T) {
+	handler := &OAuthHandler{revocations: &fakeRevocationStore{}}
+	req := sessionRequest(t, Session{
+		AccessToken: "token",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		Subject:     "user-123",
+	})
+	rec := httptest.NewRecorder()
+
+	session := handler.ActiveSession(rec, req)
+	if session == nil || session.AccessToken != "token" {
+		t.Fatalf("expected session with access token, got %+v", session)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("expected no cookie to be written for a fresh session")
+	}
+}
+
+func Test_OAuthHandler_ActiveSession_Revoked_ClearsCookie(t *testing.T) {
+	handler := &OAuthHandler{revocations: &fakeRevocationStore{revoked: true}}
+	req := sessionRequest(t, Session{
+		AccessToken: "token",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		Subject:     "user-123",
+		SessionID:   "sid-1",
+	})
+	rec := httptest.NewRecorder()
+
+	if session := handler.ActiveSession(rec, req); session != nil {
+		t.Fatal("expected revoked session to be rejected")
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Error("expected session cookie to be cleared")
+	}
+}
+
+func Test_OAuthHandler_BackchannelLogout_NoProvider_ReturnsNotImplemented(t *testing.T) {
+	handler := &OAuthHandler{revocations: &fakeRevocationStore{}}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/oidc/backchannel-logout", strings.NewReader(""))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	handler.BackchannelLogout(rec, req)
+
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected status 501, got %d", rec.Code)
+	}
+	if rec.Header().Get("Cache-Control") != "no-store" {
+		t.Error("expected Cache-Control: no-store")
+	}
+}
+
+func Test_validateLogoutToken(t *testing.T) {
+	now := time.Now()
+	events := map[string]json.RawMessage{backchannelLogoutEvent: json.RawMessage(`{}`)}
+
+	tests := []struct {
+		name    string
+		subject string
+		iat     time.Time
+		exp     time.Time
+		claims  logoutClaims
+		wantErr bool
+	}{
+		{"valid with sid", "", now, time.Time{}, logoutClaims{SessionID: "sid", Events: events}, false},
+		{"valid with sub", "user", now, now.Add(time.Minute), logoutClaims{Events: events}, false},
+		{"missing event", "user", now, time.Time{}, logoutClaims{}, true},
+		{"nonce present", "user", now, time.Time{}, logoutClaims{Nonce: "n", Events: events}, true},
+		{"no sub or sid", "", now, time.Time{}, logoutClaims{Events: events}, true},
+		{"stale iat", "user", now.Add(-time.Hour), time.Time{}, logoutClaims{Events: events}, true},
+		{"expired", "user", now, now.Add(-time.Second), logoutClaims{Events: events}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLogoutToken(tt.subject, tt.iat, tt.exp, tt.claims, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateLogoutToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
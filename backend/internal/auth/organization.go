@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// OrganizationHeader selects which of the caller's organizations a request
+// is for; without it the organization the user selected last is used
+const OrganizationHeader = "X-Org-ID"
+
+type organizationContextKey struct{}
+
+type organizationRoleContextKey struct{}
+
+// MembershipStore looks up the organizations a user is a member of. A
+// member's role is "" where the user has their own role (users.role).
+type MembershipStore interface {
+	// MemberOrganization returns the organization the user selected last, or
+	// else joined first (uuid.Nil if none)
+	MemberOrganization(ctx context.Context, userID uuid.UUID) (uuid.UUID, domain.UserRole, error)
+	// MemberRole reports whether the user is a member of an organization
+	MemberRole(ctx context.Context, orgID, userID uuid.UUID) (domain.UserRole, bool, error)
+}
+
+// OrganizationResolver scopes requests to an organization of the
+// authenticated user, with the user's role in it
+type OrganizationResolver struct {
+	members      MembershipStore
+	defaultOrgID uuid.UUID
+}
+
+// NewOrganizationResolver creates a resolver; callers that aren't users, e.g.
+// with authentication disabled, get the default organization
+func NewOrganizationResolver(members MembershipStore, defaultOrgID uuid.UUID) *OrganizationResolver {
+	return &OrganizationResolver{members: members, defaultOrgID: defaultOrgID}
+}
+
+// Resolve is middleware that adds the caller's organization, given by the
+// X-Org-ID header or else the one selected last, and their role in it to the
+// context; users who aren't a member of the organization are forbidden
+func (o *OrganizationResolver) Resolve(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := o.userOf(r.Context())
+		if !ok {
+			next.ServeHTTP(w, r.WithContext(WithOrganization(r.Context(), o.defaultOrgID)))
+			return
+		}
+
+		var orgID uuid.UUID
+		var role domain.UserRole
+		var err error
+		if header := r.Header.Get(OrganizationHeader); header != "" {
+			requested, parseErr := uuid.Parse(header)
+			if parseErr != nil {
+				http.Error(w, `{"error":"invalid `+OrganizationHeader+` header"}`, http.StatusBadRequest)
+				return
+			}
+			var member bool
+			role, member, err = o.members.MemberRole(r.Context(), requested, userID)
+			if err == nil && member {
+				orgID = requested
+			}
+		} else {
+			orgID, role, err = o.members.MemberOrganization(r.Context(), userID)
+		}
+		if err != nil {
+			slog.Error("failed to resolve organization", "error", err)
+			http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+			return
+		}
+		if orgID == uuid.Nil {
+			http.Error(w, `{"error":"not a member of the organization"}`, http.StatusForbidden)
+			return
+		}
+
+		ctx := WithOrganization(r.Context(), orgID)
+		if role != "" {
+			ctx = context.WithValue(ctx, organizationRoleContextKey{}, role)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// userOf returns the ID of the authenticated user; callers that aren't users,
+// e.g. development claims, have none
+func (o *OrganizationResolver) userOf(ctx context.Context) (uuid.UUID, bool) {
+	if user := GetUser(ctx); user != nil {
+		return user.ID, true
+	}
+	if claims := GetClaims(ctx); claims != nil {
+		id, err := uuid.Parse(claims.Subject)
+		return id, err == nil
+	}
+	return uuid.Nil, false
+}
+
+// WithOrganization returns a context scoped to an organization
+func WithOrganization(ctx context.Context, orgID uuid.UUID) context.Context {
+	return context.WithValue(ctx, organizationContextKey{}, orgID)
+}
+
+// organizationRole returns the caller's role in the request's organization
+// ("" = the user's own role)
+func organizationRole(ctx context.Context) domain.UserRole {
+	role, _ := ctx.Value(organizationRoleContextKey{}).(domain.UserRole)
+	return role
+}
+
+// OrganizationID returns the organization of the request (uuid.Nil if it
+// wasn't resolved, e.g. for unauthenticated requests)
+func OrganizationID(ctx context.Context) uuid.UUID {
+	orgID, _ := ctx.Value(organizationContextKey{}).(uuid.UUID)
+	return orgID
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type testMember struct {
+	org  uuid.UUID
+	role domain.UserRole
+}
+
+// testMembershipStore lists the memberships of users, the selected one first
+type testMembershipStore map[uuid.UUID][]testMember
+
+func (s testMembershipStore) MemberOrganization(_ context.Context, userID uuid.UUID) (uuid.UUID, domain.UserRole, error) {
+	if members := s[userID]; len(members) > 0 {
+		return members[0].org, members[0].role, nil
+	}
+	return uuid.Nil, "", nil
+}
+
+func (s testMembershipStore) MemberRole(_ context.Context, orgID, userID uuid.UUID) (domain.UserRole, bool, error) {
+	for _, m := range s[userID] {
+		if m.org == orgID {
+			return m.role, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func Test_OrganizationResolver_Resolve(t *testing.T) {
+	defaultOrg, otherOrg, cabinOrg := uuid.New(), uuid.New(), uuid.New()
+	member, stranger := uuid.New(), uuid.New()
+	resolver := NewOrganizationResolver(testMembershipStore{
+		member: {{org: otherOrg}, {org: cabinOrg, role: "viewer"}},
+	}, defaultOrg)
+	claims := func(id string) context.Context {
+		return context.WithValue(context.Background(), UserContextKey, &Claims{Subject: id, Role: domain.UserRoleAdmin})
+	}
+
+	tests := map[string]struct {
+		ctx    context.Context
+		header string
+		status int
+		org    uuid.UUID
+		role   domain.UserRole
+	}{
+		"member":          {ctx: claims(member.String()), status: http.StatusOK, org: otherOrg, role: domain.UserRoleAdmin},
+		"provisioned":     {ctx: context.WithValue(context.Background(), DomainUserContextKey, &domain.User{ID: member, Role: domain.UserRoleUser}), status: http.StatusOK, org: otherOrg, role: domain.UserRoleUser},
+		"selected":        {ctx: claims(member.String()), header: cabinOrg.String(), status: http.StatusOK, org: cabinOrg, role: "viewer"},
+		"other member":    {ctx: claims(member.String()), header: defaultOrg.String(), status: http.StatusForbidden},
+		"invalid header":  {ctx: claims(member.String()), header: "cabin", status: http.StatusBadRequest},
+		"not a member":    {ctx: claims(stranger.String()), status: http.StatusForbidden},
+		"development":     {ctx: claims("dev-user"), header: otherOrg.String(), status: http.StatusOK, org: defaultOrg, role: domain.UserRoleAdmin},
+		"unauthenticated": {ctx: context.Background(), status: http.StatusOK, org: defaultOrg},
+	}
+	for name, tt := range tests {
+		var got uuid.UUID
+		var role domain.UserRole
+		handler := resolver.Resolve(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = OrganizationID(r.Context())
+			role = CurrentRole(r.Context())
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/api/assets", nil).WithContext(tt.ctx)
+		if tt.header != "" {
+			req.Header.Set(OrganizationHeader, tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d", name, tt.status, rec.Code)
+		}
+		if got != tt.org {
+			t.Errorf("%s: expected organization %s, got %s", name, tt.org, got)
+		}
+		if role != tt.role {
+			t.Errorf("%s: expected role %q, got %q", name, tt.role, role)
+		}
+	}
+}
+
+type orgRoleStore map[uuid.UUID]*domain.Role
+
+func (s orgRoleStore) GetByName(_ context.Context, orgID uuid.UUID, _ domain.UserRole) (*domain.Role, error) {
+	return s[orgID], nil
+}
+
+func Test_Authorizer_Role_UsesRequestOrganization(t *testing.T) {
+	defaultOrg, otherOrg := uuid.New(), uuid.New()
+	a := NewAuthorizer(orgRoleStore{otherOrg: {Name: "viewer"}}, defaultOrg)
+
+	if role, _ := a.Role(context.Background(), "viewer"); role != nil {
+		t.Errorf("expected no viewer role in the default organization, got %+v", role)
+	}
+	if role, _ := a.Role(WithOrganization(context.Background(), otherOrg), "viewer"); role == nil {
+		t.Error("expected the viewer role of the request's organization")
+	}
+}
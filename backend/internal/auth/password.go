@@ -23,10 +23,8 @@ func CheckPassword(password, hash string) bool {
 	return err == nil
 }
 
-// ValidatePassword checks if password meets minimum requirements
+// ValidatePassword checks if password meets the minimum length requirement.
+// Use PasswordPolicy.Validate to enforce the full configured policy.
 func ValidatePassword(password string, minLength int) error {
-	if len(password) < minLength {
-		return fmt.Errorf("password must be at least %d characters", minLength)
-	}
-	return nil
+	return PasswordPolicy{MinLength: minLength}.Validate(password)
 }
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// bcryptMaxLength is the number of bytes bcrypt hashes; longer passwords are rejected
+const bcryptMaxLength = 72
+
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+var commonPasswords = func() map[string]bool {
+	set := make(map[string]bool)
+	for _, line := range strings.Split(commonPasswordsList, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			set[strings.ToLower(line)] = true
+		}
+	}
+	return set
+}()
+
+// ErrPasswordReused is returned when a password matches a recently used one
+var ErrPasswordReused = errors.New("password was used recently, choose a different one")
+
+// PasswordPolicy describes the requirements for local account passwords.
+// It is exposed through the auth mode endpoint so the UI can display the rules.
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	MaxLength     int  `json:"max_length"` // In bytes, at most 72 (bcrypt limit)
+	RequireUpper  bool `json:"require_uppercase"`
+	RequireLower  bool `json:"require_lowercase"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	BanCommon     bool `json:"ban_common"`   // Reject well-known passwords
+	HistorySize   int  `json:"history_size"` // Number of previous passwords that can't be reused (0 = disabled)
+}
+
+// Validate checks password against the policy
+func (p PasswordPolicy) Validate(password string) error {
+	if len(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+
+	maxLength := p.MaxLength
+	if maxLength <= 0 || maxLength > bcryptMaxLength {
+		maxLength = bcryptMaxLength
+	}
+	if len(password) > maxLength {
+		return fmt.Errorf("password must be at most %d characters", maxLength)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			hasUpper = true
+		case unicode.IsLower(c):
+			hasLower = true
+		case unicode.IsDigit(c):
+			hasDigit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c) || unicode.IsSpace(c):
+			hasSymbol = true
+		}
+	}
+
+	var missing []string
+	if p.RequireUpper && !hasUpper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		missing = append(missing, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("password must contain %s", strings.Join(missing, ", "))
+	}
+
+	if p.BanCommon && IsCommonPassword(password) {
+		return errors.New("password is too common, choose a less predictable one")
+	}
+
+	return nil
+}
+
+// CheckReuse returns ErrPasswordReused if password matches one of the
+// given hashes (current password first, then history, most recent first)
+func (p PasswordPolicy) CheckReuse(password string, hashes []string) error {
+	if p.HistorySize <= 0 {
+		return nil
+	}
+	for i, hash := range hashes {
+		if i > p.HistorySize {
+			break
+		}
+		if CheckPassword(password, hash) {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// IsCommonPassword reports whether password is on the embedded list of common passwords
+func IsCommonPassword(password string) bool {
+	return commonPasswords[strings.ToLower(password)]
+}
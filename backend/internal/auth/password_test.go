@@ -170,3 +170,71 @@ func Test_ValidatePassword_ErrorMessage_ContainsMinLength(t *testing.T) {
 		t.Errorf("error message should contain minimum length, got: %s", err.Error())
 	}
 }
+
+func Test_PasswordPolicy_Validate_CharacterClasses(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	if err := policy.Validate("Str0ng!pass"); err != nil {
+		t.Errorf("expected valid password, got: %v", err)
+	}
+
+	err := policy.Validate("lowercaseonly")
+	if err == nil {
+		t.Fatal("expected error for missing character classes")
+	}
+	for _, want := range []string{"uppercase", "digit", "symbol"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got: %s", want, err.Error())
+		}
+	}
+	if strings.Contains(err.Error(), "lowercase") {
+		t.Errorf("expected lowercase requirement to be satisfied, got: %s", err.Error())
+	}
+}
+
+func Test_PasswordPolicy_Validate_MaxLength(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 1, MaxLength: 10}
+	if err := policy.Validate("12345678901"); err == nil {
+		t.Error("expected error for password over max length")
+	}
+
+	// bcrypt limit applies even without a configured max length
+	if err := (PasswordPolicy{}).Validate(strings.Repeat("a", 73)); err == nil {
+		t.Error("expected error for password over 72 bytes")
+	}
+}
+
+func Test_PasswordPolicy_Validate_BanCommon(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, BanCommon: true}
+
+	if err := policy.Validate("Password123"); err == nil {
+		t.Error("expected common password to be rejected")
+	}
+	if err := policy.Validate("correct horse battery staple"); err != nil {
+		t.Errorf("expected uncommon password to be accepted, got: %v", err)
+	}
+	if err := (PasswordPolicy{MinLength: 8}).Validate("password123"); err != nil {
+		t.Errorf("expected common password to be accepted when not banned, got: %v", err)
+	}
+}
+
+func Test_PasswordPolicy_CheckReuse(t *testing.T) {
+	current, _ := HashPassword("current-password")
+	previous, _ := HashPassword("previous-password")
+	older, _ := HashPassword("older-password")
+	hashes := []string{current, previous, older}
+
+	policy := PasswordPolicy{HistorySize: 1}
+	if err := policy.CheckReuse("current-password", hashes); err != ErrPasswordReused {
+		t.Errorf("expected current password to be rejected, got: %v", err)
+	}
+	if err := policy.CheckReuse("previous-password", hashes); err != ErrPasswordReused {
+		t.Errorf("expected previous password to be rejected, got: %v", err)
+	}
+	if err := policy.CheckReuse("older-password", hashes); err != nil {
+		t.Errorf("expected password outside history to be accepted, got: %v", err)
+	}
+	if err := (PasswordPolicy{}).CheckReuse("current-password", hashes); err != nil {
+		t.Errorf("expected reuse to be allowed when history is disabled, got: %v", err)
+	}
+}
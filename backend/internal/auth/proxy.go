@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// proxySubjectPrefix namespaces the subjects of users authenticated by a proxy
+const proxySubjectPrefix = "proxy"
+
+var (
+	errUntrustedProxy = errors.New("request did not come from a trusted proxy")
+	errNoProxyUser    = errors.New("proxy did not send a user")
+)
+
+// ProxyConfig configures trusted reverse-proxy header authentication
+type ProxyConfig struct {
+	TrustedProxies []string // IP addresses or CIDR ranges of the proxies
+	UserHeader     string   // e.g. "Remote-User"
+	EmailHeader    string   // e.g. "Remote-Email"
+	NameHeader     string   // e.g. "Remote-Name" (optional)
+	GroupsHeader   string   // e.g. "Remote-Groups", comma separated (optional, for role mapping)
+}
+
+// ProxyAuth authenticates users by the headers a trusted reverse proxy
+// (Authelia, Authentik proxy outpost, oauth2-proxy) sets after signing them in.
+// Headers of requests from other addresses are never trusted.
+type ProxyAuth struct {
+	trusted []netip.Prefix
+	cfg     ProxyConfig
+}
+
+// NewProxyAuth creates header authentication for the given proxies
+func NewProxyAuth(cfg ProxyConfig) (*ProxyAuth, error) {
+	if len(cfg.TrustedProxies) == 0 {
+		return nil, errors.New("no trusted proxies configured")
+	}
+	if cfg.UserHeader == "" || cfg.EmailHeader == "" {
+		return nil, errors.New("user and email headers are required")
+	}
+
+	trusted, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return &ProxyAuth{trusted: trusted, cfg: cfg}, nil
+}
+
+// parseTrustedProxies parses the IP addresses or CIDR ranges of proxies
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	var trusted []netip.Prefix
+	for _, s := range proxies {
+		prefix, err := parsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", s)
+		}
+		trusted = append(trusted, prefix)
+	}
+	return trusted, nil
+}
+
+// parsePrefix parses a CIDR range or a single IP address
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+}
+
+// Claims returns the claims of the user the proxy authenticated
+func (p *ProxyAuth) Claims(r *http.Request) (*Claims, error) {
+	if !p.isTrusted(peerAddr(r)) {
+		return nil, errUntrustedProxy
+	}
+
+	user := strings.TrimSpace(r.Header.Get(p.cfg.UserHeader))
+	email := strings.TrimSpace(r.Header.Get(p.cfg.EmailHeader))
+	if user == "" || email == "" {
+		return nil, errNoProxyUser
+	}
+
+	claims := &Claims{
+		Subject:     providerSubject(proxySubjectPrefix, user),
+		Email:       email,
+		DisplayName: user,
+		Raw:         map[string]any{"sub": user, "email": email},
+	}
+	if p.cfg.NameHeader != "" {
+		claims.Name = strings.TrimSpace(r.Header.Get(p.cfg.NameHeader))
+	}
+	if p.cfg.GroupsHeader != "" {
+		var groups []any
+		for _, g := range strings.Split(r.Header.Get(p.cfg.GroupsHeader), ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				groups = append(groups, g)
+			}
+		}
+		claims.Raw["groups"] = groups
+	}
+	return claims, nil
+}
+
+func (p *ProxyAuth) isTrusted(addr netip.Addr) bool {
+	return containsAddr(p.trusted, addr)
+}
+
+// containsAddr reports whether addr is in one of prefixes
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+type peerContextKey struct{}
+
+// RememberPeer keeps the address of the directly connected client, so proxy
+// authentication can check it after middleware such as RealIP rewrote
+// RemoteAddr from forwarding headers. It must run before those.
+func RememberPeer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), peerContextKey{}, r.RemoteAddr)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// peerAddr returns the address of the directly connected client
+func peerAddr(r *http.Request) netip.Addr {
+	remote, ok := r.Context().Value(peerContextKey{}).(string)
+	if !ok {
+		remote = r.RemoteAddr
+	}
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	addr, _ := netip.ParseAddr(host)
+	return addr
+}
+
+// RealIP sets RemoteAddr to the client address a trusted proxy forwarded in
+// X-Forwarded-For or X-Real-IP. Unlike chi's RealIP, it ignores the headers
+// of requests from other peers, which could forge them to pose as another
+// client in login records and rate limits. Without trusted proxies,
+// RemoteAddr stays the peer's address.
+func RealIP(trustedProxies []string) (func(http.Handler) http.Handler, error) {
+	trusted, err := parseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if containsAddr(trusted, peerAddr(r)) {
+				if client := forwardedClient(r, trusted); client.IsValid() {
+					r.RemoteAddr = client.String()
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// forwardedClient returns the client address forwarded by trusted proxies:
+// the last X-Forwarded-For entry that isn't one of them, since a client can
+// put anything before the entries the proxies appended, or else X-Real-IP
+func forwardedClient(r *http.Request, trusted []netip.Prefix) netip.Addr {
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr.Unmap()
+		if !containsAddr(trusted, client) {
+			return client
+		}
+	}
+	if client.IsValid() {
+		return client
+	}
+	addr, _ := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	return addr.Unmap()
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestProxyAuth(t *testing.T, trusted ...string) *ProxyAuth {
+	t.Helper()
+	p, err := NewProxyAuth(ProxyConfig{
+		TrustedProxies: trusted,
+		UserHeader:     "Remote-User",
+		EmailHeader:    "Remote-Email",
+		NameHeader:     "Remote-Name",
+		GroupsHeader:   "Remote-Groups",
+	})
+	if err != nil {
+		t.Fatalf("NewProxyAuth: %v", err)
+	}
+	return p
+}
+
+func proxyRequest(remoteAddr string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Remote-User", "alice")
+	req.Header.Set("Remote-Email", "alice@example.com")
+	req.Header.Set("Remote-Name", "Alice Liddell")
+	req.Header.Set("Remote-Groups", "attic-admins, family")
+	return req
+}
+
+func Test_ProxyAuth_Claims_TrustedProxy(t *testing.T) {
+	p := newTestProxyAuth(t, "10.0.0.0/8")
+
+	claims, err := p.Claims(proxyRequest("10.1.2.3:41000"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if claims.Subject != "proxy:alice" {
+		t.Errorf("expected subject proxy:alice, got %q", claims.Subject)
+	}
+	if claims.Email != "alice@example.com" || claims.Name != "Alice Liddell" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	m, _ := ParseRoleMapping("groups", "attic-admins=admin")
+	if role, ok := m.Role(claims.Raw); !ok || role != "admin" {
+		t.Errorf("expected groups header to map to admin, got %q", role)
+	}
+}
+
+func Test_ProxyAuth_Claims_UntrustedPeer(t *testing.T) {
+	p := newTestProxyAuth(t, "10.0.0.5")
+
+	if _, err := p.Claims(proxyRequest("192.168.1.20:41000")); err != errUntrustedProxy {
+		t.Errorf("expected errUntrustedProxy, got %v", err)
+	}
+}
+
+func Test_ProxyAuth_Claims_MissingHeaders(t *testing.T) {
+	p := newTestProxyAuth(t, "10.0.0.5")
+	req := proxyRequest("10.0.0.5:41000")
+	req.Header.Del("Remote-Email")
+
+	if _, err := p.Claims(req); err != errNoProxyUser {
+		t.Errorf("expected errNoProxyUser, got %v", err)
+	}
+}
+
+func Test_ProxyAuth_Claims_ChecksPeerBeforeRealIP(t *testing.T) {
+	p := newTestProxyAuth(t, "10.0.0.5")
+	req := proxyRequest("192.168.1.20:41000")
+
+	// A rewritten RemoteAddr must not make a direct client look like the proxy
+	var got error
+	RememberPeer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.RemoteAddr = "10.0.0.5:0"
+		_, got = p.Claims(r)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != errUntrustedProxy {
+		t.Errorf("expected errUntrustedProxy, got %v", got)
+	}
+}
+
+func Test_NewProxyAuth_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ProxyConfig
+	}{
+		{"no trusted proxies", ProxyConfig{UserHeader: "Remote-User", EmailHeader: "Remote-Email"}},
+		{"invalid proxy", ProxyConfig{TrustedProxies: []string{"proxy.local"}, UserHeader: "Remote-User", EmailHeader: "Remote-Email"}},
+		{"missing headers", ProxyConfig{TrustedProxies: []string{"10.0.0.5"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewProxyAuth(tt.cfg); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func Test_RealIP(t *testing.T) {
+	realIP, err := RealIP([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("RealIP: %v", err)
+	}
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		realIPHeader string
+		expectedAddr string
+	}{
+		{"direct client forging headers", "203.0.113.7:41000", "198.51.100.1", "198.51.100.2", "203.0.113.7:41000"},
+		{"trusted proxy", "10.0.0.5:41000", "198.51.100.1", "", "198.51.100.1"},
+		{"client prepending an address", "10.0.0.5:41000", "198.51.100.1, 203.0.113.7, 10.0.0.9", "", "203.0.113.7"},
+		{"trusted proxy with X-Real-IP", "10.0.0.5:41000", "", "198.51.100.2", "198.51.100.2"},
+		{"trusted proxy without headers", "10.0.0.5:41000", "", "", "10.0.0.5:41000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.realIPHeader != "" {
+				req.Header.Set("X-Real-IP", tt.realIPHeader)
+			}
+
+			var got string
+			RememberPeer(realIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))).ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.expectedAddr {
+				t.Errorf("expected %s, got %s", tt.expectedAddr, got)
+			}
+		})
+	}
+
+	if _, err := RealIP([]string{"proxy.local"}); err == nil {
+		t.Error("expected error for an invalid proxy")
+	}
+}
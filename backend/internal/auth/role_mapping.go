@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// RoleMapping assigns roles to OIDC users from a token claim, e.g. users
+// whose "groups" claim contains "attic-admins" become admins
+type RoleMapping struct {
+	Claim string     // Claim name; dots select nested claims (e.g. "realm_access.roles")
+	Rules []RoleRule // First matching rule wins
+}
+
+// RoleRule maps a claim value to a role. The value "*" matches every user,
+// making the provider authoritative for users no other rule matches.
+type RoleRule struct {
+	Value string
+	Role  domain.UserRole
+}
+
+// ParseRoleMapping parses comma separated "value=role" rules, e.g.
+// "attic-admins=admin,*=user". It returns nil for empty rules.
+func ParseRoleMapping(claim, rules string) (*RoleMapping, error) {
+	m := &RoleMapping{Claim: strings.TrimSpace(claim)}
+	for _, rule := range strings.Split(rules, ",") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		value, role, ok := strings.Cut(rule, "=")
+		value, role = strings.TrimSpace(value), strings.TrimSpace(role)
+		if !ok || value == "" || role == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected value=role", rule)
+		}
+		m.Rules = append(m.Rules, RoleRule{Value: value, Role: domain.UserRole(strings.ToLower(role))})
+	}
+	if len(m.Rules) == 0 {
+		return nil, nil
+	}
+	if m.Claim == "" {
+		return nil, fmt.Errorf("role mapping requires a claim name")
+	}
+	return m, nil
+}
+
+// Roles lists the roles the mapping assigns
+func (m *RoleMapping) Roles() []domain.UserRole {
+	roles := make([]domain.UserRole, 0, len(m.Rules))
+	for _, r := range m.Rules {
+		roles = append(roles, r.Role)
+	}
+	return roles
+}
+
+// Role returns the role for a user with the given token claims, or false
+// when no rule matches (the user's role is left unchanged)
+func (m *RoleMapping) Role(claims map[string]any) (domain.UserRole, bool) {
+	values := claimValues(claims, m.Claim)
+	for _, rule := range m.Rules {
+		if rule.Value == "*" {
+			return rule.Role, true
+		}
+		for _, v := range values {
+			if v == rule.Value {
+				return rule.Role, true
+			}
+		}
+	}
+	return "", false
+}
+
+// claimValues reads a string or list of strings claim; dots in name select
+// nested objects
+func claimValues(claims map[string]any, name string) []string {
+	var value any = claims
+	for _, part := range strings.Split(name, ".") {
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = obj[part]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_ParseRoleMapping(t *testing.T) {
+	m, err := ParseRoleMapping("groups", " attic-admins=Admin, attic-editors = editor ,*=user")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(m.Rules) != 3 {
+		t.Fatalf("expected 3 rules, got %d", len(m.Rules))
+	}
+	if m.Rules[0] != (RoleRule{Value: "attic-admins", Role: domain.UserRoleAdmin}) {
+		t.Errorf("unexpected rule %+v", m.Rules[0])
+	}
+	if m.Rules[1] != (RoleRule{Value: "attic-editors", Role: "editor"}) {
+		t.Errorf("unexpected rule %+v", m.Rules[1])
+	}
+}
+
+func Test_ParseRoleMapping_Empty_ReturnsNil(t *testing.T) {
+	m, err := ParseRoleMapping("groups", "")
+	if err != nil || m != nil {
+		t.Errorf("expected no mapping, got %+v, %v", m, err)
+	}
+}
+
+func Test_ParseRoleMapping_Invalid(t *testing.T) {
+	for _, rules := range []string{"attic-admins", "=admin", "attic-admins="} {
+		if _, err := ParseRoleMapping("groups", rules); err == nil {
+			t.Errorf("expected error for %q", rules)
+		}
+	}
+	if _, err := ParseRoleMapping("", "attic-admins=admin"); err == nil {
+		t.Error("expected error for missing claim")
+	}
+}
+
+func Test_RoleMapping_Role(t *testing.T) {
+	m, _ := ParseRoleMapping("groups", "attic-admins=admin,attic-editors=editor")
+	tests := []struct {
+		name   string
+		claims map[string]any
+		want   domain.UserRole
+		ok     bool
+	}{
+		{"list claim", map[string]any{"groups": []any{"staff", "attic-editors"}}, "editor", true},
+		{"first rule wins", map[string]any{"groups": []any{"attic-editors", "attic-admins"}}, domain.UserRoleAdmin, true},
+		{"string claim", map[string]any{"groups": "attic-admins"}, domain.UserRoleAdmin, true},
+		{"no match", map[string]any{"groups": []any{"staff"}}, "", false},
+		{"missing claim", map[string]any{}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := m.Role(tt.claims)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("expected %q/%v, got %q/%v", tt.want, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func Test_RoleMapping_Role_NestedClaimAndWildcard(t *testing.T) {
+	m, _ := ParseRoleMapping("realm_access.roles", "attic-admin=admin,*=user")
+	claims := map[string]any{"realm_access": map[string]any{"roles": []any{"attic-admin"}}}
+
+	if role, ok := m.Role(claims); !ok || role != domain.UserRoleAdmin {
+		t.Errorf("expected admin from nested claim, got %q", role)
+	}
+	if role, ok := m.Role(map[string]any{}); !ok || role != domain.UserRoleUser {
+		t.Errorf("expected wildcard to assign user, got %q", role)
+	}
+}
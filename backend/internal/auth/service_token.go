@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// ServiceTokenPrefix starts every service token, so they are told apart from
+// access tokens and easy to spot in leaked configuration
+const ServiceTokenPrefix = "attic_sa_"
+
+// serviceTokenTouchInterval limits how often the last use of a token is written
+const serviceTokenTouchInterval = time.Minute
+
+// ServiceTokenStore looks up the tokens of service accounts
+type ServiceTokenStore interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	GetServiceTokenByHash(ctx context.Context, tokenHash string) (*domain.ServiceToken, error)
+	TouchServiceToken(ctx context.Context, id uuid.UUID) error
+}
+
+// NewServiceToken returns a new service token and the hash to store; the
+// token itself is only shown once
+func NewServiceToken() (token, hash string) {
+	token = ServiceTokenPrefix + generateSecureToken(40)
+	return token, HashServiceToken(token)
+}
+
+// HashServiceToken returns the stored hash of a service token
+func HashServiceToken(token string) string {
+	return hashSessionToken(token)
+}
+
+// SetServiceTokens lets service accounts authenticate with their tokens in
+// every auth mode
+func (m *Middleware) SetServiceTokens(store ServiceTokenStore) {
+	m.serviceTokens = store
+}
+
+// serviceToken returns the service token of a request, if it has one
+func (m *Middleware) serviceToken(r *http.Request) (string, bool) {
+	if m.serviceTokens == nil {
+		return "", false
+	}
+	token, ok := bearerToken(r)
+	if !ok || !strings.HasPrefix(token, ServiceTokenPrefix) {
+		return "", false
+	}
+	return token, true
+}
+
+// authenticateServiceToken authenticates a service account; the claims carry
+// the token's scopes, which the Authorizer enforces on top of the role
+func (m *Middleware) authenticateServiceToken(w http.ResponseWriter, r *http.Request, next http.Handler, token string) {
+	ctx := r.Context()
+	stored, err := m.serviceTokens.GetServiceTokenByHash(ctx, HashServiceToken(token))
+	if err != nil {
+		slog.Error("failed to load service token", "error", err)
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	if stored == nil || stored.Expired() {
+		http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := m.serviceTokens.GetByID(ctx, stored.UserID)
+	if err != nil {
+		slog.Error("failed to load service account", "error", err)
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	if user == nil || !user.ServiceAccount || !user.IsActive() {
+		http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+		return
+	}
+
+	if stored.LastUsedAt == nil || time.Since(*stored.LastUsedAt) > serviceTokenTouchInterval {
+		if err := m.serviceTokens.TouchServiceToken(ctx, stored.ID); err != nil {
+			slog.Warn("failed to record service token use", "error", err)
+		}
+	}
+
+	name := user.Email
+	if user.DisplayName != nil {
+		name = *user.DisplayName
+	}
+	claims := &Claims{
+		Subject:     user.ID.String(),
+		Email:       user.Email,
+		Name:        name,
+		DisplayName: name,
+		Role:        user.Role,
+		Scopes:      slices.Clone(stored.Scopes),
+	}
+	if claims.Scopes == nil {
+		claims.Scopes = []domain.Permission{}
+	}
+
+	// The domain user is known, so OIDC and proxy modes skip provisioning
+	ctx = context.WithValue(ctx, UserContextKey, claims)
+	ctx = context.WithValue(ctx, DomainUserContextKey, user)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// hasScope reports whether the token of the request (if any) grants perm
+func hasScope(ctx context.Context, perm domain.Permission) bool {
+	claims := GetClaims(ctx)
+	return claims == nil || claims.Scopes == nil || slices.Contains(claims.Scopes, perm)
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type testServiceTokenStore struct {
+	users   map[uuid.UUID]*domain.User
+	tokens  map[string]*domain.ServiceToken
+	touched []uuid.UUID
+}
+
+func (s *testServiceTokenStore) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.users[id], nil
+}
+
+func (s *testServiceTokenStore) GetServiceTokenByHash(_ context.Context, hash string) (*domain.ServiceToken, error) {
+	return s.tokens[hash], nil
+}
+
+func (s *testServiceTokenStore) TouchServiceToken(_ context.Context, id uuid.UUID) error {
+	s.touched = append(s.touched, id)
+	return nil
+}
+
+func newTestServiceToken(store *testServiceTokenStore, user *domain.User, scopes ...domain.Permission) (string, *domain.ServiceToken) {
+	token, hash := NewServiceToken()
+	stored := &domain.ServiceToken{ID: uuid.New(), UserID: user.ID, TokenHash: hash, Scopes: scopes}
+	store.tokens[hash] = stored
+	return token, stored
+}
+
+func Test_Authenticate_ServiceToken(t *testing.T) {
+	account := &domain.User{ID: uuid.New(), Email: "service@attic.invalid", Role: domain.UserRoleUser, ServiceAccount: true}
+	person := &domain.User{ID: uuid.New(), Email: "jane@example.com", Role: domain.UserRoleAdmin}
+	store := &testServiceTokenStore{
+		users:  map[uuid.UUID]*domain.User{account.ID: account, person.ID: person},
+		tokens: map[string]*domain.ServiceToken{},
+	}
+	// OIDC mode without providers: only service tokens can authenticate
+	m := &Middleware{oidcEnabled: true}
+	m.SetServiceTokens(store)
+
+	valid, stored := newTestServiceToken(store, account, domain.PermissionAssetsRead)
+	expired, expiredToken := newTestServiceToken(store, account, domain.PermissionAssetsRead)
+	past := time.Now().Add(-time.Minute)
+	expiredToken.ExpiresAt = &past
+	personal, _ := newTestServiceToken(store, person, domain.PermissionAssetsRead)
+
+	var claims *Claims
+	var user *domain.User
+	handler := m.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, user = GetClaims(r.Context()), GetUser(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, bearerRequest(valid))
+	if rec.Code != http.StatusOK || claims == nil || user != account {
+		t.Fatalf("expected the service account, got %d with %+v", rec.Code, claims)
+	}
+	if len(claims.Scopes) != 1 || claims.Scopes[0] != domain.PermissionAssetsRead || claims.Role != domain.UserRoleUser {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if len(store.touched) != 1 || store.touched[0] != stored.ID {
+		t.Errorf("expected the token use to be recorded, got %v", store.touched)
+	}
+
+	for name, token := range map[string]string{
+		"expired":              expired,
+		"not a service user":   personal,
+		"unknown":              ServiceTokenPrefix + "unknown",
+		"without prefix (jwt)": strings.TrimPrefix(valid, ServiceTokenPrefix),
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, bearerRequest(token))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected 401, got %d", name, rec.Code)
+		}
+	}
+
+	now := time.Now()
+	account.DisabledAt = &now
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, bearerRequest(valid))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a disabled service account, got %d", rec.Code)
+	}
+}
+
+func Test_Authorizer_Require_ServiceTokenScopes(t *testing.T) {
+	a := newTestAuthorizer()
+	tests := []struct {
+		role   domain.UserRole
+		scopes []domain.Permission
+		perm   domain.Permission
+		want   int
+	}{
+		{domain.UserRoleUser, []domain.Permission{domain.PermissionAssetsRead}, domain.PermissionAssetsRead, http.StatusOK},
+		{domain.UserRoleUser, []domain.Permission{domain.PermissionAssetsRead}, domain.PermissionAssetsWrite, http.StatusForbidden},
+		{domain.UserRoleUser, []domain.Permission{}, domain.PermissionAssetsRead, http.StatusForbidden},
+		// Scopes never grant more than the role
+		{"viewer", []domain.Permission{domain.PermissionAssetsWrite}, domain.PermissionAssetsWrite, http.StatusForbidden},
+		{domain.UserRoleUser, nil, domain.PermissionAssetsWrite, http.StatusOK},
+	}
+	for _, tt := range tests {
+		called := false
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(context.WithValue(req.Context(), UserContextKey, &Claims{Subject: "u1", Role: tt.role, Scopes: tt.scopes}))
+		rec := httptest.NewRecorder()
+		a.Require(tt.perm)(next).ServeHTTP(rec, req)
+
+		if rec.Code != tt.want || called != (tt.want == http.StatusOK) {
+			t.Errorf("%s with scopes %v requiring %s: expected %d, got %d", tt.role, tt.scopes, tt.perm, tt.want, rec.Code)
+		}
+	}
+}
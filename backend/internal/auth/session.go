@@ -1,21 +1,48 @@
 package auth
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/ratelimit"
 )
 
 const (
 	sessionCookieNameLocal = "attic_session"
+
+	// sessionTouchInterval limits how often the last activity of a stored
+	// session is written
+	sessionTouchInterval = time.Minute
+
+	maxSessionUserAgentLength = 512
 )
 
+var errSessionRevoked = errors.New("session revoked")
+
+// SessionStore keeps local sessions server-side, so they can be listed and
+// revoked. The user of a stored session is loaded on every request, so role
+// changes and disabled accounts take effect immediately.
+type SessionStore interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	CreateSession(ctx context.Context, s *domain.UserSession) error
+	GetSessionByTokenHash(ctx context.Context, tokenHash string) (*domain.UserSession, error)
+	TouchSession(ctx context.Context, id uuid.UUID, ipAddress, userAgent string) error
+	ExtendSession(ctx context.Context, id uuid.UUID, expiresAt time.Time) error
+	DeleteSession(ctx context.Context, userID, id uuid.UUID) (bool, error)
+}
+
 // LocalSession represents a session for email/password auth
 type LocalSession struct {
 	UserID    uuid.UUID       `json:"user_id"`
@@ -24,13 +51,44 @@ type LocalSession struct {
 	Role      domain.UserRole `json:"role"`
 	ExpiresAt time.Time       `json:"expires_at"`
 	Token     string          `json:"token"`
+
+	// ID of the stored session (uuid.Nil without a session store)
+	ID uuid.UUID `json:"-"`
+
+	// MustChangePassword restricts the session to changing the password
+	// (only with a session store)
+	MustChangePassword bool `json:"-"`
+
+	// AccessToken marks sessions of bearer access tokens, which clients
+	// renew at /auth/refresh instead of by cookie
+	AccessToken bool `json:"-"`
+}
+
+// CookieSecureMode controls the Secure attribute of the session cookie
+type CookieSecureMode string
+
+const (
+	CookieSecureAuto   CookieSecureMode = "auto"   // Secure when the request arrived over HTTPS (incl. X-Forwarded-Proto)
+	CookieSecureAlways CookieSecureMode = "always" // For TLS-terminating proxies that don't forward the protocol
+	CookieSecureNever  CookieSecureMode = "never"
+)
+
+// CookieOptions configures the attributes of the session cookie
+type CookieOptions struct {
+	Name     string // Defaults to "attic_session"
+	Domain   string // Empty = host-only cookie
+	SameSite http.SameSite
+	Secure   CookieSecureMode
 }
 
 // SessionManager handles local session management
 type SessionManager struct {
-	secret          []byte
-	durationHours   int
-	cookieSecure    bool
+	secret        []byte
+	durationHours int
+	cookie        CookieOptions
+	rolling       bool         // Renew the session once half of its lifetime has passed
+	store         SessionStore // nil = stateless cookie sessions
+	challenges    challengeLog // Answered login challenges
 }
 
 // NewSessionManager creates a new session manager
@@ -44,6 +102,52 @@ func NewSessionManager(secret string, durationHours int) *SessionManager {
 	return &SessionManager{
 		secret:        secretBytes[:32],
 		durationHours: durationHours,
+		cookie: CookieOptions{
+			Name:     sessionCookieNameLocal,
+			SameSite: http.SameSiteLaxMode,
+			Secure:   CookieSecureAuto,
+		},
+	}
+}
+
+// SetCookieOptions overrides the session cookie attributes; zero values keep the defaults
+func (m *SessionManager) SetCookieOptions(opts CookieOptions) {
+	if opts.Name != "" {
+		m.cookie.Name = opts.Name
+	}
+	if opts.SameSite != 0 {
+		m.cookie.SameSite = opts.SameSite
+	}
+	if opts.Secure != "" {
+		m.cookie.Secure = opts.Secure
+	}
+	m.cookie.Domain = opts.Domain
+}
+
+// SetRollingRenewal enables sliding expiration: active sessions are re-issued
+// with a fresh expiry once half of their lifetime has passed
+func (m *SessionManager) SetRollingRenewal(enabled bool) {
+	m.rolling = enabled
+}
+
+// SetStore keeps sessions server-side: the cookie only carries an opaque
+// token, and a session is only valid while the store has it, so deleting it
+// signs the browser out
+func (m *SessionManager) SetStore(store SessionStore) {
+	m.store = store
+}
+
+// ParseSameSite converts "lax", "strict" or "none" into an http.SameSite mode
+func ParseSameSite(mode string) (http.SameSite, error) {
+	switch strings.ToLower(mode) {
+	case "", "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("invalid SameSite mode %q", mode)
 	}
 }
 
@@ -56,41 +160,114 @@ func (m *SessionManager) CreateSession(w http.ResponseWriter, r *http.Request, u
 		name = *user.DisplayName
 	}
 
-	session := LocalSession{
+	session := &LocalSession{
 		UserID:    user.ID,
 		Email:     user.Email,
 		Name:      name,
 		Role:      user.Role,
-		ExpiresAt: time.Now().Add(time.Duration(m.durationHours) * time.Hour),
+		ExpiresAt: time.Now().Add(m.duration()),
 		Token:     token,
 	}
 
-	data, err := json.Marshal(session)
-	if err != nil {
-		return fmt.Errorf("marshaling session: %w", err)
+	if m.store != nil {
+		stored := &domain.UserSession{
+			UserID:    user.ID,
+			TokenHash: hashSessionToken(token),
+			IPAddress: ratelimit.ClientIP(r),
+			UserAgent: truncateUserAgent(r.UserAgent()),
+			ExpiresAt: session.ExpiresAt,
+		}
+		if err := m.store.CreateSession(r.Context(), stored); err != nil {
+			return fmt.Errorf("storing session: %w", err)
+		}
+		session.ID = stored.ID
+		session.MustChangePassword = stored.MustChangePassword
+	}
+
+	return m.writeSession(w, r, session)
+}
+
+// RenewSession re-issues the session cookie with a fresh expiry when rolling
+// renewal is enabled and at least half of the session lifetime has passed.
+// It reports whether the session was renewed.
+func (m *SessionManager) RenewSession(w http.ResponseWriter, r *http.Request, session *LocalSession) (bool, error) {
+	if !m.rolling || session == nil || session.AccessToken {
+		return false, nil
+	}
+	if time.Until(session.ExpiresAt) > m.duration()/2 {
+		return false, nil
+	}
+
+	renewed := *session
+	renewed.ExpiresAt = time.Now().Add(m.duration())
+	if m.store != nil && session.ID != uuid.Nil {
+		if err := m.store.ExtendSession(r.Context(), session.ID, renewed.ExpiresAt); err != nil {
+			return false, fmt.Errorf("extending session: %w", err)
+		}
+	}
+	if err := m.writeSession(w, r, &renewed); err != nil {
+		return false, err
 	}
+	*session = renewed
+	return true, nil
+}
 
-	encoded := base64.StdEncoding.EncodeToString(data)
+func (m *SessionManager) writeSession(w http.ResponseWriter, r *http.Request, session *LocalSession) error {
+	// Stored sessions keep their data server-side
+	encoded := session.Token
+	if m.store == nil {
+		data, err := json.Marshal(session)
+		if err != nil {
+			return fmt.Errorf("marshaling session: %w", err)
+		}
+		encoded = base64.StdEncoding.EncodeToString(data)
+	}
 
 	http.SetCookie(w, &http.Cookie{
-		Name:     sessionCookieNameLocal,
+		Name:     m.cookie.Name,
 		Value:    encoded,
 		Path:     "/",
+		Domain:   m.cookie.Domain,
 		MaxAge:   m.durationHours * 3600,
 		HttpOnly: true,
-		Secure:   r.TLS != nil,
-		SameSite: http.SameSiteLaxMode,
+		Secure:   m.secure(r),
+		SameSite: m.cookie.SameSite,
 	})
 
 	return nil
 }
 
-// GetSession retrieves the current session from cookie
+func (m *SessionManager) duration() time.Duration {
+	return time.Duration(m.durationHours) * time.Hour
+}
+
+// secure reports whether the cookie should carry the Secure attribute.
+// SameSite=None cookies are rejected by browsers unless they are Secure.
+func (m *SessionManager) secure(r *http.Request) bool {
+	switch m.cookie.Secure {
+	case CookieSecureAlways:
+		return true
+	case CookieSecureNever:
+		return false
+	default:
+		return isSecureRequest(r) || m.cookie.SameSite == http.SameSiteNoneMode
+	}
+}
+
+// GetSession retrieves the current session from the bearer access token of
+// an API client (see IssueTokens) or else from the cookie
 func (m *SessionManager) GetSession(r *http.Request) (*LocalSession, error) {
-	cookie, err := r.Cookie(sessionCookieNameLocal)
+	if token, ok := bearerToken(r); ok {
+		return m.VerifyAccessToken(token)
+	}
+
+	cookie, err := r.Cookie(m.cookie.Name)
 	if err != nil {
 		return nil, err
 	}
+	if m.store != nil {
+		return m.loadStored(r, cookieToken(cookie.Value))
+	}
 
 	data, err := base64.StdEncoding.DecodeString(cookie.Value)
 	if err != nil {
@@ -109,12 +286,81 @@ func (m *SessionManager) GetSession(r *http.Request) (*LocalSession, error) {
 	return &session, nil
 }
 
+// loadStored returns the stored session of a token, unless it was revoked or
+// its user disabled, and records its activity
+func (m *SessionManager) loadStored(r *http.Request, token string) (*LocalSession, error) {
+	stored, err := m.store.GetSessionByTokenHash(r.Context(), hashSessionToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("loading session: %w", err)
+	}
+	if stored == nil {
+		return nil, errSessionRevoked
+	}
+	user, err := m.store.GetByID(r.Context(), stored.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("loading session user: %w", err)
+	}
+	if user == nil || !user.IsActive() {
+		return nil, errSessionRevoked
+	}
+
+	if time.Since(stored.LastSeenAt) >= sessionTouchInterval {
+		if err := m.store.TouchSession(r.Context(), stored.ID, ratelimit.ClientIP(r), truncateUserAgent(r.UserAgent())); err != nil {
+			slog.Warn("failed to record session activity", "error", err)
+		}
+	}
+
+	name := ""
+	if user.DisplayName != nil {
+		name = *user.DisplayName
+	}
+	return &LocalSession{
+		UserID:             user.ID,
+		Email:              user.Email,
+		Name:               name,
+		Role:               user.Role,
+		ExpiresAt:          stored.ExpiresAt,
+		Token:              token,
+		ID:                 stored.ID,
+		MustChangePassword: stored.MustChangePassword,
+	}, nil
+}
+
+// cookieToken returns the session token of a cookie value. Cookies written
+// before sessions were stored server-side carry it inside the session JSON.
+func cookieToken(value string) string {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return value
+	}
+	var legacy LocalSession
+	if err := json.Unmarshal(data, &legacy); err != nil || legacy.Token == "" {
+		return value
+	}
+	return legacy.Token
+}
+
+// EndSession signs the browser out, revoking the stored session
+func (m *SessionManager) EndSession(w http.ResponseWriter, r *http.Request) error {
+	m.ClearSession(w)
+	if m.store == nil {
+		return nil
+	}
+	session, err := m.GetSession(r)
+	if err != nil {
+		return nil // Nothing to revoke
+	}
+	_, err = m.store.DeleteSession(r.Context(), session.UserID, session.ID)
+	return err
+}
+
 // ClearSession removes the session cookie
 func (m *SessionManager) ClearSession(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
-		Name:     sessionCookieNameLocal,
+		Name:     m.cookie.Name,
 		Value:    "",
 		Path:     "/",
+		Domain:   m.cookie.Domain,
 		MaxAge:   -1,
 		HttpOnly: true,
 	})
@@ -137,8 +383,22 @@ func (m *SessionManager) GetSessionInfo(r *http.Request) map[string]any {
 			"name":  session.Name,
 			"role":  session.Role,
 		},
-		"expires_at": session.ExpiresAt,
+		"expires_at":           session.ExpiresAt,
+		"must_change_password": session.MustChangePassword,
+	}
+}
+
+// hashSessionToken returns the hex SHA-256 of a session token, as stored
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncateUserAgent(ua string) string {
+	if len(ua) > maxSessionUserAgentLength {
+		return ua[:maxSessionUserAgentLength]
 	}
+	return ua
 }
 
 func generateSecureToken(length int) string {
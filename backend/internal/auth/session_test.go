@@ -1,8 +1,12 @@
 package auth
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -305,3 +309,337 @@ func Test_CreateSession_UserWithoutDisplayName_HandlesNil(t *testing.T) {
 		t.Errorf("expected empty name for nil display name, got '%s'", session.Name)
 	}
 }
+
+func Test_CreateSession_UsesCookieOptions(t *testing.T) {
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	manager.SetCookieOptions(CookieOptions{
+		Name:     "custom_session",
+		Domain:   "example.com",
+		SameSite: http.SameSiteStrictMode,
+		Secure:   CookieSecureAlways,
+	})
+	user := &domain.User{ID: uuid.New(), Email: "test@example.com", Role: domain.UserRoleUser}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	if err := manager.CreateSession(rec, req, user); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected 1 cookie, got %d", len(cookies))
+	}
+	c := cookies[0]
+	if c.Name != "custom_session" {
+		t.Errorf("expected cookie name 'custom_session', got %q", c.Name)
+	}
+	if c.Domain != "example.com" {
+		t.Errorf("expected domain 'example.com', got %q", c.Domain)
+	}
+	if c.SameSite != http.SameSiteStrictMode {
+		t.Errorf("expected SameSite=Strict, got %v", c.SameSite)
+	}
+	if !c.Secure {
+		t.Error("expected cookie to be secure")
+	}
+
+	getReq := httptest.NewRequest(http.MethodGet, "/", nil)
+	getReq.AddCookie(c)
+	if _, err := manager.GetSession(getReq); err != nil {
+		t.Errorf("expected session to be read from custom cookie, got: %v", err)
+	}
+}
+
+func Test_CreateSession_SecureAutoHonorsForwardedProto(t *testing.T) {
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	user := &domain.User{ID: uuid.New(), Email: "test@example.com", Role: domain.UserRoleUser}
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec := httptest.NewRecorder()
+	manager.CreateSession(rec, req, user)
+
+	if c := rec.Result().Cookies()[0]; !c.Secure {
+		t.Error("expected cookie to be secure behind an HTTPS proxy")
+	}
+}
+
+func Test_RenewSession_Rolling(t *testing.T) {
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 2)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	fresh := &LocalSession{UserID: uuid.New(), ExpiresAt: time.Now().Add(2 * time.Hour)}
+	old := &LocalSession{UserID: uuid.New(), ExpiresAt: time.Now().Add(30 * time.Minute)}
+
+	// Disabled by default
+	rec := httptest.NewRecorder()
+	if renewed, _ := manager.RenewSession(rec, req, old); renewed {
+		t.Error("expected no renewal when rolling sessions are disabled")
+	}
+
+	manager.SetRollingRenewal(true)
+
+	rec = httptest.NewRecorder()
+	if renewed, _ := manager.RenewSession(rec, req, fresh); renewed {
+		t.Error("expected fresh session not to be renewed")
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("expected no cookie for fresh session")
+	}
+
+	rec = httptest.NewRecorder()
+	renewed, err := manager.RenewSession(rec, req, old)
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if !renewed {
+		t.Fatal("expected old session to be renewed")
+	}
+	if time.Until(old.ExpiresAt) < 110*time.Minute {
+		t.Errorf("expected renewed expiry around 2h from now, got %v", old.ExpiresAt)
+	}
+	if len(rec.Result().Cookies()) != 1 {
+		t.Error("expected renewed session cookie")
+	}
+}
+
+func Test_ParseSameSite(t *testing.T) {
+	tests := map[string]http.SameSite{
+		"":       http.SameSiteLaxMode,
+		"lax":    http.SameSiteLaxMode,
+		"Strict": http.SameSiteStrictMode,
+		"none":   http.SameSiteNoneMode,
+	}
+	for in, want := range tests {
+		got, err := ParseSameSite(in)
+		if err != nil || got != want {
+			t.Errorf("ParseSameSite(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := ParseSameSite("sometimes"); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+}
+
+// memorySessionStore implements SessionStore in memory
+type memorySessionStore struct {
+	sessions map[string]*domain.UserSession // By token hash
+	users    map[uuid.UUID]*domain.User
+	touched  int
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{sessions: map[string]*domain.UserSession{}, users: map[uuid.UUID]*domain.User{}}
+}
+
+func (s *memorySessionStore) GetByID(_ context.Context, id uuid.UUID) (*domain.User, error) {
+	return s.users[id], nil
+}
+
+func (s *memorySessionStore) CreateSession(_ context.Context, session *domain.UserSession) error {
+	session.ID = uuid.New()
+	session.LastSeenAt = time.Now()
+	s.sessions[session.TokenHash] = session
+	return nil
+}
+
+func (s *memorySessionStore) GetSessionByTokenHash(_ context.Context, tokenHash string) (*domain.UserSession, error) {
+	return s.sessions[tokenHash], nil
+}
+
+func (s *memorySessionStore) TouchSession(_ context.Context, id uuid.UUID, ipAddress, userAgent string) error {
+	for _, session := range s.sessions {
+		if session.ID == id {
+			session.LastSeenAt, session.IPAddress, session.UserAgent = time.Now(), ipAddress, userAgent
+			s.touched++
+		}
+	}
+	return nil
+}
+
+func (s *memorySessionStore) ExtendSession(_ context.Context, id uuid.UUID, expiresAt time.Time) error {
+	for _, session := range s.sessions {
+		if session.ID == id {
+			session.ExpiresAt = expiresAt
+		}
+	}
+	return nil
+}
+
+func (s *memorySessionStore) DeleteSession(_ context.Context, userID, id uuid.UUID) (bool, error) {
+	for hash, session := range s.sessions {
+		if session.ID == id && session.UserID == userID {
+			delete(s.sessions, hash)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// signIn creates a session and returns a request carrying its cookie
+func signIn(t *testing.T, manager *SessionManager, user *domain.User) *http.Request {
+	t.Helper()
+	if store, ok := manager.store.(*memorySessionStore); ok {
+		store.users[user.ID] = user
+	}
+	login := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+	login.RemoteAddr = "192.0.2.1:5000"
+	login.Header.Set("User-Agent", "Firefox")
+	rec := httptest.NewRecorder()
+	if err := manager.CreateSession(rec, login, user); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.RemoteAddr = "198.51.100.7:5000"
+	req.Header.Set("User-Agent", "Safari")
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func Test_SessionStore_CreateAndRevoke(t *testing.T) {
+	store := newMemorySessionStore()
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	manager.SetStore(store)
+	user := &domain.User{ID: uuid.New(), Email: "test@example.com", Role: domain.UserRoleUser}
+
+	req := signIn(t, manager, user)
+
+	if len(store.sessions) != 1 {
+		t.Fatalf("expected 1 stored session, got %d", len(store.sessions))
+	}
+	for hash, s := range store.sessions {
+		if s.IPAddress != "192.0.2.1" || s.UserAgent != "Firefox" || s.UserID != user.ID {
+			t.Errorf("unexpected stored session %+v", s)
+		}
+		if len(hash) != 64 {
+			t.Errorf("expected a SHA-256 hex token hash, got %q", hash)
+		}
+	}
+
+	session, err := manager.GetSession(req)
+	if err != nil {
+		t.Fatalf("expected valid session, got %v", err)
+	}
+	if session.ID == uuid.Nil {
+		t.Error("expected stored session ID")
+	}
+
+	if ok, _ := store.DeleteSession(context.Background(), user.ID, session.ID); !ok {
+		t.Fatal("expected session to be deleted")
+	}
+	if _, err := manager.GetSession(req); err != errSessionRevoked {
+		t.Errorf("expected errSessionRevoked, got %v", err)
+	}
+}
+
+func Test_SessionStore_TouchesIdleSessions(t *testing.T) {
+	store := newMemorySessionStore()
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	manager.SetStore(store)
+	req := signIn(t, manager, &domain.User{ID: uuid.New(), Email: "test@example.com"})
+
+	// Recently created sessions are not written on every request
+	manager.GetSession(req)
+	if store.touched != 0 {
+		t.Fatalf("expected no activity update, got %d", store.touched)
+	}
+
+	for _, s := range store.sessions {
+		s.LastSeenAt = time.Now().Add(-2 * sessionTouchInterval)
+	}
+	manager.GetSession(req)
+	if store.touched != 1 {
+		t.Fatalf("expected activity update, got %d", store.touched)
+	}
+	for _, s := range store.sessions {
+		if s.IPAddress != "198.51.100.7" || s.UserAgent != "Safari" {
+			t.Errorf("expected latest IP and user agent, got %+v", s)
+		}
+	}
+}
+
+func Test_SessionStore_EndSession(t *testing.T) {
+	store := newMemorySessionStore()
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	manager.SetStore(store)
+	req := signIn(t, manager, &domain.User{ID: uuid.New(), Email: "test@example.com"})
+
+	rec := httptest.NewRecorder()
+	if err := manager.EndSession(rec, req); err != nil {
+		t.Fatalf("EndSession: %v", err)
+	}
+
+	if len(store.sessions) != 0 {
+		t.Error("expected stored session to be revoked")
+	}
+	if cookies := rec.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Error("expected session cookie to be cleared")
+	}
+}
+
+func Test_SessionStore_CookieCarriesOnlyToken(t *testing.T) {
+	store := newMemorySessionStore()
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	manager.SetStore(store)
+	user := &domain.User{ID: uuid.New(), Email: "test@example.com", Role: domain.UserRoleUser}
+	req := signIn(t, manager, user)
+
+	cookie, _ := req.Cookie("attic_session")
+	if strings.Contains(cookie.Value, "{") || cookieToken(cookie.Value) != cookie.Value {
+		t.Errorf("expected an opaque token cookie, got %q", cookie.Value)
+	}
+	if _, ok := store.sessions[hashSessionToken(cookie.Value)]; !ok {
+		t.Fatal("expected the cookie token to identify the stored session")
+	}
+
+	// Session data comes from the store, so a role change applies at once
+	user.Role = domain.UserRoleAdmin
+	session, err := manager.GetSession(req)
+	if err != nil {
+		t.Fatalf("expected valid session, got %v", err)
+	}
+	if session.Role != domain.UserRoleAdmin || session.Email != "test@example.com" {
+		t.Errorf("expected session of the stored user, got %+v", session)
+	}
+
+	// A rotated secret does not invalidate stored sessions
+	rotated := NewSessionManager("another-secret-key-32-bytes-long", 24)
+	rotated.SetStore(store)
+	if _, err := rotated.GetSession(req); err != nil {
+		t.Errorf("expected session to survive secret rotation, got %v", err)
+	}
+}
+
+func Test_SessionStore_AcceptsLegacyCookie(t *testing.T) {
+	store := newMemorySessionStore()
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	manager.SetStore(store)
+	user := &domain.User{ID: uuid.New(), Email: "test@example.com"}
+	req := signIn(t, manager, user)
+	cookie, _ := req.Cookie("attic_session")
+
+	data, _ := json.Marshal(LocalSession{UserID: user.ID, Email: user.Email, ExpiresAt: time.Now().Add(time.Hour), Token: cookie.Value})
+	legacy := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	legacy.AddCookie(&http.Cookie{Name: "attic_session", Value: base64.StdEncoding.EncodeToString(data)})
+	if _, err := manager.GetSession(legacy); err != nil {
+		t.Errorf("expected legacy cookie to be accepted, got %v", err)
+	}
+}
+
+func Test_SessionStore_DisabledUserIsSignedOut(t *testing.T) {
+	store := newMemorySessionStore()
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	manager.SetStore(store)
+	user := &domain.User{ID: uuid.New(), Email: "test@example.com"}
+	req := signIn(t, manager, user)
+
+	now := time.Now()
+	user.DisabledAt = &now
+	if _, err := manager.GetSession(req); err != errSessionRevoked {
+		t.Errorf("expected errSessionRevoked, got %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/ratelimit"
+)
+
+// accessTokenTTL is how long an access token is valid. Access tokens are
+// verified without a database lookup, so revoking their session (or disabling
+// the user) only takes effect once they expire.
+const accessTokenTTL = 15 * time.Minute
+
+// accessTokenHeader is the encoded JOSE header of every access token
+var accessTokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+var (
+	// ErrTokensUnavailable is returned when tokens are requested without a
+	// session store to keep refresh tokens in
+	ErrTokensUnavailable = errors.New("token authentication requires server-side sessions")
+
+	// ErrInvalidToken is returned for forged, expired or revoked tokens
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// TokenPair is issued to API clients (mobile apps, CLIs) that authenticate
+// with bearer tokens instead of the session cookie
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"` // Send as "Authorization: Bearer <token>"
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int       `json:"expires_in"`    // Seconds until the access token expires
+	RefreshToken     string    `json:"refresh_token"` // Exchange at /auth/refresh; single use
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// accessClaims are the claims of an access token (a JWT signed with HS256)
+type accessClaims struct {
+	Subject            uuid.UUID       `json:"sub"`
+	SessionID          uuid.UUID       `json:"sid"`
+	Email              string          `json:"email"`
+	Name               string          `json:"name,omitempty"`
+	Role               domain.UserRole `json:"role"`
+	MustChangePassword bool            `json:"must_change_password,omitempty"`
+	IssuedAt           int64           `json:"iat"`
+	ExpiresAt          int64           `json:"exp"`
+}
+
+// IssueTokens creates a stored session for an API client and returns its
+// tokens. The refresh token is the session token, so the session is listed
+// and can be revoked like a browser session.
+func (m *SessionManager) IssueTokens(r *http.Request, user *domain.User) (*TokenPair, error) {
+	name := ""
+	if user.DisplayName != nil {
+		name = *user.DisplayName
+	}
+	return m.issueTokens(r, &LocalSession{
+		UserID: user.ID,
+		Email:  user.Email,
+		Name:   name,
+		Role:   user.Role,
+	})
+}
+
+// RefreshTokens exchanges a refresh token for new tokens. The refresh token
+// is rotated: its session is replaced by a new one with a fresh expiry, and
+// the user is reloaded so role changes and disabled accounts take effect.
+func (m *SessionManager) RefreshTokens(r *http.Request, refreshToken string) (*TokenPair, error) {
+	if m.store == nil {
+		return nil, ErrTokensUnavailable
+	}
+
+	session, err := m.loadStored(r, refreshToken)
+	if errors.Is(err, errSessionRevoked) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := m.store.DeleteSession(r.Context(), session.UserID, session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("revoking session: %w", err)
+	}
+	if !deleted {
+		return nil, ErrInvalidToken // Refreshed concurrently
+	}
+
+	return m.issueTokens(r, session)
+}
+
+// VerifyAccessToken checks an access token and returns its session
+func (m *SessionManager) VerifyAccessToken(token string) (*LocalSession, error) {
+	signed, sig, ok := cutLast(token, ".")
+	if !ok || !strings.HasPrefix(signed, accessTokenHeader+".") ||
+		!hmac.Equal([]byte(sig), []byte(m.signAccessToken(signed))) {
+		return nil, ErrInvalidToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(signed, accessTokenHeader+"."))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var c accessClaims
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidToken
+	}
+	expiresAt := time.Unix(c.ExpiresAt, 0)
+	if time.Now().After(expiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	return &LocalSession{
+		UserID:             c.Subject,
+		Email:              c.Email,
+		Name:               c.Name,
+		Role:               c.Role,
+		ExpiresAt:          expiresAt,
+		ID:                 c.SessionID,
+		MustChangePassword: c.MustChangePassword,
+		AccessToken:        true,
+	}, nil
+}
+
+// issueTokens stores a new session for the user of session and returns its
+// tokens
+func (m *SessionManager) issueTokens(r *http.Request, session *LocalSession) (*TokenPair, error) {
+	if m.store == nil {
+		return nil, ErrTokensUnavailable
+	}
+
+	refreshToken := generateSecureToken(32)
+	stored := &domain.UserSession{
+		UserID:    session.UserID,
+		TokenHash: hashSessionToken(refreshToken),
+		IPAddress: ratelimit.ClientIP(r),
+		UserAgent: truncateUserAgent(r.UserAgent()),
+		ExpiresAt: time.Now().Add(m.duration()),
+	}
+	if err := m.store.CreateSession(r.Context(), stored); err != nil {
+		return nil, fmt.Errorf("storing session: %w", err)
+	}
+
+	now := time.Now()
+	data, err := json.Marshal(accessClaims{
+		Subject:            session.UserID,
+		SessionID:          stored.ID,
+		Email:              session.Email,
+		Name:               session.Name,
+		Role:               session.Role,
+		MustChangePassword: stored.MustChangePassword,
+		IssuedAt:           now.Unix(),
+		ExpiresAt:          now.Add(accessTokenTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	signed := accessTokenHeader + "." + base64.RawURLEncoding.EncodeToString(data)
+
+	return &TokenPair{
+		AccessToken:      signed + "." + m.signAccessToken(signed),
+		TokenType:        "Bearer",
+		ExpiresIn:        int(accessTokenTTL.Seconds()),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, nil
+}
+
+// signAccessToken signs with a key derived from the session secret, so access
+// tokens and login challenges can't be swapped
+func (m *SessionManager) signAccessToken(signed string) string {
+	key := hmac.New(sha256.New, m.secret)
+	key.Write([]byte("access-token"))
+	mac := hmac.New(sha256.New, key.Sum(nil))
+	mac.Write([]byte(signed))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
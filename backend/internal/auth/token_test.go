@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func newTokenManager(t *testing.T) (*SessionManager, *memorySessionStore, *domain.User) {
+	t.Helper()
+	store := newMemorySessionStore()
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	manager.SetStore(store)
+	name := "Test User"
+	user := &domain.User{ID: uuid.New(), Email: "test@example.com", DisplayName: &name, Role: domain.UserRoleUser}
+	store.users[user.ID] = user
+	return manager, store, user
+}
+
+func bearerRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func Test_IssueTokens_AccessTokenAuthenticates(t *testing.T) {
+	manager, store, user := newTokenManager(t)
+
+	tokens, err := manager.IssueTokens(httptest.NewRequest(http.MethodPost, "/auth/token", nil), user)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+	if tokens.TokenType != "Bearer" || tokens.ExpiresIn != int(accessTokenTTL.Seconds()) || tokens.RefreshToken == "" {
+		t.Errorf("unexpected tokens %+v", tokens)
+	}
+	if strings.Count(tokens.AccessToken, ".") != 2 {
+		t.Errorf("expected a JWT, got %q", tokens.AccessToken)
+	}
+	if _, ok := store.sessions[hashSessionToken(tokens.RefreshToken)]; !ok {
+		t.Fatal("expected the refresh token to identify a stored session")
+	}
+
+	session, err := manager.GetSession(bearerRequest(tokens.AccessToken))
+	if err != nil {
+		t.Fatalf("expected valid access token, got %v", err)
+	}
+	if session.UserID != user.ID || session.Email != user.Email || session.Name != "Test User" || session.Role != domain.UserRoleUser {
+		t.Errorf("unexpected session %+v", session)
+	}
+	if session.ID == uuid.Nil || !session.AccessToken {
+		t.Errorf("expected an access token session of the stored session, got %+v", session)
+	}
+}
+
+func Test_VerifyAccessToken_RejectsForgedAndExpiredTokens(t *testing.T) {
+	manager, _, user := newTokenManager(t)
+	tokens, err := manager.IssueTokens(httptest.NewRequest(http.MethodPost, "/auth/token", nil), user)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+
+	other := NewSessionManager("another-secret-key-32-bytes-long", 24)
+	if _, err := other.VerifyAccessToken(tokens.AccessToken); err != ErrInvalidToken {
+		t.Errorf("expected token of another secret to be rejected, got %v", err)
+	}
+
+	challenge, _ := manager.CreateChallenge(user.ID, ChallengeTwoFactor)
+	if _, err := manager.VerifyAccessToken(challenge); err != ErrInvalidToken {
+		t.Errorf("expected login challenge to be rejected, got %v", err)
+	}
+
+	parts := strings.Split(tokens.AccessToken, ".")
+	tampered := parts[0] + "." + parts[1] + "x." + parts[2]
+	if _, err := manager.VerifyAccessToken(tampered); err != ErrInvalidToken {
+		t.Errorf("expected tampered token to be rejected, got %v", err)
+	}
+
+	data, _ := json.Marshal(accessClaims{Subject: user.ID, ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	signed := accessTokenHeader + "." + base64.RawURLEncoding.EncodeToString(data)
+	if _, err := manager.VerifyAccessToken(signed + "." + manager.signAccessToken(signed)); err != ErrInvalidToken {
+		t.Errorf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func Test_RefreshTokens_RotatesRefreshToken(t *testing.T) {
+	manager, store, user := newTokenManager(t)
+	req := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+	tokens, err := manager.IssueTokens(req, user)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+
+	// The user is reloaded, so a role change applies to the new access token
+	user.Role = domain.UserRoleAdmin
+	refreshed, err := manager.RefreshTokens(req, tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+	if refreshed.RefreshToken == tokens.RefreshToken {
+		t.Error("expected a new refresh token")
+	}
+	if len(store.sessions) != 1 {
+		t.Errorf("expected the old session to be replaced, got %d sessions", len(store.sessions))
+	}
+	session, err := manager.VerifyAccessToken(refreshed.AccessToken)
+	if err != nil || session.Role != domain.UserRoleAdmin {
+		t.Errorf("expected admin access token, got %+v, %v", session, err)
+	}
+
+	if _, err := manager.RefreshTokens(req, tokens.RefreshToken); err != ErrInvalidToken {
+		t.Errorf("expected used refresh token to be rejected, got %v", err)
+	}
+
+	now := time.Now()
+	user.DisabledAt = &now
+	if _, err := manager.RefreshTokens(req, refreshed.RefreshToken); err != ErrInvalidToken {
+		t.Errorf("expected refresh of a disabled user to be rejected, got %v", err)
+	}
+}
+
+func Test_IssueTokens_RequiresStore(t *testing.T) {
+	manager := NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	user := &domain.User{ID: uuid.New(), Email: "test@example.com"}
+	if _, err := manager.IssueTokens(httptest.NewRequest(http.MethodPost, "/auth/token", nil), user); err != ErrTokensUnavailable {
+		t.Errorf("expected ErrTokensUnavailable, got %v", err)
+	}
+}
+
+func Test_Authenticate_BearerAccessToken(t *testing.T) {
+	manager, _, user := newTokenManager(t)
+	m := &Middleware{sessionManager: manager}
+	tokens, err := manager.IssueTokens(httptest.NewRequest(http.MethodPost, "/auth/token", nil), user)
+	if err != nil {
+		t.Fatalf("IssueTokens: %v", err)
+	}
+
+	var claims *Claims
+	handler := m.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims = GetClaims(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, bearerRequest(tokens.AccessToken))
+	if rec.Code != http.StatusOK || claims == nil || claims.Subject != user.ID.String() {
+		t.Fatalf("expected authenticated request, got %d with %+v", rec.Code, claims)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("expected no session cookie for bearer requests")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, bearerRequest("not-a-token"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an invalid token, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 test key of RFC 6238 ("12345678901234567890")
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func Test_TOTPCode_RFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("at %d: expected %s, got %s", tt.unix, tt.want, got)
+		}
+	}
+}
+
+func Test_MatchTOTP_AllowsOneStepOfDrift(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	previous, _ := TOTPCode(rfc6238Secret, now.Add(-30*time.Second))
+	old, _ := TOTPCode(rfc6238Secret, now.Add(-90*time.Second))
+
+	counter, ok := MatchTOTP(rfc6238Secret, previous, now)
+	if !ok {
+		t.Fatal("expected previous code to be accepted")
+	}
+	if want := now.Unix()/30 - 1; counter != want {
+		t.Errorf("expected counter %d, got %d", want, counter)
+	}
+	if ValidateTOTP(rfc6238Secret, old, now) {
+		t.Error("expected code from three steps ago to be rejected")
+	}
+	if ValidateTOTP(rfc6238Secret, "12345", now) || ValidateTOTP("not base32!", "005924", now) {
+		t.Error("expected malformed input to be rejected")
+	}
+}
+
+func Test_GenerateTOTPSecret_RoundTrip(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Now()
+	code, err := TOTPCode(secret, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ValidateTOTP(secret, code, now) {
+		t.Error("expected generated code to validate")
+	}
+}
+
+func Test_TOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Attic", "jane@example.com", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/Attic:jane@example.com?") {
+		t.Errorf("unexpected URI %q", uri)
+	}
+	for _, param := range []string{"secret=ABC", "issuer=Attic", "digits=6", "period=30"} {
+		if !strings.Contains(uri, param) {
+			t.Errorf("expected %q in %q", param, uri)
+		}
+	}
+}
+
+func Test_GenerateBackupCodes(t *testing.T) {
+	codes, hashes, err := GenerateBackupCodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != backupCodeCount || len(hashes) != backupCodeCount {
+		t.Fatalf("expected %d codes, got %d", backupCodeCount, len(codes))
+	}
+	// Codes can be entered without the dash and in upper case
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	if HashBackupCode(typed) != hashes[0] {
+		t.Error("expected hash to ignore case and dashes")
+	}
+}
@@ -18,8 +18,9 @@ const (
 
 // UserProvisioner handles automatic user creation
 type UserProvisioner struct {
-	userRepo *repository.UserRepository
-	orgID    uuid.UUID
+	userRepo    *repository.UserRepository
+	orgID       uuid.UUID
+	roleMapping *RoleMapping // nil = roles are managed in Attic only
 }
 
 // NewUserProvisioner creates a new user provisioner
@@ -30,12 +31,17 @@ func NewUserProvisioner(userRepo *repository.UserRepository, orgID uuid.UUID) *U
 	}
 }
 
+// SetRoleMapping assigns roles from OIDC token claims whenever a user is provisioned
+func (p *UserProvisioner) SetRoleMapping(m *RoleMapping) {
+	p.roleMapping = m
+}
+
 // Provision is middleware that ensures a domain user exists for the authenticated user
 func (p *UserProvisioner) Provision(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		claims := GetClaims(r.Context())
-		if claims == nil {
-			// No authenticated user, continue without provisioning
+		if claims == nil || GetUser(r.Context()) != nil {
+			// No authenticated user or a service account, nothing to provision
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -58,12 +64,43 @@ func (p *UserProvisioner) Provision(next http.Handler) http.Handler {
 			slog.Info("provisioned new user", "user_id", user.ID, "email", user.Email)
 		}
 
+		if err := p.applyRoleMapping(r.Context(), user, claims); err != nil {
+			slog.Error("failed to apply role mapping", "error", err, "user_id", user.ID)
+			http.Error(w, `{"error":"failed to provision user"}`, http.StatusInternalServerError)
+			return
+		}
+
+		if !user.IsActive() {
+			http.Error(w, `{"error":"account disabled"}`, http.StatusForbidden)
+			return
+		}
+
 		// Add domain user to context
 		ctx := context.WithValue(r.Context(), DomainUserContextKey, user)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// applyRoleMapping updates the user's role to the one mapped from the token
+// claims, if a rule matches
+func (p *UserProvisioner) applyRoleMapping(ctx context.Context, user *domain.User, claims *Claims) error {
+	if p.roleMapping == nil || claims.Raw == nil {
+		return nil
+	}
+	role, ok := p.roleMapping.Role(claims.Raw)
+	if !ok || role == user.Role {
+		return nil
+	}
+
+	previous := user.Role
+	user.Role = role
+	if err := p.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	slog.Info("updated user role from OIDC claims", "user_id", user.ID, "from", previous, "to", role)
+	return nil
+}
+
 // GetUser extracts the domain user from context
 func GetUser(ctx context.Context) *domain.User {
 	user, ok := ctx.Value(DomainUserContextKey).(*domain.User)
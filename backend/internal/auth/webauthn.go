@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// COSE algorithm identifiers of the supported passkey signature schemes
+const (
+	COSEAlgES256 = -7   // ECDSA P-256 with SHA-256
+	COSEAlgEdDSA = -8   // Ed25519
+	COSEAlgRS256 = -257 // RSASSA-PKCS1-v1_5 with SHA-256
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+)
+
+// ErrWebAuthn wraps all WebAuthn verification failures
+var ErrWebAuthn = errors.New("webauthn verification failed")
+
+// WebAuthn verifies passkey registrations and assertions for one relying party.
+//
+// Browsers hand the credential public key to the server in SubjectPublicKeyInfo
+// form (AuthenticatorAttestationResponse.getPublicKey()), so no CBOR parsing is
+// needed. Attestation is not verified ("none" conveyance), as is usual for
+// passkeys.
+type WebAuthn struct {
+	RPID    string // Host name the credentials are scoped to
+	RPName  string
+	Origins []string // Accepted origins, e.g. "https://attic.example.com"
+}
+
+// NewWebAuthn derives the relying party from the public base URL of the app
+func NewWebAuthn(baseURL string, rpName string) (*WebAuthn, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	return &WebAuthn{
+		RPID:    u.Hostname(),
+		RPName:  rpName,
+		Origins: []string{u.Scheme + "://" + u.Host},
+	}, nil
+}
+
+// ClientData is the part of the client data JSON checked by the server
+type ClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"` // base64url
+	Origin    string `json:"origin"`
+}
+
+// AuthenticatorData is parsed authenticator data
+type AuthenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	CredentialID []byte // Only present in registrations
+}
+
+// Credential is a verified, newly registered passkey
+type Credential struct {
+	ID        []byte
+	PublicKey []byte // SubjectPublicKeyInfo DER
+	Algorithm int
+	SignCount uint32
+}
+
+// AssertionKey is a stored passkey an assertion is verified against
+type AssertionKey struct {
+	PublicKey []byte
+	Algorithm int
+	SignCount uint32
+}
+
+// ParseChallenge returns the challenge of a client data JSON of the given
+// ceremony type ("webauthn.create" or "webauthn.get") after checking its origin
+func (wa *WebAuthn) ParseChallenge(clientDataJSON []byte, ceremony string) (string, error) {
+	var cd ClientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return "", fmt.Errorf("%w: invalid client data", ErrWebAuthn)
+	}
+	if cd.Type != ceremony {
+		return "", fmt.Errorf("%w: unexpected ceremony %q", ErrWebAuthn, cd.Type)
+	}
+	if !wa.allowedOrigin(cd.Origin) {
+		return "", fmt.Errorf("%w: origin %q not allowed", ErrWebAuthn, cd.Origin)
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(cd.Challenge)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid challenge encoding", ErrWebAuthn)
+	}
+	return string(challenge), nil
+}
+
+// VerifyRegistration checks a registration response whose challenge was
+// already validated with ParseChallenge and returns the new credential
+func (wa *WebAuthn) VerifyRegistration(rawID, authData, publicKey []byte, alg int) (*Credential, error) {
+	ad, err := wa.parseAuthData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if ad.Flags&flagAttestedCredData == 0 {
+		return nil, fmt.Errorf("%w: no attested credential data", ErrWebAuthn)
+	}
+	if !bytes.Equal(ad.CredentialID, rawID) {
+		return nil, fmt.Errorf("%w: credential ID mismatch", ErrWebAuthn)
+	}
+	if _, err := parsePublicKey(publicKey, alg); err != nil {
+		return nil, err
+	}
+	return &Credential{ID: rawID, PublicKey: publicKey, Algorithm: alg, SignCount: ad.SignCount}, nil
+}
+
+// VerifyAssertion checks the signature of an authentication response whose
+// challenge was already validated with ParseChallenge and returns the new
+// signature counter
+func (wa *WebAuthn) VerifyAssertion(key AssertionKey, clientDataJSON, authData, signature []byte) (uint32, error) {
+	ad, err := wa.parseAuthData(authData)
+	if err != nil {
+		return 0, err
+	}
+
+	pub, err := parsePublicKey(key.PublicKey, key.Algorithm)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+	digest := sha256.Sum256(signed)
+
+	var valid bool
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(k, digest[:], signature)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, signed, signature)
+	}
+	if !valid {
+		return 0, fmt.Errorf("%w: invalid signature", ErrWebAuthn)
+	}
+
+	// A counter that does not increase hints at a cloned authenticator;
+	// authenticators without a counter always report 0
+	if (ad.SignCount != 0 || key.SignCount != 0) && ad.SignCount <= key.SignCount {
+		return 0, fmt.Errorf("%w: signature counter did not increase", ErrWebAuthn)
+	}
+	return ad.SignCount, nil
+}
+
+// parseAuthData parses authenticator data and checks the relying party and
+// that the user was present and verified
+func (wa *WebAuthn) parseAuthData(data []byte) (*AuthenticatorData, error) {
+	if len(data) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data too short", ErrWebAuthn)
+	}
+	ad := &AuthenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rpIDHash := sha256.Sum256([]byte(wa.RPID))
+	if !bytes.Equal(ad.RPIDHash, rpIDHash[:]) {
+		return nil, fmt.Errorf("%w: relying party mismatch", ErrWebAuthn)
+	}
+	if ad.Flags&flagUserPresent == 0 || ad.Flags&flagUserVerified == 0 {
+		return nil, fmt.Errorf("%w: user not present or not verified", ErrWebAuthn)
+	}
+
+	if ad.Flags&flagAttestedCredData != 0 {
+		// AAGUID (16 bytes), credential ID length (2 bytes), credential ID
+		rest := data[37:]
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("%w: truncated attested credential data", ErrWebAuthn)
+		}
+		n := int(binary.BigEndian.Uint16(rest[16:18]))
+		if len(rest) < 18+n {
+			return nil, fmt.Errorf("%w: truncated credential ID", ErrWebAuthn)
+		}
+		ad.CredentialID = rest[18 : 18+n]
+	}
+	return ad, nil
+}
+
+func (wa *WebAuthn) allowedOrigin(origin string) bool {
+	for _, o := range wa.Origins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePublicKey parses a SubjectPublicKeyInfo and checks it matches alg
+func parsePublicKey(der []byte, alg int) (crypto.PublicKey, error) {
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid public key", ErrWebAuthn)
+	}
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		if alg == COSEAlgES256 && k.Curve == elliptic.P256() {
+			return k, nil
+		}
+	case *rsa.PublicKey:
+		if alg == COSEAlgRS256 {
+			return k, nil
+		}
+	case ed25519.PublicKey:
+		if alg == COSEAlgEdDSA {
+			return k, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unsupported key type or algorithm %d", ErrWebAuthn, alg)
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func testWebAuthn(t *testing.T) *WebAuthn {
+	t.Helper()
+	wa, err := NewWebAuthn("https://attic.example.com/app", "Attic")
+	if err != nil {
+		t.Fatalf("NewWebAuthn: %v", err)
+	}
+	return wa
+}
+
+func clientDataJSON(ceremony, challenge, origin string) []byte {
+	data, _ := json.Marshal(ClientData{
+		Type:      ceremony,
+		Challenge: base64.RawURLEncoding.EncodeToString([]byte(challenge)),
+		Origin:    origin,
+	})
+	return data
+}
+
+// authData builds authenticator data, with attested credential data if
+// credentialID is set
+func authData(rpID string, flags byte, signCount uint32, credentialID []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+	if credentialID != nil {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(credentialID)))
+		data = append(data, credentialID...)
+	}
+	return data
+}
+
+func TestNewWebAuthn(t *testing.T) {
+	wa := testWebAuthn(t)
+	if wa.RPID != "attic.example.com" {
+		t.Errorf("RPID = %q", wa.RPID)
+	}
+	if len(wa.Origins) != 1 || wa.Origins[0] != "https://attic.example.com" {
+		t.Errorf("Origins = %v", wa.Origins)
+	}
+
+	if _, err := NewWebAuthn("not a url", "Attic"); err == nil {
+		t.Error("expected error for invalid base URL")
+	}
+}
+
+func TestWebAuthn_ParseChallenge(t *testing.T) {
+	wa := testWebAuthn(t)
+
+	challenge, err := wa.ParseChallenge(clientDataJSON("webauthn.get", "token", "https://attic.example.com"), "webauthn.get")
+	if err != nil || challenge != "token" {
+		t.Fatalf("ParseChallenge = %q, %v", challenge, err)
+	}
+
+	tests := map[string][]byte{
+		"wrong ceremony": clientDataJSON("webauthn.create", "token", "https://attic.example.com"),
+		"wrong origin":   clientDataJSON("webauthn.get", "token", "https://evil.example.com"),
+		"invalid json":   []byte("{"),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := wa.ParseChallenge(data, "webauthn.get"); !errors.Is(err, ErrWebAuthn) {
+				t.Errorf("expected ErrWebAuthn, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWebAuthn_Registration(t *testing.T) {
+	wa := testWebAuthn(t)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	publicKey, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	credentialID := []byte("credential-1")
+	flags := byte(flagUserPresent | flagUserVerified | flagAttestedCredData)
+
+	cred, err := wa.VerifyRegistration(credentialID, authData(wa.RPID, flags, 0, credentialID), publicKey, COSEAlgES256)
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+	if string(cred.ID) != "credential-1" || cred.Algorithm != COSEAlgES256 {
+		t.Errorf("unexpected credential %+v", cred)
+	}
+
+	tests := []struct {
+		name     string
+		rawID    []byte
+		authData []byte
+		alg      int
+	}{
+		{"credential ID mismatch", []byte("other"), authData(wa.RPID, flags, 0, credentialID), COSEAlgES256},
+		{"other relying party", credentialID, authData("evil.example.com", flags, 0, credentialID), COSEAlgES256},
+		{"user not verified", credentialID, authData(wa.RPID, flagUserPresent|flagAttestedCredData, 0, credentialID), COSEAlgES256},
+		{"no credential data", credentialID, authData(wa.RPID, flagUserPresent|flagUserVerified, 0, nil), COSEAlgES256},
+		{"algorithm mismatch", credentialID, authData(wa.RPID, flags, 0, credentialID), COSEAlgRS256},
+		{"truncated", credentialID, authData(wa.RPID, flags, 0, credentialID)[:40], COSEAlgES256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := wa.VerifyRegistration(tt.rawID, tt.authData, publicKey, tt.alg); !errors.Is(err, ErrWebAuthn) {
+				t.Errorf("expected ErrWebAuthn, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWebAuthn_Assertion_ES256(t *testing.T) {
+	wa := testWebAuthn(t)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	publicKey, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	stored := AssertionKey{PublicKey: publicKey, Algorithm: COSEAlgES256, SignCount: 4}
+
+	sign := func(ad, cd []byte) []byte {
+		hash := sha256.Sum256(cd)
+		digest := sha256.Sum256(append(append([]byte{}, ad...), hash[:]...))
+		sig, _ := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		return sig
+	}
+
+	cd := clientDataJSON("webauthn.get", "token", "https://attic.example.com")
+	ad := authData(wa.RPID, flagUserPresent|flagUserVerified, 5, nil)
+	count, err := wa.VerifyAssertion(stored, cd, ad, sign(ad, cd))
+	if err != nil || count != 5 {
+		t.Fatalf("VerifyAssertion = %d, %v", count, err)
+	}
+
+	// Signature over other client data
+	other := clientDataJSON("webauthn.get", "other", "https://attic.example.com")
+	if _, err := wa.VerifyAssertion(stored, cd, ad, sign(ad, other)); !errors.Is(err, ErrWebAuthn) {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+
+	// Replayed counter hints at a cloned authenticator
+	stale := authData(wa.RPID, flagUserPresent|flagUserVerified, 4, nil)
+	if _, err := wa.VerifyAssertion(stored, cd, stale, sign(stale, cd)); !errors.Is(err, ErrWebAuthn) {
+		t.Errorf("expected counter error, got %v", err)
+	}
+}
+
+func TestWebAuthn_Assertion_EdDSAWithoutCounter(t *testing.T) {
+	wa := testWebAuthn(t)
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	publicKey, _ := x509.MarshalPKIXPublicKey(pub)
+	stored := AssertionKey{PublicKey: publicKey, Algorithm: COSEAlgEdDSA}
+
+	cd := clientDataJSON("webauthn.get", "token", "https://attic.example.com")
+	ad := authData(wa.RPID, flagUserPresent|flagUserVerified, 0, nil)
+	hash := sha256.Sum256(cd)
+	sig := ed25519.Sign(priv, append(append([]byte{}, ad...), hash[:]...))
+
+	// Authenticators without a counter always report 0
+	if _, err := wa.VerifyAssertion(stored, cd, ad, sig); err != nil {
+		t.Fatalf("VerifyAssertion: %v", err)
+	}
+}
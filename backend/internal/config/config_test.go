@@ -165,3 +165,199 @@ func Test_HasFileOwnership_OnlyPGID(t *testing.T) {
 		t.Error("expected HasFileOwnership to return false when only PGID is set")
 	}
 }
+
+func Test_Load_BaseURL_Invalid(t *testing.T) {
+	os.Setenv("ATTIC_BASE_URL", "localhost:8080")
+	defer os.Unsetenv("ATTIC_BASE_URL")
+
+	if _, err := Load(); err == nil {
+		t.Error("expected error for base URL without scheme")
+	}
+}
+
+func Test_Load_BaseURL_TrailingSlashAndAlternateHosts(t *testing.T) {
+	os.Setenv("ATTIC_BASE_URL", "https://attic.example.com/")
+	os.Setenv("ATTIC_ALTERNATE_HOSTS", "attic.lan, 192.168.1.10:8080,")
+	defer os.Unsetenv("ATTIC_BASE_URL")
+	defer os.Unsetenv("ATTIC_ALTERNATE_HOSTS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if cfg.BaseURL != "https://attic.example.com" {
+		t.Errorf("expected trailing slash to be removed, got %q", cfg.BaseURL)
+	}
+	if len(cfg.AlternateHosts) != 2 || cfg.AlternateHosts[0] != "attic.lan" || cfg.AlternateHosts[1] != "192.168.1.10:8080" {
+		t.Errorf("unexpected alternate hosts: %v", cfg.AlternateHosts)
+	}
+}
+
+func Test_Load_SessionCookie_Defaults(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if cfg.SessionCookieName != "attic_session" || cfg.SessionCookieSameSite != "lax" || cfg.SessionCookieSecure != "auto" {
+		t.Errorf("unexpected cookie defaults: %q %q %q", cfg.SessionCookieName, cfg.SessionCookieSameSite, cfg.SessionCookieSecure)
+	}
+	if !cfg.SessionRolling {
+		t.Error("expected rolling sessions to be enabled by default")
+	}
+}
+
+func Test_Load_SessionCookie_SameSiteNoneRequiresSecure(t *testing.T) {
+	os.Setenv("ATTIC_SESSION_COOKIE_SAMESITE", "none")
+	os.Setenv("ATTIC_SESSION_COOKIE_SECURE", "never")
+	defer os.Unsetenv("ATTIC_SESSION_COOKIE_SAMESITE")
+	defer os.Unsetenv("ATTIC_SESSION_COOKIE_SECURE")
+
+	if _, err := Load(); err == nil {
+		t.Error("expected error for SameSite=None without secure cookie")
+	}
+}
+
+func Test_Load_OIDCProviders(t *testing.T) {
+	t.Setenv("ATTIC_OIDC_PROVIDERS", "google, my-idp")
+	t.Setenv("ATTIC_OIDC_GOOGLE_ISSUER_URL", "https://accounts.google.com")
+	t.Setenv("ATTIC_OIDC_GOOGLE_CLIENT_ID", "google-client")
+	t.Setenv("ATTIC_OIDC_GOOGLE_NAME", "Google")
+	t.Setenv("ATTIC_OIDC_MY_IDP_ISSUER_URL", "https://idp.example.com")
+	t.Setenv("ATTIC_OIDC_MY_IDP_CLIENT_ID", "attic")
+	t.Setenv("ATTIC_OIDC_MY_IDP_CLIENT_SECRET", "secret")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if !cfg.OIDCEnabled {
+		t.Error("expected OIDC to be enabled by additional providers")
+	}
+	if len(cfg.OIDCProviders) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(cfg.OIDCProviders))
+	}
+	if p := cfg.OIDCProviders[0]; p.ID != "google" || p.Name != "Google" || p.ClientID != "google-client" {
+		t.Errorf("unexpected provider %+v", p)
+	}
+	if p := cfg.OIDCProviders[1]; p.ID != "my-idp" || p.Name != "my-idp" || p.ClientSecret != "secret" {
+		t.Errorf("unexpected provider %+v", p)
+	}
+}
+
+func Test_Load_OIDCProviders_Invalid(t *testing.T) {
+	for _, providers := range []string{"default", "Bad.ID", "google,google", "missing"} {
+		t.Run(providers, func(t *testing.T) {
+			t.Setenv("ATTIC_OIDC_PROVIDERS", providers)
+			t.Setenv("ATTIC_OIDC_GOOGLE_ISSUER_URL", "https://accounts.google.com")
+			t.Setenv("ATTIC_OIDC_GOOGLE_CLIENT_ID", "google-client")
+
+			if _, err := Load(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func Test_Load_ProxyAuth(t *testing.T) {
+	t.Setenv("ATTIC_PROXY_AUTH_ENABLED", "true")
+	t.Setenv("ATTIC_TRUSTED_PROXIES", "10.0.0.5, 172.16.0.0/12")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if len(cfg.TrustedProxies) != 2 || cfg.TrustedProxies[1] != "172.16.0.0/12" {
+		t.Errorf("unexpected trusted proxies %v", cfg.TrustedProxies)
+	}
+	if cfg.ProxyAuthUserHeader != "Remote-User" || cfg.ProxyAuthEmailHeader != "Remote-Email" {
+		t.Errorf("unexpected default headers %q, %q", cfg.ProxyAuthUserHeader, cfg.ProxyAuthEmailHeader)
+	}
+}
+
+func Test_Load_ProxyAuth_RequiresTrustedProxies(t *testing.T) {
+	t.Setenv("ATTIC_PROXY_AUTH_ENABLED", "true")
+
+	if _, err := Load(); err == nil {
+		t.Error("expected error without trusted proxies")
+	}
+}
+
+func Test_Load_RequireEmailVerification_RequiresSMTP(t *testing.T) {
+	t.Setenv("ATTIC_REQUIRE_EMAIL_VERIFICATION", "true")
+
+	if _, err := Load(); err == nil {
+		t.Error("expected error without an SMTP host")
+	}
+
+	t.Setenv("ATTIC_SMTP_HOST", "smtp.example.com")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !cfg.RequireEmailVerification {
+		t.Error("expected email verification to be required")
+	}
+}
+
+func Test_Load_ImageProcessor(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if cfg.ImageProcessor != "go" {
+		t.Errorf("expected the pure Go processor by default, got %q", cfg.ImageProcessor)
+	}
+
+	t.Setenv("ATTIC_IMAGE_PROCESSOR", "vips")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for an unknown image processor")
+	}
+}
+
+func Test_Load_SelfRegistration_RequiresLocalLogins(t *testing.T) {
+	t.Setenv("ATTIC_SELF_REGISTRATION", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !cfg.SelfRegistration {
+		t.Error("expected self-registration to be enabled")
+	}
+
+	t.Setenv("ATTIC_OIDC_ENABLED", "true")
+	if _, err := Load(); err == nil {
+		t.Error("expected error when combined with OIDC")
+	}
+}
+
+func Test_Load_EmbeddedDatabase(t *testing.T) {
+	t.Setenv("ATTIC_DATABASE_URL", "")
+	t.Setenv("ATTIC_DATA_DIR", "/srv/attic")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !cfg.EmbeddedDatabase() || cfg.EmbeddedDatabasePort != 5433 {
+		t.Errorf("expected the embedded database on port 5433, got %v on %d", cfg.EmbeddedDatabase(), cfg.EmbeddedDatabasePort)
+	}
+	if cfg.LocalStoragePath != "/srv/attic/uploads" {
+		t.Errorf("expected uploads in the data directory, got %q", cfg.LocalStoragePath)
+	}
+
+	t.Setenv("ATTIC_LOCAL_STORAGE_PATH", "/mnt/uploads")
+	if cfg, _ := Load(); cfg.LocalStoragePath != "/mnt/uploads" {
+		t.Errorf("expected the configured storage path, got %q", cfg.LocalStoragePath)
+	}
+
+	t.Setenv("ATTIC_DATABASE_URL", "postgres://attic:attic@db:5432/attic")
+	t.Setenv("ATTIC_LOCAL_STORAGE_PATH", "")
+	if cfg, _ := Load(); cfg.EmbeddedDatabase() || cfg.LocalStoragePath != "./uploads" {
+		t.Errorf("expected PostgreSQL at the URL with uploads in ./uploads, got %v and %q", cfg.EmbeddedDatabase(), cfg.LocalStoragePath)
+	}
+}
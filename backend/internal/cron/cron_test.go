@@ -0,0 +1,66 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Parse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected %q to be rejected", expr)
+		}
+	}
+}
+
+func Test_Schedule_Next(t *testing.T) {
+	from := time.Date(2025, 3, 14, 10, 30, 15, 0, time.UTC) // Friday
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2025, 3, 14, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 3, 14, 10, 45, 0, 0, time.UTC)},
+		{"0 8 * * *", time.Date(2025, 3, 15, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * mon-fri", time.Date(2025, 3, 17, 8, 0, 0, 0, time.UTC)},
+		{"0 9 1 * *", time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Either the day of month or the day of week may match
+		{"0 0 20 * mon", time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", tt.expr, err)
+		}
+		if got := s.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.expr, tt.want, got)
+		}
+	}
+}
+
+func Test_Schedule_Next_InLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Lisbon")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	s, _ := Parse("0 8 * * *")
+
+	// The day before the switch to summer time
+	got := s.Next(time.Date(2025, 3, 29, 9, 0, 0, 0, loc))
+	if want := time.Date(2025, 3, 30, 8, 0, 0, 0, loc); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got.UTC().Hour() != 7 {
+		t.Errorf("expected 07:00 UTC in summer time, got %v", got.UTC())
+	}
+}
+
+func Test_Schedule_Next_NeverMatches(t *testing.T) {
+	s, _ := Parse("0 0 30 2 *")
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Errorf("expected zero time, got %v", got)
+	}
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"archive/tar"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/xi2/xz"
+)
+
+// embeddedStartTimeout bounds the first start, which initializes the data
+// directory, on slow hardware like a Raspberry Pi
+const embeddedStartTimeout = 2 * time.Minute
+
+// embeddedVersion is the PostgreSQL version of the embedded mode; the
+// archives vendored by scripts/fetch-postgres.sh must match it
+const embeddedVersion = embeddedpostgres.V15
+
+// Embedded is a PostgreSQL server run by Attic itself, for deployments
+// without a database server
+type Embedded struct {
+	postgres *embeddedpostgres.EmbeddedPostgres
+	url      string
+}
+
+// StartEmbedded starts PostgreSQL on localhost:port with its data under
+// dataDir. The PostgreSQL binaries are built into Attic and unpacked into
+// dataDir, so no network access is needed. The first start creates the
+// database with a generated password, kept next to it.
+func StartEmbedded(dataDir string, port uint32) (*Embedded, error) {
+	if os.Geteuid() == 0 {
+		return nil, errors.New("PostgreSQL refuses to run as root: run Attic as a regular user, or set ATTIC_DATABASE_URL")
+	}
+	if _, err := os.Stat("/etc/alpine-release"); err == nil {
+		return nil, errors.New("the embedded PostgreSQL needs glibc, which Alpine Linux lacks: set ATTIC_DATABASE_URL")
+	}
+	if err := os.MkdirAll(dataDir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating data directory: %w", err)
+	}
+	binariesPath := filepath.Join(dataDir, "postgres-bin")
+	if err := installBinaries(postgresBinaries, binariesPath); err != nil {
+		return nil, err
+	}
+	password, err := embeddedPassword(filepath.Join(dataDir, "postgres.password"))
+	if err != nil {
+		return nil, err
+	}
+
+	dataPath := filepath.Join(dataDir, "postgres")
+	stopStale(binariesPath, dataPath)
+
+	// With the binaries in place, the library never downloads them
+	config := embeddedpostgres.DefaultConfig().
+		Version(embeddedVersion).
+		Port(port).
+		Username("attic").
+		Password(password).
+		Database("attic").
+		DataPath(dataPath).
+		BinariesPath(binariesPath).
+		RuntimePath(filepath.Join(dataDir, "postgres-run")).
+		StartTimeout(embeddedStartTimeout).
+		Logger(slogWriter{})
+
+	postgres := embeddedpostgres.NewDatabase(config)
+	if err := postgres.Start(); err != nil {
+		return nil, fmt.Errorf("starting embedded PostgreSQL: %w", err)
+	}
+	return &Embedded{
+		postgres: postgres,
+		url:      fmt.Sprintf("postgres://attic:%s@localhost:%d/attic?sslmode=disable", password, port),
+	}, nil
+}
+
+// URL returns the connection URL of the embedded server
+func (e *Embedded) URL() string {
+	return e.url
+}
+
+// Stop shuts the embedded server down
+func (e *Embedded) Stop() error {
+	return e.postgres.Stop()
+}
+
+// embeddedPassword returns the password stored at path, generating it on the
+// first start. A data directory without one was initialized with another
+// password, which would lock Attic out, so that is an error.
+func embeddedPassword(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("reading database password: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "postgres", "PG_VERSION")); err == nil {
+		return "", fmt.Errorf("%s is missing, the embedded database can't be opened without it", path)
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating database password: %w", err)
+	}
+	password := hex.EncodeToString(b)
+	if err := os.WriteFile(path, []byte(password+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("writing database password: %w", err)
+	}
+	return password, nil
+}
+
+// stopStale stops a server left running by an Attic that didn't shut down
+// cleanly, which would otherwise keep the port and data directory in use
+func stopStale(binariesPath, dataPath string) {
+	if _, err := os.Stat(filepath.Join(dataPath, "postmaster.pid")); err != nil {
+		return
+	}
+	pgCtl := filepath.Join(binariesPath, "bin", "pg_ctl")
+	if out, err := exec.Command(pgCtl, "stop", "-w", "-m", "fast", "-D", dataPath).CombinedOutput(); err != nil {
+		slog.Debug("no stale embedded PostgreSQL to stop", "output", strings.TrimSpace(string(out)))
+		return
+	}
+	slog.Warn("stopped embedded PostgreSQL left running by a previous start")
+}
+
+// installBinaries unpacks the PostgreSQL archive built into Attic for this
+// platform into binariesPath, unless it was already. A marker file names the
+// archive, so that an upgraded Attic replaces binaries of another version.
+func installBinaries(binaries fs.FS, binariesPath string) error {
+	archives, _ := fs.Glob(binaries, path.Join("postgres-binaries", runtime.GOOS+"_"+runtime.GOARCH, "*.txz"))
+	if len(archives) == 0 {
+		return fmt.Errorf("this build of Attic doesn't include PostgreSQL for %s/%s: set ATTIC_DATABASE_URL, or build Attic after running scripts/fetch-postgres.sh", runtime.GOOS, runtime.GOARCH)
+	}
+	archive := archives[0]
+	marker := filepath.Join(binariesPath, ".archive")
+	if installed, err := os.ReadFile(marker); err == nil && string(installed) == path.Base(archive) {
+		return nil
+	}
+
+	f, err := binaries.Open(archive)
+	if err != nil {
+		return fmt.Errorf("opening PostgreSQL archive: %w", err)
+	}
+	defer f.Close()
+
+	// Unpacked next to the final location and renamed, so that an interrupted
+	// start doesn't leave half the binaries behind
+	tmp := binariesPath + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return fmt.Errorf("unpacking PostgreSQL: %w", err)
+	}
+	if err := extractTarXz(f, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("unpacking PostgreSQL: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, ".archive"), []byte(path.Base(archive)), 0o600); err != nil {
+		return fmt.Errorf("unpacking PostgreSQL: %w", err)
+	}
+	if err := os.RemoveAll(binariesPath); err != nil {
+		return fmt.Errorf("replacing PostgreSQL binaries: %w", err)
+	}
+	return os.Rename(tmp, binariesPath)
+}
+
+// extractTarXz unpacks the directories, files and symlinks of an xz
+// compressed tar archive into dest
+func extractTarXz(r io.Reader, dest string) error {
+	xzReader, err := xz.NewReader(r, 0)
+	if err != nil {
+		return err
+	}
+	archive := tar.NewReader(xzReader)
+	root := filepath.Clean(dest) + string(os.PathSeparator)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, header.Name)
+		if !strings.HasPrefix(target+string(os.PathSeparator), root) {
+			return fmt.Errorf("invalid path %q in archive", header.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fs.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(out, archive)
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// slogWriter logs the output of the embedded server at debug level
+type slogWriter struct{}
+
+func (slogWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line != "" {
+			slog.Debug("embedded postgres", "log", line)
+		}
+	}
+	return len(p), nil
+}
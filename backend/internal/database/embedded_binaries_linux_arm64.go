@@ -0,0 +1,9 @@
+package database
+
+import "embed"
+
+// postgresBinaries holds the PostgreSQL archive of the embedded mode for
+// this platform, vendored by scripts/fetch-postgres.sh
+//
+//go:embed all:postgres-binaries/linux_arm64
+var postgresBinaries embed.FS
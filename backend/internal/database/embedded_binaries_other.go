@@ -0,0 +1,9 @@
+//go:build !((linux || darwin) && (amd64 || arm64))
+
+package database
+
+import "embed"
+
+// postgresBinaries is empty on platforms without PostgreSQL builds in
+// scripts/fetch-postgres.sh, which need ATTIC_DATABASE_URL
+var postgresBinaries embed.FS
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/lmmendes/attic/migrations"
+)
+
+func Test_embeddedPassword(t *testing.T) {
+	dir := t.TempDir()
+	passwordPath := filepath.Join(dir, "postgres.password")
+
+	password, err := embeddedPassword(passwordPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(password) != 48 {
+		t.Errorf("expected a 48 character password, got %q", password)
+	}
+	if info, err := os.Stat(passwordPath); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the password file to be private, got %v", err)
+	}
+	if again, err := embeddedPassword(passwordPath); err != nil || again != password {
+		t.Errorf("expected the stored password %q, got %q (%v)", password, again, err)
+	}
+
+	// A database initialized with a lost password can't be opened
+	os.Remove(passwordPath)
+	os.MkdirAll(filepath.Join(dir, "postgres"), 0o700)
+	os.WriteFile(filepath.Join(dir, "postgres", "PG_VERSION"), []byte("15\n"), 0o600)
+	if _, err := embeddedPassword(passwordPath); err == nil {
+		t.Error("expected an error for a database without its password")
+	}
+}
+
+func Test_installBinaries(t *testing.T) {
+	xzPath, err := exec.LookPath("xz")
+	if err != nil {
+		t.Skip("xz is needed to build a test archive")
+	}
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	tw.WriteHeader(&tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0o755})
+	tw.WriteHeader(&tar.Header{Name: "bin/postgres", Typeflag: tar.TypeReg, Mode: 0o755, Size: 2})
+	tw.Write([]byte("pg"))
+	tw.WriteHeader(&tar.Header{Name: "lib/libpq.so", Typeflag: tar.TypeSymlink, Linkname: "libpq.so.5"})
+	tw.Close()
+	cmd := exec.Command(xzPath, "-c")
+	cmd.Stdin = &archive
+	compressed, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("xz: %v", err)
+	}
+
+	dir := path.Join("postgres-binaries", runtime.GOOS+"_"+runtime.GOARCH)
+	binaries := fstest.MapFS{dir + "/embedded-postgres-binaries-test-15.3.0.txz": {Data: compressed}}
+	binariesPath := filepath.Join(t.TempDir(), "postgres-bin")
+	if err := installBinaries(binaries, binariesPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(binariesPath, "bin", "postgres")); err != nil || info.Mode().Perm() != 0o755 {
+		t.Errorf("expected an executable postgres, got %v", err)
+	}
+	if link, err := os.Readlink(filepath.Join(binariesPath, "lib", "libpq.so")); err != nil || link != "libpq.so.5" {
+		t.Errorf("expected the symlink to be kept, got %q (%v)", link, err)
+	}
+
+	// Installed binaries are kept
+	os.Remove(filepath.Join(binariesPath, "bin", "postgres"))
+	if err := installBinaries(binaries, binariesPath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(binariesPath, "bin", "postgres")); err == nil {
+		t.Error("expected the installed binaries not to be unpacked again")
+	}
+
+	// Builds without the archive fail instead of downloading it
+	if err := installBinaries(fstest.MapFS{}, t.TempDir()); err == nil || !strings.Contains(err.Error(), "ATTIC_DATABASE_URL") {
+		t.Errorf("expected an error pointing to ATTIC_DATABASE_URL, got %v", err)
+	}
+}
+
+// Test_StartEmbedded needs the PostgreSQL archive vendored by
+// scripts/fetch-postgres.sh and a user other than root
+func Test_StartEmbedded(t *testing.T) {
+	archives, _ := fs.Glob(postgresBinaries, path.Join("postgres-binaries", runtime.GOOS+"_"+runtime.GOARCH, "*.txz"))
+	if len(archives) == 0 || os.Geteuid() == 0 {
+		t.Skip("run scripts/fetch-postgres.sh and test as a regular user to start the embedded PostgreSQL")
+	}
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	embedded, err := StartEmbedded(dir, 54329)
+	if err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	db, err := New(ctx, embedded.URL())
+	if err != nil {
+		embedded.Stop()
+		t.Fatalf("failed to connect: %v", err)
+	}
+	err = db.Migrate(ctx, migrations.FS)
+	db.Close()
+	if err != nil {
+		embedded.Stop()
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := embedded.Stop(); err != nil {
+		t.Fatalf("failed to stop: %v", err)
+	}
+
+	// The second start reuses the data directory and its password
+	embedded, err = StartEmbedded(dir, 54329)
+	if err != nil {
+		t.Fatalf("failed to restart: %v", err)
+	}
+	defer embedded.Stop()
+	db, err = New(ctx, embedded.URL())
+	if err != nil {
+		t.Fatalf("failed to reconnect: %v", err)
+	}
+	defer db.Close()
+	var assets int
+	if err := db.Pool.QueryRow(ctx, "SELECT count(*) FROM assets").Scan(&assets); err != nil {
+		t.Errorf("expected the migrated schema to be kept: %v", err)
+	}
+}
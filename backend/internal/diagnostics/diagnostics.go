@@ -0,0 +1,105 @@
+// Package diagnostics checks that the services Attic is configured to use
+// (database, storage, OIDC providers, SMTP, plugins) are reachable, reporting
+// actionable problems. It backs "attic config check" and the startup checks.
+package diagnostics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds each check
+const DefaultTimeout = 10 * time.Second
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusWarning Status = "warning" // An optional feature will not work
+	StatusError   Status = "error"   // Attic will not work as configured
+)
+
+// Check verifies one configured service
+type Check struct {
+	Name     string
+	Hint     string // How to fix a failure, e.g. the settings involved
+	Optional bool   // Failures are warnings (e.g. a plugin without API key)
+
+	// Run returns a short description of what was verified, or why it failed
+	Run func(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"` // Only set for failures
+}
+
+// Run runs the checks concurrently, each bounded by timeout, and returns
+// their results in check order
+func Run(ctx context.Context, checks []Check, timeout time.Duration) []Result {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check, timeout)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func run(ctx context.Context, check Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	msg, err := check.Run(ctx)
+	if err == nil {
+		return Result{Name: check.Name, Status: StatusOK, Message: msg}
+	}
+
+	status := StatusError
+	if check.Optional {
+		status = StatusWarning
+	}
+	return Result{Name: check.Name, Status: status, Message: err.Error(), Hint: check.Hint}
+}
+
+// Failed reports whether any check failed with an error
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == StatusError {
+			return true
+		}
+	}
+	return false
+}
+
+// Print writes the results for people, one line per check followed by the
+// hint of failed checks
+func Print(w io.Writer, results []Result) {
+	for _, r := range results {
+		fmt.Fprintf(w, "[%-7s] %s: %s\n", r.Status, r.Name, r.Message)
+		if r.Hint != "" {
+			fmt.Fprintf(w, "          %s\n", r.Hint)
+		}
+	}
+}
+
+// Log logs failed checks as warnings, for diagnostics that must not stop Attic
+func Log(results []Result) {
+	for _, r := range results {
+		if r.Status != StatusOK {
+			slog.Warn("configuration check failed", "check", r.Name, "status", r.Status, "error", r.Message, "hint", r.Hint)
+		}
+	}
+}
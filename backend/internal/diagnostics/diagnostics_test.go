@@ -0,0 +1,65 @@
+package diagnostics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Run_ReportsResultsInOrder(t *testing.T) {
+	checks := []Check{
+		{Name: "database", Run: func(context.Context) (string, error) { return "connected", nil }},
+		{Name: "smtp", Hint: "check ATTIC_SMTP_HOST", Run: func(context.Context) (string, error) { return "", errors.New("connection refused") }},
+		{Name: "plugin tmdb_movies", Optional: true, Run: func(context.Context) (string, error) { return "", errors.New("missing API key") }},
+	}
+
+	results := Run(context.Background(), checks, time.Second)
+
+	want := []Result{
+		{Name: "database", Status: StatusOK, Message: "connected"},
+		{Name: "smtp", Status: StatusError, Message: "connection refused", Hint: "check ATTIC_SMTP_HOST"},
+		{Name: "plugin tmdb_movies", Status: StatusWarning, Message: "missing API key"},
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("result %d: expected %+v, got %+v", i, want[i], results[i])
+		}
+	}
+	if !Failed(results) {
+		t.Error("expected failed results")
+	}
+	if Failed(results[2:]) {
+		t.Error("expected warnings alone not to fail")
+	}
+}
+
+func Test_Run_TimesOutChecks(t *testing.T) {
+	checks := []Check{{Name: "oidc", Run: func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}}
+
+	results := Run(context.Background(), checks, 10*time.Millisecond)
+
+	if results[0].Status != StatusError || !strings.Contains(results[0].Message, "deadline") {
+		t.Errorf("expected timeout error, got %+v", results[0])
+	}
+}
+
+func Test_Print_IncludesHints(t *testing.T) {
+	var buf bytes.Buffer
+	Print(&buf, []Result{
+		{Name: "database", Status: StatusOK, Message: "connected"},
+		{Name: "smtp", Status: StatusError, Message: "connection refused", Hint: "check ATTIC_SMTP_HOST"},
+	})
+
+	want := "[ok     ] database: connected\n" +
+		"[error  ] smtp: connection refused\n" +
+		"          check ATTIC_SMTP_HOST\n"
+	if buf.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, buf.String())
+	}
+}
@@ -1,7 +1,14 @@
 package domain
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +24,22 @@ type Organization struct {
 	DeletedAt   *time.Time `json:"-"`
 }
 
+// Membership is a user's membership of an organization. Users are members of
+// the organization that created them (their home organization, which manages
+// the account) and may be added to others.
+type Membership struct {
+	OrganizationID   uuid.UUID `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	UserID           uuid.UUID `json:"user_id"`
+	Email            string    `json:"email"`
+	DisplayName      *string   `json:"display_name,omitempty"`
+	Role             UserRole  `json:"role"`     // Role in the organization
+	OwnRole          bool      `json:"own_role"` // The role is the user's own role (users.role)
+	Home             bool      `json:"home"`     // The organization that created the user
+	Selected         bool      `json:"selected"` // Used for requests without an X-Org-ID header
+	CreatedAt        time.Time `json:"created_at"`
+}
+
 // UserRole represents the user's role in the system
 type UserRole string
 
@@ -25,15 +48,90 @@ const (
 	UserRoleAdmin UserRole = "admin"
 )
 
+// Permission grants access to a part of the API
+type Permission string
+
+const (
+	PermissionAssetsRead     Permission = "assets:read"     // View the inventory
+	PermissionAssetsWrite    Permission = "assets:write"    // Create, change and import inventory data
+	PermissionUsersManage    Permission = "users:manage"    // Manage user accounts
+	PermissionSettingsManage Permission = "settings:manage" // Manage organization settings and reports
+)
+
+// Permissions lists all permissions
+var Permissions = []Permission{
+	PermissionAssetsRead,
+	PermissionAssetsWrite,
+	PermissionUsersManage,
+	PermissionSettingsManage,
+}
+
+// Valid reports whether p is a known permission
+func (p Permission) Valid() bool {
+	return slices.Contains(Permissions, p)
+}
+
+// Role is a named set of permissions. The admin and user roles are built in;
+// organizations can define custom roles.
+type Role struct {
+	ID             uuid.UUID    `json:"id,omitzero"`
+	OrganizationID uuid.UUID    `json:"-"`
+	Name           UserRole     `json:"name"` // Lowercase slug, assigned to users
+	Description    string       `json:"description,omitempty"`
+	Permissions    []Permission `json:"permissions"`
+	Builtin        bool         `json:"builtin"`
+	CreatedAt      time.Time    `json:"created_at,omitzero"`
+	UpdatedAt      time.Time    `json:"updated_at,omitzero"`
+}
+
+// BuiltinRoles returns the roles every organization has
+func BuiltinRoles() []Role {
+	return []Role{
+		{
+			Name:        UserRoleAdmin,
+			Description: "Full access",
+			Permissions: slices.Clone(Permissions),
+			Builtin:     true,
+		},
+		{
+			Name:        UserRoleUser,
+			Description: "Manage the inventory",
+			Permissions: []Permission{PermissionAssetsRead, PermissionAssetsWrite},
+			Builtin:     true,
+		},
+	}
+}
+
+// BuiltinRole returns the built-in role with the given name, if any
+func BuiltinRole(name UserRole) (*Role, bool) {
+	for _, role := range BuiltinRoles() {
+		if role.Name == name {
+			return &role, true
+		}
+	}
+	return nil, false
+}
+
+// Has reports whether the role grants permission p
+func (r *Role) Has(p Permission) bool {
+	return slices.Contains(r.Permissions, p)
+}
+
 // User represents an authenticated user
 type User struct {
 	ID             uuid.UUID  `json:"id"`
 	OrganizationID uuid.UUID  `json:"organization_id"`
 	OIDCSubject    *string    `json:"oidc_subject,omitempty"`
+	ExternalID     *string    `json:"external_id,omitempty"` // Identity provider ID (SCIM)
 	Email          string     `json:"email"`
 	DisplayName    *string    `json:"display_name,omitempty"`
 	PasswordHash   *string    `json:"-"`
 	Role           UserRole   `json:"role"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	TimeZone       *string    `json:"time_zone,omitempty"`       // IANA name, nil = organization time zone
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`     // nil = email address not verified yet
+	PendingAt      *time.Time `json:"pending_at,omitempty"`      // Self-registered, awaiting admin approval
+	ServiceAccount bool       `json:"service_account,omitempty"` // Integration account, authenticates with service tokens only
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
 	DeletedAt      *time.Time `json:"-"`
@@ -44,6 +142,21 @@ func (u *User) IsAdmin() bool {
 	return u.Role == UserRoleAdmin
 }
 
+// IsActive returns true unless the user has been deactivated
+func (u *User) IsActive() bool {
+	return u.DisabledAt == nil
+}
+
+// IsPending returns true while a self-registered account awaits admin approval
+func (u *User) IsPending() bool {
+	return u.PendingAt != nil
+}
+
+// IsVerified returns true once the user confirmed their email address
+func (u *User) IsVerified() bool {
+	return u.VerifiedAt != nil
+}
+
 // HasPassword returns true if the user has a password set
 func (u *User) HasPassword() bool {
 	return u.PasswordHash != nil && *u.PasswordHash != ""
@@ -75,6 +188,10 @@ type Category struct {
 	UpdatedAt      time.Time  `json:"updated_at"`
 	DeletedAt      *time.Time `json:"-"`
 
+	// Name the plugin last supplied (only loaded by GetByPluginID); differs
+	// from Name when the user renamed the category
+	PluginCategoryName *string `json:"-"`
+
 	// Populated by queries
 	Children   []Category          `json:"children,omitempty"`
 	Attributes []CategoryAttribute `json:"attributes,omitempty"`
@@ -141,14 +258,23 @@ type Asset struct {
 	LocationID       *uuid.UUID      `json:"location_id,omitempty"`
 	ConditionID      *uuid.UUID      `json:"condition_id,omitempty"`
 	CollectionID     *uuid.UUID      `json:"collection_id,omitempty"`
+	OwnerID          *uuid.UUID      `json:"owner_id,omitempty"` // Household member the asset belongs to
 	MainAttachmentID *uuid.UUID      `json:"main_attachment_id,omitempty"`
+	ShortID          *string         `json:"short_id,omitempty"` // Code for NFC tags, see NormalizeShortID
 	Name             string          `json:"name"`
 	Description      *string         `json:"description,omitempty"`
 	Quantity         int             `json:"quantity"`
+	MinQuantity      *int            `json:"min_quantity,omitempty"` // Low-stock threshold, see LowStock
 	Attributes       json.RawMessage `json:"attributes"`
+	HighValue        bool            `json:"high_value"` // See HighValuePolicy
+	ArchivedAt       *time.Time      `json:"archived_at,omitempty"` // Set while the asset is archived
+	Status           AssetStatus     `json:"status"`
 	PurchaseAt       *time.Time      `json:"purchase_at,omitempty"`
 	PurchasePrice    *float64        `json:"purchase_price,omitempty"`
+	CurrentValue     *float64        `json:"current_value,omitempty"` // Latest valuation of one unit, see AssetValue
+	Currency         *string         `json:"currency,omitempty"` // ISO 4217 code of PurchasePrice
 	PurchaseNote     *string         `json:"purchase_note,omitempty"`
+	PurchaseGroupID  *uuid.UUID      `json:"purchase_group_id,omitempty"` // Bought in a bundle, see PurchaseGroup
 	Notes            *string         `json:"notes,omitempty"` // User personal notes about the asset
 	ImportPluginID   *string         `json:"import_plugin_id,omitempty"`   // Plugin that imported this asset
 	ImportExternalID *string         `json:"import_external_id,omitempty"` // External ID for re-fetching
@@ -160,11 +286,99 @@ type Asset struct {
 	Category       *Category   `json:"category,omitempty"`
 	Location       *Location   `json:"location,omitempty"`
 	Condition      *Condition  `json:"condition,omitempty"`
+	Owner          *AssetOwner `json:"owner,omitempty"`
 	Tags           []Tag       `json:"tags,omitempty"`
 	Warranty       *Warranty   `json:"warranty,omitempty"`
 	MainAttachment *Attachment `json:"main_attachment,omitempty"`
 }
 
+// Short IDs are codes of ShortIDMinLength to ShortIDMaxLength characters
+// identifying an asset, short enough to write on the cheapest NFC stickers or
+// to type in. They use Crockford's base32 alphabet, without I, L, O and U.
+const (
+	ShortIDAlphabet  = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	ShortIDMinLength = 6
+	ShortIDMaxLength = 8
+)
+
+// NormalizeShortID returns s as a short ID, upper-cased and with I, L and O
+// read as the digits they look like, and whether it can be one
+func NormalizeShortID(s string) (string, bool) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < ShortIDMinLength || len(s) > ShortIDMaxLength {
+		return "", false
+	}
+	b := []byte(s)
+	for i, c := range b {
+		switch c {
+		case 'I', 'L':
+			b[i] = '1'
+		case 'O':
+			b[i] = '0'
+		}
+		if strings.IndexByte(ShortIDAlphabet, b[i]) < 0 {
+			return "", false
+		}
+	}
+	return string(b), true
+}
+
+// AssetStatus is where an asset is in its lifecycle
+type AssetStatus string
+
+const (
+	AssetStatusOwned    AssetStatus = "owned"
+	AssetStatusLoaned   AssetStatus = "loaned"
+	AssetStatusInRepair AssetStatus = "in_repair"
+	AssetStatusSold     AssetStatus = "sold"
+	AssetStatusDisposed AssetStatus = "disposed"
+	AssetStatusLost     AssetStatus = "lost"
+)
+
+// AssetStatuses lists the statuses in lifecycle order
+var AssetStatuses = []AssetStatus{
+	AssetStatusOwned, AssetStatusLoaned, AssetStatusInRepair, AssetStatusSold, AssetStatusDisposed, AssetStatusLost,
+}
+
+// Valid reports whether s is a known status
+func (s AssetStatus) Valid() bool {
+	return slices.Contains(AssetStatuses, s)
+}
+
+// Held reports whether the organization still has an asset of the status,
+// on hand, lent out or away for repair. Only held assets count toward the
+// inventory value.
+func (s AssetStatus) Held() bool {
+	return s == AssetStatusOwned || s == AssetStatusLoaned || s == AssetStatusInRepair
+}
+
+// LowStock reports whether the asset has a low-stock threshold and its
+// quantity dropped below it
+func (a *Asset) LowStock() bool {
+	return a.MinQuantity != nil && a.Quantity < *a.MinQuantity
+}
+
+// ShoppingListItem is an asset whose quantity dropped below its low-stock
+// threshold
+type ShoppingListItem struct {
+	AssetID      uuid.UUID  `json:"asset_id"`
+	Name         string     `json:"name"`
+	Quantity     int        `json:"quantity"`
+	MinQuantity  int        `json:"min_quantity"`
+	Needed       int        `json:"needed"` // Missing to reach the threshold again
+	CategoryName string     `json:"category_name"`
+	LocationName *string    `json:"location_name,omitempty"`
+	HighValue    bool       `json:"-"`
+	NotifiedAt   *time.Time `json:"notified_at,omitempty"` // When admins were emailed about it
+}
+
+// AssetOwner is the household member (user) an asset belongs to
+type AssetOwner struct {
+	ID          uuid.UUID `json:"id"`
+	Email       string    `json:"email"`
+	DisplayName *string   `json:"display_name,omitempty"`
+}
+
 // Tag represents a free-form tag
 type Tag struct {
 	ID             uuid.UUID `json:"id"`
@@ -181,6 +395,7 @@ type Warranty struct {
 	StartDate *time.Time `json:"start_date,omitempty"`
 	EndDate   *time.Time `json:"end_date,omitempty"`
 	Notes     *string    `json:"notes,omitempty"`
+	HighValue bool       `json:"-"` // Of the asset, set when listing
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at"`
 }
@@ -196,4 +411,1230 @@ type Attachment struct {
 	ContentType *string    `json:"content_type,omitempty"`
 	Description *string    `json:"description,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
+
+	// Set once the background job has extracted the text for search
+	TextExtractedAt *time.Time `json:"text_extracted_at,omitempty"`
+
+	// Set for a rotated/cropped (Edit) or converted copy of another image attachment
+	DerivedFromID *uuid.UUID `json:"derived_from_id,omitempty"`
+	Edit          *ImageEdit `json:"edit,omitempty"`
+}
+
+// AttachmentDownload records that a user was given the download URL of an
+// attachment
+type AttachmentDownload struct {
+	ID           uuid.UUID  `json:"id"`
+	AttachmentID uuid.UUID  `json:"attachment_id"`
+	UserID       *uuid.UUID `json:"user_id,omitempty"` // nil = deleted user or auth disabled
+	IPAddress    string     `json:"ip_address"`
+	UserAgent    string     `json:"user_agent"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ImageEdit rotates an image and then crops it
+type ImageEdit struct {
+	Rotate int        `json:"rotate,omitempty"` // Clockwise degrees: 0, 90, 180 or 270
+	Crop   *ImageCrop `json:"crop,omitempty"`   // In pixels of the upright, rotated image
+}
+
+// ImageCrop is a rectangle of an image in pixels
+type ImageCrop struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// AssetList represents a named, static collection of assets (e.g. "Christmas decorations box")
+type AssetList struct {
+	ID              uuid.UUID  `json:"id"`
+	OrganizationID  uuid.UUID  `json:"organization_id"`
+	Name            string     `json:"name"`
+	Description     *string    `json:"description,omitempty"`
+	ShareToken      *string    `json:"share_token,omitempty"` // nil = not shared
+	ShareShowValues bool       `json:"share_show_values"`     // Expose purchase prices on the shared view
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"-"`
+
+	// Populated by queries
+	ItemCount int     `json:"item_count"`
+	Assets    []Asset `json:"assets,omitempty"`
+}
+
+// ErrReservationConflict is returned when a reservation overlaps an existing one
+var ErrReservationConflict = errors.New("reservation conflicts with an existing reservation")
+
+// AssetReservation represents a planned use of an asset during a time period
+type AssetReservation struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	AssetID        uuid.UUID  `json:"asset_id"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	ReservedBy     string     `json:"reserved_by"`
+	StartsAt       time.Time  `json:"starts_at"`
+	EndsAt         time.Time  `json:"ends_at"` // Exclusive
+	Notes          *string    `json:"notes,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"-"`
+
+	// Populated by queries
+	AssetName string `json:"asset_name,omitempty"`
+	HighValue bool   `json:"-"` // Of the asset
+}
+
+// Contact is a person outside the organization that assets are lent to
+type Contact struct {
+	ID              uuid.UUID  `json:"id"`
+	OrganizationID  uuid.UUID  `json:"organization_id"`
+	Name            string     `json:"name"`
+	Email           *string    `json:"email,omitempty"`
+	Phone           *string    `json:"phone,omitempty"`
+	Notes           *string    `json:"notes,omitempty"`
+	ReturnReminders bool       `json:"return_reminders"` // Email the contact about overdue loans
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"-"`
+}
+
+// ErrAssetOnLoan is returned when checking out an asset that is already on loan
+var ErrAssetOnLoan = errors.New("asset is already on loan")
+
+// Loan is an asset checked out to a person until it is returned
+type Loan struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	AssetID        uuid.UUID  `json:"asset_id"`
+	ContactID      *uuid.UUID `json:"contact_id,omitempty"`
+	Borrower       string     `json:"borrower"`
+	LoanedAt       time.Time  `json:"loaned_at"`
+	DueAt          *time.Time `json:"due_at,omitempty"` // Date
+	ReturnedAt     *time.Time `json:"returned_at,omitempty"`
+	Notes          *string    `json:"notes,omitempty"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	RemindedAt     *time.Time `json:"reminded_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Populated by queries
+	AssetName    string  `json:"asset_name,omitempty"`
+	ContactEmail *string `json:"contact_email,omitempty"`
+	HighValue    bool    `json:"-"` // Of the asset
+}
+
+// Overdue reports whether the loan is still open after its due date (day as
+// UTC midnight, like DATE columns are read)
+func (l *Loan) Overdue(day time.Time) bool {
+	return l.ReturnedAt == nil && l.DueAt != nil && l.DueAt.Before(day)
+}
+
+// LabelBatchStatus is where a label batch is in the render queue
+type LabelBatchStatus string
+
+const (
+	LabelBatchPending   LabelBatchStatus = "pending"
+	LabelBatchRendering LabelBatchStatus = "rendering"
+	LabelBatchDone      LabelBatchStatus = "done"
+	LabelBatchFailed    LabelBatchStatus = "failed"
+)
+
+// LabelBatch is a sheet of asset labels rendered in the background, the PDF
+// is stored with it until downloaded or expired
+type LabelBatch struct {
+	ID             uuid.UUID        `json:"id"`
+	OrganizationID uuid.UUID        `json:"organization_id"`
+	CreatedBy      *uuid.UUID       `json:"created_by,omitempty"`
+	Status         LabelBatchStatus `json:"status"`
+	AssetIDs       []uuid.UUID      `json:"asset_ids"`
+	Layout         json.RawMessage  `json:"layout"`
+	Skip           int              `json:"skip"`
+	Error          *string          `json:"error,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+// Overlaps reports whether the reservation overlaps the half-open period [from, to)
+func (r *AssetReservation) Overlaps(from, to time.Time) bool {
+	return r.StartsAt.Before(to) && from.Before(r.EndsAt)
+}
+
+// CostInterval is the billing period of a recurring cost
+type CostInterval string
+
+const (
+	CostIntervalMonthly   CostInterval = "monthly"
+	CostIntervalQuarterly CostInterval = "quarterly"
+	CostIntervalAnnual    CostInterval = "annual"
+)
+
+// Months returns the length of the interval in months (0 if invalid)
+func (i CostInterval) Months() int {
+	switch i {
+	case CostIntervalMonthly:
+		return 1
+	case CostIntervalQuarterly:
+		return 3
+	case CostIntervalAnnual:
+		return 12
+	}
+	return 0
+}
+
+// RecurringCost is a periodic cost of owning an asset, e.g. a cloud
+// subscription for a camera or an insurance premium
+type RecurringCost struct {
+	ID             uuid.UUID    `json:"id"`
+	OrganizationID uuid.UUID    `json:"organization_id"`
+	AssetID        uuid.UUID    `json:"asset_id"`
+	Name           string       `json:"name"`
+	Amount         float64      `json:"amount"` // Per interval
+	Currency       *string      `json:"currency,omitempty"`
+	Interval       CostInterval `json:"interval"`
+	NextRenewalAt  *time.Time   `json:"next_renewal_at,omitempty"`
+	RemindDays     int          `json:"remind_days"` // Days before renewal to send a reminder (0 = none)
+	RemindedFor    *time.Time   `json:"-"`           // Renewal date the last reminder was sent for
+	Notes          *string      `json:"notes,omitempty"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	DeletedAt      *time.Time   `json:"-"`
+
+	// Populated by queries
+	AssetName string `json:"asset_name,omitempty"`
+	HighValue bool   `json:"-"` // Of the asset
+}
+
+// MonthlyAmount returns the cost normalized to one month
+func (c *RecurringCost) MonthlyAmount() float64 {
+	months := c.Interval.Months()
+	if months == 0 {
+		return 0
+	}
+	return c.Amount / float64(months)
+}
+
+// RenewalOnOrAfter returns the first renewal date on or after day, advancing
+// NextRenewalAt by whole intervals (nil if no renewal date is set)
+func (c *RecurringCost) RenewalOnOrAfter(day time.Time) *time.Time {
+	if c.NextRenewalAt == nil || c.Interval.Months() == 0 {
+		return c.NextRenewalAt
+	}
+	renewal := *c.NextRenewalAt
+	for n := 1; renewal.Before(day); n++ {
+		// Step from the original date so month-end dates don't drift
+		renewal = addMonthsClamped(*c.NextRenewalAt, n*c.Interval.Months())
+	}
+	return &renewal
+}
+
+// addMonthsClamped adds months to t, clamping the day to the end of the
+// target month (Jan 31 + 1 month = Feb 28/29)
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(t.Day(), lastDay), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// MaintenanceUnit is the unit of a maintenance interval
+type MaintenanceUnit string
+
+const (
+	MaintenanceDay   MaintenanceUnit = "day"
+	MaintenanceWeek  MaintenanceUnit = "week"
+	MaintenanceMonth MaintenanceUnit = "month"
+	MaintenanceYear  MaintenanceUnit = "year"
+)
+
+// Valid reports whether u is a known unit
+func (u MaintenanceUnit) Valid() bool {
+	switch u {
+	case MaintenanceDay, MaintenanceWeek, MaintenanceMonth, MaintenanceYear:
+		return true
+	}
+	return false
+}
+
+// After returns n units after day; months and years that end earlier clamp
+// to their last day, e.g. a month after January 31 is February 28. Unknown
+// units return day unchanged.
+func (u MaintenanceUnit) After(day time.Time, n int) time.Time {
+	switch u {
+	case MaintenanceDay:
+		return day.AddDate(0, 0, n)
+	case MaintenanceWeek:
+		return day.AddDate(0, 0, 7*n)
+	case MaintenanceMonth:
+		return addMonthsClamped(day, n)
+	case MaintenanceYear:
+		return addMonthsClamped(day, 12*n)
+	}
+	return day
+}
+
+// MaintenanceTask is maintenance to do on an asset, once or every interval
+type MaintenanceTask struct {
+	ID             uuid.UUID        `json:"id"`
+	OrganizationID uuid.UUID        `json:"organization_id"`
+	AssetID        uuid.UUID        `json:"asset_id"`
+	Name           string           `json:"name"`
+	Notes          *string          `json:"notes,omitempty"`
+	IntervalCount  *int             `json:"interval_count,omitempty"` // Nil for one-off tasks
+	IntervalUnit   *MaintenanceUnit `json:"interval_unit,omitempty"`
+	DueAt          *time.Time       `json:"due_at,omitempty"` // Date; nil once a one-off task is done
+	LastDoneAt     *time.Time       `json:"last_done_at,omitempty"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	DeletedAt      *time.Time       `json:"-"`
+
+	// Populated by queries
+	AssetName string `json:"asset_name,omitempty"`
+	HighValue bool   `json:"-"` // Of the asset
+}
+
+// Recurring reports whether the task repeats
+func (t *MaintenanceTask) Recurring() bool {
+	return t.IntervalCount != nil && t.IntervalUnit != nil
+}
+
+// NextDue returns when the task is due again after it was done on day: one
+// interval later for recurring tasks, nil for one-off tasks
+func (t *MaintenanceTask) NextDue(day time.Time) *time.Time {
+	if !t.Recurring() || !t.IntervalUnit.Valid() {
+		return nil
+	}
+	next := t.IntervalUnit.After(day, *t.IntervalCount)
+	return &next
+}
+
+// Overdue reports whether the task was due before day (dates as UTC midnight)
+func (t *MaintenanceTask) Overdue(day time.Time) bool {
+	return t.DueAt != nil && t.DueAt.Before(day)
+}
+
+// MaintenanceLogEntry is maintenance done on an asset
+type MaintenanceLogEntry struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	AssetID        uuid.UUID  `json:"asset_id"`
+	TaskID         *uuid.UUID `json:"task_id,omitempty"` // Nil for maintenance outside a task
+	Name           string     `json:"name"`
+	DoneAt         time.Time  `json:"done_at"` // Date
+	Notes          *string    `json:"notes,omitempty"`
+	DoneBy         *uuid.UUID `json:"done_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Populated by queries
+	DoneByEmail *string `json:"done_by_email,omitempty"`
+}
+
+// ConsumptionSchedule is how fast a consumable is used up, e.g. one water
+// filter every 3 months; the amount is taken from the asset's quantity every
+// interval
+type ConsumptionSchedule struct {
+	ID             uuid.UUID       `json:"id"`
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	AssetID        uuid.UUID       `json:"asset_id"`
+	Amount         int             `json:"amount"`
+	IntervalCount  int             `json:"interval_count"`
+	IntervalUnit   MaintenanceUnit `json:"interval_unit"`
+	NextAt         time.Time       `json:"next_at"` // Date
+	LastAppliedAt  *time.Time      `json:"last_applied_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// Due returns how many intervals ended by day (dates as UTC midnight), more
+// than one when runs were missed, and the date of the next one after them
+func (c *ConsumptionSchedule) Due(day time.Time) (int, time.Time) {
+	if c.IntervalCount < 1 || !c.IntervalUnit.Valid() {
+		return 0, c.NextAt
+	}
+	n, next := 0, c.NextAt
+	for !next.After(day) {
+		n++
+		// Counted from NextAt, so clamping to a short month doesn't carry over
+		next = c.IntervalUnit.After(c.NextAt, n*c.IntervalCount)
+	}
+	return n, next
+}
+
+// Reminder is something to do by a date, about an asset or standalone
+type Reminder struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	AssetID        *uuid.UUID `json:"asset_id,omitempty"`
+	Title          string     `json:"title"`
+	Notes          *string    `json:"notes,omitempty"`
+	DueAt          time.Time  `json:"due_at"` // Date
+	DoneAt         *time.Time `json:"done_at,omitempty"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Populated by queries
+	AssetName *string `json:"asset_name,omitempty"`
+}
+
+// Overdue reports whether the reminder is still open after its due date (day
+// as UTC midnight, like DATE columns are read)
+func (r *Reminder) Overdue(day time.Time) bool {
+	return r.DoneAt == nil && r.DueAt.Before(day)
+}
+
+// ReminderFilter selects reminders to list
+type ReminderFilter struct {
+	AssetID   *uuid.UUID
+	Done      *bool      // nil = open and done reminders
+	DueBefore *time.Time // Due on or before the calendar date
+}
+
+// PurchaseStatus is where a purchase is in the pipeline
+type PurchaseStatus string
+
+const (
+	PurchaseWanted   PurchaseStatus = "wanted" // On the wishlist
+	PurchaseOrdered  PurchaseStatus = "ordered"
+	PurchaseReceived PurchaseStatus = "received" // Arrived and became an asset
+)
+
+// PurchaseStatuses lists the statuses in pipeline order
+var PurchaseStatuses = []PurchaseStatus{PurchaseWanted, PurchaseOrdered, PurchaseReceived}
+
+// Valid reports whether s is a known status
+func (s PurchaseStatus) Valid() bool {
+	return slices.Contains(PurchaseStatuses, s)
+}
+
+// Purchase is something to buy, from the wishlist to its order and arrival,
+// when it becomes an asset with the purchase details
+type Purchase struct {
+	ID             uuid.UUID      `json:"id"`
+	OrganizationID uuid.UUID      `json:"organization_id"`
+	CategoryID     *uuid.UUID     `json:"category_id,omitempty"` // Of the asset it becomes, required to receive it
+	Name           string         `json:"name"`
+	Notes          *string        `json:"notes,omitempty"`
+	Quantity       int            `json:"quantity"`
+	Status         PurchaseStatus `json:"status"`
+	Vendor         *string        `json:"vendor,omitempty"`
+	Price          *float64       `json:"price,omitempty"`       // Per unit
+	Currency       *string        `json:"currency,omitempty"`    // ISO 4217 code of Price
+	OrderedAt      *time.Time     `json:"ordered_at,omitempty"`  // Date
+	ExpectedAt     *time.Time     `json:"expected_at,omitempty"` // Date the order should arrive
+	WarrantyMonths *int           `json:"warranty_months,omitempty"`
+	ReceivedAt     *time.Time     `json:"received_at,omitempty"` // Date
+	AssetID        *uuid.UUID     `json:"asset_id,omitempty"`    // The asset it became once received
+	CreatedBy      *uuid.UUID     `json:"created_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// Late reports whether an order should have arrived before day (dates as UTC
+// midnight)
+func (p *Purchase) Late(day time.Time) bool {
+	return p.Status == PurchaseOrdered && p.ExpectedAt != nil && p.ExpectedAt.Before(day)
+}
+
+// WarrantyEnd returns when the warranty of the purchase ends if it starts on
+// start, nil without a warranty
+func (p *Purchase) WarrantyEnd(start time.Time) *time.Time {
+	if p.WarrantyMonths == nil {
+		return nil
+	}
+	end := addMonthsClamped(start, *p.WarrantyMonths)
+	return &end
+}
+
+// PurchaseFilter selects purchases to list
+type PurchaseFilter struct {
+	Statuses []PurchaseStatus // Empty = all
+}
+
+// PurchaseGroup is a bundle of assets bought together for one price, e.g. a
+// camera kit, whose total was allocated across their purchase prices
+type PurchaseGroup struct {
+	ID             uuid.UUID           `json:"id"`
+	OrganizationID uuid.UUID           `json:"organization_id"`
+	Name           string              `json:"name"`
+	Total          float64             `json:"total"`              // What the bundle cost
+	Currency       *string             `json:"currency,omitempty"` // ISO 4217 code of Total
+	PurchaseAt     *time.Time          `json:"purchase_at,omitempty"`
+	PurchaseNote   *string             `json:"purchase_note,omitempty"`
+	CreatedBy      *uuid.UUID          `json:"created_by,omitempty"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	Items          []PurchaseGroupItem `json:"items,omitempty"` // Populated by queries
+	HighValue      bool                `json:"-"`               // Some of its assets are high-value
+}
+
+// PurchaseGroupItem is an asset of a purchase group and its share
+type PurchaseGroupItem struct {
+	AssetID       uuid.UUID `json:"asset_id"`
+	Name          string    `json:"name"`
+	Quantity      int       `json:"quantity"`
+	PurchasePrice *float64  `json:"purchase_price,omitempty"` // Per unit
+	HighValue     bool      `json:"-"`
+}
+
+// BundleAllocation is how the total of a purchase group is split across its
+// assets
+type BundleAllocation string
+
+const (
+	BundleProportional BundleAllocation = "proportional" // By what each item costs on its own
+	BundleManual       BundleAllocation = "manual"       // By prices entered for each item
+)
+
+// Valid reports whether a is a known allocation
+func (a BundleAllocation) Valid() bool {
+	return a == BundleProportional || a == BundleManual
+}
+
+// AllocateBundle splits total across shares in proportion to weights, to
+// the cent, so that the shares add up to total; leftover cents go to the
+// largest fractions. Without any weight, total is split evenly.
+func AllocateBundle(total float64, weights []float64) []float64 {
+	if len(weights) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	if sum <= 0 {
+		weights = slices.Repeat([]float64{1}, len(weights))
+		sum = float64(len(weights))
+	}
+
+	cents := int64(math.Round(total * 100))
+	shares := make([]int64, len(weights))
+	fractions := make([]float64, len(weights))
+	left := cents
+	for i, w := range weights {
+		exact := float64(cents) * w / sum
+		shares[i] = int64(math.Floor(exact))
+		fractions[i] = exact - float64(shares[i])
+		left -= shares[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(fractions[b], fractions[a]) })
+	// The fractions add up to fewer cents than there are shares
+	for _, i := range order[:left] {
+		shares[i]++
+	}
+
+	amounts := make([]float64, len(shares))
+	for i, c := range shares {
+		amounts[i] = float64(c) / 100
+	}
+	return amounts
+}
+
+// RecurringCostTotals are recurring costs rolled up per month and year
+type RecurringCostTotals struct {
+	Count   int     `json:"count"`
+	Monthly float64 `json:"monthly"`
+	Annual  float64 `json:"annual"`
+}
+
+// AssetValue is what one unit of an asset was worth on a date, e.g. from
+// recent sales of the same model. The latest is the asset's current value.
+type AssetValue struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	AssetID        uuid.UUID  `json:"asset_id"`
+	Value          float64    `json:"value"`
+	ValuedAt       time.Time  `json:"valued_at"` // Date
+	Note           *string    `json:"note,omitempty"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Populated by queries
+	CreatedByEmail *string `json:"created_by_email,omitempty"`
+}
+
+// DepreciationMethod is how an asset loses value over its useful life
+type DepreciationMethod string
+
+const (
+	DepreciationStraightLine     DepreciationMethod = "straight_line"     // The same amount every year
+	DepreciationDecliningBalance DepreciationMethod = "declining_balance" // Double the straight-line rate, of the remaining value
+)
+
+// Valid reports whether m is a known method
+func (m DepreciationMethod) Valid() bool {
+	return m == DepreciationStraightLine || m == DepreciationDecliningBalance
+}
+
+// Depreciation is how an asset, or every asset of a category, loses value
+type Depreciation struct {
+	ID               uuid.UUID          `json:"id"`
+	OrganizationID   uuid.UUID          `json:"organization_id"`
+	AssetID          *uuid.UUID         `json:"asset_id,omitempty"`    // Set for an asset's own rule
+	CategoryID       *uuid.UUID         `json:"category_id,omitempty"` // Set for a category's rule
+	Method           DepreciationMethod `json:"method"`
+	UsefulLifeMonths int                `json:"useful_life_months"`
+	SalvageValue     float64            `json:"salvage_value"` // Value left at the end of the useful life
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+// BookValue returns what an asset bought for cost on purchased is worth on
+// day, rounded to cents. It doesn't drop below the salvage value, nor does
+// a salvage value above the cost make the asset worth more.
+func (d *Depreciation) BookValue(cost float64, purchased, day time.Time) float64 {
+	salvage := math.Min(d.SalvageValue, cost)
+	years := day.Sub(purchased).Hours() / 24 / 365.25
+	if years <= 0 {
+		return cost
+	}
+	life := float64(d.UsefulLifeMonths) / 12
+
+	value := salvage
+	if years < life {
+		switch d.Method {
+		case DepreciationDecliningBalance:
+			value = cost * math.Pow(1-math.Min(2/life, 1), years)
+		default:
+			value = cost - (cost-salvage)*years/life
+		}
+	}
+	return math.Round(math.Max(value, salvage)*100) / 100
+}
+
+// PowerUsage is the power consumption profile of an electrical asset
+type PowerUsage struct {
+	AssetID      uuid.UUID `json:"asset_id"`
+	Watts        float64   `json:"watts"`         // While in use
+	StandbyWatts float64   `json:"standby_watts"` // For the rest of the day
+	HoursPerDay  float64   `json:"hours_per_day"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DailyKWh returns the energy used per day by one unit
+func (p *PowerUsage) DailyKWh() float64 {
+	return (p.Watts*p.HoursPerDay + p.StandbyWatts*(24-p.HoursPerDay)) / 1000
+}
+
+// PowerUsageWithAsset is a power profile with the asset details needed for reports
+type PowerUsageWithAsset struct {
+	PowerUsage
+	AssetName    string     `json:"asset_name"`
+	Quantity     int        `json:"quantity"`
+	LocationID   *uuid.UUID `json:"location_id,omitempty"`
+	LocationName *string    `json:"location_name,omitempty"`
+	HighValue    bool       `json:"-"`
+}
+
+// EnergySettings configures energy cost estimation
+type EnergySettings struct {
+	PricePerKWh float64 `json:"price_per_kwh"`
+	Currency    *string `json:"currency,omitempty"`
+}
+
+// ReportKind identifies a report that can be scheduled
+type ReportKind string
+
+const (
+	ReportInsurance  ReportKind = "insurance"  // Insurance summary
+	ReportEnergy     ReportKind = "energy"     // Energy cost estimate
+	ReportWarranties ReportKind = "warranties" // Warranties expiring within 30 days
+	ReportCosts      ReportKind = "costs"      // Recurring costs
+	ReportList       ReportKind = "list"       // Printable asset list
+)
+
+// Valid reports whether k is a known report
+func (k ReportKind) Valid() bool {
+	switch k {
+	case ReportInsurance, ReportEnergy, ReportWarranties, ReportCosts, ReportList:
+		return true
+	}
+	return false
+}
+
+// ReportDelivery is how a scheduled report is delivered
+type ReportDelivery string
+
+const (
+	ReportDeliveryEmail   ReportDelivery = "email"   // Attached to an email to the recipients
+	ReportDeliveryStorage ReportDelivery = "storage" // Uploaded into the file storage
+)
+
+// ReportSchedule delivers a report periodically
+type ReportSchedule struct {
+	ID             uuid.UUID      `json:"id"`
+	OrganizationID uuid.UUID      `json:"organization_id"`
+	Name           string         `json:"name"`
+	Report         ReportKind     `json:"report"`
+	ListID         *uuid.UUID     `json:"list_id,omitempty"` // For list reports
+	Cron           string         `json:"cron"`              // e.g. "0 8 * * mon", in the organization's time zone
+	Delivery       ReportDelivery `json:"delivery"`
+	Recipients     []string       `json:"recipients"` // For email delivery
+	Enabled        bool           `json:"enabled"`
+	NextRunAt      *time.Time     `json:"next_run_at,omitempty"`
+	LastRunAt      *time.Time     `json:"last_run_at,omitempty"`
+	CreatedBy      *uuid.UUID     `json:"created_by,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      *time.Time     `json:"-"`
+}
+
+// ReportRunStatus is the outcome of a report run
+type ReportRunStatus string
+
+const (
+	ReportRunSucceeded ReportRunStatus = "succeeded"
+	ReportRunFailed    ReportRunStatus = "failed"
+)
+
+// ReportRun is one execution of a report schedule
+type ReportRun struct {
+	ID         uuid.UUID       `json:"id"`
+	ScheduleID uuid.UUID       `json:"schedule_id"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	Status     ReportRunStatus `json:"status"`
+	Error      *string         `json:"error,omitempty"`
+	FileKey    *string         `json:"-"` // Uploaded report (storage delivery)
+	SizeBytes  int64           `json:"size_bytes"`
+}
+
+// UserTOTP is a user's TOTP two-factor enrollment
+type UserTOTP struct {
+	UserID      uuid.UUID  `json:"user_id"`
+	Secret      string     `json:"-"`                    // Encrypted base32 secret
+	EnabledAt   *time.Time `json:"enabled_at,omitempty"` // Nil until the first code is confirmed
+	BackupCodes []string   `json:"-"`                    // Hashes of the unused backup codes
+	LastCounter int64      `json:"-"`                    // Time step of the last accepted code
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Enabled reports whether the enrollment was confirmed
+func (t *UserTOTP) Enabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// Passkey is a WebAuthn credential a user can log in with instead of a password
+type Passkey struct {
+	ID           uuid.UUID  `json:"id"`
+	UserID       uuid.UUID  `json:"user_id"`
+	CredentialID []byte     `json:"-"`
+	PublicKey    []byte     `json:"-"`         // SubjectPublicKeyInfo (DER)
+	Algorithm    int        `json:"algorithm"` // COSE algorithm identifier
+	SignCount    int64      `json:"-"`
+	Name         string     `json:"name"`
+	Transports   []string   `json:"transports"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// UserSession is a local login session, stored so it can be listed and revoked
+type UserSession struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	TokenHash  string    `json:"-"` // SHA-256 of the token in the session cookie
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+
+	// MustChangePassword limits the session to changing the password,
+	// inherited from the user when the session is created
+	MustChangePassword bool `json:"must_change_password"`
+}
+
+// ServiceToken authenticates a service account. It only grants the scopes
+// that are also granted by the account's role.
+type ServiceToken struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Name       string       `json:"name"`
+	TokenHash  string       `json:"-"` // SHA-256 of the token
+	Scopes     []Permission `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"` // nil = never expires
+}
+
+// Expired reports whether the token can no longer be used
+func (t *ServiceToken) Expired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// ImportRecord logs a plugin import, successful or not
+type ImportRecord struct {
+	ID             uuid.UUID       `json:"id"`
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	UserID         *uuid.UUID      `json:"user_id,omitempty"` // User who started the import
+	PluginID       string          `json:"plugin_id"`
+	ExternalID     string          `json:"external_id"`
+	AssetID        *uuid.UUID      `json:"asset_id,omitempty"` // Created asset, nil if the import failed
+	Success        bool            `json:"success"`
+	Error          *string         `json:"error,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty"` // ImportData returned by the plugin, before transforms
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// AssetSource is the data a plugin returned for an imported asset (before
+// import transforms)
+type AssetSource struct {
+	AssetID    uuid.UUID       `json:"asset_id"`
+	PluginID   string          `json:"plugin_id"`
+	ExternalID string          `json:"external_id"`
+	Data       json.RawMessage `json:"data"`
+	FetchedAt  time.Time       `json:"fetched_at"`
+}
+
+// ImportRecordFilter filters the import log
+type ImportRecordFilter struct {
+	PluginID string
+	Success  *bool
+	Limit    int
+	Offset   int
+}
+
+// SyncResourceChanges lists the IDs of a resource type changed since a sync point
+type SyncResourceChanges struct {
+	Created []uuid.UUID `json:"created"`
+	Updated []uuid.UUID `json:"updated"`
+	Deleted []uuid.UUID `json:"deleted"`
+}
+
+// SyncChanges describes all changes since a sync point, keyed by resource type
+type SyncChanges struct {
+	Since      time.Time                       `json:"since"`
+	ServerTime time.Time                       `json:"server_time"` // Use as "since" for the next sync
+	Changes    map[string]*SyncResourceChanges `json:"changes"`
+}
+
+// Organization setting keys
+const (
+	SettingBranding    = "branding"
+	SettingOIDC        = "oidc"
+	SettingHighValue   = "high_value"
+	SettingTimeZone    = "time_zone"
+	SettingEnergy      = "energy"
+	SettingTwoFactor   = "two_factor"
+	SettingHTTPPlugins = "http_plugins"
+	SettingTransforms  = "import_transforms"
+	SettingPlugins     = "plugins"
+	SettingGallery     = "public_gallery"
+	SettingDeletion    = "pending_deletion"
+)
+
+// PublicGallery exposes selected categories read-only at /public/{slug},
+// without values, locations or high-value items
+type PublicGallery struct {
+	Enabled     bool        `json:"enabled"`
+	Slug        string      `json:"slug"`
+	Title       string      `json:"title,omitempty"` // Defaults to the branding title
+	CategoryIDs []uuid.UUID `json:"category_ids"`
+
+	OrganizationID uuid.UUID `json:"-"` // Organization the gallery belongs to
+}
+
+// PluginSettings holds the organization's import plugin toggles
+type PluginSettings struct {
+	Disabled []string `json:"disabled"` // IDs of plugins turned off by an admin
 }
+
+// ImportTransformSettings holds the import transforms of an organization,
+// keyed by plugin ID
+type ImportTransformSettings struct {
+	Plugins map[string][]ImportTransform `json:"plugins"`
+}
+
+// ImportTransform rewrites a field of imported data with an expression (see
+// package expr), e.g. stripping edition suffixes from titles. Transforms run
+// in order, each seeing the result of the previous ones.
+type ImportTransform struct {
+	Field      string `json:"field"`      // "name", "description", "image_url" or an attribute key
+	Expression string `json:"expression"` // Result null removes the field (name cannot be removed)
+}
+
+// HTTPPluginSettings lists the generic HTTP import plugins of an organization
+type HTTPPluginSettings struct {
+	Plugins []HTTPPluginConfig `json:"plugins"`
+}
+
+// HTTPPluginConfig defines an import plugin backed by any JSON HTTP API.
+// Paths are JSONPath expressions, e.g. "$.items[*]" or "volumeInfo.title".
+type HTTPPluginConfig struct {
+	ID           string `json:"id"` // Lowercase slug, the plugin is registered as "http_<id>"
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	CategoryName string `json:"category_name"`
+
+	SearchURL string `json:"search_url"` // Template with {query} and {limit}
+	FetchURL  string `json:"fetch_url"`  // Template with {id}
+
+	ResultsPath    string `json:"results_path"`              // Result list in the search response
+	ResultID       string `json:"result_id"`                 // Per result: external ID
+	ResultTitle    string `json:"result_title"`              // Per result: title
+	ResultSubtitle string `json:"result_subtitle,omitempty"` // Per result: subtitle
+	ResultImage    string `json:"result_image,omitempty"`    // Per result: thumbnail URL
+
+	NamePath        string                `json:"name_path"` // Fetch response: asset name
+	DescriptionPath string                `json:"description_path,omitempty"`
+	ImagePath       string                `json:"image_path,omitempty"`
+	Attributes      []HTTPPluginAttribute `json:"attributes,omitempty"`
+
+	HeadersEncrypted string `json:"headers_encrypted,omitempty"` // Request headers (e.g. API keys) as encrypted JSON
+}
+
+// HTTPPluginAttribute maps a value of the fetch response to an asset attribute
+type HTTPPluginAttribute struct {
+	Key      string            `json:"key"` // Namespaced with the plugin ID, e.g. "year" becomes "http_games.year"
+	Name     string            `json:"name"`
+	DataType AttributeDataType `json:"data_type"`
+	Path     string            `json:"path"`
+}
+
+// TwoFactorPolicy configures two-factor authentication for local accounts
+type TwoFactorPolicy struct {
+	Required bool `json:"required"` // Users must enroll in TOTP before they can sign in
+}
+
+// Branding holds an organization's look & feel for the login page, reports and emails
+type Branding struct {
+	Title       string  `json:"title,omitempty"`
+	AccentColor string  `json:"accent_color,omitempty"` // Hex color, e.g. "#4f46e5"
+	LogoKey     *string `json:"logo_key,omitempty"`     // FileStorage key of the uploaded logo
+}
+
+// HighValuePolicy controls the handling of high-value items
+type HighValuePolicy struct {
+	Threshold    float64    `json:"threshold"`     // Items worth at least this much are flagged automatically (0 = off)
+	VisibleRoles []UserRole `json:"visible_roles"` // Roles besides admin that can see high-value items (empty = everyone)
+}
+
+// Exceeds reports whether the asset's total purchase value reaches the threshold
+func (p HighValuePolicy) Exceeds(a *Asset) bool {
+	return p.Threshold > 0 && a.PurchasePrice != nil && *a.PurchasePrice*float64(a.Quantity) >= p.Threshold
+}
+
+// CanView reports whether users with role can see high-value items (admins always can)
+func (p HighValuePolicy) CanView(role UserRole) bool {
+	return role == UserRoleAdmin || len(p.VisibleRoles) == 0 || slices.Contains(p.VisibleRoles, role)
+}
+
+// OIDCProvider is an organization's own OIDC identity provider configuration
+type OIDCProvider struct {
+	IssuerURL             string `json:"issuer_url"`
+	ClientID              string `json:"client_id"`
+	ClientSecretEncrypted string `json:"client_secret_encrypted,omitempty"` // Encrypted with the server encryption key
+	Enabled               bool   `json:"enabled"`
+}
+
+// LoginMethod identifies how a user authenticated
+type LoginMethod string
+
+const (
+	LoginMethodPassword LoginMethod = "password"
+	LoginMethodOIDC     LoginMethod = "oidc"
+	LoginMethodPasskey  LoginMethod = "passkey"
+)
+
+// LoginEvent records a login attempt for auditing
+type LoginEvent struct {
+	ID             uuid.UUID   `json:"id"`
+	OrganizationID uuid.UUID   `json:"organization_id"`
+	UserID         *uuid.UUID  `json:"user_id,omitempty"` // nil = unknown account
+	Email          string      `json:"email"`
+	IPAddress      string      `json:"ip_address"`
+	UserAgent      string      `json:"user_agent"`
+	Method         LoginMethod `json:"method"`
+	Success        bool        `json:"success"`
+	FailureReason  *string     `json:"failure_reason,omitempty"`
+	NewDevice      bool        `json:"new_device"` // First successful login from this IP/user agent
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// LoginEventFilter filters the login audit log
+type LoginEventFilter struct {
+	UserID  *uuid.UUID
+	Success *bool
+	Limit   int
+	Offset  int
+}
+
+// AuthEventType is the kind of an authentication event
+type AuthEventType string
+
+const (
+	AuthEventLogin          AuthEventType = "login"
+	AuthEventLoginFailed    AuthEventType = "login_failed"
+	AuthEventLogout         AuthEventType = "logout"
+	AuthEventPasswordChange AuthEventType = "password_change"
+	AuthEventPasswordReset  AuthEventType = "password_reset" // By an administrator
+)
+
+// Valid reports whether t is a known event type
+func (t AuthEventType) Valid() bool {
+	switch t {
+	case AuthEventLogin, AuthEventLoginFailed, AuthEventLogout, AuthEventPasswordChange, AuthEventPasswordReset:
+		return true
+	}
+	return false
+}
+
+// AuthEvent records an authentication event for auditing
+type AuthEvent struct {
+	ID             uuid.UUID     `json:"id"`
+	OrganizationID uuid.UUID     `json:"organization_id"`
+	UserID         *uuid.UUID    `json:"user_id,omitempty"` // nil = unknown account
+	Email          string        `json:"email"`
+	Type           AuthEventType `json:"type"`
+	Method         *LoginMethod  `json:"method,omitempty"` // Set for logins
+	FailureReason  *string       `json:"failure_reason,omitempty"`
+	IPAddress      string        `json:"ip_address"`
+	UserAgent      string        `json:"user_agent"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// AuthEventFilter filters the authentication audit log
+type AuthEventFilter struct {
+	UserID *uuid.UUID
+	Type   AuthEventType // Empty = all
+	From   *time.Time    // Inclusive
+	To     *time.Time    // Exclusive
+	Limit  int
+	Offset int
+}
+
+// ShortLink is a short URL to a view of the web app with its filter, sort and
+// facet state (the query parameters of the view)
+type ShortLink struct {
+	Code           string              `json:"code"`
+	OrganizationID uuid.UUID           `json:"organization_id"`
+	CreatedBy      *uuid.UUID          `json:"created_by,omitempty"`
+	Path           string              `json:"path"` // App route, e.g. "/assets"
+	State          map[string][]string `json:"state"`
+	CreatedAt      time.Time           `json:"created_at"`
+	LastUsedAt     *time.Time          `json:"last_used_at,omitempty"`
+}
+
+// AssetChange is the change of a field of an asset: "name", or
+// "attributes.<key>" for an attribute. Old is null for added attributes, New
+// for removed ones.
+type AssetChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// AssetEvent is an entry of the change history of an asset
+type AssetEvent struct {
+	ID             uuid.UUID      `json:"id"`
+	OrganizationID uuid.UUID      `json:"organization_id"`
+	AssetID        uuid.UUID      `json:"asset_id"`
+	Type           string         `json:"type"` // Event type, e.g. "asset.updated"
+	ActorID        *uuid.UUID     `json:"actor_id,omitempty"`
+	ActorEmail     *string        `json:"actor_email,omitempty"`
+	Changes        []AssetChange  `json:"changes"`
+	Snapshot       *AssetSnapshot `json:"snapshot,omitempty"` // Nil for events recorded before snapshots
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// AssetComment is a note on an asset, e.g. about maintenance, in a timeline
+// separate from its description
+type AssetComment struct {
+	ID        uuid.UUID  `json:"id"`
+	AssetID   uuid.UUID  `json:"asset_id"`
+	AuthorID  *uuid.UUID `json:"author_id,omitempty"` // Nil if the author was deleted
+	Body      string     `json:"body"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Populated by queries
+	AuthorEmail *string `json:"author_email,omitempty"`
+	AuthorName  *string `json:"author_name,omitempty"`
+}
+
+// AssetSnapshot is the state of an asset right after an event, to which the
+// asset can be reverted
+type AssetSnapshot struct {
+	CategoryID    uuid.UUID       `json:"category_id"`
+	LocationID    *uuid.UUID      `json:"location_id"`
+	ConditionID   *uuid.UUID      `json:"condition_id"`
+	CollectionID  *uuid.UUID      `json:"collection_id"`
+	OwnerID       *uuid.UUID      `json:"owner_id"`
+	Name          string          `json:"name"`
+	Description   *string         `json:"description"`
+	Quantity      int             `json:"quantity"`
+	Attributes    json.RawMessage `json:"attributes"`
+	PurchaseAt    *string         `json:"purchase_at"` // YYYY-MM-DD
+	PurchasePrice *float64        `json:"purchase_price"`
+	Currency      *string         `json:"currency"`
+	PurchaseNote  *string         `json:"purchase_note"`
+	Notes         *string         `json:"notes"`
+	TagIDs        []uuid.UUID     `json:"tag_ids"`
+}
+
+// Apply sets the fields of asset to the snapshot. Attributes are only
+// restored if attributes is true; tags are stored separately.
+func (s *AssetSnapshot) Apply(asset *Asset, attributes bool) error {
+	asset.PurchaseAt = nil
+	if s.PurchaseAt != nil {
+		t, err := time.Parse(time.DateOnly, *s.PurchaseAt)
+		if err != nil {
+			return fmt.Errorf("invalid purchase_at in snapshot: %w", err)
+		}
+		asset.PurchaseAt = &t
+	}
+	asset.CategoryID = s.CategoryID
+	asset.LocationID = s.LocationID
+	asset.ConditionID = s.ConditionID
+	asset.CollectionID = s.CollectionID
+	asset.OwnerID = s.OwnerID
+	asset.Name = s.Name
+	asset.Description = s.Description
+	asset.Quantity = s.Quantity
+	asset.PurchasePrice = s.PurchasePrice
+	asset.Currency = s.Currency
+	asset.PurchaseNote = s.PurchaseNote
+	asset.Notes = s.Notes
+	if attributes {
+		asset.Attributes = s.Attributes
+	}
+	return nil
+}
+
+// AttributePrefix prefixes the fields of attribute changes
+const AttributePrefix = "attributes."
+
+// AttributeChanges returns the changes between two versions of asset
+// attributes (JSON objects), by key
+func AttributeChanges(before, after json.RawMessage) ([]AssetChange, error) {
+	var old, cur map[string]json.RawMessage
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &old); err != nil {
+			return nil, err
+		}
+	}
+	if len(after) > 0 {
+		if err := json.Unmarshal(after, &cur); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(old)+len(cur))
+	for k := range old {
+		keys = append(keys, k)
+	}
+	for k := range cur {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	changes := []AssetChange{}
+	for _, k := range keys {
+		o, n := old[k], cur[k]
+		if jsonEqual(o, n) {
+			continue
+		}
+		changes = append(changes, AssetChange{Field: AttributePrefix + k, Old: o, New: n})
+	}
+	return changes, nil
+}
+
+// SnapshotChanges returns the changes between two snapshots of an asset (JSON
+// objects, before may be empty for a new asset) by field, with attributes
+// compared by key like AttributeChanges
+func SnapshotChanges(before, after json.RawMessage) ([]AssetChange, error) {
+	var old, cur map[string]json.RawMessage
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &old); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(after, &cur); err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(cur))
+	for f := range cur {
+		fields = append(fields, f)
+	}
+	slices.Sort(fields)
+
+	changes := []AssetChange{}
+	for _, f := range fields {
+		if f == "attributes" {
+			attributes, err := AttributeChanges(old[f], cur[f])
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, attributes...)
+			continue
+		}
+		if !jsonEqual(old[f], cur[f]) {
+			changes = append(changes, AssetChange{Field: f, Old: old[f], New: cur[f]})
+		}
+	}
+	return changes, nil
+}
+
+// jsonEqual reports whether two JSON values are the same, ignoring
+// formatting; missing values equal null
+func jsonEqual(a, b json.RawMessage) bool {
+	var x, y any
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &x); err != nil {
+			return false
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &y); err != nil {
+			return false
+		}
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// Invite is a link that lets people join an organization with a role. Only
+// the hash of its token is stored.
+type Invite struct {
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	TokenHash      string     `json:"-"`
+	Role           UserRole   `json:"role"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	MaxUses        *int       `json:"max_uses,omitempty"` // nil = unlimited
+	Uses           int        `json:"uses"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Usable reports whether the invite can still be accepted at now
+func (i *Invite) Usable(now time.Time) bool {
+	return i.RevokedAt == nil && now.Before(i.ExpiresAt) && (i.MaxUses == nil || i.Uses < *i.MaxUses)
+}
+
+// UserPreferences are the settings of the web app a user keeps across
+// devices. Unset fields fall back to the app defaults.
+type UserPreferences struct {
+	PageSize          int        `json:"page_size,omitempty"`   // 1 to 100, 0 = unset
+	Currency          string     `json:"currency,omitempty"`    // ISO 4217 code
+	DateFormat        string     `json:"date_format,omitempty"` // One of DateFormats
+	Theme             string     `json:"theme,omitempty"`       // One of Themes
+	DefaultCategoryID *uuid.UUID `json:"default_category_id,omitempty"`
+
+	// Views holds the layout of each view (table columns, sort defaults),
+	// keyed by view name; the web app owns its format
+	Views map[string]json.RawMessage `json:"views,omitempty"`
+}
+
+// DateFormats are the date formats a user can choose
+var DateFormats = []string{"YYYY-MM-DD", "DD/MM/YYYY", "MM/DD/YYYY", "DD.MM.YYYY"}
+
+// Themes are the color themes a user can choose
+var Themes = []string{"system", "light", "dark"}
@@ -1,7 +1,11 @@
 package domain
 
 import (
+	"encoding/json"
+	"math"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -90,3 +94,452 @@ func Test_AttributeDataType_Constants_HaveExpectedValues(t *testing.T) {
 		}
 	}
 }
+
+func Test_AssetReservation_Overlaps(t *testing.T) {
+	base := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	res := &AssetReservation{StartsAt: base, EndsAt: base.Add(2 * time.Hour)}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     bool
+	}{
+		{"inside", base.Add(30 * time.Minute), base.Add(time.Hour), true},
+		{"covering", base.Add(-time.Hour), base.Add(3 * time.Hour), true},
+		{"overlapping start", base.Add(-time.Hour), base.Add(time.Minute), true},
+		{"ending at start", base.Add(-time.Hour), base, false},
+		{"starting at end", base.Add(2 * time.Hour), base.Add(3 * time.Hour), false},
+		{"after", base.Add(5 * time.Hour), base.Add(6 * time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := res.Overlaps(tt.from, tt.to); got != tt.want {
+				t.Errorf("Overlaps() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_HighValuePolicy_Exceeds(t *testing.T) {
+	price := 400.0
+	asset := &Asset{Quantity: 3, PurchasePrice: &price}
+
+	if !(HighValuePolicy{Threshold: 1200}).Exceeds(asset) {
+		t.Error("expected total value reaching the threshold to exceed it")
+	}
+	if (HighValuePolicy{Threshold: 1500}).Exceeds(asset) {
+		t.Error("expected value below the threshold not to exceed it")
+	}
+	if (HighValuePolicy{}).Exceeds(asset) {
+		t.Error("expected a zero threshold to disable auto-flagging")
+	}
+	if (HighValuePolicy{Threshold: 1}).Exceeds(&Asset{Quantity: 1}) {
+		t.Error("expected assets without a price not to exceed the threshold")
+	}
+}
+
+func Test_HighValuePolicy_CanView(t *testing.T) {
+	open := HighValuePolicy{}
+	if !open.CanView(UserRoleUser) {
+		t.Error("expected everyone to see high-value items without visible roles")
+	}
+
+	restricted := HighValuePolicy{VisibleRoles: []UserRole{UserRoleAdmin}}
+	if restricted.CanView(UserRoleUser) {
+		t.Error("expected users to be excluded")
+	}
+	if restricted.CanView("") {
+		t.Error("expected unknown roles to be excluded")
+	}
+	if !restricted.CanView(UserRoleAdmin) {
+		t.Error("expected admins to see high-value items")
+	}
+}
+
+func Test_RecurringCost_MonthlyAmount(t *testing.T) {
+	annual := &RecurringCost{Amount: 120, Interval: CostIntervalAnnual}
+	if got := annual.MonthlyAmount(); got != 10 {
+		t.Errorf("expected 10, got %v", got)
+	}
+	invalid := &RecurringCost{Amount: 120, Interval: "weekly"}
+	if got := invalid.MonthlyAmount(); got != 0 {
+		t.Errorf("expected 0 for an invalid interval, got %v", got)
+	}
+}
+
+func Test_RecurringCost_RenewalOnOrAfter(t *testing.T) {
+	renewal := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	cost := &RecurringCost{Interval: CostIntervalMonthly, NextRenewalAt: &renewal}
+
+	got := cost.RenewalOnOrAfter(time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	got = cost.RenewalOnOrAfter(time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected month end to be clamped to %v, got %v", want, got)
+	}
+
+	if got := cost.RenewalOnOrAfter(renewal); !got.Equal(renewal) {
+		t.Errorf("expected a renewal today to be kept, got %v", got)
+	}
+}
+
+func Test_MaintenanceTask_NextDue(t *testing.T) {
+	day := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	every := func(n int, unit MaintenanceUnit) *MaintenanceTask {
+		return &MaintenanceTask{IntervalCount: &n, IntervalUnit: &unit}
+	}
+
+	tests := []struct {
+		task *MaintenanceTask
+		want time.Time
+	}{
+		{every(10, MaintenanceDay), time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)},
+		{every(2, MaintenanceWeek), time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC)},
+		{every(1, MaintenanceMonth), time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)},
+		{every(3, MaintenanceMonth), time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)},
+		{every(1, MaintenanceYear), time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := tt.task.NextDue(day); got == nil || !got.Equal(tt.want) {
+			t.Errorf("every %d %s: expected %v, got %v", *tt.task.IntervalCount, *tt.task.IntervalUnit, tt.want, got)
+		}
+	}
+
+	if got := (&MaintenanceTask{}).NextDue(day); got != nil {
+		t.Errorf("expected a one-off task not to be due again, got %v", got)
+	}
+}
+
+func Test_ConsumptionSchedule_Due(t *testing.T) {
+	schedule := &ConsumptionSchedule{Amount: 1, IntervalCount: 1, IntervalUnit: MaintenanceMonth, NextAt: time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		day  time.Time
+		n    int
+		next time.Time
+	}{
+		{time.Date(2025, 1, 30, 0, 0, 0, 0, time.UTC), 0, time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), 1, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC)},
+		// Missed runs catch up, back on the 31st after February
+		{time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC), 3, time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		n, next := schedule.Due(tt.day)
+		if n != tt.n || !next.Equal(tt.next) {
+			t.Errorf("on %s: expected %d due, next %v, got %d, %v", tt.day.Format(time.DateOnly), tt.n, tt.next, n, next)
+		}
+	}
+
+	if n, _ := (&ConsumptionSchedule{NextAt: schedule.NextAt}).Due(tests[2].day); n != 0 {
+		t.Errorf("expected nothing due without an interval, got %d", n)
+	}
+}
+
+func Test_PowerUsage_DailyKWh(t *testing.T) {
+	tv := &PowerUsage{Watts: 100, StandbyWatts: 1, HoursPerDay: 4}
+	if got := tv.DailyKWh(); math.Abs(got-0.42) > 1e-9 {
+		t.Errorf("expected 0.42 kWh, got %v", got)
+	}
+}
+
+func Test_Invite_Usable(t *testing.T) {
+	now := time.Now()
+	one := 1
+	revoked := now.Add(-time.Minute)
+	tests := map[string]struct {
+		invite Invite
+		want   bool
+	}{
+		"open":      {invite: Invite{ExpiresAt: now.Add(time.Hour)}, want: true},
+		"expired":   {invite: Invite{ExpiresAt: now.Add(-time.Second)}},
+		"revoked":   {invite: Invite{ExpiresAt: now.Add(time.Hour), RevokedAt: &revoked}},
+		"used up":   {invite: Invite{ExpiresAt: now.Add(time.Hour), MaxUses: &one, Uses: 1}},
+		"uses left": {invite: Invite{ExpiresAt: now.Add(time.Hour), MaxUses: &one}, want: true},
+	}
+	for name, tt := range tests {
+		if got := tt.invite.Usable(now); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, got)
+		}
+	}
+}
+
+func Test_AttributeChanges(t *testing.T) {
+	changes, err := AttributeChanges(
+		json.RawMessage(`{"serial":"A1","color":"red","size":2,"removed":true}`),
+		json.RawMessage(`{"serial":"A2","color":"red","size":2.0,"added":"x"}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AssetChange{
+		{Field: "attributes.added", New: json.RawMessage(`"x"`)},
+		{Field: "attributes.removed", Old: json.RawMessage(`true`)},
+		{Field: "attributes.serial", Old: json.RawMessage(`"A1"`), New: json.RawMessage(`"A2"`)},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i, c := range changes {
+		if c.Field != want[i].Field || string(c.Old) != string(want[i].Old) || string(c.New) != string(want[i].New) {
+			t.Errorf("change %d: expected %+v, got %+v", i, want[i], c)
+		}
+	}
+
+	if changes, err := AttributeChanges(nil, json.RawMessage(`{}`)); err != nil || len(changes) != 0 {
+		t.Errorf("expected no changes, got %+v, %v", changes, err)
+	}
+	if _, err := AttributeChanges(json.RawMessage(`[1]`), nil); err == nil {
+		t.Error("expected an error for attributes that aren't an object")
+	}
+}
+
+func Test_SnapshotChanges(t *testing.T) {
+	changes, err := SnapshotChanges(
+		json.RawMessage(`{"name":"Camera","purchase_price":100,"location_id":null,"attributes":{"serial":"A1"}}`),
+		json.RawMessage(`{"name":"Camera","purchase_price":80.5,"location_id":"garage","attributes":{"serial":"A2"}}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"attributes.serial", "location_id", "purchase_price"}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %v, got %+v", want, changes)
+	}
+	for i, c := range changes {
+		if c.Field != want[i] {
+			t.Errorf("change %d: expected %s, got %+v", i, want[i], c)
+		}
+	}
+	if string(changes[2].Old) != "100" || string(changes[2].New) != "80.5" {
+		t.Errorf("unexpected price change %+v", changes[2])
+	}
+
+	created, err := SnapshotChanges(nil, json.RawMessage(`{"name":"Camera","notes":null,"attributes":{}}`))
+	if err != nil || len(created) != 1 || created[0].Field != "name" || created[0].Old != nil {
+		t.Errorf("expected the set fields of a new asset, got %+v, %v", created, err)
+	}
+}
+
+func Test_AssetSnapshot_Apply(t *testing.T) {
+	date := "2024-03-01"
+	location := uuid.New()
+	snap := &AssetSnapshot{
+		CategoryID: uuid.New(),
+		LocationID: &location,
+		Name:       "Camera",
+		Quantity:   1,
+		Attributes: json.RawMessage(`{"serial":"A1"}`),
+		PurchaseAt: &date,
+	}
+	asset := &Asset{Name: "Old camera", Quantity: 3, Attributes: json.RawMessage(`{"serial":"B2"}`)}
+
+	if err := snap.Apply(asset, false); err != nil {
+		t.Fatal(err)
+	}
+	if asset.Name != "Camera" || asset.Quantity != 1 || asset.LocationID != &location {
+		t.Errorf("expected fields to be restored, got %+v", asset)
+	}
+	if asset.PurchaseAt == nil || !asset.PurchaseAt.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected purchase date to be restored, got %v", asset.PurchaseAt)
+	}
+	if string(asset.Attributes) != `{"serial":"B2"}` {
+		t.Errorf("expected attributes to be kept, got %s", asset.Attributes)
+	}
+
+	if err := snap.Apply(asset, true); err != nil || string(asset.Attributes) != `{"serial":"A1"}` {
+		t.Errorf("expected attributes to be restored, got %s, %v", asset.Attributes, err)
+	}
+
+	bad := "March"
+	snap.PurchaseAt = &bad
+	if err := snap.Apply(asset, false); err == nil {
+		t.Error("expected an invalid date to fail")
+	}
+}
+
+func Test_AssetStatus(t *testing.T) {
+	for _, s := range AssetStatuses {
+		if !s.Valid() {
+			t.Errorf("expected %q to be valid", s)
+		}
+	}
+	if AssetStatus("in-repair").Valid() || AssetStatus("").Valid() {
+		t.Error("expected unknown statuses to be invalid")
+	}
+
+	held := map[AssetStatus]bool{AssetStatusOwned: true, AssetStatusLoaned: true, AssetStatusInRepair: true}
+	for _, s := range AssetStatuses {
+		if s.Held() != held[s] {
+			t.Errorf("%q: expected Held() = %v", s, held[s])
+		}
+	}
+}
+
+func Test_NormalizeShortID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"7KQ2XM", "7KQ2XM", true},
+		{" 7kq2xm\n", "7KQ2XM", true},
+		{"oil5xm", "0115XM", true},
+		{"7KQ2XM9P", "7KQ2XM9P", true},
+		{"7KQ2X", "", false},
+		{"7KQ2XM9PZ", "", false},
+		{"7KQ-XM", "", false},
+		{"7KQ2UM", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeShortID(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeShortID(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func Test_Loan_Overdue(t *testing.T) {
+	day := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	yesterday := day.AddDate(0, 0, -1)
+	returned := day
+
+	tests := []struct {
+		name string
+		loan Loan
+		want bool
+	}{
+		{"no due date", Loan{}, false},
+		{"due today", Loan{DueAt: &day}, false},
+		{"due yesterday", Loan{DueAt: &yesterday}, true},
+		{"returned late", Loan{DueAt: &yesterday, ReturnedAt: &returned}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.loan.Overdue(day); got != tt.want {
+				t.Errorf("Overdue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Reminder_Overdue(t *testing.T) {
+	day := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	done := day
+
+	tests := []struct {
+		name     string
+		reminder Reminder
+		want     bool
+	}{
+		{"due today", Reminder{DueAt: day}, false},
+		{"due yesterday", Reminder{DueAt: day.AddDate(0, 0, -1)}, true},
+		{"done late", Reminder{DueAt: day.AddDate(0, 0, -1), DoneAt: &done}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.reminder.Overdue(day); got != tt.want {
+				t.Errorf("Overdue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Depreciation_BookValue(t *testing.T) {
+	purchased := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := func(years float64) time.Time {
+		return purchased.Add(time.Duration(years * 365.25 * 24 * float64(time.Hour)))
+	}
+	straight := Depreciation{Method: DepreciationStraightLine, UsefulLifeMonths: 60, SalvageValue: 100}
+	declining := Depreciation{Method: DepreciationDecliningBalance, UsefulLifeMonths: 60, SalvageValue: 100}
+
+	tests := []struct {
+		name  string
+		rule  Depreciation
+		cost  float64
+		years float64
+		want  float64
+	}{
+		{"not purchased yet", straight, 1000, -1, 1000},
+		{"straight line halfway", straight, 1000, 2.5, 550},
+		{"straight line after its life", straight, 1000, 7, 100},
+		{"declining balance after a year", declining, 1000, 1, 600},
+		{"declining balance after two years", declining, 1000, 2, 360},
+		{"declining balance after its life", declining, 1000, 5, 100},
+		{"salvage above cost", straight, 50, 2, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.BookValue(tt.cost, purchased, after(tt.years)); got != tt.want {
+				t.Errorf("BookValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Purchase_Late(t *testing.T) {
+	day := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	yesterday := day.AddDate(0, 0, -1)
+
+	tests := []struct {
+		name     string
+		purchase Purchase
+		want     bool
+	}{
+		{"expected today", Purchase{Status: PurchaseOrdered, ExpectedAt: &day}, false},
+		{"expected yesterday", Purchase{Status: PurchaseOrdered, ExpectedAt: &yesterday}, true},
+		{"no expected date", Purchase{Status: PurchaseOrdered}, false},
+		{"received late", Purchase{Status: PurchaseReceived, ExpectedAt: &yesterday}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.purchase.Late(day); got != tt.want {
+				t.Errorf("Late() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Purchase_WarrantyEnd(t *testing.T) {
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	if end := (&Purchase{}).WarrantyEnd(start); end != nil {
+		t.Errorf("expected no warranty, got %v", end)
+	}
+	months := 25
+	end := (&Purchase{WarrantyMonths: &months}).WarrantyEnd(start)
+	if want := time.Date(2026, 2, 28, 0, 0, 0, 0, time.UTC); end == nil || !end.Equal(want) {
+		t.Errorf("WarrantyEnd() = %v, want %v", end, want)
+	}
+}
+
+func Test_AllocateBundle(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   float64
+		weights []float64
+		want    []float64
+	}{
+		{"proportional", 1000, []float64{900, 200, 100}, []float64{750, 166.67, 83.33}},
+		{"even thirds", 100, []float64{1, 1, 1}, []float64{33.34, 33.33, 33.33}},
+		{"no weights", 10, []float64{0, 0}, []float64{5, 5}},
+		{"free item", 50, []float64{60, 0}, []float64{50, 0}},
+		{"nothing to split", 50, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AllocateBundle(tt.total, tt.weights)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("AllocateBundle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"errors"
+)
 
 // ImportPlugin defines the interface for all import plugins
 type ImportPlugin interface {
 	// Metadata
-	ID() string          // Unique identifier, e.g., "google_books"
-	Name() string        // Display name, e.g., "Google Books"
-	Description() string // Brief description of the plugin
+	ID() string                       // Unique identifier, e.g., "google_books"
+	Name() string                     // Display name, e.g., "Google Books"
+	Description() string              // Brief description of the plugin
+	Version() string                  // Plugin version, e.g., "1.0.0"
+	Capabilities() []PluginCapability // Optional features the UI can adapt to
 
 	// Configuration status
 	Enabled() bool          // Returns true if the plugin is properly configured
@@ -26,6 +31,16 @@ type ImportPlugin interface {
 	Fetch(ctx context.Context, externalID string) (*ImportData, error)
 }
 
+// PluginCapability is an optional feature of an import plugin
+type PluginCapability string
+
+const (
+	CapabilityBarcode      PluginCapability = "barcode"      // A search field accepts scanned barcodes (ISBN, UPC/EAN)
+	CapabilityBatch        PluginCapability = "batch"        // Several items can be fetched in one request
+	CapabilityImages       PluginCapability = "images"       // Provides cover or product images
+	CapabilityLocalization PluginCapability = "localization" // Returns data in the user's language
+)
+
 // PluginAttribute defines an attribute managed by a plugin
 type PluginAttribute struct {
 	Key      string            `json:"key"`       // Namespaced key, e.g., "books.isbn"
@@ -36,8 +51,9 @@ type PluginAttribute struct {
 
 // SearchField defines a searchable field
 type SearchField struct {
-	Key   string `json:"key"`   // Field identifier, e.g., "title", "isbn"
-	Label string `json:"label"` // Display label, e.g., "Title", "ISBN"
+	Key     string `json:"key"`               // Field identifier, e.g., "title", "isbn"
+	Label   string `json:"label"`             // Display label, e.g., "Title", "ISBN"
+	Barcode bool   `json:"barcode,omitempty"` // Accepts scanned barcodes (with the barcode capability)
 }
 
 // SearchResult represents a search result from a plugin
@@ -59,15 +75,17 @@ type ImportData struct {
 
 // PluginInfo represents plugin metadata for API responses
 type PluginInfo struct {
-	ID                  string            `json:"id"`
-	Name                string            `json:"name"`
-	Description         string            `json:"description"`
-	Enabled             bool              `json:"enabled"`
-	DisabledReason      string            `json:"disabled_reason,omitempty"`
-	CategoryName        string            `json:"category_name"`
-	CategoryDescription string            `json:"category_description"`
-	SearchFields        []SearchField     `json:"search_fields"`
-	Attributes          []PluginAttribute `json:"attributes"`
+	ID                  string             `json:"id"`
+	Name                string             `json:"name"`
+	Description         string             `json:"description"`
+	Version             string             `json:"version"`
+	Capabilities        []PluginCapability `json:"capabilities"`
+	Enabled             bool               `json:"enabled"`
+	DisabledReason      string             `json:"disabled_reason,omitempty"`
+	CategoryName        string             `json:"category_name"`
+	CategoryDescription string             `json:"category_description"`
+	SearchFields        []SearchField      `json:"search_fields"`
+	Attributes          []PluginAttribute  `json:"attributes"`
 }
 
 // ToInfo converts an ImportPlugin to PluginInfo for API responses
@@ -76,6 +94,8 @@ func PluginToInfo(p ImportPlugin) PluginInfo {
 		ID:                  p.ID(),
 		Name:                p.Name(),
 		Description:         p.Description(),
+		Version:             p.Version(),
+		Capabilities:        p.Capabilities(),
 		Enabled:             p.Enabled(),
 		DisabledReason:      p.DisabledReason(),
 		CategoryName:        p.CategoryName(),
@@ -84,3 +104,60 @@ func PluginToInfo(p ImportPlugin) PluginInfo {
 		Attributes:          p.Attributes(),
 	}
 }
+
+// EnrichmentPlugin looks up product data by barcode or brand and model to
+// fill in the attributes of existing assets. Unlike import plugins it does not
+// own a category, so it works for any asset (electronics, appliances, ...).
+type EnrichmentPlugin interface {
+	ID() string          // Unique identifier, e.g., "upcitemdb"
+	Name() string        // Display name, e.g., "UPCitemdb"
+	Description() string // Brief description of the plugin
+
+	Enabled() bool          // Returns true if the plugin is properly configured
+	DisabledReason() string // Returns reason if disabled
+
+	// Lookup returns the best matching product, or ErrProductNotFound
+	Lookup(ctx context.Context, q ProductQuery) (*ProductData, error)
+}
+
+// ErrProductNotFound is returned by enrichment plugins without a match
+var ErrProductNotFound = errors.New("product not found")
+
+// ProductQuery identifies a product by barcode, or by brand and model
+type ProductQuery struct {
+	Brand string `json:"brand,omitempty"`
+	Model string `json:"model,omitempty"`
+	UPC   string `json:"upc,omitempty"` // UPC, EAN or GTIN
+}
+
+// ProductData is the product information found by an enrichment plugin
+type ProductData struct {
+	Title       string            `json:"title"`
+	Brand       string            `json:"brand,omitempty"`
+	Model       string            `json:"model,omitempty"`
+	UPC         string            `json:"upc,omitempty"`
+	Description *string           `json:"description,omitempty"`
+	ImageURL    *string           `json:"image_url,omitempty"`
+	Specs       map[string]string `json:"specs,omitempty"` // Specification name to value, e.g. "Weight": "4.2 kg"
+	SourceURL   string            `json:"source_url,omitempty"`
+}
+
+// EnrichmentPluginInfo represents enrichment plugin metadata for API responses
+type EnrichmentPluginInfo struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Enabled        bool   `json:"enabled"`
+	DisabledReason string `json:"disabled_reason,omitempty"`
+}
+
+// EnrichmentPluginToInfo converts an EnrichmentPlugin to EnrichmentPluginInfo
+func EnrichmentPluginToInfo(p EnrichmentPlugin) EnrichmentPluginInfo {
+	return EnrichmentPluginInfo{
+		ID:             p.ID(),
+		Name:           p.Name(),
+		Description:    p.Description(),
+		Enabled:        p.Enabled(),
+		DisabledReason: p.DisabledReason(),
+	}
+}
@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -73,11 +74,78 @@ type LocationRepository interface {
 // AssetFilter defines filters for asset queries
 type AssetFilter struct {
 	CategoryID  *uuid.UUID
+	CategoryIDs []uuid.UUID // Restrict results to these categories
 	LocationID  *uuid.UUID
 	ConditionID *uuid.UUID
+	OwnerID     *uuid.UUID
+	NoOwner     bool // Only assets without an owner
+	NoHighValue bool // Exclude high-value assets
+	Archived    ArchivedFilter
+	Statuses    []AssetStatus // Restrict results to these statuses
 	TagIDs      []uuid.UUID
 	Query       string // Full-text search query
 	Attributes  map[string]any
+	IDs         []uuid.UUID // Restrict results to these assets
+}
+
+// ArchivedFilter selects assets by whether they are archived
+type ArchivedFilter string
+
+const (
+	ArchivedExclude ArchivedFilter = ""     // Only active assets, the default
+	ArchivedOnly    ArchivedFilter = "true" // Only archived assets
+	ArchivedAll     ArchivedFilter = "all"  // Active and archived assets
+)
+
+// FacetCount is the number of assets sharing a facet value (e.g. a category)
+type FacetCount struct {
+	ID    uuid.UUID `json:"id"`
+	Name  string    `json:"name"`
+	Count int       `json:"count"`
+}
+
+// OwnerValue summarizes the assets belonging to one owner (OwnerID nil = no owner)
+type OwnerValue struct {
+	OwnerID    *uuid.UUID `json:"owner_id"`
+	Name       string     `json:"name"`
+	Count      int        `json:"count"`
+	TotalValue float64    `json:"total_value"`
+}
+
+// StatusValue summarizes the assets of one lifecycle status
+type StatusValue struct {
+	Status     AssetStatus `json:"status"`
+	Count      int         `json:"count"`
+	TotalValue float64     `json:"total_value"`
+}
+
+// AssetHistoryPoint is the number and total value of the assets that existed at a point in time
+type AssetHistoryPoint struct {
+	At           time.Time `json:"at"`
+	Count        int       `json:"count"`
+	Value        float64   `json:"value"`         // Purchase value
+	CurrentValue float64   `json:"current_value"` // Latest valuation at the time, else purchase value
+}
+
+// BulkAssetAction is an operation applied to many assets at once
+type BulkAssetAction string
+
+const (
+	BulkAssetDelete       BulkAssetAction = "delete"
+	BulkAssetSetLocation  BulkAssetAction = "set_location"
+	BulkAssetSetCategory  BulkAssetAction = "set_category"
+	BulkAssetSetCondition BulkAssetAction = "set_condition"
+	BulkAssetAddTags      BulkAssetAction = "add_tags"
+	BulkAssetRemoveTags   BulkAssetAction = "remove_tags"
+)
+
+// BulkAssetOperation applies an action to assets of an organization
+type BulkAssetOperation struct {
+	Action      BulkAssetAction
+	AssetIDs    []uuid.UUID
+	TargetID    *uuid.UUID  // Location, category or condition to set; nil clears a location or condition
+	TagIDs      []uuid.UUID // Tags to add or remove
+	NoHighValue bool        // High-value assets count as missing
 }
 
 // Pagination defines pagination parameters
@@ -92,11 +160,18 @@ type AssetRepository interface {
 	GetByIDFull(ctx context.Context, id uuid.UUID) (*Asset, error) // With relations
 	List(ctx context.Context, orgID uuid.UUID, filter AssetFilter, page Pagination) ([]Asset, int, error)
 	Search(ctx context.Context, orgID uuid.UUID, query string, page Pagination) ([]Asset, int, error)
+	Facets(ctx context.Context, orgID uuid.UUID, filter AssetFilter, facets []string) (map[string][]FacetCount, error)
 	Create(ctx context.Context, asset *Asset) error
 	Update(ctx context.Context, asset *Asset) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	SetTags(ctx context.Context, assetID uuid.UUID, tagIDs []uuid.UUID) error
 	GetTotalValue(ctx context.Context, orgID uuid.UUID) (float64, error)
+	ValueByOwner(ctx context.Context, orgID uuid.UUID) ([]OwnerValue, error)
+	ValueByStatus(ctx context.Context, orgID uuid.UUID) ([]StatusValue, error)
+	History(ctx context.Context, orgID uuid.UUID, from, to time.Time, step time.Duration) ([]AssetHistoryPoint, error)
+	FlagHighValue(ctx context.Context, orgID uuid.UUID, threshold float64) (int64, error)
+	Bulk(ctx context.Context, orgID uuid.UUID, op BulkAssetOperation) ([]uuid.UUID, error)
+	Restore(ctx context.Context, asset *Asset, tagIDs []uuid.UUID) error // nil tagIDs keeps the tags
 }
 
 // TagRepository handles tag persistence
@@ -121,6 +196,7 @@ type WarrantyRepository interface {
 	GetByAssetID(ctx context.Context, assetID uuid.UUID) (*Warranty, error)
 	List(ctx context.Context, orgID uuid.UUID) ([]WarrantyWithAsset, error)
 	ListExpiring(ctx context.Context, orgID uuid.UUID, days int) ([]Warranty, error)
+	ListExpiringBefore(ctx context.Context, orgID uuid.UUID, until time.Time) ([]Warranty, error)
 	Create(ctx context.Context, warranty *Warranty) error
 	Update(ctx context.Context, warranty *Warranty) error
 	Delete(ctx context.Context, assetID uuid.UUID) error
@@ -132,4 +208,84 @@ type AttachmentRepository interface {
 	ListByAsset(ctx context.Context, assetID uuid.UUID) ([]Attachment, error)
 	Create(ctx context.Context, attachment *Attachment) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	TotalSize(ctx context.Context, orgID uuid.UUID) (int64, error)
+	ListTextPending(ctx context.Context, orgID uuid.UUID, limit int) ([]Attachment, error)
+	SetText(ctx context.Context, id uuid.UUID, text *string) error
+}
+
+// AssetListRepository handles asset list persistence
+type AssetListRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*AssetList, error)
+	GetByShareToken(ctx context.Context, token string) (*AssetList, error)
+	List(ctx context.Context, orgID uuid.UUID) ([]AssetList, error)
+	Create(ctx context.Context, list *AssetList) error
+	Update(ctx context.Context, list *AssetList) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListAssets(ctx context.Context, listID uuid.UUID) ([]Asset, error)
+	AddAssets(ctx context.Context, listID uuid.UUID, assetIDs []uuid.UUID) error
+	RemoveAsset(ctx context.Context, listID, assetID uuid.UUID) error
+	SetShare(ctx context.Context, id uuid.UUID, token *string, showValues bool) error
+}
+
+// ReservationRepository handles asset reservation persistence
+type ReservationRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*AssetReservation, error)
+	List(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]AssetReservation, error)
+	ListByAsset(ctx context.Context, assetID uuid.UUID, from, to time.Time) ([]AssetReservation, error)
+	Create(ctx context.Context, reservation *AssetReservation) error
+	Update(ctx context.Context, reservation *AssetReservation) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// RecurringCostRepository handles recurring cost persistence
+type RecurringCostRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*RecurringCost, error)
+	List(ctx context.Context, orgID uuid.UUID) ([]RecurringCost, error)
+	ListByAsset(ctx context.Context, assetID uuid.UUID) ([]RecurringCost, error)
+	ListRenewingBefore(ctx context.Context, orgID uuid.UUID, until time.Time) ([]RecurringCost, error)
+	Create(ctx context.Context, cost *RecurringCost) error
+	Update(ctx context.Context, cost *RecurringCost) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	Totals(ctx context.Context, orgID uuid.UUID) (*RecurringCostTotals, error)
+	SetRenewal(ctx context.Context, id uuid.UUID, nextRenewalAt time.Time) error
+	MarkReminded(ctx context.Context, id uuid.UUID, renewal time.Time) error
+}
+
+// PowerUsageRepository handles asset power consumption persistence
+type PowerUsageRepository interface {
+	GetByAssetID(ctx context.Context, assetID uuid.UUID) (*PowerUsage, error)
+	List(ctx context.Context, orgID uuid.UUID) ([]PowerUsageWithAsset, error)
+	Upsert(ctx context.Context, usage *PowerUsage) error
+	Delete(ctx context.Context, assetID uuid.UUID) error
+}
+
+// ReportScheduleRepository handles report schedule and run history persistence
+type ReportScheduleRepository interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*ReportSchedule, error)
+	List(ctx context.Context, orgID uuid.UUID) ([]ReportSchedule, error)
+	ListDue(ctx context.Context, orgID uuid.UUID, now time.Time) ([]ReportSchedule, error)
+	Create(ctx context.Context, schedule *ReportSchedule) error
+	Update(ctx context.Context, schedule *ReportSchedule) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	MarkRun(ctx context.Context, id uuid.UUID, ranAt time.Time, nextRunAt *time.Time) error
+	CreateRun(ctx context.Context, run *ReportRun) error
+	ListRuns(ctx context.Context, scheduleID uuid.UUID, limit int) ([]ReportRun, error)
+}
+
+// SyncRepository computes change sets for delta sync
+type SyncRepository interface {
+	Changes(ctx context.Context, orgID uuid.UUID, since time.Time, noHighValue bool) (*SyncChanges, error)
+}
+
+// SettingsRepository handles per-organization settings stored as JSON by key
+type SettingsRepository interface {
+	Get(ctx context.Context, orgID uuid.UUID, key string, dest any) (bool, error)
+	Set(ctx context.Context, orgID uuid.UUID, key string, value any) error
+	Delete(ctx context.Context, orgID uuid.UUID, key string) error
+}
+
+// LoginEventRepository handles the login audit log
+type LoginEventRepository interface {
+	Create(ctx context.Context, event *LoginEvent) error
+	List(ctx context.Context, orgID uuid.UUID, filter LoginEventFilter) ([]LoginEvent, error)
 }
@@ -0,0 +1,83 @@
+// Package events provides an in-process publish/subscribe bus used to
+// notify integrations (search indexing, webhooks, ...) of domain changes.
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// Type identifies the kind of event, in "<entity>.<action>" form
+type Type string
+
+const (
+	AssetCreated Type = "asset.created"
+	AssetUpdated Type = "asset.updated"
+	AssetDeleted Type = "asset.deleted"
+)
+
+// Event describes a change to a domain entity
+type Event struct {
+	Type           Type                 `json:"type"`
+	OrganizationID uuid.UUID            `json:"organization_id"`
+	SubjectID      uuid.UUID            `json:"subject_id"`         // ID of the changed entity
+	ActorID        *uuid.UUID           `json:"actor_id,omitempty"` // User who caused the change, if known
+	Changes        []domain.AssetChange `json:"changes,omitempty"`  // Field-level diff, recorded in the asset's history
+	At             time.Time            `json:"at"`
+}
+
+// Handler receives published events. Handlers run synchronously on the
+// publisher's goroutine and must hand off slow work themselves.
+type Handler func(ctx context.Context, e Event)
+
+// Bus dispatches events to subscribed handlers
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for all events
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish delivers an event to every handler. A nil bus is a no-op so
+// callers don't need to check whether eventing is configured.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	if e.At.IsZero() {
+		e.At = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		dispatch(ctx, h, e)
+	}
+}
+
+// dispatch isolates handler panics so one faulty subscriber can't break a request
+func dispatch(ctx context.Context, h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("event handler panicked", "type", e.Type, "panic", r)
+		}
+	}()
+	h(ctx, e)
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func Test_Bus_Publish_DeliversToAllHandlers(t *testing.T) {
+	bus := NewBus()
+	var got []Type
+	bus.Subscribe(func(ctx context.Context, e Event) { got = append(got, e.Type) })
+	bus.Subscribe(func(ctx context.Context, e Event) { got = append(got, e.Type) })
+
+	bus.Publish(context.Background(), Event{Type: AssetCreated, SubjectID: uuid.New()})
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", len(got))
+	}
+}
+
+func Test_Bus_Publish_SetsTimestamp(t *testing.T) {
+	bus := NewBus()
+	var got Event
+	bus.Subscribe(func(ctx context.Context, e Event) { got = e })
+
+	bus.Publish(context.Background(), Event{Type: AssetUpdated})
+
+	if got.At.IsZero() {
+		t.Error("expected At to be set")
+	}
+}
+
+func Test_Bus_Publish_NilBusIsNoop(t *testing.T) {
+	var bus *Bus
+	bus.Publish(context.Background(), Event{Type: AssetDeleted})
+}
+
+func Test_Bus_Publish_RecoversHandlerPanic(t *testing.T) {
+	bus := NewBus()
+	delivered := false
+	bus.Subscribe(func(ctx context.Context, e Event) { panic("boom") })
+	bus.Subscribe(func(ctx context.Context, e Event) { delivered = true })
+
+	bus.Publish(context.Background(), Event{Type: AssetCreated})
+
+	if !delivered {
+		t.Error("expected later handlers to run after a panic")
+	}
+}
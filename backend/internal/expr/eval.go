@@ -0,0 +1,316 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type node interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type literal struct {
+	value any
+}
+
+func (n *literal) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+type variable struct {
+	name string
+}
+
+func (n *variable) eval(vars map[string]any) (any, error) {
+	return normalize(vars[n.name]), nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(vars map[string]any) (any, error) {
+	list := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+type conditional struct {
+	cond, then, otherwise node
+}
+
+func (n *conditional) eval(vars map[string]any) (any, error) {
+	cond, err := evalBool(n.cond, vars, "?:")
+	if err != nil {
+		return nil, err
+	}
+	if cond {
+		return n.then.eval(vars)
+	}
+	return n.otherwise.eval(vars)
+}
+
+type unary struct {
+	op      string
+	operand node
+}
+
+func (n *unary) eval(vars map[string]any) (any, error) {
+	if n.op == "!" {
+		b, err := evalBool(n.operand, vars, "!")
+		return !b, err
+	}
+	v, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("operator - requires a number, got %s", typeName(v))
+	}
+	return -f, nil
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (n *binary) eval(vars map[string]any) (any, error) {
+	// Logical operators short-circuit
+	switch n.op {
+	case "&&", "||":
+		left, err := evalBool(n.left, vars, n.op)
+		if err != nil || left == (n.op == "||") {
+			return left, err
+		}
+		return evalBool(n.right, vars, n.op)
+	}
+
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(left, right), nil
+	case "!=":
+		return !reflect.DeepEqual(left, right), nil
+	case "+":
+		ls, lok := left.(string)
+		rs, rok := right.(string)
+		if lok || rok {
+			if !lok {
+				ls = Text(left)
+			}
+			if !rok {
+				rs = Text(right)
+			}
+			return ls + rs, nil
+		}
+	case "<", "<=", ">", ">=":
+		if ls, ok := left.(string); ok {
+			if rs, ok := right.(string); ok {
+				return compare(n.op, strings.Compare(ls, rs)), nil
+			}
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s is not defined for %s and %s", n.op, typeName(left), typeName(right))
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/", "%":
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if n.op == "%" {
+			return math.Mod(l, r), nil
+		}
+		return l / r, nil
+	default:
+		c := 0
+		if l < r {
+			c = -1
+		} else if l > r {
+			c = 1
+		}
+		return compare(n.op, c), nil
+	}
+}
+
+func compare(op string, c int) bool {
+	switch op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// indexNode reads a list element or map value; missing entries and indexing
+// null yield null, so optional attributes need no guards
+type indexNode struct {
+	target, index node
+}
+
+func (n *indexNode) eval(vars map[string]any) (any, error) {
+	target, err := n.target.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := target.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key must be a string, got %s", typeName(index))
+		}
+		return normalize(t[key]), nil
+	case []any:
+		f, ok := index.(float64)
+		if !ok || f != math.Trunc(f) {
+			return nil, fmt.Errorf("list index must be an integer, got %s", typeName(index))
+		}
+		i := int(f)
+		if i < 0 {
+			i += len(t)
+		}
+		if i < 0 || i >= len(t) {
+			return nil, nil
+		}
+		return normalize(t[i]), nil
+	}
+	return nil, fmt.Errorf("cannot index %s", typeName(target))
+}
+
+type callNode struct {
+	name string
+	fn   function
+	args []node
+}
+
+func (n *callNode) eval(vars map[string]any) (any, error) {
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := n.fn.call(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+func evalBool(n node, vars map[string]any, op string) (bool, error) {
+	v, err := n.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("operator %s requires a bool, got %s", op, typeName(v))
+	}
+	return b, nil
+}
+
+// normalize converts Go values from variables to the JSON value types
+func normalize(v any) any {
+	switch t := v.(type) {
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case float32:
+		return float64(t)
+	case *string:
+		if t == nil {
+			return nil
+		}
+		return *t
+	case []string:
+		list := make([]any, len(t))
+		for i, s := range t {
+			list[i] = s
+		}
+		return list
+	}
+	return v
+}
+
+// Text formats a value as a string: numbers without exponent, lists joined
+// with ", " and null as ""
+func Text(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case []any:
+		parts := make([]string, 0, len(t))
+		for _, e := range t {
+			parts = append(parts, Text(e))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(normalize(t))
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,98 @@
+package expr
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	vars := map[string]any{
+		"value": "Dune (Special Edition)",
+		"attributes": map[string]any{
+			"runtime": 155.0,
+			"genres":  []any{"Sci-Fi", "Drama"},
+			"year":    "2021",
+		},
+		"minutes": 95,
+	}
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{`regex_replace(value, "\\s*\\(.*Edition\\)$", "")`, "Dune"},
+		{`round(attributes.runtime / 60, 1)`, 2.6},
+		{`round(minutes / 60, 2)`, 1.58},
+		{`attributes["genres"][0] + " / " + attributes.genres[-1]`, "Sci-Fi / Drama"},
+		{`join(attributes.genres, ", ")`, "Sci-Fi, Drama"},
+		{`number(attributes.year) + 1`, 2022.0},
+		{`attributes.missing == null ? "n/a" : attributes.missing`, "n/a"},
+		{`upper(attributes.missing)`, nil},
+		{`default(attributes.missing, 0)`, 0.0},
+		{`contains(attributes.genres, "Drama") && !starts_with(value, "X")`, true},
+		{`len(value) > 10 || value.foo == 1`, true},
+		{`"Year " + 2021`, "Year 2021"},
+		{`-attributes.runtime % 100`, -55.0},
+		{`1 + 2 * 3 - 4 / 2`, 5.0},
+		{`(1 + 2) * 3`, 9.0},
+		{`split("a, b ,c", ",")`, []any{"a", "b", "c"}},
+		{`lower('It\'s')`, "it's"},
+		{`"b" > "a"`, true},
+		{`[1, 2] == [1, 2]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := Compile(tt.expr, "value", "attributes", "minutes")
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			got, err := p.Eval(vars)
+			if err != nil {
+				t.Fatalf("Eval: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`value +`,
+		`unknown`,
+		`nope(value)`,
+		`trim(value, 1)`,
+		`"unterminated`,
+		`(value`,
+		`value ? 1`,
+		`value # 1`,
+		`value value`,
+	} {
+		if _, err := Compile(src, "value"); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Compile(%q): expected syntax error, got %v", src, err)
+		}
+	}
+}
+
+func TestEval_Errors(t *testing.T) {
+	vars := map[string]any{"value": "text"}
+	for _, src := range []string{
+		`value - 1`,
+		`value ? 1 : 2`,
+		`1 / 0`,
+		`round(value)`,
+		`number(value)`,
+		`regex_replace(value, "(", "")`,
+		`value[0]`,
+	} {
+		p, err := Compile(src, "value")
+		if err != nil {
+			t.Fatalf("Compile(%q): %v", src, err)
+		}
+		if _, err := p.Eval(vars); err == nil {
+			t.Errorf("Eval(%q): expected error", src)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type function struct {
+	minArgs, maxArgs int
+	call             func(args []any) (any, error)
+}
+
+func (f function) arity() string {
+	switch {
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+// functions available to expressions. String functions return null for a
+// null first argument.
+var functions = map[string]function{
+	"lower": stringFunc(1, func(s string, _ []any) (any, error) { return strings.ToLower(s), nil }),
+	"upper": stringFunc(1, func(s string, _ []any) (any, error) { return strings.ToUpper(s), nil }),
+	"trim":  stringFunc(1, func(s string, _ []any) (any, error) { return strings.TrimSpace(s), nil }),
+	"replace": stringFunc(3, func(s string, args []any) (any, error) {
+		return strings.ReplaceAll(s, Text(args[1]), Text(args[2])), nil
+	}),
+	"regex_replace": stringFunc(3, func(s string, args []any) (any, error) {
+		re, err := regexp.Compile(Text(args[1]))
+		if err != nil {
+			return nil, err
+		}
+		return re.ReplaceAllString(s, Text(args[2])), nil
+	}),
+	"matches": stringFunc(2, func(s string, args []any) (any, error) {
+		return regexp.MatchString(Text(args[1]), s)
+	}),
+	"starts_with": stringFunc(2, func(s string, args []any) (any, error) {
+		return strings.HasPrefix(s, Text(args[1])), nil
+	}),
+	"ends_with": stringFunc(2, func(s string, args []any) (any, error) {
+		return strings.HasSuffix(s, Text(args[1])), nil
+	}),
+	"split": stringFunc(2, func(s string, args []any) (any, error) {
+		parts := strings.Split(s, Text(args[1]))
+		list := make([]any, len(parts))
+		for i, p := range parts {
+			list[i] = strings.TrimSpace(p)
+		}
+		return list, nil
+	}),
+	"contains": {2, 2, func(args []any) (any, error) {
+		switch t := args[0].(type) {
+		case nil:
+			return false, nil
+		case string:
+			return strings.Contains(t, Text(args[1])), nil
+		case []any:
+			return slices.ContainsFunc(t, func(e any) bool { return Text(e) == Text(args[1]) }), nil
+		}
+		return nil, fmt.Errorf("expects a string or list, got %s", typeName(args[0]))
+	}},
+	"join": {2, 2, func(args []any) (any, error) {
+		list, ok := args[0].([]any)
+		if !ok {
+			return Text(args[0]), nil
+		}
+		parts := make([]string, len(list))
+		for i, e := range list {
+			parts[i] = Text(e)
+		}
+		return strings.Join(parts, Text(args[1])), nil
+	}},
+	"len": {1, 1, func(args []any) (any, error) {
+		switch t := args[0].(type) {
+		case nil:
+			return 0.0, nil
+		case string:
+			return float64(len([]rune(t))), nil
+		case []any:
+			return float64(len(t)), nil
+		case map[string]any:
+			return float64(len(t)), nil
+		}
+		return nil, fmt.Errorf("expects a string, list or map, got %s", typeName(args[0]))
+	}},
+	"string": {1, 1, func(args []any) (any, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return Text(args[0]), nil
+	}},
+	"number": {1, 1, func(args []any) (any, error) {
+		switch t := args[0].(type) {
+		case nil, float64:
+			return t, nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", t)
+			}
+			return f, nil
+		}
+		return nil, fmt.Errorf("expects a string or number, got %s", typeName(args[0]))
+	}},
+	"round": numberFunc(1, 2, func(f float64, args []any) (any, error) {
+		places := 0.0
+		if len(args) == 2 {
+			p, ok := args[1].(float64)
+			if !ok || p < 0 || p > 10 {
+				return nil, fmt.Errorf("decimal places must be a number from 0 to 10")
+			}
+			places = math.Trunc(p)
+		}
+		scale := math.Pow(10, places)
+		return math.Round(f*scale) / scale, nil
+	}),
+	"floor": numberFunc(1, 1, func(f float64, _ []any) (any, error) { return math.Floor(f), nil }),
+	"ceil":  numberFunc(1, 1, func(f float64, _ []any) (any, error) { return math.Ceil(f), nil }),
+	"default": {2, 2, func(args []any) (any, error) {
+		if args[0] == nil || args[0] == "" {
+			return args[1], nil
+		}
+		return args[0], nil
+	}},
+}
+
+// stringFunc defines a function taking a string (or null) and args-1 more arguments
+func stringFunc(args int, fn func(s string, args []any) (any, error)) function {
+	return function{args, args, func(a []any) (any, error) {
+		switch t := a[0].(type) {
+		case nil:
+			return nil, nil
+		case string:
+			return fn(t, a)
+		case float64, bool:
+			return fn(Text(t), a)
+		}
+		return nil, fmt.Errorf("expects a string, got %s", typeName(a[0]))
+	}}
+}
+
+// numberFunc defines a function taking a number (or null) and optional arguments
+func numberFunc(minArgs, maxArgs int, fn func(f float64, args []any) (any, error)) function {
+	return function{minArgs, maxArgs, func(a []any) (any, error) {
+		switch t := a[0].(type) {
+		case nil:
+			return nil, nil
+		case float64:
+			return fn(t, a)
+		}
+		return nil, fmt.Errorf("expects a number, got %s", typeName(a[0]))
+	}}
+}
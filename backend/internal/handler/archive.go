@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+const (
+	// archiveBatchSize is the number of attachments loaded at a time by ArchiveAttachments
+	archiveBatchSize = 100
+
+	// archiveRetryAfter is the Retry-After of an archived attachment being restored
+	archiveRetryAfter = time.Hour
+)
+
+// ArchiveAttachments moves the files of attachments of assets deleted longer
+// than the archive period ago to the archive tier of the storage, e.g. a
+// Glacier storage class on S3, which is cheaper for files rarely read again.
+// Like RelayoutStorage, the archived copy is written before the attachment is
+// updated and the original deleted.
+func (h *Handler) ArchiveAttachments(ctx context.Context) error {
+	archiver, ok := h.storage.(storage.Archiver)
+	if !ok || h.archiveAfter <= 0 {
+		return nil
+	}
+
+	before := time.Now().Add(-h.archiveAfter)
+	return h.forEachOrganization(ctx, func(orgID uuid.UUID) error {
+		return h.archiveOrganization(ctx, archiver, orgID, before)
+	})
+}
+
+// archiveOrganization archives the attachments of an organization's assets
+// deleted before a time
+func (h *Handler) archiveOrganization(ctx context.Context, archiver storage.Archiver, orgID uuid.UUID, before time.Time) error {
+	after := uuid.Nil
+	for {
+		batch, err := h.repos.Attachments.ListArchivable(ctx, orgID, before, after, archiveBatchSize)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := h.archiveAttachmentFile(ctx, archiver, &batch[i]); err != nil {
+				// Skipped until the next run
+				slog.Error("failed to archive attachment file", "attachment_id", batch[i].ID, "key", batch[i].FileKey, "error", err)
+			}
+		}
+		if len(batch) < archiveBatchSize {
+			return nil
+		}
+		after = batch[len(batch)-1].ID
+	}
+}
+
+func (h *Handler) archiveAttachmentFile(ctx context.Context, archiver storage.Archiver, att *domain.Attachment) error {
+	key, err := archiver.Archive(ctx, att.FileKey)
+	if err != nil {
+		return err
+	}
+
+	ok, err := h.repos.Attachments.MoveFile(ctx, att.ID, att.FileKey, key)
+	if err != nil || !ok {
+		h.storage.Delete(ctx, key)
+		return err
+	}
+	if err := h.storage.Delete(ctx, att.FileKey); err != nil {
+		slog.Warn("failed to delete archived attachment file", "key", att.FileKey, "error", err)
+	}
+	slog.Info("archived attachment file", "attachment_id", att.ID, "key", key)
+	return nil
+}
+
+// retrieveFile reports whether the file at key can be downloaded now; an
+// archived file may first need to be restored, which retrieveFile starts
+func retrieveFile(ctx context.Context, fs FileStorage, key string) (bool, error) {
+	archiver, ok := fs.(storage.Archiver)
+	if !ok || !storage.IsArchived(key) {
+		return true, nil
+	}
+	return archiver.Retrieve(ctx, key)
+}
@@ -2,12 +2,18 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/money"
 )
 
 const maxAssetQuantity = 1000000
@@ -17,14 +23,20 @@ type CreateAssetRequest struct {
 	LocationID    *string         `json:"location_id,omitempty"`
 	ConditionID   *string         `json:"condition_id,omitempty"`
 	CollectionID  *string         `json:"collection_id,omitempty"`
+	OwnerID       *string         `json:"owner_id,omitempty"`
 	Name          string          `json:"name"`
 	Description   *string         `json:"description,omitempty"`
 	Quantity      int             `json:"quantity"`
+	MinQuantity   *int            `json:"min_quantity,omitempty"` // Low-stock threshold, nil = none
 	Attributes    json.RawMessage `json:"attributes,omitempty"`
 	PurchaseAt    *string         `json:"purchase_at,omitempty"`
-	PurchasePrice *float64        `json:"purchase_price,omitempty"`
+	PurchasePrice *PriceInput     `json:"purchase_price,omitempty"`
+	Currency      *string         `json:"currency,omitempty"`
 	PurchaseNote  *string         `json:"purchase_note,omitempty"`
 	Notes         *string         `json:"notes,omitempty"`
+	HighValue     *bool           `json:"high_value,omitempty"` // nil = keep (update) or not set (create)
+	Archived      *bool           `json:"archived,omitempty"`   // nil = keep (update) or active (create)
+	Status        *string         `json:"status,omitempty"`     // nil = keep (update) or owned (create)
 }
 
 type UpdateAssetRequest struct {
@@ -32,31 +44,40 @@ type UpdateAssetRequest struct {
 	LocationID    *string         `json:"location_id,omitempty"`
 	ConditionID   *string         `json:"condition_id,omitempty"`
 	CollectionID  *string         `json:"collection_id,omitempty"`
+	OwnerID       *string         `json:"owner_id,omitempty"`
 	Name          string          `json:"name"`
 	Description   *string         `json:"description,omitempty"`
 	Quantity      int             `json:"quantity"`
+	MinQuantity   *int            `json:"min_quantity,omitempty"` // Low-stock threshold, nil = none
 	Attributes    json.RawMessage `json:"attributes,omitempty"`
 	PurchaseAt    *string         `json:"purchase_at,omitempty"`
-	PurchasePrice *float64        `json:"purchase_price,omitempty"`
+	PurchasePrice *PriceInput     `json:"purchase_price,omitempty"`
+	Currency      *string         `json:"currency,omitempty"`
 	PurchaseNote  *string         `json:"purchase_note,omitempty"`
 	Notes         *string         `json:"notes,omitempty"`
+	HighValue     *bool           `json:"high_value,omitempty"` // nil = keep (update) or not set (create)
+	Archived      *bool           `json:"archived,omitempty"`   // nil = keep (update) or active (create)
+	Status        *string         `json:"status,omitempty"`     // nil = keep (update) or owned (create)
 }
 
 type AssetListResponse struct {
-	Assets []AssetWithImageURL `json:"assets"`
-	Total  int                 `json:"total"`
-	Limit  int                 `json:"limit"`
-	Offset int                 `json:"offset"`
+	Assets []AssetWithImageURL            `json:"assets"`
+	Total  int                            `json:"total"`
+	Limit  int                            `json:"limit"`
+	Offset int                            `json:"offset"`
+	Facets map[string][]domain.FacetCount `json:"facets,omitempty"` // Only when ?facets= is given
 }
 
 type AssetWithImageURL struct {
 	domain.Asset
-	MainAttachmentURL string `json:"main_attachment_url,omitempty"`
+	MainAttachmentURL string   `json:"main_attachment_url,omitempty"`
+	BookValue         *float64 `json:"book_value,omitempty"` // Per unit, with a depreciation rule only
 }
 
 type AssetDetailResponse struct {
 	domain.Asset
-	MainAttachmentURL string `json:"main_attachment_url,omitempty"`
+	MainAttachmentURL string   `json:"main_attachment_url,omitempty"`
+	BookValue         *float64 `json:"book_value,omitempty"` // Per unit, with a depreciation rule only
 }
 
 func (h *Handler) ListAssets(w http.ResponseWriter, r *http.Request) {
@@ -90,22 +111,67 @@ func (h *Handler) ListAssets(w http.ResponseWriter, r *http.Request) {
 			filter.ConditionID = &id
 		}
 	}
+	if ownerID := q.Get("owner_id"); ownerID == "none" {
+		filter.NoOwner = true
+	} else if ownerID == "me" {
+		if id := currentUserID(r); id != nil {
+			filter.OwnerID = id
+		}
+	} else if id, err := uuid.Parse(ownerID); err == nil {
+		filter.OwnerID = &id
+	}
+	for _, tagID := range q["tag_id"] {
+		if id, err := uuid.Parse(tagID); err == nil {
+			filter.TagIDs = append(filter.TagIDs, id)
+		}
+	}
+
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	filter.NoHighValue = hide
+	if filter.Archived, err = parseArchivedFilter(q.Get("archived")); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Statuses, err = parseStatusFilter(q["status"]); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	page := domain.Pagination{Limit: limit, Offset: offset}
-	assets, total, err := h.repos.Assets.List(r.Context(), h.orgID, filter, page)
+	assets, total, err := h.repos.Assets.List(r.Context(), h.org(r), filter, page)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list assets")
 		return
 	}
 
+	var facets map[string][]domain.FacetCount
+	if requested := parseFacets(q.Get("facets")); len(requested) > 0 {
+		facets, err = h.repos.Assets.Facets(r.Context(), h.org(r), filter, requested)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to count facets")
+			return
+		}
+	}
+
 	if assets == nil {
 		assets = []domain.Asset{}
 	}
 
+	rules, err := h.depreciationRules(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get depreciation")
+		return
+	}
+
 	// Generate presigned URLs for main attachments
+	now := time.Now()
 	assetsWithURLs := make([]AssetWithImageURL, len(assets))
 	for i, asset := range assets {
-		assetsWithURLs[i] = AssetWithImageURL{Asset: asset}
+		assetsWithURLs[i] = AssetWithImageURL{Asset: asset, BookValue: rules.bookValue(&asset, now)}
 		if asset.MainAttachment != nil && h.storage != nil {
 			url, err := h.storage.GetPresignedURL(r.Context(), asset.MainAttachment.FileKey, 15*time.Minute)
 			if err == nil {
@@ -119,9 +185,25 @@ func (h *Handler) ListAssets(w http.ResponseWriter, r *http.Request) {
 		Total:  total,
 		Limit:  limit,
 		Offset: offset,
+		Facets: facets,
 	})
 }
 
+// assetFacets are the facets supported by ListAssets
+var assetFacets = []string{"category", "location", "condition", "owner", "tags"}
+
+// parseFacets parses a comma-separated facet list, ignoring unknown and duplicate names
+func parseFacets(s string) []string {
+	var facets []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if slices.Contains(assetFacets, name) && !slices.Contains(facets, name) {
+			facets = append(facets, name)
+		}
+	}
+	return facets
+}
+
 func (h *Handler) GetAsset(w http.ResponseWriter, r *http.Request) {
 	id, err := parseUUID(r, "id")
 	if err != nil {
@@ -134,13 +216,24 @@ func (h *Handler) GetAsset(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "failed to get asset")
 		return
 	}
-	if asset == nil {
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || hidden {
 		writeError(w, http.StatusNotFound, "asset not found")
 		return
 	}
 
+	rules, err := h.depreciationRules(r.Context(), asset.OrganizationID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get depreciation")
+		return
+	}
+
 	// Generate presigned URL for main attachment
-	response := AssetDetailResponse{Asset: *asset}
+	response := AssetDetailResponse{Asset: *asset, BookValue: rules.bookValue(asset, time.Now())}
 	if asset.MainAttachment != nil && h.storage != nil {
 		url, err := h.storage.GetPresignedURL(r.Context(), asset.MainAttachment.FileKey, 15*time.Minute)
 		if err == nil {
@@ -170,7 +263,7 @@ func (h *Handler) CreateAsset(w http.ResponseWriter, r *http.Request) {
 	}
 
 	asset := &domain.Asset{
-		OrganizationID: h.orgID,
+		OrganizationID: h.org(r),
 		CategoryID:     categoryID,
 		Name:           req.Name,
 		Description:    req.Description,
@@ -185,6 +278,11 @@ func (h *Handler) CreateAsset(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "quantity exceeds maximum allowed value")
 		return
 	}
+	if err := validateMinQuantity(req.MinQuantity); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	asset.MinQuantity = req.MinQuantity
 
 	if req.LocationID != nil {
 		if id, err := parseUUIDString(*req.LocationID); err == nil {
@@ -201,20 +299,38 @@ func (h *Handler) CreateAsset(w http.ResponseWriter, r *http.Request) {
 			asset.CollectionID = &id
 		}
 	}
+	if asset.OwnerID, err = h.resolveOwner(r, req.OwnerID); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if req.PurchaseAt != nil && *req.PurchaseAt != "" {
-		if t, err := time.Parse("2006-01-02", *req.PurchaseAt); err == nil {
+		if t, err := parseDate(*req.PurchaseAt, h.location(r)); err == nil {
 			asset.PurchaseAt = &t
 		}
 	}
-	asset.PurchasePrice = req.PurchasePrice
+	if err := applyPrice(r, asset, req.PurchasePrice, req.Currency); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	asset.PurchaseNote = req.PurchaseNote
 	asset.Notes = req.Notes
+	if err := h.applyHighValue(r.Context(), asset, req.HighValue); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	applyArchived(asset, req.Archived)
+	if err := applyStatus(asset, req.Status); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	if err := h.repos.Assets.Create(r.Context(), asset); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create asset")
 		return
 	}
 
+	h.publish(r, events.AssetCreated, asset.OrganizationID, asset.ID)
+
 	writeJSON(w, http.StatusCreated, asset)
 }
 
@@ -236,11 +352,22 @@ func (h *Handler) UpdateAsset(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "failed to get asset")
 		return
 	}
-	if asset == nil {
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || hidden {
 		writeError(w, http.StatusNotFound, "asset not found")
 		return
 	}
 
+	h.saveAsset(w, r, asset, req)
+}
+
+// saveAsset replaces the editable fields of asset with req, validates and
+// stores the result and responds with the updated asset
+func (h *Handler) saveAsset(w http.ResponseWriter, r *http.Request, asset *domain.Asset, req UpdateAssetRequest) {
 	categoryID, err := uuid.Parse(req.CategoryID)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "invalid category_id")
@@ -258,6 +385,12 @@ func (h *Handler) UpdateAsset(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "quantity exceeds maximum allowed value")
 		return
 	}
+	if err := validateMinQuantity(req.MinQuantity); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	asset.MinQuantity = req.MinQuantity
+	attributesBefore := asset.Attributes
 	asset.Attributes = req.Attributes
 
 	if req.LocationID != nil {
@@ -281,22 +414,42 @@ func (h *Handler) UpdateAsset(w http.ResponseWriter, r *http.Request) {
 	} else {
 		asset.CollectionID = nil
 	}
+	if asset.OwnerID, err = h.resolveOwner(r, req.OwnerID); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if req.PurchaseAt != nil && *req.PurchaseAt != "" {
-		if t, err := time.Parse("2006-01-02", *req.PurchaseAt); err == nil {
+		if t, err := parseDate(*req.PurchaseAt, h.location(r)); err == nil {
 			asset.PurchaseAt = &t
 		}
 	} else {
 		asset.PurchaseAt = nil
 	}
-	asset.PurchasePrice = req.PurchasePrice
+	if err := applyPrice(r, asset, req.PurchasePrice, req.Currency); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	asset.PurchaseNote = req.PurchaseNote
 	asset.Notes = req.Notes
+	if err := h.applyHighValue(r.Context(), asset, req.HighValue); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	applyArchived(asset, req.Archived)
+	if err := applyStatus(asset, req.Status); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	if err := h.repos.Assets.Update(r.Context(), asset); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to update asset")
 		return
 	}
 
+	// Attributes are JSON objects once stored, so diffing them can't fail
+	changes, _ := domain.AttributeChanges(attributesBefore, asset.Attributes)
+	h.publish(r, events.AssetUpdated, asset.OrganizationID, asset.ID, changes...)
+
 	writeJSON(w, http.StatusOK, asset)
 }
 
@@ -307,30 +460,190 @@ func (h *Handler) DeleteAsset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	asset, err := h.repos.Assets.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if hidden {
+		writeError(w, http.StatusNotFound, "asset not found")
+		return
+	}
+
 	if err := h.repos.Assets.Delete(r.Context(), id); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to delete asset")
 		return
 	}
 
+	h.publish(r, events.AssetDeleted, h.org(r), id)
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// validateMinQuantity checks a low-stock threshold, nil for none
+func validateMinQuantity(minQuantity *int) error {
+	if minQuantity != nil && (*minQuantity < 0 || *minQuantity > maxAssetQuantity) {
+		return fmt.Errorf("min_quantity must be between 0 and %d", maxAssetQuantity)
+	}
+	return nil
+}
+
 func parseUUIDString(s string) (uuid.UUID, error) {
 	return uuid.Parse(s)
 }
 
+// PriceInput is a price as sent by clients: a JSON number or a localized
+// string such as "1.299,99 €"
+type PriceInput struct {
+	number *float64
+	text   string
+}
+
+func (p PriceInput) MarshalJSON() ([]byte, error) {
+	if p.number != nil {
+		return json.Marshal(*p.number)
+	}
+	return json.Marshal(p.text)
+}
+
+func (p *PriceInput) UnmarshalJSON(data []byte) error {
+	*p = PriceInput{}
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &p.text)
+	}
+	return json.Unmarshal(data, &p.number)
+}
+
+// Parse returns the price, interpreting strings according to locale
+func (p *PriceInput) Parse(locale string) (money.Price, error) {
+	if p.number != nil {
+		if *p.number < 0 {
+			return money.Price{}, errors.New("price must not be negative")
+		}
+		return money.Price{Amount: *p.number}, nil
+	}
+	return money.Parse(p.text, locale)
+}
+
+// requestLocale returns the preferred locale of the client of r, e.g. "de-DE"
+func requestLocale(r *http.Request) string {
+	if locale := r.URL.Query().Get("locale"); locale != "" {
+		return locale
+	}
+	first, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	tag, _, _ := strings.Cut(first, ";")
+	return strings.TrimSpace(tag)
+}
+
+// applyPrice sets the purchase price and currency of asset from a request. A
+// nil currency keeps the asset's currency; a currency contained in the price
+// string sets it when unknown and must match it otherwise.
+func applyPrice(r *http.Request, asset *domain.Asset, price *PriceInput, currency *string) error {
+	if currency != nil {
+		if *currency == "" {
+			asset.Currency = nil
+		} else {
+			code, err := money.NormalizeCurrency(*currency)
+			if err != nil {
+				return err
+			}
+			asset.Currency = &code
+		}
+	}
+
+	if price == nil || (price.number == nil && strings.TrimSpace(price.text) == "") {
+		asset.PurchasePrice = nil
+		return nil
+	}
+	parsed, err := price.Parse(requestLocale(r))
+	if err != nil {
+		return fmt.Errorf("invalid purchase_price: %w", err)
+	}
+	if parsed.Currency != "" {
+		if asset.Currency != nil && *asset.Currency != parsed.Currency {
+			return fmt.Errorf("purchase_price currency %s does not match asset currency %s", parsed.Currency, *asset.Currency)
+		}
+		asset.Currency = &parsed.Currency
+	}
+	asset.PurchasePrice = &parsed.Amount
+	return nil
+}
+
+// resolveOwner validates an owner_id from a request: nil or empty clears the
+// owner, otherwise it must be a member of the organization, including users
+// of other organizations
+func (h *Handler) resolveOwner(r *http.Request, ownerID *string) (*uuid.UUID, error) {
+	if ownerID == nil || *ownerID == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*ownerID)
+	if err != nil {
+		return nil, errors.New("invalid owner_id")
+	}
+	member, err := h.repos.Organizations.GetMember(r.Context(), h.org(r), id)
+	if err != nil || member == nil {
+		return nil, errors.New("owner not found")
+	}
+	return &id, nil
+}
+
 type AssetStatsResponse struct {
-	TotalValue float64 `json:"total_value"`
+	TotalValue     float64                     `json:"total_value"`
+	BookValue      float64                     `json:"book_value"`    // Total value with depreciated assets at their book value
+	CurrentValue   float64                     `json:"current_value"` // Total value at the latest valuations, else purchase prices
+	ByOwner        []domain.OwnerValue         `json:"by_owner"`
+	ByStatus       []domain.StatusValue        `json:"by_status"`
+	RecurringCosts *domain.RecurringCostTotals `json:"recurring_costs,omitempty"`
 }
 
 func (h *Handler) GetAssetStats(w http.ResponseWriter, r *http.Request) {
-	totalValue, err := h.repos.Assets.GetTotalValue(r.Context(), h.orgID)
+	totalValue, err := h.repos.Assets.GetTotalValue(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset stats")
+		return
+	}
+
+	bookValue, err := h.totalBookValue(r.Context(), h.org(r), totalValue)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset stats")
+		return
+	}
+
+	currentValue, err := h.repos.Assets.GetCurrentValue(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset stats")
+		return
+	}
+
+	byOwner, err := h.repos.Assets.ValueByOwner(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset stats")
+		return
+	}
+
+	byStatus, err := h.repos.Assets.ValueByStatus(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset stats")
+		return
+	}
+
+	recurring, err := h.repos.Costs.Totals(r.Context(), h.org(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to get asset stats")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, AssetStatsResponse{
-		TotalValue: totalValue,
+		TotalValue:     totalValue,
+		BookValue:      bookValue,
+		CurrentValue:   currentValue,
+		ByOwner:        byOwner,
+		ByStatus:       byStatus,
+		RecurringCosts: recurring,
 	})
 }
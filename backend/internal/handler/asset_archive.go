@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// parseArchivedFilter parses the ?archived= parameter of asset lists: false
+// (the default) for active assets, true for archived ones or all
+func parseArchivedFilter(value string) (domain.ArchivedFilter, error) {
+	switch value {
+	case "", "false":
+		return domain.ArchivedExclude, nil
+	case "true":
+		return domain.ArchivedOnly, nil
+	case "all":
+		return domain.ArchivedAll, nil
+	}
+	return "", fmt.Errorf("invalid archived: %q, expected true, false or all", value)
+}
+
+// applyArchived archives asset or returns it to the active inventory; nil
+// keeps its state
+func applyArchived(asset *domain.Asset, archived *bool) {
+	switch {
+	case archived == nil:
+	case !*archived:
+		asset.ArchivedAt = nil
+	case asset.ArchivedAt == nil:
+		now := time.Now()
+		asset.ArchivedAt = &now
+	}
+}
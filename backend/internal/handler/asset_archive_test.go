@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_parseArchivedFilter(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    domain.ArchivedFilter
+		wantErr bool
+	}{
+		{"", domain.ArchivedExclude, false},
+		{"false", domain.ArchivedExclude, false},
+		{"true", domain.ArchivedOnly, false},
+		{"all", domain.ArchivedAll, false},
+		{"yes", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseArchivedFilter(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseArchivedFilter(%q) = %q, %v", tt.value, got, err)
+		}
+	}
+}
+
+func Test_applyArchived(t *testing.T) {
+	yes, no := true, false
+	earlier := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	asset := &domain.Asset{}
+	applyArchived(asset, nil)
+	if asset.ArchivedAt != nil {
+		t.Error("expected nil to keep the asset active")
+	}
+	applyArchived(asset, &yes)
+	if asset.ArchivedAt == nil {
+		t.Fatal("expected the asset to be archived")
+	}
+
+	asset.ArchivedAt = &earlier
+	applyArchived(asset, &yes)
+	if !asset.ArchivedAt.Equal(earlier) {
+		t.Error("expected archiving again to keep the archive date")
+	}
+	applyArchived(asset, nil)
+	if asset.ArchivedAt == nil {
+		t.Error("expected nil to keep the asset archived")
+	}
+	applyArchived(asset, &no)
+	if asset.ArchivedAt != nil {
+		t.Error("expected the asset to be active again")
+	}
+}
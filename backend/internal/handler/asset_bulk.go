@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/repository"
+)
+
+// maxBulkAssets is the most assets one bulk operation may change
+const maxBulkAssets = 1000
+
+// BulkAssetsRequest applies an operation to many assets at once
+type BulkAssetsRequest struct {
+	AssetIDs    []uuid.UUID `json:"asset_ids"`
+	Operation   string      `json:"operation"`    // delete, set_location, set_category, set_condition, add_tags or remove_tags
+	LocationID  *uuid.UUID  `json:"location_id"`  // set_location; null clears the location
+	CategoryID  *uuid.UUID  `json:"category_id"`  // set_category
+	ConditionID *uuid.UUID  `json:"condition_id"` // set_condition; null clears the condition
+	TagIDs      []uuid.UUID `json:"tag_ids"`      // add_tags, remove_tags
+}
+
+// BulkAssetsResponse reports the outcome of a bulk operation
+type BulkAssetsResponse struct {
+	Operation string `json:"operation"`
+	Affected  int    `json:"affected"`
+}
+
+// bulkTargets are the kinds of record the bulk actions set
+var bulkTargets = map[domain.BulkAssetAction]repository.Resource{
+	domain.BulkAssetSetLocation:  repository.ResourceLocation,
+	domain.BulkAssetSetCategory:  repository.ResourceCategory,
+	domain.BulkAssetSetCondition: repository.ResourceCondition,
+}
+
+// BulkAssets deletes, moves, recategorizes or tags many assets in one
+// transaction: either all of them change or none does
+func (h *Handler) BulkAssets(w http.ResponseWriter, r *http.Request) {
+	var req BulkAssetsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	op := domain.BulkAssetOperation{Action: domain.BulkAssetAction(req.Operation)}
+	for _, id := range req.AssetIDs {
+		if !slices.Contains(op.AssetIDs, id) {
+			op.AssetIDs = append(op.AssetIDs, id)
+		}
+	}
+	if len(op.AssetIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "asset_ids is required")
+		return
+	}
+	if len(op.AssetIDs) > maxBulkAssets {
+		writeError(w, http.StatusBadRequest, "at most 1000 assets can be changed at once")
+		return
+	}
+
+	switch op.Action {
+	case domain.BulkAssetDelete:
+	case domain.BulkAssetSetLocation:
+		op.TargetID = req.LocationID
+	case domain.BulkAssetSetCategory:
+		if req.CategoryID == nil {
+			writeError(w, http.StatusBadRequest, "category_id is required")
+			return
+		}
+		op.TargetID = req.CategoryID
+	case domain.BulkAssetSetCondition:
+		op.TargetID = req.ConditionID
+	case domain.BulkAssetAddTags, domain.BulkAssetRemoveTags:
+		if len(req.TagIDs) == 0 {
+			writeError(w, http.StatusBadRequest, "tag_ids is required")
+			return
+		}
+		op.TagIDs = req.TagIDs
+	default:
+		writeError(w, http.StatusBadRequest, "unknown operation: "+req.Operation)
+		return
+	}
+
+	orgID := h.org(r)
+	if res, ok := bulkTargets[op.Action]; ok && op.TargetID != nil {
+		if !h.ownedTarget(w, r, res, *op.TargetID) {
+			return
+		}
+	}
+	for _, id := range op.TagIDs {
+		if !h.ownedTarget(w, r, repository.ResourceTag, id) {
+			return
+		}
+	}
+
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	op.NoHighValue = hide
+
+	missing, err := h.repos.Assets.Bulk(r.Context(), orgID, op)
+	if err != nil {
+		slog.Error("failed to apply bulk operation", "operation", op.Action, "assets", len(op.AssetIDs), "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to apply bulk operation")
+		return
+	}
+	if len(missing) > 0 {
+		writeJSON(w, http.StatusNotFound, map[string]any{
+			"error":       "assets not found, nothing was changed",
+			"missing_ids": missing,
+		})
+		return
+	}
+
+	eventType := events.AssetUpdated
+	if op.Action == domain.BulkAssetDelete {
+		eventType = events.AssetDeleted
+	}
+	for _, id := range op.AssetIDs {
+		h.publish(r, eventType, orgID, id)
+	}
+
+	writeJSON(w, http.StatusOK, BulkAssetsResponse{
+		Operation: req.Operation,
+		Affected:  len(op.AssetIDs),
+	})
+}
+
+// ownedTarget checks that a record an operation refers to belongs to the
+// caller's organization, writing a bad request response if not
+func (h *Handler) ownedTarget(w http.ResponseWriter, r *http.Request, res repository.Resource, id uuid.UUID) bool {
+	owned, err := h.repos.Organizations.OwnedBy(r.Context(), h.org(r), res, id)
+	if err != nil {
+		slog.Error("failed to check organization", "resource", res, "id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return false
+	}
+	if !owned {
+		writeError(w, http.StatusBadRequest, string(res)+" not found: "+id.String())
+		return false
+	}
+	return true
+}
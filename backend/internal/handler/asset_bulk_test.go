@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func Test_BulkAssets_Validation(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	id := uuid.New()
+	tooMany := make([]string, maxBulkAssets+1)
+	for i := range tooMany {
+		tooMany[i] = `"` + uuid.NewString() + `"`
+	}
+	tests := map[string]string{
+		"invalid body":        `{`,
+		"no assets":           `{"operation":"delete","asset_ids":[]}`,
+		"too many assets":     `{"operation":"delete","asset_ids":[` + strings.Join(tooMany, ",") + `]}`,
+		"unknown operation":   fmt.Sprintf(`{"operation":"archive","asset_ids":["%s"]}`, id),
+		"category missing":    fmt.Sprintf(`{"operation":"set_category","asset_ids":["%s"]}`, id),
+		"tags missing":        fmt.Sprintf(`{"operation":"add_tags","asset_ids":["%s"]}`, id),
+		"remove without tags": fmt.Sprintf(`{"operation":"remove_tags","asset_ids":["%s"],"tag_ids":[]}`, id),
+		"invalid asset ID":    `{"operation":"delete","asset_ids":["x"]}`,
+	}
+	for name, body := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/assets/bulk", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.BulkAssets(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+}
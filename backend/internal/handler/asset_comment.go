@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// maxCommentLength is the longest comment body, in characters
+const maxCommentLength = 10000
+
+// CreateCommentRequest adds a comment to an asset
+type CreateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+// ListAssetComments returns the comments of an asset, newest first
+func (h *Handler) ListAssetComments(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	comments, err := h.repos.Comments.ListByAsset(r.Context(), asset.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list comments")
+		return
+	}
+	writeJSON(w, http.StatusOK, comments)
+}
+
+// CreateAssetComment adds a comment by the current user to an asset
+func (h *Handler) CreateAssetComment(w http.ResponseWriter, r *http.Request) {
+	var req CreateCommentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		writeError(w, http.StatusBadRequest, "body is required")
+		return
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		writeError(w, http.StatusBadRequest, "body is too long")
+		return
+	}
+
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	comment := &domain.AssetComment{AssetID: asset.ID, AuthorID: currentUserID(r), Body: body}
+	if err := h.repos.Comments.Create(r.Context(), comment); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create comment")
+		return
+	}
+	if created, err := h.repos.Comments.GetByID(r.Context(), asset.ID, comment.ID); err == nil && created != nil {
+		comment = created
+	}
+	writeJSON(w, http.StatusCreated, comment)
+}
+
+// DeleteAssetComment deletes a comment; only its author and admins may
+func (h *Handler) DeleteAssetComment(w http.ResponseWriter, r *http.Request) {
+	commentID, err := parseUUID(r, "commentId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid comment ID")
+		return
+	}
+
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	comment, err := h.repos.Comments.GetByID(r.Context(), asset.ID, commentID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get comment")
+		return
+	}
+	if comment == nil {
+		writeError(w, http.StatusNotFound, "comment not found")
+		return
+	}
+	userID := currentUserID(r)
+	isAuthor := userID != nil && comment.AuthorID != nil && *userID == *comment.AuthorID
+	if !isAuthor && auth.CurrentRole(r.Context()) != domain.UserRoleAdmin {
+		writeError(w, http.StatusForbidden, "only the author or an admin can delete a comment")
+		return
+	}
+
+	if err := h.repos.Comments.Delete(r.Context(), comment.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete comment")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// visibleAsset returns the asset of the "id" URL parameter, writing an error
+// response if it is invalid, doesn't exist or is hidden from the caller
+func (h *Handler) visibleAsset(w http.ResponseWriter, r *http.Request) (*domain.Asset, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return nil, false
+	}
+
+	asset, err := h.repos.Assets.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return nil, false
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return nil, false
+	}
+	if asset == nil || hidden {
+		writeError(w, http.StatusNotFound, "asset not found")
+		return nil, false
+	}
+	return asset, true
+}
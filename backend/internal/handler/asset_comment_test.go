@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func Test_CreateAssetComment_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		body string
+	}{
+		{"invalid JSON", uuid.NewString(), `{`},
+		{"empty body", uuid.NewString(), `{"body": "  "}`},
+		{"body too long", uuid.NewString(), `{"body": "` + strings.Repeat("a", maxCommentLength+1) + `"}`},
+		{"invalid asset ID", "x", `{"body": "Replaced the filter"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{repos: &Repositories{}}
+			req := withChiURLParam(httptest.NewRequest(http.MethodPost, "/api/assets/"+tt.id+"/comments", strings.NewReader(tt.body)), "id", tt.id)
+			rr := httptest.NewRecorder()
+
+			h.CreateAssetComment(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_DeleteAssetComment_InvalidID(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	req := withChiURLParam(httptest.NewRequest(http.MethodDelete, "/api/assets/x/comments/y", nil), "commentId", "y")
+	rr := httptest.NewRecorder()
+
+	h.DeleteAssetComment(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
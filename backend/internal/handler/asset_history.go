@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// AttributeChangeResponse is a change of an attribute of an asset
+type AttributeChangeResponse struct {
+	EventID        uuid.UUID       `json:"event_id"`
+	Key            string          `json:"key"`
+	OldValue       json.RawMessage `json:"old_value"` // null if the attribute was added
+	NewValue       json.RawMessage `json:"new_value"` // null if the attribute was removed
+	ChangedBy      *uuid.UUID      `json:"changed_by,omitempty"`
+	ChangedByEmail *string         `json:"changed_by_email,omitempty"`
+	ChangedAt      time.Time       `json:"changed_at"`
+}
+
+// GetAssetHistory returns the audit trail of an asset, newest first: who
+// created, changed or deleted it when, with the changed fields. ?field= only
+// returns the changes of fields starting with it, e.g. "purchase_price" or
+// "attributes.".
+func (h *Handler) GetAssetHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+
+	asset, err := h.repos.Assets.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || hidden {
+		writeError(w, http.StatusNotFound, "asset not found")
+		return
+	}
+
+	history, err := h.repos.AssetEvents.ListByAsset(r.Context(), id, r.URL.Query().Get("field"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset history")
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// GetAttributeHistory returns the changes of an asset's attributes, newest
+// first, so accidental edits can be traced and reverted
+func (h *Handler) GetAttributeHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+
+	asset, err := h.repos.Assets.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || hidden {
+		writeError(w, http.StatusNotFound, "asset not found")
+		return
+	}
+
+	history, err := h.repos.AssetEvents.ListByAsset(r.Context(), id, domain.AttributePrefix)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get attribute history")
+		return
+	}
+	writeJSON(w, http.StatusOK, attributeChanges(history))
+}
+
+// attributeChanges flattens history entries into their attribute changes
+func attributeChanges(history []domain.AssetEvent) []AttributeChangeResponse {
+	changes := []AttributeChangeResponse{}
+	for _, e := range history {
+		for _, c := range e.Changes {
+			key, ok := strings.CutPrefix(c.Field, domain.AttributePrefix)
+			if !ok {
+				continue
+			}
+			changes = append(changes, AttributeChangeResponse{
+				EventID:        e.ID,
+				Key:            key,
+				OldValue:       jsonOrNull(c.Old),
+				NewValue:       jsonOrNull(c.New),
+				ChangedBy:      e.ActorID,
+				ChangedByEmail: e.ActorEmail,
+				ChangedAt:      e.CreatedAt,
+			})
+		}
+	}
+	return changes
+}
+
+func jsonOrNull(v json.RawMessage) json.RawMessage {
+	if len(v) == 0 {
+		return json.RawMessage("null")
+	}
+	return v
+}
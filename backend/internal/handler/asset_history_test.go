@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_GetAttributeHistory_InvalidID(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	req := withChiURLParam(httptest.NewRequest(http.MethodGet, "/api/assets/x/attribute-history", nil), "id", "x")
+	w := httptest.NewRecorder()
+	h.GetAttributeHistory(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func Test_GetAssetHistory_InvalidID(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	req := withChiURLParam(httptest.NewRequest(http.MethodGet, "/api/assets/x/history", nil), "id", "x")
+	w := httptest.NewRecorder()
+	h.GetAssetHistory(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func Test_attributeChanges(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	history := []domain.AssetEvent{
+		{ID: second, Changes: []domain.AssetChange{
+			{Field: "name", Old: json.RawMessage(`"a"`), New: json.RawMessage(`"b"`)},
+			{Field: "attributes.serial", Old: json.RawMessage(`"A1"`), New: json.RawMessage(`"A2"`)},
+		}},
+		{ID: first, Changes: []domain.AssetChange{
+			{Field: "attributes.serial", New: json.RawMessage(`"A1"`)},
+		}},
+	}
+
+	changes := attributeChanges(history)
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 attribute changes, got %+v", changes)
+	}
+	if changes[0].EventID != second || changes[0].Key != "serial" || string(changes[0].OldValue) != `"A1"` {
+		t.Errorf("unexpected change %+v", changes[0])
+	}
+	if changes[1].EventID != first || string(changes[1].OldValue) != "null" || string(changes[1].NewValue) != `"A1"` {
+		t.Errorf("unexpected change %+v", changes[1])
+	}
+}
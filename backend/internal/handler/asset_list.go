@@ -0,0 +1,478 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/security"
+)
+
+type CreateAssetListRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+}
+
+type UpdateAssetListRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+}
+
+type AddListAssetsRequest struct {
+	AssetIDs []string `json:"asset_ids"`
+}
+
+type ShareAssetListRequest struct {
+	ShowValues bool `json:"show_values"`
+}
+
+type ShareAssetListResponse struct {
+	ShareToken string `json:"share_token"`
+	ShareURL   string `json:"share_url"`
+	ShowValues bool   `json:"show_values"`
+}
+
+type AssetListDetailResponse struct {
+	domain.AssetList
+	Assets []AssetWithImageURL `json:"assets"`
+}
+
+// SharedListItem is the public (unauthenticated) representation of a list item
+type SharedListItem struct {
+	Name          string   `json:"name"`
+	Description   *string  `json:"description,omitempty"`
+	Quantity      int      `json:"quantity"`
+	Category      string   `json:"category,omitempty"`
+	Location      string   `json:"location,omitempty"`
+	ImageURL      string   `json:"image_url,omitempty"`
+	PurchasePrice *float64 `json:"purchase_price,omitempty"`
+}
+
+type SharedListResponse struct {
+	Name        string           `json:"name"`
+	Description *string          `json:"description,omitempty"`
+	Items       []SharedListItem `json:"items"`
+}
+
+func (h *Handler) ListAssetLists(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.repos.Lists.List(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list lists")
+		return
+	}
+
+	if lists == nil {
+		lists = []domain.AssetList{}
+	}
+
+	writeJSON(w, http.StatusOK, lists)
+}
+
+func (h *Handler) GetAssetList(w http.ResponseWriter, r *http.Request) {
+	list, ok := h.loadAssetList(w, r)
+	if !ok {
+		return
+	}
+
+	assets, err := h.visibleAssets(r, list.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list assets")
+		return
+	}
+
+	response := AssetListDetailResponse{AssetList: *list, Assets: make([]AssetWithImageURL, len(assets))}
+	for i, asset := range assets {
+		response.Assets[i] = AssetWithImageURL{Asset: asset, MainAttachmentURL: h.mainAttachmentURL(r, &asset)}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) CreateAssetList(w http.ResponseWriter, r *http.Request) {
+	var req CreateAssetListRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	list := &domain.AssetList{
+		OrganizationID: h.org(r),
+		Name:           req.Name,
+		Description:    req.Description,
+	}
+
+	if err := h.repos.Lists.Create(r.Context(), list); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create list")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, list)
+}
+
+func (h *Handler) UpdateAssetList(w http.ResponseWriter, r *http.Request) {
+	list, ok := h.loadAssetList(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateAssetListRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	list.Name = req.Name
+	list.Description = req.Description
+
+	if err := h.repos.Lists.Update(r.Context(), list); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update list")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) DeleteAssetList(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid list ID")
+		return
+	}
+
+	if err := h.repos.Lists.Delete(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) AddAssetListAssets(w http.ResponseWriter, r *http.Request) {
+	list, ok := h.loadAssetList(w, r)
+	if !ok {
+		return
+	}
+
+	var req AddListAssetsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if len(req.AssetIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "asset_ids is required")
+		return
+	}
+
+	// Hidden high-value assets can't be added, as shared lists would show them
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+
+	assetIDs := make([]uuid.UUID, 0, len(req.AssetIDs))
+	for _, raw := range req.AssetIDs {
+		assetID, err := parseUUIDString(raw)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid asset ID: "+raw)
+			return
+		}
+		asset, err := h.repos.Assets.GetByID(r.Context(), assetID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to check asset")
+			return
+		}
+		if asset == nil || asset.OrganizationID != list.OrganizationID || (hide && asset.HighValue) {
+			writeError(w, http.StatusNotFound, "asset not found: "+raw)
+			return
+		}
+		assetIDs = append(assetIDs, assetID)
+	}
+
+	if err := h.repos.Lists.AddAssets(r.Context(), list.ID, assetIDs); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to add assets to list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) RemoveAssetListAsset(w http.ResponseWriter, r *http.Request) {
+	listID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid list ID")
+		return
+	}
+	assetID, err := parseUUID(r, "assetId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+
+	if err := h.repos.Lists.RemoveAsset(r.Context(), listID, assetID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to remove asset from list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShareAssetList creates (or rotates) the public share link of a list
+func (h *Handler) ShareAssetList(w http.ResponseWriter, r *http.Request) {
+	list, ok := h.loadAssetList(w, r)
+	if !ok {
+		return
+	}
+
+	var req ShareAssetListRequest
+	if r.ContentLength > 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		slog.Error("failed to generate share token", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to share list")
+		return
+	}
+
+	if err := h.repos.Lists.SetShare(r.Context(), list.ID, &token, req.ShowValues); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to share list")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ShareAssetListResponse{
+		ShareToken: token,
+		ShareURL:   h.absoluteURL(r, sharedListPath(token)),
+		ShowValues: req.ShowValues,
+	})
+}
+
+// UnshareAssetList revokes the public share link of a list
+func (h *Handler) UnshareAssetList(w http.ResponseWriter, r *http.Request) {
+	list, ok := h.loadAssetList(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Lists.SetShare(r.Context(), list.ID, nil, false); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to unshare list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PrintAssetList renders a printer-friendly HTML page of a list
+func (h *Handler) PrintAssetList(w http.ResponseWriter, r *http.Request) {
+	list, ok := h.loadAssetList(w, r)
+	if !ok {
+		return
+	}
+
+	assets, err := h.visibleAssets(r, list.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list assets")
+		return
+	}
+
+	branding, err := h.loadBranding(r.Context(), list.OrganizationID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+
+	data := printListData{
+		List:     toSharedList(list, assets, true, nil),
+		Branding: toBrandingResponse(branding),
+		Nonce:    security.Nonce(),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", security.PolicyWithNonce(data.Nonce))
+	if err := printListTemplate.Execute(w, data); err != nil {
+		slog.Error("failed to render list", "error", err)
+	}
+}
+
+// GetSharedAssetList returns a shared list by its public token (no auth required)
+func (h *Handler) GetSharedAssetList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.sharedList(r, chi.URLParam(r, "token"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get list")
+		return
+	}
+	if list == nil {
+		writeError(w, http.StatusNotFound, "list not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// sharedList returns the public representation of the list shared with
+// token, or nil if there is none
+func (h *Handler) sharedList(r *http.Request, token string) (*SharedListResponse, error) {
+	list, err := h.repos.Lists.GetByShareToken(r.Context(), token)
+	if err != nil || list == nil {
+		return nil, err
+	}
+
+	assets, err := h.repos.Lists.ListAssets(r.Context(), list.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values of high-value items are never exposed through share links
+	maskHighValue(assets)
+
+	imageURL := func(a *domain.Asset) string { return h.mainAttachmentURL(r, a) }
+	shared := toSharedList(list, assets, list.ShareShowValues, imageURL)
+	return &shared, nil
+}
+
+// visibleAssets returns the assets of a list the user of r may see
+func (h *Handler) visibleAssets(r *http.Request, listID uuid.UUID) ([]domain.Asset, error) {
+	assets, err := h.repos.Lists.ListAssets(r.Context(), listID)
+	if err != nil {
+		return nil, err
+	}
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		return nil, err
+	}
+	if hide {
+		assets = withoutHighValue(assets)
+	}
+	return assets, nil
+}
+
+func (h *Handler) loadAssetList(w http.ResponseWriter, r *http.Request) (*domain.AssetList, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid list ID")
+		return nil, false
+	}
+
+	list, err := h.repos.Lists.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get list")
+		return nil, false
+	}
+	if list == nil || list.OrganizationID != h.org(r) {
+		writeError(w, http.StatusNotFound, "list not found")
+		return nil, false
+	}
+
+	return list, true
+}
+
+// mainAttachmentURL returns a download URL for the asset's main image, or "" if unavailable
+func (h *Handler) mainAttachmentURL(r *http.Request, asset *domain.Asset) string {
+	if asset.MainAttachment == nil || h.storage == nil {
+		return ""
+	}
+	url, err := h.storage.GetPresignedURL(r.Context(), asset.MainAttachment.FileKey, 15*time.Minute)
+	if err != nil {
+		return ""
+	}
+	return url
+}
+
+// toSharedList flattens a list into its public representation
+func toSharedList(list *domain.AssetList, assets []domain.Asset, showValues bool, imageURL func(*domain.Asset) string) SharedListResponse {
+	response := SharedListResponse{
+		Name:        list.Name,
+		Description: list.Description,
+		Items:       make([]SharedListItem, len(assets)),
+	}
+	for i := range assets {
+		a := &assets[i]
+		item := SharedListItem{
+			Name:        a.Name,
+			Description: a.Description,
+			Quantity:    a.Quantity,
+		}
+		if a.Category != nil {
+			item.Category = a.Category.Name
+		}
+		if a.Location != nil {
+			item.Location = a.Location.Name
+		}
+		if showValues {
+			item.PurchasePrice = a.PurchasePrice
+		}
+		if imageURL != nil {
+			item.ImageURL = imageURL(a)
+		}
+		response.Items[i] = item
+	}
+	return response
+}
+
+func sharedListPath(token string) string {
+	return "/share/lists/" + token
+}
+
+func newShareToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// printListData is the view model of the printable list page
+type printListData struct {
+	List     SharedListResponse
+	Branding BrandingResponse
+	Nonce    string // CSP nonce of the inline print script
+}
+
+var printListTemplate = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>{{.List.Name}} - {{.Branding.Title}}</title>
+  <style>
+    body { font-family: sans-serif; margin: 2rem; }
+    header { display: flex; align-items: center; gap: 1rem; color: #666; }
+    header img { max-height: 3rem; }
+    h1 { color: {{with .Branding.AccentColor}}{{.}}{{else}}#000{{end}}; }
+    table { border-collapse: collapse; width: 100%; }
+    th, td { border: 1px solid #999; padding: 0.4rem 0.6rem; text-align: left; }
+    td.check { width: 1.5rem; }
+  </style>
+</head>
+<body>
+  <header>{{with .Branding.LogoURL}}<img src="{{.}}" alt="">{{end}}<span>{{.Branding.Title}}</span></header>
+  <h1>{{.List.Name}}</h1>
+  {{with .List.Description}}<p>{{.}}</p>{{end}}
+  <table>
+    <thead><tr><th></th><th>Item</th><th>Qty</th><th>Category</th><th>Location</th></tr></thead>
+    <tbody>
+    {{range .List.Items}}<tr><td class="check">&#9744;</td><td>{{.Name}}</td><td>{{.Quantity}}</td><td>{{.Category}}</td><td>{{.Location}}</td></tr>
+    {{end}}</tbody>
+  </table>
+  <script nonce="{{.Nonce}}">window.addEventListener("load", function () { window.print(); });</script>
+</body>
+</html>`))
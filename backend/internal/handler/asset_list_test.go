@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_newShareToken_IsUniqueAndURLSafe(t *testing.T) {
+	a, err := newShareToken()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := newShareToken()
+
+	if a == b {
+		t.Error("expected distinct tokens")
+	}
+	if len(a) != 32 {
+		t.Errorf("expected 32 character token, got %d", len(a))
+	}
+	for _, c := range a {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			t.Fatalf("token contains non URL-safe character %q", c)
+		}
+	}
+}
+
+func Test_toSharedList_HidesValuesByDefault(t *testing.T) {
+	price := 49.99
+	list := &domain.AssetList{Name: "Summer"}
+	assets := []domain.Asset{{
+		Name:          "Kite",
+		Quantity:      2,
+		PurchasePrice: &price,
+		Category:      &domain.Category{Name: "Toys"},
+		Location:      &domain.Location{Name: "Garage"},
+	}}
+
+	shared := toSharedList(list, assets, false, nil)
+
+	if len(shared.Items) != 1 {
+		t.Fatalf("expected 1 item, got %d", len(shared.Items))
+	}
+	item := shared.Items[0]
+	if item.PurchasePrice != nil {
+		t.Error("expected purchase price to be hidden")
+	}
+	if item.Category != "Toys" || item.Location != "Garage" || item.Quantity != 2 {
+		t.Errorf("unexpected item: %+v", item)
+	}
+}
+
+func Test_toSharedList_ShowValues_IncludesPrice(t *testing.T) {
+	price := 49.99
+	list := &domain.AssetList{Name: "Summer"}
+	assets := []domain.Asset{{Name: "Kite", PurchasePrice: &price}}
+
+	shared := toSharedList(list, assets, true, func(*domain.Asset) string { return "http://img" })
+
+	item := shared.Items[0]
+	if item.PurchasePrice == nil || *item.PurchasePrice != price {
+		t.Error("expected purchase price to be included")
+	}
+	if item.ImageURL != "http://img" {
+		t.Errorf("expected image URL, got %q", item.ImageURL)
+	}
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// PatchAsset applies a JSON merge patch (RFC 7386) to an asset: fields the
+// body doesn't mention keep their value, null clears a field and objects
+// such as attributes are merged key by key. The result is validated like a
+// full update.
+func (h *Handler) PatchAsset(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil || !isJSONObject(patch) {
+		writeError(w, http.StatusBadRequest, "request body must be a JSON object")
+		return
+	}
+
+	asset, err := h.repos.Assets.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || hidden {
+		writeError(w, http.StatusNotFound, "asset not found")
+		return
+	}
+
+	current, err := json.Marshal(updateRequestFor(asset, h.location(r)))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to patch asset")
+		return
+	}
+	merged, err := mergePatch(current, patch)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	var req UpdateAssetRequest
+	if err := json.Unmarshal(merged, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	h.saveAsset(w, r, asset, req)
+}
+
+// updateRequestFor returns the full update that leaves asset as it is.
+// HighValue stays nil so the organization's policy applies as on updates.
+func updateRequestFor(asset *domain.Asset, loc *time.Location) UpdateAssetRequest {
+	req := UpdateAssetRequest{
+		CategoryID:   asset.CategoryID.String(),
+		Name:         asset.Name,
+		Description:  asset.Description,
+		Quantity:     asset.Quantity,
+		MinQuantity:  asset.MinQuantity,
+		Attributes:   asset.Attributes,
+		Currency:     asset.Currency,
+		PurchaseNote: asset.PurchaseNote,
+		Notes:        asset.Notes,
+	}
+	if asset.LocationID != nil {
+		s := asset.LocationID.String()
+		req.LocationID = &s
+	}
+	if asset.ConditionID != nil {
+		s := asset.ConditionID.String()
+		req.ConditionID = &s
+	}
+	if asset.CollectionID != nil {
+		s := asset.CollectionID.String()
+		req.CollectionID = &s
+	}
+	if asset.OwnerID != nil {
+		s := asset.OwnerID.String()
+		req.OwnerID = &s
+	}
+	if asset.PurchaseAt != nil {
+		s := asset.PurchaseAt.In(loc).Format("2006-01-02")
+		req.PurchaseAt = &s
+	}
+	if asset.PurchasePrice != nil {
+		price := *asset.PurchasePrice
+		req.PurchasePrice = &PriceInput{number: &price}
+	}
+	return req
+}
+
+// mergePatch applies the JSON merge patch to target. A patch that isn't an
+// object replaces target; otherwise null members delete keys and the others
+// are merged recursively.
+func mergePatch(target, patch json.RawMessage) (json.RawMessage, error) {
+	if !isJSONObject(patch) {
+		return patch, nil
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil {
+		return nil, err
+	}
+	doc := map[string]json.RawMessage{}
+	if isJSONObject(target) {
+		if err := json.Unmarshal(target, &doc); err != nil {
+			return nil, err
+		}
+	}
+	for key, value := range members {
+		if string(bytes.TrimSpace(value)) == "null" {
+			delete(doc, key)
+			continue
+		}
+		merged, err := mergePatch(doc[key], value)
+		if err != nil {
+			return nil, err
+		}
+		doc[key] = merged
+	}
+	return json.Marshal(doc)
+}
+
+func isJSONObject(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] == '{'
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_MergePatch(t *testing.T) {
+	tests := map[string]struct {
+		target, patch, want string
+	}{
+		"adds member":         {`{"a":1}`, `{"b":2}`, `{"a":1,"b":2}`},
+		"replaces member":     {`{"a":1}`, `{"a":"x"}`, `{"a":"x"}`},
+		"null removes member": {`{"a":1,"b":2}`, `{"a":null}`, `{"b":2}`},
+		"merges nested":       {`{"o":{"a":1,"b":2}}`, `{"o":{"b":3,"a":null}}`, `{"o":{"b":3}}`},
+		"array replaces":      {`{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		"object over scalar":  {`{"a":1}`, `{"a":{"b":1}}`, `{"a":{"b":1}}`},
+		"empty patch":         {`{"a":1}`, `{}`, `{"a":1}`},
+	}
+	for name, tt := range tests {
+		got, err := mergePatch(json.RawMessage(tt.target), json.RawMessage(tt.patch))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: expected %s, got %s", name, tt.want, got)
+		}
+	}
+}
+
+func Test_UpdateRequestFor_RoundTrip(t *testing.T) {
+	location := uuid.New()
+	price := 12.5
+	currency := "EUR"
+	bought := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	asset := &domain.Asset{
+		CategoryID:    uuid.New(),
+		LocationID:    &location,
+		Name:          "Camera",
+		Quantity:      2,
+		Attributes:    json.RawMessage(`{"serial":"X1"}`),
+		PurchaseAt:    &bought,
+		PurchasePrice: &price,
+		Currency:      &currency,
+	}
+
+	current, err := json.Marshal(updateRequestFor(asset, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged, err := mergePatch(current, json.RawMessage(`{"location_id":null,"attributes":{"lens":"50mm"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req UpdateAssetRequest
+	if err := json.Unmarshal(merged, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.CategoryID != asset.CategoryID.String() || req.Name != "Camera" || req.Quantity != 2 {
+		t.Errorf("expected unmentioned fields to be kept, got %+v", req)
+	}
+	if req.LocationID != nil {
+		t.Errorf("expected location to be cleared, got %s", *req.LocationID)
+	}
+	if req.PurchaseAt == nil || *req.PurchaseAt != "2024-03-01" {
+		t.Errorf("expected purchase date to be kept, got %v", req.PurchaseAt)
+	}
+	if req.PurchasePrice == nil || req.PurchasePrice.number == nil || *req.PurchasePrice.number != price {
+		t.Errorf("expected purchase price to be kept, got %+v", req.PurchasePrice)
+	}
+	if req.HighValue != nil {
+		t.Errorf("expected high value flag to be left to the policy, got %v", *req.HighValue)
+	}
+	var attrs map[string]string
+	if err := json.Unmarshal(req.Attributes, &attrs); err != nil {
+		t.Fatal(err)
+	}
+	if attrs["serial"] != "X1" || attrs["lens"] != "50mm" {
+		t.Errorf("expected attributes to be merged, got %s", req.Attributes)
+	}
+}
+
+func Test_PatchAsset_Validation(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	tests := map[string]struct {
+		id   string
+		body string
+	}{
+		"invalid ID":  {"not-a-uuid", `{"name":"x"}`},
+		"empty body":  {uuid.NewString(), ``},
+		"array body":  {uuid.NewString(), `[{"name":"x"}]`},
+		"scalar body": {uuid.NewString(), `"x"`},
+		"null body":   {uuid.NewString(), `null`},
+	}
+	for name, tt := range tests {
+		req := httptest.NewRequest(http.MethodPatch, "/api/assets/"+tt.id, strings.NewReader(tt.body))
+		req = withChiURLParam(req, "id", tt.id)
+		w := httptest.NewRecorder()
+		h.PatchAsset(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+}
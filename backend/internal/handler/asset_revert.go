@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/repository"
+)
+
+// RevertAsset restores an asset to its state right after one of its history
+// events. Attributes and tags are only restored when asked for with
+// ?include=attributes,tags; the asset and its tags change in one transaction.
+func (h *Handler) RevertAsset(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+	eventID, err := parseUUID(r, "eventId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid event ID")
+		return
+	}
+	var withAttributes, withTags bool
+	if include := r.URL.Query().Get("include"); include != "" {
+		for _, part := range strings.Split(include, ",") {
+			switch strings.TrimSpace(part) {
+			case "attributes":
+				withAttributes = true
+			case "tags":
+				withTags = true
+			default:
+				writeError(w, http.StatusBadRequest, "include must list attributes and/or tags")
+				return
+			}
+		}
+	}
+
+	asset, err := h.repos.Assets.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || hidden {
+		writeError(w, http.StatusNotFound, "asset not found")
+		return
+	}
+
+	event, err := h.repos.AssetEvents.GetByID(r.Context(), eventID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get history event")
+		return
+	}
+	if event == nil || event.AssetID != asset.ID {
+		writeError(w, http.StatusNotFound, "history event not found")
+		return
+	}
+	if event.Snapshot == nil {
+		writeError(w, http.StatusConflict, "the asset's state wasn't recorded for this event")
+		return
+	}
+
+	attributesBefore := asset.Attributes
+	if err := event.Snapshot.Apply(asset, withAttributes); err != nil {
+		slog.Error("failed to apply asset snapshot", "event_id", eventID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to revert asset")
+		return
+	}
+	if !h.revertTargetsExist(w, r, asset) {
+		return
+	}
+	if err := h.applyHighValue(r.Context(), asset, nil); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+
+	var tagIDs []uuid.UUID
+	if withTags {
+		tagIDs = append([]uuid.UUID{}, event.Snapshot.TagIDs...)
+	}
+	if err := h.repos.Assets.Restore(r.Context(), asset, tagIDs); err != nil {
+		slog.Error("failed to revert asset", "asset_id", id, "event_id", eventID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to revert asset")
+		return
+	}
+
+	// Attributes are JSON objects once stored, so diffing them can't fail
+	changes, _ := domain.AttributeChanges(attributesBefore, asset.Attributes)
+	h.publish(r, events.AssetUpdated, asset.OrganizationID, asset.ID, changes...)
+
+	writeJSON(w, http.StatusOK, asset)
+}
+
+// revertTargetsExist checks that the records a reverted asset refers to
+// haven't been deleted since, writing a bad request response if one has
+func (h *Handler) revertTargetsExist(w http.ResponseWriter, r *http.Request, asset *domain.Asset) bool {
+	if !h.ownedTarget(w, r, repository.ResourceCategory, asset.CategoryID) {
+		return false
+	}
+	for res, id := range map[repository.Resource]*uuid.UUID{
+		repository.ResourceLocation:  asset.LocationID,
+		repository.ResourceCondition: asset.ConditionID,
+		repository.ResourceAsset:     asset.CollectionID,
+	} {
+		if id != nil && !h.ownedTarget(w, r, res, *id) {
+			return false
+		}
+	}
+	if asset.OwnerID != nil {
+		owner := asset.OwnerID.String()
+		if _, err := h.resolveOwner(r, &owner); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return false
+		}
+	}
+	return true
+}
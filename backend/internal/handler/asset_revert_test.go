@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func Test_RevertAsset_Validation(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	tests := map[string]struct {
+		id, eventID, query string
+	}{
+		"invalid asset ID": {"x", uuid.NewString(), ""},
+		"invalid event ID": {uuid.NewString(), "x", ""},
+		"unknown include":  {uuid.NewString(), uuid.NewString(), "?include=tags,photos"},
+	}
+	for name, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/assets/"+tt.id+"/revert/"+tt.eventID+tt.query, nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", tt.id)
+		rctx.URLParams.Add("eventId", tt.eventID)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		h.RevertAsset(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+}
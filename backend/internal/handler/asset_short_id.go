@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+)
+
+// ShortIDResponse is the short ID of an asset, to write to an NFC tag or
+// print; /api/resolve finds the asset by it
+type ShortIDResponse struct {
+	ShortID string `json:"short_id"`
+}
+
+// AssignShortID returns the short ID of an asset, assigning one to assets
+// that have none yet (assets get one when created, imported ones may not)
+func (h *Handler) AssignShortID(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+	if asset.ShortID != nil {
+		writeJSON(w, http.StatusOK, ShortIDResponse{ShortID: *asset.ShortID})
+		return
+	}
+
+	shortID, err := h.repos.Assets.AssignShortID(r.Context(), asset.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to assign short ID")
+		return
+	}
+	writeJSON(w, http.StatusOK, ShortIDResponse{ShortID: shortID})
+}
@@ -0,0 +1,25 @@
+package handler
+
+import "net/http"
+
+// GetAssetSource returns the data the import plugin returned for an asset, to
+// backfill attributes without fetching it again
+func (h *Handler) GetAssetSource(w http.ResponseWriter, r *http.Request) {
+	assetID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+
+	src, err := h.repos.Sources.GetByAssetID(r.Context(), assetID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset source")
+		return
+	}
+	if src == nil {
+		writeError(w, http.StatusNotFound, "asset source not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, src)
+}
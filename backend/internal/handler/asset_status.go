@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// parseStatusFilter parses the ?status= parameters of asset lists; none
+// means any status
+func parseStatusFilter(values []string) ([]domain.AssetStatus, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	statuses := make([]domain.AssetStatus, len(values))
+	for i, v := range values {
+		statuses[i] = domain.AssetStatus(v)
+		if !statuses[i].Valid() {
+			return nil, invalidStatusError(v)
+		}
+	}
+	return statuses, nil
+}
+
+// applyStatus sets the lifecycle status of asset; nil keeps it
+func applyStatus(asset *domain.Asset, status *string) error {
+	if status == nil {
+		return nil
+	}
+	if !domain.AssetStatus(*status).Valid() {
+		return invalidStatusError(*status)
+	}
+	asset.Status = domain.AssetStatus(*status)
+	return nil
+}
+
+func invalidStatusError(status string) error {
+	return fmt.Errorf("invalid status: %q, expected one of %v", status, domain.AssetStatuses)
+}
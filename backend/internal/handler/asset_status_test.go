@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_parseStatusFilter(t *testing.T) {
+	statuses, err := parseStatusFilter(nil)
+	if err != nil || statuses != nil {
+		t.Errorf("expected no filter, got %v, %v", statuses, err)
+	}
+
+	statuses, err = parseStatusFilter([]string{"loaned", "in_repair"})
+	if err != nil || !slices.Equal(statuses, []domain.AssetStatus{domain.AssetStatusLoaned, domain.AssetStatusInRepair}) {
+		t.Errorf("expected loaned and in_repair, got %v, %v", statuses, err)
+	}
+
+	if _, err := parseStatusFilter([]string{"owned", "stolen"}); err == nil {
+		t.Error("expected an unknown status to fail")
+	}
+}
+
+func Test_applyStatus(t *testing.T) {
+	asset := &domain.Asset{Status: domain.AssetStatusOwned}
+	sold, unknown := "sold", "in-repair"
+
+	if err := applyStatus(asset, nil); err != nil || asset.Status != domain.AssetStatusOwned {
+		t.Errorf("expected nil to keep the status, got %q, %v", asset.Status, err)
+	}
+	if err := applyStatus(asset, &sold); err != nil || asset.Status != domain.AssetStatusSold {
+		t.Errorf("expected the asset to be sold, got %q, %v", asset.Status, err)
+	}
+	if err := applyStatus(asset, &unknown); err == nil || asset.Status != domain.AssetStatusSold {
+		t.Errorf("expected an unknown status to fail and keep the status, got %q, %v", asset.Status, err)
+	}
+}
@@ -240,7 +240,12 @@ func (h *testAssetHandler) createAsset(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	if req.PurchasePrice != nil {
-		asset.PurchasePrice = req.PurchasePrice
+		price, err := req.PurchasePrice.Parse("")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		asset.PurchasePrice = &price.Amount
 	}
 
 	if err := h.assetRepo.Create(r.Context(), asset); err != nil {
@@ -744,3 +749,102 @@ func Test_GetAssetStats_NoAssets_ReturnsZero(t *testing.T) {
 		t.Errorf("expected total value 0, got %.2f", resp.TotalValue)
 	}
 }
+
+// Tests for facet parsing
+
+func Test_parseFacets_FiltersUnknownAndDuplicates(t *testing.T) {
+	got := parseFacets("category, tags,bogus,category,,location")
+
+	want := []string{"category", "tags", "location"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func Test_parseFacets_Empty_ReturnsNil(t *testing.T) {
+	if got := parseFacets(""); got != nil {
+		t.Errorf("expected nil, got %v", got)
+	}
+}
+
+// Tests for price input
+
+func Test_PriceInput_UnmarshalJSON_AcceptsNumbersAndStrings(t *testing.T) {
+	var req CreateAssetRequest
+	if err := json.Unmarshal([]byte(`{"purchase_price": 12.5}`), &req); err != nil {
+		t.Fatalf("failed to decode number: %v", err)
+	}
+	if price, err := req.PurchasePrice.Parse(""); err != nil || price.Amount != 12.5 {
+		t.Errorf("expected 12.5, got %v (%v)", price.Amount, err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"purchase_price": "1.299,99 €"}`), &req); err != nil {
+		t.Fatalf("failed to decode string: %v", err)
+	}
+	if price, err := req.PurchasePrice.Parse("de-DE"); err != nil || price.Amount != 1299.99 || price.Currency != "EUR" {
+		t.Errorf("expected 1299.99 EUR, got %v %q (%v)", price.Amount, price.Currency, err)
+	}
+}
+
+func Test_requestLocale(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/assets", nil)
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.9,en;q=0.8")
+	if got := requestLocale(req); got != "de-DE" {
+		t.Errorf("expected de-DE, got %q", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/assets?locale=fr-CH", nil)
+	req.Header.Set("Accept-Language", "de-DE")
+	if got := requestLocale(req); got != "fr-CH" {
+		t.Errorf("expected locale parameter to win, got %q", got)
+	}
+}
+
+func Test_applyPrice_SetsCurrencyFromPrice(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/assets", nil)
+	req.Header.Set("Accept-Language", "de-DE")
+	asset := &domain.Asset{}
+
+	if err := applyPrice(req, asset, &PriceInput{text: "1.299,99 €"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if asset.PurchasePrice == nil || *asset.PurchasePrice != 1299.99 {
+		t.Errorf("expected price 1299.99, got %v", asset.PurchasePrice)
+	}
+	if asset.Currency == nil || *asset.Currency != "EUR" {
+		t.Errorf("expected currency EUR, got %v", asset.Currency)
+	}
+}
+
+func Test_applyPrice_CurrencyMismatch_ReturnsError(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/assets", nil)
+	currency := "usd"
+	asset := &domain.Asset{}
+
+	if err := applyPrice(req, asset, &PriceInput{text: "12 €"}, &currency); err == nil {
+		t.Error("expected mismatching currencies to be rejected")
+	}
+}
+
+func Test_applyPrice_NilCurrency_KeepsAssetCurrency(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/api/assets/x", nil)
+	chf := "CHF"
+	amount := 20.0
+	asset := &domain.Asset{Currency: &chf}
+
+	if err := applyPrice(req, asset, &PriceInput{number: &amount}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if asset.Currency == nil || *asset.Currency != "CHF" {
+		t.Errorf("expected currency to be kept, got %v", asset.Currency)
+	}
+
+	if err := applyPrice(req, asset, &PriceInput{text: " "}, nil); err != nil || asset.PurchasePrice != nil {
+		t.Errorf("expected blank price to clear the price, got %v (%v)", asset.PurchasePrice, err)
+	}
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+)
+
+// AssetValueRequest records what one unit of an asset is worth
+type AssetValueRequest struct {
+	Value    *PriceInput `json:"value"`               // Number or localized string, e.g. "1.250 €"
+	ValuedAt *string     `json:"valued_at,omitempty"` // YYYY-MM-DD, defaults to today
+	Note     *string     `json:"note,omitempty"`      // e.g. where the value comes from
+}
+
+const (
+	// defaultValueHistoryDays is how far back the value history goes by default
+	defaultValueHistoryDays = 365
+
+	// maxValueHistoryDays caps how far back the value history goes
+	maxValueHistoryDays = 3650
+)
+
+// ListAssetValues returns the valuations of an asset, latest first
+func (h *Handler) ListAssetValues(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	values, err := h.repos.Values.ListByAsset(r.Context(), asset.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list asset values")
+		return
+	}
+	writeJSON(w, http.StatusOK, values)
+}
+
+// CreateAssetValue records a valuation of an asset; the latest one is its
+// current value
+func (h *Handler) CreateAssetValue(w http.ResponseWriter, r *http.Request) {
+	var req AssetValueRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Value == nil {
+		writeError(w, http.StatusBadRequest, "value is required")
+		return
+	}
+	value, err := req.Value.Parse(requestLocale(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid value: "+err.Error())
+		return
+	}
+
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+	// Values are kept in the asset's currency, as its purchase price
+	if value.Currency != "" && asset.Currency != nil && *asset.Currency != value.Currency {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("value currency %s does not match asset currency %s", value.Currency, *asset.Currency))
+		return
+	}
+	// Like maintenance, a valuation can't be dated in the future
+	valuedAt, err := parseDoneAt(req.ValuedAt, today(h.location(r)))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid valued_at, expected YYYY-MM-DD not in the future")
+		return
+	}
+
+	entry := &domain.AssetValue{
+		OrganizationID: asset.OrganizationID,
+		AssetID:        asset.ID,
+		Value:          value.Amount,
+		ValuedAt:       valuedAt,
+		Note:           optionalText(req.Note),
+		CreatedBy:      currentUserID(r),
+	}
+	current, err := h.repos.Values.Create(r.Context(), entry)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to record asset value")
+		return
+	}
+	if !sameValue(current, asset.CurrentValue) {
+		h.publish(r, events.AssetUpdated, asset.OrganizationID, asset.ID)
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// DeleteAssetValue removes a valuation of an asset, e.g. a typo; the latest
+// remaining one becomes its current value
+func (h *Handler) DeleteAssetValue(w http.ResponseWriter, r *http.Request) {
+	valueID, err := parseUUID(r, "valueId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid value ID")
+		return
+	}
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	entry, err := h.repos.Values.GetByID(r.Context(), valueID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset value")
+		return
+	}
+	if entry == nil || entry.AssetID != asset.ID {
+		writeError(w, http.StatusNotFound, "asset value not found")
+		return
+	}
+
+	if err := h.repos.Values.Delete(r.Context(), entry); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete asset value")
+		return
+	}
+	h.publish(r, events.AssetUpdated, asset.OrganizationID, asset.ID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetValueHistory returns the number, purchase value and current value of
+// the organization's assets over the last ?days=N days (default 365), to
+// chart the inventory's value over time
+func (h *Handler) GetValueHistory(w http.ResponseWriter, r *http.Request) {
+	days := defaultValueHistoryDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxValueHistoryDays {
+			writeError(w, http.StatusBadRequest, "days must be between 1 and "+strconv.Itoa(maxValueHistoryDays))
+			return
+		}
+		days = n
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+	history, err := h.repos.Assets.History(r.Context(), h.org(r), from, to, valueHistoryStep(days))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get value history")
+		return
+	}
+	writeJSON(w, http.StatusOK, history)
+}
+
+// valueHistoryStep returns the interval between points of a value history
+// over days: daily for up to three months, weekly for up to two years and
+// monthly beyond
+func valueHistoryStep(days int) time.Duration {
+	switch {
+	case days <= 90:
+		return 24 * time.Hour
+	case days <= 730:
+		return 7 * 24 * time.Hour
+	default:
+		return 30 * 24 * time.Hour
+	}
+}
+
+// sameValue reports whether two optional amounts are equal
+func sameValue(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
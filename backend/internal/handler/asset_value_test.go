@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func Test_CreateAssetValue_Validation(t *testing.T) {
+	for name, body := range map[string]string{
+		"invalid JSON":   `{`,
+		"missing value":  `{"note": "eBay"}`,
+		"negative value": `{"value": -10}`,
+		"invalid value":  `{"value": "a lot"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := &Handler{repos: &Repositories{}}
+			id := uuid.NewString()
+			req := withChiURLParam(httptest.NewRequest(http.MethodPost, "/api/assets/"+id+"/values", strings.NewReader(body)), "id", id)
+			rr := httptest.NewRecorder()
+
+			h.CreateAssetValue(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_GetValueHistory_InvalidDays(t *testing.T) {
+	for _, days := range []string{"0", "-1", "x", "3651"} {
+		h := &Handler{repos: &Repositories{}}
+		rr := httptest.NewRecorder()
+
+		h.GetValueHistory(rr, httptest.NewRequest(http.MethodGet, "/api/assets/stats/history?days="+days, nil))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("days=%s: expected 400, got %d", days, rr.Code)
+		}
+	}
+}
+
+func Test_valueHistoryStep(t *testing.T) {
+	day := 24 * time.Hour
+	for days, want := range map[int]time.Duration{30: day, 90: day, 365: 7 * day, 3650: 30 * day} {
+		if got := valueHistoryStep(days); got != want {
+			t.Errorf("%d days: expected %v, got %v", days, want, got)
+		}
+	}
+}
+
+func Test_sameValue(t *testing.T) {
+	a, b, c := 10.0, 10.0, 12.5
+	if !sameValue(nil, nil) || !sameValue(&a, &b) {
+		t.Error("expected equal values to be the same")
+	}
+	if sameValue(&a, nil) || sameValue(nil, &a) || sameValue(&a, &c) {
+		t.Error("expected different values not to be the same")
+	}
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/storage"
 )
 
 const maxUploadSize = 50 * 1024 * 1024 // 50MB
@@ -14,21 +18,27 @@ const maxUploadSize = 50 * 1024 * 1024 // 50MB
 type AttachmentResponse struct {
 	domain.Attachment
 	URL string `json:"url,omitempty"`
+
+	// Set instead of URL while an archived file is being restored
+	Restoring bool `json:"restoring,omitempty"`
 }
 
 func (h *Handler) ListAttachments(w http.ResponseWriter, r *http.Request) {
-	assetID, err := parseUUID(r, "id")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid asset ID")
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
 		return
 	}
 
-	attachments, err := h.repos.Attachments.ListByAsset(r.Context(), assetID)
+	attachments, err := h.repos.Attachments.ListByAsset(r.Context(), asset.ID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list attachments")
 		return
 	}
 
+	if used, err := h.repos.Attachments.TotalSize(r.Context(), h.org(r)); err == nil {
+		writeStorageQuotaHeaders(w, used, h.storageQuota)
+	}
+
 	if attachments == nil {
 		attachments = []domain.Attachment{}
 	}
@@ -37,20 +47,8 @@ func (h *Handler) ListAttachments(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
-	assetID, err := parseUUID(r, "id")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid asset ID")
-		return
-	}
-
-	// Check if asset exists
-	asset, err := h.repos.Assets.GetByID(r.Context(), assetID)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to check asset")
-		return
-	}
-	if asset == nil {
-		writeError(w, http.StatusNotFound, "asset not found")
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
 		return
 	}
 
@@ -70,6 +68,18 @@ func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	// Enforce storage quota
+	used, err := h.repos.Attachments.TotalSize(r.Context(), asset.OrganizationID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check storage quota")
+		return
+	}
+	if h.storageQuota > 0 && used+header.Size > h.storageQuota {
+		writeStorageQuotaHeaders(w, used, h.storageQuota)
+		writeError(w, http.StatusRequestEntityTooLarge, "storage quota exceeded")
+		return
+	}
+
 	// Determine content type
 	contentType := header.Header.Get("Content-Type")
 	if contentType == "" {
@@ -86,7 +96,23 @@ func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	key, err := h.storage.Upload(r.Context(), header.Filename, contentType, file)
+	fileName, fileSize := header.Filename, header.Size
+	var body io.Reader = file
+	converted := h.convertUpload(r.Context(), file, header.Size, contentType)
+	if converted != nil && !h.conversion.KeepOriginals {
+		// Store the converted photo in place of the upload
+		fileName = convertedFileName(fileName, h.conversion.Format)
+		fileSize = int64(len(converted.Data))
+		contentType = converted.ContentType
+		body = bytes.NewReader(converted.Data)
+		converted = nil
+	}
+
+	key, err := h.storage.UploadObject(r.Context(), storage.Object{
+		OrganizationID: asset.OrganizationID,
+		AssetID:        asset.ID,
+		FileName:       fileName,
+	}, contentType, body)
 	if err != nil {
 		slog.Error("failed to upload file to storage", "error", err, "filename", header.Filename)
 		writeError(w, http.StatusInternalServerError, "failed to upload file")
@@ -102,10 +128,10 @@ func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
 
 	// Create attachment record
 	attachment := &domain.Attachment{
-		AssetID:     assetID,
+		AssetID:     asset.ID,
 		FileKey:     key,
-		FileName:    header.Filename,
-		FileSize:    header.Size,
+		FileName:    fileName,
+		FileSize:    fileSize,
 		ContentType: &contentType,
 		Description: desc,
 	}
@@ -116,35 +142,46 @@ func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "failed to save attachment record")
 		return
 	}
+	used += attachment.FileSize
 
-	// Auto-set as main image for any image upload
+	// Auto-set as main image for any image upload, preferring the converted copy
+	mainID := attachment.ID
+	if converted != nil {
+		if c := h.storeConvertedCopy(r, asset, attachment, converted, used); c != nil {
+			mainID = c.ID
+			used += c.FileSize
+		}
+	}
 	if isImageContentType(contentType) {
-		h.repos.Assets.SetMainAttachment(r.Context(), assetID, &attachment.ID)
+		h.repos.Assets.SetMainAttachment(r.Context(), asset.ID, &mainID)
 	}
 
+	writeStorageQuotaHeaders(w, used, h.storageQuota)
 	writeJSON(w, http.StatusCreated, attachment)
 }
 
 func (h *Handler) GetAttachment(w http.ResponseWriter, r *http.Request) {
-	id, err := parseUUID(r, "attachmentId")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid attachment ID")
+	attachment, ok := h.visibleAttachment(w, r)
+	if !ok {
 		return
 	}
 
-	attachment, err := h.repos.Attachments.GetByID(r.Context(), id)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to get attachment")
+	// Generate presigned URL
+	if h.storage == nil {
+		writeError(w, http.StatusServiceUnavailable, "storage not configured")
 		return
 	}
-	if attachment == nil {
-		writeError(w, http.StatusNotFound, "attachment not found")
+
+	ready, err := retrieveFile(r.Context(), h.storage, attachment.FileKey)
+	if err != nil {
+		slog.Error("failed to retrieve archived attachment", "attachment_id", attachment.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to retrieve archived attachment")
 		return
 	}
-
-	// Generate presigned URL
-	if h.storage == nil {
-		writeError(w, http.StatusServiceUnavailable, "storage not configured")
+	if !ready {
+		// Restoring from cold storage takes hours; the client asks again later
+		w.Header().Set("Retry-After", strconv.Itoa(int(archiveRetryAfter.Seconds())))
+		writeJSON(w, http.StatusAccepted, AttachmentResponse{Attachment: *attachment, Restoring: true})
 		return
 	}
 
@@ -153,6 +190,7 @@ func (h *Handler) GetAttachment(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "failed to generate download URL")
 		return
 	}
+	h.recordDownload(r, attachment)
 
 	response := AttachmentResponse{
 		Attachment: *attachment,
@@ -163,19 +201,8 @@ func (h *Handler) GetAttachment(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
-	id, err := parseUUID(r, "attachmentId")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid attachment ID")
-		return
-	}
-
-	attachment, err := h.repos.Attachments.GetByID(r.Context(), id)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to get attachment")
-		return
-	}
-	if attachment == nil {
-		writeError(w, http.StatusNotFound, "attachment not found")
+	attachment, ok := h.visibleAttachment(w, r)
+	if !ok {
 		return
 	}
 
@@ -187,7 +214,7 @@ func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delete from database
-	if err := h.repos.Attachments.Delete(r.Context(), id); err != nil {
+	if err := h.repos.Attachments.Delete(r.Context(), attachment.ID); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to delete attachment")
 		return
 	}
@@ -196,9 +223,8 @@ func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) SetMainAttachment(w http.ResponseWriter, r *http.Request) {
-	assetID, err := parseUUID(r, "id")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid asset ID")
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
 		return
 	}
 
@@ -208,17 +234,6 @@ func (h *Handler) SetMainAttachment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Verify asset exists
-	asset, err := h.repos.Assets.GetByID(r.Context(), assetID)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to check asset")
-		return
-	}
-	if asset == nil {
-		writeError(w, http.StatusNotFound, "asset not found")
-		return
-	}
-
 	// Verify attachment exists and belongs to this asset
 	attachment, err := h.repos.Attachments.GetByID(r.Context(), attachmentID)
 	if err != nil {
@@ -229,7 +244,7 @@ func (h *Handler) SetMainAttachment(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "attachment not found")
 		return
 	}
-	if attachment.AssetID != assetID {
+	if attachment.AssetID != asset.ID {
 		writeError(w, http.StatusBadRequest, "attachment does not belong to this asset")
 		return
 	}
@@ -240,35 +255,51 @@ func (h *Handler) SetMainAttachment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Optionally rotate and crop, stored as an edited copy of the image
+	edit, err := decodeImageEdit(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	mainID := attachmentID
+	var edited *domain.Attachment
+	if edit != nil {
+		edited, err = h.createEditedImage(r, asset, attachment, edit)
+		if err != nil {
+			var editErr *editedImageError
+			if errors.As(err, &editErr) {
+				writeError(w, editErr.status, editErr.message)
+				return
+			}
+			slog.Error("failed to edit image", "attachment_id", attachmentID, "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to edit image")
+			return
+		}
+		mainID = edited.ID
+	}
+
 	// Set as main attachment
-	if err := h.repos.Assets.SetMainAttachment(r.Context(), assetID, &attachmentID); err != nil {
+	if err := h.repos.Assets.SetMainAttachment(r.Context(), asset.ID, &mainID); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to set main attachment")
 		return
 	}
+	h.deleteReplacedEdit(r, asset.MainAttachmentID, mainID)
 
+	if edited != nil {
+		writeJSON(w, http.StatusCreated, edited)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *Handler) ClearMainAttachment(w http.ResponseWriter, r *http.Request) {
-	assetID, err := parseUUID(r, "id")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid asset ID")
-		return
-	}
-
-	// Verify asset exists
-	asset, err := h.repos.Assets.GetByID(r.Context(), assetID)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to check asset")
-		return
-	}
-	if asset == nil {
-		writeError(w, http.StatusNotFound, "asset not found")
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
 		return
 	}
 
 	// Clear main attachment
-	if err := h.repos.Assets.SetMainAttachment(r.Context(), assetID, nil); err != nil {
+	if err := h.repos.Assets.SetMainAttachment(r.Context(), asset.ID, nil); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to clear main attachment")
 		return
 	}
@@ -276,11 +307,63 @@ func (h *Handler) ClearMainAttachment(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// visibleAttachment returns the attachment of the "attachmentId" URL
+// parameter, writing an error response if it is invalid, doesn't exist or
+// belongs to an asset hidden from the caller
+func (h *Handler) visibleAttachment(w http.ResponseWriter, r *http.Request) (*domain.Attachment, bool) {
+	id, err := parseUUID(r, "attachmentId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid attachment ID")
+		return nil, false
+	}
+
+	attachment, err := h.repos.Attachments.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get attachment")
+		return nil, false
+	}
+	if attachment == nil {
+		writeError(w, http.StatusNotFound, "attachment not found")
+		return nil, false
+	}
+
+	hidden, err := h.attachmentHidden(r, attachment)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check asset")
+		return nil, false
+	}
+	if hidden {
+		writeError(w, http.StatusNotFound, "attachment not found")
+		return nil, false
+	}
+	return attachment, true
+}
+
+// attachmentHidden reports whether the asset of attachment is high-value and
+// hidden from the user of r
+func (h *Handler) attachmentHidden(r *http.Request, attachment *domain.Attachment) (bool, error) {
+	asset, err := h.repos.Assets.GetByID(r.Context(), attachment.AssetID)
+	if err != nil {
+		return true, err
+	}
+	return h.assetHidden(r, asset)
+}
+
 func isImageContentType(contentType string) bool {
 	switch contentType {
-	case "image/jpeg", "image/png", "image/gif", "image/webp", "image/svg+xml":
+	case "image/jpeg", "image/png", "image/gif", "image/webp", "image/avif", "image/svg+xml":
 		return true
 	default:
 		return false
 	}
 }
+
+// writeStorageQuotaHeaders reports attachment storage usage; limit and
+// remaining are only sent when a quota is configured
+func writeStorageQuotaHeaders(w http.ResponseWriter, used, quota int64) {
+	w.Header().Set("X-Storage-Quota-Used", strconv.FormatInt(used, 10))
+	if quota > 0 {
+		w.Header().Set("X-Storage-Quota-Limit", strconv.FormatInt(quota, 10))
+		w.Header().Set("X-Storage-Quota-Remaining", strconv.FormatInt(max(quota-used, 0), 10))
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/ratelimit"
+)
+
+// AttachmentDownloadsResponse wraps a page of attachment downloads
+type AttachmentDownloadsResponse struct {
+	Downloads []domain.AttachmentDownload `json:"downloads"`
+	Limit     int                         `json:"limit"`
+	Offset    int                         `json:"offset"`
+}
+
+// recordDownload adds the current user's download of an attachment to its
+// audit trail; a failure is logged and doesn't fail the download
+func (h *Handler) recordDownload(r *http.Request, attachment *domain.Attachment) {
+	download := &domain.AttachmentDownload{
+		AttachmentID: attachment.ID,
+		UserID:       currentUserID(r),
+		IPAddress:    ratelimit.ClientIP(r),
+		UserAgent:    truncate(r.UserAgent(), maxUserAgentLength),
+	}
+	if err := h.repos.Attachments.RecordDownload(r.Context(), download); err != nil {
+		slog.Error("failed to record attachment download", "attachment_id", attachment.ID, "error", err)
+	}
+}
+
+// ListAttachmentDownloads returns who downloaded an attachment and when
+// (admin only)
+func (h *Handler) ListAttachmentDownloads(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "attachmentId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid attachment ID")
+		return
+	}
+
+	attachment, err := h.repos.Attachments.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get attachment")
+		return
+	}
+	if attachment == nil {
+		writeError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+
+	limit, offset := 50, 0
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, 200)
+	}
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	downloads, err := h.repos.Attachments.ListDownloads(r.Context(), attachment.ID, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list downloads")
+		return
+	}
+	if downloads == nil {
+		downloads = []domain.AttachmentDownload{}
+	}
+
+	writeJSON(w, http.StatusOK, AttachmentDownloadsResponse{
+		Downloads: downloads,
+		Limit:     limit,
+		Offset:    offset,
+	})
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/imaging"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+// SetMainImageRequest optionally rotates and crops the main image
+type SetMainImageRequest struct {
+	domain.ImageEdit
+}
+
+// decodeImageEdit reads the optional edit of a main image request; nil means
+// the attachment is used as is
+func decodeImageEdit(r *http.Request) (*domain.ImageEdit, error) {
+	var req SetMainImageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if req.Rotate == 0 && req.Crop == nil {
+		return nil, nil
+	}
+	return &req.ImageEdit, nil
+}
+
+// editedImageError is an error of createEditedImage with the status to respond with
+type editedImageError struct {
+	status  int
+	message string
+}
+
+func (e *editedImageError) Error() string {
+	return e.message
+}
+
+// createEditedImage stores a rotated and cropped copy of an image attachment.
+// Edits are applied to the original, so editing an edited image doesn't
+// compound.
+func (h *Handler) createEditedImage(r *http.Request, asset *domain.Asset, source *domain.Attachment, edit *domain.ImageEdit) (*domain.Attachment, error) {
+	if h.storage == nil {
+		return nil, &editedImageError{http.StatusServiceUnavailable, "storage not configured"}
+	}
+
+	original := source
+	if source.DerivedFromID != nil {
+		att, err := h.repos.Attachments.GetByID(r.Context(), *source.DerivedFromID)
+		if err != nil {
+			return nil, err
+		}
+		if att != nil {
+			original = att
+		}
+	}
+
+	f, err := h.storage.Open(r.Context(), original.FileKey)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(f, imaging.MaxInputSize+1))
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > imaging.MaxInputSize {
+		return nil, &editedImageError{http.StatusUnprocessableEntity, "image too large to edit"}
+	}
+
+	var crop image.Rectangle
+	if edit.Crop != nil {
+		if edit.Crop.Width <= 0 || edit.Crop.Height <= 0 {
+			return nil, &editedImageError{http.StatusBadRequest, "crop width and height must be positive"}
+		}
+		crop = image.Rect(edit.Crop.X, edit.Crop.Y, edit.Crop.X+edit.Crop.Width, edit.Crop.Y+edit.Crop.Height)
+	}
+	edited, err := imaging.Apply(r.Context(), data, derefString(original.ContentType), imaging.Edit{Rotate: edit.Rotate, Crop: crop})
+	switch {
+	case errors.Is(err, imaging.ErrUnsupported):
+		return nil, &editedImageError{http.StatusUnsupportedMediaType, "only JPEG, PNG and GIF images can be edited"}
+	case errors.Is(err, imaging.ErrInvalidEdit):
+		return nil, &editedImageError{http.StatusBadRequest, "rotate must be 0, 90, 180 or 270 and the crop inside the rotated image"}
+	case err != nil:
+		return nil, err
+	}
+
+	used, err := h.repos.Attachments.TotalSize(r.Context(), asset.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if h.storageQuota > 0 && used+int64(len(edited.Data)) > h.storageQuota {
+		return nil, &editedImageError{http.StatusRequestEntityTooLarge, "storage quota exceeded"}
+	}
+
+	fileName := editedFileName(original.FileName, edited.ContentType)
+	key, err := h.storage.UploadObject(r.Context(), storage.Object{
+		OrganizationID: asset.OrganizationID,
+		AssetID:        asset.ID,
+		FileName:       fileName,
+	}, edited.ContentType, bytes.NewReader(edited.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &domain.Attachment{
+		AssetID:       asset.ID,
+		UploadedBy:    currentUserID(r),
+		FileKey:       key,
+		FileName:      fileName,
+		FileSize:      int64(len(edited.Data)),
+		ContentType:   &edited.ContentType,
+		DerivedFromID: &original.ID,
+		Edit:          edit,
+	}
+	if err := h.repos.Attachments.Create(r.Context(), attachment); err != nil {
+		h.storage.Delete(r.Context(), key)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// deleteReplacedEdit removes an edited main image once another image replaced
+// it; converted copies of uploads are kept
+func (h *Handler) deleteReplacedEdit(r *http.Request, previousID *uuid.UUID, current uuid.UUID) {
+	if previousID == nil || *previousID == current {
+		return
+	}
+	previous, err := h.repos.Attachments.GetByID(r.Context(), *previousID)
+	if err != nil || previous == nil || previous.DerivedFromID == nil || previous.Edit == nil {
+		return
+	}
+	if err := h.repos.Attachments.Delete(r.Context(), previous.ID); err != nil {
+		slog.Error("failed to delete replaced image edit", "attachment_id", previous.ID, "error", err)
+		return
+	}
+	if h.storage != nil {
+		if err := h.storage.Delete(r.Context(), previous.FileKey); err != nil {
+			slog.Error("failed to delete replaced image edit file", "key", previous.FileKey, "error", err)
+		}
+	}
+}
+
+// editedFileName names the edited copy of an image, e.g. "photo-edited.jpg"
+func editedFileName(name, contentType string) string {
+	ext := ".png"
+	if contentType == "image/jpeg" {
+		ext = ".jpg"
+	}
+	return strings.TrimSuffix(name, path.Ext(name)) + "-edited" + ext
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_decodeImageEdit(t *testing.T) {
+	tests := map[string]bool{
+		"":              false,
+		"{}":            false,
+		`{"rotate":0}`:  false,
+		`{"rotate":90}`: true,
+		`{"crop":{"x":1,"y":2,"width":3,"height":4}}`: true,
+	}
+	for body, wantEdit := range tests {
+		r := httptest.NewRequest("PUT", "/", strings.NewReader(body))
+		edit, err := decodeImageEdit(r)
+		if err != nil {
+			t.Fatalf("decodeImageEdit(%q) error = %v", body, err)
+		}
+		if (edit != nil) != wantEdit {
+			t.Errorf("decodeImageEdit(%q) = %+v, want edit: %v", body, edit, wantEdit)
+		}
+	}
+
+	r := httptest.NewRequest("PUT", "/", strings.NewReader("{"))
+	if _, err := decodeImageEdit(r); err == nil {
+		t.Error("decodeImageEdit() with invalid JSON should fail")
+	}
+}
+
+func Test_editedFileName(t *testing.T) {
+	tests := []struct{ name, contentType, want string }{
+		{"photo.jpeg", "image/jpeg", "photo-edited.jpg"},
+		{"scan.gif", "image/png", "scan-edited.png"},
+		{"noext", "image/png", "noext-edited.png"},
+	}
+	for _, tt := range tests {
+		if got := editedFileName(tt.name, tt.contentType); got != tt.want {
+			t.Errorf("editedFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
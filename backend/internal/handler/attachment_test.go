@@ -8,12 +8,14 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/storage"
 )
 
 // mockAttachmentRepo implements attachment repository for testing
@@ -107,6 +109,10 @@ func (m *mockStorage) Upload(ctx context.Context, filename, contentType string,
 	return key, nil
 }
 
+func (m *mockStorage) UploadObject(ctx context.Context, obj storage.Object, contentType string, reader io.Reader) (string, error) {
+	return m.Upload(ctx, obj.FileName, contentType, reader)
+}
+
 func (m *mockStorage) Delete(ctx context.Context, key string) error {
 	if m.deleteErr != nil {
 		return m.deleteErr
@@ -115,6 +121,14 @@ func (m *mockStorage) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+func (m *mockStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := m.files[key]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
 func (m *mockStorage) GetPresignedURL(ctx context.Context, key string, duration time.Duration) (string, error) {
 	if m.presignedErr != nil {
 		return "", m.presignedErr
@@ -695,3 +709,64 @@ func Test_DeleteAttachment_NoStorage_StillDeletesFromDB(t *testing.T) {
 		t.Error("expected attachment to be deleted from repository")
 	}
 }
+
+// Tests for storage quota headers
+
+func Test_writeStorageQuotaHeaders_NoQuota_OnlyReportsUsage(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	writeStorageQuotaHeaders(rec, 1024, 0)
+
+	if rec.Header().Get("X-Storage-Quota-Used") != "1024" {
+		t.Errorf("expected used 1024, got %q", rec.Header().Get("X-Storage-Quota-Used"))
+	}
+	if rec.Header().Get("X-Storage-Quota-Limit") != "" {
+		t.Error("expected no limit header without a quota")
+	}
+}
+
+func Test_writeStorageQuotaHeaders_WithQuota_ReportsRemaining(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	writeStorageQuotaHeaders(rec, 1500, 1000)
+
+	if rec.Header().Get("X-Storage-Quota-Limit") != "1000" {
+		t.Errorf("expected limit 1000, got %q", rec.Header().Get("X-Storage-Quota-Limit"))
+	}
+	if rec.Header().Get("X-Storage-Quota-Remaining") != "0" {
+		t.Errorf("expected remaining clamped to 0, got %q", rec.Header().Get("X-Storage-Quota-Remaining"))
+	}
+}
+
+// mockArchiveStorage is a storage with an archive tier that is still restoring
+type mockArchiveStorage struct {
+	*mockStorage
+	retrieved []string
+}
+
+func (m *mockArchiveStorage) Archive(ctx context.Context, key string) (string, error) {
+	return "archive/" + key, nil
+}
+
+func (m *mockArchiveStorage) Retrieve(ctx context.Context, key string) (bool, error) {
+	m.retrieved = append(m.retrieved, key)
+	return false, nil
+}
+
+func Test_retrieveFile(t *testing.T) {
+	ctx := context.Background()
+	if ready, err := retrieveFile(ctx, newMockStorage(), "archive/a/b.pdf"); err != nil || !ready {
+		t.Errorf("expected storages without an archive tier to be ready, got %v, %v", ready, err)
+	}
+
+	archive := &mockArchiveStorage{mockStorage: newMockStorage()}
+	if ready, err := retrieveFile(ctx, archive, "a/b.pdf"); err != nil || !ready {
+		t.Errorf("expected unarchived files to be ready, got %v, %v", ready, err)
+	}
+	if ready, err := retrieveFile(ctx, archive, "archive/a/b.pdf"); err != nil || ready {
+		t.Errorf("expected archived file to be restoring, got %v, %v", ready, err)
+	}
+	if len(archive.retrieved) != 1 || archive.retrieved[0] != "archive/a/b.pdf" {
+		t.Errorf("expected a restore of the archived file only, got %v", archive.retrieved)
+	}
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/textextract"
+)
+
+// textExtractionBatchSize is the number of attachments processed per run
+const textExtractionBatchSize = 20
+
+// ExtractAttachmentText extracts the text of attachments uploaded since the
+// last run (PDF manuals, receipts, text files) so asset search matches their
+// contents. Files without extractable text are marked as processed too.
+func (h *Handler) ExtractAttachmentText(ctx context.Context) error {
+	if h.storage == nil {
+		return nil
+	}
+	return h.forEachOrganization(ctx, func(orgID uuid.UUID) error {
+		return h.extractOrganizationText(ctx, orgID)
+	})
+}
+
+// extractOrganizationText extracts the text of an organization's pending
+// attachments
+func (h *Handler) extractOrganizationText(ctx context.Context, orgID uuid.UUID) error {
+	pending, err := h.repos.Attachments.ListTextPending(ctx, orgID, textExtractionBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, att := range pending {
+		if !textextract.Supported(derefString(att.ContentType), att.FileName) {
+			if err := h.repos.Attachments.SetText(ctx, att.ID, nil); err != nil {
+				return err
+			}
+			continue
+		}
+
+		text, err := h.extractAttachmentText(ctx, att)
+		if err != nil {
+			// Storage errors are likely transient, retry on the next run
+			return err
+		}
+		if err := h.repos.Attachments.SetText(ctx, att.ID, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractAttachmentText returns the text of a stored attachment, or nil if
+// the document could not be parsed or holds no text
+func (h *Handler) extractAttachmentText(ctx context.Context, att domain.Attachment) (*string, error) {
+	f, err := h.storage.Open(ctx, att.FileKey)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	text, err := textextract.Extract(f, derefString(att.ContentType), att.FileName)
+	if err != nil {
+		slog.Warn("failed to extract attachment text", "attachment_id", att.ID, "error", err)
+		return nil, nil
+	}
+	if text == "" {
+		return nil, nil
+	}
+	return &text, nil
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/lmmendes/attic/internal/imaging"
+)
+
+// defaultThumbnailSize is the thumbnail edge in pixels when no size is requested
+const defaultThumbnailSize = 256
+
+// GetAttachmentThumbnail returns an image attachment scaled down to fit
+// ?size= pixels (default 256, at most 1024)
+func (h *Handler) GetAttachmentThumbnail(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "attachmentId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid attachment ID")
+		return
+	}
+
+	size := defaultThumbnailSize
+	if s := r.URL.Query().Get("size"); s != "" {
+		size, err = strconv.Atoi(s)
+		if err != nil || size < 1 || size > imaging.MaxThumbnailSize {
+			writeError(w, http.StatusBadRequest, "size must be between 1 and "+strconv.Itoa(imaging.MaxThumbnailSize))
+			return
+		}
+	}
+
+	if h.storage == nil || h.images == nil {
+		writeError(w, http.StatusServiceUnavailable, "storage not configured")
+		return
+	}
+
+	attachment, err := h.repos.Attachments.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get attachment")
+		return
+	}
+	if attachment == nil {
+		writeError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+	hidden, err := h.attachmentHidden(r, attachment)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check asset")
+		return
+	}
+	if hidden {
+		writeError(w, http.StatusNotFound, "attachment not found")
+		return
+	}
+
+	contentType := derefString(attachment.ContentType)
+	if !h.images.Supports(contentType) {
+		writeError(w, http.StatusUnsupportedMediaType, "no thumbnail available for this file type")
+		return
+	}
+
+	f, err := h.storage.Open(r.Context(), attachment.FileKey)
+	if err != nil {
+		slog.Error("failed to open attachment", "attachment_id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to read attachment")
+		return
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, imaging.MaxInputSize+1))
+	if err != nil {
+		slog.Error("failed to read attachment", "attachment_id", id, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to read attachment")
+		return
+	}
+	if len(data) > imaging.MaxInputSize {
+		writeError(w, http.StatusUnprocessableEntity, "image too large for a thumbnail")
+		return
+	}
+
+	thumb, err := h.images.Thumbnail(r.Context(), data, contentType, size)
+	if err != nil {
+		if !errors.Is(err, imaging.ErrUnsupported) {
+			slog.Warn("failed to create thumbnail", "attachment_id", id, "processor", h.images.Name(), "error", err)
+		}
+		writeError(w, http.StatusUnprocessableEntity, "failed to create thumbnail")
+		return
+	}
+
+	w.Header().Set("Content-Type", thumb.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(thumb.Data)))
+	// Attachments never change, only get deleted
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Write(thumb.Data)
+}
@@ -21,7 +21,7 @@ type UpdateAttributeRequest struct {
 
 // ListAttributes returns all attributes for the organization
 func (h *Handler) ListAttributes(w http.ResponseWriter, r *http.Request) {
-	attributes, err := h.repos.Attributes.List(r.Context(), h.orgID)
+	attributes, err := h.repos.Attributes.List(r.Context(), h.org(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list attributes")
 		return
@@ -81,7 +81,7 @@ func (h *Handler) CreateAttribute(w http.ResponseWriter, r *http.Request) {
 	}
 
 	attr := &domain.Attribute{
-		OrganizationID: h.orgID,
+		OrganizationID: h.org(r),
 		Name:           req.Name,
 		Key:            req.Key,
 		DataType:       req.DataType,
@@ -1,30 +1,53 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/ratelimit"
 	"github.com/lmmendes/attic/internal/repository"
+	"github.com/lmmendes/attic/internal/secrets"
 )
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	userRepo          *repository.UserRepository
-	sessionManager    *auth.SessionManager
-	passwordMinLength int
-	oidcEnabled       bool
-	oauthHandler      *auth.OAuthHandler
+	userRepo       *repository.UserRepository
+	sessionManager *auth.SessionManager
+	passwordPolicy auth.PasswordPolicy
+	oidcEnabled    bool
+	oauthHandler   *auth.OAuthHandler
+	proxyAuth      *auth.ProxyAuth // nil = no reverse-proxy header authentication
+	audit          *LoginAudit     // nil = login attempts are not recorded
+	verifier       *EmailVerifier  // nil = email verification disabled
+
+	// Attempts per account (see SetLoginLimits); nil = not limited
+	passwordLimit *ratelimit.Limiter // Passwords, by email
+	codeLimit     *ratelimit.Limiter // Two-factor codes, by the user of the challenge
+
+	// Two-factor authentication (see SetTwoFactor)
+	settings domain.SettingsRepository // nil = 2FA cannot be required org-wide
+	secrets  *secrets.Box              // nil = TOTP enrollment unavailable
+
+	webauthn *auth.WebAuthn // nil = passkeys unavailable (see SetPasskeys)
+
+	registrationOrgID uuid.UUID                    // uuid.Nil = self-registration disabled
+	invites           *repository.InviteRepository // nil = no sign-up with invite links
 }
 
 // NewAuthHandler creates a new auth handler
 func NewAuthHandler(userRepo *repository.UserRepository, sessionManager *auth.SessionManager, passwordMinLength int, oidcEnabled bool) *AuthHandler {
 	return &AuthHandler{
-		userRepo:          userRepo,
-		sessionManager:    sessionManager,
-		passwordMinLength: passwordMinLength,
-		oidcEnabled:       oidcEnabled,
+		userRepo:       userRepo,
+		sessionManager: sessionManager,
+		passwordPolicy: auth.PasswordPolicy{MinLength: passwordMinLength},
+		oidcEnabled:    oidcEnabled,
 	}
 }
 
@@ -33,6 +56,52 @@ func (h *AuthHandler) SetOAuthHandler(oauthHandler *auth.OAuthHandler) {
 	h.oauthHandler = oauthHandler
 }
 
+// SetProxyAuth reports sessions from trusted reverse-proxy headers
+func (h *AuthHandler) SetProxyAuth(proxyAuth *auth.ProxyAuth) {
+	h.proxyAuth = proxyAuth
+}
+
+// SetPasswordPolicy sets the requirements enforced for new passwords
+func (h *AuthHandler) SetPasswordPolicy(policy auth.PasswordPolicy) {
+	h.passwordPolicy = policy
+}
+
+// SetLoginAudit enables recording of login attempts
+func (h *AuthHandler) SetLoginAudit(audit *LoginAudit) {
+	h.audit = audit
+}
+
+// SetLoginLimits throttles guessing the password of an account and the
+// two-factor codes of a user, wherever the attempts come from
+func (h *AuthHandler) SetLoginLimits(passwords, codes *ratelimit.Limiter) {
+	h.passwordLimit = passwords
+	h.codeLimit = codes
+}
+
+// allowAttempt checks a login attempt for key against limiter (nil = not
+// limited), answering 429 when over the limit
+func allowAttempt(w http.ResponseWriter, limiter *ratelimit.Limiter, key string) bool {
+	return limiter == nil || limiter.Check(w, "X-RateLimit", key)
+}
+
+// recordEvent records an authentication event of a user other than a login
+func (h *AuthHandler) recordEvent(r *http.Request, userID uuid.UUID, eventType domain.AuthEventType) {
+	if h.audit == nil {
+		return
+	}
+	user, err := h.userRepo.GetByID(r.Context(), userID)
+	if err != nil || user == nil {
+		return
+	}
+	h.audit.RecordEvent(r, user, eventType)
+}
+
+func (h *AuthHandler) recordLogin(r *http.Request, user *domain.User, email string, method domain.LoginMethod, failureReason string) {
+	if h.audit != nil {
+		h.audit.Record(r, user, email, method, failureReason)
+	}
+}
+
 // LoginRequest represents login credentials
 type LoginRequest struct {
 	Email    string `json:"email"`
@@ -56,6 +125,9 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "email and password are required")
 		return
 	}
+	if !allowAttempt(w, h.passwordLimit, "email:"+strings.ToLower(strings.TrimSpace(req.Email))) {
+		return
+	}
 
 	user, err := h.userRepo.GetByEmail(r.Context(), req.Email)
 	if err != nil {
@@ -65,28 +137,91 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if user == nil {
+		h.recordLogin(r, nil, req.Email, domain.LoginMethodPassword, loginFailureUnknownUser)
 		writeError(w, http.StatusUnauthorized, "invalid email or password")
 		return
 	}
 
 	if !user.HasPassword() {
+		h.recordLogin(r, user, req.Email, domain.LoginMethodPassword, loginFailureNoPassword)
 		writeError(w, http.StatusUnauthorized, "invalid email or password")
 		return
 	}
 
 	if !auth.CheckPassword(req.Password, *user.PasswordHash) {
+		h.recordLogin(r, user, req.Email, domain.LoginMethodPassword, loginFailureInvalidPassword)
 		writeError(w, http.StatusUnauthorized, "invalid email or password")
 		return
 	}
 
-	if err := h.sessionManager.CreateSession(w, r, user); err != nil {
+	if !user.IsActive() {
+		h.recordLogin(r, user, req.Email, domain.LoginMethodPassword, loginFailureDisabled)
+		writeError(w, http.StatusForbidden, "account disabled")
+		return
+	}
+
+	if user.IsPending() {
+		h.recordLogin(r, user, req.Email, domain.LoginMethodPassword, loginFailurePending)
+		writeError(w, http.StatusForbidden, "account awaiting approval")
+		return
+	}
+
+	if h.verifier.Blocks(user) {
+		h.recordLogin(r, user, req.Email, domain.LoginMethodPassword, loginFailureUnverified)
+		writeError(w, http.StatusForbidden, "email address not verified")
+		return
+	}
+
+	// Passwords set before the policy was tightened must be replaced
+	if err := h.passwordPolicy.Validate(req.Password); err != nil {
+		if err := h.userRepo.RequirePasswordChange(r.Context(), user.ID); err != nil {
+			slog.Error("failed to require password change", "error", err)
+		}
+	}
+
+	// Accounts with 2FA (or required to enroll) continue with a second step
+	purpose, err := h.secondFactor(r.Context(), user)
+	if err != nil {
+		slog.Error("failed to check two-factor authentication", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if purpose == auth.ChallengeTwoFactorSetup && tokenMode(r) {
+		writeError(w, http.StatusForbidden, "set up two-factor authentication in the web app first")
+		return
+	}
+	if purpose != "" {
+		h.writeChallenge(w, user, purpose)
+		return
+	}
+
+	h.completeLogin(w, r, user, req.Email, domain.LoginMethodPassword, nil)
+}
+
+// completeLogin creates the session of a fully authenticated user (or the
+// tokens of an API client) and writes the login response, merging extra
+// fields into it
+func (h *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, user *domain.User, email string, method domain.LoginMethod, extra map[string]any) {
+	var tokens *auth.TokenPair
+	var err error
+	if tokenMode(r) {
+		tokens, err = h.sessionManager.IssueTokens(r, user)
+		if errors.Is(err, auth.ErrTokensUnavailable) {
+			writeError(w, http.StatusNotImplemented, err.Error())
+			return
+		}
+	} else {
+		err = h.sessionManager.CreateSession(w, r, user)
+	}
+	if err != nil {
 		slog.Error("failed to create session", "error", err)
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
+	h.recordLogin(r, user, email, method, "")
+
+	response := map[string]any{
 		"success": true,
 		"user": map[string]any{
 			"id":    user.ID.String(),
@@ -94,18 +229,41 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 			"name":  user.DisplayName,
 			"role":  user.Role,
 		},
-	})
+	}
+	if tokens != nil {
+		response["access_token"] = tokens.AccessToken
+		response["token_type"] = tokens.TokenType
+		response["expires_in"] = tokens.ExpiresIn
+		response["refresh_token"] = tokens.RefreshToken
+		response["refresh_expires_at"] = tokens.RefreshExpiresAt
+	}
+	for k, v := range extra {
+		response[k] = v
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
 }
 
-// Logout clears the session
+// Logout clears and revokes the session
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	h.sessionManager.ClearSession(w)
+	if session, err := h.sessionManager.GetSession(r); err == nil {
+		h.recordEvent(r, session.UserID, domain.AuthEventLogout)
+	}
+	if err := h.sessionManager.EndSession(w, r); err != nil {
+		slog.Error("failed to revoke session", "error", err)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{"success": true})
 }
 
 // GetSession returns current session info
 func (h *AuthHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	if h.proxyAuth != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(h.proxySessionInfo(r))
+		return
+	}
 	if h.oidcEnabled && h.oauthHandler != nil {
 		info := h.oauthHandler.GetSessionInfo(r)
 
@@ -130,6 +288,29 @@ func (h *AuthHandler) GetSession(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(info)
 }
 
+// proxySessionInfo describes the user signed in at the reverse proxy. The
+// user is provisioned on their first API request, so id and role may be missing.
+func (h *AuthHandler) proxySessionInfo(r *http.Request) map[string]any {
+	claims, err := h.proxyAuth.Claims(r)
+	if err != nil {
+		return map[string]any{"authenticated": false, "oidc_enabled": false, "proxy_auth": true}
+	}
+
+	user := map[string]string{
+		"sub":   claims.Subject,
+		"email": claims.Email,
+		"name":  claims.Name,
+	}
+	if user["name"] == "" {
+		user["name"] = claims.DisplayName
+	}
+	if dbUser, err := h.userRepo.GetByOIDCSubject(r.Context(), claims.Subject); err == nil && dbUser != nil {
+		user["role"] = string(dbUser.Role)
+		user["id"] = dbUser.ID.String()
+	}
+	return map[string]any{"authenticated": true, "oidc_enabled": false, "proxy_auth": true, "user": user}
+}
+
 // ChangePasswordRequest represents a password change request
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password"`
@@ -160,7 +341,7 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := auth.ValidatePassword(req.NewPassword, h.passwordMinLength); err != nil {
+	if err := h.passwordPolicy.Validate(req.NewPassword); err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -176,6 +357,16 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := checkPasswordReuse(r.Context(), h.userRepo, h.passwordPolicy, user, req.NewPassword); err != nil {
+		if errors.Is(err, auth.ErrPasswordReused) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.Error("failed to check password history", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
 	hash, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
 		slog.Error("failed to hash password", "error", err)
@@ -189,14 +380,50 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Sign out other browsers that may have used the old password
+	if _, err := h.userRepo.DeleteSessions(r.Context(), user.ID, session.ID); err != nil {
+		slog.Error("failed to revoke other sessions", "error", err)
+	}
+	if h.audit != nil {
+		h.audit.RecordEvent(r, user, domain.AuthEventPasswordChange)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{"success": true})
 }
 
 // GetAuthMode returns the current authentication mode
 func (h *AuthHandler) GetAuthMode(w http.ResponseWriter, r *http.Request) {
+	providers := []auth.ProviderInfo{}
+	if h.oidcEnabled && h.oauthHandler != nil {
+		providers = h.oauthHandler.Providers()
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{
-		"oidc_enabled": h.oidcEnabled,
+		"oidc_enabled":         h.oidcEnabled,
+		"oidc_providers":       providers,
+		"proxy_auth":           h.proxyAuth != nil,
+		"passkeys_enabled":     h.webauthn != nil && !h.oidcEnabled,
+		"registration_enabled": h.registrationEnabled(),
+		"password_policy":      h.passwordPolicy,
 	})
 }
+
+// checkPasswordReuse rejects passwords matching the user's current or recent passwords
+func checkPasswordReuse(ctx context.Context, userRepo *repository.UserRepository, policy auth.PasswordPolicy, user *domain.User, password string) error {
+	if policy.HistorySize <= 0 {
+		return nil
+	}
+
+	var hashes []string
+	if user.HasPassword() {
+		hashes = append(hashes, *user.PasswordHash)
+	}
+	history, err := userRepo.PasswordHistory(ctx, user.ID, policy.HistorySize)
+	if err != nil {
+		return err
+	}
+
+	return policy.CheckReuse(password, append(hashes, history...))
+}
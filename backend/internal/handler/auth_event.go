@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// AuthEventsResponse wraps a page of authentication events
+type AuthEventsResponse struct {
+	Events []domain.AuthEvent `json:"events"`
+	Limit  int                `json:"limit"`
+	Offset int                `json:"offset"`
+}
+
+// ListAuthEvents returns the organization's authentication audit log (admin
+// only), filtered by user_id, type and the time range from (inclusive) to
+// (exclusive) in RFC 3339
+func (h *Handler) ListAuthEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuthEventFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := h.repos.AuthEvents.List(r.Context(), h.org(r), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list auth events")
+		return
+	}
+	if events == nil {
+		events = []domain.AuthEvent{}
+	}
+
+	writeJSON(w, http.StatusOK, AuthEventsResponse{
+		Events: events,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+func parseAuthEventFilter(q url.Values) (domain.AuthEventFilter, error) {
+	filter := domain.AuthEventFilter{Limit: 50}
+	if limit, err := strconv.Atoi(q.Get("limit")); err == nil && limit > 0 {
+		filter.Limit = min(limit, 200)
+	}
+	if offset, err := strconv.Atoi(q.Get("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+
+	if v := q.Get("user_id"); v != "" {
+		userID, err := uuid.Parse(v)
+		if err != nil {
+			return filter, errors.New("invalid user ID")
+		}
+		filter.UserID = &userID
+	}
+	if v := q.Get("type"); v != "" {
+		filter.Type = domain.AuthEventType(v)
+		if !filter.Type.Valid() {
+			return filter, errors.New("type must be login, login_failed, logout, password_change or password_reset")
+		}
+	}
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, errors.New(name + " must be an RFC 3339 time")
+			}
+			*dst = &t
+		}
+	}
+	return filter, nil
+}
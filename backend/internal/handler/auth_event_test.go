@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_parseAuthEventFilter(t *testing.T) {
+	q, _ := url.ParseQuery("user_id=6f1c2a4e-8f0b-4c7e-9a51-3d2b1c0e9f88&type=logout&from=2026-01-01T00:00:00Z&to=2026-02-01T00:00:00Z&limit=500")
+	filter, err := parseAuthEventFilter(q)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filter.UserID == nil || filter.Type != domain.AuthEventLogout || filter.Limit != 200 {
+		t.Errorf("unexpected filter %+v", filter)
+	}
+	if filter.From == nil || filter.To == nil || !filter.From.Before(*filter.To) {
+		t.Errorf("expected the time range, got %v - %v", filter.From, filter.To)
+	}
+
+	for _, invalid := range []string{"user_id=x", "type=signup", "from=yesterday", "to=2026-02-01"} {
+		q, _ := url.ParseQuery(invalid)
+		if _, err := parseAuthEventFilter(q); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+
+	filter, _ = parseAuthEventFilter(url.Values{})
+	if filter.Limit != 50 || filter.UserID != nil || filter.From != nil {
+		t.Errorf("unexpected default filter %+v", filter)
+	}
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+const maxLogoSize = 2 * 1024 * 1024 // 2MB
+
+// brandingLogoPath is the public, stable URL of the organization logo
+const brandingLogoPath = "/api/branding/logo"
+
+var (
+	hexColorPattern   = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+	logoContentTypes  = map[string]bool{"image/png": true, "image/jpeg": true, "image/webp": true, "image/svg+xml": true, "image/gif": true}
+	defaultBrandTitle = "Attic"
+)
+
+type UpdateBrandingRequest struct {
+	Title       string `json:"title"`
+	AccentColor string `json:"accent_color"`
+}
+
+// BrandingResponse is the public view of an organization's branding
+type BrandingResponse struct {
+	Title       string `json:"title"`
+	AccentColor string `json:"accent_color,omitempty"`
+	LogoURL     string `json:"logo_url,omitempty"`
+}
+
+// GetBranding returns the organization branding (no auth required, used by the login page)
+func (h *Handler) GetBranding(w http.ResponseWriter, r *http.Request) {
+	branding, err := h.loadBranding(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toBrandingResponse(branding))
+}
+
+// GetBrandingLogo redirects to the current logo file (no auth required)
+func (h *Handler) GetBrandingLogo(w http.ResponseWriter, r *http.Request) {
+	branding, err := h.loadBranding(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+	if branding.LogoKey == nil || h.storage == nil {
+		writeError(w, http.StatusNotFound, "logo not found")
+		return
+	}
+
+	url, err := h.storage.GetPresignedURL(r.Context(), *branding.LogoKey, time.Hour)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate logo URL")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+func (h *Handler) UpdateBranding(w http.ResponseWriter, r *http.Request) {
+	var req UpdateBrandingRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.AccentColor != "" && !hexColorPattern.MatchString(req.AccentColor) {
+		writeError(w, http.StatusBadRequest, "accent_color must be a hex color like #4f46e5")
+		return
+	}
+
+	branding, err := h.loadBranding(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+
+	branding.Title = req.Title
+	branding.AccentColor = req.AccentColor
+
+	if err := h.repos.Settings.Set(r.Context(), h.org(r), domain.SettingBranding, branding); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update branding")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toBrandingResponse(branding))
+}
+
+func (h *Handler) UploadBrandingLogo(w http.ResponseWriter, r *http.Request) {
+	if h.storage == nil {
+		writeError(w, http.StatusServiceUnavailable, "storage not configured")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxLogoSize+1024)
+	if err := r.ParseMultipartForm(maxLogoSize); err != nil {
+		writeError(w, http.StatusBadRequest, "file too large or invalid form")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "missing file in request")
+		return
+	}
+	defer file.Close()
+
+	contentType := header.Header.Get("Content-Type")
+	if !logoContentTypes[contentType] {
+		writeError(w, http.StatusBadRequest, "logo must be a PNG, JPEG, WebP, GIF or SVG image")
+		return
+	}
+
+	branding, err := h.loadBranding(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+
+	key, err := h.storage.UploadObject(r.Context(), storage.Object{
+		OrganizationID: h.org(r),
+		Kind:           "branding",
+		FileName:       "branding-" + header.Filename,
+	}, contentType, file)
+	if err != nil {
+		slog.Error("failed to upload logo", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to upload logo")
+		return
+	}
+
+	previous := branding.LogoKey
+	branding.LogoKey = &key
+	if err := h.repos.Settings.Set(r.Context(), h.org(r), domain.SettingBranding, branding); err != nil {
+		h.storage.Delete(r.Context(), key)
+		writeError(w, http.StatusInternalServerError, "failed to update branding")
+		return
+	}
+	if previous != nil {
+		h.storage.Delete(r.Context(), *previous)
+	}
+
+	writeJSON(w, http.StatusOK, toBrandingResponse(branding))
+}
+
+func (h *Handler) DeleteBrandingLogo(w http.ResponseWriter, r *http.Request) {
+	branding, err := h.loadBranding(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+	if branding.LogoKey == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	key := *branding.LogoKey
+	branding.LogoKey = nil
+	if err := h.repos.Settings.Set(r.Context(), h.org(r), domain.SettingBranding, branding); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update branding")
+		return
+	}
+	if h.storage != nil {
+		h.storage.Delete(r.Context(), key)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// loadBranding returns the organization branding, or empty branding if unset
+func (h *Handler) loadBranding(ctx context.Context, orgID uuid.UUID) (*domain.Branding, error) {
+	var branding domain.Branding
+	if _, err := h.repos.Settings.Get(ctx, orgID, domain.SettingBranding, &branding); err != nil {
+		return nil, err
+	}
+	return &branding, nil
+}
+
+func toBrandingResponse(b *domain.Branding) BrandingResponse {
+	resp := BrandingResponse{
+		Title:       b.Title,
+		AccentColor: b.AccentColor,
+	}
+	if resp.Title == "" {
+		resp.Title = defaultBrandTitle
+	}
+	if b.LogoKey != nil {
+		resp.LogoURL = brandingLogoPath
+	}
+	return resp
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_toBrandingResponse_Defaults(t *testing.T) {
+	resp := toBrandingResponse(&domain.Branding{})
+
+	if resp.Title != "Attic" {
+		t.Errorf("expected default title 'Attic', got %q", resp.Title)
+	}
+	if resp.LogoURL != "" {
+		t.Errorf("expected no logo URL, got %q", resp.LogoURL)
+	}
+}
+
+func Test_toBrandingResponse_WithLogo(t *testing.T) {
+	key := "branding-logo.png"
+	resp := toBrandingResponse(&domain.Branding{Title: "Hackerspace", AccentColor: "#ff0000", LogoKey: &key})
+
+	if resp.Title != "Hackerspace" || resp.AccentColor != "#ff0000" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.LogoURL != brandingLogoPath {
+		t.Errorf("expected logo URL %q, got %q", brandingLogoPath, resp.LogoURL)
+	}
+}
+
+func Test_hexColorPattern(t *testing.T) {
+	valid := []string{"#fff", "#4f46e5", "#ABCDEF"}
+	invalid := []string{"fff", "#ffff", "red", "#gggggg", "#4f46e5; background:url(x)"}
+
+	for _, c := range valid {
+		if !hexColorPattern.MatchString(c) {
+			t.Errorf("expected %q to be valid", c)
+		}
+	}
+	for _, c := range invalid {
+		if hexColorPattern.MatchString(c) {
+			t.Errorf("expected %q to be invalid", c)
+		}
+	}
+}
+
+func Test_printListTemplate_UsesBranding(t *testing.T) {
+	key := "logo.png"
+	data := printListData{
+		List:     SharedListResponse{Name: "Camping gear"},
+		Branding: toBrandingResponse(&domain.Branding{Title: "Hackerspace", AccentColor: "#ff0000", LogoKey: &key}),
+		Nonce:    "abc123",
+	}
+
+	var buf strings.Builder
+	if err := printListTemplate.Execute(&buf, data); err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"Hackerspace", "#ff0000", brandingLogoPath, "Camping gear", `nonce="abc123"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q", want)
+		}
+	}
+}
@@ -37,9 +37,9 @@ func (h *Handler) ListCategories(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	if tree {
-		categories, err = h.repos.Categories.ListTree(r.Context(), h.orgID)
+		categories, err = h.repos.Categories.ListTree(r.Context(), h.org(r))
 	} else {
-		categories, err = h.repos.Categories.List(r.Context(), h.orgID)
+		categories, err = h.repos.Categories.List(r.Context(), h.org(r))
 	}
 
 	if err != nil {
@@ -55,7 +55,7 @@ func (h *Handler) ListCategories(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetCategoryAssetCounts(w http.ResponseWriter, r *http.Request) {
-	counts, err := h.repos.Categories.GetAssetCounts(r.Context(), h.orgID)
+	counts, err := h.repos.Categories.GetAssetCounts(r.Context(), h.org(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to get asset counts")
 		return
@@ -96,7 +96,7 @@ func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cat := &domain.Category{
-		OrganizationID: h.orgID,
+		OrganizationID: h.org(r),
 		Name:           req.Name,
 		Description:    req.Description,
 		Icon:           req.Icon,
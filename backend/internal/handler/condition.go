@@ -21,7 +21,7 @@ type UpdateConditionRequest struct {
 }
 
 func (h *Handler) ListConditions(w http.ResponseWriter, r *http.Request) {
-	conditions, err := h.repos.Conditions.List(r.Context(), h.orgID)
+	conditions, err := h.repos.Conditions.List(r.Context(), h.org(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list conditions")
 		return
@@ -67,7 +67,7 @@ func (h *Handler) CreateCondition(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cond := &domain.Condition{
-		OrganizationID: h.orgID,
+		OrganizationID: h.org(r),
 		Code:           req.Code,
 		Label:          req.Label,
 		Description:    req.Description,
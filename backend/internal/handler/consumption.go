@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+)
+
+// ConsumptionRequest sets how fast a consumable is used up, e.g. "amount": 1,
+// "interval_count": 3, "interval_unit": "month" for one every 3 months
+type ConsumptionRequest struct {
+	Amount        int                    `json:"amount"`
+	IntervalCount int                    `json:"interval_count"`
+	IntervalUnit  domain.MaintenanceUnit `json:"interval_unit"`     // day, week, month or year
+	NextAt        *string                `json:"next_at,omitempty"` // YYYY-MM-DD, defaults to one interval from today
+}
+
+// GetConsumption returns the consumption schedule of an asset
+func (h *Handler) GetConsumption(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.repos.Consumption.GetByAsset(r.Context(), asset.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get consumption schedule")
+		return
+	}
+	if schedule == nil {
+		writeError(w, http.StatusNotFound, "consumption schedule not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+// UpdateConsumption creates or replaces the consumption schedule of an asset
+func (h *Handler) UpdateConsumption(w http.ResponseWriter, r *http.Request) {
+	var req ConsumptionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	schedule, err := consumptionFromRequest(&req, today(h.location(r)))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	schedule.OrganizationID = asset.OrganizationID
+	schedule.AssetID = asset.ID
+	if err := h.repos.Consumption.Upsert(r.Context(), schedule); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save consumption schedule")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+func (h *Handler) DeleteConsumption(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Consumption.Delete(r.Context(), asset.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete consumption schedule")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// consumptionFromRequest validates req; day is today, from which the first
+// decrement is counted
+func consumptionFromRequest(req *ConsumptionRequest, day time.Time) (*domain.ConsumptionSchedule, error) {
+	if req.Amount < 1 || req.Amount > maxAssetQuantity {
+		return nil, fmt.Errorf("amount must be between 1 and %d", maxAssetQuantity)
+	}
+	if req.IntervalCount < 1 {
+		return nil, errors.New("interval_count must be at least 1")
+	}
+	if !req.IntervalUnit.Valid() {
+		return nil, fmt.Errorf("invalid interval_unit %q, expected day, week, month or year", req.IntervalUnit)
+	}
+
+	schedule := &domain.ConsumptionSchedule{Amount: req.Amount, IntervalCount: req.IntervalCount, IntervalUnit: req.IntervalUnit}
+	y, m, d := day.Date()
+	todayUTC := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if req.NextAt == nil || *req.NextAt == "" {
+		schedule.NextAt = req.IntervalUnit.After(todayUTC, req.IntervalCount)
+		return schedule, nil
+	}
+	// Stored as a DATE, the location doesn't change the day
+	next, err := parseDate(*req.NextAt, time.UTC)
+	if err != nil {
+		return nil, errors.New("invalid next_at, expected YYYY-MM-DD")
+	}
+	if next.Before(todayUTC) {
+		return nil, errors.New("next_at can't be in the past")
+	}
+	schedule.NextAt = next
+	return schedule, nil
+}
+
+// RunConsumption takes the amounts of due consumption schedules from their
+// assets' quantities; a single run catches up on intervals missed while down
+func (h *Handler) RunConsumption(ctx context.Context) error {
+	return h.forEachOrganization(ctx, func(orgID uuid.UUID) error {
+		return h.runConsumption(ctx, orgID)
+	})
+}
+
+func (h *Handler) runConsumption(ctx context.Context, orgID uuid.UUID) error {
+	zone, err := h.orgTimeZone(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	y, m, d := today(loadLocation(zone)).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	due, err := h.repos.Consumption.ListDue(ctx, orgID, day)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		schedule := &due[i]
+		n, next := schedule.Due(day)
+		if n == 0 {
+			continue
+		}
+		// No more than any quantity can hold, however long the job was down
+		amount := min(n*schedule.Amount, maxAssetQuantity)
+		applied, err := h.repos.Consumption.Apply(ctx, schedule, amount, day, next)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			continue
+		}
+		slog.Info("applied consumption schedule", "asset_id", schedule.AssetID, "amount", amount)
+		h.events.Publish(ctx, events.Event{Type: events.AssetUpdated, OrganizationID: orgID, SubjectID: schedule.AssetID})
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_consumptionFromRequest(t *testing.T) {
+	day := time.Date(2025, 1, 31, 0, 0, 0, 0, time.Local)
+
+	schedule, err := consumptionFromRequest(&ConsumptionRequest{Amount: 1, IntervalCount: 1, IntervalUnit: domain.MaintenanceMonth}, day)
+	if err != nil || !schedule.NextAt.Equal(*utcDate(2025, 2, 28)) {
+		t.Errorf("expected the first decrement a month from today, got %+v, %v", schedule, err)
+	}
+
+	next := "2025-03-01"
+	schedule, err = consumptionFromRequest(&ConsumptionRequest{Amount: 2, IntervalCount: 3, IntervalUnit: domain.MaintenanceMonth, NextAt: &next}, day)
+	if err != nil || !schedule.NextAt.Equal(*utcDate(2025, 3, 1)) || schedule.Amount != 2 {
+		t.Errorf("expected the given next date, got %+v, %v", schedule, err)
+	}
+
+	past := "2025-01-30"
+	invalid := map[string]ConsumptionRequest{
+		"no amount":        {IntervalCount: 1, IntervalUnit: domain.MaintenanceMonth},
+		"too much":         {Amount: maxAssetQuantity + 1, IntervalCount: 1, IntervalUnit: domain.MaintenanceMonth},
+		"no interval":      {Amount: 1, IntervalUnit: domain.MaintenanceMonth},
+		"unknown unit":     {Amount: 1, IntervalCount: 1, IntervalUnit: "fortnight"},
+		"next in the past": {Amount: 1, IntervalCount: 1, IntervalUnit: domain.MaintenanceWeek, NextAt: &past},
+	}
+	for name, req := range invalid {
+		if _, err := consumptionFromRequest(&req, day); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
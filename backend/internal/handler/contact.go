@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// ContactRequest creates or replaces a contact
+type ContactRequest struct {
+	Name            string  `json:"name"`
+	Email           *string `json:"email,omitempty"`
+	Phone           *string `json:"phone,omitempty"`
+	Notes           *string `json:"notes,omitempty"`
+	ReturnReminders bool    `json:"return_reminders"` // Requires an email
+}
+
+// ListContacts returns the organization's contacts, filtered with ?q= by
+// name, email or phone
+func (h *Handler) ListContacts(w http.ResponseWriter, r *http.Request) {
+	contacts, err := h.repos.Contacts.List(r.Context(), h.org(r), strings.TrimSpace(r.URL.Query().Get("q")))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list contacts")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, contacts)
+}
+
+func (h *Handler) GetContact(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contact ID")
+		return
+	}
+
+	contact, err := h.repos.Contacts.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get contact")
+		return
+	}
+	if contact == nil {
+		writeError(w, http.StatusNotFound, "contact not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, contact)
+}
+
+func (h *Handler) CreateContact(w http.ResponseWriter, r *http.Request) {
+	var req ContactRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	contact := &domain.Contact{OrganizationID: h.org(r)}
+	if err := applyContact(contact, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.repos.Contacts.Create(r.Context(), contact); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create contact")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, contact)
+}
+
+func (h *Handler) UpdateContact(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contact ID")
+		return
+	}
+
+	var req ContactRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	contact, err := h.repos.Contacts.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get contact")
+		return
+	}
+	if contact == nil {
+		writeError(w, http.StatusNotFound, "contact not found")
+		return
+	}
+
+	if err := applyContact(contact, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.repos.Contacts.Update(r.Context(), contact); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update contact")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, contact)
+}
+
+func (h *Handler) DeleteContact(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contact ID")
+		return
+	}
+
+	if err := h.repos.Contacts.Delete(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete contact")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyContact validates req and copies it to contact
+func applyContact(contact *domain.Contact, req *ContactRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	email := optionalText(req.Email)
+	if email != nil {
+		addr, err := mail.ParseAddress(*email)
+		if err != nil || addr.Address != *email {
+			return errors.New("invalid email")
+		}
+	}
+	if req.ReturnReminders && email == nil {
+		return errors.New("return reminders require an email")
+	}
+
+	contact.Name = name
+	contact.Email = email
+	contact.Phone = optionalText(req.Phone)
+	contact.Notes = req.Notes
+	contact.ReturnReminders = req.ReturnReminders
+	return nil
+}
+
+// optionalText trims s, returning nil if nothing is left
+func optionalText(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_applyContact(t *testing.T) {
+	email, blank, invalid := " ana@example.com ", "  ", "Ana <ana@example.com>"
+
+	tests := []struct {
+		name    string
+		req     ContactRequest
+		wantErr bool
+	}{
+		{"name only", ContactRequest{Name: "Ana"}, false},
+		{"with email and reminders", ContactRequest{Name: "Ana", Email: &email, ReturnReminders: true}, false},
+		{"no name", ContactRequest{Name: " ", Email: &email}, true},
+		{"invalid email", ContactRequest{Name: "Ana", Email: &invalid}, true},
+		{"reminders without email", ContactRequest{Name: "Ana", Email: &blank, ReturnReminders: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contact := &domain.Contact{}
+			err := applyContact(contact, &tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	contact := &domain.Contact{}
+	applyContact(contact, &ContactRequest{Name: " Ana ", Email: &email, Phone: &blank})
+	if contact.Name != "Ana" || contact.Email == nil || *contact.Email != "ana@example.com" || contact.Phone != nil {
+		t.Errorf("expected trimmed fields, got %+v", contact)
+	}
+}
+
+func Test_CreateContact_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `{`},
+		{"missing name", `{"email": "ana@example.com"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{repos: &Repositories{}}
+			req := httptest.NewRequest(http.MethodPost, "/api/contacts", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			h.CreateContact(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", rr.Code)
+			}
+		})
+	}
+}
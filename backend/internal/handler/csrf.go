@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/lmmendes/attic/internal/auth"
+)
+
+// CSRFTokenResponse is the token clients send in the X-CSRF-Token header of
+// state-changing requests authenticated by the session cookie
+type CSRFTokenResponse struct {
+	Token  string `json:"csrf_token"`
+	Header string `json:"header"`
+}
+
+// CSRFToken issues a new CSRF token, also set as the attic_csrf cookie. Same-site
+// clients can read the cookie instead; cross-origin ones need this endpoint.
+func (h *AuthHandler) CSRFToken(w http.ResponseWriter, r *http.Request) {
+	claims := auth.GetClaims(r.Context())
+	if claims == nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, CSRFTokenResponse{
+		Token:  h.sessionManager.CSRFToken(w, r, claims.Subject),
+		Header: auth.CSRFHeader,
+	})
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/auth"
+)
+
+func Test_AuthHandler_CSRFToken(t *testing.T) {
+	h := NewAuthHandler(nil, auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24), 8, false)
+
+	w := httptest.NewRecorder()
+	h.CSRFToken(w, httptest.NewRequest(http.MethodGet, "/api/auth/csrf", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without claims, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/csrf", nil)
+	req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, &auth.Claims{Subject: "user-1"}))
+	w = httptest.NewRecorder()
+	h.CSRFToken(w, req)
+
+	var resp CSRFTokenResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || resp.Token == "" || resp.Header != auth.CSRFHeader {
+		t.Fatalf("unexpected response %d %+v", w.Code, resp)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Value != resp.Token {
+		t.Errorf("expected the token as cookie, got %+v", cookies)
+	}
+}
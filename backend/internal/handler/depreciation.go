@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// DepreciationRequest sets how an asset, or the assets of a category, lose
+// value
+type DepreciationRequest struct {
+	Method           domain.DepreciationMethod `json:"method"` // straight_line or declining_balance
+	UsefulLifeMonths int                       `json:"useful_life_months"`
+	SalvageValue     *PriceInput               `json:"salvage_value,omitempty"` // Number or localized string, defaults to 0
+}
+
+// Where the depreciation rule of an asset comes from
+const (
+	depreciationFromAsset    = "asset"
+	depreciationFromCategory = "category" // The asset's category or one of its parents
+)
+
+// AssetDepreciationResponse is the depreciation rule that applies to an
+// asset and the asset's current book value
+type AssetDepreciationResponse struct {
+	domain.Depreciation
+	Source    string   `json:"source"`               // asset or category
+	BookValue *float64 `json:"book_value,omitempty"` // Per unit, nil without purchase price and date
+}
+
+// GetAssetDepreciation returns the depreciation rule that applies to an
+// asset, its own or its category's
+func (h *Handler) GetAssetDepreciation(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := h.depreciationRules(r.Context(), asset.OrganizationID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get depreciation")
+		return
+	}
+	rule := rules.forAsset(asset)
+	if rule == nil {
+		writeError(w, http.StatusNotFound, "depreciation not found")
+		return
+	}
+
+	response := AssetDepreciationResponse{
+		Depreciation: *rule,
+		Source:       depreciationFromCategory,
+		BookValue:    rules.bookValue(asset, time.Now()),
+	}
+	if rule.AssetID != nil {
+		response.Source = depreciationFromAsset
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// UpdateAssetDepreciation creates or replaces the asset's own depreciation
+// rule, which takes precedence over its category's
+func (h *Handler) UpdateAssetDepreciation(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	rule, ok := h.decodeDepreciation(w, r)
+	if !ok {
+		return
+	}
+	rule.OrganizationID = asset.OrganizationID
+	rule.AssetID = &asset.ID
+	if err := h.repos.Depreciation.Upsert(r.Context(), rule); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save depreciation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// DeleteAssetDepreciation removes the asset's own depreciation rule; its
+// category's applies again, if any
+func (h *Handler) DeleteAssetDepreciation(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Depreciation.DeleteByAsset(r.Context(), asset.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete depreciation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCategoryDepreciation returns the category's own depreciation rule
+func (h *Handler) GetCategoryDepreciation(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid category ID")
+		return
+	}
+
+	rule, err := h.repos.Depreciation.GetByCategory(r.Context(), categoryID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get depreciation")
+		return
+	}
+	if rule == nil {
+		writeError(w, http.StatusNotFound, "depreciation not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// UpdateCategoryDepreciation creates or replaces the depreciation rule of a
+// category, which applies to its assets and those of its subcategories
+// without a rule of their own
+func (h *Handler) UpdateCategoryDepreciation(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid category ID")
+		return
+	}
+
+	category, err := h.repos.Categories.GetByID(r.Context(), categoryID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check category")
+		return
+	}
+	if category == nil {
+		writeError(w, http.StatusNotFound, "category not found")
+		return
+	}
+
+	rule, ok := h.decodeDepreciation(w, r)
+	if !ok {
+		return
+	}
+	rule.OrganizationID = category.OrganizationID
+	rule.CategoryID = &category.ID
+	if err := h.repos.Depreciation.Upsert(r.Context(), rule); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save depreciation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rule)
+}
+
+func (h *Handler) DeleteCategoryDepreciation(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid category ID")
+		return
+	}
+
+	if err := h.repos.Depreciation.DeleteByCategory(r.Context(), categoryID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete depreciation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeDepreciation decodes and validates a DepreciationRequest, writing an
+// error response if it is invalid
+func (h *Handler) decodeDepreciation(w http.ResponseWriter, r *http.Request) (*domain.Depreciation, bool) {
+	var req DepreciationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return nil, false
+	}
+	rule, err := depreciationFromRequest(&req, requestLocale(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return rule, true
+}
+
+func depreciationFromRequest(req *DepreciationRequest, locale string) (*domain.Depreciation, error) {
+	if !req.Method.Valid() {
+		return nil, fmt.Errorf("invalid method %q, expected straight_line or declining_balance", req.Method)
+	}
+	if req.UsefulLifeMonths < 1 {
+		return nil, errors.New("useful_life_months must be at least 1")
+	}
+	rule := &domain.Depreciation{Method: req.Method, UsefulLifeMonths: req.UsefulLifeMonths}
+	if req.SalvageValue != nil {
+		salvage, err := req.SalvageValue.Parse(locale)
+		if err != nil {
+			return nil, fmt.Errorf("invalid salvage_value: %w", err)
+		}
+		rule.SalvageValue = salvage.Amount
+	}
+	return rule, nil
+}
+
+// depreciationRules are the depreciation rules of an organization, by asset
+// and by category
+type depreciationRules struct {
+	byAsset    map[uuid.UUID]*domain.Depreciation
+	byCategory map[uuid.UUID]*domain.Depreciation
+	parents    map[uuid.UUID]uuid.UUID // Parent of each subcategory
+}
+
+func (h *Handler) depreciationRules(ctx context.Context, orgID uuid.UUID) (*depreciationRules, error) {
+	rules, err := h.repos.Depreciation.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	var categories []domain.Category
+	if len(rules) > 0 {
+		if categories, err = h.repos.Categories.List(ctx, orgID); err != nil {
+			return nil, err
+		}
+	}
+	return newDepreciationRules(rules, categories), nil
+}
+
+func newDepreciationRules(rules []domain.Depreciation, categories []domain.Category) *depreciationRules {
+	d := &depreciationRules{
+		byAsset:    map[uuid.UUID]*domain.Depreciation{},
+		byCategory: map[uuid.UUID]*domain.Depreciation{},
+		parents:    map[uuid.UUID]uuid.UUID{},
+	}
+	for i := range rules {
+		switch rule := &rules[i]; {
+		case rule.AssetID != nil:
+			d.byAsset[*rule.AssetID] = rule
+		case rule.CategoryID != nil:
+			d.byCategory[*rule.CategoryID] = rule
+		}
+	}
+	for _, c := range categories {
+		if c.ParentID != nil {
+			d.parents[c.ID] = *c.ParentID
+		}
+	}
+	return d
+}
+
+// forAsset returns the rule that applies to an asset: its own, else that of
+// the closest category up from its own. It is nil if there is none.
+func (d *depreciationRules) forAsset(a *domain.Asset) *domain.Depreciation {
+	if rule, ok := d.byAsset[a.ID]; ok {
+		return rule
+	}
+	seen := map[uuid.UUID]bool{}
+	for id, ok := a.CategoryID, true; ok && !seen[id]; id, ok = d.parents[id] {
+		if rule, ok := d.byCategory[id]; ok {
+			return rule
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// bookValue returns what one unit of an asset is worth on day, or nil if it
+// has no depreciation rule, purchase price or purchase date
+func (d *depreciationRules) bookValue(a *domain.Asset, day time.Time) *float64 {
+	if a.PurchasePrice == nil || a.PurchaseAt == nil {
+		return nil
+	}
+	rule := d.forAsset(a)
+	if rule == nil {
+		return nil
+	}
+	value := rule.BookValue(*a.PurchasePrice, *a.PurchaseAt, day)
+	return &value
+}
+
+// totalBookValue returns the total value of the organization's held, active
+// assets, as GetTotalValue, with depreciated assets at their book value
+func (h *Handler) totalBookValue(ctx context.Context, orgID uuid.UUID, totalValue float64) (float64, error) {
+	rules, err := h.depreciationRules(ctx, orgID)
+	if err != nil || len(rules.byAsset)+len(rules.byCategory) == 0 {
+		return totalValue, err
+	}
+	assets, err := h.repos.Depreciation.ListPricedAssets(ctx, orgID)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	for i := range assets {
+		if value := rules.bookValue(&assets[i], now); value != nil {
+			totalValue -= (*assets[i].PurchasePrice - *value) * float64(assets[i].Quantity)
+		}
+	}
+	return round2(totalValue), nil
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_depreciationRules_forAsset(t *testing.T) {
+	electronics, computers, laptops, books := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	ownID := uuid.New()
+	categoryRule := domain.Depreciation{CategoryID: &electronics, Method: domain.DepreciationStraightLine, UsefulLifeMonths: 60}
+	assetRule := domain.Depreciation{AssetID: &ownID, Method: domain.DepreciationDecliningBalance, UsefulLifeMonths: 36}
+	rules := newDepreciationRules(
+		[]domain.Depreciation{categoryRule, assetRule},
+		[]domain.Category{
+			{ID: electronics},
+			{ID: computers, ParentID: &electronics},
+			{ID: laptops, ParentID: &computers},
+			{ID: books},
+		},
+	)
+
+	if rule := rules.forAsset(&domain.Asset{ID: uuid.New(), CategoryID: laptops}); rule == nil || rule.CategoryID == nil || *rule.CategoryID != electronics {
+		t.Errorf("expected the rule of the parent category, got %+v", rule)
+	}
+	if rule := rules.forAsset(&domain.Asset{ID: ownID, CategoryID: laptops}); rule == nil || rule.AssetID == nil {
+		t.Errorf("expected the asset's own rule, got %+v", rule)
+	}
+	if rule := rules.forAsset(&domain.Asset{ID: uuid.New(), CategoryID: books}); rule != nil {
+		t.Errorf("expected no rule, got %+v", rule)
+	}
+
+	// A cycle in the category tree doesn't loop forever
+	a, b := uuid.New(), uuid.New()
+	cyclic := newDepreciationRules(nil, []domain.Category{{ID: a, ParentID: &b}, {ID: b, ParentID: &a}})
+	if rule := cyclic.forAsset(&domain.Asset{ID: uuid.New(), CategoryID: a}); rule != nil {
+		t.Errorf("expected no rule, got %+v", rule)
+	}
+}
+
+func Test_depreciationRules_bookValue(t *testing.T) {
+	category := uuid.New()
+	rules := newDepreciationRules([]domain.Depreciation{
+		{CategoryID: &category, Method: domain.DepreciationStraightLine, UsefulLifeMonths: 48},
+	}, nil)
+	price := 1000.0
+	purchased := utcDate(2024, 1, 1)
+	day := purchased.Add(2 * 365.25 * 24 * time.Hour)
+
+	if v := rules.bookValue(&domain.Asset{CategoryID: category, PurchasePrice: &price, PurchaseAt: purchased}, day); v == nil || *v != 500 {
+		t.Errorf("expected half the price after half the useful life, got %v", v)
+	}
+	if v := rules.bookValue(&domain.Asset{CategoryID: category, PurchasePrice: &price}, day); v != nil {
+		t.Errorf("expected no book value without purchase date, got %v", *v)
+	}
+}
+
+func Test_UpdateAssetDepreciation_Validation(t *testing.T) {
+	for name, body := range map[string]string{
+		"invalid JSON":        `{`,
+		"unknown method":      `{"method": "sum_of_years", "useful_life_months": 12}`,
+		"missing useful life": `{"method": "straight_line"}`,
+		"negative salvage":    `{"method": "straight_line", "useful_life_months": 12, "salvage_value": -5}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/api/categories/x/depreciation", strings.NewReader(body))
+
+			_, ok := (&Handler{}).decodeDepreciation(rr, req)
+
+			if ok || rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_depreciationFromRequest_SalvageValue(t *testing.T) {
+	salvage := &PriceInput{text: "1.250,50 €"}
+	rule, err := depreciationFromRequest(&DepreciationRequest{Method: domain.DepreciationStraightLine, UsefulLifeMonths: 60, SalvageValue: salvage}, "de-DE")
+	if err != nil || rule.SalvageValue != 1250.5 {
+		t.Errorf("expected a salvage value of 1250.5, got %+v, %v", rule, err)
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/links"
+	"github.com/lmmendes/attic/internal/mail"
+	"github.com/lmmendes/attic/internal/repository"
+)
+
+// Login failure reason of accounts that must verify their email first
+const loginFailureUnverified = "unverified"
+
+// EmailVerifier sends verification links to new (or changed) email addresses
+type EmailVerifier struct {
+	userRepo       *repository.UserRepository
+	sessionManager *auth.SessionManager
+	settings       domain.SettingsRepository
+	mailer         mail.Mailer
+	links          *links.Builder
+	required       bool // Unverified accounts can't sign in
+}
+
+// NewEmailVerifier creates an email verifier
+func NewEmailVerifier(userRepo *repository.UserRepository, sessionManager *auth.SessionManager, settings domain.SettingsRepository, mailer mail.Mailer, builder *links.Builder) *EmailVerifier {
+	return &EmailVerifier{
+		userRepo:       userRepo,
+		sessionManager: sessionManager,
+		settings:       settings,
+		mailer:         mailer,
+		links:          builder,
+	}
+}
+
+// SetRequired blocks logins of accounts that haven't verified their email
+func (v *EmailVerifier) SetRequired(required bool) {
+	v.required = required
+}
+
+// Blocks reports whether user may not sign in until they verify their email
+func (v *EmailVerifier) Blocks(user *domain.User) bool {
+	return v != nil && v.required && !user.IsVerified()
+}
+
+// Send mails a verification link to the user's current address in the background
+func (v *EmailVerifier) Send(user *domain.User) {
+	if user.IsVerified() {
+		return
+	}
+	token, err := v.sessionManager.CreateEmailVerification(user.ID, user.Email)
+	if err != nil {
+		slog.Error("failed to create email verification", "user_id", user.ID, "error", err)
+		return
+	}
+	link := v.links.URL("/auth/verify-email?token=" + url.QueryEscape(token))
+
+	// Don't delay the response on the mail server
+	go func(user domain.User) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		title := defaultBrandTitle
+		var branding domain.Branding
+		if found, err := v.settings.Get(ctx, user.OrganizationID, domain.SettingBranding, &branding); err == nil && found && branding.Title != "" {
+			title = branding.Title
+		}
+
+		if err := v.mailer.Send(ctx, verificationEmail(title, &user, link)); err != nil {
+			slog.Error("failed to send verification email", "user_id", user.ID, "error", err)
+		}
+	}(*user)
+}
+
+// verificationEmail builds the email asking a user to confirm their address
+func verificationEmail(title string, user *domain.User, link string) mail.Message {
+	text := fmt.Sprintf(`Please confirm that %s is your email address for %s by opening
+this link:
+
+%s
+
+The link is valid for 3 days. If you didn't expect this email, you can ignore it.
+`, user.Email, title, link)
+
+	return mail.Message{
+		To:      []string{user.Email},
+		Subject: fmt.Sprintf("[%s] Verify your email address", title),
+		Text:    text,
+	}
+}
+
+// SetEmailVerifier enables email verification for password and passkey logins
+func (h *AuthHandler) SetEmailVerifier(verifier *EmailVerifier) {
+	h.verifier = verifier
+}
+
+// VerifyEmail confirms an email address from the link in a verification email
+// and redirects to the login page
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	userID, email, err := h.sessionManager.VerifyEmailVerification(r.URL.Query().Get("token"))
+	if err != nil {
+		http.Redirect(w, r, "/login?verified=invalid", http.StatusSeeOther)
+		return
+	}
+
+	ok, err := h.userRepo.MarkVerified(r.Context(), userID, email)
+	if err != nil {
+		slog.Error("failed to verify email", "user_id", userID, "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !ok {
+		// The address was changed (or the user deleted) after the email was sent
+		http.Redirect(w, r, "/login?verified=invalid", http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, "/login?verified=1", http.StatusSeeOther)
+}
+
+// SetEmailVerifier sends verification emails to created users and changed addresses
+func (h *UserManagementHandler) SetEmailVerifier(verifier *EmailVerifier) {
+	h.verifier = verifier
+}
+
+// ResendVerification sends a new verification email to an unverified user
+func (h *UserManagementHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	if h.verifier == nil {
+		writeError(w, http.StatusBadRequest, "email verification requires email to be configured")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if user == nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if user.IsVerified() {
+		writeError(w, http.StatusConflict, "email address already verified")
+		return
+	}
+
+	h.verifier.Send(user)
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_verificationEmail_BuildsMessage(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Email: "user@example.com"}
+
+	msg := verificationEmail("Hackerspace", user, "https://attic.example.com/auth/verify-email?token=abc")
+
+	if len(msg.To) != 1 || msg.To[0] != "user@example.com" {
+		t.Errorf("unexpected recipients: %v", msg.To)
+	}
+	if !strings.Contains(msg.Subject, "Hackerspace") {
+		t.Errorf("unexpected subject: %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "https://attic.example.com/auth/verify-email?token=abc") {
+		t.Error("expected body to contain the verification link")
+	}
+}
+
+func Test_EmailVerifier_Blocks(t *testing.T) {
+	now := time.Now()
+	verified := &domain.User{VerifiedAt: &now}
+	unverified := &domain.User{}
+
+	var disabled *EmailVerifier
+	if disabled.Blocks(unverified) {
+		t.Error("expected no blocking without a verifier")
+	}
+
+	v := &EmailVerifier{}
+	if v.Blocks(unverified) {
+		t.Error("expected no blocking unless verification is required")
+	}
+
+	v.SetRequired(true)
+	if !v.Blocks(unverified) {
+		t.Error("expected unverified user to be blocked")
+	}
+	if v.Blocks(verified) {
+		t.Error("expected verified user not to be blocked")
+	}
+}
+
+func Test_AuthHandler_VerifyEmail_InvalidToken(t *testing.T) {
+	h := NewAuthHandler(nil, auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24), 8, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/verify-email?token=forged", nil)
+	w := httptest.NewRecorder()
+	h.VerifyEmail(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("expected 303, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/login?verified=invalid" {
+		t.Errorf("unexpected redirect %q", loc)
+	}
+}
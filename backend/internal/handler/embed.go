@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lmmendes/attic/internal/security"
+)
+
+const (
+	defaultEmbedItems = 24  // Items shown by a widget without ?limit
+	maxEmbedItems     = 100 // Largest ?limit of a widget
+
+	defaultEmbedWidth  = 600
+	defaultEmbedHeight = 400
+)
+
+// embedData is the view model of an embeddable collection widget
+type embedData struct {
+	Title  string
+	Layout string // "grid" or "list"
+	Items  []embedItem
+	More   int // Items not shown because of the limit
+}
+
+type embedItem struct {
+	Name     string
+	Detail   string // Category, quantity and (if shared) price
+	ImageURL string
+}
+
+// OEmbedResponse is an oEmbed "rich" response (https://oembed.com)
+type OEmbedResponse struct {
+	Version      string `json:"version"`
+	Type         string `json:"type"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	Title        string `json:"title"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	CacheAge     int    `json:"cache_age"`
+}
+
+// EmbedPublicGallery renders the public gallery as a widget for iframes on
+// other sites (no auth required)
+func (h *Handler) EmbedPublicGallery(w http.ResponseWriter, r *http.Request) {
+	gallery, ok := h.loadPublicGallery(w, r)
+	if !ok {
+		return
+	}
+	response, err := h.galleryResponse(r, gallery)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list assets")
+		return
+	}
+
+	data := embedData{Title: response.Title, Items: make([]embedItem, len(response.Items))}
+	for i, item := range response.Items {
+		data.Items[i] = embedItem{Name: item.Name, Detail: item.Category, ImageURL: item.ImageURL}
+	}
+	writeEmbed(w, r, data)
+}
+
+// EmbedSharedList renders a shared list as a widget for iframes on other
+// sites (no auth required)
+func (h *Handler) EmbedSharedList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.sharedList(r, chi.URLParam(r, "token"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get list")
+		return
+	}
+	if list == nil {
+		writeError(w, http.StatusNotFound, "list not found")
+		return
+	}
+
+	data := embedData{Title: list.Name, Items: make([]embedItem, len(list.Items))}
+	for i, item := range list.Items {
+		details := []string{}
+		if item.Category != "" {
+			details = append(details, item.Category)
+		}
+		if item.Quantity > 1 {
+			details = append(details, "×"+strconv.Itoa(item.Quantity))
+		}
+		if item.PurchasePrice != nil {
+			details = append(details, strconv.FormatFloat(*item.PurchasePrice, 'f', 2, 64))
+		}
+		data.Items[i] = embedItem{Name: item.Name, Detail: strings.Join(details, " · "), ImageURL: item.ImageURL}
+	}
+	writeEmbed(w, r, data)
+}
+
+// GetOEmbed describes how to embed a public gallery or shared list URL
+// (?url=), so blogs and forums that support oEmbed can embed it from a link
+func (h *Handler) GetOEmbed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if format := query.Get("format"); format != "" && format != "json" {
+		writeError(w, http.StatusNotImplemented, "only the json format is supported")
+		return
+	}
+
+	target, err := url.Parse(query.Get("url"))
+	if err != nil || query.Get("url") == "" {
+		writeError(w, http.StatusBadRequest, "url is required")
+		return
+	}
+	embedPath, kind, key := oembedTarget(target.Path)
+
+	var title string
+	switch kind {
+	case "gallery":
+		gallery, err := h.enabledGallery(r.Context(), key)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get public gallery")
+			return
+		}
+		if gallery != nil {
+			response, err := h.galleryResponse(r, gallery)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to list assets")
+				return
+			}
+			title = response.Title
+		}
+	case "list":
+		list, err := h.sharedList(r, key)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get list")
+			return
+		}
+		if list != nil {
+			title = list.Name
+		}
+	}
+	if title == "" {
+		writeError(w, http.StatusNotFound, "nothing to embed at this url")
+		return
+	}
+
+	branding, err := h.loadBranding(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+
+	width := embedSize(query.Get("maxwidth"), defaultEmbedWidth)
+	height := embedSize(query.Get("maxheight"), defaultEmbedHeight)
+	src := h.absoluteURL(r, embedPath)
+	html := fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" title="%s" style="border:0" loading="lazy"></iframe>`,
+		template.HTMLEscapeString(src), width, height, template.HTMLEscapeString(title))
+
+	writeCachedJSON(w, r, OEmbedResponse{
+		Version:      "1.0",
+		Type:         "rich",
+		ProviderName: toBrandingResponse(branding).Title,
+		ProviderURL:  h.absoluteURL(r, "/"),
+		Title:        title,
+		HTML:         html,
+		Width:        width,
+		Height:       height,
+		CacheAge:     600,
+	})
+}
+
+// oembedTarget maps the path of a public gallery or shared list URL to its
+// widget path, the kind of collection and its slug or token
+func oembedTarget(path string) (embedPath, kind, key string) {
+	if slug, ok := strings.CutPrefix(path, "/public/"); ok && gallerySlugPattern.MatchString(slug) {
+		return "/embed" + path, "gallery", slug
+	}
+	if token, ok := strings.CutPrefix(path, sharedListPath("")); ok && token != "" && !strings.Contains(token, "/") {
+		return "/embed/lists/" + token, "list", token
+	}
+	return "", "", ""
+}
+
+// embedSize returns the widget dimension for an oEmbed maxwidth/maxheight
+func embedSize(limit string, def int) int {
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 || n > def {
+		return def
+	}
+	return n
+}
+
+// writeEmbed renders a widget in the layout (?layout=grid|list) and with the
+// number of items (?limit=) of the request
+func writeEmbed(w http.ResponseWriter, r *http.Request, data embedData) {
+	query := r.URL.Query()
+	data.Layout = "grid"
+	if query.Get("layout") == "list" {
+		data.Layout = "list"
+	}
+	limit := defaultEmbedItems
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 {
+		limit = min(n, maxEmbedItems)
+	}
+	if len(data.Items) > limit {
+		data.More = len(data.Items) - limit
+		data.Items = data.Items[:limit]
+	}
+
+	var buf bytes.Buffer
+	if err := embedTemplate.Execute(&buf, data); err != nil {
+		slog.Error("failed to render widget", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to render widget")
+		return
+	}
+	security.AllowFraming(w)
+	writeCached(w, r, "text/html; charset=utf-8", buf.Bytes())
+}
+
+var embedTemplate = template.Must(template.New("embed").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.Title}}</title>
+  <style>
+    body { font-family: system-ui, sans-serif; font-size: 14px; margin: 0; padding: 0.75rem; color: #222; background: #fff; }
+    h1 { font-size: 1rem; margin: 0 0 0.75rem; }
+    ul { list-style: none; margin: 0; padding: 0; }
+    .grid ul { display: grid; grid-template-columns: repeat(auto-fill, minmax(7rem, 1fr)); gap: 0.75rem; }
+    .grid img, .grid .placeholder { width: 100%; aspect-ratio: 1; object-fit: cover; border-radius: 4px; background: #eee; display: block; }
+    .list li { display: flex; align-items: center; gap: 0.5rem; padding: 0.25rem 0; border-bottom: 1px solid #eee; }
+    .list img, .list .placeholder { width: 2.5rem; height: 2.5rem; object-fit: cover; border-radius: 4px; background: #eee; flex: none; }
+    .name { font-weight: 600; overflow: hidden; text-overflow: ellipsis; }
+    .detail, .more { color: #666; font-size: 0.85em; }
+  </style>
+</head>
+<body class="{{.Layout}}">
+  <h1>{{.Title}}</h1>
+  <ul>
+  {{range .Items}}<li>{{if .ImageURL}}<img src="{{.ImageURL}}" alt="" loading="lazy">{{else}}<span class="placeholder"></span>{{end}}<div><div class="name">{{.Name}}</div>{{with .Detail}}<div class="detail">{{.}}</div>{{end}}</div></li>
+  {{end}}</ul>
+  {{if .More}}<p class="more">and {{.More}} more</p>{{end}}
+</body>
+</html>`))
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_oembedTarget(t *testing.T) {
+	tests := []struct {
+		path, embedPath, kind, key string
+	}{
+		{"/public/board-games", "/embed/public/board-games", "gallery", "board-games"},
+		{"/share/lists/abc123", "/embed/lists/abc123", "list", "abc123"},
+		{"/public/Board Games", "", "", ""},
+		{"/share/lists/", "", "", ""},
+		{"/share/lists/a/b", "", "", ""},
+		{"/assets", "", "", ""},
+	}
+	for _, tt := range tests {
+		embedPath, kind, key := oembedTarget(tt.path)
+		if embedPath != tt.embedPath || kind != tt.kind || key != tt.key {
+			t.Errorf("oembedTarget(%q) = %q, %q, %q", tt.path, embedPath, kind, key)
+		}
+	}
+}
+
+func Test_embedSize(t *testing.T) {
+	if got := embedSize("", 600); got != 600 {
+		t.Errorf("expected the default without maxwidth, got %d", got)
+	}
+	if got := embedSize("320", 600); got != 320 {
+		t.Errorf("expected maxwidth to shrink the widget, got %d", got)
+	}
+	if got := embedSize("2000", 600); got != 600 {
+		t.Errorf("expected the default as upper bound, got %d", got)
+	}
+}
+
+func Test_writeEmbed(t *testing.T) {
+	data := embedData{Title: "<Games>", Items: []embedItem{{Name: "Catan"}, {Name: "Azul"}, {Name: "Root"}}}
+	rec := httptest.NewRecorder()
+	rec.Header().Set("X-Frame-Options", "DENY")
+	writeEmbed(rec, httptest.NewRequest(http.MethodGet, "/embed/public/games?layout=list&limit=2", nil), data)
+
+	if rec.Header().Get("X-Frame-Options") != "" {
+		t.Error("expected the widget to be frameable")
+	}
+	if csp := rec.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "frame-ancestors *") {
+		t.Errorf("expected frame-ancestors * in CSP, got %q", csp)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `class="list"`) || !strings.Contains(body, "and 1 more") || strings.Contains(body, "Root") {
+		t.Errorf("expected a list of 2 items, got %s", body)
+	}
+	if strings.Contains(body, "<Games>") {
+		t.Error("expected the title to be escaped")
+	}
+}
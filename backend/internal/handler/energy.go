@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/money"
+)
+
+// PowerUsageRequest sets the power consumption of an asset
+type PowerUsageRequest struct {
+	Watts        float64 `json:"watts"`
+	StandbyWatts float64 `json:"standby_watts"`
+	HoursPerDay  float64 `json:"hours_per_day"`
+}
+
+// EnergySettingsRequest sets the electricity price
+type EnergySettingsRequest struct {
+	PricePerKWh *PriceInput `json:"price_per_kwh"` // Number or localized string, e.g. "0,32 €"
+	Currency    *string     `json:"currency,omitempty"`
+}
+
+// EnergyReportItem is the estimated consumption of one asset (all units)
+type EnergyReportItem struct {
+	AssetID      string  `json:"asset_id"`
+	AssetName    string  `json:"asset_name"`
+	Quantity     int     `json:"quantity"`
+	LocationName string  `json:"location_name,omitempty"`
+	DailyKWh     float64 `json:"daily_kwh"`
+	AnnualKWh    float64 `json:"annual_kwh"`
+	AnnualCost   float64 `json:"annual_cost"`
+	StandbyShare float64 `json:"standby_share"` // Fraction of the energy used in standby
+}
+
+// EnergyLocationTotal is the estimated consumption of all assets in a location
+type EnergyLocationTotal struct {
+	LocationID   *string `json:"location_id,omitempty"` // nil = no location
+	LocationName string  `json:"location_name,omitempty"`
+	AnnualKWh    float64 `json:"annual_kwh"`
+	AnnualCost   float64 `json:"annual_cost"`
+}
+
+// EnergyReportResponse estimates energy use and cost per item and location
+type EnergyReportResponse struct {
+	PricePerKWh float64               `json:"price_per_kwh"`
+	Currency    *string               `json:"currency,omitempty"`
+	AnnualKWh   float64               `json:"annual_kwh"`
+	AnnualCost  float64               `json:"annual_cost"`
+	Items       []EnergyReportItem    `json:"items"`
+	Locations   []EnergyLocationTotal `json:"locations"`
+}
+
+func (h *Handler) GetPowerUsage(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := h.repos.Power.GetByAssetID(r.Context(), asset.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get power usage")
+		return
+	}
+	if usage == nil {
+		writeError(w, http.StatusNotFound, "power usage not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, usage)
+}
+
+// UpdatePowerUsage creates or replaces the power consumption of an asset
+func (h *Handler) UpdatePowerUsage(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	var req PowerUsageRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Watts < 0 || req.StandbyWatts < 0 {
+		writeError(w, http.StatusBadRequest, "watts must not be negative")
+		return
+	}
+	if req.HoursPerDay < 0 || req.HoursPerDay > 24 {
+		writeError(w, http.StatusBadRequest, "hours_per_day must be between 0 and 24")
+		return
+	}
+
+	usage := &domain.PowerUsage{
+		AssetID:      asset.ID,
+		Watts:        req.Watts,
+		StandbyWatts: req.StandbyWatts,
+		HoursPerDay:  req.HoursPerDay,
+	}
+	if err := h.repos.Power.Upsert(r.Context(), usage); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save power usage")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, usage)
+}
+
+func (h *Handler) DeletePowerUsage(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Power.Delete(r.Context(), asset.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete power usage")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetEnergySettings returns the electricity price (admin only)
+func (h *Handler) GetEnergySettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.energySettings(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get energy settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// UpdateEnergySettings sets the electricity price (admin only)
+func (h *Handler) UpdateEnergySettings(w http.ResponseWriter, r *http.Request) {
+	var req EnergySettingsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.PricePerKWh == nil {
+		writeError(w, http.StatusBadRequest, "price_per_kwh is required")
+		return
+	}
+
+	price, err := req.PricePerKWh.Parse(requestLocale(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid price_per_kwh: "+err.Error())
+		return
+	}
+
+	settings := domain.EnergySettings{PricePerKWh: price.Amount}
+	if req.Currency != nil && *req.Currency != "" {
+		code, err := money.NormalizeCurrency(*req.Currency)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if price.Currency != "" && price.Currency != code {
+			writeError(w, http.StatusBadRequest, "price_per_kwh currency does not match currency")
+			return
+		}
+		settings.Currency = &code
+	} else if price.Currency != "" {
+		settings.Currency = &price.Currency
+	}
+
+	if err := h.repos.Settings.Set(r.Context(), h.org(r), domain.SettingEnergy, settings); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save energy settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) energySettings(ctx context.Context, orgID uuid.UUID) (domain.EnergySettings, error) {
+	var settings domain.EnergySettings
+	_, err := h.repos.Settings.Get(ctx, orgID, domain.SettingEnergy, &settings)
+	return settings, err
+}
+
+// GetEnergyReport estimates yearly energy use and cost per item and location
+func (h *Handler) GetEnergyReport(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.energySettings(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get energy settings")
+		return
+	}
+
+	usages, err := h.repos.Power.List(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list power usage")
+		return
+	}
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if hide {
+		usages = withoutHighValueUsages(usages)
+	}
+
+	writeJSON(w, http.StatusOK, buildEnergyReport(usages, settings))
+}
+
+// withoutHighValueUsages removes the power profiles of high-value assets
+func withoutHighValueUsages(usages []domain.PowerUsageWithAsset) []domain.PowerUsageWithAsset {
+	return slices.DeleteFunc(usages, func(u domain.PowerUsageWithAsset) bool { return u.HighValue })
+}
+
+func buildEnergyReport(usages []domain.PowerUsageWithAsset, settings domain.EnergySettings) EnergyReportResponse {
+	report := EnergyReportResponse{
+		PricePerKWh: settings.PricePerKWh,
+		Currency:    settings.Currency,
+		Items:       make([]EnergyReportItem, 0, len(usages)),
+		Locations:   []EnergyLocationTotal{},
+	}
+
+	locations := map[string]*EnergyLocationTotal{}
+	for _, u := range usages {
+		quantity := max(u.Quantity, 1)
+		daily := u.DailyKWh() * float64(quantity)
+		item := EnergyReportItem{
+			AssetID:    u.AssetID.String(),
+			AssetName:  u.AssetName,
+			Quantity:   quantity,
+			DailyKWh:   round2(daily),
+			AnnualKWh:  round2(daily * 365),
+			AnnualCost: round2(daily * 365 * settings.PricePerKWh),
+		}
+		if total := u.DailyKWh(); total > 0 {
+			item.StandbyShare = round2(u.StandbyWatts * (24 - u.HoursPerDay) / 1000 / total)
+		}
+
+		key := ""
+		if u.LocationID != nil {
+			key = u.LocationID.String()
+		}
+		if u.LocationName != nil {
+			item.LocationName = *u.LocationName
+		}
+		loc, ok := locations[key]
+		if !ok {
+			loc = &EnergyLocationTotal{LocationName: item.LocationName}
+			if key != "" {
+				loc.LocationID = &key
+			}
+			locations[key] = loc
+		}
+		loc.AnnualKWh += daily * 365
+		loc.AnnualCost += daily * 365 * settings.PricePerKWh
+
+		report.AnnualKWh += daily * 365
+		report.AnnualCost += daily * 365 * settings.PricePerKWh
+		report.Items = append(report.Items, item)
+	}
+
+	// Biggest consumers first
+	slices.SortStableFunc(report.Items, func(a, b EnergyReportItem) int {
+		return compareDesc(a.AnnualKWh, b.AnnualKWh)
+	})
+	for _, loc := range locations {
+		loc.AnnualKWh = round2(loc.AnnualKWh)
+		loc.AnnualCost = round2(loc.AnnualCost)
+		report.Locations = append(report.Locations, *loc)
+	}
+	slices.SortFunc(report.Locations, func(a, b EnergyLocationTotal) int {
+		return compareDesc(a.AnnualKWh, b.AnnualKWh)
+	})
+	report.AnnualKWh = round2(report.AnnualKWh)
+	report.AnnualCost = round2(report.AnnualCost)
+	return report
+}
+
+// round2 rounds to two decimals for display
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
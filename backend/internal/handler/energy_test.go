@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_buildEnergyReport_TotalsPerItemAndLocation(t *testing.T) {
+	living := uuid.New()
+	livingName := "Living room"
+	usages := []domain.PowerUsageWithAsset{
+		{
+			// 0.1 kWh/day while in use, no standby
+			PowerUsage: domain.PowerUsage{AssetID: uuid.New(), Watts: 50, HoursPerDay: 2},
+			AssetName:  "Lamp", Quantity: 2, LocationID: &living, LocationName: &livingName,
+		},
+		{
+			// 0.42 kWh/day
+			PowerUsage: domain.PowerUsage{AssetID: uuid.New(), Watts: 100, StandbyWatts: 1, HoursPerDay: 4},
+			AssetName:  "TV", Quantity: 1, LocationID: &living, LocationName: &livingName,
+		},
+		{
+			// 1.2 kWh/day
+			PowerUsage: domain.PowerUsage{AssetID: uuid.New(), Watts: 50, HoursPerDay: 24},
+			AssetName:  "Router", Quantity: 0,
+		},
+	}
+
+	report := buildEnergyReport(usages, domain.EnergySettings{PricePerKWh: 0.5})
+
+	if len(report.Items) != 3 || report.Items[0].AssetName != "Router" || report.Items[2].AssetName != "Lamp" {
+		t.Fatalf("expected items sorted by consumption, got %+v", report.Items)
+	}
+	if router := report.Items[0]; router.Quantity != 1 || router.AnnualKWh != 438 || router.AnnualCost != 219 {
+		t.Errorf("unexpected router estimate: %+v", router)
+	}
+	if lamp := report.Items[2]; lamp.DailyKWh != 0.2 {
+		t.Errorf("expected lamp quantity to be counted, got %+v", lamp)
+	}
+	if tv := report.Items[1]; tv.StandbyShare != 0.05 {
+		t.Errorf("expected TV standby share 0.05, got %v", tv.StandbyShare)
+	}
+
+	if len(report.Locations) != 2 {
+		t.Fatalf("expected 2 locations, got %+v", report.Locations)
+	}
+	if loc := report.Locations[0]; loc.LocationID != nil || loc.AnnualKWh != 438 {
+		t.Errorf("expected unassigned items first, got %+v", loc)
+	}
+	if loc := report.Locations[1]; loc.LocationName != "Living room" || loc.AnnualKWh != 226.3 {
+		t.Errorf("unexpected living room total: %+v", loc)
+	}
+	if report.AnnualKWh != 664.3 || report.AnnualCost != 332.15 {
+		t.Errorf("unexpected totals: %v kWh, %v", report.AnnualKWh, report.AnnualCost)
+	}
+}
+
+func Test_buildEnergyReport_WithoutHighValue(t *testing.T) {
+	usages := []domain.PowerUsageWithAsset{
+		{PowerUsage: domain.PowerUsage{AssetID: uuid.New(), Watts: 50, HoursPerDay: 24}, AssetName: "Router", Quantity: 1},
+		{PowerUsage: domain.PowerUsage{AssetID: uuid.New(), Watts: 500, HoursPerDay: 24}, AssetName: "Server", Quantity: 1, HighValue: true},
+	}
+
+	report := buildEnergyReport(withoutHighValueUsages(usages), domain.EnergySettings{PricePerKWh: 0.5})
+
+	if len(report.Items) != 1 || report.Items[0].AssetName != "Router" {
+		t.Fatalf("expected only the router, got %+v", report.Items)
+	}
+	if report.AnnualKWh != 438 || report.Locations[0].AnnualKWh != 438 {
+		t.Errorf("expected totals without the server, got %v kWh, %+v", report.AnnualKWh, report.Locations)
+	}
+}
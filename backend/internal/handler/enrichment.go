@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/plugin"
+)
+
+// EnrichmentPluginListResponse represents the response for listing enrichment plugins
+type EnrichmentPluginListResponse struct {
+	Plugins []domain.EnrichmentPluginInfo `json:"plugins"`
+}
+
+// EnrichAssetRequest selects the product to look up. Fields left empty are
+// read from the asset's attributes (e.g. "brand", "model", "upc").
+type EnrichAssetRequest struct {
+	PluginID  string `json:"plugin_id,omitempty"` // Empty = try all enabled plugins
+	Brand     string `json:"brand,omitempty"`
+	Model     string `json:"model,omitempty"`
+	UPC       string `json:"upc,omitempty"`
+	Overwrite bool   `json:"overwrite,omitempty"` // Replace attribute values that are already set
+}
+
+// EnrichAssetResponse represents the response for enriching an asset
+type EnrichAssetResponse struct {
+	Asset    *domain.Asset       `json:"asset"`
+	PluginID string              `json:"plugin_id"`
+	Product  *domain.ProductData `json:"product"`
+	Updated  []string            `json:"updated"` // Attribute keys that were filled in
+}
+
+// ListEnrichers returns all enrichment plugins
+func (h *PluginHandler) ListEnrichers(w http.ResponseWriter, r *http.Request) {
+	enrichers := h.registry.ListEnrichers()
+
+	response := EnrichmentPluginListResponse{
+		Plugins: make([]domain.EnrichmentPluginInfo, 0, len(enrichers)),
+	}
+	for _, e := range enrichers {
+		response.Plugins = append(response.Plugins, domain.EnrichmentPluginToInfo(e))
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// EnrichAsset looks up an asset's product in the enrichment plugins and fills
+// the specifications found into its attributes (under "product.")
+func (h *PluginHandler) EnrichAsset(w http.ResponseWriter, r *http.Request) {
+	assetID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+
+	var req EnrichAssetRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	enrichers, err := h.enrichersFor(req.PluginID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if len(enrichers) == 0 {
+		writeError(w, http.StatusServiceUnavailable, "no enrichment plugin is enabled")
+		return
+	}
+
+	asset, err := h.repos.Assets.GetByID(r.Context(), assetID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || hidden {
+		writeError(w, http.StatusNotFound, "asset not found")
+		return
+	}
+
+	attrs := map[string]any{}
+	if len(asset.Attributes) > 0 {
+		if err := json.Unmarshal(asset.Attributes, &attrs); err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "asset attributes are not an object")
+			return
+		}
+		if attrs == nil {
+			attrs = map[string]any{}
+		}
+	}
+
+	query := plugin.ProductQueryFromAttributes(attrs)
+	if v := strings.TrimSpace(req.Brand); v != "" {
+		query.Brand = v
+	}
+	if v := strings.TrimSpace(req.Model); v != "" {
+		query.Model = v
+	}
+	if v := strings.TrimSpace(req.UPC); v != "" {
+		query.UPC = v
+	}
+	if query.UPC == "" && query.Model == "" {
+		writeError(w, http.StatusBadRequest, "a UPC or a model is required to look up the product")
+		return
+	}
+
+	var (
+		product  *domain.ProductData
+		pluginID string
+		failed   bool // A plugin could not be queried
+	)
+	for _, e := range enrichers {
+		found, err := e.Lookup(r.Context(), query)
+		if err == nil {
+			product, pluginID = found, e.ID()
+			break
+		}
+		if r.Context().Err() != nil {
+			return
+		}
+		if !errors.Is(err, domain.ErrProductNotFound) {
+			slog.Error("product lookup failed", "plugin_id", e.ID(), "asset_id", assetID, "error", err)
+			h.countError(e.ID(), "enrich")
+			failed = true
+		}
+	}
+	if product == nil {
+		if failed {
+			writeError(w, http.StatusBadGateway, "product lookup service temporarily unavailable")
+			return
+		}
+		writeError(w, http.StatusNotFound, "product not found")
+		return
+	}
+
+	updated := plugin.MergeAttributes(attrs, plugin.EnrichmentAttributes(product), req.Overwrite)
+	if len(updated) > 0 {
+		attrsJSON, err := json.Marshal(attrs)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to process product data")
+			return
+		}
+		changes, _ := domain.AttributeChanges(asset.Attributes, attrsJSON)
+		asset.Attributes = attrsJSON
+		if err := h.repos.Assets.Update(r.Context(), asset); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to update asset")
+			return
+		}
+
+		h.events.Publish(r.Context(), events.Event{
+			Type:           events.AssetUpdated,
+			OrganizationID: asset.OrganizationID,
+			SubjectID:      asset.ID,
+			ActorID:        currentUserID(r),
+			Changes:        changes,
+		})
+	}
+
+	if updated == nil {
+		updated = []string{}
+	}
+	writeJSON(w, http.StatusOK, EnrichAssetResponse{
+		Asset:    asset,
+		PluginID: pluginID,
+		Product:  product,
+		Updated:  updated,
+	})
+}
+
+// enrichersFor returns the enabled enrichment plugins to query: the one
+// requested, or all of them in registration order
+func (h *PluginHandler) enrichersFor(pluginID string) ([]domain.EnrichmentPlugin, error) {
+	if pluginID != "" {
+		e, ok := h.registry.GetEnricher(pluginID)
+		if !ok {
+			return nil, fmt.Errorf("enrichment plugin '%s' not found", pluginID)
+		}
+		if !e.Enabled() {
+			return nil, nil
+		}
+		return []domain.EnrichmentPlugin{e}, nil
+	}
+
+	var enabled []domain.EnrichmentPlugin
+	for _, e := range h.registry.ListEnrichers() {
+		if e.Enabled() {
+			enabled = append(enabled, e)
+		}
+	}
+	return enabled, nil
+}
+
+// assetHidden reports whether asset is high-value and hidden from the user of r
+func (h *PluginHandler) assetHidden(r *http.Request, asset *domain.Asset) (bool, error) {
+	if asset == nil || !asset.HighValue {
+		return false, nil
+	}
+	policy, err := loadHighValuePolicy(r.Context(), h.repos, h.org(r))
+	if err != nil {
+		return true, err
+	}
+	return !policy.CanView(currentUserRole(r)), nil
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// fileURLVerifier is implemented by storages that serve their own signed
+// URLs, i.e. local storage
+type fileURLVerifier interface {
+	VerifyURL(key string, query url.Values) bool
+}
+
+// LocalFiles serves the files of the local storage at /files/*. Signed URLs
+// handed out by GetPresignedURL are served as is; other requests go through
+// authorize, which must authenticate the caller and check its role, and only
+// get files of attachments of the caller's organization.
+func (h *Handler) LocalFiles(authorize func(http.Handler) http.Handler) http.Handler {
+	attachmentFile := authorize(http.HandlerFunc(h.serveAttachmentFile))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := chi.URLParam(r, "*")
+		if v, ok := h.storage.(fileURLVerifier); ok && v.VerifyURL(key, r.URL.Query()) {
+			h.serveFile(w, r, key, "")
+			return
+		}
+		attachmentFile.ServeHTTP(w, r)
+	})
+}
+
+// serveAttachmentFile streams the file of an attachment to an authorized caller
+func (h *Handler) serveAttachmentFile(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+	attachment, err := h.repos.Attachments.GetByFileKey(r.Context(), key)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get attachment")
+		return
+	}
+	if attachment == nil {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+
+	asset, err := h.repos.Assets.GetByID(r.Context(), attachment.AssetID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check asset")
+		return
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || asset.OrganizationID != h.org(r) || hidden {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+
+	h.recordDownload(r, attachment)
+	h.serveFile(w, r, key, derefString(attachment.ContentType))
+}
+
+// serveFile streams a stored file, with range requests when the storage
+// returns a seekable file
+func (h *Handler) serveFile(w http.ResponseWriter, r *http.Request, key, contentType string) {
+	if h.storage == nil {
+		writeError(w, http.StatusServiceUnavailable, "storage not configured")
+		return
+	}
+	f, err := h.storage.Open(r.Context(), key)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "file not found")
+		return
+	}
+	defer f.Close()
+
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Cache-Control", "private")
+
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, path.Base(key), time.Time{}, rs)
+		return
+	}
+	if _, err := io.Copy(w, f); err != nil {
+		slog.Warn("failed to stream file", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// signingStorage accepts URLs with signature=valid
+type signingStorage struct {
+	*mockStorage
+}
+
+func (s signingStorage) VerifyURL(key string, query url.Values) bool {
+	return query.Get("signature") == "valid"
+}
+
+func Test_Handler_LocalFiles(t *testing.T) {
+	files := newMockStorage()
+	files.files["a/manual.pdf"] = []byte("%PDF")
+	h := &Handler{storage: signingStorage{files}}
+
+	authorized := false
+	deny := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorized = true
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	}
+	router := chi.NewRouter()
+	router.Get("/files/*", h.LocalFiles(deny).ServeHTTP)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/a/manual.pdf?signature=valid", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "%PDF" || w.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("expected the signed file, got %d %q (%s)", w.Code, w.Body.String(), w.Header().Get("Content-Type"))
+	}
+	if authorized {
+		t.Error("expected signed URLs to skip authorization")
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/a/manual.pdf?signature=forged", nil))
+	if w.Code != http.StatusUnauthorized || !authorized {
+		t.Errorf("expected unsigned requests to be authorized, got %d", w.Code)
+	}
+}
+
+func Test_Handler_serveFile_NotFound(t *testing.T) {
+	h := &Handler{storage: newMockStorage()}
+	w := httptest.NewRecorder()
+	h.serveFile(w, httptest.NewRequest(http.MethodGet, "/files/missing", nil), "missing", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
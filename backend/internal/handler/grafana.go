@@ -0,0 +1,235 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// Targets of the Grafana JSON datasource
+const (
+	grafanaAssetCount         = "asset_count"         // Time series
+	grafanaAssetValue         = "asset_value"         // Time series, purchase value
+	grafanaAssetCurrentValue  = "asset_current_value" // Time series, latest valuations
+	grafanaExpiringWarranties = "expiring_warranties" // Table
+)
+
+var grafanaTargets = []string{grafanaAssetCount, grafanaAssetValue, grafanaAssetCurrentValue, grafanaExpiringWarranties}
+
+const (
+	// maxGrafanaPoints caps the number of points of a time series
+	maxGrafanaPoints = 1000
+
+	// minGrafanaStep is the smallest interval between points of a time series
+	minGrafanaStep = time.Minute
+)
+
+// GrafanaQueryRequest is a query of the Grafana JSON (SimpleJSON) datasource
+type GrafanaQueryRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	IntervalMs    int64           `json:"intervalMs"`
+	MaxDataPoints int             `json:"maxDataPoints"`
+	Targets       []GrafanaTarget `json:"targets"`
+}
+
+// GrafanaTarget is one queried target; options such as {"days": 60} for
+// expiring_warranties are read from data (SimpleJSON) or payload (JSON datasource)
+type GrafanaTarget struct {
+	Target  string          `json:"target"`
+	RefID   string          `json:"refId,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// GrafanaTimeSeries is a time series result
+type GrafanaTimeSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"` // [value, unix milliseconds]
+}
+
+// GrafanaTable is a table result
+type GrafanaTable struct {
+	Type    string          `json:"type"` // Always "table"
+	Columns []GrafanaColumn `json:"columns"`
+	Rows    [][]any         `json:"rows"`
+}
+
+// GrafanaColumn is a column of a table result
+type GrafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"` // string, number or time
+}
+
+// RequireGrafanaToken middleware checks the datasource bearer token
+func RequireGrafanaToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "invalid bearer token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GrafanaTestConnection answers the datasource's connection test
+func (h *Handler) GrafanaTestConnection(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// GrafanaSearch lists the available targets
+func (h *Handler) GrafanaSearch(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, grafanaTargets)
+}
+
+// GrafanaMetrics lists the available targets in the format of the JSON datasource plugin
+func (h *Handler) GrafanaMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics := make([]map[string]string, len(grafanaTargets))
+	for i, target := range grafanaTargets {
+		metrics[i] = map[string]string{"label": target, "value": target}
+	}
+	writeJSON(w, http.StatusOK, metrics)
+}
+
+// GrafanaAnnotations returns no annotations (not supported)
+func (h *Handler) GrafanaAnnotations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, []any{})
+}
+
+// GrafanaQuery returns the data of the queried targets
+func (h *Handler) GrafanaQuery(w http.ResponseWriter, r *http.Request) {
+	var req GrafanaQueryRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	from, to := req.Range.From, req.Range.To
+	if from.IsZero() || !to.After(from) {
+		writeError(w, http.StatusBadRequest, "range.to must be after range.from")
+		return
+	}
+
+	var history []domain.AssetHistoryPoint
+	results := []any{}
+	for _, target := range req.Targets {
+		switch target.Target {
+		case grafanaAssetCount, grafanaAssetValue, grafanaAssetCurrentValue:
+			if history == nil {
+				var err error
+				step := grafanaStep(from, to, req.IntervalMs, req.MaxDataPoints)
+				history, err = h.repos.Assets.History(r.Context(), h.org(r), from, to, step)
+				if err != nil {
+					writeError(w, http.StatusInternalServerError, "failed to get asset history")
+					return
+				}
+			}
+			results = append(results, assetHistorySeries(target.Target, history))
+
+		case grafanaExpiringWarranties:
+			warranties, err := h.repos.Warranties.List(r.Context(), h.org(r))
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to list warranties")
+				return
+			}
+			hide, err := h.hidesHighValue(r)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+				return
+			}
+			if hide {
+				warranties = slices.DeleteFunc(warranties, func(item domain.WarrantyWithAsset) bool { return item.HighValue })
+			}
+			// DATE columns are read as UTC midnight: compare against today's date in UTC
+			y, m, d := today(h.location(r)).Date()
+			day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+			results = append(results, expiringWarrantyTable(warranties, day, target.days()))
+
+		default:
+			writeError(w, http.StatusBadRequest, "unknown target: "+target.Target)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+// days returns the window of the expiring_warranties table (default 30)
+func (t GrafanaTarget) days() int {
+	for _, raw := range []json.RawMessage{t.Payload, t.Data} {
+		var opts struct {
+			Days int `json:"days"`
+		}
+		if len(raw) > 0 && json.Unmarshal(raw, &opts) == nil && opts.Days > 0 {
+			return opts.Days
+		}
+	}
+	return 30
+}
+
+// grafanaStep returns the interval between points: Grafana's interval,
+// widened to respect the maximum number of points
+func grafanaStep(from, to time.Time, intervalMs int64, maxDataPoints int) time.Duration {
+	span := to.Sub(from)
+	step := time.Duration(intervalMs) * time.Millisecond
+	if maxDataPoints <= 0 || maxDataPoints > maxGrafanaPoints {
+		maxDataPoints = maxGrafanaPoints
+	}
+	step = max(step, span/time.Duration(maxDataPoints), minGrafanaStep)
+	return step.Round(time.Second)
+}
+
+func assetHistorySeries(target string, history []domain.AssetHistoryPoint) GrafanaTimeSeries {
+	series := GrafanaTimeSeries{Target: target, Datapoints: make([][2]float64, len(history))}
+	for i, p := range history {
+		value := float64(p.Count)
+		switch target {
+		case grafanaAssetValue:
+			value = p.Value
+		case grafanaAssetCurrentValue:
+			value = p.CurrentValue
+		}
+		series.Datapoints[i] = [2]float64{value, float64(p.At.UnixMilli())}
+	}
+	return series
+}
+
+// expiringWarrantyTable lists the warranties ending within days of day, soonest first
+func expiringWarrantyTable(warranties []domain.WarrantyWithAsset, day time.Time, days int) GrafanaTable {
+	table := GrafanaTable{
+		Type: "table",
+		Columns: []GrafanaColumn{
+			{Text: "Asset", Type: "string"},
+			{Text: "Provider", Type: "string"},
+			{Text: "End date", Type: "time"},
+			{Text: "Days left", Type: "number"},
+		},
+		Rows: [][]any{},
+	}
+
+	until := day.AddDate(0, 0, days)
+	var expiring []domain.WarrantyWithAsset
+	for _, w := range warranties {
+		if w.EndDate != nil && !w.EndDate.Before(day) && !w.EndDate.After(until) {
+			expiring = append(expiring, w)
+		}
+	}
+	slices.SortStableFunc(expiring, func(a, b domain.WarrantyWithAsset) int {
+		return a.EndDate.Compare(*b.EndDate)
+	})
+
+	for _, w := range expiring {
+		daysLeft := int(w.EndDate.Sub(day).Hours() / 24)
+		table.Rows = append(table.Rows, []any{w.AssetName, derefString(w.Provider), w.EndDate.UnixMilli(), daysLeft})
+	}
+	return table
+}
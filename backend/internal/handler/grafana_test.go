@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_grafanaStep(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		to            time.Time
+		intervalMs    int64
+		maxDataPoints int
+		want          time.Duration
+	}{
+		{"grafana interval", from.AddDate(0, 0, 1), 3600_000, 1000, time.Hour},
+		{"widened to max points", from.AddDate(0, 0, 10), 60_000, 240, time.Hour},
+		{"capped points", from.AddDate(0, 0, 1000), 0, 0, 24 * time.Hour},
+		{"minimum step", from.Add(time.Hour), 1000, 1000, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grafanaStep(from, tt.to, tt.intervalMs, tt.maxDataPoints); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_assetHistorySeries(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := []domain.AssetHistoryPoint{{At: at, Count: 3, Value: 150, CurrentValue: 90}}
+
+	count := assetHistorySeries(grafanaAssetCount, history)
+	value := assetHistorySeries(grafanaAssetValue, history)
+	current := assetHistorySeries(grafanaAssetCurrentValue, history)
+
+	if count.Datapoints[0] != [2]float64{3, float64(at.UnixMilli())} {
+		t.Errorf("unexpected count datapoint %v", count.Datapoints[0])
+	}
+	if value.Datapoints[0][0] != 150 {
+		t.Errorf("unexpected value datapoint %v", value.Datapoints[0])
+	}
+	if current.Datapoints[0][0] != 90 {
+		t.Errorf("unexpected current value datapoint %v", current.Datapoints[0])
+	}
+}
+
+func Test_expiringWarrantyTable(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	ended, later, soon, far := day.AddDate(0, 0, -1), day.AddDate(0, 0, 20), day.AddDate(0, 0, 5), day.AddDate(0, 0, 60)
+	provider := "Acme"
+	warranties := []domain.WarrantyWithAsset{
+		{Warranty: domain.Warranty{EndDate: &ended}, AssetName: "Old"},
+		{Warranty: domain.Warranty{EndDate: &later}, AssetName: "Laptop"},
+		{Warranty: domain.Warranty{EndDate: &soon, Provider: &provider}, AssetName: "Phone"},
+		{Warranty: domain.Warranty{EndDate: &far}, AssetName: "Fridge"},
+		{AssetName: "No end"},
+	}
+
+	table := expiringWarrantyTable(warranties, day, 30)
+
+	if len(table.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %v", table.Rows)
+	}
+	if table.Rows[0][0] != "Phone" || table.Rows[0][1] != "Acme" || table.Rows[0][3] != 5 {
+		t.Errorf("unexpected first row %v", table.Rows[0])
+	}
+	if table.Rows[1][0] != "Laptop" {
+		t.Errorf("expected laptop second, got %v", table.Rows[1])
+	}
+}
+
+func Test_GrafanaTarget_days(t *testing.T) {
+	tests := []struct {
+		target GrafanaTarget
+		want   int
+	}{
+		{GrafanaTarget{}, 30},
+		{GrafanaTarget{Data: json.RawMessage(`{"days": 60}`)}, 60},
+		{GrafanaTarget{Payload: json.RawMessage(`{"days": 7}`), Data: json.RawMessage(`{"days": 60}`)}, 7},
+		{GrafanaTarget{Payload: json.RawMessage(`"nope"`)}, 30},
+	}
+	for _, tt := range tests {
+		if got := tt.target.days(); got != tt.want {
+			t.Errorf("expected %d, got %d", tt.want, got)
+		}
+	}
+}
+
+func Test_RequireGrafanaToken(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	handler := RequireGrafanaToken("secret")(next)
+
+	for header, want := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"Bearer secret": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/grafana/query", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%q: expected %d, got %d", header, want, rec.Code)
+		}
+	}
+}
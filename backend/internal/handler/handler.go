@@ -10,14 +10,24 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
 	"github.com/lmmendes/attic/internal/database"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/imaging"
+	"github.com/lmmendes/attic/internal/links"
+	"github.com/lmmendes/attic/internal/mail"
 	"github.com/lmmendes/attic/internal/repository"
+	"github.com/lmmendes/attic/internal/search"
+	"github.com/lmmendes/attic/internal/secrets"
+	"github.com/lmmendes/attic/internal/storage"
 )
 
 // FileStorage defines the interface for file storage backends
 type FileStorage interface {
-	Upload(ctx context.Context, filename string, contentType string, body io.Reader) (string, error)
+	UploadObject(ctx context.Context, obj storage.Object, contentType string, body io.Reader) (string, error)
 	GetPresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
 	Delete(ctx context.Context, key string) error
 }
 
@@ -32,6 +42,32 @@ type Repositories struct {
 	Warranties    *repository.WarrantyRepository
 	Attachments   *repository.AttachmentRepository
 	Attributes    *repository.AttributeRepository
+	Lists         *repository.AssetListRepository
+	Reservations  *repository.ReservationRepository
+	Costs         *repository.RecurringCostRepository
+	Maintenance   *repository.MaintenanceRepository
+	Reminders     *repository.ReminderRepository
+	Depreciation  *repository.DepreciationRepository
+	Consumption   *repository.ConsumptionRepository
+	Values        *repository.AssetValueRepository
+	Purchases     *repository.PurchaseRepository
+	Bundles       *repository.PurchaseGroupRepository
+	Power         *repository.PowerUsageRepository
+	Reports       *repository.ReportScheduleRepository
+	Sync          *repository.SyncRepository
+	Settings      *repository.SettingsRepository
+	Logins        *repository.LoginEventRepository
+	AuthEvents    *repository.AuthEventRepository
+	Roles         *repository.RoleRepository
+	Imports       *repository.ImportRepository
+	Sources       *repository.AssetSourceRepository
+	ShortLinks    *repository.ShortLinkRepository
+	Invites       *repository.InviteRepository
+	AssetEvents   *repository.AssetEventRepository
+	Comments      *repository.AssetCommentRepository
+	Contacts      *repository.ContactRepository
+	Loans         *repository.LoanRepository
+	LabelBatches  *repository.LabelBatchRepository
 }
 
 // Handler holds dependencies for HTTP handlers
@@ -39,7 +75,18 @@ type Handler struct {
 	db      *database.DB
 	repos   *Repositories
 	storage FileStorage
-	orgID   uuid.UUID // Default organization ID
+	orgID   uuid.UUID // Default organization, for requests without one
+
+	storageQuota int64 // Max attachment bytes per organization (0 = unlimited)
+	events       *events.Bus
+	search       search.Engine     // nil = external search disabled
+	indexer      *search.Indexer   // nil = external search disabled
+	links        *links.Builder    // nil = relative links
+	secrets      *secrets.Box      // Encrypts secrets stored in settings
+	mailer       mail.Mailer       // nil = email delivery disabled
+	images       imaging.Processor // nil = thumbnails unavailable
+	conversion   *ImageConversion  // nil = uploads are stored as is
+	archiveAfter time.Duration     // 0 = attachments aren't archived
 }
 
 // New creates a new Handler
@@ -52,6 +99,48 @@ func New(db *database.DB, repos *Repositories, storage FileStorage, defaultOrgID
 	}
 }
 
+// SetStorageQuota sets the maximum attachment storage per organization in bytes
+func (h *Handler) SetStorageQuota(bytes int64) {
+	h.storageQuota = bytes
+}
+
+// SetEvents sets the bus used to publish domain events
+func (h *Handler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// SetSearch enables the external search engine
+func (h *Handler) SetSearch(engine search.Engine, indexer *search.Indexer) {
+	h.search = engine
+	h.indexer = indexer
+}
+
+// SetLinks sets the builder used for absolute URLs in responses
+func (h *Handler) SetLinks(builder *links.Builder) {
+	h.links = builder
+}
+
+// SetSecrets sets the box used to encrypt secrets stored in the database
+func (h *Handler) SetSecrets(box *secrets.Box) {
+	h.secrets = box
+}
+
+// SetMailer sets the mailer used to deliver scheduled reports by email
+func (h *Handler) SetMailer(mailer mail.Mailer) {
+	h.mailer = mailer
+}
+
+// SetImageProcessor sets the processor generating attachment thumbnails
+func (h *Handler) SetImageProcessor(p imaging.Processor) {
+	h.images = p
+}
+
+// SetArchiveAfter sets how long after an asset was deleted its attachments
+// move to the archive tier of the storage
+func (h *Handler) SetArchiveAfter(d time.Duration) {
+	h.archiveAfter = d
+}
+
 // Health returns server health status
 func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
@@ -87,3 +176,87 @@ func parseUUID(r *http.Request, param string) (uuid.UUID, error) {
 func decodeJSON(r *http.Request, v any) error {
 	return json.NewDecoder(r.Body).Decode(v)
 }
+
+// org returns the organization of the request: the caller's, or the default
+// organization for unauthenticated requests such as the login page's branding
+func (h *Handler) org(r *http.Request) uuid.UUID {
+	return requestOrg(r, h.orgID)
+}
+
+// requestOrg returns the organization resolved for r, or else defaultOrgID
+func requestOrg(r *http.Request, defaultOrgID uuid.UUID) uuid.UUID {
+	if orgID := auth.OrganizationID(r.Context()); orgID != uuid.Nil {
+		return orgID
+	}
+	return defaultOrgID
+}
+
+// forEachOrganization runs fn for every organization, for background jobs;
+// it stops at the first error
+func (h *Handler) forEachOrganization(ctx context.Context, fn func(orgID uuid.UUID) error) error {
+	orgs, err := h.repos.Organizations.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if err := fn(org.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// absoluteURL returns the public URL of path as seen by the client of r
+func (h *Handler) absoluteURL(r *http.Request, path string) string {
+	if h.links == nil {
+		return path
+	}
+	return h.links.ForRequest(r, path)
+}
+
+// publish emits a domain event attributed to the current user, with the
+// field-level changes to record in the history
+func (h *Handler) publish(r *http.Request, eventType events.Type, orgID, subjectID uuid.UUID, changes ...domain.AssetChange) {
+	h.events.Publish(r.Context(), events.Event{
+		Type:           eventType,
+		OrganizationID: orgID,
+		SubjectID:      subjectID,
+		ActorID:        currentUserID(r),
+		Changes:        changes,
+	})
+}
+
+// currentUserID returns the ID of the authenticated user, if known
+func currentUserID(r *http.Request) *uuid.UUID {
+	if user := auth.GetUser(r.Context()); user != nil {
+		return &user.ID
+	}
+	if claims := auth.GetClaims(r.Context()); claims != nil {
+		if id, err := uuid.Parse(claims.Subject); err == nil {
+			return &id
+		}
+	}
+	return nil
+}
+
+// currentUserRole returns the role of the authenticated user ("" if unknown)
+func currentUserRole(r *http.Request) domain.UserRole {
+	return auth.CurrentRole(r.Context())
+}
+
+// currentUserName returns a display name for the authenticated user, if known
+func currentUserName(r *http.Request) string {
+	if user := auth.GetUser(r.Context()); user != nil {
+		if user.DisplayName != nil && *user.DisplayName != "" {
+			return *user.DisplayName
+		}
+		return user.Email
+	}
+	if claims := auth.GetClaims(r.Context()); claims != nil {
+		if claims.DisplayName != "" {
+			return claims.DisplayName
+		}
+		return claims.Email
+	}
+	return ""
+}
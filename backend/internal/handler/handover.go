@@ -0,0 +1,299 @@
+package handler
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// exportPageSize is the page size used to collect assets for exports
+const exportPageSize = 100
+
+// handoverItem is an asset in a handover archive
+type handoverItem struct {
+	Asset domain.Asset
+	Dir   string   // Archive directory holding the asset's files
+	Files []string // Archive paths of the asset's attachments
+	Photo string   // Archive path of the main photo, if any
+}
+
+// handoverData is the view model of the handover index page
+type handoverData struct {
+	Owner      string
+	Branding   BrandingResponse
+	Items      []handoverItem
+	TotalValue float64
+	Generated  time.Time
+}
+
+// ListOwners returns the people assets can belong to (the organization's
+// members)
+func (h *Handler) ListOwners(w http.ResponseWriter, r *http.Request) {
+	members, err := h.repos.Organizations.ListMembers(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list owners")
+		return
+	}
+
+	owners := make([]domain.AssetOwner, len(members))
+	for i, m := range members {
+		owners[i] = domain.AssetOwner{ID: m.UserID, Email: m.Email, DisplayName: m.DisplayName}
+	}
+	writeJSON(w, http.StatusOK, owners)
+}
+
+// ExportOwnerHandover bundles every asset belonging to a person (item list,
+// photos and documents) into a zip archive, e.g. for someone moving out or
+// inheritance documentation
+func (h *Handler) ExportOwnerHandover(w http.ResponseWriter, r *http.Request) {
+	ownerID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid owner ID")
+		return
+	}
+
+	owner, err := h.repos.Organizations.GetMember(r.Context(), h.org(r), ownerID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get owner")
+		return
+	}
+	if owner == nil {
+		writeError(w, http.StatusNotFound, "owner not found")
+		return
+	}
+
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+
+	assets, err := h.allAssets(r.Context(), h.org(r), domain.AssetFilter{OwnerID: &ownerID, NoHighValue: hide})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list assets")
+		return
+	}
+
+	branding, err := h.loadBranding(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+
+	ownerName := owner.Email
+	if owner.DisplayName != nil && *owner.DisplayName != "" {
+		ownerName = *owner.DisplayName
+	}
+
+	data := handoverData{
+		Owner:     ownerName,
+		Branding:  toBrandingResponse(branding),
+		Items:     make([]handoverItem, len(assets)),
+		Generated: time.Now(),
+	}
+	// The archive is self-contained, so the logo is not linked
+	data.Branding.LogoURL = ""
+
+	filename := fmt.Sprintf("handover-%s-%s.zip", archiveName(ownerName), data.Generated.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// From here on errors can only be logged, the response has started
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	for i, asset := range assets {
+		item := handoverItem{
+			Asset: asset,
+			Dir:   fmt.Sprintf("files/%03d-%s", i+1, archiveName(asset.Name)),
+		}
+		if asset.PurchasePrice != nil {
+			data.TotalValue += *asset.PurchasePrice * float64(asset.Quantity)
+		}
+
+		attachments, err := h.repos.Attachments.ListByAsset(r.Context(), asset.ID)
+		if err != nil {
+			slog.Error("failed to list attachments", "asset_id", asset.ID, "error", err)
+		}
+		for j, att := range attachments {
+			name := fmt.Sprintf("%s/%02d-%s", item.Dir, j+1, archiveName(att.FileName))
+			if err := h.copyToArchive(r.Context(), zw, name, att.FileKey); err != nil {
+				slog.Error("failed to add attachment to archive", "attachment_id", att.ID, "error", err)
+				continue
+			}
+			item.Files = append(item.Files, name)
+			if asset.MainAttachmentID != nil && *asset.MainAttachmentID == att.ID {
+				item.Photo = name
+			}
+		}
+		data.Items[i] = item
+	}
+
+	if err := writeHandoverCSV(zw, data.Items); err != nil {
+		slog.Error("failed to write handover item list", "error", err)
+		return
+	}
+
+	index, err := zw.Create("index.html")
+	if err != nil {
+		slog.Error("failed to write handover index", "error", err)
+		return
+	}
+	if err := handoverTemplate.Execute(index, data); err != nil {
+		slog.Error("failed to render handover index", "error", err)
+	}
+}
+
+// allAssets returns all assets matching filter
+func (h *Handler) allAssets(ctx context.Context, orgID uuid.UUID, filter domain.AssetFilter) ([]domain.Asset, error) {
+	var all []domain.Asset
+	for offset := 0; ; offset += exportPageSize {
+		assets, total, err := h.repos.Assets.List(ctx, orgID, filter, domain.Pagination{Limit: exportPageSize, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, assets...)
+		if len(assets) == 0 || len(all) >= total {
+			return all, nil
+		}
+	}
+}
+
+// copyToArchive adds the stored file key to the archive as name
+func (h *Handler) copyToArchive(ctx context.Context, zw *zip.Writer, name, key string) error {
+	if h.storage == nil {
+		return fmt.Errorf("file storage not configured")
+	}
+	src, err := h.storage.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// writeHandoverCSV adds the item list as items.csv
+func writeHandoverCSV(zw *zip.Writer, items []handoverItem) error {
+	f, err := zw.Create("items.csv")
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(f)
+	cw.Write([]string{"Name", "Category", "Location", "Condition", "Quantity", "Purchase date", "Purchase price", "Description", "Notes", "Files"})
+	for _, item := range items {
+		a := item.Asset
+		var category, location, condition, purchaseAt, price string
+		if a.Category != nil {
+			category = a.Category.Name
+		}
+		if a.Location != nil {
+			location = a.Location.Name
+		}
+		if a.Condition != nil {
+			condition = a.Condition.Label
+		}
+		if a.PurchaseAt != nil {
+			purchaseAt = a.PurchaseAt.Format("2006-01-02")
+		}
+		if a.PurchasePrice != nil {
+			price = strconv.FormatFloat(*a.PurchasePrice, 'f', 2, 64)
+		}
+		cw.Write([]string{
+			a.Name, category, location, condition, strconv.Itoa(a.Quantity), purchaseAt, price,
+			derefString(a.Description), derefString(a.Notes), strings.Join(item.Files, "; "),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// archiveName makes s safe to use as a file name inside an archive
+func archiveName(s string) string {
+	ext := path.Ext(s)
+	base := strings.TrimSuffix(s, ext)
+	clean := func(s string) string {
+		return strings.Trim(strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+				return r
+			}
+			return '-'
+		}, s), "-")
+	}
+	base = clean(base)
+	if base == "" {
+		base = "file"
+	}
+	if runes := []rune(base); len(runes) > 60 {
+		base = string(runes[:60])
+	}
+	if ext = clean(ext); ext != "" {
+		return base + "." + ext
+	}
+	return base
+}
+
+var handoverTemplate = template.Must(template.New("handover").Funcs(template.FuncMap{
+	"money": func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>Items of {{.Owner}} - {{.Branding.Title}}</title>
+  <style>
+    body { font-family: sans-serif; margin: 2rem; }
+    header { color: #666; }
+    h1 { color: {{with .Branding.AccentColor}}{{.}}{{else}}#000{{end}}; }
+    table { border-collapse: collapse; width: 100%; }
+    th, td { border: 1px solid #999; padding: 0.4rem 0.6rem; text-align: left; vertical-align: top; }
+    td img { max-width: 8rem; max-height: 8rem; }
+    td.num { text-align: right; }
+  </style>
+</head>
+<body>
+  <header>{{.Branding.Title}}</header>
+  <h1>Items of {{.Owner}}</h1>
+  <p>{{len .Items}} items, total purchase value {{money .TotalValue}}. Generated {{.Generated.Format "2006-01-02"}}.</p>
+  <table>
+    <thead><tr><th>Photo</th><th>Item</th><th>Qty</th><th>Category</th><th>Location</th><th>Purchased</th><th>Price</th><th>Documents</th></tr></thead>
+    <tbody>
+    {{range .Items}}<tr>
+      <td>{{with .Photo}}<img src="{{.}}" alt="">{{end}}</td>
+      <td><strong>{{.Asset.Name}}</strong>{{with .Asset.Description}}<br>{{.}}{{end}}</td>
+      <td class="num">{{.Asset.Quantity}}</td>
+      <td>{{with .Asset.Category}}{{.Name}}{{end}}</td>
+      <td>{{with .Asset.Location}}{{.Name}}{{end}}</td>
+      <td>{{with .Asset.PurchaseAt}}{{.Format "2006-01-02"}}{{end}}</td>
+      <td class="num">{{with .Asset.PurchasePrice}}{{money .}}{{end}}</td>
+      <td>{{range .Files}}<a href="{{.}}">{{.}}</a><br>{{end}}</td>
+    </tr>
+    {{end}}</tbody>
+  </table>
+</body>
+</html>`))
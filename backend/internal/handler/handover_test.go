@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_archiveName(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"receipt.pdf", "receipt.pdf"},
+		{"My Photo (1).JPG", "My-Photo--1.JPG"},
+		{"../../etc/passwd", "etc-passwd"},
+		{"Sofá grande", "Sofá-grande"},
+		{"", "file"},
+		{"???.png", "file.png"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := archiveName(tt.in); got != tt.want {
+				t.Errorf("archiveName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_writeHandoverCSV_WritesItems(t *testing.T) {
+	price := 12.5
+	items := []handoverItem{{
+		Asset: domain.Asset{
+			Name:          "Lamp, brass",
+			Quantity:      2,
+			PurchasePrice: &price,
+			Category:      &domain.Category{Name: "Furniture"},
+		},
+		Files: []string{"files/001-Lamp-brass/01-receipt.pdf"},
+	}}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := writeHandoverCSV(zw, items); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	zw.Close()
+
+	zr, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if len(zr.File) != 1 || zr.File[0].Name != "items.csv" {
+		t.Fatalf("expected items.csv in archive")
+	}
+	f, _ := zr.File[0].Open()
+	data, _ := io.ReadAll(f)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and 1 item, got %d lines", len(lines))
+	}
+	if !strings.HasPrefix(lines[1], `"Lamp, brass",Furniture,,,2,,12.50,`) {
+		t.Errorf("unexpected item row: %s", lines[1])
+	}
+}
+
+func Test_handoverTemplate_RendersItems(t *testing.T) {
+	price := 99.0
+	purchased := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	data := handoverData{
+		Owner:    "Alice",
+		Branding: BrandingResponse{Title: "Attic"},
+		Items: []handoverItem{{
+			Asset: domain.Asset{Name: "Guitar", Quantity: 1, PurchasePrice: &price, PurchaseAt: &purchased},
+			Photo: "files/001-Guitar/01-photo.jpg",
+			Files: []string{"files/001-Guitar/01-photo.jpg"},
+		}},
+		TotalValue: 99,
+		Generated:  purchased,
+	}
+
+	var buf bytes.Buffer
+	if err := handoverTemplate.Execute(&buf, data); err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+
+	html := buf.String()
+	for _, want := range []string{"Items of Alice", "Guitar", "99.00", "2024-03-01", `src="files/001-Guitar/01-photo.jpg"`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected page to contain %q", want)
+		}
+	}
+}
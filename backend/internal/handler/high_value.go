@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// HighValuePolicyRequest updates the high-value item policy
+type HighValuePolicyRequest struct {
+	Threshold    float64           `json:"threshold"`
+	VisibleRoles []domain.UserRole `json:"visible_roles"`
+}
+
+// HighValuePolicyResponse is the high-value item policy
+type HighValuePolicyResponse struct {
+	domain.HighValuePolicy
+	Flagged int64 `json:"flagged,omitempty"` // Assets flagged by the new threshold
+}
+
+// GetHighValuePolicy returns the high-value item policy (admin only)
+func (h *Handler) GetHighValuePolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.highValuePolicy(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	writeJSON(w, http.StatusOK, HighValuePolicyResponse{HighValuePolicy: policy})
+}
+
+// UpdateHighValuePolicy replaces the high-value item policy and flags existing
+// assets reaching the threshold (admin only)
+func (h *Handler) UpdateHighValuePolicy(w http.ResponseWriter, r *http.Request) {
+	var req HighValuePolicyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Threshold < 0 {
+		writeError(w, http.StatusBadRequest, "threshold must not be negative")
+		return
+	}
+	for _, role := range req.VisibleRoles {
+		exists, err := roleExists(r.Context(), h.repos.Roles, h.org(r), role)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to save high-value policy")
+			return
+		}
+		if !exists {
+			writeError(w, http.StatusBadRequest, "invalid role: "+string(role))
+			return
+		}
+	}
+
+	policy := domain.HighValuePolicy{Threshold: req.Threshold, VisibleRoles: req.VisibleRoles}
+	if policy.VisibleRoles == nil {
+		policy.VisibleRoles = []domain.UserRole{}
+	}
+	if err := h.repos.Settings.Set(r.Context(), h.org(r), domain.SettingHighValue, policy); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save high-value policy")
+		return
+	}
+
+	response := HighValuePolicyResponse{HighValuePolicy: policy}
+	if policy.Threshold > 0 {
+		flagged, err := h.repos.Assets.FlagHighValue(r.Context(), h.org(r), policy.Threshold)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to flag high-value assets")
+			return
+		}
+		response.Flagged = flagged
+		if flagged > 0 && h.indexer != nil {
+			// Flagging doesn't publish asset events; the search engine filters
+			// on the flag, so push it there without delaying the response
+			go func(orgID uuid.UUID) {
+				if _, err := h.indexer.Reindex(context.Background(), orgID); err != nil {
+					slog.Error("failed to reindex search after flagging high-value assets", "error", err)
+				}
+			}(h.org(r))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// highValuePolicy loads the organization's high-value policy (zero value if unset)
+func (h *Handler) highValuePolicy(ctx context.Context, orgID uuid.UUID) (domain.HighValuePolicy, error) {
+	return loadHighValuePolicy(ctx, h.repos, orgID)
+}
+
+func loadHighValuePolicy(ctx context.Context, repos *Repositories, orgID uuid.UUID) (domain.HighValuePolicy, error) {
+	policy := domain.HighValuePolicy{VisibleRoles: []domain.UserRole{}}
+	if _, err := repos.Settings.Get(ctx, orgID, domain.SettingHighValue, &policy); err != nil {
+		return policy, err
+	}
+	return policy, nil
+}
+
+// hidesHighValue reports whether high-value assets must be hidden from the user of r
+func (h *Handler) hidesHighValue(r *http.Request) (bool, error) {
+	policy, err := h.highValuePolicy(r.Context(), h.org(r))
+	if err != nil {
+		return true, err
+	}
+	return !policy.CanView(currentUserRole(r)), nil
+}
+
+// assetHidden reports whether asset is high-value and hidden from the user of r
+func (h *Handler) assetHidden(r *http.Request, asset *domain.Asset) (bool, error) {
+	if asset == nil || !asset.HighValue {
+		return false, nil
+	}
+	return h.hidesHighValue(r)
+}
+
+// applyHighValue sets the high-value flag of asset: an explicit flag wins,
+// otherwise assets reaching the policy threshold are flagged and a previous
+// flag is kept
+func (h *Handler) applyHighValue(ctx context.Context, asset *domain.Asset, flag *bool) error {
+	if flag != nil {
+		asset.HighValue = *flag
+		return nil
+	}
+	policy, err := h.highValuePolicy(ctx, asset.OrganizationID)
+	if err != nil {
+		return err
+	}
+	asset.HighValue = asset.HighValue || policy.Exceeds(asset)
+	return nil
+}
+
+// withoutHighValue removes high-value assets
+func withoutHighValue(assets []domain.Asset) []domain.Asset {
+	return slices.DeleteFunc(assets, func(a domain.Asset) bool { return a.HighValue })
+}
+
+// maskHighValue removes the values of high-value assets
+func maskHighValue(assets []domain.Asset) {
+	for i := range assets {
+		if assets[i].HighValue {
+			assets[i].PurchasePrice = nil
+		}
+	}
+}
+
+// InsuranceReportItem is an individually listed item of the insurance report
+type InsuranceReportItem struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Category      string   `json:"category,omitempty"`
+	Location      string   `json:"location,omitempty"`
+	Quantity      int      `json:"quantity"`
+	PurchaseAt    *string  `json:"purchase_at,omitempty"`
+	PurchasePrice *float64 `json:"purchase_price,omitempty"`
+	TotalValue    float64  `json:"total_value"`
+	HasPhoto      bool     `json:"has_photo"`
+}
+
+// InsuranceCategoryTotal summarizes the other items of a category
+type InsuranceCategoryTotal struct {
+	Category   string  `json:"category"`
+	Count      int     `json:"count"`
+	TotalValue float64 `json:"total_value"`
+}
+
+// InsuranceReportResponse lists high-value items individually and summarizes
+// everything else per category
+type InsuranceReportResponse struct {
+	GeneratedAt    time.Time                `json:"generated_at"`
+	ItemCount      int                      `json:"item_count"`
+	TotalValue     float64                  `json:"total_value"`
+	HighValueCount int                      `json:"high_value_count"`
+	HighValueTotal float64                  `json:"high_value_total"`
+	HighValueItems []InsuranceReportItem    `json:"high_value_items"`
+	Categories     []InsuranceCategoryTotal `json:"categories"`
+}
+
+// GetInsuranceReport returns an inventory summary for insurance purposes
+func (h *Handler) GetInsuranceReport(w http.ResponseWriter, r *http.Request) {
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+
+	assets, err := h.allAssets(r.Context(), h.org(r), domain.AssetFilter{NoHighValue: hide})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list assets")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, buildInsuranceReport(assets, time.Now()))
+}
+
+func buildInsuranceReport(assets []domain.Asset, now time.Time) InsuranceReportResponse {
+	report := InsuranceReportResponse{
+		GeneratedAt:    now,
+		ItemCount:      len(assets),
+		HighValueItems: []InsuranceReportItem{},
+		Categories:     []InsuranceCategoryTotal{},
+	}
+
+	categories := map[string]*InsuranceCategoryTotal{}
+	for _, a := range assets {
+		var value float64
+		if a.PurchasePrice != nil {
+			value = *a.PurchasePrice * float64(a.Quantity)
+		}
+		report.TotalValue += value
+
+		category := ""
+		if a.Category != nil {
+			category = a.Category.Name
+		}
+
+		if !a.HighValue {
+			total, ok := categories[category]
+			if !ok {
+				total = &InsuranceCategoryTotal{Category: category}
+				categories[category] = total
+			}
+			total.Count++
+			total.TotalValue += value
+			continue
+		}
+
+		item := InsuranceReportItem{
+			ID:            a.ID.String(),
+			Name:          a.Name,
+			Category:      category,
+			Quantity:      a.Quantity,
+			PurchasePrice: a.PurchasePrice,
+			TotalValue:    value,
+			HasPhoto:      a.MainAttachmentID != nil,
+		}
+		if a.Location != nil {
+			item.Location = a.Location.Name
+		}
+		if a.PurchaseAt != nil {
+			date := a.PurchaseAt.Format("2006-01-02")
+			item.PurchaseAt = &date
+		}
+		report.HighValueItems = append(report.HighValueItems, item)
+		report.HighValueCount++
+		report.HighValueTotal += value
+	}
+
+	// Most valuable first
+	slices.SortStableFunc(report.HighValueItems, func(a, b InsuranceReportItem) int {
+		return compareDesc(a.TotalValue, b.TotalValue)
+	})
+	for _, total := range categories {
+		report.Categories = append(report.Categories, *total)
+	}
+	slices.SortFunc(report.Categories, func(a, b InsuranceCategoryTotal) int {
+		if c := compareDesc(a.TotalValue, b.TotalValue); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Category, b.Category)
+	})
+
+	return report
+}
+
+func compareDesc(a, b float64) int {
+	return cmp.Compare(b, a)
+}
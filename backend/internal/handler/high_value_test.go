@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_maskHighValue_ClearsOnlyHighValuePrices(t *testing.T) {
+	ring, lamp := 3000.0, 40.0
+	assets := []domain.Asset{
+		{Name: "Ring", PurchasePrice: &ring, HighValue: true},
+		{Name: "Lamp", PurchasePrice: &lamp},
+	}
+
+	maskHighValue(assets)
+
+	if assets[0].PurchasePrice != nil {
+		t.Error("expected high-value price to be masked")
+	}
+	if assets[1].PurchasePrice == nil {
+		t.Error("expected regular price to be kept")
+	}
+}
+
+func Test_withoutHighValue(t *testing.T) {
+	assets := []domain.Asset{{Name: "Ring", HighValue: true}, {Name: "Lamp"}}
+
+	visible := withoutHighValue(assets)
+
+	if len(visible) != 1 || visible[0].Name != "Lamp" {
+		t.Errorf("expected only the lamp, got %+v", visible)
+	}
+}
+
+func Test_buildInsuranceReport_ListsHighValueItems(t *testing.T) {
+	watch, ring, lamp, chair := 2000.0, 5000.0, 40.0, 100.0
+	furniture := &domain.Category{Name: "Furniture"}
+	assets := []domain.Asset{
+		{ID: uuid.New(), Name: "Watch", Quantity: 1, PurchasePrice: &watch, HighValue: true},
+		{ID: uuid.New(), Name: "Ring", Quantity: 1, PurchasePrice: &ring, HighValue: true},
+		{ID: uuid.New(), Name: "Lamp", Quantity: 2, PurchasePrice: &lamp, Category: furniture},
+		{ID: uuid.New(), Name: "Chair", Quantity: 1, PurchasePrice: &chair, Category: furniture},
+		{ID: uuid.New(), Name: "Box", Quantity: 1},
+	}
+
+	report := buildInsuranceReport(assets, time.Now())
+
+	if report.ItemCount != 5 || report.TotalValue != 7180 {
+		t.Errorf("unexpected totals: %d items, %v", report.ItemCount, report.TotalValue)
+	}
+	if report.HighValueCount != 2 || report.HighValueTotal != 7000 {
+		t.Errorf("unexpected high-value totals: %d items, %v", report.HighValueCount, report.HighValueTotal)
+	}
+	if report.HighValueItems[0].Name != "Ring" || report.HighValueItems[1].Name != "Watch" {
+		t.Error("expected high-value items ordered by value, most valuable first")
+	}
+	if len(report.Categories) != 2 {
+		t.Fatalf("expected 2 category totals, got %d", len(report.Categories))
+	}
+	if c := report.Categories[0]; c.Category != "Furniture" || c.Count != 2 || c.TotalValue != 180 {
+		t.Errorf("unexpected category total: %+v", c)
+	}
+}
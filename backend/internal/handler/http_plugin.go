@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/plugin"
+	"github.com/lmmendes/attic/internal/plugin/httpplugin"
+	"github.com/lmmendes/attic/internal/secrets"
+)
+
+// SetSecrets sets the box encrypting the request headers of HTTP plugins
+func (h *PluginHandler) SetSecrets(box *secrets.Box) {
+	h.secrets = box
+}
+
+// HTTPPluginRequest creates or replaces a generic HTTP plugin. Headers (e.g.
+// API keys) replace the stored headers; omit them to keep the stored ones.
+type HTTPPluginRequest struct {
+	domain.HTTPPluginConfig
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// HTTPPluginResponse never includes header values
+type HTTPPluginResponse struct {
+	domain.HTTPPluginConfig
+	PluginID    string   `json:"plugin_id"`
+	HeaderNames []string `json:"header_names"`
+}
+
+// LoadHTTPPlugins registers the organization's generic HTTP plugins, replacing
+// those registered before
+func (h *PluginHandler) LoadHTTPPlugins(ctx context.Context) error {
+	h.httpPluginsMu.Lock()
+	defer h.httpPluginsMu.Unlock()
+
+	settings, err := h.httpPluginSettings(ctx)
+	if err != nil {
+		return err
+	}
+	h.registerHTTPPlugins(settings)
+	return nil
+}
+
+// ListHTTPPlugins returns the generic HTTP plugin configurations (admin only)
+func (h *PluginHandler) ListHTTPPlugins(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.httpPluginSettings(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get HTTP plugins")
+		return
+	}
+
+	response := make([]HTTPPluginResponse, 0, len(settings.Plugins))
+	for _, cfg := range settings.Plugins {
+		response = append(response, h.toHTTPPluginResponse(cfg))
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// UpdateHTTPPlugin creates or replaces a generic HTTP plugin; it can be used
+// for imports right away (admin only)
+func (h *PluginHandler) UpdateHTTPPlugin(w http.ResponseWriter, r *http.Request) {
+	if !h.instanceAdmin(w, r) {
+		return
+	}
+	var req HTTPPluginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	cfg := req.HTTPPluginConfig
+	cfg.ID = chi.URLParam(r, "id")
+	cfg.HeadersEncrypted = ""
+
+	h.httpPluginsMu.Lock()
+	defer h.httpPluginsMu.Unlock()
+
+	settings, err := h.httpPluginSettings(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get HTTP plugins")
+		return
+	}
+	i := slices.IndexFunc(settings.Plugins, func(p domain.HTTPPluginConfig) bool { return p.ID == cfg.ID })
+
+	switch {
+	case req.Headers != nil:
+		if len(req.Headers) > 0 {
+			if h.secrets == nil {
+				writeError(w, http.StatusServiceUnavailable, "encryption key not configured")
+				return
+			}
+			data, _ := json.Marshal(req.Headers)
+			if cfg.HeadersEncrypted, err = h.secrets.Encrypt(string(data)); err != nil {
+				slog.Error("failed to encrypt plugin headers", "error", err)
+				writeError(w, http.StatusInternalServerError, "failed to save HTTP plugin")
+				return
+			}
+		}
+	case i >= 0:
+		cfg.HeadersEncrypted = settings.Plugins[i].HeadersEncrypted
+	}
+
+	// Validate the configuration by building the plugin
+	if _, err := h.newHTTPPlugin(cfg); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if p, exists := h.registry.Get(httpplugin.IDPrefix + cfg.ID); exists {
+		if _, generic := p.(*httpplugin.Plugin); !generic {
+			writeError(w, http.StatusConflict, "plugin ID is already used by a built-in plugin")
+			return
+		}
+	}
+
+	status := http.StatusOK
+	if i >= 0 {
+		settings.Plugins[i] = cfg
+	} else {
+		settings.Plugins = append(settings.Plugins, cfg)
+		status = http.StatusCreated
+	}
+	if err := h.repos.Settings.Set(r.Context(), h.orgID, domain.SettingHTTPPlugins, settings); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save HTTP plugin")
+		return
+	}
+	h.registerHTTPPlugins(settings)
+
+	// A changed category name renames the category created by earlier imports
+	if p, ok := h.registry.Get(httpplugin.IDPrefix + cfg.ID); ok {
+		if _, err := plugin.ReconcileCategory(r.Context(), h.repos.Categories, h.orgID, p); err != nil {
+			slog.Error("failed to reconcile plugin category", "plugin_id", p.ID(), "error", err)
+		}
+	}
+
+	writeJSON(w, status, h.toHTTPPluginResponse(cfg))
+}
+
+// DeleteHTTPPlugin removes a generic HTTP plugin. Assets imported with it and
+// its category are kept (admin only).
+func (h *PluginHandler) DeleteHTTPPlugin(w http.ResponseWriter, r *http.Request) {
+	if !h.instanceAdmin(w, r) {
+		return
+	}
+	id := chi.URLParam(r, "id")
+
+	h.httpPluginsMu.Lock()
+	defer h.httpPluginsMu.Unlock()
+
+	settings, err := h.httpPluginSettings(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get HTTP plugins")
+		return
+	}
+	i := slices.IndexFunc(settings.Plugins, func(p domain.HTTPPluginConfig) bool { return p.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "HTTP plugin not found")
+		return
+	}
+	settings.Plugins = slices.Delete(settings.Plugins, i, i+1)
+
+	if err := h.repos.Settings.Set(r.Context(), h.orgID, domain.SettingHTTPPlugins, settings); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete HTTP plugin")
+		return
+	}
+	h.registerHTTPPlugins(settings)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PluginHandler) httpPluginSettings(ctx context.Context) (domain.HTTPPluginSettings, error) {
+	var settings domain.HTTPPluginSettings
+	_, err := h.repos.Settings.Get(ctx, h.orgID, domain.SettingHTTPPlugins, &settings)
+	return settings, err
+}
+
+// registerHTTPPlugins replaces the registered generic HTTP plugins with the
+// configured ones; invalid configurations are logged and skipped
+func (h *PluginHandler) registerHTTPPlugins(settings domain.HTTPPluginSettings) {
+	for _, p := range h.registry.List() {
+		if _, generic := p.(*httpplugin.Plugin); generic {
+			h.registry.Unregister(p.ID())
+		}
+	}
+	for _, cfg := range settings.Plugins {
+		p, err := h.newHTTPPlugin(cfg)
+		if err == nil {
+			err = h.registry.Register(p)
+		}
+		if err != nil {
+			slog.Error("failed to register HTTP plugin", "plugin_id", httpplugin.IDPrefix+cfg.ID, "error", err)
+		}
+	}
+}
+
+// newHTTPPlugin builds a plugin from its configuration, decrypting the headers
+func (h *PluginHandler) newHTTPPlugin(cfg domain.HTTPPluginConfig) (*httpplugin.Plugin, error) {
+	headers, err := h.httpPluginHeaders(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return httpplugin.New(cfg, headers)
+}
+
+func (h *PluginHandler) httpPluginHeaders(cfg domain.HTTPPluginConfig) (map[string]string, error) {
+	if cfg.HeadersEncrypted == "" {
+		return nil, nil
+	}
+	if h.secrets == nil {
+		return nil, errors.New("encryption key not configured")
+	}
+	data, err := h.secrets.Decrypt(cfg.HeadersEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(data), &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+func (h *PluginHandler) toHTTPPluginResponse(cfg domain.HTTPPluginConfig) HTTPPluginResponse {
+	resp := HTTPPluginResponse{
+		HTTPPluginConfig: cfg,
+		PluginID:         httpplugin.IDPrefix + cfg.ID,
+		HeaderNames:      []string{},
+	}
+	resp.HeadersEncrypted = ""
+
+	if headers, err := h.httpPluginHeaders(cfg); err == nil {
+		for name := range headers {
+			resp.HeaderNames = append(resp.HeaderNames, name)
+		}
+		slices.SortFunc(resp.HeaderNames, func(a, b string) int {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		})
+	}
+	return resp
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/imaging"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+// ImageConversion configures the conversion of uploaded photos to a more
+// compact format
+type ImageConversion struct {
+	imaging.ConvertOptions
+	MinSize       int64 // Uploads of this many bytes or less are stored as is
+	KeepOriginals bool  // Keep the upload and add the converted copy as a derived attachment
+}
+
+// SetImageConversion converts uploaded JPEG and PNG photos larger than
+// c.MinSize; the image processor must be able to convert
+func (h *Handler) SetImageConversion(c ImageConversion) {
+	h.conversion = &c
+}
+
+// convertUpload converts an uploaded photo if conversion is enabled and the
+// result is smaller. It returns nil when the upload is stored as is; a failed
+// conversion is logged and doesn't fail the upload.
+func (h *Handler) convertUpload(ctx context.Context, file io.ReadSeeker, size int64, contentType string) *imaging.Image {
+	if h.conversion == nil || size <= h.conversion.MinSize || size > imaging.MaxInputSize {
+		return nil
+	}
+	converter, ok := imaging.AsConverter(h.images)
+	if !ok {
+		return nil
+	}
+	switch contentType {
+	case "image/jpeg", "image/png":
+	default:
+		return nil
+	}
+
+	data, err := io.ReadAll(file)
+	if _, seekErr := file.Seek(0, io.SeekStart); err == nil {
+		err = seekErr
+	}
+	if err != nil {
+		slog.Warn("failed to read upload for conversion", "error", err)
+		return nil
+	}
+
+	converted, err := converter.Convert(ctx, data, contentType, h.conversion.ConvertOptions)
+	if err != nil {
+		slog.Warn("failed to convert uploaded image", "format", h.conversion.Format, "error", err)
+		return nil
+	}
+	if int64(len(converted.Data)) >= size {
+		return nil
+	}
+	return converted
+}
+
+// convertedFileName names the converted copy of a photo, e.g. "photo.webp"
+func convertedFileName(name string, format imaging.Format) string {
+	return strings.TrimSuffix(name, path.Ext(name)) + "." + string(format)
+}
+
+// storeConvertedCopy stores a converted photo as a copy derived from the
+// uploaded original. It returns nil if the copy exceeds the storage quota or
+// couldn't be stored, leaving the original as the only attachment.
+func (h *Handler) storeConvertedCopy(r *http.Request, asset *domain.Asset, original *domain.Attachment, converted *imaging.Image, used int64) *domain.Attachment {
+	size := int64(len(converted.Data))
+	if h.storageQuota > 0 && used+size > h.storageQuota {
+		return nil
+	}
+
+	fileName := convertedFileName(original.FileName, h.conversion.Format)
+	key, err := h.storage.UploadObject(r.Context(), storage.Object{
+		OrganizationID: asset.OrganizationID,
+		AssetID:        asset.ID,
+		FileName:       fileName,
+	}, converted.ContentType, bytes.NewReader(converted.Data))
+	if err != nil {
+		slog.Error("failed to upload converted image", "attachment_id", original.ID, "error", err)
+		return nil
+	}
+
+	attachment := &domain.Attachment{
+		AssetID:       asset.ID,
+		UploadedBy:    currentUserID(r),
+		FileKey:       key,
+		FileName:      fileName,
+		FileSize:      size,
+		ContentType:   &converted.ContentType,
+		DerivedFromID: &original.ID,
+	}
+	if err := h.repos.Attachments.Create(r.Context(), attachment); err != nil {
+		h.storage.Delete(r.Context(), key)
+		slog.Error("failed to save converted image", "attachment_id", original.ID, "error", err)
+		return nil
+	}
+	return attachment
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/imaging"
+)
+
+// convertingProcessor converts images to the given data
+type convertingProcessor struct {
+	data []byte
+}
+
+func (convertingProcessor) Name() string                     { return "converting" }
+func (convertingProcessor) Supports(contentType string) bool { return true }
+func (convertingProcessor) Thumbnail(context.Context, []byte, string, int) (*imaging.Image, error) {
+	return nil, imaging.ErrUnsupported
+}
+func (p convertingProcessor) Convert(_ context.Context, _ []byte, _ string, opts imaging.ConvertOptions) (*imaging.Image, error) {
+	return &imaging.Image{Data: p.data, ContentType: opts.Format.ContentType()}, nil
+}
+
+func Test_Handler_convertUpload(t *testing.T) {
+	upload := bytes.Repeat([]byte("x"), 100)
+	conversion := ImageConversion{ConvertOptions: imaging.ConvertOptions{Format: imaging.FormatWebP}, MinSize: 50}
+
+	tests := map[string]struct {
+		conversion  *ImageConversion
+		images      imaging.Processor
+		contentType string
+		size        int64
+		converted   bool
+	}{
+		"converted":         {&conversion, convertingProcessor{[]byte("small")}, "image/png", 100, true},
+		"disabled":          {nil, convertingProcessor{[]byte("small")}, "image/png", 100, false},
+		"without converter": {&conversion, imaging.New(false), "image/png", 100, false},
+		"small upload":      {&conversion, convertingProcessor{[]byte("small")}, "image/png", 50, false},
+		"not a photo":       {&conversion, convertingProcessor{[]byte("small")}, "image/gif", 100, false},
+		"larger result":     {&conversion, convertingProcessor{bytes.Repeat([]byte("y"), 200)}, "image/jpeg", 100, false},
+	}
+	for name, tt := range tests {
+		h := &Handler{images: tt.images, conversion: tt.conversion}
+		file := bytes.NewReader(upload)
+		img := h.convertUpload(context.Background(), file, tt.size, tt.contentType)
+		if (img != nil) != tt.converted {
+			t.Errorf("%s: expected converted = %v, got %+v", name, tt.converted, img)
+		}
+		if img != nil && img.ContentType != "image/webp" {
+			t.Errorf("%s: expected WebP, got %s", name, img.ContentType)
+		}
+		// The upload is stored as is when it isn't converted
+		if rest, _ := io.ReadAll(file); len(rest) != len(upload) {
+			t.Errorf("%s: expected the upload to be rewound", name)
+		}
+	}
+}
+
+func Test_convertedFileName(t *testing.T) {
+	tests := []struct {
+		name   string
+		format imaging.Format
+		want   string
+	}{
+		{"photo.JPG", imaging.FormatWebP, "photo.webp"},
+		{"scan.png", imaging.FormatAVIF, "scan.avif"},
+		{"noext", imaging.FormatWebP, "noext.webp"},
+	}
+	for _, tt := range tests {
+		if got := convertedFileName(tt.name, tt.format); got != tt.want {
+			t.Errorf("convertedFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// ImportsResponse is a page of the import log
+type ImportsResponse struct {
+	Imports []domain.ImportRecord `json:"imports"`
+	Limit   int                   `json:"limit"`
+	Offset  int                   `json:"offset"`
+}
+
+// ListImports returns the import log, most recent first. Filters: plugin_id,
+// success, limit and offset.
+func (h *PluginHandler) ListImports(w http.ResponseWriter, r *http.Request) {
+	filter := domain.ImportRecordFilter{
+		PluginID: r.URL.Query().Get("plugin_id"),
+		Limit:    50,
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+		filter.Limit = min(limit, 200)
+	}
+	if offset, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+	if successStr := r.URL.Query().Get("success"); successStr != "" {
+		success, err := strconv.ParseBool(successStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid success filter")
+			return
+		}
+		filter.Success = &success
+	}
+
+	records, err := h.repos.Imports.List(r.Context(), h.org(r), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list imports")
+		return
+	}
+	if records == nil {
+		records = []domain.ImportRecord{}
+	}
+
+	writeJSON(w, http.StatusOK, ImportsResponse{
+		Imports: records,
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	})
+}
+
+// GetImport returns an import including the data the plugin returned
+func (h *PluginHandler) GetImport(w http.ResponseWriter, r *http.Request) {
+	rec, ok := h.importRecord(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, rec)
+}
+
+// RerunImport imports the item of a logged import again, creating a new asset
+func (h *PluginHandler) RerunImport(w http.ResponseWriter, r *http.Request) {
+	rec, ok := h.importRecord(w, r)
+	if !ok {
+		return
+	}
+	p, ok := h.enabledPlugin(w, rec.PluginID)
+	if !ok {
+		return
+	}
+	h.importItem(w, r, p, ImportRequest{ExternalID: rec.ExternalID})
+}
+
+func (h *PluginHandler) importRecord(w http.ResponseWriter, r *http.Request) (*domain.ImportRecord, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid import ID")
+		return nil, false
+	}
+	rec, err := h.repos.Imports.GetByID(r.Context(), h.org(r), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get import")
+		return nil, false
+	}
+	if rec == nil {
+		writeError(w, http.StatusNotFound, "import not found")
+		return nil, false
+	}
+	return rec, true
+}
+
+// logImport records the outcome of an import; failures to record are only logged
+func (h *PluginHandler) logImport(r *http.Request, pluginID, externalID string, payload json.RawMessage, assetID *uuid.UUID, importErr error) {
+	rec := &domain.ImportRecord{
+		OrganizationID: h.org(r),
+		UserID:         currentUserID(r),
+		PluginID:       pluginID,
+		ExternalID:     externalID,
+		AssetID:        assetID,
+		Success:        importErr == nil,
+		Payload:        payload,
+	}
+	if importErr != nil {
+		msg := importErr.Error()
+		rec.Error = &msg
+	}
+
+	if err := h.repos.Imports.Create(r.Context(), rec); err != nil {
+		slog.Warn("failed to record import", "plugin_id", pluginID, "external_id", externalID, "error", err)
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/plugin"
+)
+
+// ImportTransformsRequest replaces the import transforms of a plugin
+type ImportTransformsRequest struct {
+	Transforms []domain.ImportTransform `json:"transforms"`
+}
+
+// ListImportTransforms returns the import transforms of all plugins (admin only)
+func (h *PluginHandler) ListImportTransforms(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.transformSettings(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get import transforms")
+		return
+	}
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// UpdateImportTransforms replaces the import transforms of a plugin; an empty
+// list removes them (admin only)
+func (h *PluginHandler) UpdateImportTransforms(w http.ResponseWriter, r *http.Request) {
+	if !h.instanceAdmin(w, r) {
+		return
+	}
+	pluginID := chi.URLParam(r, "pluginId")
+	if _, exists := h.registry.Get(pluginID); !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("plugin '%s' not found", pluginID))
+		return
+	}
+
+	var req ImportTransformsRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if _, err := plugin.CompileTransforms(req.Transforms); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.transformsMu.Lock()
+	defer h.transformsMu.Unlock()
+
+	settings, err := h.transformSettings(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get import transforms")
+		return
+	}
+	if len(req.Transforms) == 0 {
+		delete(settings.Plugins, pluginID)
+	} else {
+		settings.Plugins[pluginID] = req.Transforms
+	}
+	if err := h.repos.Settings.Set(r.Context(), h.orgID, domain.SettingTransforms, settings); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save import transforms")
+		return
+	}
+
+	if req.Transforms == nil {
+		req.Transforms = []domain.ImportTransform{}
+	}
+	writeJSON(w, http.StatusOK, req)
+}
+
+// transformSettings loads the organization's import transforms
+func (h *PluginHandler) transformSettings(ctx context.Context) (domain.ImportTransformSettings, error) {
+	var settings domain.ImportTransformSettings
+	_, err := h.repos.Settings.Get(ctx, h.orgID, domain.SettingTransforms, &settings)
+	if settings.Plugins == nil {
+		settings.Plugins = map[string][]domain.ImportTransform{}
+	}
+	return settings, err
+}
+
+// applyTransforms rewrites import data with the transforms of the plugin
+func (h *PluginHandler) applyTransforms(ctx context.Context, pluginID string, data *domain.ImportData) error {
+	settings, err := h.transformSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("loading import transforms: %w", err)
+	}
+	return plugin.ApplyTransforms(data, settings.Plugins[pluginID])
+}
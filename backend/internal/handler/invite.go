@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/repository"
+)
+
+// Invite lifetimes: a week unless set, at most 30 days
+const (
+	defaultInviteHours = 7 * 24
+	maxInviteHours     = 30 * 24
+)
+
+// CreateInviteRequest creates an invite link to the caller's organization
+type CreateInviteRequest struct {
+	Role           string `json:"role"`             // Default user
+	ExpiresInHours int    `json:"expires_in_hours"` // Default a week
+	MaxUses        *int   `json:"max_uses"`         // nil = unlimited
+}
+
+// InviteResponse is a new invite with its link, shown only once
+type InviteResponse struct {
+	domain.Invite
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// InviteInfoResponse is what people opening an invite link see before
+// accepting it
+type InviteInfoResponse struct {
+	OrganizationName string          `json:"organization_name"`
+	Role             domain.UserRole `json:"role"`
+	ExpiresAt        time.Time       `json:"expires_at"`
+}
+
+// SetInvites lets people sign up with invite links
+func (h *AuthHandler) SetInvites(invites *repository.InviteRepository) {
+	h.invites = invites
+}
+
+// ListInvites returns the invites of the caller's organization, including
+// expired and revoked ones
+func (h *Handler) ListInvites(w http.ResponseWriter, r *http.Request) {
+	invites, err := h.repos.Invites.List(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list invites")
+		return
+	}
+	writeJSON(w, http.StatusOK, invites)
+}
+
+// CreateInvite creates a link that lets people join the caller's
+// organization with a role, signing up or with an existing account
+func (h *Handler) CreateInvite(w http.ResponseWriter, r *http.Request) {
+	var req CreateInviteRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	role := domain.UserRoleUser
+	if req.Role != "" {
+		role = domain.UserRole(req.Role)
+	}
+	if !h.validMemberRole(w, r, role) {
+		return
+	}
+	if req.ExpiresInHours == 0 {
+		req.ExpiresInHours = defaultInviteHours
+	}
+	if req.ExpiresInHours < 0 || req.ExpiresInHours > maxInviteHours {
+		writeError(w, http.StatusBadRequest, "expires_in_hours must be between 1 and 720")
+		return
+	}
+	if req.MaxUses != nil && *req.MaxUses < 1 {
+		writeError(w, http.StatusBadRequest, "max_uses must be at least 1")
+		return
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		slog.Error("failed to generate invite token", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to create invite")
+		return
+	}
+	invite := domain.Invite{
+		OrganizationID: h.org(r),
+		TokenHash:      hashSecretToken(token),
+		Role:           role,
+		CreatedBy:      currentUserID(r),
+		ExpiresAt:      time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour),
+		MaxUses:        req.MaxUses,
+	}
+	if err := h.repos.Invites.Create(r.Context(), &invite); err != nil {
+		slog.Error("failed to create invite", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to create invite")
+		return
+	}
+	writeJSON(w, http.StatusCreated, InviteResponse{
+		Invite: invite,
+		Token:  token,
+		URL:    h.absoluteURL(r, invitePath(token)),
+	})
+}
+
+// RevokeInvite stops an invite link from being accepted
+func (h *Handler) RevokeInvite(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid invite ID")
+		return
+	}
+	ok, err := h.repos.Invites.Revoke(r.Context(), h.org(r), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke invite")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "invite not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetInvite returns the organization and role of an invite link (no auth
+// required)
+func (h *Handler) GetInvite(w http.ResponseWriter, r *http.Request) {
+	invite, err := h.repos.Invites.GetByTokenHash(r.Context(), hashSecretToken(chi.URLParam(r, "token")))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get invite")
+		return
+	}
+	if invite == nil || !invite.Usable(time.Now()) {
+		writeError(w, http.StatusNotFound, "invite not found or expired")
+		return
+	}
+	org, err := h.repos.Organizations.GetByID(r.Context(), invite.OrganizationID)
+	if err != nil || org == nil {
+		writeError(w, http.StatusNotFound, "invite not found or expired")
+		return
+	}
+	writeJSON(w, http.StatusOK, InviteInfoResponse{
+		OrganizationName: org.Name,
+		Role:             invite.Role,
+		ExpiresAt:        invite.ExpiresAt,
+	})
+}
+
+// AcceptInvite makes the current user a member of the organization of an
+// invite link, with its role, and selects the organization
+func (h *Handler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	if user := auth.GetUser(r.Context()); user != nil && user.ServiceAccount {
+		writeError(w, http.StatusBadRequest, "service accounts belong to one organization")
+		return
+	}
+
+	tokenHash := hashSecretToken(chi.URLParam(r, "token"))
+	pending, err := h.repos.Invites.GetByTokenHash(r.Context(), tokenHash)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get invite")
+		return
+	}
+	if pending == nil || !pending.Usable(time.Now()) {
+		writeError(w, http.StatusNotFound, "invite not found or expired")
+		return
+	}
+	// Members keep their role; accepting again mustn't use the invite up
+	member, err := h.repos.Organizations.GetMember(r.Context(), pending.OrganizationID, *userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get member")
+		return
+	}
+	if member != nil {
+		writeError(w, http.StatusConflict, "you are already a member")
+		return
+	}
+
+	invite, err := h.repos.Invites.Use(r.Context(), tokenHash)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to accept invite")
+		return
+	}
+	if invite == nil {
+		writeError(w, http.StatusNotFound, "invite not found or expired")
+		return
+	}
+	added, err := h.repos.Organizations.AddMember(r.Context(), invite.OrganizationID, *userID, invite.Role)
+	if err != nil {
+		slog.Error("failed to add member", "organization_id", invite.OrganizationID, "user_id", *userID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to accept invite")
+		return
+	}
+	if !added {
+		writeError(w, http.StatusConflict, "you are already a member")
+		return
+	}
+	slog.Info("invite accepted", "invite_id", invite.ID, "organization_id", invite.OrganizationID, "user_id", *userID)
+
+	if _, err := h.repos.Organizations.SelectOrganization(r.Context(), invite.OrganizationID, *userID); err != nil {
+		slog.Error("failed to select organization", "organization_id", invite.OrganizationID, "error", err)
+	}
+	h.writeMember(w, r, http.StatusCreated, invite.OrganizationID, *userID)
+}
+
+// RegisterWithInvite creates a local account in the organization of an
+// invite link, with its role. Unlike self-registration, the account needs no
+// approval. With OIDC or proxy authentication, people sign in and accept the
+// invite instead.
+func (h *AuthHandler) RegisterWithInvite(w http.ResponseWriter, r *http.Request) {
+	if h.invites == nil || h.oidcEnabled || h.proxyAuth != nil {
+		writeError(w, http.StatusNotFound, "sign up is unavailable, sign in to accept the invite")
+		return
+	}
+
+	var req RegisterRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	user, ok := h.newLocalUser(w, r, req)
+	if !ok {
+		return
+	}
+
+	invite, err := h.invites.Use(r.Context(), hashSecretToken(chi.URLParam(r, "token")))
+	if err != nil {
+		slog.Error("failed to use invite", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if invite == nil {
+		writeError(w, http.StatusNotFound, "invite not found or expired")
+		return
+	}
+
+	user.OrganizationID = invite.OrganizationID
+	user.Role = invite.Role
+	if err := h.userRepo.Create(r.Context(), user); err != nil {
+		slog.Error("failed to create user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	slog.Info("user signed up with invite", "user_id", user.ID, "invite_id", invite.ID, "organization_id", invite.OrganizationID)
+
+	if h.verifier != nil {
+		h.verifier.Send(user)
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]any{"organization_id": invite.OrganizationID})
+}
+
+func invitePath(token string) string {
+	return "/invite/" + token
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/repository"
+)
+
+func Test_Invite_Validation(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	serviceAccount := context.WithValue(context.Background(), auth.DomainUserContextKey, &domain.User{ID: uuid.New(), ServiceAccount: true})
+	tests := map[string]struct {
+		handler http.HandlerFunc
+		ctx     context.Context
+		body    string
+		status  int
+	}{
+		"create invalid body":       {handler: h.CreateInvite, body: `{`, status: http.StatusBadRequest},
+		"create unknown role":       {handler: h.CreateInvite, body: `{"role":"viewer"}`, status: http.StatusBadRequest},
+		"create negative lifetime":  {handler: h.CreateInvite, body: `{"expires_in_hours":-1}`, status: http.StatusBadRequest},
+		"create too long lifetime":  {handler: h.CreateInvite, body: `{"expires_in_hours":721}`, status: http.StatusBadRequest},
+		"create zero uses":          {handler: h.CreateInvite, body: `{"max_uses":0}`, status: http.StatusBadRequest},
+		"revoke invalid ID":         {handler: h.RevokeInvite, status: http.StatusBadRequest},
+		"accept without user":       {handler: h.AcceptInvite, status: http.StatusUnauthorized},
+		"accept as service account": {handler: h.AcceptInvite, ctx: serviceAccount, status: http.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/organization/invites", strings.NewReader(tt.body))
+		if tt.ctx != nil {
+			req = req.WithContext(tt.ctx)
+		}
+		w := httptest.NewRecorder()
+		tt.handler(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", name, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
+
+func Test_AuthHandler_RegisterWithInvite_Unavailable(t *testing.T) {
+	withInvites := func(oidcEnabled bool) *AuthHandler {
+		h := NewAuthHandler(nil, auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24), 8, oidcEnabled)
+		h.SetInvites(&repository.InviteRepository{})
+		return h
+	}
+	for name, h := range map[string]*AuthHandler{
+		"no invites": NewAuthHandler(nil, auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24), 8, false),
+		"oidc":       withInvites(true),
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/auth/invites/abc/register", strings.NewReader(`{"email":"new@example.com","password":"long-enough"}`))
+		w := httptest.NewRecorder()
+		h.RegisterWithInvite(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", name, w.Code)
+		}
+	}
+}
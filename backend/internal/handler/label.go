@@ -0,0 +1,382 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/labels"
+)
+
+// maxDirectLabels is the largest batch rendered during the request, larger
+// ones are queued and rendered in the background
+const maxDirectLabels = 100
+
+// maxBatchLabels is the largest batch that can be printed at once
+const maxBatchLabels = 5000
+
+// labelBatchStaleAfter is how long a batch may render before another run
+// retries it, e.g. after a restart
+const labelBatchStaleAfter = 10 * time.Minute
+
+// labelBatchRetention is how long rendered batches are kept for download
+const labelBatchRetention = 24 * time.Hour
+
+// LabelBatchRequest selects the assets to print labels for, by ID (printed in
+// that order) or by the filters of the asset list, and the label sheet
+type LabelBatchRequest struct {
+	AssetIDs []uuid.UUID    `json:"asset_ids,omitempty"`
+	Filter   *LabelFilter   `json:"filter,omitempty"`
+	Preset   string         `json:"preset,omitempty"` // Defaults to avery-l7160
+	Layout   *labels.Layout `json:"layout,omitempty"` // Custom sheet instead of a preset
+	Skip     int            `json:"skip,omitempty"`   // Positions already used on the first sheet
+}
+
+// LabelFilter selects assets like the query parameters of the asset list
+type LabelFilter struct {
+	Query       string      `json:"q,omitempty"`
+	CategoryID  *uuid.UUID  `json:"category_id,omitempty"`
+	LocationID  *uuid.UUID  `json:"location_id,omitempty"`
+	ConditionID *uuid.UUID  `json:"condition_id,omitempty"`
+	OwnerID     *uuid.UUID  `json:"owner_id,omitempty"`
+	TagIDs      []uuid.UUID `json:"tag_id,omitempty"`
+	Statuses    []string    `json:"status,omitempty"`
+	Archived    string      `json:"archived,omitempty"` // true, false or all
+}
+
+// LabelPreset is a named label sheet layout
+type LabelPreset struct {
+	Name   string        `json:"name"`
+	Layout labels.Layout `json:"layout"`
+}
+
+// ListLabelPresets returns the label sheet layouts that can be chosen by name
+func (h *Handler) ListLabelPresets(w http.ResponseWriter, r *http.Request) {
+	names := labels.PresetNames()
+	presets := make([]LabelPreset, len(names))
+	for i, name := range names {
+		presets[i] = LabelPreset{Name: name, Layout: labels.Presets[name]}
+	}
+	writeJSON(w, http.StatusOK, presets)
+}
+
+// CreateLabelBatch prints labels with QR codes linking to the assets. Small
+// batches are returned as a PDF right away; larger ones are queued and
+// answered with 202 and the batch, whose PDF can be downloaded once done.
+func (h *Handler) CreateLabelBatch(w http.ResponseWriter, r *http.Request) {
+	var req LabelBatchRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	layout, err := labelLayout(req.Preset, req.Layout)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Skip < 0 || req.Skip >= layout.PerPage() {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("skip must be between 0 and %d", layout.PerPage()-1))
+		return
+	}
+	if len(req.AssetIDs) == 0 && req.Filter == nil {
+		writeError(w, http.StatusBadRequest, "asset_ids or filter is required")
+		return
+	}
+	if len(req.AssetIDs) > maxBatchLabels {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d labels can be printed at once", maxBatchLabels))
+		return
+	}
+
+	filter := domain.AssetFilter{IDs: req.AssetIDs}
+	if req.AssetIDs != nil {
+		// Explicitly chosen assets are printed even if archived
+		filter.Archived = domain.ArchivedAll
+	}
+	if req.Filter != nil {
+		if err := req.Filter.apply(&filter); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if filter.NoHighValue, err = h.hidesHighValue(r); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+
+	assets, err := h.allAssets(r.Context(), h.org(r), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list assets")
+		return
+	}
+	if len(assets) == 0 {
+		writeError(w, http.StatusBadRequest, "no assets match")
+		return
+	}
+	if len(assets) > maxBatchLabels {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("at most %d labels can be printed at once, %d assets match", maxBatchLabels, len(assets)))
+		return
+	}
+	assets = orderAssets(assets, req.AssetIDs)
+
+	if len(assets) <= maxDirectLabels {
+		var doc bytes.Buffer
+		if err := h.renderLabels(r.Context(), &doc, h.org(r), layout, assets, req.Skip); err != nil {
+			slog.Error("failed to render labels", "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to render labels")
+			return
+		}
+		writeLabelPDF(w, doc.Bytes(), time.Now())
+		return
+	}
+
+	encoded, err := json.Marshal(layout)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to queue labels")
+		return
+	}
+	batch := &domain.LabelBatch{
+		OrganizationID: h.org(r),
+		CreatedBy:      currentUserID(r),
+		AssetIDs:       make([]uuid.UUID, len(assets)),
+		Layout:         encoded,
+		Skip:           req.Skip,
+	}
+	for i, asset := range assets {
+		batch.AssetIDs[i] = asset.ID
+	}
+	if err := h.repos.LabelBatches.Create(r.Context(), batch); err != nil {
+		slog.Error("failed to queue label batch", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to queue labels")
+		return
+	}
+
+	w.Header().Set("Location", "/api/labels/batch/"+batch.ID.String())
+	writeJSON(w, http.StatusAccepted, batch)
+}
+
+// GetLabelBatch returns the state of a queued label batch
+func (h *Handler) GetLabelBatch(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid label batch ID")
+		return
+	}
+
+	batch, err := h.repos.LabelBatches.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get label batch")
+		return
+	}
+	if batch == nil {
+		writeError(w, http.StatusNotFound, "label batch not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, batch)
+}
+
+// DownloadLabelBatch returns the PDF of a rendered label batch
+func (h *Handler) DownloadLabelBatch(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid label batch ID")
+		return
+	}
+
+	batch, err := h.repos.LabelBatches.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get label batch")
+		return
+	}
+	if batch == nil {
+		writeError(w, http.StatusNotFound, "label batch not found")
+		return
+	}
+	if batch.Status != domain.LabelBatchDone {
+		writeError(w, http.StatusConflict, fmt.Sprintf("label batch is %s", batch.Status))
+		return
+	}
+
+	doc, err := h.repos.LabelBatches.GetPDF(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get label batch")
+		return
+	}
+	writeLabelPDF(w, doc, batch.CreatedAt)
+}
+
+// RenderLabelBatches renders the queued label batches one at a time and
+// removes those rendered more than a day ago
+func (h *Handler) RenderLabelBatches(ctx context.Context) error {
+	for {
+		batch, err := h.repos.LabelBatches.Claim(ctx, time.Now().Add(-labelBatchStaleAfter))
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			break
+		}
+		if err := h.renderLabelBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	deleted, err := h.repos.LabelBatches.DeleteCompletedBefore(ctx, time.Now().Add(-labelBatchRetention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		slog.Info("deleted expired label batches", "count", deleted)
+	}
+	return nil
+}
+
+// renderLabelBatch renders a claimed batch, recording a failure on the batch
+// unless it is likely transient (database errors)
+func (h *Handler) renderLabelBatch(ctx context.Context, batch *domain.LabelBatch) error {
+	var layout labels.Layout
+	if err := json.Unmarshal(batch.Layout, &layout); err != nil {
+		return h.repos.LabelBatches.Fail(ctx, batch.ID, "invalid layout")
+	}
+
+	filter := domain.AssetFilter{IDs: batch.AssetIDs, Archived: domain.ArchivedAll}
+	assets, err := h.allAssets(ctx, batch.OrganizationID, filter)
+	if err != nil {
+		return err
+	}
+	if len(assets) == 0 {
+		return h.repos.LabelBatches.Fail(ctx, batch.ID, "the assets were deleted")
+	}
+
+	var doc bytes.Buffer
+	if err := h.renderLabels(ctx, &doc, batch.OrganizationID, layout, orderAssets(assets, batch.AssetIDs), batch.Skip); err != nil {
+		slog.Error("failed to render label batch", "batch_id", batch.ID, "error", err)
+		return h.repos.LabelBatches.Fail(ctx, batch.ID, err.Error())
+	}
+	return h.repos.LabelBatches.Complete(ctx, batch.ID, doc.Bytes())
+}
+
+// renderLabels writes the labels of assets as a PDF; each shows the name and
+// location path of the asset and a QR code with its URL
+func (h *Handler) renderLabels(ctx context.Context, w io.Writer, orgID uuid.UUID, layout labels.Layout, assets []domain.Asset, skip int) error {
+	locations, err := h.repos.Locations.List(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	byID := make(map[uuid.UUID]domain.Location, len(locations))
+	for _, l := range locations {
+		byID[l.ID] = l
+	}
+
+	items := make([]labels.Label, len(assets))
+	for i, asset := range assets {
+		var lines []string
+		if asset.LocationID != nil {
+			lines = append(lines, locationPath(byID, *asset.LocationID))
+		}
+		if asset.Category != nil {
+			lines = append(lines, asset.Category.Name)
+		}
+		items[i] = labels.Label{Title: asset.Name, Lines: lines, Code: h.assetURL(asset.ID)}
+	}
+	return labels.Render(w, layout, items, skip)
+}
+
+// assetURL returns the URL of an asset's page in the app. Printed labels
+// always use the public base URL, not the host of the request.
+func (h *Handler) assetURL(id uuid.UUID) string {
+	path := "/assets/" + id.String()
+	if h.links == nil {
+		return path
+	}
+	return h.links.URL(path)
+}
+
+// labelLayout returns the custom layout if given, otherwise the named preset
+func labelLayout(preset string, custom *labels.Layout) (labels.Layout, error) {
+	if custom != nil {
+		if preset != "" {
+			return labels.Layout{}, fmt.Errorf("preset and layout can't both be set")
+		}
+		if err := custom.Validate(); err != nil {
+			return labels.Layout{}, fmt.Errorf("invalid layout: %w", err)
+		}
+		return *custom, nil
+	}
+	if preset == "" {
+		preset = labels.DefaultPreset
+	}
+	layout, ok := labels.Presets[preset]
+	if !ok {
+		return labels.Layout{}, fmt.Errorf("unknown preset %q, expected one of %s", preset, strings.Join(labels.PresetNames(), ", "))
+	}
+	return layout, nil
+}
+
+// apply adds the filter's criteria to filter
+func (f *LabelFilter) apply(filter *domain.AssetFilter) error {
+	filter.Query = f.Query
+	filter.CategoryID = f.CategoryID
+	filter.LocationID = f.LocationID
+	filter.ConditionID = f.ConditionID
+	filter.OwnerID = f.OwnerID
+	filter.TagIDs = f.TagIDs
+
+	var err error
+	if filter.Statuses, err = parseStatusFilter(f.Statuses); err != nil {
+		return err
+	}
+	if f.Archived != "" || filter.IDs == nil {
+		if filter.Archived, err = parseArchivedFilter(f.Archived); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderAssets sorts assets in the order of ids; without ids they are kept as
+// listed
+func orderAssets(assets []domain.Asset, ids []uuid.UUID) []domain.Asset {
+	if len(ids) == 0 {
+		return assets
+	}
+	byID := make(map[uuid.UUID]domain.Asset, len(assets))
+	for _, a := range assets {
+		byID[a.ID] = a
+	}
+	ordered := make([]domain.Asset, 0, len(assets))
+	for _, id := range ids {
+		if a, ok := byID[id]; ok {
+			ordered = append(ordered, a)
+			delete(byID, id) // Print duplicated IDs once
+		}
+	}
+	return ordered
+}
+
+// locationPath returns the names of a location and its parents, outermost
+// first, e.g. "Garage > Shelf 2"
+func locationPath(locations map[uuid.UUID]domain.Location, id uuid.UUID) string {
+	var names []string
+	for _, l := range locationAncestors(locations, id) {
+		names = append(names, l.Name)
+	}
+	return strings.Join(names, " > ")
+}
+
+func writeLabelPDF(w http.ResponseWriter, doc []byte, created time.Time) {
+	filename := fmt.Sprintf("labels-%s.pdf", created.Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(doc)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/labels"
+)
+
+func Test_labelLayout(t *testing.T) {
+	if layout, err := labelLayout("", nil); err != nil || layout != labels.Presets[labels.DefaultPreset] {
+		t.Errorf("expected the default preset, got %+v, %v", layout, err)
+	}
+	if layout, err := labelLayout("avery-5160", nil); err != nil || layout.Columns != 3 || layout.Rows != 10 {
+		t.Errorf("expected avery-5160, got %+v, %v", layout, err)
+	}
+	if _, err := labelLayout("avery-0000", nil); err == nil {
+		t.Error("expected an unknown preset to fail")
+	}
+
+	custom := labels.Layout{PageWidth: 100, PageHeight: 50, Columns: 2, Rows: 1, LabelWidth: 50, LabelHeight: 50}
+	if layout, err := labelLayout("", &custom); err != nil || layout != custom {
+		t.Errorf("expected the custom layout, got %+v, %v", layout, err)
+	}
+	if _, err := labelLayout("avery-5160", &custom); err == nil {
+		t.Error("expected a preset and a layout together to fail")
+	}
+	custom.Columns = 3
+	if _, err := labelLayout("", &custom); err == nil {
+		t.Error("expected a layout that doesn't fit to fail")
+	}
+}
+
+func Test_CreateLabelBatch_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `{`},
+		{"no assets", `{"preset": "avery-l7160"}`},
+		{"unknown preset", `{"asset_ids": ["` + uuid.NewString() + `"], "preset": "x"}`},
+		{"skip past the sheet", `{"asset_ids": ["` + uuid.NewString() + `"], "skip": 21}`},
+		{"invalid status", `{"filter": {"status": ["misplaced"]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{repos: &Repositories{}}
+			req := httptest.NewRequest(http.MethodPost, "/api/labels/batch", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			h.CreateLabelBatch(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_orderAssets(t *testing.T) {
+	a, b, c := domain.Asset{ID: uuid.New()}, domain.Asset{ID: uuid.New()}, domain.Asset{ID: uuid.New()}
+
+	got := orderAssets([]domain.Asset{a, b, c}, []uuid.UUID{c.ID, uuid.New(), a.ID, c.ID})
+	if len(got) != 2 || got[0].ID != c.ID || got[1].ID != a.ID {
+		t.Errorf("expected c and a in the requested order, got %+v", got)
+	}
+	if got := orderAssets([]domain.Asset{b, a}, nil); got[0].ID != b.ID {
+		t.Error("expected the listed order without IDs")
+	}
+}
+
+func Test_locationPath(t *testing.T) {
+	garage, shelf := uuid.New(), uuid.New()
+	locations := map[uuid.UUID]domain.Location{
+		garage: {ID: garage, Name: "Garage"},
+		shelf:  {ID: shelf, Name: "Shelf 2", ParentID: &garage},
+	}
+
+	if got := locationPath(locations, shelf); got != "Garage > Shelf 2" {
+		t.Errorf("expected the full path, got %q", got)
+	}
+	if got := locationPath(locations, uuid.New()); got != "" {
+		t.Errorf("expected no path for an unknown location, got %q", got)
+	}
+}
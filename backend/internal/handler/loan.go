@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/mail"
+)
+
+// CheckOutRequest lends an asset to a contact or to a person by name
+type CheckOutRequest struct {
+	ContactID *uuid.UUID `json:"contact_id,omitempty"`
+	Borrower  *string    `json:"borrower,omitempty"` // Defaults to the contact's name
+	DueAt     *string    `json:"due_at,omitempty"`   // YYYY-MM-DD
+	Notes     *string    `json:"notes,omitempty"`
+}
+
+// UpdateLoanRequest replaces the borrower, due date and notes of a loan
+type UpdateLoanRequest struct {
+	Borrower string  `json:"borrower"`
+	DueAt    *string `json:"due_at,omitempty"` // YYYY-MM-DD, empty = no due date
+	Notes    *string `json:"notes,omitempty"`
+}
+
+// ListLoans returns the assets currently on loan, or with ?overdue=true only
+// those past their due date
+func (h *Handler) ListLoans(w http.ResponseWriter, r *http.Request) {
+	var loans []domain.Loan
+	var err error
+	if r.URL.Query().Get("overdue") == "true" {
+		loans, err = h.repos.Loans.ListOverdue(r.Context(), h.org(r), today(h.location(r)))
+	} else {
+		loans, err = h.repos.Loans.ListOpen(r.Context(), h.org(r))
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list loans")
+		return
+	}
+
+	h.writeLoans(w, r, loans)
+}
+
+// ListAssetLoans returns the loan history of an asset
+func (h *Handler) ListAssetLoans(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	loans, err := h.repos.Loans.ListByAsset(r.Context(), asset.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list loans")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, loans)
+}
+
+// ListContactLoans returns the loan history of a contact
+func (h *Handler) ListContactLoans(w http.ResponseWriter, r *http.Request) {
+	contactID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid contact ID")
+		return
+	}
+
+	loans, err := h.repos.Loans.ListByContact(r.Context(), contactID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list loans")
+		return
+	}
+
+	h.writeLoans(w, r, loans)
+}
+
+// writeLoans responds with loans, without those of high-value assets hidden
+// from the caller
+func (h *Handler) writeLoans(w http.ResponseWriter, r *http.Request, loans []domain.Loan) {
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if hide {
+		loans = slices.DeleteFunc(loans, func(l domain.Loan) bool { return l.HighValue })
+	}
+	writeJSON(w, http.StatusOK, loans)
+}
+
+// visibleLoan loads the loan of the "id" URL parameter, responding 404 for
+// loans of high-value assets hidden from the caller
+func (h *Handler) visibleLoan(w http.ResponseWriter, r *http.Request) (*domain.Loan, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid loan ID")
+		return nil, false
+	}
+
+	loan, err := h.repos.Loans.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get loan")
+		return nil, false
+	}
+	hidden := false
+	if loan != nil && loan.HighValue {
+		if hidden, err = h.hidesHighValue(r); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+			return nil, false
+		}
+	}
+	if loan == nil || hidden {
+		writeError(w, http.StatusNotFound, "loan not found")
+		return nil, false
+	}
+	return loan, true
+}
+
+// CheckOutAsset lends an asset, setting its status to loaned
+func (h *Handler) CheckOutAsset(w http.ResponseWriter, r *http.Request) {
+	assetID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+
+	var req CheckOutRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	dueAt, err := parseDueDate(req.DueAt)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	asset, err := h.repos.Assets.GetByID(r.Context(), assetID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || hidden {
+		writeError(w, http.StatusNotFound, "asset not found")
+		return
+	}
+	if !asset.Status.Held() {
+		writeError(w, http.StatusConflict, fmt.Sprintf("cannot lend an asset that is %s", asset.Status))
+		return
+	}
+
+	loan := &domain.Loan{
+		OrganizationID: asset.OrganizationID,
+		AssetID:        asset.ID,
+		DueAt:          dueAt,
+		Notes:          req.Notes,
+		CreatedBy:      currentUserID(r),
+		AssetName:      asset.Name,
+	}
+	if req.ContactID != nil {
+		contact, err := h.repos.Contacts.GetByID(r.Context(), *req.ContactID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get contact")
+			return
+		}
+		if contact == nil || contact.OrganizationID != asset.OrganizationID {
+			writeError(w, http.StatusBadRequest, "contact not found")
+			return
+		}
+		loan.ContactID = &contact.ID
+		loan.ContactEmail = contact.Email
+		loan.Borrower = contact.Name
+	}
+	if borrower := optionalText(req.Borrower); borrower != nil {
+		loan.Borrower = *borrower
+	}
+	if loan.Borrower == "" {
+		writeError(w, http.StatusBadRequest, "borrower or contact_id is required")
+		return
+	}
+
+	if err := h.repos.Loans.CheckOut(r.Context(), loan); err != nil {
+		if errors.Is(err, domain.ErrAssetOnLoan) {
+			writeError(w, http.StatusConflict, "asset is already on loan")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to check out asset")
+		return
+	}
+
+	h.publish(r, events.AssetUpdated, asset.OrganizationID, asset.ID)
+	writeJSON(w, http.StatusCreated, loan)
+}
+
+func (h *Handler) UpdateLoan(w http.ResponseWriter, r *http.Request) {
+	var req UpdateLoanRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	borrower := optionalText(&req.Borrower)
+	if borrower == nil {
+		writeError(w, http.StatusBadRequest, "borrower is required")
+		return
+	}
+	dueAt, err := parseDueDate(req.DueAt)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	loan, ok := h.visibleLoan(w, r)
+	if !ok {
+		return
+	}
+
+	loan.Borrower = *borrower
+	loan.DueAt = dueAt
+	loan.Notes = req.Notes
+	if err := h.repos.Loans.Update(r.Context(), loan); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update loan")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, loan)
+}
+
+// ReturnLoan checks a loaned asset back in
+func (h *Handler) ReturnLoan(w http.ResponseWriter, r *http.Request) {
+	loan, ok := h.visibleLoan(w, r)
+	if !ok {
+		return
+	}
+
+	returned, err := h.repos.Loans.Return(r.Context(), loan)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to return loan")
+		return
+	}
+	if !returned {
+		writeError(w, http.StatusConflict, "loan was already returned")
+		return
+	}
+
+	h.publish(r, events.AssetUpdated, loan.OrganizationID, loan.AssetID)
+	writeJSON(w, http.StatusOK, loan)
+}
+
+// parseDueDate parses an optional YYYY-MM-DD due date, stored as a DATE
+func parseDueDate(s *string) (*time.Time, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	t, err := parseDate(*s, time.UTC)
+	if err != nil {
+		return nil, errors.New("invalid due_at, expected YYYY-MM-DD")
+	}
+	return &t, nil
+}
+
+// LoanReminders emails contacts that opted in to return reminders about
+// their overdue loans, once per loan (again after the due date changes)
+type LoanReminders struct {
+	repos  *Repositories
+	mailer mail.Mailer
+}
+
+// NewLoanReminders creates the loan reminder task
+func NewLoanReminders(repos *Repositories, mailer mail.Mailer) *LoanReminders {
+	return &LoanReminders{repos: repos, mailer: mailer}
+}
+
+// RunOnce sends the reminders that are due
+func (lr *LoanReminders) RunOnce(ctx context.Context) error {
+	orgs, err := lr.repos.Organizations.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if err := lr.runOrganization(ctx, org.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (lr *LoanReminders) runOrganization(ctx context.Context, orgID uuid.UUID) error {
+	var zone string
+	if _, err := lr.repos.Settings.Get(ctx, orgID, domain.SettingTimeZone, &zone); err != nil {
+		return err
+	}
+	loans, err := lr.repos.Loans.ListRemindersDue(ctx, orgID, today(loadLocation(zone)))
+	if err != nil || len(loans) == 0 {
+		return err
+	}
+
+	title := defaultBrandTitle
+	var branding domain.Branding
+	if found, err := lr.repos.Settings.Get(ctx, orgID, domain.SettingBranding, &branding); err == nil && found && branding.Title != "" {
+		title = branding.Title
+	}
+
+	// One email per contact, listing all their overdue loans
+	byContact := map[uuid.UUID][]domain.Loan{}
+	var contacts []uuid.UUID
+	for _, l := range loans {
+		if _, ok := byContact[*l.ContactID]; !ok {
+			contacts = append(contacts, *l.ContactID)
+		}
+		byContact[*l.ContactID] = append(byContact[*l.ContactID], l)
+	}
+	for _, id := range contacts {
+		due := byContact[id]
+		if err := lr.mailer.Send(ctx, returnReminder(title, due)); err != nil {
+			return err
+		}
+		for _, l := range due {
+			if err := lr.repos.Loans.MarkReminded(ctx, l.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// returnReminder builds the email asking a contact to return overdue loans,
+// all to the same contact
+func returnReminder(title string, loans []domain.Loan) mail.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Hi %s,\n\nThe following items you borrowed were due back:\n\n", loans[0].Borrower)
+	for _, l := range loans {
+		fmt.Fprintf(&b, "- %s, due %s\n", l.AssetName, l.DueAt.Format("2006-01-02"))
+	}
+	fmt.Fprintf(&b, "\nPlease return them when you can. Thank you!\n")
+
+	subject := fmt.Sprintf("[%s] Please return %d borrowed items", title, len(loans))
+	if len(loans) == 1 {
+		subject = fmt.Sprintf("[%s] Please return %s", title, loans[0].AssetName)
+	}
+	return mail.Message{To: []string{*loans[0].ContactEmail}, Subject: subject, Text: b.String()}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_parseDueDate(t *testing.T) {
+	empty, valid, invalid := "", "2025-07-01", "07/01/2025"
+
+	if due, err := parseDueDate(nil); due != nil || err != nil {
+		t.Errorf("expected no due date, got %v, %v", due, err)
+	}
+	if due, err := parseDueDate(&empty); due != nil || err != nil {
+		t.Errorf("expected no due date, got %v, %v", due, err)
+	}
+	if due, err := parseDueDate(&valid); err != nil || !due.Equal(*utcDate(2025, 7, 1)) {
+		t.Errorf("expected 2025-07-01, got %v, %v", due, err)
+	}
+	if _, err := parseDueDate(&invalid); err == nil {
+		t.Error("expected an invalid date to fail")
+	}
+}
+
+func Test_CheckOutAsset_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+		body string
+	}{
+		{"invalid asset ID", "x", `{"borrower": "Ana"}`},
+		{"invalid JSON", uuid.NewString(), `{`},
+		{"invalid due date", uuid.NewString(), `{"borrower": "Ana", "due_at": "tomorrow"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{repos: &Repositories{}}
+			req := withChiURLParam(httptest.NewRequest(http.MethodPost, "/api/assets/"+tt.id+"/loans", strings.NewReader(tt.body)), "id", tt.id)
+			rr := httptest.NewRecorder()
+
+			h.CheckOutAsset(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_UpdateLoan_RequiresBorrower(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	id := uuid.NewString()
+	req := withChiURLParam(httptest.NewRequest(http.MethodPut, "/api/loans/"+id, strings.NewReader(`{"borrower": "  "}`)), "id", id)
+	rr := httptest.NewRecorder()
+
+	h.UpdateLoan(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func Test_returnReminder(t *testing.T) {
+	email := "ana@example.com"
+	loans := []domain.Loan{
+		{Borrower: "Ana", AssetName: "Tent", DueAt: utcDate(2025, 7, 1), ContactEmail: &email},
+		{Borrower: "Ana", AssetName: "Drill", DueAt: utcDate(2025, 7, 3), ContactEmail: &email},
+	}
+
+	msg := returnReminder("Attic", loans)
+
+	if len(msg.To) != 1 || msg.To[0] != email {
+		t.Errorf("expected the contact as recipient, got %v", msg.To)
+	}
+	if msg.Subject != "[Attic] Please return 2 borrowed items" {
+		t.Errorf("unexpected subject: %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Hi Ana") || !strings.Contains(msg.Text, "- Tent, due 2025-07-01") {
+		t.Errorf("unexpected body: %q", msg.Text)
+	}
+
+	if msg := returnReminder("Attic", loans[:1]); msg.Subject != "[Attic] Please return Tent" {
+		t.Errorf("unexpected subject: %q", msg.Subject)
+	}
+}
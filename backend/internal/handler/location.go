@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 
+	"github.com/google/uuid"
 	"github.com/lmmendes/attic/internal/domain"
 )
 
@@ -27,9 +28,9 @@ func (h *Handler) ListLocations(w http.ResponseWriter, r *http.Request) {
 	var err error
 
 	if tree {
-		locations, err = h.repos.Locations.ListTree(r.Context(), h.orgID)
+		locations, err = h.repos.Locations.ListTree(r.Context(), h.org(r))
 	} else {
-		locations, err = h.repos.Locations.List(r.Context(), h.orgID)
+		locations, err = h.repos.Locations.List(r.Context(), h.org(r))
 	}
 
 	if err != nil {
@@ -77,7 +78,7 @@ func (h *Handler) CreateLocation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	loc := &domain.Location{
-		OrganizationID: h.orgID,
+		OrganizationID: h.org(r),
 		Name:           req.Name,
 		Description:    req.Description,
 		Icon:           req.Icon,
@@ -160,3 +161,19 @@ func (h *Handler) DeleteLocation(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// locationAncestors returns a location and its parents, outermost first; it
+// is empty if the location is unknown
+func locationAncestors(locations map[uuid.UUID]domain.Location, id uuid.UUID) []domain.Location {
+	var path []domain.Location
+	seen := map[uuid.UUID]bool{}
+	for l, ok := locations[id]; ok && !seen[l.ID]; {
+		seen[l.ID] = true
+		path = append([]domain.Location{l}, path...)
+		if l.ParentID == nil {
+			break
+		}
+		l, ok = locations[*l.ParentID]
+	}
+	return path
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/links"
+	"github.com/lmmendes/attic/internal/mail"
+	"github.com/lmmendes/attic/internal/ratelimit"
+)
+
+// Login failure reasons recorded in the audit log
+const (
+	loginFailureUnknownUser     = "unknown_user"
+	loginFailureNoPassword      = "no_password"
+	loginFailureInvalidPassword = "invalid_password"
+	loginFailureDisabled        = "disabled"
+	loginFailureInvalidCode     = "invalid_two_factor_code"
+)
+
+const maxUserAgentLength = 512
+
+// LoginAudit records login attempts and other authentication events, and
+// alerts users about logins from new devices
+type LoginAudit struct {
+	repos  *Repositories
+	orgID  uuid.UUID
+	mailer mail.Mailer    // nil = no alerts
+	links  *links.Builder // nil = no link in alerts
+}
+
+// NewLoginAudit creates a login audit recorder
+func NewLoginAudit(repos *Repositories, defaultOrgID uuid.UUID) *LoginAudit {
+	return &LoginAudit{repos: repos, orgID: defaultOrgID}
+}
+
+// SetAlerts enables new device/IP email alerts
+func (a *LoginAudit) SetAlerts(mailer mail.Mailer, builder *links.Builder) {
+	a.mailer = mailer
+	a.links = builder
+}
+
+// Record stores a login attempt. user is nil when the account is unknown;
+// failureReason is empty for successful logins.
+func (a *LoginAudit) Record(r *http.Request, user *domain.User, email string, method domain.LoginMethod, failureReason string) {
+	event := &domain.LoginEvent{
+		OrganizationID: a.orgID,
+		Email:          email,
+		IPAddress:      ratelimit.ClientIP(r),
+		UserAgent:      truncate(r.UserAgent(), maxUserAgentLength),
+		Method:         method,
+		Success:        failureReason == "",
+	}
+	if user != nil {
+		event.UserID = &user.ID
+		event.OrganizationID = user.OrganizationID
+		if event.Email == "" {
+			event.Email = user.Email
+		}
+	}
+	if failureReason != "" {
+		event.FailureReason = &failureReason
+	}
+
+	authEvent := &domain.AuthEvent{
+		UserID:        event.UserID,
+		Email:         event.Email,
+		Type:          domain.AuthEventLogin,
+		Method:        &method,
+		FailureReason: event.FailureReason,
+	}
+	if !event.Success {
+		authEvent.Type = domain.AuthEventLoginFailed
+	}
+	a.recordEvent(r, user, authEvent)
+
+	if err := a.repos.Logins.Create(r.Context(), event); err != nil {
+		slog.Error("failed to record login event", "error", err)
+		return
+	}
+
+	if event.NewDevice && user != nil && a.mailer != nil {
+		// Don't delay the login response on the mail server
+		go a.sendNewDeviceAlert(user, event)
+	}
+}
+
+// RecordEvent stores an authentication event of user other than a login
+// attempt, e.g. a logout or password change
+func (a *LoginAudit) RecordEvent(r *http.Request, user *domain.User, eventType domain.AuthEventType) {
+	a.recordEvent(r, user, &domain.AuthEvent{UserID: &user.ID, Email: user.Email, Type: eventType})
+}
+
+func (a *LoginAudit) recordEvent(r *http.Request, user *domain.User, event *domain.AuthEvent) {
+	event.OrganizationID = a.orgID
+	if user != nil {
+		event.OrganizationID = user.OrganizationID
+	}
+	event.IPAddress = ratelimit.ClientIP(r)
+	event.UserAgent = truncate(r.UserAgent(), maxUserAgentLength)
+	if err := a.repos.AuthEvents.Create(r.Context(), event); err != nil {
+		slog.Error("failed to record auth event", "type", event.Type, "error", err)
+	}
+}
+
+func (a *LoginAudit) sendNewDeviceAlert(user *domain.User, event *domain.LoginEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	title := defaultBrandTitle
+	var branding domain.Branding
+	if found, err := a.repos.Settings.Get(ctx, user.OrganizationID, domain.SettingBranding, &branding); err == nil && found && branding.Title != "" {
+		title = branding.Title
+	}
+
+	// Show the sign-in time in the user's (or organization's) time zone
+	zone := ""
+	if user.TimeZone != nil {
+		zone = *user.TimeZone
+	} else if _, err := a.repos.Settings.Get(ctx, user.OrganizationID, domain.SettingTimeZone, &zone); err != nil {
+		slog.Error("failed to get organization time zone", "error", err)
+	}
+
+	if err := a.mailer.Send(ctx, newDeviceAlert(title, user, event, a.links, loadLocation(zone))); err != nil {
+		slog.Error("failed to send login alert", "user_id", user.ID, "error", err)
+	}
+}
+
+// newDeviceAlert builds the email sent on a login from a new IP/device
+func newDeviceAlert(title string, user *domain.User, event *domain.LoginEvent, builder *links.Builder, loc *time.Location) mail.Message {
+	text := fmt.Sprintf(`A new sign-in to your %s account (%s) was detected.
+
+Time:    %s
+IP:      %s
+Browser: %s
+Method:  %s
+
+If this was you, you can ignore this email. Otherwise change your password
+and review your recent sign-ins`,
+		title, user.Email, event.CreatedAt.In(loc).Format(time.RFC1123), event.IPAddress, event.UserAgent, event.Method)
+	if builder != nil {
+		text += " at " + builder.URL("/")
+	}
+	text += ".\n"
+
+	return mail.Message{
+		To:      []string{user.Email},
+		Subject: fmt.Sprintf("[%s] New sign-in from %s", title, event.IPAddress),
+		Text:    text,
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max]
+}
+
+// LoginEventsResponse wraps a page of login events
+type LoginEventsResponse struct {
+	Logins []domain.LoginEvent `json:"logins"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+}
+
+// GetMySecurity returns the recent login activity of the current user
+func (h *Handler) GetMySecurity(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+
+	filter := parseLoginEventFilter(r)
+	filter.UserID = userID
+	h.writeLoginEvents(w, r, filter)
+}
+
+// ListLogins returns the organization's login audit log (admin only)
+func (h *Handler) ListLogins(w http.ResponseWriter, r *http.Request) {
+	filter := parseLoginEventFilter(r)
+
+	if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid user ID")
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	if successStr := r.URL.Query().Get("success"); successStr != "" {
+		success, err := strconv.ParseBool(successStr)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid success filter")
+			return
+		}
+		filter.Success = &success
+	}
+
+	h.writeLoginEvents(w, r, filter)
+}
+
+func (h *Handler) writeLoginEvents(w http.ResponseWriter, r *http.Request, filter domain.LoginEventFilter) {
+	events, err := h.repos.Logins.List(r.Context(), h.org(r), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list logins")
+		return
+	}
+	if events == nil {
+		events = []domain.LoginEvent{}
+	}
+
+	writeJSON(w, http.StatusOK, LoginEventsResponse{
+		Logins: events,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+func parseLoginEventFilter(r *http.Request) domain.LoginEventFilter {
+	filter := domain.LoginEventFilter{Limit: 50}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+		filter.Limit = min(limit, 200)
+	}
+	if offset, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+	return filter
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/links"
+)
+
+func Test_newDeviceAlert_BuildsMessage(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Email: "user@example.com"}
+	event := &domain.LoginEvent{
+		IPAddress: "203.0.113.7",
+		UserAgent: "Firefox",
+		Method:    domain.LoginMethodPassword,
+		CreatedAt: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+	builder, _ := links.New("https://attic.example.com", nil)
+
+	msg := newDeviceAlert("Hackerspace", user, event, builder, time.UTC)
+
+	if len(msg.To) != 1 || msg.To[0] != "user@example.com" {
+		t.Errorf("unexpected recipients: %v", msg.To)
+	}
+	if !strings.Contains(msg.Subject, "Hackerspace") || !strings.Contains(msg.Subject, "203.0.113.7") {
+		t.Errorf("unexpected subject: %q", msg.Subject)
+	}
+	for _, want := range []string{"Firefox", "203.0.113.7", "https://attic.example.com/"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("expected body to contain %q", want)
+		}
+	}
+}
+
+func Test_newDeviceAlert_UsesTimeZone(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), Email: "user@example.com"}
+	event := &domain.LoginEvent{CreatedAt: time.Date(2025, 3, 1, 23, 30, 0, 0, time.UTC)}
+
+	msg := newDeviceAlert("Attic", user, event, nil, loadLocation("Europe/Berlin"))
+
+	if !strings.Contains(msg.Text, "Sun, 02 Mar 2025 00:30:00 CET") {
+		t.Errorf("expected sign-in time in the user's time zone, got %q", msg.Text)
+	}
+}
+
+func Test_parseLoginEventFilter(t *testing.T) {
+	filter := parseLoginEventFilter(httptest.NewRequest("GET", "/api/admin/logins?limit=1000&offset=20", nil))
+	if filter.Limit != 200 {
+		t.Errorf("expected limit to be capped at 200, got %d", filter.Limit)
+	}
+	if filter.Offset != 20 {
+		t.Errorf("expected offset 20, got %d", filter.Offset)
+	}
+
+	filter = parseLoginEventFilter(httptest.NewRequest("GET", "/api/me/security?limit=abc", nil))
+	if filter.Limit != 50 || filter.Offset != 0 {
+		t.Errorf("expected defaults, got %+v", filter)
+	}
+}
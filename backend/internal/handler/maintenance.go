@@ -0,0 +1,347 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// MaintenanceTaskRequest creates or replaces a maintenance task. Without an
+// interval the task is done once.
+type MaintenanceTaskRequest struct {
+	Name          string                  `json:"name"`
+	Notes         *string                 `json:"notes,omitempty"`
+	IntervalCount *int                    `json:"interval_count,omitempty"` // e.g. 3 for every 3 months
+	IntervalUnit  *domain.MaintenanceUnit `json:"interval_unit,omitempty"`  // day, week, month or year
+	DueAt         *string                 `json:"due_at,omitempty"`         // YYYY-MM-DD, defaults to one interval from today
+}
+
+// MaintenanceLogRequest records maintenance done on an asset, for a task
+// (completing it) or not
+type MaintenanceLogRequest struct {
+	Name   string  `json:"name,omitempty"`    // What was done, required outside a task
+	DoneAt *string `json:"done_at,omitempty"` // YYYY-MM-DD, defaults to today
+	Notes  *string `json:"notes,omitempty"`
+}
+
+// defaultUpcomingDays is how far ahead upcoming maintenance is listed by default
+const defaultUpcomingDays = 30
+
+// ListMaintenanceTasks returns the organization's maintenance tasks, soonest
+// due first
+func (h *Handler) ListMaintenanceTasks(w http.ResponseWriter, r *http.Request) {
+	tasks, err := h.repos.Maintenance.ListTasks(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list maintenance tasks")
+		return
+	}
+	h.writeMaintenanceTasks(w, r, tasks)
+}
+
+// ListUpcomingMaintenance returns the maintenance due in the next ?days=N
+// days (default 30), including overdue tasks
+func (h *Handler) ListUpcomingMaintenance(w http.ResponseWriter, r *http.Request) {
+	days := defaultUpcomingDays
+	if v := r.URL.Query().Get("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid days parameter")
+			return
+		}
+		days = n
+	}
+
+	until := today(h.location(r)).AddDate(0, 0, days)
+	tasks, err := h.repos.Maintenance.ListDueBefore(r.Context(), h.org(r), until)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list maintenance tasks")
+		return
+	}
+	h.writeMaintenanceTasks(w, r, tasks)
+}
+
+// ListAssetMaintenance returns the maintenance tasks of an asset
+func (h *Handler) ListAssetMaintenance(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.repos.Maintenance.ListTasksByAsset(r.Context(), asset.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list maintenance tasks")
+		return
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (h *Handler) CreateMaintenanceTask(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceTaskRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	task := &domain.MaintenanceTask{}
+	if err := applyMaintenanceTask(task, &req, today(h.location(r))); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	task.OrganizationID = asset.OrganizationID
+	task.AssetID = asset.ID
+	if err := h.repos.Maintenance.CreateTask(r.Context(), task); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create maintenance task")
+		return
+	}
+
+	task.AssetName = asset.Name
+	writeJSON(w, http.StatusCreated, task)
+}
+
+func (h *Handler) UpdateMaintenanceTask(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceTaskRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	task, ok := h.visibleMaintenanceTask(w, r)
+	if !ok {
+		return
+	}
+
+	if err := applyMaintenanceTask(task, &req, today(h.location(r))); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.repos.Maintenance.UpdateTask(r.Context(), task); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update maintenance task")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
+
+func (h *Handler) DeleteMaintenanceTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := h.visibleMaintenanceTask(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Maintenance.DeleteTask(r.Context(), task.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete maintenance task")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CompleteMaintenanceTask logs that a task was done and schedules it again
+// one interval later; one-off tasks are no longer due
+func (h *Handler) CompleteMaintenanceTask(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceLogRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	doneAt, err := parseDoneAt(req.DoneAt, today(h.location(r)))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	task, ok := h.visibleMaintenanceTask(w, r)
+	if !ok {
+		return
+	}
+
+	entry := &domain.MaintenanceLogEntry{
+		OrganizationID: task.OrganizationID,
+		AssetID:        task.AssetID,
+		TaskID:         &task.ID,
+		Name:           task.Name,
+		DoneAt:         doneAt,
+		Notes:          optionalText(req.Notes),
+		DoneBy:         currentUserID(r),
+	}
+	// Logging an earlier completion doesn't move the schedule back
+	last := doneAt
+	if task.LastDoneAt != nil && task.LastDoneAt.After(last) {
+		last = *task.LastDoneAt
+	}
+	task.DueAt = task.NextDue(last)
+
+	if err := h.repos.Maintenance.Complete(r.Context(), task, entry); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to complete maintenance task")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, task)
+}
+
+// ListMaintenanceLog returns the maintenance done on an asset, latest first
+func (h *Handler) ListMaintenanceLog(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.repos.Maintenance.ListLog(r.Context(), asset.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list maintenance log")
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// LogMaintenance records maintenance done on an asset outside a task, e.g. a
+// repair
+func (h *Handler) LogMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceLogRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	doneAt, err := parseDoneAt(req.DoneAt, today(h.location(r)))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	entry := &domain.MaintenanceLogEntry{
+		OrganizationID: asset.OrganizationID,
+		AssetID:        asset.ID,
+		Name:           name,
+		DoneAt:         doneAt,
+		Notes:          optionalText(req.Notes),
+		DoneBy:         currentUserID(r),
+	}
+	if err := h.repos.Maintenance.CreateLogEntry(r.Context(), entry); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to log maintenance")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// visibleMaintenanceTask returns the maintenance task of the "id" URL
+// parameter, writing an error response if it is invalid, doesn't exist or
+// belongs to an asset hidden from the caller
+func (h *Handler) visibleMaintenanceTask(w http.ResponseWriter, r *http.Request) (*domain.MaintenanceTask, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid maintenance task ID")
+		return nil, false
+	}
+
+	task, err := h.repos.Maintenance.GetTask(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get maintenance task")
+		return nil, false
+	}
+	hidden := false
+	if task != nil && task.HighValue {
+		if hidden, err = h.hidesHighValue(r); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+			return nil, false
+		}
+	}
+	if task == nil || hidden {
+		writeError(w, http.StatusNotFound, "maintenance task not found")
+		return nil, false
+	}
+	return task, true
+}
+
+// writeMaintenanceTasks responds with tasks, without those of high-value
+// assets hidden from the caller
+func (h *Handler) writeMaintenanceTasks(w http.ResponseWriter, r *http.Request, tasks []domain.MaintenanceTask) {
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if hide {
+		tasks = slices.DeleteFunc(tasks, func(t domain.MaintenanceTask) bool { return t.HighValue })
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+// applyMaintenanceTask validates req and copies it onto task; day is today,
+// from which the first due date of a recurring task is counted
+func applyMaintenanceTask(task *domain.MaintenanceTask, req *MaintenanceTaskRequest, day time.Time) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if (req.IntervalCount == nil) != (req.IntervalUnit == nil) {
+		return errors.New("interval_count and interval_unit must be set together")
+	}
+	if req.IntervalCount != nil && *req.IntervalCount < 1 {
+		return errors.New("interval_count must be at least 1")
+	}
+	if req.IntervalUnit != nil && !req.IntervalUnit.Valid() {
+		return fmt.Errorf("invalid interval_unit %q, expected day, week, month or year", *req.IntervalUnit)
+	}
+
+	task.Name = name
+	task.Notes = optionalText(req.Notes)
+	task.IntervalCount = req.IntervalCount
+	task.IntervalUnit = req.IntervalUnit
+
+	if req.DueAt != nil && *req.DueAt != "" {
+		// Stored as a DATE, the location doesn't change the day
+		due, err := parseDate(*req.DueAt, time.UTC)
+		if err != nil {
+			return errors.New("invalid due_at, expected YYYY-MM-DD")
+		}
+		task.DueAt = &due
+		return nil
+	}
+	if !task.Recurring() {
+		return errors.New("due_at is required for one-off tasks")
+	}
+	y, m, d := day.Date()
+	task.DueAt = task.NextDue(time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+	return nil
+}
+
+// parseDoneAt parses the date maintenance was done, today by default; it
+// can't be in the future
+func parseDoneAt(value *string, day time.Time) (time.Time, error) {
+	y, m, d := day.Date()
+	todayUTC := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if value == nil || *value == "" {
+		return todayUTC, nil
+	}
+	doneAt, err := parseDate(*value, time.UTC)
+	if err != nil {
+		return time.Time{}, errors.New("invalid done_at, expected YYYY-MM-DD")
+	}
+	if doneAt.After(todayUTC) {
+		return time.Time{}, errors.New("done_at can't be in the future")
+	}
+	return doneAt, nil
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_applyMaintenanceTask(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	three, month := 3, domain.MaintenanceMonth
+	due := "2025-04-01"
+
+	task := &domain.MaintenanceTask{}
+	req := &MaintenanceTaskRequest{Name: " Descale ", IntervalCount: &three, IntervalUnit: &month}
+	if err := applyMaintenanceTask(task, req, day); err != nil {
+		t.Fatal(err)
+	}
+	if task.Name != "Descale" || !task.DueAt.Equal(*utcDate(2025, 6, 10)) {
+		t.Errorf("expected the first due date one interval from today, got %+v", task)
+	}
+
+	req.DueAt = &due
+	if err := applyMaintenanceTask(task, req, day); err != nil || !task.DueAt.Equal(*utcDate(2025, 4, 1)) {
+		t.Errorf("expected the given due date, got %v, %v", task.DueAt, err)
+	}
+
+	oneOff := &domain.MaintenanceTask{}
+	if err := applyMaintenanceTask(oneOff, &MaintenanceTaskRequest{Name: "Replace battery", DueAt: &due}, day); err != nil || oneOff.Recurring() {
+		t.Errorf("expected a one-off task, got %+v, %v", oneOff, err)
+	}
+}
+
+func Test_applyMaintenanceTask_Invalid(t *testing.T) {
+	zero, three := 0, 3
+	month, fortnight := domain.MaintenanceMonth, domain.MaintenanceUnit("fortnight")
+	invalidDate := "next week"
+
+	tests := map[string]MaintenanceTaskRequest{
+		"no name":                  {IntervalCount: &three, IntervalUnit: &month},
+		"count without unit":       {Name: "Descale", IntervalCount: &three},
+		"zero count":               {Name: "Descale", IntervalCount: &zero, IntervalUnit: &month},
+		"unknown unit":             {Name: "Descale", IntervalCount: &three, IntervalUnit: &fortnight},
+		"invalid due date":         {Name: "Descale", IntervalCount: &three, IntervalUnit: &month, DueAt: &invalidDate},
+		"one-off without due date": {Name: "Replace battery"},
+	}
+	for name, req := range tests {
+		if err := applyMaintenanceTask(&domain.MaintenanceTask{}, &req, time.Now()); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func Test_parseDoneAt(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)
+	past, future := "2025-03-01", "2025-03-11"
+
+	if got, err := parseDoneAt(nil, day); err != nil || !got.Equal(*utcDate(2025, 3, 10)) {
+		t.Errorf("expected today, got %v, %v", got, err)
+	}
+	if got, err := parseDoneAt(&past, day); err != nil || !got.Equal(*utcDate(2025, 3, 1)) {
+		t.Errorf("expected 2025-03-01, got %v, %v", got, err)
+	}
+	if _, err := parseDoneAt(&future, day); err == nil {
+		t.Error("expected a future date to fail")
+	}
+}
+
+func Test_LogMaintenance_RequiresName(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	id := uuid.NewString()
+	req := withChiURLParam(httptest.NewRequest(http.MethodPost, "/api/assets/"+id+"/maintenance/log", strings.NewReader(`{"name": " "}`)), "id", id)
+	rr := httptest.NewRecorder()
+
+	h.LogMaintenance(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func Test_ListUpcomingMaintenance_InvalidDays(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	rr := httptest.NewRecorder()
+
+	h.ListUpcomingMaintenance(rr, httptest.NewRequest(http.MethodGet, "/api/maintenance/upcoming?days=-1", nil))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
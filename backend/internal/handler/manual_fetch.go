@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/manuals"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+// manualDescriptionPrefix marks attachments added by the manual fetcher
+const manualDescriptionPrefix = "Manual from "
+
+var (
+	errAssetNotFound = errors.New("asset not found")
+	errNoProduct     = errors.New("asset has no brand and model attributes")
+	errManualPresent = errors.New("asset already has a fetched manual")
+	errQuotaExceeded = errors.New("storage quota exceeded")
+)
+
+// ManualFetcher attaches the product manual (PDF) to new assets whose
+// attributes name a brand and model
+type ManualFetcher struct {
+	repos        *Repositories
+	storage      FileStorage
+	fetcher      *manuals.Fetcher
+	storageQuota int64 // Max attachment bytes per organization (0 = unlimited)
+	queue        chan uuid.UUID
+}
+
+// NewManualFetcher creates the enrichment job; call Run to start processing
+// asset events
+func NewManualFetcher(repos *Repositories, storage FileStorage, fetcher *manuals.Fetcher, storageQuota int64) *ManualFetcher {
+	return &ManualFetcher{
+		repos:        repos,
+		storage:      storage,
+		fetcher:      fetcher,
+		storageQuota: storageQuota,
+		queue:        make(chan uuid.UUID, 256),
+	}
+}
+
+// HandleEvent queues newly created assets. It never blocks the publisher; if
+// the queue is full the manual can still be fetched on demand.
+func (m *ManualFetcher) HandleEvent(ctx context.Context, e events.Event) {
+	if e.Type != events.AssetCreated {
+		return
+	}
+	select {
+	case m.queue <- e.SubjectID:
+	default:
+		slog.Warn("manual fetch queue full, skipping asset", "asset_id", e.SubjectID)
+	}
+}
+
+// Run processes queued assets until ctx is cancelled
+func (m *ManualFetcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-m.queue:
+			att, err := m.FetchForAsset(ctx, id)
+			switch {
+			case err == nil:
+				slog.Info("attached product manual", "asset_id", id, "attachment_id", att.ID)
+			case errors.Is(err, errAssetNotFound), errors.Is(err, errNoProduct), errors.Is(err, errManualPresent):
+			case errors.Is(err, manuals.ErrNotFound):
+				slog.Debug("no product manual found", "asset_id", id, "error", err)
+			default:
+				slog.Error("failed to fetch product manual", "asset_id", id, "error", err)
+			}
+		}
+	}
+}
+
+// FetchForAsset looks up the manual of an asset's brand and model and stores
+// it as an attachment
+func (m *ManualFetcher) FetchForAsset(ctx context.Context, assetID uuid.UUID) (*domain.Attachment, error) {
+	asset, err := m.repos.Assets.GetByID(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil {
+		return nil, errAssetNotFound
+	}
+
+	var attrs map[string]any
+	if len(asset.Attributes) > 0 {
+		if err := json.Unmarshal(asset.Attributes, &attrs); err != nil {
+			return nil, err
+		}
+	}
+	product, ok := manuals.ProductFromAttributes(attrs)
+	if !ok {
+		return nil, errNoProduct
+	}
+
+	existing, err := m.repos.Attachments.ListByAsset(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	for _, att := range existing {
+		if att.Description != nil && strings.HasPrefix(*att.Description, manualDescriptionPrefix) {
+			return nil, errManualPresent
+		}
+	}
+
+	manual, err := m.fetcher.Fetch(ctx, product)
+	if err != nil {
+		return nil, err
+	}
+
+	size := int64(len(manual.Data))
+	if m.storageQuota > 0 {
+		used, err := m.repos.Attachments.TotalSize(ctx, asset.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		if used+size > m.storageQuota {
+			return nil, errQuotaExceeded
+		}
+	}
+
+	contentType := "application/pdf"
+	key, err := m.storage.UploadObject(ctx, storage.Object{
+		OrganizationID: asset.OrganizationID,
+		AssetID:        asset.ID,
+		FileName:       manual.FileName,
+	}, contentType, bytes.NewReader(manual.Data))
+	if err != nil {
+		return nil, err
+	}
+
+	description := manualDescriptionPrefix + manual.SourceURL
+	attachment := &domain.Attachment{
+		AssetID:     assetID,
+		FileKey:     key,
+		FileName:    manual.FileName,
+		FileSize:    size,
+		ContentType: &contentType,
+		Description: &description,
+	}
+	if err := m.repos.Attachments.Create(ctx, attachment); err != nil {
+		m.storage.Delete(ctx, key)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// FetchAssetManual fetches the manual of an asset on demand
+func (m *ManualFetcher) FetchAssetManual(w http.ResponseWriter, r *http.Request) {
+	assetID, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+
+	att, err := m.FetchForAsset(r.Context(), assetID)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusCreated, att)
+	case errors.Is(err, errAssetNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errNoProduct):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, errManualPresent):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, errQuotaExceeded):
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, manuals.ErrNotFound):
+		writeError(w, http.StatusNotFound, "no manual found for this product")
+	default:
+		slog.Error("failed to fetch product manual", "asset_id", assetID, "error", err)
+		writeError(w, http.StatusBadGateway, "failed to fetch manual")
+	}
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// SelectOrganizationRequest selects the organization used for requests
+// without an X-Org-ID header
+type SelectOrganizationRequest struct {
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+// AddMemberRequest adds an existing user to the caller's organization
+type AddMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"` // Default user
+}
+
+// MemberRoleRequest changes the role of a member
+type MemberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// ListMyOrganizations returns the organizations of the current user with
+// their role in each, the selected one first
+func (h *Handler) ListMyOrganizations(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	memberships, err := h.repos.Organizations.ListMemberships(r.Context(), *userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list organizations")
+		return
+	}
+	writeJSON(w, http.StatusOK, memberships)
+}
+
+// SelectMyOrganization switches the organization of the current user's
+// requests without an X-Org-ID header
+func (h *Handler) SelectMyOrganization(w http.ResponseWriter, r *http.Request) {
+	userID := currentUserID(r)
+	if userID == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+	var req SelectOrganizationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ok, err := h.repos.Organizations.SelectOrganization(r.Context(), req.OrganizationID, *userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to select organization")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "organization not found")
+		return
+	}
+	h.ListMyOrganizations(w, r)
+}
+
+// ListMembers returns the members of the caller's organization, including
+// those of other organizations
+func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	members, err := h.repos.Organizations.ListMembers(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list members")
+		return
+	}
+	writeJSON(w, http.StatusOK, members)
+}
+
+// AddMember adds a user of another organization to the caller's
+// organization. Accounts stay with the organization that created them, which
+// manages e.g. their password.
+func (h *Handler) AddMember(w http.ResponseWriter, r *http.Request) {
+	var req AddMemberRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" {
+		writeError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+	role := domain.UserRoleUser
+	if req.Role != "" {
+		role = domain.UserRole(req.Role)
+	}
+	if !h.validMemberRole(w, r, role) {
+		return
+	}
+
+	user, err := h.repos.Users.GetByEmail(r.Context(), req.Email)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if user == nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if user.ServiceAccount {
+		writeError(w, http.StatusBadRequest, "service accounts belong to one organization")
+		return
+	}
+
+	orgID := h.org(r)
+	added, err := h.repos.Organizations.AddMember(r.Context(), orgID, user.ID, role)
+	if err != nil {
+		slog.Error("failed to add member", "organization_id", orgID, "user_id", user.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to add member")
+		return
+	}
+	if !added {
+		writeError(w, http.StatusConflict, "user is already a member")
+		return
+	}
+	h.writeMember(w, r, http.StatusCreated, orgID, user.ID)
+}
+
+// UpdateMember changes the role of a member from another organization; the
+// organization's own users get their role at /api/users/{id}
+func (h *Handler) UpdateMember(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUUID(r, "userId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+	var req MemberRoleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	role := domain.UserRole(req.Role)
+	if role == "" {
+		writeError(w, http.StatusBadRequest, "role is required")
+		return
+	}
+	if !h.validMemberRole(w, r, role) {
+		return
+	}
+
+	orgID := h.org(r)
+	ok, err := h.repos.Organizations.SetMemberRole(r.Context(), orgID, userID, role)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update member")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "member of another organization not found")
+		return
+	}
+	h.writeMember(w, r, http.StatusOK, orgID, userID)
+}
+
+// RemoveMember removes a member from another organization; the
+// organization's own users are deleted at /api/users/{id} instead
+func (h *Handler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUUID(r, "userId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+	ok, err := h.repos.Organizations.RemoveMember(r.Context(), h.org(r), userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to remove member")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "member of another organization not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validMemberRole checks that a role exists in the caller's organization,
+// writing a bad request response if not
+func (h *Handler) validMemberRole(w http.ResponseWriter, r *http.Request, role domain.UserRole) bool {
+	exists, err := roleExists(r.Context(), h.repos.Roles, h.org(r), role)
+	if err != nil {
+		slog.Error("failed to get role", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return false
+	}
+	if !exists {
+		writeError(w, http.StatusBadRequest, "unknown role: "+string(role))
+		return false
+	}
+	return true
+}
+
+func (h *Handler) writeMember(w http.ResponseWriter, r *http.Request, status int, orgID, userID uuid.UUID) {
+	member, err := h.repos.Organizations.GetMember(r.Context(), orgID, userID)
+	if err != nil || member == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get member")
+		return
+	}
+	writeJSON(w, status, member)
+}
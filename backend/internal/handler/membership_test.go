@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_Membership_Validation(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	tests := map[string]struct {
+		handler http.HandlerFunc
+		body    string
+		status  int
+	}{
+		"list without user":   {handler: h.ListMyOrganizations, status: http.StatusUnauthorized},
+		"select without user": {handler: h.SelectMyOrganization, body: `{}`, status: http.StatusUnauthorized},
+		"add invalid body":    {handler: h.AddMember, body: `{`, status: http.StatusBadRequest},
+		"add without email":   {handler: h.AddMember, body: `{"role":"user"}`, status: http.StatusBadRequest},
+		"add unknown role":    {handler: h.AddMember, body: `{"email":"a@example.com","role":"viewer"}`, status: http.StatusBadRequest},
+		"update invalid ID":   {handler: h.UpdateMember, body: `{"role":"user"}`, status: http.StatusBadRequest},
+		"remove invalid ID":   {handler: h.RemoveMember, status: http.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/organization/members", strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		tt.handler(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", name, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
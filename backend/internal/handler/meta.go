@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIVersion is the version of the REST API; it changes with breaking changes
+const APIVersion = "1"
+
+// Deprecation announces an endpoint that will change or be removed
+type Deprecation struct {
+	Method      string     `json:"method"`
+	Path        string     `json:"path"`
+	Since       time.Time  `json:"since"`
+	Sunset      *time.Time `json:"sunset,omitempty"`      // Removal date, if decided
+	Replacement string     `json:"replacement,omitempty"` // Path of the endpoint to use instead
+	Notice      string     `json:"notice"`
+}
+
+// MetaResponse describes the API to integrations
+type MetaResponse struct {
+	APIVersion    string          `json:"api_version"`
+	ServerVersion string          `json:"server_version"`
+	Features      map[string]bool `json:"features"`
+	Deprecations  []Deprecation   `json:"deprecations"`
+}
+
+// APIMeta serves the API version, the enabled features and the deprecation
+// notices, and marks deprecated endpoints with Deprecation (RFC 9745) and
+// Sunset (RFC 8594) headers so integrations can adapt before they change
+type APIMeta struct {
+	serverVersion string
+	features      map[string]bool
+	deprecations  []Deprecation
+}
+
+// NewAPIMeta creates the API description of a server version with its
+// enabled features, e.g. "oidc" or "search"
+func NewAPIMeta(serverVersion string, features map[string]bool) *APIMeta {
+	return &APIMeta{serverVersion: serverVersion, features: features}
+}
+
+// Deprecate lists d in the deprecation notices and returns middleware for its
+// route that adds the deprecation headers. Routes are set up before serving,
+// so it must not be called afterwards.
+func (m *APIMeta) Deprecate(d Deprecation) func(http.Handler) http.Handler {
+	m.deprecations = append(m.deprecations, d)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+			if d.Sunset != nil {
+				h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if d.Replacement != "" {
+				h.Add("Link", "<"+d.Replacement+`>; rel="successor-version"`)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Get returns the API description
+func (m *APIMeta) Get(w http.ResponseWriter, r *http.Request) {
+	deprecations := m.deprecations
+	if deprecations == nil {
+		deprecations = []Deprecation{}
+	}
+	writeJSON(w, http.StatusOK, MetaResponse{
+		APIVersion:    APIVersion,
+		ServerVersion: m.serverVersion,
+		Features:      m.features,
+		Deprecations:  deprecations,
+	})
+}
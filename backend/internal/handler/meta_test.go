@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_APIMeta(t *testing.T) {
+	meta := NewAPIMeta("1.2.3", map[string]bool{"search": true})
+	w := httptest.NewRecorder()
+	meta.Get(w, httptest.NewRequest(http.MethodGet, "/api/meta", nil))
+
+	var resp MetaResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.APIVersion != APIVersion || resp.ServerVersion != "1.2.3" || !resp.Features["search"] {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if resp.Deprecations == nil || len(resp.Deprecations) != 0 {
+		t.Errorf("expected an empty deprecation list, got %v", resp.Deprecations)
+	}
+}
+
+func Test_APIMeta_Deprecate(t *testing.T) {
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)
+	meta := NewAPIMeta("dev", nil)
+	deprecated := meta.Deprecate(Deprecation{
+		Method:      http.MethodGet,
+		Path:        "/api/",
+		Since:       since,
+		Sunset:      &sunset,
+		Replacement: "/api/meta",
+		Notice:      "Use /api/meta",
+	})
+
+	w := httptest.NewRecorder()
+	deprecated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/", nil))
+	if got := w.Header().Get("Deprecation"); got != "@1790812800" {
+		t.Errorf("Deprecation = %q", got)
+	}
+	if got := w.Header().Get("Sunset"); got != "Thu, 01 Apr 2027 00:00:00 GMT" {
+		t.Errorf("Sunset = %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</api/meta>; rel="successor-version"` {
+		t.Errorf("Link = %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	meta.Get(w, httptest.NewRequest(http.MethodGet, "/api/meta", nil))
+	var resp MetaResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Deprecations) != 1 || resp.Deprecations[0].Replacement != "/api/meta" {
+		t.Errorf("expected the deprecation to be listed, got %+v", resp.Deprecations)
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/metrics"
+)
+
+// CollectMetrics computes the business metrics exposed to Prometheus, e.g.
+// to alert on expiring warranties or storage running out; samples are
+// labeled with their organization
+func (h *Handler) CollectMetrics(ctx context.Context) ([]metrics.Family, error) {
+	families := []metrics.Family{
+		gauge("attic_assets_total", "Number of assets."),
+		gauge("attic_assets_value", "Total purchase value of all assets."),
+		gauge("attic_warranties_expiring_30d", "Warranties ending within the next 30 days."),
+		gauge("attic_warranties_expired", "Warranties that have ended."),
+		gauge("attic_storage_bytes", "Combined size of all attachments in bytes."),
+		gauge("attic_recurring_costs_monthly", "Recurring costs normalized to one month."),
+	}
+	err := h.forEachOrganization(ctx, func(orgID uuid.UUID) error {
+		values, err := h.organizationMetrics(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		labels := []metrics.Label{{Name: "organization_id", Value: orgID.String()}}
+		for i, value := range values {
+			families[i].Samples = append(families[i].Samples, metrics.Sample{Labels: labels, Value: value})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if h.storageQuota > 0 {
+		quota := gauge("attic_storage_quota_bytes", "Attachment storage quota in bytes.")
+		quota.Samples = []metrics.Sample{{Value: float64(h.storageQuota)}}
+		families = append(families, quota)
+	}
+	return families, nil
+}
+
+// organizationMetrics returns the values of the families of CollectMetrics
+// for one organization, in the same order
+func (h *Handler) organizationMetrics(ctx context.Context, orgID uuid.UUID) ([]float64, error) {
+	_, assetCount, err := h.repos.Assets.List(ctx, orgID, domain.AssetFilter{}, domain.Pagination{Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	totalValue, err := h.repos.Assets.GetTotalValue(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := h.orgTimeZone(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	// DATE columns are read as UTC midnight: compare against today's date in UTC
+	y, m, d := today(loadLocation(zone)).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	warranties, err := h.repos.Warranties.ListExpiringBefore(ctx, orgID, day.AddDate(0, 0, 30))
+	if err != nil {
+		return nil, err
+	}
+	expiring, expired := countWarranties(warranties, day)
+
+	storageBytes, err := h.repos.Attachments.TotalSize(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	costs, err := h.repos.Costs.Totals(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	return []float64{
+		float64(assetCount),
+		totalValue,
+		float64(expiring),
+		float64(expired),
+		float64(storageBytes),
+		costs.Monthly,
+	}, nil
+}
+
+// countWarranties splits warranties ending up to a cut-off into those ending
+// on or after day and those that already ended
+func countWarranties(warranties []domain.Warranty, day time.Time) (expiring, expired int) {
+	for _, w := range warranties {
+		if w.EndDate == nil {
+			continue
+		}
+		if w.EndDate.Before(day) {
+			expired++
+		} else {
+			expiring++
+		}
+	}
+	return expiring, expired
+}
+
+func gauge(name, help string) metrics.Family {
+	return metrics.Family{Name: name, Help: help, Type: metrics.TypeGauge}
+}
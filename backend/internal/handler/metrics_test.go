@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_countWarranties(t *testing.T) {
+	day := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	ended, today, soon := day.AddDate(0, 0, -1), day, day.AddDate(0, 0, 20)
+	warranties := []domain.Warranty{{EndDate: &ended}, {EndDate: &today}, {EndDate: &soon}, {}}
+
+	expiring, expired := countWarranties(warranties, day)
+
+	if expiring != 2 || expired != 1 {
+		t.Errorf("expected 2 expiring and 1 expired, got %d and %d", expiring, expired)
+	}
+}
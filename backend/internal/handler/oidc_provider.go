@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// OIDCProviderRequest updates the organization's OIDC provider.
+// An empty client_secret keeps the stored secret.
+type OIDCProviderRequest struct {
+	IssuerURL    string `json:"issuer_url"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Enabled      bool   `json:"enabled"`
+}
+
+// OIDCProviderResponse never includes the client secret
+type OIDCProviderResponse struct {
+	IssuerURL       string `json:"issuer_url"`
+	ClientID        string `json:"client_id"`
+	HasClientSecret bool   `json:"has_client_secret"`
+	Enabled         bool   `json:"enabled"`
+	RestartRequired bool   `json:"restart_required,omitempty"` // Provider changes apply on the next server start
+}
+
+// GetOIDCProvider returns the organization's OIDC provider configuration (admin only)
+func (h *Handler) GetOIDCProvider(w http.ResponseWriter, r *http.Request) {
+	if !h.instanceAdmin(w, r) {
+		return
+	}
+	var provider domain.OIDCProvider
+	found, err := h.repos.Settings.Get(r.Context(), h.orgID, domain.SettingOIDC, &provider)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get OIDC provider")
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "OIDC provider not configured")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, toOIDCProviderResponse(&provider))
+}
+
+// UpdateOIDCProvider creates or replaces the organization's OIDC provider (admin only)
+func (h *Handler) UpdateOIDCProvider(w http.ResponseWriter, r *http.Request) {
+	if !h.instanceAdmin(w, r) {
+		return
+	}
+	if h.secrets == nil {
+		writeError(w, http.StatusServiceUnavailable, "encryption key not configured")
+		return
+	}
+
+	var req OIDCProviderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.IssuerURL = strings.TrimRight(strings.TrimSpace(req.IssuerURL), "/")
+	req.ClientID = strings.TrimSpace(req.ClientID)
+	if err := validateIssuerURL(req.IssuerURL); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.ClientID == "" {
+		writeError(w, http.StatusBadRequest, "client_id is required")
+		return
+	}
+
+	var provider domain.OIDCProvider
+	if _, err := h.repos.Settings.Get(r.Context(), h.orgID, domain.SettingOIDC, &provider); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get OIDC provider")
+		return
+	}
+
+	provider.IssuerURL = req.IssuerURL
+	provider.ClientID = req.ClientID
+	provider.Enabled = req.Enabled
+	if req.ClientSecret != "" {
+		encrypted, err := h.secrets.Encrypt(req.ClientSecret)
+		if err != nil {
+			slog.Error("failed to encrypt client secret", "error", err)
+			writeError(w, http.StatusInternalServerError, "failed to update OIDC provider")
+			return
+		}
+		provider.ClientSecretEncrypted = encrypted
+	}
+
+	// Don't let an admin enable a provider users can't log in with
+	if provider.Enabled {
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		if err := auth.DiscoverIssuer(ctx, provider.IssuerURL); err != nil {
+			writeError(w, http.StatusBadRequest, "OIDC discovery failed for issuer: "+err.Error())
+			return
+		}
+	}
+
+	if err := h.repos.Settings.Set(r.Context(), h.orgID, domain.SettingOIDC, provider); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update OIDC provider")
+		return
+	}
+
+	resp := toOIDCProviderResponse(&provider)
+	resp.RestartRequired = true
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DeleteOIDCProvider removes the organization's OIDC provider, falling back to the
+// environment configuration on the next start (admin only)
+func (h *Handler) DeleteOIDCProvider(w http.ResponseWriter, r *http.Request) {
+	if !h.instanceAdmin(w, r) {
+		return
+	}
+	if err := h.repos.Settings.Delete(r.Context(), h.orgID, domain.SettingOIDC); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete OIDC provider")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validateIssuerURL(issuer string) error {
+	u, err := url.Parse(issuer)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return errInvalidIssuerURL
+	}
+	return nil
+}
+
+var errInvalidIssuerURL = errors.New("issuer_url must be an absolute http(s) URL")
+
+func toOIDCProviderResponse(p *domain.OIDCProvider) OIDCProviderResponse {
+	return OIDCProviderResponse{
+		IssuerURL:       p.IssuerURL,
+		ClientID:        p.ClientID,
+		HasClientSecret: p.ClientSecretEncrypted != "",
+		Enabled:         p.Enabled,
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_validateIssuerURL(t *testing.T) {
+	valid := []string{"https://accounts.example.com", "http://localhost:8180/realms/attic"}
+	invalid := []string{"", "accounts.example.com", "ftp://example.com", "https://"}
+
+	for _, issuer := range valid {
+		if err := validateIssuerURL(issuer); err != nil {
+			t.Errorf("expected %q to be valid, got %v", issuer, err)
+		}
+	}
+	for _, issuer := range invalid {
+		if err := validateIssuerURL(issuer); err == nil {
+			t.Errorf("expected %q to be invalid", issuer)
+		}
+	}
+}
+
+func Test_toOIDCProviderResponse_HidesSecret(t *testing.T) {
+	resp := toOIDCProviderResponse(&domain.OIDCProvider{
+		IssuerURL:             "https://accounts.example.com",
+		ClientID:              "attic",
+		ClientSecretEncrypted: "v1:c2VjcmV0",
+		Enabled:               true,
+	})
+
+	data, _ := json.Marshal(resp)
+	if strings.Contains(string(data), "c2VjcmV0") {
+		t.Errorf("expected response not to contain the secret: %s", data)
+	}
+	if !resp.HasClientSecret {
+		t.Error("expected has_client_secret to be true")
+	}
+}
+
+func Test_UpdateOIDCProvider_WithoutEncryption_ReturnsUnavailable(t *testing.T) {
+	h := &Handler{}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/admin/oidc", strings.NewReader(`{}`))
+	rec := httptest.NewRecorder()
+	h.UpdateOIDCProvider(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/repository"
+)
+
+// OrganizationRequest updates the name and description of an organization
+type OrganizationRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+// CreateOrganizationRequest creates an organization and its first admin
+type CreateOrganizationRequest struct {
+	OrganizationRequest
+	Admin CreateUserRequest `json:"admin"` // The role is always admin
+}
+
+// CreateOrganizationResponse is a new organization and its first admin
+type CreateOrganizationResponse struct {
+	Organization domain.Organization `json:"organization"`
+	Admin        UserResponse        `json:"admin"`
+}
+
+// ScopeTo returns middleware for the routes of a resource that answers not
+// found when the first path segment below the route is the ID of a record of
+// another organization than the caller's. Other segments, e.g. "tree", pass.
+func (h *Handler) ScopeTo(res repository.Resource) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path := r.URL.Path
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+				path = rctx.RoutePath
+			}
+			segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+			id, err := uuid.Parse(segment)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			owned, err := h.repos.Organizations.OwnedBy(r.Context(), h.org(r), res, id)
+			if err != nil {
+				slog.Error("failed to check organization", "resource", res, "id", id, "error", err)
+				writeError(w, http.StatusInternalServerError, "internal server error")
+				return
+			}
+			if !owned {
+				writeError(w, http.StatusNotFound, string(res)+" not found")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// instanceAdmin reports whether the caller may change settings of the whole
+// server, e.g. the OIDC provider, which are stored with the default
+// organization; it writes a forbidden response if not
+func (h *Handler) instanceAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.org(r) != h.orgID {
+		writeError(w, http.StatusForbidden, "only admins of the default organization can change this setting")
+		return false
+	}
+	return true
+}
+
+// GetOrganization returns the caller's organization
+func (h *Handler) GetOrganization(w http.ResponseWriter, r *http.Request) {
+	org, err := h.repos.Organizations.GetByID(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get organization")
+		return
+	}
+	if org == nil {
+		writeError(w, http.StatusNotFound, "organization not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, org)
+}
+
+// UpdateOrganization renames the caller's organization (admin only)
+func (h *Handler) UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	var req OrganizationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	org := &domain.Organization{ID: h.org(r), Name: req.Name, Description: req.Description}
+	if err := h.repos.Organizations.Update(r.Context(), org); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update organization")
+		return
+	}
+	updated, err := h.repos.Organizations.GetByID(r.Context(), org.ID)
+	if err != nil || updated == nil {
+		writeError(w, http.StatusInternalServerError, "failed to get organization")
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// SetOrganizations enables creating organizations
+func (h *UserManagementHandler) SetOrganizations(orgs *repository.OrganizationRepository) {
+	h.orgs = orgs
+}
+
+// CreateOrganization creates an organization with a local admin account, who
+// then sets it up like a separate instance. Only admins of the default
+// organization can create organizations.
+func (h *UserManagementHandler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	if h.orgs == nil {
+		writeError(w, http.StatusNotImplemented, "organizations cannot be created")
+		return
+	}
+	if h.org(r) != h.defaultOrgID {
+		writeError(w, http.StatusForbidden, "organizations are created by admins of the default organization")
+		return
+	}
+
+	var req CreateOrganizationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if req.Admin.Email == "" || req.Admin.Password == "" {
+		writeError(w, http.StatusBadRequest, "admin email and password are required")
+		return
+	}
+	if err := h.passwordPolicy.Validate(req.Admin.Password); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := h.userRepo.GetByEmail(r.Context(), req.Admin.Email)
+	if err != nil {
+		slog.Error("failed to check existing user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if existing != nil {
+		writeError(w, http.StatusConflict, "email already in use")
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Admin.Password)
+	if err != nil {
+		slog.Error("failed to hash password", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	org := &domain.Organization{Name: req.Name, Description: req.Description}
+	if err := h.orgs.Create(r.Context(), org); err != nil {
+		slog.Error("failed to create organization", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to create organization")
+		return
+	}
+
+	// Accounts of a new organization are trusted like those admins create
+	now := time.Now()
+	admin := &domain.User{
+		OrganizationID: org.ID,
+		Email:          req.Admin.Email,
+		PasswordHash:   &hash,
+		Role:           domain.UserRoleAdmin,
+		VerifiedAt:     &now,
+	}
+	if req.Admin.Name != "" {
+		admin.DisplayName = &req.Admin.Name
+	}
+	if err := h.userRepo.Create(r.Context(), admin); err != nil {
+		slog.Error("failed to create organization admin", "organization_id", org.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to create admin")
+		return
+	}
+
+	slog.Info("created organization", "organization_id", org.ID, "name", org.Name, "admin", admin.Email)
+	writeJSON(w, http.StatusCreated, CreateOrganizationResponse{Organization: *org, Admin: toUserResponse(admin)})
+}
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// organizationDeletionTTL is how long a deletion confirmation token is valid
+const organizationDeletionTTL = 15 * time.Minute
+
+// pendingDeletion is a requested organization deletion, stored with the
+// organization until confirmed
+type pendingDeletion struct {
+	TokenHash   string    `json:"token_hash"`
+	RequestedBy uuid.UUID `json:"requested_by"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// OrganizationDeletionResponse is the token confirming an organization
+// deletion
+type OrganizationDeletionResponse struct {
+	Organization      domain.Organization `json:"organization"`
+	ConfirmationToken string              `json:"confirmation_token"`
+	ExpiresAt         time.Time           `json:"expires_at"`
+}
+
+// DeleteOrganizationRequest confirms an organization deletion
+type DeleteOrganizationRequest struct {
+	ConfirmationToken string `json:"confirmation_token"`
+}
+
+// exportedAttachment is the part of an exported attachment row needed to add
+// its file to the archive
+type exportedAttachment struct {
+	ID       uuid.UUID `json:"id"`
+	FileKey  string    `json:"file_key"`
+	FileName string    `json:"file_name"`
+}
+
+// ExportOrganizationData bundles everything stored for an organization into a
+// zip archive, e.g. for a data subject access request: a JSON file per table
+// under data/ and the attachment files under attachments/{id}/. Credentials
+// are left out. Only admins of the default organization can export.
+func (h *Handler) ExportOrganizationData(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.managedOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	tables, err := h.repos.Organizations.ExportData(r.Context(), org.ID)
+	if err != nil {
+		slog.Error("failed to export organization", "organization_id", org.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to export organization")
+		return
+	}
+	branding, err := h.loadBranding(r.Context(), org.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+
+	filename := fmt.Sprintf("organization-%s-%s.zip", archiveName(org.Name), time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// From here on errors can only be logged, the response has started
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	for _, table := range tables {
+		f, err := zw.Create("data/" + table.Name + ".json")
+		if err != nil {
+			slog.Error("failed to write organization export", "table", table.Name, "error", err)
+			return
+		}
+		if _, err := f.Write(table.Rows); err != nil {
+			slog.Error("failed to write organization export", "table", table.Name, "error", err)
+			return
+		}
+
+		if table.Name != "attachments" {
+			continue
+		}
+		var attachments []exportedAttachment
+		if err := json.Unmarshal(table.Rows, &attachments); err != nil {
+			slog.Error("failed to read exported attachments", "error", err)
+			continue
+		}
+		for _, att := range attachments {
+			name := fmt.Sprintf("attachments/%s/%s", att.ID, archiveName(att.FileName))
+			if err := h.copyToArchive(r.Context(), zw, name, att.FileKey); err != nil {
+				slog.Error("failed to add attachment to archive", "attachment_id", att.ID, "error", err)
+			}
+		}
+	}
+
+	if branding.LogoKey != nil {
+		if err := h.copyToArchive(r.Context(), zw, "branding/"+archiveName(path.Base(*branding.LogoKey)), *branding.LogoKey); err != nil {
+			slog.Error("failed to add logo to archive", "organization_id", org.ID, "error", err)
+		}
+	}
+	slog.Info("exported organization", "organization_id", org.ID, "user_id", currentUserID(r))
+}
+
+// RequestOrganizationDeletion returns a token to confirm deleting an
+// organization with, valid for a few minutes. Only admins of the default
+// organization can delete organizations, and never the default one.
+func (h *Handler) RequestOrganizationDeletion(w http.ResponseWriter, r *http.Request) {
+	org, ok := h.deletableOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create confirmation token")
+		return
+	}
+	pending := pendingDeletion{
+		TokenHash:   hashSecretToken(token),
+		RequestedBy: *currentUserID(r),
+		ExpiresAt:   time.Now().Add(organizationDeletionTTL),
+	}
+	if err := h.repos.Settings.Set(r.Context(), org.ID, domain.SettingDeletion, pending); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to request deletion")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, OrganizationDeletionResponse{
+		Organization:      *org,
+		ConfirmationToken: token,
+		ExpiresAt:         pending.ExpiresAt,
+	})
+}
+
+// DeleteOrganization permanently deletes an organization with its users,
+// assets and files, given the token from RequestOrganizationDeletion. The
+// deletion cannot be undone; export the organization first.
+func (h *Handler) DeleteOrganization(w http.ResponseWriter, r *http.Request) {
+	var req DeleteOrganizationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.ConfirmationToken == "" {
+		writeError(w, http.StatusBadRequest, "confirmation_token is required")
+		return
+	}
+	org, ok := h.deletableOrganization(w, r)
+	if !ok {
+		return
+	}
+
+	var pending pendingDeletion
+	found, err := h.repos.Settings.Get(r.Context(), org.ID, domain.SettingDeletion, &pending)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get deletion request")
+		return
+	}
+	valid := found && pending.RequestedBy == *currentUserID(r) && time.Now().Before(pending.ExpiresAt) &&
+		subtle.ConstantTimeCompare([]byte(pending.TokenHash), []byte(hashSecretToken(req.ConfirmationToken))) == 1
+	if !valid {
+		writeError(w, http.StatusBadRequest, "invalid or expired confirmation token")
+		return
+	}
+
+	keys, err := h.repos.Organizations.FileKeys(r.Context(), org.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list files")
+		return
+	}
+	branding, err := h.loadBranding(r.Context(), org.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get branding")
+		return
+	}
+	if branding.LogoKey != nil {
+		keys = append(keys, *branding.LogoKey)
+	}
+
+	if err := h.repos.Organizations.DeleteData(r.Context(), org.ID); err != nil {
+		slog.Error("failed to delete organization", "organization_id", org.ID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to delete organization")
+		return
+	}
+	// The records are gone, so files left behind are only logged
+	if h.storage != nil {
+		for _, key := range keys {
+			if err := h.storage.Delete(r.Context(), key); err != nil {
+				slog.Error("failed to delete file of deleted organization", "organization_id", org.ID, "key", key, "error", err)
+			}
+		}
+	}
+
+	slog.Info("deleted organization", "organization_id", org.ID, "name", org.Name, "files", len(keys), "user_id", currentUserID(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// managedOrganization returns the organization of the {id} URL parameter if
+// the caller is an admin of the default organization, writing an error
+// response if not
+func (h *Handler) managedOrganization(w http.ResponseWriter, r *http.Request) (*domain.Organization, bool) {
+	if h.org(r) != h.orgID {
+		writeError(w, http.StatusForbidden, "organizations are managed by admins of the default organization")
+		return nil, false
+	}
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid organization ID")
+		return nil, false
+	}
+	org, err := h.repos.Organizations.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get organization")
+		return nil, false
+	}
+	if org == nil {
+		writeError(w, http.StatusNotFound, "organization not found")
+		return nil, false
+	}
+	return org, true
+}
+
+// deletableOrganization is managedOrganization for deletions, which also
+// refuses the default organization and the caller's own
+func (h *Handler) deletableOrganization(w http.ResponseWriter, r *http.Request) (*domain.Organization, bool) {
+	userID := currentUserID(r)
+	if userID == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return nil, false
+	}
+	if id, err := parseUUID(r, "id"); err == nil && id == h.orgID {
+		writeError(w, http.StatusBadRequest, "the default organization cannot be deleted")
+		return nil, false
+	}
+	org, ok := h.managedOrganization(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	user, err := h.repos.Users.GetByID(r.Context(), *userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get user")
+		return nil, false
+	}
+	if user != nil && user.OrganizationID == org.ID {
+		writeError(w, http.StatusConflict, "your account belongs to this organization")
+		return nil, false
+	}
+	return org, true
+}
+
+func newSecretToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecretToken returns the hex SHA-256 of a confirmation or invite token,
+// as stored
+func hashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
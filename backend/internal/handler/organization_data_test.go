@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_OrganizationData_Validation(t *testing.T) {
+	defaultOrg := uuid.New()
+	h := &Handler{repos: &Repositories{}, orgID: defaultOrg}
+	tests := map[string]struct {
+		handler http.HandlerFunc
+		org     uuid.UUID
+		id      string
+		body    string
+		status  int
+	}{
+		"export from other organization": {handler: h.ExportOrganizationData, org: uuid.New(), id: uuid.NewString(), status: http.StatusForbidden},
+		"export invalid ID":              {handler: h.ExportOrganizationData, org: defaultOrg, id: "nope", status: http.StatusBadRequest},
+		"request from other org":         {handler: h.RequestOrganizationDeletion, org: uuid.New(), id: uuid.NewString(), status: http.StatusForbidden},
+		"request default organization":   {handler: h.RequestOrganizationDeletion, org: defaultOrg, id: defaultOrg.String(), status: http.StatusBadRequest},
+		"delete invalid body":            {handler: h.DeleteOrganization, org: defaultOrg, id: uuid.NewString(), body: `{`, status: http.StatusBadRequest},
+		"delete without token":           {handler: h.DeleteOrganization, org: defaultOrg, id: uuid.NewString(), body: `{}`, status: http.StatusBadRequest},
+		"delete default organization":    {handler: h.DeleteOrganization, org: defaultOrg, id: defaultOrg.String(), body: `{"confirmation_token":"x"}`, status: http.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/organizations/"+tt.id, strings.NewReader(tt.body))
+		ctx := auth.WithOrganization(req.Context(), tt.org)
+		ctx = context.WithValue(ctx, auth.DomainUserContextKey, &domain.User{ID: uuid.New(), OrganizationID: tt.org})
+		req = withChiURLParam(req.WithContext(ctx), "id", tt.id)
+		w := httptest.NewRecorder()
+		tt.handler(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", name, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
+
+func Test_hashSecretToken(t *testing.T) {
+	token, err := newSecretToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := newSecretToken()
+	if token == other {
+		t.Error("expected unique tokens")
+	}
+	if hashSecretToken(token) != hashSecretToken(token) || hashSecretToken(token) == hashSecretToken(other) {
+		t.Error("expected the hash to identify the token")
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/repository"
+)
+
+func Test_requestOrg(t *testing.T) {
+	defaultOrg, callerOrg := uuid.New(), uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/api/branding", nil)
+	if got := requestOrg(req, defaultOrg); got != defaultOrg {
+		t.Errorf("expected the default organization without a caller, got %s", got)
+	}
+	req = req.WithContext(auth.WithOrganization(req.Context(), callerOrg))
+	if got := requestOrg(req, defaultOrg); got != callerOrg {
+		t.Errorf("expected the caller's organization, got %s", got)
+	}
+}
+
+func Test_ScopeTo_PassesRoutesWithoutID(t *testing.T) {
+	h := &Handler{}
+	r := chi.NewRouter()
+	r.Route("/categories", func(r chi.Router) {
+		r.Use(h.ScopeTo(repository.ResourceCategory))
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+		r.Get("/asset-counts", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	for _, path := range []string{"/categories", "/categories/", "/categories/asset-counts"} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, got %d", path, rec.Code)
+		}
+	}
+}
+
+func Test_CreateOrganization(t *testing.T) {
+	defaultOrg := uuid.New()
+	tests := map[string]struct {
+		org    uuid.UUID
+		body   string
+		status int
+	}{
+		"other organization": {org: uuid.New(), body: `{}`, status: http.StatusForbidden},
+		"invalid body":       {org: defaultOrg, body: `{`, status: http.StatusBadRequest},
+		"missing name":       {org: defaultOrg, body: `{"admin":{"email":"a@example.com","password":"long-enough-password"}}`, status: http.StatusBadRequest},
+		"missing admin":      {org: defaultOrg, body: `{"name":"Cabin"}`, status: http.StatusBadRequest},
+		"weak password":      {org: defaultOrg, body: `{"name":"Cabin","admin":{"email":"a@example.com","password":"short"}}`, status: http.StatusBadRequest},
+	}
+	for name, tt := range tests {
+		h := NewUserManagementHandler(nil, nil, 8, defaultOrg)
+		h.SetOrganizations(repository.NewOrganizationRepository(nil))
+		req := httptest.NewRequest(http.MethodPost, "/api/organizations", strings.NewReader(tt.body))
+		req = req.WithContext(auth.WithOrganization(req.Context(), tt.org))
+		rec := httptest.NewRecorder()
+		h.CreateOrganization(rec, req)
+
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", name, tt.status, rec.Code, rec.Body.String())
+		}
+	}
+
+	h := NewUserManagementHandler(nil, nil, 8, defaultOrg)
+	rec := httptest.NewRecorder()
+	h.CreateOrganization(rec, httptest.NewRequest(http.MethodPost, "/api/organizations", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501 without the organization repository, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,399 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// passkeyTimeout is the WebAuthn ceremony timeout in milliseconds, matching
+// the lifetime of the challenge
+const passkeyTimeout = 5 * 60 * 1000
+
+const loginFailureInvalidPasskey = "invalid_passkey"
+
+// SetPasskeys enables passkey registration and login for a relying party
+func (h *AuthHandler) SetPasskeys(wa *auth.WebAuthn) {
+	h.webauthn = wa
+}
+
+// base64URL is binary WebAuthn data, encoded as unpadded base64url in JSON
+// like the browser's PublicKeyCredential.toJSON()
+type base64URL []byte
+
+func (b base64URL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(b))
+}
+
+func (b *base64URL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+type passkeyCredentialDescriptor struct {
+	Type       string    `json:"type"`
+	ID         base64URL `json:"id"`
+	Transports []string  `json:"transports,omitempty"`
+}
+
+type passkeyCredentialParam struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+// PasskeyCreationOptions are the publicKey options for navigator.credentials.create()
+type PasskeyCreationOptions struct {
+	Challenge base64URL `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          base64URL `json:"id"`
+		Name        string    `json:"name"`
+		DisplayName string    `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams       []passkeyCredentialParam      `json:"pubKeyCredParams"`
+	Timeout                int                           `json:"timeout"`
+	Attestation            string                        `json:"attestation"`
+	ExcludeCredentials     []passkeyCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection map[string]string             `json:"authenticatorSelection"`
+}
+
+// PasskeyRequestOptions are the publicKey options for navigator.credentials.get()
+type PasskeyRequestOptions struct {
+	Challenge        base64URL `json:"challenge"`
+	RPID             string    `json:"rpId"`
+	Timeout          int       `json:"timeout"`
+	UserVerification string    `json:"userVerification"`
+}
+
+// PasskeyOptionsResponse wraps WebAuthn options the way the browser API expects them
+type PasskeyOptionsResponse struct {
+	PublicKey any `json:"publicKey"`
+}
+
+// RegisterPasskeyRequest is the result of navigator.credentials.create().
+// PublicKey and PublicKeyAlgorithm come from the response's getPublicKey()
+// and getPublicKeyAlgorithm().
+type RegisterPasskeyRequest struct {
+	Name               string    `json:"name"`
+	ID                 base64URL `json:"id"`
+	ClientDataJSON     base64URL `json:"client_data_json"`
+	AuthenticatorData  base64URL `json:"authenticator_data"`
+	PublicKey          base64URL `json:"public_key"`
+	PublicKeyAlgorithm int       `json:"public_key_algorithm"`
+	Transports         []string  `json:"transports,omitempty"`
+}
+
+// PasskeyLoginRequest is the result of navigator.credentials.get()
+type PasskeyLoginRequest struct {
+	ID                base64URL `json:"id"`
+	ClientDataJSON    base64URL `json:"client_data_json"`
+	AuthenticatorData base64URL `json:"authenticator_data"`
+	Signature         base64URL `json:"signature"`
+	UserHandle        base64URL `json:"user_handle,omitempty"`
+}
+
+// PasskeyLoginOptions starts a passkey login. No account is named: the
+// browser offers the passkeys it holds for this site.
+func (h *AuthHandler) PasskeyLoginOptions(w http.ResponseWriter, r *http.Request) {
+	if !h.passkeysAvailable(w) {
+		return
+	}
+
+	challenge, err := h.sessionManager.CreateChallenge(uuid.Nil, auth.ChallengePasskeyLogin)
+	if err != nil {
+		slog.Error("failed to create passkey challenge", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, PasskeyOptionsResponse{PublicKey: PasskeyRequestOptions{
+		Challenge:        base64URL(challenge),
+		RPID:             h.webauthn.RPID,
+		Timeout:          passkeyTimeout,
+		UserVerification: "required",
+	}})
+}
+
+// LoginPasskey completes a passkey login. Passkeys verify the user on the
+// device, so no password or second factor is asked for.
+func (h *AuthHandler) LoginPasskey(w http.ResponseWriter, r *http.Request) {
+	if !h.passkeysAvailable(w) {
+		return
+	}
+
+	var req PasskeyLoginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	challenge, err := h.webauthn.ParseChallenge(req.ClientDataJSON, "webauthn.get")
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if _, err := h.sessionManager.VerifyChallenge(challenge, auth.ChallengePasskeyLogin); err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	passkey, err := h.userRepo.GetPasskeyByCredentialID(r.Context(), req.ID)
+	if err != nil {
+		slog.Error("failed to get passkey", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if passkey == nil || (len(req.UserHandle) > 0 && string(req.UserHandle) != string(passkey.UserID[:])) {
+		writeError(w, http.StatusUnauthorized, "unknown passkey")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), passkey.UserID)
+	if err != nil || user == nil {
+		slog.Error("failed to get passkey user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	signCount, err := h.webauthn.VerifyAssertion(auth.AssertionKey{
+		PublicKey: passkey.PublicKey,
+		Algorithm: passkey.Algorithm,
+		SignCount: uint32(passkey.SignCount),
+	}, req.ClientDataJSON, req.AuthenticatorData, req.Signature)
+	if err != nil {
+		h.recordLogin(r, user, user.Email, domain.LoginMethodPasskey, loginFailureInvalidPasskey)
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if !user.IsActive() {
+		h.recordLogin(r, user, user.Email, domain.LoginMethodPasskey, loginFailureDisabled)
+		writeError(w, http.StatusForbidden, "account disabled")
+		return
+	}
+
+	if user.IsPending() {
+		h.recordLogin(r, user, user.Email, domain.LoginMethodPasskey, loginFailurePending)
+		writeError(w, http.StatusForbidden, "account awaiting approval")
+		return
+	}
+
+	if h.verifier.Blocks(user) {
+		h.recordLogin(r, user, user.Email, domain.LoginMethodPasskey, loginFailureUnverified)
+		writeError(w, http.StatusForbidden, "email address not verified")
+		return
+	}
+
+	if err := h.userRepo.UpdatePasskeyUsage(r.Context(), passkey.ID, int64(signCount)); err != nil {
+		slog.Error("failed to update passkey usage", "error", err)
+	}
+	h.completeLogin(w, r, user, user.Email, domain.LoginMethodPasskey, nil)
+}
+
+// ListPasskeys lists the passkeys of the signed-in user
+func (h *AuthHandler) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.sessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	passkeys, err := h.userRepo.ListPasskeys(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list passkeys")
+		return
+	}
+	if passkeys == nil {
+		passkeys = []domain.Passkey{}
+	}
+	writeJSON(w, http.StatusOK, passkeys)
+}
+
+// PasskeyRegistrationOptions starts registering a passkey for the signed-in user
+func (h *AuthHandler) PasskeyRegistrationOptions(w http.ResponseWriter, r *http.Request) {
+	if !h.passkeysAvailable(w) {
+		return
+	}
+	user, ok := h.sessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	existing, err := h.userRepo.ListPasskeys(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list passkeys")
+		return
+	}
+	challenge, err := h.sessionManager.CreateChallenge(user.ID, auth.ChallengePasskeyRegister)
+	if err != nil {
+		slog.Error("failed to create passkey challenge", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	options := PasskeyCreationOptions{
+		Challenge: base64URL(challenge),
+		PubKeyCredParams: []passkeyCredentialParam{
+			{Type: "public-key", Alg: auth.COSEAlgES256},
+			{Type: "public-key", Alg: auth.COSEAlgEdDSA},
+			{Type: "public-key", Alg: auth.COSEAlgRS256},
+		},
+		Timeout:            passkeyTimeout,
+		Attestation:        "none",
+		ExcludeCredentials: []passkeyCredentialDescriptor{},
+		AuthenticatorSelection: map[string]string{
+			"residentKey":      "required",
+			"userVerification": "required",
+		},
+	}
+	options.RP.ID = h.webauthn.RPID
+	options.RP.Name = h.webauthn.RPName
+	options.User.ID = base64URL(user.ID[:])
+	options.User.Name = user.Email
+	options.User.DisplayName = user.Email
+	if user.DisplayName != nil && *user.DisplayName != "" {
+		options.User.DisplayName = *user.DisplayName
+	}
+	for _, p := range existing {
+		options.ExcludeCredentials = append(options.ExcludeCredentials, passkeyCredentialDescriptor{
+			Type:       "public-key",
+			ID:         p.CredentialID,
+			Transports: p.Transports,
+		})
+	}
+	writeJSON(w, http.StatusOK, PasskeyOptionsResponse{PublicKey: options})
+}
+
+// RegisterPasskey stores the passkey created with PasskeyRegistrationOptions
+func (h *AuthHandler) RegisterPasskey(w http.ResponseWriter, r *http.Request) {
+	if !h.passkeysAvailable(w) {
+		return
+	}
+	user, ok := h.sessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req RegisterPasskeyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = "Passkey"
+	}
+	if len(req.Name) > 255 {
+		writeError(w, http.StatusBadRequest, "name is too long")
+		return
+	}
+
+	challenge, err := h.webauthn.ParseChallenge(req.ClientDataJSON, "webauthn.create")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userID, err := h.sessionManager.VerifyChallenge(challenge, auth.ChallengePasskeyRegister)
+	if err != nil || userID != user.ID {
+		writeError(w, http.StatusBadRequest, auth.ErrInvalidChallenge.Error())
+		return
+	}
+
+	credential, err := h.webauthn.VerifyRegistration(req.ID, req.AuthenticatorData, req.PublicKey, req.PublicKeyAlgorithm)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	existing, err := h.userRepo.GetPasskeyByCredentialID(r.Context(), credential.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to register passkey")
+		return
+	}
+	if existing != nil {
+		writeError(w, http.StatusConflict, "passkey is already registered")
+		return
+	}
+
+	passkey := &domain.Passkey{
+		UserID:       user.ID,
+		CredentialID: credential.ID,
+		PublicKey:    credential.PublicKey,
+		Algorithm:    credential.Algorithm,
+		SignCount:    int64(credential.SignCount),
+		Name:         req.Name,
+		Transports:   req.Transports,
+	}
+	if err := h.userRepo.CreatePasskey(r.Context(), passkey); err != nil {
+		slog.Error("failed to create passkey", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to register passkey")
+		return
+	}
+	writeJSON(w, http.StatusCreated, passkey)
+}
+
+// DeletePasskey removes a passkey of the signed-in user. The last passkey of
+// an account without a password cannot be removed, as the user could no
+// longer log in.
+func (h *AuthHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.sessionUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid passkey ID")
+		return
+	}
+
+	if !user.HasPassword() {
+		passkeys, err := h.userRepo.ListPasskeys(r.Context(), user.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list passkeys")
+			return
+		}
+		if len(passkeys) == 1 && passkeys[0].ID == id {
+			writeError(w, http.StatusConflict, "cannot remove the only passkey of an account without a password")
+			return
+		}
+	}
+
+	deleted, err := h.userRepo.DeletePasskey(r.Context(), user.ID, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete passkey")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "passkey not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// passkeysAvailable writes an error response if passkeys cannot be used
+func (h *AuthHandler) passkeysAvailable(w http.ResponseWriter) bool {
+	if h.oidcEnabled {
+		writeError(w, http.StatusBadRequest, "passkey login is disabled when OIDC is enabled")
+		return false
+	}
+	if h.webauthn == nil {
+		writeError(w, http.StatusServiceUnavailable, "passkeys are not configured")
+		return false
+	}
+	return true
+}
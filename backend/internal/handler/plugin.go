@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -11,12 +12,17 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/metrics"
 	"github.com/lmmendes/attic/internal/plugin"
+	"github.com/lmmendes/attic/internal/secrets"
+	"github.com/lmmendes/attic/internal/storage"
 )
 
 // PluginHandler handles plugin-related HTTP requests
@@ -25,6 +31,13 @@ type PluginHandler struct {
 	repos    *Repositories
 	storage  FileStorage
 	orgID    uuid.UUID
+	events   *events.Bus
+	errors   *metrics.CounterVec // nil = errors not counted
+	secrets  *secrets.Box        // Encrypts HTTP plugin headers
+
+	httpPluginsMu sync.Mutex // Serializes changes to the HTTP plugin settings
+	transformsMu  sync.Mutex // Serializes changes to the import transform settings
+	pluginsMu     sync.Mutex // Serializes changes to the plugin toggles
 }
 
 // NewPluginHandler creates a new PluginHandler
@@ -37,6 +50,28 @@ func NewPluginHandler(registry *plugin.Registry, repos *Repositories, storage Fi
 	}
 }
 
+// org returns the organization of the request
+func (h *PluginHandler) org(r *http.Request) uuid.UUID {
+	return requestOrg(r, h.orgID)
+}
+
+// SetEvents sets the bus used to publish domain events
+func (h *PluginHandler) SetEvents(bus *events.Bus) {
+	h.events = bus
+}
+
+// SetErrorCounter sets the counter of failed plugin calls (labels: plugin, operation)
+func (h *PluginHandler) SetErrorCounter(c *metrics.CounterVec) {
+	h.errors = c
+}
+
+// countError records a failed call to an external plugin source
+func (h *PluginHandler) countError(pluginID, operation string) {
+	if h.errors != nil {
+		h.errors.Inc(pluginID, operation)
+	}
+}
+
 // PluginListResponse represents the response for listing plugins
 type PluginListResponse struct {
 	Plugins []PluginResponse `json:"plugins"`
@@ -44,16 +79,19 @@ type PluginListResponse struct {
 
 // PluginResponse represents a plugin in API responses
 type PluginResponse struct {
-	ID                  string                   `json:"id"`
-	Name                string                   `json:"name"`
-	Description         string                   `json:"description"`
-	Enabled             bool                     `json:"enabled"`
-	DisabledReason      string                   `json:"disabled_reason,omitempty"`
-	CategoryName        string                   `json:"category_name"`
-	CategoryDescription string                   `json:"category_description"`
-	SearchFields        []domain.SearchField     `json:"search_fields"`
-	Attributes          []domain.PluginAttribute `json:"attributes"`
-	CategoryID          *uuid.UUID               `json:"category_id,omitempty"`
+	ID                  string                    `json:"id"`
+	Name                string                    `json:"name"`
+	Description         string                    `json:"description"`
+	Version             string                    `json:"version"`
+	Capabilities        []domain.PluginCapability `json:"capabilities"`
+	Enabled             bool                      `json:"enabled"`
+	DisabledReason      string                    `json:"disabled_reason,omitempty"`
+	DisabledByAdmin     bool                      `json:"disabled_by_admin"`
+	CategoryName        string                    `json:"category_name"`
+	CategoryDescription string                    `json:"category_description"`
+	SearchFields        []domain.SearchField      `json:"search_fields"`
+	Attributes          []domain.PluginAttribute  `json:"attributes"`
+	CategoryID          *uuid.UUID                `json:"category_id,omitempty"`
 }
 
 // SearchResponse represents the response for plugin search
@@ -63,7 +101,58 @@ type SearchResponse struct {
 
 // ImportRequest represents the request body for importing
 type ImportRequest struct {
-	ExternalID string `json:"external_id"`
+	ExternalID    string      `json:"external_id"`
+	PurchasePrice *PriceInput `json:"purchase_price,omitempty"` // Number or localized string, e.g. "12,99 €"
+	Currency      *string     `json:"currency,omitempty"`
+
+	// Edits made while reviewing the import, applied after import transforms
+	Name        *string        `json:"name,omitempty"`        // Replaces the imported name
+	Description *string        `json:"description,omitempty"` // Replaces the imported description
+	Exclude     []string       `json:"exclude,omitempty"`     // Imported fields to skip: "description", "image" or attribute keys
+	Attributes  map[string]any `json:"attributes,omitempty"`  // Replace imported attribute values (null removes one)
+	LocationID  *string        `json:"location_id,omitempty"`
+	ConditionID *string        `json:"condition_id,omitempty"`
+	Quantity    int            `json:"quantity,omitempty"` // Default 1
+	Notes       *string        `json:"notes,omitempty"`
+}
+
+// applyEdits applies the review edits of the request to imported data
+func (req *ImportRequest) applyEdits(data *domain.ImportData) error {
+	for _, field := range req.Exclude {
+		switch field {
+		case "name":
+			return errors.New("name cannot be excluded, override it instead")
+		case "description":
+			data.Description = nil
+		case "image":
+			data.ImageURL = nil
+		default:
+			delete(data.Attributes, field)
+		}
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return errors.New("name cannot be empty")
+		}
+		data.Name = name
+	}
+	if req.Description != nil {
+		data.Description = req.Description
+	}
+
+	if len(req.Attributes) > 0 && data.Attributes == nil {
+		data.Attributes = map[string]any{}
+	}
+	for key, value := range req.Attributes {
+		if value == nil {
+			delete(data.Attributes, key)
+		} else {
+			data.Attributes[key] = value
+		}
+	}
+	return nil
 }
 
 // ImportResponse represents the response for importing
@@ -80,25 +169,7 @@ func (h *PluginHandler) ListPlugins(w http.ResponseWriter, r *http.Request) {
 	}
 
 	for _, p := range plugins {
-		pr := PluginResponse{
-			ID:                  p.ID(),
-			Name:                p.Name(),
-			Description:         p.Description(),
-			Enabled:             p.Enabled(),
-			DisabledReason:      p.DisabledReason(),
-			CategoryName:        p.CategoryName(),
-			CategoryDescription: p.CategoryDescription(),
-			SearchFields:        p.SearchFields(),
-			Attributes:          p.Attributes(),
-		}
-
-		// Check if category exists for this plugin
-		cat, _ := h.repos.Categories.GetByPluginID(r.Context(), h.orgID, p.ID())
-		if cat != nil {
-			pr.CategoryID = &cat.ID
-		}
-
-		response.Plugins = append(response.Plugins, pr)
+		response.Plugins = append(response.Plugins, h.pluginResponse(r.Context(), h.org(r), p))
 	}
 
 	writeJSON(w, http.StatusOK, response)
@@ -114,12 +185,21 @@ func (h *PluginHandler) GetPlugin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	writeJSON(w, http.StatusOK, h.pluginResponse(r.Context(), h.org(r), p))
+}
+
+// pluginResponse describes a plugin, including whether an admin turned it off
+func (h *PluginHandler) pluginResponse(ctx context.Context, orgID uuid.UUID, p domain.ImportPlugin) PluginResponse {
+	enabled, reason := h.registry.Available(p)
 	pr := PluginResponse{
 		ID:                  p.ID(),
 		Name:                p.Name(),
 		Description:         p.Description(),
-		Enabled:             p.Enabled(),
-		DisabledReason:      p.DisabledReason(),
+		Version:             p.Version(),
+		Capabilities:        p.Capabilities(),
+		Enabled:             enabled,
+		DisabledReason:      reason,
+		DisabledByAdmin:     h.registry.IsDisabled(p.ID()),
 		CategoryName:        p.CategoryName(),
 		CategoryDescription: p.CategoryDescription(),
 		SearchFields:        p.SearchFields(),
@@ -127,26 +207,19 @@ func (h *PluginHandler) GetPlugin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Check if category exists for this plugin
-	cat, _ := h.repos.Categories.GetByPluginID(r.Context(), h.orgID, p.ID())
+	cat, _ := h.repos.Categories.GetByPluginID(ctx, orgID, p.ID())
 	if cat != nil {
 		pr.CategoryID = &cat.ID
 	}
-
-	writeJSON(w, http.StatusOK, pr)
+	return pr
 }
 
 // Search performs a search using a plugin
 func (h *PluginHandler) Search(w http.ResponseWriter, r *http.Request) {
 	pluginID := chi.URLParam(r, "pluginId")
 
-	p, exists := h.registry.Get(pluginID)
-	if !exists {
-		writeError(w, http.StatusNotFound, fmt.Sprintf("plugin '%s' not found", pluginID))
-		return
-	}
-
-	if !p.Enabled() {
-		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("plugin '%s' is disabled: %s", pluginID, p.DisabledReason()))
+	p, ok := h.enabledPlugin(w, pluginID)
+	if !ok {
 		return
 	}
 
@@ -203,6 +276,7 @@ func (h *PluginHandler) Search(w http.ResponseWriter, r *http.Request) {
 		if r.Context().Err() != nil {
 			return
 		}
+		h.countError(pluginID, "search")
 
 		writeError(w, http.StatusBadGateway, "search service temporarily unavailable")
 		return
@@ -217,16 +291,8 @@ func (h *PluginHandler) Search(w http.ResponseWriter, r *http.Request) {
 
 // Import fetches data from a plugin and creates an asset
 func (h *PluginHandler) Import(w http.ResponseWriter, r *http.Request) {
-	pluginID := chi.URLParam(r, "pluginId")
-
-	p, exists := h.registry.Get(pluginID)
-	if !exists {
-		writeError(w, http.StatusNotFound, fmt.Sprintf("plugin '%s' not found", pluginID))
-		return
-	}
-
-	if !p.Enabled() {
-		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("plugin '%s' is disabled: %s", pluginID, p.DisabledReason()))
+	p, ok := h.enabledPlugin(w, chi.URLParam(r, "pluginId"))
+	if !ok {
 		return
 	}
 
@@ -241,6 +307,30 @@ func (h *PluginHandler) Import(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.importItem(w, r, p, req)
+}
+
+// enabledPlugin looks up an import plugin, writing an error response if it
+// does not exist or is disabled
+func (h *PluginHandler) enabledPlugin(w http.ResponseWriter, pluginID string) (domain.ImportPlugin, bool) {
+	p, exists := h.registry.Get(pluginID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("plugin '%s' not found", pluginID))
+		return nil, false
+	}
+
+	if enabled, reason := h.registry.Available(p); !enabled {
+		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("plugin '%s' is disabled: %s", pluginID, reason))
+		return nil, false
+	}
+	return p, true
+}
+
+// importItem fetches an item from a plugin and creates an asset, recording the
+// outcome in the import log
+func (h *PluginHandler) importItem(w http.ResponseWriter, r *http.Request, p domain.ImportPlugin, req ImportRequest) {
+	pluginID := p.ID()
+
 	slog.Info("importing item from plugin",
 		"plugin_id", pluginID,
 		"external_id", req.ExternalID)
@@ -257,19 +347,43 @@ func (h *PluginHandler) Import(w http.ResponseWriter, r *http.Request) {
 		if r.Context().Err() != nil {
 			return
 		}
+		h.logImport(r, pluginID, req.ExternalID, nil, nil, err)
 
 		// Check for "not found" type errors
 		if strings.Contains(err.Error(), "not found") {
 			writeError(w, http.StatusNotFound, "item not found in external source")
 			return
 		}
+		h.countError(pluginID, "import")
 
 		writeError(w, http.StatusBadGateway, "failed to fetch data from external source")
 		return
 	}
+	// Snapshot the data as returned by the plugin, before transforms
+	payload, err := json.Marshal(importData)
+	if err != nil {
+		slog.Warn("failed to encode import data", "plugin_id", pluginID, "error", err)
+	}
+
+	// Apply the admin-defined transforms, e.g. cleaning up titles
+	if err := h.applyTransforms(r.Context(), pluginID, importData); err != nil {
+		slog.Error("failed to apply import transforms",
+			"plugin_id", pluginID,
+			"external_id", req.ExternalID,
+			"error", err)
+		h.logImport(r, pluginID, req.ExternalID, payload, nil, err)
+		writeError(w, http.StatusUnprocessableEntity, "import transform failed: "+err.Error())
+		return
+	}
+
+	if err := req.applyEdits(importData); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	// Validate import data
 	if importData.Name == "" {
+		h.logImport(r, pluginID, req.ExternalID, payload, nil, errors.New("missing name"))
 		writeError(w, http.StatusBadGateway, "external source returned invalid data (missing name)")
 		return
 	}
@@ -296,7 +410,7 @@ func (h *PluginHandler) Import(w http.ResponseWriter, r *http.Request) {
 
 	// Create the asset
 	asset := &domain.Asset{
-		OrganizationID:   h.orgID,
+		OrganizationID:   h.org(r),
 		CategoryID:       cat.ID,
 		Name:             importData.Name,
 		Description:      importData.Description,
@@ -305,12 +419,41 @@ func (h *PluginHandler) Import(w http.ResponseWriter, r *http.Request) {
 		ImportPluginID:   &pluginID,
 		ImportExternalID: &importData.ExternalID,
 	}
+	if err := applyPrice(r, asset, req.PurchasePrice, req.Currency); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Quantity > 0 {
+		if req.Quantity > maxAssetQuantity {
+			writeError(w, http.StatusBadRequest, "quantity exceeds maximum allowed value")
+			return
+		}
+		asset.Quantity = req.Quantity
+	}
+	if req.LocationID != nil && *req.LocationID != "" {
+		id, err := parseUUIDString(*req.LocationID)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid location_id")
+			return
+		}
+		asset.LocationID = &id
+	}
+	if req.ConditionID != nil && *req.ConditionID != "" {
+		id, err := parseUUIDString(*req.ConditionID)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid condition_id")
+			return
+		}
+		asset.ConditionID = &id
+	}
+	asset.Notes = req.Notes
 
 	if err := h.repos.Assets.Create(r.Context(), asset); err != nil {
 		slog.Error("failed to create imported asset",
 			"plugin_id", pluginID,
 			"external_id", req.ExternalID,
 			"error", err)
+		h.logImport(r, pluginID, req.ExternalID, payload, nil, err)
 		writeError(w, http.StatusInternalServerError, "failed to save imported item")
 		return
 	}
@@ -319,10 +462,19 @@ func (h *PluginHandler) Import(w http.ResponseWriter, r *http.Request) {
 		"plugin_id", pluginID,
 		"external_id", req.ExternalID,
 		"asset_id", asset.ID)
+	h.logImport(r, pluginID, req.ExternalID, payload, &asset.ID, nil)
+
+	// Keep the source data to backfill attributes later without re-fetching
+	if payload != nil {
+		src := &domain.AssetSource{AssetID: asset.ID, PluginID: pluginID, ExternalID: importData.ExternalID, Data: payload}
+		if err := h.repos.Sources.Upsert(r.Context(), src); err != nil {
+			slog.Warn("failed to store asset source data", "asset_id", asset.ID, "error", err)
+		}
+	}
 
 	// Download and store image if available
 	if importData.ImageURL != nil && *importData.ImageURL != "" && h.storage != nil {
-		if err := h.downloadAndStoreImage(r.Context(), asset.ID, *importData.ImageURL); err != nil {
+		if err := h.downloadAndStoreImage(r.Context(), asset.OrganizationID, asset.ID, *importData.ImageURL); err != nil {
 			// Log error but don't fail the import - image is optional
 			slog.Warn("failed to download image for imported asset",
 				"asset_id", asset.ID,
@@ -331,6 +483,13 @@ func (h *PluginHandler) Import(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	h.events.Publish(r.Context(), events.Event{
+		Type:           events.AssetCreated,
+		OrganizationID: asset.OrganizationID,
+		SubjectID:      asset.ID,
+		ActorID:        currentUserID(r),
+	})
+
 	// Load full asset with category
 	asset.Category = cat
 
@@ -343,7 +502,7 @@ func (h *PluginHandler) ensurePluginCategory(r *http.Request, p domain.ImportPlu
 	pluginID := p.ID()
 
 	// Check if category already exists
-	cat, err := h.repos.Categories.GetByPluginID(ctx, h.orgID, pluginID)
+	cat, err := h.repos.Categories.GetByPluginID(ctx, h.org(r), pluginID)
 	if err != nil {
 		return nil, err
 	}
@@ -354,10 +513,11 @@ func (h *PluginHandler) ensurePluginCategory(r *http.Request, p domain.ImportPlu
 
 	// Create category
 	cat = &domain.Category{
-		OrganizationID: h.orgID,
-		PluginID:       &pluginID,
-		Name:           p.CategoryName(),
-		Description:    strPtr(p.CategoryDescription()),
+		OrganizationID:     h.org(r),
+		PluginID:           &pluginID,
+		Name:               p.CategoryName(),
+		Description:        strPtr(p.CategoryDescription()),
+		PluginCategoryName: strPtr(p.CategoryName()),
 	}
 
 	if err := h.repos.Categories.Create(ctx, cat); err != nil {
@@ -370,7 +530,7 @@ func (h *PluginHandler) ensurePluginCategory(r *http.Request, p domain.ImportPlu
 
 	for i, pa := range pluginAttrs {
 		// Check if attribute already exists
-		attr, err := h.repos.Attributes.GetByKey(ctx, h.orgID, pa.Key)
+		attr, err := h.repos.Attributes.GetByKey(ctx, h.org(r), pa.Key)
 		if err != nil {
 			return nil, err
 		}
@@ -378,7 +538,7 @@ func (h *PluginHandler) ensurePluginCategory(r *http.Request, p domain.ImportPlu
 		if attr == nil {
 			// Create the attribute
 			attr = &domain.Attribute{
-				OrganizationID: h.orgID,
+				OrganizationID: h.org(r),
 				PluginID:       &pluginID,
 				Name:           pa.Name,
 				Key:            pa.Key,
@@ -409,7 +569,7 @@ func strPtr(s string) *string {
 }
 
 // downloadAndStoreImage downloads an image from URL and stores it as an attachment
-func (h *PluginHandler) downloadAndStoreImage(ctx context.Context, assetID uuid.UUID, imageURL string) error {
+func (h *PluginHandler) downloadAndStoreImage(ctx context.Context, orgID uuid.UUID, assetID uuid.UUID, imageURL string) error {
 	// Create HTTP client with timeout
 	client := &http.Client{
 		Timeout: 30 * time.Second,
@@ -478,7 +638,11 @@ func (h *PluginHandler) downloadAndStoreImage(ctx context.Context, assetID uuid.
 	}
 
 	// Upload to storage
-	key, err := h.storage.Upload(ctx, filename, contentType, bytes.NewReader(imageData))
+	key, err := h.storage.UploadObject(ctx, storage.Object{
+		OrganizationID: orgID,
+		AssetID:        assetID,
+		FileName:       filename,
+	}, contentType, bytes.NewReader(imageData))
 	if err != nil {
 		return fmt.Errorf("uploading to storage: %w", err)
 	}
@@ -33,6 +33,8 @@ type mockPlugin struct {
 func (m *mockPlugin) ID() string                              { return m.id }
 func (m *mockPlugin) Name() string                            { return m.name }
 func (m *mockPlugin) Description() string                     { return m.description }
+func (m *mockPlugin) Version() string                         { return "1.0.0" }
+func (m *mockPlugin) Capabilities() []domain.PluginCapability { return nil }
 func (m *mockPlugin) Enabled() bool                           { return true }
 func (m *mockPlugin) DisabledReason() string                  { return "" }
 func (m *mockPlugin) CategoryName() string                    { return m.categoryName }
@@ -756,3 +758,51 @@ func Test_Import_CreatesCategory_WhenNotExists(t *testing.T) {
 		t.Error("expected category to be created for plugin")
 	}
 }
+
+// Tests for import review edits
+
+func Test_ImportRequest_applyEdits(t *testing.T) {
+	desc := "Imported description"
+	image := "https://example.com/cover.jpg"
+	data := &domain.ImportData{
+		Name:        "Dune (Deluxe Edition)",
+		Description: &desc,
+		ImageURL:    &image,
+		Attributes: map[string]any{
+			"google_books.pages":     412,
+			"google_books.publisher": "Ace",
+			"google_books.isbn":      "9780441013593",
+		},
+	}
+	name := "  Dune  "
+	req := ImportRequest{
+		Name:    &name,
+		Exclude: []string{"description", "image", "google_books.publisher"},
+		Attributes: map[string]any{
+			"google_books.pages": 500,
+			"google_books.isbn":  nil,
+		},
+	}
+
+	if err := req.applyEdits(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if data.Name != "Dune" {
+		t.Errorf("expected overridden name, got %q", data.Name)
+	}
+	if data.Description != nil || data.ImageURL != nil {
+		t.Error("expected description and image to be excluded")
+	}
+	if len(data.Attributes) != 1 || data.Attributes["google_books.pages"] != 500 {
+		t.Errorf("unexpected attributes %v", data.Attributes)
+	}
+}
+
+func Test_ImportRequest_applyEdits_InvalidName(t *testing.T) {
+	empty := " "
+	for _, req := range []ImportRequest{{Exclude: []string{"name"}}, {Name: &empty}} {
+		if err := req.applyEdits(&domain.ImportData{Name: "Dune"}); err == nil {
+			t.Errorf("expected error for %+v", req)
+		}
+	}
+}
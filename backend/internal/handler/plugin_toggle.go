@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// PluginEnabledRequest turns an import plugin on or off
+type PluginEnabledRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// instanceAdmin reports whether the caller may change the plugin
+// configuration, which applies to all organizations and is stored with the
+// default one; it writes a forbidden response if not
+func (h *PluginHandler) instanceAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if h.org(r) != h.orgID {
+		writeError(w, http.StatusForbidden, "plugins are configured by admins of the default organization")
+		return false
+	}
+	return true
+}
+
+// LoadPluginSettings applies the stored plugin toggles to the registry
+func (h *PluginHandler) LoadPluginSettings(ctx context.Context) error {
+	var settings domain.PluginSettings
+	if _, err := h.repos.Settings.Get(ctx, h.orgID, domain.SettingPlugins, &settings); err != nil {
+		return err
+	}
+	h.registry.SetDisabled(settings.Disabled)
+	return nil
+}
+
+// SetPluginEnabled turns an import plugin on or off (admin only). A disabled
+// plugin cannot be searched or imported from; its category and assets are kept.
+func (h *PluginHandler) SetPluginEnabled(w http.ResponseWriter, r *http.Request) {
+	if !h.instanceAdmin(w, r) {
+		return
+	}
+	pluginID := chi.URLParam(r, "pluginId")
+	p, exists := h.registry.Get(pluginID)
+	if !exists {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("plugin '%s' not found", pluginID))
+		return
+	}
+
+	var req PluginEnabledRequest
+	if err := decodeJSON(r, &req); err != nil || req.Enabled == nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: expected JSON with 'enabled' field")
+		return
+	}
+
+	h.pluginsMu.Lock()
+	defer h.pluginsMu.Unlock()
+
+	previous := h.registry.IsDisabled(pluginID)
+	h.registry.SetEnabled(pluginID, *req.Enabled)
+	settings := domain.PluginSettings{Disabled: h.registry.Disabled()}
+	if err := h.repos.Settings.Set(r.Context(), h.orgID, domain.SettingPlugins, settings); err != nil {
+		h.registry.SetEnabled(pluginID, !previous)
+		writeError(w, http.StatusInternalServerError, "failed to save plugin settings")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.pluginResponse(r.Context(), h.org(r), p))
+}
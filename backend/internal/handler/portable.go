@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/portable"
+)
+
+// maxPortableImportSize bounds the archives accepted by ImportPortable
+const maxPortableImportSize = 4 << 30
+
+// ExportPortable downloads the caller's organization in the open export
+// format: a zip archive of attic-export.json, described by the schema at
+// /api/schema/export.json, and the attached files
+func (h *Handler) ExportPortable(w http.ResponseWriter, r *http.Request) {
+	orgID := h.org(r)
+	export, files, err := h.repos.Organizations.ExportPortable(r.Context(), orgID)
+	if err != nil {
+		slog.Error("failed to export organization", "organization_id", orgID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to export organization")
+		return
+	}
+	keys := make(map[uuid.UUID]string, len(files))
+	for _, f := range files {
+		keys[f.ID] = f.FileKey
+	}
+
+	filename := fmt.Sprintf("attic-export-%s-%s.zip", archiveName(export.Organization.Name), time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// From here on errors can only be logged, the response has started
+	missing, err := portable.Write(w, export, func(f portable.File) (io.ReadCloser, error) {
+		if h.storage == nil {
+			return nil, errors.New("file storage not configured")
+		}
+		return h.storage.Open(r.Context(), keys[f.ID])
+	})
+	for _, f := range missing {
+		slog.Warn("left file out of organization export", "organization_id", orgID, "attachment_id", f.ID)
+	}
+	if err != nil {
+		slog.Error("failed to write organization export", "organization_id", orgID, "error", err)
+		return
+	}
+	slog.Info("exported organization", "organization_id", orgID, "assets", len(export.Assets), "user_id", currentUserID(r))
+}
+
+// ImportPortable imports an archive of ExportPortable, e.g. of another
+// instance, into the caller's organization, sent as the request body. The
+// records are added next to the existing ones; matching taxonomy is reused,
+// see repository.ImportPortable.
+func (h *Handler) ImportPortable(w http.ResponseWriter, r *http.Request) {
+	// The archive is spooled to disk, zip needs random access
+	tmp, err := os.CreateTemp("", "attic-import-*.zip")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read archive")
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, http.MaxBytesReader(w, r.Body, maxPortableImportSize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "archive too large or incomplete")
+		return
+	}
+	archive, err := portable.Read(tmp, size)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	export := archive.Export
+
+	orgID := h.org(r)
+	if len(export.Files) > 0 {
+		if h.storage == nil {
+			writeError(w, http.StatusServiceUnavailable, "storage not configured")
+			return
+		}
+		used, err := h.repos.Attachments.TotalSize(r.Context(), orgID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to check storage quota")
+			return
+		}
+		for _, f := range export.Files {
+			used += f.Size
+		}
+		if h.storageQuota > 0 && used > h.storageQuota {
+			writeError(w, http.StatusRequestEntityTooLarge, "storage quota exceeded")
+			return
+		}
+	}
+
+	export.RenewAssetIDs()
+	var keys map[uuid.UUID]string
+	if len(export.Files) > 0 {
+		if keys, err = archive.UploadFiles(r.Context(), h.storage, orgID); err != nil {
+			slog.Error("failed to import files", "organization_id", orgID, "error", err)
+			writeError(w, http.StatusBadRequest, "failed to import "+err.Error())
+			return
+		}
+	}
+
+	result, err := h.repos.Organizations.ImportPortable(r.Context(), orgID, export, keys)
+	if err != nil {
+		portable.DeleteFiles(r.Context(), h.storage, keys)
+		slog.Error("failed to import organization", "organization_id", orgID, "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to import organization")
+		return
+	}
+
+	for _, a := range export.Assets {
+		h.publish(r, events.AssetCreated, orgID, a.ID)
+	}
+	slog.Info("imported organization", "organization_id", orgID, "assets", result.Assets, "files", result.Files, "user_id", currentUserID(r))
+	writeJSON(w, http.StatusCreated, result)
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/portable"
+)
+
+func Test_ImportPortable_Validation(t *testing.T) {
+	// An archive with one asset and one file
+	e := portable.New(portable.Organization{Name: "Home"})
+	category, asset := uuid.New(), uuid.New()
+	e.Categories = []portable.Category{{ID: category, Name: "Tools"}}
+	e.Assets = []portable.Asset{{ID: asset, CategoryID: category, Name: "Drill", Quantity: 1}}
+	e.Files = []portable.File{{ID: uuid.New(), AssetID: asset, FileName: "manual.pdf"}}
+	var archive bytes.Buffer
+	if _, err := portable.Write(&archive, e, func(portable.File) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader("%PDF")), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		body       []byte
+		wantStatus int
+	}{
+		{"not a zip", []byte("name,category\nDrill,Tools\n"), http.StatusBadRequest},
+		{"files without storage", archive.Bytes(), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{repos: &Repositories{}}
+			req := httptest.NewRequest(http.MethodPost, "/api/organization/import", bytes.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			h.ImportPortable(rr, req)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.wantStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/money"
+)
+
+// maxPageSize is the largest page size of asset lists
+const maxPageSize = 100
+
+const (
+	maxViewPreferences    = 50       // Views with a stored layout per user
+	maxViewPreferenceSize = 16 << 10 // Bytes of JSON per view layout
+)
+
+// viewNamePattern matches view names, e.g. "assets" or "locations.detail"
+var viewNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,63}$`)
+
+// GetMyPreferences returns the current user's web app preferences
+func (h *Handler) GetMyPreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUser(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+
+	prefs, err := h.repos.Users.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get preferences")
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+// UpdateMyPreferences replaces the current user's web app preferences. View
+// layouts are kept when "views" is omitted.
+func (h *Handler) UpdateMyPreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUser(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+
+	var prefs domain.UserPreferences
+	if err := decodeJSON(r, &prefs); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if msg := validatePreferences(&prefs); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if prefs.Views == nil {
+		current, err := h.repos.Users.GetPreferences(r.Context(), user.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get preferences")
+			return
+		}
+		prefs.Views = current.Views
+	}
+	if prefs.DefaultCategoryID != nil {
+		cat, err := h.repos.Categories.GetByID(r.Context(), *prefs.DefaultCategoryID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get category")
+			return
+		}
+		if cat == nil || cat.OrganizationID != h.org(r) {
+			writeError(w, http.StatusBadRequest, "default category not found")
+			return
+		}
+	}
+
+	if err := h.repos.Users.SetPreferences(r.Context(), user.ID, &prefs); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save preferences")
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+// validatePreferences checks and normalizes preferences, returning an error
+// message if they are invalid
+func validatePreferences(p *domain.UserPreferences) string {
+	// 0 leaves the page size unset, like the other fields
+	if p.PageSize < 0 || p.PageSize > maxPageSize {
+		return "page size must be between 1 and 100, or 0 for the default"
+	}
+	if p.Currency != "" {
+		currency, err := money.NormalizeCurrency(p.Currency)
+		if err != nil {
+			return "invalid currency"
+		}
+		p.Currency = currency
+	}
+	if p.DateFormat != "" && !slices.Contains(domain.DateFormats, p.DateFormat) {
+		return "unknown date format"
+	}
+	if p.Theme != "" && !slices.Contains(domain.Themes, p.Theme) {
+		return "unknown theme"
+	}
+	if len(p.Views) > maxViewPreferences {
+		return "too many views"
+	}
+	for view, layout := range p.Views {
+		if msg := validateViewPreferences(view, layout); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// validateViewPreferences checks the layout of a view, returning an error
+// message if it is invalid
+func validateViewPreferences(view string, layout json.RawMessage) string {
+	if !viewNamePattern.MatchString(view) {
+		return "invalid view name"
+	}
+	if len(layout) > maxViewPreferenceSize {
+		return "view preferences too large"
+	}
+	if !json.Valid(layout) || string(layout) == "null" {
+		return "view preferences must be JSON"
+	}
+	return ""
+}
+
+// GetMyViewPreferences returns the current user's layout of a view
+func (h *Handler) GetMyViewPreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUser(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+
+	prefs, err := h.repos.Users.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get preferences")
+		return
+	}
+	layout, ok := prefs.Views[chi.URLParam(r, "view")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "view preferences not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(layout)
+}
+
+// UpdateMyViewPreferences stores the current user's layout of a view (any
+// JSON the web app chooses), leaving other preferences untouched
+func (h *Handler) UpdateMyViewPreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUser(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+
+	view := chi.URLParam(r, "view")
+	layout, err := io.ReadAll(io.LimitReader(r.Body, maxViewPreferenceSize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if msg := validateViewPreferences(view, layout); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	prefs, err := h.repos.Users.GetPreferences(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get preferences")
+		return
+	}
+	if _, ok := prefs.Views[view]; !ok && len(prefs.Views) >= maxViewPreferences {
+		writeError(w, http.StatusBadRequest, "too many views")
+		return
+	}
+
+	if err := h.repos.Users.SetViewPreferences(r.Context(), user.ID, view, layout); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save preferences")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(layout)
+}
+
+// DeleteMyViewPreferences resets the current user's layout of a view
+func (h *Handler) DeleteMyViewPreferences(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUser(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+
+	deleted, err := h.repos.Users.DeleteViewPreferences(r.Context(), user.ID, chi.URLParam(r, "view"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete preferences")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "view preferences not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
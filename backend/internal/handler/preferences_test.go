@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_validatePreferences(t *testing.T) {
+	valid := &domain.UserPreferences{PageSize: 50, Currency: " eur", DateFormat: "DD.MM.YYYY", Theme: "dark"}
+	if msg := validatePreferences(valid); msg != "" {
+		t.Fatalf("validatePreferences() = %q, want valid", msg)
+	}
+	if valid.Currency != "EUR" {
+		t.Errorf("Currency = %q, want EUR", valid.Currency)
+	}
+
+	if msg := validatePreferences(&domain.UserPreferences{}); msg != "" {
+		t.Errorf("validatePreferences() of empty preferences = %q, want valid", msg)
+	}
+
+	invalid := []domain.UserPreferences{
+		{PageSize: -1},
+		{PageSize: 101},
+		{Currency: "euro"},
+		{DateFormat: "YY-M-D"},
+		{Theme: "pink"},
+		{Views: map[string]json.RawMessage{"Assets!": json.RawMessage(`{}`)}},
+		{Views: map[string]json.RawMessage{"assets": json.RawMessage(`{`)}},
+	}
+	for _, p := range invalid {
+		if msg := validatePreferences(&p); msg == "" {
+			t.Errorf("validatePreferences(%+v) should fail", p)
+		}
+	}
+}
+
+func Test_validateViewPreferences(t *testing.T) {
+	tests := []struct {
+		view, layout string
+		valid        bool
+	}{
+		{"assets", `{"columns":["name","location"],"sort":"-created_at"}`, true},
+		{"locations.detail", `["name"]`, true},
+		{"", `{}`, false},
+		{"Assets", `{}`, false},
+		{"../assets", `{}`, false},
+		{"assets", `null`, false},
+		{"assets", `not json`, false},
+		{"assets", `"` + strings.Repeat("x", maxViewPreferenceSize) + `"`, false},
+	}
+	for _, tt := range tests {
+		if msg := validateViewPreferences(tt.view, json.RawMessage(tt.layout)); (msg == "") != tt.valid {
+			t.Errorf("validateViewPreferences(%q) = %q, want valid: %v", tt.view, msg, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+const (
+	// maxGalleryItems is the largest number of items listed in the public gallery
+	maxGalleryItems = 1000
+
+	// galleryCacheControl lets browsers and proxies cache the public gallery;
+	// image redirects stay valid for longer than that (see mainAttachmentURL)
+	galleryCacheControl = "public, max-age=600"
+)
+
+// gallerySlugPattern matches the URL slug of the public gallery
+var gallerySlugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,62}[a-z0-9])?$`)
+
+// PublicGalleryItem is an item of the public gallery
+type PublicGalleryItem struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	Quantity    int     `json:"quantity"`
+	Category    string  `json:"category,omitempty"`
+	ImageURL    string  `json:"image_url,omitempty"`
+}
+
+// PublicGalleryResponse is the public view of the gallery
+type PublicGalleryResponse struct {
+	Title string              `json:"title"`
+	Items []PublicGalleryItem `json:"items"`
+}
+
+// GetPublicGallerySettings returns the public gallery settings (admin only)
+func (h *Handler) GetPublicGallerySettings(w http.ResponseWriter, r *http.Request) {
+	gallery, err := h.publicGallery(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get public gallery")
+		return
+	}
+	writeJSON(w, http.StatusOK, gallery)
+}
+
+// UpdatePublicGallerySettings replaces the public gallery settings (admin only)
+func (h *Handler) UpdatePublicGallerySettings(w http.ResponseWriter, r *http.Request) {
+	var gallery domain.PublicGallery
+	if err := decodeJSON(r, &gallery); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if gallery.CategoryIDs == nil {
+		gallery.CategoryIDs = []uuid.UUID{}
+	}
+	if gallery.Enabled {
+		if !gallerySlugPattern.MatchString(gallery.Slug) {
+			writeError(w, http.StatusBadRequest, "slug must be lowercase letters, digits and dashes")
+			return
+		}
+		if len(gallery.CategoryIDs) == 0 {
+			writeError(w, http.StatusBadRequest, "select at least one category")
+			return
+		}
+		other, err := h.enabledGallery(r.Context(), gallery.Slug)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get public gallery")
+			return
+		}
+		if other != nil && other.OrganizationID != h.org(r) {
+			writeError(w, http.StatusConflict, "slug is used by another organization")
+			return
+		}
+	}
+	for _, id := range gallery.CategoryIDs {
+		cat, err := h.repos.Categories.GetByID(r.Context(), id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get category")
+			return
+		}
+		if cat == nil || cat.OrganizationID != h.org(r) {
+			writeError(w, http.StatusBadRequest, "category not found: "+id.String())
+			return
+		}
+	}
+
+	if err := h.repos.Settings.Set(r.Context(), h.org(r), domain.SettingGallery, gallery); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save public gallery")
+		return
+	}
+	writeJSON(w, http.StatusOK, gallery)
+}
+
+// GetPublicGallery returns the items of the public gallery (no auth required)
+func (h *Handler) GetPublicGallery(w http.ResponseWriter, r *http.Request) {
+	gallery, ok := h.loadPublicGallery(w, r)
+	if !ok {
+		return
+	}
+
+	response, err := h.galleryResponse(r, gallery)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list assets")
+		return
+	}
+	writeCachedJSON(w, r, response)
+}
+
+// galleryResponse returns the public view of the gallery
+func (h *Handler) galleryResponse(r *http.Request, gallery *domain.PublicGallery) (*PublicGalleryResponse, error) {
+	assets, err := h.galleryAssets(r.Context(), gallery, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	title := gallery.Title
+	if title == "" {
+		branding, err := h.loadBranding(r.Context(), gallery.OrganizationID)
+		if err != nil {
+			return nil, err
+		}
+		title = toBrandingResponse(branding).Title
+	}
+
+	response := &PublicGalleryResponse{Title: title, Items: make([]PublicGalleryItem, len(assets))}
+	for i := range assets {
+		a := &assets[i]
+		item := PublicGalleryItem{Name: a.Name, Description: a.Description, Quantity: a.Quantity}
+		if a.Category != nil {
+			item.Category = a.Category.Name
+		}
+		if a.MainAttachment != nil {
+			item.ImageURL = h.absoluteURL(r, galleryImagePath(gallery.Slug, a.ID))
+		}
+		response.Items[i] = item
+	}
+	return response, nil
+}
+
+// GetPublicGalleryImage redirects to the main image of a gallery item (no auth
+// required). The stable URL keeps the gallery cacheable while the storage
+// URLs it redirects to expire.
+func (h *Handler) GetPublicGalleryImage(w http.ResponseWriter, r *http.Request) {
+	gallery, ok := h.loadPublicGallery(w, r)
+	if !ok {
+		return
+	}
+	assetID, err := parseUUID(r, "assetId")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid asset ID")
+		return
+	}
+
+	assets, err := h.galleryAssets(r.Context(), gallery, []uuid.UUID{assetID})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return
+	}
+	if len(assets) == 0 {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+	url := h.mainAttachmentURL(r, &assets[0])
+	if url == "" {
+		writeError(w, http.StatusNotFound, "image not found")
+		return
+	}
+
+	w.Header().Set("Cache-Control", galleryCacheControl)
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// loadPublicGallery returns the gallery of the slug in the URL, writing a not
+// found response unless it is enabled
+func (h *Handler) loadPublicGallery(w http.ResponseWriter, r *http.Request) (*domain.PublicGallery, bool) {
+	gallery, err := h.enabledGallery(r.Context(), chi.URLParam(r, "slug"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get public gallery")
+		return nil, false
+	}
+	if gallery == nil {
+		writeError(w, http.StatusNotFound, "gallery not found")
+		return nil, false
+	}
+	return gallery, true
+}
+
+// enabledGallery returns the public gallery of any organization if it is
+// enabled at slug, else nil
+func (h *Handler) enabledGallery(ctx context.Context, slug string) (*domain.PublicGallery, error) {
+	var found *domain.PublicGallery
+	err := h.forEachOrganization(ctx, func(orgID uuid.UUID) error {
+		if found != nil {
+			return nil
+		}
+		gallery, err := h.publicGallery(ctx, orgID)
+		if err == nil && gallery.Enabled && gallery.Slug == slug {
+			found = gallery
+		}
+		return err
+	})
+	return found, err
+}
+
+// publicGallery loads the public gallery settings (disabled if unset)
+func (h *Handler) publicGallery(ctx context.Context, orgID uuid.UUID) (*domain.PublicGallery, error) {
+	gallery := &domain.PublicGallery{CategoryIDs: []uuid.UUID{}, OrganizationID: orgID}
+	if _, err := h.repos.Settings.Get(ctx, orgID, domain.SettingGallery, gallery); err != nil {
+		return nil, err
+	}
+	return gallery, nil
+}
+
+// galleryAssets returns the assets shown in the gallery, optionally only
+// those with the given IDs. High-value items are never shown.
+func (h *Handler) galleryAssets(ctx context.Context, gallery *domain.PublicGallery, ids []uuid.UUID) ([]domain.Asset, error) {
+	filter := domain.AssetFilter{CategoryIDs: gallery.CategoryIDs, NoHighValue: true, IDs: ids}
+	assets, _, err := h.repos.Assets.List(ctx, gallery.OrganizationID, filter, domain.Pagination{Limit: maxGalleryItems})
+	return assets, err
+}
+
+func galleryImagePath(slug string, assetID uuid.UUID) string {
+	return "/public/" + slug + "/images/" + assetID.String()
+}
+
+// writeCachedJSON writes a publicly cacheable JSON response (see writeCached)
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode response")
+		return
+	}
+	writeCached(w, r, "application/json", body)
+}
+
+// writeCached writes a publicly cacheable response, answering conditional
+// requests for an unchanged body with 304 Not Modified
+func writeCached(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("Cache-Control", galleryCacheControl)
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(body)
+}
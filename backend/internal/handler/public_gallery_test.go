@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_gallerySlugPattern(t *testing.T) {
+	tests := map[string]bool{
+		"board-games": true,
+		"attic":       true,
+		"a":           true,
+		"":            false,
+		"-games":      false,
+		"games-":      false,
+		"Board-Games": false,
+		"board_games": false,
+		"a/b":         false,
+	}
+	for slug, want := range tests {
+		if got := gallerySlugPattern.MatchString(slug); got != want {
+			t.Errorf("gallerySlugPattern.MatchString(%q) = %v, want %v", slug, got, want)
+		}
+	}
+}
+
+func Test_writeCachedJSON_NotModified(t *testing.T) {
+	body := PublicGalleryResponse{Title: "Games", Items: []PublicGalleryItem{{Name: "Catan", Quantity: 1}}}
+
+	rec := httptest.NewRecorder()
+	writeCachedJSON(rec, httptest.NewRequest(http.MethodGet, "/public/games", nil), body)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", rec.Code, etag)
+	}
+	if rec.Header().Get("Cache-Control") != galleryCacheControl {
+		t.Errorf("expected public caching, got %q", rec.Header().Get("Cache-Control"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/public/games", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	writeCachedJSON(rec, req, body)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("expected 304 without body, got %d (%d bytes)", rec.Code, rec.Body.Len())
+	}
+}
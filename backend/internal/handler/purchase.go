@@ -0,0 +1,477 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/money"
+)
+
+// PurchaseRequest creates or replaces a purchase on the wishlist
+type PurchaseRequest struct {
+	Name           string      `json:"name"`
+	CategoryID     *uuid.UUID  `json:"category_id,omitempty"` // Of the asset it becomes
+	Notes          *string     `json:"notes,omitempty"`
+	Quantity       int         `json:"quantity"` // Defaults to 1
+	Vendor         *string     `json:"vendor,omitempty"`
+	Price          *PriceInput `json:"price,omitempty"`       // Per unit, number or localized string, e.g. "1.250 €"
+	Currency       *string     `json:"currency,omitempty"`    // ISO 4217 code
+	ExpectedAt     *string     `json:"expected_at,omitempty"` // YYYY-MM-DD
+	WarrantyMonths *int        `json:"warranty_months,omitempty"`
+}
+
+// OrderPurchaseRequest marks a purchase ordered; vendor, price and expected
+// date default to those already on the purchase
+type OrderPurchaseRequest struct {
+	Vendor     *string     `json:"vendor,omitempty"`
+	Price      *PriceInput `json:"price,omitempty"`
+	Currency   *string     `json:"currency,omitempty"`
+	OrderedAt  *string     `json:"ordered_at,omitempty"`  // YYYY-MM-DD, defaults to today
+	ExpectedAt *string     `json:"expected_at,omitempty"` // YYYY-MM-DD
+}
+
+// ReceivePurchaseRequest turns a purchase that arrived into an asset
+type ReceivePurchaseRequest struct {
+	ReceivedAt *string    `json:"received_at,omitempty"` // YYYY-MM-DD, defaults to today
+	LocationID *uuid.UUID `json:"location_id,omitempty"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"` // Defaults to the purchase's, one is required
+}
+
+// PurchaseResponse is a purchase and whether its order is late
+type PurchaseResponse struct {
+	domain.Purchase
+	Late bool `json:"late"` // Ordered and expected before today
+}
+
+// ReceivePurchaseResponse is a received purchase and the asset it became
+type ReceivePurchaseResponse struct {
+	Purchase domain.Purchase  `json:"purchase"`
+	Asset    *domain.Asset    `json:"asset"`
+	Warranty *domain.Warranty `json:"warranty,omitempty"` // Started on arrival if the purchase has one
+}
+
+// maxPurchaseName is the longest purchase name, in characters
+const maxPurchaseName = 255
+
+// ListPurchases returns the organization's purchases in pipeline order.
+// ?status= selects wanted, ordered or received ones and can be repeated;
+// by default, those not received yet.
+func (h *Handler) ListPurchases(w http.ResponseWriter, r *http.Request) {
+	statuses, err := parsePurchaseStatuses(r.URL.Query()["status"])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if statuses == nil {
+		statuses = []domain.PurchaseStatus{domain.PurchaseWanted, domain.PurchaseOrdered}
+	}
+
+	purchases, err := h.repos.Purchases.List(r.Context(), h.org(r), domain.PurchaseFilter{Statuses: statuses})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list purchases")
+		return
+	}
+
+	y, m, d := today(h.location(r)).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	response := make([]PurchaseResponse, len(purchases))
+	for i := range purchases {
+		response[i] = PurchaseResponse{Purchase: purchases[i], Late: purchases[i].Late(day)}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) GetPurchase(w http.ResponseWriter, r *http.Request) {
+	purchase, ok := h.purchase(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, h.purchaseResponse(r, purchase))
+}
+
+// CreatePurchase adds a purchase to the wishlist
+func (h *Handler) CreatePurchase(w http.ResponseWriter, r *http.Request) {
+	var req PurchaseRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	purchase := &domain.Purchase{OrganizationID: h.org(r), Status: domain.PurchaseWanted, CreatedBy: currentUserID(r)}
+	if err := applyPurchase(purchase, &req, requestLocale(r)); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.purchaseCategory(w, r, purchase.CategoryID) {
+		return
+	}
+	if err := h.repos.Purchases.Create(r.Context(), purchase); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create purchase")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, PurchaseResponse{Purchase: *purchase})
+}
+
+// UpdatePurchase replaces the details of a purchase not received yet,
+// keeping its status and order date
+func (h *Handler) UpdatePurchase(w http.ResponseWriter, r *http.Request) {
+	var req PurchaseRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	purchase, ok := h.openPurchase(w, r)
+	if !ok {
+		return
+	}
+	if err := applyPurchase(purchase, &req, requestLocale(r)); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.purchaseCategory(w, r, purchase.CategoryID) {
+		return
+	}
+	if err := h.repos.Purchases.Update(r.Context(), purchase); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update purchase")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.purchaseResponse(r, purchase))
+}
+
+// DeletePurchase removes a purchase; the asset a received one became stays
+func (h *Handler) DeletePurchase(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid purchase ID")
+		return
+	}
+
+	if err := h.repos.Purchases.Delete(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete purchase")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// OrderPurchase marks a purchase ordered, with its vendor, price and when
+// it should arrive; ordering it again corrects the order
+func (h *Handler) OrderPurchase(w http.ResponseWriter, r *http.Request) {
+	var req OrderPurchaseRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	purchase, ok := h.openPurchase(w, r)
+	if !ok {
+		return
+	}
+	if err := applyOrder(purchase, &req, requestLocale(r), today(h.location(r))); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.repos.Purchases.Update(r.Context(), purchase); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to order purchase")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.purchaseResponse(r, purchase))
+}
+
+// ReceivePurchase records the arrival of a purchase: it becomes an owned
+// asset with its purchase details, and its warranty, if any, starts
+func (h *Handler) ReceivePurchase(w http.ResponseWriter, r *http.Request) {
+	var req ReceivePurchaseRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	purchase, ok := h.openPurchase(w, r)
+	if !ok {
+		return
+	}
+	if req.CategoryID != nil {
+		purchase.CategoryID = req.CategoryID
+	}
+	if purchase.CategoryID == nil {
+		writeError(w, http.StatusBadRequest, "category_id is required to receive a purchase")
+		return
+	}
+	if !h.purchaseCategory(w, r, purchase.CategoryID) {
+		return
+	}
+	// Like maintenance, an arrival can't be dated in the future
+	receivedAt, err := parseDoneAt(req.ReceivedAt, today(h.location(r)))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid received_at, expected YYYY-MM-DD not in the future")
+		return
+	}
+
+	asset := assetFromPurchase(purchase, receivedAt)
+	asset.LocationID = req.LocationID
+	if err := h.applyHighValue(r.Context(), asset, nil); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+
+	// Claimed first, so that a purchase received twice at once becomes a
+	// single asset
+	claimed, err := h.repos.Purchases.Receive(r.Context(), purchase.ID, receivedAt)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to receive purchase")
+		return
+	}
+	if !claimed {
+		writeError(w, http.StatusConflict, "purchase already received")
+		return
+	}
+	if err := h.repos.Assets.Create(r.Context(), asset); err != nil {
+		// Back in the pipeline, so that it can be received again
+		if err := h.repos.Purchases.Update(r.Context(), purchase); err != nil {
+			slog.Warn("failed to reopen purchase", "purchase_id", purchase.ID, "error", err)
+		}
+		writeError(w, http.StatusInternalServerError, "failed to create asset")
+		return
+	}
+
+	purchase.Status = domain.PurchaseReceived
+	purchase.ReceivedAt = &receivedAt
+	purchase.AssetID = &asset.ID
+	if err := h.repos.Purchases.Update(r.Context(), purchase); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to receive purchase")
+		return
+	}
+
+	var warranty *domain.Warranty
+	if end := purchase.WarrantyEnd(receivedAt); end != nil {
+		warranty = &domain.Warranty{AssetID: asset.ID, Provider: purchase.Vendor, StartDate: &receivedAt, EndDate: end}
+		if err := h.repos.Warranties.Create(r.Context(), warranty); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to create warranty")
+			return
+		}
+	}
+
+	h.publish(r, events.AssetCreated, asset.OrganizationID, asset.ID)
+
+	writeJSON(w, http.StatusCreated, ReceivePurchaseResponse{Purchase: *purchase, Asset: asset, Warranty: warranty})
+}
+
+// purchase returns the purchase of the "id" URL parameter, writing an error
+// response if it is invalid or doesn't exist
+func (h *Handler) purchase(w http.ResponseWriter, r *http.Request) (*domain.Purchase, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid purchase ID")
+		return nil, false
+	}
+
+	purchase, err := h.repos.Purchases.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get purchase")
+		return nil, false
+	}
+	if purchase == nil {
+		writeError(w, http.StatusNotFound, "purchase not found")
+		return nil, false
+	}
+	return purchase, true
+}
+
+// openPurchase is purchase, with a conflict if it was received already
+func (h *Handler) openPurchase(w http.ResponseWriter, r *http.Request) (*domain.Purchase, bool) {
+	purchase, ok := h.purchase(w, r)
+	if !ok {
+		return nil, false
+	}
+	if purchase.Status == domain.PurchaseReceived {
+		writeError(w, http.StatusConflict, "purchase already received")
+		return nil, false
+	}
+	return purchase, true
+}
+
+// purchaseCategory checks the category of a purchase, nil for none, writing
+// an error response if it doesn't exist in the organization
+func (h *Handler) purchaseCategory(w http.ResponseWriter, r *http.Request, categoryID *uuid.UUID) bool {
+	if categoryID == nil {
+		return true
+	}
+
+	category, err := h.repos.Categories.GetByID(r.Context(), *categoryID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check category")
+		return false
+	}
+	if category == nil || category.OrganizationID != h.org(r) {
+		writeError(w, http.StatusBadRequest, "category not found")
+		return false
+	}
+	return true
+}
+
+// purchaseResponse returns a purchase and whether its order is late today
+// in the caller's time zone
+func (h *Handler) purchaseResponse(r *http.Request, purchase *domain.Purchase) PurchaseResponse {
+	y, m, d := today(h.location(r)).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return PurchaseResponse{Purchase: *purchase, Late: purchase.Late(day)}
+}
+
+// parsePurchaseStatuses parses ?status= values, nil if there are none
+func parsePurchaseStatuses(values []string) ([]domain.PurchaseStatus, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	statuses := make([]domain.PurchaseStatus, len(values))
+	for i, v := range values {
+		statuses[i] = domain.PurchaseStatus(v)
+		if !statuses[i].Valid() {
+			return nil, fmt.Errorf("invalid status %q, expected wanted, ordered or received", v)
+		}
+	}
+	return statuses, nil
+}
+
+// applyPurchase validates req and copies it onto purchase
+func applyPurchase(purchase *domain.Purchase, req *PurchaseRequest, locale string) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if utf8.RuneCountInString(name) > maxPurchaseName {
+		return errors.New("name is too long")
+	}
+	quantity := req.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+	if quantity > maxAssetQuantity {
+		return errors.New("quantity exceeds maximum allowed value")
+	}
+	if req.WarrantyMonths != nil && *req.WarrantyMonths < 1 {
+		return errors.New("warranty_months must be at least 1")
+	}
+	price, currency, err := parsePurchasePrice("price", req.Price, req.Currency, locale)
+	if err != nil {
+		return err
+	}
+	var expectedAt *time.Time
+	if req.ExpectedAt != nil && *req.ExpectedAt != "" {
+		// Stored as a DATE, the location doesn't change the day
+		t, err := parseDate(*req.ExpectedAt, time.UTC)
+		if err != nil {
+			return errors.New("invalid expected_at, expected YYYY-MM-DD")
+		}
+		expectedAt = &t
+	}
+
+	purchase.Name = name
+	purchase.CategoryID = req.CategoryID
+	purchase.Notes = optionalText(req.Notes)
+	purchase.Quantity = quantity
+	purchase.Vendor = optionalText(req.Vendor)
+	purchase.Price, purchase.Currency = price, currency
+	purchase.ExpectedAt = expectedAt
+	purchase.WarrantyMonths = req.WarrantyMonths
+	return nil
+}
+
+// applyOrder validates req and marks purchase ordered; day is today
+func applyOrder(purchase *domain.Purchase, req *OrderPurchaseRequest, locale string, day time.Time) error {
+	orderedAt, err := parseDoneAt(req.OrderedAt, day)
+	if err != nil {
+		return errors.New("invalid ordered_at, expected YYYY-MM-DD not in the future")
+	}
+	if req.Price != nil {
+		price, currency, err := parsePurchasePrice("price", req.Price, req.Currency, locale)
+		if err != nil {
+			return err
+		}
+		purchase.Price, purchase.Currency = price, currency
+	}
+	switch {
+	case req.ExpectedAt == nil:
+	case *req.ExpectedAt == "":
+		purchase.ExpectedAt = nil
+	default:
+		t, err := parseDate(*req.ExpectedAt, time.UTC)
+		if err != nil {
+			return errors.New("invalid expected_at, expected YYYY-MM-DD")
+		}
+		if t.Before(orderedAt) {
+			return errors.New("expected_at can't be before ordered_at")
+		}
+		purchase.ExpectedAt = &t
+	}
+	if req.Vendor != nil {
+		purchase.Vendor = optionalText(req.Vendor)
+	}
+
+	purchase.Status = domain.PurchaseOrdered
+	purchase.OrderedAt = &orderedAt
+	return nil
+}
+
+// parsePurchasePrice parses the price in a request field and its currency,
+// which can also come with the price, e.g. "€ 1.299,00"; no price is none
+func parsePurchasePrice(field string, price *PriceInput, currency *string, locale string) (*float64, *string, error) {
+	var code *string
+	if currency != nil && *currency != "" {
+		normalized, err := money.NormalizeCurrency(*currency)
+		if err != nil {
+			return nil, nil, err
+		}
+		code = &normalized
+	}
+	if price == nil || (price.number == nil && strings.TrimSpace(price.text) == "") {
+		return nil, code, nil
+	}
+	parsed, err := price.Parse(locale)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %w", field, err)
+	}
+	if parsed.Currency != "" {
+		if code != nil && *code != parsed.Currency {
+			return nil, nil, fmt.Errorf("%s currency %s does not match currency %s", field, parsed.Currency, *code)
+		}
+		code = &parsed.Currency
+	}
+	return &parsed.Amount, code, nil
+}
+
+// assetFromPurchase returns the owned asset a purchase becomes when it
+// arrives on day, with its purchase details; bought when it was ordered,
+// else when it arrived
+func assetFromPurchase(p *domain.Purchase, day time.Time) *domain.Asset {
+	asset := &domain.Asset{
+		OrganizationID: p.OrganizationID,
+		Name:           p.Name,
+		Quantity:       p.Quantity,
+		Notes:          p.Notes,
+		PurchasePrice:  p.Price,
+		Currency:       p.Currency,
+		PurchaseNote:   p.Vendor,
+		Status:         domain.AssetStatusOwned,
+	}
+	if p.CategoryID != nil {
+		asset.CategoryID = *p.CategoryID
+	}
+	asset.PurchaseAt = &day
+	if p.OrderedAt != nil {
+		asset.PurchaseAt = p.OrderedAt
+	}
+	return asset
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+)
+
+// PurchaseGroupRequest creates the assets of a bundle bought for one price,
+// e.g. a camera kit, allocating the total across them
+type PurchaseGroupRequest struct {
+	Name         string                     `json:"name"`
+	Total        *PriceInput                `json:"total"`                 // Number or localized string, e.g. "1.499 €"
+	Currency     *string                    `json:"currency,omitempty"`    // ISO 4217 code
+	PurchaseAt   *string                    `json:"purchase_at,omitempty"` // YYYY-MM-DD
+	PurchaseNote *string                    `json:"purchase_note,omitempty"`
+	Allocation   domain.BundleAllocation    `json:"allocation"` // proportional (default) or manual
+	Items        []PurchaseGroupItemRequest `json:"items"`
+}
+
+// PurchaseGroupItemRequest is an asset of a bundle
+type PurchaseGroupItemRequest struct {
+	Name       string      `json:"name"`
+	CategoryID uuid.UUID   `json:"category_id"`
+	LocationID *uuid.UUID  `json:"location_id,omitempty"`
+	Quantity   int         `json:"quantity"`             // Defaults to 1
+	ListPrice  *PriceInput `json:"list_price,omitempty"` // Proportional: what one costs on its own
+	Price      *PriceInput `json:"price,omitempty"`      // Manual: the share of one unit
+}
+
+// PurchaseGroupResponse is a purchase group as the caller may see it
+type PurchaseGroupResponse struct {
+	domain.PurchaseGroup
+	Total *float64 `json:"total,omitempty"` // Left out when it would reveal hidden high-value assets
+}
+
+// maxPurchaseGroupItems is the most assets one purchase group may create
+const maxPurchaseGroupItems = 100
+
+// ListPurchaseGroups returns the organization's purchase groups, latest
+// purchase first
+func (h *Handler) ListPurchaseGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.repos.Bundles.List(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list purchase groups")
+		return
+	}
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+
+	response := make([]PurchaseGroupResponse, len(groups))
+	for i := range groups {
+		response[i] = newPurchaseGroupResponse(&groups[i], hide)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// GetPurchaseGroup returns a purchase group with its assets and their shares
+func (h *Handler) GetPurchaseGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid purchase group ID")
+		return
+	}
+
+	group, err := h.repos.Bundles.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get purchase group")
+		return
+	}
+	if group == nil {
+		writeError(w, http.StatusNotFound, "purchase group not found")
+		return
+	}
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	writeJSON(w, http.StatusOK, newPurchaseGroupResponse(group, hide))
+}
+
+// CreatePurchaseGroup records a bundle and creates its assets, all at once,
+// with the total allocated across their purchase prices
+func (h *Handler) CreatePurchaseGroup(w http.ResponseWriter, r *http.Request) {
+	var req PurchaseGroupRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	group, assets, err := purchaseGroupFromRequest(&req, requestLocale(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	orgID := h.org(r)
+	group.OrganizationID = orgID
+	group.CreatedBy = currentUserID(r)
+	policy, err := h.highValuePolicy(r.Context(), orgID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	checked := map[uuid.UUID]bool{}
+	for _, asset := range assets {
+		if !checked[asset.CategoryID] && !h.purchaseCategory(w, r, &asset.CategoryID) {
+			return
+		}
+		checked[asset.CategoryID] = true
+		asset.OrganizationID = orgID
+		asset.HighValue = policy.Exceeds(asset)
+	}
+
+	if err := h.repos.Bundles.Create(r.Context(), group, assets); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create purchase group")
+		return
+	}
+	for _, asset := range assets {
+		h.publish(r, events.AssetCreated, orgID, asset.ID)
+	}
+
+	writeJSON(w, http.StatusCreated, newPurchaseGroupResponse(group, !policy.CanView(currentUserRole(r))))
+}
+
+// DeletePurchaseGroup removes a purchase group; its assets stay, with their
+// shares as purchase prices
+func (h *Handler) DeletePurchaseGroup(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid purchase group ID")
+		return
+	}
+
+	if err := h.repos.Bundles.Delete(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete purchase group")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newPurchaseGroupResponse returns group, without its high-value assets and
+// total if hide is set. The total is the sum of all shares, so it would
+// reveal the price of the hidden assets.
+func newPurchaseGroupResponse(group *domain.PurchaseGroup, hide bool) PurchaseGroupResponse {
+	response := PurchaseGroupResponse{PurchaseGroup: *group, Total: &group.Total}
+	if hide && group.HighValue {
+		response.Total = nil
+		response.Items = slices.DeleteFunc(slices.Clone(group.Items), func(item domain.PurchaseGroupItem) bool { return item.HighValue })
+	}
+	return response
+}
+
+// purchaseGroupFromRequest validates req and returns the purchase group and
+// the assets it creates, their purchase prices allocated from the total
+func purchaseGroupFromRequest(req *PurchaseGroupRequest, locale string) (*domain.PurchaseGroup, []*domain.Asset, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, nil, errors.New("name is required")
+	}
+	if utf8.RuneCountInString(name) > maxPurchaseName {
+		return nil, nil, errors.New("name is too long")
+	}
+	if req.Allocation == "" {
+		req.Allocation = domain.BundleProportional
+	}
+	if !req.Allocation.Valid() {
+		return nil, nil, fmt.Errorf("invalid allocation %q, expected proportional or manual", req.Allocation)
+	}
+	if len(req.Items) == 0 {
+		return nil, nil, errors.New("items is required")
+	}
+	if len(req.Items) > maxPurchaseGroupItems {
+		return nil, nil, fmt.Errorf("at most %d items can be bought together", maxPurchaseGroupItems)
+	}
+	total, currency, err := parsePurchasePrice("total", req.Total, req.Currency, locale)
+	if err != nil {
+		return nil, nil, err
+	}
+	if total == nil {
+		return nil, nil, errors.New("total is required")
+	}
+
+	group := &domain.PurchaseGroup{Name: name, Total: *total, Currency: currency, PurchaseNote: optionalText(req.PurchaseNote)}
+	if req.PurchaseAt != nil && *req.PurchaseAt != "" {
+		// Stored as a DATE, the location doesn't change the day
+		t, err := parseDate(*req.PurchaseAt, time.UTC)
+		if err != nil {
+			return nil, nil, errors.New("invalid purchase_at, expected YYYY-MM-DD")
+		}
+		group.PurchaseAt = &t
+	}
+
+	assets := make([]*domain.Asset, len(req.Items))
+	for i := range req.Items {
+		item := &req.Items[i]
+		itemName := strings.TrimSpace(item.Name)
+		if itemName == "" || item.CategoryID == uuid.Nil {
+			return nil, nil, fmt.Errorf("items[%d]: name and category_id are required", i)
+		}
+		quantity := max(item.Quantity, 1)
+		if quantity > maxAssetQuantity {
+			return nil, nil, fmt.Errorf("items[%d]: quantity exceeds maximum allowed value", i)
+		}
+		assets[i] = &domain.Asset{
+			CategoryID:   item.CategoryID,
+			LocationID:   item.LocationID,
+			Name:         itemName,
+			Quantity:     quantity,
+			Status:       domain.AssetStatusOwned,
+			PurchaseAt:   group.PurchaseAt,
+			Currency:     currency,
+			PurchaseNote: group.PurchaseNote,
+		}
+	}
+
+	prices, err := allocatePurchaseGroup(req, *total, currency, locale)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, price := range prices {
+		assets[i].PurchasePrice = &price
+	}
+	return group, assets, nil
+}
+
+// allocatePurchaseGroup returns the purchase price of one unit of each item
+// of a bundle. Proportionally, the total is split by the items' list prices,
+// or evenly by unit without any; manually, the items' prices must add up to
+// the total. Units of an item share its part to the cent, so prices of
+// several units may miss the total by a few cents.
+func allocatePurchaseGroup(req *PurchaseGroupRequest, total float64, currency *string, locale string) ([]float64, error) {
+	prices := make([]float64, len(req.Items))
+
+	if req.Allocation == domain.BundleManual {
+		var sum float64
+		for i, item := range req.Items {
+			price, _, err := parsePurchasePrice(fmt.Sprintf("items[%d].price", i), item.Price, currency, locale)
+			if err != nil {
+				return nil, err
+			}
+			if price == nil {
+				return nil, fmt.Errorf("items[%d]: price is required for a manual allocation", i)
+			}
+			prices[i] = *price
+			sum += *price * float64(max(item.Quantity, 1))
+		}
+		if math.Abs(sum-total) >= 0.005 {
+			return nil, fmt.Errorf("the item prices add up to %.2f, not the total %.2f", sum, total)
+		}
+		return prices, nil
+	}
+
+	weights := make([]float64, len(req.Items))
+	listed := 0
+	for i, item := range req.Items {
+		quantity := float64(max(item.Quantity, 1))
+		price, _, err := parsePurchasePrice(fmt.Sprintf("items[%d].list_price", i), item.ListPrice, currency, locale)
+		if err != nil {
+			return nil, err
+		}
+		weights[i] = quantity
+		if price != nil {
+			weights[i] = *price * quantity
+			listed++
+		}
+	}
+	if listed > 0 && listed < len(req.Items) {
+		return nil, errors.New("list_price is required for every item, or none to split the total evenly")
+	}
+	for i, share := range domain.AllocateBundle(total, weights) {
+		prices[i] = round2(share / float64(max(req.Items[i].Quantity, 1)))
+	}
+	return prices, nil
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_purchaseGroupFromRequest_Proportional(t *testing.T) {
+	cameras := uuid.New()
+	body, lens, card := 900.0, 400.0, 25.0
+	req := &PurchaseGroupRequest{
+		Name:  " Camera kit ",
+		Total: &PriceInput{text: "1.000 €"},
+		Items: []PurchaseGroupItemRequest{
+			{Name: "Camera body", CategoryID: cameras, ListPrice: &PriceInput{number: &body}},
+			{Name: "Kit lens", CategoryID: cameras, ListPrice: &PriceInput{number: &lens}},
+			{Name: "Memory card", CategoryID: cameras, Quantity: 2, ListPrice: &PriceInput{number: &card}},
+		},
+	}
+
+	group, assets, err := purchaseGroupFromRequest(req, "de-DE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if group.Name != "Camera kit" || group.Total != 1000 || group.Currency == nil || *group.Currency != "EUR" {
+		t.Errorf("unexpected group: %+v", group)
+	}
+	// 900 + 400 + 2 × 25 = 1350 at list prices; the parts add up to 1000
+	want := []float64{666.67, 296.29, 18.52}
+	for i, asset := range assets {
+		if asset.PurchasePrice == nil || *asset.PurchasePrice != want[i] || *asset.Currency != "EUR" || asset.Status != domain.AssetStatusOwned {
+			t.Errorf("%s: expected %v EUR, got %v %v", asset.Name, want[i], asset.PurchasePrice, asset.Currency)
+		}
+	}
+}
+
+func Test_purchaseGroupFromRequest_EvenWithoutListPrices(t *testing.T) {
+	total := 90.0
+	req := &PurchaseGroupRequest{
+		Name:  "Cable pack",
+		Total: &PriceInput{number: &total},
+		Items: []PurchaseGroupItemRequest{
+			{Name: "HDMI cable", CategoryID: uuid.New(), Quantity: 2},
+			{Name: "USB-C cable", CategoryID: uuid.New()},
+		},
+	}
+
+	_, assets, err := purchaseGroupFromRequest(req, "en-US")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *assets[0].PurchasePrice != 30 || *assets[1].PurchasePrice != 30 {
+		t.Errorf("expected 30 per cable, got %v and %v", *assets[0].PurchasePrice, *assets[1].PurchasePrice)
+	}
+}
+
+func Test_purchaseGroupFromRequest_Manual(t *testing.T) {
+	total, body, lens := 1000.0, 750.0, 250.0
+	item := func(name string, price *float64) PurchaseGroupItemRequest {
+		return PurchaseGroupItemRequest{Name: name, CategoryID: uuid.New(), Price: &PriceInput{number: price}}
+	}
+	req := &PurchaseGroupRequest{
+		Name: "Camera kit", Total: &PriceInput{number: &total}, Allocation: domain.BundleManual,
+		Items: []PurchaseGroupItemRequest{item("Camera body", &body), item("Kit lens", &lens)},
+	}
+
+	_, assets, err := purchaseGroupFromRequest(req, "en-US")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *assets[0].PurchasePrice != 750 || *assets[1].PurchasePrice != 250 {
+		t.Errorf("expected the prices entered, got %v and %v", *assets[0].PurchasePrice, *assets[1].PurchasePrice)
+	}
+
+	lens = 200
+	if _, _, err := purchaseGroupFromRequest(req, "en-US"); err == nil {
+		t.Error("expected prices that don't add up to the total to be rejected")
+	}
+}
+
+func Test_purchaseGroupFromRequest_Invalid(t *testing.T) {
+	total, price := 100.0, 50.0
+	valid := PurchaseGroupItemRequest{Name: "Tripod", CategoryID: uuid.New(), ListPrice: &PriceInput{number: &price}}
+	unpriced := PurchaseGroupItemRequest{Name: "Bag", CategoryID: uuid.New()}
+
+	for name, req := range map[string]PurchaseGroupRequest{
+		"no name":            {Total: &PriceInput{number: &total}, Items: []PurchaseGroupItemRequest{valid}},
+		"no total":           {Name: "Kit", Items: []PurchaseGroupItemRequest{valid}},
+		"no items":           {Name: "Kit", Total: &PriceInput{number: &total}},
+		"unknown allocation": {Name: "Kit", Total: &PriceInput{number: &total}, Allocation: "random", Items: []PurchaseGroupItemRequest{valid}},
+		"no category":        {Name: "Kit", Total: &PriceInput{number: &total}, Items: []PurchaseGroupItemRequest{{Name: "Tripod"}}},
+		"some list prices":   {Name: "Kit", Total: &PriceInput{number: &total}, Items: []PurchaseGroupItemRequest{valid, unpriced}},
+		"manual without":     {Name: "Kit", Total: &PriceInput{number: &total}, Allocation: domain.BundleManual, Items: []PurchaseGroupItemRequest{unpriced}},
+		"currency mismatch":  {Name: "Kit", Total: &PriceInput{text: "100 €"}, Items: []PurchaseGroupItemRequest{{Name: "Tripod", CategoryID: uuid.New(), ListPrice: &PriceInput{text: "$50"}}}},
+	} {
+		if _, _, err := purchaseGroupFromRequest(&req, "en-US"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func Test_newPurchaseGroupResponse_HidesHighValue(t *testing.T) {
+	body, lens := 2500.0, 500.0
+	group := &domain.PurchaseGroup{
+		Name:      "Camera kit",
+		Total:     3000,
+		HighValue: true,
+		Items: []domain.PurchaseGroupItem{
+			{AssetID: uuid.New(), Name: "Camera body", Quantity: 1, PurchasePrice: &body, HighValue: true},
+			{AssetID: uuid.New(), Name: "Kit lens", Quantity: 1, PurchasePrice: &lens},
+		},
+	}
+
+	data, _ := json.Marshal(newPurchaseGroupResponse(group, true))
+	if strings.Contains(string(data), "Camera body") || strings.Contains(string(data), `"total"`) {
+		t.Errorf("expected the body and the total to be hidden, got %s", data)
+	}
+	if !strings.Contains(string(data), "Kit lens") {
+		t.Errorf("expected the lens to be listed, got %s", data)
+	}
+	if len(group.Items) != 2 {
+		t.Errorf("expected the group to be left unchanged, got %+v", group.Items)
+	}
+
+	data, _ = json.Marshal(newPurchaseGroupResponse(group, false))
+	if !strings.Contains(string(data), "Camera body") || !strings.Contains(string(data), `"total":3000`) {
+		t.Errorf("expected the whole group, got %s", data)
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_ListPurchases_InvalidStatus(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	rr := httptest.NewRecorder()
+
+	h.ListPurchases(rr, httptest.NewRequest(http.MethodGet, "/api/purchases?status=ordered&status=lost", nil))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func Test_applyPurchase(t *testing.T) {
+	notes, vendor, months := " ", "Camera Shop ", 24
+	expected := "2025-10-01"
+	purchase := &domain.Purchase{}
+	err := applyPurchase(purchase, &PurchaseRequest{
+		Name: " Mirrorless camera ", Notes: &notes, Vendor: &vendor,
+		Price: &PriceInput{text: "1.299,00 €"}, ExpectedAt: &expected, WarrantyMonths: &months,
+	}, "de-DE")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if purchase.Name != "Mirrorless camera" || purchase.Notes != nil || purchase.Quantity != 1 || *purchase.Vendor != "Camera Shop" {
+		t.Errorf("unexpected purchase: %+v", purchase)
+	}
+	if purchase.Price == nil || *purchase.Price != 1299 || purchase.Currency == nil || *purchase.Currency != "EUR" {
+		t.Errorf("expected 1299 EUR, got %v %v", purchase.Price, purchase.Currency)
+	}
+	if purchase.ExpectedAt == nil || !purchase.ExpectedAt.Equal(*utcDate(2025, 10, 1)) {
+		t.Errorf("unexpected expected_at %v", purchase.ExpectedAt)
+	}
+
+	zero, usd := 0, "USD"
+	for name, req := range map[string]PurchaseRequest{
+		"no name":           {Name: " "},
+		"too many":          {Name: "Batteries", Quantity: maxAssetQuantity + 1},
+		"no warranty":       {Name: "Batteries", WarrantyMonths: &zero},
+		"currency mismatch": {Name: "Batteries", Price: &PriceInput{text: "12 €"}, Currency: &usd},
+		"invalid expected":  {Name: "Batteries", ExpectedAt: &notes},
+	} {
+		if err := applyPurchase(&domain.Purchase{}, &req, "de-DE"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func Test_applyOrder(t *testing.T) {
+	day := time.Date(2025, 9, 10, 0, 0, 0, 0, time.Local)
+	price, vendor := 80.0, "Corner Shop"
+	purchase := &domain.Purchase{Status: domain.PurchaseWanted, Price: &price, Vendor: &vendor}
+
+	expected := "2025-09-15"
+	if err := applyOrder(purchase, &OrderPurchaseRequest{ExpectedAt: &expected}, "en-US", day); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The wishlist's price and vendor are kept
+	if purchase.Status != domain.PurchaseOrdered || !purchase.OrderedAt.Equal(*utcDate(2025, 9, 10)) || *purchase.Price != 80 || *purchase.Vendor != "Corner Shop" {
+		t.Errorf("unexpected order: %+v", purchase)
+	}
+
+	tomorrow, before, earlier := "2025-09-11", "2025-09-08", "2025-09-01"
+	for name, req := range map[string]OrderPurchaseRequest{
+		"ordered tomorrow":         {OrderedAt: &tomorrow},
+		"expected before ordering": {OrderedAt: &before, ExpectedAt: &earlier},
+		"invalid price":            {Price: &PriceInput{text: "cheap"}},
+	} {
+		if err := applyOrder(&domain.Purchase{}, &req, "en-US", day); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func Test_assetFromPurchase(t *testing.T) {
+	categoryID := uuid.New()
+	price, vendor := 1299.0, "Camera Shop"
+	purchase := &domain.Purchase{
+		OrganizationID: uuid.New(), CategoryID: &categoryID, Name: "Mirrorless camera", Quantity: 1,
+		Price: &price, Vendor: &vendor,
+	}
+	arrived := *utcDate(2025, 9, 12)
+
+	asset := assetFromPurchase(purchase, arrived)
+	if asset.CategoryID != categoryID || asset.Status != domain.AssetStatusOwned || *asset.PurchasePrice != 1299 || *asset.PurchaseNote != "Camera Shop" {
+		t.Errorf("unexpected asset: %+v", asset)
+	}
+	if !asset.PurchaseAt.Equal(arrived) {
+		t.Errorf("expected the purchase date to be the arrival, got %v", asset.PurchaseAt)
+	}
+
+	purchase.OrderedAt = utcDate(2025, 9, 1)
+	if asset := assetFromPurchase(purchase, arrived); !asset.PurchaseAt.Equal(*purchase.OrderedAt) {
+		t.Errorf("expected the purchase date to be the order, got %v", asset.PurchaseAt)
+	}
+}
@@ -0,0 +1,393 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/links"
+	"github.com/lmmendes/attic/internal/mail"
+	"github.com/lmmendes/attic/internal/money"
+)
+
+// RecurringCostRequest creates or replaces a recurring cost
+type RecurringCostRequest struct {
+	Name          string              `json:"name"`
+	Amount        *PriceInput         `json:"amount"` // Number or localized string, e.g. "9,99 €"
+	Currency      *string             `json:"currency,omitempty"`
+	Interval      domain.CostInterval `json:"interval"`
+	NextRenewalAt *string             `json:"next_renewal_at,omitempty"` // YYYY-MM-DD
+	RemindDays    int                 `json:"remind_days"`
+	Notes         *string             `json:"notes,omitempty"`
+}
+
+// RecurringCostListResponse lists recurring costs with their rollup
+type RecurringCostListResponse struct {
+	Costs  []domain.RecurringCost     `json:"costs"`
+	Totals domain.RecurringCostTotals `json:"totals"`
+}
+
+// maxRemindDays bounds how far ahead renewal reminders can be sent
+const maxRemindDays = 365
+
+// ListRecurringCosts returns the organization's recurring costs. With
+// ?renewing_within=N only costs renewing in the next N days (or overdue) are returned.
+func (h *Handler) ListRecurringCosts(w http.ResponseWriter, r *http.Request) {
+	var costs []domain.RecurringCost
+	var err error
+	if v := r.URL.Query().Get("renewing_within"); v != "" {
+		days, convErr := strconv.Atoi(v)
+		if convErr != nil || days < 0 {
+			writeError(w, http.StatusBadRequest, "invalid renewing_within parameter")
+			return
+		}
+		until := today(h.location(r)).AddDate(0, 0, days)
+		costs, err = h.repos.Costs.ListRenewingBefore(r.Context(), h.org(r), until)
+	} else {
+		costs, err = h.repos.Costs.List(r.Context(), h.org(r))
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list recurring costs")
+		return
+	}
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if hide {
+		costs = withoutHighValueCosts(costs)
+	}
+
+	writeJSON(w, http.StatusOK, newRecurringCostList(costs))
+}
+
+// ListAssetRecurringCosts returns the recurring costs of an asset
+func (h *Handler) ListAssetRecurringCosts(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	costs, err := h.repos.Costs.ListByAsset(r.Context(), asset.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list recurring costs")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newRecurringCostList(costs))
+}
+
+// withoutHighValueCosts removes the recurring costs of high-value assets
+func withoutHighValueCosts(costs []domain.RecurringCost) []domain.RecurringCost {
+	return slices.DeleteFunc(costs, func(c domain.RecurringCost) bool { return c.HighValue })
+}
+
+func newRecurringCostList(costs []domain.RecurringCost) RecurringCostListResponse {
+	if costs == nil {
+		costs = []domain.RecurringCost{}
+	}
+	response := RecurringCostListResponse{Costs: costs}
+	for i := range costs {
+		response.Totals.Count++
+		response.Totals.Monthly += costs[i].MonthlyAmount()
+	}
+	response.Totals.Annual = response.Totals.Monthly * 12
+	return response
+}
+
+func (h *Handler) CreateRecurringCost(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	var req RecurringCostRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	cost := &domain.RecurringCost{
+		OrganizationID: asset.OrganizationID,
+		AssetID:        asset.ID,
+		Currency:       asset.Currency,
+	}
+	if err := applyRecurringCost(r, cost, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.repos.Costs.Create(r.Context(), cost); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create recurring cost")
+		return
+	}
+
+	cost.AssetName = asset.Name
+	writeJSON(w, http.StatusCreated, cost)
+}
+
+func (h *Handler) UpdateRecurringCost(w http.ResponseWriter, r *http.Request) {
+	cost, ok := h.visibleRecurringCost(w, r)
+	if !ok {
+		return
+	}
+
+	var req RecurringCostRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := applyRecurringCost(r, cost, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.repos.Costs.Update(r.Context(), cost); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update recurring cost")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cost)
+}
+
+func (h *Handler) DeleteRecurringCost(w http.ResponseWriter, r *http.Request) {
+	cost, ok := h.visibleRecurringCost(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Costs.Delete(r.Context(), cost.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete recurring cost")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// visibleRecurringCost returns the recurring cost of the "id" URL parameter,
+// writing an error response if it is invalid, doesn't exist or is of an
+// asset hidden from the caller
+func (h *Handler) visibleRecurringCost(w http.ResponseWriter, r *http.Request) (*domain.RecurringCost, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid recurring cost ID")
+		return nil, false
+	}
+
+	cost, err := h.repos.Costs.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get recurring cost")
+		return nil, false
+	}
+	hidden := false
+	if cost != nil && cost.HighValue {
+		if hidden, err = h.hidesHighValue(r); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+			return nil, false
+		}
+	}
+	if cost == nil || hidden {
+		writeError(w, http.StatusNotFound, "recurring cost not found")
+		return nil, false
+	}
+	return cost, true
+}
+
+// applyRecurringCost validates req and copies it onto cost
+func applyRecurringCost(r *http.Request, cost *domain.RecurringCost, req *RecurringCostRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if req.Interval.Months() == 0 {
+		return errors.New("interval must be monthly, quarterly or annual")
+	}
+	if req.RemindDays < 0 || req.RemindDays > maxRemindDays {
+		return fmt.Errorf("remind_days must be between 0 and %d", maxRemindDays)
+	}
+	if req.Amount == nil {
+		return errors.New("amount is required")
+	}
+
+	if req.Currency != nil {
+		if *req.Currency == "" {
+			cost.Currency = nil
+		} else {
+			code, err := money.NormalizeCurrency(*req.Currency)
+			if err != nil {
+				return err
+			}
+			cost.Currency = &code
+		}
+	}
+	amount, err := req.Amount.Parse(requestLocale(r))
+	if err != nil {
+		return fmt.Errorf("invalid amount: %w", err)
+	}
+	if amount.Currency != "" {
+		// The amount may be billed in another currency than the asset was bought in,
+		// unless a currency was given explicitly
+		if req.Currency != nil && cost.Currency != nil && *cost.Currency != amount.Currency {
+			return fmt.Errorf("amount currency %s does not match currency %s", amount.Currency, *cost.Currency)
+		}
+		cost.Currency = &amount.Currency
+	}
+
+	cost.NextRenewalAt = nil
+	if req.NextRenewalAt != nil && *req.NextRenewalAt != "" {
+		// Stored as a DATE, the location doesn't change the day
+		t, err := parseDate(*req.NextRenewalAt, time.UTC)
+		if err != nil {
+			return errors.New("invalid next_renewal_at, expected YYYY-MM-DD")
+		}
+		cost.NextRenewalAt = &t
+	}
+
+	cost.Name = req.Name
+	cost.Amount = amount.Amount
+	cost.Interval = req.Interval
+	cost.RemindDays = req.RemindDays
+	cost.Notes = req.Notes
+	return nil
+}
+
+// RenewalReminders emails each organization's admins about recurring costs
+// renewing soon, and moves past renewal dates forward by their interval
+type RenewalReminders struct {
+	repos  *Repositories
+	mailer mail.Mailer    // nil = only advance renewal dates
+	links  *links.Builder // nil = no link in reminders
+}
+
+// NewRenewalReminders creates the renewal reminder task
+func NewRenewalReminders(repos *Repositories, mailer mail.Mailer, builder *links.Builder) *RenewalReminders {
+	return &RenewalReminders{repos: repos, mailer: mailer, links: builder}
+}
+
+// RunOnce advances past renewals and sends the reminders that are due
+func (rr *RenewalReminders) RunOnce(ctx context.Context) error {
+	orgs, err := rr.repos.Organizations.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if err := rr.runOrganization(ctx, org.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runOrganization handles the renewals of an organization's costs
+func (rr *RenewalReminders) runOrganization(ctx context.Context, orgID uuid.UUID) error {
+	var zone string
+	if _, err := rr.repos.Settings.Get(ctx, orgID, domain.SettingTimeZone, &zone); err != nil {
+		return err
+	}
+	// DATE columns are read as UTC midnight: compare against today's date in UTC
+	y, m, d := today(loadLocation(zone)).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	// Costs renewing within the longest reminder window, including past renewals
+	costs, err := rr.repos.Costs.ListRenewingBefore(ctx, orgID, day.AddDate(0, 0, maxRemindDays))
+	if err != nil {
+		return err
+	}
+
+	var due []domain.RecurringCost
+	for _, c := range costs {
+		renewal := c.RenewalOnOrAfter(day)
+		if !renewal.Equal(*c.NextRenewalAt) {
+			if err := rr.repos.Costs.SetRenewal(ctx, c.ID, *renewal); err != nil {
+				return err
+			}
+			c.NextRenewalAt = renewal
+		}
+		if reminderDue(&c, day) {
+			due = append(due, c)
+		}
+	}
+	if len(due) == 0 || rr.mailer == nil {
+		return nil
+	}
+
+	recipients, err := adminEmails(ctx, rr.repos, orgID)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+
+	title := defaultBrandTitle
+	var branding domain.Branding
+	if found, err := rr.repos.Settings.Get(ctx, orgID, domain.SettingBranding, &branding); err == nil && found && branding.Title != "" {
+		title = branding.Title
+	}
+
+	if err := rr.mailer.Send(ctx, renewalReminder(title, recipients, due, rr.links)); err != nil {
+		return err
+	}
+	for _, c := range due {
+		if err := rr.repos.Costs.MarkReminded(ctx, c.ID, *c.NextRenewalAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reminderDue reports whether a reminder for the cost's next renewal should
+// be sent on day (dates as UTC midnight)
+func reminderDue(c *domain.RecurringCost, day time.Time) bool {
+	if c.RemindDays == 0 || c.NextRenewalAt == nil {
+		return false
+	}
+	if c.RemindedFor != nil && c.RemindedFor.Equal(*c.NextRenewalAt) {
+		return false
+	}
+	return !day.Before(c.NextRenewalAt.AddDate(0, 0, -c.RemindDays))
+}
+
+// adminEmails returns the email addresses of the organization's active admins
+func adminEmails(ctx context.Context, repos *Repositories, orgID uuid.UUID) ([]string, error) {
+	users, err := repos.Users.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	var emails []string
+	for _, u := range users {
+		if u.IsAdmin() && u.IsActive() {
+			emails = append(emails, u.Email)
+		}
+	}
+	return emails, nil
+}
+
+// renewalReminder builds the email listing upcoming renewals
+func renewalReminder(title string, to []string, costs []domain.RecurringCost, builder *links.Builder) mail.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The following recurring costs renew soon:\n\n")
+	for _, c := range costs {
+		currency := ""
+		if c.Currency != nil {
+			currency = " " + *c.Currency
+		}
+		fmt.Fprintf(&b, "- %s (%s): %.2f%s %s, renews %s\n",
+			c.Name, c.AssetName, c.Amount, currency, c.Interval, c.NextRenewalAt.Format("2006-01-02"))
+	}
+	if builder != nil {
+		fmt.Fprintf(&b, "\nReview them at %s\n", builder.URL("/"))
+	}
+
+	subject := fmt.Sprintf("[%s] %d recurring costs renew soon", title, len(costs))
+	if len(costs) == 1 {
+		subject = fmt.Sprintf("[%s] %s renews on %s", title, costs[0].Name, costs[0].NextRenewalAt.Format("2006-01-02"))
+	}
+	return mail.Message{To: to, Subject: subject, Text: b.String()}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func utcDate(y int, m time.Month, d int) *time.Time {
+	t := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func Test_applyRecurringCost_ValidRequest(t *testing.T) {
+	var req RecurringCostRequest
+	body := `{"name": " Cloud storage ", "amount": "2,99 €", "interval": "monthly", "next_renewal_at": "2025-07-01", "remind_days": 7}`
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	r := httptest.NewRequest("POST", "/api/assets/x/costs", nil)
+	r.Header.Set("Accept-Language", "de-DE")
+	cost := &domain.RecurringCost{}
+
+	if err := applyRecurringCost(r, cost, &req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cost.Name != "Cloud storage" || cost.Amount != 2.99 || cost.Currency == nil || *cost.Currency != "EUR" {
+		t.Errorf("unexpected cost: %+v", cost)
+	}
+	if cost.NextRenewalAt == nil || !cost.NextRenewalAt.Equal(*utcDate(2025, 7, 1)) {
+		t.Errorf("unexpected renewal date: %v", cost.NextRenewalAt)
+	}
+}
+
+func Test_applyRecurringCost_Invalid(t *testing.T) {
+	amount := 10.0
+	valid := func() RecurringCostRequest {
+		return RecurringCostRequest{Name: "Insurance", Amount: &PriceInput{number: &amount}, Interval: domain.CostIntervalAnnual}
+	}
+	tests := map[string]func(*RecurringCostRequest){
+		"missing name":     func(r *RecurringCostRequest) { r.Name = " " },
+		"missing amount":   func(r *RecurringCostRequest) { r.Amount = nil },
+		"invalid interval": func(r *RecurringCostRequest) { r.Interval = "weekly" },
+		"negative remind":  func(r *RecurringCostRequest) { r.RemindDays = -1 },
+		"invalid date":     func(r *RecurringCostRequest) { s := "07/01/2025"; r.NextRenewalAt = &s },
+	}
+	for name, mutate := range tests {
+		req := valid()
+		mutate(&req)
+		if err := applyRecurringCost(httptest.NewRequest("POST", "/", nil), &domain.RecurringCost{}, &req); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func Test_newRecurringCostList_RollsUpPerMonthAndYear(t *testing.T) {
+	costs := []domain.RecurringCost{
+		{Amount: 10, Interval: domain.CostIntervalMonthly},
+		{Amount: 30, Interval: domain.CostIntervalQuarterly},
+		{Amount: 120, Interval: domain.CostIntervalAnnual},
+	}
+
+	list := newRecurringCostList(costs)
+
+	if list.Totals.Count != 3 || list.Totals.Monthly != 30 || list.Totals.Annual != 360 {
+		t.Errorf("unexpected totals: %+v", list.Totals)
+	}
+	if empty := newRecurringCostList(nil); empty.Costs == nil {
+		t.Error("expected empty list to encode as []")
+	}
+}
+
+func Test_reminderDue(t *testing.T) {
+	day := *utcDate(2025, 6, 24)
+	tests := []struct {
+		name string
+		cost domain.RecurringCost
+		want bool
+	}{
+		{"within window", domain.RecurringCost{RemindDays: 7, NextRenewalAt: utcDate(2025, 7, 1)}, true},
+		{"before window", domain.RecurringCost{RemindDays: 6, NextRenewalAt: utcDate(2025, 7, 1)}, false},
+		{"reminders off", domain.RecurringCost{RemindDays: 0, NextRenewalAt: utcDate(2025, 6, 25)}, false},
+		{"already reminded", domain.RecurringCost{RemindDays: 7, NextRenewalAt: utcDate(2025, 7, 1), RemindedFor: utcDate(2025, 7, 1)}, false},
+		{"reminded for previous renewal", domain.RecurringCost{RemindDays: 7, NextRenewalAt: utcDate(2025, 7, 1), RemindedFor: utcDate(2025, 6, 1)}, true},
+	}
+	for _, tt := range tests {
+		if got := reminderDue(&tt.cost, day); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func Test_renewalReminder_ListsCosts(t *testing.T) {
+	eur := "EUR"
+	costs := []domain.RecurringCost{{Name: "Camera cloud", AssetName: "Doorbell", Amount: 3.5, Currency: &eur, Interval: domain.CostIntervalMonthly, NextRenewalAt: utcDate(2025, 7, 1)}}
+
+	msg := renewalReminder("Attic", []string{"admin@example.com"}, costs, nil)
+
+	if msg.Subject != "[Attic] Camera cloud renews on 2025-07-01" {
+		t.Errorf("unexpected subject: %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Camera cloud (Doorbell): 3.50 EUR monthly, renews 2025-07-01") {
+		t.Errorf("unexpected body: %q", msg.Text)
+	}
+}
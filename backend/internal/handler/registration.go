@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// Login failure reason of self-registered accounts not approved yet
+const loginFailurePending = "pending_approval"
+
+// SetSelfRegistration lets visitors register accounts in the organization,
+// to be approved by an admin before they can sign in
+func (h *AuthHandler) SetSelfRegistration(orgID uuid.UUID) {
+	h.registrationOrgID = orgID
+}
+
+func (h *AuthHandler) registrationEnabled() bool {
+	return h.registrationOrgID != uuid.Nil && !h.oidcEnabled && h.proxyAuth == nil
+}
+
+// RegisterRequest represents a self-registration request
+type RegisterRequest struct {
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// Register creates a local account that awaits admin approval
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	if !h.registrationEnabled() {
+		writeError(w, http.StatusNotFound, "registration is disabled")
+		return
+	}
+
+	var req RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	user, ok := h.newLocalUser(w, r, req)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	user.OrganizationID = h.registrationOrgID
+	user.Role = domain.UserRoleUser
+	user.PendingAt = &now
+
+	if err := h.userRepo.Create(r.Context(), user); err != nil {
+		slog.Error("failed to create user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	slog.Info("user registered, awaiting approval", "user_id", user.ID, "email", user.Email)
+
+	if h.verifier != nil {
+		h.verifier.Send(user)
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]any{"pending": true})
+}
+
+// newLocalUser validates a sign-up and returns the account to create, without
+// organization and role, writing an error response if it is invalid
+func (h *AuthHandler) newLocalUser(w http.ResponseWriter, r *http.Request, req RegisterRequest) (*domain.User, bool) {
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Email == "" || req.Password == "" {
+		writeError(w, http.StatusBadRequest, "email and password are required")
+		return nil, false
+	}
+	if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+		writeError(w, http.StatusBadRequest, "invalid email address")
+		return nil, false
+	}
+
+	if err := h.passwordPolicy.Validate(req.Password); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	existing, err := h.userRepo.GetByEmail(r.Context(), req.Email)
+	if err != nil {
+		slog.Error("failed to check existing user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return nil, false
+	}
+	if existing != nil {
+		writeError(w, http.StatusConflict, "email already in use")
+		return nil, false
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		slog.Error("failed to hash password", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return nil, false
+	}
+
+	user := &domain.User{
+		Email:        req.Email,
+		PasswordHash: &hash,
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		user.DisplayName = &name
+	}
+	return user, true
+}
+
+// ListPendingUsers returns the self-registered users awaiting approval
+func (h *UserManagementHandler) ListPendingUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.userRepo.ListPending(r.Context(), h.org(r))
+	if err != nil {
+		slog.Error("failed to list pending users", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	response := make([]UserResponse, len(users))
+	for i, u := range users {
+		response[i] = toUserResponse(&u)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// ApproveUser lets a self-registered user sign in
+func (h *UserManagementHandler) ApproveUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	ok, err := h.userRepo.Approve(r.Context(), id)
+	if err != nil {
+		slog.Error("failed to approve user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !ok {
+		writeError(w, http.StatusNotFound, "no pending user found")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil || user == nil {
+		slog.Error("failed to get user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserResponse(user))
+}
+
+// RejectUser deletes a self-registered user awaiting approval
+func (h *UserManagementHandler) RejectUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if user == nil || !user.IsPending() {
+		writeError(w, http.StatusNotFound, "no pending user found")
+		return
+	}
+
+	if err := h.userRepo.Delete(r.Context(), id); err != nil {
+		slog.Error("failed to delete user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
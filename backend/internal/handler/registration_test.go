@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+)
+
+func newRegistrationHandler(oidcEnabled bool) *AuthHandler {
+	h := NewAuthHandler(nil, auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24), 8, oidcEnabled)
+	h.SetSelfRegistration(uuid.New())
+	return h
+}
+
+func Test_AuthHandler_Register_Disabled(t *testing.T) {
+	for name, h := range map[string]*AuthHandler{
+		"not enabled": NewAuthHandler(nil, auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24), 8, false),
+		"oidc":        newRegistrationHandler(true),
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(`{"email":"new@example.com","password":"long-enough"}`))
+		w := httptest.NewRecorder()
+		h.Register(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", name, w.Code)
+		}
+	}
+}
+
+func Test_AuthHandler_Register_Validation(t *testing.T) {
+	h := newRegistrationHandler(false)
+
+	tests := map[string]string{
+		"missing password": `{"email":"new@example.com"}`,
+		"invalid email":    `{"email":"Someone <new@example.com>","password":"long-enough"}`,
+		"short password":   `{"email":"new@example.com","password":"short"}`,
+		"invalid body":     `{`,
+	}
+	for name, body := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/auth/register", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.Register(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, w.Code)
+		}
+	}
+}
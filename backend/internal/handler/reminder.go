@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// ReminderRequest creates or replaces a reminder
+type ReminderRequest struct {
+	Title   string     `json:"title"`
+	Notes   *string    `json:"notes,omitempty"`
+	DueAt   string     `json:"due_at"`             // YYYY-MM-DD
+	AssetID *uuid.UUID `json:"asset_id,omitempty"` // nil = standalone
+	Done    bool       `json:"done"`
+}
+
+// maxReminderTitle is the longest reminder title, in characters
+const maxReminderTitle = 255
+
+// ListReminders returns the organization's reminders, soonest due first.
+// ?status= selects open (default), done or all reminders, ?asset_id= those
+// of an asset, ?overdue=true those past their due date and ?days=N those due
+// in the next N days, including overdue ones.
+func (h *Handler) ListReminders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var filter domain.ReminderFilter
+
+	switch q.Get("status") {
+	case "", "open":
+		open := false
+		filter.Done = &open
+	case "done":
+		done := true
+		filter.Done = &done
+	case "all":
+	default:
+		writeError(w, http.StatusBadRequest, "invalid status, expected open, done or all")
+		return
+	}
+	if v := q.Get("asset_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid asset_id")
+			return
+		}
+		filter.AssetID = &id
+	}
+
+	var dueWithin *int // Days from today, -1 for overdue reminders
+	if q.Get("overdue") == "true" {
+		overdue := -1
+		dueWithin = &overdue
+	} else if v := q.Get("days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			writeError(w, http.StatusBadRequest, "invalid days parameter")
+			return
+		}
+		dueWithin = &days
+	}
+	if dueWithin != nil {
+		until := today(h.location(r)).AddDate(0, 0, *dueWithin)
+		filter.DueBefore = &until
+	}
+
+	reminders, err := h.repos.Reminders.List(r.Context(), h.org(r), filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list reminders")
+		return
+	}
+	writeJSON(w, http.StatusOK, reminders)
+}
+
+func (h *Handler) GetReminder(w http.ResponseWriter, r *http.Request) {
+	reminder, ok := h.reminder(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, reminder)
+}
+
+func (h *Handler) CreateReminder(w http.ResponseWriter, r *http.Request) {
+	var req ReminderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	reminder := &domain.Reminder{OrganizationID: h.org(r), CreatedBy: currentUserID(r)}
+	if err := applyReminder(reminder, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.reminderAsset(w, r, reminder, req.AssetID) {
+		return
+	}
+	if err := h.repos.Reminders.Create(r.Context(), reminder); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create reminder")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reminder)
+}
+
+func (h *Handler) UpdateReminder(w http.ResponseWriter, r *http.Request) {
+	var req ReminderRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	reminder, ok := h.reminder(w, r)
+	if !ok {
+		return
+	}
+	if err := applyReminder(reminder, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !h.reminderAsset(w, r, reminder, req.AssetID) {
+		return
+	}
+	if err := h.repos.Reminders.Update(r.Context(), reminder); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update reminder")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reminder)
+}
+
+func (h *Handler) DeleteReminder(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid reminder ID")
+		return
+	}
+
+	if err := h.repos.Reminders.Delete(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete reminder")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reminder returns the reminder of the "id" URL parameter, writing an error
+// response if it is invalid or doesn't exist
+func (h *Handler) reminder(w http.ResponseWriter, r *http.Request) (*domain.Reminder, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid reminder ID")
+		return nil, false
+	}
+
+	reminder, err := h.repos.Reminders.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get reminder")
+		return nil, false
+	}
+	if reminder == nil {
+		writeError(w, http.StatusNotFound, "reminder not found")
+		return nil, false
+	}
+	return reminder, true
+}
+
+// applyReminder validates req and copies it onto reminder, except for the
+// asset (see reminderAsset). Marking it done records the time; reminders
+// that were done already keep it.
+func applyReminder(reminder *domain.Reminder, req *ReminderRequest) error {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return errors.New("title is required")
+	}
+	if utf8.RuneCountInString(title) > maxReminderTitle {
+		return errors.New("title is too long")
+	}
+	if req.DueAt == "" {
+		return errors.New("due_at is required")
+	}
+	// Stored as a DATE, the location doesn't change the day
+	dueAt, err := parseDate(req.DueAt, time.UTC)
+	if err != nil {
+		return errors.New("invalid due_at, expected YYYY-MM-DD")
+	}
+
+	reminder.Title = title
+	reminder.Notes = optionalText(req.Notes)
+	reminder.DueAt = dueAt
+	switch {
+	case !req.Done:
+		reminder.DoneAt = nil
+	case reminder.DoneAt == nil:
+		now := time.Now()
+		reminder.DoneAt = &now
+	}
+	return nil
+}
+
+// reminderAsset sets the asset of a reminder, nil for none, writing an error
+// response if it doesn't exist or is hidden from the caller
+func (h *Handler) reminderAsset(w http.ResponseWriter, r *http.Request, reminder *domain.Reminder, assetID *uuid.UUID) bool {
+	reminder.AssetID, reminder.AssetName = nil, nil
+	if assetID == nil {
+		return true
+	}
+
+	asset, err := h.repos.Assets.GetByID(r.Context(), *assetID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return false
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return false
+	}
+	if asset == nil || asset.OrganizationID != h.org(r) || hidden {
+		writeError(w, http.StatusBadRequest, "asset not found")
+		return false
+	}
+	reminder.AssetID, reminder.AssetName = &asset.ID, &asset.Name
+	return true
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_ListReminders_InvalidQuery(t *testing.T) {
+	for _, query := range []string{"status=later", "asset_id=x", "days=-1", "days=soon"} {
+		h := &Handler{repos: &Repositories{}}
+		rr := httptest.NewRecorder()
+
+		h.ListReminders(rr, httptest.NewRequest(http.MethodGet, "/api/reminders?"+query, nil))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
+
+func Test_applyReminder(t *testing.T) {
+	notes := "  "
+	reminder := &domain.Reminder{}
+	err := applyReminder(reminder, &ReminderRequest{Title: " Renew car insurance ", Notes: &notes, DueAt: "2025-09-30"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reminder.Title != "Renew car insurance" || reminder.Notes != nil || !reminder.DueAt.Equal(*utcDate(2025, 9, 30)) || reminder.DoneAt != nil {
+		t.Errorf("unexpected reminder: %+v", reminder)
+	}
+
+	// Marking it done again keeps when it was done
+	done := time.Date(2025, 9, 28, 10, 0, 0, 0, time.UTC)
+	reminder.DoneAt = &done
+	if err := applyReminder(reminder, &ReminderRequest{Title: "Renew car insurance", DueAt: "2025-09-30", Done: true}); err != nil || !reminder.DoneAt.Equal(done) {
+		t.Errorf("expected the done time to be kept, got %v, %v", reminder.DoneAt, err)
+	}
+	if err := applyReminder(reminder, &ReminderRequest{Title: "Renew car insurance", DueAt: "2025-09-30"}); err != nil || reminder.DoneAt != nil {
+		t.Errorf("expected the reminder to be reopened, got %v, %v", reminder.DoneAt, err)
+	}
+
+	for name, req := range map[string]ReminderRequest{
+		"no title":       {Title: " ", DueAt: "2025-09-30"},
+		"no due date":    {Title: "Service boiler"},
+		"invalid due at": {Title: "Service boiler", DueAt: "30/09/2025"},
+	} {
+		if err := applyReminder(&domain.Reminder{}, &req); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,545 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	netmail "net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/cron"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/mail"
+	"github.com/lmmendes/attic/internal/security"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+// reportRunHistory is the number of runs returned by ListReportRuns
+const reportRunHistory = 50
+
+// ReportScheduleRequest creates or replaces a report schedule
+type ReportScheduleRequest struct {
+	Name       string   `json:"name"`
+	Report     string   `json:"report"`            // insurance, energy, warranties, costs or list
+	ListID     *string  `json:"list_id,omitempty"` // Required for list reports
+	Cron       string   `json:"cron"`              // e.g. "0 8 * * mon" or "@monthly"
+	Delivery   string   `json:"delivery"`          // email or storage
+	Recipients []string `json:"recipients,omitempty"`
+	Enabled    *bool    `json:"enabled,omitempty"` // Default true
+}
+
+// ReportRunResponse is a report run with a download link for uploaded reports
+type ReportRunResponse struct {
+	domain.ReportRun
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+// reportFile is a rendered report
+type reportFile struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+func (h *Handler) ListReportSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.repos.Reports.List(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list report schedules")
+		return
+	}
+
+	if schedules == nil {
+		schedules = []domain.ReportSchedule{}
+	}
+
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+func (h *Handler) GetReportSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.loadReportSchedule(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+func (h *Handler) CreateReportSchedule(w http.ResponseWriter, r *http.Request) {
+	var req ReportScheduleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	schedule := &domain.ReportSchedule{
+		OrganizationID: h.org(r),
+		CreatedBy:      currentUserID(r),
+	}
+	if !h.applyReportScheduleRequest(w, r, schedule, req) {
+		return
+	}
+
+	if err := h.repos.Reports.Create(r.Context(), schedule); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create report schedule")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, schedule)
+}
+
+func (h *Handler) UpdateReportSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.loadReportSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	var req ReportScheduleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !h.applyReportScheduleRequest(w, r, schedule, req) {
+		return
+	}
+
+	if err := h.repos.Reports.Update(r.Context(), schedule); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update report schedule")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedule)
+}
+
+func (h *Handler) DeleteReportSchedule(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid report schedule ID")
+		return
+	}
+
+	if err := h.repos.Reports.Delete(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete report schedule")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListReportRuns returns the run history of a schedule, newest first
+func (h *Handler) ListReportRuns(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.loadReportSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	runs, err := h.repos.Reports.ListRuns(r.Context(), schedule.ID, reportRunHistory)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list report runs")
+		return
+	}
+
+	response := make([]ReportRunResponse, len(runs))
+	for i, run := range runs {
+		response[i] = h.toReportRunResponse(r.Context(), run)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// RunReportSchedule delivers a report right away, without moving its next run
+func (h *Handler) RunReportSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule, ok := h.loadReportSchedule(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	run := h.runReport(r.Context(), schedule, now)
+	if err := h.repos.Reports.MarkRun(r.Context(), schedule.ID, now, schedule.NextRunAt); err != nil {
+		slog.Error("failed to update report schedule", "schedule_id", schedule.ID, "error", err)
+	}
+
+	writeJSON(w, http.StatusOK, h.toReportRunResponse(r.Context(), *run))
+}
+
+// RunDueReports delivers the reports whose next run has come and schedules
+// their following run (a single run catches up on runs missed while down)
+func (h *Handler) RunDueReports(ctx context.Context) error {
+	now := time.Now()
+	return h.forEachOrganization(ctx, func(orgID uuid.UUID) error {
+		return h.runDueReports(ctx, orgID, now)
+	})
+}
+
+// runDueReports delivers the due reports of an organization
+func (h *Handler) runDueReports(ctx context.Context, orgID uuid.UUID, now time.Time) error {
+	due, err := h.repos.Reports.ListDue(ctx, orgID, now)
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		schedule := &due[i]
+		h.runReport(ctx, schedule, now)
+
+		next, err := h.nextReportRun(ctx, schedule, now)
+		if err != nil {
+			return err
+		}
+		if err := h.repos.Reports.MarkRun(ctx, schedule.ID, now, next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) loadReportSchedule(w http.ResponseWriter, r *http.Request) (*domain.ReportSchedule, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid report schedule ID")
+		return nil, false
+	}
+
+	schedule, err := h.repos.Reports.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get report schedule")
+		return nil, false
+	}
+	if schedule == nil || schedule.OrganizationID != h.org(r) {
+		writeError(w, http.StatusNotFound, "report schedule not found")
+		return nil, false
+	}
+	return schedule, true
+}
+
+// applyReportScheduleRequest validates req and copies it onto schedule,
+// computing the next run; it writes the error response on failure
+func (h *Handler) applyReportScheduleRequest(w http.ResponseWriter, r *http.Request, schedule *domain.ReportSchedule, req ReportScheduleRequest) bool {
+	if err := applyReportSchedule(schedule, req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	switch schedule.Delivery {
+	case domain.ReportDeliveryEmail:
+		if h.mailer == nil {
+			writeError(w, http.StatusBadRequest, "email delivery requires outgoing email to be configured")
+			return false
+		}
+	case domain.ReportDeliveryStorage:
+		if h.storage == nil {
+			writeError(w, http.StatusBadRequest, "storage delivery requires file storage to be configured")
+			return false
+		}
+	}
+
+	if schedule.ListID != nil {
+		list, err := h.repos.Lists.GetByID(r.Context(), *schedule.ListID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to check list")
+			return false
+		}
+		if list == nil || list.OrganizationID != h.org(r) {
+			writeError(w, http.StatusBadRequest, "list not found")
+			return false
+		}
+	}
+
+	next, err := h.nextReportRun(r.Context(), schedule, time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to schedule report")
+		return false
+	}
+	schedule.NextRunAt = next
+	return true
+}
+
+func applyReportSchedule(schedule *domain.ReportSchedule, req ReportScheduleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+
+	report := domain.ReportKind(req.Report)
+	if !report.Valid() {
+		return errors.New("report must be one of insurance, energy, warranties, costs or list")
+	}
+
+	var listID *uuid.UUID
+	if report == domain.ReportList {
+		if req.ListID == nil {
+			return errors.New("list_id is required for list reports")
+		}
+		id, err := uuid.Parse(*req.ListID)
+		if err != nil {
+			return errors.New("invalid list_id")
+		}
+		listID = &id
+	}
+
+	expr := strings.TrimSpace(req.Cron)
+	sched, err := cron.Parse(expr)
+	if err != nil {
+		return fmt.Errorf("invalid cron expression: %w", err)
+	}
+	if sched.Next(time.Now()).IsZero() {
+		return errors.New("cron expression never matches")
+	}
+
+	delivery := domain.ReportDelivery(req.Delivery)
+	var recipients []string
+	switch delivery {
+	case domain.ReportDeliveryEmail:
+		for _, to := range req.Recipients {
+			addr, err := netmail.ParseAddress(strings.TrimSpace(to))
+			if err != nil {
+				return fmt.Errorf("invalid recipient %q", to)
+			}
+			recipients = append(recipients, addr.Address)
+		}
+		if len(recipients) == 0 {
+			return errors.New("recipients are required for email delivery")
+		}
+	case domain.ReportDeliveryStorage:
+	default:
+		return errors.New("delivery must be email or storage")
+	}
+
+	schedule.Name = name
+	schedule.Report = report
+	schedule.ListID = listID
+	schedule.Cron = expr
+	schedule.Delivery = delivery
+	schedule.Recipients = recipients
+	schedule.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
+
+// nextReportRun returns the first run of schedule after now, evaluating the
+// cron expression in the organization's time zone (nil if disabled)
+func (h *Handler) nextReportRun(ctx context.Context, schedule *domain.ReportSchedule, now time.Time) (*time.Time, error) {
+	if !schedule.Enabled {
+		return nil, nil
+	}
+	sched, err := cron.Parse(schedule.Cron)
+	if err != nil {
+		return nil, err
+	}
+	zone, err := h.orgTimeZone(ctx, schedule.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	next := sched.Next(now.In(loadLocation(zone)))
+	if next.IsZero() {
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// runReport renders and delivers a report, recording the run in the history
+func (h *Handler) runReport(ctx context.Context, schedule *domain.ReportSchedule, now time.Time) *domain.ReportRun {
+	run := &domain.ReportRun{
+		ScheduleID: schedule.ID,
+		StartedAt:  now,
+		Status:     domain.ReportRunSucceeded,
+	}
+
+	size, key, err := h.deliverReport(ctx, schedule, now)
+	run.FinishedAt = time.Now()
+	run.SizeBytes = size
+	run.FileKey = key
+	if err != nil {
+		slog.Error("failed to deliver scheduled report", "schedule_id", schedule.ID, "error", err)
+		msg := err.Error()
+		run.Status = domain.ReportRunFailed
+		run.Error = &msg
+	}
+
+	if err := h.repos.Reports.CreateRun(ctx, run); err != nil {
+		slog.Error("failed to record report run", "schedule_id", schedule.ID, "error", err)
+	}
+	return run
+}
+
+// deliverReport renders the report and emails or uploads it, returning its
+// size and storage key (storage delivery)
+func (h *Handler) deliverReport(ctx context.Context, schedule *domain.ReportSchedule, now time.Time) (int64, *string, error) {
+	zone, err := h.orgTimeZone(ctx, schedule.OrganizationID)
+	if err != nil {
+		return 0, nil, err
+	}
+	loc := loadLocation(zone)
+
+	file, err := h.renderReport(ctx, schedule, now, loc)
+	if err != nil {
+		return 0, nil, err
+	}
+	size := int64(len(file.Data))
+
+	switch schedule.Delivery {
+	case domain.ReportDeliveryEmail:
+		if h.mailer == nil {
+			return size, nil, errors.New("outgoing email is not configured")
+		}
+		branding, err := h.loadBranding(ctx, schedule.OrganizationID)
+		if err != nil {
+			return size, nil, err
+		}
+		return size, nil, h.mailer.Send(ctx, reportEmail(toBrandingResponse(branding).Title, schedule, file, now.In(loc)))
+
+	case domain.ReportDeliveryStorage:
+		if h.storage == nil {
+			return size, nil, errors.New("file storage is not configured")
+		}
+		key, err := h.storage.UploadObject(ctx, storage.Object{
+			OrganizationID: schedule.OrganizationID,
+			Kind:           "reports",
+			FileName:       file.Name,
+		}, file.ContentType, bytes.NewReader(file.Data))
+		if err != nil {
+			return size, nil, err
+		}
+		return size, &key, nil
+	}
+	return size, nil, fmt.Errorf("unknown delivery %q", schedule.Delivery)
+}
+
+// renderReport renders the scheduled report; reports are generated without a
+// user, so high-value items are left out unless everyone may see them
+func (h *Handler) renderReport(ctx context.Context, schedule *domain.ReportSchedule, now time.Time, loc *time.Location) (*reportFile, error) {
+	orgID := schedule.OrganizationID
+	policy, err := h.highValuePolicy(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	hide := len(policy.VisibleRoles) > 0
+	base := fmt.Sprintf("%s-%s", archiveName(schedule.Name), now.In(loc).Format("2006-01-02-1504"))
+
+	var data any
+	switch schedule.Report {
+	case domain.ReportInsurance:
+		assets, err := h.allAssets(ctx, orgID, domain.AssetFilter{NoHighValue: hide})
+		if err != nil {
+			return nil, err
+		}
+		data = buildInsuranceReport(assets, now)
+
+	case domain.ReportEnergy:
+		settings, err := h.energySettings(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		usages, err := h.repos.Power.List(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		if hide {
+			usages = withoutHighValueUsages(usages)
+		}
+		data = buildEnergyReport(usages, settings)
+
+	case domain.ReportWarranties:
+		warranties, err := h.repos.Warranties.ListExpiringBefore(ctx, orgID, today(loc).AddDate(0, 0, 30))
+		if err != nil {
+			return nil, err
+		}
+		if hide {
+			warranties = withoutHighValueWarranties(warranties)
+		}
+		if warranties == nil {
+			warranties = []domain.Warranty{}
+		}
+		data = warranties
+
+	case domain.ReportCosts:
+		costs, err := h.repos.Costs.List(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		if hide {
+			costs = withoutHighValueCosts(costs)
+		}
+		data = newRecurringCostList(costs)
+
+	case domain.ReportList:
+		page, err := h.renderListReport(ctx, schedule, hide)
+		if err != nil {
+			return nil, err
+		}
+		return &reportFile{Name: base + ".html", ContentType: "text/html; charset=utf-8", Data: page}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown report %q", schedule.Report)
+	}
+
+	body, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &reportFile{Name: base + ".json", ContentType: "application/json", Data: body}, nil
+}
+
+// renderListReport renders the printable page of the schedule's asset list
+func (h *Handler) renderListReport(ctx context.Context, schedule *domain.ReportSchedule, hideHighValue bool) ([]byte, error) {
+	if schedule.ListID == nil {
+		return nil, errors.New("list report without list")
+	}
+	list, err := h.repos.Lists.GetByID(ctx, *schedule.ListID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return nil, errors.New("list not found")
+	}
+
+	assets, err := h.repos.Lists.ListAssets(ctx, list.ID)
+	if err != nil {
+		return nil, err
+	}
+	if hideHighValue {
+		assets = withoutHighValue(assets)
+	}
+
+	branding, err := h.loadBranding(ctx, schedule.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = printListTemplate.Execute(&buf, printListData{
+		List:     toSharedList(list, assets, true, nil),
+		Branding: toBrandingResponse(branding),
+		Nonce:    security.Nonce(),
+	})
+	return buf.Bytes(), err
+}
+
+// reportEmail builds the email delivering a scheduled report
+func reportEmail(title string, schedule *domain.ReportSchedule, file *reportFile, generated time.Time) mail.Message {
+	return mail.Message{
+		To:      schedule.Recipients,
+		Subject: fmt.Sprintf("%s: %s", title, schedule.Name),
+		Text: fmt.Sprintf("Attached is the scheduled report %q, generated %s.\n\nYou receive this email because you are a recipient of this %s report schedule.\n",
+			schedule.Name, generated.Format("2006-01-02 15:04 MST"), title),
+		Attachments: []mail.Attachment{{Name: file.Name, ContentType: file.ContentType, Data: file.Data}},
+	}
+}
+
+func (h *Handler) toReportRunResponse(ctx context.Context, run domain.ReportRun) ReportRunResponse {
+	response := ReportRunResponse{ReportRun: run}
+	if run.FileKey != nil && h.storage != nil {
+		url, err := h.storage.GetPresignedURL(ctx, *run.FileKey, 15*time.Minute)
+		if err != nil {
+			slog.Error("failed to get report download URL", "run_id", run.ID, "error", err)
+		} else {
+			response.DownloadURL = url
+		}
+	}
+	return response
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_applyReportSchedule_Valid(t *testing.T) {
+	var schedule domain.ReportSchedule
+	err := applyReportSchedule(&schedule, ReportScheduleRequest{
+		Name:       " Weekly insurance ",
+		Report:     "insurance",
+		Cron:       "0 8 * * mon",
+		Delivery:   "email",
+		Recipients: []string{"Jo <jo@example.com>"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if schedule.Name != "Weekly insurance" || !schedule.Enabled {
+		t.Errorf("unexpected schedule: %+v", schedule)
+	}
+	if len(schedule.Recipients) != 1 || schedule.Recipients[0] != "jo@example.com" {
+		t.Errorf("expected bare recipient address, got %v", schedule.Recipients)
+	}
+}
+
+func Test_applyReportSchedule_Invalid(t *testing.T) {
+	valid := ReportScheduleRequest{Name: "Report", Report: "energy", Cron: "@daily", Delivery: "storage"}
+	tests := []struct {
+		name   string
+		modify func(*ReportScheduleRequest)
+	}{
+		{"missing name", func(r *ReportScheduleRequest) { r.Name = " " }},
+		{"unknown report", func(r *ReportScheduleRequest) { r.Report = "taxes" }},
+		{"list without list_id", func(r *ReportScheduleRequest) { r.Report = "list" }},
+		{"invalid cron", func(r *ReportScheduleRequest) { r.Cron = "every day" }},
+		{"cron never matches", func(r *ReportScheduleRequest) { r.Cron = "0 0 31 2 *" }},
+		{"unknown delivery", func(r *ReportScheduleRequest) { r.Delivery = "fax" }},
+		{"email without recipients", func(r *ReportScheduleRequest) { r.Delivery = "email" }},
+		{"invalid recipient", func(r *ReportScheduleRequest) {
+			r.Delivery = "email"
+			r.Recipients = []string{"not an address"}
+		}},
+		{"invalid list_id", func(r *ReportScheduleRequest) {
+			r.Report = "list"
+			bad := "nope"
+			r.ListID = &bad
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			if err := applyReportSchedule(&domain.ReportSchedule{}, req); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func Test_reportEmail_AttachesReport(t *testing.T) {
+	schedule := &domain.ReportSchedule{Name: "Monthly costs", Recipients: []string{"jo@example.com"}}
+	file := &reportFile{Name: "monthly-costs.json", ContentType: "application/json", Data: []byte("{}")}
+
+	msg := reportEmail("Attic", schedule, file, time.Date(2025, 5, 1, 8, 0, 0, 0, time.UTC))
+
+	if msg.Subject != "Attic: Monthly costs" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Name != "monthly-costs.json" {
+		t.Errorf("expected report attachment, got %+v", msg.Attachments)
+	}
+	if !strings.Contains(msg.Text, "2025-05-01 08:00") {
+		t.Errorf("expected generation time in body, got %q", msg.Text)
+	}
+}
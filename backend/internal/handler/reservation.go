@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type CreateReservationRequest struct {
+	ReservedBy *string `json:"reserved_by,omitempty"`
+	StartsAt   string  `json:"starts_at"`
+	EndsAt     string  `json:"ends_at"`
+	Notes      *string `json:"notes,omitempty"`
+}
+
+type UpdateReservationRequest struct {
+	ReservedBy *string `json:"reserved_by,omitempty"`
+	StartsAt   string  `json:"starts_at"`
+	EndsAt     string  `json:"ends_at"`
+	Notes      *string `json:"notes,omitempty"`
+}
+
+type AvailabilityResponse struct {
+	AssetID   string                    `json:"asset_id"`
+	From      time.Time                 `json:"from"`
+	To        time.Time                 `json:"to"`
+	Available bool                      `json:"available"`
+	Conflicts []domain.AssetReservation `json:"conflicts"`
+}
+
+// ListReservations returns all reservations overlapping a period (calendar view)
+func (h *Handler) ListReservations(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r, 30, h.location(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reservations, err := h.repos.Reservations.List(r.Context(), h.org(r), from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list reservations")
+		return
+	}
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if hide {
+		reservations = slices.DeleteFunc(reservations, func(res domain.AssetReservation) bool { return res.HighValue })
+	}
+
+	if reservations == nil {
+		reservations = []domain.AssetReservation{}
+	}
+
+	writeJSON(w, http.StatusOK, reservations)
+}
+
+func (h *Handler) ListAssetReservations(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	from, to, err := parsePeriod(r, 365, h.location(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reservations, err := h.repos.Reservations.ListByAsset(r.Context(), asset.ID, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list reservations")
+		return
+	}
+
+	if reservations == nil {
+		reservations = []domain.AssetReservation{}
+	}
+
+	writeJSON(w, http.StatusOK, reservations)
+}
+
+// GetAssetAvailability reports whether an asset is free during a period
+func (h *Handler) GetAssetAvailability(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	from, to, err := parsePeriod(r, 1, h.location(r))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	conflicts, err := h.repos.Reservations.ListByAsset(r.Context(), asset.ID, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check availability")
+		return
+	}
+
+	if conflicts == nil {
+		conflicts = []domain.AssetReservation{}
+	}
+
+	writeJSON(w, http.StatusOK, AvailabilityResponse{
+		AssetID:   asset.ID.String(),
+		From:      from,
+		To:        to,
+		Available: len(conflicts) == 0,
+		Conflicts: conflicts,
+	})
+}
+
+func (h *Handler) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateReservationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	startsAt, endsAt, err := parseReservationPeriod(req.StartsAt, req.EndsAt)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reservation := &domain.AssetReservation{
+		OrganizationID: asset.OrganizationID,
+		AssetID:        asset.ID,
+		UserID:         currentUserID(r),
+		ReservedBy:     currentUserName(r),
+		StartsAt:       startsAt,
+		EndsAt:         endsAt,
+		Notes:          req.Notes,
+	}
+	if req.ReservedBy != nil && *req.ReservedBy != "" {
+		reservation.ReservedBy = *req.ReservedBy
+	}
+	if reservation.ReservedBy == "" {
+		writeError(w, http.StatusBadRequest, "reserved_by is required")
+		return
+	}
+
+	if err := h.repos.Reservations.Create(r.Context(), reservation); err != nil {
+		if errors.Is(err, domain.ErrReservationConflict) {
+			writeError(w, http.StatusConflict, "asset is already reserved during this period")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to create reservation")
+		return
+	}
+
+	reservation.AssetName = asset.Name
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+func (h *Handler) UpdateReservation(w http.ResponseWriter, r *http.Request) {
+	reservation, ok := h.visibleReservation(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateReservationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	startsAt, endsAt, err := parseReservationPeriod(req.StartsAt, req.EndsAt)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reservation.StartsAt = startsAt
+	reservation.EndsAt = endsAt
+	reservation.Notes = req.Notes
+	if req.ReservedBy != nil && *req.ReservedBy != "" {
+		reservation.ReservedBy = *req.ReservedBy
+	}
+
+	if err := h.repos.Reservations.Update(r.Context(), reservation); err != nil {
+		if errors.Is(err, domain.ErrReservationConflict) {
+			writeError(w, http.StatusConflict, "asset is already reserved during this period")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to update reservation")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+func (h *Handler) DeleteReservation(w http.ResponseWriter, r *http.Request) {
+	reservation, ok := h.visibleReservation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repos.Reservations.Delete(r.Context(), reservation.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete reservation")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// visibleReservation returns the reservation of the "id" URL parameter,
+// writing an error response if it is invalid, doesn't exist or is of an
+// asset hidden from the caller
+func (h *Handler) visibleReservation(w http.ResponseWriter, r *http.Request) (*domain.AssetReservation, bool) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid reservation ID")
+		return nil, false
+	}
+
+	reservation, err := h.repos.Reservations.GetByID(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get reservation")
+		return nil, false
+	}
+	hidden := false
+	if reservation != nil && reservation.HighValue {
+		if hidden, err = h.hidesHighValue(r); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+			return nil, false
+		}
+	}
+	if reservation == nil || hidden {
+		writeError(w, http.StatusNotFound, "reservation not found")
+		return nil, false
+	}
+	return reservation, true
+}
+
+// parseReservationPeriod parses and validates RFC 3339 start/end timestamps
+func parseReservationPeriod(start, end string) (time.Time, time.Time, error) {
+	startsAt, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid starts_at, expected RFC 3339 timestamp")
+	}
+	endsAt, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid ends_at, expected RFC 3339 timestamp")
+	}
+	if !endsAt.After(startsAt) {
+		return time.Time{}, time.Time{}, errors.New("ends_at must be after starts_at")
+	}
+	return startsAt, endsAt, nil
+}
+
+// parsePeriod reads the optional from/to query parameters (RFC 3339, or
+// YYYY-MM-DD as midnight in loc), defaulting to a window of defaultDays starting now
+func parsePeriod(r *http.Request, defaultDays int, loc *time.Location) (time.Time, time.Time, error) {
+	from := time.Now().UTC()
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := parseTimeParam(v, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from parameter")
+		}
+		from = t
+	}
+
+	to := from.AddDate(0, 0, defaultDays)
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := parseTimeParam(v, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to parameter")
+		}
+		to = t
+	}
+
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, errors.New("to must be after from")
+	}
+	return from, to, nil
+}
+
+func parseTimeParam(v string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return parseDate(v, loc)
+}
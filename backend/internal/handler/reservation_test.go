@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_parseReservationPeriod_Valid(t *testing.T) {
+	start, end, err := parseReservationPeriod("2025-06-01T10:00:00Z", "2025-06-01T12:00:00Z")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if end.Sub(start) != 2*time.Hour {
+		t.Errorf("expected 2h period, got %v", end.Sub(start))
+	}
+}
+
+func Test_parseReservationPeriod_EndBeforeStart_ReturnsError(t *testing.T) {
+	_, _, err := parseReservationPeriod("2025-06-01T12:00:00Z", "2025-06-01T10:00:00Z")
+	if err == nil {
+		t.Error("expected error for inverted period")
+	}
+}
+
+func Test_parseReservationPeriod_InvalidFormat_ReturnsError(t *testing.T) {
+	_, _, err := parseReservationPeriod("tomorrow", "2025-06-01T10:00:00Z")
+	if err == nil {
+		t.Error("expected error for invalid timestamp")
+	}
+}
+
+func Test_parsePeriod_Defaults(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/reservations", nil)
+
+	from, to, err := parsePeriod(req, 30, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if to.Sub(from) != 30*24*time.Hour {
+		t.Errorf("expected 30 day window, got %v", to.Sub(from))
+	}
+}
+
+func Test_parsePeriod_AcceptsDates(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/reservations?from=2025-06-01&to=2025-07-01", nil)
+
+	from, to, err := parsePeriod(req, 30, time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if from.Day() != 1 || from.Month() != time.June || to.Month() != time.July {
+		t.Errorf("unexpected period %v - %v", from, to)
+	}
+}
+
+func Test_parsePeriod_DatesInLocation(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/reservations?from=2025-06-01&to=2025-07-01", nil)
+	loc := time.FixedZone("UTC-5", -5*60*60)
+
+	from, _, err := parsePeriod(req, 30, loc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2025, time.June, 1, 5, 0, 0, 0, time.UTC); !from.Equal(want) {
+		t.Errorf("expected local midnight (%v), got %v", want, from.UTC())
+	}
+}
+
+func Test_parsePeriod_Inverted_ReturnsError(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/reservations?from=2025-07-01&to=2025-06-01", nil)
+
+	if _, _, err := parsePeriod(req, 30, time.UTC); err == nil {
+		t.Error("expected error for inverted period")
+	}
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// inventoryNumberAttribute is the key of the asset attribute holding
+// inventory numbers, e.g. printed on existing asset tags
+const inventoryNumberAttribute = "inventory_number"
+
+// How a scanned code was matched to an asset
+const (
+	matchedByID              = "id" // Asset UUID or URL
+	matchedByInventoryNumber = "inventory_number"
+	matchedByShortID         = "short_id" // Written to NFC tags
+	matchedByShortLink       = "short_link"
+)
+
+// errAmbiguousCode is returned when a code matches several assets
+var errAmbiguousCode = errors.New("code matches several assets")
+
+// ResolveResponse is the asset a scanned code points to
+type ResolveResponse struct {
+	Asset        AssetDetailResponse `json:"asset"`
+	LocationPath []domain.Location   `json:"location_path"` // Outermost first, empty without location
+	MatchedBy    string              `json:"matched_by"`    // id, inventory_number, short_id or short_link
+}
+
+// ResolveCode returns the asset a scanned QR code or label points to, with
+// the path of its location. ?code= takes the scanned payload: an asset URL
+// or UUID, an inventory number, an asset short ID (e.g. read from an NFC tag)
+// or a short link (code or URL).
+func (h *Handler) ResolveCode(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimSpace(r.URL.Query().Get("code"))
+	if code == "" {
+		writeError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	id, matchedBy, err := h.resolveCode(r.Context(), h.org(r), code)
+	if errors.Is(err, errAmbiguousCode) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to resolve code")
+		return
+	}
+	if id == uuid.Nil {
+		writeError(w, http.StatusNotFound, "no asset matches code")
+		return
+	}
+
+	asset, err := h.repos.Assets.GetByIDFull(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get asset")
+		return
+	}
+	hidden, err := h.assetHidden(r, asset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if asset == nil || asset.OrganizationID != h.org(r) || hidden {
+		writeError(w, http.StatusNotFound, "no asset matches code")
+		return
+	}
+
+	response := ResolveResponse{Asset: AssetDetailResponse{Asset: *asset}, LocationPath: []domain.Location{}, MatchedBy: matchedBy}
+	if asset.MainAttachment != nil && h.storage != nil {
+		if imageURL, err := h.storage.GetPresignedURL(r.Context(), asset.MainAttachment.FileKey, 15*time.Minute); err == nil {
+			response.Asset.MainAttachmentURL = imageURL
+		}
+	}
+	if asset.LocationID != nil {
+		locations, err := h.repos.Locations.List(r.Context(), h.org(r))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to get location")
+			return
+		}
+		byID := make(map[uuid.UUID]domain.Location, len(locations))
+		for _, l := range locations {
+			byID[l.ID] = l
+		}
+		response.LocationPath = append(response.LocationPath, locationAncestors(byID, *asset.LocationID)...)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// resolveCode returns the ID of the asset code points to and how it was
+// matched, or uuid.Nil if there is none
+func (h *Handler) resolveCode(ctx context.Context, orgID uuid.UUID, code string) (uuid.UUID, string, error) {
+	// URLs of an asset page or short link, as printed on labels
+	if u, err := url.Parse(code); err == nil && strings.HasPrefix(u.Path, "/") {
+		path := h.appPath(u.Path)
+		if id, ok := assetIDFromPath(path); ok {
+			return id, matchedByID, nil
+		}
+		if rest, ok := strings.CutPrefix(path, shortLinkPath("")); ok {
+			return h.resolveShortLinkCode(ctx, orgID, strings.Trim(rest, "/"))
+		}
+		return uuid.Nil, "", nil
+	}
+
+	if id, err := uuid.Parse(code); err == nil {
+		return id, matchedByID, nil
+	}
+
+	ids, err := h.repos.Assets.FindByAttribute(ctx, orgID, inventoryNumberAttribute, code)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	switch {
+	case len(ids) == 1:
+		return ids[0], matchedByInventoryNumber, nil
+	case len(ids) > 1:
+		return uuid.Nil, "", errAmbiguousCode
+	}
+
+	if shortID, ok := domain.NormalizeShortID(code); ok {
+		id, err := h.repos.Assets.FindByShortID(ctx, orgID, shortID)
+		if err != nil || id != uuid.Nil {
+			return id, matchedByShortID, err
+		}
+	}
+
+	return h.resolveShortLinkCode(ctx, orgID, code)
+}
+
+// resolveShortLinkCode returns the asset a short link of the organization
+// leads to, if it leads to an asset page
+func (h *Handler) resolveShortLinkCode(ctx context.Context, orgID uuid.UUID, code string) (uuid.UUID, string, error) {
+	if code == "" {
+		return uuid.Nil, "", nil
+	}
+	link, err := h.repos.ShortLinks.Resolve(ctx, code)
+	if err != nil || link == nil || link.OrganizationID != orgID {
+		return uuid.Nil, "", err
+	}
+	if id, ok := assetIDFromPath(link.Path); ok {
+		return id, matchedByShortLink, nil
+	}
+	return uuid.Nil, "", nil
+}
+
+// appPath strips the path of the public base URL, if the app is served
+// below one, from path
+func (h *Handler) appPath(path string) string {
+	if h.links == nil {
+		return path
+	}
+	base, err := url.Parse(h.links.BaseURL())
+	if err != nil || base.Path == "" {
+		return path
+	}
+	if rest, ok := strings.CutPrefix(path, base.Path); ok && strings.HasPrefix(rest, "/") {
+		return rest
+	}
+	return path
+}
+
+// assetIDFromPath returns the asset ID of an app path such as "/assets/{id}"
+// or one of its subpages
+func assetIDFromPath(path string) (uuid.UUID, bool) {
+	rest, ok := strings.CutPrefix(path, "/assets/")
+	if !ok {
+		return uuid.Nil, false
+	}
+	segment, _, _ := strings.Cut(rest, "/")
+	id, err := uuid.Parse(segment)
+	return id, err == nil
+}
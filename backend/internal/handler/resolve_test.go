@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/links"
+)
+
+func Test_ResolveCode_RequiresCode(t *testing.T) {
+	h := &Handler{repos: &Repositories{}}
+	rr := httptest.NewRecorder()
+
+	h.ResolveCode(rr, httptest.NewRequest(http.MethodGet, "/api/resolve?code=%20", nil))
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func Test_resolveCode_AssetURLs(t *testing.T) {
+	builder, err := links.New("https://example.com/attic", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{repos: &Repositories{}, links: builder}
+	id := uuid.New()
+
+	for _, code := range []string{
+		id.String(),
+		"https://example.com/attic/assets/" + id.String(),
+		"https://attic.lan/assets/" + id.String() + "/edit",
+		"/assets/" + id.String(),
+	} {
+		got, matchedBy, err := h.resolveCode(context.Background(), uuid.New(), code)
+		if err != nil || got != id || matchedBy != matchedByID {
+			t.Errorf("%s: expected %s by id, got %s by %q, %v", code, id, got, matchedBy, err)
+		}
+	}
+
+	// Other pages of the app don't point to an asset
+	if got, _, err := h.resolveCode(context.Background(), uuid.New(), "https://example.com/attic/locations"); got != uuid.Nil || err != nil {
+		t.Errorf("expected no asset, got %s, %v", got, err)
+	}
+}
+
+func Test_assetIDFromPath(t *testing.T) {
+	id := uuid.New()
+	if got, ok := assetIDFromPath("/assets/" + id.String() + "/loans"); !ok || got != id {
+		t.Errorf("expected %s, got %s", id, got)
+	}
+	for _, path := range []string{"/assets", "/assets/new", "/locations/" + id.String()} {
+		if _, ok := assetIDFromPath(path); ok {
+			t.Errorf("%s: expected no asset ID", path)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/repository"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// RoleRequest creates or updates a custom role (the name cannot be changed)
+type RoleRequest struct {
+	Name        domain.UserRole     `json:"name"`
+	Description string              `json:"description"`
+	Permissions []domain.Permission `json:"permissions"`
+}
+
+// ListPermissions returns the permissions roles can grant (admin only)
+func (h *Handler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, domain.Permissions)
+}
+
+// ListRoles returns the built-in and custom roles (admin only)
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	custom, err := h.repos.Roles.List(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list roles")
+		return
+	}
+	writeJSON(w, http.StatusOK, append(domain.BuiltinRoles(), custom...))
+}
+
+// CreateRole creates a custom role (admin only)
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req RoleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !roleNamePattern.MatchString(string(req.Name)) {
+		writeError(w, http.StatusBadRequest, "name must be a lowercase slug (a-z, 0-9, _ and -)")
+		return
+	}
+	permissions, ok := normalizePermissions(req.Permissions)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid permission")
+		return
+	}
+
+	exists, err := roleExists(r.Context(), h.repos.Roles, h.org(r), req.Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create role")
+		return
+	}
+	if exists {
+		writeError(w, http.StatusConflict, "role already exists")
+		return
+	}
+
+	role := &domain.Role{
+		OrganizationID: h.org(r),
+		Name:           req.Name,
+		Description:    req.Description,
+		Permissions:    permissions,
+	}
+	if err := h.repos.Roles.Create(r.Context(), role); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create role")
+		return
+	}
+	writeJSON(w, http.StatusCreated, role)
+}
+
+// UpdateRole replaces the description and permissions of a custom role (admin only)
+func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid role ID")
+		return
+	}
+	var req RoleRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	permissions, ok := normalizePermissions(req.Permissions)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid permission")
+		return
+	}
+
+	role, err := h.repos.Roles.GetByID(r.Context(), h.org(r), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get role")
+		return
+	}
+	if role == nil {
+		writeError(w, http.StatusNotFound, "role not found")
+		return
+	}
+	if req.Name != "" && req.Name != role.Name {
+		writeError(w, http.StatusBadRequest, "role name cannot be changed")
+		return
+	}
+
+	role.Description = req.Description
+	role.Permissions = permissions
+	if err := h.repos.Roles.Update(r.Context(), role); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update role")
+		return
+	}
+	writeJSON(w, http.StatusOK, role)
+}
+
+// DeleteRole deletes a custom role no user is assigned (admin only)
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid role ID")
+		return
+	}
+
+	role, err := h.repos.Roles.GetByID(r.Context(), h.org(r), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get role")
+		return
+	}
+	if role == nil {
+		writeError(w, http.StatusNotFound, "role not found")
+		return
+	}
+	count, err := h.repos.Roles.CountUsers(r.Context(), h.org(r), role.Name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete role")
+		return
+	}
+	if count > 0 {
+		writeError(w, http.StatusConflict, "role is assigned to users")
+		return
+	}
+
+	if err := h.repos.Roles.Delete(r.Context(), h.org(r), id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete role")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// normalizePermissions validates and deduplicates permissions, in the order of
+// domain.Permissions
+func normalizePermissions(perms []domain.Permission) ([]domain.Permission, bool) {
+	normalized := []domain.Permission{}
+	for _, p := range domain.Permissions {
+		if slices.Contains(perms, p) {
+			normalized = append(normalized, p)
+		}
+	}
+	for _, p := range perms {
+		if !p.Valid() {
+			return nil, false
+		}
+	}
+	return normalized, true
+}
+
+// roleExists reports whether name is a built-in or custom role
+func roleExists(ctx context.Context, roles *repository.RoleRepository, orgID uuid.UUID, name domain.UserRole) (bool, error) {
+	if _, ok := domain.BuiltinRole(name); ok {
+		return true, nil
+	}
+	if roles == nil {
+		return false, nil
+	}
+	role, err := roles.GetByName(ctx, orgID, name)
+	return role != nil, err
+}
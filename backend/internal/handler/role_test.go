@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_normalizePermissions(t *testing.T) {
+	perms, ok := normalizePermissions([]domain.Permission{
+		domain.PermissionSettingsManage, domain.PermissionAssetsRead, domain.PermissionAssetsRead,
+	})
+	want := []domain.Permission{domain.PermissionAssetsRead, domain.PermissionSettingsManage}
+	if !ok || !slices.Equal(perms, want) {
+		t.Errorf("expected %v, got %v (ok %v)", want, perms, ok)
+	}
+
+	if perms, ok := normalizePermissions(nil); !ok || perms == nil || len(perms) != 0 {
+		t.Errorf("expected empty permissions, got %v (ok %v)", perms, ok)
+	}
+	if _, ok := normalizePermissions([]domain.Permission{"assets:delete"}); ok {
+		t.Error("expected unknown permission to be rejected")
+	}
+}
+
+func Test_roleExists_BuiltinRoles(t *testing.T) {
+	for _, name := range []domain.UserRole{domain.UserRoleAdmin, domain.UserRoleUser} {
+		if ok, err := roleExists(context.Background(), nil, uuid.New(), name); err != nil || !ok {
+			t.Errorf("expected %q to exist", name)
+		}
+	}
+	if ok, _ := roleExists(context.Background(), nil, uuid.New(), "viewer"); ok {
+		t.Error("expected unknown role not to exist without custom roles")
+	}
+}
+
+func Test_roleNamePattern(t *testing.T) {
+	for _, name := range []string{"viewer", "house-sitter", "auditor_2"} {
+		if !roleNamePattern.MatchString(name) {
+			t.Errorf("expected %q to be valid", name)
+		}
+	}
+	for _, name := range []string{"", "v", "Viewer", "2nd", "view er"} {
+		if roleNamePattern.MatchString(name) {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
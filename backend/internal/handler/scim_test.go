@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_SCIMHandler_RequireToken(t *testing.T) {
+	h := NewSCIMHandler(nil, uuid.New(), "secret-token")
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"valid token", "Bearer secret-token", http.StatusOK},
+		{"wrong token", "Bearer other", http.StatusUnauthorized},
+		{"missing header", "", http.StatusUnauthorized},
+		{"basic auth", "Basic secret-token", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			h.RequireToken(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, rec.Code)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("Content-Type") != scimContentType {
+				t.Errorf("expected SCIM content type, got %q", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func Test_parseSCIMFilter(t *testing.T) {
+	tests := []struct {
+		filter    string
+		attribute string
+		value     string
+		wantErr   bool
+	}{
+		{"", "", "", false},
+		{`userName eq "jane@example.com"`, "username", "jane@example.com", false},
+		{`externalId eq "00u1 abc"`, "externalid", "00u1 abc", false},
+		{`userName co "jane"`, "", "", true},
+		{`displayName eq "Jane"`, "", "", true},
+		{`userName eq jane`, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			attribute, value, err := parseSCIMFilter(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSCIMFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attribute != tt.attribute || value != tt.value {
+				t.Errorf("expected (%q, %q), got (%q, %q)", tt.attribute, tt.value, attribute, value)
+			}
+		})
+	}
+}
+
+func Test_patchSCIMUser_Active(t *testing.T) {
+	tests := []struct {
+		name string
+		op   SCIMPatchOperation
+		want bool
+	}{
+		{"bool path", SCIMPatchOperation{Op: "replace", Path: "active", Value: json.RawMessage(`false`)}, false},
+		{"string value", SCIMPatchOperation{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)}, false},
+		{"no path", SCIMPatchOperation{Op: "replace", Value: json.RawMessage(`{"active":false}`)}, false},
+		{"reactivate", SCIMPatchOperation{Op: "add", Path: "active", Value: json.RawMessage(`true`)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active := !tt.want
+			var u SCIMUser
+			if err := patchSCIMUser(&u, &active, tt.op); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if active != tt.want {
+				t.Errorf("expected active=%v, got %v", tt.want, active)
+			}
+		})
+	}
+}
+
+func Test_patchSCIMUser_UnsupportedOperation_ReturnsError(t *testing.T) {
+	active := true
+	var u SCIMUser
+	if err := patchSCIMUser(&u, &active, SCIMPatchOperation{Op: "remove", Path: "displayName"}); err == nil {
+		t.Error("expected error for remove operation")
+	}
+	if err := patchSCIMUser(&u, &active, SCIMPatchOperation{Op: "replace", Path: "title", Value: json.RawMessage(`"x"`)}); err == nil {
+		t.Error("expected error for unsupported path")
+	}
+}
+
+func Test_SCIMUser_apply(t *testing.T) {
+	u := SCIMUser{
+		UserName:   "jdoe",
+		ExternalID: "ext-1",
+		Name:       &SCIMName{Formatted: "Jane Doe"},
+		Emails:     []SCIMEmail{{Value: "other@example.com"}, {Value: "jane@example.com", Primary: true}},
+	}
+
+	var user domain.User
+	if err := u.apply(&user); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Email != "jane@example.com" {
+		t.Errorf("expected primary email, got %q", user.Email)
+	}
+	if user.DisplayName == nil || *user.DisplayName != "Jane Doe" {
+		t.Errorf("expected display name from name.formatted, got %v", user.DisplayName)
+	}
+	if user.ExternalID == nil || *user.ExternalID != "ext-1" {
+		t.Errorf("expected external ID, got %v", user.ExternalID)
+	}
+
+	if err := (SCIMUser{UserName: "not-an-email"}).apply(&user); err == nil {
+		t.Error("expected error for user without an email address")
+	}
+}
+
+func Test_SCIMHandler_Discovery(t *testing.T) {
+	h := NewSCIMHandler(nil, uuid.New(), "secret-token")
+
+	tests := map[string]struct {
+		handle http.HandlerFunc
+		id     string
+	}{
+		"ResourceTypes": {h.ResourceTypes, "User"},
+		"Schemas":       {h.Schemas, scimUserSchema},
+	}
+	for name, tt := range tests {
+		rec := httptest.NewRecorder()
+		tt.handle(rec, httptest.NewRequest(http.MethodGet, "/scim/v2/"+name, nil))
+
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != scimContentType {
+			t.Fatalf("%s: unexpected response %d %q", name, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		var resp struct {
+			TotalResults int `json:"totalResults"`
+			Resources    []struct {
+				ID string `json:"id"`
+			} `json:"Resources"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if resp.TotalResults != 1 || len(resp.Resources) != 1 || resp.Resources[0].ID != tt.id {
+			t.Errorf("%s: unexpected resources %+v", name, resp)
+		}
+	}
+}
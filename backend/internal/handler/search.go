@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/search"
+)
+
+type SearchAssetsResponse struct {
+	Assets []AssetWithImageURL       `json:"assets"`
+	Total  int                       `json:"total"`
+	Limit  int                       `json:"limit"`
+	Offset int                       `json:"offset"`
+	Facets map[string]map[string]int `json:"facets"`
+}
+
+type ReindexResponse struct {
+	Indexed int `json:"indexed"`
+}
+
+// SearchAssets runs a typo-tolerant, faceted search against the external search engine.
+// Facet filters are passed as query parameters named after the facet (category, location, condition, tags).
+func (h *Handler) SearchAssets(w http.ResponseWriter, r *http.Request) {
+	if h.search == nil {
+		writeError(w, http.StatusServiceUnavailable, "search engine not configured")
+		return
+	}
+
+	q := r.URL.Query()
+
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+
+	// Filtered by the engine too, so that the total and facets don't count
+	// hidden assets
+	query := search.Query{
+		OrganizationID: h.org(r),
+		NoHighValue:    hide,
+		Text:           q.Get("q"),
+		Filters:        map[string]string{},
+		Limit:          limit,
+		Offset:         offset,
+	}
+	for _, field := range search.FacetFields {
+		if v := q.Get(field); v != "" {
+			query.Filters[field] = v
+		}
+	}
+
+	result, err := h.search.Search(r.Context(), query)
+	if err != nil {
+		slog.Error("search engine query failed", "error", err)
+		writeError(w, http.StatusBadGateway, "search failed")
+		return
+	}
+
+	assets := []domain.Asset{}
+	if len(result.IDs) > 0 {
+		filter := domain.AssetFilter{IDs: result.IDs, NoHighValue: hide}
+		assets, _, err = h.repos.Assets.List(r.Context(), h.org(r), filter, domain.Pagination{Limit: len(result.IDs)})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to load assets")
+			return
+		}
+	}
+
+	ordered := orderByIDs(assets, result.IDs)
+	response := SearchAssetsResponse{
+		Assets: make([]AssetWithImageURL, len(ordered)),
+		Total:  result.Total,
+		Limit:  limit,
+		Offset: offset,
+		Facets: result.Facets,
+	}
+	for i := range ordered {
+		response.Assets[i] = AssetWithImageURL{Asset: ordered[i], MainAttachmentURL: h.mainAttachmentURL(r, &ordered[i])}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// ReindexSearch rebuilds the search index for the organization
+func (h *Handler) ReindexSearch(w http.ResponseWriter, r *http.Request) {
+	if h.indexer == nil {
+		writeError(w, http.StatusServiceUnavailable, "search engine not configured")
+		return
+	}
+
+	count, err := h.indexer.Reindex(r.Context(), h.org(r))
+	if err != nil {
+		slog.Error("failed to reindex search", "error", err)
+		writeError(w, http.StatusBadGateway, "failed to reindex")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ReindexResponse{Indexed: count})
+}
+
+// orderByIDs returns assets in the order of ids (search relevance), skipping
+// IDs whose asset no longer exists in the database
+func orderByIDs(assets []domain.Asset, ids []uuid.UUID) []domain.Asset {
+	byID := make(map[uuid.UUID]domain.Asset, len(assets))
+	for _, a := range assets {
+		byID[a.ID] = a
+	}
+
+	ordered := make([]domain.Asset, 0, len(ids))
+	for _, id := range ids {
+		if a, ok := byID[id]; ok {
+			ordered = append(ordered, a)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_orderByIDs_FollowsRelevanceOrder(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+	assets := []domain.Asset{{ID: a, Name: "A"}, {ID: b, Name: "B"}}
+
+	ordered := orderByIDs(assets, []uuid.UUID{b, c, a})
+
+	if len(ordered) != 2 {
+		t.Fatalf("expected 2 assets, got %d", len(ordered))
+	}
+	if ordered[0].ID != b || ordered[1].ID != a {
+		t.Error("expected assets in search result order, skipping missing IDs")
+	}
+}
+
+func Test_SearchAssets_NotConfigured_ReturnsServiceUnavailable(t *testing.T) {
+	h := &Handler{}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=lamp", nil)
+	rec := httptest.NewRecorder()
+
+	h.SearchAssets(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// maxServiceTokenDays is the longest lifetime of a service token
+const maxServiceTokenDays = 3650
+
+// CreateServiceAccountRequest creates a service account
+type CreateServiceAccountRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"` // Defaults to "user"
+}
+
+// CreateServiceTokenRequest creates a token of a service account
+type CreateServiceTokenRequest struct {
+	Name          string              `json:"name"`
+	Scopes        []domain.Permission `json:"scopes"`
+	ExpiresInDays int                 `json:"expires_in_days"` // 0 = never expires
+}
+
+// ServiceTokenResponse is a newly created service token; the token itself
+// is only returned once
+type ServiceTokenResponse struct {
+	domain.ServiceToken
+	Token string `json:"token"` // Send as "Authorization: Bearer <token>"
+}
+
+// ListServiceAccounts returns the service accounts
+func (h *UserManagementHandler) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	users, err := h.userRepo.List(r.Context(), h.org(r))
+	if err != nil {
+		slog.Error("failed to list users", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	response := []UserResponse{}
+	for i := range users {
+		if users[i].ServiceAccount {
+			response = append(response, toUserResponse(&users[i]))
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// CreateServiceAccount creates a service account for an integration. It
+// can't sign in; it authenticates with the tokens created for it.
+func (h *UserManagementHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	var req CreateServiceAccountRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	role := domain.UserRoleUser
+	if req.Role != "" {
+		if !h.validRole(w, r, domain.UserRole(req.Role)) {
+			return
+		}
+		role = domain.UserRole(req.Role)
+	}
+
+	// The address is never used, .invalid keeps it from matching an account
+	// of the identity provider
+	now := time.Now()
+	user := &domain.User{
+		ID:             uuid.New(),
+		OrganizationID: h.org(r),
+		DisplayName:    &req.Name,
+		Role:           role,
+		VerifiedAt:     &now,
+		ServiceAccount: true,
+	}
+	user.Email = fmt.Sprintf("service-%s@attic.invalid", user.ID)
+
+	if err := h.userRepo.Create(r.Context(), user); err != nil {
+		slog.Error("failed to create service account", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	slog.Info("service account created", "user_id", user.ID, "role", role)
+	writeJSON(w, http.StatusCreated, toUserResponse(user))
+}
+
+// DeleteServiceAccount deletes a service account, invalidating its tokens
+func (h *UserManagementHandler) DeleteServiceAccount(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadServiceAccount(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.Delete(r.Context(), user.ID); err != nil {
+		slog.Error("failed to delete service account", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListServiceTokens returns the tokens of a service account
+func (h *UserManagementHandler) ListServiceTokens(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadServiceAccount(w, r)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.userRepo.ListServiceTokens(r.Context(), user.ID)
+	if err != nil {
+		slog.Error("failed to list service tokens", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if tokens == nil {
+		tokens = []domain.ServiceToken{}
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+// CreateServiceToken creates a token of a service account limited to scopes.
+// A token never grants more than the account's role does.
+func (h *UserManagementHandler) CreateServiceToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadServiceAccount(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateServiceTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	scopes, err := validateServiceToken(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	token, hash := auth.NewServiceToken()
+	stored := &domain.ServiceToken{
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: hash,
+		Scopes:    scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		stored.ExpiresAt = &expiresAt
+	}
+	if err := h.userRepo.CreateServiceToken(r.Context(), stored); err != nil {
+		slog.Error("failed to create service token", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	slog.Info("service token created", "user_id", user.ID, "token_id", stored.ID, "scopes", scopes)
+	writeJSON(w, http.StatusCreated, ServiceTokenResponse{ServiceToken: *stored, Token: token})
+}
+
+// DeleteServiceToken revokes a token of a service account
+func (h *UserManagementHandler) DeleteServiceToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadServiceAccount(w, r)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(chi.URLParam(r, "tokenId"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid token ID")
+		return
+	}
+
+	deleted, err := h.userRepo.DeleteServiceToken(r.Context(), user.ID, id)
+	if err != nil {
+		slog.Error("failed to delete service token", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "token not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validateServiceToken checks a token request, returning the distinct scopes
+func validateServiceToken(req *CreateServiceTokenRequest) ([]domain.Permission, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxServiceTokenDays {
+		return nil, fmt.Errorf("expires_in_days must be between 0 and %d", maxServiceTokenDays)
+	}
+	if len(req.Scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+
+	var scopes []domain.Permission
+	for _, scope := range req.Scopes {
+		if !scope.Valid() {
+			return nil, fmt.Errorf("unknown scope: %s", scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func (h *UserManagementHandler) loadServiceAccount(w http.ResponseWriter, r *http.Request) (*domain.User, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid service account ID")
+		return nil, false
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return nil, false
+	}
+	if user == nil || !user.ServiceAccount || user.OrganizationID != h.org(r) {
+		writeError(w, http.StatusNotFound, "service account not found")
+		return nil, false
+	}
+	return user, true
+}
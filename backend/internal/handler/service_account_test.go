@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_validateServiceToken(t *testing.T) {
+	tests := map[string]struct {
+		req     CreateServiceTokenRequest
+		wantErr bool
+	}{
+		"read only":     {req: CreateServiceTokenRequest{Name: "dashboard", Scopes: []domain.Permission{"assets:read"}}},
+		"expiring":      {req: CreateServiceTokenRequest{Name: "dashboard", Scopes: []domain.Permission{"assets:read"}, ExpiresInDays: 90}},
+		"missing name":  {req: CreateServiceTokenRequest{Name: " ", Scopes: []domain.Permission{"assets:read"}}, wantErr: true},
+		"no scopes":     {req: CreateServiceTokenRequest{Name: "dashboard"}, wantErr: true},
+		"unknown scope": {req: CreateServiceTokenRequest{Name: "dashboard", Scopes: []domain.Permission{"assets:delete"}}, wantErr: true},
+		"negative days": {req: CreateServiceTokenRequest{Name: "dashboard", Scopes: []domain.Permission{"assets:read"}, ExpiresInDays: -1}, wantErr: true},
+		"too many days": {req: CreateServiceTokenRequest{Name: "dashboard", Scopes: []domain.Permission{"assets:read"}, ExpiresInDays: maxServiceTokenDays + 1}, wantErr: true},
+	}
+	for name, tt := range tests {
+		_, err := validateServiceToken(&tt.req)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", name, tt.wantErr, err)
+		}
+	}
+
+	scopes, _ := validateServiceToken(&CreateServiceTokenRequest{Name: "sync", Scopes: []domain.Permission{"assets:read", "assets:write", "assets:read"}})
+	if !slices.Equal(scopes, []domain.Permission{domain.PermissionAssetsRead, domain.PermissionAssetsWrite}) {
+		t.Errorf("expected distinct scopes, got %v", scopes)
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// SessionResponse is an active login session of the current user
+type SessionResponse struct {
+	domain.UserSession
+	Current bool `json:"current"` // The session of this request
+}
+
+// ListSessions returns the active sessions of the signed-in user
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.localSession(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.userRepo.ListSessions(r.Context(), session.UserID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		response = append(response, SessionResponse{UserSession: s, Current: s.ID == session.ID})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// RevokeSession signs out one session of the signed-in user; revoking the
+// current session also clears its cookie
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.localSession(w, r)
+	if !ok {
+		return
+	}
+	id, err := parseUUID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid session ID")
+		return
+	}
+
+	deleted, err := h.userRepo.DeleteSession(r.Context(), session.UserID, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+	if id == session.ID {
+		h.sessionManager.ClearSession(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeSessions signs out all other sessions of the signed-in user, or all
+// of them including the current one with ?all=true
+func (h *AuthHandler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	session, ok := h.localSession(w, r)
+	if !ok {
+		return
+	}
+
+	keep := session.ID
+	all := r.URL.Query().Get("all") == "true"
+	if all {
+		keep = uuid.Nil
+	}
+	revoked, err := h.userRepo.DeleteSessions(r.Context(), session.UserID, keep)
+	if err != nil {
+		slog.Error("failed to revoke sessions", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+	if all {
+		h.sessionManager.ClearSession(w)
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"revoked": revoked})
+}
+
+// localSession returns the local session of the request, writing an error
+// response when there is none (sessions of OIDC and proxy logins are managed
+// by the identity provider)
+func (h *AuthHandler) localSession(w http.ResponseWriter, r *http.Request) (*auth.LocalSession, bool) {
+	if h.oidcEnabled || h.proxyAuth != nil {
+		writeError(w, http.StatusBadRequest, "sessions are managed by the identity provider")
+		return nil, false
+	}
+	session, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, false
+	}
+	return session, true
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+	"github.com/lmmendes/attic/internal/links"
+	"github.com/lmmendes/attic/internal/mail"
+)
+
+// AdjustQuantityRequest adds to or takes from the quantity of an asset, e.g.
+// when a consumable was used up or restocked
+type AdjustQuantityRequest struct {
+	Delta int `json:"delta"` // e.g. -1 for one used up; the quantity doesn't go below 0
+}
+
+// GetShoppingList returns the assets whose quantity dropped below their
+// low-stock threshold
+func (h *Handler) GetShoppingList(w http.ResponseWriter, r *http.Request) {
+	items, err := h.repos.Assets.ListLowStock(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get shopping list")
+		return
+	}
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if hide {
+		items = slices.DeleteFunc(items, func(i domain.ShoppingListItem) bool { return i.HighValue })
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+// AdjustAssetQuantity adds the request's delta to the quantity of an asset
+func (h *Handler) AdjustAssetQuantity(w http.ResponseWriter, r *http.Request) {
+	var req AdjustQuantityRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Delta == 0 {
+		writeError(w, http.StatusBadRequest, "delta is required")
+		return
+	}
+
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
+		return
+	}
+	if req.Delta > maxAssetQuantity-asset.Quantity {
+		writeError(w, http.StatusBadRequest, "quantity exceeds maximum allowed value")
+		return
+	}
+
+	quantity, err := h.repos.Assets.AdjustQuantity(r.Context(), asset.ID, req.Delta)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to adjust quantity")
+		return
+	}
+	if quantity == nil {
+		writeError(w, http.StatusNotFound, "asset not found")
+		return
+	}
+	asset.Quantity = *quantity
+
+	h.publish(r, events.AssetUpdated, asset.OrganizationID, asset.ID)
+
+	writeJSON(w, http.StatusOK, asset)
+}
+
+// LowStockAlerts emails admins when assets drop below their low-stock
+// threshold, once until they are restocked
+type LowStockAlerts struct {
+	repos  *Repositories
+	mailer mail.Mailer
+	links  *links.Builder // nil = no link in alerts
+}
+
+// NewLowStockAlerts creates the low-stock alert task
+func NewLowStockAlerts(repos *Repositories, mailer mail.Mailer, builder *links.Builder) *LowStockAlerts {
+	return &LowStockAlerts{repos: repos, mailer: mailer, links: builder}
+}
+
+// RunOnce sends the alerts for assets that ran low since the last run
+func (la *LowStockAlerts) RunOnce(ctx context.Context) error {
+	orgs, err := la.repos.Organizations.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		if err := la.runOrganization(ctx, org.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (la *LowStockAlerts) runOrganization(ctx context.Context, orgID uuid.UUID) error {
+	items, err := la.repos.Assets.ListLowStock(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	items = slices.DeleteFunc(items, func(i domain.ShoppingListItem) bool { return i.NotifiedAt != nil })
+	if len(items) == 0 {
+		return nil
+	}
+
+	recipients, err := adminEmails(ctx, la.repos, orgID)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+
+	title := defaultBrandTitle
+	var branding domain.Branding
+	if found, err := la.repos.Settings.Get(ctx, orgID, domain.SettingBranding, &branding); err == nil && found && branding.Title != "" {
+		title = branding.Title
+	}
+
+	if err := la.mailer.Send(ctx, lowStockAlert(title, recipients, items, la.links)); err != nil {
+		return err
+	}
+	ids := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		ids[i] = item.AssetID
+	}
+	return la.repos.Assets.MarkLowStockNotified(ctx, ids)
+}
+
+// lowStockAlert builds the email listing assets that ran low
+func lowStockAlert(title string, to []string, items []domain.ShoppingListItem, builder *links.Builder) mail.Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The following items are running low:\n\n")
+	for _, i := range items {
+		fmt.Fprintf(&b, "- %s: %d left, keep at least %d\n", i.Name, i.Quantity, i.MinQuantity)
+	}
+	if builder != nil {
+		fmt.Fprintf(&b, "\nReview them at %s\n", builder.URL("/"))
+	}
+
+	subject := fmt.Sprintf("[%s] %d items are running low", title, len(items))
+	if len(items) == 1 {
+		subject = fmt.Sprintf("[%s] %s is running low", title, items[0].Name)
+	}
+	return mail.Message{To: to, Subject: subject, Text: b.String()}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_AdjustAssetQuantity_Validation(t *testing.T) {
+	for name, body := range map[string]string{
+		"invalid JSON":  `{`,
+		"missing delta": `{}`,
+		"zero delta":    `{"delta": 0}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := &Handler{repos: &Repositories{}}
+			id := uuid.NewString()
+			req := withChiURLParam(httptest.NewRequest(http.MethodPost, "/api/assets/"+id+"/adjust", strings.NewReader(body)), "id", id)
+			rr := httptest.NewRecorder()
+
+			h.AdjustAssetQuantity(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func Test_validateMinQuantity(t *testing.T) {
+	zero, three, negative, tooMany := 0, 3, -1, maxAssetQuantity+1
+	for _, q := range []*int{nil, &zero, &three} {
+		if err := validateMinQuantity(q); err != nil {
+			t.Errorf("expected %v to be valid, got %v", q, err)
+		}
+	}
+	for _, q := range []*int{&negative, &tooMany} {
+		if err := validateMinQuantity(q); err == nil {
+			t.Errorf("expected %d to be invalid", *q)
+		}
+	}
+}
+
+func Test_lowStockAlert(t *testing.T) {
+	items := []domain.ShoppingListItem{{Name: "Coffee beans", Quantity: 1, MinQuantity: 3, Needed: 2}}
+
+	msg := lowStockAlert("Attic", []string{"admin@example.com"}, items, nil)
+
+	if msg.Subject != "[Attic] Coffee beans is running low" {
+		t.Errorf("unexpected subject: %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "- Coffee beans: 1 left, keep at least 3") {
+		t.Errorf("unexpected body: %q", msg.Text)
+	}
+
+	items = append(items, domain.ShoppingListItem{Name: "Dish soap", MinQuantity: 1, Needed: 1})
+	if msg := lowStockAlert("Attic", nil, items, nil); msg.Subject != "[Attic] 2 items are running low" {
+		t.Errorf("unexpected subject: %q", msg.Subject)
+	}
+}
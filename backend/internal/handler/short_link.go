@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// maxShortLinkState is the longest encoded query string a short link stores
+const maxShortLinkState = 4096
+
+// CreateShortLinkRequest is the view a short link points to
+type CreateShortLinkRequest struct {
+	Path  string              `json:"path"`  // App route, e.g. "/assets"
+	State map[string][]string `json:"state"` // Query parameters with the filter, sort and facet state
+}
+
+// ShortLinkResponse is a short link with its absolute URL
+type ShortLinkResponse struct {
+	domain.ShortLink
+	URL string `json:"url"`
+}
+
+// CreateShortLink stores the state of a view and returns a short link to it.
+// Sharing the same view again returns the existing link.
+func (h *Handler) CreateShortLink(w http.ResponseWriter, r *http.Request) {
+	var req CreateShortLinkRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if !validAppPath(req.Path) {
+		writeError(w, http.StatusBadRequest, "path must be a route of the app, e.g. /assets")
+		return
+	}
+	if len(url.Values(req.State).Encode()) > maxShortLinkState {
+		writeError(w, http.StatusBadRequest, "state is too large")
+		return
+	}
+
+	code, err := newShortLinkCode()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create link")
+		return
+	}
+
+	link := &domain.ShortLink{
+		Code:           code,
+		OrganizationID: h.org(r),
+		CreatedBy:      currentUserID(r),
+		Path:           req.Path,
+		State:          req.State,
+	}
+	created, err := h.repos.ShortLinks.Create(r.Context(), link)
+	if err != nil {
+		slog.Error("failed to create short link", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to create link")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, ShortLinkResponse{ShortLink: *link, URL: h.absoluteURL(r, shortLinkPath(link.Code))})
+}
+
+// GetShortLink returns the view a short link points to, for the app to apply
+func (h *Handler) GetShortLink(w http.ResponseWriter, r *http.Request) {
+	link, ok := h.resolveShortLink(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, ShortLinkResponse{ShortLink: *link, URL: h.absoluteURL(r, shortLinkPath(link.Code))})
+}
+
+// FollowShortLink redirects to the view a short link points to
+func (h *Handler) FollowShortLink(w http.ResponseWriter, r *http.Request) {
+	link, ok := h.resolveShortLink(w, r)
+	if !ok {
+		return
+	}
+	http.Redirect(w, r, shortLinkTarget(link), http.StatusFound)
+}
+
+func (h *Handler) resolveShortLink(w http.ResponseWriter, r *http.Request) (*domain.ShortLink, bool) {
+	link, err := h.repos.ShortLinks.Resolve(r.Context(), chi.URLParam(r, "code"))
+	if err != nil {
+		slog.Error("failed to resolve short link", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to get link")
+		return nil, false
+	}
+	// Links are followed before signing in; the app they lead to is scoped
+	// to the user's organization anyway
+	signedIn := auth.OrganizationID(r.Context()) != uuid.Nil
+	if link == nil || (signedIn && link.OrganizationID != h.org(r)) {
+		writeError(w, http.StatusNotFound, "link not found")
+		return nil, false
+	}
+	return link, true
+}
+
+func shortLinkPath(code string) string {
+	return "/l/" + code
+}
+
+// shortLinkTarget returns the app URL of a link, with its state as query string
+func shortLinkTarget(link *domain.ShortLink) string {
+	if len(link.State) == 0 {
+		return link.Path
+	}
+	return link.Path + "?" + url.Values(link.State).Encode()
+}
+
+// validAppPath reports whether path is a route of the app, so short links
+// can't redirect to other sites or to the API
+func validAppPath(path string) bool {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.ContainsAny(path, "\\?#") {
+		return false
+	}
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return false
+	}
+	for _, reserved := range []string{"/api", "/auth", "/l", "/share", "/files"} {
+		if path == reserved || strings.HasPrefix(path, reserved+"/") {
+			return false
+		}
+	}
+	return true
+}
+
+func newShortLinkCode() (string, error) {
+	b := make([]byte, 9)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_validAppPath(t *testing.T) {
+	tests := map[string]bool{
+		"/assets":             true,
+		"/":                   true,
+		"/locations/123":      true,
+		"":                    false,
+		"assets":              false,
+		"//evil.example.com":  false,
+		"/\\evil.example.com": false,
+		"https://example.com": false,
+		"/assets?x=1":         false,
+		"/api/assets":         false,
+		"/auth/logout":        false,
+		"/l/abc":              false,
+		"/apiary":             true,
+	}
+	for path, want := range tests {
+		if got := validAppPath(path); got != want {
+			t.Errorf("validAppPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func Test_shortLinkTarget(t *testing.T) {
+	link := &domain.ShortLink{
+		Path:  "/assets",
+		State: map[string][]string{"sort": {"-created_at"}, "tag": {"a b", "c"}},
+	}
+	if got := shortLinkTarget(link); got != "/assets?sort=-created_at&tag=a+b&tag=c" {
+		t.Errorf("unexpected target %q", got)
+	}
+
+	if got := shortLinkTarget(&domain.ShortLink{Path: "/assets"}); got != "/assets" {
+		t.Errorf("expected no query string without state, got %q", got)
+	}
+}
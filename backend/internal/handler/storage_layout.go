@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+// relayoutBatchSize is the number of attachments loaded at a time by RelayoutStorage
+const relayoutBatchSize = 100
+
+// RelayoutStorage moves the files of attachments whose keys don't follow
+// layout, e.g. after ATTIC_STORAGE_LAYOUT changed; the storage must upload
+// with the same layout. A file is copied to its new key before the attachment
+// is updated and the old file deleted, so the server can keep running and an
+// interrupted run can simply be repeated. It returns the number of moved and
+// failed files.
+func (h *Handler) RelayoutStorage(ctx context.Context, layout storage.Layout) (moved, failed int, err error) {
+	err = h.forEachOrganization(ctx, func(orgID uuid.UUID) error {
+		m, f, err := h.relayoutOrganization(ctx, layout, orgID)
+		moved += m
+		failed += f
+		return err
+	})
+	return moved, failed, err
+}
+
+// relayoutOrganization moves the files of an organization's attachments
+func (h *Handler) relayoutOrganization(ctx context.Context, layout storage.Layout, orgID uuid.UUID) (moved, failed int, err error) {
+	after := uuid.Nil
+	for {
+		batch, err := h.repos.Attachments.ListByOrganization(ctx, orgID, after, relayoutBatchSize)
+		if err != nil {
+			return moved, failed, err
+		}
+		for i := range batch {
+			att := &batch[i]
+			if storage.IsArchived(att.FileKey) {
+				continue // Archived files keep their keys
+			}
+			obj := storage.Object{
+				OrganizationID: orgID,
+				AssetID:        att.AssetID,
+				FileName:       att.FileName,
+				CreatedAt:      att.CreatedAt,
+			}
+			if layout.Matches(att.FileKey, obj) {
+				continue
+			}
+			if err := h.moveAttachmentFile(ctx, att, obj); err != nil {
+				slog.Error("failed to move attachment file", "attachment_id", att.ID, "key", att.FileKey, "error", err)
+				failed++
+				continue
+			}
+			moved++
+		}
+		if len(batch) < relayoutBatchSize {
+			return moved, failed, nil
+		}
+		after = batch[len(batch)-1].ID
+	}
+}
+
+// moveAttachmentFile copies the file of an attachment to a new key of obj and
+// deletes the old file once the attachment points to the copy
+func (h *Handler) moveAttachmentFile(ctx context.Context, att *domain.Attachment, obj storage.Object) error {
+	f, err := h.storage.Open(ctx, att.FileKey)
+	if err != nil {
+		return err
+	}
+	key, err := h.storage.UploadObject(ctx, obj, derefString(att.ContentType), f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	ok, err := h.repos.Attachments.MoveFile(ctx, att.ID, att.FileKey, key)
+	if err != nil || !ok {
+		// Deleted or moved meanwhile; drop the copy
+		h.storage.Delete(ctx, key)
+		return err
+	}
+	if err := h.storage.Delete(ctx, att.FileKey); err != nil {
+		slog.Warn("failed to delete moved attachment file", "key", att.FileKey, "error", err)
+	}
+	slog.Info("moved attachment file", "attachment_id", att.ID, "from", att.FileKey, "to", key)
+	return nil
+}
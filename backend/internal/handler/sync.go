@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+// GetSyncChanges returns the IDs of all resources created, updated or deleted
+// since the given timestamp, for offline clients doing delta sync. Omitting
+// "since" returns everything (initial sync). Clients should pass the returned
+// server_time as "since" on their next call. High-value assets hidden from
+// the caller are left out.
+func (h *Handler) GetSyncChanges(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if v := r.URL.Query().Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid since, expected RFC 3339 timestamp")
+			return
+		}
+		since = t
+	}
+
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+
+	changes, err := h.repos.Sync.Changes(r.Context(), h.org(r), since, hide)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to compute changes")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, changes)
+}
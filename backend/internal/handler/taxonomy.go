@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// TaxonomyBundle is the inventory structure in a single cacheable response
+type TaxonomyBundle struct {
+	Version    string             `json:"version"` // Content hash, also sent as ETag
+	Categories []domain.Category  `json:"categories"`
+	Locations  []domain.Location  `json:"locations"`
+	Conditions []domain.Condition `json:"conditions"`
+	Attributes []domain.Attribute `json:"attributes"`
+}
+
+// GetTaxonomyBundle returns categories, locations, conditions and attributes
+// in one response so a PWA can cache the inventory structure for offline use.
+// The response carries an ETag; clients revalidate with If-None-Match and get
+// 304 Not Modified while nothing changed.
+func (h *Handler) GetTaxonomyBundle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	categories, err := h.repos.Categories.List(ctx, h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list categories")
+		return
+	}
+	locations, err := h.repos.Locations.List(ctx, h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list locations")
+		return
+	}
+	conditions, err := h.repos.Conditions.List(ctx, h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list conditions")
+		return
+	}
+	attributes, err := h.repos.Attributes.List(ctx, h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list attributes")
+		return
+	}
+
+	bundle := TaxonomyBundle{
+		Categories: categories,
+		Locations:  locations,
+		Conditions: conditions,
+		Attributes: attributes,
+	}
+	if bundle.Categories == nil {
+		bundle.Categories = []domain.Category{}
+	}
+	if bundle.Locations == nil {
+		bundle.Locations = []domain.Location{}
+	}
+	if bundle.Conditions == nil {
+		bundle.Conditions = []domain.Condition{}
+	}
+	if bundle.Attributes == nil {
+		bundle.Attributes = []domain.Attribute{}
+	}
+
+	version, err := taxonomyVersion(bundle)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to compute taxonomy version")
+		return
+	}
+	bundle.Version = version
+
+	etag := `"` + version + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+// taxonomyVersion hashes the bundle contents (excluding the version itself)
+func taxonomyVersion(bundle TaxonomyBundle) (string, error) {
+	bundle.Version = ""
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// etagMatches reports whether an If-None-Match header matches etag
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
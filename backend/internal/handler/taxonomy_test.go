@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_taxonomyVersion_StableForSameContent(t *testing.T) {
+	id := uuid.New()
+	a := TaxonomyBundle{Categories: []domain.Category{{ID: id, Name: "Books"}}}
+	b := TaxonomyBundle{Categories: []domain.Category{{ID: id, Name: "Books"}}, Version: "ignored"}
+
+	va, _ := taxonomyVersion(a)
+	vb, _ := taxonomyVersion(b)
+
+	if va != vb {
+		t.Errorf("expected equal versions, got %s and %s", va, vb)
+	}
+	if len(va) != 32 {
+		t.Errorf("expected 32 hex chars, got %d", len(va))
+	}
+}
+
+func Test_taxonomyVersion_ChangesWithContent(t *testing.T) {
+	id := uuid.New()
+	a := TaxonomyBundle{Categories: []domain.Category{{ID: id, Name: "Books"}}}
+	b := TaxonomyBundle{Categories: []domain.Category{{ID: id, Name: "Comics"}}}
+
+	va, _ := taxonomyVersion(a)
+	vb, _ := taxonomyVersion(b)
+
+	if va == vb {
+		t.Error("expected versions to differ")
+	}
+}
+
+func Test_etagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{"*", true},
+		{`"xyz"`, false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// TimeZoneRequest sets a time zone ("" = inherit: organization default or UTC)
+type TimeZoneRequest struct {
+	TimeZone string `json:"time_zone"`
+}
+
+// TimeZoneResponse is a configured time zone
+type TimeZoneResponse struct {
+	TimeZone string `json:"time_zone"` // "" when not set
+}
+
+// GetTimeZone returns the organization time zone (admin only)
+func (h *Handler) GetTimeZone(w http.ResponseWriter, r *http.Request) {
+	name, err := h.orgTimeZone(r.Context(), h.org(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get time zone")
+		return
+	}
+	writeJSON(w, http.StatusOK, TimeZoneResponse{TimeZone: name})
+}
+
+// UpdateTimeZone sets the organization time zone used to interpret dates
+// and schedule notifications (admin only)
+func (h *Handler) UpdateTimeZone(w http.ResponseWriter, r *http.Request) {
+	var req TimeZoneRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if req.TimeZone == "" {
+		if err := h.repos.Settings.Delete(r.Context(), h.org(r), domain.SettingTimeZone); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to save time zone")
+			return
+		}
+		writeJSON(w, http.StatusOK, TimeZoneResponse{})
+		return
+	}
+
+	if !validTimeZone(req.TimeZone) {
+		writeError(w, http.StatusBadRequest, "unknown time zone")
+		return
+	}
+	if err := h.repos.Settings.Set(r.Context(), h.org(r), domain.SettingTimeZone, req.TimeZone); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save time zone")
+		return
+	}
+	writeJSON(w, http.StatusOK, TimeZoneResponse{TimeZone: req.TimeZone})
+}
+
+// UpdateMyTimeZone sets the current user's time zone, overriding the organization's
+func (h *Handler) UpdateMyTimeZone(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUser(r.Context())
+	if user == nil {
+		writeError(w, http.StatusUnauthorized, "not authenticated")
+		return
+	}
+
+	var req TimeZoneRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var timeZone *string
+	if req.TimeZone != "" {
+		if !validTimeZone(req.TimeZone) {
+			writeError(w, http.StatusBadRequest, "unknown time zone")
+			return
+		}
+		timeZone = &req.TimeZone
+	}
+
+	if err := h.repos.Users.SetTimeZone(r.Context(), user.ID, timeZone); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save time zone")
+		return
+	}
+	writeJSON(w, http.StatusOK, TimeZoneResponse{TimeZone: req.TimeZone})
+}
+
+// orgTimeZone returns the organization time zone name ("" if not set)
+func (h *Handler) orgTimeZone(ctx context.Context, orgID uuid.UUID) (string, error) {
+	var name string
+	if _, err := h.repos.Settings.Get(ctx, orgID, domain.SettingTimeZone, &name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// location returns the time zone of the user of r: their own, else the
+// organization's, else UTC
+func (h *Handler) location(r *http.Request) *time.Location {
+	name := ""
+	if user := auth.GetUser(r.Context()); user != nil && user.TimeZone != nil {
+		name = *user.TimeZone
+	}
+	if name == "" && h.repos != nil {
+		orgName, err := h.orgTimeZone(r.Context(), h.org(r))
+		if err != nil {
+			slog.Error("failed to get organization time zone", "error", err)
+		}
+		name = orgName
+	}
+	return loadLocation(name)
+}
+
+// loadLocation returns the named time zone, falling back to UTC
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func validTimeZone(name string) bool {
+	// LoadLocation accepts "" and "Local", which are not portable zone names
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+// parseDate parses a date-only value ("2006-01-02") as midnight in loc
+func parseDate(s string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, loc)
+}
+
+// today returns midnight of the current day in loc
+func today(loc *time.Location) time.Time {
+	y, m, d := time.Now().In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_validTimeZone(t *testing.T) {
+	for _, name := range []string{"Europe/Lisbon", "America/New_York", "UTC"} {
+		if !validTimeZone(name) {
+			t.Errorf("expected %q to be valid", name)
+		}
+	}
+	for _, name := range []string{"", "Local", "Mars/Olympus", "../etc/passwd"} {
+		if validTimeZone(name) {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func Test_loadLocation_FallsBackToUTC(t *testing.T) {
+	if loc := loadLocation(""); loc != time.UTC {
+		t.Errorf("expected UTC for empty name, got %v", loc)
+	}
+	if loc := loadLocation("Mars/Olympus"); loc != time.UTC {
+		t.Errorf("expected UTC for unknown zone, got %v", loc)
+	}
+	if loc := loadLocation("Asia/Tokyo"); loc.String() != "Asia/Tokyo" {
+		t.Errorf("expected Asia/Tokyo, got %v", loc)
+	}
+}
+
+func Test_parseDate_MidnightInLocation(t *testing.T) {
+	loc := loadLocation("America/Los_Angeles")
+
+	d, err := parseDate("2025-01-15", loc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Year() != 2025 || d.Month() != time.January || d.Day() != 15 || d.Hour() != 0 {
+		t.Errorf("expected 2025-01-15 00:00 local, got %v", d)
+	}
+	if d.UTC().Hour() != 8 {
+		t.Errorf("expected 08:00 UTC, got %v", d.UTC())
+	}
+}
+
+func Test_today_UsesLocation(t *testing.T) {
+	loc := loadLocation("Pacific/Kiritimati") // UTC+14
+	now := time.Now().In(loc)
+
+	got := today(loc)
+
+	if got.Day() != now.Day() || got.Hour() != 0 || got.Location() != loc {
+		t.Errorf("expected midnight of %v, got %v", now, got)
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/lmmendes/attic/internal/auth"
+)
+
+// tokenModeContextKey marks logins of API clients, which get tokens instead
+// of the session cookie
+type tokenModeContextKey struct{}
+
+// RefreshTokenRequest exchanges a refresh token for new tokens
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Token logs in with email and password like Login, but returns an access
+// and a refresh token instead of setting the session cookie, for mobile apps
+// and CLIs. Accounts with 2FA continue with TokenTwoFactor.
+func (h *AuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	h.Login(w, withTokenMode(r))
+}
+
+// TokenTwoFactor completes a token login with a TOTP or backup code
+func (h *AuthHandler) TokenTwoFactor(w http.ResponseWriter, r *http.Request) {
+	h.LoginTwoFactor(w, withTokenMode(r))
+}
+
+// RefreshToken exchanges a refresh token for new tokens; the old refresh
+// token can't be used again
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	if h.oidcEnabled {
+		writeError(w, http.StatusBadRequest, "tokens are issued by the identity provider when OIDC is enabled")
+		return
+	}
+
+	var req RefreshTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.RefreshToken == "" {
+		writeError(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
+
+	tokens, err := h.sessionManager.RefreshTokens(r, req.RefreshToken)
+	switch {
+	case errors.Is(err, auth.ErrInvalidToken):
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	case errors.Is(err, auth.ErrTokensUnavailable):
+		writeError(w, http.StatusNotImplemented, err.Error())
+		return
+	case err != nil:
+		slog.Error("failed to refresh tokens", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+func withTokenMode(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), tokenModeContextKey{}, true))
+}
+
+// tokenMode reports whether a login returns tokens instead of the session cookie
+func tokenMode(r *http.Request) bool {
+	on, _ := r.Context().Value(tokenModeContextKey{}).(bool)
+	return on
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/auth"
+)
+
+func Test_AuthHandler_RefreshToken(t *testing.T) {
+	tests := map[string]struct {
+		oidc   bool
+		body   string
+		status int
+	}{
+		"oidc":            {oidc: true, body: `{"refresh_token":"abc"}`, status: http.StatusBadRequest},
+		"invalid body":    {body: `{`, status: http.StatusBadRequest},
+		"missing token":   {body: `{}`, status: http.StatusBadRequest},
+		"without a store": {body: `{"refresh_token":"abc"}`, status: http.StatusNotImplemented},
+	}
+	for name, tt := range tests {
+		h := NewAuthHandler(nil, auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24), 8, tt.oidc)
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(tt.body))
+		w := httptest.NewRecorder()
+		h.RefreshToken(w, req)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: expected %d, got %d: %s", name, tt.status, w.Code, w.Body.String())
+		}
+	}
+}
+
+func Test_TokenMode(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/auth/token", nil)
+	if tokenMode(req) {
+		t.Error("expected cookie mode by default")
+	}
+	if !tokenMode(withTokenMode(req)) {
+		t.Error("expected token mode")
+	}
+}
@@ -0,0 +1,497 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/secrets"
+)
+
+// totpIssuer names the account in authenticator apps
+const totpIssuer = "Attic"
+
+// SetTwoFactor enables TOTP enrollment (secrets are encrypted with box) and
+// the organization-wide 2FA requirements stored in settings
+func (h *AuthHandler) SetTwoFactor(settings domain.SettingsRepository, box *secrets.Box) {
+	h.settings = settings
+	h.secrets = box
+}
+
+// TwoFactorChallengeResponse is returned by login when a second step is needed
+type TwoFactorChallengeResponse struct {
+	Challenge         string `json:"challenge"`                     // Pass back with the next step
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"` // Complete with /auth/login/2fa
+	SetupRequired     bool   `json:"setup_required,omitempty"`      // Enroll with /auth/2fa/setup and /auth/2fa/enable
+}
+
+// TwoFactorLoginRequest completes a login with a TOTP or backup code
+type TwoFactorLoginRequest struct {
+	Challenge  string `json:"challenge"`
+	Code       string `json:"code,omitempty"`
+	BackupCode string `json:"backup_code,omitempty"`
+}
+
+// TwoFactorSetupRequest starts (or confirms) a TOTP enrollment. Signed-in users
+// omit the challenge; it is only needed to enroll during a login.
+type TwoFactorSetupRequest struct {
+	Challenge string `json:"challenge,omitempty"`
+	Code      string `json:"code,omitempty"` // Confirmation code (enable only)
+}
+
+// TwoFactorSetupResponse holds the secret to add to an authenticator app
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI, render as a QR code
+}
+
+// TwoFactorStatusResponse describes the 2FA state of the current user
+type TwoFactorStatusResponse struct {
+	Enabled              bool       `json:"enabled"`
+	EnabledAt            *time.Time `json:"enabled_at,omitempty"`
+	BackupCodesRemaining int        `json:"backup_codes_remaining"`
+	Required             bool       `json:"required"` // Required by the organization
+}
+
+// BackupCodesResponse lists newly generated backup codes; they are shown only once
+type BackupCodesResponse struct {
+	BackupCodes []string `json:"backup_codes"`
+}
+
+// TwoFactorCodeRequest carries a current TOTP code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// DisableTwoFactorRequest confirms disabling 2FA with the account password
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+}
+
+// LoginTwoFactor completes a login started with a password by checking a TOTP
+// or backup code
+func (h *AuthHandler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorLoginRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Code == "" && req.BackupCode == "" {
+		writeError(w, http.StatusBadRequest, "code or backup_code is required")
+		return
+	}
+
+	userID, err := h.sessionManager.VerifyChallenge(req.Challenge, auth.ChallengeTwoFactor)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	// Fresh challenges come with every password login, so codes are limited
+	// by user rather than by challenge or client
+	if !allowAttempt(w, h.codeLimit, "user:"+userID.String()) {
+		return
+	}
+	user, err := h.userRepo.GetByID(r.Context(), userID)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if user == nil || !user.IsActive() {
+		writeError(w, http.StatusUnauthorized, auth.ErrInvalidChallenge.Error())
+		return
+	}
+
+	var ok bool
+	if req.BackupCode != "" {
+		ok, err = h.userRepo.UseBackupCode(r.Context(), user.ID, auth.HashBackupCode(req.BackupCode))
+	} else {
+		ok, err = h.checkTOTP(r.Context(), user.ID, req.Code)
+	}
+	if err != nil {
+		slog.Error("failed to verify two-factor code", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !ok {
+		h.sessionManager.FailChallenge(req.Challenge)
+		h.recordLogin(r, user, user.Email, domain.LoginMethodPassword, loginFailureInvalidCode)
+		writeError(w, http.StatusUnauthorized, "invalid code")
+		return
+	}
+	// A challenge completes one login, also when answered twice at once
+	if !h.sessionManager.SpendChallenge(req.Challenge) {
+		writeError(w, http.StatusUnauthorized, auth.ErrInvalidChallenge.Error())
+		return
+	}
+
+	h.completeLogin(w, r, user, user.Email, domain.LoginMethodPassword, nil)
+}
+
+// GetTwoFactor returns the 2FA status of the signed-in user
+func (h *AuthHandler) GetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.sessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	totp, err := h.userRepo.GetTOTP(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get two-factor status")
+		return
+	}
+	required, err := h.twoFactorRequired(r.Context(), user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get two-factor policy")
+		return
+	}
+
+	response := TwoFactorStatusResponse{Required: required}
+	if totp.Enabled() {
+		response.Enabled = true
+		response.EnabledAt = totp.EnabledAt
+		response.BackupCodesRemaining = len(totp.BackupCodes)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// SetupTwoFactor generates a new TOTP secret for the user to add to an
+// authenticator app; the enrollment is pending until confirmed with EnableTwoFactor
+func (h *AuthHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if h.secrets == nil {
+		writeError(w, http.StatusServiceUnavailable, "encryption key not configured")
+		return
+	}
+
+	var req TwoFactorSetupRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	user, _, ok := h.enrollingUser(w, r, req.Challenge)
+	if !ok {
+		return
+	}
+
+	existing, err := h.userRepo.GetTOTP(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get two-factor status")
+		return
+	}
+	if existing.Enabled() {
+		writeError(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate secret")
+		return
+	}
+	encrypted, err := h.secrets.Encrypt(secret)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encrypt secret")
+		return
+	}
+	if err := h.userRepo.StartTOTP(r.Context(), user.ID, encrypted); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save two-factor secret")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: auth.TOTPProvisioningURI(totpIssuer, user.Email, secret),
+	})
+}
+
+// EnableTwoFactor confirms a pending enrollment with a code from the
+// authenticator app and returns the backup codes. When enrolling during a
+// login, the login is completed as well.
+func (h *AuthHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req TwoFactorSetupRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Code == "" {
+		writeError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+	user, duringLogin, ok := h.enrollingUser(w, r, req.Challenge)
+	if !ok {
+		return
+	}
+	if !allowAttempt(w, h.codeLimit, "user:"+user.ID.String()) {
+		return
+	}
+
+	totp, err := h.userRepo.GetTOTP(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get two-factor status")
+		return
+	}
+	if totp == nil {
+		writeError(w, http.StatusBadRequest, "two-factor setup has not been started")
+		return
+	}
+	if totp.Enabled() {
+		writeError(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+
+	valid, err := h.checkTOTP(r.Context(), user.ID, req.Code)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to verify code")
+		return
+	}
+	if !valid {
+		if duringLogin {
+			h.sessionManager.FailChallenge(req.Challenge)
+		}
+		writeError(w, http.StatusBadRequest, "invalid code")
+		return
+	}
+	// Like a login challenge, a setup challenge completes one login
+	if duringLogin && !h.sessionManager.SpendChallenge(req.Challenge) {
+		writeError(w, http.StatusUnauthorized, auth.ErrInvalidChallenge.Error())
+		return
+	}
+
+	codes, hashes, err := auth.GenerateBackupCodes()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate backup codes")
+		return
+	}
+	if err := h.userRepo.EnableTOTP(r.Context(), user.ID, hashes); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to enable two-factor authentication")
+		return
+	}
+
+	if duringLogin {
+		h.completeLogin(w, r, user, user.Email, domain.LoginMethodPassword, map[string]any{"backup_codes": codes})
+		return
+	}
+	writeJSON(w, http.StatusOK, BackupCodesResponse{BackupCodes: codes})
+}
+
+// RegenerateBackupCodes replaces the backup codes of the signed-in user,
+// confirmed with a current TOTP code
+func (h *AuthHandler) RegenerateBackupCodes(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.sessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	totp, err := h.userRepo.GetTOTP(r.Context(), user.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get two-factor status")
+		return
+	}
+	if !totp.Enabled() {
+		writeError(w, http.StatusBadRequest, "two-factor authentication is not enabled")
+		return
+	}
+
+	valid, err := h.checkTOTP(r.Context(), user.ID, req.Code)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to verify code")
+		return
+	}
+	if !valid {
+		writeError(w, http.StatusBadRequest, "invalid code")
+		return
+	}
+
+	codes, hashes, err := auth.GenerateBackupCodes()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate backup codes")
+		return
+	}
+	if err := h.userRepo.SetBackupCodes(r.Context(), user.ID, hashes); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save backup codes")
+		return
+	}
+	writeJSON(w, http.StatusOK, BackupCodesResponse{BackupCodes: codes})
+}
+
+// DisableTwoFactor removes the TOTP enrollment of the signed-in user, unless
+// the organization requires 2FA
+func (h *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.sessionUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req DisableTwoFactorRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !user.HasPassword() || !auth.CheckPassword(req.Password, *user.PasswordHash) {
+		writeError(w, http.StatusUnauthorized, "password is incorrect")
+		return
+	}
+
+	required, err := h.twoFactorRequired(r.Context(), user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get two-factor policy")
+		return
+	}
+	if required {
+		writeError(w, http.StatusForbidden, "two-factor authentication is required by your organization")
+		return
+	}
+
+	if err := h.userRepo.DeleteTOTP(r.Context(), user.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to disable two-factor authentication")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// secondFactor returns the login step a user must complete after the
+// password, or "" if the password is enough
+func (h *AuthHandler) secondFactor(ctx context.Context, user *domain.User) (auth.ChallengePurpose, error) {
+	totp, err := h.userRepo.GetTOTP(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if totp.Enabled() {
+		return auth.ChallengeTwoFactor, nil
+	}
+
+	required, err := h.twoFactorRequired(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	if required {
+		return auth.ChallengeTwoFactorSetup, nil
+	}
+	return "", nil
+}
+
+func (h *AuthHandler) writeChallenge(w http.ResponseWriter, user *domain.User, purpose auth.ChallengePurpose) {
+	token, err := h.sessionManager.CreateChallenge(user.ID, purpose)
+	if err != nil {
+		slog.Error("failed to create login challenge", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	writeJSON(w, http.StatusOK, TwoFactorChallengeResponse{
+		Challenge:         token,
+		TwoFactorRequired: purpose == auth.ChallengeTwoFactor,
+		SetupRequired:     purpose == auth.ChallengeTwoFactorSetup,
+	})
+}
+
+// twoFactorRequired reports whether the user's organization requires 2FA
+func (h *AuthHandler) twoFactorRequired(ctx context.Context, user *domain.User) (bool, error) {
+	if h.settings == nil {
+		return false, nil
+	}
+	var policy domain.TwoFactorPolicy
+	if _, err := h.settings.Get(ctx, user.OrganizationID, domain.SettingTwoFactor, &policy); err != nil {
+		return false, err
+	}
+	return policy.Required, nil
+}
+
+// checkTOTP verifies a code against the user's secret and consumes its time
+// step, so a code cannot be used twice
+func (h *AuthHandler) checkTOTP(ctx context.Context, userID uuid.UUID, code string) (bool, error) {
+	if h.secrets == nil {
+		return false, errors.New("encryption key not configured")
+	}
+	totp, err := h.userRepo.GetTOTP(ctx, userID)
+	if err != nil || totp == nil {
+		return false, err
+	}
+	secret, err := h.secrets.Decrypt(totp.Secret)
+	if err != nil {
+		return false, err
+	}
+
+	counter, ok := auth.MatchTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return h.userRepo.UseTOTPCounter(ctx, userID, counter)
+}
+
+// sessionUser returns the signed-in local user, writing an error response if
+// there is none
+func (h *AuthHandler) sessionUser(w http.ResponseWriter, r *http.Request) (*domain.User, bool) {
+	if h.oidcEnabled {
+		writeError(w, http.StatusBadRequest, "sign-in methods are managed by the identity provider when OIDC is enabled")
+		return nil, false
+	}
+
+	session, err := h.sessionManager.GetSession(r)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return nil, false
+	}
+	user, err := h.userRepo.GetByID(r.Context(), session.UserID)
+	if err != nil || user == nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return nil, false
+	}
+	return user, true
+}
+
+// enrollingUser returns the user enrolling in 2FA: the user of a setup
+// challenge issued at login (duringLogin), or else the signed-in user
+func (h *AuthHandler) enrollingUser(w http.ResponseWriter, r *http.Request, challenge string) (user *domain.User, duringLogin, ok bool) {
+	if challenge == "" {
+		user, ok := h.sessionUser(w, r)
+		return user, false, ok
+	}
+
+	userID, err := h.sessionManager.VerifyChallenge(challenge, auth.ChallengeTwoFactorSetup)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return nil, false, false
+	}
+	user, err = h.userRepo.GetByID(r.Context(), userID)
+	if err != nil || user == nil || !user.IsActive() {
+		writeError(w, http.StatusUnauthorized, auth.ErrInvalidChallenge.Error())
+		return nil, false, false
+	}
+	return user, true, true
+}
+
+// GetTwoFactorPolicy returns the organization's two-factor policy (admin only)
+func (h *Handler) GetTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy domain.TwoFactorPolicy
+	if _, err := h.repos.Settings.Get(r.Context(), h.org(r), domain.SettingTwoFactor, &policy); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get two-factor policy")
+		return
+	}
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// UpdateTwoFactorPolicy sets whether local accounts must use two-factor
+// authentication (admin only). Users without 2FA enroll at their next login.
+func (h *Handler) UpdateTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy domain.TwoFactorPolicy
+	if err := decodeJSON(r, &policy); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := h.repos.Settings.Set(r.Context(), h.org(r), domain.SettingTwoFactor, policy); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save two-factor policy")
+		return
+	}
+	writeJSON(w, http.StatusOK, policy)
+}
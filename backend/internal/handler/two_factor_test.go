@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/auth"
+	"github.com/lmmendes/attic/internal/ratelimit"
+)
+
+func Test_Login_PasswordLimitByAccount(t *testing.T) {
+	passwords := ratelimit.New(1, time.Minute)
+	passwords.Allow("email:alice@example.com")
+	h := NewAuthHandler(nil, auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24), 8, false)
+	h.SetLoginLimits(passwords, nil)
+
+	rr := httptest.NewRecorder()
+	body := `{"email": " Alice@Example.com", "password": "guess"}`
+	h.Login(rr, httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body)))
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", rr.Code)
+	}
+}
+
+func Test_LoginTwoFactor_CodeLimitByUser(t *testing.T) {
+	sessions := auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	userID := uuid.New()
+	codes := ratelimit.New(1, time.Minute)
+	codes.Allow("user:" + userID.String())
+	h := NewAuthHandler(nil, sessions, 8, false)
+	h.SetLoginLimits(nil, codes)
+
+	// A fresh challenge from another password login doesn't reset the limit
+	challenge, err := sessions.CreateChallenge(userID, auth.ChallengeTwoFactor)
+	if err != nil {
+		t.Fatalf("CreateChallenge: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	body := `{"challenge": "` + challenge + `", "code": "123456"}`
+	h.LoginTwoFactor(rr, httptest.NewRequest(http.MethodPost, "/auth/login/2fa", strings.NewReader(body)))
+
+	if rr.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", rr.Code)
+	}
+}
+
+func Test_EnableTwoFactor_SpentSetupChallenge(t *testing.T) {
+	sessions := auth.NewSessionManager("test-secret-key-32-bytes-long!!", 24)
+	challenge, err := sessions.CreateChallenge(uuid.New(), auth.ChallengeTwoFactorSetup)
+	if err != nil {
+		t.Fatalf("CreateChallenge: %v", err)
+	}
+	// Spent by an earlier enrollment, or by wrong codes
+	sessions.SpendChallenge(challenge)
+	h := NewAuthHandler(nil, sessions, 8, false)
+
+	rr := httptest.NewRecorder()
+	body := `{"challenge": "` + challenge + `", "code": "123456"}`
+	h.EnableTwoFactor(rr, httptest.NewRequest(http.MethodPost, "/auth/2fa/enable", strings.NewReader(body)))
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", rr.Code)
+	}
+}
@@ -10,6 +10,7 @@ type CurrentUserResponse struct {
 	ID          string  `json:"id"`
 	Email       string  `json:"email"`
 	DisplayName *string `json:"display_name,omitempty"`
+	TimeZone    string  `json:"time_zone"` // Effective IANA time zone (user, organization or UTC)
 }
 
 func (h *Handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
@@ -23,6 +24,7 @@ func (h *Handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
 		ID:          user.ID.String(),
 		Email:       user.Email,
 		DisplayName: user.DisplayName,
+		TimeZone:    h.location(r).String(),
 	}
 
 	writeJSON(w, http.StatusOK, response)
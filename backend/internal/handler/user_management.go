@@ -2,8 +2,10 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -14,22 +16,48 @@ import (
 
 // UserManagementHandler handles user management endpoints (admin only)
 type UserManagementHandler struct {
-	userRepo          *repository.UserRepository
-	sessionManager    *auth.SessionManager
-	passwordMinLength int
-	defaultOrgID      uuid.UUID
+	userRepo       *repository.UserRepository
+	sessionManager *auth.SessionManager
+	passwordPolicy auth.PasswordPolicy
+	defaultOrgID   uuid.UUID
+	roles          *repository.RoleRepository         // Custom roles users can be assigned
+	verifier       *EmailVerifier                     // nil = addresses are not verified
+	audit          *LoginAudit                        // nil = password resets are not recorded
+	orgs           *repository.OrganizationRepository // nil = organizations cannot be created
 }
 
 // NewUserManagementHandler creates a new user management handler
 func NewUserManagementHandler(userRepo *repository.UserRepository, sessionManager *auth.SessionManager, passwordMinLength int, defaultOrgID uuid.UUID) *UserManagementHandler {
 	return &UserManagementHandler{
-		userRepo:          userRepo,
-		sessionManager:    sessionManager,
-		passwordMinLength: passwordMinLength,
-		defaultOrgID:      defaultOrgID,
+		userRepo:       userRepo,
+		sessionManager: sessionManager,
+		passwordPolicy: auth.PasswordPolicy{MinLength: passwordMinLength},
+		defaultOrgID:   defaultOrgID,
 	}
 }
 
+// org returns the organization whose users the request manages
+func (h *UserManagementHandler) org(r *http.Request) uuid.UUID {
+	return requestOrg(r, h.defaultOrgID)
+}
+
+// SetPasswordPolicy sets the requirements enforced for new passwords
+func (h *UserManagementHandler) SetPasswordPolicy(policy auth.PasswordPolicy) {
+	h.passwordPolicy = policy
+}
+
+// SetLoginAudit enables recording of password resets in the authentication
+// audit log
+func (h *UserManagementHandler) SetLoginAudit(audit *LoginAudit) {
+	h.audit = audit
+}
+
+// SetRoles sets the repository of custom roles; without it only the built-in
+// roles can be assigned
+func (h *UserManagementHandler) SetRoles(roles *repository.RoleRepository) {
+	h.roles = roles
+}
+
 // RequireAdmin middleware checks if user is admin
 func (h *UserManagementHandler) RequireAdmin(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -56,6 +84,10 @@ type UserResponse struct {
 	Role        string  `json:"role"`
 	HasPassword bool    `json:"has_password"`
 	HasOIDC     bool    `json:"has_oidc"`
+	Active      bool    `json:"active"`
+	Verified    bool    `json:"verified"`
+	Pending     bool    `json:"pending"` // Self-registered, awaiting approval
+	Service     bool    `json:"service_account"`
 	CreatedAt   string  `json:"created_at"`
 }
 
@@ -67,13 +99,17 @@ func toUserResponse(u *domain.User) UserResponse {
 		Role:        string(u.Role),
 		HasPassword: u.HasPassword(),
 		HasOIDC:     u.OIDCSubject != nil && *u.OIDCSubject != "",
+		Active:      u.IsActive(),
+		Verified:    u.IsVerified(),
+		Pending:     u.IsPending(),
+		Service:     u.ServiceAccount,
 		CreatedAt:   u.CreatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 }
 
 // ListUsers returns all users
 func (h *UserManagementHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.userRepo.List(r.Context(), h.defaultOrgID)
+	users, err := h.userRepo.List(r.Context(), h.org(r))
 	if err != nil {
 		slog.Error("failed to list users", "error", err)
 		writeError(w, http.StatusInternalServerError, "internal server error")
@@ -140,11 +176,19 @@ func (h *UserManagementHandler) CreateUser(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := auth.ValidatePassword(req.Password, h.passwordMinLength); err != nil {
+	if err := h.passwordPolicy.Validate(req.Password); err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	role := domain.UserRoleUser
+	if req.Role != "" {
+		if !h.validRole(w, r, domain.UserRole(req.Role)) {
+			return
+		}
+		role = domain.UserRole(req.Role)
+	}
+
 	// Check if email already exists
 	existing, err := h.userRepo.GetByEmail(r.Context(), req.Email)
 	if err != nil {
@@ -164,13 +208,8 @@ func (h *UserManagementHandler) CreateUser(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	role := domain.UserRoleUser
-	if req.Role == "admin" {
-		role = domain.UserRoleAdmin
-	}
-
 	user := &domain.User{
-		OrganizationID: h.defaultOrgID,
+		OrganizationID: h.org(r),
 		Email:          req.Email,
 		PasswordHash:   &hash,
 		Role:           role,
@@ -179,12 +218,22 @@ func (h *UserManagementHandler) CreateUser(w http.ResponseWriter, r *http.Reques
 		user.DisplayName = &req.Name
 	}
 
+	if h.verifier == nil {
+		// Nobody can confirm the address, trust the admin
+		now := time.Now()
+		user.VerifiedAt = &now
+	}
+
 	if err := h.userRepo.Create(r.Context(), user); err != nil {
 		slog.Error("failed to create user", "error", err)
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
+	if h.verifier != nil {
+		h.verifier.Send(user)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(toUserResponse(user))
@@ -237,6 +286,10 @@ func (h *UserManagementHandler) UpdateUser(w http.ResponseWriter, r *http.Reques
 			return
 		}
 		user.Email = req.Email
+		if h.verifier != nil {
+			// The new address needs verifying again
+			user.VerifiedAt = nil
+		}
 	}
 
 	if req.Name != "" {
@@ -244,11 +297,10 @@ func (h *UserManagementHandler) UpdateUser(w http.ResponseWriter, r *http.Reques
 	}
 
 	if req.Role != "" {
-		if req.Role == "admin" {
-			user.Role = domain.UserRoleAdmin
-		} else {
-			user.Role = domain.UserRoleUser
+		if !h.validRole(w, r, domain.UserRole(req.Role)) {
+			return
 		}
+		user.Role = domain.UserRole(req.Role)
 	}
 
 	if err := h.userRepo.Update(r.Context(), user); err != nil {
@@ -257,6 +309,10 @@ func (h *UserManagementHandler) UpdateUser(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	if h.verifier != nil {
+		h.verifier.Send(user)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(toUserResponse(user))
 }
@@ -327,7 +383,7 @@ func (h *UserManagementHandler) ResetPassword(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if err := auth.ValidatePassword(req.Password, h.passwordMinLength); err != nil {
+	if err := h.passwordPolicy.Validate(req.Password); err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -343,6 +399,20 @@ func (h *UserManagementHandler) ResetPassword(w http.ResponseWriter, r *http.Req
 		writeError(w, http.StatusNotFound, "user not found")
 		return
 	}
+	if user.ServiceAccount {
+		writeError(w, http.StatusBadRequest, "service accounts authenticate with tokens only")
+		return
+	}
+
+	if err := checkPasswordReuse(r.Context(), h.userRepo, h.passwordPolicy, user, req.Password); err != nil {
+		if errors.Is(err, auth.ErrPasswordReused) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.Error("failed to check password history", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
 
 	hash, err := auth.HashPassword(req.Password)
 	if err != nil {
@@ -357,6 +427,83 @@ func (h *UserManagementHandler) ResetPassword(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// The administrator knows the new password, so the user must replace it,
+	// and browsers signed in with the old one are signed out
+	if err := h.userRepo.RequirePasswordChange(r.Context(), id); err != nil {
+		slog.Error("failed to require password change", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if _, err := h.userRepo.DeleteSessions(r.Context(), id, uuid.Nil); err != nil {
+		slog.Error("failed to revoke sessions", "error", err)
+	}
+	if h.audit != nil {
+		h.audit.RecordEvent(r, user, domain.AuthEventPasswordReset)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{"success": true})
 }
+
+// ExpireSessions signs a user out of all browsers (admin only)
+func (h *UserManagementHandler) ExpireSessions(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	revoked, err := h.userRepo.DeleteSessions(r.Context(), id, uuid.Nil)
+	if err != nil {
+		slog.Error("failed to revoke sessions", "error", err)
+		writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"revoked": revoked})
+}
+
+// ResetTwoFactor removes a user's TOTP enrollment, e.g. after losing their
+// authenticator device. If the organization requires 2FA they enroll again
+// at their next login.
+func (h *UserManagementHandler) ResetTwoFactor(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(r.Context(), id)
+	if err != nil {
+		slog.Error("failed to get user", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if user == nil || user.OrganizationID != h.org(r) {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if err := h.userRepo.DeleteTOTP(r.Context(), id); err != nil {
+		slog.Error("failed to reset two-factor authentication", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validRole reports whether role is a built-in or custom role, writing an
+// error response if it is not
+func (h *UserManagementHandler) validRole(w http.ResponseWriter, r *http.Request, role domain.UserRole) bool {
+	exists, err := roleExists(r.Context(), h.roles, h.org(r), role)
+	if err != nil {
+		slog.Error("failed to get role", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return false
+	}
+	if !exists {
+		writeError(w, http.StatusBadRequest, "unknown role: "+string(role))
+		return false
+	}
+	return true
+}
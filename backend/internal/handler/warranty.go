@@ -2,8 +2,8 @@ package handler
 
 import (
 	"net/http"
+	"slices"
 	"strconv"
-	"time"
 
 	"github.com/lmmendes/attic/internal/domain"
 )
@@ -23,13 +23,12 @@ type UpdateWarrantyRequest struct {
 }
 
 func (h *Handler) GetWarranty(w http.ResponseWriter, r *http.Request) {
-	assetID, err := parseUUID(r, "id")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid asset ID")
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
 		return
 	}
 
-	warranty, err := h.repos.Warranties.GetByAssetID(r.Context(), assetID)
+	warranty, err := h.repos.Warranties.GetByAssetID(r.Context(), asset.ID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to get warranty")
 		return
@@ -43,11 +42,19 @@ func (h *Handler) GetWarranty(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) ListWarranties(w http.ResponseWriter, r *http.Request) {
-	warranties, err := h.repos.Warranties.List(r.Context(), h.orgID)
+	warranties, err := h.repos.Warranties.List(r.Context(), h.org(r))
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list warranties")
 		return
 	}
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if hide {
+		warranties = slices.DeleteFunc(warranties, func(item domain.WarrantyWithAsset) bool { return item.HighValue })
+	}
 
 	if warranties == nil {
 		warranties = []domain.WarrantyWithAsset{}
@@ -62,11 +69,21 @@ func (h *Handler) ListExpiringWarranties(w http.ResponseWriter, r *http.Request)
 		days = 30 // Default to 30 days
 	}
 
-	warranties, err := h.repos.Warranties.ListExpiring(r.Context(), h.orgID, days)
+	// "Within N days" counts calendar days in the user's time zone
+	until := today(h.location(r)).AddDate(0, 0, days)
+	warranties, err := h.repos.Warranties.ListExpiringBefore(r.Context(), h.org(r), until)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to list warranties")
 		return
 	}
+	hide, err := h.hidesHighValue(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to get high-value policy")
+		return
+	}
+	if hide {
+		warranties = withoutHighValueWarranties(warranties)
+	}
 
 	if warranties == nil {
 		warranties = []domain.Warranty{}
@@ -76,25 +93,13 @@ func (h *Handler) ListExpiringWarranties(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *Handler) CreateWarranty(w http.ResponseWriter, r *http.Request) {
-	assetID, err := parseUUID(r, "id")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid asset ID")
-		return
-	}
-
-	// Check if asset exists
-	asset, err := h.repos.Assets.GetByID(r.Context(), assetID)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to check asset")
-		return
-	}
-	if asset == nil {
-		writeError(w, http.StatusNotFound, "asset not found")
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
 		return
 	}
 
 	// Check if warranty already exists
-	existing, err := h.repos.Warranties.GetByAssetID(r.Context(), assetID)
+	existing, err := h.repos.Warranties.GetByAssetID(r.Context(), asset.ID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to check existing warranty")
 		return
@@ -111,18 +116,18 @@ func (h *Handler) CreateWarranty(w http.ResponseWriter, r *http.Request) {
 	}
 
 	warranty := &domain.Warranty{
-		AssetID:  assetID,
+		AssetID:  asset.ID,
 		Provider: req.Provider,
 		Notes:    req.Notes,
 	}
 
 	if req.StartDate != nil {
-		if t, err := time.Parse("2006-01-02", *req.StartDate); err == nil {
+		if t, err := parseDate(*req.StartDate, h.location(r)); err == nil {
 			warranty.StartDate = &t
 		}
 	}
 	if req.EndDate != nil {
-		if t, err := time.Parse("2006-01-02", *req.EndDate); err == nil {
+		if t, err := parseDate(*req.EndDate, h.location(r)); err == nil {
 			warranty.EndDate = &t
 		}
 	}
@@ -136,13 +141,12 @@ func (h *Handler) CreateWarranty(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UpdateWarranty(w http.ResponseWriter, r *http.Request) {
-	assetID, err := parseUUID(r, "id")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid asset ID")
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
 		return
 	}
 
-	warranty, err := h.repos.Warranties.GetByAssetID(r.Context(), assetID)
+	warranty, err := h.repos.Warranties.GetByAssetID(r.Context(), asset.ID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to get warranty")
 		return
@@ -162,14 +166,14 @@ func (h *Handler) UpdateWarranty(w http.ResponseWriter, r *http.Request) {
 	warranty.Notes = req.Notes
 
 	if req.StartDate != nil {
-		if t, err := time.Parse("2006-01-02", *req.StartDate); err == nil {
+		if t, err := parseDate(*req.StartDate, h.location(r)); err == nil {
 			warranty.StartDate = &t
 		}
 	} else {
 		warranty.StartDate = nil
 	}
 	if req.EndDate != nil {
-		if t, err := time.Parse("2006-01-02", *req.EndDate); err == nil {
+		if t, err := parseDate(*req.EndDate, h.location(r)); err == nil {
 			warranty.EndDate = &t
 		}
 	} else {
@@ -185,16 +189,20 @@ func (h *Handler) UpdateWarranty(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteWarranty(w http.ResponseWriter, r *http.Request) {
-	assetID, err := parseUUID(r, "id")
-	if err != nil {
-		writeError(w, http.StatusBadRequest, "invalid asset ID")
+	asset, ok := h.visibleAsset(w, r)
+	if !ok {
 		return
 	}
 
-	if err := h.repos.Warranties.Delete(r.Context(), assetID); err != nil {
+	if err := h.repos.Warranties.Delete(r.Context(), asset.ID); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to delete warranty")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// withoutHighValueWarranties removes the warranties of high-value assets
+func withoutHighValueWarranties(warranties []domain.Warranty) []domain.Warranty {
+	return slices.DeleteFunc(warranties, func(w domain.Warranty) bool { return w.HighValue })
+}
@@ -0,0 +1,49 @@
+// Package history records the change history of assets from asset events.
+package history
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+)
+
+// Store persists history entries
+type Store interface {
+	Create(ctx context.Context, e *domain.AssetEvent) error
+}
+
+// Recorder stores asset events and their field-level changes
+type Recorder struct {
+	store Store
+}
+
+// NewRecorder creates a recorder; subscribe HandleEvent to the event bus
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store}
+}
+
+// HandleEvent records an asset event with its changes. Every event is
+// recorded, even without changes, as the store keeps the asset's state after
+// it to revert to. Failures are logged: history must not fail the change
+// itself.
+func (r *Recorder) HandleEvent(ctx context.Context, e events.Event) {
+	switch e.Type {
+	case events.AssetCreated, events.AssetUpdated, events.AssetDeleted:
+	default:
+		return
+	}
+
+	entry := &domain.AssetEvent{
+		OrganizationID: e.OrganizationID,
+		AssetID:        e.SubjectID,
+		Type:           string(e.Type),
+		ActorID:        e.ActorID,
+		Changes:        e.Changes,
+	}
+	// The request may be over by the time slow subscribers are done
+	if err := r.store.Create(context.WithoutCancel(ctx), entry); err != nil {
+		slog.Error("failed to record asset history", "asset_id", e.SubjectID, "type", e.Type, "error", err)
+	}
+}
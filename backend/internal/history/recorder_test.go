@@ -0,0 +1,60 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+)
+
+type fakeStore struct {
+	entries []domain.AssetEvent
+	err     error
+}
+
+func (s *fakeStore) Create(ctx context.Context, e *domain.AssetEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.entries = append(s.entries, *e)
+	return nil
+}
+
+func Test_Recorder_HandleEvent(t *testing.T) {
+	changes := []domain.AssetChange{{Field: "attributes.serial", Old: json.RawMessage(`"A1"`), New: json.RawMessage(`"A2"`)}}
+	actor := uuid.New()
+	tests := map[string]struct {
+		event events.Event
+		want  int
+	}{
+		"update with changes":    {event: events.Event{Type: events.AssetUpdated, SubjectID: uuid.New(), ActorID: &actor, Changes: changes}, want: 1},
+		"update without changes": {event: events.Event{Type: events.AssetUpdated, SubjectID: uuid.New(), ActorID: &actor}, want: 1},
+		"other event type":       {event: events.Event{Type: "list.updated", SubjectID: uuid.New(), Changes: changes}},
+	}
+	for name, tt := range tests {
+		store := &fakeStore{}
+		NewRecorder(store).HandleEvent(context.Background(), tt.event)
+
+		if len(store.entries) != tt.want {
+			t.Fatalf("%s: expected %d entries, got %d", name, tt.want, len(store.entries))
+		}
+		if tt.want == 1 {
+			got := store.entries[0]
+			if got.AssetID != tt.event.SubjectID || got.Type != "asset.updated" || got.ActorID != &actor || len(got.Changes) != len(tt.event.Changes) {
+				t.Errorf("%s: unexpected entry %+v", name, got)
+			}
+		}
+	}
+}
+
+func Test_Recorder_HandleEvent_StoreFailureIsLogged(t *testing.T) {
+	store := &fakeStore{err: errors.New("down")}
+	NewRecorder(store).HandleEvent(context.Background(), events.Event{
+		Type:    events.AssetUpdated,
+		Changes: []domain.AssetChange{{Field: "attributes.serial"}},
+	})
+}
@@ -0,0 +1,60 @@
+package imaging
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Format is a compact image format photos can be converted to
+type Format string
+
+const (
+	FormatWebP Format = "webp"
+	FormatAVIF Format = "avif"
+)
+
+// ParseFormat returns the format of a configuration value ("" = none)
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "", FormatWebP, FormatAVIF:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid image format %q (webp or avif)", s)
+	}
+}
+
+// ContentType returns the MIME type of the format ("" if unknown)
+func (f Format) ContentType() string {
+	switch f {
+	case FormatWebP:
+		return "image/webp"
+	case FormatAVIF:
+		return "image/avif"
+	}
+	return ""
+}
+
+// ConvertOptions configures the conversion of a photo
+type ConvertOptions struct {
+	Format  Format
+	Quality int // 1-100
+	MaxSize int // Longest edge in pixels; larger images are scaled down
+}
+
+// Converter re-encodes photos in a more compact format. Only the libvips
+// processor can, as the standard library has no WebP or AVIF encoder.
+type Converter interface {
+	// Convert returns a JPEG or PNG image in opts.Format, applying the EXIF
+	// orientation; it returns ErrUnsupported for other images
+	Convert(ctx context.Context, data []byte, contentType string, opts ConvertOptions) (*Image, error)
+}
+
+// AsConverter returns the converter of a processor, if it can convert images
+func AsConverter(p Processor) (Converter, bool) {
+	if f, ok := p.(*fallback); ok {
+		p = f.native
+	}
+	c, ok := p.(Converter)
+	return c, ok
+}
@@ -0,0 +1,67 @@
+package imaging
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_ParseFormat(t *testing.T) {
+	tests := map[string]Format{"": "", "webp": FormatWebP, "AVIF": FormatAVIF}
+	for in, want := range tests {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFormat("jxl"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func Test_AsConverter(t *testing.T) {
+	if _, ok := AsConverter(&goProcessor{}); ok {
+		t.Error("the Go processor has no WebP encoder")
+	}
+	native := &vipsProcessor{path: "vipsthumbnail"}
+	if c, ok := AsConverter(&fallback{native: native, pure: &goProcessor{}}); !ok || c != native {
+		t.Errorf("expected the native processor of a fallback, got %v", c)
+	}
+}
+
+// fakeVips installs a vipsthumbnail that records its arguments and writes
+// "converted" to the output file
+func fakeVips(t *testing.T) (*vipsProcessor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	args := filepath.Join(dir, "args")
+	script := "#!/bin/sh\necho \"$@\" > " + args + "\nout=\"$5\"\nprintf converted > \"${out%%[*}\"\n"
+	path := filepath.Join(dir, vipsThumbnailCommand)
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return &vipsProcessor{path: path}, args
+}
+
+func Test_vipsProcessor_Convert(t *testing.T) {
+	p, argsFile := fakeVips(t)
+	opts := ConvertOptions{Format: FormatWebP, Quality: 75, MaxSize: 2000}
+
+	img, err := p.Convert(context.Background(), []byte("jpeg"), "image/jpeg", opts)
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if string(img.Data) != "converted" || img.ContentType != "image/webp" {
+		t.Errorf("unexpected image %q (%s)", img.Data, img.ContentType)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if !strings.Contains(string(args), "--size 2000x2000>") || !strings.Contains(string(args), "converted.webp[Q=75,strip]") {
+		t.Errorf("unexpected vipsthumbnail arguments %q", args)
+	}
+
+	if _, err := p.Convert(context.Background(), nil, "image/gif", opts); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported for GIF, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package imaging
+
+import (
+	"context"
+	"errors"
+	"image"
+)
+
+// ErrInvalidEdit is returned for rotations other than multiples of 90° and
+// crops outside the image
+var ErrInvalidEdit = errors.New("invalid image edit")
+
+// Edit rotates an image and then crops it
+type Edit struct {
+	Rotate int             // Clockwise degrees: 0, 90, 180 or 270
+	Crop   image.Rectangle // In pixels of the upright, rotated image; empty = no crop
+}
+
+// rotateOrientation maps clockwise rotations to the EXIF orientation that applies them
+var rotateOrientation = map[int]int{0: 1, 90: 6, 180: 3, 270: 8}
+
+// Apply returns the image upright (EXIF orientation applied), rotated and
+// cropped by edit. It always uses the pure Go decoders.
+func Apply(ctx context.Context, data []byte, contentType string, edit Edit) (*Image, error) {
+	if !(&goProcessor{}).Supports(contentType) {
+		return nil, ErrUnsupported
+	}
+	rotation, ok := rotateOrientation[edit.Rotate]
+	if !ok {
+		return nil, ErrInvalidEdit
+	}
+
+	img, format, orientation, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := orient(orient(toNRGBA(img), orientation), rotation)
+	if !edit.Crop.Empty() {
+		if !edit.Crop.In(out.Rect) {
+			return nil, ErrInvalidEdit
+		}
+		out = out.SubImage(edit.Crop).(*image.NRGBA)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return encode(out, format)
+}
@@ -0,0 +1,107 @@
+// Package imaging generates thumbnails of uploaded images.
+//
+// The default processor is written in pure Go (standard library decoders),
+// so ARM and Alpine builds work with CGO_ENABLED=0. Deployments that have
+// libvips installed can prefer its vipsthumbnail command, which is faster
+// and reads more formats; images it fails on fall back to the Go processor.
+package imaging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"mime"
+	"os/exec"
+)
+
+// MaxInputSize is the largest image read for a thumbnail
+const MaxInputSize = 32 << 20
+
+// MaxPixels is the largest image (width × height) decoded by the Go processor
+const MaxPixels = 50_000_000
+
+// MaxThumbnailSize is the largest thumbnail edge in pixels
+const MaxThumbnailSize = 1024
+
+// ErrUnsupported is returned for images whose format cannot be processed
+var ErrUnsupported = errors.New("unsupported image type")
+
+// Image is an encoded image
+type Image struct {
+	Data        []byte
+	ContentType string
+}
+
+// Processor scales images down to thumbnails, applying the EXIF orientation
+type Processor interface {
+	// Name identifies the processor in logs
+	Name() string
+
+	// Supports reports whether images with the given content type can be processed
+	Supports(contentType string) bool
+
+	// Thumbnail returns the image scaled to fit a size × size box. Images
+	// that already fit are not enlarged.
+	Thumbnail(ctx context.Context, data []byte, contentType string, size int) (*Image, error)
+}
+
+// New returns the pure Go processor, or the libvips processor (with the Go
+// processor as fallback) when preferNative is set and vipsthumbnail is installed
+func New(preferNative bool) Processor {
+	pure := &goProcessor{}
+	if !preferNative {
+		return pure
+	}
+	path, err := exec.LookPath(vipsThumbnailCommand)
+	if err != nil {
+		slog.Warn("native image processing unavailable, using pure Go", "error", err)
+		return pure
+	}
+	return &fallback{native: &vipsProcessor{path: path}, pure: pure}
+}
+
+// fallback uses the native processor and retries failed images with the Go one
+type fallback struct {
+	native Processor
+	pure   Processor
+}
+
+func (f *fallback) Name() string {
+	return f.native.Name()
+}
+
+func (f *fallback) Supports(contentType string) bool {
+	return f.native.Supports(contentType) || f.pure.Supports(contentType)
+}
+
+func (f *fallback) Thumbnail(ctx context.Context, data []byte, contentType string, size int) (*Image, error) {
+	if f.native.Supports(contentType) {
+		thumb, err := f.native.Thumbnail(ctx, data, contentType, size)
+		if err == nil || !f.pure.Supports(contentType) {
+			return thumb, err
+		}
+		slog.Warn("native thumbnail failed, using pure Go", "processor", f.native.Name(), "error", err)
+	}
+	return f.pure.Thumbnail(ctx, data, contentType, size)
+}
+
+// mediaType returns the content type without parameters
+func mediaType(contentType string) string {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return t
+}
+
+// fit returns the dimensions of a width × height image scaled down to fit
+// a size × size box, keeping the aspect ratio
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
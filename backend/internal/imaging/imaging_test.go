@@ -0,0 +1,224 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// encodeJPEG encodes img with an EXIF segment holding orientation
+func encodeJPEG(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("failed to encode JPEG: %v", err)
+	}
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], exifOrientationTag)
+	binary.BigEndian.PutUint16(entry[2:], 3) // SHORT
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(append(tiff, entry...), 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(append(out, app1...), segment...)
+	return append(out, data[2:]...)
+}
+
+func decodeSize(t *testing.T, thumb *Image) (int, int) {
+	t.Helper()
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(thumb.Data))
+	if err != nil {
+		t.Fatalf("failed to decode thumbnail: %v", err)
+	}
+	return cfg.Width, cfg.Height
+}
+
+func Test_goProcessor_Thumbnail_KeepsAspectRatio(t *testing.T) {
+	p := &goProcessor{}
+
+	thumb, err := p.Thumbnail(context.Background(), encodePNG(t, testImage(400, 200)), "image/png", 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if thumb.ContentType != "image/png" {
+		t.Errorf("expected image/png, got %s", thumb.ContentType)
+	}
+	if w, h := decodeSize(t, thumb); w != 100 || h != 50 {
+		t.Errorf("expected 100x50, got %dx%d", w, h)
+	}
+}
+
+func Test_goProcessor_Thumbnail_DoesNotEnlarge(t *testing.T) {
+	p := &goProcessor{}
+
+	thumb, err := p.Thumbnail(context.Background(), encodePNG(t, testImage(40, 30)), "image/png", 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w, h := decodeSize(t, thumb); w != 40 || h != 30 {
+		t.Errorf("expected 40x30, got %dx%d", w, h)
+	}
+}
+
+func Test_goProcessor_Thumbnail_AppliesOrientation(t *testing.T) {
+	p := &goProcessor{}
+
+	// Orientation 6 is a portrait photo stored in landscape
+	thumb, err := p.Thumbnail(context.Background(), encodeJPEG(t, testImage(200, 100), 6), "image/jpeg", 50)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if thumb.ContentType != "image/jpeg" {
+		t.Errorf("expected image/jpeg, got %s", thumb.ContentType)
+	}
+	if w, h := decodeSize(t, thumb); w != 25 || h != 50 {
+		t.Errorf("expected 25x50, got %dx%d", w, h)
+	}
+}
+
+func Test_goProcessor_Thumbnail_Unsupported(t *testing.T) {
+	p := &goProcessor{}
+
+	if _, err := p.Thumbnail(context.Background(), []byte("<svg/>"), "image/svg+xml", 100); err != ErrUnsupported {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func Test_exifOrientation(t *testing.T) {
+	img := testImage(8, 8)
+	for _, want := range []int{1, 3, 6, 8} {
+		if got := exifOrientation(encodeJPEG(t, img, uint16(want))); got != want {
+			t.Errorf("expected orientation %d, got %d", want, got)
+		}
+	}
+
+	var plain bytes.Buffer
+	jpeg.Encode(&plain, img, nil)
+	if got := exifOrientation(plain.Bytes()); got != 1 {
+		t.Errorf("expected orientation 1 without EXIF, got %d", got)
+	}
+	if got := exifOrientation([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF}); got != 1 {
+		t.Errorf("expected orientation 1 for truncated data, got %d", got)
+	}
+}
+
+func Test_orient(t *testing.T) {
+	src := testImage(3, 2)
+	topLeft := src.NRGBAAt(0, 0)
+
+	// After rotating 90° clockwise the top-left pixel is the bottom-left one
+	dst := orient(src, 6)
+	if dst.Rect.Dx() != 2 || dst.Rect.Dy() != 3 {
+		t.Fatalf("expected 2x3, got %v", dst.Rect)
+	}
+	if got := dst.NRGBAAt(1, 0); got != topLeft {
+		t.Errorf("expected %v at the top right, got %v", topLeft, got)
+	}
+
+	if got := orient(src, 3).NRGBAAt(2, 1); got != topLeft {
+		t.Errorf("expected %v at the bottom right, got %v", topLeft, got)
+	}
+}
+
+type failingProcessor struct{}
+
+func (failingProcessor) Name() string                     { return "failing" }
+func (failingProcessor) Supports(contentType string) bool { return true }
+func (failingProcessor) Thumbnail(context.Context, []byte, string, int) (*Image, error) {
+	return nil, errors.New("native processing failed")
+}
+
+func Test_fallback_UsesGoProcessorOnError(t *testing.T) {
+	p := &fallback{native: failingProcessor{}, pure: &goProcessor{}}
+
+	thumb, err := p.Thumbnail(context.Background(), encodePNG(t, testImage(20, 20)), "image/png", 10)
+	if err != nil {
+		t.Fatalf("expected fallback to succeed, got %v", err)
+	}
+	if w, h := decodeSize(t, thumb); w != 10 || h != 10 {
+		t.Errorf("expected 10x10, got %dx%d", w, h)
+	}
+
+	// Formats only the native processor reads have no fallback
+	if _, err := p.Thumbnail(context.Background(), nil, "image/webp", 10); err == nil {
+		t.Error("expected the native error for WebP images")
+	}
+}
+
+func Test_New_WithoutNative(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	if p := New(true); p.Name() != "go" {
+		t.Errorf("expected the Go processor without vipsthumbnail, got %s", p.Name())
+	}
+	if p := New(false); p.Name() != "go" {
+		t.Errorf("expected the Go processor, got %s", p.Name())
+	}
+}
+
+func Test_Apply_RotatesThenCrops(t *testing.T) {
+	data := encodePNG(t, testImage(200, 100))
+
+	img, err := Apply(context.Background(), data, "image/png", Edit{Rotate: 90, Crop: image.Rect(10, 20, 60, 120)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w, h := decodeSize(t, img); w != 50 || h != 100 {
+		t.Errorf("expected 50x100, got %dx%d", w, h)
+	}
+
+	// The crop is relative to the rotated (100x200) image
+	if _, err := Apply(context.Background(), data, "image/png", Edit{Crop: image.Rect(0, 0, 150, 150)}); err != ErrInvalidEdit {
+		t.Errorf("expected ErrInvalidEdit for a crop outside the image, got %v", err)
+	}
+	if _, err := Apply(context.Background(), data, "image/png", Edit{Rotate: 45}); err != ErrInvalidEdit {
+		t.Errorf("expected ErrInvalidEdit for a 45° rotation, got %v", err)
+	}
+}
+
+func Test_Apply_UprightJPEG(t *testing.T) {
+	// Orientation 6 stores a 100x200 portrait photo as 200x100
+	img, err := Apply(context.Background(), encodeJPEG(t, testImage(200, 100), 6), "image/jpeg", Edit{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if img.ContentType != "image/jpeg" {
+		t.Errorf("expected image/jpeg, got %s", img.ContentType)
+	}
+	if w, h := decodeSize(t, img); w != 100 || h != 200 {
+		t.Errorf("expected 100x200, got %dx%d", w, h)
+	}
+}
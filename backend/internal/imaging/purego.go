@@ -0,0 +1,190 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// jpegQuality is the quality of JPEG thumbnails
+const jpegQuality = 85
+
+// goProcessor decodes and scales images with the standard library only
+type goProcessor struct{}
+
+func (p *goProcessor) Name() string {
+	return "go"
+}
+
+func (p *goProcessor) Supports(contentType string) bool {
+	switch mediaType(contentType) {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+func (p *goProcessor) Thumbnail(ctx context.Context, data []byte, contentType string, size int) (*Image, error) {
+	if !p.Supports(contentType) {
+		return nil, ErrUnsupported
+	}
+
+	img, format, orientation, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scale first and rotate the (smaller) result; orientations 5-8 swap
+	// the width and height
+	b := img.Bounds()
+	var w, h int
+	if orientation >= 5 {
+		h, w = fit(b.Dy(), b.Dx(), size)
+	} else {
+		w, h = fit(b.Dx(), b.Dy(), size)
+	}
+	thumb := orient(resize(toNRGBA(img), w, h), orientation)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return encode(thumb, format)
+}
+
+// decode returns the image, its format and EXIF orientation
+func decode(data []byte) (image.Image, string, int, error) {
+	// Check the dimensions before allocating the decoded image
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, "", 0, fmt.Errorf("image of %dx%d pixels is too large", cfg.Width, cfg.Height)
+	}
+
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+	case "gif":
+		img, err = gif.Decode(bytes.NewReader(data)) // First frame only
+	default:
+		return nil, "", 0, ErrUnsupported
+	}
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = exifOrientation(data)
+	}
+	return img, format, orientation, nil
+}
+
+// encode returns img as JPEG if it was decoded from a JPEG, else as PNG to
+// keep the transparency of PNG and GIF images
+func encode(img image.Image, format string) (*Image, error) {
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		return &Image{Data: buf.Bytes(), ContentType: "image/jpeg"}, err
+	}
+	err := png.Encode(&buf, img)
+	return &Image{Data: buf.Bytes(), ContentType: "image/png"}, err
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := img.Bounds()
+	n := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(n, n.Rect, img, b.Min, draw.Src)
+	return n
+}
+
+// resize scales src to width × height by averaging the source pixels that
+// fall into each target pixel (a box filter, good for shrinking)
+func resize(src *image.NRGBA, width, height int) *image.NRGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	if sw == width && sh == height {
+		return src
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+
+			// Weigh colors by alpha so transparent pixels don't darken edges
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					pa := uint64(p[3])
+					r += uint64(p[0]) * pa
+					g += uint64(p[1]) * pa
+					bl += uint64(p[2]) * pa
+					a += pa
+					n++
+				}
+			}
+
+			d := dst.Pix[y*dst.Stride+x*4:]
+			if a > 0 {
+				d[0], d[1], d[2] = uint8(r/a), uint8(g/a), uint8(bl/a)
+			}
+			d[3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// orient rotates and flips src so that it displays upright for the given
+// EXIF orientation (1-8)
+func orient(src *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // Rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // Transposed
+				sx, sy = y, x
+			case 6: // Needs rotating 90° clockwise
+				sx, sy = y, h-1-x
+			case 7: // Transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // Needs rotating 90° counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:sy*src.Stride+sx*4+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,95 @@
+package imaging
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// vipsThumbnailCommand is the libvips command line tool used for native thumbnails
+const vipsThumbnailCommand = "vipsthumbnail"
+
+// vipsProcessor runs libvips' vipsthumbnail, which avoids linking libvips
+// into the binary (no cgo) while using it when the system has it installed
+type vipsProcessor struct {
+	path string
+}
+
+func (p *vipsProcessor) Name() string {
+	return "vips"
+}
+
+func (p *vipsProcessor) Supports(contentType string) bool {
+	switch mediaType(contentType) {
+	case "image/jpeg", "image/png", "image/gif", "image/webp", "image/avif":
+		return true
+	}
+	return false
+}
+
+func (p *vipsProcessor) Thumbnail(ctx context.Context, data []byte, contentType string, size int) (*Image, error) {
+	if !p.Supports(contentType) {
+		return nil, ErrUnsupported
+	}
+
+	// Keep transparency of everything but JPEG
+	out, outType, options := "thumbnail.png", "image/png", "[strip]"
+	if mediaType(contentType) == "image/jpeg" {
+		out, outType, options = "thumbnail.jpg", "image/jpeg", fmt.Sprintf("[Q=%d,strip]", jpegQuality)
+	}
+
+	thumb, err := p.run(ctx, data, size, out+options)
+	if err != nil {
+		return nil, err
+	}
+	return &Image{Data: thumb, ContentType: outType}, nil
+}
+
+// Convert re-encodes a JPEG or PNG image as WebP or AVIF, scaled down to
+// opts.MaxSize; AVIF requires libvips built with libheif
+func (p *vipsProcessor) Convert(ctx context.Context, data []byte, contentType string, opts ConvertOptions) (*Image, error) {
+	switch mediaType(contentType) {
+	case "image/jpeg", "image/png":
+	default:
+		return nil, ErrUnsupported
+	}
+	if opts.Format.ContentType() == "" {
+		return nil, ErrUnsupported
+	}
+
+	out := fmt.Sprintf("converted.%s[Q=%d,strip]", opts.Format, opts.Quality)
+	converted, err := p.run(ctx, data, opts.MaxSize, out)
+	if err != nil {
+		return nil, err
+	}
+	return &Image{Data: converted, ContentType: opts.Format.ContentType()}, nil
+}
+
+// run scales an image down to fit a size × size box and returns it encoded
+// as output, a file name whose extension and options choose the format
+func (p *vipsProcessor) run(ctx context.Context, data []byte, size int, output string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "attic-thumbnail-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "input")
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		return nil, err
+	}
+
+	// ">" only shrinks; vipsthumbnail applies the EXIF orientation itself
+	out := filepath.Join(dir, output)
+	cmd := exec.CommandContext(ctx, p.path, in, "--size", fmt.Sprintf("%dx%d>", size, size), "-o", out)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", vipsThumbnailCommand, err, strings.TrimSpace(string(output)))
+	}
+
+	// The options in brackets are not part of the written file name
+	name, _, _ := strings.Cut(out, "[")
+	return os.ReadFile(name)
+}
@@ -0,0 +1,65 @@
+// Package jobs runs periodic background jobs (reminders, scheduled reports).
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Func is the work done by a job on each run
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	fn       Func
+}
+
+// Scheduler runs registered jobs at fixed intervals
+type Scheduler struct {
+	jobs []job
+}
+
+// New creates an empty scheduler
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers a job running every interval, starting when the scheduler starts
+func (s *Scheduler) Every(name string, interval time.Duration, fn Func) {
+	s.jobs = append(s.jobs, job{name: name, interval: interval, fn: fn})
+}
+
+// Start runs each job in its own goroutine until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		go j.loop(ctx)
+	}
+}
+
+func (j job) loop(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		if err := j.run(ctx); err != nil {
+			slog.Error("job failed", "job", j.name, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run calls the job, turning a panic into an error so the job keeps running
+func (j job) run(ctx context.Context) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return j.fn(ctx)
+}
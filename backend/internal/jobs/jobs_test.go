@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_Scheduler_RunsJobsRepeatedly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var runs atomic.Int32
+	s := New()
+	s.Every("count", 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	s.Start(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for runs.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected at least 3 runs, got %d", runs.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func Test_job_run_RecoversPanic(t *testing.T) {
+	j := job{name: "broken", fn: func(ctx context.Context) error { panic("boom") }}
+
+	if err := j.run(context.Background()); err == nil || err.Error() != "panic: boom" {
+		t.Errorf("expected panic to be returned as error, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+// Package jsonpath evaluates a subset of JSONPath against decoded JSON
+// documents: the root "$", child keys (".name" or "['name']"), array indexes
+// ("[0]", "[-1]" for the last element) and wildcards ("[*]" or ".*").
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrSyntax is returned for expressions outside the supported subset
+var ErrSyntax = errors.New("invalid JSONPath")
+
+type stepKind int
+
+const (
+	stepKey stepKind = iota
+	stepIndex
+	stepWildcard
+)
+
+type step struct {
+	kind  stepKind
+	key   string
+	index int
+}
+
+// Path is a compiled JSONPath expression
+type Path struct {
+	expr  string
+	steps []step
+}
+
+// Parse compiles a JSONPath expression. The leading "$" is optional, so
+// "items[0].title" and "$.items[0].title" are equivalent.
+func Parse(expr string) (*Path, error) {
+	p := &Path{expr: expr}
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			return nil, fmt.Errorf("%w %q: recursive descent is not supported", ErrSyntax, expr)
+		case s[0] == '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			name := s[:end]
+			s = s[end:]
+			switch name {
+			case "":
+				return nil, fmt.Errorf("%w %q: empty key", ErrSyntax, expr)
+			case "*":
+				p.steps = append(p.steps, step{kind: stepWildcard})
+			default:
+				p.steps = append(p.steps, step{kind: stepKey, key: name})
+			}
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w %q: unclosed bracket", ErrSyntax, expr)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			st, err := bracketStep(inner)
+			if err != nil {
+				return nil, fmt.Errorf("%w %q: %v", ErrSyntax, expr, err)
+			}
+			p.steps = append(p.steps, st)
+		default:
+			if len(p.steps) > 0 || strings.HasPrefix(strings.TrimSpace(expr), "$") {
+				return nil, fmt.Errorf("%w %q: unexpected %q", ErrSyntax, expr, s[:1])
+			}
+			// Relative path without a leading "$."
+			s = "." + s
+		}
+	}
+	return p, nil
+}
+
+func bracketStep(inner string) (step, error) {
+	if inner == "*" {
+		return step{kind: stepWildcard}, nil
+	}
+	if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+		return step{kind: stepKey, key: inner[1 : len(inner)-1]}, nil
+	}
+	i, err := strconv.Atoi(inner)
+	if err != nil {
+		return step{}, fmt.Errorf("unsupported selector [%s]", inner)
+	}
+	return step{kind: stepIndex, index: i}, nil
+}
+
+// String returns the expression the path was parsed from
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Get returns all values matching the path in a document decoded with
+// encoding/json into any
+func (p *Path) Get(doc any) []any {
+	current := []any{doc}
+	for _, st := range p.steps {
+		var next []any
+		for _, v := range current {
+			switch st.kind {
+			case stepKey:
+				if m, ok := v.(map[string]any); ok {
+					if child, ok := m[st.key]; ok {
+						next = append(next, child)
+					}
+				}
+			case stepIndex:
+				if a, ok := v.([]any); ok {
+					i := st.index
+					if i < 0 {
+						i += len(a)
+					}
+					if i >= 0 && i < len(a) {
+						next = append(next, a[i])
+					}
+				}
+			case stepWildcard:
+				switch c := v.(type) {
+				case []any:
+					next = append(next, c...)
+				case map[string]any:
+					for _, child := range c {
+						next = append(next, child)
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// First returns the first value matching the path
+func (p *Path) First(doc any) (any, bool) {
+	values := p.Get(doc)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+// Text formats a matched JSON value for display: numbers without exponent,
+// arrays joined with ", " and null as ""
+func Text(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case []any:
+		parts := make([]string, 0, len(t))
+		for _, e := range t {
+			if s := Text(e); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,96 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testDoc = `{
+	"total": 2,
+	"items": [
+		{"id": 101, "title": "Catan", "tags": ["strategy", "family"], "meta": {"year": 1995}},
+		{"id": 102, "title": "Carcassonne", "tags": [], "meta": {"year": 2000}}
+	],
+	"weird key": {"ok": true}
+}`
+
+func decode(t *testing.T) any {
+	t.Helper()
+	var doc any
+	if err := json.Unmarshal([]byte(testDoc), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestPath_Get(t *testing.T) {
+	doc := decode(t)
+
+	tests := []struct {
+		expr string
+		want []any
+	}{
+		{"$.total", []any{2.0}},
+		{"total", []any{2.0}},
+		{"$.items[0].title", []any{"Catan"}},
+		{"items[-1].title", []any{"Carcassonne"}},
+		{"$.items[*].id", []any{101.0, 102.0}},
+		{"$.items.*.meta.year", []any{1995.0, 2000.0}},
+		{"$['weird key'].ok", []any{true}},
+		{`$["items"][1]["title"]`, []any{"Carcassonne"}},
+		{"$.items[5].title", nil},
+		{"$.missing.key", nil},
+		{"$.total.key", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := p.Get(doc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPath_Root(t *testing.T) {
+	doc := decode(t)
+	p, err := Parse("$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := p.First(doc); !ok || !reflect.DeepEqual(v, doc) {
+		t.Errorf("expected the document itself, got %#v", v)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"$..title", "$.items[", "$.items[abc]", "$.", "$foo", "$.items[0]x"} {
+		if _, err := Parse(expr); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q): expected ErrSyntax, got %v", expr, err)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{nil, ""},
+		{"text", "text"},
+		{12345.0, "12345"},
+		{1.5, "1.5"},
+		{true, "true"},
+		{[]any{"a", nil, 2.0}, "a, 2"},
+	}
+	for _, tt := range tests {
+		if got := Text(tt.value); got != tt.want {
+			t.Errorf("Text(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+// Package labels renders printable asset labels with QR codes onto sheets of
+// adhesive labels (e.g. Avery) as PDF documents.
+package labels
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Layout describes a sheet of labels, all lengths in millimeters
+type Layout struct {
+	PageWidth   float64 `json:"page_width"`
+	PageHeight  float64 `json:"page_height"`
+	Columns     int     `json:"columns"`
+	Rows        int     `json:"rows"`
+	LabelWidth  float64 `json:"label_width"`
+	LabelHeight float64 `json:"label_height"`
+	MarginTop   float64 `json:"margin_top"`  // Page edge to the first row
+	MarginLeft  float64 `json:"margin_left"` // Page edge to the first column
+	ColumnGap   float64 `json:"column_gap"`  // Between labels of a row
+	RowGap      float64 `json:"row_gap"`     // Between rows
+}
+
+const (
+	a4Width, a4Height         = 210, 297
+	letterWidth, letterHeight = 215.9, 279.4
+)
+
+// Presets are the layouts of common label sheets by name
+var Presets = map[string]Layout{
+	// A4: 21 labels, 63.5 x 38.1 mm
+	"avery-l7160": {PageWidth: a4Width, PageHeight: a4Height, Columns: 3, Rows: 7, LabelWidth: 63.5, LabelHeight: 38.1,
+		MarginTop: 15.15, MarginLeft: 7.2, ColumnGap: 2.5},
+	// A4: 14 labels, 99.1 x 38.1 mm
+	"avery-l7163": {PageWidth: a4Width, PageHeight: a4Height, Columns: 2, Rows: 7, LabelWidth: 99.1, LabelHeight: 38.1,
+		MarginTop: 15.15, MarginLeft: 4.65, ColumnGap: 2.5},
+	// A4: 65 labels, 38.1 x 21.2 mm
+	"avery-l7651": {PageWidth: a4Width, PageHeight: a4Height, Columns: 5, Rows: 13, LabelWidth: 38.1, LabelHeight: 21.2,
+		MarginTop: 10.7, MarginLeft: 4.75, ColumnGap: 2.5},
+	// US Letter: 30 labels, 2 5/8 x 1 in
+	"avery-5160": {PageWidth: letterWidth, PageHeight: letterHeight, Columns: 3, Rows: 10, LabelWidth: 66.675, LabelHeight: 25.4,
+		MarginTop: 12.7, MarginLeft: 4.7625, ColumnGap: 3.175},
+	// US Letter: 10 labels, 4 x 2 in
+	"avery-5163": {PageWidth: letterWidth, PageHeight: letterHeight, Columns: 2, Rows: 5, LabelWidth: 101.6, LabelHeight: 50.8,
+		MarginTop: 12.7, MarginLeft: 3.96875, ColumnGap: 4.7625},
+	// US Letter: 12 square labels, 2 x 2 in
+	"avery-22806": {PageWidth: letterWidth, PageHeight: letterHeight, Columns: 3, Rows: 4, LabelWidth: 50.8, LabelHeight: 50.8,
+		MarginTop: 15.875, MarginLeft: 15.875, ColumnGap: 15.875, RowGap: 15.875},
+}
+
+// DefaultPreset is the preset used when none is chosen
+const DefaultPreset = "avery-l7160"
+
+// PresetNames returns the names of the presets, sorted
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// PerPage returns the number of labels on a sheet
+func (l *Layout) PerPage() int {
+	return l.Columns * l.Rows
+}
+
+// Validate checks that the labels fit on the page
+func (l *Layout) Validate() error {
+	if l.PageWidth <= 0 || l.PageHeight <= 0 || l.LabelWidth <= 0 || l.LabelHeight <= 0 {
+		return errors.New("page and label sizes must be positive")
+	}
+	if l.Columns < 1 || l.Rows < 1 {
+		return errors.New("a sheet needs at least one column and row")
+	}
+	if l.MarginTop < 0 || l.MarginLeft < 0 || l.ColumnGap < 0 || l.RowGap < 0 {
+		return errors.New("margins and gaps can't be negative")
+	}
+	width := l.MarginLeft + float64(l.Columns)*l.LabelWidth + float64(l.Columns-1)*l.ColumnGap
+	height := l.MarginTop + float64(l.Rows)*l.LabelHeight + float64(l.Rows-1)*l.RowGap
+	if width > l.PageWidth+0.01 || height > l.PageHeight+0.01 {
+		return fmt.Errorf("%d x %d labels don't fit on a %g x %g mm page", l.Columns, l.Rows, l.PageWidth, l.PageHeight)
+	}
+	return nil
+}
+
+// Label is the content of one label
+type Label struct {
+	Title string // Asset name
+	Lines []string
+	Code  string // Encoded in the QR code, usually the asset's URL
+}
+
+// points per millimeter
+const mm = 72 / 25.4
+
+// Render writes labels onto sheets of layout as a PDF document, leaving the
+// first skip positions of the first sheet empty so partly used sheets can
+// be printed on
+func Render(w io.Writer, layout Layout, labels []Label, skip int) error {
+	if err := layout.Validate(); err != nil {
+		return err
+	}
+	skip = max(skip, 0) % layout.PerPage()
+
+	doc := &pdf{width: layout.PageWidth * mm, height: layout.PageHeight * mm}
+	content := doc.page()
+	for i, label := range labels {
+		position := skip + i
+		if position > 0 && position%layout.PerPage() == 0 {
+			content = doc.page()
+		}
+		position %= layout.PerPage()
+		col, row := position%layout.Columns, position/layout.Columns
+
+		// Bottom left corner of the label, PDF coordinates start at the bottom
+		x := (layout.MarginLeft + float64(col)*(layout.LabelWidth+layout.ColumnGap)) * mm
+		top := (layout.MarginTop + float64(row)*(layout.LabelHeight+layout.RowGap)) * mm
+		y := doc.height - top - layout.LabelHeight*mm
+		if err := drawLabel(content, x, y, layout.LabelWidth*mm, layout.LabelHeight*mm, label); err != nil {
+			return fmt.Errorf("label %d: %w", i+1, err)
+		}
+	}
+	return doc.writeTo(w)
+}
+
+// drawLabel draws the QR code at the left of the label and the text to the
+// right of it, or the title below it on labels too narrow for text beside it
+func drawLabel(content *bytes.Buffer, x, y, w, h float64, label Label) error {
+	pad := min(w, h) * 0.08
+	titleSize := min(max(h*0.14, 6), 14)
+	lineSize := titleSize * 0.8
+
+	if w < h*1.6 {
+		qrSize := h - 2*pad - titleSize*1.4
+		if err := drawQR(content, x+(w-qrSize)/2, y+h-pad-qrSize, qrSize, label.Code); err != nil {
+			return err
+		}
+		title := fitText(label.Title, titleSize, w-2*pad)
+		text(content, x+(w-textWidth(title, titleSize))/2, y+pad+titleSize*0.2, titleSize, title)
+		return nil
+	}
+
+	qrSize := h - 2*pad
+	if err := drawQR(content, x+pad, y+pad, qrSize, label.Code); err != nil {
+		return err
+	}
+	tx := x + 2*pad + qrSize
+	width := x + w - pad - tx
+	ty := y + h - pad - titleSize
+	text(content, tx, ty, titleSize, fitText(label.Title, titleSize, width))
+	for _, line := range label.Lines {
+		ty -= lineSize * 1.3
+		if ty < y+pad {
+			break
+		}
+		text(content, tx, ty, lineSize, fitText(line, lineSize, width))
+	}
+	return nil
+}
+
+// quietZone is the light border around QR codes, in modules
+const quietZone = 2
+
+// drawQR draws code as a QR code filling a size x size square with its
+// bottom left corner at (x, y); runs of dark modules are drawn as one rectangle
+func drawQR(content *bytes.Buffer, x, y, size float64, code string) error {
+	if code == "" {
+		return nil
+	}
+	q, err := EncodeQR([]byte(code))
+	if err != nil {
+		return err
+	}
+	module := size / float64(q.Size+2*quietZone)
+	top := y + size - quietZone*module
+	for row := range q.Size {
+		for col := 0; col < q.Size; {
+			if !q.Dark(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < q.Size && q.Dark(col, row) {
+				col++
+			}
+			rect(content, x+float64(quietZone+start)*module, top-float64(row+1)*module, float64(col-start)*module, module)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package labels
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func Test_Presets_Fit(t *testing.T) {
+	for _, name := range PresetNames() {
+		layout := Presets[name]
+		if err := layout.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if _, ok := Presets[DefaultPreset]; !ok {
+		t.Errorf("default preset %s doesn't exist", DefaultPreset)
+	}
+}
+
+func Test_Layout_Validate(t *testing.T) {
+	layout := Presets["avery-l7160"]
+	layout.Rows = 8
+	if err := layout.Validate(); err == nil {
+		t.Error("expected 8 rows not to fit")
+	}
+	layout = Presets["avery-l7160"]
+	layout.ColumnGap = -1
+	if err := layout.Validate(); err == nil {
+		t.Error("expected a negative gap to be invalid")
+	}
+}
+
+func Test_Render(t *testing.T) {
+	labels := make([]Label, 25)
+	for i := range labels {
+		labels[i] = Label{
+			Title: fmt.Sprintf("Drill (%d)", i+1),
+			Lines: []string{"Garage > Shelf 2", "Café"},
+			Code:  fmt.Sprintf("https://attic.example.com/assets/%d", i),
+		}
+	}
+
+	var out bytes.Buffer
+	// 21 labels per sheet, 5 skipped: 16 on the first sheet, 9 on the second
+	if err := Render(&out, Presets["avery-l7160"], labels, 5); err != nil {
+		t.Fatal(err)
+	}
+	doc := out.Bytes()
+
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4")) || !bytes.HasSuffix(doc, []byte("%%EOF\n")) {
+		t.Fatal("expected a PDF document")
+	}
+	if !bytes.Contains(doc, []byte("/Count 2 ")) {
+		t.Error("expected 2 pages")
+	}
+
+	// Every cross-reference entry points at its object
+	xref := bytes.LastIndex(doc, []byte("\nxref\n"))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(doc[xref:], -1)
+	if len(entries) != 3+2*2 {
+		t.Fatalf("expected 7 objects, got %d", len(entries))
+	}
+	for i, e := range entries {
+		offset, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(doc[offset:], []byte(want)) {
+			t.Errorf("object %d: offset %d doesn't point at it", i+1, offset)
+		}
+	}
+
+	pages := streams(t, doc)
+	if strings.Count(pages[0], "(Drill (") != 0 || strings.Count(pages[0], `(Drill \(`) != 16 {
+		t.Errorf("expected 16 escaped titles on the first page")
+	}
+	if strings.Count(pages[1], `(Drill \(`) != 9 {
+		t.Errorf("expected 9 titles on the second page")
+	}
+	if !strings.Contains(pages[0], `(Caf\351)`) {
+		t.Error("expected Latin-1 text to be encoded")
+	}
+	if !strings.Contains(pages[0], " re f\n") {
+		t.Error("expected QR code modules")
+	}
+}
+
+func Test_fitText(t *testing.T) {
+	if got := fitText("Drill", 10, 100); got != "Drill" {
+		t.Errorf("expected short text to be kept, got %q", got)
+	}
+	got := fitText("Cordless drill with two batteries", 10, 60)
+	if !strings.HasSuffix(got, "...") || textWidth(got, 10) > 60 {
+		t.Errorf("expected the text to be shortened to fit, got %q", got)
+	}
+}
+
+// streams returns the decompressed content streams of doc
+func streams(t *testing.T, doc []byte) []string {
+	t.Helper()
+	var result []string
+	for _, m := range regexp.MustCompile(`(?s)/Length (\d+) /Filter /FlateDecode >>\nstream\n`).FindAllSubmatchIndex(doc, -1) {
+		length, _ := strconv.Atoi(string(doc[m[2]:m[3]]))
+		zr, err := zlib.NewReader(bytes.NewReader(doc[m[1] : m[1]+length]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, string(content))
+	}
+	return result
+}
@@ -0,0 +1,482 @@
+package labels
+
+import (
+	"errors"
+)
+
+// QR is an encoded QR code: Size x Size modules, true for dark ones
+type QR struct {
+	Size    int
+	modules [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark
+func (q *QR) Dark(x, y int) bool {
+	return q.modules[y][x]
+}
+
+// ErrTooLong is returned for data that doesn't fit the largest supported QR code
+var ErrTooLong = errors.New("data too long for a QR code")
+
+// maxVersion is the largest QR version EncodeQR produces (57 x 57 modules,
+// 213 bytes at error correction level M), plenty for asset links
+const maxVersion = 10
+
+// Error correction codewords per block and number of blocks per version at
+// level M, which restores up to 15% of a damaged label
+var (
+	eccCodewordsPerBlock = [maxVersion + 1]int{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26}
+	eccBlocks            = [maxVersion + 1]int{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5}
+)
+
+// eccFormatBits identifies level M in the format information
+const eccFormatBits = 0
+
+// EncodeQR encodes data in byte mode in the smallest QR code that fits it
+func EncodeQR(data []byte) (*QR, error) {
+	version := 1
+	for ; ; version++ {
+		if version > maxVersion {
+			return nil, ErrTooLong
+		}
+		if 4+countBits(version)+len(data)*8 <= dataCodewords(version)*8 {
+			break
+		}
+	}
+
+	var bits bitBuffer
+	bits.append(0b0100, 4) // Byte mode
+	bits.append(len(data), countBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := dataCodewords(version) * 8
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+
+	q := newQR(version)
+	q.drawCodewords(addECCAndInterleave(codewords, version))
+
+	// Use the mask with the lowest penalty
+	best, bestPenalty := 0, -1
+	for mask := range 8 {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // Undo, masks are XORs
+	}
+	q.applyMask(best)
+	q.drawFormatBits(best)
+	return &q.QR, nil
+}
+
+// countBits is the length of the byte count in the data of a version
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// rawDataModules is the number of modules of a version available for data
+// and error correction, i.e. not used by function patterns
+func rawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// dataCodewords is the number of data codewords of a version at level M
+func dataCodewords(version int) int {
+	return rawDataModules(version)/8 - eccCodewordsPerBlock[version]*eccBlocks[version]
+}
+
+// alignmentPositions returns the centers of the alignment patterns of a
+// version, in both directions
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := (version*8 + numAlign*3 + 5) / (numAlign*4 - 4) * 2
+	positions := make([]int, numAlign)
+	positions[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// addECCAndInterleave splits the data codewords into blocks, adds the
+// Reed-Solomon error correction codewords of each and interleaves them
+func addECCAndInterleave(data []byte, version int) []byte {
+	numBlocks := eccBlocks[version]
+	blockECCLen := eccCodewordsPerBlock[version]
+	rawCodewords := rawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			n++
+		}
+		block := make([]byte, shortBlockLen+1)
+		copy(block, data[k:k+n])
+		copy(block[len(block)-blockECCLen:], reedSolomonRemainder(data[k:k+n], divisor))
+		k += n
+		blocks[i] = block
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range shortBlockLen + 1 {
+		for j, block := range blocks {
+			// Short blocks have a gap where long blocks have their last data codeword
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// reedSolomonDivisor returns the generator polynomial of the given degree,
+// highest coefficient first, without the leading 1
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for range degree {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder returns the error correction codewords of data
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// bitBuffer is a sequence of bits, most significant first
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (value>>i)&1 != 0)
+	}
+}
+
+// builder is a QR code being drawn, which tracks the function modules that
+// data and masks must not touch
+type builder struct {
+	QR
+	version  int
+	function [][]bool
+}
+
+// newQR draws the function patterns of a version, with placeholder format bits
+func newQR(version int) *builder {
+	size := version*4 + 17
+	q := &builder{QR: QR{Size: size}, version: version}
+	q.modules = make([][]bool, size)
+	q.function = make([][]bool, size)
+	for i := range size {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+
+	for i := range size {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(size-4, 3)
+	q.drawFinder(3, size-4)
+
+	positions := alignmentPositions(version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Skip the three corners with finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			q.drawAlignment(x, y)
+		}
+	}
+
+	q.drawFormatBits(0)
+	q.drawVersion()
+	return q
+}
+
+// set sets a function module
+func (q *builder) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+// drawFinder draws a finder pattern with its separator around (x, y)
+func (q *builder) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= q.Size || yy < 0 || yy >= q.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			q.set(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignment draws an alignment pattern around (x, y)
+func (q *builder) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the error correction level and mask
+func (q *builder) drawFormatBits(mask int) {
+	data := eccFormatBits<<3 | mask
+	rem := data
+	for range 10 {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(bits, i))
+	}
+	q.set(8, 7, bit(bits, 6))
+	q.set(8, 8, bit(bits, 7))
+	q.set(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(bits, i))
+	}
+
+	for i := range 8 {
+		q.set(q.Size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.Size-15+i, bit(bits, i))
+	}
+	q.set(8, q.Size-8, true) // Always dark
+}
+
+// drawVersion draws both copies of the version information (version 7 and up)
+func (q *builder) drawVersion() {
+	if q.version < 7 {
+		return
+	}
+	rem := q.version
+	for range 12 {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := q.version<<12 | rem
+	for i := range 18 {
+		a, b := q.Size-11+i%3, i/3
+		q.set(a, b, bit(bits, i))
+		q.set(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places the codewords in the zigzag pattern of the data area
+func (q *builder) drawCodewords(data []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // Skip the vertical timing pattern
+		}
+		for vert := range q.Size {
+			for j := range 2 {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert // Upward column pair
+				}
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = bit(int(data[i>>3]), 7-i&7)
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by a mask pattern
+func (q *builder) applyMask(mask int) {
+	for y := range q.Size {
+		for x := range q.Size {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.function[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to scan, following the four rules
+// of the specification: long runs, 2x2 blocks, finder-like patterns and an
+// unbalanced dark ratio
+func (q *builder) penalty() int {
+	n := q.Size
+	result := 0
+	line := make([]bool, n)
+	for _, horizontal := range []bool{true, false} {
+		for a := range n {
+			for b := range n {
+				if horizontal {
+					line[b] = q.modules[a][b]
+				} else {
+					line[b] = q.modules[b][a]
+				}
+			}
+			result += runPenalty(line) + finderPenalty(line)
+		}
+	}
+
+	dark := 0
+	for y := range n {
+		for x := range n {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					result += 3
+				}
+			}
+		}
+	}
+	total := n * n
+	// Steps of 5% away from a 50% dark ratio
+	result += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+	return result
+}
+
+// runPenalty scores runs of 5 or more modules of the same color
+func runPenalty(line []bool) int {
+	result := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			result += run - 2
+		}
+		run = 1
+	}
+	return result
+}
+
+// finderPattern is dark-light-dark-dark-dark-light-dark, the core of a finder
+var finderPattern = []bool{true, false, true, true, true, false, true}
+
+// finderPenalty scores finder-like patterns with four light modules (or the
+// edge) on either side
+func finderPenalty(line []bool) int {
+	result := 0
+	lightAt := func(i int) bool { return i < 0 || i >= len(line) || !line[i] }
+	for i := 0; i+len(finderPattern) <= len(line); i++ {
+		match := true
+		for j, dark := range finderPattern {
+			if line[i+j] != dark {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		before, after := true, true
+		for k := 1; k <= 4; k++ {
+			before = before && lightAt(i-k)
+			after = after && lightAt(i+len(finderPattern)-1+k)
+		}
+		if before || after {
+			result += 40
+		}
+	}
+	return result
+}
+
+func bit(x, i int) bool {
+	return (x>>i)&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,203 @@
+package labels
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func Test_reedSolomonRemainder(t *testing.T) {
+	// "HELLO WORLD" at version 1-M, the worked example of the specification tutorials
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := reedSolomonRemainder(data, reedSolomonDivisor(10))
+	if !bytes.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func Test_dataCodewords(t *testing.T) {
+	// Byte capacity at level M of versions 1 to 10
+	capacity := []int{14, 26, 42, 62, 84, 106, 122, 152, 180, 213}
+	for i, want := range capacity {
+		version := i + 1
+		if got := (dataCodewords(version)*8 - 4 - countBits(version)) / 8; got != want {
+			t.Errorf("version %d: expected %d bytes, got %d", version, want, got)
+		}
+	}
+}
+
+func Test_builder_FormatAndVersionBits(t *testing.T) {
+	q := newQR(7)
+	q.drawFormatBits(0)
+
+	var format int
+	for i, pos := range [][2]int{{8, 0}, {8, 1}, {8, 2}, {8, 3}, {8, 4}, {8, 5}, {8, 7}, {8, 8}, {7, 8}, {5, 8}, {4, 8}, {3, 8}, {2, 8}, {1, 8}, {0, 8}} {
+		if q.Dark(pos[0], pos[1]) {
+			format |= 1 << i
+		}
+	}
+	if format != 0b101010000010010 {
+		t.Errorf("expected the format bits of level M, mask 0, got %015b", format)
+	}
+
+	var version int
+	for i := range 18 {
+		if q.Dark(q.Size-11+i%3, i/3) {
+			version |= 1 << i
+		}
+	}
+	if version != 0b000111110010010100 {
+		t.Errorf("expected the version bits of version 7, got %018b", version)
+	}
+}
+
+func Test_EncodeQR_RoundTrip(t *testing.T) {
+	for _, data := range []string{
+		"a",
+		"https://attic.example.com/assets/7d5c3a3e-2f4b-4c8e-9a51-0b3f0a6c1d2e",
+		strings.Repeat("x", 100),
+		strings.Repeat("é", 106),
+	} {
+		q, err := EncodeQR([]byte(data))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", len(data), err)
+		}
+		if got := decodeQR(t, q); got != data {
+			t.Errorf("expected %q, got %q", data, got)
+		}
+	}
+
+	if _, err := EncodeQR(bytes.Repeat([]byte("x"), 214)); err != ErrTooLong {
+		t.Errorf("expected ErrTooLong, got %v", err)
+	}
+}
+
+// decodeQR reads a QR code back, checking its format bits and error
+// correction codewords
+func decodeQR(t *testing.T, q *QR) string {
+	t.Helper()
+	version := (q.Size - 17) / 4
+
+	var format, copy2 int
+	for i := range 15 {
+		var x, y int
+		switch {
+		case i <= 5:
+			x, y = 8, i
+		case i <= 7:
+			x, y = 8, i+1
+		case i == 8:
+			x, y = 7, 8
+		default:
+			x, y = 14-i, 8
+		}
+		if q.Dark(x, y) {
+			format |= 1 << i
+		}
+		if i < 8 {
+			x, y = q.Size-1-i, 8
+		} else {
+			x, y = 8, q.Size-15+i
+		}
+		if q.Dark(x, y) {
+			copy2 |= 1 << i
+		}
+	}
+	if format != copy2 {
+		t.Fatalf("format bits differ: %015b, %015b", format, copy2)
+	}
+	format ^= 0x5412
+	if format>>13 != eccFormatBits {
+		t.Fatalf("unexpected error correction level %d", format>>13)
+	}
+
+	// Unmask a copy, then read the codewords in placement order
+	b := newQR(version)
+	for y := range q.Size {
+		for x := range q.Size {
+			b.modules[y][x] = q.Dark(x, y)
+		}
+	}
+	b.applyMask(format >> 10 & 7)
+	var codewords []byte
+	var current byte
+	n := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := range q.Size {
+			for j := range 2 {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if b.function[y][x] {
+					continue
+				}
+				current <<= 1
+				if b.modules[y][x] {
+					current |= 1
+				}
+				if n++; n%8 == 0 {
+					codewords = append(codewords, current)
+				}
+			}
+		}
+	}
+	codewords = codewords[:rawDataModules(version)/8]
+
+	// De-interleave the blocks and check their syndromes
+	numBlocks, eccLen := eccBlocks[version], eccCodewordsPerBlock[version]
+	numShort := numBlocks - len(codewords)%numBlocks
+	shortLen := len(codewords) / numBlocks
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for i := range shortLen + 1 {
+		for j := range numBlocks {
+			if i != shortLen-eccLen || j >= numShort {
+				blocks[j] = append(blocks[j], codewords[k])
+				k++
+			}
+		}
+	}
+	var data []byte
+	for j, block := range blocks {
+		for i, root := 0, byte(1); i < eccLen; i, root = i+1, gfMultiply(root, 2) {
+			var s byte
+			for _, c := range block {
+				s = gfMultiply(s, root) ^ c
+			}
+			if s != 0 {
+				t.Fatalf("block %d: syndrome %d is %d", j, i, s)
+			}
+		}
+		data = append(data, block[:len(block)-eccLen]...)
+	}
+
+	var bits bitBuffer
+	for _, c := range data {
+		bits.append(int(c), 8)
+	}
+	read := func(pos, length int) int {
+		v := 0
+		for _, bit := range bits[pos : pos+length] {
+			v <<= 1
+			if bit {
+				v |= 1
+			}
+		}
+		return v
+	}
+	if mode := read(0, 4); mode != 0b0100 {
+		t.Fatalf("expected byte mode, got %04b", mode)
+	}
+	count := read(4, countBits(version))
+	out := make([]byte, count)
+	for i := range out {
+		out[i] = byte(read(4+countBits(version)+8*i, 8))
+	}
+	return string(out)
+}
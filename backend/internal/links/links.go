@@ -0,0 +1,117 @@
+// Package links builds absolute URLs (file URLs, share links, email links,
+// OIDC redirects) from the configured public base URL.
+package links
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Builder generates absolute URLs rooted at the public base URL. Requests
+// arriving on one of the alternate hostnames get links on that hostname.
+type Builder struct {
+	base      *url.URL
+	alternate map[string]bool
+}
+
+// New validates baseURL and returns a Builder. Alternate hosts are hostnames
+// (optionally with port) the server is also reachable on, e.g. "attic.lan".
+func New(baseURL string, alternateHosts []string) (*Builder, error) {
+	base, err := ParseBaseURL(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	alternate := make(map[string]bool, len(alternateHosts))
+	for _, host := range alternateHosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host == "" {
+			continue
+		}
+		if strings.ContainsAny(host, "/?#@") {
+			return nil, fmt.Errorf("invalid alternate host %q", host)
+		}
+		alternate[host] = true
+	}
+
+	return &Builder{base: base, alternate: alternate}, nil
+}
+
+// ParseBaseURL validates a public base URL: it must be an absolute http(s)
+// URL without query or fragment. A trailing slash is removed.
+func ParseBaseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: missing host", raw)
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return nil, fmt.Errorf("invalid base URL %q: must not contain credentials, query or fragment", raw)
+	}
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u, nil
+}
+
+// BaseURL returns the canonical base URL (without trailing slash)
+func (b *Builder) BaseURL() string {
+	return b.base.String()
+}
+
+// URL returns the absolute canonical URL of path (e.g. "/share/lists/abc")
+func (b *Builder) URL(path string) string {
+	return b.join(b.base, path)
+}
+
+// ForRequest returns the absolute URL of path as seen by the client of r:
+// the alternate hostname it used, if any, otherwise the canonical base URL.
+func (b *Builder) ForRequest(r *http.Request, path string) string {
+	if r == nil {
+		return b.URL(path)
+	}
+
+	host := r.Header.Get("X-Forwarded-Host")
+	if host == "" {
+		host = r.Host
+	}
+	host = strings.ToLower(strings.TrimSpace(strings.Split(host, ",")[0]))
+
+	if host == "" || !b.isAlternate(host) {
+		return b.URL(path)
+	}
+
+	u := *b.base
+	u.Host = host
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		u.Scheme = proto
+	} else if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	return b.join(&u, path)
+}
+
+func (b *Builder) isAlternate(host string) bool {
+	if b.alternate[host] {
+		return true
+	}
+	// Allow "attic.lan" to match "attic.lan:8080"
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return b.alternate[name]
+	}
+	return false
+}
+
+func (b *Builder) join(base *url.URL, path string) string {
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return base.String() + path
+}
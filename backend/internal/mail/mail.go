@@ -0,0 +1,237 @@
+// Package mail sends transactional email (login alerts, notifications).
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message is an email with a plain text body and an optional HTML alternative
+type Message struct {
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string       // Optional
+	Attachments []Attachment // Optional
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	Name        string
+	ContentType string // Empty = application/octet-stream
+	Data        []byte
+}
+
+// Mailer sends email messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPConfig configures the SMTP mailer
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // Empty = no authentication
+	Password string
+	From     string // e.g. "Attic <attic@example.com>"
+}
+
+// SMTPMailer sends email through an SMTP server (STARTTLS when offered)
+type SMTPMailer struct {
+	cfg SMTPConfig
+}
+
+// NewSMTP creates an SMTP mailer
+func NewSMTP(cfg SMTPConfig) *SMTPMailer {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &SMTPMailer{cfg: cfg}
+}
+
+// Send delivers msg to all recipients
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("mail: no recipients")
+	}
+
+	body, err := buildMessage(m.cfg.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, fmt.Sprint(m.cfg.Port))
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(addr, auth, envelopeAddress(m.cfg.From), msg.To, body)
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return fmt.Errorf("mail: sending to %s: %w", strings.Join(msg.To, ", "), err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check connects to the SMTP server and authenticates like Send does,
+// without sending a message
+func (m *SMTPMailer) Check(ctx context.Context) error {
+	addr := net.JoinHostPort(m.cfg.Host, fmt.Sprint(m.cfg.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("mail: connecting to %s: %w", addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("mail: greeting from %s: %w", addr, err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return fmt.Errorf("mail: starting TLS: %w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return fmt.Errorf("mail: authenticating as %s: %w", m.cfg.Username, err)
+		}
+	}
+	return c.Quit()
+}
+
+// buildMessage renders msg as an RFC 5322 message
+func buildMessage(from string, msg Message, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	writeHeader(&buf, "From", from)
+	writeHeader(&buf, "To", strings.Join(msg.To, ", "))
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	writeHeader(&buf, "Date", now.Format(time.RFC1123Z))
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	if len(msg.Attachments) == 0 {
+		if err := writeBody(&buf, msg); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	boundary := newBoundary()
+	writeHeader(&buf, "Content-Type", `multipart/mixed; boundary="`+boundary+`"`)
+	buf.WriteString("\r\n")
+
+	fmt.Fprintf(&buf, "--%s\r\n", boundary)
+	if err := writeBody(&buf, msg); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\r\n")
+
+	for _, a := range msg.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		writeHeader(&buf, "Content-Type", contentType)
+		writeHeader(&buf, "Content-Transfer-Encoding", "base64")
+		writeHeader(&buf, "Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Name}))
+		buf.WriteString("\r\n")
+		writeBase64(&buf, a.Data)
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// writeBody writes the content headers and text of msg (with its HTML alternative)
+func writeBody(buf *bytes.Buffer, msg Message) error {
+	if msg.HTML == "" {
+		writeHeader(buf, "Content-Type", "text/plain; charset=utf-8")
+		writeHeader(buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		return writeQuotedPrintable(buf, msg.Text)
+	}
+
+	boundary := newBoundary()
+	writeHeader(buf, "Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		fmt.Fprintf(buf, "--%s\r\n", boundary)
+		writeHeader(buf, "Content-Type", part.contentType)
+		writeHeader(buf, "Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(buf, part.body); err != nil {
+			return err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(buf, "--%s--\r\n", boundary)
+	return nil
+}
+
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	fmt.Fprintf(buf, "%s: %s\r\n", key, value)
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters
+func writeBase64(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
+
+func writeQuotedPrintable(buf *bytes.Buffer, s string) error {
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func newBoundary() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "attic-" + hex.EncodeToString(b)
+}
+
+// envelopeAddress extracts the bare address from "Name <addr>"
+func envelopeAddress(from string) string {
+	if i := strings.LastIndex(from, "<"); i >= 0 {
+		return strings.TrimSuffix(from[i+1:], ">")
+	}
+	return from
+}
@@ -0,0 +1,79 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_buildMessage_PlainText(t *testing.T) {
+	msg := Message{To: []string{"user@example.com"}, Subject: "Hello", Text: "Plain body"}
+
+	out, err := buildMessage("Attic <attic@example.com>", msg, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("failed to build message: %v", err)
+	}
+
+	s := string(out)
+	for _, want := range []string{"From: Attic <attic@example.com>\r\n", "To: user@example.com\r\n", "Subject: Hello\r\n", "text/plain", "Plain body"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expected message to contain %q, got:\n%s", want, s)
+		}
+	}
+	if strings.Contains(s, "multipart") {
+		t.Error("expected a single-part message")
+	}
+}
+
+func Test_buildMessage_WithHTML(t *testing.T) {
+	msg := Message{To: []string{"a@example.com", "b@example.com"}, Subject: "Héllo", Text: "text", HTML: "<p>html</p>"}
+
+	out, err := buildMessage("attic@example.com", msg, time.Now())
+	if err != nil {
+		t.Fatalf("failed to build message: %v", err)
+	}
+
+	s := string(out)
+	if !strings.Contains(s, "multipart/alternative") {
+		t.Error("expected multipart message")
+	}
+	if !strings.Contains(s, "To: a@example.com, b@example.com\r\n") {
+		t.Error("expected both recipients")
+	}
+	if !strings.Contains(s, "=?utf-8?q?") {
+		t.Error("expected encoded subject")
+	}
+	if !strings.Contains(s, "<p>html</p>") {
+		t.Error("expected HTML part")
+	}
+}
+
+func Test_envelopeAddress(t *testing.T) {
+	if got := envelopeAddress("Attic <attic@example.com>"); got != "attic@example.com" {
+		t.Errorf("unexpected address: %q", got)
+	}
+	if got := envelopeAddress("attic@example.com"); got != "attic@example.com" {
+		t.Errorf("unexpected address: %q", got)
+	}
+}
+
+func Test_buildMessage_WithAttachment(t *testing.T) {
+	msg := Message{
+		To:          []string{"user@example.com"},
+		Subject:     "Report",
+		Text:        "See attached",
+		Attachments: []Attachment{{Name: "report.json", ContentType: "application/json", Data: []byte(`{"ok":true}`)}},
+	}
+
+	out, err := buildMessage("attic@example.com", msg, time.Now())
+	if err != nil {
+		t.Fatalf("failed to build message: %v", err)
+	}
+
+	s := string(out)
+	for _, want := range []string{"multipart/mixed", "text/plain", "See attached", `attachment; filename=report.json`, "eyJvayI6dHJ1ZX0="} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expected message to contain %q, got:\n%s", want, s)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package manuals finds and downloads product manuals (PDF) for a brand and
+// model from configurable sources such as manufacturer support sites.
+package manuals
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxManualSize is the largest manual that is downloaded
+const MaxManualSize = 50 << 20
+
+// maxPageSize is the largest search result page that is read
+const maxPageSize = 2 << 20
+
+// ErrNotFound is returned when no source has a manual for a product
+var ErrNotFound = errors.New("manual not found")
+
+// Product identifies the product a manual is looked up for
+type Product struct {
+	Brand string
+	Model string
+}
+
+// Manual is a downloaded product manual
+type Manual struct {
+	FileName  string
+	SourceURL string
+	Data      []byte
+}
+
+// Source locates the manual of a product
+type Source interface {
+	// Name identifies the source in logs
+	Name() string
+	// Find returns candidate PDF URLs for a product, best match first
+	Find(ctx context.Context, client *http.Client, p Product) ([]string, error)
+}
+
+// Fetcher tries its sources in order and downloads the first PDF found
+type Fetcher struct {
+	sources []Source
+	client  *http.Client
+}
+
+// NewFetcher creates a fetcher querying sources in order
+func NewFetcher(sources ...Source) *Fetcher {
+	return &Fetcher{
+		sources: sources,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Fetch returns the manual of a product, or ErrNotFound
+func (f *Fetcher) Fetch(ctx context.Context, p Product) (*Manual, error) {
+	if p.Brand == "" || p.Model == "" {
+		return nil, ErrNotFound
+	}
+
+	var errs []error
+	for _, src := range f.sources {
+		candidates, err := src.Find(ctx, f.client, p)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", src.Name(), err))
+			continue
+		}
+		for _, u := range candidates {
+			data, err := f.download(ctx, u)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", src.Name(), err))
+				continue
+			}
+			return &Manual{FileName: fileName(p, u), SourceURL: u, Data: data}, nil
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", ErrNotFound, errors.Join(errs...))
+	}
+	return nil, ErrNotFound
+}
+
+// download fetches a PDF, rejecting anything else (e.g. HTML error pages)
+func (f *Fetcher) download(ctx context.Context, u string) ([]byte, error) {
+	body, err := get(ctx, f.client, u, MaxManualSize+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > MaxManualSize {
+		return nil, fmt.Errorf("%s: manual larger than %d bytes", u, MaxManualSize)
+	}
+	if !bytes.HasPrefix(body, []byte("%PDF-")) {
+		return nil, fmt.Errorf("%s: not a PDF document", u)
+	}
+	return body, nil
+}
+
+// get reads up to limit bytes of a URL
+func get(ctx context.Context, client *http.Client, u string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Attic manual fetcher")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %d", u, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+// fileName names the attachment of a manual, e.g. "Bosch-WAN28K40-manual.pdf"
+func fileName(p Product, u string) string {
+	name := p.Brand + "-" + p.Model + "-manual.pdf"
+	if parsed, err := url.Parse(u); err == nil {
+		if base := path.Base(parsed.Path); strings.EqualFold(path.Ext(base), ".pdf") && len(base) <= 100 {
+			name = base
+		}
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == '"' || r < ' ' {
+			return '-'
+		}
+		return r
+	}, name)
+}
+
+// expand replaces {brand} and {model} in a URL template with values escaped
+// for use in both paths and query strings
+func expand(template string, p Product) string {
+	escape := func(s string) string {
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	}
+	return strings.NewReplacer("{brand}", escape(p.Brand), "{model}", escape(p.Model)).Replace(template)
+}
+
+// DirectSource is a URL template pointing straight at a manual, for
+// manufacturers with predictable URLs, e.g.
+// "https://example.com/manuals/{brand}/{model}.pdf"
+type DirectSource struct {
+	Template string
+}
+
+func (s DirectSource) Name() string { return "direct " + s.Template }
+
+func (s DirectSource) Find(_ context.Context, _ *http.Client, p Product) ([]string, error) {
+	return []string{expand(s.Template, p)}, nil
+}
+
+// SearchSource is the URL template of a search page (e.g. a support site or
+// manual library); the PDF links on the result page are the candidates
+type SearchSource struct {
+	Template string
+}
+
+// maxSearchCandidates limits the PDF links tried per search page
+const maxSearchCandidates = 3
+
+var hrefPattern = regexp.MustCompile(`(?i)href\s*=\s*["']([^"'#]+)["']`)
+
+func (s SearchSource) Name() string { return "search " + s.Template }
+
+func (s SearchSource) Find(ctx context.Context, client *http.Client, p Product) ([]string, error) {
+	pageURL := expand(s.Template, p)
+	page, err := get(ctx, client, pageURL, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	seen := map[string]bool{}
+	for _, m := range hrefPattern.FindAllSubmatch(page, -1) {
+		ref, err := url.Parse(strings.TrimSpace(string(m[1])))
+		if err != nil || !strings.EqualFold(path.Ext(ref.Path), ".pdf") {
+			continue
+		}
+		abs := base.ResolveReference(ref)
+		if abs.Scheme != "http" && abs.Scheme != "https" {
+			continue
+		}
+		if u := abs.String(); !seen[u] {
+			seen[u] = true
+			candidates = append(candidates, u)
+		}
+		if len(candidates) == maxSearchCandidates {
+			break
+		}
+	}
+	return candidates, nil
+}
+
+// ParseSources parses a comma separated list of "direct:<url template>" and
+// "search:<url template>" entries
+func ParseSources(spec string) ([]Source, error) {
+	var sources []Source
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kind, template, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid manual source %q, expected direct:<url> or search:<url>", entry)
+		}
+		if u, err := url.Parse(template); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid manual source URL %q", template)
+		}
+		switch kind {
+		case "direct":
+			sources = append(sources, DirectSource{Template: template})
+		case "search":
+			sources = append(sources, SearchSource{Template: template})
+		default:
+			return nil, fmt.Errorf("unknown manual source type %q", kind)
+		}
+	}
+	return sources, nil
+}
+
+// brandKeys and modelKeys are the attribute names (last segment of the key)
+// holding a product's brand and model
+var (
+	brandKeys = []string{"brand", "manufacturer", "make"}
+	modelKeys = []string{"model", "model_number", "model_no"}
+)
+
+// ProductFromAttributes reads the brand and model from asset attributes,
+// matching keys such as "brand" or "appliances.manufacturer"
+func ProductFromAttributes(attrs map[string]any) (Product, bool) {
+	var p Product
+	for key, value := range attrs {
+		s, ok := value.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			continue
+		}
+		name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+		switch {
+		case p.Brand == "" && slices.Contains(brandKeys, name):
+			p.Brand = strings.TrimSpace(s)
+		case p.Model == "" && slices.Contains(modelKeys, name):
+			p.Model = strings.TrimSpace(s)
+		}
+	}
+	return p, p.Brand != "" && p.Model != ""
+}
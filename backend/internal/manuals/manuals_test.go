@@ -0,0 +1,104 @@
+package manuals
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newManualServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "Bosch WAN28K40" {
+			w.Write([]byte(`<html>No results</html>`))
+			return
+		}
+		w.Write([]byte(`<html>
+			<a href="/products/wan28k40">Product page</a>
+			<a href='/docs/broken.pdf'>Broken</a>
+			<a HREF="/docs/WAN28K40_manual.pdf">Manual</a>
+		</html>`))
+	})
+	mux.HandleFunc("/docs/broken.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html>Login required</html>`))
+	})
+	mux.HandleFunc("/docs/WAN28K40_manual.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("%PDF-1.4 manual"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func Test_Fetcher_Fetch_FollowsSearchResults(t *testing.T) {
+	srv := newManualServer(t)
+	fetcher := NewFetcher(
+		DirectSource{Template: srv.URL + "/missing/{brand}/{model}.pdf"},
+		SearchSource{Template: srv.URL + "/search?q={brand}+{model}"},
+	)
+
+	manual, err := fetcher.Fetch(context.Background(), Product{Brand: "Bosch", Model: "WAN28K40"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if manual.SourceURL != srv.URL+"/docs/WAN28K40_manual.pdf" {
+		t.Errorf("unexpected source %q", manual.SourceURL)
+	}
+	if manual.FileName != "WAN28K40_manual.pdf" {
+		t.Errorf("unexpected file name %q", manual.FileName)
+	}
+	if string(manual.Data) != "%PDF-1.4 manual" {
+		t.Errorf("unexpected data %q", manual.Data)
+	}
+}
+
+func Test_Fetcher_Fetch_NotFound(t *testing.T) {
+	srv := newManualServer(t)
+	fetcher := NewFetcher(SearchSource{Template: srv.URL + "/search?q={brand}+{model}"})
+
+	_, err := fetcher.Fetch(context.Background(), Product{Brand: "Acme", Model: "X1"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	_, err = fetcher.Fetch(context.Background(), Product{Brand: "Acme"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound without model, got %v", err)
+	}
+}
+
+func Test_ParseSources(t *testing.T) {
+	sources, err := ParseSources("search:https://example.com/s?q={model}, direct:https://example.com/{brand}/{model}.pdf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 sources, got %d", len(sources))
+	}
+	if _, ok := sources[0].(SearchSource); !ok {
+		t.Errorf("expected first source to be a search source, got %T", sources[0])
+	}
+
+	for _, spec := range []string{"https://example.com", "ftp:https://example.com", "direct:file:///etc/passwd"} {
+		if _, err := ParseSources(spec); err == nil {
+			t.Errorf("expected %q to be rejected", spec)
+		}
+	}
+}
+
+func Test_ProductFromAttributes(t *testing.T) {
+	p, ok := ProductFromAttributes(map[string]any{
+		"appliances.manufacturer": " Bosch ",
+		"appliances.model_number": "WAN28K40",
+		"appliances.watts":        2300,
+	})
+	if !ok || p.Brand != "Bosch" || p.Model != "WAN28K40" {
+		t.Errorf("unexpected product %+v (%v)", p, ok)
+	}
+
+	if _, ok := ProductFromAttributes(map[string]any{"brand": "Bosch"}); ok {
+		t.Error("expected product without model to be incomplete")
+	}
+}
@@ -0,0 +1,202 @@
+// Package metrics exposes application metrics in the Prometheus text
+// exposition format.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types
+const (
+	TypeGauge   = "gauge"
+	TypeCounter = "counter"
+)
+
+// Label is a metric label
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one value of a metric family
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Family is a named metric with its samples
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Collector computes metric families at scrape time (e.g. from the database)
+type Collector func(ctx context.Context) ([]Family, error)
+
+// CounterVec is a counter partitioned by label values
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64 // Keyed by label values joined with \xff
+}
+
+// NewCounterVec creates a counter with the given label names
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labels: labels, values: map[string]float64{}}
+}
+
+// Inc increments the counter for the label values (in label order)
+func (c *CounterVec) Inc(values ...string) {
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", c.name, len(c.labels), len(values)))
+	}
+	c.mu.Lock()
+	c.values[strings.Join(values, "\xff")]++
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) family() Family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := Family{Name: c.name, Help: c.help, Type: TypeCounter}
+	for key, v := range c.values {
+		values := strings.Split(key, "\xff")
+		labels := make([]Label, len(c.labels))
+		for i, name := range c.labels {
+			labels[i] = Label{Name: name, Value: values[i]}
+		}
+		f.Samples = append(f.Samples, Sample{Labels: labels, Value: v})
+	}
+	return f
+}
+
+// Registry holds the counters and collectors exposed on the metrics endpoint
+type Registry struct {
+	mu         sync.Mutex
+	counters   []*CounterVec
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// MustRegister adds a counter to the registry
+func (r *Registry) MustRegister(c *CounterVec) {
+	r.mu.Lock()
+	r.counters = append(r.counters, c)
+	r.mu.Unlock()
+}
+
+// RegisterCollector adds a collector run on each scrape
+func (r *Registry) RegisterCollector(fn Collector) {
+	r.mu.Lock()
+	r.collectors = append(r.collectors, fn)
+	r.mu.Unlock()
+}
+
+// Gather returns all metric families sorted by name; collector errors are
+// logged and the failing collector's metrics are left out
+func (r *Registry) Gather(ctx context.Context) []Family {
+	r.mu.Lock()
+	counters := slices.Clone(r.counters)
+	collectors := slices.Clone(r.collectors)
+	r.mu.Unlock()
+
+	var families []Family
+	for _, c := range counters {
+		families = append(families, c.family())
+	}
+	for _, collect := range collectors {
+		fs, err := collect(ctx)
+		if err != nil {
+			slog.Error("failed to collect metrics", "error", err)
+			continue
+		}
+		families = append(families, fs...)
+	}
+	slices.SortFunc(families, func(a, b Family) int { return strings.Compare(a.Name, b.Name) })
+	return families
+}
+
+// Handler serves the metrics; a non-empty token must be sent as bearer token
+func (r *Registry) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if token != "" {
+			got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := Write(w, r.Gather(req.Context())); err != nil {
+			slog.Error("failed to write metrics", "error", err)
+		}
+	})
+}
+
+// Write renders families in the Prometheus text format
+func Write(w io.Writer, families []Family) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		fmt.Fprintf(bw, "# HELP %s %s\n", f.Name, escapeHelp(f.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.Name, f.Type)
+		samples := slices.Clone(f.Samples)
+		slices.SortFunc(samples, func(a, b Sample) int { return strings.Compare(labelString(a.Labels), labelString(b.Labels)) })
+		for _, s := range samples {
+			fmt.Fprintf(bw, "%s%s %s\n", f.Name, labelString(s.Labels), formatValue(s.Value))
+		}
+	}
+	return bw.Flush()
+}
+
+func labelString(labels []Label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = l.Name + `="` + escapeLabel(l.Value) + `"`
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
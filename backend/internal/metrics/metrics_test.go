@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_Registry_Handler_WritesTextFormat(t *testing.T) {
+	reg := NewRegistry()
+	errs := NewCounterVec("attic_plugin_errors_total", "Plugin errors.", "plugin")
+	reg.MustRegister(errs)
+	errs.Inc("tmdb")
+	errs.Inc("tmdb")
+	errs.Inc(`b"gg`)
+	reg.RegisterCollector(func(ctx context.Context) ([]Family, error) {
+		return []Family{{Name: "attic_assets_total", Help: "Assets.", Type: TypeGauge, Samples: []Sample{{Value: 12}}}}, nil
+	})
+	reg.RegisterCollector(func(ctx context.Context) ([]Family, error) {
+		return nil, errors.New("database down")
+	})
+
+	rec := httptest.NewRecorder()
+	reg.Handler("").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	want := `# HELP attic_assets_total Assets.
+# TYPE attic_assets_total gauge
+attic_assets_total 12
+# HELP attic_plugin_errors_total Plugin errors.
+# TYPE attic_plugin_errors_total counter
+attic_plugin_errors_total{plugin="b\"gg"} 1
+attic_plugin_errors_total{plugin="tmdb"} 2
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", got, want)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+}
+
+func Test_Registry_Handler_RequiresToken(t *testing.T) {
+	handler := NewRegistry().Handler("secret")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 with token, got %d", rec.Code)
+	}
+}
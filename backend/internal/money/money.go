@@ -0,0 +1,186 @@
+// Package money parses localized price strings such as "1.299,99 €" or
+// "US$ 1,299.99" into an amount and an ISO 4217 currency code.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Price is a parsed amount with its currency ("" if the input had none)
+type Price struct {
+	Amount   float64
+	Currency string
+}
+
+// symbols maps currency symbols to ISO 4217 codes. Ambiguous symbols like
+// "kr" are not recognized; clients should send the code instead.
+var symbols = map[string]string{
+	"€":   "EUR",
+	"$":   "USD",
+	"US$": "USD",
+	"£":   "GBP",
+	"¥":   "JPY",
+	"₹":   "INR",
+	"R$":  "BRL",
+	"zł":  "PLN",
+	"Fr.": "CHF",
+	"₺":   "TRY",
+	"₩":   "KRW",
+	"₽":   "RUB",
+	"Kč":  "CZK",
+}
+
+// commaLanguages use a decimal comma
+var commaLanguages = map[string]bool{
+	"bg": true, "ca": true, "cs": true, "da": true, "de": true, "el": true, "es": true,
+	"et": true, "fi": true, "fr": true, "hr": true, "hu": true, "id": true, "is": true,
+	"it": true, "lt": true, "lv": true, "nb": true, "nl": true, "nn": true, "no": true,
+	"pl": true, "pt": true, "ro": true, "ru": true, "sk": true, "sl": true, "sr": true,
+	"sv": true, "tr": true, "uk": true, "vi": true,
+}
+
+// DecimalSeparator returns the decimal separator of a BCP 47 locale such as
+// "de-DE" ('.' for unknown or empty locales)
+func DecimalSeparator(locale string) byte {
+	parts := strings.FieldsFunc(strings.ToLower(locale), func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) == 0 || !commaLanguages[parts[0]] {
+		return '.'
+	}
+	// Switzerland and Liechtenstein use a decimal point in every language
+	for _, region := range parts[1:] {
+		if region == "ch" || region == "li" {
+			return '.'
+		}
+	}
+	return ','
+}
+
+// NormalizeCurrency validates an ISO 4217 code and returns it in upper case
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency %q", code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency %q", code)
+		}
+	}
+	return code, nil
+}
+
+// Parse parses a price string. The currency may be given as a symbol or an
+// ISO code before or after the number. Grouping separators (".", ",", spaces
+// and apostrophes) are removed; when it is ambiguous whether a single "." or
+// "," is a decimal or grouping separator, the locale decides.
+func Parse(s, locale string) (Price, error) {
+	var price Price
+
+	number, currency, err := splitCurrency(strings.TrimSpace(s))
+	if err != nil {
+		return price, err
+	}
+	if currency != "" {
+		if price.Currency, err = NormalizeCurrency(currency); err != nil {
+			return price, err
+		}
+	}
+
+	amount, err := parseNumber(number, DecimalSeparator(locale))
+	if err != nil {
+		return price, err
+	}
+	if amount < 0 {
+		return price, errors.New("price must not be negative")
+	}
+	price.Amount = amount
+	return price, nil
+}
+
+// splitCurrency separates the number from a leading or trailing currency
+func splitCurrency(s string) (number, currency string, err error) {
+	isNumberRune := func(r rune) bool {
+		return unicode.IsDigit(r) || r == '.' || r == ',' || r == '\'' || r == '-' || unicode.IsSpace(r)
+	}
+	start := strings.IndexFunc(s, unicode.IsDigit)
+	if start < 0 {
+		return "", "", errors.New("price contains no digits")
+	}
+	// A minus sign or decimal separator may precede the first digit
+	for start > 0 && strings.ContainsRune("-.,", rune(s[start-1])) {
+		start--
+	}
+	end := strings.LastIndexFunc(s, unicode.IsDigit) + 1
+
+	number = s[start:end]
+	if strings.IndexFunc(number, func(r rune) bool { return !isNumberRune(r) }) >= 0 {
+		return "", "", fmt.Errorf("invalid price %q", s)
+	}
+
+	prefix := strings.TrimSpace(s[:start])
+	suffix := strings.TrimSpace(s[end:])
+	if prefix != "" && suffix != "" {
+		return "", "", fmt.Errorf("invalid price %q", s)
+	}
+	currency = prefix + suffix
+	if code, ok := symbols[currency]; ok {
+		currency = code
+	}
+	return number, currency, nil
+}
+
+// parseNumber parses a number with grouping separators
+func parseNumber(s string, decimal byte) (float64, error) {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '\'' {
+			return -1
+		}
+		return r
+	}, s)
+
+	lastDot, lastComma := strings.LastIndexByte(s, '.'), strings.LastIndexByte(s, ',')
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// Both present: the last one is the decimal separator
+		if lastDot > lastComma {
+			decimal = '.'
+		} else {
+			decimal = ','
+		}
+	case lastDot >= 0 || lastComma >= 0:
+		sep := byte('.')
+		if lastComma >= 0 {
+			sep = ','
+		}
+		last := max(lastDot, lastComma)
+		// Repeated, or followed by exactly three digits in a locale where
+		// it is not the decimal separator: a grouping separator
+		if strings.Count(s, string(sep)) > 1 || (sep != decimal && len(s)-last-1 == 3) {
+			decimal = 0
+		} else {
+			decimal = sep
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == decimal:
+			b.WriteByte('.')
+		case c == '.' || c == ',':
+			// Grouping separator
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	amount, err := strconv.ParseFloat(b.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return amount, nil
+}
@@ -0,0 +1,70 @@
+package money
+
+import "testing"
+
+func Test_Parse_LocalizedFormats(t *testing.T) {
+	tests := []struct {
+		input    string
+		locale   string
+		amount   float64
+		currency string
+	}{
+		{"1299.99", "", 1299.99, ""},
+		{"1.299,99 €", "de-DE", 1299.99, "EUR"},
+		{"1.299,99 €", "", 1299.99, "EUR"},
+		{"€1,299.99", "en-IE", 1299.99, "EUR"},
+		{"US$ 1,299.99", "en-US", 1299.99, "USD"},
+		{"1 299,99 EUR", "fr-FR", 1299.99, "EUR"},
+		{"1 299,99 €", "fr-FR", 1299.99, "EUR"},
+		{"CHF 1'299.90", "de-CH", 1299.90, "CHF"},
+		{"12,50", "", 12.50, ""},
+		{"1,299", "en-US", 1299, ""},
+		{"1,299", "de", 1.299, ""},
+		{"1.299", "de-AT", 1299, ""},
+		{"1.299", "en", 1.299, ""},
+		{"1.234.567", "", 1234567, ""},
+		{"£5", "en-GB", 5, "GBP"},
+		{"45 usd", "", 45, "USD"},
+		{",5", "de", 0.5, ""},
+	}
+
+	for _, tt := range tests {
+		price, err := Parse(tt.input, tt.locale)
+		if err != nil {
+			t.Errorf("Parse(%q, %q) failed: %v", tt.input, tt.locale, err)
+			continue
+		}
+		if price.Amount != tt.amount || price.Currency != tt.currency {
+			t.Errorf("Parse(%q, %q) = %v %q, want %v %q", tt.input, tt.locale, price.Amount, price.Currency, tt.amount, tt.currency)
+		}
+	}
+}
+
+func Test_Parse_Invalid(t *testing.T) {
+	invalid := []string{"", "€", "abc", "-5", "1.2.3,4,5", "12 EUR 5", "EUR 5 USD", "5 euros", "1x2"}
+	for _, input := range invalid {
+		if _, err := Parse(input, "de-DE"); err == nil {
+			t.Errorf("expected %q to be rejected", input)
+		}
+	}
+}
+
+func Test_DecimalSeparator(t *testing.T) {
+	tests := map[string]byte{"": '.', "en-US": '.', "de": ',', "pt_BR": ',', "de-CH": '.', "fr-LI": '.', "ja": '.'}
+	for locale, want := range tests {
+		if got := DecimalSeparator(locale); got != want {
+			t.Errorf("DecimalSeparator(%q) = %q, want %q", locale, got, want)
+		}
+	}
+}
+
+func Test_NormalizeCurrency(t *testing.T) {
+	if code, err := NormalizeCurrency(" eur "); err != nil || code != "EUR" {
+		t.Errorf("expected EUR, got %q (%v)", code, err)
+	}
+	for _, code := range []string{"", "EU", "EURO", "E1R"} {
+		if _, err := NormalizeCurrency(code); err == nil {
+			t.Errorf("expected %q to be rejected", code)
+		}
+	}
+}
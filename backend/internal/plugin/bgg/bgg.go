@@ -22,6 +22,9 @@ const (
 	bggAPIKeyEnvVar = "ATTIC_BGG_API_KEY"
 )
 
+// version is reported by Version; bump it when the imported data changes
+const version = "1.0.0"
+
 // APIKey can be set at build time via ldflags:
 // go build -ldflags="-X github.com/lmmendes/attic/internal/plugin/bgg.APIKey=your-key"
 var APIKey = ""
@@ -78,6 +81,16 @@ func (p *Plugin) DisabledReason() string {
 	return "Missing API key: " + bggAPIKeyEnvVar
 }
 
+// Version returns the plugin version
+func (p *Plugin) Version() string {
+	return version
+}
+
+// Capabilities returns the optional features the plugin supports
+func (p *Plugin) Capabilities() []domain.PluginCapability {
+	return []domain.PluginCapability{domain.CapabilityImages}
+}
+
 // CategoryName returns the category this plugin manages
 func (p *Plugin) CategoryName() string {
 	return "Board Games"
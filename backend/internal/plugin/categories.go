@@ -0,0 +1,104 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// CategoryStore is the category storage used to reconcile plugin categories
+type CategoryStore interface {
+	GetByPluginID(ctx context.Context, orgID uuid.UUID, pluginID string) (*domain.Category, error)
+	GetByName(ctx context.Context, orgID uuid.UUID, name string) (*domain.Category, error)
+	SyncPluginName(ctx context.Context, id uuid.UUID, name, pluginName string) error
+}
+
+// CategoryAction is the outcome of reconciling a plugin category name
+type CategoryAction string
+
+const (
+	CategoryRenamed  CategoryAction = "renamed"  // Followed the plugin's new name
+	CategoryKept     CategoryAction = "kept"     // Renamed by the user, their name is kept
+	CategoryConflict CategoryAction = "conflict" // Another category has the new name
+)
+
+// CategoryChange reports a plugin whose category name drifted from the
+// category stored for it
+type CategoryChange struct {
+	PluginID string
+	From     string
+	To       string
+	Action   CategoryAction
+}
+
+// ReconcileCategories checks the categories of all registered plugins against
+// their current CategoryName, see ReconcileCategory
+func (r *Registry) ReconcileCategories(ctx context.Context, store CategoryStore, orgID uuid.UUID) ([]CategoryChange, error) {
+	plugins := r.List()
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].ID() < plugins[j].ID() })
+
+	var changes []CategoryChange
+	for _, p := range plugins {
+		change, err := ReconcileCategory(ctx, store, orgID, p)
+		if err != nil {
+			return changes, fmt.Errorf("plugin %s: %w", p.ID(), err)
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	return changes, nil
+}
+
+// ReconcileCategory follows a plugin's renamed category (e.g. "Board Games"
+// to "Boardgames") so imports keep using the existing category instead of
+// diverging from the plugin. A name changed by the user is kept, and the
+// category is left alone while another category already has the new name.
+// It returns nil when there is nothing to reconcile.
+func ReconcileCategory(ctx context.Context, store CategoryStore, orgID uuid.UUID, p domain.ImportPlugin) (*CategoryChange, error) {
+	cat, err := store.GetByPluginID(ctx, orgID, p.ID())
+	if err != nil || cat == nil {
+		return nil, err
+	}
+
+	name := p.CategoryName()
+	previous := cat.Name
+	if cat.PluginCategoryName != nil {
+		previous = *cat.PluginCategoryName
+	}
+	if previous == name {
+		return nil, nil
+	}
+
+	change := &CategoryChange{PluginID: p.ID(), From: cat.Name, To: name}
+	switch {
+	case cat.Name == name:
+		// Already named like the plugin, only the recorded name is outdated
+		change = nil
+	case cat.Name != previous:
+		change.Action = CategoryKept
+		name = cat.Name
+	default:
+		other, err := store.GetByName(ctx, orgID, name)
+		if err != nil {
+			return nil, err
+		}
+		if other != nil && other.ID != cat.ID {
+			// Recorded name stays outdated, so the rename is retried once resolved
+			change.Action = CategoryConflict
+			slog.Warn("plugin category rename conflicts with an existing category",
+				"plugin_id", p.ID(), "category", cat.Name, "new_name", name, "conflicting_id", other.ID)
+			return change, nil
+		}
+		change.Action = CategoryRenamed
+	}
+
+	if err := store.SyncPluginName(ctx, cat.ID, name, p.CategoryName()); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
@@ -0,0 +1,138 @@
+package plugin
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// fakeCategoryStore implements CategoryStore in memory
+type fakeCategoryStore struct {
+	categories []*domain.Category
+}
+
+func (s *fakeCategoryStore) GetByPluginID(_ context.Context, _ uuid.UUID, pluginID string) (*domain.Category, error) {
+	for _, c := range s.categories {
+		if c.PluginID != nil && *c.PluginID == pluginID {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeCategoryStore) GetByName(_ context.Context, _ uuid.UUID, name string) (*domain.Category, error) {
+	for _, c := range s.categories {
+		if strings.EqualFold(c.Name, name) {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeCategoryStore) SyncPluginName(_ context.Context, id uuid.UUID, name, pluginName string) error {
+	for _, c := range s.categories {
+		if c.ID == id {
+			c.Name = name
+			c.PluginCategoryName = &pluginName
+		}
+	}
+	return nil
+}
+
+// categoryPlugin is a mock plugin with a configurable category name
+type categoryPlugin struct {
+	*mockPlugin
+	category string
+}
+
+func (p *categoryPlugin) CategoryName() string { return p.category }
+
+func pluginCategory(pluginID, name, pluginName string) *domain.Category {
+	return &domain.Category{ID: uuid.New(), PluginID: &pluginID, Name: name, PluginCategoryName: &pluginName}
+}
+
+func Test_ReconcileCategory(t *testing.T) {
+	ctx := context.Background()
+	p := &categoryPlugin{mockPlugin: newMockPlugin("bgg", "BoardGameGeek", ""), category: "Boardgames"}
+
+	tests := []struct {
+		name       string
+		categories []*domain.Category
+		wantAction CategoryAction
+		wantName   string
+	}{
+		{
+			name:       "plugin renamed its category",
+			categories: []*domain.Category{pluginCategory("bgg", "Board Games", "Board Games")},
+			wantAction: CategoryRenamed,
+			wantName:   "Boardgames",
+		},
+		{
+			name:       "user renamed the category",
+			categories: []*domain.Category{pluginCategory("bgg", "Tabletop", "Board Games")},
+			wantAction: CategoryKept,
+			wantName:   "Tabletop",
+		},
+		{
+			name: "new name taken by another category",
+			categories: []*domain.Category{
+				pluginCategory("bgg", "Board Games", "Board Games"),
+				{ID: uuid.New(), Name: "boardgames"},
+			},
+			wantAction: CategoryConflict,
+			wantName:   "Board Games",
+		},
+		{
+			name:       "in sync",
+			categories: []*domain.Category{pluginCategory("bgg", "Boardgames", "Boardgames")},
+			wantName:   "Boardgames",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeCategoryStore{categories: tt.categories}
+
+			change, err := ReconcileCategory(ctx, store, uuid.Nil, p)
+			if err != nil {
+				t.Fatalf("ReconcileCategory: %v", err)
+			}
+
+			var action CategoryAction
+			if change != nil {
+				action = change.Action
+			}
+			if action != tt.wantAction {
+				t.Errorf("expected action %q, got %q", tt.wantAction, action)
+			}
+			if got := tt.categories[0].Name; got != tt.wantName {
+				t.Errorf("expected category name %q, got %q", tt.wantName, got)
+			}
+			// Conflicts are retried on the next start
+			wantRecorded := "Boardgames"
+			if tt.wantAction == CategoryConflict {
+				wantRecorded = "Board Games"
+			}
+			if got := *tt.categories[0].PluginCategoryName; got != wantRecorded {
+				t.Errorf("expected recorded plugin name %q, got %q", wantRecorded, got)
+			}
+		})
+	}
+}
+
+func Test_Registry_ReconcileCategories_SkipsPluginsWithoutCategory(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&categoryPlugin{mockPlugin: newMockPlugin("bgg", "BoardGameGeek", ""), category: "Boardgames"})
+	registry.Register(newMockPlugin("tmdb", "TMDb", ""))
+	store := &fakeCategoryStore{categories: []*domain.Category{pluginCategory("bgg", "Board Games", "Board Games")}}
+
+	changes, err := registry.ReconcileCategories(context.Background(), store, uuid.Nil)
+	if err != nil {
+		t.Fatalf("ReconcileCategories: %v", err)
+	}
+	if len(changes) != 1 || changes[0].PluginID != "bgg" || changes[0].From != "Board Games" || changes[0].To != "Boardgames" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
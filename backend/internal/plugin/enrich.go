@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// EnrichmentNamespace prefixes the attribute keys filled in by enrichment
+// plugins, e.g. "product.model" or "product.screen_size"
+const EnrichmentNamespace = "product"
+
+// maxSpecKeyLength limits attribute keys derived from specification names
+const maxSpecKeyLength = 64
+
+// Attribute names (last segment of the key) identifying a product
+var (
+	brandKeys = []string{"brand", "manufacturer", "make"}
+	modelKeys = []string{"model", "model_number", "model_no"}
+	upcKeys   = []string{"upc", "ean", "gtin", "barcode"}
+)
+
+// ProductQueryFromAttributes reads the brand, model and barcode from asset
+// attributes, matching keys such as "upc" or "appliances.manufacturer"
+func ProductQueryFromAttributes(attrs map[string]any) domain.ProductQuery {
+	var q domain.ProductQuery
+	for key, value := range attrs {
+		s, ok := value.(string)
+		if !ok || strings.TrimSpace(s) == "" {
+			continue
+		}
+		s = strings.TrimSpace(s)
+		name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+		switch {
+		case q.Brand == "" && slices.Contains(brandKeys, name):
+			q.Brand = s
+		case q.Model == "" && slices.Contains(modelKeys, name):
+			q.Model = s
+		case q.UPC == "" && slices.Contains(upcKeys, name):
+			q.UPC = s
+		}
+	}
+	return q
+}
+
+// EnrichmentAttributes converts product data into asset attribute values
+func EnrichmentAttributes(data *domain.ProductData) map[string]any {
+	values := make(map[string]any)
+	set := func(name, value string) {
+		key := specKey(name)
+		if value = strings.TrimSpace(value); key != "" && value != "" {
+			values[EnrichmentNamespace+"."+key] = value
+		}
+	}
+
+	for name, value := range data.Specs {
+		set(name, value)
+	}
+	// Identifiers win over specifications of the same name
+	set("brand", data.Brand)
+	set("model", data.Model)
+	set("upc", data.UPC)
+	return values
+}
+
+// MergeAttributes copies values into attrs, keeping existing non-empty values
+// unless overwrite is set, and returns the keys that changed in order
+func MergeAttributes(attrs, values map[string]any, overwrite bool) []string {
+	var changed []string
+	for key, value := range values {
+		if current, ok := attrs[key]; ok && current != nil && current != "" && !overwrite {
+			continue
+		}
+		if attrs[key] != value {
+			attrs[key] = value
+			changed = append(changed, key)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// specKey turns a specification name into an attribute key segment, e.g.
+// "Screen Size (in)" becomes "screen_size_in"
+func specKey(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if underscore && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+			underscore = false
+		} else {
+			underscore = true
+		}
+	}
+	key := b.String()
+	if len(key) > maxSpecKeyLength {
+		key = strings.TrimRight(key[:maxSpecKeyLength], "_")
+	}
+	return key
+}
@@ -0,0 +1,94 @@
+package plugin
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func Test_ProductQueryFromAttributes(t *testing.T) {
+	q := ProductQueryFromAttributes(map[string]any{
+		"appliances.manufacturer": "Bosch",
+		"model_number":            " WAN28K40 ",
+		"ean":                     "4242005183529",
+		"notes":                   "kitchen",
+		"brand":                   42, // Not a string
+	})
+
+	want := domain.ProductQuery{Brand: "Bosch", Model: "WAN28K40", UPC: "4242005183529"}
+	if q != want {
+		t.Errorf("expected %+v, got %+v", want, q)
+	}
+}
+
+func Test_EnrichmentAttributes(t *testing.T) {
+	values := EnrichmentAttributes(&domain.ProductData{
+		Brand: "Sony",
+		Model: "WH-1000XM4",
+		Specs: map[string]string{
+			"Weight":            "254 g",
+			"Battery Life (h)":  "30",
+			"Model":             "ignored, identifiers win",
+			"Empty":             " ",
+			"Wireless — range!": "10 m",
+		},
+	})
+
+	want := map[string]any{
+		"product.brand":          "Sony",
+		"product.model":          "WH-1000XM4",
+		"product.weight":         "254 g",
+		"product.battery_life_h": "30",
+		"product.wireless_range": "10 m",
+	}
+	if len(values) != len(want) {
+		t.Fatalf("expected %v, got %v", want, values)
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, values[k])
+		}
+	}
+}
+
+func Test_MergeAttributes(t *testing.T) {
+	attrs := map[string]any{
+		"product.brand":  "Sony",
+		"product.weight": "250 g",
+		"product.color":  "",
+	}
+	values := map[string]any{
+		"product.brand":  "Sony",
+		"product.weight": "254 g",
+		"product.color":  "Black",
+		"product.size":   "One size",
+	}
+
+	changed := MergeAttributes(attrs, values, false)
+	if !slices.Equal(changed, []string{"product.color", "product.size"}) {
+		t.Errorf("unexpected changed keys %v", changed)
+	}
+	if attrs["product.weight"] != "250 g" {
+		t.Errorf("expected existing value to be kept, got %v", attrs["product.weight"])
+	}
+
+	changed = MergeAttributes(attrs, values, true)
+	if !slices.Equal(changed, []string{"product.weight"}) {
+		t.Errorf("unexpected changed keys with overwrite %v", changed)
+	}
+}
+
+func Test_specKey(t *testing.T) {
+	tests := map[string]string{
+		"Screen Size (in)": "screen_size_in",
+		"  Weight ":        "weight",
+		"Wi-Fi 6E":         "wi_fi_6e",
+		"---":              "",
+	}
+	for name, want := range tests {
+		if got := specKey(name); got != want {
+			t.Errorf("specKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -18,6 +18,9 @@ const (
 	defaultLimit = 10
 )
 
+// version is reported by Version; bump it when the imported data changes
+const version = "1.0.0"
+
 // Plugin implements the Google Books import plugin
 type Plugin struct {
 	client *http.Client
@@ -57,6 +60,16 @@ func (p *Plugin) DisabledReason() string {
 	return ""
 }
 
+// Version returns the plugin version
+func (p *Plugin) Version() string {
+	return version
+}
+
+// Capabilities returns the optional features the plugin supports
+func (p *Plugin) Capabilities() []domain.PluginCapability {
+	return []domain.PluginCapability{domain.CapabilityBarcode, domain.CapabilityImages}
+}
+
 // CategoryName returns the category this plugin manages
 func (p *Plugin) CategoryName() string {
 	return "Books"
@@ -84,7 +97,7 @@ func (p *Plugin) Attributes() []domain.PluginAttribute {
 func (p *Plugin) SearchFields() []domain.SearchField {
 	return []domain.SearchField{
 		{Key: "title", Label: "Title"},
-		{Key: "isbn", Label: "ISBN"},
+		{Key: "isbn", Label: "ISBN", Barcode: true},
 		{Key: "author", Label: "Author"},
 	}
 }
@@ -0,0 +1,363 @@
+// Package httpplugin implements import plugins defined entirely by
+// configuration: URL templates for searching and fetching items from a JSON
+// HTTP API, and JSONPath mappings from its responses to assets.
+package httpplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/jsonpath"
+)
+
+// IDPrefix prefixes the plugin IDs of generic HTTP plugins
+const IDPrefix = "http_"
+
+// version is reported by Version for all generic HTTP plugins
+const version = "1.0.0"
+
+// maxResponseSize is the largest API response that is read
+const maxResponseSize = 5 << 20
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_]{0,39}$`)
+
+// Plugin is a generic HTTP import plugin
+type Plugin struct {
+	cfg     domain.HTTPPluginConfig
+	headers map[string]string
+	client  *http.Client
+
+	results, resultID, resultTitle, resultSubtitle, resultImage *jsonpath.Path
+	name, description, image                                    *jsonpath.Path
+	attributes                                                  []attribute
+}
+
+type attribute struct {
+	domain.PluginAttribute
+	path *jsonpath.Path
+}
+
+// New validates a plugin configuration and creates the plugin; headers are
+// sent with every request
+func New(cfg domain.HTTPPluginConfig, headers map[string]string) (*Plugin, error) {
+	if !idPattern.MatchString(cfg.ID) {
+		return nil, errors.New("id must be a lowercase slug (a-z, 0-9, _)")
+	}
+	if strings.TrimSpace(cfg.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	if strings.TrimSpace(cfg.CategoryName) == "" {
+		return nil, errors.New("category_name is required")
+	}
+	if err := validateTemplate("search_url", cfg.SearchURL, "{query}"); err != nil {
+		return nil, err
+	}
+	if err := validateTemplate("fetch_url", cfg.FetchURL, "{id}"); err != nil {
+		return nil, err
+	}
+
+	p := &Plugin{
+		cfg:     cfg,
+		headers: headers,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+
+	var err error
+	paths := []struct {
+		field    string
+		expr     string
+		required bool
+		dest     **jsonpath.Path
+	}{
+		{"results_path", cfg.ResultsPath, true, &p.results},
+		{"result_id", cfg.ResultID, true, &p.resultID},
+		{"result_title", cfg.ResultTitle, true, &p.resultTitle},
+		{"result_subtitle", cfg.ResultSubtitle, false, &p.resultSubtitle},
+		{"result_image", cfg.ResultImage, false, &p.resultImage},
+		{"name_path", cfg.NamePath, true, &p.name},
+		{"description_path", cfg.DescriptionPath, false, &p.description},
+		{"image_path", cfg.ImagePath, false, &p.image},
+	}
+	for _, f := range paths {
+		if f.expr == "" {
+			if f.required {
+				return nil, fmt.Errorf("%s is required", f.field)
+			}
+			continue
+		}
+		if *f.dest, err = jsonpath.Parse(f.expr); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.field, err)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, a := range cfg.Attributes {
+		if !idPattern.MatchString(a.Key) {
+			return nil, fmt.Errorf("attribute key %q must be a lowercase slug (a-z, 0-9, _)", a.Key)
+		}
+		if seen[a.Key] {
+			return nil, fmt.Errorf("duplicate attribute key %q", a.Key)
+		}
+		seen[a.Key] = true
+
+		switch a.DataType {
+		case domain.AttributeTypeString, domain.AttributeTypeNumber, domain.AttributeTypeBoolean,
+			domain.AttributeTypeText, domain.AttributeTypeDate:
+		default:
+			return nil, fmt.Errorf("attribute %q: invalid data_type %q", a.Key, a.DataType)
+		}
+		path, err := jsonpath.Parse(a.Path)
+		if err != nil || a.Path == "" {
+			return nil, fmt.Errorf("attribute %q: invalid path %q", a.Key, a.Path)
+		}
+
+		name := a.Name
+		if name == "" {
+			name = a.Key
+		}
+		p.attributes = append(p.attributes, attribute{
+			PluginAttribute: domain.PluginAttribute{
+				Key:      p.ID() + "." + a.Key,
+				Name:     name,
+				DataType: a.DataType,
+			},
+			path: path,
+		})
+	}
+	return p, nil
+}
+
+func validateTemplate(field, template, placeholder string) error {
+	if !strings.Contains(template, placeholder) {
+		return fmt.Errorf("%s must contain %s", field, placeholder)
+	}
+	u, err := url.Parse(strings.NewReplacer("{query}", "x", "{limit}", "1", "{id}", "x").Replace(template))
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("%s must be an absolute http(s) URL", field)
+	}
+	return nil
+}
+
+// ID returns the plugin identifier
+func (p *Plugin) ID() string {
+	return IDPrefix + p.cfg.ID
+}
+
+// Name returns the display name
+func (p *Plugin) Name() string {
+	return p.cfg.Name
+}
+
+// Description returns the plugin description
+func (p *Plugin) Description() string {
+	return p.cfg.Description
+}
+
+// Enabled returns true as the configuration was validated by New
+func (p *Plugin) Enabled() bool {
+	return true
+}
+
+// DisabledReason returns empty string as configured plugins are always enabled
+func (p *Plugin) DisabledReason() string {
+	return ""
+}
+
+// Version returns the plugin version
+func (p *Plugin) Version() string {
+	return version
+}
+
+// Capabilities returns the optional features the plugin supports: images
+// when an image path is configured
+func (p *Plugin) Capabilities() []domain.PluginCapability {
+	if p.cfg.ImagePath != "" || p.cfg.ResultImage != "" {
+		return []domain.PluginCapability{domain.CapabilityImages}
+	}
+	return []domain.PluginCapability{}
+}
+
+// CategoryName returns the category this plugin manages
+func (p *Plugin) CategoryName() string {
+	return p.cfg.CategoryName
+}
+
+// CategoryDescription returns the category description
+func (p *Plugin) CategoryDescription() string {
+	return "Items imported from " + p.cfg.Name
+}
+
+// Attributes returns the attributes this plugin provides
+func (p *Plugin) Attributes() []domain.PluginAttribute {
+	attrs := make([]domain.PluginAttribute, 0, len(p.attributes))
+	for _, a := range p.attributes {
+		attrs = append(attrs, a.PluginAttribute)
+	}
+	return attrs
+}
+
+// SearchFields returns the available search fields
+func (p *Plugin) SearchFields() []domain.SearchField {
+	return []domain.SearchField{{Key: "query", Label: "Search"}}
+}
+
+// Search queries the search URL and maps the results
+func (p *Plugin) Search(ctx context.Context, _, query string, limit int) ([]domain.SearchResult, error) {
+	u := strings.NewReplacer(
+		"{query}", url.QueryEscape(query),
+		"{limit}", strconv.Itoa(limit),
+	).Replace(p.cfg.SearchURL)
+
+	doc, err := p.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	items := p.results.Get(doc)
+	if len(items) == 1 {
+		if list, ok := items[0].([]any); ok {
+			items = list
+		}
+	}
+
+	results := make([]domain.SearchResult, 0, len(items))
+	for _, item := range items {
+		id := firstText(p.resultID, item)
+		title := firstText(p.resultTitle, item)
+		if id == "" || title == "" {
+			continue
+		}
+		result := domain.SearchResult{
+			ExternalID: id,
+			Title:      title,
+			Subtitle:   firstText(p.resultSubtitle, item),
+		}
+		if image := firstText(p.resultImage, item); image != "" {
+			result.ImageURL = &image
+		}
+		results = append(results, result)
+		if limit > 0 && len(results) == limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Fetch retrieves an item by external ID and maps it to import data
+func (p *Plugin) Fetch(ctx context.Context, externalID string) (*domain.ImportData, error) {
+	u := strings.ReplaceAll(p.cfg.FetchURL, "{id}", url.PathEscape(externalID))
+
+	doc, err := p.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &domain.ImportData{
+		Name:       firstText(p.name, doc),
+		ExternalID: externalID,
+		Attributes: map[string]any{},
+	}
+	if desc := firstText(p.description, doc); desc != "" {
+		data.Description = &desc
+	}
+	if image := firstText(p.image, doc); image != "" {
+		data.ImageURL = &image
+	}
+
+	for _, a := range p.attributes {
+		// Wildcard paths matching several values (e.g. a list of authors)
+		// are joined for string attributes
+		var v any
+		switch values := a.path.Get(doc); len(values) {
+		case 0:
+			continue
+		case 1:
+			v = values[0]
+		default:
+			v = values
+		}
+		if v == nil {
+			continue
+		}
+		if value, ok := convert(v, a.DataType); ok {
+			data.Attributes[a.Key] = value
+		}
+	}
+	return data, nil
+}
+
+// get fetches and decodes a JSON document
+func (p *Plugin) get(ctx context.Context, u string) (any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("item not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var doc any
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	return doc, nil
+}
+
+func firstText(path *jsonpath.Path, doc any) string {
+	if path == nil {
+		return ""
+	}
+	v, _ := path.First(doc)
+	return strings.TrimSpace(jsonpath.Text(v))
+}
+
+// convert turns a JSON value into an attribute value of the given type
+func convert(v any, dataType domain.AttributeDataType) (any, bool) {
+	switch dataType {
+	case domain.AttributeTypeNumber:
+		switch t := v.(type) {
+		case float64:
+			return t, true
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+			return f, err == nil
+		}
+		return nil, false
+	case domain.AttributeTypeBoolean:
+		switch t := v.(type) {
+		case bool:
+			return t, true
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(t))
+			return b, err == nil
+		}
+		return nil, false
+	default:
+		s := strings.TrimSpace(jsonpath.Text(v))
+		return s, s != ""
+	}
+}
@@ -0,0 +1,136 @@
+package httpplugin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func testConfig(baseURL string) domain.HTTPPluginConfig {
+	return domain.HTTPPluginConfig{
+		ID:           "records",
+		Name:         "Records",
+		CategoryName: "Vinyl",
+		SearchURL:    baseURL + "/search?q={query}&n={limit}",
+		FetchURL:     baseURL + "/items/{id}",
+		ResultsPath:  "$.results",
+		ResultID:     "$.id",
+		ResultTitle:  "$.title",
+		ResultImage:  "$.cover",
+		NamePath:     "$.title",
+		Attributes: []domain.HTTPPluginAttribute{
+			{Key: "year", DataType: domain.AttributeTypeNumber, Path: "$.year"},
+			{Key: "artists", DataType: domain.AttributeTypeString, Path: "$.artists[*].name"},
+			{Key: "reissue", DataType: domain.AttributeTypeBoolean, Path: "$.reissue"},
+		},
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*domain.HTTPPluginConfig)
+	}{
+		{"invalid id", func(c *domain.HTTPPluginConfig) { c.ID = "Bad ID" }},
+		{"missing placeholder", func(c *domain.HTTPPluginConfig) { c.SearchURL = "https://example.com/search" }},
+		{"relative url", func(c *domain.HTTPPluginConfig) { c.FetchURL = "/items/{id}" }},
+		{"missing title path", func(c *domain.HTTPPluginConfig) { c.ResultTitle = "" }},
+		{"invalid path", func(c *domain.HTTPPluginConfig) { c.NamePath = "$..title" }},
+		{"invalid data type", func(c *domain.HTTPPluginConfig) { c.Attributes[0].DataType = "color" }},
+		{"duplicate attribute", func(c *domain.HTTPPluginConfig) { c.Attributes[1].Key = "year" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig("https://example.com")
+			tt.modify(&cfg)
+			if _, err := New(cfg, nil); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestPlugin_SearchAndFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("q") != "blue train" || r.URL.Query().Get("n") != "5" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"results": [
+			{"id": 42, "title": "Blue Train", "cover": "https://img.example.com/42.jpg"},
+			{"id": 43},
+			{"id": "a-1", "title": "Blue Train (Mono)"}
+		]}`))
+	})
+	mux.HandleFunc("/items/42", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"title": "Blue Train", "year": "1957", "reissue": false,
+			"artists": [{"name": "John Coltrane"}, {"name": "Lee Morgan"}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p, err := New(testConfig(srv.URL), map[string]string{"X-Api-Key": "secret"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if p.ID() != "http_records" {
+		t.Errorf("ID = %q", p.ID())
+	}
+
+	results, err := p.Search(context.Background(), "query", "blue train", 5)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2 (results without a title are skipped)", len(results))
+	}
+	if results[0].ExternalID != "42" || results[0].ImageURL == nil || results[1].ExternalID != "a-1" {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	data, err := p.Fetch(context.Background(), "42")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if data.Name != "Blue Train" {
+		t.Errorf("Name = %q", data.Name)
+	}
+	want := map[string]any{
+		"http_records.year":    1957.0,
+		"http_records.artists": "John Coltrane, Lee Morgan",
+		"http_records.reissue": false,
+	}
+	for key, value := range want {
+		if data.Attributes[key] != value {
+			t.Errorf("attribute %s = %v, want %v", key, data.Attributes[key], value)
+		}
+	}
+
+	if _, err := p.Fetch(context.Background(), "missing"); err == nil || err.Error() != "item not found" {
+		t.Errorf("Fetch missing item: err = %v", err)
+	}
+}
+
+func TestPlugin_Capabilities(t *testing.T) {
+	cfg := testConfig("https://api.example.com")
+	p, err := New(cfg, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if caps := p.Capabilities(); len(caps) != 1 || caps[0] != domain.CapabilityImages {
+		t.Errorf("expected images capability, got %v", caps)
+	}
+
+	cfg.ResultImage = ""
+	p, _ = New(cfg, nil)
+	if caps := p.Capabilities(); caps == nil || len(caps) != 0 {
+		t.Errorf("expected no capabilities, got %v", caps)
+	}
+}
@@ -0,0 +1,189 @@
+package icecat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+const (
+	PluginID = "icecat"
+	baseURL  = "https://live.icecat.biz/api"
+	language = "en"
+)
+
+// maxSpecs limits the specifications taken from a product sheet
+const maxSpecs = 100
+
+const usernameEnvVar = "ATTIC_ICECAT_USERNAME"
+
+// Plugin implements the Open Icecat enrichment plugin, which has detailed
+// specification sheets for electronics and appliances
+type Plugin struct {
+	client   *http.Client
+	username string
+}
+
+// New creates a new Icecat plugin
+func New() *Plugin {
+	return &Plugin{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		username: os.Getenv(usernameEnvVar),
+	}
+}
+
+// ID returns the plugin identifier
+func (p *Plugin) ID() string {
+	return PluginID
+}
+
+// Name returns the display name
+func (p *Plugin) Name() string {
+	return "Icecat"
+}
+
+// Description returns the plugin description
+func (p *Plugin) Description() string {
+	return "Fill in specifications of electronics and appliances from Open Icecat"
+}
+
+// Enabled returns true if an Icecat account is configured
+func (p *Plugin) Enabled() bool {
+	return p.username != ""
+}
+
+// DisabledReason returns the reason the plugin is disabled
+func (p *Plugin) DisabledReason() string {
+	if p.Enabled() {
+		return ""
+	}
+	return "Missing Icecat username: " + usernameEnvVar
+}
+
+// Lookup finds a product sheet by GTIN, or else by brand and model
+func (p *Plugin) Lookup(ctx context.Context, q domain.ProductQuery) (*domain.ProductData, error) {
+	params := url.Values{}
+	params.Set("UserName", p.username)
+	params.Set("Language", language)
+	switch {
+	case q.UPC != "":
+		params.Set("GTIN", q.UPC)
+	case q.Brand != "" && q.Model != "":
+		params.Set("Brand", q.Brand)
+		params.Set("ProductCode", q.Model)
+	default:
+		return nil, domain.ErrProductNotFound
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Unknown products are reported as 404, products without a sheet in the
+	// open catalog as 403
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+		return nil, domain.ErrProductNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var apiResp productResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if apiResp.Data.GeneralInfo.Title == "" {
+		return nil, domain.ErrProductNotFound
+	}
+
+	return toProductData(apiResp.Data), nil
+}
+
+func toProductData(product product) *domain.ProductData {
+	info := product.GeneralInfo
+	data := &domain.ProductData{
+		Title: info.Title,
+		Brand: info.Brand,
+		Model: info.BrandPartCode,
+		Specs: map[string]string{},
+	}
+	if len(info.GTIN) > 0 {
+		data.UPC = info.GTIN[0]
+	}
+	if desc := info.SummaryDescription.LongSummaryDescription; desc != "" {
+		data.Description = &desc
+	} else if desc := info.Description.LongDesc; desc != "" {
+		data.Description = &desc
+	}
+	if product.Image.HighPic != "" {
+		data.ImageURL = &product.Image.HighPic
+	}
+	if info.IcecatID != 0 {
+		data.SourceURL = "https://icecat.biz/p/" + strconv.FormatInt(info.IcecatID, 10)
+	}
+
+	for _, group := range product.FeaturesGroups {
+		for _, f := range group.Features {
+			if len(data.Specs) == maxSpecs {
+				return data
+			}
+			if name := f.Feature.Name.Value; name != "" && f.PresentationValue != "" {
+				data.Specs[name] = f.PresentationValue
+			}
+		}
+	}
+	return data
+}
+
+// API response types
+
+type productResponse struct {
+	Msg  string  `json:"msg"`
+	Data product `json:"data"`
+}
+
+type product struct {
+	GeneralInfo struct {
+		IcecatID      int64    `json:"IcecatId"`
+		Title         string   `json:"Title"`
+		Brand         string   `json:"Brand"`
+		BrandPartCode string   `json:"BrandPartCode"`
+		GTIN          []string `json:"GTIN"`
+		Description   struct {
+			LongDesc string `json:"LongDesc"`
+		} `json:"Description"`
+		SummaryDescription struct {
+			LongSummaryDescription string `json:"LongSummaryDescription"`
+		} `json:"SummaryDescription"`
+	} `json:"GeneralInfo"`
+	Image struct {
+		HighPic string `json:"HighPic"`
+	} `json:"Image"`
+	FeaturesGroups []struct {
+		Features []struct {
+			Feature struct {
+				Name struct {
+					Value string `json:"Value"`
+				} `json:"Name"`
+			} `json:"Feature"`
+			PresentationValue string `json:"PresentationValue"`
+		} `json:"Features"`
+	} `json:"FeaturesGroups"`
+}
@@ -2,21 +2,28 @@ package plugin
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/lmmendes/attic/internal/domain"
 )
 
-// Registry holds all registered import plugins
+// Registry holds all registered import and enrichment plugins
 type Registry struct {
-	mu      sync.RWMutex
-	plugins map[string]domain.ImportPlugin
+	mu        sync.RWMutex
+	plugins   map[string]domain.ImportPlugin
+	enrichers []domain.EnrichmentPlugin // In registration (lookup) order
+	disabled  map[string]bool           // Import plugins turned off by an admin, by ID
 }
 
+// DisabledByAdminReason is the disabled reason of plugins turned off by an admin
+const DisabledByAdminReason = "disabled by an administrator"
+
 // NewRegistry creates a new plugin registry
 func NewRegistry() *Registry {
 	return &Registry{
-		plugins: make(map[string]domain.ImportPlugin),
+		plugins:  make(map[string]domain.ImportPlugin),
+		disabled: make(map[string]bool),
 	}
 }
 
@@ -34,6 +41,18 @@ func (r *Registry) Register(plugin domain.ImportPlugin) error {
 	return nil
 }
 
+// Unregister removes a plugin, reporting whether it was registered
+func (r *Registry) Unregister(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.plugins[id]; !exists {
+		return false
+	}
+	delete(r.plugins, id)
+	return true
+}
+
 // Get retrieves a plugin by ID
 func (r *Registry) Get(id string) (domain.ImportPlugin, bool) {
 	r.mu.RLock()
@@ -55,6 +74,64 @@ func (r *Registry) List() []domain.ImportPlugin {
 	return plugins
 }
 
+// SetEnabled turns an import plugin on or off, independent of whether the
+// plugin is configured (e.g. has an API key). The setting is kept for IDs
+// that are not registered (yet), such as HTTP plugins loaded later.
+func (r *Registry) SetEnabled(id string, enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if enabled {
+		delete(r.disabled, id)
+	} else {
+		r.disabled[id] = true
+	}
+}
+
+// SetDisabled replaces the set of plugins turned off by an admin
+func (r *Registry) SetDisabled(ids []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.disabled = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		r.disabled[id] = true
+	}
+}
+
+// Disabled returns the sorted IDs of plugins turned off by an admin
+func (r *Registry) Disabled() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.disabled))
+	for id := range r.disabled {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// IsDisabled reports whether an admin turned off the plugin
+func (r *Registry) IsDisabled(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.disabled[id]
+}
+
+// Available reports whether a plugin can be used for search and import: it is
+// configured and not turned off by an admin. The reason is empty when it is.
+func (r *Registry) Available(p domain.ImportPlugin) (bool, string) {
+	if r.IsDisabled(p.ID()) {
+		return false, DisabledByAdminReason
+	}
+	if !p.Enabled() {
+		return false, p.DisabledReason()
+	}
+	return true, ""
+}
+
 // ListInfo returns plugin info for all registered plugins
 func (r *Registry) ListInfo() []domain.PluginInfo {
 	r.mu.RLock()
@@ -62,7 +139,48 @@ func (r *Registry) ListInfo() []domain.PluginInfo {
 
 	infos := make([]domain.PluginInfo, 0, len(r.plugins))
 	for _, p := range r.plugins {
-		infos = append(infos, domain.PluginToInfo(p))
+		info := domain.PluginToInfo(p)
+		if r.disabled[p.ID()] {
+			info.Enabled, info.DisabledReason = false, DisabledByAdminReason
+		}
+		infos = append(infos, info)
 	}
 	return infos
 }
+
+// RegisterEnricher adds an enrichment plugin; enrichers are tried in
+// registration order
+func (r *Registry) RegisterEnricher(enricher domain.EnrichmentPlugin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.enrichers {
+		if e.ID() == enricher.ID() {
+			return fmt.Errorf("enrichment plugin %q already registered", enricher.ID())
+		}
+	}
+
+	r.enrichers = append(r.enrichers, enricher)
+	return nil
+}
+
+// GetEnricher retrieves an enrichment plugin by ID
+func (r *Registry) GetEnricher(id string) (domain.EnrichmentPlugin, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.enrichers {
+		if e.ID() == id {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// ListEnrichers returns all enrichment plugins in registration order
+func (r *Registry) ListEnrichers() []domain.EnrichmentPlugin {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]domain.EnrichmentPlugin(nil), r.enrichers...)
+}
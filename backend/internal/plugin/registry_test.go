@@ -18,6 +18,8 @@ type mockPlugin struct {
 func (m *mockPlugin) ID() string                                { return m.id }
 func (m *mockPlugin) Name() string                              { return m.name }
 func (m *mockPlugin) Description() string                       { return m.description }
+func (m *mockPlugin) Version() string                           { return "1.0.0" }
+func (m *mockPlugin) Capabilities() []domain.PluginCapability   { return nil }
 func (m *mockPlugin) Enabled() bool                             { return true }
 func (m *mockPlugin) DisabledReason() string                    { return "" }
 func (m *mockPlugin) CategoryName() string                      { return "Test Category" }
@@ -135,6 +137,48 @@ func Test_ListInfo_ReturnsPluginInfo(t *testing.T) {
 	if infos[0].Description != "Plugin for info test" {
 		t.Errorf("expected description 'Plugin for info test', got '%s'", infos[0].Description)
 	}
+	if infos[0].Version != "1.0.0" {
+		t.Errorf("expected version '1.0.0', got '%s'", infos[0].Version)
+	}
+}
+
+func Test_SetEnabled_DisablesPluginUntilReenabled(t *testing.T) {
+	registry := NewRegistry()
+	p := newMockPlugin("bgg", "BoardGameGeek", "")
+	registry.Register(p)
+
+	registry.SetEnabled("bgg", false)
+
+	if ok, reason := registry.Available(p); ok || reason != DisabledByAdminReason {
+		t.Errorf("expected plugin to be disabled by an admin, got %v, %q", ok, reason)
+	}
+	if info := registry.ListInfo()[0]; info.Enabled {
+		t.Error("expected plugin info to report the plugin as disabled")
+	}
+	if _, exists := registry.Get("bgg"); !exists {
+		t.Error("expected disabled plugin to stay registered")
+	}
+
+	registry.SetEnabled("bgg", true)
+
+	if ok, _ := registry.Available(p); !ok {
+		t.Error("expected plugin to be available again")
+	}
+}
+
+func Test_SetDisabled_ReplacesDisabledPlugins(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetEnabled("tmdb_movies", false)
+
+	// IDs of plugins registered later, e.g. HTTP plugins, are kept
+	registry.SetDisabled([]string{"http_library", "bgg"})
+
+	if got := registry.Disabled(); len(got) != 2 || got[0] != "bgg" || got[1] != "http_library" {
+		t.Errorf("expected [bgg http_library], got %v", got)
+	}
+	if registry.IsDisabled("tmdb_movies") {
+		t.Error("expected tmdb_movies to be enabled again")
+	}
 }
 
 func Test_Registry_ConcurrentAccess_IsThreadSafe(t *testing.T) {
@@ -209,3 +253,47 @@ func Test_Registry_ConcurrentRegister_HandlesRaceCondition(t *testing.T) {
 		t.Errorf("expected exactly 1 successful registration, got %d", successCount)
 	}
 }
+
+// mockEnricher implements domain.EnrichmentPlugin for testing
+type mockEnricher struct {
+	id string
+}
+
+func (m *mockEnricher) ID() string             { return m.id }
+func (m *mockEnricher) Name() string           { return m.id }
+func (m *mockEnricher) Description() string    { return "" }
+func (m *mockEnricher) Enabled() bool          { return true }
+func (m *mockEnricher) DisabledReason() string { return "" }
+func (m *mockEnricher) Lookup(_ context.Context, _ domain.ProductQuery) (*domain.ProductData, error) {
+	return nil, domain.ErrProductNotFound
+}
+
+func Test_RegisterEnricher_KeepsRegistrationOrder(t *testing.T) {
+	registry := NewRegistry()
+
+	for _, id := range []string{"icecat", "upcitemdb"} {
+		if err := registry.RegisterEnricher(&mockEnricher{id: id}); err != nil {
+			t.Fatalf("expected no error, got: %v", err)
+		}
+	}
+	if err := registry.RegisterEnricher(&mockEnricher{id: "icecat"}); err == nil {
+		t.Error("expected error for duplicate enricher ID")
+	}
+
+	enrichers := registry.ListEnrichers()
+	if len(enrichers) != 2 || enrichers[0].ID() != "icecat" || enrichers[1].ID() != "upcitemdb" {
+		t.Errorf("expected enrichers in registration order, got %v", enrichers)
+	}
+
+	if _, ok := registry.GetEnricher("upcitemdb"); !ok {
+		t.Error("expected to find upcitemdb enricher")
+	}
+	if _, ok := registry.GetEnricher("missing"); ok {
+		t.Error("expected missing enricher not to be found")
+	}
+
+	// Enrichers are separate from import plugins
+	if len(registry.List()) != 0 {
+		t.Errorf("expected no import plugins, got %d", len(registry.List()))
+	}
+}
@@ -23,6 +23,9 @@ var APIKey = ""
 
 const tmdbAPIKeyEnvVar = "ATTIC_TMDB_API_KEY"
 
+// version is reported by the plugins' Version; bump it when the imported data changes
+const version = "1.0.0"
+
 // getAPIKey returns the API key, preferring environment variable over build-time value
 func getAPIKey() string {
 	if key := os.Getenv(tmdbAPIKeyEnvVar); key != "" {
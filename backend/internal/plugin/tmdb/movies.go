@@ -50,6 +50,16 @@ func (p *MoviesPlugin) DisabledReason() string {
 	return GetDisabledReason()
 }
 
+// Version returns the plugin version
+func (p *MoviesPlugin) Version() string {
+	return version
+}
+
+// Capabilities returns the optional features the plugin supports
+func (p *MoviesPlugin) Capabilities() []domain.PluginCapability {
+	return []domain.PluginCapability{domain.CapabilityImages}
+}
+
 // CategoryName returns the category this plugin manages
 func (p *MoviesPlugin) CategoryName() string {
 	return "Movies"
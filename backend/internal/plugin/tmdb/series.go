@@ -50,6 +50,16 @@ func (p *SeriesPlugin) DisabledReason() string {
 	return GetDisabledReason()
 }
 
+// Version returns the plugin version
+func (p *SeriesPlugin) Version() string {
+	return version
+}
+
+// Capabilities returns the optional features the plugin supports
+func (p *SeriesPlugin) Capabilities() []domain.PluginCapability {
+	return []domain.PluginCapability{domain.CapabilityImages}
+}
+
 // CategoryName returns the category this plugin manages
 func (p *SeriesPlugin) CategoryName() string {
 	return "TV Series"
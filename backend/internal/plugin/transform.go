@@ -0,0 +1,97 @@
+package plugin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/expr"
+)
+
+// Import data fields transforms can rewrite besides attributes
+const (
+	TransformFieldName        = "name"
+	TransformFieldDescription = "description"
+	TransformFieldImageURL    = "image_url"
+)
+
+// transformVariables are the variables available to transform expressions:
+// the value of the transformed field and the whole import data
+var transformVariables = []string{"value", "name", "description", "image_url", "external_id", "attributes"}
+
+// CompileTransforms validates import transforms, compiling their expressions
+func CompileTransforms(transforms []domain.ImportTransform) ([]*expr.Program, error) {
+	programs := make([]*expr.Program, 0, len(transforms))
+	for i, t := range transforms {
+		if strings.TrimSpace(t.Field) == "" {
+			return nil, fmt.Errorf("transform %d: field is required", i+1)
+		}
+		p, err := expr.Compile(t.Expression, transformVariables...)
+		if err != nil {
+			return nil, fmt.Errorf("transform %d (%s): %w", i+1, t.Field, err)
+		}
+		programs = append(programs, p)
+	}
+	return programs, nil
+}
+
+// ApplyTransforms rewrites import data with transforms, in order
+func ApplyTransforms(data *domain.ImportData, transforms []domain.ImportTransform) error {
+	programs, err := CompileTransforms(transforms)
+	if err != nil {
+		return err
+	}
+	if data.Attributes == nil {
+		data.Attributes = map[string]any{}
+	}
+
+	for i, p := range programs {
+		field := transforms[i].Field
+		vars := map[string]any{
+			"name":        data.Name,
+			"description": data.Description,
+			"image_url":   data.ImageURL,
+			"external_id": data.ExternalID,
+			"attributes":  data.Attributes,
+		}
+		switch field {
+		case TransformFieldName, TransformFieldDescription, TransformFieldImageURL:
+			vars["value"] = vars[field]
+		default:
+			vars["value"] = data.Attributes[field]
+		}
+
+		result, err := p.Eval(vars)
+		if err != nil {
+			return fmt.Errorf("transform %d (%s): %w", i+1, field, err)
+		}
+
+		switch field {
+		case TransformFieldName:
+			name := strings.TrimSpace(expr.Text(result))
+			if name == "" {
+				return fmt.Errorf("transform %d (%s): name cannot be empty", i+1, field)
+			}
+			data.Name = name
+		case TransformFieldDescription:
+			data.Description = textPtr(result)
+		case TransformFieldImageURL:
+			data.ImageURL = textPtr(result)
+		default:
+			if result == nil {
+				delete(data.Attributes, field)
+			} else {
+				data.Attributes[field] = result
+			}
+		}
+	}
+	return nil
+}
+
+func textPtr(v any) *string {
+	if v == nil {
+		return nil
+	}
+	s := expr.Text(v)
+	return &s
+}
@@ -0,0 +1,60 @@
+package plugin
+
+import (
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+func TestApplyTransforms(t *testing.T) {
+	desc := "A film"
+	data := &domain.ImportData{
+		Name:        "Dune (Collector's Edition)",
+		Description: &desc,
+		ExternalID:  "438631",
+		Attributes: map[string]any{
+			"tmdb_movies.runtime": 155,
+			"tmdb_movies.tagline": "",
+		},
+	}
+	transforms := []domain.ImportTransform{
+		{Field: "name", Expression: `regex_replace(value, "\\s*\\([^)]*Edition\\)$", "")`},
+		{Field: "tmdb_movies.runtime_hours", Expression: `round(attributes["tmdb_movies.runtime"] / 60, 1)`},
+		{Field: "tmdb_movies.tagline", Expression: `value == "" ? null : value`},
+		{Field: "description", Expression: `name + ": " + value`},
+	}
+
+	if err := ApplyTransforms(data, transforms); err != nil {
+		t.Fatalf("ApplyTransforms: %v", err)
+	}
+	if data.Name != "Dune" {
+		t.Errorf("Name = %q", data.Name)
+	}
+	if data.Attributes["tmdb_movies.runtime_hours"] != 2.6 {
+		t.Errorf("runtime_hours = %v", data.Attributes["tmdb_movies.runtime_hours"])
+	}
+	if _, ok := data.Attributes["tmdb_movies.tagline"]; ok {
+		t.Error("expected empty tagline to be removed")
+	}
+	if data.Description == nil || *data.Description != "Dune: A film" {
+		t.Errorf("Description = %v", data.Description)
+	}
+}
+
+func TestApplyTransforms_Errors(t *testing.T) {
+	tests := map[string]domain.ImportTransform{
+		"missing field": {Expression: `value`},
+		"syntax error":  {Field: "name", Expression: `value +`},
+		"empty name":    {Field: "name", Expression: `null`},
+		"type error":    {Field: "name", Expression: `value * 2`},
+		"unknown var":   {Field: "name", Expression: `title`},
+	}
+	for name, transform := range tests {
+		t.Run(name, func(t *testing.T) {
+			data := &domain.ImportData{Name: "Dune"}
+			if err := ApplyTransforms(data, []domain.ImportTransform{transform}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package upcitemdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+const (
+	PluginID = "upcitemdb"
+	trialURL = "https://api.upcitemdb.com/prod/trial"
+	paidURL  = "https://api.upcitemdb.com/prod/v1"
+)
+
+const apiKeyEnvVar = "ATTIC_UPCITEMDB_KEY"
+
+// Plugin implements the UPCitemdb enrichment plugin. Without an API key the
+// free trial endpoint is used (100 requests per day).
+type Plugin struct {
+	client *http.Client
+	apiKey string
+}
+
+// New creates a new UPCitemdb plugin
+func New() *Plugin {
+	return &Plugin{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		apiKey: os.Getenv(apiKeyEnvVar),
+	}
+}
+
+// ID returns the plugin identifier
+func (p *Plugin) ID() string {
+	return PluginID
+}
+
+// Name returns the display name
+func (p *Plugin) Name() string {
+	return "UPCitemdb"
+}
+
+// Description returns the plugin description
+func (p *Plugin) Description() string {
+	return "Look up product details by UPC/EAN barcode or brand and model"
+}
+
+// Enabled returns true as the trial API doesn't require authentication
+func (p *Plugin) Enabled() bool {
+	return true
+}
+
+// DisabledReason returns empty string as UPCitemdb is always enabled
+func (p *Plugin) DisabledReason() string {
+	return ""
+}
+
+// Lookup finds a product by barcode, or else by brand and model
+func (p *Plugin) Lookup(ctx context.Context, q domain.ProductQuery) (*domain.ProductData, error) {
+	params := url.Values{}
+	endpoint := "/lookup"
+	switch {
+	case q.UPC != "":
+		params.Set("upc", q.UPC)
+	case q.Model != "":
+		endpoint = "/search"
+		params.Set("s", q.Model)
+		if q.Brand != "" {
+			params.Set("brand", q.Brand)
+		}
+		params.Set("type", "product")
+	default:
+		return nil, domain.ErrProductNotFound
+	}
+
+	base := trialURL
+	if p.apiKey != "" {
+		base = paidURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("user_key", p.apiKey)
+		req.Header.Set("key_type", "3scale")
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("executing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, domain.ErrProductNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	var apiResp lookupResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if len(apiResp.Items) == 0 {
+		return nil, domain.ErrProductNotFound
+	}
+
+	return toProductData(apiResp.Items[0]), nil
+}
+
+func toProductData(item item) *domain.ProductData {
+	data := &domain.ProductData{
+		Title: item.Title,
+		Brand: item.Brand,
+		Model: item.Model,
+		UPC:   item.UPC,
+		Specs: map[string]string{},
+	}
+	if data.UPC == "" {
+		data.UPC = item.EAN
+	}
+	if item.Description != "" {
+		data.Description = &item.Description
+	}
+	if len(item.Images) > 0 {
+		image := strings.Replace(item.Images[0], "http://", "https://", 1)
+		data.ImageURL = &image
+	}
+	if item.UPC != "" {
+		data.SourceURL = "https://www.upcitemdb.com/upc/" + item.UPC
+	}
+
+	for name, value := range map[string]string{
+		"color":     item.Color,
+		"size":      item.Size,
+		"dimension": item.Dimension,
+		"weight":    item.Weight,
+		"category":  item.Category,
+	} {
+		if value != "" {
+			data.Specs[name] = value
+		}
+	}
+	return data
+}
+
+// API response types
+
+type lookupResponse struct {
+	Code  string `json:"code"`
+	Total int    `json:"total"`
+	Items []item `json:"items"`
+}
+
+type item struct {
+	EAN         string   `json:"ean"`
+	UPC         string   `json:"upc"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Brand       string   `json:"brand"`
+	Model       string   `json:"model"`
+	Color       string   `json:"color"`
+	Size        string   `json:"size"`
+	Dimension   string   `json:"dimension"`
+	Weight      string   `json:"weight"`
+	Category    string   `json:"category"`
+	Images      []string `json:"images"`
+}
@@ -0,0 +1,218 @@
+package portable
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+// maxDocumentSize bounds the export document read from an archive
+const maxDocumentSize = 512 << 20
+
+// Write writes an archive of e to w: the files listed in e.Files, read with
+// open, then the document. It sets the path, size and checksum of each file.
+// Files that can't be opened are left out of the archive and the document
+// and returned as missing; an error means the archive is incomplete.
+func Write(w io.Writer, e *Export, open func(File) (io.ReadCloser, error)) (missing []File, err error) {
+	zw := zip.NewWriter(w)
+
+	files := e.Files[:0]
+	for _, f := range e.Files {
+		src, err := open(f)
+		if err != nil {
+			missing = append(missing, f)
+			continue
+		}
+		f.Path = FilesDir + f.ID.String() + "/" + fileName(f.FileName)
+		dst, err := zw.Create(f.Path)
+		if err != nil {
+			src.Close()
+			return missing, err
+		}
+		sum := sha256.New()
+		f.Size, err = io.Copy(io.MultiWriter(dst, sum), src)
+		src.Close()
+		if err != nil {
+			return missing, fmt.Errorf("copying file %s: %w", f.ID, err)
+		}
+		f.SHA256 = hex.EncodeToString(sum.Sum(nil))
+		files = append(files, f)
+	}
+	e.Files = files
+	if len(missing) > 0 {
+		written := ids(e.Files, func(f File) uuid.UUID { return f.ID })
+		for i := range e.Assets {
+			if id := e.Assets[i].MainFileID; id != nil && !written[*id] {
+				e.Assets[i].MainFileID = nil
+			}
+		}
+	}
+
+	doc, err := zw.Create(DocumentName)
+	if err != nil {
+		return missing, err
+	}
+	enc := json.NewEncoder(doc)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(e); err != nil {
+		return missing, err
+	}
+	return missing, zw.Close()
+}
+
+// Archive is an export archive being read
+type Archive struct {
+	Export *Export
+	files  map[string]*zip.File
+}
+
+// Read opens an export archive and validates its document. The files are
+// checked against the manifest as they are read.
+func Read(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not a zip archive: %w", err)
+	}
+	a := &Archive{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+	}
+
+	doc, ok := a.files[DocumentName]
+	if !ok {
+		return nil, fmt.Errorf("archive has no %s", DocumentName)
+	}
+	rc, err := doc.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(io.LimitReader(rc, maxDocumentSize)).Decode(&a.Export); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", DocumentName, err)
+	}
+	if a.Export == nil {
+		return nil, fmt.Errorf("invalid %s: empty document", DocumentName)
+	}
+	if err := a.Export.Validate(); err != nil {
+		return nil, err
+	}
+	for _, f := range a.Export.Files {
+		zf, ok := a.files[f.Path]
+		if !ok {
+			return nil, fmt.Errorf("file %s: %s is missing from the archive", f.ID, f.Path)
+		}
+		if zf.UncompressedSize64 != uint64(f.Size) {
+			return nil, fmt.Errorf("file %s: size doesn't match the manifest", f.ID)
+		}
+	}
+	return a, nil
+}
+
+// Open opens a file of the manifest. Reading it fails at the end if its
+// contents don't match the manifest's checksum.
+func (a *Archive) Open(f File) (io.ReadCloser, error) {
+	zf, ok := a.files[f.Path]
+	if !ok {
+		return nil, fmt.Errorf("file %s: %s is missing from the archive", f.ID, f.Path)
+	}
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &checkedReader{rc: rc, sum: sha256.New(), want: strings.ToLower(f.SHA256)}, nil
+}
+
+// FileStore stores the files of an import, see storage.FileStorage
+type FileStore interface {
+	UploadObject(ctx context.Context, obj storage.Object, contentType string, body io.Reader) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// UploadFiles stores the files of the manifest for their assets in orgID and
+// returns their keys by file ID. If a file fails, e.g. its checksum, the
+// files stored so far are deleted again.
+func (a *Archive) UploadFiles(ctx context.Context, store FileStore, orgID uuid.UUID) (map[uuid.UUID]string, error) {
+	keys := make(map[uuid.UUID]string, len(a.Export.Files))
+	for _, f := range a.Export.Files {
+		key, err := a.uploadFile(ctx, store, orgID, f)
+		if err != nil {
+			DeleteFiles(ctx, store, keys)
+			return nil, fmt.Errorf("file %s: %w", f.Path, err)
+		}
+		keys[f.ID] = key
+	}
+	return keys, nil
+}
+
+func (a *Archive) uploadFile(ctx context.Context, store FileStore, orgID uuid.UUID, f File) (string, error) {
+	src, err := a.Open(f)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	contentType := "application/octet-stream"
+	if f.ContentType != nil && *f.ContentType != "" {
+		contentType = *f.ContentType
+	}
+	return store.UploadObject(ctx, storage.Object{
+		OrganizationID: orgID,
+		AssetID:        f.AssetID,
+		FileName:       f.FileName,
+		CreatedAt:      f.CreatedAt,
+	}, contentType, src)
+}
+
+// DeleteFiles deletes the files stored by UploadFiles, e.g. when the import
+// of the records fails
+func DeleteFiles(ctx context.Context, store FileStore, keys map[uuid.UUID]string) {
+	for _, key := range keys {
+		store.Delete(ctx, key)
+	}
+}
+
+// checkedReader verifies the SHA-256 of what it read once at EOF
+type checkedReader struct {
+	rc   io.ReadCloser
+	sum  hash.Hash
+	want string
+}
+
+func (c *checkedReader) Read(p []byte) (int, error) {
+	n, err := c.rc.Read(p)
+	c.sum.Write(p[:n])
+	if errors.Is(err, io.EOF) && c.want != "" && hex.EncodeToString(c.sum.Sum(nil)) != c.want {
+		return n, errors.New("file doesn't match its checksum")
+	}
+	return n, err
+}
+
+func (c *checkedReader) Close() error {
+	return c.rc.Close()
+}
+
+// fileName makes name safe to use as the last element of an archive path
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || unicode.IsControl(r) {
+			return '_'
+		}
+		return r
+	}, path.Base(name))
+	if name == "." || name == ".." || name == "" {
+		return "file"
+	}
+	return name
+}
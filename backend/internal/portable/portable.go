@@ -0,0 +1,450 @@
+// Package portable defines Attic's open export format: a versioned JSON
+// document of an organization's inventory, described by the JSON Schema in
+// schema.json, zipped together with the attached files. It lets users take
+// their data to another Attic instance or read it with other tools.
+package portable
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+const (
+	// Format identifies export documents
+	Format = "attic.export"
+
+	// Version is the version of the format written; documents of older
+	// versions can still be read. Bump it, and document the change in
+	// schema.json, whenever fields are renamed or change meaning.
+	Version = 1
+
+	// DocumentName is the name of the document in an export archive
+	DocumentName = "attic-export.json"
+
+	// FilesDir is the directory of the attached files in an export archive
+	FilesDir = "files/"
+)
+
+// dataTypes are the data types of attributes
+var dataTypes = []string{"string", "number", "boolean", "date", "text"}
+
+// Schema is the JSON Schema of export documents
+//
+//go:embed schema.json
+var Schema []byte
+
+// Export is the document of an export. IDs are only meaningful within the
+// document: they link its records and are replaced on import.
+type Export struct {
+	Format       string       `json:"format"`
+	Version      int          `json:"version"`
+	ExportedAt   time.Time    `json:"exported_at"`
+	Organization Organization `json:"organization"`
+	Users        []User       `json:"users"`
+	Conditions   []Condition  `json:"conditions"`
+	Attributes   []Attribute  `json:"attributes"`
+	Categories   []Category   `json:"categories"`
+	Locations    []Location   `json:"locations"`
+	Tags         []Tag        `json:"tags"`
+	Assets       []Asset      `json:"assets"`
+	Files        []File       `json:"files"`
+}
+
+type Organization struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+// User is a member of the organization, without credentials
+type User struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+	Name  *string   `json:"name"`
+	Role  string    `json:"role"`
+}
+
+type Condition struct {
+	ID          uuid.UUID `json:"id"`
+	Code        string    `json:"code"`
+	Label       string    `json:"label"`
+	Description *string   `json:"description"`
+	SortOrder   int       `json:"sort_order"`
+}
+
+type Attribute struct {
+	ID       uuid.UUID `json:"id"`
+	Name     string    `json:"name"`
+	Key      string    `json:"key"` // Key of the attribute in asset attributes
+	DataType string    `json:"data_type"`
+}
+
+type Category struct {
+	ID          uuid.UUID           `json:"id"`
+	ParentID    *uuid.UUID          `json:"parent_id"`
+	Name        string              `json:"name"`
+	Description *string             `json:"description"`
+	Icon        *string             `json:"icon"`
+	Attributes  []CategoryAttribute `json:"attributes"`
+}
+
+type CategoryAttribute struct {
+	AttributeID uuid.UUID `json:"attribute_id"`
+	Required    bool      `json:"required"`
+	SortOrder   int       `json:"sort_order"`
+}
+
+type Location struct {
+	ID          uuid.UUID  `json:"id"`
+	ParentID    *uuid.UUID `json:"parent_id"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	Icon        *string    `json:"icon"`
+}
+
+type Tag struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+type Asset struct {
+	ID            uuid.UUID       `json:"id"`
+	CategoryID    uuid.UUID       `json:"category_id"`
+	LocationID    *uuid.UUID      `json:"location_id"`
+	ConditionID   *uuid.UUID      `json:"condition_id"`
+	CollectionID  *uuid.UUID      `json:"collection_id"` // Asset this one belongs to
+	OwnerID       *uuid.UUID      `json:"owner_id"`      // One of the users
+	MainFileID    *uuid.UUID      `json:"main_file_id"`  // File shown as the asset's image
+	Name          string          `json:"name"`
+	Description   *string         `json:"description"`
+	Quantity      int             `json:"quantity"`
+	Attributes    json.RawMessage `json:"attributes"`  // Values by attribute key
+	PurchaseAt    *string         `json:"purchase_at"` // YYYY-MM-DD
+	PurchasePrice *float64        `json:"purchase_price"`
+	Currency      *string         `json:"currency"` // ISO 4217
+	PurchaseNote  *string         `json:"purchase_note"`
+	Notes         *string         `json:"notes"`
+	HighValue     bool            `json:"high_value"`
+	ArchivedAt    *time.Time      `json:"archived_at"` // Set while the asset is archived
+	Status        string          `json:"status"`      // Lifecycle status, see domain.AssetStatuses; empty = owned
+	TagIDs        []uuid.UUID     `json:"tag_ids"`
+	Warranty      *Warranty       `json:"warranty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+type Warranty struct {
+	Provider  *string `json:"provider"`
+	StartDate *string `json:"start_date"` // YYYY-MM-DD
+	EndDate   *string `json:"end_date"`   // YYYY-MM-DD
+	Notes     *string `json:"notes"`
+}
+
+// File is the manifest entry of an attached file stored in the archive
+type File struct {
+	ID          uuid.UUID  `json:"id"`
+	AssetID     uuid.UUID  `json:"asset_id"`
+	Path        string     `json:"path"` // In the archive, under FilesDir
+	FileName    string     `json:"file_name"`
+	ContentType *string    `json:"content_type"`
+	Size        int64      `json:"size"`
+	SHA256      string     `json:"sha256"` // Hex
+	Description *string    `json:"description"`
+	UploadedBy  *uuid.UUID `json:"uploaded_by"` // One of the users
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ImportResult reports what an import changed
+type ImportResult struct {
+	Conditions ImportCount `json:"conditions"`
+	Attributes ImportCount `json:"attributes"`
+	Categories ImportCount `json:"categories"`
+	Locations  ImportCount `json:"locations"`
+	Tags       ImportCount `json:"tags"`
+	Assets     int         `json:"assets"`
+	Files      int         `json:"files"`
+
+	// Emails of exported users who aren't members of the organization; the
+	// assets and files referring to them were imported without owner or
+	// uploader
+	UnmatchedUsers []string `json:"unmatched_users"`
+}
+
+// ImportCount counts the records of a kind that were created, and those
+// that matched an existing record (e.g. a category of the same name and
+// parent) which was used instead
+type ImportCount struct {
+	Created int `json:"created"`
+	Matched int `json:"matched"`
+}
+
+// New returns an empty export of the current version
+func New(org Organization) *Export {
+	return &Export{
+		Format:       Format,
+		Version:      Version,
+		ExportedAt:   time.Now().UTC(),
+		Organization: org,
+		Users:        []User{},
+		Conditions:   []Condition{},
+		Attributes:   []Attribute{},
+		Categories:   []Category{},
+		Locations:    []Location{},
+		Tags:         []Tag{},
+		Assets:       []Asset{},
+		Files:        []File{},
+	}
+}
+
+// Validate checks that e is a document of a supported version whose
+// references all point to records in it
+func (e *Export) Validate() error {
+	if e.Format != Format {
+		return fmt.Errorf("not an Attic export: format %q", e.Format)
+	}
+	if e.Version < 1 || e.Version > Version {
+		return fmt.Errorf("unsupported export version %d, this instance reads up to %d", e.Version, Version)
+	}
+
+	users := ids(e.Users, func(u User) uuid.UUID { return u.ID })
+	conditions := ids(e.Conditions, func(c Condition) uuid.UUID { return c.ID })
+	attributes := ids(e.Attributes, func(a Attribute) uuid.UUID { return a.ID })
+	categories := ids(e.Categories, func(c Category) uuid.UUID { return c.ID })
+	locations := ids(e.Locations, func(l Location) uuid.UUID { return l.ID })
+	tags := ids(e.Tags, func(t Tag) uuid.UUID { return t.ID })
+	assets := ids(e.Assets, func(a Asset) uuid.UUID { return a.ID })
+	files := ids(e.Files, func(f File) uuid.UUID { return f.ID })
+	for name, set := range map[string]map[uuid.UUID]bool{
+		"user": users, "condition": conditions, "attribute": attributes, "category": categories,
+		"location": locations, "tag": tags, "asset": assets, "file": files,
+	} {
+		if set == nil {
+			return fmt.Errorf("duplicate %s ID", name)
+		}
+	}
+
+	for _, a := range e.Attributes {
+		if !slices.Contains(dataTypes, a.DataType) {
+			return fmt.Errorf("attribute %s: unknown data type %q", a.ID, a.DataType)
+		}
+	}
+	for _, c := range e.Categories {
+		if err := refer("category", c.ID, "parent", c.ParentID, categories); err != nil {
+			return err
+		}
+		for _, a := range c.Attributes {
+			if err := refer("category", c.ID, "attribute", &a.AttributeID, attributes); err != nil {
+				return err
+			}
+		}
+	}
+	if err := acyclic("category", e.Categories, func(c Category) (uuid.UUID, *uuid.UUID) { return c.ID, c.ParentID }); err != nil {
+		return err
+	}
+	for _, l := range e.Locations {
+		if err := refer("location", l.ID, "parent", l.ParentID, locations); err != nil {
+			return err
+		}
+	}
+	if err := acyclic("location", e.Locations, func(l Location) (uuid.UUID, *uuid.UUID) { return l.ID, l.ParentID }); err != nil {
+		return err
+	}
+
+	for _, a := range e.Assets {
+		if strings.TrimSpace(a.Name) == "" {
+			return fmt.Errorf("asset %s has no name", a.ID)
+		}
+		for _, ref := range []struct {
+			name string
+			id   *uuid.UUID
+			set  map[uuid.UUID]bool
+		}{
+			{"category", &a.CategoryID, categories},
+			{"location", a.LocationID, locations},
+			{"condition", a.ConditionID, conditions},
+			{"collection", a.CollectionID, assets},
+			{"owner", a.OwnerID, users},
+			{"main file", a.MainFileID, files},
+		} {
+			if err := refer("asset", a.ID, ref.name, ref.id, ref.set); err != nil {
+				return err
+			}
+		}
+		if a.Status != "" && !domain.AssetStatus(a.Status).Valid() {
+			return fmt.Errorf("asset %s has unknown status %q", a.ID, a.Status)
+		}
+		for _, id := range a.TagIDs {
+			if err := refer("asset", a.ID, "tag", &id, tags); err != nil {
+				return err
+			}
+		}
+		if len(a.Attributes) > 0 && string(a.Attributes) != "null" {
+			var values map[string]any
+			if err := json.Unmarshal(a.Attributes, &values); err != nil {
+				return fmt.Errorf("asset %s: attributes must be an object", a.ID)
+			}
+		}
+		for _, date := range []*string{a.PurchaseAt, warrantyDate(a.Warranty, true), warrantyDate(a.Warranty, false)} {
+			if date != nil {
+				if _, err := time.Parse(time.DateOnly, *date); err != nil {
+					return fmt.Errorf("asset %s: invalid date %q", a.ID, *date)
+				}
+			}
+		}
+	}
+
+	for _, f := range e.Files {
+		if err := refer("file", f.ID, "asset", &f.AssetID, assets); err != nil {
+			return err
+		}
+		if err := refer("file", f.ID, "uploader", f.UploadedBy, users); err != nil {
+			return err
+		}
+		if !strings.HasPrefix(f.Path, FilesDir) || path.Clean(f.Path) != f.Path {
+			return fmt.Errorf("file %s: invalid path %q", f.ID, f.Path)
+		}
+		if f.Size < 0 {
+			return fmt.Errorf("file %s: invalid size", f.ID)
+		}
+	}
+	return nil
+}
+
+// Prune clears optional references to records that aren't in e, e.g. to a
+// deleted location, so a partial set of records can be exported
+func (e *Export) Prune() {
+	users := ids(e.Users, func(u User) uuid.UUID { return u.ID })
+	conditions := ids(e.Conditions, func(c Condition) uuid.UUID { return c.ID })
+	attributes := ids(e.Attributes, func(a Attribute) uuid.UUID { return a.ID })
+	categories := ids(e.Categories, func(c Category) uuid.UUID { return c.ID })
+	locations := ids(e.Locations, func(l Location) uuid.UUID { return l.ID })
+	tags := ids(e.Tags, func(t Tag) uuid.UUID { return t.ID })
+	assets := ids(e.Assets, func(a Asset) uuid.UUID { return a.ID })
+	files := ids(e.Files, func(f File) uuid.UUID { return f.ID })
+
+	for i := range e.Categories {
+		c := &e.Categories[i]
+		c.ParentID = known(c.ParentID, categories)
+		c.Attributes = slices.DeleteFunc(c.Attributes, func(a CategoryAttribute) bool { return !attributes[a.AttributeID] })
+	}
+	for i := range e.Locations {
+		e.Locations[i].ParentID = known(e.Locations[i].ParentID, locations)
+	}
+	for i := range e.Assets {
+		a := &e.Assets[i]
+		a.LocationID = known(a.LocationID, locations)
+		a.ConditionID = known(a.ConditionID, conditions)
+		a.CollectionID = known(a.CollectionID, assets)
+		a.OwnerID = known(a.OwnerID, users)
+		a.MainFileID = known(a.MainFileID, files)
+		a.TagIDs = slices.DeleteFunc(a.TagIDs, func(id uuid.UUID) bool { return !tags[id] })
+	}
+	for i := range e.Files {
+		e.Files[i].UploadedBy = known(e.Files[i].UploadedBy, users)
+	}
+}
+
+// RenewAssetIDs gives the assets new IDs and updates the references to them,
+// so the assets of an export can be imported next to the ones it was taken
+// from
+func (e *Export) RenewAssetIDs() {
+	e.mapAssetIDs(func(uuid.UUID) uuid.UUID { return uuid.New() })
+}
+
+// DeriveAssetIDs is RenewAssetIDs with IDs derived from the exported ones and
+// namespace, e.g. the importing organization, so that importing the same
+// export again yields the same IDs
+func (e *Export) DeriveAssetIDs(namespace uuid.UUID) {
+	e.mapAssetIDs(func(id uuid.UUID) uuid.UUID { return uuid.NewSHA1(namespace, id[:]) })
+}
+
+// DropAssets removes the assets in ids and their files from the export.
+// References of other assets to them are kept, as when they exist already.
+func (e *Export) DropAssets(ids map[uuid.UUID]bool) {
+	e.Assets = slices.DeleteFunc(e.Assets, func(a Asset) bool { return ids[a.ID] })
+	e.Files = slices.DeleteFunc(e.Files, func(f File) bool { return ids[f.AssetID] })
+}
+
+// mapAssetIDs changes the asset IDs with next and updates the references
+func (e *Export) mapAssetIDs(next func(uuid.UUID) uuid.UUID) {
+	renewed := make(map[uuid.UUID]uuid.UUID, len(e.Assets))
+	for i := range e.Assets {
+		id := next(e.Assets[i].ID)
+		renewed[e.Assets[i].ID] = id
+		e.Assets[i].ID = id
+	}
+	for i := range e.Assets {
+		if a := &e.Assets[i]; a.CollectionID != nil {
+			id := renewed[*a.CollectionID]
+			a.CollectionID = &id
+		}
+	}
+	for i := range e.Files {
+		e.Files[i].AssetID = renewed[e.Files[i].AssetID]
+	}
+}
+
+// ids returns the set of IDs of records, or nil if one is duplicated
+func ids[T any](records []T, id func(T) uuid.UUID) map[uuid.UUID]bool {
+	set := make(map[uuid.UUID]bool, len(records))
+	for _, r := range records {
+		if set[id(r)] {
+			return nil
+		}
+		set[id(r)] = true
+	}
+	return set
+}
+
+// known returns ref if it is to a record in set, else nil
+func known(ref *uuid.UUID, set map[uuid.UUID]bool) *uuid.UUID {
+	if ref != nil && !set[*ref] {
+		return nil
+	}
+	return ref
+}
+
+// refer checks that a reference of a record, if set, is to a record in set
+func refer(kind string, id uuid.UUID, field string, ref *uuid.UUID, set map[uuid.UUID]bool) error {
+	if ref != nil && !set[*ref] {
+		return fmt.Errorf("%s %s: unknown %s %s", kind, id, field, *ref)
+	}
+	return nil
+}
+
+// acyclic checks that following the parents of records ends at a root
+func acyclic[T any](kind string, records []T, parent func(T) (uuid.UUID, *uuid.UUID)) error {
+	parents := make(map[uuid.UUID]*uuid.UUID, len(records))
+	for _, r := range records {
+		id, p := parent(r)
+		parents[id] = p
+	}
+	for id := range parents {
+		seen := map[uuid.UUID]bool{}
+		for cur := &id; cur != nil; cur = parents[*cur] {
+			if seen[*cur] {
+				return fmt.Errorf("%s %s: parents form a cycle", kind, id)
+			}
+			seen[*cur] = true
+		}
+	}
+	return nil
+}
+
+func warrantyDate(w *Warranty, start bool) *string {
+	switch {
+	case w == nil:
+		return nil
+	case start:
+		return w.StartDate
+	default:
+		return w.EndDate
+	}
+}
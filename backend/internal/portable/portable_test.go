@@ -0,0 +1,302 @@
+package portable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+// sample returns a small valid export
+func sample() *Export {
+	e := New(Organization{Name: "Home"})
+	owner := uuid.New()
+	parent, child := uuid.New(), uuid.New()
+	attr, tag, box, camera, photo := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	date := "2024-03-01"
+	e.Users = []User{{ID: owner, Email: "owner@example.com", Role: "admin"}}
+	e.Attributes = []Attribute{{ID: attr, Name: "Serial", Key: "serial", DataType: "string"}}
+	e.Categories = []Category{
+		{ID: parent, Name: "Electronics"},
+		{ID: child, ParentID: &parent, Name: "Cameras", Attributes: []CategoryAttribute{{AttributeID: attr}}},
+	}
+	e.Tags = []Tag{{ID: tag, Name: "insured"}}
+	e.Assets = []Asset{
+		{ID: box, CategoryID: parent, Name: "Camera bag", Quantity: 1},
+		{ID: camera, CategoryID: child, CollectionID: &box, OwnerID: &owner, MainFileID: &photo, Name: "Camera", Quantity: 1,
+			Attributes: json.RawMessage(`{"serial":"A1"}`), PurchaseAt: &date, TagIDs: []uuid.UUID{tag}},
+	}
+	e.Files = []File{{ID: photo, AssetID: camera, Path: FilesDir + photo.String() + "/photo.jpg", FileName: "photo.jpg", UploadedBy: &owner}}
+	return e
+}
+
+func Test_Schema_DocumentsEveryField(t *testing.T) {
+	var schema map[string]any
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		t.Fatalf("schema.json is not valid JSON: %v", err)
+	}
+
+	// Checks that the JSON fields of typ are the properties of node
+	var check func(where string, typ reflect.Type, node map[string]any)
+	check = func(where string, typ reflect.Type, node map[string]any) {
+		props, _ := node["properties"].(map[string]any)
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			prop, ok := props[name].(map[string]any)
+			if !ok {
+				t.Errorf("%s.%s is not documented in schema.json", where, name)
+				continue
+			}
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer || ft.Kind() == reflect.Slice {
+				if ft.Kind() == reflect.Slice {
+					if items, ok := prop["items"].(map[string]any); ok {
+						prop = items
+					}
+				}
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft.PkgPath() == typ.PkgPath() {
+				check(where+"."+name, ft, prop)
+			}
+		}
+	}
+	check("export", reflect.TypeOf(Export{}), schema)
+}
+
+func Test_Export_Validate(t *testing.T) {
+	if err := sample().Validate(); err != nil {
+		t.Fatalf("expected the sample to be valid, got %v", err)
+	}
+
+	missing := uuid.New()
+	tests := map[string]func(e *Export){
+		"other format":        func(e *Export) { e.Format = "csv" },
+		"newer version":       func(e *Export) { e.Version = Version + 1 },
+		"duplicate ID":        func(e *Export) { e.Tags = append(e.Tags, e.Tags[0]) },
+		"unknown parent":      func(e *Export) { e.Categories[1].ParentID = &missing },
+		"parent cycle":        func(e *Export) { e.Categories[0].ParentID = &e.Categories[1].ID },
+		"unknown attribute":   func(e *Export) { e.Categories[1].Attributes[0].AttributeID = missing },
+		"unknown data type":   func(e *Export) { e.Attributes[0].DataType = "color" },
+		"unknown category":    func(e *Export) { e.Assets[0].CategoryID = missing },
+		"unknown owner":       func(e *Export) { e.Assets[0].OwnerID = &missing },
+		"unknown tag":         func(e *Export) { e.Assets[1].TagIDs = []uuid.UUID{missing} },
+		"unknown main file":   func(e *Export) { e.Assets[0].MainFileID = &missing },
+		"no name":             func(e *Export) { e.Assets[0].Name = " " },
+		"unknown status":      func(e *Export) { e.Assets[0].Status = "stolen" },
+		"attributes array":    func(e *Export) { e.Assets[0].Attributes = json.RawMessage(`[1]`) },
+		"invalid date":        func(e *Export) { bad := "01/03/2024"; e.Assets[0].PurchaseAt = &bad },
+		"file of other asset": func(e *Export) { e.Files[0].AssetID = missing },
+		"file outside files/": func(e *Export) { e.Files[0].Path = "attic-export.json" },
+		"file path traversal": func(e *Export) { e.Files[0].Path = "files/../../etc/passwd" },
+	}
+	for name, change := range tests {
+		e := sample()
+		change(e)
+		if err := e.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func Test_Export_Prune(t *testing.T) {
+	e := sample()
+	gone := uuid.New()
+	e.Categories[0].ParentID = &gone
+	e.Categories[1].Attributes = append(e.Categories[1].Attributes, CategoryAttribute{AttributeID: gone})
+	e.Assets[0].LocationID = &gone
+	e.Assets[1].TagIDs = append(e.Assets[1].TagIDs, gone)
+	e.Users = nil
+
+	e.Prune()
+
+	if err := e.Validate(); err != nil {
+		t.Fatalf("expected a valid export, got %v", err)
+	}
+	if e.Assets[1].OwnerID != nil || e.Files[0].UploadedBy != nil {
+		t.Error("expected references to the missing user to be cleared")
+	}
+	if e.Categories[1].ParentID == nil || len(e.Assets[1].TagIDs) != 1 || *e.Assets[1].CollectionID != e.Assets[0].ID {
+		t.Error("expected valid references to be kept")
+	}
+}
+
+func Test_Export_RenewAssetIDs(t *testing.T) {
+	e := sample()
+	box, camera := e.Assets[0].ID, e.Assets[1].ID
+
+	e.RenewAssetIDs()
+
+	if e.Assets[0].ID == box || e.Assets[1].ID == camera {
+		t.Fatal("expected new asset IDs")
+	}
+	if *e.Assets[1].CollectionID != e.Assets[0].ID || e.Files[0].AssetID != e.Assets[1].ID {
+		t.Error("expected references to follow the new IDs")
+	}
+	if err := e.Validate(); err != nil {
+		t.Errorf("expected the export to stay valid, got %v", err)
+	}
+}
+
+func Test_Export_DeriveAssetIDs(t *testing.T) {
+	namespace := uuid.New()
+	first, second := sample(), sample()
+	second.Assets[0].ID, second.Assets[1].ID = first.Assets[0].ID, first.Assets[1].ID
+	*second.Assets[1].CollectionID = first.Assets[0].ID
+	second.Files[0].AssetID = first.Assets[1].ID
+	exported := first.Assets[0].ID
+
+	first.DeriveAssetIDs(namespace)
+	second.DeriveAssetIDs(namespace)
+
+	if first.Assets[0].ID == exported || first.Assets[0].ID != second.Assets[0].ID || first.Assets[1].ID != second.Assets[1].ID {
+		t.Error("expected the same new IDs for the same exported IDs")
+	}
+	if *first.Assets[1].CollectionID != first.Assets[0].ID || first.Files[0].AssetID != first.Assets[1].ID {
+		t.Error("expected references to follow the new IDs")
+	}
+	other := sample()
+	other.Assets[0].ID = exported
+	other.DeriveAssetIDs(uuid.New())
+	if other.Assets[0].ID == first.Assets[0].ID {
+		t.Error("expected another namespace to derive other IDs")
+	}
+}
+
+func Test_Export_DropAssets(t *testing.T) {
+	e := sample()
+	box, camera := e.Assets[0].ID, e.Assets[1].ID
+
+	e.DropAssets(map[uuid.UUID]bool{box: true})
+	if len(e.Assets) != 1 || e.Assets[0].ID != camera || len(e.Files) != 1 {
+		t.Fatalf("expected only the bag to be dropped, got %+v", e.Assets)
+	}
+	if *e.Assets[0].CollectionID != box {
+		t.Error("expected the reference to the dropped asset to be kept")
+	}
+
+	e.DropAssets(map[uuid.UUID]bool{camera: true})
+	if len(e.Assets) != 0 || len(e.Files) != 0 {
+		t.Error("expected the camera to be dropped with its file")
+	}
+}
+
+func Test_Archive_RoundTrip(t *testing.T) {
+	e := sample()
+	lost := File{ID: uuid.New(), AssetID: e.Assets[0].ID, FileName: "lost.pdf"}
+	e.Files = append(e.Files, lost)
+	e.Assets[0].MainFileID = &lost.ID
+
+	var buf bytes.Buffer
+	missing, err := Write(&buf, e, func(f File) (io.ReadCloser, error) {
+		if f.ID == lost.ID {
+			return nil, errors.New("gone")
+		}
+		return io.NopCloser(strings.NewReader("jpeg data")), nil
+	})
+	if err != nil {
+		t.Fatalf("failed to write archive: %v", err)
+	}
+	if len(missing) != 1 || missing[0].ID != lost.ID {
+		t.Fatalf("expected the unreadable file to be missing, got %+v", missing)
+	}
+
+	a, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	if len(a.Export.Files) != 1 || a.Export.Assets[0].MainFileID != nil {
+		t.Fatalf("expected only the written file in the manifest, got %+v", a.Export.Files)
+	}
+	f := a.Export.Files[0]
+	if f.Size != int64(len("jpeg data")) || len(f.SHA256) != 64 {
+		t.Errorf("expected size and checksum to be set, got %+v", f)
+	}
+
+	rc, err := a.Open(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "jpeg data" {
+		t.Errorf("expected the file contents, got %q, %v", data, err)
+	}
+
+	f.SHA256 = strings.Repeat("0", 64)
+	rc, _ = a.Open(f)
+	if _, err := io.ReadAll(rc); err == nil {
+		t.Error("expected a checksum mismatch to fail")
+	}
+}
+
+// memoryStore is a FileStore keeping the files in memory
+type memoryStore map[string]string
+
+func (m memoryStore) UploadObject(_ context.Context, obj storage.Object, _ string, body io.Reader) (string, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	key := obj.AssetID.String() + "/" + obj.FileName
+	m[key] = string(data)
+	return key, nil
+}
+
+func (m memoryStore) Delete(_ context.Context, key string) error {
+	delete(m, key)
+	return nil
+}
+
+func Test_Archive_UploadFiles(t *testing.T) {
+	e := sample()
+	second := File{ID: uuid.New(), AssetID: e.Assets[0].ID, FileName: "receipt.pdf"}
+	e.Files = append(e.Files, second)
+	var buf bytes.Buffer
+	Write(&buf, e, func(f File) (io.ReadCloser, error) { return io.NopCloser(strings.NewReader(f.FileName)), nil })
+	a, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := memoryStore{}
+	keys, err := a.UploadFiles(context.Background(), store, uuid.New())
+	if err != nil {
+		t.Fatalf("failed to upload files: %v", err)
+	}
+	if len(keys) != 2 || store[keys[second.ID]] != "receipt.pdf" {
+		t.Errorf("expected both files to be stored, got %v", store)
+	}
+
+	// A corrupted file fails the upload and removes the stored ones
+	a.Export.Files[1].SHA256 = strings.Repeat("0", 64)
+	store = memoryStore{}
+	if _, err := a.UploadFiles(context.Background(), store, uuid.New()); err == nil {
+		t.Fatal("expected a checksum mismatch to fail")
+	}
+	if len(store) != 0 {
+		t.Errorf("expected the stored files to be deleted, got %v", store)
+	}
+}
+
+func Test_Read_RejectsInvalidArchives(t *testing.T) {
+	if _, err := Read(strings.NewReader("not a zip"), 9); err == nil {
+		t.Error("expected a non-zip to fail")
+	}
+
+	var buf bytes.Buffer
+	e := sample()
+	e.Format = "other"
+	Write(&buf, e, func(File) (io.ReadCloser, error) { return io.NopCloser(strings.NewReader("x")), nil })
+	if _, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len())); err == nil {
+		t.Error("expected an invalid document to fail")
+	}
+}
@@ -0,0 +1,129 @@
+// Package ratelimit provides a fixed-window request limiter that reports
+// usage to clients through X-RateLimit-* style response headers.
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Result describes the state of a key's quota after a request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+type window struct {
+	count int
+	reset time.Time
+}
+
+// Limiter allows up to limit requests per key within each window
+type Limiter struct {
+	limit  int
+	period time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	windows     map[string]*window
+	lastCleanup time.Time
+}
+
+// New creates a limiter allowing limit requests per period for each key
+func New(limit int, period time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		period:  period,
+		now:     time.Now,
+		windows: make(map[string]*window),
+	}
+}
+
+// Allow records a request for key and reports whether it is within the limit
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.cleanup(now)
+
+	win, ok := l.windows[key]
+	if !ok || !now.Before(win.reset) {
+		win = &window{reset: now.Add(l.period)}
+		l.windows[key] = win
+	}
+
+	if win.count >= l.limit {
+		return Result{Allowed: false, Limit: l.limit, Remaining: 0, Reset: win.reset}
+	}
+
+	win.count++
+	return Result{Allowed: true, Limit: l.limit, Remaining: l.limit - win.count, Reset: win.reset}
+}
+
+// cleanup drops expired windows at most once per period to bound memory use
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < l.period {
+		return
+	}
+	for key, win := range l.windows {
+		if !now.Before(win.reset) {
+			delete(l.windows, key)
+		}
+	}
+	l.lastCleanup = now
+}
+
+// KeyFunc derives the limiter key for a request
+type KeyFunc func(r *http.Request) string
+
+// ClientIP keys requests by remote address (use after auth.RealIP)
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// WriteHeaders sets <prefix>-Limit, <prefix>-Remaining and <prefix>-Reset headers
+func WriteHeaders(w http.ResponseWriter, prefix string, res Result) {
+	w.Header().Set(prefix+"-Limit", strconv.Itoa(res.Limit))
+	w.Header().Set(prefix+"-Remaining", strconv.Itoa(res.Remaining))
+	w.Header().Set(prefix+"-Reset", strconv.FormatInt(res.Reset.Unix(), 10))
+}
+
+// Middleware enforces the limit, reporting usage in headers named after
+// prefix (e.g. "X-RateLimit") and rejecting excess requests with 429
+func (l *Limiter) Middleware(prefix string, keyFunc KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if l.Check(w, prefix, keyFunc(r)) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// Check records a request for key like the middleware does, for keys only
+// known inside a handler: it reports usage in headers named after prefix,
+// rejects an excess request with 429 and reports whether it was allowed
+func (l *Limiter) Check(w http.ResponseWriter, prefix, key string) bool {
+	res := l.Allow(key)
+	WriteHeaders(w, prefix, res)
+
+	if !res.Allowed {
+		retryAfter := int(time.Until(res.Reset).Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"rate limit exceeded"}`))
+		return false
+	}
+	return true
+}
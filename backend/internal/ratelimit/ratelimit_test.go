@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(limit int, period time.Duration, now *time.Time) *Limiter {
+	l := New(limit, period)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func Test_Limiter_Allow_WithinLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newTestLimiter(3, time.Minute, &now)
+
+	for i := 0; i < 3; i++ {
+		res := l.Allow("client")
+		if !res.Allowed {
+			t.Fatalf("request %d: expected allowed", i+1)
+		}
+		if res.Remaining != 2-i {
+			t.Errorf("request %d: expected remaining %d, got %d", i+1, 2-i, res.Remaining)
+		}
+	}
+}
+
+func Test_Limiter_Allow_ExceedsLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newTestLimiter(2, time.Minute, &now)
+
+	l.Allow("client")
+	l.Allow("client")
+	res := l.Allow("client")
+
+	if res.Allowed {
+		t.Error("expected request to be rejected")
+	}
+	if res.Remaining != 0 {
+		t.Errorf("expected remaining 0, got %d", res.Remaining)
+	}
+}
+
+func Test_Limiter_Allow_ResetsAfterWindow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newTestLimiter(1, time.Minute, &now)
+
+	l.Allow("client")
+	now = now.Add(time.Minute)
+
+	if res := l.Allow("client"); !res.Allowed {
+		t.Error("expected request to be allowed in new window")
+	}
+}
+
+func Test_Limiter_Allow_KeysAreIndependent(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newTestLimiter(1, time.Minute, &now)
+
+	l.Allow("a")
+	if res := l.Allow("b"); !res.Allowed {
+		t.Error("expected other key to be allowed")
+	}
+}
+
+func Test_Limiter_Middleware_SetsHeadersAndRejects(t *testing.T) {
+	l := New(1, time.Minute)
+	handler := l.Middleware("X-RateLimit", ClientIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/assets", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if rec.Header().Get("X-RateLimit-Limit") != "1" {
+		t.Errorf("expected X-RateLimit-Limit 1, got %q", rec.Header().Get("X-RateLimit-Limit"))
+	}
+	if rec.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("expected X-RateLimit-Remaining 0, got %q", rec.Header().Get("X-RateLimit-Remaining"))
+	}
+	if rec.Header().Get("X-RateLimit-Reset") == "" {
+		t.Error("expected X-RateLimit-Reset to be set")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After to be set")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -22,16 +24,16 @@ func NewAssetRepository(pool *pgxpool.Pool) *AssetRepository {
 
 func (r *AssetRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Asset, error) {
 	query := `
-		SELECT id, organization_id, category_id, location_id, condition_id, collection_id, main_attachment_id,
-		       name, description, quantity, attributes, purchase_at, purchase_price, purchase_note, notes,
+		SELECT id, organization_id, category_id, location_id, condition_id, collection_id, owner_id, main_attachment_id, short_id,
+		       name, description, quantity, min_quantity, attributes, high_value, archived_at, status, purchase_at, purchase_price, current_value, currency, purchase_note, purchase_group_id, notes,
 		       import_plugin_id, import_external_id, created_at, updated_at
 		FROM assets
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 	var a domain.Asset
 	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&a.ID, &a.OrganizationID, &a.CategoryID, &a.LocationID, &a.ConditionID, &a.CollectionID, &a.MainAttachmentID,
-		&a.Name, &a.Description, &a.Quantity, &a.Attributes, &a.PurchaseAt, &a.PurchasePrice, &a.PurchaseNote, &a.Notes,
+		&a.ID, &a.OrganizationID, &a.CategoryID, &a.LocationID, &a.ConditionID, &a.CollectionID, &a.OwnerID, &a.MainAttachmentID, &a.ShortID,
+		&a.Name, &a.Description, &a.Quantity, &a.MinQuantity, &a.Attributes, &a.HighValue, &a.ArchivedAt, &a.Status, &a.PurchaseAt, &a.PurchasePrice, &a.CurrentValue, &a.Currency, &a.PurchaseNote, &a.PurchaseGroupID, &a.Notes,
 		&a.ImportPluginID, &a.ImportExternalID, &a.CreatedAt, &a.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
@@ -80,6 +82,17 @@ func (r *AssetRepository) GetByIDFull(ctx context.Context, id uuid.UUID) (*domai
 		}
 	}
 
+	// Load owner if set
+	if asset.OwnerID != nil {
+		ownerQuery := `SELECT id, email, display_name FROM users WHERE id = $1 AND deleted_at IS NULL`
+		var owner domain.AssetOwner
+		if err := r.pool.QueryRow(ctx, ownerQuery, asset.OwnerID).Scan(
+			&owner.ID, &owner.Email, &owner.DisplayName,
+		); err == nil {
+			asset.Owner = &owner
+		}
+	}
+
 	// Load tags
 	tagQuery := `
 		SELECT t.id, t.organization_id, t.name, t.created_at
@@ -110,13 +123,13 @@ func (r *AssetRepository) GetByIDFull(ctx context.Context, id uuid.UUID) (*domai
 	// Load main attachment if set
 	if asset.MainAttachmentID != nil {
 		attQuery := `
-			SELECT id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description, created_at
+			SELECT id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description, created_at, derived_from_id, edit
 			FROM attachments WHERE id = $1
 		`
 		var att domain.Attachment
 		if err := r.pool.QueryRow(ctx, attQuery, asset.MainAttachmentID).Scan(
 			&att.ID, &att.AssetID, &att.UploadedBy, &att.FileKey, &att.FileName,
-			&att.FileSize, &att.ContentType, &att.Description, &att.CreatedAt,
+			&att.FileSize, &att.ContentType, &att.Description, &att.CreatedAt, &att.DerivedFromID, &att.Edit,
 		); err == nil {
 			asset.MainAttachment = &att
 		}
@@ -125,7 +138,8 @@ func (r *AssetRepository) GetByIDFull(ctx context.Context, id uuid.UUID) (*domai
 	return asset, nil
 }
 
-func (r *AssetRepository) List(ctx context.Context, orgID uuid.UUID, filter domain.AssetFilter, page domain.Pagination) ([]domain.Asset, int, error) {
+// assetFilterClause builds the WHERE clause (over alias "a") and arguments for an asset filter
+func assetFilterClause(orgID uuid.UUID, filter domain.AssetFilter) (string, []any) {
 	var conditions []string
 	var args []any
 	argNum := 1
@@ -141,6 +155,11 @@ func (r *AssetRepository) List(ctx context.Context, orgID uuid.UUID, filter doma
 		args = append(args, *filter.CategoryID)
 		argNum++
 	}
+	if filter.CategoryIDs != nil {
+		conditions = append(conditions, fmt.Sprintf("a.category_id = ANY($%d)", argNum))
+		args = append(args, filter.CategoryIDs)
+		argNum++
+	}
 	if filter.LocationID != nil {
 		conditions = append(conditions, fmt.Sprintf("a.location_id = $%d", argNum))
 		args = append(args, *filter.LocationID)
@@ -151,13 +170,52 @@ func (r *AssetRepository) List(ctx context.Context, orgID uuid.UUID, filter doma
 		args = append(args, *filter.ConditionID)
 		argNum++
 	}
+	if filter.OwnerID != nil {
+		conditions = append(conditions, fmt.Sprintf("a.owner_id = $%d", argNum))
+		args = append(args, *filter.OwnerID)
+		argNum++
+	}
+	if filter.NoOwner {
+		conditions = append(conditions, "a.owner_id IS NULL")
+	}
+	if filter.NoHighValue {
+		conditions = append(conditions, "NOT a.high_value")
+	}
+	switch filter.Archived {
+	case domain.ArchivedExclude:
+		conditions = append(conditions, "a.archived_at IS NULL")
+	case domain.ArchivedOnly:
+		conditions = append(conditions, "a.archived_at IS NOT NULL")
+	}
 	if filter.Query != "" {
-		conditions = append(conditions, fmt.Sprintf("a.search_vector @@ plainto_tsquery('english', $%d)", argNum))
+		// Also match the text extracted from the asset's documents
+		conditions = append(conditions, fmt.Sprintf(`(a.search_vector @@ plainto_tsquery('english', $%[1]d)
+			OR EXISTS (SELECT 1 FROM attachments doc WHERE doc.asset_id = a.id AND doc.search_vector @@ plainto_tsquery('english', $%[1]d)))`, argNum))
 		args = append(args, filter.Query)
 		argNum++
 	}
+	if filter.IDs != nil {
+		conditions = append(conditions, fmt.Sprintf("a.id = ANY($%d)", argNum))
+		args = append(args, filter.IDs)
+		argNum++
+	}
+	if filter.Statuses != nil {
+		conditions = append(conditions, fmt.Sprintf("a.status = ANY($%d)", argNum))
+		args = append(args, filter.Statuses)
+		argNum++
+	}
+	if len(filter.TagIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM asset_tags at WHERE at.asset_id = a.id AND at.tag_id = ANY($%d))", argNum))
+		args = append(args, filter.TagIDs)
+		argNum++
+	}
 
-	whereClause := strings.Join(conditions, " AND ")
+	return strings.Join(conditions, " AND "), args
+}
+
+func (r *AssetRepository) List(ctx context.Context, orgID uuid.UUID, filter domain.AssetFilter, page domain.Pagination) ([]domain.Asset, int, error) {
+	whereClause, args := assetFilterClause(orgID, filter)
+	argNum := len(args) + 1
 
 	// Count total
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM assets a WHERE %s", whereClause)
@@ -168,16 +226,18 @@ func (r *AssetRepository) List(ctx context.Context, orgID uuid.UUID, filter doma
 
 	// Get assets with related data
 	query := fmt.Sprintf(`
-		SELECT a.id, a.organization_id, a.category_id, a.location_id, a.condition_id, a.collection_id, a.main_attachment_id,
-		       a.name, a.description, a.quantity, a.attributes, a.purchase_at, a.purchase_price, a.purchase_note, a.notes, a.created_at, a.updated_at,
+		SELECT a.id, a.organization_id, a.category_id, a.location_id, a.condition_id, a.collection_id, a.owner_id, a.main_attachment_id, a.short_id,
+		       a.name, a.description, a.quantity, a.min_quantity, a.attributes, a.high_value, a.archived_at, a.status, a.purchase_at, a.purchase_price, a.current_value, a.currency, a.purchase_note, a.purchase_group_id, a.notes, a.created_at, a.updated_at,
 		       c.id, c.name,
 		       l.id, l.name,
 		       cond.id, cond.code, cond.label,
+		       u.email, u.display_name,
 		       att.id, att.file_key, att.file_name, att.content_type
 		FROM assets a
 		LEFT JOIN categories c ON c.id = a.category_id AND c.deleted_at IS NULL
 		LEFT JOIN locations l ON l.id = a.location_id AND l.deleted_at IS NULL
 		LEFT JOIN conditions cond ON cond.id = a.condition_id AND cond.deleted_at IS NULL
+		LEFT JOIN users u ON u.id = a.owner_id AND u.deleted_at IS NULL
 		LEFT JOIN attachments att ON att.id = a.main_attachment_id
 		WHERE %s
 		ORDER BY a.updated_at DESC
@@ -198,14 +258,16 @@ func (r *AssetRepository) List(ctx context.Context, orgID uuid.UUID, filter doma
 		var catID, catName *string
 		var locID, locName *string
 		var condID, condCode, condLabel *string
+		var ownerEmail, ownerName *string
 		var attID, attFileKey, attFileName, attContentType *string
 
 		if err := rows.Scan(
-			&a.ID, &a.OrganizationID, &a.CategoryID, &a.LocationID, &a.ConditionID, &a.CollectionID, &a.MainAttachmentID,
-			&a.Name, &a.Description, &a.Quantity, &a.Attributes, &a.PurchaseAt, &a.PurchasePrice, &a.PurchaseNote, &a.Notes, &a.CreatedAt, &a.UpdatedAt,
+			&a.ID, &a.OrganizationID, &a.CategoryID, &a.LocationID, &a.ConditionID, &a.CollectionID, &a.OwnerID, &a.MainAttachmentID, &a.ShortID,
+			&a.Name, &a.Description, &a.Quantity, &a.MinQuantity, &a.Attributes, &a.HighValue, &a.ArchivedAt, &a.Status, &a.PurchaseAt, &a.PurchasePrice, &a.CurrentValue, &a.Currency, &a.PurchaseNote, &a.PurchaseGroupID, &a.Notes, &a.CreatedAt, &a.UpdatedAt,
 			&catID, &catName,
 			&locID, &locName,
 			&condID, &condCode, &condLabel,
+			&ownerEmail, &ownerName,
 			&attID, &attFileKey, &attFileName, &attContentType,
 		); err != nil {
 			return nil, 0, err
@@ -236,6 +298,15 @@ func (r *AssetRepository) List(ctx context.Context, orgID uuid.UUID, filter doma
 			}
 		}
 
+		// Populate owner
+		if a.OwnerID != nil && ownerEmail != nil {
+			a.Owner = &domain.AssetOwner{
+				ID:          *a.OwnerID,
+				Email:       *ownerEmail,
+				DisplayName: ownerName,
+			}
+		}
+
 		// Populate main attachment
 		if attID != nil && attFileKey != nil && attFileName != nil {
 			a.MainAttachment = &domain.Attachment{
@@ -252,17 +323,85 @@ func (r *AssetRepository) List(ctx context.Context, orgID uuid.UUID, filter doma
 	return assets, total, rows.Err()
 }
 
+// Facets counts assets matching the filter per value of each requested
+// facet ("category", "location", "condition", "owner", "tags") in a single query
+func (r *AssetRepository) Facets(ctx context.Context, orgID uuid.UUID, filter domain.AssetFilter, facets []string) (map[string][]domain.FacetCount, error) {
+	var selects []string
+	for _, facet := range facets {
+		if sel, ok := facetQueries[facet]; ok {
+			selects = append(selects, sel)
+		}
+	}
+	result := make(map[string][]domain.FacetCount)
+	if len(selects) == 0 {
+		return result, nil
+	}
+
+	whereClause, args := assetFilterClause(orgID, filter)
+	query := fmt.Sprintf(`
+		WITH filtered AS (SELECT a.id, a.category_id, a.location_id, a.condition_id, a.owner_id FROM assets a WHERE %s)
+		%s
+		ORDER BY 1, 4 DESC, 3
+	`, whereClause, strings.Join(selects, " UNION ALL "))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for _, facet := range facets {
+		if _, ok := facetQueries[facet]; ok {
+			result[facet] = []domain.FacetCount{}
+		}
+	}
+	for rows.Next() {
+		var facet string
+		var fc domain.FacetCount
+		if err := rows.Scan(&facet, &fc.ID, &fc.Name, &fc.Count); err != nil {
+			return nil, err
+		}
+		result[facet] = append(result[facet], fc)
+	}
+	return result, rows.Err()
+}
+
+// facetQueries are the per-facet aggregations over the "filtered" CTE
+var facetQueries = map[string]string{
+	"category":  `(SELECT 'category', c.id, c.name, COUNT(*) FROM filtered f JOIN categories c ON c.id = f.category_id GROUP BY c.id, c.name)`,
+	"location":  `(SELECT 'location', l.id, l.name, COUNT(*) FROM filtered f JOIN locations l ON l.id = f.location_id GROUP BY l.id, l.name)`,
+	"condition": `(SELECT 'condition', c.id, c.label, COUNT(*) FROM filtered f JOIN conditions c ON c.id = f.condition_id GROUP BY c.id, c.label)`,
+	"owner":     `(SELECT 'owner', u.id, COALESCE(u.display_name, u.email), COUNT(*) FROM filtered f JOIN users u ON u.id = f.owner_id GROUP BY u.id, u.display_name, u.email)`,
+	"tags":      `(SELECT 'tags', t.id, t.name, COUNT(*) FROM filtered f JOIN asset_tags at ON at.asset_id = f.id JOIN tags t ON t.id = at.tag_id GROUP BY t.id, t.name)`,
+}
+
 func (r *AssetRepository) Search(ctx context.Context, orgID uuid.UUID, query string, page domain.Pagination) ([]domain.Asset, int, error) {
 	filter := domain.AssetFilter{Query: query}
 	return r.List(ctx, orgID, filter, page)
 }
 
+// FindByAttribute returns the IDs of the organization's assets whose string
+// attribute key equals value, ignoring case and surrounding spaces
+func (r *AssetRepository) FindByAttribute(ctx context.Context, orgID uuid.UUID, key, value string) ([]uuid.UUID, error) {
+	query := `
+		SELECT id FROM assets
+		WHERE organization_id = $1 AND deleted_at IS NULL
+		  AND lower(trim(attributes->>$2)) = lower(trim($3))
+		ORDER BY created_at
+	`
+	rows, err := r.pool.Query(ctx, query, orgID, key, value)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
 func (r *AssetRepository) Create(ctx context.Context, a *domain.Asset) error {
 	query := `
 		INSERT INTO assets (id, organization_id, category_id, location_id, condition_id, collection_id,
 		                    name, description, quantity, attributes, purchase_at, purchase_price, purchase_note, notes,
-		                    import_plugin_id, import_external_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		                    import_plugin_id, import_external_id, owner_id, high_value, currency, archived_at, status, short_id, min_quantity)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 		RETURNING created_at, updated_at
 	`
 	if a.ID == uuid.Nil {
@@ -271,25 +410,70 @@ func (r *AssetRepository) Create(ctx context.Context, a *domain.Asset) error {
 	if a.Attributes == nil {
 		a.Attributes = []byte("{}")
 	}
-	return r.pool.QueryRow(ctx, query,
-		a.ID, a.OrganizationID, a.CategoryID, a.LocationID, a.ConditionID, a.CollectionID,
-		a.Name, a.Description, a.Quantity, a.Attributes, a.PurchaseAt, a.PurchasePrice, a.PurchaseNote, a.Notes,
-		a.ImportPluginID, a.ImportExternalID,
-	).Scan(&a.CreatedAt, &a.UpdatedAt)
+	if a.Status == "" {
+		a.Status = domain.AssetStatusOwned
+	}
+	shortID, err := withShortID(func(shortID string) error {
+		return r.pool.QueryRow(ctx, query,
+			a.ID, a.OrganizationID, a.CategoryID, a.LocationID, a.ConditionID, a.CollectionID,
+			a.Name, a.Description, a.Quantity, a.Attributes, a.PurchaseAt, a.PurchasePrice, a.PurchaseNote, a.Notes,
+			a.ImportPluginID, a.ImportExternalID, a.OwnerID, a.HighValue, a.Currency, a.ArchivedAt, a.Status, shortID, a.MinQuantity,
+		).Scan(&a.CreatedAt, &a.UpdatedAt)
+	})
+	if err != nil {
+		return err
+	}
+	a.ShortID = &shortID
+	return nil
 }
 
-func (r *AssetRepository) Update(ctx context.Context, a *domain.Asset) error {
-	query := `
-		UPDATE assets
-		SET category_id = $2, location_id = $3, condition_id = $4, collection_id = $5,
-		    name = $6, description = $7, quantity = $8, attributes = $9, purchase_at = $10, purchase_price = $11, purchase_note = $12, notes = $13
-		WHERE id = $1 AND deleted_at IS NULL
-		RETURNING updated_at
-	`
-	return r.pool.QueryRow(ctx, query,
+const updateAssetQuery = `
+	UPDATE assets
+	SET category_id = $2, location_id = $3, condition_id = $4, collection_id = $5,
+	    name = $6, description = $7, quantity = $8, attributes = $9, purchase_at = $10, purchase_price = $11, purchase_note = $12, notes = $13,
+	    owner_id = $14, high_value = $15, currency = $16, archived_at = $17, status = $18, min_quantity = $19,
+	    low_stock_notified_at = CASE WHEN $8 < $19 THEN low_stock_notified_at END
+	WHERE id = $1 AND deleted_at IS NULL
+	RETURNING updated_at
+`
+
+func updateAssetArgs(a *domain.Asset) []any {
+	return []any{
 		a.ID, a.CategoryID, a.LocationID, a.ConditionID, a.CollectionID,
 		a.Name, a.Description, a.Quantity, a.Attributes, a.PurchaseAt, a.PurchasePrice, a.PurchaseNote, a.Notes,
-	).Scan(&a.UpdatedAt)
+		a.OwnerID, a.HighValue, a.Currency, a.ArchivedAt, a.Status, a.MinQuantity,
+	}
+}
+
+func (r *AssetRepository) Update(ctx context.Context, a *domain.Asset) error {
+	return r.pool.QueryRow(ctx, updateAssetQuery, updateAssetArgs(a)...).Scan(&a.UpdatedAt)
+}
+
+// Restore updates an asset and, unless tagIDs is nil, replaces its tags in
+// one transaction. Tags that were deleted in the meantime are skipped.
+func (r *AssetRepository) Restore(ctx context.Context, a *domain.Asset, tagIDs []uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := tx.QueryRow(ctx, updateAssetQuery, updateAssetArgs(a)...).Scan(&a.UpdatedAt); err != nil {
+		return err
+	}
+	if tagIDs != nil {
+		if _, err := tx.Exec(ctx, "DELETE FROM asset_tags WHERE asset_id = $1", a.ID); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO asset_tags (asset_id, tag_id)
+			SELECT $1, id FROM tags WHERE id = ANY($2) AND organization_id = $3
+		`, a.ID, tagIDs, a.OrganizationID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
 }
 
 func (r *AssetRepository) Delete(ctx context.Context, id uuid.UUID) error {
@@ -320,17 +504,197 @@ func (r *AssetRepository) SetTags(ctx context.Context, assetID uuid.UUID, tagIDs
 	return tx.Commit(ctx)
 }
 
+// Bulk applies an operation to assets in one transaction. If any of the
+// assets isn't one of the organization's, nothing changes and their IDs are
+// returned.
+func (r *AssetRepository) Bulk(ctx context.Context, orgID uuid.UUID, op domain.BulkAssetOperation) ([]uuid.UUID, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id FROM assets
+		WHERE id = ANY($1) AND organization_id = $2 AND deleted_at IS NULL AND NOT (high_value AND $3)
+		FOR UPDATE
+	`, op.AssetIDs, orgID, op.NoHighValue)
+	if err != nil {
+		return nil, err
+	}
+	found, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, err
+	}
+	var missing []uuid.UUID
+	for _, id := range op.AssetIDs {
+		if !slices.Contains(found, id) {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return missing, nil
+	}
+
+	ids := op.AssetIDs
+	switch op.Action {
+	case domain.BulkAssetDelete:
+		_, err = tx.Exec(ctx, `UPDATE assets SET deleted_at = NOW() WHERE id = ANY($1)`, ids)
+	case domain.BulkAssetSetLocation:
+		_, err = tx.Exec(ctx, `UPDATE assets SET location_id = $2 WHERE id = ANY($1)`, ids, op.TargetID)
+	case domain.BulkAssetSetCategory:
+		_, err = tx.Exec(ctx, `UPDATE assets SET category_id = $2 WHERE id = ANY($1)`, ids, op.TargetID)
+	case domain.BulkAssetSetCondition:
+		_, err = tx.Exec(ctx, `UPDATE assets SET condition_id = $2 WHERE id = ANY($1)`, ids, op.TargetID)
+	case domain.BulkAssetAddTags:
+		_, err = tx.Exec(ctx, `
+			INSERT INTO asset_tags (asset_id, tag_id)
+			SELECT a, t FROM unnest($1::uuid[]) a, unnest($2::uuid[]) t
+			ON CONFLICT DO NOTHING
+		`, ids, op.TagIDs)
+	case domain.BulkAssetRemoveTags:
+		_, err = tx.Exec(ctx, `DELETE FROM asset_tags WHERE asset_id = ANY($1) AND tag_id = ANY($2)`, ids, op.TagIDs)
+	default:
+		return nil, fmt.Errorf("unknown bulk action %q", op.Action)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if op.Action == domain.BulkAssetAddTags || op.Action == domain.BulkAssetRemoveTags {
+		// Tags are part of the asset for sync clients
+		if _, err := tx.Exec(ctx, `UPDATE assets SET updated_at = NOW() WHERE id = ANY($1)`, ids); err != nil {
+			return nil, err
+		}
+	}
+	return nil, tx.Commit(ctx)
+}
+
+// heldStatuses lists the statuses of assets that count toward the inventory
+// value, see domain.AssetStatus.Held
+const heldStatuses = `('owned', 'loaned', 'in_repair')`
+
+// GetTotalValue returns the purchase value of the held, active assets
 func (r *AssetRepository) GetTotalValue(ctx context.Context, orgID uuid.UUID) (float64, error) {
 	query := `
 		SELECT COALESCE(SUM(purchase_price * quantity), 0)
 		FROM assets
-		WHERE organization_id = $1 AND deleted_at IS NULL
+		WHERE organization_id = $1 AND deleted_at IS NULL AND archived_at IS NULL AND status IN ` + heldStatuses + `
 	`
 	var total float64
 	err := r.pool.QueryRow(ctx, query, orgID).Scan(&total)
 	return total, err
 }
 
+// ValueByOwner returns the count and total value of held assets per owner,
+// including a nil-owner entry for unassigned assets
+func (r *AssetRepository) ValueByOwner(ctx context.Context, orgID uuid.UUID) ([]domain.OwnerValue, error) {
+	query := `
+		SELECT u.id, COALESCE(u.display_name, u.email, ''), COUNT(*), COALESCE(SUM(a.purchase_price * a.quantity), 0)
+		FROM assets a
+		LEFT JOIN users u ON u.id = a.owner_id AND u.deleted_at IS NULL
+		WHERE a.organization_id = $1 AND a.deleted_at IS NULL AND a.archived_at IS NULL AND a.status IN ` + heldStatuses + `
+		GROUP BY u.id, u.display_name, u.email
+		ORDER BY u.id IS NULL, 4 DESC
+	`
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []domain.OwnerValue{}
+	for rows.Next() {
+		var v domain.OwnerValue
+		if err := rows.Scan(&v.OwnerID, &v.Name, &v.Count, &v.TotalValue); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// ValueByStatus returns asset count and total value per lifecycle status, in
+// lifecycle order
+func (r *AssetRepository) ValueByStatus(ctx context.Context, orgID uuid.UUID) ([]domain.StatusValue, error) {
+	query := `
+		SELECT status, COUNT(*), COALESCE(SUM(purchase_price * quantity), 0)
+		FROM assets
+		WHERE organization_id = $1 AND deleted_at IS NULL AND archived_at IS NULL
+		GROUP BY status
+	`
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []domain.StatusValue{}
+	for rows.Next() {
+		var v domain.StatusValue
+		if err := rows.Scan(&v.Status, &v.Count, &v.TotalValue); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	slices.SortFunc(values, func(a, b domain.StatusValue) int {
+		return slices.Index(domain.AssetStatuses, a.Status) - slices.Index(domain.AssetStatuses, b.Status)
+	})
+	return values, rows.Err()
+}
+
+// History returns the asset count and total value every step from from to to,
+// based on when assets were created, archived and deleted (at their current
+// prices), with the total of the valuations recorded by then
+func (r *AssetRepository) History(ctx context.Context, orgID uuid.UUID, from, to time.Time, step time.Duration) ([]domain.AssetHistoryPoint, error) {
+	query := `
+		SELECT t, COUNT(a.id), COALESCE(SUM(a.purchase_price * a.quantity), 0),
+		       COALESCE(SUM(COALESCE(v.value, a.purchase_price) * a.quantity), 0)
+		FROM generate_series($2::timestamptz, $3::timestamptz, $4::interval) AS t
+		LEFT JOIN assets a ON a.organization_id = $1 AND a.created_at <= t
+		  AND (a.deleted_at IS NULL OR a.deleted_at > t)
+		  AND (a.archived_at IS NULL OR a.archived_at > t)
+		LEFT JOIN LATERAL (
+			SELECT av.value FROM asset_values av
+			WHERE av.asset_id = a.id AND av.valued_at <= t
+			ORDER BY av.valued_at DESC, av.created_at DESC
+			LIMIT 1
+		) v ON true
+		GROUP BY t
+		ORDER BY t
+	`
+	rows, err := r.pool.Query(ctx, query, orgID, from, to, step)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []domain.AssetHistoryPoint{}
+	for rows.Next() {
+		var p domain.AssetHistoryPoint
+		if err := rows.Scan(&p.At, &p.Count, &p.Value, &p.CurrentValue); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// FlagHighValue flags the assets whose total purchase value reaches threshold,
+// returning how many were newly flagged. Existing flags are never cleared.
+func (r *AssetRepository) FlagHighValue(ctx context.Context, orgID uuid.UUID, threshold float64) (int64, error) {
+	query := `
+		UPDATE assets
+		SET high_value = true
+		WHERE organization_id = $1 AND deleted_at IS NULL AND NOT high_value
+		  AND purchase_price * quantity >= $2
+	`
+	tag, err := r.pool.Exec(ctx, query, orgID, threshold)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
 func (r *AssetRepository) SetMainAttachment(ctx context.Context, assetID uuid.UUID, attachmentID *uuid.UUID) error {
 	query := `
 		UPDATE assets
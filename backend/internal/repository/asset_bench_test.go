@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/seed"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+// benchAssets is the number of generated assets the benchmarks run against;
+// ATTIC_BENCH_ASSETS measures other sizes, without enforcing the budgets
+const benchAssets = 10000
+
+// Latency budgets per operation at benchAssets assets, see "Performance
+// Budgets" in the README. They are generous for CI machines; a benchmark over
+// budget fails.
+var benchBudgets = map[string]time.Duration{
+	"List":        25 * time.Millisecond,
+	"Search":      40 * time.Millisecond,
+	"GetByIDFull": 5 * time.Millisecond,
+}
+
+var benchData struct {
+	once    sync.Once
+	org     uuid.UUID
+	assetID uuid.UUID
+	size    int
+	err     error
+}
+
+// benchOrganization returns an organization filled with generated assets,
+// created once per run
+func benchOrganization(b *testing.B) uuid.UUID {
+	b.Helper()
+	benchData.once.Do(func() {
+		ctx := context.Background()
+		benchData.size = benchAssets
+		if v := os.Getenv("ATTIC_BENCH_ASSETS"); v != "" {
+			if benchData.size, benchData.err = strconv.Atoi(v); benchData.err != nil {
+				return
+			}
+		}
+		if benchData.err = testDB.TruncateAll(ctx); benchData.err != nil {
+			return
+		}
+		org, err := testutil.NewFixtures(testDB.Pool).CreateOrganization(ctx, "Benchmark")
+		if err != nil {
+			benchData.err = err
+			return
+		}
+		benchData.org = org.ID
+		if _, benchData.err = seed.New(testDB.Pool, nil).Run(ctx, org.ID, seed.Options{Assets: benchData.size, Seed: 1}); benchData.err != nil {
+			return
+		}
+		benchData.err = testDB.Pool.QueryRow(ctx, `SELECT id FROM assets WHERE organization_id = $1 ORDER BY id LIMIT 1`, org.ID).Scan(&benchData.assetID)
+	})
+	if benchData.err != nil {
+		b.Fatalf("failed to seed benchmark data: %v", benchData.err)
+	}
+	b.ResetTimer()
+	return benchData.org
+}
+
+// checkBudget fails the benchmark if an operation took longer than its
+// budget on average
+func checkBudget(b *testing.B, name string) {
+	b.Helper()
+	if benchData.size != benchAssets || b.N == 0 {
+		return
+	}
+	perOp := b.Elapsed() / time.Duration(b.N)
+	if budget := benchBudgets[name]; perOp > budget {
+		b.Errorf("%s took %s per operation, over its budget of %s", name, perOp, budget)
+	}
+}
+
+func BenchmarkAssetRepository_List(b *testing.B) {
+	orgID := benchOrganization(b)
+	repo := NewAssetRepository(testDB.Pool)
+	ctx := context.Background()
+	for b.Loop() {
+		if _, _, err := repo.List(ctx, orgID, domain.AssetFilter{}, domain.Pagination{Limit: 20}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	checkBudget(b, "List")
+}
+
+func BenchmarkAssetRepository_Search(b *testing.B) {
+	orgID := benchOrganization(b)
+	repo := NewAssetRepository(testDB.Pool)
+	ctx := context.Background()
+	queries := []string{"drill", "sony headphones", "oak", "camera lens", "tent"}
+	i := 0
+	for b.Loop() {
+		filter := domain.AssetFilter{Query: queries[i%len(queries)]}
+		if _, _, err := repo.List(ctx, orgID, filter, domain.Pagination{Limit: 20}); err != nil {
+			b.Fatal(err)
+		}
+		i++
+	}
+	checkBudget(b, "Search")
+}
+
+func BenchmarkAssetRepository_GetByIDFull(b *testing.B) {
+	benchOrganization(b)
+	repo := NewAssetRepository(testDB.Pool)
+	ctx := context.Background()
+	for b.Loop() {
+		asset, err := repo.GetByIDFull(ctx, benchData.assetID)
+		if err != nil || asset == nil {
+			b.Fatalf("failed to get asset: %v", err)
+		}
+	}
+	checkBudget(b, "GetByIDFull")
+}
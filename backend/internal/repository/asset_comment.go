@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type AssetCommentRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAssetCommentRepository(pool *pgxpool.Pool) *AssetCommentRepository {
+	return &AssetCommentRepository{pool: pool}
+}
+
+const assetCommentColumns = `
+	c.id, c.asset_id, c.author_id, c.body, c.created_at, u.email, u.display_name
+`
+
+func scanAssetComment(row pgx.Row) (*domain.AssetComment, error) {
+	var c domain.AssetComment
+	err := row.Scan(&c.ID, &c.AssetID, &c.AuthorID, &c.Body, &c.CreatedAt, &c.AuthorEmail, &c.AuthorName)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetByID returns a comment of an asset, nil if the asset has no such comment
+func (r *AssetCommentRepository) GetByID(ctx context.Context, assetID, id uuid.UUID) (*domain.AssetComment, error) {
+	query := `
+		SELECT ` + assetCommentColumns + `
+		FROM asset_comments c
+		LEFT JOIN users u ON u.id = c.author_id
+		WHERE c.id = $1 AND c.asset_id = $2
+	`
+	c, err := scanAssetComment(r.pool.QueryRow(ctx, query, id, assetID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// ListByAsset returns the comments of an asset, newest first
+func (r *AssetCommentRepository) ListByAsset(ctx context.Context, assetID uuid.UUID) ([]domain.AssetComment, error) {
+	query := `
+		SELECT ` + assetCommentColumns + `
+		FROM asset_comments c
+		LEFT JOIN users u ON u.id = c.author_id
+		WHERE c.asset_id = $1
+		ORDER BY c.created_at DESC, c.id
+	`
+	rows, err := r.pool.Query(ctx, query, assetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []domain.AssetComment{}
+	for rows.Next() {
+		c, err := scanAssetComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *c)
+	}
+	return comments, rows.Err()
+}
+
+func (r *AssetCommentRepository) Create(ctx context.Context, c *domain.AssetComment) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO asset_comments (id, asset_id, author_id, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+	return r.pool.QueryRow(ctx, query, c.ID, c.AssetID, c.AuthorID, c.Body).Scan(&c.CreatedAt)
+}
+
+func (r *AssetCommentRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM asset_comments WHERE id = $1`, id)
+	return err
+}
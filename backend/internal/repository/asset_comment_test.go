@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_AssetCommentRepository(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "ana@example.com")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Appliances", nil)
+	dryer, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Dryer")
+	fridge, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Fridge")
+
+	repo := NewAssetCommentRepository(testDB.Pool)
+	first := &domain.AssetComment{AssetID: dryer.ID, AuthorID: &user.ID, Body: "Cleaned the lint trap"}
+	second := &domain.AssetComment{AssetID: dryer.ID, AuthorID: &user.ID, Body: "Replaced the belt"}
+	for _, c := range []*domain.AssetComment{first, second} {
+		if err := repo.Create(ctx, c); err != nil {
+			t.Fatalf("failed to create comment: %v", err)
+		}
+	}
+
+	comments, err := repo.ListByAsset(ctx, dryer.ID)
+	if err != nil || len(comments) != 2 || comments[0].ID != second.ID {
+		t.Fatalf("expected both comments, newest first, got %+v, %v", comments, err)
+	}
+	if comments[0].AuthorEmail == nil || *comments[0].AuthorEmail != "ana@example.com" {
+		t.Errorf("expected the author's email, got %v", comments[0].AuthorEmail)
+	}
+	if other, _ := repo.ListByAsset(ctx, fridge.ID); len(other) != 0 {
+		t.Errorf("expected no comments on another asset, got %+v", other)
+	}
+
+	// Comments are only found through their asset
+	if got, _ := repo.GetByID(ctx, fridge.ID, first.ID); got != nil {
+		t.Errorf("expected no comment through another asset, got %+v", got)
+	}
+	if got, _ := repo.GetByID(ctx, dryer.ID, uuid.New()); got != nil {
+		t.Errorf("expected no comment for an unknown ID, got %+v", got)
+	}
+
+	if err := repo.Delete(ctx, first.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if comments, _ := repo.ListByAsset(ctx, dryer.ID); len(comments) != 1 || comments[0].ID != second.ID {
+		t.Errorf("expected only the second comment to remain, got %+v", comments)
+	}
+}
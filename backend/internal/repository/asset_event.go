@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+)
+
+type AssetEventRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAssetEventRepository(pool *pgxpool.Pool) *AssetEventRepository {
+	return &AssetEventRepository{pool: pool}
+}
+
+// assetSnapshot selects the current state of asset $1 as a domain.AssetSnapshot,
+// with the status, high-value and archive flags, low-stock threshold, current
+// value and deletion time, which aren't reverted, for the history
+const assetSnapshot = `
+	SELECT jsonb_build_object(
+	           'category_id', a.category_id, 'location_id', a.location_id, 'condition_id', a.condition_id,
+	           'collection_id', a.collection_id, 'owner_id', a.owner_id, 'name', a.name, 'description', a.description,
+	           'quantity', a.quantity, 'attributes', a.attributes, 'purchase_at', a.purchase_at,
+	           'purchase_price', a.purchase_price, 'currency', a.currency, 'purchase_note', a.purchase_note, 'notes', a.notes,
+	           'tag_ids', COALESCE((SELECT jsonb_agg(at.tag_id ORDER BY at.tag_id) FROM asset_tags at WHERE at.asset_id = a.id), '[]'),
+	           'status', a.status, 'high_value', a.high_value, 'archived_at', a.archived_at, 'min_quantity', a.min_quantity,
+	           'current_value', a.current_value, 'deleted_at', a.deleted_at
+	       )
+	FROM assets a
+	WHERE a.id = $1
+`
+
+// Create records an event together with a snapshot of the asset as it is
+// now, i.e. right after the event. The changes since the previous snapshot
+// (or, for a new asset, all its fields) are added to the event's own changes,
+// so every field change is recorded whichever way the asset was changed.
+func (r *AssetEventRepository) Create(ctx context.Context, e *domain.AssetEvent) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the asset so concurrent events of it are diffed in order
+	var snapshot json.RawMessage
+	if err := tx.QueryRow(ctx, assetSnapshot+` FOR UPDATE`, e.AssetID).Scan(&snapshot); err != nil {
+		return err
+	}
+	var previous json.RawMessage
+	err = tx.QueryRow(ctx, `
+		SELECT snapshot FROM asset_events
+		WHERE asset_id = $1 AND snapshot IS NOT NULL
+		ORDER BY created_at DESC, id
+		LIMIT 1
+	`, e.AssetID).Scan(&previous)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	// Without a previous snapshot only a new asset can be diffed: older
+	// assets may have changed before snapshots were recorded
+	if previous != nil || e.Type == string(events.AssetCreated) {
+		derived, err := domain.SnapshotChanges(previous, snapshot)
+		if err != nil {
+			return err
+		}
+		e.Changes = mergeChanges(e.Changes, derived)
+	}
+	if e.Changes == nil {
+		e.Changes = []domain.AssetChange{}
+	}
+
+	query := `
+		INSERT INTO asset_events (id, organization_id, asset_id, event_type, actor_id, changes, snapshot)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING snapshot, created_at
+	`
+	err = tx.QueryRow(ctx, query,
+		e.ID, e.OrganizationID, e.AssetID, e.Type, e.ActorID, e.Changes, snapshot,
+	).Scan(&e.Snapshot, &e.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// mergeChanges adds the derived changes of fields the own changes don't cover
+func mergeChanges(own, derived []domain.AssetChange) []domain.AssetChange {
+	merged := slices.Clip(own)
+	for _, d := range derived {
+		if !slices.ContainsFunc(own, func(c domain.AssetChange) bool { return c.Field == d.Field }) {
+			merged = append(merged, d)
+		}
+	}
+	return merged
+}
+
+// GetByID returns an event with its snapshot, or nil if it doesn't exist
+func (r *AssetEventRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AssetEvent, error) {
+	query := `
+		SELECT e.id, e.organization_id, e.asset_id, e.event_type, e.actor_id, u.email, e.changes, e.snapshot, e.created_at
+		FROM asset_events e
+		LEFT JOIN users u ON u.id = e.actor_id
+		WHERE e.id = $1
+	`
+	var e domain.AssetEvent
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&e.ID, &e.OrganizationID, &e.AssetID, &e.Type, &e.ActorID, &e.ActorEmail, &e.Changes, &e.Snapshot, &e.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListByAsset returns the history of an asset, newest first. With a field
+// prefix (e.g. domain.AttributePrefix) only events changing such fields are
+// returned, with just those changes.
+func (r *AssetEventRepository) ListByAsset(ctx context.Context, assetID uuid.UUID, fieldPrefix string) ([]domain.AssetEvent, error) {
+	query := `
+		SELECT e.id, e.organization_id, e.asset_id, e.event_type, e.actor_id, u.email,
+		       COALESCE((
+		           SELECT jsonb_agg(c ORDER BY n)
+		           FROM jsonb_array_elements(e.changes) WITH ORDINALITY AS x(c, n)
+		           WHERE starts_with(c->>'field', $2)
+		       ), '[]'),
+		       e.created_at
+		FROM asset_events e
+		LEFT JOIN users u ON u.id = e.actor_id
+		WHERE e.asset_id = $1
+		  AND ($2 = '' OR EXISTS (
+		      SELECT 1 FROM jsonb_array_elements(e.changes) c WHERE starts_with(c->>'field', $2)
+		  ))
+		ORDER BY e.created_at DESC, e.id
+	`
+	rows, err := r.pool.Query(ctx, query, assetID, fieldPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []domain.AssetEvent{}
+	for rows.Next() {
+		var e domain.AssetEvent
+		if err := rows.Scan(
+			&e.ID, &e.OrganizationID, &e.AssetID, &e.Type, &e.ActorID, &e.ActorEmail, &e.Changes, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		history = append(history, e)
+	}
+	return history, rows.Err()
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_AssetEventRepository_ListByAsset(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "editor@example.com")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Camera")
+
+	repo := NewAssetEventRepository(testDB.Pool)
+	entries := []*domain.AssetEvent{
+		{OrganizationID: org.ID, AssetID: asset.ID, Type: "asset.updated", ActorID: &user.ID, Changes: []domain.AssetChange{
+			{Field: "attributes.serial", Old: json.RawMessage(`"A1"`), New: json.RawMessage(`"A2"`)},
+		}},
+		{OrganizationID: org.ID, AssetID: asset.ID, Type: "asset.updated", Changes: []domain.AssetChange{
+			{Field: "name", Old: json.RawMessage(`"Camera"`), New: json.RawMessage(`"Old camera"`)},
+		}},
+	}
+	for _, e := range entries {
+		if err := repo.Create(ctx, e); err != nil {
+			t.Fatalf("failed to record event: %v", err)
+		}
+	}
+
+	all, err := repo.ListByAsset(ctx, asset.ID, "")
+	if err != nil {
+		t.Fatalf("failed to list history: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(all))
+	}
+
+	attributes, err := repo.ListByAsset(ctx, asset.ID, domain.AttributePrefix)
+	if err != nil {
+		t.Fatalf("failed to list attribute history: %v", err)
+	}
+	if len(attributes) != 1 || len(attributes[0].Changes) != 1 || attributes[0].Changes[0].Field != "attributes.serial" {
+		t.Fatalf("unexpected attribute history %+v", attributes)
+	}
+	if got := attributes[0]; got.ActorEmail == nil || *got.ActorEmail != "editor@example.com" || string(got.Changes[0].Old) != `"A1"` {
+		t.Errorf("unexpected entry %+v", got)
+	}
+}
+
+func Test_AssetEventRepository_SnapshotAndRestore(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	garage, _ := fixtures.CreateLocation(ctx, org.ID, "Garage", nil)
+	tagID, _ := fixtures.CreateTag(ctx, org.ID, "lent")
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Camera")
+
+	assets := NewAssetRepository(testDB.Pool)
+	repo := NewAssetEventRepository(testDB.Pool)
+
+	stored, _ := assets.GetByID(ctx, asset.ID)
+	stored.LocationID = &garage.ID
+	stored.Attributes = json.RawMessage(`{"serial":"A1"}`)
+	if err := assets.Update(ctx, stored); err != nil {
+		t.Fatalf("failed to update asset: %v", err)
+	}
+	if err := assets.SetTags(ctx, asset.ID, []uuid.UUID{tagID}); err != nil {
+		t.Fatalf("failed to tag asset: %v", err)
+	}
+	revision := &domain.AssetEvent{OrganizationID: org.ID, AssetID: asset.ID, Type: "asset.updated"}
+	if err := repo.Create(ctx, revision); err != nil {
+		t.Fatalf("failed to record event: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, revision.ID)
+	if err != nil || got == nil {
+		t.Fatalf("failed to get event: %v", err)
+	}
+	snap := got.Snapshot
+	if snap == nil || snap.Name != "Camera" || snap.LocationID == nil || *snap.LocationID != garage.ID || len(snap.TagIDs) != 1 {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+
+	stored.Name = "Old camera"
+	stored.LocationID = nil
+	stored.Attributes = json.RawMessage(`{}`)
+	if err := assets.Update(ctx, stored); err != nil {
+		t.Fatalf("failed to update asset: %v", err)
+	}
+	if err := assets.SetTags(ctx, asset.ID, nil); err != nil {
+		t.Fatalf("failed to untag asset: %v", err)
+	}
+
+	if err := snap.Apply(stored, true); err != nil {
+		t.Fatalf("failed to apply snapshot: %v", err)
+	}
+	if err := assets.Restore(ctx, stored, snap.TagIDs); err != nil {
+		t.Fatalf("failed to restore asset: %v", err)
+	}
+	restored, _ := assets.GetByID(ctx, asset.ID)
+	if restored.Name != "Camera" || restored.LocationID == nil || *restored.LocationID != garage.ID {
+		t.Errorf("expected the asset to be restored, got %+v", restored)
+	}
+	if full, _ := assets.GetByIDFull(ctx, asset.ID); len(full.Tags) != 1 || full.Tags[0].ID != tagID {
+		t.Errorf("expected the tags to be restored, got %+v", full.Tags)
+	}
+
+	if missing, err := repo.GetByID(ctx, uuid.New()); err != nil || missing != nil {
+		t.Errorf("expected no event, got %+v, %v", missing, err)
+	}
+}
+
+func Test_AssetEventRepository_RecordsFieldChanges(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	garage, _ := fixtures.CreateLocation(ctx, org.ID, "Garage", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Camera")
+
+	assets := NewAssetRepository(testDB.Pool)
+	repo := NewAssetEventRepository(testDB.Pool)
+
+	created := &domain.AssetEvent{OrganizationID: org.ID, AssetID: asset.ID, Type: "asset.created"}
+	if err := repo.Create(ctx, created); err != nil {
+		t.Fatalf("failed to record event: %v", err)
+	}
+	if !hasChange(created.Changes, "name") {
+		t.Errorf("expected the fields of the new asset, got %+v", created.Changes)
+	}
+
+	stored, _ := assets.GetByID(ctx, asset.ID)
+	price := 80.0
+	stored.PurchasePrice = &price
+	stored.LocationID = &garage.ID
+	if err := assets.Update(ctx, stored); err != nil {
+		t.Fatalf("failed to update asset: %v", err)
+	}
+	updated := &domain.AssetEvent{OrganizationID: org.ID, AssetID: asset.ID, Type: "asset.updated"}
+	if err := repo.Create(ctx, updated); err != nil {
+		t.Fatalf("failed to record event: %v", err)
+	}
+	if len(updated.Changes) != 2 || !hasChange(updated.Changes, "purchase_price") || !hasChange(updated.Changes, "location_id") {
+		t.Errorf("expected the price and location changes, got %+v", updated.Changes)
+	}
+
+	prices, err := repo.ListByAsset(ctx, asset.ID, "purchase_price")
+	if err != nil || len(prices) != 1 || prices[0].ID != updated.ID || string(prices[0].Changes[0].New) != "80.00" {
+		t.Errorf("expected the price change, got %+v, %v", prices, err)
+	}
+}
+
+func hasChange(changes []domain.AssetChange, field string) bool {
+	for _, c := range changes {
+		if c.Field == field {
+			return true
+		}
+	}
+	return false
+}
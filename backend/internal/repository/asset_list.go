@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type AssetListRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAssetListRepository(pool *pgxpool.Pool) *AssetListRepository {
+	return &AssetListRepository{pool: pool}
+}
+
+const assetListColumns = `
+	l.id, l.organization_id, l.name, l.description, l.share_token, l.share_show_values, l.created_at, l.updated_at,
+	(SELECT COUNT(*) FROM asset_list_items i JOIN assets a ON a.id = i.asset_id AND a.deleted_at IS NULL WHERE i.list_id = l.id)
+`
+
+func scanAssetList(row pgx.Row) (*domain.AssetList, error) {
+	var l domain.AssetList
+	err := row.Scan(
+		&l.ID, &l.OrganizationID, &l.Name, &l.Description, &l.ShareToken, &l.ShareShowValues, &l.CreatedAt, &l.UpdatedAt,
+		&l.ItemCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *AssetListRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AssetList, error) {
+	query := `SELECT ` + assetListColumns + ` FROM asset_lists l WHERE l.id = $1 AND l.deleted_at IS NULL`
+	l, err := scanAssetList(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return l, err
+}
+
+func (r *AssetListRepository) GetByShareToken(ctx context.Context, token string) (*domain.AssetList, error) {
+	query := `SELECT ` + assetListColumns + ` FROM asset_lists l WHERE l.share_token = $1 AND l.deleted_at IS NULL`
+	l, err := scanAssetList(r.pool.QueryRow(ctx, query, token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return l, err
+}
+
+func (r *AssetListRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.AssetList, error) {
+	query := `SELECT ` + assetListColumns + ` FROM asset_lists l WHERE l.organization_id = $1 AND l.deleted_at IS NULL ORDER BY l.name`
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lists []domain.AssetList
+	for rows.Next() {
+		l, err := scanAssetList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, *l)
+	}
+	return lists, rows.Err()
+}
+
+func (r *AssetListRepository) Create(ctx context.Context, l *domain.AssetList) error {
+	query := `
+		INSERT INTO asset_lists (id, organization_id, name, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at, updated_at
+	`
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+	return r.pool.QueryRow(ctx, query, l.ID, l.OrganizationID, l.Name, l.Description).Scan(&l.CreatedAt, &l.UpdatedAt)
+}
+
+func (r *AssetListRepository) Update(ctx context.Context, l *domain.AssetList) error {
+	query := `
+		UPDATE asset_lists
+		SET name = $2, description = $3
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	return r.pool.QueryRow(ctx, query, l.ID, l.Name, l.Description).Scan(&l.UpdatedAt)
+}
+
+func (r *AssetListRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE asset_lists SET deleted_at = NOW(), share_token = NULL WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// ListAssets returns the (non-deleted) assets in a list, in list order
+func (r *AssetListRepository) ListAssets(ctx context.Context, listID uuid.UUID) ([]domain.Asset, error) {
+	query := `
+		SELECT a.id, a.organization_id, a.category_id, a.location_id, a.condition_id, a.main_attachment_id,
+		       a.name, a.description, a.quantity, a.attributes, a.high_value, a.purchase_at, a.purchase_price, a.currency, a.created_at, a.updated_at,
+		       c.name, l.name, att.file_key
+		FROM asset_list_items i
+		JOIN assets a ON a.id = i.asset_id AND a.deleted_at IS NULL
+		LEFT JOIN categories c ON c.id = a.category_id AND c.deleted_at IS NULL
+		LEFT JOIN locations l ON l.id = a.location_id AND l.deleted_at IS NULL
+		LEFT JOIN attachments att ON att.id = a.main_attachment_id
+		WHERE i.list_id = $1
+		ORDER BY i.sort_order, i.added_at
+	`
+	rows, err := r.pool.Query(ctx, query, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []domain.Asset
+	for rows.Next() {
+		var a domain.Asset
+		var catName, locName, attFileKey *string
+		if err := rows.Scan(
+			&a.ID, &a.OrganizationID, &a.CategoryID, &a.LocationID, &a.ConditionID, &a.MainAttachmentID,
+			&a.Name, &a.Description, &a.Quantity, &a.Attributes, &a.HighValue, &a.PurchaseAt, &a.PurchasePrice, &a.Currency, &a.CreatedAt, &a.UpdatedAt,
+			&catName, &locName, &attFileKey,
+		); err != nil {
+			return nil, err
+		}
+		if catName != nil {
+			a.Category = &domain.Category{ID: a.CategoryID, Name: *catName}
+		}
+		if a.LocationID != nil && locName != nil {
+			a.Location = &domain.Location{ID: *a.LocationID, Name: *locName}
+		}
+		if a.MainAttachmentID != nil && attFileKey != nil {
+			a.MainAttachment = &domain.Attachment{ID: *a.MainAttachmentID, FileKey: *attFileKey}
+		}
+		assets = append(assets, a)
+	}
+	return assets, rows.Err()
+}
+
+// AddAssets appends assets to the end of a list, ignoring assets already present
+func (r *AssetListRepository) AddAssets(ctx context.Context, listID uuid.UUID, assetIDs []uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var next int
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(MAX(sort_order), -1) + 1 FROM asset_list_items WHERE list_id = $1`, listID).Scan(&next); err != nil {
+		return err
+	}
+
+	for _, assetID := range assetIDs {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO asset_list_items (list_id, asset_id, sort_order)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (list_id, asset_id) DO NOTHING
+		`, listID, assetID, next)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() > 0 {
+			next++
+		}
+	}
+
+	// Membership changes count as list updates (e.g. for delta sync)
+	if _, err := tx.Exec(ctx, `UPDATE asset_lists SET updated_at = NOW() WHERE id = $1`, listID); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *AssetListRepository) RemoveAsset(ctx context.Context, listID, assetID uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM asset_list_items WHERE list_id = $1 AND asset_id = $2`, listID, assetID)
+	if err != nil || tag.RowsAffected() == 0 {
+		return err
+	}
+	_, err = r.pool.Exec(ctx, `UPDATE asset_lists SET updated_at = NOW() WHERE id = $1`, listID)
+	return err
+}
+
+// SetShare sets or clears (token = nil) the public share token of a list
+func (r *AssetListRepository) SetShare(ctx context.Context, id uuid.UUID, token *string, showValues bool) error {
+	query := `UPDATE asset_lists SET share_token = $2, share_show_values = $3 WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id, token, showValues)
+	return err
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_AssetListRepository_Create_Success(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewAssetListRepository(testDB.Pool)
+	desc := "Toys stored for summer"
+	list := &domain.AssetList{
+		OrganizationID: org.ID,
+		Name:           "Summer toys",
+		Description:    &desc,
+	}
+
+	if err := repo.Create(ctx, list); err != nil {
+		t.Fatalf("failed to create list: %v", err)
+	}
+
+	if list.ID == uuid.Nil {
+		t.Error("expected ID to be set")
+	}
+	if list.CreatedAt.IsZero() {
+		t.Error("expected CreatedAt to be set")
+	}
+
+	got, err := repo.GetByID(ctx, list.ID)
+	if err != nil {
+		t.Fatalf("failed to get list: %v", err)
+	}
+	if got == nil || got.Name != "Summer toys" {
+		t.Fatalf("expected list 'Summer toys', got %+v", got)
+	}
+	if got.ShareToken != nil {
+		t.Error("expected new list not to be shared")
+	}
+}
+
+func Test_AssetListRepository_GetByID_NotFound(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	repo := NewAssetListRepository(testDB.Pool)
+	got, err := repo.GetByID(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != nil {
+		t.Error("expected nil for non-existent list")
+	}
+}
+
+func Test_AssetListRepository_AddAssets_ListsInOrder(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Toys", nil)
+	a1, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Kite")
+	a2, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Beach Ball")
+
+	repo := NewAssetListRepository(testDB.Pool)
+	list := &domain.AssetList{OrganizationID: org.ID, Name: "Summer"}
+	if err := repo.Create(ctx, list); err != nil {
+		t.Fatalf("failed to create list: %v", err)
+	}
+
+	if err := repo.AddAssets(ctx, list.ID, []uuid.UUID{a2.ID, a1.ID}); err != nil {
+		t.Fatalf("failed to add assets: %v", err)
+	}
+	// Adding an existing member again is a no-op
+	if err := repo.AddAssets(ctx, list.ID, []uuid.UUID{a2.ID}); err != nil {
+		t.Fatalf("failed to re-add asset: %v", err)
+	}
+
+	assets, err := repo.ListAssets(ctx, list.ID)
+	if err != nil {
+		t.Fatalf("failed to list assets: %v", err)
+	}
+	if len(assets) != 2 {
+		t.Fatalf("expected 2 assets, got %d", len(assets))
+	}
+	if assets[0].ID != a2.ID || assets[1].ID != a1.ID {
+		t.Error("expected assets in insertion order")
+	}
+	if assets[0].Category == nil || assets[0].Category.Name != "Toys" {
+		t.Error("expected category to be populated")
+	}
+
+	got, _ := repo.GetByID(ctx, list.ID)
+	if got.ItemCount != 2 {
+		t.Errorf("expected item count 2, got %d", got.ItemCount)
+	}
+}
+
+func Test_AssetListRepository_RemoveAsset_Success(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Toys", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Kite")
+
+	repo := NewAssetListRepository(testDB.Pool)
+	list := &domain.AssetList{OrganizationID: org.ID, Name: "Summer"}
+	_ = repo.Create(ctx, list)
+	_ = repo.AddAssets(ctx, list.ID, []uuid.UUID{asset.ID})
+
+	if err := repo.RemoveAsset(ctx, list.ID, asset.ID); err != nil {
+		t.Fatalf("failed to remove asset: %v", err)
+	}
+
+	assets, _ := repo.ListAssets(ctx, list.ID)
+	if len(assets) != 0 {
+		t.Errorf("expected empty list, got %d assets", len(assets))
+	}
+}
+
+func Test_AssetListRepository_SetShare_GetByShareToken(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewAssetListRepository(testDB.Pool)
+	list := &domain.AssetList{OrganizationID: org.ID, Name: "Winter"}
+	_ = repo.Create(ctx, list)
+
+	token := "share-token-123"
+	if err := repo.SetShare(ctx, list.ID, &token, true); err != nil {
+		t.Fatalf("failed to share list: %v", err)
+	}
+
+	got, err := repo.GetByShareToken(ctx, token)
+	if err != nil {
+		t.Fatalf("failed to get by token: %v", err)
+	}
+	if got == nil || got.ID != list.ID {
+		t.Fatal("expected shared list to be found by token")
+	}
+	if !got.ShareShowValues {
+		t.Error("expected ShareShowValues to be true")
+	}
+
+	if err := repo.SetShare(ctx, list.ID, nil, false); err != nil {
+		t.Fatalf("failed to unshare list: %v", err)
+	}
+	got, _ = repo.GetByShareToken(ctx, token)
+	if got != nil {
+		t.Error("expected revoked token not to resolve")
+	}
+}
+
+func Test_AssetListRepository_Delete_SoftDeletes(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewAssetListRepository(testDB.Pool)
+	list := &domain.AssetList{OrganizationID: org.ID, Name: "Winter"}
+	_ = repo.Create(ctx, list)
+
+	if err := repo.Delete(ctx, list.ID); err != nil {
+		t.Fatalf("failed to delete list: %v", err)
+	}
+
+	got, _ := repo.GetByID(ctx, list.ID)
+	if got != nil {
+		t.Error("expected deleted list not to be found")
+	}
+	lists, _ := repo.List(ctx, org.ID)
+	if len(lists) != 0 {
+		t.Errorf("expected no lists, got %d", len(lists))
+	}
+}
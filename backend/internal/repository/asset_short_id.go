@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// shortIDAttempts is how many random short IDs of a length are tried before
+// moving to a longer one; collisions only become likely with hundreds of
+// millions of assets
+const shortIDAttempts = 5
+
+// errNoShortID is returned when no unused short ID was found
+var errNoShortID = errors.New("no unused short ID found")
+
+// withShortID calls store with random short IDs until one isn't taken yet,
+// returning the ID stored
+func withShortID(store func(shortID string) error) (string, error) {
+	for length := domain.ShortIDMinLength; length <= domain.ShortIDMaxLength; length++ {
+		for range shortIDAttempts {
+			shortID, err := newShortID(length)
+			if err != nil {
+				return "", err
+			}
+			err = store(shortID)
+			if isShortIDConflict(err) {
+				continue
+			}
+			return shortID, err
+		}
+	}
+	return "", errNoShortID
+}
+
+func newShortID(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	// The alphabet has 32 characters, so every byte maps to one evenly
+	for i := range b {
+		b[i] = domain.ShortIDAlphabet[b[i]%byte(len(domain.ShortIDAlphabet))]
+	}
+	return string(b), nil
+}
+
+// isShortIDConflict reports whether err is a violation of the uniqueness of
+// short IDs
+func isShortIDConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_assets_short_id"
+}
+
+// AssignShortID returns the short ID of an asset, assigning one if it has
+// none yet (e.g. imported assets)
+func (r *AssetRepository) AssignShortID(ctx context.Context, id uuid.UUID) (string, error) {
+	var existing *string
+	err := r.pool.QueryRow(ctx, `SELECT short_id FROM assets WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&existing)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return *existing, nil
+	}
+
+	query := `
+		UPDATE assets SET short_id = COALESCE(short_id, $2)
+		WHERE id = $1
+		RETURNING short_id
+	`
+	var assigned string
+	_, err = withShortID(func(shortID string) error {
+		// Another request may have assigned one meanwhile, which is kept
+		return r.pool.QueryRow(ctx, query, id, shortID).Scan(&assigned)
+	})
+	return assigned, err
+}
+
+// FindByShortID returns the ID of the organization's asset with a short ID
+// (normalized, see domain.NormalizeShortID), or uuid.Nil if there is none
+func (r *AssetRepository) FindByShortID(ctx context.Context, orgID uuid.UUID, shortID string) (uuid.UUID, error) {
+	query := `SELECT id FROM assets WHERE short_id = $1 AND organization_id = $2 AND deleted_at IS NULL`
+	var id uuid.UUID
+	err := r.pool.QueryRow(ctx, query, shortID, orgID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	return id, err
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_AssetRepository_ShortIDs(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	other, _ := fixtures.CreateOrganization(ctx, "Other Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Storage", nil)
+
+	repo := NewAssetRepository(testDB.Pool)
+	box := &domain.Asset{OrganizationID: org.ID, CategoryID: category.ID, Name: "Box 12", Quantity: 1}
+	if err := repo.Create(ctx, box); err != nil {
+		t.Fatalf("failed to create asset: %v", err)
+	}
+	if box.ShortID == nil || len(*box.ShortID) != domain.ShortIDMinLength {
+		t.Fatalf("expected a short ID to be assigned, got %v", box.ShortID)
+	}
+	if got, _ := repo.GetByID(ctx, box.ID); got.ShortID == nil || *got.ShortID != *box.ShortID {
+		t.Errorf("expected the short ID to be read back, got %v", got.ShortID)
+	}
+
+	if id, err := repo.FindByShortID(ctx, org.ID, *box.ShortID); err != nil || id != box.ID {
+		t.Errorf("expected to find the box, got %s, %v", id, err)
+	}
+	if id, _ := repo.FindByShortID(ctx, other.ID, *box.ShortID); id != uuid.Nil {
+		t.Error("expected the short ID not to resolve in another organization")
+	}
+
+	// Assets inserted without one get a short ID on demand, once
+	imported, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Imported box")
+	shortID, err := repo.AssignShortID(ctx, imported.ID)
+	if err != nil || shortID == "" || shortID == *box.ShortID {
+		t.Fatalf("expected a new short ID, got %q, %v", shortID, err)
+	}
+	if again, _ := repo.AssignShortID(ctx, imported.ID); again != shortID {
+		t.Errorf("expected the short ID to be kept, got %q and %q", shortID, again)
+	}
+}
+
+func Test_withShortID_RetriesCollisions(t *testing.T) {
+	var tried []string
+	shortID, err := withShortID(func(shortID string) error {
+		tried = append(tried, shortID)
+		if len(tried) <= shortIDAttempts {
+			return &pgconn.PgError{Code: "23505", ConstraintName: "idx_assets_short_id"}
+		}
+		return nil
+	})
+	if err != nil || shortID != tried[len(tried)-1] {
+		t.Fatalf("expected the last short ID tried, got %q, %v", shortID, err)
+	}
+	// Collisions on every attempt of a length move on to longer IDs
+	if len(shortID) != domain.ShortIDMinLength+1 {
+		t.Errorf("expected a %d character short ID, got %q", domain.ShortIDMinLength+1, shortID)
+	}
+	for _, id := range tried {
+		if normalized, ok := domain.NormalizeShortID(id); !ok || normalized != id {
+			t.Errorf("expected %q to be a normalized short ID", id)
+		}
+	}
+}
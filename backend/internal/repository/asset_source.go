@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type AssetSourceRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAssetSourceRepository(pool *pgxpool.Pool) *AssetSourceRepository {
+	return &AssetSourceRepository{pool: pool}
+}
+
+// Upsert stores the source data of an asset (compressed), replacing any
+// previous data
+func (r *AssetSourceRepository) Upsert(ctx context.Context, src *domain.AssetSource) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(src.Data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO asset_sources (asset_id, plugin_id, external_id, payload, fetched_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (asset_id) DO UPDATE SET
+			plugin_id = EXCLUDED.plugin_id,
+			external_id = EXCLUDED.external_id,
+			payload = EXCLUDED.payload,
+			fetched_at = EXCLUDED.fetched_at
+		RETURNING fetched_at
+	`
+	return r.pool.QueryRow(ctx, query, src.AssetID, src.PluginID, src.ExternalID, buf.Bytes()).Scan(&src.FetchedAt)
+}
+
+// GetByAssetID returns the source data of an asset, or nil if it has none
+func (r *AssetSourceRepository) GetByAssetID(ctx context.Context, assetID uuid.UUID) (*domain.AssetSource, error) {
+	query := `SELECT asset_id, plugin_id, external_id, payload, fetched_at FROM asset_sources WHERE asset_id = $1`
+	var src domain.AssetSource
+	var payload []byte
+	err := r.pool.QueryRow(ctx, query, assetID).Scan(&src.AssetID, &src.PluginID, &src.ExternalID, &payload, &src.FetchedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	if src.Data, err = io.ReadAll(zr); err != nil {
+		return nil, err
+	}
+	return &src, nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_AssetSourceRepository_UpsertGet(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Books", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Dune")
+	repo := NewAssetSourceRepository(testDB.Pool)
+
+	if src, err := repo.GetByAssetID(ctx, asset.ID); err != nil || src != nil {
+		t.Fatalf("expected no source, got %+v (%v)", src, err)
+	}
+
+	for _, data := range []string{`{"name":"Dune"}`, `{"name":"Dune","attributes":{"pages":412}}`} {
+		src := &domain.AssetSource{
+			AssetID:    asset.ID,
+			PluginID:   "google_books",
+			ExternalID: "abc",
+			Data:       json.RawMessage(data),
+		}
+		if err := repo.Upsert(ctx, src); err != nil {
+			t.Fatalf("failed to store source: %v", err)
+		}
+	}
+
+	src, err := repo.GetByAssetID(ctx, asset.ID)
+	if err != nil || src == nil {
+		t.Fatalf("expected source, got %v", err)
+	}
+	if string(src.Data) != `{"name":"Dune","attributes":{"pages":412}}` || src.PluginID != "google_books" {
+		t.Errorf("expected latest source data, got %+v (%s)", src, src.Data)
+	}
+}
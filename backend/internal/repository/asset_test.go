@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"slices"
 	"testing"
 	"time"
 
@@ -256,6 +257,64 @@ func Test_AssetRepository_List_FilterByCategory(t *testing.T) {
 	}
 }
 
+func Test_AssetRepository_FindByAttribute(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	other, _ := fixtures.CreateOrganization(ctx, "Other Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	otherCat, _ := fixtures.CreateCategory(ctx, other.ID, "Electronics", nil)
+
+	laptop, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Laptop")
+	fixtures.CreateAsset(ctx, org.ID, cat.ID, "Phone")
+	otherLaptop, _ := fixtures.CreateAsset(ctx, other.ID, otherCat.ID, "Laptop")
+	for _, id := range []uuid.UUID{laptop.ID, otherLaptop.ID} {
+		if _, err := testDB.Pool.Exec(ctx, `UPDATE assets SET attributes = '{"inventory_number": "INV-0042"}' WHERE id = $1`, id); err != nil {
+			t.Fatalf("failed to set attributes: %v", err)
+		}
+	}
+
+	repo := NewAssetRepository(testDB.Pool)
+	ids, err := repo.FindByAttribute(ctx, org.ID, "inventory_number", " inv-0042")
+	if err != nil || len(ids) != 1 || ids[0] != laptop.ID {
+		t.Errorf("expected only the organization's laptop, got %v, %v", ids, err)
+	}
+	if ids, _ := repo.FindByAttribute(ctx, org.ID, "inventory_number", "INV-0043"); len(ids) != 0 {
+		t.Errorf("expected no match, got %v", ids)
+	}
+}
+
+func Test_AssetRepository_List_FilterByCategories(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	games, _ := fixtures.CreateCategory(ctx, org.ID, "Board Games", nil)
+	books, _ := fixtures.CreateCategory(ctx, org.ID, "Books", nil)
+	tools, _ := fixtures.CreateCategory(ctx, org.ID, "Tools", nil)
+
+	fixtures.CreateAsset(ctx, org.ID, games.ID, "Catan")
+	fixtures.CreateAsset(ctx, org.ID, books.ID, "Dune")
+	fixtures.CreateAsset(ctx, org.ID, tools.ID, "Drill")
+
+	repo := NewAssetRepository(testDB.Pool)
+	filter := domain.AssetFilter{CategoryIDs: []uuid.UUID{games.ID, books.ID}}
+	_, total, err := repo.List(ctx, org.ID, filter, domain.Pagination{Limit: 100})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if total != 2 {
+		t.Errorf("expected 2 assets in the selected categories, got %d", total)
+	}
+}
+
 func Test_AssetRepository_List_FilterByLocation(t *testing.T) {
 	ctx := context.Background()
 	if err := testDB.TruncateAll(ctx); err != nil {
@@ -472,3 +531,413 @@ func Test_AssetRepository_GetTotalValue(t *testing.T) {
 		t.Errorf("expected total value 400, got %f", total)
 	}
 }
+
+func Test_AssetRepository_List_FilterByTags(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	tagged, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Tagged")
+	fixtures.CreateAsset(ctx, org.ID, cat.ID, "Untagged")
+	tagID, _ := fixtures.CreateTag(ctx, org.ID, "audio")
+	fixtures.AddTagToAsset(ctx, tagged.ID, tagID)
+
+	repo := NewAssetRepository(testDB.Pool)
+	filter := domain.AssetFilter{TagIDs: []uuid.UUID{tagID}}
+	assets, total, err := repo.List(ctx, org.ID, filter, domain.Pagination{Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to list assets: %v", err)
+	}
+
+	if total != 1 || len(assets) != 1 || assets[0].ID != tagged.ID {
+		t.Errorf("expected only tagged asset, got total=%d", total)
+	}
+}
+
+func Test_AssetRepository_Facets_CountsPerValue(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	electronics, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	books, _ := fixtures.CreateCategory(ctx, org.ID, "Books", nil)
+	a1, _ := fixtures.CreateAsset(ctx, org.ID, electronics.ID, "Laptop")
+	fixtures.CreateAsset(ctx, org.ID, electronics.ID, "Phone")
+	fixtures.CreateAsset(ctx, org.ID, books.ID, "Novel")
+	tagID, _ := fixtures.CreateTag(ctx, org.ID, "work")
+	fixtures.AddTagToAsset(ctx, a1.ID, tagID)
+
+	repo := NewAssetRepository(testDB.Pool)
+	facets, err := repo.Facets(ctx, org.ID, domain.AssetFilter{}, []string{"category", "tags", "location"})
+	if err != nil {
+		t.Fatalf("failed to count facets: %v", err)
+	}
+
+	categories := facets["category"]
+	if len(categories) != 2 {
+		t.Fatalf("expected 2 category facets, got %d", len(categories))
+	}
+	if categories[0].Name != "Electronics" || categories[0].Count != 2 {
+		t.Errorf("expected Electronics=2 first, got %+v", categories[0])
+	}
+	if len(facets["tags"]) != 1 || facets["tags"][0].Count != 1 {
+		t.Errorf("unexpected tag facets: %+v", facets["tags"])
+	}
+	if facets["location"] == nil || len(facets["location"]) != 0 {
+		t.Errorf("expected empty location facets, got %+v", facets["location"])
+	}
+
+	// Facets respect the active filter
+	filtered, _ := repo.Facets(ctx, org.ID, domain.AssetFilter{CategoryID: &books.ID}, []string{"category"})
+	if len(filtered["category"]) != 1 || filtered["category"][0].Name != "Books" {
+		t.Errorf("expected only Books facet, got %+v", filtered["category"])
+	}
+}
+
+func Test_AssetRepository_List_FilterByOwner(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	owner, _ := fixtures.CreateUser(ctx, org.ID, "alice@example.com")
+
+	repo := NewAssetRepository(testDB.Pool)
+	owned := &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Laptop", Quantity: 1, OwnerID: &owner.ID}
+	repo.Create(ctx, owned)
+	repo.Create(ctx, &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "TV", Quantity: 1})
+
+	assets, total, err := repo.List(ctx, org.ID, domain.AssetFilter{OwnerID: &owner.ID}, domain.Pagination{Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to list assets: %v", err)
+	}
+	if total != 1 || assets[0].ID != owned.ID {
+		t.Fatalf("expected only the owned asset, got %d assets", total)
+	}
+	if assets[0].Owner == nil || assets[0].Owner.Email != "alice@example.com" {
+		t.Errorf("expected owner to be populated, got %+v", assets[0].Owner)
+	}
+
+	_, total, _ = repo.List(ctx, org.ID, domain.AssetFilter{NoOwner: true}, domain.Pagination{Limit: 10})
+	if total != 1 {
+		t.Errorf("expected 1 asset without owner, got %d", total)
+	}
+}
+
+func Test_AssetRepository_ValueByOwner(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	owner, _ := fixtures.CreateUser(ctx, org.ID, "alice@example.com")
+
+	repo := NewAssetRepository(testDB.Pool)
+	price1, price2 := 100.0, 50.0
+	repo.Create(ctx, &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Laptop", Quantity: 2, PurchasePrice: &price1, OwnerID: &owner.ID})
+	repo.Create(ctx, &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "TV", Quantity: 1, PurchasePrice: &price2})
+
+	values, err := repo.ValueByOwner(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("failed to get value by owner: %v", err)
+	}
+	if len(values) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(values))
+	}
+	if values[0].OwnerID == nil || *values[0].OwnerID != owner.ID || values[0].TotalValue != 200 || values[0].Name != "alice@example.com" {
+		t.Errorf("unexpected owner entry: %+v", values[0])
+	}
+	if values[1].OwnerID != nil || values[1].TotalValue != 50 || values[1].Count != 1 {
+		t.Errorf("unexpected unassigned entry: %+v", values[1])
+	}
+}
+
+func Test_AssetRepository_FlagHighValue(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Jewelry", nil)
+
+	repo := NewAssetRepository(testDB.Pool)
+	ring, earrings, pen := 3000.0, 600.0, 20.0
+	ringAsset := &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Ring", Quantity: 1, PurchasePrice: &ring}
+	pairAsset := &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Earrings", Quantity: 2, PurchasePrice: &earrings}
+	penAsset := &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Pen", Quantity: 1, PurchasePrice: &pen, HighValue: true}
+	repo.Create(ctx, ringAsset)
+	repo.Create(ctx, pairAsset)
+	repo.Create(ctx, penAsset)
+
+	flagged, err := repo.FlagHighValue(ctx, org.ID, 1000)
+	if err != nil {
+		t.Fatalf("failed to flag high-value assets: %v", err)
+	}
+	if flagged != 2 {
+		t.Errorf("expected 2 newly flagged assets, got %d", flagged)
+	}
+
+	got, _ := repo.GetByID(ctx, pairAsset.ID)
+	if !got.HighValue {
+		t.Error("expected total value (price x quantity) to count toward the threshold")
+	}
+	got, _ = repo.GetByID(ctx, penAsset.ID)
+	if !got.HighValue {
+		t.Error("expected manual flag below the threshold to be kept")
+	}
+
+	visible, total, err := repo.List(ctx, org.ID, domain.AssetFilter{NoHighValue: true}, domain.Pagination{Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to list assets: %v", err)
+	}
+	if total != 0 || len(visible) != 0 {
+		t.Errorf("expected high-value assets to be filtered out, got %d", total)
+	}
+}
+
+func Test_AssetRepository_History(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+
+	repo := NewAssetRepository(testDB.Pool)
+	price := 100.0
+	repo.Create(ctx, &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Laptop", Quantity: 2, PurchasePrice: &price})
+	tv := &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "TV", Quantity: 1}
+	repo.Create(ctx, tv)
+	repo.Delete(ctx, tv.ID)
+
+	now := time.Now()
+	points, err := repo.History(ctx, org.ID, now.Add(-time.Hour), now.Add(time.Hour), time.Hour)
+	if err != nil {
+		t.Fatalf("failed to get history: %v", err)
+	}
+	if len(points) != 3 {
+		t.Fatalf("expected 3 points, got %+v", points)
+	}
+	if points[0].Count != 0 {
+		t.Errorf("expected no assets an hour ago, got %d", points[0].Count)
+	}
+	if points[1].Count != 1 || points[1].Value != 200 {
+		t.Errorf("expected the laptop only, got %+v", points[1])
+	}
+}
+
+func Test_AssetRepository_Search_MatchesAttachmentText(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Appliances", nil)
+	washer, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Washing Machine")
+	fixtures.CreateAsset(ctx, org.ID, cat.ID, "Dishwasher")
+
+	attachments := NewAttachmentRepository(testDB.Pool)
+	manual := &domain.Attachment{AssetID: washer.ID, FileKey: "a/manual.pdf", FileName: "manual.pdf", FileSize: 100}
+	if err := attachments.Create(ctx, manual); err != nil {
+		t.Fatalf("failed to create attachment: %v", err)
+	}
+	text := "Troubleshooting. Error code E4: the drain filter is blocked."
+	if err := attachments.SetText(ctx, manual.ID, &text); err != nil {
+		t.Fatalf("failed to set text: %v", err)
+	}
+
+	repo := NewAssetRepository(testDB.Pool)
+	assets, total, err := repo.Search(ctx, org.ID, "error code E4", domain.Pagination{Limit: 100})
+	if err != nil {
+		t.Fatalf("failed to search: %v", err)
+	}
+	if total != 1 || len(assets) != 1 || assets[0].ID != washer.ID {
+		t.Fatalf("expected the washing machine, got %d results", total)
+	}
+}
+
+func Test_AssetRepository_Bulk(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	other, _ := fixtures.CreateOrganization(ctx, "Other Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Tools", nil)
+	otherCategory, _ := fixtures.CreateCategory(ctx, other.ID, "Tools", nil)
+	garage, _ := fixtures.CreateLocation(ctx, org.ID, "Garage", nil)
+	tagID, _ := fixtures.CreateTag(ctx, org.ID, "lent")
+	drill, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Drill")
+	saw, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Saw")
+	foreign, _ := fixtures.CreateAsset(ctx, other.ID, otherCategory.ID, "Foreign")
+
+	repo := NewAssetRepository(testDB.Pool)
+	ids := []uuid.UUID{drill.ID, saw.ID}
+
+	missing, err := repo.Bulk(ctx, org.ID, domain.BulkAssetOperation{
+		Action: domain.BulkAssetSetLocation, AssetIDs: append(ids, foreign.ID), TargetID: &garage.ID,
+	})
+	if err != nil {
+		t.Fatalf("failed to apply bulk operation: %v", err)
+	}
+	if len(missing) != 1 || missing[0] != foreign.ID {
+		t.Fatalf("expected the other organization's asset to be missing, got %v", missing)
+	}
+	if got, _ := repo.GetByID(ctx, drill.ID); got.LocationID != nil {
+		t.Fatal("expected nothing to change when an asset is missing")
+	}
+
+	if _, err := repo.Bulk(ctx, org.ID, domain.BulkAssetOperation{Action: domain.BulkAssetSetLocation, AssetIDs: ids, TargetID: &garage.ID}); err != nil {
+		t.Fatalf("failed to set location: %v", err)
+	}
+	if _, err := repo.Bulk(ctx, org.ID, domain.BulkAssetOperation{Action: domain.BulkAssetAddTags, AssetIDs: ids, TagIDs: []uuid.UUID{tagID}}); err != nil {
+		t.Fatalf("failed to add tags: %v", err)
+	}
+	for _, id := range ids {
+		got, _ := repo.GetByIDFull(ctx, id)
+		if got.LocationID == nil || *got.LocationID != garage.ID || len(got.Tags) != 1 {
+			t.Errorf("expected %s to be in the garage and tagged, got %+v", got.Name, got)
+		}
+	}
+
+	if _, err := repo.Bulk(ctx, org.ID, domain.BulkAssetOperation{Action: domain.BulkAssetRemoveTags, AssetIDs: ids, TagIDs: []uuid.UUID{tagID}}); err != nil {
+		t.Fatalf("failed to remove tags: %v", err)
+	}
+	if _, err := repo.Bulk(ctx, org.ID, domain.BulkAssetOperation{Action: domain.BulkAssetDelete, AssetIDs: ids}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, saw.ID); got != nil {
+		t.Error("expected the assets to be deleted")
+	}
+}
+
+func Test_AssetRepository_Archived(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+
+	repo := NewAssetRepository(testDB.Pool)
+	price := 100.0
+	active := &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Active", Quantity: 1, PurchasePrice: &price}
+	sold := &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Sold", Quantity: 1, PurchasePrice: &price}
+	repo.Create(ctx, active)
+	repo.Create(ctx, sold)
+
+	archivedAt := time.Now()
+	sold.ArchivedAt = &archivedAt
+	if err := repo.Update(ctx, sold); err != nil {
+		t.Fatalf("failed to archive asset: %v", err)
+	}
+
+	got, _ := repo.GetByID(ctx, sold.ID)
+	if got == nil || got.ArchivedAt == nil {
+		t.Fatal("expected the archived asset to be found with archived_at")
+	}
+
+	tests := []struct {
+		filter domain.ArchivedFilter
+		want   []string
+	}{
+		{domain.ArchivedExclude, []string{"Active"}},
+		{domain.ArchivedOnly, []string{"Sold"}},
+		{domain.ArchivedAll, []string{"Active", "Sold"}},
+	}
+	for _, tt := range tests {
+		assets, total, err := repo.List(ctx, org.ID, domain.AssetFilter{Archived: tt.filter}, domain.Pagination{Limit: 10})
+		if err != nil {
+			t.Fatalf("failed to list assets: %v", err)
+		}
+		names := make([]string, len(assets))
+		for i, a := range assets {
+			names[i] = a.Name
+		}
+		slices.Sort(names)
+		if total != len(tt.want) || !slices.Equal(names, tt.want) {
+			t.Errorf("archived=%q: expected %v, got %v (total %d)", tt.filter, tt.want, names, total)
+		}
+	}
+
+	total, err := repo.GetTotalValue(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("failed to get total value: %v", err)
+	}
+	if total != 100.0 {
+		t.Errorf("expected archived assets to be left out of the total value, got %f", total)
+	}
+}
+
+func Test_AssetRepository_Status(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Tools", nil)
+
+	repo := NewAssetRepository(testDB.Pool)
+	price := 100.0
+	for _, a := range []*domain.Asset{
+		{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Drill", Quantity: 1, PurchasePrice: &price},
+		{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Saw", Quantity: 1, PurchasePrice: &price, Status: domain.AssetStatusLoaned},
+		{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Sander", Quantity: 2, PurchasePrice: &price, Status: domain.AssetStatusSold},
+	} {
+		if err := repo.Create(ctx, a); err != nil {
+			t.Fatalf("failed to create asset: %v", err)
+		}
+	}
+
+	assets, total, err := repo.List(ctx, org.ID, domain.AssetFilter{Statuses: []domain.AssetStatus{domain.AssetStatusOwned}}, domain.Pagination{Limit: 10})
+	if err != nil {
+		t.Fatalf("failed to list assets: %v", err)
+	}
+	if total != 1 || assets[0].Name != "Drill" || assets[0].Status != domain.AssetStatusOwned {
+		t.Errorf("expected only the owned drill, got %+v", assets)
+	}
+
+	// Sold assets are left out of the inventory value
+	value, err := repo.GetTotalValue(ctx, org.ID)
+	if err != nil || value != 200.0 {
+		t.Errorf("expected a total value of 200, got %f (%v)", value, err)
+	}
+
+	byStatus, err := repo.ValueByStatus(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("failed to get value by status: %v", err)
+	}
+	want := []domain.StatusValue{
+		{Status: domain.AssetStatusOwned, Count: 1, TotalValue: 100},
+		{Status: domain.AssetStatusLoaned, Count: 1, TotalValue: 100},
+		{Status: domain.AssetStatusSold, Count: 1, TotalValue: 200},
+	}
+	if !slices.Equal(byStatus, want) {
+		t.Errorf("expected %+v, got %+v", want, byStatus)
+	}
+}
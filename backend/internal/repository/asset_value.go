@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type AssetValueRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAssetValueRepository(pool *pgxpool.Pool) *AssetValueRepository {
+	return &AssetValueRepository{pool: pool}
+}
+
+func (r *AssetValueRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AssetValue, error) {
+	query := `
+		SELECT id, organization_id, asset_id, value, valued_at, note, created_by, created_at
+		FROM asset_values
+		WHERE id = $1
+	`
+	var v domain.AssetValue
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&v.ID, &v.OrganizationID, &v.AssetID, &v.Value, &v.ValuedAt, &v.Note, &v.CreatedBy, &v.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListByAsset returns the valuations of an asset, latest first
+func (r *AssetValueRepository) ListByAsset(ctx context.Context, assetID uuid.UUID) ([]domain.AssetValue, error) {
+	query := `
+		SELECT v.id, v.organization_id, v.asset_id, v.value, v.valued_at, v.note, v.created_by, v.created_at, u.email
+		FROM asset_values v
+		LEFT JOIN users u ON u.id = v.created_by
+		WHERE v.asset_id = $1
+		ORDER BY v.valued_at DESC, v.created_at DESC
+	`
+	rows, err := r.pool.Query(ctx, query, assetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []domain.AssetValue{}
+	for rows.Next() {
+		var v domain.AssetValue
+		if err := rows.Scan(
+			&v.ID, &v.OrganizationID, &v.AssetID, &v.Value, &v.ValuedAt, &v.Note, &v.CreatedBy, &v.CreatedAt, &v.CreatedByEmail,
+		); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// Create records a valuation and returns the asset's current value, which
+// it becomes unless a later valuation exists
+func (r *AssetValueRepository) Create(ctx context.Context, v *domain.AssetValue) (*float64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO asset_values (id, organization_id, asset_id, value, valued_at, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at
+	`
+	err = tx.QueryRow(ctx, query,
+		v.ID, v.OrganizationID, v.AssetID, v.Value, calendarDate(v.ValuedAt), v.Note, v.CreatedBy,
+	).Scan(&v.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	current, err := syncAssetValue(ctx, tx, v.AssetID)
+	if err != nil {
+		return nil, err
+	}
+	return current, tx.Commit(ctx)
+}
+
+// Delete removes a valuation; the asset's current value falls back to the
+// latest remaining one, if any
+func (r *AssetValueRepository) Delete(ctx context.Context, v *domain.AssetValue) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM asset_values WHERE id = $1`, v.ID); err != nil {
+		return err
+	}
+	if _, err := syncAssetValue(ctx, tx, v.AssetID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// syncAssetValue sets the current value of an asset to its latest valuation
+func syncAssetValue(ctx context.Context, tx pgx.Tx, assetID uuid.UUID) (*float64, error) {
+	query := `
+		UPDATE assets
+		SET current_value = (
+			SELECT value FROM asset_values WHERE asset_id = $1 ORDER BY valued_at DESC, created_at DESC LIMIT 1
+		)
+		WHERE id = $1
+		RETURNING current_value
+	`
+	var current *float64
+	err := tx.QueryRow(ctx, query, assetID).Scan(&current)
+	return current, err
+}
+
+// GetCurrentValue returns the value of the held, active assets at their
+// current value, or their purchase price if they have none
+func (r *AssetRepository) GetCurrentValue(ctx context.Context, orgID uuid.UUID) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(COALESCE(current_value, purchase_price) * quantity), 0)
+		FROM assets
+		WHERE organization_id = $1 AND deleted_at IS NULL AND archived_at IS NULL AND status IN ` + heldStatuses + `
+	`
+	var total float64
+	err := r.pool.QueryRow(ctx, query, orgID).Scan(&total)
+	return total, err
+}
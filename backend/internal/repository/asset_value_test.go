@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_AssetValueRepository_CurrentValue(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Watches", nil)
+	watch, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Watch")
+
+	repo := NewAssetValueRepository(testDB.Pool)
+	assets := NewAssetRepository(testDB.Pool)
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	later := &domain.AssetValue{OrganizationID: org.ID, AssetID: watch.ID, Value: 900, ValuedAt: day}
+	earlier := &domain.AssetValue{OrganizationID: org.ID, AssetID: watch.ID, Value: 700, ValuedAt: day.AddDate(0, -6, 0)}
+
+	if current, err := repo.Create(ctx, later); err != nil || current == nil || *current != 900 {
+		t.Fatalf("expected a current value of 900, got %v, %v", current, err)
+	}
+	// A valuation dated earlier doesn't replace the current value
+	if current, err := repo.Create(ctx, earlier); err != nil || current == nil || *current != 900 {
+		t.Fatalf("expected the current value to stay 900, got %v, %v", current, err)
+	}
+	if values, _ := repo.ListByAsset(ctx, watch.ID); len(values) != 2 || values[0].ID != later.ID {
+		t.Errorf("expected both values, latest first, got %+v", values)
+	}
+	if total, _ := assets.GetCurrentValue(ctx, org.ID); total != 900 {
+		t.Errorf("expected a total current value of 900, got %v", total)
+	}
+
+	if err := repo.Delete(ctx, later); err != nil {
+		t.Fatalf("failed to delete value: %v", err)
+	}
+	if got, _ := assets.GetByID(ctx, watch.ID); got.CurrentValue == nil || *got.CurrentValue != 700 {
+		t.Errorf("expected the current value to fall back to 700, got %v", got.CurrentValue)
+	}
+}
+
+func Test_AssetRepository_History_CurrentValue(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+
+	assets := NewAssetRepository(testDB.Pool)
+	price := 100.0
+	camera := &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Camera", Quantity: 2, PurchasePrice: &price}
+	assets.Create(ctx, camera)
+	assets.Create(ctx, &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, Name: "Lens", Quantity: 1, PurchasePrice: &price})
+	now := time.Now()
+	value := &domain.AssetValue{OrganizationID: org.ID, AssetID: camera.ID, Value: 60, ValuedAt: now.AddDate(0, 0, -1)}
+	if _, err := NewAssetValueRepository(testDB.Pool).Create(ctx, value); err != nil {
+		t.Fatalf("failed to record value: %v", err)
+	}
+
+	points, err := assets.History(ctx, org.ID, now.Add(time.Hour), now.Add(time.Hour), time.Hour)
+	if err != nil || len(points) != 1 {
+		t.Fatalf("expected one point, got %+v, %v", points, err)
+	}
+	// The camera at its valuation, the lens at its purchase price
+	if points[0].Value != 300 || points[0].CurrentValue != 220 {
+		t.Errorf("expected a purchase value of 300 and a current value of 220, got %+v", points[0])
+	}
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -20,14 +21,35 @@ func NewAttachmentRepository(pool *pgxpool.Pool) *AttachmentRepository {
 
 func (r *AttachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Attachment, error) {
 	query := `
-		SELECT id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description, created_at
+		SELECT id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description, created_at, text_extracted_at, derived_from_id, edit
 		FROM attachments
 		WHERE id = $1
 	`
 	var a domain.Attachment
 	err := r.pool.QueryRow(ctx, query, id).Scan(
 		&a.ID, &a.AssetID, &a.UploadedBy, &a.FileKey, &a.FileName,
-		&a.FileSize, &a.ContentType, &a.Description, &a.CreatedAt,
+		&a.FileSize, &a.ContentType, &a.Description, &a.CreatedAt, &a.TextExtractedAt, &a.DerivedFromID, &a.Edit,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetByFileKey returns the attachment stored at a storage key
+func (r *AttachmentRepository) GetByFileKey(ctx context.Context, key string) (*domain.Attachment, error) {
+	query := `
+		SELECT id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description, created_at, text_extracted_at, derived_from_id, edit
+		FROM attachments
+		WHERE file_key = $1
+	`
+	var a domain.Attachment
+	err := r.pool.QueryRow(ctx, query, key).Scan(
+		&a.ID, &a.AssetID, &a.UploadedBy, &a.FileKey, &a.FileName,
+		&a.FileSize, &a.ContentType, &a.Description, &a.CreatedAt, &a.TextExtractedAt, &a.DerivedFromID, &a.Edit,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -40,7 +62,7 @@ func (r *AttachmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*doma
 
 func (r *AttachmentRepository) ListByAsset(ctx context.Context, assetID uuid.UUID) ([]domain.Attachment, error) {
 	query := `
-		SELECT id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description, created_at
+		SELECT id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description, created_at, text_extracted_at, derived_from_id, edit
 		FROM attachments
 		WHERE asset_id = $1
 		ORDER BY created_at DESC
@@ -56,7 +78,7 @@ func (r *AttachmentRepository) ListByAsset(ctx context.Context, assetID uuid.UUI
 		var a domain.Attachment
 		if err := rows.Scan(
 			&a.ID, &a.AssetID, &a.UploadedBy, &a.FileKey, &a.FileName,
-			&a.FileSize, &a.ContentType, &a.Description, &a.CreatedAt,
+			&a.FileSize, &a.ContentType, &a.Description, &a.CreatedAt, &a.TextExtractedAt, &a.DerivedFromID, &a.Edit,
 		); err != nil {
 			return nil, err
 		}
@@ -67,15 +89,15 @@ func (r *AttachmentRepository) ListByAsset(ctx context.Context, assetID uuid.UUI
 
 func (r *AttachmentRepository) Create(ctx context.Context, a *domain.Attachment) error {
 	query := `
-		INSERT INTO attachments (id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO attachments (id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description, derived_from_id, edit)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		RETURNING created_at
 	`
 	if a.ID == uuid.Nil {
 		a.ID = uuid.New()
 	}
 	return r.pool.QueryRow(ctx, query,
-		a.ID, a.AssetID, a.UploadedBy, a.FileKey, a.FileName, a.FileSize, a.ContentType, a.Description,
+		a.ID, a.AssetID, a.UploadedBy, a.FileKey, a.FileName, a.FileSize, a.ContentType, a.Description, a.DerivedFromID, a.Edit,
 	).Scan(&a.CreatedAt)
 }
 
@@ -84,3 +106,167 @@ func (r *AttachmentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	_, err := r.pool.Exec(ctx, query, id)
 	return err
 }
+
+// TotalSize returns the combined size in bytes of all attachments in an organization
+func (r *AttachmentRepository) TotalSize(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(att.file_size), 0)
+		FROM attachments att
+		JOIN assets a ON a.id = att.asset_id
+		WHERE a.organization_id = $1
+	`
+	var total int64
+	err := r.pool.QueryRow(ctx, query, orgID).Scan(&total)
+	return total, err
+}
+
+// ListTextPending returns the oldest attachments of an organization whose text
+// has not been extracted yet
+func (r *AttachmentRepository) ListTextPending(ctx context.Context, orgID uuid.UUID, limit int) ([]domain.Attachment, error) {
+	query := `
+		SELECT att.id, att.asset_id, att.uploaded_by, att.file_key, att.file_name, att.file_size, att.content_type, att.description, att.created_at, att.text_extracted_at
+		FROM attachments att
+		JOIN assets a ON a.id = att.asset_id
+		WHERE a.organization_id = $1 AND a.deleted_at IS NULL AND att.text_extracted_at IS NULL
+		ORDER BY att.created_at
+		LIMIT $2
+	`
+	rows, err := r.pool.Query(ctx, query, orgID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []domain.Attachment
+	for rows.Next() {
+		var a domain.Attachment
+		if err := rows.Scan(
+			&a.ID, &a.AssetID, &a.UploadedBy, &a.FileKey, &a.FileName,
+			&a.FileSize, &a.ContentType, &a.Description, &a.CreatedAt, &a.TextExtractedAt,
+		); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// SetText stores the extracted text of an attachment (nil when the file has
+// no extractable text) and marks it as processed
+func (r *AttachmentRepository) SetText(ctx context.Context, id uuid.UUID, text *string) error {
+	query := `UPDATE attachments SET extracted_text = $2, text_extracted_at = NOW() WHERE id = $1`
+	_, err := r.pool.Exec(ctx, query, id, text)
+	return err
+}
+
+// ListByOrganization returns the attachments of an organization with IDs
+// after afterID in ID order, for walking all attachments in batches
+func (r *AttachmentRepository) ListByOrganization(ctx context.Context, orgID, afterID uuid.UUID, limit int) ([]domain.Attachment, error) {
+	query := `
+		SELECT att.id, att.asset_id, att.uploaded_by, att.file_key, att.file_name, att.file_size, att.content_type, att.description, att.created_at, att.text_extracted_at, att.derived_from_id, att.edit
+		FROM attachments att
+		JOIN assets a ON a.id = att.asset_id
+		WHERE a.organization_id = $1 AND att.id > $2
+		ORDER BY att.id
+		LIMIT $3
+	`
+	rows, err := r.pool.Query(ctx, query, orgID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []domain.Attachment
+	for rows.Next() {
+		var a domain.Attachment
+		if err := rows.Scan(
+			&a.ID, &a.AssetID, &a.UploadedBy, &a.FileKey, &a.FileName,
+			&a.FileSize, &a.ContentType, &a.Description, &a.CreatedAt, &a.TextExtractedAt, &a.DerivedFromID, &a.Edit,
+		); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// ListArchivable lists the attachments of assets deleted before
+// deletedBefore whose files aren't archived yet, after afterID by ID
+func (r *AttachmentRepository) ListArchivable(ctx context.Context, orgID uuid.UUID, deletedBefore time.Time, afterID uuid.UUID, limit int) ([]domain.Attachment, error) {
+	query := `
+		SELECT att.id, att.asset_id, att.uploaded_by, att.file_key, att.file_name, att.file_size, att.content_type, att.description, att.created_at, att.text_extracted_at, att.derived_from_id, att.edit
+		FROM attachments att
+		JOIN assets a ON a.id = att.asset_id
+		WHERE a.organization_id = $1 AND a.deleted_at < $2 AND att.file_key NOT LIKE 'archive/%' AND att.id > $3
+		ORDER BY att.id
+		LIMIT $4
+	`
+	rows, err := r.pool.Query(ctx, query, orgID, deletedBefore, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []domain.Attachment
+	for rows.Next() {
+		var a domain.Attachment
+		if err := rows.Scan(
+			&a.ID, &a.AssetID, &a.UploadedBy, &a.FileKey, &a.FileName,
+			&a.FileSize, &a.ContentType, &a.Description, &a.CreatedAt, &a.TextExtractedAt, &a.DerivedFromID, &a.Edit,
+		); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// MoveFile changes the storage key of an attachment, unless it no longer has
+// oldKey. It reports whether the key was changed.
+func (r *AttachmentRepository) MoveFile(ctx context.Context, id uuid.UUID, oldKey, newKey string) (bool, error) {
+	query := `UPDATE attachments SET file_key = $3 WHERE id = $1 AND file_key = $2`
+	tag, err := r.pool.Exec(ctx, query, id, oldKey, newKey)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RecordDownload adds a download to the audit trail of an attachment
+func (r *AttachmentRepository) RecordDownload(ctx context.Context, d *domain.AttachmentDownload) error {
+	query := `
+		INSERT INTO attachment_downloads (id, attachment_id, user_id, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return r.pool.QueryRow(ctx, query, d.ID, d.AttachmentID, d.UserID, d.IPAddress, d.UserAgent).Scan(&d.CreatedAt)
+}
+
+// ListDownloads returns the downloads of an attachment, newest first
+func (r *AttachmentRepository) ListDownloads(ctx context.Context, attachmentID uuid.UUID, limit, offset int) ([]domain.AttachmentDownload, error) {
+	query := `
+		SELECT id, attachment_id, user_id, ip_address, user_agent, created_at
+		FROM attachment_downloads
+		WHERE attachment_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.pool.Query(ctx, query, attachmentID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var downloads []domain.AttachmentDownload
+	for rows.Next() {
+		var d domain.AttachmentDownload
+		if err := rows.Scan(&d.ID, &d.AttachmentID, &d.UserID, &d.IPAddress, &d.UserAgent, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		downloads = append(downloads, d)
+	}
+	return downloads, rows.Err()
+}
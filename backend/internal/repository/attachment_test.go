@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lmmendes/attic/internal/domain"
@@ -239,3 +240,165 @@ func Test_AttachmentRepository_Delete_CascadesOnAssetDelete(t *testing.T) {
 		t.Error("expected attachment to be cascade deleted with asset")
 	}
 }
+
+func Test_AttachmentRepository_ListTextPending_ExcludesProcessed(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	other, _ := fixtures.CreateOrganization(ctx, "Other Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Appliances", nil)
+	otherCat, _ := fixtures.CreateCategory(ctx, other.ID, "Appliances", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Washing Machine")
+	otherAsset, _ := fixtures.CreateAsset(ctx, other.ID, otherCat.ID, "Dryer")
+
+	repo := NewAttachmentRepository(testDB.Pool)
+	processed := &domain.Attachment{AssetID: asset.ID, FileKey: "a/manual.pdf", FileName: "manual.pdf", FileSize: 100}
+	pending := &domain.Attachment{AssetID: asset.ID, FileKey: "a/receipt.pdf", FileName: "receipt.pdf", FileSize: 100}
+	foreign := &domain.Attachment{AssetID: otherAsset.ID, FileKey: "b/manual.pdf", FileName: "manual.pdf", FileSize: 100}
+	for _, a := range []*domain.Attachment{processed, pending, foreign} {
+		if err := repo.Create(ctx, a); err != nil {
+			t.Fatalf("failed to create attachment: %v", err)
+		}
+	}
+
+	text := "Error code E4: check the drain filter"
+	if err := repo.SetText(ctx, processed.ID, &text); err != nil {
+		t.Fatalf("failed to set text: %v", err)
+	}
+
+	list, err := repo.ListTextPending(ctx, org.ID, 10)
+	if err != nil {
+		t.Fatalf("failed to list pending: %v", err)
+	}
+	if len(list) != 1 || list[0].ID != pending.ID {
+		t.Fatalf("expected only the unprocessed attachment, got %+v", list)
+	}
+
+	got, _ := repo.GetByID(ctx, processed.ID)
+	if got.TextExtractedAt == nil {
+		t.Error("expected TextExtractedAt to be set")
+	}
+}
+
+func Test_AttachmentRepository_ListByOrganization_AndMoveFile(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	other, _ := fixtures.CreateOrganization(ctx, "Other Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Books", nil)
+	otherCat, _ := fixtures.CreateCategory(ctx, other.ID, "Books", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Dune")
+	otherAsset, _ := fixtures.CreateAsset(ctx, other.ID, otherCat.ID, "Emma")
+
+	repo := NewAttachmentRepository(testDB.Pool)
+	for i := 0; i < 3; i++ {
+		if err := repo.Create(ctx, &domain.Attachment{AssetID: asset.ID, FileKey: uuid.NewString() + "/cover.jpg", FileName: "cover.jpg", FileSize: 100}); err != nil {
+			t.Fatalf("failed to create attachment: %v", err)
+		}
+	}
+	if err := repo.Create(ctx, &domain.Attachment{AssetID: otherAsset.ID, FileKey: "b/cover.jpg", FileName: "cover.jpg", FileSize: 100}); err != nil {
+		t.Fatalf("failed to create attachment: %v", err)
+	}
+
+	first, err := repo.ListByOrganization(ctx, org.ID, uuid.Nil, 2)
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	rest, err := repo.ListByOrganization(ctx, org.ID, first[len(first)-1].ID, 2)
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(first) != 2 || len(rest) != 1 {
+		t.Fatalf("expected batches of 2 and 1 attachments of the organization, got %d and %d", len(first), len(rest))
+	}
+
+	moved, err := repo.MoveFile(ctx, rest[0].ID, rest[0].FileKey, "org/assets/dune/cover.jpg")
+	if err != nil || !moved {
+		t.Fatalf("expected file to move, got %v, %v", moved, err)
+	}
+	if moved, _ := repo.MoveFile(ctx, rest[0].ID, rest[0].FileKey, "elsewhere/cover.jpg"); moved {
+		t.Error("expected a stale key not to move the file")
+	}
+	got, _ := repo.GetByID(ctx, rest[0].ID)
+	if got.FileKey != "org/assets/dune/cover.jpg" {
+		t.Errorf("expected the new key, got %q", got.FileKey)
+	}
+}
+
+func Test_AttachmentRepository_ListArchivable(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Books", nil)
+	kept, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Dune")
+	deleted, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Emma")
+	fixtures.CreateAttachment(ctx, kept.ID, "cover.jpg", "a/cover.jpg")
+	pending, _ := fixtures.CreateAttachment(ctx, deleted.ID, "cover.jpg", "b/cover.jpg")
+	fixtures.CreateAttachment(ctx, deleted.ID, "manual.pdf", "archive/c/manual.pdf")
+
+	if err := NewAssetRepository(testDB.Pool).Delete(ctx, deleted.ID); err != nil {
+		t.Fatalf("failed to delete asset: %v", err)
+	}
+	repo := NewAttachmentRepository(testDB.Pool)
+
+	got, err := repo.ListArchivable(ctx, org.ID, time.Now().Add(-time.Hour), uuid.Nil, 10)
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected recently deleted assets to be skipped, got %d attachments", len(got))
+	}
+
+	got, err = repo.ListArchivable(ctx, org.ID, time.Now().Add(time.Hour), uuid.Nil, 10)
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != pending.ID {
+		t.Errorf("expected only the unarchived attachment of the deleted asset, got %+v", got)
+	}
+}
+
+func Test_AttachmentRepository_RecordDownload_AndListDownloads(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "reader@example.com")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Documents", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Passport")
+	att, _ := fixtures.CreateAttachment(ctx, asset.ID, "scan.pdf", "a/scan.pdf")
+	repo := NewAttachmentRepository(testDB.Pool)
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		if err := repo.RecordDownload(ctx, &domain.AttachmentDownload{AttachmentID: att.ID, UserID: &user.ID, IPAddress: ip}); err != nil {
+			t.Fatalf("failed to record download: %v", err)
+		}
+	}
+
+	got, err := repo.ListDownloads(ctx, att.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("failed to list downloads: %v", err)
+	}
+	if len(got) != 2 || got[0].UserID == nil || *got[0].UserID != user.ID {
+		t.Fatalf("expected 2 downloads by the user, got %+v", got)
+	}
+
+	if got, _ := repo.ListDownloads(ctx, att.ID, 1, 1); len(got) != 1 {
+		t.Errorf("expected a page of 1 download, got %d", len(got))
+	}
+}
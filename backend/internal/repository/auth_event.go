@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type AuthEventRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuthEventRepository(pool *pgxpool.Pool) *AuthEventRepository {
+	return &AuthEventRepository{pool: pool}
+}
+
+// Create records an authentication event
+func (r *AuthEventRepository) Create(ctx context.Context, e *domain.AuthEvent) error {
+	query := `
+		INSERT INTO auth_events (id, organization_id, user_id, email, event_type, method, failure_reason, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at
+	`
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return r.pool.QueryRow(ctx, query,
+		e.ID, e.OrganizationID, e.UserID, e.Email, e.Type, e.Method, e.FailureReason, e.IPAddress, e.UserAgent,
+	).Scan(&e.CreatedAt)
+}
+
+// List returns authentication events, most recent first
+func (r *AuthEventRepository) List(ctx context.Context, orgID uuid.UUID, filter domain.AuthEventFilter) ([]domain.AuthEvent, error) {
+	query := `
+		SELECT id, organization_id, user_id, email, event_type, method, failure_reason, ip_address, user_agent, created_at
+		FROM auth_events
+		WHERE organization_id = $1
+	`
+	args := []any{orgID}
+
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		query += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		query += fmt.Sprintf(" AND event_type = $%d", len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.AuthEvent
+	for rows.Next() {
+		var e domain.AuthEvent
+		if err := rows.Scan(
+			&e.ID, &e.OrganizationID, &e.UserID, &e.Email, &e.Type, &e.Method,
+			&e.FailureReason, &e.IPAddress, &e.UserAgent, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
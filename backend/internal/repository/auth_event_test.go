@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_AuthEventRepository_List_Filters(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "user@example.com")
+
+	repo := NewAuthEventRepository(testDB.Pool)
+	method := domain.LoginMethodPassword
+	reason := "invalid_password"
+	events := []*domain.AuthEvent{
+		{OrganizationID: org.ID, UserID: &user.ID, Email: user.Email, Type: domain.AuthEventLogin, Method: &method, IPAddress: "10.0.0.1"},
+		{OrganizationID: org.ID, UserID: &user.ID, Email: user.Email, Type: domain.AuthEventLogout, IPAddress: "10.0.0.1"},
+		{OrganizationID: org.ID, Email: "nobody@example.com", Type: domain.AuthEventLoginFailed, Method: &method, FailureReason: &reason, IPAddress: "10.0.0.9"},
+	}
+	for _, e := range events {
+		if err := repo.Create(ctx, e); err != nil {
+			t.Fatalf("failed to create auth event: %v", err)
+		}
+	}
+
+	all, err := repo.List(ctx, org.ID, domain.AuthEventFilter{})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected 3 events, got %d", len(all))
+	}
+
+	mine, _ := repo.List(ctx, org.ID, domain.AuthEventFilter{UserID: &user.ID})
+	if len(mine) != 2 {
+		t.Errorf("expected 2 events of the user, got %d", len(mine))
+	}
+
+	logouts, _ := repo.List(ctx, org.ID, domain.AuthEventFilter{Type: domain.AuthEventLogout})
+	if len(logouts) != 1 || logouts[0].Method != nil {
+		t.Errorf("expected 1 logout without method, got %+v", logouts)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	if got, _ := repo.List(ctx, org.ID, domain.AuthEventFilter{To: &past}); len(got) != 0 {
+		t.Errorf("expected no events before an hour ago, got %d", len(got))
+	}
+	if got, _ := repo.List(ctx, org.ID, domain.AuthEventFilter{From: &past}); len(got) != 3 {
+		t.Errorf("expected 3 events since an hour ago, got %d", len(got))
+	}
+}
@@ -127,26 +127,49 @@ func buildCategoryTree(categories []domain.Category) []domain.Category {
 
 func (r *CategoryRepository) Create(ctx context.Context, c *domain.Category) error {
 	query := `
-		INSERT INTO categories (id, organization_id, parent_id, plugin_id, name, description, icon)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO categories (id, organization_id, parent_id, plugin_id, name, description, icon, plugin_category_name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING created_at, updated_at
 	`
 	if c.ID == uuid.Nil {
 		c.ID = uuid.New()
 	}
 	return r.pool.QueryRow(ctx, query,
-		c.ID, c.OrganizationID, c.ParentID, c.PluginID, c.Name, c.Description, c.Icon,
+		c.ID, c.OrganizationID, c.ParentID, c.PluginID, c.Name, c.Description, c.Icon, c.PluginCategoryName,
 	).Scan(&c.CreatedAt, &c.UpdatedAt)
 }
 
 func (r *CategoryRepository) GetByPluginID(ctx context.Context, orgID uuid.UUID, pluginID string) (*domain.Category, error) {
 	query := `
-		SELECT id, organization_id, parent_id, plugin_id, name, description, icon, created_at, updated_at
+		SELECT id, organization_id, parent_id, plugin_id, name, description, icon, plugin_category_name, created_at, updated_at
 		FROM categories
 		WHERE organization_id = $1 AND plugin_id = $2 AND deleted_at IS NULL
 	`
 	var c domain.Category
 	err := r.pool.QueryRow(ctx, query, orgID, pluginID).Scan(
+		&c.ID, &c.OrganizationID, &c.ParentID, &c.PluginID, &c.Name, &c.Description, &c.Icon, &c.PluginCategoryName,
+		&c.CreatedAt, &c.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetByName returns a category whose name matches case-insensitively, or nil
+func (r *CategoryRepository) GetByName(ctx context.Context, orgID uuid.UUID, name string) (*domain.Category, error) {
+	query := `
+		SELECT id, organization_id, parent_id, plugin_id, name, description, icon, created_at, updated_at
+		FROM categories
+		WHERE organization_id = $1 AND LOWER(name) = LOWER($2) AND deleted_at IS NULL
+		ORDER BY created_at
+		LIMIT 1
+	`
+	var c domain.Category
+	err := r.pool.QueryRow(ctx, query, orgID, name).Scan(
 		&c.ID, &c.OrganizationID, &c.ParentID, &c.PluginID, &c.Name, &c.Description, &c.Icon,
 		&c.CreatedAt, &c.UpdatedAt,
 	)
@@ -159,6 +182,16 @@ func (r *CategoryRepository) GetByPluginID(ctx context.Context, orgID uuid.UUID,
 	return &c, nil
 }
 
+// SyncPluginName sets a plugin category's name and the name its plugin supplied
+func (r *CategoryRepository) SyncPluginName(ctx context.Context, id uuid.UUID, name, pluginName string) error {
+	query := `
+		UPDATE categories SET name = $2, plugin_category_name = $3
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	_, err := r.pool.Exec(ctx, query, id, name, pluginName)
+	return err
+}
+
 func (r *CategoryRepository) Update(ctx context.Context, c *domain.Category) error {
 	query := `
 		UPDATE categories
@@ -210,7 +243,7 @@ func (r *CategoryRepository) GetAssetCounts(ctx context.Context, orgID uuid.UUID
 	query := `
 		SELECT c.id::text, COUNT(a.id)
 		FROM categories c
-		LEFT JOIN assets a ON a.category_id = c.id AND a.deleted_at IS NULL
+		LEFT JOIN assets a ON a.category_id = c.id AND a.deleted_at IS NULL AND a.archived_at IS NULL
 		WHERE c.organization_id = $1 AND c.deleted_at IS NULL
 		GROUP BY c.id
 	`
@@ -165,6 +165,48 @@ func Test_CategoryRepository_GetByPluginID(t *testing.T) {
 	}
 }
 
+func Test_CategoryRepository_GetByName_And_SyncPluginName(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewCategoryRepository(testDB.Pool)
+	pluginID := "bgg"
+	pluginName := "Board Games"
+	cat := &domain.Category{
+		OrganizationID:     org.ID,
+		Name:               "Board Games",
+		PluginID:           &pluginID,
+		PluginCategoryName: &pluginName,
+	}
+	if err := repo.Create(ctx, cat); err != nil {
+		t.Fatalf("failed to create category: %v", err)
+	}
+
+	found, err := repo.GetByName(ctx, org.ID, "board games")
+	if err != nil {
+		t.Fatalf("failed to get by name: %v", err)
+	}
+	if found == nil || found.ID != cat.ID {
+		t.Fatal("expected case-insensitive name match")
+	}
+	if missing, _ := repo.GetByName(ctx, org.ID, "Boardgames"); missing != nil {
+		t.Error("expected no category named Boardgames")
+	}
+
+	if err := repo.SyncPluginName(ctx, cat.ID, "Boardgames", "Boardgames"); err != nil {
+		t.Fatalf("failed to sync plugin name: %v", err)
+	}
+	fetched, _ := repo.GetByPluginID(ctx, org.ID, pluginID)
+	if fetched.Name != "Boardgames" || fetched.PluginCategoryName == nil || *fetched.PluginCategoryName != "Boardgames" {
+		t.Errorf("expected synced names, got %q / %v", fetched.Name, fetched.PluginCategoryName)
+	}
+}
+
 func Test_CategoryRepository_GetByIDWithAttributes(t *testing.T) {
 	ctx := context.Background()
 	if err := testDB.TruncateAll(ctx); err != nil {
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type ConsumptionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewConsumptionRepository(pool *pgxpool.Pool) *ConsumptionRepository {
+	return &ConsumptionRepository{pool: pool}
+}
+
+const consumptionColumns = `
+	c.id, c.organization_id, c.asset_id, c.amount, c.interval_count, c.interval_unit, c.next_at, c.last_applied_at,
+	c.created_at, c.updated_at
+`
+
+func scanConsumption(row pgx.Row) (*domain.ConsumptionSchedule, error) {
+	var c domain.ConsumptionSchedule
+	err := row.Scan(
+		&c.ID, &c.OrganizationID, &c.AssetID, &c.Amount, &c.IntervalCount, &c.IntervalUnit, &c.NextAt, &c.LastAppliedAt,
+		&c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetByAsset returns the consumption schedule of an asset, nil if it has none
+func (r *ConsumptionRepository) GetByAsset(ctx context.Context, assetID uuid.UUID) (*domain.ConsumptionSchedule, error) {
+	query := `SELECT ` + consumptionColumns + ` FROM consumption_schedules c WHERE c.asset_id = $1`
+	c, err := scanConsumption(r.pool.QueryRow(ctx, query, assetID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// Upsert creates or replaces the consumption schedule of c's asset
+func (r *ConsumptionRepository) Upsert(ctx context.Context, c *domain.ConsumptionSchedule) error {
+	query := `
+		INSERT INTO consumption_schedules (organization_id, asset_id, amount, interval_count, interval_unit, next_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (asset_id) DO UPDATE
+		SET amount = EXCLUDED.amount, interval_count = EXCLUDED.interval_count,
+		    interval_unit = EXCLUDED.interval_unit, next_at = EXCLUDED.next_at
+		RETURNING id, last_applied_at, created_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		c.OrganizationID, c.AssetID, c.Amount, c.IntervalCount, c.IntervalUnit, calendarDate(c.NextAt),
+	).Scan(&c.ID, &c.LastAppliedAt, &c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *ConsumptionRepository) Delete(ctx context.Context, assetID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM consumption_schedules WHERE asset_id = $1`, assetID)
+	return err
+}
+
+// ListDue returns the schedules due on or before the calendar date of day,
+// of held, active assets
+func (r *ConsumptionRepository) ListDue(ctx context.Context, orgID uuid.UUID, day time.Time) ([]domain.ConsumptionSchedule, error) {
+	query := `
+		SELECT ` + consumptionColumns + `
+		FROM consumption_schedules c
+		JOIN assets a ON a.id = c.asset_id
+		WHERE c.organization_id = $1 AND c.next_at <= $2
+		  AND a.deleted_at IS NULL AND a.archived_at IS NULL AND a.status IN ` + heldStatuses + `
+		ORDER BY c.next_at
+	`
+	rows, err := r.pool.Query(ctx, query, orgID, calendarDate(day))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []domain.ConsumptionSchedule{}
+	for rows.Next() {
+		c, err := scanConsumption(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *c)
+	}
+	return schedules, rows.Err()
+}
+
+// Apply takes amount from the quantity of the schedule's asset, which
+// doesn't go below zero, and moves the schedule to next. It returns false,
+// changing nothing, if the schedule was applied or changed since it was read.
+func (r *ConsumptionRepository) Apply(ctx context.Context, c *domain.ConsumptionSchedule, amount int, day, next time.Time) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE consumption_schedules
+		SET next_at = $3, last_applied_at = $4
+		WHERE id = $1 AND next_at = $2
+		RETURNING last_applied_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, c.ID, calendarDate(c.NextAt), calendarDate(next), calendarDate(day)).Scan(&c.LastAppliedAt, &c.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var quantity int
+	if err := tx.QueryRow(ctx, adjustQuantityQuery, c.AssetID, -amount).Scan(&quantity); err != nil {
+		return false, err
+	}
+	c.NextAt = next
+	return true, tx.Commit(ctx)
+}
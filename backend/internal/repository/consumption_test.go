@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_ConsumptionRepository_Apply(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Consumables", nil)
+	filters, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Water filter")
+	if _, err := testDB.Pool.Exec(ctx, `UPDATE assets SET quantity = 4 WHERE id = $1`, filters.ID); err != nil {
+		t.Fatalf("failed to stock asset: %v", err)
+	}
+
+	repo := NewConsumptionRepository(testDB.Pool)
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	schedule := &domain.ConsumptionSchedule{
+		OrganizationID: org.ID, AssetID: filters.ID, Amount: 1, IntervalCount: 3, IntervalUnit: domain.MaintenanceMonth, NextAt: day,
+	}
+	if err := repo.Upsert(ctx, schedule); err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+
+	if due, _ := repo.ListDue(ctx, org.ID, day.AddDate(0, 0, -1)); len(due) != 0 {
+		t.Errorf("expected nothing due the day before, got %+v", due)
+	}
+	due, err := repo.ListDue(ctx, org.ID, day)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("expected the schedule to be due, got %+v, %v", due, err)
+	}
+
+	next := day.AddDate(0, 3, 0)
+	applied, err := repo.Apply(ctx, &due[0], 1, day, next)
+	if err != nil || !applied {
+		t.Fatalf("expected the schedule to be applied, got %v, %v", applied, err)
+	}
+	// A second run with the same stale schedule doesn't take another one
+	if applied, _ := repo.Apply(ctx, schedule, 1, day, next); applied {
+		t.Errorf("expected a stale schedule not to be applied again")
+	}
+
+	var quantity int
+	testDB.Pool.QueryRow(ctx, `SELECT quantity FROM assets WHERE id = $1`, filters.ID).Scan(&quantity)
+	if quantity != 3 {
+		t.Errorf("expected 3 filters left, got %d", quantity)
+	}
+	got, _ := repo.GetByAsset(ctx, filters.ID)
+	if got == nil || !got.NextAt.Equal(next) || got.LastAppliedAt == nil || !got.LastAppliedAt.Equal(day) {
+		t.Errorf("expected the schedule to move to %v, got %+v", next, got)
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type ContactRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewContactRepository(pool *pgxpool.Pool) *ContactRepository {
+	return &ContactRepository{pool: pool}
+}
+
+const contactColumns = `c.id, c.organization_id, c.name, c.email, c.phone, c.notes, c.return_reminders, c.created_at, c.updated_at`
+
+func scanContact(row pgx.Row) (*domain.Contact, error) {
+	var c domain.Contact
+	err := row.Scan(&c.ID, &c.OrganizationID, &c.Name, &c.Email, &c.Phone, &c.Notes, &c.ReturnReminders, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *ContactRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Contact, error) {
+	query := `SELECT ` + contactColumns + ` FROM contacts c WHERE c.id = $1 AND c.deleted_at IS NULL`
+	c, err := scanContact(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// List returns the organization's contacts by name, only those whose name,
+// email or phone contains search if it isn't empty
+func (r *ContactRepository) List(ctx context.Context, orgID uuid.UUID, search string) ([]domain.Contact, error) {
+	query := `
+		SELECT ` + contactColumns + `
+		FROM contacts c
+		WHERE c.organization_id = $1 AND c.deleted_at IS NULL
+		  AND ($2 = '' OR c.name ILIKE '%' || $2 || '%' OR c.email ILIKE '%' || $2 || '%' OR c.phone LIKE '%' || $2 || '%')
+		ORDER BY LOWER(c.name), c.created_at
+	`
+	rows, err := r.pool.Query(ctx, query, orgID, search)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []domain.Contact{}
+	for rows.Next() {
+		c, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, *c)
+	}
+	return contacts, rows.Err()
+}
+
+func (r *ContactRepository) Create(ctx context.Context, c *domain.Contact) error {
+	query := `
+		INSERT INTO contacts (id, organization_id, name, email, phone, notes, return_reminders)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at, updated_at
+	`
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return r.pool.QueryRow(ctx, query,
+		c.ID, c.OrganizationID, c.Name, c.Email, c.Phone, c.Notes, c.ReturnReminders,
+	).Scan(&c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *ContactRepository) Update(ctx context.Context, c *domain.Contact) error {
+	query := `
+		UPDATE contacts
+		SET name = $2, email = $3, phone = $4, notes = $5, return_reminders = $6
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		c.ID, c.Name, c.Email, c.Phone, c.Notes, c.ReturnReminders,
+	).Scan(&c.UpdatedAt)
+}
+
+func (r *ContactRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE contacts SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
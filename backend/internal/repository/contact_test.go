@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_ContactRepository_CRUD(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	other, _ := fixtures.CreateOrganization(ctx, "Other Org")
+
+	repo := NewContactRepository(testDB.Pool)
+	email := "ana@example.com"
+	ana := &domain.Contact{OrganizationID: org.ID, Name: "Ana", Email: &email, ReturnReminders: true}
+	if err := repo.Create(ctx, ana); err != nil {
+		t.Fatalf("failed to create contact: %v", err)
+	}
+	repo.Create(ctx, &domain.Contact{OrganizationID: org.ID, Name: "bruno"})
+	repo.Create(ctx, &domain.Contact{OrganizationID: other.ID, Name: "Carla"})
+
+	got, err := repo.GetByID(ctx, ana.ID)
+	if err != nil || got == nil || got.Email == nil || *got.Email != email || !got.ReturnReminders {
+		t.Fatalf("unexpected contact %+v, %v", got, err)
+	}
+
+	contacts, err := repo.List(ctx, org.ID, "")
+	if err != nil {
+		t.Fatalf("failed to list contacts: %v", err)
+	}
+	if len(contacts) != 2 || contacts[0].Name != "Ana" || contacts[1].Name != "bruno" {
+		t.Errorf("expected the organization's contacts by name, got %+v", contacts)
+	}
+	if found, _ := repo.List(ctx, org.ID, "EXAMPLE"); len(found) != 1 || found[0].ID != ana.ID {
+		t.Errorf("expected the search to match the email, got %+v", found)
+	}
+
+	phone := "+351 912 345 678"
+	ana.Phone = &phone
+	ana.ReturnReminders = false
+	if err := repo.Update(ctx, ana); err != nil {
+		t.Fatalf("failed to update contact: %v", err)
+	}
+	got, _ = repo.GetByID(ctx, ana.ID)
+	if got.Phone == nil || *got.Phone != phone || got.ReturnReminders {
+		t.Errorf("expected the update to be stored, got %+v", got)
+	}
+
+	if err := repo.Delete(ctx, ana.ID); err != nil {
+		t.Fatalf("failed to delete contact: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, ana.ID); got != nil {
+		t.Error("expected the deleted contact to be gone")
+	}
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type DepreciationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewDepreciationRepository(pool *pgxpool.Pool) *DepreciationRepository {
+	return &DepreciationRepository{pool: pool}
+}
+
+const depreciationColumns = `
+	id, organization_id, asset_id, category_id, method, useful_life_months, salvage_value, created_at, updated_at
+`
+
+func scanDepreciation(row pgx.Row) (*domain.Depreciation, error) {
+	var d domain.Depreciation
+	err := row.Scan(
+		&d.ID, &d.OrganizationID, &d.AssetID, &d.CategoryID, &d.Method, &d.UsefulLifeMonths, &d.SalvageValue, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetByAsset returns the asset's own depreciation rule, nil if it has none
+func (r *DepreciationRepository) GetByAsset(ctx context.Context, assetID uuid.UUID) (*domain.Depreciation, error) {
+	return r.get(ctx, `SELECT `+depreciationColumns+` FROM depreciation_rules WHERE asset_id = $1`, assetID)
+}
+
+// GetByCategory returns the category's depreciation rule, nil if it has none
+func (r *DepreciationRepository) GetByCategory(ctx context.Context, categoryID uuid.UUID) (*domain.Depreciation, error) {
+	return r.get(ctx, `SELECT `+depreciationColumns+` FROM depreciation_rules WHERE category_id = $1`, categoryID)
+}
+
+func (r *DepreciationRepository) get(ctx context.Context, query string, id uuid.UUID) (*domain.Depreciation, error) {
+	d, err := scanDepreciation(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// List returns all depreciation rules of the organization
+func (r *DepreciationRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.Depreciation, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+depreciationColumns+` FROM depreciation_rules WHERE organization_id = $1`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []domain.Depreciation{}
+	for rows.Next() {
+		d, err := scanDepreciation(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *d)
+	}
+	return rules, rows.Err()
+}
+
+// Upsert creates or replaces the depreciation rule of d's asset or category
+func (r *DepreciationRepository) Upsert(ctx context.Context, d *domain.Depreciation) error {
+	conflict := "(asset_id)"
+	if d.AssetID == nil {
+		conflict = "(category_id)"
+	}
+	query := `
+		INSERT INTO depreciation_rules (organization_id, asset_id, category_id, method, useful_life_months, salvage_value)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT ` + conflict + ` DO UPDATE
+		SET method = EXCLUDED.method, useful_life_months = EXCLUDED.useful_life_months, salvage_value = EXCLUDED.salvage_value
+		RETURNING id, created_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		d.OrganizationID, d.AssetID, d.CategoryID, d.Method, d.UsefulLifeMonths, d.SalvageValue,
+	).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+}
+
+func (r *DepreciationRepository) DeleteByAsset(ctx context.Context, assetID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM depreciation_rules WHERE asset_id = $1`, assetID)
+	return err
+}
+
+func (r *DepreciationRepository) DeleteByCategory(ctx context.Context, categoryID uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM depreciation_rules WHERE category_id = $1`, categoryID)
+	return err
+}
+
+// ListPricedAssets returns the held, active assets of the organization with
+// a purchase price and date, with only the fields depreciation depends on
+func (r *DepreciationRepository) ListPricedAssets(ctx context.Context, orgID uuid.UUID) ([]domain.Asset, error) {
+	query := `
+		SELECT id, category_id, quantity, purchase_price, purchase_at
+		FROM assets
+		WHERE organization_id = $1 AND deleted_at IS NULL AND archived_at IS NULL AND status IN ` + heldStatuses + `
+		  AND purchase_price IS NOT NULL AND purchase_at IS NOT NULL
+	`
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []domain.Asset
+	for rows.Next() {
+		var a domain.Asset
+		if err := rows.Scan(&a.ID, &a.CategoryID, &a.Quantity, &a.PurchasePrice, &a.PurchaseAt); err != nil {
+			return nil, err
+		}
+		assets = append(assets, a)
+	}
+	return assets, rows.Err()
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_DepreciationRepository_Upsert(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	laptop, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Laptop")
+
+	repo := NewDepreciationRepository(testDB.Pool)
+	byCategory := &domain.Depreciation{OrganizationID: org.ID, CategoryID: &category.ID, Method: domain.DepreciationStraightLine, UsefulLifeMonths: 60}
+	if err := repo.Upsert(ctx, byCategory); err != nil {
+		t.Fatalf("failed to create category rule: %v", err)
+	}
+	replaced := &domain.Depreciation{OrganizationID: org.ID, CategoryID: &category.ID, Method: domain.DepreciationDecliningBalance, UsefulLifeMonths: 36, SalvageValue: 50}
+	if err := repo.Upsert(ctx, replaced); err != nil {
+		t.Fatalf("failed to replace category rule: %v", err)
+	}
+	if replaced.ID != byCategory.ID {
+		t.Errorf("expected the category rule to be replaced, got a new one")
+	}
+	byAsset := &domain.Depreciation{OrganizationID: org.ID, AssetID: &laptop.ID, Method: domain.DepreciationStraightLine, UsefulLifeMonths: 24}
+	if err := repo.Upsert(ctx, byAsset); err != nil {
+		t.Fatalf("failed to create asset rule: %v", err)
+	}
+
+	got, err := repo.GetByCategory(ctx, category.ID)
+	if err != nil || got == nil || got.Method != domain.DepreciationDecliningBalance || got.UsefulLifeMonths != 36 || got.SalvageValue != 50 {
+		t.Fatalf("expected the replaced category rule, got %+v, %v", got, err)
+	}
+	if rules, _ := repo.List(ctx, org.ID); len(rules) != 2 {
+		t.Errorf("expected 2 rules, got %+v", rules)
+	}
+
+	if err := repo.DeleteByAsset(ctx, laptop.ID); err != nil {
+		t.Fatalf("failed to delete asset rule: %v", err)
+	}
+	if got, err := repo.GetByAsset(ctx, laptop.ID); err != nil || got != nil {
+		t.Errorf("expected no asset rule, got %+v, %v", got, err)
+	}
+}
+
+func Test_DepreciationRepository_ListPricedAssets(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	laptop, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Laptop")
+	fixtures.CreateAsset(ctx, org.ID, category.ID, "Cable")
+	sold, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Old phone")
+
+	_, err := testDB.Pool.Exec(ctx, `UPDATE assets SET purchase_price = 1200, purchase_at = '2024-01-15', quantity = 2 WHERE id = $1`, laptop.ID)
+	if err != nil {
+		t.Fatalf("failed to price asset: %v", err)
+	}
+	_, err = testDB.Pool.Exec(ctx, `UPDATE assets SET purchase_price = 300, purchase_at = '2020-05-01', status = 'sold' WHERE id = $1`, sold.ID)
+	if err != nil {
+		t.Fatalf("failed to price asset: %v", err)
+	}
+
+	assets, err := NewDepreciationRepository(testDB.Pool).ListPricedAssets(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("failed to list priced assets: %v", err)
+	}
+	if len(assets) != 1 || assets[0].ID != laptop.ID || assets[0].Quantity != 2 || *assets[0].PurchasePrice != 1200 || assets[0].PurchaseAt == nil {
+		t.Errorf("expected only the held, priced laptop, got %+v", assets)
+	}
+}
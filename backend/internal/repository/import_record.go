@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type ImportRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewImportRepository(pool *pgxpool.Pool) *ImportRepository {
+	return &ImportRepository{pool: pool}
+}
+
+// Create records an import
+func (r *ImportRepository) Create(ctx context.Context, rec *domain.ImportRecord) error {
+	query := `
+		INSERT INTO imports (organization_id, user_id, plugin_id, external_id, asset_id, success, error, payload)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+	var payload []byte
+	if len(rec.Payload) > 0 {
+		payload = rec.Payload
+	}
+	return r.pool.QueryRow(ctx, query,
+		rec.OrganizationID, rec.UserID, rec.PluginID, rec.ExternalID, rec.AssetID, rec.Success, rec.Error, payload,
+	).Scan(&rec.ID, &rec.CreatedAt)
+}
+
+// List returns imports without their payload, most recent first
+func (r *ImportRepository) List(ctx context.Context, orgID uuid.UUID, filter domain.ImportRecordFilter) ([]domain.ImportRecord, error) {
+	query := `
+		SELECT id, organization_id, user_id, plugin_id, external_id, asset_id, success, error, created_at
+		FROM imports
+		WHERE organization_id = $1
+	`
+	args := []any{orgID}
+
+	if filter.PluginID != "" {
+		args = append(args, filter.PluginID)
+		query += fmt.Sprintf(" AND plugin_id = $%d", len(args))
+	}
+	if filter.Success != nil {
+		args = append(args, *filter.Success)
+		query += fmt.Sprintf(" AND success = $%d", len(args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []domain.ImportRecord
+	for rows.Next() {
+		var rec domain.ImportRecord
+		if err := rows.Scan(
+			&rec.ID, &rec.OrganizationID, &rec.UserID, &rec.PluginID, &rec.ExternalID, &rec.AssetID,
+			&rec.Success, &rec.Error, &rec.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// GetByID returns an import including its payload, or nil if it does not exist
+func (r *ImportRepository) GetByID(ctx context.Context, orgID, id uuid.UUID) (*domain.ImportRecord, error) {
+	query := `
+		SELECT id, organization_id, user_id, plugin_id, external_id, asset_id, success, error, payload, created_at
+		FROM imports
+		WHERE organization_id = $1 AND id = $2
+	`
+	var rec domain.ImportRecord
+	var payload []byte
+	err := r.pool.QueryRow(ctx, query, orgID, id).Scan(
+		&rec.ID, &rec.OrganizationID, &rec.UserID, &rec.PluginID, &rec.ExternalID, &rec.AssetID,
+		&rec.Success, &rec.Error, &payload, &rec.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec.Payload = payload
+	return &rec, nil
+}
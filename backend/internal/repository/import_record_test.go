@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_ImportRepository_CreateListGet(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "jane@example.com")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Books", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Dune")
+	repo := NewImportRepository(testDB.Pool)
+
+	ok := &domain.ImportRecord{
+		OrganizationID: org.ID,
+		UserID:         &user.ID,
+		PluginID:       "google_books",
+		ExternalID:     "abc",
+		AssetID:        &asset.ID,
+		Success:        true,
+		Payload:        json.RawMessage(`{"name":"Dune"}`),
+	}
+	errMsg := "API returned status 500"
+	failed := &domain.ImportRecord{
+		OrganizationID: org.ID,
+		PluginID:       "tmdb_movies",
+		ExternalID:     "42",
+		Error:          &errMsg,
+	}
+	for _, rec := range []*domain.ImportRecord{ok, failed} {
+		if err := repo.Create(ctx, rec); err != nil {
+			t.Fatalf("failed to create import: %v", err)
+		}
+	}
+
+	all, err := repo.List(ctx, org.ID, domain.ImportRecordFilter{})
+	if err != nil || len(all) != 2 {
+		t.Fatalf("expected 2 imports, got %d (%v)", len(all), err)
+	}
+	if all[0].Payload != nil {
+		t.Error("expected list to omit payloads")
+	}
+
+	success := false
+	failures, _ := repo.List(ctx, org.ID, domain.ImportRecordFilter{Success: &success})
+	if len(failures) != 1 || failures[0].ID != failed.ID || failures[0].Error == nil {
+		t.Errorf("expected only the failed import, got %+v", failures)
+	}
+	byPlugin, _ := repo.List(ctx, org.ID, domain.ImportRecordFilter{PluginID: "google_books"})
+	if len(byPlugin) != 1 || byPlugin[0].ID != ok.ID {
+		t.Errorf("expected only the google_books import, got %+v", byPlugin)
+	}
+
+	found, err := repo.GetByID(ctx, org.ID, ok.ID)
+	if err != nil || found == nil {
+		t.Fatalf("expected import, got %v", err)
+	}
+	if found.AssetID == nil || *found.AssetID != asset.ID || string(found.Payload) != `{"name": "Dune"}` {
+		t.Errorf("unexpected import %+v (payload %s)", found, found.Payload)
+	}
+	if found, _ := repo.GetByID(ctx, org.ID, asset.ID); found != nil {
+		t.Error("expected nil for unknown import")
+	}
+}
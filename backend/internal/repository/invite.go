@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type InviteRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewInviteRepository(pool *pgxpool.Pool) *InviteRepository {
+	return &InviteRepository{pool: pool}
+}
+
+const inviteColumns = `id, organization_id, token_hash, role, created_by, expires_at, max_uses, uses, revoked_at, created_at`
+
+func scanInvite(row pgx.Row) (*domain.Invite, error) {
+	var i domain.Invite
+	err := row.Scan(
+		&i.ID, &i.OrganizationID, &i.TokenHash, &i.Role, &i.CreatedBy,
+		&i.ExpiresAt, &i.MaxUses, &i.Uses, &i.RevokedAt, &i.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+func (r *InviteRepository) Create(ctx context.Context, i *domain.Invite) error {
+	query := `
+		INSERT INTO organization_invites (id, organization_id, token_hash, role, created_by, expires_at, max_uses)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING uses, created_at
+	`
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return r.pool.QueryRow(ctx, query,
+		i.ID, i.OrganizationID, i.TokenHash, i.Role, i.CreatedBy, i.ExpiresAt, i.MaxUses,
+	).Scan(&i.Uses, &i.CreatedAt)
+}
+
+// List returns the invites of an organization, newest first
+func (r *InviteRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.Invite, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+inviteColumns+`
+		FROM organization_invites
+		WHERE organization_id = $1
+		ORDER BY created_at DESC`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []domain.Invite{}
+	for rows.Next() {
+		i, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *i)
+	}
+	return invites, rows.Err()
+}
+
+// GetByTokenHash returns the invite with a token, usable or not; nil if
+// there is none
+func (r *InviteRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.Invite, error) {
+	return scanInvite(r.pool.QueryRow(ctx, `SELECT `+inviteColumns+`
+		FROM organization_invites
+		WHERE token_hash = $1`, tokenHash))
+}
+
+// Use counts a use of the invite with a token and returns it; nil if there is
+// no such invite or it is revoked, expired or used up
+func (r *InviteRepository) Use(ctx context.Context, tokenHash string) (*domain.Invite, error) {
+	return scanInvite(r.pool.QueryRow(ctx, `
+		UPDATE organization_invites SET uses = uses + 1
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		  AND (max_uses IS NULL OR uses < max_uses)
+		RETURNING `+inviteColumns, tokenHash))
+}
+
+// Revoke stops an invite of an organization from being accepted; it reports
+// false if the organization has no such invite that isn't revoked yet
+func (r *InviteRepository) Revoke(ctx context.Context, orgID, id uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE organization_invites SET revoked_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND revoked_at IS NULL
+	`, id, orgID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
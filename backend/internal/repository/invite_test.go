@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_InviteRepository_Use(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	repo := NewInviteRepository(testDB.Pool)
+
+	one := 1
+	limited := &domain.Invite{OrganizationID: org.ID, TokenHash: "limited", Role: domain.UserRoleUser, ExpiresAt: time.Now().Add(time.Hour), MaxUses: &one}
+	expired := &domain.Invite{OrganizationID: org.ID, TokenHash: "expired", Role: domain.UserRoleUser, ExpiresAt: time.Now().Add(-time.Minute)}
+	revoked := &domain.Invite{OrganizationID: org.ID, TokenHash: "revoked", Role: domain.UserRoleAdmin, ExpiresAt: time.Now().Add(time.Hour)}
+	for _, i := range []*domain.Invite{limited, expired, revoked} {
+		if err := repo.Create(ctx, i); err != nil {
+			t.Fatalf("failed to create invite: %v", err)
+		}
+	}
+	if ok, err := repo.Revoke(ctx, org.ID, revoked.ID); err != nil || !ok {
+		t.Fatalf("expected the invite to be revoked, got %v, %v", ok, err)
+	}
+	if ok, _ := repo.Revoke(ctx, org.ID, revoked.ID); ok {
+		t.Error("expected revoking twice to report false")
+	}
+
+	got, err := repo.Use(ctx, "limited")
+	if err != nil {
+		t.Fatalf("failed to use invite: %v", err)
+	}
+	if got == nil || got.Uses != 1 || got.Role != domain.UserRoleUser {
+		t.Fatalf("unexpected invite %+v", got)
+	}
+	for _, hash := range []string{"limited", "expired", "revoked", "missing"} {
+		if got, err := repo.Use(ctx, hash); err != nil || got != nil {
+			t.Errorf("%s: expected no usable invite, got %+v, %v", hash, got, err)
+		}
+	}
+
+	stored, err := repo.GetByTokenHash(ctx, "revoked")
+	if err != nil || stored == nil || stored.RevokedAt == nil || stored.Usable(time.Now()) {
+		t.Errorf("expected the revoked invite, got %+v, %v", stored, err)
+	}
+
+	invites, err := repo.List(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("failed to list invites: %v", err)
+	}
+	if len(invites) != 3 {
+		t.Errorf("expected 3 invites, got %d", len(invites))
+	}
+}
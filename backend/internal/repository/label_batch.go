@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type LabelBatchRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLabelBatchRepository(pool *pgxpool.Pool) *LabelBatchRepository {
+	return &LabelBatchRepository{pool: pool}
+}
+
+const labelBatchColumns = `
+	id, organization_id, created_by, status, asset_ids, layout, skip, error, completed_at, created_at
+`
+
+func scanLabelBatch(row pgx.Row) (*domain.LabelBatch, error) {
+	var b domain.LabelBatch
+	err := row.Scan(
+		&b.ID, &b.OrganizationID, &b.CreatedBy, &b.Status, &b.AssetIDs, &b.Layout, &b.Skip, &b.Error, &b.CompletedAt, &b.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *LabelBatchRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.LabelBatch, error) {
+	query := `SELECT ` + labelBatchColumns + ` FROM label_batches WHERE id = $1`
+	b, err := scanLabelBatch(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// GetPDF returns the rendered document of a batch, nil until it is done
+func (r *LabelBatchRepository) GetPDF(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	var pdf []byte
+	err := r.pool.QueryRow(ctx, `SELECT pdf FROM label_batches WHERE id = $1`, id).Scan(&pdf)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return pdf, err
+}
+
+// Create queues a batch for rendering
+func (r *LabelBatchRepository) Create(ctx context.Context, b *domain.LabelBatch) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO label_batches (id, organization_id, created_by, asset_ids, layout, skip)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING status, created_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		b.ID, b.OrganizationID, b.CreatedBy, b.AssetIDs, b.Layout, b.Skip,
+	).Scan(&b.Status, &b.CreatedAt)
+}
+
+// Claim marks the oldest pending batch as rendering and returns it, or nil if
+// the queue is empty. Batches claimed before staleBefore whose rendering
+// never finished (e.g. the server restarted) are claimed again.
+func (r *LabelBatchRepository) Claim(ctx context.Context, staleBefore time.Time) (*domain.LabelBatch, error) {
+	query := `
+		UPDATE label_batches SET status = 'rendering', claimed_at = NOW()
+		WHERE id = (
+			SELECT id FROM label_batches
+			WHERE status = 'pending' OR (status = 'rendering' AND claimed_at < $1)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + labelBatchColumns
+	b, err := scanLabelBatch(r.pool.QueryRow(ctx, query, staleBefore))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return b, err
+}
+
+// Complete stores the rendered document of a batch
+func (r *LabelBatchRepository) Complete(ctx context.Context, id uuid.UUID, pdf []byte) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE label_batches SET status = 'done', pdf = $2, error = NULL, completed_at = NOW()
+		WHERE id = $1
+	`, id, pdf)
+	return err
+}
+
+// Fail records why a batch could not be rendered
+func (r *LabelBatchRepository) Fail(ctx context.Context, id uuid.UUID, reason string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE label_batches SET status = 'failed', error = $2, completed_at = NOW()
+		WHERE id = $1
+	`, id, reason)
+	return err
+}
+
+// DeleteCompletedBefore removes batches finished before t, returning how many
+// were deleted
+func (r *LabelBatchRepository) DeleteCompletedBefore(ctx context.Context, t time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM label_batches WHERE completed_at < $1`, t)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
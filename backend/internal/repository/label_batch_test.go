@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_LabelBatchRepository_Queue(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	repo := NewLabelBatchRepository(testDB.Pool)
+
+	first := &domain.LabelBatch{OrganizationID: org.ID, AssetIDs: []uuid.UUID{uuid.New(), uuid.New()}, Layout: []byte(`{"columns": 3}`), Skip: 4}
+	second := &domain.LabelBatch{OrganizationID: org.ID, AssetIDs: []uuid.UUID{uuid.New()}, Layout: []byte(`{}`)}
+	for _, b := range []*domain.LabelBatch{first, second} {
+		if err := repo.Create(ctx, b); err != nil {
+			t.Fatalf("failed to create batch: %v", err)
+		}
+	}
+	if first.Status != domain.LabelBatchPending {
+		t.Errorf("expected a pending batch, got %q", first.Status)
+	}
+
+	claimed, err := repo.Claim(ctx, time.Now().Add(-time.Minute))
+	if err != nil || claimed == nil || claimed.ID != first.ID {
+		t.Fatalf("expected the oldest batch to be claimed, got %+v, %v", claimed, err)
+	}
+	if claimed.Status != domain.LabelBatchRendering || len(claimed.AssetIDs) != 2 || claimed.Skip != 4 {
+		t.Errorf("unexpected claimed batch: %+v", claimed)
+	}
+	if next, _ := repo.Claim(ctx, time.Now().Add(-time.Minute)); next == nil || next.ID != second.ID {
+		t.Errorf("expected the second batch to be claimed next, got %+v", next)
+	}
+	if next, _ := repo.Claim(ctx, time.Now().Add(-time.Minute)); next != nil {
+		t.Errorf("expected an empty queue, got %+v", next)
+	}
+	// Rendering that never finished is retried once stale
+	if stale, _ := repo.Claim(ctx, time.Now().Add(time.Minute)); stale == nil || stale.ID != first.ID {
+		t.Errorf("expected the stale batch to be claimed again, got %+v", stale)
+	}
+
+	doc := []byte("%PDF-1.4")
+	if err := repo.Complete(ctx, first.ID, doc); err != nil {
+		t.Fatalf("failed to complete: %v", err)
+	}
+	if err := repo.Fail(ctx, second.ID, "the assets were deleted"); err != nil {
+		t.Fatalf("failed to fail: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, first.ID); got.Status != domain.LabelBatchDone || got.CompletedAt == nil {
+		t.Errorf("expected a done batch, got %+v", got)
+	}
+	if got, _ := repo.GetPDF(ctx, first.ID); !bytes.Equal(got, doc) {
+		t.Errorf("expected the stored PDF, got %q", got)
+	}
+	if got, _ := repo.GetByID(ctx, second.ID); got.Status != domain.LabelBatchFailed || got.Error == nil {
+		t.Errorf("expected a failed batch, got %+v", got)
+	}
+
+	if n, err := repo.DeleteCompletedBefore(ctx, time.Now().Add(time.Minute)); err != nil || n != 2 {
+		t.Errorf("expected both batches to be deleted, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type LoanRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLoanRepository(pool *pgxpool.Pool) *LoanRepository {
+	return &LoanRepository{pool: pool}
+}
+
+const loanColumns = `
+	l.id, l.organization_id, l.asset_id, l.contact_id, l.borrower, l.loaned_at, l.due_at, l.returned_at, l.notes,
+	l.created_by, l.reminded_at, l.created_at, l.updated_at,
+	a.name, c.email, a.high_value
+`
+
+// loanJoins adds the asset name and high-value flag, and the email of a
+// contact that still exists
+const loanJoins = `
+	JOIN assets a ON a.id = l.asset_id
+	LEFT JOIN contacts c ON c.id = l.contact_id AND c.deleted_at IS NULL
+`
+
+func scanLoan(row pgx.Row) (*domain.Loan, error) {
+	var l domain.Loan
+	err := row.Scan(
+		&l.ID, &l.OrganizationID, &l.AssetID, &l.ContactID, &l.Borrower, &l.LoanedAt, &l.DueAt, &l.ReturnedAt, &l.Notes,
+		&l.CreatedBy, &l.RemindedAt, &l.CreatedAt, &l.UpdatedAt,
+		&l.AssetName, &l.ContactEmail, &l.HighValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *LoanRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Loan, error) {
+	query := `SELECT ` + loanColumns + ` FROM loans l ` + loanJoins + ` WHERE l.id = $1`
+	l, err := scanLoan(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return l, err
+}
+
+// ListOpen returns the organization's assets currently on loan, soonest due
+// first
+func (r *LoanRepository) ListOpen(ctx context.Context, orgID uuid.UUID) ([]domain.Loan, error) {
+	query := `
+		SELECT ` + loanColumns + `
+		FROM loans l ` + loanJoins + `
+		WHERE l.organization_id = $1 AND l.returned_at IS NULL AND a.deleted_at IS NULL
+		ORDER BY l.due_at NULLS LAST, l.loaned_at
+	`
+	return r.query(ctx, query, orgID)
+}
+
+// ListOverdue returns the open loans due before the calendar date of day
+func (r *LoanRepository) ListOverdue(ctx context.Context, orgID uuid.UUID, day time.Time) ([]domain.Loan, error) {
+	query := `
+		SELECT ` + loanColumns + `
+		FROM loans l ` + loanJoins + `
+		WHERE l.organization_id = $1 AND l.returned_at IS NULL AND a.deleted_at IS NULL
+		  AND l.due_at < $2
+		ORDER BY l.due_at, l.loaned_at
+	`
+	return r.query(ctx, query, orgID, calendarDate(day))
+}
+
+// ListRemindersDue returns the overdue loans of contacts that want return
+// reminders and haven't been reminded yet
+func (r *LoanRepository) ListRemindersDue(ctx context.Context, orgID uuid.UUID, day time.Time) ([]domain.Loan, error) {
+	query := `
+		SELECT ` + loanColumns + `
+		FROM loans l ` + loanJoins + `
+		WHERE l.organization_id = $1 AND l.returned_at IS NULL AND a.deleted_at IS NULL
+		  AND l.due_at < $2 AND l.reminded_at IS NULL
+		  AND c.return_reminders AND c.email IS NOT NULL
+		ORDER BY l.due_at, l.loaned_at
+	`
+	return r.query(ctx, query, orgID, calendarDate(day))
+}
+
+// ListByAsset returns the loan history of an asset, latest first
+func (r *LoanRepository) ListByAsset(ctx context.Context, assetID uuid.UUID) ([]domain.Loan, error) {
+	query := `
+		SELECT ` + loanColumns + `
+		FROM loans l ` + loanJoins + `
+		WHERE l.asset_id = $1
+		ORDER BY l.loaned_at DESC
+	`
+	return r.query(ctx, query, assetID)
+}
+
+// ListByContact returns the loans to a contact, latest first
+func (r *LoanRepository) ListByContact(ctx context.Context, contactID uuid.UUID) ([]domain.Loan, error) {
+	query := `
+		SELECT ` + loanColumns + `
+		FROM loans l ` + loanJoins + `
+		WHERE l.contact_id = $1 AND a.deleted_at IS NULL
+		ORDER BY l.loaned_at DESC
+	`
+	return r.query(ctx, query, contactID)
+}
+
+func (r *LoanRepository) query(ctx context.Context, query string, args ...any) ([]domain.Loan, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loans := []domain.Loan{}
+	for rows.Next() {
+		l, err := scanLoan(rows)
+		if err != nil {
+			return nil, err
+		}
+		loans = append(loans, *l)
+	}
+	return loans, rows.Err()
+}
+
+// CheckOut inserts a loan and marks the asset as loaned, returning
+// domain.ErrAssetOnLoan if the asset is on another open loan
+func (r *LoanRepository) CheckOut(ctx context.Context, l *domain.Loan) error {
+	if l.ID == uuid.Nil {
+		l.ID = uuid.New()
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the asset so concurrent check-outs of it are serialized
+	if _, err := tx.Exec(ctx, `SELECT id FROM assets WHERE id = $1 FOR UPDATE`, l.AssetID); err != nil {
+		return err
+	}
+	var onLoan bool
+	err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM loans WHERE asset_id = $1 AND returned_at IS NULL)`, l.AssetID).Scan(&onLoan)
+	if err != nil {
+		return err
+	}
+	if onLoan {
+		return domain.ErrAssetOnLoan
+	}
+
+	query := `
+		INSERT INTO loans (id, organization_id, asset_id, contact_id, borrower, due_at, notes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING loaned_at, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query,
+		l.ID, l.OrganizationID, l.AssetID, l.ContactID, l.Borrower, l.DueAt, l.Notes, l.CreatedBy,
+	).Scan(&l.LoanedAt, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE assets SET status = $2 WHERE id = $1`, l.AssetID, domain.AssetStatusLoaned); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Return checks a loan back in, setting the asset's status back to owned
+// unless it was changed while on loan. It returns false if the loan was
+// already returned.
+func (r *LoanRepository) Return(ctx context.Context, l *domain.Loan) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE loans SET returned_at = NOW()
+		WHERE id = $1 AND returned_at IS NULL
+		RETURNING returned_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, l.ID).Scan(&l.ReturnedAt, &l.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.Exec(ctx, `UPDATE assets SET status = $2 WHERE id = $1 AND status = $3`,
+		l.AssetID, domain.AssetStatusOwned, domain.AssetStatusLoaned)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit(ctx)
+}
+
+// Update saves the borrower, due date and notes of a loan; changing the due
+// date allows a new overdue reminder
+func (r *LoanRepository) Update(ctx context.Context, l *domain.Loan) error {
+	query := `
+		UPDATE loans
+		SET borrower = $2, due_at = $3, notes = $4,
+		    reminded_at = CASE WHEN due_at IS DISTINCT FROM $3 THEN NULL ELSE reminded_at END
+		WHERE id = $1
+		RETURNING reminded_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query, l.ID, l.Borrower, l.DueAt, l.Notes).Scan(&l.RemindedAt, &l.UpdatedAt)
+}
+
+// MarkReminded records that the overdue reminder of a loan was sent
+func (r *LoanRepository) MarkReminded(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `UPDATE loans SET reminded_at = NOW() WHERE id = $1`, id)
+	return err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_LoanRepository_CheckOutAndReturn(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Tools", nil)
+	drill, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Drill")
+	tent, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Tent")
+
+	email := "ana@example.com"
+	ana := &domain.Contact{OrganizationID: org.ID, Name: "Ana", Email: &email, ReturnReminders: true}
+	if err := NewContactRepository(testDB.Pool).Create(ctx, ana); err != nil {
+		t.Fatalf("failed to create contact: %v", err)
+	}
+
+	repo := NewLoanRepository(testDB.Pool)
+	assets := NewAssetRepository(testDB.Pool)
+	day := time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)
+	pastDue, futureDue := day.AddDate(0, 0, -3), day.AddDate(0, 0, 7)
+
+	loan := &domain.Loan{OrganizationID: org.ID, AssetID: drill.ID, ContactID: &ana.ID, Borrower: ana.Name, DueAt: &pastDue}
+	if err := repo.CheckOut(ctx, loan); err != nil {
+		t.Fatalf("failed to check out: %v", err)
+	}
+	if asset, _ := assets.GetByID(ctx, drill.ID); asset.Status != domain.AssetStatusLoaned {
+		t.Errorf("expected the asset to be loaned, got %q", asset.Status)
+	}
+	err := repo.CheckOut(ctx, &domain.Loan{OrganizationID: org.ID, AssetID: drill.ID, Borrower: "Bruno"})
+	if !errors.Is(err, domain.ErrAssetOnLoan) {
+		t.Errorf("expected ErrAssetOnLoan, got %v", err)
+	}
+	repo.CheckOut(ctx, &domain.Loan{OrganizationID: org.ID, AssetID: tent.ID, Borrower: "Bruno", DueAt: &futureDue})
+
+	open, err := repo.ListOpen(ctx, org.ID)
+	if err != nil || len(open) != 2 || open[0].ID != loan.ID || open[0].AssetName != "Drill" {
+		t.Fatalf("expected both open loans, soonest due first, got %+v, %v", open, err)
+	}
+	if open[0].ContactEmail == nil || *open[0].ContactEmail != email {
+		t.Errorf("expected the contact's email, got %v", open[0].ContactEmail)
+	}
+	if overdue, _ := repo.ListOverdue(ctx, org.ID, day); len(overdue) != 1 || overdue[0].ID != loan.ID {
+		t.Errorf("expected only the drill to be overdue, got %+v", overdue)
+	}
+
+	due, _ := repo.ListRemindersDue(ctx, org.ID, day)
+	if len(due) != 1 || due[0].ID != loan.ID {
+		t.Fatalf("expected a reminder for the drill, got %+v", due)
+	}
+	repo.MarkReminded(ctx, loan.ID)
+	if due, _ := repo.ListRemindersDue(ctx, org.ID, day); len(due) != 0 {
+		t.Errorf("expected no reminder twice, got %+v", due)
+	}
+	loan.DueAt = &futureDue
+	if err := repo.Update(ctx, loan); err != nil || loan.RemindedAt != nil {
+		t.Errorf("expected a new due date to reset the reminder, got %v, %v", loan.RemindedAt, err)
+	}
+
+	returned, err := repo.Return(ctx, loan)
+	if err != nil || !returned || loan.ReturnedAt == nil {
+		t.Fatalf("failed to return loan: %v, %v", returned, err)
+	}
+	if asset, _ := assets.GetByID(ctx, drill.ID); asset.Status != domain.AssetStatusOwned {
+		t.Errorf("expected the asset to be owned again, got %q", asset.Status)
+	}
+	if returned, _ := repo.Return(ctx, loan); returned {
+		t.Error("expected a returned loan not to be returned twice")
+	}
+
+	if history, _ := repo.ListByContact(ctx, ana.ID); len(history) != 1 || history[0].ReturnedAt == nil {
+		t.Errorf("expected the returned loan in the contact's history, got %+v", history)
+	}
+	if history, _ := repo.ListByAsset(ctx, drill.ID); len(history) != 1 {
+		t.Errorf("expected the asset's loan history, got %+v", history)
+	}
+	if err := repo.CheckOut(ctx, &domain.Loan{OrganizationID: org.ID, AssetID: drill.ID, Borrower: "Bruno"}); err != nil {
+		t.Errorf("expected a returned asset to be lent again, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type LoginEventRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewLoginEventRepository(pool *pgxpool.Pool) *LoginEventRepository {
+	return &LoginEventRepository{pool: pool}
+}
+
+// Create records a login attempt. For successful logins of known users it sets
+// NewDevice when the user logged in before, but never from this IP and user agent.
+func (r *LoginEventRepository) Create(ctx context.Context, e *domain.LoginEvent) error {
+	query := `
+		INSERT INTO login_events (id, organization_id, user_id, email, ip_address, user_agent, method, success, failure_reason, new_device)
+		SELECT $1::uuid, $2::uuid, $3::uuid, $4::text, $5::text, $6::text, $7::text, $8::boolean, $9::text,
+		       $8::boolean AND $3::uuid IS NOT NULL
+		       AND EXISTS (SELECT 1 FROM login_events WHERE user_id = $3::uuid AND success)
+		       AND NOT EXISTS (SELECT 1 FROM login_events WHERE user_id = $3::uuid AND success AND ip_address = $5::text AND user_agent = $6::text)
+		RETURNING new_device, created_at
+	`
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return r.pool.QueryRow(ctx, query,
+		e.ID, e.OrganizationID, e.UserID, e.Email, e.IPAddress, e.UserAgent, e.Method, e.Success, e.FailureReason,
+	).Scan(&e.NewDevice, &e.CreatedAt)
+}
+
+// List returns login events, most recent first
+func (r *LoginEventRepository) List(ctx context.Context, orgID uuid.UUID, filter domain.LoginEventFilter) ([]domain.LoginEvent, error) {
+	query := `
+		SELECT id, organization_id, user_id, email, ip_address, user_agent, method, success, failure_reason, new_device, created_at
+		FROM login_events
+		WHERE organization_id = $1
+	`
+	args := []any{orgID}
+
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		query += fmt.Sprintf(" AND user_id = $%d", len(args))
+	}
+	if filter.Success != nil {
+		args = append(args, *filter.Success)
+		query += fmt.Sprintf(" AND success = $%d", len(args))
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.LoginEvent
+	for rows.Next() {
+		var e domain.LoginEvent
+		if err := rows.Scan(
+			&e.ID, &e.OrganizationID, &e.UserID, &e.Email, &e.IPAddress, &e.UserAgent, &e.Method,
+			&e.Success, &e.FailureReason, &e.NewDevice, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
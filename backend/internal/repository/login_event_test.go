@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_LoginEventRepository_Create_DetectsNewDevice(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "user@example.com")
+
+	repo := NewLoginEventRepository(testDB.Pool)
+	login := func(ip, ua string) *domain.LoginEvent {
+		e := &domain.LoginEvent{
+			OrganizationID: org.ID,
+			UserID:         &user.ID,
+			Email:          user.Email,
+			IPAddress:      ip,
+			UserAgent:      ua,
+			Method:         domain.LoginMethodPassword,
+			Success:        true,
+		}
+		if err := repo.Create(ctx, e); err != nil {
+			t.Fatalf("failed to create login event: %v", err)
+		}
+		return e
+	}
+
+	if first := login("10.0.0.1", "Firefox"); first.NewDevice {
+		t.Error("expected first ever login not to be flagged as new device")
+	}
+	if same := login("10.0.0.1", "Firefox"); same.NewDevice {
+		t.Error("expected known device not to be flagged")
+	}
+	if other := login("10.0.0.2", "Firefox"); !other.NewDevice {
+		t.Error("expected login from new IP to be flagged")
+	}
+	if other := login("10.0.0.1", "Safari"); !other.NewDevice {
+		t.Error("expected login from new user agent to be flagged")
+	}
+}
+
+func Test_LoginEventRepository_List_Filters(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "user@example.com")
+
+	repo := NewLoginEventRepository(testDB.Pool)
+	reason := "invalid_password"
+	events := []*domain.LoginEvent{
+		{OrganizationID: org.ID, UserID: &user.ID, Email: user.Email, IPAddress: "10.0.0.1", Method: domain.LoginMethodPassword, Success: true},
+		{OrganizationID: org.ID, UserID: &user.ID, Email: user.Email, IPAddress: "10.0.0.1", Method: domain.LoginMethodPassword, FailureReason: &reason},
+		{OrganizationID: org.ID, Email: "nobody@example.com", IPAddress: "10.0.0.9", Method: domain.LoginMethodPassword},
+	}
+	for _, e := range events {
+		if err := repo.Create(ctx, e); err != nil {
+			t.Fatalf("failed to create login event: %v", err)
+		}
+	}
+
+	all, err := repo.List(ctx, org.ID, domain.LoginEventFilter{})
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected 3 events, got %d", len(all))
+	}
+
+	mine, _ := repo.List(ctx, org.ID, domain.LoginEventFilter{UserID: &user.ID})
+	if len(mine) != 2 {
+		t.Errorf("expected 2 events for user, got %d", len(mine))
+	}
+
+	failed := false
+	failures, _ := repo.List(ctx, org.ID, domain.LoginEventFilter{Success: &failed})
+	if len(failures) != 2 {
+		t.Errorf("expected 2 failed events, got %d", len(failures))
+	}
+
+	page, _ := repo.List(ctx, org.ID, domain.LoginEventFilter{Limit: 1, Offset: 1})
+	if len(page) != 1 {
+		t.Errorf("expected 1 event in page, got %d", len(page))
+	}
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type MaintenanceRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMaintenanceRepository(pool *pgxpool.Pool) *MaintenanceRepository {
+	return &MaintenanceRepository{pool: pool}
+}
+
+const maintenanceTaskColumns = `
+	t.id, t.organization_id, t.asset_id, t.name, t.notes, t.interval_count, t.interval_unit, t.due_at, t.last_done_at,
+	t.created_at, t.updated_at,
+	a.name, a.high_value
+`
+
+func scanMaintenanceTask(row pgx.Row) (*domain.MaintenanceTask, error) {
+	var t domain.MaintenanceTask
+	err := row.Scan(
+		&t.ID, &t.OrganizationID, &t.AssetID, &t.Name, &t.Notes, &t.IntervalCount, &t.IntervalUnit, &t.DueAt, &t.LastDoneAt,
+		&t.CreatedAt, &t.UpdatedAt,
+		&t.AssetName, &t.HighValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *MaintenanceRepository) GetTask(ctx context.Context, id uuid.UUID) (*domain.MaintenanceTask, error) {
+	query := `
+		SELECT ` + maintenanceTaskColumns + `
+		FROM maintenance_tasks t
+		JOIN assets a ON a.id = t.asset_id
+		WHERE t.id = $1 AND t.deleted_at IS NULL
+	`
+	t, err := scanMaintenanceTask(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return t, err
+}
+
+// ListTasks returns the organization's maintenance tasks, soonest due first
+func (r *MaintenanceRepository) ListTasks(ctx context.Context, orgID uuid.UUID) ([]domain.MaintenanceTask, error) {
+	query := `
+		SELECT ` + maintenanceTaskColumns + `
+		FROM maintenance_tasks t
+		JOIN assets a ON a.id = t.asset_id AND a.deleted_at IS NULL
+		WHERE t.organization_id = $1 AND t.deleted_at IS NULL
+		ORDER BY t.due_at NULLS LAST, t.name
+	`
+	return r.queryTasks(ctx, query, orgID)
+}
+
+// ListTasksByAsset returns the maintenance tasks of an asset, soonest due first
+func (r *MaintenanceRepository) ListTasksByAsset(ctx context.Context, assetID uuid.UUID) ([]domain.MaintenanceTask, error) {
+	query := `
+		SELECT ` + maintenanceTaskColumns + `
+		FROM maintenance_tasks t
+		JOIN assets a ON a.id = t.asset_id
+		WHERE t.asset_id = $1 AND t.deleted_at IS NULL
+		ORDER BY t.due_at NULLS LAST, t.name
+	`
+	return r.queryTasks(ctx, query, assetID)
+}
+
+// ListDueBefore returns the tasks due on or before the calendar date of
+// until, including overdue ones, of active assets
+func (r *MaintenanceRepository) ListDueBefore(ctx context.Context, orgID uuid.UUID, until time.Time) ([]domain.MaintenanceTask, error) {
+	query := `
+		SELECT ` + maintenanceTaskColumns + `
+		FROM maintenance_tasks t
+		JOIN assets a ON a.id = t.asset_id AND a.deleted_at IS NULL AND a.archived_at IS NULL
+		WHERE t.organization_id = $1 AND t.deleted_at IS NULL
+		  AND t.due_at <= $2
+		ORDER BY t.due_at, t.name
+	`
+	return r.queryTasks(ctx, query, orgID, calendarDate(until))
+}
+
+func (r *MaintenanceRepository) queryTasks(ctx context.Context, query string, args ...any) ([]domain.MaintenanceTask, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []domain.MaintenanceTask{}
+	for rows.Next() {
+		t, err := scanMaintenanceTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *t)
+	}
+	return tasks, rows.Err()
+}
+
+func (r *MaintenanceRepository) CreateTask(ctx context.Context, t *domain.MaintenanceTask) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO maintenance_tasks (id, organization_id, asset_id, name, notes, interval_count, interval_unit, due_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		t.ID, t.OrganizationID, t.AssetID, t.Name, t.Notes, t.IntervalCount, t.IntervalUnit, t.DueAt,
+	).Scan(&t.CreatedAt, &t.UpdatedAt)
+}
+
+func (r *MaintenanceRepository) UpdateTask(ctx context.Context, t *domain.MaintenanceTask) error {
+	query := `
+		UPDATE maintenance_tasks
+		SET name = $2, notes = $3, interval_count = $4, interval_unit = $5, due_at = $6
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		t.ID, t.Name, t.Notes, t.IntervalCount, t.IntervalUnit, t.DueAt,
+	).Scan(&t.UpdatedAt)
+}
+
+func (r *MaintenanceRepository) DeleteTask(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE maintenance_tasks SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// Complete logs that a task was done and moves it to its next due date (none
+// for one-off tasks)
+func (r *MaintenanceRepository) Complete(ctx context.Context, t *domain.MaintenanceTask, e *domain.MaintenanceLogEntry) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	if err := tx.QueryRow(ctx, insertMaintenanceLog, maintenanceLogArgs(e)...).Scan(&e.CreatedAt); err != nil {
+		return err
+	}
+	query := `
+		UPDATE maintenance_tasks
+		SET due_at = $2, last_done_at = GREATEST(last_done_at, $3)
+		WHERE id = $1
+		RETURNING last_done_at, updated_at
+	`
+	if err := tx.QueryRow(ctx, query, t.ID, t.DueAt, calendarDate(e.DoneAt)).Scan(&t.LastDoneAt, &t.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CreateLogEntry logs maintenance done outside a task
+func (r *MaintenanceRepository) CreateLogEntry(ctx context.Context, e *domain.MaintenanceLogEntry) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return r.pool.QueryRow(ctx, insertMaintenanceLog, maintenanceLogArgs(e)...).Scan(&e.CreatedAt)
+}
+
+const insertMaintenanceLog = `
+	INSERT INTO maintenance_log (id, organization_id, asset_id, task_id, name, done_at, notes, done_by)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING created_at
+`
+
+func maintenanceLogArgs(e *domain.MaintenanceLogEntry) []any {
+	return []any{e.ID, e.OrganizationID, e.AssetID, e.TaskID, e.Name, calendarDate(e.DoneAt), e.Notes, e.DoneBy}
+}
+
+// ListLog returns the maintenance done on an asset, latest first
+func (r *MaintenanceRepository) ListLog(ctx context.Context, assetID uuid.UUID) ([]domain.MaintenanceLogEntry, error) {
+	query := `
+		SELECT l.id, l.organization_id, l.asset_id, l.task_id, l.name, l.done_at, l.notes, l.done_by, l.created_at, u.email
+		FROM maintenance_log l
+		LEFT JOIN users u ON u.id = l.done_by
+		WHERE l.asset_id = $1
+		ORDER BY l.done_at DESC, l.created_at DESC
+	`
+	rows, err := r.pool.Query(ctx, query, assetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []domain.MaintenanceLogEntry{}
+	for rows.Next() {
+		var e domain.MaintenanceLogEntry
+		if err := rows.Scan(
+			&e.ID, &e.OrganizationID, &e.AssetID, &e.TaskID, &e.Name, &e.DoneAt, &e.Notes, &e.DoneBy, &e.CreatedAt, &e.DoneByEmail,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_MaintenanceRepository_CompleteAndList(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Kitchen", nil)
+	espresso, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Espresso machine")
+
+	repo := NewMaintenanceRepository(testDB.Pool)
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	three, month := 3, domain.MaintenanceMonth
+	soon, later := day.AddDate(0, 0, 5), day.AddDate(0, 2, 0)
+
+	descale := &domain.MaintenanceTask{OrganizationID: org.ID, AssetID: espresso.ID, Name: "Descale", IntervalCount: &three, IntervalUnit: &month, DueAt: &soon}
+	gasket := &domain.MaintenanceTask{OrganizationID: org.ID, AssetID: espresso.ID, Name: "Replace gasket", DueAt: &later}
+	for _, task := range []*domain.MaintenanceTask{descale, gasket} {
+		if err := repo.CreateTask(ctx, task); err != nil {
+			t.Fatalf("failed to create task: %v", err)
+		}
+	}
+
+	upcoming, err := repo.ListDueBefore(ctx, org.ID, day.AddDate(0, 0, 30))
+	if err != nil || len(upcoming) != 1 || upcoming[0].ID != descale.ID || upcoming[0].AssetName != "Espresso machine" {
+		t.Fatalf("expected only the descaling to be due within 30 days, got %+v, %v", upcoming, err)
+	}
+	if *upcoming[0].IntervalUnit != domain.MaintenanceMonth || *upcoming[0].IntervalCount != 3 {
+		t.Errorf("expected the interval to be read back, got %+v", upcoming[0])
+	}
+
+	next := descale.NextDue(day)
+	descale.DueAt = next
+	entry := &domain.MaintenanceLogEntry{OrganizationID: org.ID, AssetID: espresso.ID, TaskID: &descale.ID, Name: descale.Name, DoneAt: day}
+	if err := repo.Complete(ctx, descale, entry); err != nil {
+		t.Fatalf("failed to complete: %v", err)
+	}
+	got, _ := repo.GetTask(ctx, descale.ID)
+	if !got.DueAt.Equal(*next) || got.LastDoneAt == nil || !got.LastDoneAt.Equal(day) {
+		t.Errorf("expected the task to be due again on %v, got %+v", next, got)
+	}
+
+	repair := &domain.MaintenanceLogEntry{OrganizationID: org.ID, AssetID: espresso.ID, Name: "Fixed the steam wand", DoneAt: day.AddDate(0, 0, -7)}
+	if err := repo.CreateLogEntry(ctx, repair); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+	log, err := repo.ListLog(ctx, espresso.ID)
+	if err != nil || len(log) != 2 || log[0].ID != entry.ID || log[1].TaskID != nil {
+		t.Errorf("expected both entries, latest first, got %+v, %v", log, err)
+	}
+
+	if err := repo.DeleteTask(ctx, gasket.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if tasks, _ := repo.ListTasksByAsset(ctx, espresso.ID); len(tasks) != 1 || tasks[0].ID != descale.ID {
+		t.Errorf("expected only the descaling to remain, got %+v", tasks)
+	}
+}
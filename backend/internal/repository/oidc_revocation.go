@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OIDCRevocationRepository stores OIDC sessions ended through back-channel logout
+type OIDCRevocationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewOIDCRevocationRepository(pool *pgxpool.Pool) *OIDCRevocationRepository {
+	return &OIDCRevocationRepository{pool: pool}
+}
+
+// Revoke ends the provider session sid, or all sessions of subject started
+// before now when sid is empty
+func (r *OIDCRevocationRepository) Revoke(ctx context.Context, subject, sid string) error {
+	query := `INSERT INTO oidc_logout_revocations (subject, sid) VALUES (NULLIF($1, ''), NULLIF($2, ''))`
+	_, err := r.pool.Exec(ctx, query, subject, sid)
+	return err
+}
+
+// IsRevoked reports whether a session (provider session sid of subject, started at issuedAt) was revoked
+func (r *OIDCRevocationRepository) IsRevoked(ctx context.Context, subject, sid string, issuedAt time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM oidc_logout_revocations
+			WHERE ($2 <> '' AND sid = $2)
+			   OR (sid IS NULL AND subject = $1 AND revoked_at >= $3)
+		)
+	`
+	var revoked bool
+	err := r.pool.QueryRow(ctx, query, subject, sid, issuedAt).Scan(&revoked)
+	return revoked, err
+}
+
+// DeleteBefore removes revocations older than t (sessions that old have expired anyway)
+func (r *OIDCRevocationRepository) DeleteBefore(ctx context.Context, t time.Time) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM oidc_logout_revocations WHERE revoked_at < $1`, t)
+	return err
+}
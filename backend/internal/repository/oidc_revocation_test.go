@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_OIDCRevocationRepository_IsRevoked_BySessionID(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	repo := NewOIDCRevocationRepository(testDB.Pool)
+	if err := repo.Revoke(ctx, "user-1", "sid-1"); err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+
+	revoked, err := repo.IsRevoked(ctx, "user-1", "sid-1", time.Now())
+	if err != nil {
+		t.Fatalf("failed to check revocation: %v", err)
+	}
+	if !revoked {
+		t.Error("expected session sid-1 to be revoked")
+	}
+
+	revoked, _ = repo.IsRevoked(ctx, "user-1", "sid-2", time.Now().Add(-time.Hour))
+	if revoked {
+		t.Error("expected other sessions of the subject to stay valid")
+	}
+}
+
+func Test_OIDCRevocationRepository_IsRevoked_BySubject(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	repo := NewOIDCRevocationRepository(testDB.Pool)
+	if err := repo.Revoke(ctx, "user-1", ""); err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+
+	revoked, _ := repo.IsRevoked(ctx, "user-1", "sid-1", time.Now().Add(-time.Hour))
+	if !revoked {
+		t.Error("expected session started before the logout to be revoked")
+	}
+
+	revoked, _ = repo.IsRevoked(ctx, "user-1", "sid-2", time.Now().Add(time.Hour))
+	if revoked {
+		t.Error("expected session started after the logout to stay valid")
+	}
+
+	revoked, _ = repo.IsRevoked(ctx, "user-2", "", time.Now().Add(-time.Hour))
+	if revoked {
+		t.Error("expected other subjects to stay valid")
+	}
+}
+
+func Test_OIDCRevocationRepository_DeleteBefore_RemovesOldRevocations(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	repo := NewOIDCRevocationRepository(testDB.Pool)
+	if err := repo.Revoke(ctx, "user-1", "sid-1"); err != nil {
+		t.Fatalf("failed to revoke: %v", err)
+	}
+	if err := repo.DeleteBefore(ctx, time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+
+	revoked, _ := repo.IsRevoked(ctx, "user-1", "sid-1", time.Now())
+	if revoked {
+		t.Error("expected revocation to be pruned")
+	}
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -79,3 +80,265 @@ func (r *OrganizationRepository) Update(ctx context.Context, o *domain.Organizat
 	`
 	return r.pool.QueryRow(ctx, query, o.ID, o.Name, o.Description).Scan(&o.UpdatedAt)
 }
+
+// List returns all organizations, oldest (the default) first
+func (r *OrganizationRepository) List(ctx context.Context) ([]domain.Organization, error) {
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM organizations
+		WHERE deleted_at IS NULL
+		ORDER BY created_at
+	`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []domain.Organization{}
+	for rows.Next() {
+		var o domain.Organization
+		if err := rows.Scan(&o.ID, &o.Name, &o.Description, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, rows.Err()
+}
+
+// memberOrder puts the organization a user selected last first, or else the
+// one joined first
+const memberOrder = `m.selected_at DESC NULLS LAST, m.created_at`
+
+// MemberOrganization returns the organization a user selected last, or else
+// joined first, and the user's role in it ("" = the user's own role);
+// uuid.Nil if the user isn't a member of any
+func (r *OrganizationRepository) MemberOrganization(ctx context.Context, userID uuid.UUID) (uuid.UUID, domain.UserRole, error) {
+	query := `
+		SELECT m.organization_id, COALESCE(m.role, '')
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id AND o.deleted_at IS NULL
+		WHERE m.user_id = $1
+		ORDER BY ` + memberOrder + `
+		LIMIT 1
+	`
+	var orgID uuid.UUID
+	var role domain.UserRole
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&orgID, &role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, "", nil
+	}
+	return orgID, role, err
+}
+
+// MemberRole returns a user's role in an organization ("" = the user's own
+// role) and whether the user is a member
+func (r *OrganizationRepository) MemberRole(ctx context.Context, orgID, userID uuid.UUID) (domain.UserRole, bool, error) {
+	query := `
+		SELECT COALESCE(m.role, '')
+		FROM organization_members m
+		JOIN organizations o ON o.id = m.organization_id AND o.deleted_at IS NULL
+		WHERE m.organization_id = $1 AND m.user_id = $2
+	`
+	var role domain.UserRole
+	err := r.pool.QueryRow(ctx, query, orgID, userID).Scan(&role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	return role, err == nil, err
+}
+
+const membershipColumns = `
+	m.organization_id, o.name, m.user_id, u.email, u.display_name,
+	COALESCE(m.role, u.role), m.role IS NULL, u.organization_id = m.organization_id, m.created_at
+`
+
+const membershipFrom = `
+	FROM organization_members m
+	JOIN organizations o ON o.id = m.organization_id AND o.deleted_at IS NULL
+	JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+`
+
+func (r *OrganizationRepository) listMemberships(ctx context.Context, query string, args ...any) ([]domain.Membership, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []domain.Membership{}
+	for rows.Next() {
+		var m domain.Membership
+		if err := rows.Scan(
+			&m.OrganizationID, &m.OrganizationName, &m.UserID, &m.Email, &m.DisplayName,
+			&m.Role, &m.OwnRole, &m.Home, &m.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// ListMemberships returns the organizations of a user, the one used for
+// requests without an X-Org-ID header (Selected) first
+func (r *OrganizationRepository) ListMemberships(ctx context.Context, userID uuid.UUID) ([]domain.Membership, error) {
+	members, err := r.listMemberships(ctx, `SELECT `+membershipColumns+membershipFrom+`
+		WHERE m.user_id = $1
+		ORDER BY `+memberOrder, userID)
+	if err == nil && len(members) > 0 {
+		members[0].Selected = true
+	}
+	return members, err
+}
+
+// ListMembers returns the members of an organization by email
+func (r *OrganizationRepository) ListMembers(ctx context.Context, orgID uuid.UUID) ([]domain.Membership, error) {
+	return r.listMemberships(ctx, `SELECT `+membershipColumns+membershipFrom+`
+		WHERE m.organization_id = $1
+		ORDER BY u.email`, orgID)
+}
+
+// GetMember returns a member of an organization; nil if the user isn't one
+func (r *OrganizationRepository) GetMember(ctx context.Context, orgID, userID uuid.UUID) (*domain.Membership, error) {
+	members, err := r.listMemberships(ctx, `SELECT `+membershipColumns+membershipFrom+`
+		WHERE m.organization_id = $1 AND m.user_id = $2`, orgID, userID)
+	if err != nil || len(members) == 0 {
+		return nil, err
+	}
+	return &members[0], nil
+}
+
+// AddMember makes a user a member of an organization with a role; it reports
+// false if the user already is one
+func (r *OrganizationRepository) AddMember(ctx context.Context, orgID, userID uuid.UUID, role domain.UserRole) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO organization_members (organization_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`, orgID, userID, role)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// SetMemberRole changes the role of a member of an organization other than
+// the one that created the user, where the user has their own role; it
+// reports false if the user isn't such a member
+func (r *OrganizationRepository) SetMemberRole(ctx context.Context, orgID, userID uuid.UUID, role domain.UserRole) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE organization_members m SET role = $3
+		FROM users u
+		WHERE m.organization_id = $1 AND m.user_id = $2
+		  AND u.id = m.user_id AND u.organization_id <> m.organization_id
+	`, orgID, userID, role)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// RemoveMember removes a user from an organization other than the one that
+// created the user, clearing their ownership of its assets; it reports false
+// if the user isn't such a member
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		DELETE FROM organization_members m
+		USING users u
+		WHERE m.organization_id = $1 AND m.user_id = $2
+		  AND u.id = m.user_id AND u.organization_id <> m.organization_id
+	`, orgID, userID)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() != 1 {
+		return false, nil
+	}
+	// Their items in the organization no longer belong to anyone
+	if _, err := tx.Exec(ctx, `UPDATE assets SET owner_id = NULL, updated_at = NOW() WHERE organization_id = $1 AND owner_id = $2`, orgID, userID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit(ctx)
+}
+
+// SelectOrganization makes an organization the one used for a member's
+// requests without an X-Org-ID header; it reports false if the user isn't a
+// member
+func (r *OrganizationRepository) SelectOrganization(ctx context.Context, orgID, userID uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE organization_members SET selected_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2
+		  AND EXISTS (SELECT 1 FROM organizations o WHERE o.id = $1 AND o.deleted_at IS NULL)
+	`, orgID, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// Resource names a kind of record owned by an organization
+type Resource string
+
+const (
+	ResourceAsset       Resource = "asset"
+	ResourceAttachment  Resource = "attachment"
+	ResourceAttribute   Resource = "attribute"
+	ResourceBundle      Resource = "bundle"
+	ResourceCategory    Resource = "category"
+	ResourceCondition   Resource = "condition"
+	ResourceContact     Resource = "contact"
+	ResourceCost        Resource = "cost"
+	ResourceLabelBatch  Resource = "label_batch"
+	ResourceList        Resource = "list"
+	ResourceLoan        Resource = "loan"
+	ResourceLocation    Resource = "location"
+	ResourceMaintenance Resource = "maintenance"
+	ResourcePurchase    Resource = "purchase"
+	ResourceReminder    Resource = "reminder"
+	ResourceReport      Resource = "report"
+	ResourceReservation Resource = "reservation"
+	ResourceTag         Resource = "tag"
+	ResourceUser        Resource = "user"
+)
+
+// ownerQueries look up the organization of a record by its ID
+var ownerQueries = map[Resource]string{
+	ResourceAsset:       `SELECT organization_id FROM assets WHERE id = $1`,
+	ResourceAttachment:  `SELECT a.organization_id FROM attachments t JOIN assets a ON a.id = t.asset_id WHERE t.id = $1`,
+	ResourceAttribute:   `SELECT organization_id FROM attributes WHERE id = $1`,
+	ResourceBundle:      `SELECT organization_id FROM purchase_groups WHERE id = $1`,
+	ResourceCategory:    `SELECT organization_id FROM categories WHERE id = $1`,
+	ResourceCondition:   `SELECT organization_id FROM conditions WHERE id = $1`,
+	ResourceContact:     `SELECT organization_id FROM contacts WHERE id = $1`,
+	ResourceCost:        `SELECT organization_id FROM recurring_costs WHERE id = $1`,
+	ResourceLabelBatch:  `SELECT organization_id FROM label_batches WHERE id = $1`,
+	ResourceList:        `SELECT organization_id FROM asset_lists WHERE id = $1`,
+	ResourceLoan:        `SELECT organization_id FROM loans WHERE id = $1`,
+	ResourceLocation:    `SELECT organization_id FROM locations WHERE id = $1`,
+	ResourceMaintenance: `SELECT organization_id FROM maintenance_tasks WHERE id = $1`,
+	ResourcePurchase:    `SELECT organization_id FROM purchases WHERE id = $1`,
+	ResourceReminder:    `SELECT organization_id FROM reminders WHERE id = $1`,
+	ResourceReport:      `SELECT organization_id FROM report_schedules WHERE id = $1`,
+	ResourceReservation: `SELECT organization_id FROM asset_reservations WHERE id = $1`,
+	ResourceTag:         `SELECT organization_id FROM tags WHERE id = $1`,
+	ResourceUser:        `SELECT organization_id FROM users WHERE id = $1`, // Accounts are managed by the organization that created them
+}
+
+// OwnedBy reports whether the record of a resource belongs to an
+// organization; records that don't exist belong to none
+func (r *OrganizationRepository) OwnedBy(ctx context.Context, orgID uuid.UUID, res Resource, id uuid.UUID) (bool, error) {
+	query, ok := ownerQueries[res]
+	if !ok {
+		return false, fmt.Errorf("unknown resource %q", res)
+	}
+	var owned bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM (`+query+`) o WHERE o.organization_id = $2)`, id, orgID).Scan(&owned)
+	return owned, err
+}
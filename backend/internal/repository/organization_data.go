@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// OrganizationTable holds the rows of one table belonging to an organization
+// as a JSON array
+type OrganizationTable struct {
+	Name string
+	Rows json.RawMessage
+}
+
+// organizationTables selects everything stored for an organization ($1), by
+// table. Credentials (password hashes, sessions, passkeys, TOTP secrets and
+// service tokens) are left out, as is internal sync bookkeeping.
+var organizationTables = []struct {
+	name  string
+	query string
+	omit  []string // Columns left out of the rows
+}{
+	{"organization", `SELECT * FROM organizations WHERE id = $1`, nil},
+	{"organization_settings", `SELECT * FROM organization_settings WHERE organization_id = $1`, nil},
+	{"roles", `SELECT * FROM roles WHERE organization_id = $1`, nil},
+	{"users", `SELECT * FROM users WHERE organization_id = $1`, []string{"password_hash"}},
+	{"user_preferences", `SELECT p.* FROM user_preferences p JOIN users u ON u.id = p.user_id WHERE u.organization_id = $1`, nil},
+	{"organization_members", `SELECT * FROM organization_members WHERE organization_id = $1`, nil},
+	{"conditions", `SELECT * FROM conditions WHERE organization_id = $1`, nil},
+	{"categories", `SELECT * FROM categories WHERE organization_id = $1`, nil},
+	{"attributes", `SELECT * FROM attributes WHERE organization_id = $1`, nil},
+	{"category_attributes", `SELECT ca.* FROM category_attributes ca JOIN categories c ON c.id = ca.category_id WHERE c.organization_id = $1`, nil},
+	{"locations", `SELECT * FROM locations WHERE organization_id = $1`, nil},
+	{"tags", `SELECT * FROM tags WHERE organization_id = $1`, nil},
+	{"assets", `SELECT * FROM assets WHERE organization_id = $1`, nil},
+	{"asset_events", `SELECT * FROM asset_events WHERE organization_id = $1`, nil},
+	{"asset_tags", `SELECT t.* FROM asset_tags t JOIN assets a ON a.id = t.asset_id WHERE a.organization_id = $1`, nil},
+	{"asset_sources", `SELECT s.* FROM asset_sources s JOIN assets a ON a.id = s.asset_id WHERE a.organization_id = $1`, nil},
+	{"asset_power_usage", `SELECT p.* FROM asset_power_usage p JOIN assets a ON a.id = p.asset_id WHERE a.organization_id = $1`, nil},
+	{"asset_comments", `SELECT c.* FROM asset_comments c JOIN assets a ON a.id = c.asset_id WHERE a.organization_id = $1`, nil},
+	{"warranties", `SELECT w.* FROM warranties w JOIN assets a ON a.id = w.asset_id WHERE a.organization_id = $1`, nil},
+	{"attachments", `SELECT att.* FROM attachments att JOIN assets a ON a.id = att.asset_id WHERE a.organization_id = $1`, nil},
+	{"attachment_downloads", `SELECT d.* FROM attachment_downloads d JOIN attachments att ON att.id = d.attachment_id JOIN assets a ON a.id = att.asset_id WHERE a.organization_id = $1`, nil},
+	{"asset_values", `SELECT * FROM asset_values WHERE organization_id = $1`, nil},
+	{"consumption_schedules", `SELECT * FROM consumption_schedules WHERE organization_id = $1`, nil},
+	{"depreciation_rules", `SELECT * FROM depreciation_rules WHERE organization_id = $1`, nil},
+	{"recurring_costs", `SELECT * FROM recurring_costs WHERE organization_id = $1`, nil},
+	{"asset_reservations", `SELECT * FROM asset_reservations WHERE organization_id = $1`, nil},
+	{"maintenance_tasks", `SELECT * FROM maintenance_tasks WHERE organization_id = $1`, nil},
+	{"maintenance_log", `SELECT * FROM maintenance_log WHERE organization_id = $1`, nil},
+	{"reminders", `SELECT * FROM reminders WHERE organization_id = $1`, nil},
+	{"purchases", `SELECT * FROM purchases WHERE organization_id = $1`, nil},
+	{"purchase_groups", `SELECT * FROM purchase_groups WHERE organization_id = $1`, nil},
+	{"contacts", `SELECT * FROM contacts WHERE organization_id = $1`, nil},
+	{"loans", `SELECT * FROM loans WHERE organization_id = $1`, nil},
+	{"label_batches", `SELECT * FROM label_batches WHERE organization_id = $1`, []string{"pdf"}},
+	{"asset_lists", `SELECT * FROM asset_lists WHERE organization_id = $1`, nil},
+	{"asset_list_items", `SELECT i.* FROM asset_list_items i JOIN asset_lists l ON l.id = i.list_id WHERE l.organization_id = $1`, nil},
+	{"report_schedules", `SELECT * FROM report_schedules WHERE organization_id = $1`, nil},
+	{"report_runs", `SELECT r.* FROM report_runs r JOIN report_schedules s ON s.id = r.schedule_id WHERE s.organization_id = $1`, nil},
+	{"imports", `SELECT * FROM imports WHERE organization_id = $1`, nil},
+	{"short_links", `SELECT * FROM short_links WHERE organization_id = $1`, nil},
+	{"login_events", `SELECT * FROM login_events WHERE organization_id = $1`, nil},
+	{"auth_events", `SELECT * FROM auth_events WHERE organization_id = $1`, nil},
+}
+
+// ExportData returns everything stored for an organization, including
+// soft-deleted records, one JSON array per table
+func (r *OrganizationRepository) ExportData(ctx context.Context, orgID uuid.UUID) ([]OrganizationTable, error) {
+	tables := make([]OrganizationTable, 0, len(organizationTables))
+	for _, t := range organizationTables {
+		omit := t.omit
+		if omit == nil {
+			omit = []string{}
+		}
+		query := `SELECT COALESCE(json_agg(to_jsonb(t) - $2::text[]), '[]'::json) FROM (` + t.query + `) t`
+		var rows json.RawMessage
+		if err := r.pool.QueryRow(ctx, query, orgID, omit).Scan(&rows); err != nil {
+			return nil, fmt.Errorf("exporting %s: %w", t.name, err)
+		}
+		tables = append(tables, OrganizationTable{Name: t.name, Rows: rows})
+	}
+	return tables, nil
+}
+
+// FileKeys returns the storage keys of an organization's attachments and
+// report files, including those of soft-deleted records
+func (r *OrganizationRepository) FileKeys(ctx context.Context, orgID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT att.file_key FROM attachments att
+		JOIN assets a ON a.id = att.asset_id
+		WHERE a.organization_id = $1
+		UNION ALL
+		SELECT r.file_key FROM report_runs r
+		JOIN report_schedules s ON s.id = r.schedule_id
+		WHERE s.organization_id = $1 AND r.file_key IS NOT NULL
+	`
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// DeleteData permanently deletes an organization with everything stored for
+// it, including its users, in one transaction. Stored files are left to the
+// caller, see FileKeys. References from other organizations to its users,
+// e.g. as uploader, are cleared.
+func (r *OrganizationRepository) DeleteData(ctx context.Context, orgID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// In dependency order; the remaining tables cascade from assets,
+	// categories, users or the organization itself
+	statements := []string{
+		`UPDATE assets SET collection_id = NULL, main_attachment_id = NULL WHERE organization_id = $1`,
+		`DELETE FROM report_schedules WHERE organization_id = $1`,
+		`DELETE FROM recurring_costs WHERE organization_id = $1`,
+		`DELETE FROM asset_reservations WHERE organization_id = $1`,
+		`DELETE FROM maintenance_log WHERE organization_id = $1`,
+		`DELETE FROM maintenance_tasks WHERE organization_id = $1`,
+		`DELETE FROM reminders WHERE organization_id = $1`,
+		`DELETE FROM purchases WHERE organization_id = $1`,
+		`DELETE FROM loans WHERE organization_id = $1`,
+		`DELETE FROM label_batches WHERE organization_id = $1`,
+		`DELETE FROM contacts WHERE organization_id = $1`,
+		`DELETE FROM asset_lists WHERE organization_id = $1`,
+		`DELETE FROM assets WHERE organization_id = $1`,
+		`DELETE FROM tags WHERE organization_id = $1`,
+		`UPDATE categories SET parent_id = NULL WHERE organization_id = $1`,
+		`DELETE FROM categories WHERE organization_id = $1`,
+		`DELETE FROM attributes WHERE organization_id = $1`,
+		`UPDATE locations SET parent_id = NULL WHERE organization_id = $1`,
+		`DELETE FROM locations WHERE organization_id = $1`,
+		`DELETE FROM conditions WHERE organization_id = $1`,
+		`UPDATE attachments SET uploaded_by = NULL WHERE uploaded_by IN (SELECT id FROM users WHERE organization_id = $1)`,
+		`DELETE FROM users WHERE organization_id = $1`,
+		`DELETE FROM organizations WHERE id = $1`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(ctx, stmt, orgID); err != nil {
+			return fmt.Errorf("deleting organization data: %w", err)
+		}
+	}
+	return tx.Commit(ctx)
+}
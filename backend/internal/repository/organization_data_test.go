@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_OrganizationRepository_ExportAndDeleteData(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	home, _ := fixtures.CreateOrganization(ctx, "Home")
+	cabin, _ := fixtures.CreateOrganization(ctx, "Cabin")
+	user, _ := fixtures.CreateUser(ctx, cabin.ID, "cabin@example.com")
+	parent, _ := fixtures.CreateCategory(ctx, cabin.ID, "Tools", nil)
+	cat, _ := fixtures.CreateCategory(ctx, cabin.ID, "Saws", &parent.ID)
+	asset, _ := fixtures.CreateAsset(ctx, cabin.ID, cat.ID, "Chainsaw")
+	fixtures.CreateAttachment(ctx, asset.ID, "manual.pdf", "cabin/manual.pdf")
+	tagID, _ := fixtures.CreateTag(ctx, cabin.ID, "garden")
+	fixtures.AddTagToAsset(ctx, asset.ID, tagID)
+
+	homeCat, _ := fixtures.CreateCategory(ctx, home.ID, "Books", nil)
+	homeAsset, _ := fixtures.CreateAsset(ctx, home.ID, homeCat.ID, "Atlas")
+	homeAttachment, _ := fixtures.CreateAttachment(ctx, homeAsset.ID, "scan.pdf", "home/scan.pdf")
+	// Uploaded by a member of the deleted organization
+	if _, err := testDB.Pool.Exec(ctx, `UPDATE attachments SET uploaded_by = $1 WHERE id = $2`, user.ID, homeAttachment.ID); err != nil {
+		t.Fatalf("failed to set uploader: %v", err)
+	}
+
+	repo := NewOrganizationRepository(testDB.Pool)
+	tables, err := repo.ExportData(ctx, cabin.ID)
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	rows := map[string][]map[string]any{}
+	for _, table := range tables {
+		var r []map[string]any
+		if err := json.Unmarshal(table.Rows, &r); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", table.Name, err)
+		}
+		rows[table.Name] = r
+	}
+	for table, want := range map[string]int{"organization": 1, "users": 1, "categories": 2, "assets": 1, "attachments": 1, "asset_tags": 1, "warranties": 0} {
+		if got := len(rows[table]); got != want {
+			t.Errorf("%s: expected %d rows, got %d", table, want, got)
+		}
+	}
+	if _, ok := rows["users"][0]["password_hash"]; ok {
+		t.Error("expected password hashes to be left out")
+	}
+
+	keys, err := repo.FileKeys(ctx, cabin.ID)
+	if err != nil || len(keys) != 1 || keys[0] != "cabin/manual.pdf" {
+		t.Errorf("expected the attachment key, got %v, %v", keys, err)
+	}
+
+	if err := repo.DeleteData(ctx, cabin.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	var remaining int
+	testDB.Pool.QueryRow(ctx, `
+		SELECT (SELECT COUNT(*) FROM organizations WHERE id = $1)
+		     + (SELECT COUNT(*) FROM users WHERE organization_id = $1)
+		     + (SELECT COUNT(*) FROM categories WHERE organization_id = $1)
+		     + (SELECT COUNT(*) FROM assets WHERE organization_id = $1)
+		     + (SELECT COUNT(*) FROM tags WHERE organization_id = $1)
+	`, cabin.ID).Scan(&remaining)
+	if remaining != 0 {
+		t.Errorf("expected no rows of the deleted organization, got %d", remaining)
+	}
+
+	if keys, _ := repo.FileKeys(ctx, home.ID); len(keys) != 1 || !strings.HasPrefix(keys[0], "home/") {
+		t.Errorf("expected the other organization's files to stay, got %v", keys)
+	}
+	var uploadedBy *string
+	testDB.Pool.QueryRow(ctx, `SELECT uploaded_by::text FROM attachments WHERE id = $1`, homeAttachment.ID).Scan(&uploadedBy)
+	if uploadedBy != nil {
+		t.Errorf("expected the uploader reference to be cleared, got %s", *uploadedBy)
+	}
+}
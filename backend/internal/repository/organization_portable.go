@@ -0,0 +1,405 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/lmmendes/attic/internal/portable"
+)
+
+// PortableFile is an exported file with its storage key
+type PortableFile struct {
+	portable.File
+	FileKey string `json:"file_key"`
+}
+
+// ExportPortable returns an organization's inventory in the open export
+// format, without deleted records, and its files with their storage keys.
+// Paths and checksums of the files are left to portable.Write.
+func (r *OrganizationRepository) ExportPortable(ctx context.Context, orgID uuid.UUID) (*portable.Export, []PortableFile, error) {
+	var org portable.Organization
+	err := r.pool.QueryRow(ctx, `SELECT name, description FROM organizations WHERE id = $1`, orgID).Scan(&org.Name, &org.Description)
+	if err != nil {
+		return nil, nil, err
+	}
+	e := portable.New(org)
+	var files []PortableFile
+
+	// Each query selects the records of a section ($1 = organization) with
+	// columns named like the fields of the format
+	sections := []struct {
+		name  string
+		query string
+		dest  any
+	}{
+		{"users", `
+			SELECT u.id, u.email, u.display_name AS name, COALESCE(m.role, u.role) AS role
+			FROM organization_members m
+			JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+			WHERE m.organization_id = $1 AND NOT u.service_account
+			ORDER BY u.email`, &e.Users},
+		{"conditions", `
+			SELECT id, code, label, description, sort_order
+			FROM conditions WHERE organization_id = $1 AND deleted_at IS NULL
+			ORDER BY sort_order, code`, &e.Conditions},
+		{"attributes", `
+			SELECT id, name, key, data_type
+			FROM attributes WHERE organization_id = $1 AND deleted_at IS NULL
+			ORDER BY key`, &e.Attributes},
+		// Deleted categories are kept while assets still use them
+		{"categories", `
+			SELECT c.id, c.parent_id, c.name, c.description, c.icon,
+			       COALESCE((
+			           SELECT json_agg(json_build_object('attribute_id', ca.attribute_id, 'required', ca.required, 'sort_order', ca.sort_order) ORDER BY ca.sort_order)
+			           FROM category_attributes ca WHERE ca.category_id = c.id
+			       ), '[]') AS attributes
+			FROM categories c
+			WHERE c.organization_id = $1
+			  AND (c.deleted_at IS NULL OR EXISTS (SELECT 1 FROM assets a WHERE a.category_id = c.id AND a.deleted_at IS NULL))
+			ORDER BY c.name`, &e.Categories},
+		{"locations", `
+			SELECT id, parent_id, name, description, icon
+			FROM locations WHERE organization_id = $1 AND deleted_at IS NULL
+			ORDER BY name`, &e.Locations},
+		{"tags", `SELECT id, name FROM tags WHERE organization_id = $1 ORDER BY name`, &e.Tags},
+		{"assets", `
+			SELECT a.id, a.category_id, a.location_id, a.condition_id, a.collection_id, a.owner_id,
+			       a.main_attachment_id AS main_file_id, a.name, a.description, a.quantity, a.attributes,
+			       a.purchase_at, a.purchase_price, a.currency, a.purchase_note, a.notes, a.high_value, a.archived_at, a.status,
+			       COALESCE((SELECT json_agg(at.tag_id) FROM asset_tags at WHERE at.asset_id = a.id), '[]') AS tag_ids,
+			       (SELECT json_build_object('provider', w.provider, 'start_date', w.start_date, 'end_date', w.end_date, 'notes', w.notes)
+			        FROM warranties w WHERE w.asset_id = a.id) AS warranty,
+			       a.created_at, a.updated_at
+			FROM assets a
+			WHERE a.organization_id = $1 AND a.deleted_at IS NULL
+			ORDER BY a.created_at, a.id`, &e.Assets},
+		{"files", `
+			SELECT att.id, att.asset_id, att.file_name, att.content_type, att.file_size AS size,
+			       att.description, att.uploaded_by, att.created_at, att.file_key
+			FROM attachments att
+			JOIN assets a ON a.id = att.asset_id AND a.deleted_at IS NULL
+			WHERE a.organization_id = $1
+			ORDER BY att.created_at, att.id`, &files},
+	}
+	for _, s := range sections {
+		query := `SELECT COALESCE(json_agg(t), '[]'::json) FROM (` + s.query + `) t`
+		var rows json.RawMessage
+		if err := r.pool.QueryRow(ctx, query, orgID).Scan(&rows); err != nil {
+			return nil, nil, fmt.Errorf("exporting %s: %w", s.name, err)
+		}
+		if err := json.Unmarshal(rows, s.dest); err != nil {
+			return nil, nil, fmt.Errorf("exporting %s: %w", s.name, err)
+		}
+	}
+
+	for _, f := range files {
+		e.Files = append(e.Files, f.File)
+	}
+	e.Prune()
+	return e, files, nil
+}
+
+// ImportPortable imports an export into an organization in one transaction.
+// The export's assets are created with their IDs, so callers renew them
+// first (see portable.Export.RenewAssetIDs); all other records get new IDs.
+// Conditions, attributes and tags with the code, key or name of an existing
+// one, and categories and locations with the name and parent of an existing
+// one, are matched to it instead. Users are matched to members by email.
+// fileKeys holds the storage keys of the uploaded files by file ID; files
+// without one are left out.
+func (r *OrganizationRepository) ImportPortable(ctx context.Context, orgID uuid.UUID, e *portable.Export, fileKeys map[uuid.UUID]string) (*portable.ImportResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	result := &portable.ImportResult{UnmatchedUsers: []string{}}
+
+	users := map[uuid.UUID]uuid.UUID{}
+	for _, u := range e.Users {
+		var id uuid.UUID
+		err := tx.QueryRow(ctx, `
+			SELECT u.id FROM organization_members m
+			JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+			WHERE m.organization_id = $1 AND LOWER(u.email) = LOWER($2)
+		`, orgID, u.Email).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			result.UnmatchedUsers = append(result.UnmatchedUsers, u.Email)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("matching users: %w", err)
+		}
+		users[u.ID] = id
+	}
+
+	// Conditions, attributes and tags are unique by code, key or name; a
+	// conflicting record is used instead, and restored if it was deleted.
+	// xmax is 0 for inserted rows only.
+	conditions := map[uuid.UUID]uuid.UUID{}
+	for _, c := range e.Conditions {
+		err := upsertPortable(ctx, tx, conditions, c.ID, &result.Conditions, `
+			INSERT INTO conditions (organization_id, code, label, description, sort_order)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (organization_id, code) DO UPDATE SET deleted_at = NULL
+			RETURNING id, xmax = 0
+		`, orgID, c.Code, c.Label, c.Description, c.SortOrder)
+		if err != nil {
+			return nil, fmt.Errorf("importing condition %s: %w", c.Code, err)
+		}
+	}
+	attributes := map[uuid.UUID]uuid.UUID{}
+	for _, a := range e.Attributes {
+		err := upsertPortable(ctx, tx, attributes, a.ID, &result.Attributes, `
+			INSERT INTO attributes (organization_id, name, key, data_type)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (organization_id, key) DO UPDATE SET deleted_at = NULL
+			RETURNING id, xmax = 0
+		`, orgID, a.Name, a.Key, a.DataType)
+		if err != nil {
+			return nil, fmt.Errorf("importing attribute %s: %w", a.Key, err)
+		}
+	}
+	tags := map[uuid.UUID]uuid.UUID{}
+	for _, t := range e.Tags {
+		err := upsertPortable(ctx, tx, tags, t.ID, &result.Tags, `
+			INSERT INTO tags (organization_id, name)
+			VALUES ($1, $2)
+			ON CONFLICT (organization_id, name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id, xmax = 0
+		`, orgID, t.Name)
+		if err != nil {
+			return nil, fmt.Errorf("importing tag %s: %w", t.Name, err)
+		}
+	}
+
+	categories := map[uuid.UUID]uuid.UUID{}
+	for _, c := range parentsFirst(e.Categories, func(c portable.Category) (uuid.UUID, *uuid.UUID) { return c.ID, c.ParentID }) {
+		parentID := mappedID(categories, c.ParentID)
+		err := matchOrInsertPortable(ctx, tx, categories, c.ID, &result.Categories, `
+			SELECT id FROM categories
+			WHERE organization_id = $1 AND deleted_at IS NULL AND LOWER(name) = LOWER($2) AND parent_id IS NOT DISTINCT FROM $3
+			ORDER BY created_at LIMIT 1
+		`, `
+			INSERT INTO categories (organization_id, name, parent_id, description, icon)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, orgID, c.Name, parentID, c.Description, c.Icon)
+		if err != nil {
+			return nil, fmt.Errorf("importing category %s: %w", c.Name, err)
+		}
+		for _, ca := range c.Attributes {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO category_attributes (category_id, attribute_id, required, sort_order)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (category_id, attribute_id) DO NOTHING
+			`, categories[c.ID], attributes[ca.AttributeID], ca.Required, ca.SortOrder)
+			if err != nil {
+				return nil, fmt.Errorf("importing attributes of category %s: %w", c.Name, err)
+			}
+		}
+	}
+	locations := map[uuid.UUID]uuid.UUID{}
+	for _, l := range parentsFirst(e.Locations, func(l portable.Location) (uuid.UUID, *uuid.UUID) { return l.ID, l.ParentID }) {
+		parentID := mappedID(locations, l.ParentID)
+		err := matchOrInsertPortable(ctx, tx, locations, l.ID, &result.Locations, `
+			SELECT id FROM locations
+			WHERE organization_id = $1 AND deleted_at IS NULL AND LOWER(name) = LOWER($2) AND parent_id IS NOT DISTINCT FROM $3
+			ORDER BY created_at LIMIT 1
+		`, `
+			INSERT INTO locations (organization_id, name, parent_id, description, icon)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, orgID, l.Name, parentID, l.Description, l.Icon)
+		if err != nil {
+			return nil, fmt.Errorf("importing location %s: %w", l.Name, err)
+		}
+	}
+
+	// Collections and main files refer to records created later, so they're
+	// set once everything is in
+	for _, a := range e.Assets {
+		attrs := a.Attributes
+		if len(attrs) == 0 || string(attrs) == "null" {
+			attrs = json.RawMessage("{}")
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO assets (id, organization_id, category_id, location_id, condition_id, owner_id,
+			                    name, description, quantity, attributes, purchase_at, purchase_price, currency,
+			                    purchase_note, notes, high_value, archived_at, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, COALESCE(NULLIF($18, ''), 'owned'), $19)
+		`, a.ID, orgID, categories[a.CategoryID], mappedID(locations, a.LocationID), mappedID(conditions, a.ConditionID), mappedID(users, a.OwnerID),
+			a.Name, a.Description, max(a.Quantity, 1), attrs, portableDate(a.PurchaseAt), a.PurchasePrice, a.Currency,
+			a.PurchaseNote, a.Notes, a.HighValue, a.ArchivedAt, a.Status, portableTime(a.CreatedAt))
+		if err != nil {
+			return nil, fmt.Errorf("importing asset %s: %w", a.Name, err)
+		}
+		for _, tagID := range a.TagIDs {
+			if _, err := tx.Exec(ctx, `INSERT INTO asset_tags (asset_id, tag_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, a.ID, tags[tagID]); err != nil {
+				return nil, fmt.Errorf("importing tags of asset %s: %w", a.Name, err)
+			}
+		}
+		if w := a.Warranty; w != nil {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO warranties (asset_id, provider, start_date, end_date, notes)
+				VALUES ($1, $2, $3, $4, $5)
+			`, a.ID, w.Provider, portableDate(w.StartDate), portableDate(w.EndDate), w.Notes)
+			if err != nil {
+				return nil, fmt.Errorf("importing warranty of asset %s: %w", a.Name, err)
+			}
+		}
+		result.Assets++
+	}
+
+	files := map[uuid.UUID]uuid.UUID{}
+	for _, f := range e.Files {
+		key, ok := fileKeys[f.ID]
+		if !ok {
+			continue
+		}
+		id := uuid.New()
+		_, err := tx.Exec(ctx, `
+			INSERT INTO attachments (id, asset_id, uploaded_by, file_key, file_name, file_size, content_type, description, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, id, f.AssetID, mappedID(users, f.UploadedBy), key, f.FileName, f.Size, f.ContentType, f.Description, portableTime(f.CreatedAt))
+		if err != nil {
+			return nil, fmt.Errorf("importing file %s: %w", f.FileName, err)
+		}
+		files[f.ID] = id
+		result.Files++
+	}
+
+	for _, a := range e.Assets {
+		mainID := mappedID(files, a.MainFileID)
+		if a.CollectionID == nil && mainID == nil {
+			continue
+		}
+		_, err := tx.Exec(ctx, `UPDATE assets SET collection_id = $2, main_attachment_id = $3 WHERE id = $1`, a.ID, a.CollectionID, mainID)
+		if err != nil {
+			return nil, fmt.Errorf("importing asset %s: %w", a.Name, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ExistingAssetIDs returns which of ids are taken by assets, deleted ones
+// included, e.g. by an earlier import with portable.Export.DeriveAssetIDs
+func (r *OrganizationRepository) ExistingAssetIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	rows, err := r.pool.Query(ctx, `SELECT id FROM assets WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := map[uuid.UUID]bool{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		existing[id] = true
+	}
+	return existing, rows.Err()
+}
+
+// upsertPortable runs an insert returning the ID of the new or conflicting
+// record and whether it was inserted, mapping the exported ID to it
+func upsertPortable(ctx context.Context, tx pgx.Tx, ids map[uuid.UUID]uuid.UUID, exportedID uuid.UUID, count *portable.ImportCount, query string, args ...any) error {
+	var id uuid.UUID
+	var inserted bool
+	if err := tx.QueryRow(ctx, query, args...).Scan(&id, &inserted); err != nil {
+		return err
+	}
+	ids[exportedID] = id
+	if inserted {
+		count.Created++
+	} else {
+		count.Matched++
+	}
+	return nil
+}
+
+// matchOrInsertPortable maps the exported ID to the record found by match,
+// or else to the one created by insert; both take the same arguments
+func matchOrInsertPortable(ctx context.Context, tx pgx.Tx, ids map[uuid.UUID]uuid.UUID, exportedID uuid.UUID, count *portable.ImportCount, match, insert string, args ...any) error {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, match, args[:3]...).Scan(&id)
+	switch {
+	case err == nil:
+		count.Matched++
+	case errors.Is(err, pgx.ErrNoRows):
+		if err := tx.QueryRow(ctx, insert, args...).Scan(&id); err != nil {
+			return err
+		}
+		count.Created++
+	default:
+		return err
+	}
+	ids[exportedID] = id
+	return nil
+}
+
+// parentsFirst orders records so that every parent comes before its
+// children. The parents must be acyclic, as checked by Validate.
+func parentsFirst[T any](records []T, parent func(T) (uuid.UUID, *uuid.UUID)) []T {
+	done := make(map[uuid.UUID]bool, len(records))
+	ordered := make([]T, 0, len(records))
+	for len(ordered) < len(records) {
+		progress := false
+		for _, rec := range records {
+			id, p := parent(rec)
+			if done[id] || (p != nil && !done[*p]) {
+				continue
+			}
+			done[id] = true
+			ordered = append(ordered, rec)
+			progress = true
+		}
+		if !progress {
+			break
+		}
+	}
+	return ordered
+}
+
+// mappedID returns the imported ID of an exported one; nil if id is nil or
+// wasn't imported
+func mappedID(ids map[uuid.UUID]uuid.UUID, id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	if mapped, ok := ids[*id]; ok {
+		return &mapped
+	}
+	return nil
+}
+
+// portableDate parses a validated YYYY-MM-DD date of an export
+func portableDate(s *string) *time.Time {
+	if s == nil {
+		return nil
+	}
+	t, err := time.Parse(time.DateOnly, *s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// portableTime returns t, or now if the export didn't set it
+func portableTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Now()
+	}
+	return t
+}
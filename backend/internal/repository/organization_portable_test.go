@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/portable"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_OrganizationRepository_PortableRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	source, _ := fixtures.CreateOrganization(ctx, "Source")
+	target, _ := fixtures.CreateOrganization(ctx, "Target")
+	owner, _ := fixtures.CreateUser(ctx, source.ID, "owner@example.com")
+	fixtures.CreateUser(ctx, source.ID, "stays@example.com")
+	electronics, _ := fixtures.CreateCategory(ctx, source.ID, "Electronics", nil)
+	cameras, _ := fixtures.CreateCategory(ctx, source.ID, "Cameras", &electronics.ID)
+	garage, _ := fixtures.CreateLocation(ctx, source.ID, "Garage", nil)
+	tagID, _ := fixtures.CreateTag(ctx, source.ID, "insured")
+	camera := &domain.Asset{
+		OrganizationID: source.ID, CategoryID: cameras.ID, LocationID: &garage.ID, OwnerID: &owner.ID,
+		Name: "Camera", Quantity: 1, Attributes: []byte(`{"serial":"A1"}`),
+	}
+	if err := fixtures.CreateAssetFull(ctx, camera); err != nil {
+		t.Fatalf("failed to create asset: %v", err)
+	}
+	fixtures.AddTagToAsset(ctx, camera.ID, tagID)
+	fixtures.CreateAttachment(ctx, camera.ID, "receipt.pdf", "source/receipt.pdf")
+
+	// The target already has a matching category and tag, and the owner
+	fixtures.CreateCategory(ctx, target.ID, "electronics", nil)
+	fixtures.CreateTag(ctx, target.ID, "insured")
+	repo := NewOrganizationRepository(testDB.Pool)
+	if _, err := repo.AddMember(ctx, target.ID, owner.ID, domain.UserRoleUser); err != nil {
+		t.Fatalf("failed to add member: %v", err)
+	}
+
+	export, files, err := repo.ExportPortable(ctx, source.ID)
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+	if err := export.Validate(); err != nil {
+		t.Fatalf("expected a valid export, got %v", err)
+	}
+	if len(export.Users) != 2 || len(export.Categories) != 2 || len(export.Assets) != 1 || len(files) != 1 || files[0].FileKey != "source/receipt.pdf" {
+		t.Fatalf("unexpected export %+v, files %+v", export, files)
+	}
+
+	export.RenewAssetIDs()
+	result, err := repo.ImportPortable(ctx, target.ID, export, map[uuid.UUID]string{files[0].ID: "target/receipt.pdf"})
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+	if result.Categories.Matched != 1 || result.Categories.Created != 1 || result.Tags.Matched != 1 || result.Locations.Created != 1 {
+		t.Errorf("unexpected taxonomy counts %+v", result)
+	}
+	if result.Assets != 1 || result.Files != 1 || len(result.UnmatchedUsers) != 1 || result.UnmatchedUsers[0] != "stays@example.com" {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	imported, err := NewAssetRepository(testDB.Pool).GetByIDFull(ctx, export.Assets[0].ID)
+	if err != nil || imported == nil {
+		t.Fatalf("failed to get imported asset: %v", err)
+	}
+	if imported.OrganizationID != target.ID || imported.OwnerID == nil || *imported.OwnerID != owner.ID || len(imported.Tags) != 1 {
+		t.Errorf("unexpected imported asset %+v", imported)
+	}
+	if imported.CategoryID == cameras.ID || imported.LocationID == nil || *imported.LocationID == garage.ID {
+		t.Error("expected the taxonomy to be imported with new IDs")
+	}
+}
+
+func Test_OrganizationRepository_PortableMergeAgain(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	source, _ := fixtures.CreateOrganization(ctx, "Source")
+	target, _ := fixtures.CreateOrganization(ctx, "Target")
+	cat, _ := fixtures.CreateCategory(ctx, source.ID, "Tools", nil)
+	fixtures.CreateAsset(ctx, source.ID, cat.ID, "Drill")
+
+	repo := NewOrganizationRepository(testDB.Pool)
+	transfer := func() *portable.Export {
+		export, _, err := repo.ExportPortable(ctx, source.ID)
+		if err != nil {
+			t.Fatalf("failed to export: %v", err)
+		}
+		export.DeriveAssetIDs(target.ID)
+		ids := make([]uuid.UUID, len(export.Assets))
+		for i, a := range export.Assets {
+			ids[i] = a.ID
+		}
+		existing, err := repo.ExistingAssetIDs(ctx, ids)
+		if err != nil {
+			t.Fatalf("failed to check existing assets: %v", err)
+		}
+		export.DropAssets(existing)
+		if _, err := repo.ImportPortable(ctx, target.ID, export, nil); err != nil {
+			t.Fatalf("failed to import: %v", err)
+		}
+		return export
+	}
+
+	if first := transfer(); len(first.Assets) != 1 {
+		t.Fatalf("expected the first transfer to import the asset, got %d", len(first.Assets))
+	}
+	fixtures.CreateAsset(ctx, source.ID, cat.ID, "Saw")
+	second := transfer()
+	if len(second.Assets) != 1 || second.Assets[0].Name != "Saw" {
+		t.Errorf("expected only the new asset to be transferred again, got %+v", second.Assets)
+	}
+
+	_, total, err := NewAssetRepository(testDB.Pool).List(ctx, target.ID, domain.AssetFilter{}, domain.Pagination{Limit: 10})
+	if err != nil || total != 2 {
+		t.Errorf("expected 2 assets in the target, got %d (%v)", total, err)
+	}
+}
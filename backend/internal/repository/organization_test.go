@@ -202,3 +202,118 @@ func Test_OrganizationRepository_Update_Success(t *testing.T) {
 		t.Error("expected UpdatedAt to be updated")
 	}
 }
+
+func Test_OrganizationRepository_Membership(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	home, _ := fixtures.CreateOrganization(ctx, "Home")
+	cabin, _ := fixtures.CreateOrganization(ctx, "Cabin")
+	cat, _ := fixtures.CreateCategory(ctx, cabin.ID, "Tools", nil)
+
+	user := &domain.User{OrganizationID: cabin.ID, Email: "member@example.com", Role: domain.UserRoleUser}
+	if err := NewUserRepository(testDB.Pool).Create(ctx, user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	repo := NewOrganizationRepository(testDB.Pool)
+	orgID, role, err := repo.MemberOrganization(ctx, user.ID)
+	if err != nil || orgID != cabin.ID || role != "" {
+		t.Errorf("expected new users to be members of their organization with their own role, got %s %q, %v", orgID, role, err)
+	}
+	if orgID, _, _ := repo.MemberOrganization(ctx, uuid.New()); orgID != uuid.Nil {
+		t.Errorf("expected no organization of an unknown user, got %s", orgID)
+	}
+
+	if owned, err := repo.OwnedBy(ctx, cabin.ID, ResourceCategory, cat.ID); err != nil || !owned {
+		t.Errorf("expected the category to belong to its organization, got %v, %v", owned, err)
+	}
+	if owned, _ := repo.OwnedBy(ctx, home.ID, ResourceCategory, cat.ID); owned {
+		t.Error("expected the category not to belong to another organization")
+	}
+	if owned, _ := repo.OwnedBy(ctx, cabin.ID, ResourceUser, user.ID); !owned {
+		t.Error("expected the user to belong to its organization")
+	}
+
+	orgs, err := repo.List(ctx)
+	if err != nil || len(orgs) != 2 || orgs[0].ID != home.ID {
+		t.Errorf("expected both organizations, oldest first, got %+v, %v", orgs, err)
+	}
+}
+
+func Test_OrganizationRepository_Members(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	home, _ := fixtures.CreateOrganization(ctx, "Home")
+	cabin, _ := fixtures.CreateOrganization(ctx, "Cabin")
+	user := &domain.User{OrganizationID: home.ID, Email: "member@example.com", Role: domain.UserRoleAdmin}
+	if err := NewUserRepository(testDB.Pool).Create(ctx, user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	repo := NewOrganizationRepository(testDB.Pool)
+	viewer := domain.UserRole("viewer")
+	if added, err := repo.AddMember(ctx, cabin.ID, user.ID, viewer); err != nil || !added {
+		t.Fatalf("failed to add member: %v, %v", added, err)
+	}
+	if added, _ := repo.AddMember(ctx, cabin.ID, user.ID, domain.UserRoleUser); added {
+		t.Error("expected adding a member twice to be reported")
+	}
+	if role, member, _ := repo.MemberRole(ctx, cabin.ID, user.ID); !member || role != viewer {
+		t.Errorf("expected the viewer role in the cabin, got %q, %v", role, member)
+	}
+
+	memberships, err := repo.ListMemberships(ctx, user.ID)
+	if err != nil || len(memberships) != 2 || memberships[0].OrganizationID != home.ID || !memberships[0].Selected || !memberships[0].Home {
+		t.Fatalf("expected the home organization to be selected, got %+v, %v", memberships, err)
+	}
+	if memberships[0].Role != domain.UserRoleAdmin || !memberships[0].OwnRole || memberships[1].Role != viewer {
+		t.Errorf("unexpected roles %+v", memberships)
+	}
+
+	if ok, err := repo.SelectOrganization(ctx, cabin.ID, user.ID); err != nil || !ok {
+		t.Fatalf("failed to select organization: %v, %v", ok, err)
+	}
+	if orgID, role, _ := repo.MemberOrganization(ctx, user.ID); orgID != cabin.ID || role != viewer {
+		t.Errorf("expected the selected organization, got %s %q", orgID, role)
+	}
+
+	if ok, _ := repo.SetMemberRole(ctx, cabin.ID, user.ID, domain.UserRoleUser); !ok {
+		t.Error("expected the role to be changed")
+	}
+	if ok, _ := repo.SetMemberRole(ctx, home.ID, user.ID, domain.UserRoleUser); ok {
+		t.Error("expected the role in the home organization not to be changed")
+	}
+	if member, _ := repo.GetMember(ctx, cabin.ID, user.ID); member == nil || member.Role != domain.UserRoleUser || member.OwnRole || member.Home {
+		t.Errorf("expected the changed role, got %+v", member)
+	}
+	if members, _ := repo.ListMembers(ctx, cabin.ID); len(members) != 1 {
+		t.Errorf("expected one member, got %+v", members)
+	}
+
+	cat, _ := fixtures.CreateCategory(ctx, cabin.ID, "Tools", nil)
+	owned := &domain.Asset{OrganizationID: cabin.ID, CategoryID: cat.ID, Name: "Kayak", Quantity: 1, OwnerID: &user.ID}
+	if err := fixtures.CreateAssetFull(ctx, owned); err != nil {
+		t.Fatalf("failed to create asset: %v", err)
+	}
+
+	if removed, _ := repo.RemoveMember(ctx, home.ID, user.ID); removed {
+		t.Error("expected users not to be removed from their home organization")
+	}
+	if removed, err := repo.RemoveMember(ctx, cabin.ID, user.ID); err != nil || !removed {
+		t.Errorf("failed to remove member: %v, %v", removed, err)
+	}
+	if _, member, _ := repo.MemberRole(ctx, cabin.ID, user.ID); member {
+		t.Error("expected the user not to be a member anymore")
+	}
+	if asset, _ := NewAssetRepository(testDB.Pool).GetByID(ctx, owned.ID); asset == nil || asset.OwnerID != nil {
+		t.Errorf("expected the removed member's asset to have no owner, got %+v", asset)
+	}
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+const passkeyColumns = `id, user_id, credential_id, public_key, algorithm, sign_count, name, transports, last_used_at, created_at, updated_at`
+
+func scanPasskey(row pgx.Row) (*domain.Passkey, error) {
+	var p domain.Passkey
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.CredentialID, &p.PublicKey, &p.Algorithm, &p.SignCount,
+		&p.Name, &p.Transports, &p.LastUsedAt, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListPasskeys returns the passkeys of a user, oldest first
+func (r *UserRepository) ListPasskeys(ctx context.Context, userID uuid.UUID) ([]domain.Passkey, error) {
+	query := `SELECT ` + passkeyColumns + ` FROM passkeys WHERE user_id = $1 ORDER BY created_at`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var passkeys []domain.Passkey
+	for rows.Next() {
+		p, err := scanPasskey(rows)
+		if err != nil {
+			return nil, err
+		}
+		passkeys = append(passkeys, *p)
+	}
+	return passkeys, rows.Err()
+}
+
+// GetPasskeyByCredentialID returns the passkey with a WebAuthn credential ID,
+// or nil if there is none
+func (r *UserRepository) GetPasskeyByCredentialID(ctx context.Context, credentialID []byte) (*domain.Passkey, error) {
+	query := `SELECT ` + passkeyColumns + ` FROM passkeys WHERE credential_id = $1`
+	p, err := scanPasskey(r.pool.QueryRow(ctx, query, credentialID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return p, err
+}
+
+// CreatePasskey stores a newly registered passkey
+func (r *UserRepository) CreatePasskey(ctx context.Context, p *domain.Passkey) error {
+	if p.Transports == nil {
+		p.Transports = []string{}
+	}
+	query := `
+		INSERT INTO passkeys (user_id, credential_id, public_key, algorithm, sign_count, name, transports)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		p.UserID, p.CredentialID, p.PublicKey, p.Algorithm, p.SignCount, p.Name, p.Transports,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+}
+
+// UpdatePasskeyUsage records a successful login with a passkey
+func (r *UserRepository) UpdatePasskeyUsage(ctx context.Context, id uuid.UUID, signCount int64) error {
+	query := `UPDATE passkeys SET sign_count = $2, last_used_at = NOW() WHERE id = $1`
+	_, err := r.pool.Exec(ctx, query, id, signCount)
+	return err
+}
+
+// DeletePasskey removes a passkey of a user, reporting whether it existed
+func (r *UserRepository) DeletePasskey(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	query := `DELETE FROM passkeys WHERE id = $1 AND user_id = $2`
+	tag, err := r.pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
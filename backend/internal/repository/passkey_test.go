@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_UserRepository_Passkey_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "jane@example.com")
+	other, _ := fixtures.CreateUser(ctx, org.ID, "john@example.com")
+	repo := NewUserRepository(testDB.Pool)
+
+	passkey := &domain.Passkey{
+		UserID:       user.ID,
+		CredentialID: []byte{1, 2, 3},
+		PublicKey:    []byte{4, 5, 6},
+		Algorithm:    -7,
+		Name:         "Laptop",
+		Transports:   []string{"internal"},
+	}
+	if err := repo.CreatePasskey(ctx, passkey); err != nil {
+		t.Fatalf("failed to create passkey: %v", err)
+	}
+
+	found, err := repo.GetPasskeyByCredentialID(ctx, []byte{1, 2, 3})
+	if err != nil || found == nil || found.ID != passkey.ID || found.Algorithm != -7 {
+		t.Fatalf("expected passkey, got %+v (%v)", found, err)
+	}
+	if found, _ := repo.GetPasskeyByCredentialID(ctx, []byte{9}); found != nil {
+		t.Errorf("expected no passkey for unknown credential, got %+v", found)
+	}
+
+	if err := repo.UpdatePasskeyUsage(ctx, passkey.ID, 5); err != nil {
+		t.Fatalf("failed to update usage: %v", err)
+	}
+	passkeys, err := repo.ListPasskeys(ctx, user.ID)
+	if err != nil || len(passkeys) != 1 {
+		t.Fatalf("expected 1 passkey, got %d (%v)", len(passkeys), err)
+	}
+	if passkeys[0].SignCount != 5 || passkeys[0].LastUsedAt == nil {
+		t.Errorf("expected usage to be recorded, got %+v", passkeys[0])
+	}
+
+	// Users can only delete their own passkeys
+	if ok, _ := repo.DeletePasskey(ctx, other.ID, passkey.ID); ok {
+		t.Error("expected passkey of another user not to be deleted")
+	}
+	if ok, err := repo.DeletePasskey(ctx, user.ID, passkey.ID); err != nil || !ok {
+		t.Fatalf("expected passkey to be deleted (%v)", err)
+	}
+	if passkeys, _ := repo.ListPasskeys(ctx, user.ID); len(passkeys) != 0 {
+		t.Errorf("expected no passkeys, got %d", len(passkeys))
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type PowerUsageRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPowerUsageRepository(pool *pgxpool.Pool) *PowerUsageRepository {
+	return &PowerUsageRepository{pool: pool}
+}
+
+func (r *PowerUsageRepository) GetByAssetID(ctx context.Context, assetID uuid.UUID) (*domain.PowerUsage, error) {
+	query := `
+		SELECT asset_id, watts, standby_watts, hours_per_day, created_at, updated_at
+		FROM asset_power_usage
+		WHERE asset_id = $1
+	`
+	var p domain.PowerUsage
+	err := r.pool.QueryRow(ctx, query, assetID).Scan(
+		&p.AssetID, &p.Watts, &p.StandbyWatts, &p.HoursPerDay, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// List returns the power profiles of the organization's active assets with
+// their quantity and location
+func (r *PowerUsageRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.PowerUsageWithAsset, error) {
+	query := `
+		SELECT p.asset_id, p.watts, p.standby_watts, p.hours_per_day, p.created_at, p.updated_at,
+		       a.name, a.quantity, a.location_id, l.name, a.high_value
+		FROM asset_power_usage p
+		JOIN assets a ON a.id = p.asset_id
+		LEFT JOIN locations l ON l.id = a.location_id AND l.deleted_at IS NULL
+		WHERE a.organization_id = $1
+		  AND a.deleted_at IS NULL
+		  AND a.archived_at IS NULL
+		ORDER BY a.name
+	`
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usages []domain.PowerUsageWithAsset
+	for rows.Next() {
+		var p domain.PowerUsageWithAsset
+		if err := rows.Scan(
+			&p.AssetID, &p.Watts, &p.StandbyWatts, &p.HoursPerDay, &p.CreatedAt, &p.UpdatedAt,
+			&p.AssetName, &p.Quantity, &p.LocationID, &p.LocationName, &p.HighValue,
+		); err != nil {
+			return nil, err
+		}
+		usages = append(usages, p)
+	}
+	return usages, rows.Err()
+}
+
+// Upsert creates or replaces the power profile of an asset
+func (r *PowerUsageRepository) Upsert(ctx context.Context, p *domain.PowerUsage) error {
+	query := `
+		INSERT INTO asset_power_usage (asset_id, watts, standby_watts, hours_per_day)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (asset_id) DO UPDATE
+		SET watts = EXCLUDED.watts, standby_watts = EXCLUDED.standby_watts, hours_per_day = EXCLUDED.hours_per_day
+		RETURNING created_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query, p.AssetID, p.Watts, p.StandbyWatts, p.HoursPerDay).Scan(&p.CreatedAt, &p.UpdatedAt)
+}
+
+func (r *PowerUsageRepository) Delete(ctx context.Context, assetID uuid.UUID) error {
+	query := `DELETE FROM asset_power_usage WHERE asset_id = $1`
+	_, err := r.pool.Exec(ctx, query, assetID)
+	return err
+}
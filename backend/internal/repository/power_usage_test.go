@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_PowerUsageRepository_UpsertAndList(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	loc, _ := fixtures.CreateLocation(ctx, org.ID, "Living room", nil)
+	asset := &domain.Asset{OrganizationID: org.ID, CategoryID: cat.ID, LocationID: &loc.ID, Name: "TV", Quantity: 1}
+	if err := fixtures.CreateAssetFull(ctx, asset); err != nil {
+		t.Fatalf("failed to create asset: %v", err)
+	}
+
+	repo := NewPowerUsageRepository(testDB.Pool)
+	usage := &domain.PowerUsage{AssetID: asset.ID, Watts: 100, StandbyWatts: 1, HoursPerDay: 4}
+	if err := repo.Upsert(ctx, usage); err != nil {
+		t.Fatalf("failed to save power usage: %v", err)
+	}
+	usage.Watts = 80
+	if err := repo.Upsert(ctx, usage); err != nil {
+		t.Fatalf("failed to update power usage: %v", err)
+	}
+
+	got, err := repo.GetByAssetID(ctx, asset.ID)
+	if err != nil || got == nil {
+		t.Fatalf("failed to get power usage: %v", err)
+	}
+	if got.Watts != 80 {
+		t.Errorf("expected 80 W, got %v", got.Watts)
+	}
+
+	usages, err := repo.List(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("failed to list power usage: %v", err)
+	}
+	if len(usages) != 1 || usages[0].AssetName != "TV" || usages[0].LocationName == nil || *usages[0].LocationName != "Living room" {
+		t.Fatalf("expected TV in living room, got %+v", usages)
+	}
+
+	if err := repo.Delete(ctx, asset.ID); err != nil {
+		t.Fatalf("failed to delete power usage: %v", err)
+	}
+	got, _ = repo.GetByAssetID(ctx, asset.ID)
+	if got != nil {
+		t.Error("expected power usage to be deleted")
+	}
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type PurchaseRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPurchaseRepository(pool *pgxpool.Pool) *PurchaseRepository {
+	return &PurchaseRepository{pool: pool}
+}
+
+const purchaseColumns = `
+	id, organization_id, category_id, name, notes, quantity, status, vendor, price, currency,
+	ordered_at, expected_at, warranty_months, received_at, asset_id, created_by, created_at, updated_at
+`
+
+func scanPurchase(row pgx.Row) (*domain.Purchase, error) {
+	var p domain.Purchase
+	err := row.Scan(
+		&p.ID, &p.OrganizationID, &p.CategoryID, &p.Name, &p.Notes, &p.Quantity, &p.Status, &p.Vendor, &p.Price, &p.Currency,
+		&p.OrderedAt, &p.ExpectedAt, &p.WarrantyMonths, &p.ReceivedAt, &p.AssetID, &p.CreatedBy, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PurchaseRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Purchase, error) {
+	query := `SELECT ` + purchaseColumns + ` FROM purchases WHERE id = $1`
+	p, err := scanPurchase(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return p, err
+}
+
+// List returns the organization's purchases matching filter in pipeline
+// order: wanted, ordered (those expected soonest first), then received
+func (r *PurchaseRepository) List(ctx context.Context, orgID uuid.UUID, filter domain.PurchaseFilter) ([]domain.Purchase, error) {
+	conditions := []string{"organization_id = $1"}
+	args := []any{orgID}
+	if len(filter.Statuses) > 0 {
+		args = append(args, filter.Statuses)
+		conditions = append(conditions, fmt.Sprintf("status = ANY($%d)", len(args)))
+	}
+
+	query := `
+		SELECT ` + purchaseColumns + `
+		FROM purchases
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY CASE status WHEN 'wanted' THEN 0 WHEN 'ordered' THEN 1 ELSE 2 END,
+		         expected_at NULLS LAST, received_at DESC, created_at
+	`
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purchases := []domain.Purchase{}
+	for rows.Next() {
+		p, err := scanPurchase(rows)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, *p)
+	}
+	return purchases, rows.Err()
+}
+
+func (r *PurchaseRepository) Create(ctx context.Context, p *domain.Purchase) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	if p.Status == "" {
+		p.Status = domain.PurchaseWanted
+	}
+	query := `
+		INSERT INTO purchases (id, organization_id, category_id, name, notes, quantity, status, vendor, price, currency,
+		                       ordered_at, expected_at, warranty_months, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING created_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		p.ID, p.OrganizationID, p.CategoryID, p.Name, p.Notes, p.Quantity, p.Status, p.Vendor, p.Price, p.Currency,
+		p.OrderedAt, p.ExpectedAt, p.WarrantyMonths, p.CreatedBy,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+}
+
+func (r *PurchaseRepository) Update(ctx context.Context, p *domain.Purchase) error {
+	query := `
+		UPDATE purchases
+		SET category_id = $2, name = $3, notes = $4, quantity = $5, status = $6, vendor = $7, price = $8, currency = $9,
+		    ordered_at = $10, expected_at = $11, warranty_months = $12, received_at = $13, asset_id = $14
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		p.ID, p.CategoryID, p.Name, p.Notes, p.Quantity, p.Status, p.Vendor, p.Price, p.Currency,
+		p.OrderedAt, p.ExpectedAt, p.WarrantyMonths, p.ReceivedAt, p.AssetID,
+	).Scan(&p.UpdatedAt)
+}
+
+// Receive marks a purchase received on day, unless it was already; it
+// reports whether it did, so that it becomes an asset only once
+func (r *PurchaseRepository) Receive(ctx context.Context, id uuid.UUID, day time.Time) (bool, error) {
+	query := `
+		UPDATE purchases
+		SET status = 'received', received_at = $2
+		WHERE id = $1 AND status <> 'received'
+	`
+	tag, err := r.pool.Exec(ctx, query, id, calendarDate(day))
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *PurchaseRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM purchases WHERE id = $1`, id)
+	return err
+}
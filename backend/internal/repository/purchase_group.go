@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type PurchaseGroupRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewPurchaseGroupRepository(pool *pgxpool.Pool) *PurchaseGroupRepository {
+	return &PurchaseGroupRepository{pool: pool}
+}
+
+const purchaseGroupColumns = `
+	id, organization_id, name, total, currency, purchase_at, purchase_note, created_by, created_at, updated_at,
+	EXISTS (SELECT 1 FROM assets a WHERE a.purchase_group_id = purchase_groups.id AND a.high_value AND a.deleted_at IS NULL)
+`
+
+func scanPurchaseGroup(row pgx.Row) (*domain.PurchaseGroup, error) {
+	var g domain.PurchaseGroup
+	err := row.Scan(
+		&g.ID, &g.OrganizationID, &g.Name, &g.Total, &g.Currency, &g.PurchaseAt, &g.PurchaseNote, &g.CreatedBy, &g.CreatedAt, &g.UpdatedAt,
+		&g.HighValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// GetByID returns a purchase group with its assets that weren't deleted
+func (r *PurchaseGroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PurchaseGroup, error) {
+	query := `SELECT ` + purchaseGroupColumns + ` FROM purchase_groups WHERE id = $1`
+	g, err := scanPurchaseGroup(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, name, quantity, purchase_price, high_value
+		FROM assets
+		WHERE purchase_group_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at, name
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	g.Items = []domain.PurchaseGroupItem{}
+	for rows.Next() {
+		var item domain.PurchaseGroupItem
+		if err := rows.Scan(&item.AssetID, &item.Name, &item.Quantity, &item.PurchasePrice, &item.HighValue); err != nil {
+			return nil, err
+		}
+		g.Items = append(g.Items, item)
+	}
+	return g, rows.Err()
+}
+
+// List returns the organization's purchase groups, latest purchase first
+func (r *PurchaseGroupRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.PurchaseGroup, error) {
+	query := `
+		SELECT ` + purchaseGroupColumns + `
+		FROM purchase_groups
+		WHERE organization_id = $1
+		ORDER BY purchase_at DESC NULLS LAST, created_at DESC
+	`
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []domain.PurchaseGroup{}
+	for rows.Next() {
+		g, err := scanPurchaseGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *g)
+	}
+	return groups, rows.Err()
+}
+
+// Create records a purchase group and creates its assets in one
+// transaction. Like imported assets, they get a short ID when first asked
+// for one.
+func (r *PurchaseGroupRepository) Create(ctx context.Context, g *domain.PurchaseGroup, assets []*domain.Asset) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO purchase_groups (id, organization_id, name, total, currency, purchase_at, purchase_note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query,
+		g.ID, g.OrganizationID, g.Name, g.Total, g.Currency, g.PurchaseAt, g.PurchaseNote, g.CreatedBy,
+	).Scan(&g.CreatedAt, &g.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	g.Items = make([]domain.PurchaseGroupItem, 0, len(assets))
+	for _, a := range assets {
+		if a.ID == uuid.Nil {
+			a.ID = uuid.New()
+		}
+		if a.Attributes == nil {
+			a.Attributes = []byte("{}")
+		}
+		if a.Status == "" {
+			a.Status = domain.AssetStatusOwned
+		}
+		a.PurchaseGroupID = &g.ID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO assets (id, organization_id, category_id, location_id, name, quantity, attributes,
+			                    purchase_at, purchase_price, currency, purchase_note, high_value, status, purchase_group_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			RETURNING created_at, updated_at
+		`, a.ID, a.OrganizationID, a.CategoryID, a.LocationID, a.Name, a.Quantity, a.Attributes,
+			a.PurchaseAt, a.PurchasePrice, a.Currency, a.PurchaseNote, a.HighValue, a.Status, a.PurchaseGroupID,
+		).Scan(&a.CreatedAt, &a.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("creating asset %s: %w", a.Name, err)
+		}
+		g.Items = append(g.Items, domain.PurchaseGroupItem{AssetID: a.ID, Name: a.Name, Quantity: a.Quantity, PurchasePrice: a.PurchasePrice, HighValue: a.HighValue})
+		g.HighValue = g.HighValue || a.HighValue
+	}
+	return tx.Commit(ctx)
+}
+
+// Delete removes a purchase group; its assets keep their purchase prices
+func (r *PurchaseGroupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM purchase_groups WHERE id = $1`, id)
+	return err
+}
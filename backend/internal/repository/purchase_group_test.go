@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_PurchaseGroupRepository(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Cameras", nil)
+
+	repo := NewPurchaseGroupRepository(testDB.Pool)
+	assetRepo := NewAssetRepository(testDB.Pool)
+	bodyPrice, lensPrice := 750.0, 250.0
+	group := &domain.PurchaseGroup{OrganizationID: org.ID, Name: "Camera kit", Total: 1000}
+	body := &domain.Asset{OrganizationID: org.ID, CategoryID: category.ID, Name: "Camera body", Quantity: 1, PurchasePrice: &bodyPrice}
+	lens := &domain.Asset{OrganizationID: org.ID, CategoryID: category.ID, Name: "Kit lens", Quantity: 1, PurchasePrice: &lensPrice}
+	if err := repo.Create(ctx, group, []*domain.Asset{body, lens}); err != nil {
+		t.Fatalf("failed to create purchase group: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, group.ID)
+	if err != nil || got == nil || len(got.Items) != 2 || got.Total != 1000 {
+		t.Fatalf("expected the group with its two assets, got %+v, %v", got, err)
+	}
+	created, _ := assetRepo.GetByID(ctx, lens.ID)
+	if created == nil || created.PurchaseGroupID == nil || *created.PurchaseGroupID != group.ID || *created.PurchasePrice != 250 {
+		t.Errorf("expected the lens to belong to the group, got %+v", created)
+	}
+	if shortID, err := assetRepo.AssignShortID(ctx, lens.ID); err != nil || shortID == "" {
+		t.Errorf("expected a short ID to be assigned on demand, got %q, %v", shortID, err)
+	}
+	if groups, _ := repo.List(ctx, org.ID); len(groups) != 1 {
+		t.Errorf("expected one group, got %+v", groups)
+	}
+
+	// The assets outlive their group
+	if err := repo.Delete(ctx, group.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if kept, _ := assetRepo.GetByID(ctx, body.ID); kept == nil || kept.PurchaseGroupID != nil || *kept.PurchasePrice != 750 {
+		t.Errorf("expected the body to keep its price, got %+v", kept)
+	}
+}
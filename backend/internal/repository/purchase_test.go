@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_PurchaseRepository(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Cameras", nil)
+
+	repo := NewPurchaseRepository(testDB.Pool)
+	day := time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC)
+	price, currency := 1299.0, "EUR"
+	camera := &domain.Purchase{OrganizationID: org.ID, CategoryID: &category.ID, Name: "Mirrorless camera", Quantity: 1, Price: &price, Currency: &currency}
+	tripod := &domain.Purchase{OrganizationID: org.ID, Name: "Tripod", Quantity: 1}
+	for _, p := range []*domain.Purchase{camera, tripod} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("failed to create purchase: %v", err)
+		}
+	}
+	if camera.Status != domain.PurchaseWanted {
+		t.Errorf("expected a new purchase to be wanted, got %s", camera.Status)
+	}
+
+	expected := day.AddDate(0, 0, 5)
+	camera.Status, camera.OrderedAt, camera.ExpectedAt = domain.PurchaseOrdered, &day, &expected
+	if err := repo.Update(ctx, camera); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	all, err := repo.List(ctx, org.ID, domain.PurchaseFilter{})
+	if err != nil || len(all) != 2 || all[0].ID != tripod.ID || all[1].ExpectedAt == nil || !all[1].ExpectedAt.Equal(expected) {
+		t.Fatalf("expected the wishlist, then the order, got %+v, %v", all, err)
+	}
+	if ordered, _ := repo.List(ctx, org.ID, domain.PurchaseFilter{Statuses: []domain.PurchaseStatus{domain.PurchaseOrdered}}); len(ordered) != 1 || ordered[0].ID != camera.ID {
+		t.Errorf("expected the camera order, got %+v", ordered)
+	}
+
+	// A purchase is received once
+	if received, err := repo.Receive(ctx, camera.ID, expected); err != nil || !received {
+		t.Fatalf("expected the camera to be received, got %v, %v", received, err)
+	}
+	if received, _ := repo.Receive(ctx, camera.ID, expected); received {
+		t.Error("expected the camera not to be received twice")
+	}
+	if got, _ := repo.GetByID(ctx, camera.ID); got == nil || got.Status != domain.PurchaseReceived || got.ReceivedAt == nil || !got.ReceivedAt.Equal(expected) {
+		t.Errorf("expected the camera to be received, got %+v", got)
+	}
+
+	if err := repo.Delete(ctx, tripod.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, tripod.ID); got != nil {
+		t.Errorf("expected the purchase to be deleted, got %+v", got)
+	}
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type RecurringCostRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRecurringCostRepository(pool *pgxpool.Pool) *RecurringCostRepository {
+	return &RecurringCostRepository{pool: pool}
+}
+
+const recurringCostColumns = `
+	c.id, c.organization_id, c.asset_id, c.name, c.amount, c.currency, c.billing_interval, c.next_renewal_at,
+	c.remind_days, c.reminded_for, c.notes, c.created_at, c.updated_at,
+	a.name, a.high_value
+`
+
+func scanRecurringCost(row pgx.Row) (*domain.RecurringCost, error) {
+	var c domain.RecurringCost
+	err := row.Scan(
+		&c.ID, &c.OrganizationID, &c.AssetID, &c.Name, &c.Amount, &c.Currency, &c.Interval, &c.NextRenewalAt,
+		&c.RemindDays, &c.RemindedFor, &c.Notes, &c.CreatedAt, &c.UpdatedAt,
+		&c.AssetName, &c.HighValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *RecurringCostRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RecurringCost, error) {
+	query := `
+		SELECT ` + recurringCostColumns + `
+		FROM recurring_costs c
+		JOIN assets a ON a.id = c.asset_id
+		WHERE c.id = $1 AND c.deleted_at IS NULL
+	`
+	c, err := scanRecurringCost(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// List returns the organization's recurring costs, next renewal first
+func (r *RecurringCostRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.RecurringCost, error) {
+	query := `
+		SELECT ` + recurringCostColumns + `
+		FROM recurring_costs c
+		JOIN assets a ON a.id = c.asset_id AND a.deleted_at IS NULL
+		WHERE c.organization_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.next_renewal_at NULLS LAST, c.name
+	`
+	return r.query(ctx, query, orgID)
+}
+
+func (r *RecurringCostRepository) ListByAsset(ctx context.Context, assetID uuid.UUID) ([]domain.RecurringCost, error) {
+	query := `
+		SELECT ` + recurringCostColumns + `
+		FROM recurring_costs c
+		JOIN assets a ON a.id = c.asset_id
+		WHERE c.asset_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.name
+	`
+	return r.query(ctx, query, assetID)
+}
+
+// ListRenewingBefore returns the costs renewing on or before the calendar
+// date of until, including overdue ones
+func (r *RecurringCostRepository) ListRenewingBefore(ctx context.Context, orgID uuid.UUID, until time.Time) ([]domain.RecurringCost, error) {
+	query := `
+		SELECT ` + recurringCostColumns + `
+		FROM recurring_costs c
+		JOIN assets a ON a.id = c.asset_id AND a.deleted_at IS NULL
+		WHERE c.organization_id = $1 AND c.deleted_at IS NULL
+		  AND c.next_renewal_at <= $2
+		ORDER BY c.next_renewal_at, c.name
+	`
+	return r.query(ctx, query, orgID, calendarDate(until))
+}
+
+func (r *RecurringCostRepository) query(ctx context.Context, query string, args ...any) ([]domain.RecurringCost, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var costs []domain.RecurringCost
+	for rows.Next() {
+		c, err := scanRecurringCost(rows)
+		if err != nil {
+			return nil, err
+		}
+		costs = append(costs, *c)
+	}
+	return costs, rows.Err()
+}
+
+func (r *RecurringCostRepository) Create(ctx context.Context, c *domain.RecurringCost) error {
+	query := `
+		INSERT INTO recurring_costs (id, organization_id, asset_id, name, amount, currency, billing_interval, next_renewal_at, remind_days, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at, updated_at
+	`
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return r.pool.QueryRow(ctx, query,
+		c.ID, c.OrganizationID, c.AssetID, c.Name, c.Amount, c.Currency, c.Interval, c.NextRenewalAt, c.RemindDays, c.Notes,
+	).Scan(&c.CreatedAt, &c.UpdatedAt)
+}
+
+func (r *RecurringCostRepository) Update(ctx context.Context, c *domain.RecurringCost) error {
+	query := `
+		UPDATE recurring_costs
+		SET name = $2, amount = $3, currency = $4, billing_interval = $5, next_renewal_at = $6, remind_days = $7, notes = $8
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		c.ID, c.Name, c.Amount, c.Currency, c.Interval, c.NextRenewalAt, c.RemindDays, c.Notes,
+	).Scan(&c.UpdatedAt)
+}
+
+func (r *RecurringCostRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE recurring_costs SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// Totals rolls up the organization's recurring costs per month and year
+func (r *RecurringCostRepository) Totals(ctx context.Context, orgID uuid.UUID) (*domain.RecurringCostTotals, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(c.amount / CASE c.billing_interval
+			WHEN 'monthly' THEN 1
+			WHEN 'quarterly' THEN 3
+			WHEN 'annual' THEN 12
+		END), 0)
+		FROM recurring_costs c
+		JOIN assets a ON a.id = c.asset_id AND a.deleted_at IS NULL AND a.archived_at IS NULL
+		WHERE c.organization_id = $1 AND c.deleted_at IS NULL
+	`
+	var totals domain.RecurringCostTotals
+	if err := r.pool.QueryRow(ctx, query, orgID).Scan(&totals.Count, &totals.Monthly); err != nil {
+		return nil, err
+	}
+	totals.Annual = totals.Monthly * 12
+	return &totals, nil
+}
+
+// SetRenewal moves the next renewal date (after a renewal has passed)
+func (r *RecurringCostRepository) SetRenewal(ctx context.Context, id uuid.UUID, nextRenewalAt time.Time) error {
+	query := `UPDATE recurring_costs SET next_renewal_at = $2 WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id, calendarDate(nextRenewalAt))
+	return err
+}
+
+// MarkReminded records that the reminder for the given renewal date was sent
+func (r *RecurringCostRepository) MarkReminded(ctx context.Context, id uuid.UUID, renewal time.Time) error {
+	query := `UPDATE recurring_costs SET reminded_for = $2 WHERE id = $1`
+	_, err := r.pool.Exec(ctx, query, id, calendarDate(renewal))
+	return err
+}
+
+// calendarDate returns the date of t (in t's location) for comparison with DATE columns
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
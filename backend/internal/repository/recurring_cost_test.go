@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_RecurringCostRepository_CreateAndList(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Doorbell camera")
+
+	repo := NewRecurringCostRepository(testDB.Pool)
+	renewal := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	cost := &domain.RecurringCost{
+		OrganizationID: org.ID,
+		AssetID:        asset.ID,
+		Name:           "Cloud recording",
+		Amount:         3,
+		Interval:       domain.CostIntervalMonthly,
+		NextRenewalAt:  &renewal,
+		RemindDays:     7,
+	}
+	if err := repo.Create(ctx, cost); err != nil {
+		t.Fatalf("failed to create recurring cost: %v", err)
+	}
+	repo.Create(ctx, &domain.RecurringCost{
+		OrganizationID: org.ID, AssetID: asset.ID, Name: "Insurance", Amount: 120, Interval: domain.CostIntervalAnnual,
+	})
+
+	costs, err := repo.ListByAsset(ctx, asset.ID)
+	if err != nil {
+		t.Fatalf("failed to list recurring costs: %v", err)
+	}
+	if len(costs) != 2 || costs[0].AssetName != "Doorbell camera" {
+		t.Fatalf("expected 2 costs with asset name, got %+v", costs)
+	}
+
+	totals, err := repo.Totals(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("failed to get totals: %v", err)
+	}
+	if totals.Count != 2 || totals.Monthly != 13 || totals.Annual != 156 {
+		t.Errorf("unexpected totals: %+v", totals)
+	}
+
+	renewing, _ := repo.ListRenewingBefore(ctx, org.ID, time.Date(2025, 6, 30, 23, 0, 0, 0, time.UTC))
+	if len(renewing) != 0 {
+		t.Errorf("expected no renewals before 2025-07-01, got %d", len(renewing))
+	}
+	renewing, _ = repo.ListRenewingBefore(ctx, org.ID, renewal)
+	if len(renewing) != 1 || renewing[0].ID != cost.ID {
+		t.Errorf("expected the cloud recording renewal, got %+v", renewing)
+	}
+}
+
+func Test_RecurringCostRepository_SetRenewalAndMarkReminded(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Router")
+
+	repo := NewRecurringCostRepository(testDB.Pool)
+	renewal := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	cost := &domain.RecurringCost{
+		OrganizationID: org.ID, AssetID: asset.ID, Name: "Support plan", Amount: 50,
+		Interval: domain.CostIntervalQuarterly, NextRenewalAt: &renewal, RemindDays: 14,
+	}
+	repo.Create(ctx, cost)
+
+	next := time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC)
+	if err := repo.SetRenewal(ctx, cost.ID, next); err != nil {
+		t.Fatalf("failed to set renewal: %v", err)
+	}
+	if err := repo.MarkReminded(ctx, cost.ID, next); err != nil {
+		t.Fatalf("failed to mark reminded: %v", err)
+	}
+
+	got, _ := repo.GetByID(ctx, cost.ID)
+	if got == nil || !got.NextRenewalAt.Equal(next) {
+		t.Fatalf("expected renewal %v, got %+v", next, got)
+	}
+	if got.RemindedFor == nil || !got.RemindedFor.Equal(next) {
+		t.Errorf("expected reminder to be recorded for %v, got %v", next, got.RemindedFor)
+	}
+}
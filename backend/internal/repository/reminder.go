@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type ReminderRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewReminderRepository(pool *pgxpool.Pool) *ReminderRepository {
+	return &ReminderRepository{pool: pool}
+}
+
+const reminderColumns = `
+	r.id, r.organization_id, r.asset_id, r.title, r.notes, r.due_at, r.done_at, r.created_by, r.created_at, r.updated_at,
+	a.name
+`
+
+const reminderJoins = `LEFT JOIN assets a ON a.id = r.asset_id`
+
+func scanReminder(row pgx.Row) (*domain.Reminder, error) {
+	var m domain.Reminder
+	err := row.Scan(
+		&m.ID, &m.OrganizationID, &m.AssetID, &m.Title, &m.Notes, &m.DueAt, &m.DoneAt, &m.CreatedBy, &m.CreatedAt, &m.UpdatedAt,
+		&m.AssetName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *ReminderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Reminder, error) {
+	query := `SELECT ` + reminderColumns + ` FROM reminders r ` + reminderJoins + ` WHERE r.id = $1`
+	m, err := scanReminder(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return m, err
+}
+
+// List returns the organization's reminders matching filter, soonest due
+// first. Reminders of deleted assets are left out.
+func (r *ReminderRepository) List(ctx context.Context, orgID uuid.UUID, filter domain.ReminderFilter) ([]domain.Reminder, error) {
+	conditions := []string{"r.organization_id = $1", "(r.asset_id IS NULL OR a.deleted_at IS NULL)"}
+	args := []any{orgID}
+	if filter.AssetID != nil {
+		args = append(args, *filter.AssetID)
+		conditions = append(conditions, fmt.Sprintf("r.asset_id = $%d", len(args)))
+	}
+	if filter.Done != nil {
+		if *filter.Done {
+			conditions = append(conditions, "r.done_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "r.done_at IS NULL")
+		}
+	}
+	if filter.DueBefore != nil {
+		args = append(args, calendarDate(*filter.DueBefore))
+		conditions = append(conditions, fmt.Sprintf("r.due_at <= $%d", len(args)))
+	}
+
+	query := `
+		SELECT ` + reminderColumns + `
+		FROM reminders r ` + reminderJoins + `
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY r.due_at, r.created_at
+	`
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []domain.Reminder{}
+	for rows.Next() {
+		m, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, *m)
+	}
+	return reminders, rows.Err()
+}
+
+func (r *ReminderRepository) Create(ctx context.Context, m *domain.Reminder) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	query := `
+		INSERT INTO reminders (id, organization_id, asset_id, title, notes, due_at, done_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		m.ID, m.OrganizationID, m.AssetID, m.Title, m.Notes, calendarDate(m.DueAt), m.DoneAt, m.CreatedBy,
+	).Scan(&m.CreatedAt, &m.UpdatedAt)
+}
+
+func (r *ReminderRepository) Update(ctx context.Context, m *domain.Reminder) error {
+	query := `
+		UPDATE reminders
+		SET asset_id = $2, title = $3, notes = $4, due_at = $5, done_at = $6
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		m.ID, m.AssetID, m.Title, m.Notes, calendarDate(m.DueAt), m.DoneAt,
+	).Scan(&m.UpdatedAt)
+}
+
+func (r *ReminderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM reminders WHERE id = $1`, id)
+	return err
+}
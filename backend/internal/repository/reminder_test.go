@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_ReminderRepository_List(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Appliances", nil)
+	boiler, _ := fixtures.CreateAsset(ctx, org.ID, category.ID, "Boiler")
+
+	repo := NewReminderRepository(testDB.Pool)
+	day := time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC)
+	done := day
+	service := &domain.Reminder{OrganizationID: org.ID, AssetID: &boiler.ID, Title: "Service boiler", DueAt: day.AddDate(0, 0, -2)}
+	insurance := &domain.Reminder{OrganizationID: org.ID, Title: "Renew home insurance", DueAt: day.AddDate(0, 0, 20)}
+	returned := &domain.Reminder{OrganizationID: org.ID, Title: "Return ladder", DueAt: day.AddDate(0, 0, -5), DoneAt: &done}
+	for _, m := range []*domain.Reminder{service, insurance, returned} {
+		if err := repo.Create(ctx, m); err != nil {
+			t.Fatalf("failed to create reminder: %v", err)
+		}
+	}
+
+	open, yes := false, true
+	yesterday := day.AddDate(0, 0, -1)
+	overdue, err := repo.List(ctx, org.ID, domain.ReminderFilter{Done: &open, DueBefore: &yesterday})
+	if err != nil || len(overdue) != 1 || overdue[0].ID != service.ID || overdue[0].AssetName == nil || *overdue[0].AssetName != "Boiler" {
+		t.Fatalf("expected the boiler service to be overdue, got %+v, %v", overdue, err)
+	}
+
+	all, _ := repo.List(ctx, org.ID, domain.ReminderFilter{})
+	if len(all) != 3 || all[0].ID != returned.ID || all[2].ID != insurance.ID {
+		t.Errorf("expected all reminders, soonest due first, got %+v", all)
+	}
+	if finished, _ := repo.List(ctx, org.ID, domain.ReminderFilter{Done: &yes}); len(finished) != 1 || finished[0].ID != returned.ID {
+		t.Errorf("expected the done reminder, got %+v", finished)
+	}
+	if ofBoiler, _ := repo.List(ctx, org.ID, domain.ReminderFilter{AssetID: &boiler.ID}); len(ofBoiler) != 1 {
+		t.Errorf("expected the boiler's reminder, got %+v", ofBoiler)
+	}
+
+	service.DoneAt = &done
+	if err := repo.Update(ctx, service); err != nil {
+		t.Fatalf("failed to update: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, service.ID); got == nil || got.DoneAt == nil {
+		t.Errorf("expected the service to be done, got %+v", got)
+	}
+
+	if err := repo.Delete(ctx, insurance.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if got, _ := repo.GetByID(ctx, insurance.ID); got != nil {
+		t.Errorf("expected the reminder to be deleted, got %+v", got)
+	}
+}
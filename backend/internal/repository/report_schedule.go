@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type ReportScheduleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewReportScheduleRepository(pool *pgxpool.Pool) *ReportScheduleRepository {
+	return &ReportScheduleRepository{pool: pool}
+}
+
+const reportScheduleColumns = `
+	id, organization_id, name, report, list_id, cron, delivery, recipients, enabled,
+	next_run_at, last_run_at, created_by, created_at, updated_at
+`
+
+func scanReportSchedule(row pgx.Row) (*domain.ReportSchedule, error) {
+	var s domain.ReportSchedule
+	err := row.Scan(
+		&s.ID, &s.OrganizationID, &s.Name, &s.Report, &s.ListID, &s.Cron, &s.Delivery, &s.Recipients, &s.Enabled,
+		&s.NextRunAt, &s.LastRunAt, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *ReportScheduleRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ReportSchedule, error) {
+	query := `SELECT ` + reportScheduleColumns + ` FROM report_schedules WHERE id = $1 AND deleted_at IS NULL`
+	s, err := scanReportSchedule(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+func (r *ReportScheduleRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.ReportSchedule, error) {
+	query := `
+		SELECT ` + reportScheduleColumns + `
+		FROM report_schedules
+		WHERE organization_id = $1 AND deleted_at IS NULL
+		ORDER BY name
+	`
+	return r.query(ctx, query, orgID)
+}
+
+// ListDue returns the enabled schedules whose next run is at or before now
+func (r *ReportScheduleRepository) ListDue(ctx context.Context, orgID uuid.UUID, now time.Time) ([]domain.ReportSchedule, error) {
+	query := `
+		SELECT ` + reportScheduleColumns + `
+		FROM report_schedules
+		WHERE organization_id = $1 AND deleted_at IS NULL AND enabled
+		  AND next_run_at <= $2
+		ORDER BY next_run_at
+	`
+	return r.query(ctx, query, orgID, now)
+}
+
+func (r *ReportScheduleRepository) query(ctx context.Context, query string, args ...any) ([]domain.ReportSchedule, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []domain.ReportSchedule
+	for rows.Next() {
+		s, err := scanReportSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *s)
+	}
+	return schedules, rows.Err()
+}
+
+func (r *ReportScheduleRepository) Create(ctx context.Context, s *domain.ReportSchedule) error {
+	query := `
+		INSERT INTO report_schedules (id, organization_id, name, report, list_id, cron, delivery, recipients, enabled, next_run_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING created_at, updated_at
+	`
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	if s.Recipients == nil {
+		s.Recipients = []string{}
+	}
+	return r.pool.QueryRow(ctx, query,
+		s.ID, s.OrganizationID, s.Name, s.Report, s.ListID, s.Cron, s.Delivery, s.Recipients, s.Enabled, s.NextRunAt, s.CreatedBy,
+	).Scan(&s.CreatedAt, &s.UpdatedAt)
+}
+
+func (r *ReportScheduleRepository) Update(ctx context.Context, s *domain.ReportSchedule) error {
+	query := `
+		UPDATE report_schedules
+		SET name = $2, report = $3, list_id = $4, cron = $5, delivery = $6, recipients = $7, enabled = $8, next_run_at = $9
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	if s.Recipients == nil {
+		s.Recipients = []string{}
+	}
+	return r.pool.QueryRow(ctx, query,
+		s.ID, s.Name, s.Report, s.ListID, s.Cron, s.Delivery, s.Recipients, s.Enabled, s.NextRunAt,
+	).Scan(&s.UpdatedAt)
+}
+
+func (r *ReportScheduleRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE report_schedules SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// MarkRun records when the schedule last ran and when it runs next (nil = never)
+func (r *ReportScheduleRepository) MarkRun(ctx context.Context, id uuid.UUID, ranAt time.Time, nextRunAt *time.Time) error {
+	query := `UPDATE report_schedules SET last_run_at = $2, next_run_at = $3 WHERE id = $1`
+	_, err := r.pool.Exec(ctx, query, id, ranAt, nextRunAt)
+	return err
+}
+
+// CreateRun adds a run to the schedule's history
+func (r *ReportScheduleRepository) CreateRun(ctx context.Context, run *domain.ReportRun) error {
+	query := `
+		INSERT INTO report_runs (id, schedule_id, started_at, finished_at, status, error, file_key, size_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	if run.ID == uuid.Nil {
+		run.ID = uuid.New()
+	}
+	_, err := r.pool.Exec(ctx, query,
+		run.ID, run.ScheduleID, run.StartedAt, run.FinishedAt, run.Status, run.Error, run.FileKey, run.SizeBytes,
+	)
+	return err
+}
+
+// ListRuns returns the most recent runs of a schedule, newest first
+func (r *ReportScheduleRepository) ListRuns(ctx context.Context, scheduleID uuid.UUID, limit int) ([]domain.ReportRun, error) {
+	query := `
+		SELECT id, schedule_id, started_at, finished_at, status, error, file_key, size_bytes
+		FROM report_runs
+		WHERE schedule_id = $1
+		ORDER BY started_at DESC
+		LIMIT $2
+	`
+	rows, err := r.pool.Query(ctx, query, scheduleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []domain.ReportRun
+	for rows.Next() {
+		var run domain.ReportRun
+		if err := rows.Scan(
+			&run.ID, &run.ScheduleID, &run.StartedAt, &run.FinishedAt, &run.Status, &run.Error, &run.FileKey, &run.SizeBytes,
+		); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_ReportScheduleRepository_ListDueAndMarkRun(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewReportScheduleRepository(testDB.Pool)
+	now := time.Now().UTC().Truncate(time.Second)
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+
+	due := &domain.ReportSchedule{
+		OrganizationID: org.ID, Name: "Insurance", Report: domain.ReportInsurance, Cron: "@daily",
+		Delivery: domain.ReportDeliveryEmail, Recipients: []string{"jo@example.com"}, Enabled: true, NextRunAt: &past,
+	}
+	if err := repo.Create(ctx, due); err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+	repo.Create(ctx, &domain.ReportSchedule{
+		OrganizationID: org.ID, Name: "Later", Report: domain.ReportEnergy, Cron: "@daily",
+		Delivery: domain.ReportDeliveryStorage, Enabled: true, NextRunAt: &future,
+	})
+	repo.Create(ctx, &domain.ReportSchedule{
+		OrganizationID: org.ID, Name: "Disabled", Report: domain.ReportCosts, Cron: "@daily",
+		Delivery: domain.ReportDeliveryStorage, NextRunAt: &past,
+	})
+
+	schedules, err := repo.ListDue(ctx, org.ID, now)
+	if err != nil {
+		t.Fatalf("failed to list due schedules: %v", err)
+	}
+	if len(schedules) != 1 || schedules[0].ID != due.ID {
+		t.Fatalf("expected only the due schedule, got %+v", schedules)
+	}
+	if len(schedules[0].Recipients) != 1 || schedules[0].Recipients[0] != "jo@example.com" {
+		t.Errorf("expected recipients to round-trip, got %v", schedules[0].Recipients)
+	}
+
+	if err := repo.MarkRun(ctx, due.ID, now, &future); err != nil {
+		t.Fatalf("failed to mark run: %v", err)
+	}
+	schedules, _ = repo.ListDue(ctx, org.ID, now)
+	if len(schedules) != 0 {
+		t.Errorf("expected no due schedules after the run, got %d", len(schedules))
+	}
+
+	got, _ := repo.GetByID(ctx, due.ID)
+	if got.LastRunAt == nil || !got.LastRunAt.Equal(now) {
+		t.Errorf("expected last run %v, got %v", now, got.LastRunAt)
+	}
+}
+
+func Test_ReportScheduleRepository_Runs(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewReportScheduleRepository(testDB.Pool)
+	schedule := &domain.ReportSchedule{
+		OrganizationID: org.ID, Name: "Costs", Report: domain.ReportCosts, Cron: "@monthly",
+		Delivery: domain.ReportDeliveryStorage, Enabled: true,
+	}
+	if err := repo.Create(ctx, schedule); err != nil {
+		t.Fatalf("failed to create schedule: %v", err)
+	}
+
+	start := time.Now().UTC().Add(-time.Hour)
+	key := "reports/costs.json"
+	msg := "smtp unavailable"
+	repo.CreateRun(ctx, &domain.ReportRun{
+		ScheduleID: schedule.ID, StartedAt: start, FinishedAt: start, Status: domain.ReportRunFailed, Error: &msg,
+	})
+	if err := repo.CreateRun(ctx, &domain.ReportRun{
+		ScheduleID: schedule.ID, StartedAt: start.Add(time.Minute), FinishedAt: start.Add(time.Minute),
+		Status: domain.ReportRunSucceeded, FileKey: &key, SizeBytes: 42,
+	}); err != nil {
+		t.Fatalf("failed to create run: %v", err)
+	}
+
+	runs, err := repo.ListRuns(ctx, schedule.ID, 10)
+	if err != nil {
+		t.Fatalf("failed to list runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].Status != domain.ReportRunSucceeded || runs[0].FileKey == nil || runs[0].SizeBytes != 42 {
+		t.Fatalf("expected newest run first, got %+v", runs)
+	}
+	if runs[1].Error == nil || *runs[1].Error != msg {
+		t.Errorf("expected failed run error, got %+v", runs[1])
+	}
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_ReportingViews_FlattenAssets(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	house, _ := fixtures.CreateLocation(ctx, org.ID, "House", nil)
+	kitchen, _ := fixtures.CreateLocation(ctx, org.ID, "Kitchen", &house.ID)
+
+	price := 250.0
+	asset := &domain.Asset{
+		OrganizationID: org.ID, CategoryID: cat.ID, LocationID: &kitchen.ID, Name: "Dishwasher", Quantity: 2,
+		PurchasePrice: &price, Attributes: json.RawMessage(`{"brand": "Bosch"}`),
+	}
+	if err := fixtures.CreateAssetFull(ctx, asset); err != nil {
+		t.Fatalf("failed to create asset: %v", err)
+	}
+
+	var location string
+	var totalValue float64
+	err := testDB.Pool.QueryRow(ctx,
+		`SELECT location_path, total_value FROM reporting.assets WHERE id = $1`, asset.ID,
+	).Scan(&location, &totalValue)
+	if err != nil {
+		t.Fatalf("failed to query reporting.assets: %v", err)
+	}
+	if location != "House / Kitchen" || totalValue != 500 {
+		t.Errorf("unexpected row: location %q, total value %v", location, totalValue)
+	}
+
+	var value string
+	err = testDB.Pool.QueryRow(ctx,
+		`SELECT value FROM reporting.asset_attributes WHERE asset_id = $1 AND key = 'brand'`, asset.ID,
+	).Scan(&value)
+	if err != nil || value != "Bosch" {
+		t.Errorf("expected brand attribute, got %q (%v)", value, err)
+	}
+
+	// Every view must stay queryable
+	for _, view := range []string{"asset_tags", "warranties", "attachments", "recurring_costs", "categories"} {
+		if _, err := testDB.Pool.Exec(ctx, "SELECT * FROM reporting."+view+" LIMIT 1"); err != nil {
+			t.Errorf("failed to query reporting.%s: %v", view, err)
+		}
+	}
+}
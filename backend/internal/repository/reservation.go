@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type ReservationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewReservationRepository(pool *pgxpool.Pool) *ReservationRepository {
+	return &ReservationRepository{pool: pool}
+}
+
+const reservationColumns = `
+	r.id, r.organization_id, r.asset_id, r.user_id, r.reserved_by, r.starts_at, r.ends_at, r.notes, r.created_at, r.updated_at,
+	a.name, a.high_value
+`
+
+func scanReservation(row pgx.Row) (*domain.AssetReservation, error) {
+	var res domain.AssetReservation
+	err := row.Scan(
+		&res.ID, &res.OrganizationID, &res.AssetID, &res.UserID, &res.ReservedBy, &res.StartsAt, &res.EndsAt, &res.Notes, &res.CreatedAt, &res.UpdatedAt,
+		&res.AssetName, &res.HighValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *ReservationRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.AssetReservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM asset_reservations r
+		JOIN assets a ON a.id = r.asset_id
+		WHERE r.id = $1 AND r.deleted_at IS NULL
+	`
+	res, err := scanReservation(r.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return res, err
+}
+
+// List returns the organization's reservations overlapping [from, to)
+func (r *ReservationRepository) List(ctx context.Context, orgID uuid.UUID, from, to time.Time) ([]domain.AssetReservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM asset_reservations r
+		JOIN assets a ON a.id = r.asset_id AND a.deleted_at IS NULL
+		WHERE r.organization_id = $1 AND r.deleted_at IS NULL
+		  AND r.starts_at < $3 AND r.ends_at > $2
+		ORDER BY r.starts_at
+	`
+	return r.query(ctx, query, orgID, from, to)
+}
+
+// ListByAsset returns the asset's reservations overlapping [from, to)
+func (r *ReservationRepository) ListByAsset(ctx context.Context, assetID uuid.UUID, from, to time.Time) ([]domain.AssetReservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM asset_reservations r
+		JOIN assets a ON a.id = r.asset_id
+		WHERE r.asset_id = $1 AND r.deleted_at IS NULL
+		  AND r.starts_at < $3 AND r.ends_at > $2
+		ORDER BY r.starts_at
+	`
+	return r.query(ctx, query, assetID, from, to)
+}
+
+func (r *ReservationRepository) query(ctx context.Context, query string, args ...any) ([]domain.AssetReservation, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reservations []domain.AssetReservation
+	for rows.Next() {
+		res, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, *res)
+	}
+	return reservations, rows.Err()
+}
+
+// Create inserts a reservation, returning domain.ErrReservationConflict if
+// the period overlaps an existing reservation of the same asset
+func (r *ReservationRepository) Create(ctx context.Context, res *domain.AssetReservation) error {
+	if res.ID == uuid.Nil {
+		res.ID = uuid.New()
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkReservationConflict(ctx, tx, res); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO asset_reservations (id, organization_id, asset_id, user_id, reserved_by, starts_at, ends_at, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query,
+		res.ID, res.OrganizationID, res.AssetID, res.UserID, res.ReservedBy, res.StartsAt, res.EndsAt, res.Notes,
+	).Scan(&res.CreatedAt, &res.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Update saves a reservation, returning domain.ErrReservationConflict if
+// the new period overlaps another reservation of the same asset
+func (r *ReservationRepository) Update(ctx context.Context, res *domain.AssetReservation) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := checkReservationConflict(ctx, tx, res); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE asset_reservations
+		SET reserved_by = $2, starts_at = $3, ends_at = $4, notes = $5
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	if err := tx.QueryRow(ctx, query, res.ID, res.ReservedBy, res.StartsAt, res.EndsAt, res.Notes).Scan(&res.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (r *ReservationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE asset_reservations SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id)
+	return err
+}
+
+// checkReservationConflict locks the asset row so concurrent reservations of
+// the same asset are serialized, then looks for overlapping reservations
+func checkReservationConflict(ctx context.Context, tx pgx.Tx, res *domain.AssetReservation) error {
+	if _, err := tx.Exec(ctx, `SELECT id FROM assets WHERE id = $1 FOR UPDATE`, res.AssetID); err != nil {
+		return err
+	}
+
+	var conflict bool
+	err := tx.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM asset_reservations
+			WHERE asset_id = $1 AND id <> $2 AND deleted_at IS NULL
+			  AND starts_at < $4 AND ends_at > $3
+		)
+	`, res.AssetID, res.ID, res.StartsAt, res.EndsAt).Scan(&conflict)
+	if err != nil {
+		return err
+	}
+	if conflict {
+		return domain.ErrReservationConflict
+	}
+	return nil
+}
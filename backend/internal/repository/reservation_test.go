@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_ReservationRepository_Create_Success(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Tools", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "3D Printer")
+
+	repo := NewReservationRepository(testDB.Pool)
+	start := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+	res := &domain.AssetReservation{
+		OrganizationID: org.ID,
+		AssetID:        asset.ID,
+		ReservedBy:     "Alex",
+		StartsAt:       start,
+		EndsAt:         start.Add(3 * time.Hour),
+	}
+
+	if err := repo.Create(ctx, res); err != nil {
+		t.Fatalf("failed to create reservation: %v", err)
+	}
+	if res.CreatedAt.IsZero() {
+		t.Error("expected CreatedAt to be set")
+	}
+
+	got, err := repo.GetByID(ctx, res.ID)
+	if err != nil {
+		t.Fatalf("failed to get reservation: %v", err)
+	}
+	if got == nil || got.ReservedBy != "Alex" || got.AssetName != "3D Printer" {
+		t.Errorf("unexpected reservation: %+v", got)
+	}
+}
+
+func Test_ReservationRepository_Create_Conflict(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Tools", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "3D Printer")
+
+	repo := NewReservationRepository(testDB.Pool)
+	start := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+	first := &domain.AssetReservation{OrganizationID: org.ID, AssetID: asset.ID, ReservedBy: "Alex", StartsAt: start, EndsAt: start.Add(3 * time.Hour)}
+	if err := repo.Create(ctx, first); err != nil {
+		t.Fatalf("failed to create reservation: %v", err)
+	}
+
+	overlapping := &domain.AssetReservation{OrganizationID: org.ID, AssetID: asset.ID, ReservedBy: "Sam", StartsAt: start.Add(time.Hour), EndsAt: start.Add(5 * time.Hour)}
+	err := repo.Create(ctx, overlapping)
+	if !errors.Is(err, domain.ErrReservationConflict) {
+		t.Fatalf("expected ErrReservationConflict, got %v", err)
+	}
+
+	// Back-to-back reservations do not conflict
+	adjacent := &domain.AssetReservation{OrganizationID: org.ID, AssetID: asset.ID, ReservedBy: "Sam", StartsAt: start.Add(3 * time.Hour), EndsAt: start.Add(5 * time.Hour)}
+	if err := repo.Create(ctx, adjacent); err != nil {
+		t.Fatalf("expected adjacent reservation to succeed, got %v", err)
+	}
+}
+
+func Test_ReservationRepository_Update_IgnoresSelf(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Tools", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "3D Printer")
+
+	repo := NewReservationRepository(testDB.Pool)
+	start := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+	res := &domain.AssetReservation{OrganizationID: org.ID, AssetID: asset.ID, ReservedBy: "Alex", StartsAt: start, EndsAt: start.Add(3 * time.Hour)}
+	_ = repo.Create(ctx, res)
+
+	res.EndsAt = start.Add(4 * time.Hour)
+	if err := repo.Update(ctx, res); err != nil {
+		t.Fatalf("failed to extend reservation: %v", err)
+	}
+}
+
+func Test_ReservationRepository_ListByAsset_FiltersPeriod(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Tools", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "3D Printer")
+
+	repo := NewReservationRepository(testDB.Pool)
+	start := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+	_ = repo.Create(ctx, &domain.AssetReservation{OrganizationID: org.ID, AssetID: asset.ID, ReservedBy: "Alex", StartsAt: start, EndsAt: start.Add(time.Hour)})
+	_ = repo.Create(ctx, &domain.AssetReservation{OrganizationID: org.ID, AssetID: asset.ID, ReservedBy: "Sam", StartsAt: start.Add(48 * time.Hour), EndsAt: start.Add(49 * time.Hour)})
+
+	got, err := repo.ListByAsset(ctx, asset.ID, start, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("failed to list reservations: %v", err)
+	}
+	if len(got) != 1 || got[0].ReservedBy != "Alex" {
+		t.Errorf("expected only Alex's reservation, got %+v", got)
+	}
+
+	all, _ := repo.List(ctx, org.ID, start, start.Add(72*time.Hour))
+	if len(all) != 2 {
+		t.Errorf("expected 2 reservations, got %d", len(all))
+	}
+}
+
+func Test_ReservationRepository_Delete_FreesPeriod(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Tools", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "3D Printer")
+
+	repo := NewReservationRepository(testDB.Pool)
+	start := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+	res := &domain.AssetReservation{OrganizationID: org.ID, AssetID: asset.ID, ReservedBy: "Alex", StartsAt: start, EndsAt: start.Add(time.Hour)}
+	_ = repo.Create(ctx, res)
+
+	if err := repo.Delete(ctx, res.ID); err != nil {
+		t.Fatalf("failed to delete reservation: %v", err)
+	}
+
+	again := &domain.AssetReservation{OrganizationID: org.ID, AssetID: asset.ID, ReservedBy: "Sam", StartsAt: start, EndsAt: start.Add(time.Hour)}
+	if err := repo.Create(ctx, again); err != nil {
+		t.Errorf("expected period to be free after delete, got %v", err)
+	}
+}
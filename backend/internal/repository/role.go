@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type RoleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRoleRepository(pool *pgxpool.Pool) *RoleRepository {
+	return &RoleRepository{pool: pool}
+}
+
+const roleColumns = `id, organization_id, name, description, permissions, created_at, updated_at`
+
+func scanRole(row pgx.Row) (*domain.Role, error) {
+	var role domain.Role
+	err := row.Scan(
+		&role.ID, &role.OrganizationID, &role.Name, &role.Description, &role.Permissions,
+		&role.CreatedAt, &role.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// List returns the custom roles of an organization, by name
+func (r *RoleRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles WHERE organization_id = $1 ORDER BY name`
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []domain.Role
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+	return roles, rows.Err()
+}
+
+// GetByID returns a custom role, or nil if it does not exist
+func (r *RoleRepository) GetByID(ctx context.Context, orgID, id uuid.UUID) (*domain.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles WHERE organization_id = $1 AND id = $2`
+	role, err := scanRole(r.pool.QueryRow(ctx, query, orgID, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return role, err
+}
+
+// GetByName returns a custom role, or nil if it does not exist
+func (r *RoleRepository) GetByName(ctx context.Context, orgID uuid.UUID, name domain.UserRole) (*domain.Role, error) {
+	query := `SELECT ` + roleColumns + ` FROM roles WHERE organization_id = $1 AND name = $2`
+	role, err := scanRole(r.pool.QueryRow(ctx, query, orgID, name))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return role, err
+}
+
+// Create stores a custom role
+func (r *RoleRepository) Create(ctx context.Context, role *domain.Role) error {
+	if role.Permissions == nil {
+		role.Permissions = []domain.Permission{}
+	}
+	query := `
+		INSERT INTO roles (organization_id, name, description, permissions)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		role.OrganizationID, role.Name, role.Description, role.Permissions,
+	).Scan(&role.ID, &role.CreatedAt, &role.UpdatedAt)
+}
+
+// Update changes the description and permissions of a custom role; its name
+// is immutable as users reference it
+func (r *RoleRepository) Update(ctx context.Context, role *domain.Role) error {
+	if role.Permissions == nil {
+		role.Permissions = []domain.Permission{}
+	}
+	query := `
+		UPDATE roles SET description = $3, permissions = $4
+		WHERE organization_id = $1 AND id = $2
+		RETURNING updated_at
+	`
+	return r.pool.QueryRow(ctx, query,
+		role.OrganizationID, role.ID, role.Description, role.Permissions,
+	).Scan(&role.UpdatedAt)
+}
+
+// Delete removes a custom role
+func (r *RoleRepository) Delete(ctx context.Context, orgID, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM roles WHERE organization_id = $1 AND id = $2`, orgID, id)
+	return err
+}
+
+// CountUsers returns the number of users assigned a role
+func (r *RoleRepository) CountUsers(ctx context.Context, orgID uuid.UUID, name domain.UserRole) (int64, error) {
+	query := `SELECT COUNT(*) FROM users WHERE organization_id = $1 AND role = $2 AND deleted_at IS NULL`
+	var count int64
+	err := r.pool.QueryRow(ctx, query, orgID, name).Scan(&count)
+	return count, err
+}
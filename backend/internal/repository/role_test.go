@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_RoleRepository_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	repo := NewRoleRepository(testDB.Pool)
+
+	role := &domain.Role{
+		OrganizationID: org.ID,
+		Name:           "viewer",
+		Description:    "Read-only access",
+		Permissions:    []domain.Permission{domain.PermissionAssetsRead},
+	}
+	if err := repo.Create(ctx, role); err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+
+	found, err := repo.GetByName(ctx, org.ID, "viewer")
+	if err != nil || found == nil || found.ID != role.ID {
+		t.Fatalf("expected role, got %+v (%v)", found, err)
+	}
+	if !found.Has(domain.PermissionAssetsRead) || found.Has(domain.PermissionAssetsWrite) {
+		t.Errorf("unexpected permissions %v", found.Permissions)
+	}
+	if found, _ := repo.GetByName(ctx, org.ID, "editor"); found != nil {
+		t.Errorf("expected no role, got %+v", found)
+	}
+
+	role.Permissions = append(role.Permissions, domain.PermissionAssetsWrite)
+	if err := repo.Update(ctx, role); err != nil {
+		t.Fatalf("failed to update role: %v", err)
+	}
+	found, _ = repo.GetByID(ctx, org.ID, role.ID)
+	if found == nil || !found.Has(domain.PermissionAssetsWrite) {
+		t.Errorf("expected updated permissions, got %+v", found)
+	}
+
+	user, _ := fixtures.CreateUser(ctx, org.ID, "jane@example.com")
+	if _, err := testDB.Pool.Exec(ctx, `UPDATE users SET role = 'viewer' WHERE id = $1`, user.ID); err != nil {
+		t.Fatalf("failed to assign role: %v", err)
+	}
+	if count, err := repo.CountUsers(ctx, org.ID, "viewer"); err != nil || count != 1 {
+		t.Errorf("expected 1 user with role, got %d (%v)", count, err)
+	}
+
+	if err := repo.Delete(ctx, org.ID, role.ID); err != nil {
+		t.Fatalf("failed to delete role: %v", err)
+	}
+	roles, err := repo.List(ctx, org.ID)
+	if err != nil || len(roles) != 0 {
+		t.Errorf("expected no roles, got %d (%v)", len(roles), err)
+	}
+}
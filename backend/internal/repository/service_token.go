@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+const serviceTokenColumns = `id, user_id, name, token_hash, scopes, created_at, last_used_at, expires_at`
+
+func scanServiceToken(row pgx.Row) (*domain.ServiceToken, error) {
+	var t domain.ServiceToken
+	err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.TokenHash, &t.Scopes, &t.CreatedAt, &t.LastUsedAt, &t.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CreateServiceToken stores a new token of a service account
+func (r *UserRepository) CreateServiceToken(ctx context.Context, t *domain.ServiceToken) error {
+	query := `
+		INSERT INTO service_tokens (user_id, name, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return r.pool.QueryRow(ctx, query, t.UserID, t.Name, t.TokenHash, t.Scopes, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+}
+
+// GetServiceTokenByHash returns the token with a hash, or nil if there is none
+func (r *UserRepository) GetServiceTokenByHash(ctx context.Context, tokenHash string) (*domain.ServiceToken, error) {
+	query := `SELECT ` + serviceTokenColumns + ` FROM service_tokens WHERE token_hash = $1`
+	t, err := scanServiceToken(r.pool.QueryRow(ctx, query, tokenHash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return t, err
+}
+
+// ListServiceTokens returns the tokens of a service account, oldest first
+func (r *UserRepository) ListServiceTokens(ctx context.Context, userID uuid.UUID) ([]domain.ServiceToken, error) {
+	query := `SELECT ` + serviceTokenColumns + ` FROM service_tokens WHERE user_id = $1 ORDER BY created_at`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []domain.ServiceToken
+	for rows.Next() {
+		t, err := scanServiceToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, rows.Err()
+}
+
+// TouchServiceToken records that a token was used
+func (r *UserRepository) TouchServiceToken(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `UPDATE service_tokens SET last_used_at = NOW() WHERE id = $1`, id)
+	return err
+}
+
+// DeleteServiceToken revokes a token of a service account, reporting whether
+// it existed
+func (r *UserRepository) DeleteServiceToken(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM service_tokens WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_UserRepository_ServiceToken_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	repo := NewUserRepository(testDB.Pool)
+
+	name := "Grafana"
+	account := &domain.User{OrganizationID: org.ID, Email: "grafana@service.invalid", DisplayName: &name, Role: domain.UserRoleUser, ServiceAccount: true}
+	if err := repo.Create(ctx, account); err != nil {
+		t.Fatalf("failed to create service account: %v", err)
+	}
+	loaded, err := repo.GetByID(ctx, account.ID)
+	if err != nil || loaded == nil || !loaded.ServiceAccount {
+		t.Fatalf("expected a service account, got %+v (%v)", loaded, err)
+	}
+
+	token := &domain.ServiceToken{UserID: account.ID, Name: "dashboard", TokenHash: "hash-dashboard", Scopes: []domain.Permission{domain.PermissionAssetsRead}}
+	if err := repo.CreateServiceToken(ctx, token); err != nil {
+		t.Fatalf("failed to create token: %v", err)
+	}
+
+	found, err := repo.GetServiceTokenByHash(ctx, "hash-dashboard")
+	if err != nil || found == nil || found.ID != token.ID {
+		t.Fatalf("expected token, got %+v (%v)", found, err)
+	}
+	if !slices.Equal(found.Scopes, []domain.Permission{domain.PermissionAssetsRead}) || found.LastUsedAt != nil {
+		t.Errorf("unexpected token %+v", found)
+	}
+
+	if err := repo.TouchServiceToken(ctx, token.ID); err != nil {
+		t.Fatalf("failed to touch token: %v", err)
+	}
+	tokens, err := repo.ListServiceTokens(ctx, account.ID)
+	if err != nil || len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Fatalf("expected one used token, got %+v (%v)", tokens, err)
+	}
+
+	if deleted, _ := repo.DeleteServiceToken(ctx, org.ID, token.ID); deleted {
+		t.Error("expected token of another user not to be deleted")
+	}
+	if deleted, err := repo.DeleteServiceToken(ctx, account.ID, token.ID); err != nil || !deleted {
+		t.Fatalf("expected token to be deleted, got %v (%v)", deleted, err)
+	}
+	if found, _ := repo.GetServiceTokenByHash(ctx, "hash-dashboard"); found != nil {
+		t.Error("expected revoked token to be gone")
+	}
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SettingsRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSettingsRepository(pool *pgxpool.Pool) *SettingsRepository {
+	return &SettingsRepository{pool: pool}
+}
+
+// Get decodes the setting into dest, reporting false if it is not set
+func (r *SettingsRepository) Get(ctx context.Context, orgID uuid.UUID, key string, dest any) (bool, error) {
+	query := `SELECT value FROM organization_settings WHERE organization_id = $1 AND key = $2`
+	var raw []byte
+	err := r.pool.QueryRow(ctx, query, orgID, key).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, json.Unmarshal(raw, dest)
+}
+
+// Set stores value (encoded as JSON) under key, replacing any previous value
+func (r *SettingsRepository) Set(ctx context.Context, orgID uuid.UUID, key string, value any) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO organization_settings (organization_id, key, value)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, key) DO UPDATE SET value = EXCLUDED.value
+	`
+	_, err = r.pool.Exec(ctx, query, orgID, key, raw)
+	return err
+}
+
+func (r *SettingsRepository) Delete(ctx context.Context, orgID uuid.UUID, key string) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM organization_settings WHERE organization_id = $1 AND key = $2`, orgID, key)
+	return err
+}
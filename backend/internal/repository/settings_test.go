@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_SettingsRepository_Get_NotSet(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewSettingsRepository(testDB.Pool)
+	var branding domain.Branding
+	found, err := repo.Get(ctx, org.ID, domain.SettingBranding, &branding)
+	if err != nil {
+		t.Fatalf("failed to get setting: %v", err)
+	}
+	if found {
+		t.Error("expected setting to be unset")
+	}
+}
+
+func Test_SettingsRepository_Set_Overwrites(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewSettingsRepository(testDB.Pool)
+	if err := repo.Set(ctx, org.ID, domain.SettingBranding, domain.Branding{Title: "First"}); err != nil {
+		t.Fatalf("failed to set setting: %v", err)
+	}
+	if err := repo.Set(ctx, org.ID, domain.SettingBranding, domain.Branding{Title: "Second", AccentColor: "#fff"}); err != nil {
+		t.Fatalf("failed to overwrite setting: %v", err)
+	}
+
+	var branding domain.Branding
+	found, err := repo.Get(ctx, org.ID, domain.SettingBranding, &branding)
+	if err != nil {
+		t.Fatalf("failed to get setting: %v", err)
+	}
+	if !found {
+		t.Fatal("expected setting to be found")
+	}
+	if branding.Title != "Second" || branding.AccentColor != "#fff" {
+		t.Errorf("unexpected branding: %+v", branding)
+	}
+}
+
+func Test_SettingsRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewSettingsRepository(testDB.Pool)
+	if err := repo.Set(ctx, org.ID, domain.SettingBranding, domain.Branding{Title: "Attic"}); err != nil {
+		t.Fatalf("failed to set setting: %v", err)
+	}
+	if err := repo.Delete(ctx, org.ID, domain.SettingBranding); err != nil {
+		t.Fatalf("failed to delete setting: %v", err)
+	}
+
+	var branding domain.Branding
+	found, err := repo.Get(ctx, org.ID, domain.SettingBranding, &branding)
+	if err != nil {
+		t.Fatalf("failed to get setting: %v", err)
+	}
+	if found {
+		t.Error("expected setting to be deleted")
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// ListLowStock returns the organization's held, active assets whose quantity
+// dropped below their low-stock threshold, by category and name
+func (r *AssetRepository) ListLowStock(ctx context.Context, orgID uuid.UUID) ([]domain.ShoppingListItem, error) {
+	query := `
+		SELECT a.id, a.name, a.quantity, a.min_quantity, a.min_quantity - a.quantity, c.name, l.name, a.high_value, a.low_stock_notified_at
+		FROM assets a
+		JOIN categories c ON c.id = a.category_id
+		LEFT JOIN locations l ON l.id = a.location_id AND l.deleted_at IS NULL
+		WHERE a.organization_id = $1 AND a.deleted_at IS NULL AND a.archived_at IS NULL AND a.status IN ` + heldStatuses + `
+		  AND a.quantity < a.min_quantity
+		ORDER BY c.name, a.name
+	`
+	rows, err := r.pool.Query(ctx, query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.ShoppingListItem{}
+	for rows.Next() {
+		var i domain.ShoppingListItem
+		if err := rows.Scan(
+			&i.AssetID, &i.Name, &i.Quantity, &i.MinQuantity, &i.Needed, &i.CategoryName, &i.LocationName, &i.HighValue, &i.NotifiedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// MarkLowStockNotified records that admins were told the assets are low on
+// stock, until they are restocked
+func (r *AssetRepository) MarkLowStockNotified(ctx context.Context, ids []uuid.UUID) error {
+	_, err := r.pool.Exec(ctx, `UPDATE assets SET low_stock_notified_at = NOW() WHERE id = ANY($1)`, ids)
+	return err
+}
+
+// adjustQuantityQuery adds $2 to the quantity of asset $1, clearing the
+// low-stock notification once it is restocked
+const adjustQuantityQuery = `
+	UPDATE assets
+	SET quantity = GREATEST(quantity + $2, 0),
+	    low_stock_notified_at = CASE WHEN GREATEST(quantity + $2, 0) < min_quantity THEN low_stock_notified_at END
+	WHERE id = $1 AND deleted_at IS NULL
+	RETURNING quantity
+`
+
+// AdjustQuantity adds delta (negative to take some) to the quantity of an
+// asset, which doesn't go below zero, and returns the new quantity. It
+// returns nil if there is no such asset.
+func (r *AssetRepository) AdjustQuantity(ctx context.Context, id uuid.UUID, delta int) (*int, error) {
+	var quantity int
+	err := r.pool.QueryRow(ctx, adjustQuantityQuery, id, delta).Scan(&quantity)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &quantity, nil
+}
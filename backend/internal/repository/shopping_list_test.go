@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_AssetRepository_LowStock(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	category, _ := fixtures.CreateCategory(ctx, org.ID, "Pantry", nil)
+
+	repo := NewAssetRepository(testDB.Pool)
+	three := 3
+	coffee := &domain.Asset{OrganizationID: org.ID, CategoryID: category.ID, Name: "Coffee beans", Quantity: 4, MinQuantity: &three}
+	soap := &domain.Asset{OrganizationID: org.ID, CategoryID: category.ID, Name: "Dish soap", Quantity: 1}
+	for _, a := range []*domain.Asset{coffee, soap} {
+		if err := repo.Create(ctx, a); err != nil {
+			t.Fatalf("failed to create asset: %v", err)
+		}
+	}
+
+	if items, _ := repo.ListLowStock(ctx, org.ID); len(items) != 0 {
+		t.Fatalf("expected nothing low on stock, got %+v", items)
+	}
+
+	quantity, err := repo.AdjustQuantity(ctx, coffee.ID, -3)
+	if err != nil || quantity == nil || *quantity != 1 {
+		t.Fatalf("expected 1 left, got %v, %v", quantity, err)
+	}
+	items, err := repo.ListLowStock(ctx, org.ID)
+	if err != nil || len(items) != 1 || items[0].AssetID != coffee.ID || items[0].Needed != 2 || items[0].CategoryName != "Pantry" {
+		t.Fatalf("expected the coffee to be low on stock, got %+v, %v", items, err)
+	}
+
+	if err := repo.MarkLowStockNotified(ctx, []uuid.UUID{coffee.ID}); err != nil {
+		t.Fatalf("failed to mark notified: %v", err)
+	}
+	// Using more doesn't notify again, restocking does next time
+	_, _ = repo.AdjustQuantity(ctx, coffee.ID, -5)
+	if items, _ := repo.ListLowStock(ctx, org.ID); len(items) != 1 || items[0].Quantity != 0 || items[0].NotifiedAt == nil {
+		t.Errorf("expected the coffee to stay notified at 0, got %+v", items)
+	}
+	_, _ = repo.AdjustQuantity(ctx, coffee.ID, 3)
+	_, _ = repo.AdjustQuantity(ctx, coffee.ID, -1)
+	if items, _ := repo.ListLowStock(ctx, org.ID); len(items) != 1 || items[0].NotifiedAt != nil {
+		t.Errorf("expected the restock to reset the notification, got %+v", items)
+	}
+
+	if quantity, err := repo.AdjustQuantity(ctx, uuid.New(), 1); quantity != nil || err != nil {
+		t.Errorf("expected no asset, got %v, %v", quantity, err)
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type ShortLinkRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewShortLinkRepository(pool *pgxpool.Pool) *ShortLinkRepository {
+	return &ShortLinkRepository{pool: pool}
+}
+
+// Create stores a short link. If the organization already has a link to the
+// same path and state, that link is returned instead (link.Code is replaced)
+// and created is false.
+func (r *ShortLinkRepository) Create(ctx context.Context, link *domain.ShortLink) (created bool, err error) {
+	if link.State == nil {
+		link.State = map[string][]string{}
+	}
+	query := `
+		INSERT INTO short_links (code, organization_id, created_by, path, state)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (organization_id, path, state) DO UPDATE SET path = EXCLUDED.path
+		RETURNING code, created_by, created_at, last_used_at, xmax = 0 -- xmax is 0 for inserted rows
+	`
+	err = r.pool.QueryRow(ctx, query, link.Code, link.OrganizationID, link.CreatedBy, link.Path, link.State).Scan(
+		&link.Code, &link.CreatedBy, &link.CreatedAt, &link.LastUsedAt, &created,
+	)
+	return created, err
+}
+
+// Resolve returns the short link with code and records its use, or nil if
+// there is none
+func (r *ShortLinkRepository) Resolve(ctx context.Context, code string) (*domain.ShortLink, error) {
+	query := `
+		UPDATE short_links SET last_used_at = NOW()
+		WHERE code = $1
+		RETURNING code, organization_id, created_by, path, state, created_at, last_used_at
+	`
+	var l domain.ShortLink
+	err := r.pool.QueryRow(ctx, query, code).Scan(
+		&l.Code, &l.OrganizationID, &l.CreatedBy, &l.Path, &l.State, &l.CreatedAt, &l.LastUsedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
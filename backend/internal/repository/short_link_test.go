@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_ShortLinkRepository_Create_ReusesExistingLink(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewShortLinkRepository(testDB.Pool)
+	state := map[string][]string{"category": {"books"}, "sort": {"name"}}
+	first := &domain.ShortLink{Code: "first", OrganizationID: org.ID, Path: "/assets", State: state}
+	created, err := repo.Create(ctx, first)
+	if err != nil {
+		t.Fatalf("failed to create link: %v", err)
+	}
+	if !created {
+		t.Error("expected a new link to be created")
+	}
+
+	second := &domain.ShortLink{Code: "second", OrganizationID: org.ID, Path: "/assets", State: state}
+	created, err = repo.Create(ctx, second)
+	if err != nil {
+		t.Fatalf("failed to create link: %v", err)
+	}
+	if created || second.Code != "first" {
+		t.Errorf("expected the existing link, got code %q (created %v)", second.Code, created)
+	}
+}
+
+func Test_ShortLinkRepository_Resolve(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+
+	repo := NewShortLinkRepository(testDB.Pool)
+	link := &domain.ShortLink{Code: "abc", OrganizationID: org.ID, Path: "/assets", State: map[string][]string{"q": {"lamp"}}}
+	if _, err := repo.Create(ctx, link); err != nil {
+		t.Fatalf("failed to create link: %v", err)
+	}
+
+	got, err := repo.Resolve(ctx, "abc")
+	if err != nil {
+		t.Fatalf("failed to resolve link: %v", err)
+	}
+	if got == nil || got.Path != "/assets" || got.State["q"][0] != "lamp" {
+		t.Fatalf("unexpected link %+v", got)
+	}
+	if got.LastUsedAt == nil {
+		t.Error("expected last use to be recorded")
+	}
+
+	if got, err := repo.Resolve(ctx, "missing"); err != nil || got != nil {
+		t.Errorf("expected nil for an unknown code, got %v, %v", got, err)
+	}
+}
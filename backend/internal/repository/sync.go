@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+type SyncRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSyncRepository(pool *pgxpool.Pool) *SyncRepository {
+	return &SyncRepository{pool: pool}
+}
+
+// SyncResources are the resource types reported by Changes
+var SyncResources = []string{
+	"assets", "categories", "locations", "conditions", "attributes", "tags",
+	"warranties", "attachments", "lists", "reservations",
+}
+
+// syncChangesQuery classifies every row changed after $2 as created, updated
+// or deleted. Soft-deleted tables use deleted_at; hard-deleted rows come from
+// sync_tombstones. With $4, high-value assets and their rows are left out.
+const syncChangesQuery = `
+	WITH changes (resource, id, created_at, updated_at, deleted_at) AS (
+		SELECT 'assets', id, created_at, updated_at, deleted_at FROM assets
+		WHERE organization_id = $1 AND NOT ($4 AND high_value)
+		UNION ALL
+		SELECT 'categories', id, created_at, updated_at, deleted_at FROM categories WHERE organization_id = $1
+		UNION ALL
+		SELECT 'locations', id, created_at, updated_at, deleted_at FROM locations WHERE organization_id = $1
+		UNION ALL
+		SELECT 'conditions', id, created_at, updated_at, deleted_at FROM conditions WHERE organization_id = $1
+		UNION ALL
+		SELECT 'attributes', id, created_at, updated_at, deleted_at FROM attributes WHERE organization_id = $1
+		UNION ALL
+		SELECT 'tags', id, created_at, created_at, NULL FROM tags WHERE organization_id = $1
+		UNION ALL
+		SELECT 'warranties', w.id, w.created_at, w.updated_at, NULL
+		FROM warranties w JOIN assets a ON a.id = w.asset_id WHERE a.organization_id = $1 AND NOT ($4 AND a.high_value)
+		UNION ALL
+		SELECT 'attachments', att.id, att.created_at, att.created_at, NULL
+		FROM attachments att JOIN assets a ON a.id = att.asset_id WHERE a.organization_id = $1 AND NOT ($4 AND a.high_value)
+		UNION ALL
+		SELECT 'lists', id, created_at, updated_at, deleted_at FROM asset_lists WHERE organization_id = $1
+		UNION ALL
+		SELECT 'reservations', r.id, r.created_at, r.updated_at, r.deleted_at
+		FROM asset_reservations r JOIN assets a ON a.id = r.asset_id WHERE r.organization_id = $1 AND NOT ($4 AND a.high_value)
+		UNION ALL
+		SELECT resource_type, resource_id, NULL, deleted_at, deleted_at FROM sync_tombstones WHERE organization_id = $1
+	)
+	SELECT resource, id,
+	       CASE
+	           WHEN deleted_at IS NOT NULL THEN 'deleted'
+	           WHEN created_at > $2 THEN 'created'
+	           ELSE 'updated'
+	       END
+	FROM changes
+	WHERE updated_at > $2 AND updated_at <= $3
+	   OR deleted_at > $2 AND deleted_at <= $3
+	ORDER BY resource, id
+`
+
+// Changes returns the IDs of everything created, updated or deleted in an
+// organization after since, up to the returned server time. With
+// noHighValue, high-value assets and their warranties, attachments and
+// reservations are left out.
+func (r *SyncRepository) Changes(ctx context.Context, orgID uuid.UUID, since time.Time, noHighValue bool) (*domain.SyncChanges, error) {
+	result := &domain.SyncChanges{
+		Since:   since,
+		Changes: make(map[string]*domain.SyncResourceChanges, len(SyncResources)),
+	}
+	for _, resource := range SyncResources {
+		result.Changes[resource] = &domain.SyncResourceChanges{
+			Created: []uuid.UUID{},
+			Updated: []uuid.UUID{},
+			Deleted: []uuid.UUID{},
+		}
+	}
+
+	// Fix the upper bound up front so rows changed while we query are picked
+	// up by the next sync instead of being missed
+	if err := r.pool.QueryRow(ctx, `SELECT NOW()`).Scan(&result.ServerTime); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.pool.Query(ctx, syncChangesQuery, orgID, since, result.ServerTime, noHighValue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var resource, kind string
+		var id uuid.UUID
+		if err := rows.Scan(&resource, &id, &kind); err != nil {
+			return nil, err
+		}
+		changes, ok := result.Changes[resource]
+		if !ok {
+			continue
+		}
+		switch kind {
+		case "created":
+			changes.Created = append(changes.Created, id)
+		case "updated":
+			changes.Updated = append(changes.Updated, id)
+		case "deleted":
+			changes.Deleted = append(changes.Deleted, id)
+		}
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_SyncRepository_Changes_InitialSyncReturnsAllAsCreated(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Laptop")
+
+	repo := NewSyncRepository(testDB.Pool)
+	changes, err := repo.Changes(ctx, org.ID, time.Time{}, false)
+	if err != nil {
+		t.Fatalf("failed to get changes: %v", err)
+	}
+
+	if !slices.Contains(changes.Changes["assets"].Created, asset.ID) {
+		t.Error("expected asset to be reported as created")
+	}
+	if !slices.Contains(changes.Changes["categories"].Created, cat.ID) {
+		t.Error("expected category to be reported as created")
+	}
+	if changes.ServerTime.IsZero() {
+		t.Error("expected server time to be set")
+	}
+}
+
+func Test_SyncRepository_Changes_ReportsUpdatesAndTombstones(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	updated, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Laptop")
+	deleted, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Phone")
+	att, _ := fixtures.CreateAttachment(ctx, updated.ID, "receipt.pdf", "key/receipt.pdf")
+
+	repo := NewSyncRepository(testDB.Pool)
+	first, _ := repo.Changes(ctx, org.ID, time.Time{}, false)
+
+	assets := NewAssetRepository(testDB.Pool)
+	updated.Name = "Laptop Pro"
+	if err := assets.Update(ctx, updated); err != nil {
+		t.Fatalf("failed to update asset: %v", err)
+	}
+	if err := assets.Delete(ctx, deleted.ID); err != nil {
+		t.Fatalf("failed to delete asset: %v", err)
+	}
+	if err := NewAttachmentRepository(testDB.Pool).Delete(ctx, att.ID); err != nil {
+		t.Fatalf("failed to delete attachment: %v", err)
+	}
+
+	changes, err := repo.Changes(ctx, org.ID, first.ServerTime, false)
+	if err != nil {
+		t.Fatalf("failed to get changes: %v", err)
+	}
+
+	assetChanges := changes.Changes["assets"]
+	if !slices.Contains(assetChanges.Updated, updated.ID) {
+		t.Error("expected updated asset to be reported as updated")
+	}
+	if !slices.Contains(assetChanges.Deleted, deleted.ID) {
+		t.Error("expected soft-deleted asset to be reported as deleted")
+	}
+	if len(assetChanges.Created) != 0 {
+		t.Errorf("expected no created assets, got %v", assetChanges.Created)
+	}
+	if !slices.Contains(changes.Changes["attachments"].Deleted, att.ID) {
+		t.Error("expected hard-deleted attachment tombstone")
+	}
+	if len(changes.Changes["categories"].Updated) != 0 {
+		t.Error("expected unchanged category not to be reported")
+	}
+}
+
+func Test_SyncRepository_Changes_LeavesOutHighValue(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Jewelry", nil)
+	ring, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Diamond ring")
+	watch, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Watch")
+	att, _ := fixtures.CreateAttachment(ctx, ring.ID, "appraisal.pdf", "key/appraisal.pdf")
+	if _, err := testDB.Pool.Exec(ctx, `UPDATE assets SET high_value = true WHERE id = $1`, ring.ID); err != nil {
+		t.Fatalf("failed to flag asset: %v", err)
+	}
+
+	repo := NewSyncRepository(testDB.Pool)
+	changes, err := repo.Changes(ctx, org.ID, time.Time{}, true)
+	if err != nil {
+		t.Fatalf("failed to get changes: %v", err)
+	}
+	if assets := changes.Changes["assets"].Created; slices.Contains(assets, ring.ID) || !slices.Contains(assets, watch.ID) {
+		t.Errorf("expected only the watch, got %v", assets)
+	}
+	if slices.Contains(changes.Changes["attachments"].Created, att.ID) {
+		t.Error("expected the high-value asset's attachment to be left out")
+	}
+
+	changes, err = repo.Changes(ctx, org.ID, time.Time{}, false)
+	if err != nil {
+		t.Fatalf("failed to get changes: %v", err)
+	}
+	if !slices.Contains(changes.Changes["assets"].Created, ring.ID) {
+		t.Error("expected the high-value asset without the filter")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -21,14 +22,14 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	query := `
-		SELECT id, organization_id, oidc_subject, email, display_name, password_hash, role, created_at, updated_at
+		SELECT id, organization_id, oidc_subject, external_id, email, display_name, password_hash, role, disabled_at, time_zone, verified_at, pending_at, service_account, created_at, updated_at
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 	var u domain.User
 	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&u.ID, &u.OrganizationID, &u.OIDCSubject, &u.Email, &u.DisplayName,
-		&u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+		&u.ID, &u.OrganizationID, &u.OIDCSubject, &u.ExternalID, &u.Email, &u.DisplayName,
+		&u.PasswordHash, &u.Role, &u.DisabledAt, &u.TimeZone, &u.VerifiedAt, &u.PendingAt, &u.ServiceAccount, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -41,14 +42,14 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, organization_id, oidc_subject, email, display_name, password_hash, role, created_at, updated_at
+		SELECT id, organization_id, oidc_subject, external_id, email, display_name, password_hash, role, disabled_at, time_zone, verified_at, pending_at, service_account, created_at, updated_at
 		FROM users
 		WHERE LOWER(email) = LOWER($1) AND deleted_at IS NULL
 	`
 	var u domain.User
 	err := r.pool.QueryRow(ctx, query, email).Scan(
-		&u.ID, &u.OrganizationID, &u.OIDCSubject, &u.Email, &u.DisplayName,
-		&u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+		&u.ID, &u.OrganizationID, &u.OIDCSubject, &u.ExternalID, &u.Email, &u.DisplayName,
+		&u.PasswordHash, &u.Role, &u.DisabledAt, &u.TimeZone, &u.VerifiedAt, &u.PendingAt, &u.ServiceAccount, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -61,14 +62,14 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 
 func (r *UserRepository) GetByOIDCSubject(ctx context.Context, subject string) (*domain.User, error) {
 	query := `
-		SELECT id, organization_id, oidc_subject, email, display_name, password_hash, role, created_at, updated_at
+		SELECT id, organization_id, oidc_subject, external_id, email, display_name, password_hash, role, disabled_at, time_zone, verified_at, pending_at, service_account, created_at, updated_at
 		FROM users
 		WHERE oidc_subject = $1 AND deleted_at IS NULL
 	`
 	var u domain.User
 	err := r.pool.QueryRow(ctx, query, subject).Scan(
-		&u.ID, &u.OrganizationID, &u.OIDCSubject, &u.Email, &u.DisplayName,
-		&u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+		&u.ID, &u.OrganizationID, &u.OIDCSubject, &u.ExternalID, &u.Email, &u.DisplayName,
+		&u.PasswordHash, &u.Role, &u.DisabledAt, &u.TimeZone, &u.VerifiedAt, &u.PendingAt, &u.ServiceAccount, &u.CreatedAt, &u.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -80,13 +81,38 @@ func (r *UserRepository) GetByOIDCSubject(ctx context.Context, subject string) (
 }
 
 func (r *UserRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.User, error) {
-	query := `
-		SELECT id, organization_id, oidc_subject, email, display_name, password_hash, role, created_at, updated_at
+	return r.list(ctx, `
+		SELECT id, organization_id, oidc_subject, external_id, email, display_name, password_hash, role, disabled_at, time_zone, verified_at, pending_at, service_account, created_at, updated_at
 		FROM users
 		WHERE organization_id = $1 AND deleted_at IS NULL
 		ORDER BY email
-	`
-	rows, err := r.pool.Query(ctx, query, orgID)
+	`, orgID)
+}
+
+// ListPending returns the self-registered users awaiting approval, oldest first
+func (r *UserRepository) ListPending(ctx context.Context, orgID uuid.UUID) ([]domain.User, error) {
+	return r.list(ctx, `
+		SELECT id, organization_id, oidc_subject, external_id, email, display_name, password_hash, role, disabled_at, time_zone, verified_at, pending_at, service_account, created_at, updated_at
+		FROM users
+		WHERE organization_id = $1 AND pending_at IS NOT NULL AND deleted_at IS NULL
+		ORDER BY pending_at
+	`, orgID)
+}
+
+// Approve lets a self-registered user sign in, reporting whether the user was pending
+func (r *UserRepository) Approve(ctx context.Context, id uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE users SET pending_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND pending_at IS NOT NULL AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *UserRepository) list(ctx context.Context, query string, args ...any) ([]domain.User, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -96,8 +122,8 @@ func (r *UserRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.Us
 	for rows.Next() {
 		var u domain.User
 		if err := rows.Scan(
-			&u.ID, &u.OrganizationID, &u.OIDCSubject, &u.Email, &u.DisplayName,
-			&u.PasswordHash, &u.Role, &u.CreatedAt, &u.UpdatedAt,
+			&u.ID, &u.OrganizationID, &u.OIDCSubject, &u.ExternalID, &u.Email, &u.DisplayName,
+			&u.PasswordHash, &u.Role, &u.DisabledAt, &u.TimeZone, &u.VerifiedAt, &u.PendingAt, &u.ServiceAccount, &u.CreatedAt, &u.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -115,8 +141,8 @@ func (r *UserRepository) Count(ctx context.Context) (int, error) {
 
 func (r *UserRepository) Create(ctx context.Context, u *domain.User) error {
 	query := `
-		INSERT INTO users (id, organization_id, oidc_subject, email, display_name, password_hash, role)
-		VALUES ($1, $2, $3, LOWER($4), $5, $6, $7)
+		INSERT INTO users (id, organization_id, oidc_subject, email, display_name, password_hash, role, external_id, disabled_at, verified_at, pending_at, service_account)
+		VALUES ($1, $2, $3, LOWER($4), $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING created_at, updated_at
 	`
 	if u.ID == uuid.Nil {
@@ -125,34 +151,112 @@ func (r *UserRepository) Create(ctx context.Context, u *domain.User) error {
 	// Normalize email to lowercase
 	u.Email = strings.ToLower(u.Email)
 	return r.pool.QueryRow(ctx, query,
-		u.ID, u.OrganizationID, u.OIDCSubject, u.Email, u.DisplayName, u.PasswordHash, u.Role,
+		u.ID, u.OrganizationID, u.OIDCSubject, u.Email, u.DisplayName, u.PasswordHash, u.Role, u.ExternalID, u.DisabledAt, u.VerifiedAt, u.PendingAt, u.ServiceAccount,
 	).Scan(&u.CreatedAt, &u.UpdatedAt)
 }
 
 func (r *UserRepository) Update(ctx context.Context, u *domain.User) error {
 	query := `
 		UPDATE users
-		SET email = LOWER($2), display_name = $3, role = $4, updated_at = NOW()
+		SET email = LOWER($2), display_name = $3, role = $4, external_id = $5, verified_at = $6, updated_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL
 		RETURNING updated_at
 	`
 	// Normalize email to lowercase
 	u.Email = strings.ToLower(u.Email)
 	return r.pool.QueryRow(ctx, query,
-		u.ID, u.Email, u.DisplayName, u.Role,
+		u.ID, u.Email, u.DisplayName, u.Role, u.ExternalID, u.VerifiedAt,
 	).Scan(&u.UpdatedAt)
 }
 
+// MarkVerified records that the user confirmed email, reporting whether it is
+// still the user's address
+func (r *UserRepository) MarkVerified(ctx context.Context, id uuid.UUID, email string) (bool, error) {
+	query := `
+		UPDATE users SET verified_at = COALESCE(verified_at, NOW())
+		WHERE id = $1 AND email = LOWER($2) AND deleted_at IS NULL
+	`
+	tag, err := r.pool.Exec(ctx, query, id, email)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// maxPasswordHistory is the number of previous password hashes kept per user
+const maxPasswordHistory = 24
+
+// UpdatePassword sets a new password, moving the previous hash to the password
+// history and lifting a required password change
 func (r *UserRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO password_history (user_id, password_hash)
+		SELECT id, password_hash FROM users
+		WHERE id = $1 AND deleted_at IS NULL AND password_hash IS NOT NULL AND password_hash <> ''
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM password_history
+		WHERE user_id = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2
+		)
+	`, id, maxPasswordHistory)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		UPDATE users
-		SET password_hash = $2, updated_at = NOW()
+		SET password_hash = $2, must_change_password = FALSE, updated_at = NOW()
 		WHERE id = $1 AND deleted_at IS NULL
 	`
-	_, err := r.pool.Exec(ctx, query, id, passwordHash)
+	if _, err := tx.Exec(ctx, query, id, passwordHash); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE user_sessions SET must_change_password = FALSE WHERE user_id = $1`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RequirePasswordChange makes the next sessions of a user unusable until the
+// password is changed (see UpdatePassword)
+func (r *UserRepository) RequirePasswordChange(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE users SET must_change_password = TRUE, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.pool.Exec(ctx, query, id)
 	return err
 }
 
+// PasswordHistory returns up to limit previous password hashes, most recent first
+func (r *UserRepository) PasswordHistory(ctx context.Context, id uuid.UUID, limit int) ([]string, error) {
+	query := `SELECT password_hash FROM password_history WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := r.pool.Query(ctx, query, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `
 		UPDATE users
@@ -163,6 +267,28 @@ func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+// SetDisabled deactivates (or reactivates) a user without deleting their data
+func (r *UserRepository) SetDisabled(ctx context.Context, id uuid.UUID, disabled bool) error {
+	query := `
+		UPDATE users
+		SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) ELSE NULL END, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	_, err := r.pool.Exec(ctx, query, id, disabled)
+	return err
+}
+
+// SetTimeZone sets the user's preferred IANA time zone (nil = organization default)
+func (r *UserRepository) SetTimeZone(ctx context.Context, id uuid.UUID, timeZone *string) error {
+	query := `
+		UPDATE users
+		SET time_zone = $2, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+	`
+	_, err := r.pool.Exec(ctx, query, id, timeZone)
+	return err
+}
+
 func (r *UserRepository) LinkOIDC(ctx context.Context, id uuid.UUID, oidcSubject string) error {
 	query := `
 		UPDATE users
@@ -215,13 +341,15 @@ func (r *UserRepository) GetOrCreate(ctx context.Context, orgID uuid.UUID, subje
 		return user, false, nil
 	}
 
-	// Create new user
+	// Create new user; the identity provider owns the address
 	oidcSubject := subject
+	now := time.Now()
 	user = &domain.User{
 		OrganizationID: orgID,
 		OIDCSubject:    &oidcSubject,
 		Email:          email,
 		Role:           domain.UserRoleUser,
+		VerifiedAt:     &now,
 	}
 	if displayName != "" {
 		user.DisplayName = &displayName
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// GetPreferences returns the preferences of a user (empty if never saved)
+func (r *UserRepository) GetPreferences(ctx context.Context, userID uuid.UUID) (*domain.UserPreferences, error) {
+	query := `SELECT preferences FROM user_preferences WHERE user_id = $1`
+	var p domain.UserPreferences
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&p)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &domain.UserPreferences{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SetPreferences replaces the preferences of a user
+func (r *UserRepository) SetPreferences(ctx context.Context, userID uuid.UUID, p *domain.UserPreferences) error {
+	query := `
+		INSERT INTO user_preferences (user_id, preferences)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET preferences = EXCLUDED.preferences, updated_at = NOW()
+	`
+	_, err := r.pool.Exec(ctx, query, userID, p)
+	return err
+}
+
+// SetViewPreferences stores the layout of one view, keeping the other
+// preferences
+func (r *UserRepository) SetViewPreferences(ctx context.Context, userID uuid.UUID, view string, layout json.RawMessage) error {
+	query := `
+		INSERT INTO user_preferences (user_id, preferences)
+		VALUES ($1, jsonb_build_object('views', jsonb_build_object($2::text, $3::jsonb)))
+		ON CONFLICT (user_id) DO UPDATE
+		SET preferences = user_preferences.preferences || jsonb_build_object('views',
+				COALESCE(user_preferences.preferences->'views', '{}') || jsonb_build_object($2::text, $3::jsonb)),
+			updated_at = NOW()
+	`
+	_, err := r.pool.Exec(ctx, query, userID, view, string(layout))
+	return err
+}
+
+// DeleteViewPreferences removes the layout of one view. It reports false if
+// none was stored.
+func (r *UserRepository) DeleteViewPreferences(ctx context.Context, userID uuid.UUID, view string) (bool, error) {
+	query := `
+		UPDATE user_preferences
+		SET preferences = jsonb_set(preferences, '{views}', (preferences->'views') - $2::text), updated_at = NOW()
+		WHERE user_id = $1 AND preferences->'views' ? $2::text
+	`
+	tag, err := r.pool.Exec(ctx, query, userID, view)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
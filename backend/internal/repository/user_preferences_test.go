@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_UserRepository_Preferences_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "prefs@example.com")
+
+	repo := NewUserRepository(testDB.Pool)
+	prefs, err := repo.GetPreferences(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to get preferences: %v", err)
+	}
+	if prefs.PageSize != 0 || prefs.Theme != "" || prefs.Views != nil {
+		t.Errorf("expected empty preferences, got %+v", prefs)
+	}
+
+	for _, theme := range []string{"dark", "light"} {
+		if err := repo.SetPreferences(ctx, user.ID, &domain.UserPreferences{PageSize: 50, Theme: theme}); err != nil {
+			t.Fatalf("failed to set preferences: %v", err)
+		}
+	}
+	prefs, err = repo.GetPreferences(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to get preferences: %v", err)
+	}
+	if prefs.PageSize != 50 || prefs.Theme != "light" {
+		t.Errorf("expected the last saved preferences, got %+v", prefs)
+	}
+}
+
+func Test_UserRepository_ViewPreferences(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "views@example.com")
+
+	repo := NewUserRepository(testDB.Pool)
+	if err := repo.SetViewPreferences(ctx, user.ID, "assets", json.RawMessage(`{"columns":["name"]}`)); err != nil {
+		t.Fatalf("failed to set view preferences: %v", err)
+	}
+	if err := repo.SetPreferences(ctx, user.ID, &domain.UserPreferences{Theme: "dark", Views: map[string]json.RawMessage{"assets": json.RawMessage(`{"columns":["name"]}`)}}); err != nil {
+		t.Fatalf("failed to set preferences: %v", err)
+	}
+	if err := repo.SetViewPreferences(ctx, user.ID, "locations", json.RawMessage(`{"sort":"name"}`)); err != nil {
+		t.Fatalf("failed to set view preferences: %v", err)
+	}
+
+	prefs, err := repo.GetPreferences(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to get preferences: %v", err)
+	}
+	if prefs.Theme != "dark" || len(prefs.Views) != 2 {
+		t.Errorf("expected the theme and two views, got %+v", prefs)
+	}
+
+	deleted, err := repo.DeleteViewPreferences(ctx, user.ID, "assets")
+	if err != nil || !deleted {
+		t.Fatalf("failed to delete view preferences: %v (deleted %v)", err, deleted)
+	}
+	deleted, err = repo.DeleteViewPreferences(ctx, user.ID, "assets")
+	if err != nil || deleted {
+		t.Errorf("expected nothing to delete, got deleted %v (%v)", deleted, err)
+	}
+
+	prefs, _ = repo.GetPreferences(ctx, user.ID)
+	if _, ok := prefs.Views["locations"]; !ok || len(prefs.Views) != 1 {
+		t.Errorf("expected only the locations view, got %v", prefs.Views)
+	}
+}
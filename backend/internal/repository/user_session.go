@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+const userSessionColumns = `id, user_id, token_hash, ip_address, user_agent, created_at, last_seen_at, expires_at, must_change_password`
+
+func scanUserSession(row pgx.Row) (*domain.UserSession, error) {
+	var s domain.UserSession
+	err := row.Scan(&s.ID, &s.UserID, &s.TokenHash, &s.IPAddress, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.MustChangePassword)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CreateSession stores a new login session, which must change the password
+// if the user is required to
+func (r *UserRepository) CreateSession(ctx context.Context, s *domain.UserSession) error {
+	query := `
+		INSERT INTO user_sessions (user_id, token_hash, ip_address, user_agent, expires_at, must_change_password)
+		SELECT id, $2, $3, $4, $5, must_change_password FROM users WHERE id = $1
+		RETURNING id, created_at, last_seen_at, must_change_password
+	`
+	return r.pool.QueryRow(ctx, query, s.UserID, s.TokenHash, s.IPAddress, s.UserAgent, s.ExpiresAt).
+		Scan(&s.ID, &s.CreatedAt, &s.LastSeenAt, &s.MustChangePassword)
+}
+
+// GetSessionByTokenHash returns the unexpired session with a token hash, or
+// nil if there is none (revoked or expired)
+func (r *UserRepository) GetSessionByTokenHash(ctx context.Context, tokenHash string) (*domain.UserSession, error) {
+	query := `SELECT ` + userSessionColumns + ` FROM user_sessions WHERE token_hash = $1 AND expires_at > NOW()`
+	s, err := scanUserSession(r.pool.QueryRow(ctx, query, tokenHash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return s, err
+}
+
+// ListSessions returns the unexpired sessions of a user, most recently used first
+func (r *UserRepository) ListSessions(ctx context.Context, userID uuid.UUID) ([]domain.UserSession, error) {
+	query := `SELECT ` + userSessionColumns + ` FROM user_sessions WHERE user_id = $1 AND expires_at > NOW() ORDER BY last_seen_at DESC`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []domain.UserSession
+	for rows.Next() {
+		s, err := scanUserSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *s)
+	}
+	return sessions, rows.Err()
+}
+
+// TouchSession records activity on a session
+func (r *UserRepository) TouchSession(ctx context.Context, id uuid.UUID, ipAddress, userAgent string) error {
+	query := `UPDATE user_sessions SET last_seen_at = NOW(), ip_address = $2, user_agent = $3 WHERE id = $1`
+	_, err := r.pool.Exec(ctx, query, id, ipAddress, userAgent)
+	return err
+}
+
+// ExtendSession moves the expiry of a renewed session
+func (r *UserRepository) ExtendSession(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `UPDATE user_sessions SET expires_at = $2 WHERE id = $1`, id, expiresAt)
+	return err
+}
+
+// DeleteSession revokes a session of a user, reporting whether it existed
+func (r *UserRepository) DeleteSession(ctx context.Context, userID, id uuid.UUID) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM user_sessions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteSessions revokes all sessions of a user except keep (uuid.Nil keeps
+// none), returning how many were revoked
+func (r *UserRepository) DeleteSessions(ctx context.Context, userID, keep uuid.UUID) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM user_sessions WHERE user_id = $1 AND id <> $2`, userID, keep)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteExpiredSessions removes sessions that expired before t
+func (r *UserRepository) DeleteExpiredSessions(ctx context.Context, t time.Time) error {
+	_, err := r.pool.Exec(ctx, `DELETE FROM user_sessions WHERE expires_at < $1`, t)
+	return err
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_UserRepository_Session_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "jane@example.com")
+	other, _ := fixtures.CreateUser(ctx, org.ID, "john@example.com")
+	repo := NewUserRepository(testDB.Pool)
+
+	newSession := func(hash string) *domain.UserSession {
+		s := &domain.UserSession{UserID: user.ID, TokenHash: hash, IPAddress: "192.0.2.1", UserAgent: "Firefox", ExpiresAt: time.Now().Add(time.Hour)}
+		if err := repo.CreateSession(ctx, s); err != nil {
+			t.Fatalf("failed to create session: %v", err)
+		}
+		return s
+	}
+	laptop := newSession("hash-laptop")
+	phone := newSession("hash-phone")
+	tablet := newSession("hash-tablet")
+
+	found, err := repo.GetSessionByTokenHash(ctx, "hash-laptop")
+	if err != nil || found == nil || found.ID != laptop.ID {
+		t.Fatalf("expected session, got %+v (%v)", found, err)
+	}
+
+	if err := repo.TouchSession(ctx, phone.ID, "198.51.100.7", "Safari"); err != nil {
+		t.Fatalf("failed to touch session: %v", err)
+	}
+	sessions, err := repo.ListSessions(ctx, user.ID)
+	if err != nil || len(sessions) != 3 {
+		t.Fatalf("expected 3 sessions, got %d (%v)", len(sessions), err)
+	}
+	if sessions[0].ID != phone.ID || sessions[0].IPAddress != "198.51.100.7" {
+		t.Errorf("expected the touched session first, got %+v", sessions[0])
+	}
+
+	// Users can only revoke their own sessions
+	if ok, _ := repo.DeleteSession(ctx, other.ID, tablet.ID); ok {
+		t.Error("expected session of another user not to be revoked")
+	}
+	if ok, err := repo.DeleteSession(ctx, user.ID, tablet.ID); err != nil || !ok {
+		t.Fatalf("expected session to be revoked (%v)", err)
+	}
+	if found, _ := repo.GetSessionByTokenHash(ctx, "hash-tablet"); found != nil {
+		t.Error("expected revoked session not to be found")
+	}
+
+	revoked, err := repo.DeleteSessions(ctx, user.ID, laptop.ID)
+	if err != nil || revoked != 1 {
+		t.Fatalf("expected 1 other session revoked, got %d (%v)", revoked, err)
+	}
+	if sessions, _ := repo.ListSessions(ctx, user.ID); len(sessions) != 1 || sessions[0].ID != laptop.ID {
+		t.Errorf("expected only the kept session, got %+v", sessions)
+	}
+	if revoked, _ := repo.DeleteSessions(ctx, user.ID, uuid.Nil); revoked != 1 {
+		t.Errorf("expected the last session revoked, got %d", revoked)
+	}
+}
+
+func Test_UserRepository_Session_Expired(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "jane@example.com")
+	repo := NewUserRepository(testDB.Pool)
+
+	s := &domain.UserSession{UserID: user.ID, TokenHash: "hash", IPAddress: "192.0.2.1", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.CreateSession(ctx, s); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if err := repo.ExtendSession(ctx, s.ID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("failed to extend session: %v", err)
+	}
+
+	if found, _ := repo.GetSessionByTokenHash(ctx, "hash"); found != nil {
+		t.Error("expected expired session not to be found")
+	}
+	if err := repo.DeleteExpiredSessions(ctx, time.Now()); err != nil {
+		t.Fatalf("failed to delete expired sessions: %v", err)
+	}
+	var count int
+	testDB.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM user_sessions`).Scan(&count)
+	if count != 0 {
+		t.Errorf("expected expired session to be deleted, got %d", count)
+	}
+}
+
+func Test_UserRepository_Session_MustChangePassword(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "jane@example.com")
+	repo := NewUserRepository(testDB.Pool)
+
+	if err := repo.RequirePasswordChange(ctx, user.ID); err != nil {
+		t.Fatalf("failed to require password change: %v", err)
+	}
+	s := &domain.UserSession{UserID: user.ID, TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repo.CreateSession(ctx, s); err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if !s.MustChangePassword {
+		t.Error("expected the session to inherit the required password change")
+	}
+
+	if err := repo.UpdatePassword(ctx, user.ID, "new-hash"); err != nil {
+		t.Fatalf("failed to update password: %v", err)
+	}
+	found, err := repo.GetSessionByTokenHash(ctx, "hash")
+	if err != nil || found == nil {
+		t.Fatalf("expected session, got %+v (%v)", found, err)
+	}
+	if found.MustChangePassword {
+		t.Error("expected the password change to lift the restriction")
+	}
+}
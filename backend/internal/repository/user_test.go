@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lmmendes/attic/internal/domain"
@@ -459,3 +460,169 @@ func Test_UserRepository_GetOrCreate_LinksExistingByEmail(t *testing.T) {
 		t.Error("expected OIDC subject to be linked")
 	}
 }
+
+func Test_UserRepository_UpdatePassword_RecordsHistory(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "user@example.com")
+
+	repo := NewUserRepository(testDB.Pool)
+	for _, hash := range []string{"hash-1", "hash-2", "hash-3"} {
+		if err := repo.UpdatePassword(ctx, user.ID, hash); err != nil {
+			t.Fatalf("failed to update password: %v", err)
+		}
+	}
+
+	history, err := repo.PasswordHistory(ctx, user.ID, 10)
+	if err != nil {
+		t.Fatalf("failed to get password history: %v", err)
+	}
+
+	// The current hash (hash-3) is not part of the history
+	if len(history) != 2 || history[0] != "hash-2" || history[1] != "hash-1" {
+		t.Errorf("unexpected history: %v", history)
+	}
+}
+
+func Test_UserRepository_SetDisabled_TogglesActive(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "user@example.com")
+
+	repo := NewUserRepository(testDB.Pool)
+	if err := repo.SetDisabled(ctx, user.ID, true); err != nil {
+		t.Fatalf("failed to disable user: %v", err)
+	}
+
+	got, _ := repo.GetByID(ctx, user.ID)
+	if got == nil || got.IsActive() {
+		t.Fatal("expected user to be disabled")
+	}
+
+	if err := repo.SetDisabled(ctx, user.ID, false); err != nil {
+		t.Fatalf("failed to enable user: %v", err)
+	}
+
+	got, _ = repo.GetByID(ctx, user.ID)
+	if got == nil || !got.IsActive() {
+		t.Error("expected user to be active again")
+	}
+}
+
+func Test_UserRepository_SetTimeZone(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "user@example.com")
+
+	repo := NewUserRepository(testDB.Pool)
+	zone := "Europe/Lisbon"
+	if err := repo.SetTimeZone(ctx, user.ID, &zone); err != nil {
+		t.Fatalf("failed to set time zone: %v", err)
+	}
+
+	got, _ := repo.GetByID(ctx, user.ID)
+	if got == nil || got.TimeZone == nil || *got.TimeZone != zone {
+		t.Fatalf("expected time zone %q, got %v", zone, got)
+	}
+
+	if err := repo.SetTimeZone(ctx, user.ID, nil); err != nil {
+		t.Fatalf("failed to clear time zone: %v", err)
+	}
+	got, _ = repo.GetByID(ctx, user.ID)
+	if got.TimeZone != nil {
+		t.Error("expected time zone to be cleared")
+	}
+}
+
+func Test_UserRepository_MarkVerified(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "user@example.com")
+
+	repo := NewUserRepository(testDB.Pool)
+
+	// A link sent to a previous address doesn't verify the current one
+	ok, err := repo.MarkVerified(ctx, user.ID, "old@example.com")
+	if err != nil {
+		t.Fatalf("failed to mark verified: %v", err)
+	}
+	if ok {
+		t.Error("expected a different address not to be verified")
+	}
+
+	ok, err = repo.MarkVerified(ctx, user.ID, "User@Example.com")
+	if err != nil {
+		t.Fatalf("failed to mark verified: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected the current address to be verified")
+	}
+
+	got, _ := repo.GetByID(ctx, user.ID)
+	if got == nil || !got.IsVerified() {
+		t.Error("expected user to be verified")
+	}
+}
+
+func Test_UserRepository_PendingApproval(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	approved, _ := fixtures.CreateUser(ctx, org.ID, "member@example.com")
+
+	repo := NewUserRepository(testDB.Pool)
+	now := time.Now()
+	pending := &domain.User{
+		OrganizationID: org.ID,
+		Email:          "new@example.com",
+		Role:           domain.UserRoleUser,
+		PendingAt:      &now,
+	}
+	if err := repo.Create(ctx, pending); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	users, err := repo.ListPending(ctx, org.ID)
+	if err != nil {
+		t.Fatalf("failed to list pending users: %v", err)
+	}
+	if len(users) != 1 || users[0].ID != pending.ID || !users[0].IsPending() {
+		t.Fatalf("expected only the new user to be pending, got %v", users)
+	}
+
+	if ok, err := repo.Approve(ctx, approved.ID); err != nil || ok {
+		t.Errorf("expected approving an approved user to be a no-op, got %v, %v", ok, err)
+	}
+	if ok, err := repo.Approve(ctx, pending.ID); err != nil || !ok {
+		t.Fatalf("expected pending user to be approved, got %v, %v", ok, err)
+	}
+
+	got, _ := repo.GetByID(ctx, pending.ID)
+	if got == nil || got.IsPending() {
+		t.Error("expected user not to be pending after approval")
+	}
+}
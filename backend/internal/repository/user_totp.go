@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// GetTOTP returns the TOTP enrollment of a user, or nil if there is none
+func (r *UserRepository) GetTOTP(ctx context.Context, userID uuid.UUID) (*domain.UserTOTP, error) {
+	query := `
+		SELECT user_id, secret, enabled_at, backup_codes, last_counter, created_at, updated_at
+		FROM user_totp
+		WHERE user_id = $1
+	`
+	var t domain.UserTOTP
+	err := r.pool.QueryRow(ctx, query, userID).Scan(
+		&t.UserID, &t.Secret, &t.EnabledAt, &t.BackupCodes, &t.LastCounter, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// StartTOTP stores a new, not yet confirmed TOTP secret for a user,
+// replacing any pending enrollment
+func (r *UserRepository) StartTOTP(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret, enabled_at = NULL, backup_codes = '{}', last_counter = 0
+	`
+	_, err := r.pool.Exec(ctx, query, userID, secret)
+	return err
+}
+
+// EnableTOTP confirms a pending enrollment and stores the backup code hashes
+func (r *UserRepository) EnableTOTP(ctx context.Context, userID uuid.UUID, backupCodes []string) error {
+	query := `UPDATE user_totp SET enabled_at = NOW(), backup_codes = $2 WHERE user_id = $1`
+	_, err := r.pool.Exec(ctx, query, userID, backupCodes)
+	return err
+}
+
+// SetBackupCodes replaces the backup code hashes of a user
+func (r *UserRepository) SetBackupCodes(ctx context.Context, userID uuid.UUID, backupCodes []string) error {
+	query := `UPDATE user_totp SET backup_codes = $2 WHERE user_id = $1`
+	_, err := r.pool.Exec(ctx, query, userID, backupCodes)
+	return err
+}
+
+// UseTOTPCounter records the time step of an accepted code. It reports false
+// if a code of that (or a later) step was already used.
+func (r *UserRepository) UseTOTPCounter(ctx context.Context, userID uuid.UUID, counter int64) (bool, error) {
+	query := `UPDATE user_totp SET last_counter = $2 WHERE user_id = $1 AND last_counter < $2`
+	tag, err := r.pool.Exec(ctx, query, userID, counter)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UseBackupCode consumes a backup code by hash, reporting whether it was
+// valid and unused
+func (r *UserRepository) UseBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE user_totp SET backup_codes = array_remove(backup_codes, $2)
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND $2 = ANY(backup_codes)
+	`
+	tag, err := r.pool.Exec(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// DeleteTOTP removes the TOTP enrollment of a user
+func (r *UserRepository) DeleteTOTP(ctx context.Context, userID uuid.UUID) error {
+	query := `DELETE FROM user_totp WHERE user_id = $1`
+	_, err := r.pool.Exec(ctx, query, userID)
+	return err
+}
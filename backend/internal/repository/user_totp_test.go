@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lmmendes/attic/internal/testutil"
+)
+
+func Test_UserRepository_TOTP_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	user, _ := fixtures.CreateUser(ctx, org.ID, "jane@example.com")
+	repo := NewUserRepository(testDB.Pool)
+
+	if totp, err := repo.GetTOTP(ctx, user.ID); err != nil || totp != nil {
+		t.Fatalf("expected no enrollment, got %+v (%v)", totp, err)
+	}
+
+	if err := repo.StartTOTP(ctx, user.ID, "encrypted-secret"); err != nil {
+		t.Fatalf("failed to start enrollment: %v", err)
+	}
+	totp, _ := repo.GetTOTP(ctx, user.ID)
+	if totp.Enabled() || totp.Secret != "encrypted-secret" {
+		t.Fatalf("expected pending enrollment, got %+v", totp)
+	}
+
+	if err := repo.EnableTOTP(ctx, user.ID, []string{"hash-1", "hash-2"}); err != nil {
+		t.Fatalf("failed to enable: %v", err)
+	}
+	totp, _ = repo.GetTOTP(ctx, user.ID)
+	if !totp.Enabled() || len(totp.BackupCodes) != 2 {
+		t.Fatalf("expected enabled enrollment with 2 backup codes, got %+v", totp)
+	}
+
+	// Backup codes are single use
+	if ok, err := repo.UseBackupCode(ctx, user.ID, "hash-1"); err != nil || !ok {
+		t.Fatalf("expected backup code to be accepted (%v)", err)
+	}
+	if ok, _ := repo.UseBackupCode(ctx, user.ID, "hash-1"); ok {
+		t.Error("expected used backup code to be rejected")
+	}
+
+	// Codes of a time step cannot be replayed
+	if ok, err := repo.UseTOTPCounter(ctx, user.ID, 100); err != nil || !ok {
+		t.Fatalf("expected counter to be accepted (%v)", err)
+	}
+	if ok, _ := repo.UseTOTPCounter(ctx, user.ID, 100); ok {
+		t.Error("expected replayed counter to be rejected")
+	}
+
+	if err := repo.DeleteTOTP(ctx, user.ID); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if totp, _ := repo.GetTOTP(ctx, user.ID); totp != nil {
+		t.Error("expected enrollment to be removed")
+	}
+}
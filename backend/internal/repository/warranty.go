@@ -42,7 +42,7 @@ func (r *WarrantyRepository) GetByAssetID(ctx context.Context, assetID uuid.UUID
 func (r *WarrantyRepository) List(ctx context.Context, orgID uuid.UUID) ([]domain.WarrantyWithAsset, error) {
 	query := `
 		SELECT w.id, w.asset_id, w.provider, w.start_date, w.end_date, w.notes, w.created_at, w.updated_at,
-		       a.name as asset_name
+		       a.high_value, a.name as asset_name
 		FROM warranties w
 		JOIN assets a ON a.id = w.asset_id
 		WHERE a.organization_id = $1
@@ -60,7 +60,7 @@ func (r *WarrantyRepository) List(ctx context.Context, orgID uuid.UUID) ([]domai
 		var w domain.WarrantyWithAsset
 		if err := rows.Scan(
 			&w.ID, &w.AssetID, &w.Provider, &w.StartDate, &w.EndDate,
-			&w.Notes, &w.CreatedAt, &w.UpdatedAt, &w.AssetName,
+			&w.Notes, &w.CreatedAt, &w.UpdatedAt, &w.HighValue, &w.AssetName,
 		); err != nil {
 			return nil, err
 		}
@@ -70,18 +70,25 @@ func (r *WarrantyRepository) List(ctx context.Context, orgID uuid.UUID) ([]domai
 }
 
 func (r *WarrantyRepository) ListExpiring(ctx context.Context, orgID uuid.UUID, days int) ([]domain.Warranty, error) {
+	return r.ListExpiringBefore(ctx, orgID, time.Now().AddDate(0, 0, days))
+}
+
+// ListExpiringBefore returns the warranties of active assets ending on or
+// before the calendar date of until (taken in until's location)
+func (r *WarrantyRepository) ListExpiringBefore(ctx context.Context, orgID uuid.UUID, until time.Time) ([]domain.Warranty, error) {
 	query := `
-		SELECT w.id, w.asset_id, w.provider, w.start_date, w.end_date, w.notes, w.created_at, w.updated_at
+		SELECT w.id, w.asset_id, w.provider, w.start_date, w.end_date, w.notes, w.created_at, w.updated_at,
+		       a.high_value
 		FROM warranties w
 		JOIN assets a ON a.id = w.asset_id
 		WHERE a.organization_id = $1
 		  AND a.deleted_at IS NULL
+		  AND a.archived_at IS NULL
 		  AND w.end_date IS NOT NULL
 		  AND w.end_date <= $2
 		ORDER BY w.end_date ASC
 	`
-	expiryDate := time.Now().AddDate(0, 0, days)
-	rows, err := r.pool.Query(ctx, query, orgID, expiryDate)
+	rows, err := r.pool.Query(ctx, query, orgID, calendarDate(until))
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +99,7 @@ func (r *WarrantyRepository) ListExpiring(ctx context.Context, orgID uuid.UUID,
 		var w domain.Warranty
 		if err := rows.Scan(
 			&w.ID, &w.AssetID, &w.Provider, &w.StartDate, &w.EndDate,
-			&w.Notes, &w.CreatedAt, &w.UpdatedAt,
+			&w.Notes, &w.CreatedAt, &w.UpdatedAt, &w.HighValue,
 		); err != nil {
 			return nil, err
 		}
@@ -193,6 +193,37 @@ func Test_WarrantyRepository_ListExpiring_ReturnsExpiringWarranties(t *testing.T
 	}
 }
 
+func Test_WarrantyRepository_ListExpiringBefore_ComparesCalendarDates(t *testing.T) {
+	ctx := context.Background()
+	if err := testDB.TruncateAll(ctx); err != nil {
+		t.Fatalf("failed to truncate: %v", err)
+	}
+
+	fixtures := testutil.NewFixtures(testDB.Pool)
+	org, _ := fixtures.CreateOrganization(ctx, "Test Org")
+	cat, _ := fixtures.CreateCategory(ctx, org.ID, "Electronics", nil)
+	asset, _ := fixtures.CreateAsset(ctx, org.ID, cat.ID, "Asset")
+
+	repo := NewWarrantyRepository(testDB.Pool)
+	endDate := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	repo.Create(ctx, &domain.Warranty{AssetID: asset.ID, EndDate: &endDate})
+
+	// 2025-06-01 late evening in Los Angeles is already 2025-06-02 in UTC
+	loc, _ := time.LoadLocation("America/Los_Angeles")
+	warranties, err := repo.ListExpiringBefore(ctx, org.ID, time.Date(2025, 5, 31, 20, 0, 0, 0, loc))
+	if err != nil {
+		t.Fatalf("failed to list expiring: %v", err)
+	}
+	if len(warranties) != 0 {
+		t.Errorf("expected no warranty ending by 2025-05-31, got %d", len(warranties))
+	}
+
+	warranties, _ = repo.ListExpiringBefore(ctx, org.ID, time.Date(2025, 6, 1, 20, 0, 0, 0, loc))
+	if len(warranties) != 1 {
+		t.Errorf("expected the warranty ending on 2025-06-01, got %d", len(warranties))
+	}
+}
+
 func Test_WarrantyRepository_Update_Success(t *testing.T) {
 	ctx := context.Background()
 	if err := testDB.TruncateAll(ctx); err != nil {
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+)
+
+const reindexBatchSize = 100
+
+// AssetSource loads assets to be indexed
+type AssetSource interface {
+	GetByIDFull(ctx context.Context, id uuid.UUID) (*domain.Asset, error)
+	List(ctx context.Context, orgID uuid.UUID, filter domain.AssetFilter, page domain.Pagination) ([]domain.Asset, int, error)
+}
+
+// Indexer keeps the search index in sync with asset events
+type Indexer struct {
+	engine Engine
+	assets AssetSource
+	queue  chan uuid.UUID
+}
+
+// NewIndexer creates an indexer; call Run to start processing events
+func NewIndexer(engine Engine, assets AssetSource) *Indexer {
+	return &Indexer{
+		engine: engine,
+		assets: assets,
+		queue:  make(chan uuid.UUID, 1024),
+	}
+}
+
+// HandleEvent queues affected assets for (re)indexing. It never blocks the
+// publisher; if the queue is full the change is picked up by the next reindex.
+func (i *Indexer) HandleEvent(ctx context.Context, e events.Event) {
+	switch e.Type {
+	case events.AssetCreated, events.AssetUpdated, events.AssetDeleted:
+	default:
+		return
+	}
+
+	select {
+	case i.queue <- e.SubjectID:
+	default:
+		slog.Warn("search index queue full, dropping update", "asset_id", e.SubjectID)
+	}
+}
+
+// Run processes queued updates until ctx is cancelled
+func (i *Indexer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-i.queue:
+			if err := i.sync(ctx, id); err != nil {
+				slog.Error("failed to sync asset to search index", "asset_id", id, "error", err)
+			}
+		}
+	}
+}
+
+// sync indexes the current state of an asset, removing it if it no longer exists
+func (i *Indexer) sync(ctx context.Context, id uuid.UUID) error {
+	asset, err := i.assets.GetByIDFull(ctx, id)
+	if err != nil {
+		return err
+	}
+	if asset == nil {
+		return i.engine.Delete(ctx, id.String())
+	}
+	return i.engine.Index(ctx, NewDocument(asset))
+}
+
+// Reindex pushes every asset of an organization to the index and returns the count
+func (i *Indexer) Reindex(ctx context.Context, orgID uuid.UUID) (int, error) {
+	if err := i.engine.Setup(ctx); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for offset := 0; ; offset += reindexBatchSize {
+		page := domain.Pagination{Limit: reindexBatchSize, Offset: offset}
+		assets, _, err := i.assets.List(ctx, orgID, domain.AssetFilter{}, page)
+		if err != nil {
+			return count, err
+		}
+		if len(assets) == 0 {
+			return count, nil
+		}
+
+		docs := make([]Document, 0, len(assets))
+		for _, a := range assets {
+			full, err := i.assets.GetByIDFull(ctx, a.ID)
+			if err != nil {
+				return count, err
+			}
+			if full != nil {
+				docs = append(docs, NewDocument(full))
+			}
+		}
+		if err := i.engine.Index(ctx, docs...); err != nil {
+			return count, err
+		}
+		count += len(docs)
+	}
+}
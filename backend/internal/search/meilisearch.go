@@ -0,0 +1,131 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Meilisearch implements Engine using the Meilisearch REST API
+type Meilisearch struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	index   string
+}
+
+func (m *Meilisearch) headers() map[string]string {
+	if m.apiKey == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + m.apiKey}
+}
+
+func (m *Meilisearch) indexURL() string {
+	return m.baseURL + "/indexes/" + m.index
+}
+
+func (m *Meilisearch) Setup(ctx context.Context) error {
+	err := doJSON(ctx, m.client, http.MethodPost, m.baseURL+"/indexes", m.headers(),
+		map[string]string{"uid": m.index, "primaryKey": "id"}, nil)
+	var statusErr *StatusError
+	if err != nil && !(errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusConflict) {
+		return err
+	}
+
+	settings := map[string]any{
+		"searchableAttributes": []string{"name", "tags", "category", "location", "description", "notes", "attribute_text"},
+		"filterableAttributes": append([]string{"organization_id", "high_value"}, FacetFields...),
+		"sortableAttributes":   []string{"updated_at"},
+	}
+	return doJSON(ctx, m.client, http.MethodPatch, m.indexURL()+"/settings", m.headers(), settings, nil)
+}
+
+func (m *Meilisearch) Index(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	return doJSON(ctx, m.client, http.MethodPost, m.indexURL()+"/documents", m.headers(), docs, nil)
+}
+
+func (m *Meilisearch) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return doJSON(ctx, m.client, http.MethodPost, m.indexURL()+"/documents/delete-batch", m.headers(), ids, nil)
+}
+
+type meiliSearchResponse struct {
+	Hits []struct {
+		ID string `json:"id"`
+	} `json:"hits"`
+	EstimatedTotalHits int                       `json:"estimatedTotalHits"`
+	FacetDistribution  map[string]map[string]int `json:"facetDistribution"`
+}
+
+func (m *Meilisearch) Search(ctx context.Context, q Query) (*Result, error) {
+	req := map[string]any{
+		"q":                    q.Text,
+		"filter":               meiliFilter(q),
+		"facets":               FacetFields,
+		"limit":                q.Limit,
+		"offset":               q.Offset,
+		"attributesToRetrieve": []string{"id"},
+	}
+
+	var resp meiliSearchResponse
+	if err := doJSON(ctx, m.client, http.MethodPost, m.indexURL()+"/search", m.headers(), req, &resp); err != nil {
+		return nil, err
+	}
+
+	raw := make([]string, len(resp.Hits))
+	for i, hit := range resp.Hits {
+		raw[i] = hit.ID
+	}
+	facets := resp.FacetDistribution
+	if facets == nil {
+		facets = map[string]map[string]int{}
+	}
+	return &Result{IDs: parseIDs(raw), Total: resp.EstimatedTotalHits, Facets: facets}, nil
+}
+
+// meiliFilter builds a filter expression scoping results to the organization
+// and the requested facet values
+func meiliFilter(q Query) string {
+	clauses := []string{`organization_id = ` + meiliQuote(q.OrganizationID.String())}
+	if q.NoHighValue {
+		// Also matches documents indexed before the flag was
+		clauses = append(clauses, `high_value != true`)
+	}
+
+	fields := make([]string, 0, len(q.Filters))
+	for field := range q.Filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if !isFacetField(field) {
+			continue
+		}
+		clauses = append(clauses, field+" = "+meiliQuote(q.Filters[field]))
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+func meiliQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+func isFacetField(field string) bool {
+	for _, f := range FacetFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// OpenSearch implements Engine using the OpenSearch REST API
+type OpenSearch struct {
+	client  *http.Client
+	baseURL string
+	index   string
+}
+
+func (o *OpenSearch) indexURL() string {
+	return o.baseURL + "/" + o.index
+}
+
+func (o *OpenSearch) Setup(ctx context.Context) error {
+	keyword := map[string]string{"type": "keyword"}
+	text := map[string]string{"type": "text"}
+	mapping := map[string]any{
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				"id":              keyword,
+				"organization_id": keyword,
+				"name":            text,
+				"description":     text,
+				"notes":           text,
+				"attribute_text":  text,
+				"category":        keyword,
+				"location":        keyword,
+				"condition":       keyword,
+				"tags":            keyword,
+				"high_value":      map[string]string{"type": "boolean"},
+				"updated_at":      map[string]string{"type": "long"},
+			},
+		},
+	}
+
+	err := doJSON(ctx, o.client, http.MethodPut, o.indexURL(), nil, mapping, nil)
+	var statusErr *StatusError
+	if err != nil && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest {
+		// Index already exists
+		return nil
+	}
+	return err
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+}
+
+func (o *OpenSearch) bulk(ctx context.Context, body []byte) error {
+	var resp bulkResponse
+	if err := doJSON(ctx, o.client, http.MethodPost, o.baseURL+"/_bulk", nil, body, &resp); err != nil {
+		return err
+	}
+	if resp.Errors {
+		return fmt.Errorf("opensearch bulk request had item errors")
+	}
+	return nil
+}
+
+func (o *OpenSearch) Index(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, doc := range docs {
+		enc.Encode(map[string]any{"index": map[string]string{"_index": o.index, "_id": doc.ID}})
+		enc.Encode(doc)
+	}
+	return o.bulk(ctx, buf.Bytes())
+}
+
+func (o *OpenSearch) Delete(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, id := range ids {
+		enc.Encode(map[string]any{"delete": map[string]string{"_index": o.index, "_id": id}})
+	}
+	return o.bulk(ctx, buf.Bytes())
+}
+
+type openSearchResponse struct {
+	Hits struct {
+		Total struct {
+			Value int `json:"value"`
+		} `json:"total"`
+		Hits []struct {
+			ID string `json:"_id"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]struct {
+		Buckets []struct {
+			Key      string `json:"key"`
+			DocCount int    `json:"doc_count"`
+		} `json:"buckets"`
+	} `json:"aggregations"`
+}
+
+func (o *OpenSearch) Search(ctx context.Context, q Query) (*Result, error) {
+	var resp openSearchResponse
+	if err := doJSON(ctx, o.client, http.MethodPost, o.indexURL()+"/_search", nil, openSearchQuery(q), &resp); err != nil {
+		return nil, err
+	}
+
+	raw := make([]string, len(resp.Hits.Hits))
+	for i, hit := range resp.Hits.Hits {
+		raw[i] = hit.ID
+	}
+
+	facets := make(map[string]map[string]int, len(resp.Aggregations))
+	for field, agg := range resp.Aggregations {
+		counts := make(map[string]int, len(agg.Buckets))
+		for _, b := range agg.Buckets {
+			counts[b.Key] = b.DocCount
+		}
+		facets[field] = counts
+	}
+
+	return &Result{IDs: parseIDs(raw), Total: resp.Hits.Total.Value, Facets: facets}, nil
+}
+
+// openSearchQuery builds a fuzzy multi-field query scoped to the organization
+func openSearchQuery(q Query) map[string]any {
+	filters := []any{
+		map[string]any{"term": map[string]string{"organization_id": q.OrganizationID.String()}},
+	}
+
+	fields := make([]string, 0, len(q.Filters))
+	for field := range q.Filters {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if !isFacetField(field) {
+			continue
+		}
+		filters = append(filters, map[string]any{"term": map[string]string{field: q.Filters[field]}})
+	}
+
+	boolQuery := map[string]any{"filter": filters}
+	if q.NoHighValue {
+		boolQuery["must_not"] = map[string]any{"term": map[string]bool{"high_value": true}}
+	}
+	if q.Text != "" {
+		boolQuery["must"] = map[string]any{
+			"multi_match": map[string]any{
+				"query":     q.Text,
+				"fields":    []string{"name^3", "tags^2", "category", "location", "description", "notes", "attribute_text"},
+				"fuzziness": "AUTO",
+			},
+		}
+	}
+
+	aggs := make(map[string]any, len(FacetFields))
+	for _, field := range FacetFields {
+		aggs[field] = map[string]any{"terms": map[string]any{"field": field, "size": 50}}
+	}
+
+	return map[string]any{
+		"query":            map[string]any{"bool": boolQuery},
+		"aggs":             aggs,
+		"from":             q.Offset,
+		"size":             q.Limit,
+		"_source":          false,
+		"track_total_hits": true,
+	}
+}
@@ -0,0 +1,278 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lmmendes/attic/internal/domain"
+	"github.com/lmmendes/attic/internal/events"
+)
+
+func Test_New_UnsupportedEngine_ReturnsError(t *testing.T) {
+	_, err := New(Config{Engine: "solr", URL: "http://localhost"})
+	if err == nil {
+		t.Error("expected error for unsupported engine")
+	}
+}
+
+func Test_New_MissingURL_ReturnsError(t *testing.T) {
+	_, err := New(Config{Engine: EngineMeilisearch})
+	if err == nil {
+		t.Error("expected error for missing URL")
+	}
+}
+
+func Test_NewDocument_FlattensRelations(t *testing.T) {
+	desc := "Wireless"
+	asset := &domain.Asset{
+		ID:             uuid.New(),
+		OrganizationID: uuid.New(),
+		Name:           "Headphones",
+		Description:    &desc,
+		Attributes:     json.RawMessage(`{"brand":"Sony","year":2020,"empty":null}`),
+		UpdatedAt:      time.Unix(1700000000, 0),
+		Category:       &domain.Category{Name: "Electronics"},
+		Location:       &domain.Location{Name: "Office"},
+		Condition:      &domain.Condition{Label: "Good"},
+		Tags:           []domain.Tag{{Name: "audio"}, {Name: "travel"}},
+	}
+
+	doc := NewDocument(asset)
+
+	if doc.ID != asset.ID.String() || doc.Name != "Headphones" || doc.Description != "Wireless" {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+	if doc.Category != "Electronics" || doc.Location != "Office" || doc.Condition != "Good" {
+		t.Errorf("expected relations to be flattened, got %+v", doc)
+	}
+	if len(doc.Tags) != 2 || doc.Tags[0] != "audio" {
+		t.Errorf("unexpected tags: %v", doc.Tags)
+	}
+	if doc.AttributeText != "Sony 2020" {
+		t.Errorf("expected attribute text 'Sony 2020', got %q", doc.AttributeText)
+	}
+	if doc.UpdatedAt != 1700000000 {
+		t.Errorf("unexpected updated_at: %d", doc.UpdatedAt)
+	}
+}
+
+func Test_meiliFilter_ScopesToOrganizationAndFacets(t *testing.T) {
+	orgID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	q := Query{
+		OrganizationID: orgID,
+		Filters:        map[string]string{"location": `Shed "B"`, "category": "Tools", "bogus": "x"},
+	}
+
+	got := meiliFilter(q)
+	want := `organization_id = "11111111-1111-1111-1111-111111111111" AND category = "Tools" AND location = "Shed \"B\""`
+	if got != want {
+		t.Errorf("meiliFilter() = %s, want %s", got, want)
+	}
+}
+
+func Test_meiliFilter_NoHighValue(t *testing.T) {
+	q := Query{OrganizationID: uuid.MustParse("11111111-1111-1111-1111-111111111111"), NoHighValue: true}
+
+	got := meiliFilter(q)
+	want := `organization_id = "11111111-1111-1111-1111-111111111111" AND high_value != true`
+	if got != want {
+		t.Errorf("meiliFilter() = %s, want %s", got, want)
+	}
+}
+
+func Test_openSearchQuery_NoHighValue(t *testing.T) {
+	q := Query{OrganizationID: uuid.New(), NoHighValue: true}
+
+	body, _ := json.Marshal(openSearchQuery(q))
+
+	if !strings.Contains(string(body), `"must_not":{"term":{"high_value":true}}`) {
+		t.Errorf("expected high-value assets to be excluded, got %s", body)
+	}
+	body, _ = json.Marshal(openSearchQuery(Query{OrganizationID: uuid.New()}))
+	if strings.Contains(string(body), "high_value") {
+		t.Errorf("expected no high-value filter, got %s", body)
+	}
+}
+
+func Test_openSearchQuery_FuzzyWhenTextGiven(t *testing.T) {
+	q := Query{OrganizationID: uuid.New(), Text: "hedphones", Limit: 10}
+
+	body, _ := json.Marshal(openSearchQuery(q))
+
+	if !strings.Contains(string(body), `"fuzziness":"AUTO"`) {
+		t.Errorf("expected fuzzy multi_match, got %s", body)
+	}
+	if !strings.Contains(string(body), `"organization_id"`) {
+		t.Errorf("expected organization filter, got %s", body)
+	}
+}
+
+func Test_Meilisearch_Search_ParsesResponse(t *testing.T) {
+	id := uuid.New()
+	var gotAuth string
+	var gotBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &gotBody)
+		if r.URL.Path != "/indexes/assets/search" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"hits":[{"id":"` + id.String() + `"}],"estimatedTotalHits":1,"facetDistribution":{"category":{"Books":1}}}`))
+	}))
+	defer srv.Close()
+
+	engine, _ := New(Config{Engine: EngineMeilisearch, URL: srv.URL, APIKey: "secret", Index: "assets"})
+	result, err := engine.Search(context.Background(), Query{OrganizationID: uuid.New(), Text: "dune", Limit: 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotAuth != "Bearer secret" {
+		t.Errorf("expected bearer auth, got %q", gotAuth)
+	}
+	if gotBody["q"] != "dune" {
+		t.Errorf("expected q 'dune', got %v", gotBody["q"])
+	}
+	if len(result.IDs) != 1 || result.IDs[0] != id {
+		t.Errorf("unexpected IDs: %v", result.IDs)
+	}
+	if result.Facets["category"]["Books"] != 1 {
+		t.Errorf("unexpected facets: %v", result.Facets)
+	}
+}
+
+func Test_OpenSearch_Search_ParsesResponse(t *testing.T) {
+	id := uuid.New()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/assets/_search" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"hits":{"total":{"value":3},"hits":[{"_id":"` + id.String() + `"}]},
+			"aggregations":{"tags":{"buckets":[{"key":"audio","doc_count":2}]}}}`))
+	}))
+	defer srv.Close()
+
+	engine, _ := New(Config{Engine: EngineOpenSearch, URL: srv.URL, Index: "assets"})
+	result, err := engine.Search(context.Background(), Query{OrganizationID: uuid.New(), Limit: 20})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Total != 3 || len(result.IDs) != 1 || result.IDs[0] != id {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Facets["tags"]["audio"] != 2 {
+		t.Errorf("unexpected facets: %v", result.Facets)
+	}
+}
+
+func Test_Meilisearch_ErrorStatus_ReturnsStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	engine, _ := New(Config{Engine: EngineMeilisearch, URL: srv.URL, Index: "assets"})
+	_, err := engine.Search(context.Background(), Query{OrganizationID: uuid.New()})
+
+	statusErr, ok := err.(*StatusError)
+	if !ok || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected StatusError 401, got %v", err)
+	}
+}
+
+// fakeEngine records indexing calls
+type fakeEngine struct {
+	indexed []Document
+	deleted []string
+}
+
+func (f *fakeEngine) Setup(ctx context.Context) error { return nil }
+func (f *fakeEngine) Index(ctx context.Context, docs ...Document) error {
+	f.indexed = append(f.indexed, docs...)
+	return nil
+}
+func (f *fakeEngine) Delete(ctx context.Context, ids ...string) error {
+	f.deleted = append(f.deleted, ids...)
+	return nil
+}
+func (f *fakeEngine) Search(ctx context.Context, q Query) (*Result, error) { return &Result{}, nil }
+
+// fakeAssets serves assets from memory
+type fakeAssets struct {
+	assets map[uuid.UUID]*domain.Asset
+}
+
+func (f *fakeAssets) GetByIDFull(ctx context.Context, id uuid.UUID) (*domain.Asset, error) {
+	return f.assets[id], nil
+}
+
+func (f *fakeAssets) List(ctx context.Context, orgID uuid.UUID, filter domain.AssetFilter, page domain.Pagination) ([]domain.Asset, int, error) {
+	var all []domain.Asset
+	for _, a := range f.assets {
+		all = append(all, *a)
+	}
+	if page.Offset >= len(all) {
+		return nil, len(all), nil
+	}
+	end := min(page.Offset+page.Limit, len(all))
+	return all[page.Offset:end], len(all), nil
+}
+
+func Test_Indexer_Sync_IndexesExistingAndDeletesMissing(t *testing.T) {
+	existing := &domain.Asset{ID: uuid.New(), Name: "Lamp"}
+	missing := uuid.New()
+	engine := &fakeEngine{}
+	indexer := NewIndexer(engine, &fakeAssets{assets: map[uuid.UUID]*domain.Asset{existing.ID: existing}})
+
+	if err := indexer.sync(context.Background(), existing.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := indexer.sync(context.Background(), missing); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(engine.indexed) != 1 || engine.indexed[0].Name != "Lamp" {
+		t.Errorf("expected Lamp to be indexed, got %+v", engine.indexed)
+	}
+	if len(engine.deleted) != 1 || engine.deleted[0] != missing.String() {
+		t.Errorf("expected missing asset to be deleted, got %v", engine.deleted)
+	}
+}
+
+func Test_Indexer_HandleEvent_IgnoresUnrelatedEvents(t *testing.T) {
+	indexer := NewIndexer(&fakeEngine{}, &fakeAssets{})
+
+	indexer.HandleEvent(context.Background(), events.Event{Type: "list.created", SubjectID: uuid.New()})
+	indexer.HandleEvent(context.Background(), events.Event{Type: events.AssetUpdated, SubjectID: uuid.New()})
+
+	if len(indexer.queue) != 1 {
+		t.Errorf("expected 1 queued update, got %d", len(indexer.queue))
+	}
+}
+
+func Test_Indexer_Reindex_IndexesAllAssets(t *testing.T) {
+	assets := map[uuid.UUID]*domain.Asset{}
+	for i := 0; i < 150; i++ {
+		a := &domain.Asset{ID: uuid.New(), Name: "Item"}
+		assets[a.ID] = a
+	}
+	engine := &fakeEngine{}
+	indexer := NewIndexer(engine, &fakeAssets{assets: assets})
+
+	count, err := indexer.Reindex(context.Background(), uuid.New())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 150 || len(engine.indexed) != 150 {
+		t.Errorf("expected 150 indexed, got count=%d indexed=%d", count, len(engine.indexed))
+	}
+}
@@ -0,0 +1,70 @@
+// Package secrets encrypts sensitive values (e.g. OIDC client secrets) before
+// they are stored in the database.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const versionPrefix = "v1:"
+
+// ErrInvalidCiphertext is returned for values that were not produced by Encrypt
+// with the same key
+var ErrInvalidCiphertext = errors.New("secrets: invalid ciphertext or wrong key")
+
+// Box encrypts and decrypts values with AES-256-GCM
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New creates a Box whose key is derived from the given passphrase
+func New(passphrase string) (*Box, error) {
+	if passphrase == "" {
+		return nil, errors.New("secrets: empty encryption key")
+	}
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Encrypt returns the encrypted, base64 encoded form of plaintext
+func (b *Box) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("secrets: generating nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return versionPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt
+func (b *Box) Decrypt(ciphertext string) (string, error) {
+	encoded, ok := strings.CutPrefix(ciphertext, versionPrefix)
+	if !ok {
+		return "", ErrInvalidCiphertext
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(data) < b.aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+	nonce, sealed := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_Box_EncryptDecrypt_RoundTrip(t *testing.T) {
+	box, err := New("test-key")
+	if err != nil {
+		t.Fatalf("failed to create box: %v", err)
+	}
+
+	ciphertext, err := box.Encrypt("client-secret")
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	if strings.Contains(ciphertext, "client-secret") {
+		t.Error("expected ciphertext not to contain the plaintext")
+	}
+
+	plaintext, err := box.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+	if plaintext != "client-secret" {
+		t.Errorf("expected 'client-secret', got %q", plaintext)
+	}
+}
+
+func Test_Box_Encrypt_UsesRandomNonce(t *testing.T) {
+	box, _ := New("test-key")
+	a, _ := box.Encrypt("same")
+	b, _ := box.Encrypt("same")
+	if a == b {
+		t.Error("expected different ciphertexts for the same plaintext")
+	}
+}
+
+func Test_Box_Decrypt_WrongKeyOrTampered(t *testing.T) {
+	box, _ := New("test-key")
+	other, _ := New("other-key")
+	ciphertext, _ := box.Encrypt("client-secret")
+
+	if _, err := other.Decrypt(ciphertext); err != ErrInvalidCiphertext {
+		t.Errorf("expected ErrInvalidCiphertext for wrong key, got %v", err)
+	}
+	if _, err := box.Decrypt("plain-value"); err != ErrInvalidCiphertext {
+		t.Errorf("expected ErrInvalidCiphertext for unversioned value, got %v", err)
+	}
+	if _, err := box.Decrypt(ciphertext[:len(ciphertext)-4] + "AAAA"); err != ErrInvalidCiphertext {
+		t.Errorf("expected ErrInvalidCiphertext for tampered value, got %v", err)
+	}
+}
+
+func Test_New_EmptyKey(t *testing.T) {
+	if _, err := New(""); err == nil {
+		t.Error("expected error for empty key")
+	}
+}
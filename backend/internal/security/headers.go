@@ -0,0 +1,107 @@
+// Package security provides HTTP security headers (CSP and friends).
+package security
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// Content Security Policies for the different kinds of responses
+const (
+	// APIPolicy applies to JSON responses and user uploaded files: nothing may
+	// be loaded or executed, so uploaded HTML/SVG can't run scripts.
+	APIPolicy = "default-src 'none'; frame-ancestors 'none'; base-uri 'none'; form-action 'none'; sandbox"
+
+	// SPAPolicy applies to the embedded frontend. Nuxt injects its runtime
+	// config as an inline script; images may come from presigned S3 URLs and
+	// plugin providers (cover art); icons are fetched from Iconify.
+	SPAPolicy = "default-src 'self'; " +
+		"script-src 'self' 'unsafe-inline'; " +
+		"style-src 'self' 'unsafe-inline'; " +
+		"img-src 'self' data: blob: https:; " +
+		"font-src 'self' data:; " +
+		"connect-src 'self' https://api.iconify.design; " +
+		"object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+
+	// EmbedPolicy applies to server-rendered widgets that any site may frame
+	EmbedPolicy = "default-src 'none'; " +
+		"style-src 'unsafe-inline'; " +
+		"img-src 'self' data: https:; " +
+		"base-uri 'none'; form-action 'none'; frame-ancestors *"
+
+	// SwaggerPolicy applies to the API documentation pages (vendored Swagger
+	// UI and ReDoc, which runs its search index in a blob worker)
+	SwaggerPolicy = "default-src 'self'; " +
+		"style-src 'self' 'unsafe-inline'; " +
+		"img-src 'self' data:; " +
+		"worker-src 'self' blob:; " +
+		"object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'"
+)
+
+// Config configures the security headers middleware
+type Config struct {
+	SPAPolicy string // Overrides SPAPolicy (empty = default)
+	DocsPath  string // Path prefix of the API documentation, e.g. "/api/docs"
+}
+
+// apiPrefixes are served with the strict APIPolicy
+var apiPrefixes = []string{"/api/", "/auth/", "/share/", "/public/", "/embed/", "/oembed", "/files/", "/scim/", "/health", "/ready"}
+
+// Headers returns a middleware that sets security headers on every response.
+// Handlers may replace the Content-Security-Policy header before writing,
+// e.g. to allow a nonce-based inline script (see Nonce and PolicyWithNonce).
+func Headers(cfg Config) func(http.Handler) http.Handler {
+	spaPolicy := cfg.SPAPolicy
+	if spaPolicy == "" {
+		spaPolicy = SPAPolicy
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Content-Security-Policy", policyFor(r.URL.Path, cfg.DocsPath, spaPolicy))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func policyFor(path, docsPath, spaPolicy string) string {
+	if docsPath != "" && (path == docsPath || strings.HasPrefix(path, docsPath+"/")) {
+		return SwaggerPolicy
+	}
+	for _, prefix := range apiPrefixes {
+		if strings.HasPrefix(path, prefix) || path+"/" == prefix {
+			return APIPolicy
+		}
+	}
+	return spaPolicy
+}
+
+// AllowFraming lets any site embed the response in an iframe. Handlers call
+// it before writing embeddable widgets; the page may not run scripts.
+func AllowFraming(w http.ResponseWriter) {
+	w.Header().Del("X-Frame-Options")
+	w.Header().Set("Content-Security-Policy", EmbedPolicy)
+}
+
+// Nonce returns a random value for use in a CSP script-src 'nonce-...' source
+func Nonce() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// PolicyWithNonce returns a policy for server-rendered pages with inline
+// styles and nonce-tagged inline scripts only
+func PolicyWithNonce(nonce string) string {
+	return "default-src 'none'; " +
+		"script-src 'nonce-" + nonce + "'; " +
+		"style-src 'unsafe-inline'; " +
+		"img-src 'self' data: https:; " +
+		"base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+}
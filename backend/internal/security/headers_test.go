@@ -0,0 +1,64 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_Headers_SetsSecurityHeaders(t *testing.T) {
+	handler := Headers(Config{DocsPath: "/api/docs"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := rec.Header().Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("expected nosniff, got %q", got)
+	}
+	if got := rec.Header().Get("Referrer-Policy"); got == "" {
+		t.Error("expected Referrer-Policy header")
+	}
+	if got := rec.Header().Get("Content-Security-Policy"); !strings.Contains(got, "frame-ancestors 'none'") {
+		t.Errorf("expected frame-ancestors in CSP, got %q", got)
+	}
+}
+
+func Test_policyFor(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", SPAPolicy},
+		{"/assets/123", SPAPolicy},
+		{"/_nuxt/entry.js", SPAPolicy},
+		{"/api/assets", APIPolicy},
+		{"/files/abc.svg", APIPolicy},
+		{"/share/lists/token", APIPolicy},
+		{"/public/board-games", APIPolicy},
+		{"/embed/public/board-games", APIPolicy},
+		{"/oembed", APIPolicy},
+		{"/health", APIPolicy},
+		{"/api/docs", SwaggerPolicy},
+		{"/api/docs/swagger-ui.css", SwaggerPolicy},
+		{"/api/docs/redoc", SwaggerPolicy},
+	}
+	for _, tt := range tests {
+		if got := policyFor(tt.path, "/api/docs", SPAPolicy); got != tt.want {
+			t.Errorf("policyFor(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func Test_Headers_HandlerCanOverridePolicy(t *testing.T) {
+	handler := Headers(Config{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", PolicyWithNonce("abc"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/lists/1/print", nil))
+
+	if got := rec.Header().Get("Content-Security-Policy"); !strings.Contains(got, "'nonce-abc'") {
+		t.Errorf("expected overridden policy, got %q", got)
+	}
+}
@@ -0,0 +1,208 @@
+package seed
+
+import "github.com/lmmendes/attic/internal/domain"
+
+// attributeSpec is an attribute of generated assets; values are picked from
+// Options, or else generated for the data type (numbers between Min and Max)
+type attributeSpec struct {
+	Name     string
+	Key      string
+	DataType domain.AttributeDataType
+	Options  []string
+	Min, Max float64
+}
+
+// categorySpec is a category of generated assets. Leaf categories have
+// items, which are named "<brand> <item> <model>".
+type categorySpec struct {
+	Name       string
+	Children   []categorySpec
+	Items      []string
+	Brands     []string
+	Attributes []attributeSpec
+	MinPrice   float64
+	MaxPrice   float64
+}
+
+var serialNumber = attributeSpec{Name: "Serial number", Key: "serial_number", DataType: domain.AttributeTypeString}
+
+var catalog = []categorySpec{
+	{Name: "Electronics", Children: []categorySpec{
+		{
+			Name:     "Computers",
+			Items:    []string{"Laptop", "Ultrabook", "Desktop PC", "Mini PC", "Tablet", "Monitor", "Mechanical Keyboard", "Docking Station"},
+			Brands:   []string{"Lenovo", "Dell", "Apple", "HP", "Asus", "Acer", "Framework", "Logitech"},
+			MinPrice: 80, MaxPrice: 2800,
+			Attributes: []attributeSpec{
+				serialNumber,
+				{Name: "Screen size (in)", Key: "screen_size", DataType: domain.AttributeTypeNumber, Min: 10, Max: 34},
+				{Name: "Operating system", Key: "operating_system", DataType: domain.AttributeTypeString, Options: []string{"Linux", "Windows 11", "macOS", "ChromeOS", "Android"}},
+			},
+		},
+		{
+			Name:     "Audio & Video",
+			Items:    []string{"Headphones", "Soundbar", "Bluetooth Speaker", "Turntable", "Projector", "Television", "AV Receiver", "Microphone"},
+			Brands:   []string{"Sony", "Bose", "Sennheiser", "Samsung", "LG", "Yamaha", "JBL", "Denon"},
+			MinPrice: 30, MaxPrice: 2200,
+			Attributes: []attributeSpec{
+				serialNumber,
+				{Name: "Wireless", Key: "wireless", DataType: domain.AttributeTypeBoolean},
+			},
+		},
+		{
+			Name:     "Cameras",
+			Items:    []string{"Mirrorless Camera", "DSLR", "Zoom Lens", "Prime Lens", "Action Camera", "Tripod", "Flash"},
+			Brands:   []string{"Canon", "Nikon", "Fujifilm", "Sony", "Olympus", "GoPro", "Manfrotto"},
+			MinPrice: 40, MaxPrice: 3500,
+			Attributes: []attributeSpec{
+				serialNumber,
+				{Name: "Mount", Key: "lens_mount", DataType: domain.AttributeTypeString, Options: []string{"EF", "RF", "Z", "X", "E", "MFT"}},
+			},
+		},
+	}},
+	{Name: "Tools", Children: []categorySpec{
+		{
+			Name:     "Power Tools",
+			Items:    []string{"Cordless Drill", "Impact Driver", "Circular Saw", "Jigsaw", "Angle Grinder", "Random Orbit Sander", "Router", "Heat Gun"},
+			Brands:   []string{"Makita", "Bosch", "DeWalt", "Milwaukee", "Ryobi", "Metabo", "Festool"},
+			MinPrice: 45, MaxPrice: 650,
+			Attributes: []attributeSpec{
+				serialNumber,
+				{Name: "Voltage (V)", Key: "voltage", DataType: domain.AttributeTypeNumber, Min: 10, Max: 36},
+				{Name: "Battery included", Key: "battery_included", DataType: domain.AttributeTypeBoolean},
+			},
+		},
+		{
+			Name:     "Hand Tools",
+			Items:    []string{"Socket Set", "Hammer", "Screwdriver Set", "Spirit Level", "Hand Saw", "Chisel Set", "Pliers", "Torque Wrench"},
+			Brands:   []string{"Stanley", "Knipex", "Wera", "Bahco", "Stabila", "Gedore", "Irwin"},
+			MinPrice: 8, MaxPrice: 320,
+		},
+		{
+			Name:     "Garden",
+			Items:    []string{"Lawn Mower", "Hedge Trimmer", "Leaf Blower", "Pressure Washer", "Chainsaw", "Wheelbarrow", "Garden Hose Reel"},
+			Brands:   []string{"Husqvarna", "Stihl", "Gardena", "Kärcher", "Honda", "Einhell"},
+			MinPrice: 25, MaxPrice: 1400,
+			Attributes: []attributeSpec{
+				{Name: "Fuel", Key: "fuel", DataType: domain.AttributeTypeString, Options: []string{"Battery", "Petrol", "Corded", "Manual"}},
+			},
+		},
+	}},
+	{Name: "Home & Kitchen", Children: []categorySpec{
+		{
+			Name:     "Appliances",
+			Items:    []string{"Espresso Machine", "Stand Mixer", "Dishwasher", "Washing Machine", "Vacuum Cleaner", "Air Purifier", "Microwave", "Blender"},
+			Brands:   []string{"Bosch", "Miele", "Siemens", "KitchenAid", "Dyson", "Philips", "De'Longhi", "Breville"},
+			MinPrice: 35, MaxPrice: 1900,
+			Attributes: []attributeSpec{
+				serialNumber,
+				{Name: "Power (W)", Key: "power_watts", DataType: domain.AttributeTypeNumber, Min: 20, Max: 2400},
+			},
+		},
+		{
+			Name:     "Furniture",
+			Items:    []string{"Sofa", "Armchair", "Dining Table", "Bookshelf", "Desk", "Office Chair", "Wardrobe", "Bed Frame"},
+			Brands:   []string{"IKEA", "Hay", "Vitra", "Muuto", "Herman Miller", "West Elm"},
+			MinPrice: 60, MaxPrice: 3200,
+			Attributes: []attributeSpec{
+				{Name: "Material", Key: "material", DataType: domain.AttributeTypeString, Options: []string{"Oak", "Walnut", "Pine", "Steel", "Leather", "Linen"}},
+			},
+		},
+	}},
+	{Name: "Collections", Children: []categorySpec{
+		{
+			Name:     "Books",
+			Items:    []string{"Novel", "Cookbook", "Atlas", "Art Book", "Biography", "Field Guide", "Poetry Collection"},
+			Brands:   []string{"Penguin", "Vintage", "Taschen", "Phaidon", "Faber", "Folio Society"},
+			MinPrice: 6, MaxPrice: 180,
+			Attributes: []attributeSpec{
+				{Name: "ISBN", Key: "isbn", DataType: domain.AttributeTypeString},
+				{Name: "Pages", Key: "pages", DataType: domain.AttributeTypeNumber, Min: 80, Max: 1200},
+			},
+		},
+		{
+			Name:     "Board Games",
+			Items:    []string{"Strategy Game", "Party Game", "Card Game", "Cooperative Game", "Expansion"},
+			Brands:   []string{"Asmodee", "Z-Man", "Stonemaier", "Fantasy Flight", "Ravensburger", "Kosmos"},
+			MinPrice: 10, MaxPrice: 160,
+			Attributes: []attributeSpec{
+				{Name: "Players", Key: "players", DataType: domain.AttributeTypeString, Options: []string{"1-4", "2-4", "2-6", "3-8", "1-5"}},
+				{Name: "Playing time (min)", Key: "playing_time", DataType: domain.AttributeTypeNumber, Min: 15, Max: 240},
+			},
+		},
+		{
+			Name:     "Vinyl Records",
+			Items:    []string{"LP", "Double LP", "Single", "Box Set"},
+			Brands:   []string{"Blue Note", "Warp", "Sub Pop", "Motown", "ECM", "Rough Trade"},
+			MinPrice: 12, MaxPrice: 250,
+			Attributes: []attributeSpec{
+				{Name: "Release date", Key: "release_date", DataType: domain.AttributeTypeDate},
+			},
+		},
+	}},
+	{Name: "Sports & Outdoors", Children: []categorySpec{
+		{
+			Name:     "Bikes",
+			Items:    []string{"Road Bike", "Gravel Bike", "Mountain Bike", "E-Bike", "Bike Trailer", "Child Seat"},
+			Brands:   []string{"Trek", "Specialized", "Canyon", "Giant", "Cube", "Brompton"},
+			MinPrice: 90, MaxPrice: 5200,
+			Attributes: []attributeSpec{
+				{Name: "Frame number", Key: "frame_number", DataType: domain.AttributeTypeString},
+				{Name: "Frame size", Key: "frame_size", DataType: domain.AttributeTypeString, Options: []string{"XS", "S", "M", "L", "XL"}},
+			},
+		},
+		{
+			Name:     "Camping",
+			Items:    []string{"Tent", "Sleeping Bag", "Camping Stove", "Backpack", "Headlamp", "Cooler", "Hammock"},
+			Brands:   []string{"MSR", "Vaude", "Deuter", "Black Diamond", "Coleman", "Jack Wolfskin", "Patagonia"},
+			MinPrice: 15, MaxPrice: 900,
+		},
+	}},
+}
+
+var adjectives = []string{"Compact", "Classic", "Pro", "Lightweight", "Vintage", "Heavy-duty", "Portable", "Premium", "Refurbished", "Limited Edition"}
+
+var descriptionDetails = []string{
+	"Bought as a replacement for the old one.",
+	"Works fine, small scratches on the side.",
+	"Original box and receipt are in the filing cabinet.",
+	"Gift from the family.",
+	"Used only a few times.",
+	"Needs a new battery soon.",
+	"Shared with the neighbours on request.",
+	"Kept for spare parts.",
+	"Still under manufacturer warranty.",
+	"Cleaned and serviced last spring.",
+}
+
+var notes = []string{
+	"Check before lending.",
+	"Manual downloaded to the attachments.",
+	"Insured under the household policy.",
+	"Consider selling.",
+	"Keep away from moisture.",
+}
+
+// locationTree is the generated location hierarchy; assets are stored in the
+// leaves
+var locationTree = map[string]map[string][]string{
+	"House": {
+		"Living Room": {"TV Cabinet", "Bookshelf", "Sideboard"},
+		"Kitchen":     {"Upper Cabinets", "Pantry", "Drawer"},
+		"Office":      {"Desk", "Shelf", "Filing Cabinet"},
+		"Bedroom":     {"Wardrobe", "Nightstand", "Under the Bed"},
+		"Attic":       {"Box 1", "Box 2", "Box 3", "Rafters"},
+		"Basement":    {"Workbench", "Shelf A", "Shelf B", "Storage Room"},
+	},
+	"Garage": {
+		"Wall": {"Pegboard", "Hooks"},
+		"Back": {"Tool Chest", "Cabinet", "Loft"},
+	},
+	"Garden Shed": {
+		"Inside": {"Left Shelf", "Right Shelf", "Floor"},
+	},
+}
+
+var tagNames = []string{"gift", "fragile", "vintage", "insured", "to sell", "borrowed", "favorite", "seasonal", "needs repair", "shared"}
+
+var warrantyProviders = []string{"Manufacturer", "Retailer", "Extended Care Plus", "Home Insurance"}
@@ -0,0 +1,182 @@
+package seed
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/lmmendes/attic/internal/domain"
+)
+
+// photoWidth and photoHeight are the size of generated photos
+const (
+	photoWidth  = 640
+	photoHeight = 480
+)
+
+// fakeAsset is the generated data of an asset; the seeder assigns its
+// category, location, condition, owner and tags
+type fakeAsset struct {
+	Name          string
+	Description   string
+	Notes         *string
+	Quantity      int
+	Attributes    map[string]any
+	PurchaseAt    *time.Time
+	PurchasePrice *float64
+	CreatedAt     time.Time
+	Warranty      *fakeWarranty
+}
+
+type fakeWarranty struct {
+	Provider  string
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// generator makes up realistic inventory data; the same seed generates the
+// same data
+type generator struct {
+	rnd *rand.Rand
+	now time.Time
+}
+
+func newGenerator(seed int64, now time.Time) *generator {
+	return &generator{rnd: rand.New(rand.NewPCG(uint64(seed), 0x617474696373)), now: now}
+}
+
+// asset generates an asset of a leaf category
+func (g *generator) asset(cat *categorySpec) fakeAsset {
+	item := pick(g, cat.Items)
+	brand := pick(g, cat.Brands)
+	a := fakeAsset{
+		Name:       fmt.Sprintf("%s %s %s", brand, item, g.model()),
+		Quantity:   1,
+		Attributes: map[string]any{},
+		// Spread over five years, so history charts have something to show
+		CreatedAt: g.now.Add(-time.Duration(g.rnd.Int64N(int64(5 * 365 * 24 * time.Hour)))),
+	}
+	a.Description = fmt.Sprintf("%s %s by %s. %s", pick(g, adjectives), strings.ToLower(item), brand, pick(g, descriptionDetails))
+	if g.chance(0.1) {
+		a.Quantity = 2 + g.rnd.IntN(4)
+	}
+	if g.chance(0.15) {
+		n := pick(g, notes)
+		a.Notes = &n
+	}
+
+	if g.chance(0.85) {
+		// Prices cluster at the low end of the range, like real inventories
+		price := cat.MinPrice + (cat.MaxPrice-cat.MinPrice)*math.Pow(g.rnd.Float64(), 2)
+		price = math.Round(price*100) / 100
+		a.PurchasePrice = &price
+	}
+	if g.chance(0.8) {
+		purchased := a.CreatedAt.Add(-time.Duration(g.rnd.Int64N(int64(90 * 24 * time.Hour)))).Truncate(24 * time.Hour)
+		a.PurchaseAt = &purchased
+		if g.chance(0.25) {
+			a.Warranty = &fakeWarranty{
+				Provider:  pick(g, warrantyProviders),
+				StartDate: purchased,
+				EndDate:   purchased.AddDate(1+g.rnd.IntN(4), 0, 0),
+			}
+		}
+	}
+
+	for _, attr := range cat.Attributes {
+		if g.chance(0.9) {
+			a.Attributes[attr.Key] = g.attributeValue(attr)
+		}
+	}
+	return a
+}
+
+// attributeValue generates a value of an attribute
+func (g *generator) attributeValue(attr attributeSpec) any {
+	if len(attr.Options) > 0 {
+		return pick(g, attr.Options)
+	}
+	switch attr.DataType {
+	case domain.AttributeTypeNumber:
+		return math.Round(attr.Min + (attr.Max-attr.Min)*g.rnd.Float64())
+	case domain.AttributeTypeBoolean:
+		return g.chance(0.5)
+	case domain.AttributeTypeDate:
+		days := g.rnd.IntN(50 * 365)
+		return g.now.AddDate(0, 0, -days).Format("2006-01-02")
+	default:
+		return g.serial()
+	}
+}
+
+// model returns a model designation such as "XR-420"
+func (g *generator) model() string {
+	return fmt.Sprintf("%c%c-%d", 'A'+rune(g.rnd.IntN(26)), 'A'+rune(g.rnd.IntN(26)), 10*(1+g.rnd.IntN(99)))
+}
+
+// serial returns a serial number such as "K7Q2-81930"
+func (g *generator) serial() string {
+	const chars = "ABCDEFGHJKLMNPQRSTUVWXYZ0123456789"
+	var b strings.Builder
+	for range 4 {
+		b.WriteByte(chars[g.rnd.IntN(len(chars))])
+	}
+	fmt.Fprintf(&b, "-%05d", g.rnd.IntN(100000))
+	return b.String()
+}
+
+// chance returns true with probability p
+func (g *generator) chance(p float64) bool {
+	return g.rnd.Float64() < p
+}
+
+func pick[T any](g *generator, items []T) T {
+	return items[g.rnd.IntN(len(items))]
+}
+
+// photo generates a JPEG standing in for a product photo: an object on a
+// gradient background, different for every call
+func (g *generator) photo() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, photoWidth, photoHeight))
+	top, bottom, object := g.color(), g.color(), g.color()
+	cx, cy := photoWidth/4+g.rnd.IntN(photoWidth/2), photoHeight/4+g.rnd.IntN(photoHeight/2)
+	rx, ry := 40+g.rnd.IntN(120), 40+g.rnd.IntN(100)
+	round := g.chance(0.5)
+
+	for y := range photoHeight {
+		t := float64(y) / photoHeight
+		bg := color.RGBA{blend(top.R, bottom.R, t), blend(top.G, bottom.G, t), blend(top.B, bottom.B, t), 255}
+		for x := range photoWidth {
+			dx, dy := float64(x-cx)/float64(rx), float64(y-cy)/float64(ry)
+			inside := math.Abs(dx) <= 1 && math.Abs(dy) <= 1
+			if round {
+				inside = dx*dx+dy*dy <= 1
+			}
+			if inside {
+				img.SetRGBA(x, y, object)
+			} else {
+				img.SetRGBA(x, y, bg)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g *generator) color() color.RGBA {
+	return color.RGBA{uint8(g.rnd.IntN(256)), uint8(g.rnd.IntN(256)), uint8(g.rnd.IntN(256)), 255}
+}
+
+func blend(a, b uint8, t float64) uint8 {
+	return uint8(float64(a)*(1-t) + float64(b)*t)
+}
@@ -0,0 +1,81 @@
+package seed
+
+import (
+	"bytes"
+	"image/jpeg"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_generator_SameSeedSameData(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	cat := &catalog[0].Children[0]
+	a, b := newGenerator(42, now), newGenerator(42, now)
+	for range 20 {
+		if x, y := a.asset(cat), b.asset(cat); !reflect.DeepEqual(x, y) {
+			t.Fatalf("expected the same assets, got %+v and %+v", x, y)
+		}
+	}
+	if x, y := newGenerator(1, now).asset(cat), newGenerator(2, now).asset(cat); x.Name == y.Name && x.Description == y.Description {
+		t.Error("expected different seeds to generate different assets")
+	}
+}
+
+func Test_generator_Asset(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	g := newGenerator(7, now)
+	for _, parent := range catalog {
+		for i := range parent.Children {
+			cat := &parent.Children[i]
+			if len(cat.Items) == 0 || len(cat.Brands) == 0 || cat.MinPrice > cat.MaxPrice {
+				t.Fatalf("%s: incomplete category", cat.Name)
+			}
+			for range 50 {
+				a := g.asset(cat)
+				if a.Name == "" || a.Description == "" || a.Quantity < 1 {
+					t.Fatalf("%s: incomplete asset %+v", cat.Name, a)
+				}
+				if a.CreatedAt.After(now) || a.CreatedAt.Before(now.AddDate(-5, 0, -1)) {
+					t.Errorf("%s: created at %s, expected within five years", cat.Name, a.CreatedAt)
+				}
+				if p := a.PurchasePrice; p != nil && (*p < cat.MinPrice || *p > cat.MaxPrice) {
+					t.Errorf("%s: price %.2f out of range", cat.Name, *p)
+				}
+				if a.PurchaseAt != nil && a.PurchaseAt.After(a.CreatedAt) {
+					t.Errorf("%s: purchased after it was added", cat.Name)
+				}
+				if w := a.Warranty; w != nil && !w.EndDate.After(w.StartDate) {
+					t.Errorf("%s: warranty ends before it starts", cat.Name)
+				}
+				for key := range a.Attributes {
+					found := false
+					for _, attr := range cat.Attributes {
+						found = found || attr.Key == key
+					}
+					if !found {
+						t.Errorf("%s: unexpected attribute %q", cat.Name, key)
+					}
+				}
+			}
+		}
+	}
+}
+
+func Test_generator_Photo(t *testing.T) {
+	g := newGenerator(3, time.Now())
+	data, err := g.photo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("expected a JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != photoWidth || b.Dy() != photoHeight {
+		t.Errorf("unexpected size %v", b)
+	}
+	if other, _ := g.photo(); bytes.Equal(data, other) {
+		t.Error("expected photos to differ")
+	}
+}
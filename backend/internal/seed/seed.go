@@ -0,0 +1,391 @@
+// Package seed generates fake inventory data (categories with attributes,
+// locations, tags and assets with warranties and photos) for load testing and
+// benchmarking the list, search and report endpoints at scale
+package seed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/lmmendes/attic/internal/storage"
+)
+
+// batchSize is the number of assets inserted per transaction
+const batchSize = 1000
+
+// Options configures a seed run
+type Options struct {
+	Assets  int   // Number of assets to generate
+	Photos  bool  // Upload a generated main photo per asset
+	Seed    int64 // The same seed generates the same data
+	Workers int   // Parallel photo uploads (default 8)
+}
+
+// Result counts what a seed run generated
+type Result struct {
+	Categories int `json:"categories"`
+	Locations  int `json:"locations"`
+	Assets     int `json:"assets"`
+	Photos     int `json:"photos"`
+}
+
+// Storage stores generated photos
+type Storage interface {
+	UploadObject(ctx context.Context, obj storage.Object, contentType string, body io.Reader) (string, error)
+}
+
+// Seeder writes generated data to the database and file storage
+type Seeder struct {
+	pool    *pgxpool.Pool
+	storage Storage
+}
+
+// New creates a seeder; storage may be nil if no photos are generated
+func New(pool *pgxpool.Pool, storage Storage) *Seeder {
+	return &Seeder{pool: pool, storage: storage}
+}
+
+// leafCategory is a created category that generated assets belong to
+type leafCategory struct {
+	ID   uuid.UUID
+	Spec *categorySpec
+}
+
+// seededAsset is a generated asset with its relations
+type seededAsset struct {
+	fakeAsset
+	ID          uuid.UUID
+	CategoryID  uuid.UUID
+	LocationID  *uuid.UUID
+	ConditionID *uuid.UUID
+	OwnerID     *uuid.UUID
+	TagIDs      []uuid.UUID
+}
+
+// Run adds generated assets to an organization. The categories, locations
+// and tags they use are created once and reused by later runs.
+func (s *Seeder) Run(ctx context.Context, orgID uuid.UUID, opts Options) (*Result, error) {
+	if opts.Photos && s.storage == nil {
+		return nil, errors.New("generating photos needs file storage")
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 8
+	}
+	g := newGenerator(opts.Seed, time.Now())
+
+	categories, err := s.createCategories(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("creating categories: %w", err)
+	}
+	locations, err := s.createLocations(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("creating locations: %w", err)
+	}
+	tags, err := s.createTags(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("creating tags: %w", err)
+	}
+	conditions, err := s.ids(ctx, `SELECT id FROM conditions WHERE organization_id = $1 AND deleted_at IS NULL ORDER BY sort_order`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("listing conditions: %w", err)
+	}
+	owners, err := s.ids(ctx, `SELECT user_id FROM organization_members WHERE organization_id = $1 ORDER BY created_at`, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("listing members: %w", err)
+	}
+
+	result := &Result{Categories: len(categories), Locations: len(locations)}
+	for result.Assets < opts.Assets {
+		batch := make([]seededAsset, min(batchSize, opts.Assets-result.Assets))
+		for i := range batch {
+			cat := pick(g, categories)
+			a := seededAsset{fakeAsset: g.asset(cat.Spec), ID: uuid.New(), CategoryID: cat.ID}
+			if g.chance(0.95) {
+				a.LocationID = ptr(pick(g, locations))
+			}
+			if len(conditions) > 0 && g.chance(0.7) {
+				a.ConditionID = ptr(pick(g, conditions))
+			}
+			if len(owners) > 0 && g.chance(0.5) {
+				a.OwnerID = ptr(pick(g, owners))
+			}
+			for _, tag := range tags {
+				if g.chance(0.08) {
+					a.TagIDs = append(a.TagIDs, tag)
+				}
+			}
+			batch[i] = a
+		}
+
+		if err := s.insertAssets(ctx, orgID, batch); err != nil {
+			return result, fmt.Errorf("inserting assets: %w", err)
+		}
+		result.Assets += len(batch)
+		if opts.Photos {
+			photos, err := s.addPhotos(ctx, orgID, g, batch, opts.Workers)
+			result.Photos += photos
+			if err != nil {
+				return result, fmt.Errorf("adding photos: %w", err)
+			}
+		}
+		slog.Info("seeded assets", "assets", result.Assets, "total", opts.Assets, "photos", result.Photos)
+	}
+	return result, nil
+}
+
+// createCategories creates the categories of the catalog with their
+// attributes, returning the leaves
+func (s *Seeder) createCategories(ctx context.Context, orgID uuid.UUID) ([]leafCategory, error) {
+	var leaves []leafCategory
+	for i := range catalog {
+		parentID, err := s.findOrCreate(ctx, "categories", orgID, nil, catalog[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		for j := range catalog[i].Children {
+			spec := &catalog[i].Children[j]
+			id, err := s.findOrCreate(ctx, "categories", orgID, &parentID, spec.Name)
+			if err != nil {
+				return nil, err
+			}
+			for k, attr := range spec.Attributes {
+				var attrID uuid.UUID
+				err := s.pool.QueryRow(ctx, `
+					INSERT INTO attributes (organization_id, name, key, data_type)
+					VALUES ($1, $2, $3, $4)
+					ON CONFLICT (organization_id, key) DO UPDATE SET updated_at = NOW()
+					RETURNING id
+				`, orgID, attr.Name, attr.Key, attr.DataType).Scan(&attrID)
+				if err != nil {
+					return nil, err
+				}
+				_, err = s.pool.Exec(ctx, `
+					INSERT INTO category_attributes (category_id, attribute_id, sort_order)
+					VALUES ($1, $2, $3)
+					ON CONFLICT DO NOTHING
+				`, id, attrID, k)
+				if err != nil {
+					return nil, err
+				}
+			}
+			leaves = append(leaves, leafCategory{ID: id, Spec: spec})
+		}
+	}
+	return leaves, nil
+}
+
+// createLocations creates the location tree, returning the leaves
+func (s *Seeder) createLocations(ctx context.Context, orgID uuid.UUID) ([]uuid.UUID, error) {
+	var leaves []uuid.UUID
+	for _, building := range sortedKeys(locationTree) {
+		buildingID, err := s.findOrCreate(ctx, "locations", orgID, nil, building)
+		if err != nil {
+			return nil, err
+		}
+		rooms := locationTree[building]
+		for _, room := range sortedKeys(rooms) {
+			roomID, err := s.findOrCreate(ctx, "locations", orgID, &buildingID, room)
+			if err != nil {
+				return nil, err
+			}
+			for _, spot := range rooms[room] {
+				id, err := s.findOrCreate(ctx, "locations", orgID, &roomID, spot)
+				if err != nil {
+					return nil, err
+				}
+				leaves = append(leaves, id)
+			}
+		}
+	}
+	return leaves, nil
+}
+
+func (s *Seeder) createTags(ctx context.Context, orgID uuid.UUID) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, len(tagNames))
+	for i, name := range tagNames {
+		err := s.pool.QueryRow(ctx, `
+			INSERT INTO tags (organization_id, name) VALUES ($1, $2)
+			ON CONFLICT (organization_id, name) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id
+		`, orgID, name).Scan(&ids[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// findOrCreate returns the ID of the category or location (table) with name
+// below parentID, creating it if needed
+func (s *Seeder) findOrCreate(ctx context.Context, table string, orgID uuid.UUID, parentID *uuid.UUID, name string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := s.pool.QueryRow(ctx, `
+		SELECT id FROM `+table+`
+		WHERE organization_id = $1 AND parent_id IS NOT DISTINCT FROM $2 AND name = $3 AND deleted_at IS NULL
+		ORDER BY created_at LIMIT 1
+	`, orgID, parentID, name).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = s.pool.QueryRow(ctx, `INSERT INTO `+table+` (organization_id, parent_id, name) VALUES ($1, $2, $3) RETURNING id`, orgID, parentID, name).Scan(&id)
+	}
+	return id, err
+}
+
+func (s *Seeder) ids(ctx context.Context, query string, args ...any) ([]uuid.UUID, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+}
+
+// insertAssets copies a batch of assets with their tags and warranties
+func (s *Seeder) insertAssets(ctx context.Context, orgID uuid.UUID, batch []seededAsset) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var assetRows, tagRows, warrantyRows [][]any
+	for _, a := range batch {
+		assetRows = append(assetRows, []any{
+			a.ID, orgID, a.CategoryID, a.LocationID, a.ConditionID, a.OwnerID, a.Name, a.Description, a.Notes,
+			a.Quantity, a.Attributes, a.PurchaseAt, a.PurchasePrice, a.CreatedAt, a.CreatedAt,
+		})
+		for _, tagID := range a.TagIDs {
+			tagRows = append(tagRows, []any{a.ID, tagID})
+		}
+		if w := a.Warranty; w != nil {
+			warrantyRows = append(warrantyRows, []any{a.ID, w.Provider, w.StartDate, w.EndDate})
+		}
+	}
+
+	copies := []struct {
+		table   string
+		columns []string
+		rows    [][]any
+	}{
+		{"assets", []string{
+			"id", "organization_id", "category_id", "location_id", "condition_id", "owner_id", "name", "description", "notes",
+			"quantity", "attributes", "purchase_at", "purchase_price", "created_at", "updated_at",
+		}, assetRows},
+		{"asset_tags", []string{"asset_id", "tag_id"}, tagRows},
+		{"warranties", []string{"asset_id", "provider", "start_date", "end_date"}, warrantyRows},
+	}
+	for _, c := range copies {
+		if _, err := tx.CopyFrom(ctx, pgx.Identifier{c.table}, c.columns, pgx.CopyFromRows(c.rows)); err != nil {
+			return fmt.Errorf("copying %s: %w", c.table, err)
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// addPhotos uploads a generated photo for every asset of a batch and makes
+// it the main image, returning the number of photos added
+func (s *Seeder) addPhotos(ctx context.Context, orgID uuid.UUID, g *generator, batch []seededAsset, workers int) (int, error) {
+	keys := make([]string, len(batch))
+	sizes := make([]int64, len(batch))
+	seeds := make([]int64, len(batch))
+	for i := range seeds {
+		seeds[i] = g.rnd.Int64()
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	jobs := make(chan int)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				photo, err := newGenerator(seeds[i], g.now).photo()
+				if err == nil {
+					a := batch[i]
+					keys[i], err = s.storage.UploadObject(ctx, storage.Object{
+						OrganizationID: orgID,
+						AssetID:        a.ID,
+						FileName:       a.Name + ".jpg",
+						CreatedAt:      a.CreatedAt,
+					}, "image/jpeg", bytes.NewReader(photo))
+					sizes[i] = int64(len(photo))
+				}
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range batch {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var rows [][]any
+	var assetIDs, attachmentIDs []uuid.UUID
+	for i, a := range batch {
+		if keys[i] == "" {
+			continue
+		}
+		id := uuid.New()
+		rows = append(rows, []any{id, a.ID, keys[i], a.Name + ".jpg", sizes[i], "image/jpeg", a.CreatedAt})
+		assetIDs = append(assetIDs, a.ID)
+		attachmentIDs = append(attachmentIDs, id)
+	}
+	if len(rows) == 0 {
+		return 0, firstErr
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	columns := []string{"id", "asset_id", "file_key", "file_name", "file_size", "content_type", "created_at"}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"attachments"}, columns, pgx.CopyFromRows(rows)); err != nil {
+		return 0, fmt.Errorf("copying attachments: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE assets a SET main_attachment_id = v.attachment_id
+		FROM unnest($1::uuid[], $2::uuid[]) AS v(asset_id, attachment_id)
+		WHERE a.id = v.asset_id
+	`, assetIDs, attachmentIDs)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(rows), firstErr
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
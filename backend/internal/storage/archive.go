@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"strings"
+)
+
+// ArchivePrefix is the key prefix of files in the archive tier
+const ArchivePrefix = "archive/"
+
+// Archiver is implemented by storages with an archive tier, a cheaper place
+// for files that are rarely read such as the attachments of deleted assets
+type Archiver interface {
+	// Archive copies a file under ArchivePrefix in the archive tier and
+	// returns the key of the copy; the caller deletes the original
+	Archive(ctx context.Context, key string) (string, error)
+
+	// Retrieve prepares an archived file for reading. It returns false while
+	// the file is restored from a tier without immediate access (e.g. S3
+	// Glacier Flexible Retrieval), which can take hours; call it again later.
+	Retrieve(ctx context.Context, key string) (bool, error)
+}
+
+// IsArchived reports whether key is a file in the archive tier
+func IsArchived(key string) bool {
+	return strings.HasPrefix(key, ArchivePrefix)
+}
+
+// archiveKey returns the key of an archived copy of a file
+func archiveKey(key string) string {
+	return ArchivePrefix + key
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// maxFileNameLength is the longest sanitized file name in a storage key
+const maxFileNameLength = 100
+
+// Layout decides the keys of uploaded files. Every key ends in a random
+// directory and the sanitized file name, so uploads never collide.
+type Layout string
+
+const (
+	// LayoutFlat keys files as <uuid>/<file name> (the default)
+	LayoutFlat Layout = "flat"
+
+	// LayoutAsset groups the files of an asset under
+	// <org>/assets/<asset>/, and other files under <org>/<kind>/
+	LayoutAsset Layout = "asset"
+
+	// LayoutDate groups files by upload month under <org>/<yyyy>/<mm>/, so
+	// lifecycle rules can expire or archive old prefixes
+	LayoutDate Layout = "date"
+)
+
+// ParseLayout returns the layout of a configuration value ("" = flat)
+func ParseLayout(s string) (Layout, error) {
+	switch Layout(strings.ToLower(s)) {
+	case "", LayoutFlat:
+		return LayoutFlat, nil
+	case LayoutAsset:
+		return LayoutAsset, nil
+	case LayoutDate:
+		return LayoutDate, nil
+	default:
+		return "", fmt.Errorf("invalid storage layout %q (flat, asset or date)", s)
+	}
+}
+
+// Object describes a file to store, for placing it by the layout
+type Object struct {
+	OrganizationID uuid.UUID // uuid.Nil = not placed under an organization
+	AssetID        uuid.UUID // uuid.Nil for files not attached to an asset
+	Kind           string    // Prefix of files not attached to an asset, e.g. "branding"
+	FileName       string
+	CreatedAt      time.Time // Zero = now
+}
+
+// Key returns a new storage key for obj
+func (l Layout) Key(obj Object) string {
+	return path.Join(l.prefix(obj), uuid.New().String(), SanitizeFileName(obj.FileName))
+}
+
+// Matches reports whether key was placed by this layout, i.e. it doesn't need
+// to be moved for obj
+func (l Layout) Matches(key string, obj Object) bool {
+	dir, _ := path.Split(key)
+	dir = strings.TrimSuffix(dir, "/")
+	prefix, random := path.Split(dir)
+	if uuid.Validate(random) != nil {
+		return false
+	}
+	return strings.TrimSuffix(prefix, "/") == l.prefix(obj)
+}
+
+func (l Layout) prefix(obj Object) string {
+	if l == LayoutFlat || l == "" || obj.OrganizationID == uuid.Nil {
+		return ""
+	}
+	org := obj.OrganizationID.String()
+
+	if l == LayoutDate {
+		created := obj.CreatedAt
+		if created.IsZero() {
+			created = time.Now()
+		}
+		return path.Join(org, created.UTC().Format("2006/01"))
+	}
+
+	if obj.AssetID != uuid.Nil {
+		return path.Join(org, "assets", obj.AssetID.String())
+	}
+	kind := SanitizeFileName(obj.Kind)
+	if obj.Kind == "" {
+		kind = "files"
+	}
+	return path.Join(org, kind)
+}
+
+// SanitizeFileName makes an uploaded file name safe to use in a storage key
+// and browsable in S3 consoles: directories are dropped, characters other
+// than letters, digits, dots, dashes and underscores become dashes, and long
+// names are shortened keeping the extension
+func SanitizeFileName(name string) string {
+	// Clients may send full paths, with either separator
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)), r == '.', r == '_', r == '-':
+			b.WriteRune(r)
+			dash = false
+		case !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	clean := strings.Trim(b.String(), ".-")
+	if clean == "" {
+		return "file"
+	}
+
+	if len(clean) > maxFileNameLength {
+		ext := path.Ext(clean)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		clean = strings.TrimRight(clean[:maxFileNameLength-len(ext)], ".-") + ext
+	}
+	return clean
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func Test_ParseLayout(t *testing.T) {
+	for value, want := range map[string]Layout{"": LayoutFlat, "flat": LayoutFlat, "Asset": LayoutAsset, "date": LayoutDate} {
+		got, err := ParseLayout(value)
+		if err != nil || got != want {
+			t.Errorf("ParseLayout(%q) = %q, %v; want %q", value, got, err, want)
+		}
+	}
+	if _, err := ParseLayout("yearly"); err == nil {
+		t.Error("expected an error for an unknown layout")
+	}
+}
+
+func Test_Layout_Key(t *testing.T) {
+	org := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	asset := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	created := time.Date(2026, 3, 9, 23, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		layout Layout
+		obj    Object
+		prefix string
+	}{
+		{LayoutFlat, Object{OrganizationID: org, AssetID: asset, FileName: "photo.jpg"}, ""},
+		{LayoutAsset, Object{OrganizationID: org, AssetID: asset, FileName: "photo.jpg"}, org.String() + "/assets/" + asset.String() + "/"},
+		{LayoutAsset, Object{OrganizationID: org, Kind: "reports", FileName: "photo.jpg"}, org.String() + "/reports/"},
+		{LayoutAsset, Object{FileName: "photo.jpg"}, ""},
+		{LayoutDate, Object{OrganizationID: org, AssetID: asset, FileName: "photo.jpg", CreatedAt: created}, org.String() + "/2026/03/"},
+	}
+	for _, tt := range tests {
+		key := tt.layout.Key(tt.obj)
+		rest, ok := strings.CutPrefix(key, tt.prefix)
+		random, name, _ := strings.Cut(rest, "/")
+		if !ok || uuid.Validate(random) != nil || name != "photo.jpg" {
+			t.Errorf("%s layout: expected %s<uuid>/photo.jpg, got %q", tt.layout, tt.prefix, key)
+		}
+		if !tt.layout.Matches(key, tt.obj) {
+			t.Errorf("%s layout: expected %q to match", tt.layout, key)
+		}
+	}
+
+	obj := Object{OrganizationID: org, AssetID: asset, FileName: "photo.jpg"}
+	flat := LayoutFlat.Key(obj)
+	if LayoutAsset.Matches(flat, obj) {
+		t.Errorf("expected flat key %q not to match the asset layout", flat)
+	}
+	if LayoutFlat.Matches(LayoutAsset.Key(obj), obj) {
+		t.Error("expected asset key not to match the flat layout")
+	}
+	if LayoutFlat.Matches("uploads/photo.jpg", obj) {
+		t.Error("expected a key without random directory not to match")
+	}
+}
+
+func Test_SanitizeFileName(t *testing.T) {
+	tests := map[string]string{
+		"photo.jpg":                          "photo.jpg",
+		"My Receipt (2024).pdf":              "My-Receipt-2024-.pdf",
+		"../../etc/passwd":                   "passwd",
+		`C:\Users\me\scan.png`:               "scan.png",
+		"Überweisung für Möbel.pdf":          "berweisung-f-r-M-bel.pdf",
+		"..":                                 "file",
+		"":                                   "file",
+		"?*:":                                "file",
+		".hidden":                            "hidden",
+		strings.Repeat("a", 150) + ".tar.gz": strings.Repeat("a", maxFileNameLength-len(".gz")) + ".gz",
+	}
+	for name, want := range tests {
+		if got := SanitizeFileName(name); got != want {
+			t.Errorf("SanitizeFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func Test_LocalStorage_UploadObject_UsesLayout(t *testing.T) {
+	s, err := NewLocalStorage(LocalConfig{BasePath: t.TempDir(), BaseURL: "http://localhost/files", Layout: LayoutAsset})
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	obj := Object{OrganizationID: uuid.New(), AssetID: uuid.New(), FileName: "../My Photo.jpg"}
+	key, err := s.UploadObject(context.Background(), obj, "image/jpeg", strings.NewReader("jpeg"))
+	if err != nil {
+		t.Fatalf("UploadObject: %v", err)
+	}
+	if !strings.HasPrefix(key, obj.OrganizationID.String()+"/assets/"+obj.AssetID.String()+"/") || !strings.HasSuffix(key, "/My-Photo.jpg") {
+		t.Errorf("unexpected key %q", key)
+	}
+	if _, err := s.GetPresignedURL(context.Background(), key, time.Minute); err != nil {
+		t.Errorf("expected the uploaded file to exist: %v", err)
+	}
+}
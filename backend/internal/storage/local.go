@@ -2,13 +2,16 @@ package storage
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 // LocalStorage implements file storage on the local filesystem
@@ -17,6 +20,8 @@ type LocalStorage struct {
 	baseURL  string
 	puid     *int
 	pgid     *int
+	layout   Layout
+	signKey  []byte // nil = unsigned URLs
 }
 
 // LocalConfig holds local storage configuration
@@ -29,6 +34,11 @@ type LocalConfig struct {
 	PUID *int
 	// PGID is the group ID for file ownership (nil = don't change ownership)
 	PGID *int
+	// Layout decides the keys of uploaded files (empty = flat)
+	Layout Layout
+	// SigningKey signs the URLs of GetPresignedURL, so files can only be
+	// fetched by their expiring URL (nil = unsigned, permanent URLs)
+	SigningKey []byte
 }
 
 // NewLocalStorage creates a new local file storage client
@@ -43,6 +53,12 @@ func NewLocalStorage(cfg LocalConfig) (*LocalStorage, error) {
 		baseURL:  cfg.BaseURL,
 		puid:     cfg.PUID,
 		pgid:     cfg.PGID,
+		layout:   cfg.Layout,
+	}
+	if len(cfg.SigningKey) > 0 {
+		mac := hmac.New(sha256.New, cfg.SigningKey)
+		mac.Write([]byte("local-storage-url"))
+		s.signKey = mac.Sum(nil)
 	}
 
 	// Chown the base directory if PUID/PGID are configured
@@ -55,27 +71,59 @@ func NewLocalStorage(cfg LocalConfig) (*LocalStorage, error) {
 
 // Upload saves a file to local storage and returns the storage key
 func (s *LocalStorage) Upload(ctx context.Context, filename string, contentType string, body io.Reader) (string, error) {
-	// Create a unique key like S3 does: uuid/filename
-	key := fmt.Sprintf("%s/%s", uuid.New().String(), filename)
+	return s.UploadObject(ctx, Object{FileName: filename}, contentType, body)
+}
+
+// UploadObject saves a file at a key of the storage layout and returns the key
+func (s *LocalStorage) UploadObject(ctx context.Context, obj Object, contentType string, body io.Reader) (string, error) {
+	key := s.layout.Key(obj)
+	if err := s.write(key, body); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Archive copies a file to the archive directory; local files are always
+// readable, so archiving only sets them apart
+func (s *LocalStorage) Archive(ctx context.Context, key string) (string, error) {
+	f, err := s.Open(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	archived := archiveKey(key)
+	if err := s.write(archived, f); err != nil {
+		return "", err
+	}
+	return archived, nil
+}
+
+// Retrieve reports that an archived file can be read
+func (s *LocalStorage) Retrieve(ctx context.Context, key string) (bool, error) {
+	return true, nil
+}
 
+// write saves the content of a file at key
+func (s *LocalStorage) write(key string, body io.Reader) error {
 	// Create the full path
 	fullPath := filepath.Join(s.basePath, key)
 
 	// Create parent directory if needed
 	dir := filepath.Dir(fullPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("creating directory: %w", err)
+		return fmt.Errorf("creating directory: %w", err)
 	}
 
 	// Chown the directory if PUID/PGID are configured
 	if err := s.chown(dir); err != nil {
-		return "", fmt.Errorf("setting directory ownership: %w", err)
+		return fmt.Errorf("setting directory ownership: %w", err)
 	}
 
 	// Create the file
 	file, err := os.Create(fullPath)
 	if err != nil {
-		return "", fmt.Errorf("creating file: %w", err)
+		return fmt.Errorf("creating file: %w", err)
 	}
 	defer file.Close()
 
@@ -83,21 +131,21 @@ func (s *LocalStorage) Upload(ctx context.Context, filename string, contentType
 	if _, err := io.Copy(file, body); err != nil {
 		// Clean up on failure
 		os.Remove(fullPath)
-		return "", fmt.Errorf("writing file: %w", err)
+		return fmt.Errorf("writing file: %w", err)
 	}
 
 	// Chown the file if PUID/PGID are configured
 	if err := s.chown(fullPath); err != nil {
 		// Clean up on failure
 		os.Remove(fullPath)
-		return "", fmt.Errorf("setting file ownership: %w", err)
+		return fmt.Errorf("setting file ownership: %w", err)
 	}
 
-	return key, nil
+	return nil
 }
 
-// GetPresignedURL returns a URL for accessing the file
-// For local storage, this returns a direct URL path (no expiry is enforced)
+// GetPresignedURL returns a URL for accessing the file. With a signing key
+// the URL carries its expiry and signature (see VerifyURL).
 func (s *LocalStorage) GetPresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
 	// Verify the file exists
 	fullPath := filepath.Join(s.basePath, key)
@@ -106,7 +154,42 @@ func (s *LocalStorage) GetPresignedURL(ctx context.Context, key string, expiry t
 	}
 
 	// Return the URL to access this file
-	return fmt.Sprintf("%s/%s", s.baseURL, key), nil
+	fileURL := fmt.Sprintf("%s/%s", s.baseURL, key)
+	if s.signKey == nil {
+		return fileURL, nil
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.sign(key, expires)}}
+	return fileURL + "?" + query.Encode(), nil
+}
+
+// VerifyURL reports whether the query of a request for key carries an
+// unexpired signature of GetPresignedURL
+func (s *LocalStorage) VerifyURL(key string, query url.Values) bool {
+	if s.signKey == nil {
+		return false
+	}
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(query.Get("signature")), []byte(s.sign(key, expires)))
+}
+
+func (s *LocalStorage) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Open opens a file in local storage for reading
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(filepath.Join(s.basePath, key))
+	if err != nil {
+		return nil, fmt.Errorf("opening file: %w", err)
+	}
+	return file, nil
 }
 
 // Delete removes a file from local storage
@@ -140,3 +223,13 @@ func (s *LocalStorage) chown(path string) error {
 	}
 	return os.Chown(path, *s.puid, *s.pgid)
 }
+
+// Check verifies files can be written to the storage directory
+func (s *LocalStorage) Check(ctx context.Context) error {
+	f, err := os.CreateTemp(s.basePath, ".attic-check-*")
+	if err != nil {
+		return fmt.Errorf("writing to storage directory: %w", err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"io"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -118,6 +119,34 @@ func Test_LocalStorage_GetPresignedURL_FileNotFound(t *testing.T) {
 	}
 }
 
+func Test_LocalStorage_Open_ReturnsContent(t *testing.T) {
+	storage, _ := NewLocalStorage(LocalConfig{
+		BasePath: t.TempDir(),
+		BaseURL:  "http://localhost:8080/files",
+	})
+
+	ctx := context.Background()
+	key, err := storage.Upload(ctx, "open-test.txt", "text/plain", strings.NewReader("file content"))
+	if err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+
+	file, err := storage.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+	defer file.Close()
+
+	data, _ := io.ReadAll(file)
+	if string(data) != "file content" {
+		t.Errorf("expected 'file content', got %q", data)
+	}
+
+	if _, err := storage.Open(ctx, "non-existent-key/file.txt"); err == nil {
+		t.Error("expected error for non-existent file")
+	}
+}
+
 func Test_LocalStorage_Delete_Success(t *testing.T) {
 	tmpDir := t.TempDir()
 	storage, _ := NewLocalStorage(LocalConfig{
@@ -270,6 +299,47 @@ func Test_S3Client_ImplementsFileStorage(t *testing.T) {
 	var _ FileStorage = (*S3Client)(nil)
 }
 
+func Test_Storages_ImplementArchiver(t *testing.T) {
+	var _ Archiver = (*LocalStorage)(nil)
+	var _ Archiver = (*S3Client)(nil)
+}
+
+func Test_LocalStorage_Archive_CopiesUnderArchivePrefix(t *testing.T) {
+	storage, err := NewLocalStorage(LocalConfig{BasePath: t.TempDir(), BaseURL: "http://localhost:8080/files"})
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	ctx := context.Background()
+	key, err := storage.Upload(ctx, "receipt.pdf", "application/pdf", strings.NewReader("receipt"))
+	if err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+
+	archived, err := storage.Archive(ctx, key)
+	if err != nil {
+		t.Fatalf("failed to archive: %v", err)
+	}
+	if archived != ArchivePrefix+key || !IsArchived(archived) || IsArchived(key) {
+		t.Errorf("unexpected archive key %q for %q", archived, key)
+	}
+
+	ready, err := storage.Retrieve(ctx, archived)
+	if err != nil || !ready {
+		t.Fatalf("expected archived local files to be readable, got %v, %v", ready, err)
+	}
+	for _, k := range []string{key, archived} {
+		f, err := storage.Open(ctx, k)
+		if err != nil {
+			t.Fatalf("failed to open %q: %v", k, err)
+		}
+		data, _ := io.ReadAll(f)
+		f.Close()
+		if string(data) != "receipt" {
+			t.Errorf("unexpected content of %q: %q", k, data)
+		}
+	}
+}
+
 func Test_LocalStorage_Upload_ReaderError(t *testing.T) {
 	tmpDir := t.TempDir()
 	storage, _ := NewLocalStorage(LocalConfig{
@@ -460,3 +530,42 @@ func Test_LocalStorage_Upload_WithPUIDPGID_Success(t *testing.T) {
 		t.Errorf("expected content '%s', got '%s'", content, string(data))
 	}
 }
+
+func Test_LocalStorage_GetPresignedURL_Signed(t *testing.T) {
+	storage, _ := NewLocalStorage(LocalConfig{
+		BasePath:   t.TempDir(),
+		BaseURL:    "http://localhost:8080/files",
+		SigningKey: []byte("test-secret"),
+	})
+	ctx := context.Background()
+	key, err := storage.Upload(ctx, "signed.txt", "text/plain", strings.NewReader("test content"))
+	if err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+
+	raw, err := storage.GetPresignedURL(ctx, key, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("failed to get URL: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Path != "/files/"+key {
+		t.Fatalf("unexpected URL %q", raw)
+	}
+	if !storage.VerifyURL(key, u.Query()) {
+		t.Error("expected the signed URL to verify")
+	}
+	if storage.VerifyURL("other/"+key, u.Query()) {
+		t.Error("expected the signature to be bound to the key")
+	}
+
+	expired, _ := storage.GetPresignedURL(ctx, key, -time.Minute)
+	u, _ = url.Parse(expired)
+	if storage.VerifyURL(key, u.Query()) {
+		t.Error("expected an expired URL to be rejected")
+	}
+
+	unsigned, _ := NewLocalStorage(LocalConfig{BasePath: t.TempDir()})
+	if unsigned.VerifyURL(key, url.Values{}) {
+		t.Error("expected no URL to verify without a signing key")
+	}
+}
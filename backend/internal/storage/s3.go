@@ -2,21 +2,33 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/google/uuid"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// DefaultArchiveClass is the storage class of archived files: cheaper to
+// keep than STANDARD, yet readable immediately
+const DefaultArchiveClass = "GLACIER_IR"
+
+// archiveRestoreDays is how long a file restored from Glacier stays readable
+const archiveRestoreDays = 7
+
 // S3Client wraps the AWS S3 client for file storage
 type S3Client struct {
-	client *s3.Client
-	bucket string
+	client       *s3.Client
+	bucket       string
+	layout       Layout
+	archiveClass types.StorageClass
 }
 
 // S3Config holds S3 connection configuration
@@ -26,6 +38,12 @@ type S3Config struct {
 	Bucket    string
 	AccessKey string
 	SecretKey string
+	Layout    Layout // Decides the keys of uploaded files (empty = flat)
+
+	// ArchiveClass is the storage class of archived files (empty =
+	// DefaultArchiveClass); GLACIER and DEEP_ARCHIVE need a restore before
+	// the files can be downloaded
+	ArchiveClass string
 }
 
 // NewS3Client creates a new S3 client
@@ -60,15 +78,27 @@ func NewS3Client(ctx context.Context, cfg S3Config) (*S3Client, error) {
 		o.UsePathStyle = true // Required for localstack
 	})
 
+	archiveClass := cfg.ArchiveClass
+	if archiveClass == "" {
+		archiveClass = DefaultArchiveClass
+	}
+
 	return &S3Client{
-		client: client,
-		bucket: cfg.Bucket,
+		client:       client,
+		bucket:       cfg.Bucket,
+		layout:       cfg.Layout,
+		archiveClass: types.StorageClass(strings.ToUpper(archiveClass)),
 	}, nil
 }
 
 // Upload uploads a file to S3 and returns the object key
 func (c *S3Client) Upload(ctx context.Context, filename string, contentType string, body io.Reader) (string, error) {
-	key := fmt.Sprintf("%s/%s", uuid.New().String(), filename)
+	return c.UploadObject(ctx, Object{FileName: filename}, contentType, body)
+}
+
+// UploadObject uploads a file to a key of the storage layout and returns the key
+func (c *S3Client) UploadObject(ctx context.Context, obj Object, contentType string, body io.Reader) (string, error) {
+	key := c.layout.Key(obj)
 
 	_, err := c.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      aws.String(c.bucket),
@@ -98,6 +128,18 @@ func (c *S3Client) GetPresignedURL(ctx context.Context, key string, expiry time.
 	return req.URL, nil
 }
 
+// Open downloads a file from S3
+func (c *S3Client) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("downloading from S3: %w", err)
+	}
+	return out.Body, nil
+}
+
 // Delete deletes a file from S3
 func (c *S3Client) Delete(ctx context.Context, key string) error {
 	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -109,3 +151,66 @@ func (c *S3Client) Delete(ctx context.Context, key string) error {
 	}
 	return nil
 }
+
+// Archive copies a file under the archive prefix with the archive storage class
+func (c *S3Client) Archive(ctx context.Context, key string) (string, error) {
+	archived := archiveKey(key)
+	source := (&url.URL{Path: c.bucket + "/" + key}).EscapedPath()
+
+	_, err := c.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:       aws.String(c.bucket),
+		Key:          aws.String(archived),
+		CopySource:   aws.String(source),
+		StorageClass: c.archiveClass,
+	})
+	if err != nil {
+		return "", fmt.Errorf("archiving in S3: %w", err)
+	}
+	return archived, nil
+}
+
+// Retrieve starts restoring a file in GLACIER or DEEP_ARCHIVE and reports
+// whether it can be downloaded; files of other storage classes always can
+func (c *S3Client) Retrieve(ctx context.Context, key string) (bool, error) {
+	head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return false, fmt.Errorf("reading S3 object: %w", err)
+	}
+	switch head.StorageClass {
+	case types.StorageClassGlacier, types.StorageClassDeepArchive:
+	default:
+		return true, nil
+	}
+	if head.Restore != nil {
+		// Requested before: ongoing-request="true" until the copy is readable
+		return !strings.Contains(*head.Restore, `ongoing-request="true"`), nil
+	}
+
+	_, err = c.client.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+		RestoreRequest: &types.RestoreRequest{
+			Days:                 aws.Int32(archiveRestoreDays),
+			GlacierJobParameters: &types.GlacierJobParameters{Tier: types.TierStandard},
+		},
+	})
+	var apiErr interface{ ErrorCode() string }
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.ErrorCode() == "RestoreAlreadyInProgress") {
+		return false, fmt.Errorf("restoring from S3: %w", err)
+	}
+	return false, nil
+}
+
+// Check verifies the bucket exists and the credentials can access it
+func (c *S3Client) Check(ctx context.Context) error {
+	_, err := c.client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(c.bucket),
+	})
+	if err != nil {
+		return fmt.Errorf("accessing bucket %q: %w", c.bucket, err)
+	}
+	return nil
+}
@@ -11,10 +11,17 @@ type FileStorage interface {
 	// Upload uploads a file and returns the storage key
 	Upload(ctx context.Context, filename string, contentType string, body io.Reader) (string, error)
 
+	// UploadObject uploads a file to a key of the configured layout and
+	// returns the key
+	UploadObject(ctx context.Context, obj Object, contentType string, body io.Reader) (string, error)
+
 	// GetPresignedURL returns a URL for downloading the file
 	// For S3, this is a presigned URL. For local storage, this is a direct path.
 	GetPresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
 
+	// Open returns the content of a file; the caller must close it
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
 	// Delete removes a file from storage
 	Delete(ctx context.Context, key string) error
 }
@@ -76,6 +76,42 @@ func (t *TestDB) Close(ctx context.Context) {
 // TruncateAll truncates all tables to reset state between tests
 func (t *TestDB) TruncateAll(ctx context.Context) error {
 	tables := []string{
+		"asset_events",
+		"organization_invites",
+		"user_preferences",
+		"short_links",
+		"oidc_logout_revocations",
+		"user_sessions",
+		"attachment_downloads",
+		"service_tokens",
+		"passkeys",
+		"imports",
+		"asset_sources",
+		"user_totp",
+		"password_history",
+		"auth_events",
+		"login_events",
+		"organization_settings",
+		"sync_tombstones",
+		"report_runs",
+		"report_schedules",
+		"asset_power_usage",
+		"depreciation_rules",
+		"consumption_schedules",
+		"asset_values",
+		"recurring_costs",
+		"asset_reservations",
+		"maintenance_log",
+		"maintenance_tasks",
+		"reminders",
+		"purchases",
+		"purchase_groups",
+		"loans",
+		"asset_comments",
+		"label_batches",
+		"contacts",
+		"asset_list_items",
+		"asset_lists",
 		"attachments",
 		"warranties",
 		"asset_tags",
@@ -86,7 +122,9 @@ func (t *TestDB) TruncateAll(ctx context.Context) error {
 		"locations",
 		"categories",
 		"conditions",
+		"organization_members",
 		"users",
+		"roles",
 		"organizations",
 	}
 
@@ -0,0 +1,321 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// maxStreamSize is the largest decompressed PDF stream that is parsed
+const maxStreamSize = 16 << 20
+
+// skippedStreams mark stream dictionaries that never hold page text
+// (images, embedded fonts, cross-reference and object streams, XMP metadata)
+var skippedStreams = [][]byte{
+	[]byte("/Image"), []byte("/Length1"), []byte("/Length2"), []byte("/Length3"),
+	[]byte("/XRef"), []byte("/ObjStm"), []byte("/Metadata"),
+}
+
+// extractPDF returns the text shown by the content streams of a PDF.
+//
+// This is a deliberately small extractor: it handles uncompressed and
+// Flate-compressed streams and the text showing operators, decoding strings
+// as Latin-1 (or UTF-16 with a byte order mark). Fonts with custom encodings
+// or CID fonts without a usable mapping yield garbled text, and scanned
+// documents yield none, since there is no OCR.
+func extractPDF(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return "", errors.New("not a PDF document")
+	}
+
+	var out strings.Builder
+	pos := 0
+	for {
+		i := bytes.Index(data[pos:], []byte("stream"))
+		if i < 0 {
+			break
+		}
+		keyword := pos + i
+		start := keyword + len("stream")
+		// "endstream" also contains the keyword
+		if keyword >= 3 && string(data[keyword-3:keyword]) == "end" {
+			pos = start
+			continue
+		}
+
+		if start < len(data) && data[start] == '\r' {
+			start++
+		}
+		if start < len(data) && data[start] == '\n' {
+			start++
+		}
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+
+		dict := data[pos:keyword]
+		if obj := bytes.LastIndex(dict, []byte("obj")); obj >= 0 {
+			dict = dict[obj:]
+		}
+		if body, ok := decodeStream(dict, data[start:start+end]); ok {
+			parseContent(body, &out)
+		}
+		pos = start + end + len("endstream")
+	}
+	return out.String(), nil
+}
+
+// decodeStream returns the decoded stream data, or false for streams that
+// are not worth parsing or use an unsupported filter
+func decodeStream(dict, raw []byte) ([]byte, bool) {
+	for _, marker := range skippedStreams {
+		if bytes.Contains(dict, marker) {
+			return nil, false
+		}
+	}
+
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return raw, true
+	}
+	if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/DecodeParms")) {
+		return nil, false
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, false
+	}
+	defer zr.Close()
+	body, err := io.ReadAll(io.LimitReader(zr, maxStreamSize))
+	// Truncated streams are common in damaged files; keep what was inflated
+	if err != nil && len(body) == 0 {
+		return nil, false
+	}
+	return body, true
+}
+
+// parseContent writes the strings shown by the text operators of a content
+// stream to out
+func parseContent(b []byte, out *strings.Builder) {
+	var pending []string // String operands (and TJ word gaps) since the last operator
+	inArray := false
+
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case isSpace(c):
+			i++
+		case c == '%':
+			for i < len(b) && b[i] != '\n' && b[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, n := literalString(b[i:])
+			pending = append(pending, decodeText(s))
+			i += n
+		case c == '<' && i+1 < len(b) && b[i+1] == '<', c == '>' && i+1 < len(b) && b[i+1] == '>':
+			i += 2
+		case c == '<':
+			s, n := hexString(b[i:])
+			pending = append(pending, decodeText(s))
+			i += n
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '/':
+			i++
+			for i < len(b) && !isSpace(b[i]) && !isDelimiter(b[i]) {
+				i++
+			}
+		default:
+			j := i
+			for j < len(b) && !isSpace(b[j]) && !isDelimiter(b[j]) {
+				j++
+			}
+			if j == i {
+				// Stray delimiter
+				i++
+				continue
+			}
+			token := string(b[i:j])
+			i = j
+
+			if n, err := strconv.ParseFloat(token, 64); err == nil {
+				// Large negative TJ adjustments separate words
+				if inArray && n < -250 {
+					pending = append(pending, " ")
+				}
+				continue
+			}
+
+			switch token {
+			case "Tj", "TJ":
+				for _, s := range pending {
+					out.WriteString(s)
+				}
+			case "'", `"`:
+				out.WriteByte('\n')
+				for _, s := range pending {
+					out.WriteString(s)
+				}
+			case "Td", "TD", "T*", "Tm", "ET":
+				out.WriteByte('\n')
+			case "ID":
+				// Skip inline image data up to the EI operator
+				end := bytes.Index(b[i:], []byte("EI"))
+				for end > 0 && !isSpace(b[i+end-1]) {
+					next := bytes.Index(b[i+end+2:], []byte("EI"))
+					if next < 0 {
+						end = -1
+						break
+					}
+					end += 2 + next
+				}
+				if end < 0 {
+					return
+				}
+				i += end + 2
+			}
+			pending = pending[:0]
+		}
+	}
+}
+
+// literalString parses a (string) at the start of b, returning its bytes and
+// the number of bytes consumed
+func literalString(b []byte) ([]byte, int) {
+	var s []byte
+	depth := 0
+	i := 1
+	for ; i < len(b); i++ {
+		c := b[i]
+		switch c {
+		case '(':
+			depth++
+			s = append(s, c)
+		case ')':
+			if depth == 0 {
+				return s, i + 1
+			}
+			depth--
+			s = append(s, c)
+		case '\\':
+			i++
+			if i >= len(b) {
+				return s, i
+			}
+			switch e := b[i]; e {
+			case 'n':
+				s = append(s, '\n')
+			case 'r':
+				s = append(s, '\r')
+			case 't':
+				s = append(s, '\t')
+			case 'b':
+				s = append(s, '\b')
+			case 'f':
+				s = append(s, '\f')
+			case '\r':
+				// Line continuation
+				if i+1 < len(b) && b[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					n := 0
+					for ; n < 3 && i < len(b) && b[i] >= '0' && b[i] <= '7'; n++ {
+						v = v*8 + int(b[i]-'0')
+						i++
+					}
+					i--
+					s = append(s, byte(v))
+				} else {
+					s = append(s, e)
+				}
+			}
+		default:
+			s = append(s, c)
+		}
+	}
+	return s, i
+}
+
+// hexString parses a <hex string> at the start of b, returning its bytes and
+// the number of bytes consumed
+func hexString(b []byte) ([]byte, int) {
+	var s []byte
+	hi := -1
+	i := 1
+	for ; i < len(b) && b[i] != '>'; i++ {
+		v := unhex(b[i])
+		if v < 0 {
+			continue
+		}
+		if hi < 0 {
+			hi = v
+		} else {
+			s = append(s, byte(hi<<4|v))
+			hi = -1
+		}
+	}
+	// An odd final digit is followed by an implicit 0
+	if hi >= 0 {
+		s = append(s, byte(hi<<4))
+	}
+	return s, i + 1
+}
+
+// decodeText converts PDF string bytes to text: UTF-16 when the string
+// starts with a byte order mark, Latin-1 otherwise
+func decodeText(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(s))
+	for i, c := range s {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func unhex(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0:
+		return true
+	}
+	return false
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Package textextract extracts plain text from uploaded documents so it can
+// be indexed for full-text search.
+package textextract
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxInputSize is the largest document read for extraction
+const MaxInputSize = 32 << 20
+
+// MaxTextSize is the maximum length in bytes of the extracted text
+const MaxTextSize = 1 << 20
+
+// ErrUnsupported is returned for documents whose format cannot be extracted
+var ErrUnsupported = errors.New("unsupported document type")
+
+type format int
+
+const (
+	formatUnknown format = iota
+	formatPDF
+	formatText
+)
+
+// Supported reports whether text can be extracted from a document with the
+// given content type and file name
+func Supported(contentType, fileName string) bool {
+	return detect(contentType, fileName) != formatUnknown
+}
+
+// Extract reads a document and returns its text. Whitespace is collapsed and
+// the result is truncated to MaxTextSize.
+func Extract(r io.Reader, contentType, fileName string) (string, error) {
+	f := detect(contentType, fileName)
+	if f == formatUnknown {
+		return "", ErrUnsupported
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxInputSize))
+	if err != nil {
+		return "", err
+	}
+
+	var text string
+	switch f {
+	case formatPDF:
+		if text, err = extractPDF(data); err != nil {
+			return "", err
+		}
+	case formatText:
+		text = strings.ToValidUTF8(string(data), " ")
+	}
+	return normalize(text), nil
+}
+
+func detect(contentType, fileName string) format {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case mediaType == "application/pdf":
+			return formatPDF
+		case mediaType == "text/plain", mediaType == "text/markdown", mediaType == "text/csv":
+			return formatText
+		}
+	}
+
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".pdf":
+		return formatPDF
+	case ".txt", ".md", ".csv":
+		return formatText
+	}
+	return formatUnknown
+}
+
+// normalize collapses runs of whitespace, drops control characters and
+// truncates to MaxTextSize on a rune boundary
+func normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = b.Len() > 0
+			continue
+		}
+		if !unicode.IsPrint(r) {
+			continue
+		}
+		if b.Len()+utf8.RuneLen(r)+1 > MaxTextSize {
+			break
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package textextract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF assembles a minimal PDF with one content stream per entry
+func buildPDF(t *testing.T, streams ...string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, content := range streams {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write([]byte(content))
+		zw.Close()
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", i+4, z.Len())
+		b.Write(z.Bytes())
+		b.WriteString("\nendstream\nendobj\n")
+	}
+	// An embedded font program must not leak into the text
+	b.WriteString("9 0 obj\n<< /Length 11 /Length1 11 >>\nstream\n(Garbage) Tj\nendstream\nendobj\n%%EOF\n")
+	return b.Bytes()
+}
+
+func Test_Extract_PDF(t *testing.T) {
+	pdf := buildPDF(t,
+		"BT /F1 12 Tf 72 712 Td (Washing machine manual) Tj 0 -14 Td [(Error) -300 (code) -300 (E4:) -20 ( check) ( the filter)] TJ ET",
+		"BT (Escaped \\(parens\\) and \\101\\102C) Tj T* <FEFF0048006900> Tj ET",
+	)
+
+	text, err := Extract(bytes.NewReader(pdf), "application/pdf", "manual.pdf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "Washing machine manual Error code E4: check the filter Escaped (parens) and ABC Hi"
+	if text != want {
+		t.Errorf("expected %q, got %q", want, text)
+	}
+}
+
+func Test_Extract_PDF_Invalid(t *testing.T) {
+	_, err := Extract(strings.NewReader("not a pdf"), "application/pdf", "manual.pdf")
+	if err == nil {
+		t.Error("expected error for invalid PDF")
+	}
+}
+
+func Test_Extract_Text(t *testing.T) {
+	text, err := Extract(strings.NewReader("Receipt\r\n\tTotal:  42.00 \x00\n"), "text/plain; charset=utf-8", "receipt.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text != "Receipt Total: 42.00" {
+		t.Errorf("unexpected text %q", text)
+	}
+}
+
+func Test_Extract_Unsupported(t *testing.T) {
+	_, err := Extract(strings.NewReader("\x89PNG"), "image/png", "photo.png")
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
+
+func Test_Supported(t *testing.T) {
+	tests := []struct {
+		contentType, fileName string
+		want                  bool
+	}{
+		{"application/pdf", "manual.pdf", true},
+		{"application/octet-stream", "MANUAL.PDF", true},
+		{"text/markdown", "notes", true},
+		{"", "notes.txt", true},
+		{"image/jpeg", "photo.jpg", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := Supported(tt.contentType, tt.fileName); got != tt.want {
+			t.Errorf("Supported(%q, %q) = %v, want %v", tt.contentType, tt.fileName, got, tt.want)
+		}
+	}
+}
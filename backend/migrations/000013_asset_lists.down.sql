@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS update_asset_lists_updated_at ON asset_lists;
+DROP INDEX IF EXISTS idx_asset_list_items_asset;
+DROP INDEX IF EXISTS idx_asset_lists_organization;
+DROP TABLE IF EXISTS asset_list_items;
+DROP TABLE IF EXISTS asset_lists;
@@ -0,0 +1,26 @@
+-- Static asset lists (named collections not tied to filters, e.g. "Christmas decorations box")
+CREATE TABLE asset_lists (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    share_token VARCHAR(64) UNIQUE,
+    share_show_values BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+-- List membership (many-to-many)
+CREATE TABLE asset_list_items (
+    list_id UUID NOT NULL REFERENCES asset_lists(id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (list_id, asset_id)
+);
+
+CREATE INDEX idx_asset_lists_organization ON asset_lists(organization_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_asset_list_items_asset ON asset_list_items(asset_id);
+
+CREATE TRIGGER update_asset_lists_updated_at BEFORE UPDATE ON asset_lists FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS update_asset_reservations_updated_at ON asset_reservations;
+DROP INDEX IF EXISTS idx_asset_reservations_organization_period;
+DROP INDEX IF EXISTS idx_asset_reservations_asset_period;
+DROP TABLE IF EXISTS asset_reservations;
@@ -0,0 +1,20 @@
+-- Asset reservations (planned future use of an asset by someone)
+CREATE TABLE asset_reservations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    reserved_by VARCHAR(255) NOT NULL,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    CONSTRAINT asset_reservations_period_check CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_asset_reservations_asset_period ON asset_reservations(asset_id, starts_at, ends_at) WHERE deleted_at IS NULL;
+CREATE INDEX idx_asset_reservations_organization_period ON asset_reservations(organization_id, starts_at) WHERE deleted_at IS NULL;
+
+CREATE TRIGGER update_asset_reservations_updated_at BEFORE UPDATE ON asset_reservations FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
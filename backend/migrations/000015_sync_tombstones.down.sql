@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS record_tags_tombstone ON tags;
+DROP TRIGGER IF EXISTS record_warranties_tombstone ON warranties;
+DROP TRIGGER IF EXISTS record_attachments_tombstone ON attachments;
+DROP FUNCTION IF EXISTS record_org_tombstone();
+DROP FUNCTION IF EXISTS record_asset_child_tombstone();
+DROP INDEX IF EXISTS idx_sync_tombstones_organization_deleted;
+DROP TABLE IF EXISTS sync_tombstones;
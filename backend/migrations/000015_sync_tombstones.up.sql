@@ -0,0 +1,36 @@
+-- Tombstones for hard-deleted rows so offline clients can delta-sync deletions.
+-- Soft-deleted tables expose deletions through their deleted_at column instead.
+CREATE TABLE sync_tombstones (
+    id BIGSERIAL PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id UUID NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_sync_tombstones_organization_deleted ON sync_tombstones(organization_id, deleted_at);
+
+-- Asset-owned rows resolve the organization through their asset
+CREATE OR REPLACE FUNCTION record_asset_child_tombstone()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO sync_tombstones (organization_id, resource_type, resource_id)
+    SELECT a.organization_id, TG_ARGV[0], OLD.id
+    FROM assets a
+    WHERE a.id = OLD.asset_id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION record_org_tombstone()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO sync_tombstones (organization_id, resource_type, resource_id)
+    VALUES (OLD.organization_id, TG_ARGV[0], OLD.id);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_attachments_tombstone BEFORE DELETE ON attachments FOR EACH ROW EXECUTE FUNCTION record_asset_child_tombstone('attachments');
+CREATE TRIGGER record_warranties_tombstone BEFORE DELETE ON warranties FOR EACH ROW EXECUTE FUNCTION record_asset_child_tombstone('warranties');
+CREATE TRIGGER record_tags_tombstone BEFORE DELETE ON tags FOR EACH ROW EXECUTE FUNCTION record_org_tombstone('tags');
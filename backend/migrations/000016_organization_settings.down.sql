@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_organization_settings_updated_at ON organization_settings;
+DROP TABLE IF EXISTS organization_settings;
@@ -0,0 +1,17 @@
+-- Audit trail of login attempts (local and OIDC)
+CREATE TABLE login_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL for unknown accounts
+    email VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    method VARCHAR(20) NOT NULL, -- 'password' or 'oidc'
+    success BOOLEAN NOT NULL,
+    failure_reason VARCHAR(100),
+    new_device BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_login_events_organization_created ON login_events(organization_id, created_at DESC);
+CREATE INDEX idx_login_events_user_created ON login_events(user_id, created_at DESC);
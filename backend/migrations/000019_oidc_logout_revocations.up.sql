@@ -0,0 +1,12 @@
+-- OIDC sessions ended by the provider (back-channel logout). Rows with a sid
+-- revoke one provider session; rows without revoke all earlier sessions of the subject.
+CREATE TABLE oidc_logout_revocations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subject VARCHAR(255),
+    sid VARCHAR(255),
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (subject IS NOT NULL OR sid IS NOT NULL)
+);
+
+CREATE INDEX idx_oidc_logout_revocations_sid ON oidc_logout_revocations(sid) WHERE sid IS NOT NULL;
+CREATE INDEX idx_oidc_logout_revocations_subject ON oidc_logout_revocations(subject, revoked_at) WHERE sid IS NULL;
@@ -0,0 +1,5 @@
+-- SCIM provisioning: identity provider ID and deactivation (deactivated users keep their data)
+ALTER TABLE users ADD COLUMN external_id VARCHAR(255);
+ALTER TABLE users ADD COLUMN disabled_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX idx_users_external_id ON users(organization_id, external_id) WHERE external_id IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_assets_owner;
+ALTER TABLE assets DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,4 @@
+-- Household member an asset belongs to (distinct from whoever entered it)
+ALTER TABLE assets ADD COLUMN owner_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_assets_owner ON assets(owner_id) WHERE deleted_at IS NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_assets_high_value;
+ALTER TABLE assets DROP COLUMN IF EXISTS high_value;
@@ -0,0 +1,4 @@
+-- High-value items (flagged manually or when their value reaches the configured threshold)
+ALTER TABLE assets ADD COLUMN high_value BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_assets_high_value ON assets(organization_id) WHERE high_value AND deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- ISO 4217 currency of the purchase price (NULL = not specified)
+ALTER TABLE assets ADD COLUMN currency VARCHAR(3);
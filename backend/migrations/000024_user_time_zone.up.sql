@@ -0,0 +1,2 @@
+-- Preferred IANA time zone of a user (NULL = organization time zone setting)
+ALTER TABLE users ADD COLUMN time_zone TEXT;
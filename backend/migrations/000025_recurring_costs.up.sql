@@ -0,0 +1,22 @@
+-- Recurring costs of owning an asset (subscriptions, insurance premiums, ...)
+CREATE TABLE recurring_costs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    amount DECIMAL(12, 2) NOT NULL CHECK (amount >= 0),
+    currency VARCHAR(3),
+    billing_interval VARCHAR(20) NOT NULL CHECK (billing_interval IN ('monthly', 'quarterly', 'annual')),
+    next_renewal_at DATE,
+    remind_days INTEGER NOT NULL DEFAULT 0 CHECK (remind_days >= 0),
+    reminded_for DATE, -- Renewal date the last reminder was sent for
+    notes TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_recurring_costs_asset ON recurring_costs(asset_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_recurring_costs_organization_renewal ON recurring_costs(organization_id, next_renewal_at) WHERE deleted_at IS NULL;
+
+CREATE TRIGGER update_recurring_costs_updated_at BEFORE UPDATE ON recurring_costs FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
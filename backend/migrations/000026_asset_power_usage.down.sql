@@ -0,0 +1 @@
+DROP TABLE IF EXISTS asset_power_usage;
@@ -0,0 +1,11 @@
+-- Power consumption of electrical assets, used to estimate energy costs
+CREATE TABLE asset_power_usage (
+    asset_id UUID PRIMARY KEY REFERENCES assets(id) ON DELETE CASCADE,
+    watts DECIMAL(10, 2) NOT NULL CHECK (watts >= 0), -- While in use
+    standby_watts DECIMAL(10, 2) NOT NULL DEFAULT 0 CHECK (standby_watts >= 0),
+    hours_per_day DECIMAL(4, 2) NOT NULL CHECK (hours_per_day >= 0 AND hours_per_day <= 24),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_asset_power_usage_updated_at BEFORE UPDATE ON asset_power_usage FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,37 @@
+-- Reports delivered periodically by email or into file storage
+CREATE TABLE report_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    name VARCHAR(255) NOT NULL,
+    report VARCHAR(50) NOT NULL,
+    list_id UUID REFERENCES asset_lists(id) ON DELETE CASCADE, -- For list reports
+    cron VARCHAR(100) NOT NULL, -- Evaluated in the organization's time zone
+    delivery VARCHAR(20) NOT NULL CHECK (delivery IN ('email', 'storage')),
+    recipients TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_report_schedules_organization ON report_schedules(organization_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_report_schedules_due ON report_schedules(next_run_at) WHERE deleted_at IS NULL AND enabled;
+
+CREATE TRIGGER update_report_schedules_updated_at BEFORE UPDATE ON report_schedules FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Run history of report schedules
+CREATE TABLE report_runs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    schedule_id UUID NOT NULL REFERENCES report_schedules(id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    error TEXT,
+    file_key VARCHAR(500), -- Uploaded report (storage delivery)
+    size_bytes BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_report_runs_schedule ON report_runs(schedule_id, started_at DESC);
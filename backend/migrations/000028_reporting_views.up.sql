@@ -0,0 +1,146 @@
+-- Stable, read-only views for BI tools (Metabase, Superset, ...). Columns of
+-- these views are only ever added, never renamed or removed, so dashboards
+-- keep working when the internal tables change. Deleted rows are excluded.
+CREATE SCHEMA reporting;
+
+COMMENT ON SCHEMA reporting IS 'Stable read-only views of the Attic inventory for BI tools';
+
+-- Locations and categories with their full path, e.g. "House / Kitchen"
+CREATE VIEW reporting.locations AS
+WITH RECURSIVE tree AS (
+    SELECT id, organization_id, parent_id, name, name::TEXT AS path, 0 AS depth
+    FROM locations
+    WHERE parent_id IS NULL AND deleted_at IS NULL
+    UNION ALL
+    SELECT l.id, l.organization_id, l.parent_id, l.name, tree.path || ' / ' || l.name, tree.depth + 1
+    FROM locations l
+    JOIN tree ON tree.id = l.parent_id
+    WHERE l.deleted_at IS NULL
+)
+SELECT id, organization_id, parent_id, name, path, depth FROM tree;
+
+COMMENT ON VIEW reporting.locations IS 'Locations with their full path';
+COMMENT ON COLUMN reporting.locations.path IS 'Names from the top-level location down, separated by " / "';
+COMMENT ON COLUMN reporting.locations.depth IS '0 for top-level locations';
+
+CREATE VIEW reporting.categories AS
+WITH RECURSIVE tree AS (
+    SELECT id, organization_id, parent_id, name, name::TEXT AS path, 0 AS depth
+    FROM categories
+    WHERE parent_id IS NULL AND deleted_at IS NULL
+    UNION ALL
+    SELECT c.id, c.organization_id, c.parent_id, c.name, tree.path || ' / ' || c.name, tree.depth + 1
+    FROM categories c
+    JOIN tree ON tree.id = c.parent_id
+    WHERE c.deleted_at IS NULL
+)
+SELECT id, organization_id, parent_id, name, path, depth FROM tree;
+
+COMMENT ON VIEW reporting.categories IS 'Categories with their full path';
+COMMENT ON COLUMN reporting.categories.path IS 'Names from the top-level category down, separated by " / "';
+
+-- One row per asset with its relations flattened
+CREATE VIEW reporting.assets AS
+SELECT
+    a.id,
+    a.organization_id,
+    a.name,
+    a.description,
+    a.category_id,
+    c.name AS category,
+    c.path AS category_path,
+    a.location_id,
+    l.name AS location,
+    l.path AS location_path,
+    co.label AS condition,
+    a.owner_id,
+    COALESCE(u.display_name, u.email) AS owner,
+    a.quantity,
+    a.purchase_at,
+    a.purchase_price,
+    a.currency,
+    a.purchase_price * a.quantity AS total_value,
+    a.high_value,
+    a.import_plugin_id,
+    a.created_at,
+    a.updated_at
+FROM assets a
+LEFT JOIN reporting.categories c ON c.id = a.category_id
+LEFT JOIN reporting.locations l ON l.id = a.location_id
+LEFT JOIN conditions co ON co.id = a.condition_id
+LEFT JOIN users u ON u.id = a.owner_id AND u.deleted_at IS NULL
+WHERE a.deleted_at IS NULL;
+
+COMMENT ON VIEW reporting.assets IS 'Assets with category, location, condition and owner';
+COMMENT ON COLUMN reporting.assets.purchase_price IS 'Price of one unit';
+COMMENT ON COLUMN reporting.assets.currency IS 'ISO 4217 code, NULL = organization default';
+COMMENT ON COLUMN reporting.assets.total_value IS 'purchase_price * quantity';
+COMMENT ON COLUMN reporting.assets.import_plugin_id IS 'Plugin the asset was imported with, if any';
+
+-- Custom attribute values, one row per asset and attribute
+CREATE VIEW reporting.asset_attributes AS
+SELECT
+    a.id AS asset_id,
+    a.organization_id,
+    attr.key,
+    COALESCE(def.name, attr.key) AS name,
+    def.data_type,
+    attr.value
+FROM assets a
+CROSS JOIN LATERAL jsonb_each_text(a.attributes) AS attr(key, value)
+LEFT JOIN attributes def ON def.organization_id = a.organization_id AND def.key = attr.key AND def.deleted_at IS NULL
+WHERE a.deleted_at IS NULL;
+
+COMMENT ON VIEW reporting.asset_attributes IS 'Custom attribute values of assets';
+COMMENT ON COLUMN reporting.asset_attributes.value IS 'Value as text, interpret with data_type';
+
+CREATE VIEW reporting.asset_tags AS
+SELECT a.id AS asset_id, a.organization_id, t.name AS tag
+FROM asset_tags tg
+JOIN assets a ON a.id = tg.asset_id AND a.deleted_at IS NULL
+JOIN tags t ON t.id = tg.tag_id;
+
+COMMENT ON VIEW reporting.asset_tags IS 'Tags of assets, one row per asset and tag';
+
+CREATE VIEW reporting.warranties AS
+SELECT
+    w.asset_id,
+    a.organization_id,
+    a.name AS asset,
+    w.provider,
+    w.start_date,
+    w.end_date,
+    w.end_date - CURRENT_DATE AS days_left
+FROM warranties w
+JOIN assets a ON a.id = w.asset_id AND a.deleted_at IS NULL;
+
+COMMENT ON VIEW reporting.warranties IS 'Warranties of assets';
+COMMENT ON COLUMN reporting.warranties.days_left IS 'Days until end_date (negative when expired), in the database time zone';
+
+CREATE VIEW reporting.attachments AS
+SELECT att.id, att.asset_id, a.organization_id, att.file_name, att.content_type, att.file_size, att.created_at
+FROM attachments att
+JOIN assets a ON a.id = att.asset_id AND a.deleted_at IS NULL;
+
+COMMENT ON VIEW reporting.attachments IS 'Attachment metadata (file contents are in the file storage)';
+COMMENT ON COLUMN reporting.attachments.file_size IS 'Size in bytes';
+
+CREATE VIEW reporting.recurring_costs AS
+SELECT
+    rc.id,
+    rc.asset_id,
+    rc.organization_id,
+    a.name AS asset,
+    rc.name,
+    rc.amount,
+    rc.currency,
+    rc.billing_interval,
+    rc.amount / CASE rc.billing_interval WHEN 'monthly' THEN 1 WHEN 'quarterly' THEN 3 WHEN 'annual' THEN 12 END AS monthly_amount,
+    rc.next_renewal_at
+FROM recurring_costs rc
+JOIN assets a ON a.id = rc.asset_id AND a.deleted_at IS NULL
+WHERE rc.deleted_at IS NULL;
+
+COMMENT ON VIEW reporting.recurring_costs IS 'Recurring costs of assets (subscriptions, insurance, ...)';
+COMMENT ON COLUMN reporting.recurring_costs.amount IS 'Amount per billing_interval';
+COMMENT ON COLUMN reporting.recurring_costs.monthly_amount IS 'amount normalized to one month';
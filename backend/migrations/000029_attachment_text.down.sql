@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_attachments_text_pending;
+DROP INDEX IF EXISTS idx_attachments_search;
+
+ALTER TABLE attachments
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS text_extracted_at,
+    DROP COLUMN IF EXISTS extracted_text;
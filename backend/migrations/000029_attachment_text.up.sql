@@ -0,0 +1,12 @@
+-- Text extracted from attachments (PDF manuals, receipts) by a background job
+ALTER TABLE attachments
+    ADD COLUMN extracted_text TEXT,
+    ADD COLUMN text_extracted_at TIMESTAMPTZ,
+    ADD COLUMN search_vector tsvector
+        GENERATED ALWAYS AS (to_tsvector('english', COALESCE(extracted_text, ''))) STORED;
+
+-- Full-text search over attachment contents
+CREATE INDEX idx_attachments_search ON attachments USING GIN(search_vector);
+
+-- Attachments still waiting for extraction
+CREATE INDEX idx_attachments_text_pending ON attachments(created_at) WHERE text_extracted_at IS NULL;
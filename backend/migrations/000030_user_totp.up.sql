@@ -0,0 +1,12 @@
+-- TOTP two-factor authentication for local accounts
+CREATE TABLE user_totp (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret TEXT NOT NULL, -- Encrypted with ATTIC_ENCRYPTION_KEY
+    enabled_at TIMESTAMPTZ, -- NULL until the first code is confirmed
+    backup_codes TEXT[] NOT NULL DEFAULT '{}', -- SHA-256 hashes of the unused backup codes
+    last_counter BIGINT NOT NULL DEFAULT 0, -- Time step of the last accepted code (replay protection)
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_user_totp_updated_at BEFORE UPDATE ON user_totp FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
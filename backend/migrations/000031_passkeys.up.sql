@@ -0,0 +1,18 @@
+-- WebAuthn passkeys for passwordless login
+CREATE TABLE passkeys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    credential_id BYTEA NOT NULL UNIQUE,
+    public_key BYTEA NOT NULL, -- SubjectPublicKeyInfo (DER)
+    algorithm INT NOT NULL, -- COSE algorithm identifier
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    name VARCHAR(255) NOT NULL,
+    transports TEXT[] NOT NULL DEFAULT '{}',
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_passkeys_user ON passkeys(user_id);
+
+CREATE TRIGGER update_passkeys_updated_at BEFORE UPDATE ON passkeys FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
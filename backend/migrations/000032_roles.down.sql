@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS roles;
+
+-- Users with a custom role fall back to the user role
+UPDATE users SET role = 'user' WHERE role NOT IN ('user', 'admin');
+CREATE TYPE user_role AS ENUM ('user', 'admin');
+ALTER TABLE users ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE users ALTER COLUMN role TYPE user_role USING role::user_role;
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'user';
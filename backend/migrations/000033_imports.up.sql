@@ -0,0 +1,15 @@
+-- Log of plugin imports, to investigate and re-run failed or incorrect ones
+CREATE TABLE imports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    plugin_id VARCHAR(100) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    asset_id UUID REFERENCES assets(id) ON DELETE SET NULL, -- NULL if the import failed
+    success BOOLEAN NOT NULL,
+    error TEXT,
+    payload JSONB, -- Data returned by the plugin, NULL if fetching failed
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_imports_organization_created ON imports(organization_id, created_at DESC);
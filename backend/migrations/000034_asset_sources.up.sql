@@ -0,0 +1,13 @@
+-- Data a plugin returned for an imported asset, to backfill new attributes
+-- without fetching it again
+CREATE TABLE asset_sources (
+    asset_id UUID PRIMARY KEY REFERENCES assets(id) ON DELETE CASCADE,
+    plugin_id VARCHAR(100) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    payload BYTEA NOT NULL, -- gzip-compressed JSON
+    fetched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_asset_sources_updated_at BEFORE UPDATE ON asset_sources FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
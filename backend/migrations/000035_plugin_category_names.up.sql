@@ -0,0 +1,5 @@
+-- Name a plugin last supplied for its category, to tell a renamed plugin
+-- category (synced at startup) from one renamed by the user (kept)
+ALTER TABLE categories ADD COLUMN plugin_category_name VARCHAR(255);
+
+UPDATE categories SET plugin_category_name = name WHERE plugin_id IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Local login sessions, so they can be listed and revoked. The cookie holds a
+-- random token; only its SHA-256 hash is stored.
+CREATE TABLE user_sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    ip_address VARCHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_user_sessions_user ON user_sessions(user_id, last_seen_at DESC);
+CREATE INDEX idx_user_sessions_expires ON user_sessions(expires_at);
@@ -0,0 +1,5 @@
+-- When the user confirmed their email address (NULL = not verified yet).
+-- Existing accounts are considered verified.
+ALTER TABLE users ADD COLUMN verified_at TIMESTAMPTZ;
+
+UPDATE users SET verified_at = created_at;
@@ -0,0 +1,5 @@
+-- When a self-registered account was created; NULL once an admin approved it
+-- (and for accounts created by admins, SCIM or OIDC).
+ALTER TABLE users ADD COLUMN pending_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_pending ON users(organization_id, pending_at) WHERE pending_at IS NOT NULL AND deleted_at IS NULL;
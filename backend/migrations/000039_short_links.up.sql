@@ -0,0 +1,14 @@
+-- Short links (/l/{code}) to a view of the SPA with its filter, sort and
+-- facet state, so complex views can be bookmarked and shared
+CREATE TABLE short_links (
+    code VARCHAR(16) PRIMARY KEY,
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    path TEXT NOT NULL,
+    state JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ
+);
+
+-- Sharing the same view again returns the existing link
+CREATE UNIQUE INDEX idx_short_links_state ON short_links(organization_id, path, state);
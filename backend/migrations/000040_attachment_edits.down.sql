@@ -0,0 +1,2 @@
+ALTER TABLE attachments DROP COLUMN IF EXISTS edit;
+ALTER TABLE attachments DROP COLUMN IF EXISTS derived_from_id;
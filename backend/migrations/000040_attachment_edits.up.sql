@@ -0,0 +1,5 @@
+-- Edited (rotated/cropped) copies of image attachments, used as main images.
+-- Edits are always applied to the original, so they don't compound.
+ALTER TABLE attachments
+    ADD COLUMN derived_from_id UUID REFERENCES attachments(id) ON DELETE SET NULL,
+    ADD COLUMN edit JSONB;
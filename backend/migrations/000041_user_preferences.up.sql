@@ -0,0 +1,7 @@
+-- Per-user preferences of the web app (page size, currency, date format,
+-- theme, default category), so they follow the user across devices
+CREATE TABLE user_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    preferences JSONB NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS must_change_password;
+ALTER TABLE users DROP COLUMN IF EXISTS must_change_password;
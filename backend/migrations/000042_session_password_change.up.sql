@@ -0,0 +1,5 @@
+-- Login sessions hold all session data server-side (the cookie only carries
+-- an opaque token) and can require the password to be changed before the
+-- session is usable, e.g. after an administrator reset it
+ALTER TABLE users ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE user_sessions ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS service_tokens;
+ALTER TABLE users DROP COLUMN IF EXISTS service_account;
@@ -0,0 +1,17 @@
+-- Service accounts are users for integrations such as dashboards; they can't
+-- sign in and authenticate with tokens limited to scopes (permissions). Only
+-- the SHA-256 hash of a token is stored.
+ALTER TABLE users ADD COLUMN service_account BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE service_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_service_tokens_user ON service_tokens(user_id, created_at);
@@ -0,0 +1,11 @@
+-- Audit trail of attachment downloads: who was given a download URL and when
+CREATE TABLE attachment_downloads (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    attachment_id UUID NOT NULL REFERENCES attachments(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ip_address VARCHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_attachment_downloads_attachment_created ON attachment_downloads(attachment_id, created_at DESC);
@@ -0,0 +1,17 @@
+-- Structured audit log of authentication events: logins (local and OIDC
+-- callbacks), failed logins, logouts and password changes
+CREATE TABLE auth_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL for unknown accounts
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    event_type VARCHAR(30) NOT NULL,
+    method VARCHAR(20), -- Login method of login events
+    failure_reason VARCHAR(100),
+    ip_address VARCHAR(64) NOT NULL,
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_auth_events_organization_created ON auth_events(organization_id, created_at DESC);
+CREATE INDEX idx_auth_events_user_created ON auth_events(user_id, created_at DESC);
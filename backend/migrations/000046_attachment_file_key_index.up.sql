@@ -0,0 +1,2 @@
+-- Files served from local storage are resolved to their attachment by key
+CREATE INDEX IF NOT EXISTS idx_attachments_file_key ON attachments(file_key);
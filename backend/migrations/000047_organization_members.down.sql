@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS add_users_organization_member ON users;
+DROP FUNCTION IF EXISTS add_organization_member();
+DROP TABLE IF EXISTS organization_members;
@@ -0,0 +1,25 @@
+-- Users are members of organizations; requests are scoped to the caller's
+-- organization. Every user is a member of the organization that created it.
+CREATE TABLE organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user ON organization_members(user_id, created_at);
+
+INSERT INTO organization_members (organization_id, user_id, created_at)
+SELECT organization_id, id, created_at FROM users;
+
+CREATE OR REPLACE FUNCTION add_organization_member()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO organization_members (organization_id, user_id)
+    VALUES (NEW.organization_id, NEW.id)
+    ON CONFLICT DO NOTHING;
+    RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER add_users_organization_member AFTER INSERT ON users FOR EACH ROW EXECUTE FUNCTION add_organization_member();
@@ -0,0 +1,5 @@
+DELETE FROM organization_members m USING users u
+WHERE u.id = m.user_id AND u.organization_id <> m.organization_id;
+
+ALTER TABLE organization_members DROP COLUMN IF EXISTS selected_at;
+ALTER TABLE organization_members DROP COLUMN IF EXISTS role;
@@ -0,0 +1,6 @@
+-- Users can be members of several organizations, with a role in each. A NULL
+-- role is the user's own role (users.role), as in the organization that
+-- created the user. Requests without an X-Org-ID header use the organization
+-- the user selected last.
+ALTER TABLE organization_members ADD COLUMN role VARCHAR(50);
+ALTER TABLE organization_members ADD COLUMN selected_at TIMESTAMPTZ;
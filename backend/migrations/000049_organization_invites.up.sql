@@ -0,0 +1,17 @@
+-- Invite links let people join an organization with a preset role, signing
+-- up or signing in with an existing account. Only a hash of the token is
+-- stored; the link is shown once when created.
+CREATE TABLE organization_invites (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(50) NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    max_uses INTEGER CHECK (max_uses > 0), -- NULL = unlimited
+    uses INTEGER NOT NULL DEFAULT 0,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_organization_invites_organization ON organization_invites(organization_id, created_at);
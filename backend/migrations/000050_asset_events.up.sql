@@ -0,0 +1,14 @@
+-- Change history of assets, recorded from asset events: the field-level
+-- changes (field, old and new value) of each event, e.g. of
+-- "attributes.serial_number" when an attribute is edited
+CREATE TABLE asset_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    event_type VARCHAR(30) NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    changes JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_asset_events_asset_created ON asset_events(asset_id, created_at DESC);
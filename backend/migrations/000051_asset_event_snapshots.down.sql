@@ -0,0 +1 @@
+ALTER TABLE asset_events DROP COLUMN IF EXISTS snapshot;
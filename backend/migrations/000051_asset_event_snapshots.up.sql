@@ -0,0 +1,2 @@
+-- State of the asset after each event, so it can be reverted to that revision
+ALTER TABLE asset_events ADD COLUMN snapshot JSONB;
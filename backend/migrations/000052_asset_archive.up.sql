@@ -0,0 +1,5 @@
+-- Archived assets (sold, given away, boxed up) are kept but left out of
+-- normal views and stats
+ALTER TABLE assets ADD COLUMN archived_at TIMESTAMPTZ;
+
+CREATE INDEX idx_assets_archived ON assets(organization_id) WHERE archived_at IS NOT NULL AND deleted_at IS NULL;
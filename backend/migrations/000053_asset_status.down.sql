@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_assets_status;
+ALTER TABLE assets DROP COLUMN IF EXISTS status;
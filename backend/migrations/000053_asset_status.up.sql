@@ -0,0 +1,6 @@
+-- Where an asset is in its lifecycle; only owned, loaned and in_repair assets
+-- count toward the inventory value
+ALTER TABLE assets ADD COLUMN status TEXT NOT NULL DEFAULT 'owned'
+    CHECK (status IN ('owned', 'loaned', 'in_repair', 'sold', 'disposed', 'lost'));
+
+CREATE INDEX idx_assets_status ON assets(organization_id, status) WHERE deleted_at IS NULL;
@@ -0,0 +1,23 @@
+-- Assets checked out to a person, a contact or just a name, until returned
+CREATE TABLE loans (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    contact_id UUID REFERENCES contacts(id) ON DELETE SET NULL,
+    borrower VARCHAR(255) NOT NULL,
+    loaned_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    due_at DATE,
+    returned_at TIMESTAMPTZ,
+    notes TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reminded_at TIMESTAMPTZ, -- Overdue reminder sent to the contact
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- An asset can only be on one open loan
+CREATE UNIQUE INDEX idx_loans_open_asset ON loans(asset_id) WHERE returned_at IS NULL;
+CREATE INDEX idx_loans_organization ON loans(organization_id, due_at) WHERE returned_at IS NULL;
+CREATE INDEX idx_loans_contact ON loans(contact_id, loaned_at DESC);
+
+CREATE TRIGGER update_loans_updated_at BEFORE UPDATE ON loans FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
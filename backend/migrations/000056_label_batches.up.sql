@@ -0,0 +1,19 @@
+-- Label sheets too large to render during the request, rendered in the
+-- background and kept for download for a day
+CREATE TABLE label_batches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'rendering', 'done', 'failed')),
+    asset_ids UUID[] NOT NULL, -- In label order
+    layout JSONB NOT NULL,
+    skip INTEGER NOT NULL DEFAULT 0, -- Positions left empty on the first sheet
+    pdf BYTEA,
+    error TEXT,
+    claimed_at TIMESTAMPTZ, -- Rendering started, retried if it never finished
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_label_batches_queue ON label_batches(created_at) WHERE status IN ('pending', 'rendering');
+CREATE INDEX idx_label_batches_organization ON label_batches(organization_id);
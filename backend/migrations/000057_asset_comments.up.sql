@@ -0,0 +1,10 @@
+-- Notes accumulating on an asset over time (e.g. maintenance), next to its description
+CREATE TABLE asset_comments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    author_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_asset_comments_asset ON asset_comments(asset_id, created_at DESC);
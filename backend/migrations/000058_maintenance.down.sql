@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS maintenance_log;
+DROP TABLE IF EXISTS maintenance_tasks;
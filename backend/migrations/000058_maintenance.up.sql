@@ -0,0 +1,37 @@
+-- Maintenance to do on an asset, once or every interval (e.g. descale the
+-- espresso machine every 3 months)
+CREATE TABLE maintenance_tasks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    notes TEXT,
+    interval_count INTEGER CHECK (interval_count > 0), -- NULL for one-off tasks
+    interval_unit VARCHAR(10) CHECK (interval_unit IN ('day', 'week', 'month', 'year')),
+    due_at DATE, -- Next time it is due, NULL once a one-off task is done
+    last_done_at DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ,
+    CHECK ((interval_count IS NULL) = (interval_unit IS NULL))
+);
+
+CREATE INDEX idx_maintenance_tasks_asset ON maintenance_tasks(asset_id) WHERE deleted_at IS NULL;
+CREATE INDEX idx_maintenance_tasks_organization_due ON maintenance_tasks(organization_id, due_at) WHERE deleted_at IS NULL;
+
+CREATE TRIGGER update_maintenance_tasks_updated_at BEFORE UPDATE ON maintenance_tasks FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- Maintenance done on an asset, for a task or not
+CREATE TABLE maintenance_log (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    task_id UUID REFERENCES maintenance_tasks(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL, -- The task's name at the time, or what was done
+    done_at DATE NOT NULL,
+    notes TEXT,
+    done_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_maintenance_log_asset ON maintenance_log(asset_id, done_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_assets_short_id;
+ALTER TABLE assets DROP COLUMN IF EXISTS short_id;
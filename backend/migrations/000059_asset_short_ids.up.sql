@@ -0,0 +1,5 @@
+-- Short codes identifying assets, e.g. written to NFC stickers. Assigned when
+-- an asset is created, or on demand for assets imported without one.
+ALTER TABLE assets ADD COLUMN short_id VARCHAR(8);
+
+CREATE UNIQUE INDEX idx_assets_short_id ON assets(short_id);
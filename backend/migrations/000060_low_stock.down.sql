@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_assets_low_stock;
+ALTER TABLE assets
+    DROP COLUMN IF EXISTS low_stock_notified_at,
+    DROP COLUMN IF EXISTS min_quantity;
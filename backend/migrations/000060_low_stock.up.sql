@@ -0,0 +1,8 @@
+-- Low-stock threshold of consumables: below min_quantity an asset is on the
+-- shopping list. low_stock_notified_at is set once admins were emailed about
+-- it and cleared when it is restocked.
+ALTER TABLE assets
+    ADD COLUMN min_quantity INTEGER CHECK (min_quantity >= 0),
+    ADD COLUMN low_stock_notified_at TIMESTAMPTZ;
+
+CREATE INDEX idx_assets_low_stock ON assets(organization_id) WHERE quantity < min_quantity AND deleted_at IS NULL;
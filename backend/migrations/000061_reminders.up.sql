@@ -0,0 +1,19 @@
+-- Reminders of things to do by a date (service due, return a borrowed item,
+-- renew a subscription), about an asset or standalone
+CREATE TABLE reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    asset_id UUID REFERENCES assets(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    notes TEXT,
+    due_at DATE NOT NULL,
+    done_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_reminders_open ON reminders(organization_id, due_at) WHERE done_at IS NULL;
+CREATE INDEX idx_reminders_asset ON reminders(asset_id);
+
+CREATE TRIGGER update_reminders_updated_at BEFORE UPDATE ON reminders FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
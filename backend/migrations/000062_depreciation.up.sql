@@ -0,0 +1,18 @@
+-- How assets lose value over time, set for an asset or for all assets of a
+-- category (and its subcategories); the asset's own rule wins
+CREATE TABLE depreciation_rules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    asset_id UUID UNIQUE REFERENCES assets(id) ON DELETE CASCADE,
+    category_id UUID UNIQUE REFERENCES categories(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL CHECK (method IN ('straight_line', 'declining_balance')),
+    useful_life_months INTEGER NOT NULL CHECK (useful_life_months > 0),
+    salvage_value DECIMAL(12, 2) NOT NULL DEFAULT 0 CHECK (salvage_value >= 0), -- Value left at the end of the useful life
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((asset_id IS NULL) <> (category_id IS NULL))
+);
+
+CREATE INDEX idx_depreciation_rules_org ON depreciation_rules(organization_id);
+
+CREATE TRIGGER update_depreciation_rules_updated_at BEFORE UPDATE ON depreciation_rules FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
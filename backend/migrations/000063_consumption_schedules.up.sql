@@ -0,0 +1,18 @@
+-- How fast a consumable is used up (e.g. one water filter every 3 months);
+-- a background job takes the amount from the asset's quantity every interval
+CREATE TABLE consumption_schedules (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL UNIQUE REFERENCES assets(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    interval_count INTEGER NOT NULL CHECK (interval_count > 0),
+    interval_unit VARCHAR(10) NOT NULL CHECK (interval_unit IN ('day', 'week', 'month', 'year')),
+    next_at DATE NOT NULL, -- Next time the amount is taken
+    last_applied_at DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_consumption_schedules_organization_next ON consumption_schedules(organization_id, next_at);
+
+CREATE TRIGGER update_consumption_schedules_updated_at BEFORE UPDATE ON consumption_schedules FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
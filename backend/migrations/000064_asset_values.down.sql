@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS asset_values;
+ALTER TABLE assets DROP COLUMN IF EXISTS current_value;
@@ -0,0 +1,16 @@
+-- What assets are worth now, as opposed to what they cost. Every valuation
+-- is kept in asset_values; assets.current_value is the latest one.
+ALTER TABLE assets ADD COLUMN current_value DECIMAL(12, 2) CHECK (current_value >= 0);
+
+CREATE TABLE asset_values (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    asset_id UUID NOT NULL REFERENCES assets(id) ON DELETE CASCADE,
+    value DECIMAL(12, 2) NOT NULL CHECK (value >= 0), -- Per unit
+    valued_at DATE NOT NULL,
+    note TEXT, -- e.g. where the value comes from
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_asset_values_asset ON asset_values(asset_id, valued_at DESC);
@@ -0,0 +1,26 @@
+-- Things to buy, from the wishlist (wanted) to the order (ordered) to their
+-- arrival (received), when they become an asset
+CREATE TABLE purchases (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    category_id UUID REFERENCES categories(id) ON DELETE SET NULL, -- Of the asset it becomes
+    name VARCHAR(255) NOT NULL,
+    notes TEXT,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'wanted' CHECK (status IN ('wanted', 'ordered', 'received')),
+    vendor VARCHAR(255),
+    price DECIMAL(12, 2) CHECK (price >= 0), -- Per unit
+    currency VARCHAR(3),
+    ordered_at DATE,
+    expected_at DATE, -- When the order should arrive
+    warranty_months INTEGER CHECK (warranty_months > 0),
+    received_at DATE,
+    asset_id UUID REFERENCES assets(id) ON DELETE SET NULL, -- Set once received
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_purchases_organization_status ON purchases(organization_id, status);
+
+CREATE TRIGGER update_purchases_updated_at BEFORE UPDATE ON purchases FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,3 @@
+ALTER TABLE assets DROP COLUMN IF EXISTS purchase_group_id;
+
+DROP TABLE IF EXISTS purchase_groups;
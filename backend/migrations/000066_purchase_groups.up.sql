@@ -0,0 +1,22 @@
+-- Assets bought together for one price, e.g. a camera kit; the total is
+-- allocated across the assets' purchase prices
+CREATE TABLE purchase_groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    total DECIMAL(12, 2) NOT NULL CHECK (total >= 0),
+    currency VARCHAR(3),
+    purchase_at DATE,
+    purchase_note TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_purchase_groups_organization_id ON purchase_groups(organization_id);
+
+CREATE TRIGGER update_purchase_groups_updated_at BEFORE UPDATE ON purchase_groups FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE assets ADD COLUMN purchase_group_id UUID REFERENCES purchase_groups(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_assets_purchase_group_id ON assets(purchase_group_id) WHERE purchase_group_id IS NOT NULL;
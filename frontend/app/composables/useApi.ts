@@ -37,6 +37,16 @@ interface ApiFetchOptions extends Omit<RequestInit, 'method'> {
   method?: HttpMethod
 }
 
+// CSRF header for state-changing requests, copied from the cookie the API sets
+// on authenticated responses
+export function csrfHeaders(method = 'GET'): Record<string, string> {
+  if (['GET', 'HEAD', 'OPTIONS'].includes(method.toUpperCase()) || typeof document === 'undefined') {
+    return {}
+  }
+  const match = document.cookie.match(/(?:^|;\s*)attic_csrf=([^;]*)/)
+  return match?.[1] ? { 'X-CSRF-Token': decodeURIComponent(match[1]) } : {}
+}
+
 // Composable for making authenticated API mutations (POST, PUT, DELETE)
 export function useApiFetch() {
   const config = useRuntimeConfig()
@@ -44,6 +54,7 @@ export function useApiFetch() {
   return async <T>(url: string, options: ApiFetchOptions = {}): Promise<T> => {
     const headers: HeadersInit = {
       'Content-Type': 'application/json',
+      ...csrfHeaders(options.method),
       ...options.headers
     }
 
@@ -61,8 +61,10 @@ export function useAuth() {
     }
   }
 
-  const loginWithOIDC = () => {
-    window.location.href = `${config.public.apiBase}/auth/oidc/login`
+  // returnTo is the app path to come back to after signing in
+  const loginWithOIDC = (returnTo?: string) => {
+    const query = returnTo ? `?return_to=${encodeURIComponent(returnTo)}` : ''
+    window.location.href = `${config.public.apiBase}/auth/oidc/login${query}`
   }
 
   const login = () => {
@@ -97,6 +99,7 @@ export function useAuth() {
         baseURL: config.public.apiBase as string,
         method: 'PUT',
         body: { current_password: currentPassword, new_password: newPassword },
+        headers: csrfHeaders('PUT'),
         credentials: 'include'
       })
       return { success: true }
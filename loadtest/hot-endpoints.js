@@ -0,0 +1,61 @@
+// k6 load test of the hot API endpoints: listing, searching and opening
+// assets. The thresholds are the latency budgets from "Performance Budgets"
+// in the README; k6 exits non-zero when one is exceeded.
+//
+//   make seed ASSETS=100000
+//   make loadtest BASE_URL=http://localhost:8080 EMAIL=admin@example.com PASSWORD=...
+//
+// Run the server with ATTIC_RATE_LIMIT_PER_MINUTE=0, or the rate limit
+// fails the requests. Environment: BASE_URL, EMAIL, PASSWORD (an account
+// without 2FA), VUS (default 10) and DURATION (default 1m, at most the
+// 15-minute lifetime of an access token).
+import http from 'k6/http'
+import { check, fail } from 'k6'
+
+const baseURL = (__ENV.BASE_URL || 'http://localhost:8080').replace(/\/$/, '')
+const queries = ['drill', 'sony headphones', 'oak', 'camera lens', 'tent', 'board game']
+
+export const options = {
+  vus: Number(__ENV.VUS || 10),
+  duration: __ENV.DURATION || '1m',
+  thresholds: {
+    'http_req_duration{endpoint:list}': ['p(95)<200'],
+    'http_req_duration{endpoint:search}': ['p(95)<300'],
+    'http_req_duration{endpoint:get}': ['p(95)<100'],
+    'http_req_failed': ['rate<0.01'],
+  },
+}
+
+export function setup() {
+  const login = http.post(`${baseURL}/auth/token`, JSON.stringify({
+    email: __ENV.EMAIL,
+    password: __ENV.PASSWORD,
+  }), { headers: { 'Content-Type': 'application/json' } })
+  if (login.status !== 200) {
+    fail(`login failed with ${login.status}: ${login.body}`)
+  }
+  const headers = { Authorization: `Bearer ${login.json('access_token')}` }
+
+  const list = http.get(`${baseURL}/api/assets?limit=100`, { headers })
+  const ids = (list.json('assets') || []).map(a => a.id)
+  if (ids.length === 0) {
+    fail('no assets to load test with, run "make seed" first')
+  }
+  return { headers, ids }
+}
+
+export default function (data) {
+  const params = tag => ({ headers: data.headers, tags: { endpoint: tag } })
+  const offset = Math.floor(Math.random() * 50) * 20
+
+  const list = http.get(`${baseURL}/api/assets?limit=20&offset=${offset}`, params('list'))
+  check(list, { 'list: 200': r => r.status === 200 })
+
+  const q = encodeURIComponent(queries[Math.floor(Math.random() * queries.length)])
+  const search = http.get(`${baseURL}/api/assets?q=${q}&limit=20`, params('search'))
+  check(search, { 'search: 200': r => r.status === 200 })
+
+  const id = data.ids[Math.floor(Math.random() * data.ids.length)]
+  const asset = http.get(`${baseURL}/api/assets/${id}`, params('get'))
+  check(asset, { 'get: 200': r => r.status === 200 })
+}
@@ -0,0 +1,43 @@
+#!/bin/sh
+# Vendor the PostgreSQL binaries of the embedded database mode into the
+# backend, so that Attic runs without ATTIC_DATABASE_URL and without network
+# access. Pass the platforms as os_arch (e.g. linux_arm64); the default is
+# the platform Go builds for.
+set -eu
+
+# Must match embeddedVersion in backend/internal/database/embedded.go
+VERSION="${POSTGRES_VERSION:-15.3.0}"
+ROOT="$(cd "$(dirname "$0")/.." && pwd)"
+DEST="$ROOT/backend/internal/database/postgres-binaries"
+PLATFORMS="${*:-$(cd "$ROOT/backend" && go env GOOS)_$(cd "$ROOT/backend" && go env GOARCH)}"
+
+TMP="$(mktemp -d)"
+trap 'rm -rf "$TMP"' EXIT
+
+for platform in $PLATFORMS; do
+  os="${platform%_*}"
+  arch="${platform#*_}"
+  # The zonky builds name 64-bit ARM arm64v8
+  case "$arch" in
+    arm64) zonky_arch=arm64v8 ;;
+    *) zonky_arch="$arch" ;;
+  esac
+  name="embedded-postgres-binaries-${os}-${zonky_arch}"
+  target="$DEST/$platform/$name-$VERSION.txz"
+
+  if [ -f "$target" ] && [ "${FORCE:-}" != "1" ]; then
+    echo "PostgreSQL $VERSION for $platform already present (set FORCE=1 to refresh)"
+    continue
+  fi
+
+  url="https://repo1.maven.org/maven2/io/zonky/test/postgres/$name/$VERSION/$name-$VERSION.jar"
+  if command -v curl >/dev/null 2>&1; then
+    curl -fsSL "$url" -o "$TMP/$name.jar"
+  else
+    wget -q "$url" -O "$TMP/$name.jar"
+  fi
+  mkdir -p "$DEST/$platform"
+  rm -f "$DEST/$platform"/*.txz
+  unzip -p "$TMP/$name.jar" '*.txz' > "$target"
+  echo "PostgreSQL $VERSION for $platform vendored into $target"
+done
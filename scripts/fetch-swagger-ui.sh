@@ -0,0 +1,35 @@
+#!/bin/sh
+# Vendor Swagger UI and ReDoc assets into the backend so /api/docs and
+# /api/docs/redoc do not depend on a CDN
+set -eu
+
+VERSION="${SWAGGER_UI_VERSION:-5.11.0}"
+REDOC_VERSION="${REDOC_VERSION:-2.1.3}"
+DEST="$(cd "$(dirname "$0")/.." && pwd)/backend/cmd/server/swagger-ui"
+
+if [ -f "$DEST/swagger-ui-bundle.js" ] && [ -f "$DEST/redoc.standalone.js" ] && [ "${FORCE:-}" != "1" ]; then
+  echo "API docs assets already present in $DEST (set FORCE=1 to refresh)"
+  exit 0
+fi
+
+TMP="$(mktemp -d)"
+trap 'rm -rf "$TMP"' EXIT
+
+# fetch downloads and unpacks an npm package tarball into $TMP/<name>
+fetch() {
+  mkdir -p "$TMP/$1"
+  if command -v curl >/dev/null 2>&1; then
+    curl -fsSL "$2" -o "$TMP/$1.tgz"
+  else
+    wget -q "$2" -O "$TMP/$1.tgz"
+  fi
+  tar -xzf "$TMP/$1.tgz" -C "$TMP/$1"
+}
+
+fetch swagger-ui "https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-${VERSION}.tgz"
+fetch redoc "https://registry.npmjs.org/redoc/-/redoc-${REDOC_VERSION}.tgz"
+
+mkdir -p "$DEST"
+cp "$TMP/swagger-ui/package/swagger-ui.css" "$TMP/swagger-ui/package/swagger-ui-bundle.js" "$DEST/"
+cp "$TMP/redoc/package/bundles/redoc.standalone.js" "$DEST/"
+echo "Swagger UI ${VERSION} and ReDoc ${REDOC_VERSION} vendored into $DEST"